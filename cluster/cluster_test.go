@@ -0,0 +1,33 @@
+package cluster
+
+import (
+	"context"
+	"testing"
+)
+
+func TestNewStore(t *testing.T) {
+
+	ctx := context.Background()
+
+	tests := map[string]bool{
+		"memory://":             true,
+		"redis://localhost":     true,
+		"etcd://localhost:2379": true,
+		"redis://":              false,
+		"etcd://?timeout=bogus": false,
+		"bogus://":              false,
+	}
+
+	for uri, expected := range tests {
+
+		_, err := NewStore(ctx, uri)
+
+		if expected && err != nil {
+			t.Fatalf("Failed to create store for '%s', %v", uri, err)
+		}
+
+		if !expected && err == nil {
+			t.Fatalf("Expected store for '%s' to fail", uri)
+		}
+	}
+}
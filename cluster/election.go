@@ -0,0 +1,215 @@
+package cluster
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aaronland/go-roster"
+	"github.com/whosonfirst/go-webhookd/v3/secrets"
+)
+
+// DEFAULT_LEASE_DURATION is the default amount of time that leadership lasts unless it is renewed.
+const DEFAULT_LEASE_DURATION time.Duration = 15 * time.Second
+
+// Elector is an interface for electing a single leader, among multiple webhookd processes, for named tasks that must only be
+// performed by one process at a time.
+type Elector interface {
+	// Campaign attempts to acquire, or renew, leadership for 'name' and returns a boolean value indicating whether this
+	// process is the leader. Leadership lasts for the elector's lease duration unless it is renewed.
+	Campaign(context.Context, string) (bool, error)
+	// Resign gives up leadership for 'name', if held, so that another process may be elected without waiting for the lease to expire.
+	Resign(context.Context, string) error
+	// LeaseDuration returns the amount of time that leadership lasts unless it is renewed.
+	LeaseDuration() time.Duration
+	// Identity returns the unique identifier for this process used to record leadership.
+	Identity() string
+	// Close releases any resources associated with the elector.
+	Close() error
+}
+
+// electors is a `aaronland/go-roster.Roster` instance used to maintain a list of registered `Elector` initialization functions.
+var electors roster.Roster
+
+// ElectorInitializationFunc is a function used to initialize an implementation of the `Elector` interface.
+type ElectorInitializationFunc func(ctx context.Context, uri string) (Elector, error)
+
+// NewElector returns a new `Elector` instance derived from 'uri'. The semantics of and requirements for 'uri' as specific to the
+// package implementing the interface.
+func NewElector(ctx context.Context, uri string) (Elector, error) {
+
+	err := ensureElectorRoster()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to ensure elector roster, %w", err)
+	}
+
+	uri, err = secrets.ResolveURI(ctx, uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to resolve secrets, %w", err)
+	}
+
+	parsed, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	scheme := parsed.Scheme
+
+	i, err := electors.Driver(ctx, scheme)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to find initialization function for '%s', %w", scheme, err)
+	}
+
+	init_func := i.(ElectorInitializationFunc)
+	return init_func(ctx, uri)
+}
+
+// RegisterElector associates 'scheme' with 'init_func' in an internal list of avilable `Elector` implementations.
+func RegisterElector(ctx context.Context, scheme string, init_func ElectorInitializationFunc) error {
+
+	err := ensureElectorRoster()
+
+	if err != nil {
+		return fmt.Errorf("Failed to ensure elector roster, %w", err)
+	}
+
+	return electors.Register(ctx, scheme, init_func)
+}
+
+// ensureElectorRoster ensures that a `aaronland/go-roster.Roster` instance used to maintain a list of registered `Elector`
+// initialization functions is present
+func ensureElectorRoster() error {
+
+	if electors == nil {
+
+		r, err := roster.NewDefaultRoster()
+
+		if err != nil {
+			return fmt.Errorf("Failed to create new roster, %w", err)
+		}
+
+		electors = r
+	}
+
+	return nil
+}
+
+// ElectorSchemes returns the list of elector schemes that have been "registered".
+func ElectorSchemes() []string {
+
+	ctx := context.Background()
+	drivers := electors.Drivers(ctx)
+
+	schemes := make([]string, len(drivers))
+
+	for idx, dr := range drivers {
+		schemes[idx] = fmt.Sprintf("%s://", dr)
+	}
+
+	sort.Strings(schemes)
+	return schemes
+}
+
+// electorOptions returns the identity and lease duration, derived from the `identity` and `lease_duration` parameters in 'q', for
+// an `Elector` instance. If there is no `identity` parameter a unique identifier derived from the host name is returned.
+func electorOptions(q url.Values) (string, time.Duration, error) {
+
+	identity := q.Get("identity")
+
+	if identity == "" {
+
+		host, err := os.Hostname()
+
+		if err != nil || host == "" {
+			host = "webhookd"
+		}
+
+		b := make([]byte, 4)
+		rand.Read(b)
+
+		identity = fmt.Sprintf("%s-%x", host, b)
+	}
+
+	lease_duration := DEFAULT_LEASE_DURATION
+
+	str_duration := q.Get("lease_duration")
+
+	if str_duration != "" {
+
+		v, err := time.ParseDuration(str_duration)
+
+		if err != nil || v <= 0 {
+			return "", 0, fmt.Errorf("Invalid ?lease_duration= parameter '%s'", str_duration)
+		}
+
+		lease_duration = v
+	}
+
+	return identity, lease_duration, nil
+}
+
+// Candidate campaigns for leadership of a single name using an `Elector`, caching the result for a third of the elector's lease
+// duration so that it can be checked frequently (for example for every message) without contacting the elector each time.
+type Candidate struct {
+	elector Elector
+	name    string
+	mu      *sync.Mutex
+	leader  bool
+	checked time.Time
+	now     func() time.Time
+}
+
+// NewCandidate returns a new `Candidate` instance campaigning for leadership of 'name' using 'elector'.
+func NewCandidate(elector Elector, name string) *Candidate {
+
+	c := &Candidate{
+		elector: elector,
+		name:    name,
+		mu:      new(sync.Mutex),
+		now:     time.Now,
+	}
+
+	return c
+}
+
+// IsLeader returns a boolean value indicating whether this process is the leader for the name of 'c', campaigning if the last
+// result is older than a third of the elector's lease duration. If the campaign fails leadership is considered to have been lost.
+func (c *Candidate) IsLeader(ctx context.Context) (bool, error) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+
+	if !c.checked.IsZero() && now.Sub(c.checked) < c.elector.LeaseDuration()/3 {
+		return c.leader, nil
+	}
+
+	leader, err := c.elector.Campaign(ctx, c.name)
+
+	c.leader = leader && err == nil
+	c.checked = now
+
+	return c.leader, err
+}
+
+// Resign gives up leadership for the name of 'c', if held.
+func (c *Candidate) Resign(ctx context.Context) error {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.leader = false
+	c.checked = time.Time{}
+
+	return c.elector.Resign(ctx, c.name)
+}
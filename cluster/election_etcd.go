@@ -0,0 +1,193 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// DEFAULT_ETCD_ELECTION_PREFIX is the default prefix for keys used by an `EtcdElector` to record leadership.
+const DEFAULT_ETCD_ELECTION_PREFIX string = "/webhookd/election/"
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterElector(ctx, "etcd", NewEtcdElector)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// EtcdElector implements the `Elector` interface for leadership recorded in an etcd (v3) cluster. Leadership for a name is held
+// by the process that creates its key, attached to a lease which is kept alive for as long as the process remains the leader.
+type EtcdElector struct {
+	Elector
+	store          *EtcdStore
+	identity       string
+	lease_duration time.Duration
+	// leases is a dictionary of names and the IDs of the leases attached to the keys for which this process is the leader.
+	leases map[string]clientv3.LeaseID
+	// mu is a `sync.Mutex` used to guard 'leases'.
+	mu *sync.Mutex
+}
+
+// NewEtcdElector returns a new `EtcdElector` instance configured by 'uri' in the form of:
+//
+//	etcd://[{USERNAME}:{PASSWORD}@]{HOST}:{PORT}?{PARAMETERS}
+//
+// Valid {PARAMETERS} are those for `NewEtcdStore` (with a default prefix of "/webhookd/election/") and:
+// * `identity={STRING}` The unique identifier used to record leadership. Default is derived from the host name.
+// * `lease_duration={DURATION}` The amount of time that leadership lasts unless it is renewed. Default is "15s".
+func NewEtcdElector(ctx context.Context, uri string) (Elector, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	identity, lease_duration, err := electorOptions(q)
+
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := NewEtcdStore(ctx, uri)
+
+	if err != nil {
+		return nil, err
+	}
+
+	store := s.(*EtcdStore)
+
+	if !q.Has("prefix") {
+		store.prefix = DEFAULT_ETCD_ELECTION_PREFIX
+	}
+
+	e := &EtcdElector{
+		store:          store,
+		identity:       identity,
+		lease_duration: lease_duration,
+		leases:         make(map[string]clientv3.LeaseID),
+		mu:             new(sync.Mutex),
+	}
+
+	return e, nil
+}
+
+// Campaign renews the lease for 'name' if this process is the leader or, if there is no leader, attempts to create the key for
+// 'name' attached to a new lease.
+func (e *EtcdElector) Campaign(ctx context.Context, name string) (bool, error) {
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	lease_id, ok := e.leases[name]
+
+	if ok {
+
+		leader, err := e.renew(ctx, name, lease_id)
+
+		if err != nil {
+			return false, err
+		}
+
+		if leader {
+			return true, nil
+		}
+
+		delete(e.leases, name)
+	}
+
+	leader, lease_id, err := e.store.create(ctx, name, e.identity, e.lease_duration)
+
+	if err != nil {
+		return false, err
+	}
+
+	if leader {
+		e.leases[name] = lease_id
+	}
+
+	return leader, nil
+}
+
+// Resign revokes the lease for 'name', if this process is the leader, which deletes its key.
+func (e *EtcdElector) Resign(ctx context.Context, name string) error {
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	lease_id, ok := e.leases[name]
+
+	if !ok {
+		return nil
+	}
+
+	delete(e.leases, name)
+
+	ctx, cancel := context.WithTimeout(ctx, e.store.timeout)
+	defer cancel()
+
+	_, err := e.store.client.Revoke(ctx, lease_id)
+
+	if err != nil && !errors.Is(err, rpctypes.ErrLeaseNotFound) {
+		return fmt.Errorf("Failed to revoke lease, %w", err)
+	}
+
+	return nil
+}
+
+// LeaseDuration returns the amount of time that leadership lasts unless it is renewed.
+func (e *EtcdElector) LeaseDuration() time.Duration {
+	return e.lease_duration
+}
+
+// Identity returns the unique identifier used to record leadership.
+func (e *EtcdElector) Identity() string {
+	return e.identity
+}
+
+// Close closes the connection to the etcd cluster.
+func (e *EtcdElector) Close() error {
+	return e.store.Close()
+}
+
+// renew keeps 'lease_id' alive and returns a boolean value indicating whether the key for 'name' still records this process
+// as its leader.
+func (e *EtcdElector) renew(ctx context.Context, name string, lease_id clientv3.LeaseID) (bool, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, e.store.timeout)
+	defer cancel()
+
+	_, err := e.store.client.KeepAliveOnce(ctx, lease_id)
+
+	if errors.Is(err, rpctypes.ErrLeaseNotFound) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("Failed to renew lease, %w", err)
+	}
+
+	rsp, err := e.store.client.Get(ctx, e.store.prefix+name)
+
+	if err != nil {
+		return false, fmt.Errorf("Failed to get key, %w", err)
+	}
+
+	if len(rsp.Kvs) == 0 {
+		return false, nil
+	}
+
+	return string(rsp.Kvs[0].Value) == e.identity, nil
+}
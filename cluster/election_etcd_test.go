@@ -0,0 +1,92 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func TestEtcdElector(t *testing.T) {
+
+	ctx := context.Background()
+
+	client, host := newEtcdTestServer(t)
+
+	a, err := NewElector(ctx, fmt.Sprintf("etcd://webhookd:s33kret@%s?identity=a&lease_duration=10s", host))
+
+	if err != nil {
+		t.Fatalf("Failed to create new etcd elector, %v", err)
+	}
+
+	b, err := NewElector(ctx, fmt.Sprintf("etcd://webhookd:s33kret@%s?identity=b", host))
+
+	if err != nil {
+		t.Fatalf("Failed to create new etcd elector, %v", err)
+	}
+
+	campaign := func(e Elector, expected bool) {
+
+		ok, err := e.Campaign(ctx, "test")
+
+		if err != nil {
+			t.Fatalf("Failed to campaign for %s, %v", e.Identity(), err)
+		}
+
+		if ok != expected {
+			t.Fatalf("Unexpected leadership for %s: %t", e.Identity(), ok)
+		}
+	}
+
+	campaign(a, true)
+	campaign(b, false)
+
+	// Renewing keeps the existing lease alive
+
+	campaign(a, true)
+
+	rsp, err := client.Get(ctx, "/webhookd/election/test")
+
+	if err != nil || len(rsp.Kvs) != 1 || string(rsp.Kvs[0].Value) != "a" {
+		t.Fatalf("Unexpected leader, %v %v", rsp, err)
+	}
+
+	ttl, err := client.TimeToLive(ctx, clientv3.LeaseID(rsp.Kvs[0].Lease))
+
+	if err != nil || ttl.GrantedTTL != 10 {
+		t.Fatalf("Expected leadership to be attached to a ten second lease, %v %v", ttl, err)
+	}
+
+	leases, err := client.Leases(ctx)
+
+	if err != nil || len(leases.Leases) != 1 {
+		t.Fatalf("Expected a single lease, %v %v", leases, err)
+	}
+
+	err = a.Resign(ctx, "test")
+
+	if err != nil {
+		t.Fatalf("Failed to resign, %v", err)
+	}
+
+	campaign(b, true)
+	campaign(a, false)
+
+	// Simulate b's lease expiring
+
+	rsp, err = client.Get(ctx, "/webhookd/election/test")
+
+	if err != nil || len(rsp.Kvs) != 1 {
+		t.Fatalf("Expected leader key to exist, %v", err)
+	}
+
+	_, err = client.Revoke(ctx, clientv3.LeaseID(rsp.Kvs[0].Lease))
+
+	if err != nil {
+		t.Fatalf("Failed to revoke lease, %v", err)
+	}
+
+	campaign(a, true)
+	campaign(b, false)
+}
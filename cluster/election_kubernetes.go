@@ -0,0 +1,291 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/whosonfirst/go-webhookd/v3/kubernetes"
+)
+
+// DEFAULT_KUBERNETES_LEASE_PREFIX is the default prefix for the names of the Lease objects used by a `KubernetesElector`.
+const DEFAULT_KUBERNETES_LEASE_PREFIX string = "webhookd-"
+
+// kubernetesMicroTime is the layout used to encode Kubernetes `MicroTime` values.
+const kubernetesMicroTime string = "2006-01-02T15:04:05.000000Z07:00"
+
+// reKubernetesLeaseName matches the characters that are not allowed in the names of Kubernetes objects.
+var reKubernetesLeaseName = regexp.MustCompile(`[^a-z0-9\-\.]+`)
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterElector(ctx, "kubernetes", NewKubernetesElector)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// kubernetesLease is the subset of a Kubernetes `coordination.k8s.io/v1` Lease object used by `KubernetesElector`.
+type kubernetesLease struct {
+	APIVersion string                `json:"apiVersion"`
+	Kind       string                `json:"kind"`
+	Metadata   kubernetes.ObjectMeta `json:"metadata"`
+	Spec       kubernetesLeaseSpec   `json:"spec"`
+}
+
+// kubernetesLeaseSpec is the spec for a Kubernetes Lease object.
+type kubernetesLeaseSpec struct {
+	HolderIdentity       string `json:"holderIdentity"`
+	LeaseDurationSeconds int64  `json:"leaseDurationSeconds"`
+	AcquireTime          string `json:"acquireTime,omitempty"`
+	RenewTime            string `json:"renewTime,omitempty"`
+	LeaseTransitions     int64  `json:"leaseTransitions"`
+}
+
+// KubernetesElector implements the `Elector` interface for leadership recorded in Kubernetes `coordination.k8s.io/v1` Lease
+// objects, the same mechanism used by Kubernetes controllers. Updates are made using optimistic concurrency, so if two processes
+// attempt to acquire the same lease at once only one will succeed.
+type KubernetesElector struct {
+	Elector
+	client         *kubernetes.Client
+	prefix         string
+	identity       string
+	lease_duration time.Duration
+	now            func() time.Time
+}
+
+// NewKubernetesElector returns a new `KubernetesElector` instance configured by 'uri' in the form of:
+//
+//	kubernetes://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `namespace={STRING}` The namespace for Lease objects. Default is the namespace of the pod webhookd is running in.
+// * `prefix={STRING}` The prefix for the names of Lease objects. Default is "webhookd-".
+// * `endpoint={URL}` The URL of the Kubernetes API server. Default is derived from the `KUBERNETES_SERVICE_HOST` and
+// `KUBERNETES_SERVICE_PORT` environment variables.
+// * `token={STRING}` The bearer token used to authenticate requests. Default is the pod's service account token, which is read
+// before each request since it may be rotated.
+// * `identity={STRING}` The unique identifier used to record leadership. Default is derived from the host name, which is the
+// name of the pod.
+// * `lease_duration={DURATION}` The amount of time that leadership lasts unless it is renewed. Default is "15s".
+//
+// The service account must be allowed to "get", "create" and "update" leases in the namespace.
+func NewKubernetesElector(ctx context.Context, uri string) (Elector, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	identity, lease_duration, err := electorOptions(q)
+
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := kubernetes.NewClient(ctx, q)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create Kubernetes client, %w", err)
+	}
+
+	e := &KubernetesElector{
+		client:         client,
+		prefix:         DEFAULT_KUBERNETES_LEASE_PREFIX,
+		identity:       identity,
+		lease_duration: lease_duration,
+		now:            time.Now,
+	}
+
+	if q.Has("prefix") {
+		e.prefix = q.Get("prefix")
+	}
+
+	return e, nil
+}
+
+// Campaign renews the Lease object for 'name' if this process is its holder, acquires it if it has no holder or has expired, or
+// creates it if it does not exist.
+func (e *KubernetesElector) Campaign(ctx context.Context, name string) (bool, error) {
+
+	lease_name := e.leaseName(name)
+	now := e.now()
+
+	var lease *kubernetesLease
+
+	status, err := e.do(ctx, http.MethodGet, lease_name, nil, &lease)
+
+	if err != nil {
+		return false, err
+	}
+
+	switch status {
+	case http.StatusNotFound:
+
+		lease = &kubernetesLease{
+			APIVersion: "coordination.k8s.io/v1",
+			Kind:       "Lease",
+			Metadata: kubernetes.ObjectMeta{
+				Name:      lease_name,
+				Namespace: e.client.Namespace(),
+			},
+			Spec: kubernetesLeaseSpec{
+				HolderIdentity:       e.identity,
+				LeaseDurationSeconds: e.leaseSeconds(),
+				AcquireTime:          now.UTC().Format(kubernetesMicroTime),
+				RenewTime:            now.UTC().Format(kubernetesMicroTime),
+			},
+		}
+
+		status, err := e.do(ctx, http.MethodPost, "", lease, nil)
+
+		if err != nil {
+			return false, err
+		}
+
+		return e.result(status)
+
+	case http.StatusOK:
+		// pass
+	default:
+		return false, fmt.Errorf("Failed to retrieve lease, unexpected status %d", status)
+	}
+
+	spec := &lease.Spec
+
+	if spec.HolderIdentity != e.identity {
+
+		if spec.HolderIdentity != "" && !e.expired(spec, now) {
+			return false, nil
+		}
+
+		spec.HolderIdentity = e.identity
+		spec.AcquireTime = now.UTC().Format(kubernetesMicroTime)
+		spec.LeaseTransitions += 1
+	}
+
+	spec.LeaseDurationSeconds = e.leaseSeconds()
+	spec.RenewTime = now.UTC().Format(kubernetesMicroTime)
+
+	status, err = e.do(ctx, http.MethodPut, lease_name, lease, nil)
+
+	if err != nil {
+		return false, err
+	}
+
+	return e.result(status)
+}
+
+// Resign clears the holder of the Lease object for 'name', if this process is its holder.
+func (e *KubernetesElector) Resign(ctx context.Context, name string) error {
+
+	lease_name := e.leaseName(name)
+
+	var lease *kubernetesLease
+
+	status, err := e.do(ctx, http.MethodGet, lease_name, nil, &lease)
+
+	if err != nil {
+		return err
+	}
+
+	if status != http.StatusOK || lease.Spec.HolderIdentity != e.identity {
+		return nil
+	}
+
+	lease.Spec.HolderIdentity = ""
+
+	status, err = e.do(ctx, http.MethodPut, lease_name, lease, nil)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = e.result(status)
+	return err
+}
+
+// LeaseDuration returns the amount of time that leadership lasts unless it is renewed.
+func (e *KubernetesElector) LeaseDuration() time.Duration {
+	return e.lease_duration
+}
+
+// Identity returns the unique identifier used to record leadership.
+func (e *KubernetesElector) Identity() string {
+	return e.identity
+}
+
+// Close is a no-op for `KubernetesElector` instances.
+func (e *KubernetesElector) Close() error {
+	return nil
+}
+
+// leaseName returns the name of the Lease object for 'name', which is lower-cased and has any characters that are not allowed in
+// the names of Kubernetes objects replaced with dashes.
+func (e *KubernetesElector) leaseName(name string) string {
+
+	name = reKubernetesLeaseName.ReplaceAllString(strings.ToLower(name), "-")
+	name = strings.Trim(e.prefix+name, "-.")
+
+	if len(name) > 253 {
+		name = strings.TrimRight(name[:253], "-.")
+	}
+
+	return name
+}
+
+// leaseSeconds returns the lease duration for 'e' rounded up to a whole number of seconds.
+func (e *KubernetesElector) leaseSeconds() int64 {
+	return int64(math.Ceil(e.lease_duration.Seconds()))
+}
+
+// expired returns a boolean value indicating whether the lease described by 'spec' has expired at 'now'. Leases with an invalid
+// renew time are considered to have expired.
+func (e *KubernetesElector) expired(spec *kubernetesLeaseSpec, now time.Time) bool {
+
+	renewed, err := time.Parse(time.RFC3339Nano, spec.RenewTime)
+
+	if err != nil {
+		return true
+	}
+
+	expires := renewed.Add(time.Duration(spec.LeaseDurationSeconds) * time.Second)
+	return !now.Before(expires)
+}
+
+// result returns a boolean value indicating whether an update, with the HTTP status code 'status', succeeded. Conflicts, which
+// mean that another process updated the lease first, are not considered errors.
+func (e *KubernetesElector) result(status int) (bool, error) {
+
+	switch status {
+	case http.StatusOK, http.StatusCreated:
+		return true, nil
+	case http.StatusConflict:
+		return false, nil
+	default:
+		return false, fmt.Errorf("Failed to update lease, unexpected status %d", status)
+	}
+}
+
+// do sends 'body', if not nil, to the Lease object 'name' (or the collection of Lease objects if empty) using 'method' and decodes
+// a successful response in to 'target' if not nil. It returns the HTTP status code of the response.
+func (e *KubernetesElector) do(ctx context.Context, method string, name string, body interface{}, target interface{}) (int, error) {
+
+	path := fmt.Sprintf("/apis/coordination.k8s.io/v1/namespaces/%s/leases", url.PathEscape(e.client.Namespace()))
+
+	if name != "" {
+		path = path + "/" + url.PathEscape(name)
+	}
+
+	return e.client.Do(ctx, method, path, body, target)
+}
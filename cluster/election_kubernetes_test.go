@@ -0,0 +1,181 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeKubernetesServer is a minimal Kubernetes API server that understands the requests sent by `KubernetesElector`.
+type fakeKubernetesServer struct {
+	mu       sync.Mutex
+	leases   map[string]*kubernetesLease
+	versions int
+}
+
+func (srv *fakeKubernetesServer) ServeHTTP(rsp http.ResponseWriter, req *http.Request) {
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	if req.Header.Get("Authorization") != "Bearer s33kret" {
+		http.Error(rsp, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	prefix := "/apis/coordination.k8s.io/v1/namespaces/webhookd/leases"
+
+	if !strings.HasPrefix(req.URL.Path, prefix) {
+		http.Error(rsp, "Not found", http.StatusNotFound)
+		return
+	}
+
+	name := strings.TrimPrefix(strings.TrimPrefix(req.URL.Path, prefix), "/")
+
+	var lease *kubernetesLease
+
+	if req.Method == http.MethodPost || req.Method == http.MethodPut {
+		json.NewDecoder(req.Body).Decode(&lease)
+	}
+
+	existing, exists := srv.leases[name]
+
+	switch req.Method {
+	case http.MethodGet:
+
+		if !exists {
+			http.Error(rsp, "Not found", http.StatusNotFound)
+			return
+		}
+
+		json.NewEncoder(rsp).Encode(existing)
+
+	case http.MethodPost:
+
+		if _, ok := srv.leases[lease.Metadata.Name]; ok {
+			http.Error(rsp, "Conflict", http.StatusConflict)
+			return
+		}
+
+		srv.versions += 1
+		lease.Metadata.ResourceVersion = strconv.Itoa(srv.versions)
+		srv.leases[lease.Metadata.Name] = lease
+
+		rsp.WriteHeader(http.StatusCreated)
+		json.NewEncoder(rsp).Encode(lease)
+
+	case http.MethodPut:
+
+		if !exists {
+			http.Error(rsp, "Not found", http.StatusNotFound)
+			return
+		}
+
+		if lease.Metadata.ResourceVersion != existing.Metadata.ResourceVersion {
+			http.Error(rsp, "Conflict", http.StatusConflict)
+			return
+		}
+
+		srv.versions += 1
+		lease.Metadata.ResourceVersion = strconv.Itoa(srv.versions)
+		srv.leases[name] = lease
+
+		json.NewEncoder(rsp).Encode(lease)
+
+	default:
+		http.Error(rsp, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func TestKubernetesElector(t *testing.T) {
+
+	ctx := context.Background()
+
+	srv := &fakeKubernetesServer{
+		leases: make(map[string]*kubernetesLease),
+	}
+
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	new_elector := func(identity string) *KubernetesElector {
+
+		uri := fmt.Sprintf("kubernetes://?endpoint=%s&namespace=webhookd&token=s33kret&identity=%s&lease_duration=10s", ts.URL, identity)
+
+		e, err := NewElector(ctx, uri)
+
+		if err != nil {
+			t.Fatalf("Failed to create new kubernetes elector, %v", err)
+		}
+
+		return e.(*KubernetesElector)
+	}
+
+	a := new_elector("a")
+	b := new_elector("b")
+
+	now := time.Now()
+	clock := func() time.Time { return now }
+
+	a.now = clock
+	b.now = clock
+
+	campaign := func(e Elector, expected bool) {
+
+		ok, err := e.Campaign(ctx, "source:/GitHub")
+
+		if err != nil {
+			t.Fatalf("Failed to campaign for %s, %v", e.Identity(), err)
+		}
+
+		if ok != expected {
+			t.Fatalf("Unexpected leadership for %s: %t", e.Identity(), ok)
+		}
+	}
+
+	campaign(a, true)
+	campaign(b, false)
+
+	now = now.Add(5 * time.Second)
+	campaign(a, true)
+
+	now = now.Add(8 * time.Second)
+	campaign(b, false)
+
+	// Leases that are not renewed expire
+
+	now = now.Add(5 * time.Second)
+	campaign(b, true)
+	campaign(a, false)
+
+	lease, ok := srv.leases["webhookd-source-github"]
+
+	if !ok {
+		t.Fatalf("Missing lease, %v", srv.leases)
+	}
+
+	if lease.Spec.HolderIdentity != "b" || lease.Spec.LeaseTransitions != 1 || lease.Spec.LeaseDurationSeconds != 10 {
+		t.Fatalf("Unexpected lease spec, %v", lease.Spec)
+	}
+
+	err := b.Resign(ctx, "source:/GitHub")
+
+	if err != nil {
+		t.Fatalf("Failed to resign, %v", err)
+	}
+
+	campaign(a, true)
+
+	_, err = NewElector(ctx, "kubernetes://?endpoint="+ts.URL)
+
+	if err == nil {
+		t.Fatalf("Expected elector without a namespace to fail")
+	}
+}
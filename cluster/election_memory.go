@@ -0,0 +1,123 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+)
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterElector(ctx, "memory", NewMemoryElector)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// memoryLease is the leadership, for a single name, recorded by `MemoryElector` instances.
+type memoryLease struct {
+	holder  string
+	expires time.Time
+}
+
+// memoryLeases is the dictionary of names and their leases shared by all the `MemoryElector` instances in a process.
+var memoryLeases = make(map[string]*memoryLease)
+
+// memoryLeasesMu is a `sync.Mutex` used to guard 'memoryLeases'.
+var memoryLeasesMu = new(sync.Mutex)
+
+// MemoryElector implements the `Elector` interface for leadership recorded in memory. Leadership is shared by all the
+// `MemoryElector` instances in a process, but not between processes, so it is only useful for a single webhookd process or for
+// testing.
+type MemoryElector struct {
+	Elector
+	identity       string
+	lease_duration time.Duration
+	now            func() time.Time
+}
+
+// NewMemoryElector returns a new `MemoryElector` instance configured by 'uri' in the form of:
+//
+//	memory://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `identity={STRING}` The unique identifier used to record leadership. Default is derived from the host name.
+// * `lease_duration={DURATION}` The amount of time that leadership lasts unless it is renewed. Default is "15s".
+func NewMemoryElector(ctx context.Context, uri string) (Elector, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	identity, lease_duration, err := electorOptions(u.Query())
+
+	if err != nil {
+		return nil, err
+	}
+
+	e := &MemoryElector{
+		identity:       identity,
+		lease_duration: lease_duration,
+		now:            time.Now,
+	}
+
+	return e, nil
+}
+
+// Campaign acquires, or renews, leadership for 'name' if it is not held by another elector or has expired.
+func (e *MemoryElector) Campaign(ctx context.Context, name string) (bool, error) {
+
+	memoryLeasesMu.Lock()
+	defer memoryLeasesMu.Unlock()
+
+	now := e.now()
+
+	l, ok := memoryLeases[name]
+
+	if ok && l.holder != e.identity && now.Before(l.expires) {
+		return false, nil
+	}
+
+	memoryLeases[name] = &memoryLease{
+		holder:  e.identity,
+		expires: now.Add(e.lease_duration),
+	}
+
+	return true, nil
+}
+
+// Resign gives up leadership for 'name', if held.
+func (e *MemoryElector) Resign(ctx context.Context, name string) error {
+
+	memoryLeasesMu.Lock()
+	defer memoryLeasesMu.Unlock()
+
+	l, ok := memoryLeases[name]
+
+	if ok && l.holder == e.identity {
+		delete(memoryLeases, name)
+	}
+
+	return nil
+}
+
+// LeaseDuration returns the amount of time that leadership lasts unless it is renewed.
+func (e *MemoryElector) LeaseDuration() time.Duration {
+	return e.lease_duration
+}
+
+// Identity returns the unique identifier used to record leadership.
+func (e *MemoryElector) Identity() string {
+	return e.identity
+}
+
+// Close is a no-op for `MemoryElector` instances.
+func (e *MemoryElector) Close() error {
+	return nil
+}
@@ -0,0 +1,67 @@
+package cluster
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryElector(t *testing.T) {
+
+	ctx := context.Background()
+
+	a, _ := NewElector(ctx, "memory://?identity=a&lease_duration=1m")
+	b, _ := NewElector(ctx, "memory://?identity=b&lease_duration=1m")
+
+	now := time.Now()
+	clock := func() time.Time { return now }
+
+	a.(*MemoryElector).now = clock
+	b.(*MemoryElector).now = clock
+
+	campaign := func(e Elector, expected bool) {
+
+		ok, err := e.Campaign(ctx, "test-memory")
+
+		if err != nil {
+			t.Fatalf("Failed to campaign for %s, %v", e.Identity(), err)
+		}
+
+		if ok != expected {
+			t.Fatalf("Unexpected leadership for %s: %t", e.Identity(), ok)
+		}
+	}
+
+	campaign(a, true)
+	campaign(b, false)
+
+	// Renewing extends the lease
+
+	now = now.Add(50 * time.Second)
+	campaign(a, true)
+
+	now = now.Add(50 * time.Second)
+	campaign(b, false)
+
+	// Leases that are not renewed expire
+
+	now = now.Add(time.Minute)
+	campaign(b, true)
+	campaign(a, false)
+
+	err := a.Resign(ctx, "test-memory")
+
+	if err != nil {
+		t.Fatalf("Failed to resign, %v", err)
+	}
+
+	campaign(a, false)
+
+	err = b.Resign(ctx, "test-memory")
+
+	if err != nil {
+		t.Fatalf("Failed to resign, %v", err)
+	}
+
+	campaign(a, true)
+}
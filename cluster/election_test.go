@@ -0,0 +1,108 @@
+package cluster
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestNewElector(t *testing.T) {
+
+	ctx := context.Background()
+
+	e, err := NewElector(ctx, "memory://?identity=a&lease_duration=30s")
+
+	if err != nil {
+		t.Fatalf("Failed to create new elector, %v", err)
+	}
+
+	defer e.Close()
+
+	if e.Identity() != "a" || e.LeaseDuration() != 30*time.Second {
+		t.Fatalf("Unexpected identity (%s) or lease duration (%v)", e.Identity(), e.LeaseDuration())
+	}
+
+	for _, uri := range []string{"bogus://", "memory://?lease_duration=x", "memory://?lease_duration=-1s"} {
+
+		_, err := NewElector(ctx, uri)
+
+		if err == nil {
+			t.Fatalf("Expected %s to fail", uri)
+		}
+	}
+}
+
+func TestCandidate(t *testing.T) {
+
+	ctx := context.Background()
+
+	a, _ := NewElector(ctx, "memory://?identity=a&lease_duration=30s")
+	b, _ := NewElector(ctx, "memory://?identity=b&lease_duration=30s")
+
+	now := time.Now()
+	clock := func() time.Time { return now }
+
+	a.(*MemoryElector).now = clock
+	b.(*MemoryElector).now = clock
+
+	c_a := NewCandidate(a, "test-candidate")
+	c_b := NewCandidate(b, "test-candidate")
+
+	c_a.now = clock
+	c_b.now = clock
+
+	tests := []struct {
+		candidate *Candidate
+		expected  bool
+	}{
+		{c_a, true},
+		{c_b, false},
+	}
+
+	for idx, test := range tests {
+
+		ok, err := test.candidate.IsLeader(ctx)
+
+		if err != nil {
+			t.Fatalf("Failed to determine leader for candidate %d, %v", idx, err)
+		}
+
+		if ok != test.expected {
+			t.Fatalf("Unexpected leadership for candidate %d: %t", idx, ok)
+		}
+	}
+
+	// Results are cached for a third of the lease duration
+
+	err := a.Resign(ctx, "test-candidate")
+
+	if err != nil {
+		t.Fatalf("Failed to resign, %v", err)
+	}
+
+	ok, _ := c_b.IsLeader(ctx)
+
+	if ok {
+		t.Fatalf("Expected cached result")
+	}
+
+	now = now.Add(10 * time.Second)
+
+	ok, _ = c_b.IsLeader(ctx)
+
+	if !ok {
+		t.Fatalf("Expected b to be elected after a resigned")
+	}
+
+	err = c_b.Resign(ctx)
+
+	if err != nil {
+		t.Fatalf("Failed to resign, %v", err)
+	}
+
+	ok, _ = c_a.IsLeader(ctx)
+
+	if !ok {
+		t.Fatalf("Expected a to be elected after b resigned")
+	}
+}
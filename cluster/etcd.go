@@ -0,0 +1,260 @@
+package cluster
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"strconv"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// DEFAULT_ETCD_PREFIX is the default prefix for keys stored by an `EtcdStore`.
+const DEFAULT_ETCD_PREFIX string = "/webhookd/cluster/"
+
+// DEFAULT_ETCD_TIMEOUT is the default amount of time to wait for an etcd request to complete.
+const DEFAULT_ETCD_TIMEOUT time.Duration = 5 * time.Second
+
+// etcdMaxAttempts is the maximum number of times a counter update is attempted when it conflicts with another update.
+const etcdMaxAttempts int = 10
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterStore(ctx, "etcd", NewEtcdStore)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// EtcdStore implements the `Store` interface for state stored in an etcd (v3) cluster, using the official etcd client. Expiry
+// is implemented using etcd leases so it has a resolution of one second.
+type EtcdStore struct {
+	Store
+	client *clientv3.Client
+	prefix string
+	// timeout is the amount of time to wait for an etcd request to complete.
+	timeout time.Duration
+}
+
+// NewEtcdStore returns a new `EtcdStore` instance configured by 'uri' in the form of:
+//
+//	etcd://[{USERNAME}:{PASSWORD}@]{HOST}:{PORT}?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `prefix={STRING}` The prefix for stored keys. Default is "/webhookd/cluster/".
+// * `timeout={DURATION}` The amount of time to wait for an etcd request to complete. Default is "5s".
+// * `tls={BOOLEAN}` Whether to connect to etcd using TLS. Default is false.
+//
+// Connections to the etcd cluster are established when the first request is sent.
+func NewEtcdStore(ctx context.Context, uri string) (Store, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	address := u.Host
+
+	if address == "" {
+		return nil, fmt.Errorf("Missing etcd host")
+	}
+
+	if u.Port() == "" {
+		address = net.JoinHostPort(address, "2379")
+	}
+
+	q := u.Query()
+
+	config := clientv3.Config{
+		Endpoints: []string{address},
+	}
+
+	str_tls := q.Get("tls")
+
+	if str_tls != "" {
+
+		v, err := strconv.ParseBool(str_tls)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse ?tls= parameter, %w", err)
+		}
+
+		if v {
+			config.TLS = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+	}
+
+	timeout := DEFAULT_ETCD_TIMEOUT
+
+	str_timeout := q.Get("timeout")
+
+	if str_timeout != "" {
+
+		v, err := time.ParseDuration(str_timeout)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse ?timeout= parameter, %w", err)
+		}
+
+		timeout = v
+	}
+
+	config.DialTimeout = timeout
+
+	if u.User != nil {
+		config.Username = u.User.Username()
+		config.Password, _ = u.User.Password()
+	}
+
+	client, err := clientv3.New(config)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create etcd client, %w", err)
+	}
+
+	s := &EtcdStore{
+		client:  client,
+		prefix:  DEFAULT_ETCD_PREFIX,
+		timeout: timeout,
+	}
+
+	if q.Has("prefix") {
+		s.prefix = q.Get("prefix")
+	}
+
+	return s, nil
+}
+
+// Claim records 'key' for 'ttl' if it has not already been recorded, or has expired, and returns a boolean value indicating
+// whether it was recorded. Keys are recorded using a transaction that only succeeds if the key does not exist.
+func (s *EtcdStore) Claim(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+
+	ok, _, err := s.create(ctx, key, "1", ttl)
+	return ok, err
+}
+
+// Release removes 'key'.
+func (s *EtcdStore) Release(ctx context.Context, key string) error {
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	_, err := s.client.Delete(ctx, s.prefix+key)
+
+	if err != nil {
+		return fmt.Errorf("Failed to delete key, %w", err)
+	}
+
+	return nil
+}
+
+// Increment adds 'delta' to the counter for 'key', creating it with an expiry of 'ttl' if it does not exist, and returns the
+// new value. Counters are updated using transactions that only succeed if the counter has not been modified since it was read.
+func (s *EtcdStore) Increment(ctx context.Context, key string, delta int64, ttl time.Duration) (int64, error) {
+
+	for i := 0; i < etcdMaxAttempts; i++ {
+
+		get_ctx, cancel := context.WithTimeout(ctx, s.timeout)
+		get_rsp, err := s.client.Get(get_ctx, s.prefix+key)
+		cancel()
+
+		if err != nil {
+			return 0, fmt.Errorf("Failed to get counter, %w", err)
+		}
+
+		if len(get_rsp.Kvs) == 0 {
+
+			ok, _, err := s.create(ctx, key, strconv.FormatInt(delta, 10), ttl)
+
+			if err != nil {
+				return 0, err
+			}
+
+			if ok {
+				return delta, nil
+			}
+
+			continue
+		}
+
+		kv := get_rsp.Kvs[0]
+
+		value, err := strconv.ParseInt(string(kv.Value), 10, 64)
+
+		if err != nil {
+			return 0, fmt.Errorf("Invalid counter value, %w", err)
+		}
+
+		value += delta
+
+		txn_ctx, cancel := context.WithTimeout(ctx, s.timeout)
+
+		txn_rsp, err := s.client.Txn(txn_ctx).
+			If(clientv3.Compare(clientv3.ModRevision(s.prefix+key), "=", kv.ModRevision)).
+			Then(clientv3.OpPut(s.prefix+key, strconv.FormatInt(value, 10), clientv3.WithIgnoreLease())).
+			Commit()
+
+		cancel()
+
+		if err != nil {
+			return 0, fmt.Errorf("Failed to update counter, %w", err)
+		}
+
+		if txn_rsp.Succeeded {
+			return value, nil
+		}
+	}
+
+	return 0, fmt.Errorf("Failed to update counter after %d attempts", etcdMaxAttempts)
+}
+
+// Close closes the connection to the etcd cluster.
+func (s *EtcdStore) Close() error {
+	return s.client.Close()
+}
+
+// create stores 'value' for 'key', attached to a new lease which expires after 'ttl', if 'key' does not already exist. It returns
+// a boolean value indicating whether the value was stored and the ID of the lease.
+func (s *EtcdStore) create(ctx context.Context, key string, value string, ttl time.Duration) (bool, clientv3.LeaseID, error) {
+
+	seconds := int64(math.Ceil(ttl.Seconds()))
+
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	lease_rsp, err := s.client.Grant(ctx, seconds)
+
+	if err != nil {
+		return false, 0, fmt.Errorf("Failed to grant lease, %w", err)
+	}
+
+	txn_rsp, err := s.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.CreateRevision(s.prefix+key), "=", 0)).
+		Then(clientv3.OpPut(s.prefix+key, value, clientv3.WithLease(lease_rsp.ID))).
+		Commit()
+
+	if err != nil {
+		return false, 0, fmt.Errorf("Failed to create key, %w", err)
+	}
+
+	if !txn_rsp.Succeeded {
+
+		// The lease would expire by itself but there's no reason to keep it around
+
+		s.client.Revoke(ctx, lease_rsp.ID)
+		return false, 0, nil
+	}
+
+	return true, lease_rsp.ID, nil
+}
@@ -0,0 +1,182 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/server/v3/embed"
+)
+
+// newEtcdTestServer starts a new in-process etcd server, with authentication enabled for the user "webhookd" and the password
+// "s33kret", and returns a client for it along with its address.
+func newEtcdTestServer(t *testing.T) (*clientv3.Client, string) {
+
+	urls := make([]url.URL, 2)
+
+	for i := range urls {
+
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+
+		if err != nil {
+			t.Fatalf("Failed to find a free port, %v", err)
+		}
+
+		urls[i] = url.URL{Scheme: "http", Host: l.Addr().String()}
+		l.Close()
+	}
+
+	cfg := embed.NewConfig()
+	cfg.Dir = t.TempDir()
+	cfg.LogLevel = "error"
+	cfg.ListenClientUrls = urls[:1]
+	cfg.AdvertiseClientUrls = urls[:1]
+	cfg.ListenPeerUrls = urls[1:]
+	cfg.AdvertisePeerUrls = urls[1:]
+	cfg.InitialCluster = cfg.InitialClusterFromName(cfg.Name)
+
+	e, err := embed.StartEtcd(cfg)
+
+	if err != nil {
+		t.Fatalf("Failed to start etcd, %v", err)
+	}
+
+	t.Cleanup(e.Close)
+
+	select {
+	case <-e.Server.ReadyNotify():
+		// pass
+	case <-time.After(10 * time.Second):
+		t.Fatalf("Timed out waiting for etcd to start")
+	}
+
+	address := urls[0].Host
+
+	client, err := clientv3.New(clientv3.Config{Endpoints: []string{address}})
+
+	if err != nil {
+		t.Fatalf("Failed to create etcd client, %v", err)
+	}
+
+	ctx := context.Background()
+
+	setup := []func() error{
+		func() error { _, err := client.UserAdd(ctx, "root", "r00t"); return err },
+		func() error { _, err := client.UserGrantRole(ctx, "root", "root"); return err },
+		func() error { _, err := client.UserAdd(ctx, "webhookd", "s33kret"); return err },
+		func() error { _, err := client.UserGrantRole(ctx, "webhookd", "root"); return err },
+		func() error { _, err := client.AuthEnable(ctx); return err },
+	}
+
+	for _, fn := range setup {
+
+		err := fn()
+
+		if err != nil {
+			t.Fatalf("Failed to set up etcd authentication, %v", err)
+		}
+	}
+
+	client.Close()
+
+	client, err = clientv3.New(clientv3.Config{Endpoints: []string{address}, Username: "root", Password: "r00t"})
+
+	if err != nil {
+		t.Fatalf("Failed to create etcd client, %v", err)
+	}
+
+	t.Cleanup(func() {
+		client.Close()
+	})
+
+	return client, address
+}
+
+func TestEtcdStore(t *testing.T) {
+
+	ctx := context.Background()
+
+	client, address := newEtcdTestServer(t)
+
+	uri := fmt.Sprintf("etcd://webhookd:s33kret@%s?prefix=/test/", address)
+
+	s, err := NewStore(ctx, uri)
+
+	if err != nil {
+		t.Fatalf("Failed to create new etcd store, %v", err)
+	}
+
+	defer s.Close()
+
+	for i, expected := range []bool{true, false} {
+
+		ok, err := s.Claim(ctx, "abc", 1500*time.Millisecond)
+
+		if err != nil {
+			t.Fatalf("Failed to claim key, %v", err)
+		}
+
+		if ok != expected {
+			t.Fatalf("Unexpected result for claim %d: %t", i, ok)
+		}
+	}
+
+	rsp, err := client.Get(ctx, "/test/abc")
+
+	if err != nil || len(rsp.Kvs) != 1 {
+		t.Fatalf("Expected claimed key to be stored, %v", err)
+	}
+
+	ttl, err := client.TimeToLive(ctx, clientv3.LeaseID(rsp.Kvs[0].Lease))
+
+	if err != nil || ttl.GrantedTTL != 2 {
+		t.Fatalf("Expected claim to be attached to a two second lease, %v %v", ttl, err)
+	}
+
+	err = s.Release(ctx, "abc")
+
+	if err != nil {
+		t.Fatalf("Failed to release key, %v", err)
+	}
+
+	for i, expected := range []int64{2, 4, 6} {
+
+		v, err := s.Increment(ctx, "counter", 2, time.Minute)
+
+		if err != nil {
+			t.Fatalf("Failed to increment counter, %v", err)
+		}
+
+		if v != expected {
+			t.Fatalf("Unexpected value for increment %d: %d", i, v)
+		}
+	}
+
+	rsp, err = client.Get(ctx, "/test/", clientv3.WithPrefix())
+
+	if err != nil {
+		t.Fatalf("Failed to list keys, %v", err)
+	}
+
+	if len(rsp.Kvs) != 1 || string(rsp.Kvs[0].Key) != "/test/counter" || string(rsp.Kvs[0].Value) != "6" {
+		t.Fatalf("Unexpected keys, %v", rsp.Kvs)
+	}
+
+	// Updating the counter keeps the lease it was created with
+
+	ttl, err = client.TimeToLive(ctx, clientv3.LeaseID(rsp.Kvs[0].Lease))
+
+	if err != nil || ttl.GrantedTTL != 60 {
+		t.Fatalf("Expected counter to be attached to a one minute lease, %v %v", ttl, err)
+	}
+
+	_, err = NewStore(ctx, "etcd://localhost?tls=maybe")
+
+	if err == nil {
+		t.Fatalf("Expected invalid tls parameter to fail")
+	}
+}
@@ -0,0 +1,135 @@
+package cluster
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterStore(ctx, "memory", NewMemoryStore)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// memorySweepInterval is the minimum interval between removing all the expired entries from a `MemoryStore`.
+const memorySweepInterval time.Duration = time.Minute
+
+// memoryEntry is a single claimed key or counter stored by a `MemoryStore`.
+type memoryEntry struct {
+	value   int64
+	expires time.Time
+}
+
+// MemoryStore implements the `Store` interface for state stored in memory. State is not shared between processes so it is only
+// useful for a single webhookd process, or for testing.
+type MemoryStore struct {
+	Store
+	mu      *sync.Mutex
+	entries map[string]*memoryEntry
+	swept   time.Time
+	now     func() time.Time
+}
+
+// NewMemoryStore returns a new `MemoryStore` instance configured by 'uri' in the form of:
+//
+//	memory://
+func NewMemoryStore(ctx context.Context, uri string) (Store, error) {
+
+	s := &MemoryStore{
+		mu:      new(sync.Mutex),
+		entries: make(map[string]*memoryEntry),
+		now:     time.Now,
+	}
+
+	return s, nil
+}
+
+// Claim records 'key' for 'ttl' if it has not already been recorded, or has expired, and returns a boolean value indicating
+// whether it was recorded.
+func (s *MemoryStore) Claim(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.get(key) != nil {
+		return false, nil
+	}
+
+	s.entries[key] = &memoryEntry{
+		value:   1,
+		expires: s.now().Add(ttl),
+	}
+
+	return true, nil
+}
+
+// Release removes 'key'.
+func (s *MemoryStore) Release(ctx context.Context, key string) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
+
+// Increment adds 'delta' to the counter for 'key', creating it with an expiry of 'ttl' if it does not exist, and returns the
+// new value.
+func (s *MemoryStore) Increment(ctx context.Context, key string, delta int64, ttl time.Duration) (int64, error) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := s.get(key)
+
+	if e == nil {
+
+		e = &memoryEntry{
+			expires: s.now().Add(ttl),
+		}
+
+		s.entries[key] = e
+	}
+
+	e.value += delta
+	return e.value, nil
+}
+
+// Close is a no-op for `MemoryStore` instances.
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+// get returns the entry for 'key', or nil if it does not exist or has expired. Expired entries are removed at most once every
+// `memorySweepInterval`. Callers are expected to hold the lock for 's'.
+func (s *MemoryStore) get(key string) *memoryEntry {
+
+	now := s.now()
+
+	e, ok := s.entries[key]
+
+	if ok && now.Before(e.expires) {
+		return e
+	}
+
+	delete(s.entries, key)
+
+	if now.Sub(s.swept) >= memorySweepInterval {
+
+		for k, v := range s.entries {
+
+			if !now.Before(v.expires) {
+				delete(s.entries, k)
+			}
+		}
+
+		s.swept = now
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package cluster
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStore(t *testing.T) {
+
+	ctx := context.Background()
+
+	s, err := NewStore(ctx, "memory://")
+
+	if err != nil {
+		t.Fatalf("Failed to create new memory store, %v", err)
+	}
+
+	defer s.Close()
+
+	now := time.Now()
+	s.(*MemoryStore).now = func() time.Time { return now }
+
+	for i, expected := range []bool{true, false} {
+
+		ok, err := s.Claim(ctx, "abc", time.Minute)
+
+		if err != nil {
+			t.Fatalf("Failed to claim key, %v", err)
+		}
+
+		if ok != expected {
+			t.Fatalf("Unexpected result for claim %d: %t", i, ok)
+		}
+	}
+
+	err = s.Release(ctx, "abc")
+
+	if err != nil {
+		t.Fatalf("Failed to release key, %v", err)
+	}
+
+	ok, err := s.Claim(ctx, "abc", time.Minute)
+
+	if err != nil {
+		t.Fatalf("Failed to claim key, %v", err)
+	}
+
+	if !ok {
+		t.Fatalf("Expected released key to be claimed")
+	}
+
+	increments := []struct {
+		delta    int64
+		expected int64
+	}{
+		{2, 2},
+		{3, 5},
+	}
+
+	for i, test := range increments {
+
+		v, err := s.Increment(ctx, "counter", test.delta, time.Minute)
+
+		if err != nil {
+			t.Fatalf("Failed to increment counter, %v", err)
+		}
+
+		if v != test.expected {
+			t.Fatalf("Unexpected value for increment %d: %d", i, v)
+		}
+	}
+
+	now = now.Add(2 * time.Minute)
+
+	ok, err = s.Claim(ctx, "abc", time.Minute)
+
+	if err != nil {
+		t.Fatalf("Failed to claim key, %v", err)
+	}
+
+	if !ok {
+		t.Fatalf("Expected expired key to be claimed")
+	}
+
+	v, err := s.Increment(ctx, "counter", 1, time.Minute)
+
+	if err != nil {
+		t.Fatalf("Failed to increment counter, %v", err)
+	}
+
+	if v != 1 {
+		t.Fatalf("Expected expired counter to be reset, got %d", v)
+	}
+}
@@ -0,0 +1,189 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DEFAULT_REDIS_PREFIX is the default prefix for keys stored by a `RedisStore`.
+const DEFAULT_REDIS_PREFIX string = "webhookd:cluster:"
+
+// DEFAULT_REDIS_TIMEOUT is the default amount of time to wait for a Redis command to complete.
+const DEFAULT_REDIS_TIMEOUT time.Duration = 5 * time.Second
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterStore(ctx, "redis", NewRedisStore)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// RedisStore implements the `Store` interface for state stored in a Redis server.
+type RedisStore struct {
+	Store
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisStore returns a new `RedisStore` instance configured by 'uri' in the form of:
+//
+//	redis://[{USERNAME}:{PASSWORD}@]{HOST}:{PORT}[/{DATABASE}]?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `prefix={STRING}` The prefix for stored keys. Default is "webhookd:cluster:".
+// * `timeout={DURATION}` The amount of time to wait for a Redis command to complete. Default is "5s".
+//
+// Connections to the Redis server are managed by a `go-redis` connection pool and established when the first command is sent.
+func NewRedisStore(ctx context.Context, uri string) (Store, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	address := u.Host
+
+	if address == "" {
+		return nil, fmt.Errorf("Missing Redis host")
+	}
+
+	if u.Port() == "" {
+		address = net.JoinHostPort(address, "6379")
+	}
+
+	opts := &redis.Options{
+		Addr:            address,
+		DialTimeout:     DEFAULT_REDIS_TIMEOUT,
+		ReadTimeout:     DEFAULT_REDIS_TIMEOUT,
+		WriteTimeout:    DEFAULT_REDIS_TIMEOUT,
+		DisableIdentity: true,
+	}
+
+	if u.User != nil {
+
+		opts.Username = u.User.Username()
+
+		password, ok := u.User.Password()
+
+		if ok {
+			opts.Password = password
+		} else {
+			// redis://{PASSWORD}@{HOST} is a common shorthand when there is no username
+			opts.Password = opts.Username
+			opts.Username = ""
+		}
+	}
+
+	str_db := strings.TrimPrefix(u.Path, "/")
+
+	if str_db != "" {
+
+		v, err := strconv.Atoi(str_db)
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid Redis database '%s', %w", str_db, err)
+		}
+
+		opts.DB = v
+	}
+
+	q := u.Query()
+
+	prefix := DEFAULT_REDIS_PREFIX
+
+	if q.Has("prefix") {
+		prefix = q.Get("prefix")
+	}
+
+	str_timeout := q.Get("timeout")
+
+	if str_timeout != "" {
+
+		v, err := time.ParseDuration(str_timeout)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse ?timeout= parameter, %w", err)
+		}
+
+		opts.DialTimeout = v
+		opts.ReadTimeout = v
+		opts.WriteTimeout = v
+	}
+
+	s := &RedisStore{
+		client: redis.NewClient(opts),
+		prefix: prefix,
+	}
+
+	return s, nil
+}
+
+// Claim records 'key' for 'ttl' if it has not already been recorded, or has expired, and returns a boolean value indicating
+// whether it was recorded. Keys are recorded using the Redis `SET {KEY} 1 NX` command with an expiry of 'ttl'.
+func (s *RedisStore) Claim(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+
+	ok, err := s.client.SetNX(ctx, s.prefix+key, "1", redisTTL(ttl)).Result()
+
+	if err != nil {
+		return false, fmt.Errorf("Failed to set key, %w", err)
+	}
+
+	return ok, nil
+}
+
+// Release removes 'key' using the Redis `DEL` command.
+func (s *RedisStore) Release(ctx context.Context, key string) error {
+
+	err := s.client.Del(ctx, s.prefix+key).Err()
+
+	if err != nil {
+		return fmt.Errorf("Failed to delete key, %w", err)
+	}
+
+	return nil
+}
+
+// Increment adds 'delta' to the counter for 'key', creating it with an expiry of 'ttl' if it does not exist, and returns the
+// new value. Counters are created using the Redis `SET {KEY} 0 NX` command, so that the expiry is only assigned once, and
+// updated using the `INCRBY` command in the same `MULTI` transaction.
+func (s *RedisStore) Increment(ctx context.Context, key string, delta int64, ttl time.Duration) (int64, error) {
+
+	pipe := s.client.TxPipeline()
+
+	pipe.SetNX(ctx, s.prefix+key, "0", redisTTL(ttl))
+	incr := pipe.IncrBy(ctx, s.prefix+key, delta)
+
+	_, err := pipe.Exec(ctx)
+
+	if err != nil {
+		return 0, fmt.Errorf("Failed to increment counter, %w", err)
+	}
+
+	return incr.Val(), nil
+}
+
+// Close closes the connections to the Redis server.
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
+
+// redisTTL returns 'ttl' rounded up to at least one millisecond, since `go-redis` treats a zero expiry as no expiry at all.
+func redisTTL(ttl time.Duration) time.Duration {
+
+	if ttl < time.Millisecond {
+		return time.Millisecond
+	}
+
+	return ttl
+}
@@ -0,0 +1,88 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestRedisStore(t *testing.T) {
+
+	ctx := context.Background()
+
+	srv := miniredis.RunT(t)
+	srv.RequireAuth("s33kret")
+
+	uri := fmt.Sprintf("redis://:s33kret@%s/2?prefix=test:", srv.Addr())
+
+	s, err := NewStore(ctx, uri)
+
+	if err != nil {
+		t.Fatalf("Failed to create new redis store, %v", err)
+	}
+
+	defer s.Close()
+
+	for i, expected := range []bool{true, false} {
+
+		ok, err := s.Claim(ctx, "abc", time.Minute)
+
+		if err != nil {
+			t.Fatalf("Failed to claim key, %v", err)
+		}
+
+		if ok != expected {
+			t.Fatalf("Unexpected result for claim %d: %t", i, ok)
+		}
+	}
+
+	db := srv.DB(2)
+
+	if db.TTL("test:abc") != time.Minute {
+		t.Fatalf("Expected claim to be stored in database 2 with a TTL, got %v", db.TTL("test:abc"))
+	}
+
+	err = s.Release(ctx, "abc")
+
+	if err != nil {
+		t.Fatalf("Failed to release key, %v", err)
+	}
+
+	if db.Exists("test:abc") {
+		t.Fatalf("Expected released key to be removed")
+	}
+
+	for i, expected := range []int64{2, 4} {
+
+		v, err := s.Increment(ctx, "counter", 2, time.Second)
+
+		if err != nil {
+			t.Fatalf("Failed to increment counter, %v", err)
+		}
+
+		if v != expected {
+			t.Fatalf("Unexpected value for increment %d: %d", i, v)
+		}
+	}
+
+	// The expiry is only assigned when the counter is created
+
+	if db.TTL("test:counter") != time.Second {
+		t.Fatalf("Unexpected counter TTL %v", db.TTL("test:counter"))
+	}
+
+	srv.FastForward(2 * time.Second)
+
+	v, err := s.Increment(ctx, "counter", 2, time.Second)
+
+	if err != nil {
+		t.Fatalf("Failed to increment counter, %v", err)
+	}
+
+	if v != 2 {
+		t.Fatalf("Expected expired counter to be reset, got %d", v)
+	}
+}
@@ -0,0 +1,102 @@
+// webhookd-components is a command line tool to list the registered receivers, sources, transformations and dispatchers and the
+// URI parameters they accept.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/sfomuseum/go-flags/flagset"
+	"github.com/whosonfirst/go-webhookd/v3/components"
+	"log"
+	"os"
+	"strings"
+)
+
+func main() {
+
+	fs := flagset.NewFlagSet("webhooks")
+
+	kind := fs.String("kind", "", fmt.Sprintf("List only components of this kind. Valid options are: %s. If empty components of every kind are listed.", strings.Join(components.Kinds(), ", ")))
+	scheme := fs.String("scheme", "", "List only components registered with this URI scheme.")
+	as_json := fs.Bool("json", false, "A boolean flag indicating components should be emitted as JSON.")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "webhookd-components is a command line tool to list the registered receivers, sources, transformations and dispatchers and the URI parameters they accept.\n")
+		fmt.Fprintf(os.Stderr, "Usage:\n\t %s [options]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+
+	flagset.Parse(fs)
+
+	err := flagset.SetFlagsFromEnvVarsWithFeedback(fs, "WEBHOOKD", false)
+
+	if err != nil {
+		log.Fatalf("Failed to set flags from env vars, %v", err)
+	}
+
+	ctx := context.Background()
+
+	all, err := components.Components(ctx, *kind)
+
+	if err != nil {
+		log.Fatalf("Failed to list components, %v", err)
+	}
+
+	selected := make([]*components.Component, 0)
+
+	for _, c := range all {
+
+		if *scheme != "" && !strings.EqualFold(c.Scheme, strings.TrimSuffix(*scheme, "://")) {
+			continue
+		}
+
+		selected = append(selected, c)
+	}
+
+	if len(selected) == 0 {
+		log.Fatalf("No matching components")
+	}
+
+	if *as_json {
+
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+
+		err = enc.Encode(selected)
+
+		if err != nil {
+			log.Fatalf("Failed to encode components, %v", err)
+		}
+
+		return
+	}
+
+	for i, c := range selected {
+
+		if i > 0 {
+			fmt.Println("")
+		}
+
+		fmt.Printf("%s %s://\n", c.Kind, c.Scheme)
+
+		for _, uri := range c.URIs {
+			fmt.Printf("\t%s\n", uri)
+		}
+
+		for _, p := range c.Parameters {
+
+			required := ""
+
+			if p.Required {
+				required = " (required)"
+			}
+
+			fmt.Printf("\t* %s=%s%s\n", p.Name, p.Value, required)
+
+			if p.Description != "" {
+				fmt.Printf("\t\t%s\n", p.Description)
+			}
+		}
+	}
+}
@@ -17,6 +17,8 @@ func main() {
 
 	config_path := flag.String("config", "", "The path your webhookd config file")
 	constvar := flag.Bool("constvar", false, "A boolean flag indicating flattened config should be encoded as a gocloud.dev/runtimevar 'constant://' URI.")
+	env := flag.Bool("env", false, "A boolean flag indicating flattened config should be encoded as a list of environment variables that can be read using the 'env://' config URI.")
+	env_prefix := flag.String("env-prefix", config.DEFAULT_ENV_PREFIX, "The prefix for environment variables when the -env flag is true.")
 
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "webhookd-flatten-config is a command line tool for \"flattening\" a webhookd configuration file in to a string.\n")
@@ -60,6 +62,21 @@ func main() {
 		log.Fatalf("Failed to decode config, %v", err)
 	}
 
+	if *env {
+
+		environ, err := config.EnvironFromConfig(&cfg, *env_prefix)
+
+		if err != nil {
+			log.Fatalf("Failed to encode config as environment variables, %v", err)
+		}
+
+		for _, kv := range environ {
+			fmt.Println(kv)
+		}
+
+		return
+	}
+
 	body, err = json.Marshal(cfg)
 
 	if err != nil {
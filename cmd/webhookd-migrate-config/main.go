@@ -0,0 +1,144 @@
+// webhookd-migrate-config is a command line tool to convert a webhookd config file in an older format to the current (URI-based)
+// format, validate it and emit the result.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/sfomuseum/go-flags/flagset"
+	"github.com/sfomuseum/runtimevar"
+	"github.com/whosonfirst/go-webhookd/v3/components"
+	"github.com/whosonfirst/go-webhookd/v3/config"
+	"log"
+	"net/url"
+	"os"
+	"sort"
+)
+
+func main() {
+
+	fs := flagset.NewFlagSet("webhooks")
+
+	config_uri := fs.String("config-uri", "", "A valid Go Cloud runtimevar URI representing the webhookd config to migrate.")
+	output := fs.String("output", "", "The path to write the migrated config to. If empty the config is written to STDOUT.")
+	strict := fs.Bool("strict", false, "A boolean flag indicating that components whose URI scheme has not been registered are an error rather than a warning.")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "webhookd-migrate-config is a command line tool to convert a webhookd config file in an older format to the current (URI-based) format, validate it and emit the result.\n")
+		fmt.Fprintf(os.Stderr, "Usage:\n\t %s [options]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+
+	flagset.Parse(fs)
+
+	err := flagset.SetFlagsFromEnvVarsWithFeedback(fs, "WEBHOOKD", false)
+
+	if err != nil {
+		log.Fatalf("Failed to set flags from env vars, %v", err)
+	}
+
+	ctx := context.Background()
+
+	str_cfg, err := runtimevar.StringVar(ctx, *config_uri)
+
+	if err != nil {
+		log.Fatalf("Failed to open config %s, %v", *config_uri, err)
+	}
+
+	cfg, report, err := config.MigrateConfig(ctx, []byte(str_cfg))
+
+	if err != nil {
+		log.Fatalf("Failed to migrate config, %v", err)
+	}
+
+	if len(report.Changes) == 0 {
+		log.Printf("Config is already in the current format (version %s)", report.To)
+	}
+
+	for _, change := range report.Changes {
+		log.Printf("Migrated %s", change)
+	}
+
+	// Components defined by other packages, for example go-webhookd-github, need to be imported by the webhookd program
+
+	unregistered := unregisteredComponents(ctx, cfg)
+
+	for _, msg := range unregistered {
+		log.Printf("Warning: %s", msg)
+	}
+
+	if *strict && len(unregistered) > 0 {
+		log.Fatalf("Config uses %d component(s) that are not registered", len(unregistered))
+	}
+
+	wr := os.Stdout
+
+	if *output != "" {
+
+		fh, err := os.Create(*output)
+
+		if err != nil {
+			log.Fatalf("Failed to create %s, %v", *output, err)
+		}
+
+		defer fh.Close()
+		wr = fh
+	}
+
+	enc := json.NewEncoder(wr)
+	enc.SetIndent("", "    ")
+	enc.SetEscapeHTML(false)
+
+	err = enc.Encode(cfg)
+
+	if err != nil {
+		log.Fatalf("Failed to encode config, %v", err)
+	}
+}
+
+// unregisteredComponents returns the list of descriptions of the components, in 'cfg' and its tenants, whose URI scheme has not
+// been registered.
+func unregisteredComponents(ctx context.Context, cfg *config.WebhookConfig) []string {
+
+	configs := map[string]*config.WebhookConfig{
+		"": cfg,
+	}
+
+	for name, t := range cfg.Tenants {
+		configs["tenant "+name+" "] = t.WebhookConfig()
+	}
+
+	messages := make([]string, 0)
+
+	for prefix, c := range configs {
+
+		kinds := map[string]map[string]string{
+			components.KIND_RECEIVER:       c.Receivers,
+			components.KIND_SOURCE:         c.Sources,
+			components.KIND_TRANSFORMATION: c.Transformations,
+			components.KIND_DISPATCHER:     c.Dispatchers,
+		}
+
+		for kind, uris := range kinds {
+
+			for label, uri := range uris {
+
+				u, err := url.Parse(uri)
+
+				if err != nil {
+					continue
+				}
+
+				_, err = components.Describe(ctx, kind, u.Scheme)
+
+				if err != nil {
+					messages = append(messages, fmt.Sprintf("%s%s '%s' uses the unregistered scheme '%s'", prefix, kind, label, u.Scheme))
+				}
+			}
+		}
+	}
+
+	sort.Strings(messages)
+	return messages
+}
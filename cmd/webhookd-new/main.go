@@ -0,0 +1,59 @@
+// webhookd-new is a command line tool to generate the skeleton of a new receiver, transformation or dispatcher, and a table-driven
+// test for it, in a go-webhookd source tree.
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/sfomuseum/go-flags/flagset"
+	"github.com/whosonfirst/go-webhookd/v3/scaffold"
+	"log"
+	"os"
+	"strings"
+)
+
+func main() {
+
+	fs := flagset.NewFlagSet("webhooks")
+
+	root := fs.String("root", ".", "The root of the go-webhookd source tree to write files to.")
+	type_name := fs.String("type", "", "The prefix for the names of the types and constructor in the generated code. If empty the name with its first letter capitalized is used.")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "webhookd-new is a command line tool to generate the skeleton of a new receiver, transformation or dispatcher, and a table-driven test for it, in a go-webhookd source tree.\n")
+		fmt.Fprintf(os.Stderr, "Usage:\n\t %s [options] %s name\n", os.Args[0], strings.Join(scaffold.Kinds(), "|"))
+		fs.PrintDefaults()
+	}
+
+	flagset.Parse(fs)
+
+	err := flagset.SetFlagsFromEnvVarsWithFeedback(fs, "WEBHOOKD", false)
+
+	if err != nil {
+		log.Fatalf("Failed to set flags from env vars, %v", err)
+	}
+
+	args := fs.Args()
+
+	if len(args) != 2 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+
+	opts := &scaffold.Options{
+		Root: *root,
+		Type: *type_name,
+	}
+
+	paths, err := scaffold.Generate(ctx, args[0], args[1], opts)
+
+	if err != nil {
+		log.Fatalf("Failed to generate %s, %v", args[0], err)
+	}
+
+	for _, path := range paths {
+		fmt.Println(path)
+	}
+}
@@ -0,0 +1,70 @@
+// webhookd-openapi is a command line tool to emit an OpenAPI 3 document describing the webhook endpoints defined in a webhookd config.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/sfomuseum/go-flags/flagset"
+	"github.com/whosonfirst/go-webhookd/v3/config"
+	"github.com/whosonfirst/go-webhookd/v3/openapi"
+	"log"
+	"os"
+)
+
+func main() {
+
+	fs := flagset.NewFlagSet("webhooks")
+
+	config_uri := fs.String("config-uri", "", "A valid Go Cloud runtimevar URI representing your webhookd config, or \"env://\" to derive the config from environment variables.")
+	title := fs.String("title", openapi.DEFAULT_TITLE, "The title of the API.")
+	version := fs.String("version", openapi.DEFAULT_VERSION, "The version of the API.")
+	server_url := fs.String("server-url", "", "The URL of the webhookd server. If empty the public_url property in your webhookd config is used.")
+	indent := fs.Bool("indent", false, "A boolean flag indicating the document should be indented.")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "webhookd-openapi is a command line tool to emit an OpenAPI 3 document describing the webhook endpoints defined in a webhookd config.\n")
+		fmt.Fprintf(os.Stderr, "Usage:\n\t %s [options]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+
+	flagset.Parse(fs)
+
+	err := flagset.SetFlagsFromEnvVarsWithFeedback(fs, "WEBHOOKD", false)
+
+	if err != nil {
+		log.Fatalf("Failed to set flags from env vars, %v", err)
+	}
+
+	ctx := context.Background()
+
+	cfg, err := config.NewConfigFromURI(ctx, *config_uri)
+
+	if err != nil {
+		log.Fatalf("Failed to load config %s, %v", *config_uri, err)
+	}
+
+	opts := &openapi.Options{
+		Title:     *title,
+		Version:   *version,
+		ServerURL: *server_url,
+	}
+
+	doc, err := openapi.NewDocumentFromConfig(ctx, cfg, opts)
+
+	if err != nil {
+		log.Fatalf("Failed to create OpenAPI document, %v", err)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+
+	if *indent {
+		enc.SetIndent("", "  ")
+	}
+
+	err = enc.Encode(doc)
+
+	if err != nil {
+		log.Fatalf("Failed to encode OpenAPI document, %v", err)
+	}
+}
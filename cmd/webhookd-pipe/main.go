@@ -0,0 +1,74 @@
+// webhookd-pipe is a command line tool to process newline-delimited messages read from STDIN, or a named pipe, using the
+// transformations, routes and dispatchers for a webhook defined in a go-webhookd config file.
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/sfomuseum/go-flags/flagset"
+	"github.com/whosonfirst/go-webhookd/v3/config"
+	"github.com/whosonfirst/go-webhookd/v3/daemon"
+	"github.com/whosonfirst/go-webhookd/v3/source"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+func main() {
+
+	fs := flagset.NewFlagSet("webhooks")
+
+	config_uri := fs.String("config-uri", "", "A valid Go Cloud runtimevar URI representing your webhookd config.")
+	webhook := fs.String("webhook", "", "The endpoint of the webhook whose transformations, routes and dispatchers should be applied to each message.")
+	source_uri := fs.String("source-uri", "stdin://", "A valid source URI to read messages from. For example 'stdin://' or 'fifo:///path/to/fifo'.")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "webhookd-pipe is a command line tool to process newline-delimited messages read from STDIN, or a named pipe, using the transformations, routes and dispatchers for a webhook defined in a go-webhookd config file.\n")
+		fmt.Fprintf(os.Stderr, "Usage:\n\t %s [options]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+
+	flagset.Parse(fs)
+
+	err := flagset.SetFlagsFromEnvVarsWithFeedback(fs, "WEBHOOKD", false)
+
+	if err != nil {
+		log.Fatalf("Failed to set flags from env vars, %v", err)
+	}
+
+	if *webhook == "" {
+		log.Fatalf("Missing -webhook flag")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	cfg, err := config.NewConfigFromURI(ctx, *config_uri)
+
+	if err != nil {
+		log.Fatalf("Failed to load config %s, %v", *config_uri, err)
+	}
+
+	wh_daemon, err := daemon.NewWebhookDaemonFromConfig(ctx, cfg)
+
+	if err != nil {
+		log.Fatalf("Failed to create webhook daemon, %v", err)
+	}
+
+	src, err := source.NewSource(ctx, *source_uri)
+
+	if err != nil {
+		log.Fatalf("Failed to create source for '%s', %v", *source_uri, err)
+	}
+
+	logger := log.Default()
+
+	err = wh_daemon.ConsumeWebhook(ctx, *webhook, src, logger)
+
+	if err != nil {
+		log.Fatalf("Failed to process messages for %s, %v", *webhook, err)
+	}
+
+	os.Exit(0)
+}
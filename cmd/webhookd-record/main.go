@@ -0,0 +1,75 @@
+// webhookd-record is a command line tool to proxy webhook deliveries to a destination while recording them, in a format that can
+// be replayed by the webhookd-replay tool, to disk.
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/aaronland/go-http-server"
+	"github.com/sfomuseum/go-flags/flagset"
+	"github.com/whosonfirst/go-webhookd/v3/recorder"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+func main() {
+
+	fs := flagset.NewFlagSet("webhooks")
+
+	server_uri := fs.String("server-uri", "http://localhost:8080", "A valid aaronland/go-http-server URI to listen for deliveries on.")
+	destination := fs.String("destination", "", "The URL of the server that deliveries should be relayed to. For example 'http://localhost:8081'.")
+	path := fs.String("path", "", "The path to the file that deliveries will be recorded to. Deliveries are appended to the file if it already exists.")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "webhookd-record is a command line tool to proxy webhook deliveries to a destination while recording them, in a format that can be replayed by the webhookd-replay tool, to disk.\n")
+		fmt.Fprintf(os.Stderr, "Usage:\n\t %s [options]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+
+	flagset.Parse(fs)
+
+	err := flagset.SetFlagsFromEnvVarsWithFeedback(fs, "WEBHOOKD", false)
+
+	if err != nil {
+		log.Fatalf("Failed to set flags from env vars, %v", err)
+	}
+
+	if *path == "" {
+		log.Fatalf("Missing -path flag")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fh, err := os.OpenFile(*path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+
+	if err != nil {
+		log.Fatalf("Failed to open %s, %v", *path, err)
+	}
+
+	defer fh.Close()
+
+	r, err := recorder.NewRecorder(*destination, fh)
+
+	if err != nil {
+		log.Fatalf("Failed to create recorder, %v", err)
+	}
+
+	svr, err := server.NewServer(ctx, *server_uri)
+
+	if err != nil {
+		log.Fatalf("Failed to create server for '%s', %v", *server_uri, err)
+	}
+
+	log.Printf("Recording deliveries to %s and relaying them to %s, listening for requests on %s\n", *path, *destination, svr.Address())
+
+	err = svr.ListenAndServe(ctx, r)
+
+	if err != nil {
+		log.Fatalf("Failed to listen for requests, %v", err)
+	}
+
+	os.Exit(0)
+}
@@ -0,0 +1,127 @@
+// webhookd-register is a command line tool to create, or update, the webhooks registered with upstream providers (for example
+// GitHub, GitLab or Stripe) for the webhooks defined in a webhookd config.
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/sfomuseum/go-flags/flagset"
+	"github.com/whosonfirst/go-webhookd/v3/config"
+	"github.com/whosonfirst/go-webhookd/v3/provider"
+	"log"
+	"os"
+	"strings"
+)
+
+func main() {
+
+	fs := flagset.NewFlagSet("webhooks")
+
+	config_uri := fs.String("config-uri", "", "A valid Go Cloud runtimevar URI representing your webhookd config, or \"env://\" to derive the config from environment variables.")
+	public_url := fs.String("public-url", "", "The URL where the webhookd server can be reached by upstream providers. If empty the public_url property in your webhookd config is used.")
+	endpoint := fs.String("endpoint", "", "An optional webhook endpoint, including any tenant prefix, to register. If empty every webhook with a registration is registered.")
+	dryrun := fs.Bool("dryrun", false, "List the hooks that would be created or updated without changing anything.")
+	check := fs.Bool("check", false, "Compare the hooks registered with each provider with the registrations in your webhookd config, without changing anything, and list any drift. Exits with an error if drift is found.")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "webhookd-register is a command line tool to create, or update, the webhooks registered with upstream providers for the webhooks defined in a webhookd config.\n")
+		fmt.Fprintf(os.Stderr, "Usage:\n\t %s [options]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+
+	flagset.Parse(fs)
+
+	err := flagset.SetFlagsFromEnvVarsWithFeedback(fs, "WEBHOOKD", false)
+
+	if err != nil {
+		log.Fatalf("Failed to set flags from env vars, %v", err)
+	}
+
+	ctx := context.Background()
+
+	cfg, err := config.NewConfigFromURI(ctx, *config_uri)
+
+	if err != nil {
+		log.Fatalf("Failed to load config %s, %v", *config_uri, err)
+	}
+
+	registrations, err := provider.RegistrationsFromConfig(ctx, cfg, *public_url)
+
+	if err != nil {
+		log.Fatalf("Failed to derive registrations from config, %v", err)
+	}
+
+	selected := make([]*provider.Registration, 0)
+
+	for _, r := range registrations {
+
+		if *endpoint != "" && r.Endpoint != *endpoint {
+			continue
+		}
+
+		selected = append(selected, r)
+	}
+
+	if len(selected) == 0 {
+		log.Fatalf("No webhooks to register")
+	}
+
+	if *check {
+
+		drift := provider.CheckDrift(ctx, selected)
+
+		for _, d := range drift {
+
+			details := d.Error
+
+			if d.Expected != nil || d.Actual != nil {
+				details = fmt.Sprintf("expected %s, found %s", strings.Join(d.Expected, ","), strings.Join(d.Actual, ","))
+			}
+
+			fmt.Printf("%s\t%s\t%s\t%s\n", d.Endpoint, d.Kind, d.URL, details)
+		}
+
+		if len(drift) > 0 {
+			log.Fatalf("Found %d problem(s) with registered hooks", len(drift))
+		}
+
+		os.Exit(0)
+	}
+
+	for _, r := range selected {
+
+		if *dryrun {
+			fmt.Printf("%s\t%s\t%s\n", r.Endpoint, r.Hook.URL, strings.Join(r.Hook.Events, ","))
+			continue
+		}
+
+		p, err := provider.NewProvider(ctx, r.Provider)
+
+		if err != nil {
+			log.Fatalf("Failed to create provider for %s, %v", r.Endpoint, err)
+		}
+
+		hook, created, err := provider.Register(ctx, p, r.Hook)
+
+		if err != nil {
+			log.Fatalf("Failed to register %s, %v", r.Endpoint, err)
+		}
+
+		action := "Updated"
+
+		if created {
+			action = "Created"
+		}
+
+		log.Printf("%s hook %s for %s at %s\n", action, hook.ID, r.Endpoint, hook.URL)
+
+		// Some providers (for example Stripe) generate their own secrets which need to be copied to the receiver
+
+		if hook.Secret != "" && hook.Secret != r.Hook.Secret {
+			fmt.Printf("%s\t%s\n", r.Endpoint, hook.Secret)
+			log.Printf("The provider generated a new secret for %s which should be added to its receiver\n", r.Endpoint)
+		}
+	}
+
+	os.Exit(0)
+}
@@ -0,0 +1,67 @@
+// webhookd-replay is a command line tool to send webhook deliveries recorded by the webhookd-record tool to a server.
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/sfomuseum/go-flags/flagset"
+	"github.com/whosonfirst/go-webhookd/v3/recorder"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+func main() {
+
+	fs := flagset.NewFlagSet("webhooks")
+
+	path := fs.String("path", "", "The path to a file containing deliveries recorded by the webhookd-record tool.")
+	target := fs.String("target", "", "The URL of the server that deliveries should be sent to. For example 'http://localhost:8080'.")
+	speed := fs.Float64("speed", 1, "The factor by which to accelerate the original timing of deliveries. For example 1 replays deliveries at their original timing and 10 replays them ten times faster. If 0 deliveries are sent as quickly as possible.")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "webhookd-replay is a command line tool to send webhook deliveries recorded by the webhookd-record tool to a server.\n")
+		fmt.Fprintf(os.Stderr, "Usage:\n\t %s [options]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+
+	flagset.Parse(fs)
+
+	err := flagset.SetFlagsFromEnvVarsWithFeedback(fs, "WEBHOOKD", false)
+
+	if err != nil {
+		log.Fatalf("Failed to set flags from env vars, %v", err)
+	}
+
+	if *path == "" {
+		log.Fatalf("Missing -path flag")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fh, err := os.Open(*path)
+
+	if err != nil {
+		log.Fatalf("Failed to open %s, %v", *path, err)
+	}
+
+	defer fh.Close()
+
+	logger := log.Default()
+
+	opts := &recorder.ReplayOptions{
+		Target: *target,
+		Speed:  *speed,
+		Logger: logger,
+	}
+
+	err = recorder.Replay(ctx, fh, opts)
+
+	if err != nil {
+		log.Fatalf("Failed to replay deliveries, %v", err)
+	}
+
+	os.Exit(0)
+}
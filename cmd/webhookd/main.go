@@ -15,7 +15,8 @@ func main() {
 
 	fs := flagset.NewFlagSet("webhooks")
 
-	config_uri := fs.String("config-uri", "", "A valid Go Cloud runtimevar URI representing your webhookd config.")
+	config_uri := fs.String("config-uri", "", "A valid Go Cloud runtimevar URI representing your webhookd config, or \"env://\" to derive the config from environment variables.")
+	tunnel_uri := fs.String("tunnel", "", "An optional tunnel URI, for example \"ngrok://\" or \"cloudflared://\", used to expose the webhookd server to the internet for testing provider webhooks. If present this overrides the tunnel in your webhookd config.")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, "webhookd is a command line tool to start a go-webhookd daemon and serve requests over HTTP.\n")
@@ -39,6 +40,10 @@ func main() {
 		log.Fatalf("Failed to load config %s, %v", *config_uri, err)
 	}
 
+	if *tunnel_uri != "" {
+		cfg.Tunnel = *tunnel_uri
+	}
+
 	wh_daemon, err := daemon.NewWebhookDaemonFromConfig(ctx, cfg)
 
 	if err != nil {
@@ -0,0 +1,196 @@
+// Package components provides methods for describing the receivers, sources, transformations and dispatchers that have been
+// registered and the URI parameters they accept.
+package components
+
+//go:generate go run ./internal/generate -root .. -output descriptions.go
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/whosonfirst/go-webhookd/v3/dispatcher"
+	"github.com/whosonfirst/go-webhookd/v3/receiver"
+	"github.com/whosonfirst/go-webhookd/v3/source"
+	"github.com/whosonfirst/go-webhookd/v3/transformation"
+)
+
+// Kinds of components.
+const (
+	// KIND_RECEIVER is a `webhookd.WebhookReceiver` implementation.
+	KIND_RECEIVER string = "receiver"
+	// KIND_SOURCE is a `webhookd.WebhookSource` implementation.
+	KIND_SOURCE string = "source"
+	// KIND_TRANSFORMATION is a `webhookd.WebhookTransformation` implementation.
+	KIND_TRANSFORMATION string = "transformation"
+	// KIND_DISPATCHER is a `webhookd.WebhookDispatcher` implementation.
+	KIND_DISPATCHER string = "dispatcher"
+)
+
+// Parameter is a URI parameter accepted by a component.
+type Parameter struct {
+	// Name is the name of the query parameter.
+	Name string `json:"name"`
+	// Value is a description of the expected value, for example "{STRING}" or "{DURATION}".
+	Value string `json:"value"`
+	// Description is the description of the parameter.
+	Description string `json:"description,omitempty"`
+	// Required is a boolean flag signaling that the parameter is required.
+	Required bool `json:"required"`
+}
+
+// Component describes a registered component and the URIs it is configured by.
+type Component struct {
+	// Kind is one of the KIND_ constants.
+	Kind string `json:"kind"`
+	// Scheme is the URI scheme the component is registered with.
+	Scheme string `json:"scheme"`
+	// URIs is the list of URI templates the component is configured by, for example "linear://?{PARAMETERS}". It is empty for
+	// components that have not been described.
+	URIs []string `json:"uris,omitempty"`
+	// Parameters is the list of URI parameters accepted by the component.
+	Parameters []*Parameter `json:"parameters,omitempty"`
+}
+
+// registered is a dictionary of `Component` instances registered with `RegisterComponent`, keyed by kind and scheme.
+var registered = make(map[string]*Component)
+
+// registered_mu is a `sync.RWMutex` used to guard 'registered'.
+var registered_mu = new(sync.RWMutex)
+
+// Kinds returns the list of kinds of components.
+func Kinds() []string {
+	return []string{KIND_RECEIVER, KIND_SOURCE, KIND_TRANSFORMATION, KIND_DISPATCHER}
+}
+
+// RegisterComponent registers 'c' as the description of the component of kind `c.Kind` registered with the URI scheme `c.Scheme`.
+// Descriptions of the components in this package are derived from the doc comments of their constructors (see `go generate`);
+// this allows components registered by other packages to describe themselves. It is typically called from an `init` function.
+func RegisterComponent(ctx context.Context, c *Component) error {
+
+	if !isKind(c.Kind) {
+		return fmt.Errorf("Invalid kind '%s'", c.Kind)
+	}
+
+	if c.Scheme == "" {
+		return fmt.Errorf("Missing scheme")
+	}
+
+	registered_mu.Lock()
+	defer registered_mu.Unlock()
+
+	registered[c.Kind+":"+strings.ToLower(c.Scheme)] = c
+	return nil
+}
+
+// Components returns a `Component` for each registered component of kind 'kind' (one of the KIND_ constants), or of every kind if
+// 'kind' is empty, sorted by kind and scheme. Components that have not been described are included with only their kind and scheme.
+func Components(ctx context.Context, kind string) ([]*Component, error) {
+
+	kinds := Kinds()
+
+	if kind != "" {
+
+		if !isKind(kind) {
+			return nil, fmt.Errorf("Invalid kind '%s', expected one of: %s", kind, strings.Join(Kinds(), ", "))
+		}
+
+		kinds = []string{kind}
+	}
+
+	components := make([]*Component, 0)
+
+	for _, k := range kinds {
+
+		schemes := make([]string, 0)
+
+		for _, s := range registeredSchemes(k) {
+			schemes = append(schemes, strings.TrimSuffix(strings.ToLower(s), "://"))
+		}
+
+		sort.Strings(schemes)
+
+		for _, s := range schemes {
+			components = append(components, describe(k, s))
+		}
+	}
+
+	return components, nil
+}
+
+// Describe returns the `Component` for the registered component of kind 'kind' with the URI scheme 'scheme'.
+func Describe(ctx context.Context, kind string, scheme string) (*Component, error) {
+
+	if !isKind(kind) {
+		return nil, fmt.Errorf("Invalid kind '%s', expected one of: %s", kind, strings.Join(Kinds(), ", "))
+	}
+
+	scheme = strings.ToLower(strings.TrimSuffix(scheme, "://"))
+
+	for _, s := range registeredSchemes(kind) {
+
+		if strings.EqualFold(s, scheme+"://") {
+			return describe(kind, scheme), nil
+		}
+	}
+
+	return nil, fmt.Errorf("No %s is registered for the scheme '%s'", kind, scheme)
+}
+
+// describe returns the description of the component of kind 'kind' with the URI scheme 'scheme', preferring descriptions
+// registered with `RegisterComponent` to those derived from doc comments.
+func describe(kind string, scheme string) *Component {
+
+	key := kind + ":" + scheme
+
+	registered_mu.RLock()
+	c, ok := registered[key]
+	registered_mu.RUnlock()
+
+	if ok {
+		return c
+	}
+
+	c, ok = descriptions[key]
+
+	if ok {
+		return c
+	}
+
+	return &Component{
+		Kind:   kind,
+		Scheme: scheme,
+	}
+}
+
+// registeredSchemes returns the list of schemes, in the form of "{SCHEME}://", registered for components of kind 'kind'.
+func registeredSchemes(kind string) []string {
+
+	switch kind {
+	case KIND_RECEIVER:
+		return receiver.Schemes()
+	case KIND_SOURCE:
+		return source.Schemes()
+	case KIND_TRANSFORMATION:
+		return transformation.Schemes()
+	case KIND_DISPATCHER:
+		return dispatcher.Schemes()
+	default:
+		return nil
+	}
+}
+
+// isKind returns a boolean value indicating whether 'kind' is one of the KIND_ constants.
+func isKind(kind string) bool {
+
+	for _, k := range Kinds() {
+
+		if k == kind {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,189 @@
+package components
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/whosonfirst/go-webhookd/v3/components/internal/parse"
+)
+
+func TestDescriptionsUpToDate(t *testing.T) {
+
+	parsed, err := parse.ParseTree("..")
+
+	if err != nil {
+		t.Fatalf("Failed to parse components, %v", err)
+	}
+
+	if len(parsed) != len(descriptions) {
+		t.Fatalf("Expected %d descriptions but got %d, run 'go generate' in the components package", len(parsed), len(descriptions))
+	}
+
+	for _, d := range parsed {
+
+		c, ok := descriptions[d.Kind+":"+d.Scheme]
+
+		if !ok {
+			t.Fatalf("Missing description for %s '%s', run 'go generate' in the components package", d.Kind, d.Scheme)
+		}
+
+		params := make([]*Parameter, 0)
+
+		for _, p := range d.Parameters {
+			params = append(params, &Parameter{Name: p.Name, Value: p.Value, Description: p.Description, Required: p.Required})
+		}
+
+		expected := &Component{
+			Kind:       d.Kind,
+			Scheme:     d.Scheme,
+			URIs:       d.URIs,
+			Parameters: params,
+		}
+
+		if len(expected.URIs) == 0 {
+			expected.URIs = nil
+		}
+
+		if len(expected.Parameters) == 0 {
+			expected.Parameters = nil
+		}
+
+		if !reflect.DeepEqual(c, expected) {
+			t.Fatalf("Description for %s '%s' is out of date, run 'go generate' in the components package", d.Kind, d.Scheme)
+		}
+	}
+}
+
+func TestComponents(t *testing.T) {
+
+	ctx := context.Background()
+
+	all, err := Components(ctx, "")
+
+	if err != nil {
+		t.Fatalf("Failed to list components, %v", err)
+	}
+
+	for _, kind := range Kinds() {
+
+		components, err := Components(ctx, kind)
+
+		if err != nil {
+			t.Fatalf("Failed to list %s components, %v", kind, err)
+		}
+
+		if len(components) == 0 {
+			t.Fatalf("Expected at least one %s", kind)
+		}
+
+		for _, c := range components {
+
+			if c.Kind != kind {
+				t.Fatalf("Unexpected kind '%s' for %s components", c.Kind, kind)
+			}
+
+			if len(c.URIs) == 0 {
+				t.Fatalf("Missing URIs for %s '%s'", c.Kind, c.Scheme)
+			}
+		}
+	}
+
+	if len(all) != len(descriptions) {
+		t.Fatalf("Expected %d components but got %d", len(descriptions), len(all))
+	}
+
+	_, err = Components(ctx, "widget")
+
+	if err == nil {
+		t.Fatalf("Expected invalid kind to fail")
+	}
+}
+
+func TestDescribe(t *testing.T) {
+
+	ctx := context.Background()
+
+	c, err := Describe(ctx, KIND_RECEIVER, "linear://")
+
+	if err != nil {
+		t.Fatalf("Failed to describe linear receiver, %v", err)
+	}
+
+	if c.Scheme != "linear" || c.URIs[0] != "linear://?{PARAMETERS}" {
+		t.Fatalf("Unexpected description %v", c)
+	}
+
+	var secret *Parameter
+
+	for _, p := range c.Parameters {
+
+		if p.Name == "secret" {
+			secret = p
+		}
+	}
+
+	if secret == nil || !secret.Required || secret.Value != "{STRING}" {
+		t.Fatalf("Unexpected secret parameter %v", secret)
+	}
+
+	_, err = Describe(ctx, KIND_RECEIVER, "bogus")
+
+	if err == nil {
+		t.Fatalf("Expected describing unregistered receiver to fail")
+	}
+
+	_, err = Describe(ctx, "widget", "linear")
+
+	if err == nil {
+		t.Fatalf("Expected describing invalid kind to fail")
+	}
+}
+
+func TestRegisterComponent(t *testing.T) {
+
+	ctx := context.Background()
+
+	err := RegisterComponent(ctx, &Component{Kind: "widget", Scheme: "null"})
+
+	if err == nil {
+		t.Fatalf("Expected registering invalid kind to fail")
+	}
+
+	err = RegisterComponent(ctx, &Component{Kind: KIND_DISPATCHER})
+
+	if err == nil {
+		t.Fatalf("Expected registering component without scheme to fail")
+	}
+
+	custom := &Component{
+		Kind:   KIND_DISPATCHER,
+		Scheme: "null",
+		URIs:   []string{"null://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "example", Value: "{STRING}", Description: "An example parameter."},
+		},
+	}
+
+	err = RegisterComponent(ctx, custom)
+
+	if err != nil {
+		t.Fatalf("Failed to register component, %v", err)
+	}
+
+	defer func() {
+		registered_mu.Lock()
+		delete(registered, KIND_DISPATCHER+":null")
+		registered_mu.Unlock()
+	}()
+
+	c, err := Describe(ctx, KIND_DISPATCHER, "null")
+
+	if err != nil {
+		t.Fatalf("Failed to describe null dispatcher, %v", err)
+	}
+
+	if c != custom {
+		t.Fatalf("Expected registered description for null dispatcher")
+	}
+}
@@ -0,0 +1,974 @@
+// Code generated by internal/generate from the doc comments of component constructors. DO NOT EDIT.
+
+package components
+
+// descriptions is a dictionary of `Component` instances for the components in this package, keyed by kind and scheme.
+var descriptions = map[string]*Component{
+	"receiver:airtable": {
+		Kind:   "receiver",
+		Scheme: "airtable",
+		URIs:   []string{"airtable://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "mac_secret", Value: "{STRING}", Description: "The (URI-escaped) base64-encoded `macSecretBase64` value returned by Airtable when a webhook is created. Multiple comma-separated values may be defined so that secrets can be rotated. Required.", Required: true},
+			{Name: "form_field", Value: "{NAME}", Description: "The name of a form field whose value will be used as the message body.", Required: false},
+			{Name: "form_json", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that all form fields should be encoded as a JSON dictionary.", Required: false},
+			{Name: "decompress", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that gzip and deflate encoded bodies should be decompressed. Default is true.", Required: false},
+			{Name: "max_decompressed_bytes", Value: "{INT}", Description: "The maximum number of bytes that a compressed body may be decompressed to. Default is `DEFAULT_MAX_DECOMPRESSED_BYTES`.", Required: false},
+			{Name: "verify_raw", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that message signatures should be validated using the raw (compressed) bytes of a message body. Default is false.", Required: false},
+		},
+	},
+	"receiver:atlassian": {
+		Kind:   "receiver",
+		Scheme: "atlassian",
+		URIs:   []string{"atlassian://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "shared_secret", Value: "{STRING}", Description: "One or more (comma-separated) Atlassian Connect shared secrets used to validate the JWT token included with each request.", Required: false},
+			{Name: "client_key", Value: "{STRING}", Description: "The Atlassian Connect client key that JWT tokens must be issued by. If empty tokens from any client are accepted.", Required: false},
+			{Name: "token", Value: "{STRING}", Description: "One or more (comma-separated) valid values for the `token` query parameter that requests must include.", Required: false},
+			{Name: "base_path", Value: "{STRING}", Description: "The path prefix, relative to the Atlassian Connect app base URL, removed from request paths before computing query string hashes.", Required: false},
+			{Name: "form_field", Value: "{NAME}", Description: "The name of a form field whose value will be used as the message body.", Required: false},
+			{Name: "form_json", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that all form fields should be encoded as a JSON dictionary.", Required: false},
+			{Name: "decompress", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that gzip and deflate encoded bodies should be decompressed. Default is true.", Required: false},
+			{Name: "max_decompressed_bytes", Value: "{INT}", Description: "The maximum number of bytes that a compressed body may be decompressed to. Default is `DEFAULT_MAX_DECOMPRESSED_BYTES`.", Required: false},
+			{Name: "verify_raw", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that message signatures should be validated using the raw (compressed) bytes of a message body. Default is false.", Required: false},
+		},
+	},
+	"receiver:auth0": {
+		Kind:   "receiver",
+		Scheme: "auth0",
+		URIs:   []string{"auth0://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "authorization", Value: "{STRING}", Description: "One or more (comma-separated) valid values for the `Authorization` header, as configured for the log stream, that requests must include. Required.", Required: true},
+			{Name: "form_field", Value: "{NAME}", Description: "The name of a form field whose value will be used as the message body.", Required: false},
+			{Name: "form_json", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that all form fields should be encoded as a JSON dictionary.", Required: false},
+			{Name: "decompress", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that gzip and deflate encoded bodies should be decompressed. Default is true.", Required: false},
+			{Name: "max_decompressed_bytes", Value: "{INT}", Description: "The maximum number of bytes that a compressed body may be decompressed to. Default is `DEFAULT_MAX_DECOMPRESSED_BYTES`.", Required: false},
+			{Name: "verify_raw", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that message signatures should be validated using the raw (compressed) bytes of a message body. Default is false.", Required: false},
+		},
+	},
+	"receiver:azuredevops": {
+		Kind:   "receiver",
+		Scheme: "azuredevops",
+		URIs:   []string{"azuredevops://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "username", Value: "{STRING}", Description: "The basic authentication username, as configured for the service hook subscription, that requests must include. Required.", Required: true},
+			{Name: "password", Value: "{STRING}", Description: "The basic authentication password, as configured for the service hook subscription, that requests must include. Required.", Required: true},
+			{Name: "form_field", Value: "{NAME}", Description: "The name of a form field whose value will be used as the message body.", Required: false},
+			{Name: "form_json", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that all form fields should be encoded as a JSON dictionary.", Required: false},
+			{Name: "decompress", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that gzip and deflate encoded bodies should be decompressed. Default is true.", Required: false},
+			{Name: "max_decompressed_bytes", Value: "{INT}", Description: "The maximum number of bytes that a compressed body may be decompressed to. Default is `DEFAULT_MAX_DECOMPRESSED_BYTES`.", Required: false},
+			{Name: "verify_raw", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that message signatures should be validated using the raw (compressed) bytes of a message body. Default is false.", Required: false},
+		},
+	},
+	"receiver:buildkite": {
+		Kind:   "receiver",
+		Scheme: "buildkite",
+		URIs:   []string{"buildkite://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "token", Value: "{STRING}", Description: "One or more (comma-separated) tokens that messages may include in the `X-Buildkite-Token` header.", Required: false},
+			{Name: "secret", Value: "{STRING}", Description: "One or more (comma-separated) secrets used to validate message signatures in the `X-Buildkite-Signature` header.", Required: false},
+			{Name: "max_age", Value: "{DURATION}", Description: "The maximum age of a signed message, derived from its timestamp. A value of \"0s\" disables this check. Default is \"5m\".", Required: false},
+			{Name: "max_skew", Value: "{DURATION}", Description: "The maximum amount of time that the timestamp of a message may be ahead of the current time. A value of \"0s\" disables this check. Default is the value of `max_age`.", Required: false},
+			{Name: "form_field", Value: "{NAME}", Description: "The name of a form field whose value will be used as the message body.", Required: false},
+			{Name: "form_json", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that all form fields should be encoded as a JSON dictionary.", Required: false},
+			{Name: "decompress", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that gzip and deflate encoded bodies should be decompressed. Default is true.", Required: false},
+			{Name: "max_decompressed_bytes", Value: "{INT}", Description: "The maximum number of bytes that a compressed body may be decompressed to. Default is `DEFAULT_MAX_DECOMPRESSED_BYTES`.", Required: false},
+			{Name: "verify_raw", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that message signatures should be validated using the raw (compressed) bytes of a message body. Default is false.", Required: false},
+		},
+	},
+	"receiver:chargebee": {
+		Kind:   "receiver",
+		Scheme: "chargebee",
+		URIs:   []string{"chargebee://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "username", Value: "{STRING}", Description: "The basic authentication username, as configured for the webhook, that requests must include. Required.", Required: true},
+			{Name: "password", Value: "{STRING}", Description: "The basic authentication password, as configured for the webhook, that requests must include. Required.", Required: true},
+			{Name: "form_field", Value: "{NAME}", Description: "The name of a form field whose value will be used as the message body.", Required: false},
+			{Name: "form_json", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that all form fields should be encoded as a JSON dictionary.", Required: false},
+			{Name: "decompress", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that gzip and deflate encoded bodies should be decompressed. Default is true.", Required: false},
+			{Name: "max_decompressed_bytes", Value: "{INT}", Description: "The maximum number of bytes that a compressed body may be decompressed to. Default is `DEFAULT_MAX_DECOMPRESSED_BYTES`.", Required: false},
+			{Name: "verify_raw", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that message signatures should be validated using the raw (compressed) bytes of a message body. Default is false.", Required: false},
+		},
+	},
+	"receiver:circleci": {
+		Kind:   "receiver",
+		Scheme: "circleci",
+		URIs:   []string{"circleci://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "secret", Value: "{STRING}", Description: "One or more (comma-separated) webhook secrets used to validate message signatures. Required.", Required: true},
+			{Name: "form_field", Value: "{NAME}", Description: "The name of a form field whose value will be used as the message body.", Required: false},
+			{Name: "form_json", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that all form fields should be encoded as a JSON dictionary.", Required: false},
+			{Name: "decompress", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that gzip and deflate encoded bodies should be decompressed. Default is true.", Required: false},
+			{Name: "max_decompressed_bytes", Value: "{INT}", Description: "The maximum number of bytes that a compressed body may be decompressed to. Default is `DEFAULT_MAX_DECOMPRESSED_BYTES`.", Required: false},
+			{Name: "verify_raw", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that message signatures should be validated using the raw (compressed) bytes of a message body. Default is false.", Required: false},
+		},
+	},
+	"receiver:cloudflare": {
+		Kind:   "receiver",
+		Scheme: "cloudflare",
+		URIs:   []string{"cloudflare://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "secret", Value: "{STRING}", Description: "One or more (comma-separated) secrets, defined when the webhook destination was created, that requests must include in the `cf-webhook-auth` header. Required.", Required: true},
+			{Name: "form_field", Value: "{NAME}", Description: "The name of a form field whose value will be used as the message body.", Required: false},
+			{Name: "form_json", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that all form fields should be encoded as a JSON dictionary.", Required: false},
+			{Name: "decompress", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that gzip and deflate encoded bodies should be decompressed. Default is true.", Required: false},
+			{Name: "max_decompressed_bytes", Value: "{INT}", Description: "The maximum number of bytes that a compressed body may be decompressed to. Default is `DEFAULT_MAX_DECOMPRESSED_BYTES`.", Required: false},
+			{Name: "verify_raw", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that message signatures should be validated using the raw (compressed) bytes of a message body. Default is false.", Required: false},
+		},
+	},
+	"receiver:digitalocean": {
+		Kind:   "receiver",
+		Scheme: "digitalocean",
+		URIs:   []string{"digitalocean://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "token", Value: "{STRING}", Description: "One or more (comma-separated) valid tokens that requests must include. Required.", Required: true},
+			{Name: "form_field", Value: "{NAME}", Description: "The name of a form field whose value will be used as the message body.", Required: false},
+			{Name: "form_json", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that all form fields should be encoded as a JSON dictionary.", Required: false},
+			{Name: "decompress", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that gzip and deflate encoded bodies should be decompressed. Default is true.", Required: false},
+			{Name: "max_decompressed_bytes", Value: "{INT}", Description: "The maximum number of bytes that a compressed body may be decompressed to. Default is `DEFAULT_MAX_DECOMPRESSED_BYTES`.", Required: false},
+			{Name: "verify_raw", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that message signatures should be validated using the raw (compressed) bytes of a message body. Default is false.", Required: false},
+		},
+	},
+	"receiver:dockerhub": {
+		Kind:   "receiver",
+		Scheme: "dockerhub",
+		URIs:   []string{"dockerhub://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "token", Value: "{STRING}", Description: "One or more (comma-separated) valid tokens that requests must include. Required.", Required: true},
+			{Name: "normalize", Value: "{BOOLEAN}", Description: "Return notifications as a JSON-encoded list of `RegistryEvent` instances. Default is false.", Required: false},
+			{Name: "form_field", Value: "{NAME}", Description: "The name of a form field whose value will be used as the message body.", Required: false},
+			{Name: "form_json", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that all form fields should be encoded as a JSON dictionary.", Required: false},
+			{Name: "decompress", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that gzip and deflate encoded bodies should be decompressed. Default is true.", Required: false},
+			{Name: "max_decompressed_bytes", Value: "{INT}", Description: "The maximum number of bytes that a compressed body may be decompressed to. Default is `DEFAULT_MAX_DECOMPRESSED_BYTES`.", Required: false},
+			{Name: "verify_raw", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that message signatures should be validated using the raw (compressed) bytes of a message body. Default is false.", Required: false},
+		},
+	},
+	"receiver:docusign": {
+		Kind:   "receiver",
+		Scheme: "docusign",
+		URIs:   []string{"docusign://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "key", Value: "{STRING}", Description: "One or more (comma-separated) Connect HMAC keys used to validate message signatures. Required.", Required: true},
+			{Name: "form_field", Value: "{NAME}", Description: "The name of a form field whose value will be used as the message body.", Required: false},
+			{Name: "form_json", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that all form fields should be encoded as a JSON dictionary.", Required: false},
+			{Name: "decompress", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that gzip and deflate encoded bodies should be decompressed. Default is true.", Required: false},
+			{Name: "max_decompressed_bytes", Value: "{INT}", Description: "The maximum number of bytes that a compressed body may be decompressed to. Default is `DEFAULT_MAX_DECOMPRESSED_BYTES`.", Required: false},
+			{Name: "verify_raw", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that message signatures should be validated using the raw (compressed) bytes of a message body. Default is false.", Required: false},
+		},
+	},
+	"receiver:fastly": {
+		Kind:   "receiver",
+		Scheme: "fastly",
+		URIs:   []string{"fastly://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "token", Value: "{STRING}", Description: "One or more (comma-separated) valid tokens that requests must include. Required.", Required: true},
+			{Name: "service_id", Value: "{STRING}", Description: "Zero or more Fastly service IDs allowed to stream logs to the endpoint. If empty any service is allowed.", Required: false},
+			{Name: "form_field", Value: "{NAME}", Description: "The name of a form field whose value will be used as the message body.", Required: false},
+			{Name: "form_json", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that all form fields should be encoded as a JSON dictionary.", Required: false},
+			{Name: "decompress", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that gzip and deflate encoded bodies should be decompressed. Default is true.", Required: false},
+			{Name: "max_decompressed_bytes", Value: "{INT}", Description: "The maximum number of bytes that a compressed body may be decompressed to. Default is `DEFAULT_MAX_DECOMPRESSED_BYTES`.", Required: false},
+			{Name: "verify_raw", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that message signatures should be validated using the raw (compressed) bytes of a message body. Default is false.", Required: false},
+		},
+	},
+	"receiver:gerrit": {
+		Kind:   "receiver",
+		Scheme: "gerrit",
+		URIs:   []string{"gerrit://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "token", Value: "{STRING}", Description: "One or more (comma-separated) valid tokens that requests must include. Required.", Required: true},
+			{Name: "form_field", Value: "{NAME}", Description: "The name of a form field whose value will be used as the message body.", Required: false},
+			{Name: "form_json", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that all form fields should be encoded as a JSON dictionary.", Required: false},
+			{Name: "decompress", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that gzip and deflate encoded bodies should be decompressed. Default is true.", Required: false},
+			{Name: "max_decompressed_bytes", Value: "{INT}", Description: "The maximum number of bytes that a compressed body may be decompressed to. Default is `DEFAULT_MAX_DECOMPRESSED_BYTES`.", Required: false},
+			{Name: "verify_raw", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that message signatures should be validated using the raw (compressed) bytes of a message body. Default is false.", Required: false},
+		},
+	},
+	"receiver:gitea": {
+		Kind:   "receiver",
+		Scheme: "gitea",
+		URIs:   []string{"gitea://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "secret", Value: "{STRING}", Description: "One or more (comma-separated) webhook secrets used to validate message signatures. Required.", Required: true},
+			{Name: "form_field", Value: "{NAME}", Description: "The name of a form field whose value will be used as the message body.", Required: false},
+			{Name: "form_json", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that all form fields should be encoded as a JSON dictionary.", Required: false},
+			{Name: "decompress", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that gzip and deflate encoded bodies should be decompressed. Default is true.", Required: false},
+			{Name: "max_decompressed_bytes", Value: "{INT}", Description: "The maximum number of bytes that a compressed body may be decompressed to. Default is `DEFAULT_MAX_DECOMPRESSED_BYTES`.", Required: false},
+			{Name: "verify_raw", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that message signatures should be validated using the raw (compressed) bytes of a message body. Default is false.", Required: false},
+		},
+	},
+	"receiver:gogs": {
+		Kind:   "receiver",
+		Scheme: "gogs",
+		URIs:   []string{"gogs://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "secret", Value: "{STRING}", Description: "One or more (comma-separated) webhook secrets used to validate message signatures. Required.", Required: true},
+			{Name: "form_field", Value: "{NAME}", Description: "The name of a form field whose value will be used as the message body.", Required: false},
+			{Name: "form_json", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that all form fields should be encoded as a JSON dictionary.", Required: false},
+			{Name: "decompress", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that gzip and deflate encoded bodies should be decompressed. Default is true.", Required: false},
+			{Name: "max_decompressed_bytes", Value: "{INT}", Description: "The maximum number of bytes that a compressed body may be decompressed to. Default is `DEFAULT_MAX_DECOMPRESSED_BYTES`.", Required: false},
+			{Name: "verify_raw", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that message signatures should be validated using the raw (compressed) bytes of a message body. Default is false.", Required: false},
+		},
+	},
+	"receiver:heroku": {
+		Kind:   "receiver",
+		Scheme: "heroku",
+		URIs:   []string{"heroku://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "secret", Value: "{STRING}", Description: "One or more (comma-separated) webhook secrets used to validate message signatures. Required.", Required: true},
+			{Name: "form_field", Value: "{NAME}", Description: "The name of a form field whose value will be used as the message body.", Required: false},
+			{Name: "form_json", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that all form fields should be encoded as a JSON dictionary.", Required: false},
+			{Name: "decompress", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that gzip and deflate encoded bodies should be decompressed. Default is true.", Required: false},
+			{Name: "max_decompressed_bytes", Value: "{INT}", Description: "The maximum number of bytes that a compressed body may be decompressed to. Default is `DEFAULT_MAX_DECOMPRESSED_BYTES`.", Required: false},
+			{Name: "verify_raw", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that message signatures should be validated using the raw (compressed) bytes of a message body. Default is false.", Required: false},
+		},
+	},
+	"receiver:hubspot": {
+		Kind:   "receiver",
+		Scheme: "hubspot",
+		URIs:   []string{"hubspot://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "client_secret", Value: "{STRING}", Description: "One or more (comma-separated) HubSpot app client secrets used to validate message signatures. Required.", Required: true},
+			{Name: "url", Value: "{URL}", Description: "The (URI-escaped) public URL of the webhook endpoint, without a query string. If empty the URL is derived from the request and its `X-Forwarded-Proto` and `X-Forwarded-Host` headers.", Required: false},
+			{Name: "max_age", Value: "{DURATION}", Description: "The maximum age of a message, derived from its timestamp. A value of \"0s\" disables this check. Default is \"5m\".", Required: false},
+			{Name: "max_skew", Value: "{DURATION}", Description: "The maximum amount of time that the timestamp of a message may be ahead of the current time. A value of \"0s\" disables this check. Default is the value of `max_age`.", Required: false},
+			{Name: "form_field", Value: "{NAME}", Description: "The name of a form field whose value will be used as the message body.", Required: false},
+			{Name: "form_json", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that all form fields should be encoded as a JSON dictionary.", Required: false},
+			{Name: "decompress", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that gzip and deflate encoded bodies should be decompressed. Default is true.", Required: false},
+			{Name: "max_decompressed_bytes", Value: "{INT}", Description: "The maximum number of bytes that a compressed body may be decompressed to. Default is `DEFAULT_MAX_DECOMPRESSED_BYTES`.", Required: false},
+			{Name: "verify_raw", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that message signatures should be validated using the raw (compressed) bytes of a message body. Default is false.", Required: false},
+		},
+	},
+	"receiver:insecure": {
+		Kind:   "receiver",
+		Scheme: "insecure",
+		URIs:   []string{"insecure://?{PARAMETERS}"},
+	},
+	"receiver:intercom": {
+		Kind:   "receiver",
+		Scheme: "intercom",
+		URIs:   []string{"intercom://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "client_secret", Value: "{STRING}", Description: "One or more (comma-separated) Intercom app client secrets used to validate message signatures. Required.", Required: true},
+			{Name: "form_field", Value: "{NAME}", Description: "The name of a form field whose value will be used as the message body.", Required: false},
+			{Name: "form_json", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that all form fields should be encoded as a JSON dictionary.", Required: false},
+			{Name: "decompress", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that gzip and deflate encoded bodies should be decompressed. Default is true.", Required: false},
+			{Name: "max_decompressed_bytes", Value: "{INT}", Description: "The maximum number of bytes that a compressed body may be decompressed to. Default is `DEFAULT_MAX_DECOMPRESSED_BYTES`.", Required: false},
+			{Name: "verify_raw", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that message signatures should be validated using the raw (compressed) bytes of a message body. Default is false.", Required: false},
+		},
+	},
+	"receiver:jwt": {
+		Kind:   "receiver",
+		Scheme: "jwt",
+		URIs:   []string{"jwt://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "secret", Value: "{STRING}", Description: "One or more (comma-separated) shared secrets used to verify tokens signed with the HS256, HS384 or HS512 algorithms.", Required: false},
+			{Name: "jwks", Value: "{URL}", Description: "The URL of a JSON Web Key Set used to verify tokens signed with the RS*, PS*, ES* or EdDSA algorithms.", Required: false},
+			{Name: "audience", Value: "{STRING}", Description: "Zero or more audiences; if present a token's \"aud\" claim must contain at least one of them.", Required: false},
+			{Name: "issuer", Value: "{STRING}", Description: "If present a token's \"iss\" claim must match this value.", Required: false},
+			{Name: "leeway", Value: "{DURATION}", Description: "The amount of clock skew allowed when checking the \"exp\" and \"nbf\" claims. Default is \"1m\".", Required: false},
+			{Name: "timeout", Value: "{DURATION}", Description: "The amount of time to wait for a JSON Web Key Set request to complete. Default is \"10s\".", Required: false},
+			{Name: "form_field", Value: "{NAME}", Description: "The name of a form field whose value will be used as the message body.", Required: false},
+			{Name: "form_json", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that all form fields should be encoded as a JSON dictionary.", Required: false},
+			{Name: "decompress", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that gzip and deflate encoded bodies should be decompressed. Default is true.", Required: false},
+			{Name: "max_decompressed_bytes", Value: "{INT}", Description: "The maximum number of bytes that a compressed body may be decompressed to. Default is `DEFAULT_MAX_DECOMPRESSED_BYTES`.", Required: false},
+			{Name: "verify_raw", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that message signatures should be validated using the raw (compressed) bytes of a message body. Default is false.", Required: false},
+		},
+	},
+	"receiver:linear": {
+		Kind:   "receiver",
+		Scheme: "linear",
+		URIs:   []string{"linear://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "secret", Value: "{STRING}", Description: "One or more (comma-separated) Linear webhook signing secrets used to validate message signatures. Required.", Required: true},
+			{Name: "max_age", Value: "{DURATION}", Description: "The maximum age of a message, derived from its `webhookTimestamp` property. A value of \"0s\" disables this check. Default is \"5m\".", Required: false},
+			{Name: "max_skew", Value: "{DURATION}", Description: "The maximum amount of time that the timestamp of a message may be ahead of the current time. A value of \"0s\" disables this check. Default is the value of `max_age`.", Required: false},
+			{Name: "form_field", Value: "{NAME}", Description: "The name of a form field whose value will be used as the message body.", Required: false},
+			{Name: "form_json", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that all form fields should be encoded as a JSON dictionary.", Required: false},
+			{Name: "decompress", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that gzip and deflate encoded bodies should be decompressed. Default is true.", Required: false},
+			{Name: "max_decompressed_bytes", Value: "{INT}", Description: "The maximum number of bytes that a compressed body may be decompressed to. Default is `DEFAULT_MAX_DECOMPRESSED_BYTES`.", Required: false},
+			{Name: "verify_raw", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that message signatures should be validated using the raw (compressed) bytes of a message body. Default is false.", Required: false},
+		},
+	},
+	"receiver:linode": {
+		Kind:   "receiver",
+		Scheme: "linode",
+		URIs:   []string{"linode://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "token", Value: "{STRING}", Description: "One or more (comma-separated) valid tokens that requests must include. Required.", Required: true},
+			{Name: "form_field", Value: "{NAME}", Description: "The name of a form field whose value will be used as the message body.", Required: false},
+			{Name: "form_json", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that all form fields should be encoded as a JSON dictionary.", Required: false},
+			{Name: "decompress", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that gzip and deflate encoded bodies should be decompressed. Default is true.", Required: false},
+			{Name: "max_decompressed_bytes", Value: "{INT}", Description: "The maximum number of bytes that a compressed body may be decompressed to. Default is `DEFAULT_MAX_DECOMPRESSED_BYTES`.", Required: false},
+			{Name: "verify_raw", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that message signatures should be validated using the raw (compressed) bytes of a message body. Default is false.", Required: false},
+		},
+	},
+	"receiver:mailgun": {
+		Kind:   "receiver",
+		Scheme: "mailgun",
+		URIs:   []string{"mailgun://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "signing_key", Value: "{STRING}", Description: "One or more (comma-separated) Mailgun webhook signing keys used to validate message signatures. Required.", Required: true},
+			{Name: "max_age", Value: "{DURATION}", Description: "The maximum age of a message, derived from its timestamp. A value of \"0s\" disables this check. Default is \"5m\".", Required: false},
+			{Name: "max_skew", Value: "{DURATION}", Description: "The maximum amount of time that the timestamp of a message may be ahead of the current time. A value of \"0s\" disables this check. Default is the value of `max_age`.", Required: false},
+			{Name: "decompress", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that gzip and deflate encoded bodies should be decompressed. Default is true.", Required: false},
+			{Name: "max_decompressed_bytes", Value: "{INT}", Description: "The maximum number of bytes that a compressed body may be decompressed to. Default is `DEFAULT_MAX_DECOMPRESSED_BYTES`.", Required: false},
+			{Name: "verify_raw", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that message signatures should be validated using the raw (compressed) bytes of a message body. Default is false.", Required: false},
+		},
+	},
+	"receiver:netlify": {
+		Kind:   "receiver",
+		Scheme: "netlify",
+		URIs:   []string{"netlify://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "secret", Value: "{STRING}", Description: "One or more (comma-separated) JWS secret tokens, defined in the deploy notification settings, used to validate message signatures. Required.", Required: true},
+			{Name: "form_field", Value: "{NAME}", Description: "The name of a form field whose value will be used as the message body.", Required: false},
+			{Name: "form_json", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that all form fields should be encoded as a JSON dictionary.", Required: false},
+			{Name: "decompress", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that gzip and deflate encoded bodies should be decompressed. Default is true.", Required: false},
+			{Name: "max_decompressed_bytes", Value: "{INT}", Description: "The maximum number of bytes that a compressed body may be decompressed to. Default is `DEFAULT_MAX_DECOMPRESSED_BYTES`.", Required: false},
+			{Name: "verify_raw", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that message signatures should be validated using the raw (compressed) bytes of a message body. Default is false.", Required: false},
+		},
+	},
+	"receiver:notion": {
+		Kind:   "receiver",
+		Scheme: "notion",
+		URIs:   []string{"notion://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "verification_token", Value: "{STRING}", Description: "One or more (comma-separated) verification tokens, sent by Notion when a webhook subscription is created, used to validate message signatures.", Required: false},
+			{Name: "form_field", Value: "{NAME}", Description: "The name of a form field whose value will be used as the message body.", Required: false},
+			{Name: "form_json", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that all form fields should be encoded as a JSON dictionary.", Required: false},
+			{Name: "decompress", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that gzip and deflate encoded bodies should be decompressed. Default is true.", Required: false},
+			{Name: "max_decompressed_bytes", Value: "{INT}", Description: "The maximum number of bytes that a compressed body may be decompressed to. Default is `DEFAULT_MAX_DECOMPRESSED_BYTES`.", Required: false},
+			{Name: "verify_raw", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that message signatures should be validated using the raw (compressed) bytes of a message body. Default is false.", Required: false},
+		},
+	},
+	"receiver:npm": {
+		Kind:   "receiver",
+		Scheme: "npm",
+		URIs:   []string{"npm://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "secret", Value: "{STRING}", Description: "One or more (comma-separated) secrets, defined when the hook was created, used to validate message signatures. Required.", Required: true},
+			{Name: "form_field", Value: "{NAME}", Description: "The name of a form field whose value will be used as the message body.", Required: false},
+			{Name: "form_json", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that all form fields should be encoded as a JSON dictionary.", Required: false},
+			{Name: "decompress", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that gzip and deflate encoded bodies should be decompressed. Default is true.", Required: false},
+			{Name: "max_decompressed_bytes", Value: "{INT}", Description: "The maximum number of bytes that a compressed body may be decompressed to. Default is `DEFAULT_MAX_DECOMPRESSED_BYTES`.", Required: false},
+			{Name: "verify_raw", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that message signatures should be validated using the raw (compressed) bytes of a message body. Default is false.", Required: false},
+		},
+	},
+	"receiver:okta": {
+		Kind:   "receiver",
+		Scheme: "okta",
+		URIs:   []string{"okta://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "authorization", Value: "{STRING}", Description: "One or more (comma-separated) valid values for the `Authorization` header, as configured for the event hook, that requests must include. Required.", Required: true},
+			{Name: "header", Value: "{NAME}:{VALUE}", Description: "Zero or more custom headers, as configured for the event hook, that requests must include.", Required: false},
+			{Name: "form_field", Value: "{NAME}", Description: "The name of a form field whose value will be used as the message body.", Required: false},
+			{Name: "form_json", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that all form fields should be encoded as a JSON dictionary.", Required: false},
+			{Name: "decompress", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that gzip and deflate encoded bodies should be decompressed. Default is true.", Required: false},
+			{Name: "max_decompressed_bytes", Value: "{INT}", Description: "The maximum number of bytes that a compressed body may be decompressed to. Default is `DEFAULT_MAX_DECOMPRESSED_BYTES`.", Required: false},
+			{Name: "verify_raw", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that message signatures should be validated using the raw (compressed) bytes of a message body. Default is false.", Required: false},
+		},
+	},
+	"receiver:paddle": {
+		Kind:   "receiver",
+		Scheme: "paddle",
+		URIs:   []string{"paddle://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "secret", Value: "{STRING}", Description: "One or more (comma-separated) notification destination secret keys used to validate message signatures. Required.", Required: true},
+			{Name: "max_age", Value: "{DURATION}", Description: "The maximum age of a message, derived from its timestamp. A value of \"0s\" disables this check. Default is \"5m\".", Required: false},
+			{Name: "max_skew", Value: "{DURATION}", Description: "The maximum amount of time that the timestamp of a message may be ahead of the current time. A value of \"0s\" disables this check. Default is the value of `max_age`.", Required: false},
+			{Name: "form_field", Value: "{NAME}", Description: "The name of a form field whose value will be used as the message body.", Required: false},
+			{Name: "form_json", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that all form fields should be encoded as a JSON dictionary.", Required: false},
+			{Name: "decompress", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that gzip and deflate encoded bodies should be decompressed. Default is true.", Required: false},
+			{Name: "max_decompressed_bytes", Value: "{INT}", Description: "The maximum number of bytes that a compressed body may be decompressed to. Default is `DEFAULT_MAX_DECOMPRESSED_BYTES`.", Required: false},
+			{Name: "verify_raw", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that message signatures should be validated using the raw (compressed) bytes of a message body. Default is false.", Required: false},
+		},
+	},
+	"receiver:paypal": {
+		Kind:   "receiver",
+		Scheme: "paypal",
+		URIs:   []string{"paypal://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "client_id", Value: "{STRING}", Description: "The PayPal REST API client ID. Required.", Required: true},
+			{Name: "client_secret", Value: "{STRING}", Description: "The PayPal REST API client secret. Required.", Required: true},
+			{Name: "webhook_id", Value: "{STRING}", Description: "The ID of the webhook, as assigned by PayPal. Required.", Required: true},
+			{Name: "sandbox", Value: "{BOOLEAN}", Description: "Use the PayPal sandbox API. Default is false.", Required: false},
+			{Name: "api", Value: "{URL}", Description: "The root URL for the PayPal REST API. Default is \"https://api-m.paypal.com\".", Required: false},
+			{Name: "timeout", Value: "{DURATION}", Description: "The amount of time to wait for a PayPal API request to complete. Default is \"10s\".", Required: false},
+			{Name: "form_field", Value: "{NAME}", Description: "The name of a form field whose value will be used as the message body.", Required: false},
+			{Name: "form_json", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that all form fields should be encoded as a JSON dictionary.", Required: false},
+			{Name: "decompress", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that gzip and deflate encoded bodies should be decompressed. Default is true.", Required: false},
+			{Name: "max_decompressed_bytes", Value: "{INT}", Description: "The maximum number of bytes that a compressed body may be decompressed to. Default is `DEFAULT_MAX_DECOMPRESSED_BYTES`.", Required: false},
+			{Name: "verify_raw", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that message signatures should be validated using the raw (compressed) bytes of a message body. Default is false.", Required: false},
+		},
+	},
+	"receiver:phabricator": {
+		Kind:   "receiver",
+		Scheme: "phabricator",
+		URIs:   []string{"phabricator://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "hmac_key", Value: "{STRING}", Description: "One or more (comma-separated) HMAC keys, shown on the webhook's page in Phabricator, used to validate message signatures. Required.", Required: true},
+			{Name: "form_field", Value: "{NAME}", Description: "The name of a form field whose value will be used as the message body.", Required: false},
+			{Name: "form_json", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that all form fields should be encoded as a JSON dictionary.", Required: false},
+			{Name: "decompress", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that gzip and deflate encoded bodies should be decompressed. Default is true.", Required: false},
+			{Name: "max_decompressed_bytes", Value: "{INT}", Description: "The maximum number of bytes that a compressed body may be decompressed to. Default is `DEFAULT_MAX_DECOMPRESSED_BYTES`.", Required: false},
+			{Name: "verify_raw", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that message signatures should be validated using the raw (compressed) bytes of a message body. Default is false.", Required: false},
+		},
+	},
+	"receiver:registry": {
+		Kind:   "receiver",
+		Scheme: "registry",
+		URIs:   []string{"registry://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "token", Value: "{STRING}", Description: "One or more (comma-separated) valid tokens that requests must include.", Required: false},
+			{Name: "authorization", Value: "{STRING}", Description: "One or more (comma-separated) valid values for the `Authorization` header that requests must include.", Required: false},
+			{Name: "normalize", Value: "{BOOLEAN}", Description: "Return notifications as a JSON-encoded list of `RegistryEvent` instances. Default is false.", Required: false},
+			{Name: "form_field", Value: "{NAME}", Description: "The name of a form field whose value will be used as the message body.", Required: false},
+			{Name: "form_json", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that all form fields should be encoded as a JSON dictionary.", Required: false},
+			{Name: "decompress", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that gzip and deflate encoded bodies should be decompressed. Default is true.", Required: false},
+			{Name: "max_decompressed_bytes", Value: "{INT}", Description: "The maximum number of bytes that a compressed body may be decompressed to. Default is `DEFAULT_MAX_DECOMPRESSED_BYTES`.", Required: false},
+			{Name: "verify_raw", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that message signatures should be validated using the raw (compressed) bytes of a message body. Default is false.", Required: false},
+		},
+	},
+	"receiver:rubygems": {
+		Kind:   "receiver",
+		Scheme: "rubygems",
+		URIs:   []string{"rubygems://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "api_key", Value: "{STRING}", Description: "One or more (comma-separated) RubyGems.org API keys, of the account that registered the web hook, used to validate messages. Required.", Required: true},
+			{Name: "form_field", Value: "{NAME}", Description: "The name of a form field whose value will be used as the message body.", Required: false},
+			{Name: "form_json", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that all form fields should be encoded as a JSON dictionary.", Required: false},
+			{Name: "decompress", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that gzip and deflate encoded bodies should be decompressed. Default is true.", Required: false},
+			{Name: "max_decompressed_bytes", Value: "{INT}", Description: "The maximum number of bytes that a compressed body may be decompressed to. Default is `DEFAULT_MAX_DECOMPRESSED_BYTES`.", Required: false},
+			{Name: "verify_raw", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that message signatures should be validated using the raw (compressed) bytes of a message body. Default is false.", Required: false},
+		},
+	},
+	"receiver:sendgrid": {
+		Kind:   "receiver",
+		Scheme: "sendgrid",
+		URIs:   []string{"sendgrid://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "public_key", Value: "{STRING}", Description: "The (URI-escaped) base64-encoded verification key for the signed event webhook, as shown in the SendGrid settings. Required.", Required: true},
+			{Name: "max_age", Value: "{DURATION}", Description: "The maximum age of a message, derived from its timestamp. A value of \"0s\" disables this check. Default is \"5m\".", Required: false},
+			{Name: "max_skew", Value: "{DURATION}", Description: "The maximum amount of time that the timestamp of a message may be ahead of the current time. A value of \"0s\" disables this check. Default is the value of `max_age`.", Required: false},
+			{Name: "form_field", Value: "{NAME}", Description: "The name of a form field whose value will be used as the message body.", Required: false},
+			{Name: "form_json", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that all form fields should be encoded as a JSON dictionary.", Required: false},
+			{Name: "decompress", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that gzip and deflate encoded bodies should be decompressed. Default is true.", Required: false},
+			{Name: "max_decompressed_bytes", Value: "{INT}", Description: "The maximum number of bytes that a compressed body may be decompressed to. Default is `DEFAULT_MAX_DECOMPRESSED_BYTES`.", Required: false},
+			{Name: "verify_raw", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that message signatures should be validated using the raw (compressed) bytes of a message body. Default is false.", Required: false},
+		},
+	},
+	"receiver:sns": {
+		Kind:   "receiver",
+		Scheme: "sns",
+		URIs:   []string{"sns://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "topic", Value: "{ARN}", Description: "Zero or more topic ARNs that messages are allowed to be sent from. If empty messages from any topic are accepted.", Required: false},
+			{Name: "confirm", Value: "{BOOLEAN}", Description: "Confirm subscriptions automatically. Default is true if one or more `topic` parameters are present and false otherwise. Enabling it without any `topic` parameters is an error since any AWS account could subscribe the receiver to its own topics.", Required: false},
+			{Name: "unwrap", Value: "{BOOLEAN}", Description: "Return the inner payload of notifications rather than the entire SNS message. Default is true.", Required: false},
+			{Name: "timeout", Value: "{DURATION}", Description: "The amount of time to wait for requests to AWS to complete. Default is \"10s\".", Required: false},
+			{Name: "decompress", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that gzip and deflate encoded bodies should be decompressed. Default is true.", Required: false},
+			{Name: "max_decompressed_bytes", Value: "{INT}", Description: "The maximum number of bytes that a compressed body may be decompressed to. Default is `DEFAULT_MAX_DECOMPRESSED_BYTES`.", Required: false},
+			{Name: "verify_raw", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that message signatures should be validated using the raw (compressed) bytes of a message body. Default is false.", Required: false},
+		},
+	},
+	"receiver:square": {
+		Kind:   "receiver",
+		Scheme: "square",
+		URIs:   []string{"square://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "signature_key", Value: "{STRING}", Description: "One or more (comma-separated) Square webhook signature keys used to validate message signatures. Required.", Required: true},
+			{Name: "url", Value: "{URL}", Description: "The (URI-escaped) notification URL of the webhook subscription, exactly as configured in Square. Required.", Required: true},
+			{Name: "form_field", Value: "{NAME}", Description: "The name of a form field whose value will be used as the message body.", Required: false},
+			{Name: "form_json", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that all form fields should be encoded as a JSON dictionary.", Required: false},
+			{Name: "decompress", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that gzip and deflate encoded bodies should be decompressed. Default is true.", Required: false},
+			{Name: "max_decompressed_bytes", Value: "{INT}", Description: "The maximum number of bytes that a compressed body may be decompressed to. Default is `DEFAULT_MAX_DECOMPRESSED_BYTES`.", Required: false},
+			{Name: "verify_raw", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that message signatures should be validated using the raw (compressed) bytes of a message body. Default is false.", Required: false},
+		},
+	},
+	"receiver:tfc": {
+		Kind:   "receiver",
+		Scheme: "tfc",
+		URIs:   []string{"tfc://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "token", Value: "{STRING}", Description: "One or more (comma-separated) tokens, defined in the notification configuration, used to validate message signatures. Required.", Required: true},
+			{Name: "form_field", Value: "{NAME}", Description: "The name of a form field whose value will be used as the message body.", Required: false},
+			{Name: "form_json", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that all form fields should be encoded as a JSON dictionary.", Required: false},
+			{Name: "decompress", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that gzip and deflate encoded bodies should be decompressed. Default is true.", Required: false},
+			{Name: "max_decompressed_bytes", Value: "{INT}", Description: "The maximum number of bytes that a compressed body may be decompressed to. Default is `DEFAULT_MAX_DECOMPRESSED_BYTES`.", Required: false},
+			{Name: "verify_raw", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that message signatures should be validated using the raw (compressed) bytes of a message body. Default is false.", Required: false},
+		},
+	},
+	"receiver:travis": {
+		Kind:   "receiver",
+		Scheme: "travis",
+		URIs:   []string{"travis://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "public_key", Value: "{STRING}", Description: "The (URI-escaped) PEM or base64-encoded DER public key used to validate signatures. If empty the key is retrieved from the Travis CI API.", Required: false},
+			{Name: "api", Value: "{URL}", Description: "The root URL for the Travis CI API. Default is \"https://api.travis-ci.com\".", Required: false},
+			{Name: "timeout", Value: "{DURATION}", Description: "The amount of time to wait for a Travis CI API request to complete. Default is \"10s\".", Required: false},
+			{Name: "decompress", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that gzip and deflate encoded bodies should be decompressed. Default is true.", Required: false},
+			{Name: "max_decompressed_bytes", Value: "{INT}", Description: "The maximum number of bytes that a compressed body may be decompressed to. Default is `DEFAULT_MAX_DECOMPRESSED_BYTES`.", Required: false},
+			{Name: "verify_raw", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that message signatures should be validated using the raw (compressed) bytes of a message body. Default is false.", Required: false},
+		},
+	},
+	"receiver:twilio": {
+		Kind:   "receiver",
+		Scheme: "twilio",
+		URIs:   []string{"twilio://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "auth_token", Value: "{STRING}", Description: "One or more (comma-separated) Twilio auth tokens, for example the primary and secondary auth tokens for an account, used to validate request signatures. Required.", Required: true},
+			{Name: "url", Value: "{URL}", Description: "The (URI-escaped) public URL of the webhook endpoint, without a query string. If empty the URL is derived from the request and its `X-Forwarded-Proto` and `X-Forwarded-Host` headers.", Required: false},
+			{Name: "decompress", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that gzip and deflate encoded bodies should be decompressed. Default is true.", Required: false},
+			{Name: "max_decompressed_bytes", Value: "{INT}", Description: "The maximum number of bytes that a compressed body may be decompressed to. Default is `DEFAULT_MAX_DECOMPRESSED_BYTES`.", Required: false},
+			{Name: "verify_raw", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that message signatures should be validated using the raw (compressed) bytes of a message body. Default is false.", Required: false},
+		},
+	},
+	"receiver:vercel": {
+		Kind:   "receiver",
+		Scheme: "vercel",
+		URIs:   []string{"vercel://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "secret", Value: "{STRING}", Description: "One or more (comma-separated) webhook secrets used to validate message signatures. Required.", Required: true},
+			{Name: "form_field", Value: "{NAME}", Description: "The name of a form field whose value will be used as the message body.", Required: false},
+			{Name: "form_json", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that all form fields should be encoded as a JSON dictionary.", Required: false},
+			{Name: "decompress", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that gzip and deflate encoded bodies should be decompressed. Default is true.", Required: false},
+			{Name: "max_decompressed_bytes", Value: "{INT}", Description: "The maximum number of bytes that a compressed body may be decompressed to. Default is `DEFAULT_MAX_DECOMPRESSED_BYTES`.", Required: false},
+			{Name: "verify_raw", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that message signatures should be validated using the raw (compressed) bytes of a message body. Default is false.", Required: false},
+		},
+	},
+	"receiver:zendesk": {
+		Kind:   "receiver",
+		Scheme: "zendesk",
+		URIs:   []string{"zendesk://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "secret", Value: "{STRING}", Description: "One or more (comma-separated) Zendesk webhook signing secrets used to validate message signatures. Required.", Required: true},
+			{Name: "max_age", Value: "{DURATION}", Description: "The maximum age of a message, derived from its timestamp. A value of \"0s\" disables this check. Default is \"5m\".", Required: false},
+			{Name: "max_skew", Value: "{DURATION}", Description: "The maximum amount of time that the timestamp of a message may be ahead of the current time. A value of \"0s\" disables this check. Default is the value of `max_age`.", Required: false},
+			{Name: "form_field", Value: "{NAME}", Description: "The name of a form field whose value will be used as the message body.", Required: false},
+			{Name: "form_json", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that all form fields should be encoded as a JSON dictionary.", Required: false},
+			{Name: "decompress", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that gzip and deflate encoded bodies should be decompressed. Default is true.", Required: false},
+			{Name: "max_decompressed_bytes", Value: "{INT}", Description: "The maximum number of bytes that a compressed body may be decompressed to. Default is `DEFAULT_MAX_DECOMPRESSED_BYTES`.", Required: false},
+			{Name: "verify_raw", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that message signatures should be validated using the raw (compressed) bytes of a message body. Default is false.", Required: false},
+		},
+	},
+	"receiver:zoom": {
+		Kind:   "receiver",
+		Scheme: "zoom",
+		URIs:   []string{"zoom://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "secret_token", Value: "{STRING}", Description: "One or more (comma-separated) Zoom app secret tokens used to validate message signatures. The first is used to answer URL validation challenges. Required.", Required: true},
+			{Name: "max_age", Value: "{DURATION}", Description: "The maximum age of a message, derived from its timestamp. A value of \"0s\" disables this check. Default is \"5m\".", Required: false},
+			{Name: "max_skew", Value: "{DURATION}", Description: "The maximum amount of time that the timestamp of a message may be ahead of the current time. A value of \"0s\" disables this check. Default is the value of `max_age`.", Required: false},
+			{Name: "form_field", Value: "{NAME}", Description: "The name of a form field whose value will be used as the message body.", Required: false},
+			{Name: "form_json", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that all form fields should be encoded as a JSON dictionary.", Required: false},
+			{Name: "decompress", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that gzip and deflate encoded bodies should be decompressed. Default is true.", Required: false},
+			{Name: "max_decompressed_bytes", Value: "{INT}", Description: "The maximum number of bytes that a compressed body may be decompressed to. Default is `DEFAULT_MAX_DECOMPRESSED_BYTES`.", Required: false},
+			{Name: "verify_raw", Value: "{BOOLEAN}", Description: "A boolean flag to indicate that message signatures should be validated using the raw (compressed) bytes of a message body. Default is false.", Required: false},
+		},
+	},
+	"source:amqp": {
+		Kind:   "source",
+		Scheme: "amqp",
+		URIs:   []string{"amqp://[{USERNAME}:{PASSWORD}@]{HOST}[:{PORT}][/{VHOST}]?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "queue", Value: "{STRING}", Description: "The name of the queue to consume. Required.", Required: true},
+			{Name: "consumer_tag", Value: "{STRING}", Description: "The consumer tag used to identify the consumer. Default is a unique tag generated by the client.", Required: false},
+			{Name: "prefetch", Value: "{INT}", Description: "The number of unacknowledged messages the server will deliver. Default is 1.", Required: false},
+			{Name: "requeue", Value: "{BOOLEAN}", Description: "Return messages that fail to be processed to the queue. Default is true.", Required: false},
+			{Name: "heartbeat", Value: "{DURATION}", Description: "The interval for heartbeats exchanged with the server. If the server proposes a shorter interval that is used instead. Default is \"60s\".", Required: false},
+			{Name: "timeout", Value: "{DURATION}", Description: "The amount of time to wait for a connection to be established. Default is \"10s\".", Required: false},
+		},
+	},
+	"source:amqps": {
+		Kind:   "source",
+		Scheme: "amqps",
+		URIs:   []string{"amqp://[{USERNAME}:{PASSWORD}@]{HOST}[:{PORT}][/{VHOST}]?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "queue", Value: "{STRING}", Description: "The name of the queue to consume. Required.", Required: true},
+			{Name: "consumer_tag", Value: "{STRING}", Description: "The consumer tag used to identify the consumer. Default is a unique tag generated by the client.", Required: false},
+			{Name: "prefetch", Value: "{INT}", Description: "The number of unacknowledged messages the server will deliver. Default is 1.", Required: false},
+			{Name: "requeue", Value: "{BOOLEAN}", Description: "Return messages that fail to be processed to the queue. Default is true.", Required: false},
+			{Name: "heartbeat", Value: "{DURATION}", Description: "The interval for heartbeats exchanged with the server. If the server proposes a shorter interval that is used instead. Default is \"60s\".", Required: false},
+			{Name: "timeout", Value: "{DURATION}", Description: "The amount of time to wait for a connection to be established. Default is \"10s\".", Required: false},
+		},
+	},
+	"source:cron": {
+		Kind:   "source",
+		Scheme: "cron",
+		URIs:   []string{"cron://?schedule={SCHEDULE}&{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "tz", Value: "{STRING}", Description: "The IANA time zone used to evaluate the schedule. Default is \"UTC\".", Required: false},
+			{Name: "body", Value: "{STRING}", Description: "A fixed body for each message. Default is a JSON object containing the schedule and the time the message was scheduled for.", Required: false},
+		},
+	},
+	"source:fifo": {
+		Kind:   "source",
+		Scheme: "fifo",
+		URIs:   []string{"fifo://{PATH}?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "max_size", Value: "{INT}", Description: "The maximum size, in bytes, of a single line. Default is 1048576.", Required: false},
+		},
+	},
+	"source:kafka": {
+		Kind:   "source",
+		Scheme: "kafka",
+		URIs:   []string{"kafka://[{USERNAME}:{PASSWORD}@]{HOST}:{PORT}[,{HOST}:{PORT}...]/{TOPIC}?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "group", Value: "{STRING}", Description: "The consumer group to join. Partitions are balanced between the members of the group and the offsets of processed messages are committed to the group. If empty offsets are not stored and each partition is consumed starting from `offset` every time the source is started.", Required: false},
+			{Name: "partition", Value: "{INT}", Description: "Zero or more partitions to consume, without a consumer group. Default is all the partitions for the topic.", Required: false},
+			{Name: "offset", Value: "{STRING}", Description: "Where to start consuming partitions without a committed offset. Valid options are \"oldest\" and \"newest\". Default is \"newest\".", Required: false},
+			{Name: "client_id", Value: "{STRING}", Description: "The client ID sent to brokers. Default is \"webhookd\".", Required: false},
+			{Name: "tls", Value: "{BOOLEAN}", Description: "Connect to brokers using TLS. Default is false.", Required: false},
+			{Name: "timeout", Value: "{DURATION}", Description: "The amount of time to wait for a connection to a broker to be established. Default is \"10s\".", Required: false},
+			{Name: "max_wait", Value: "{DURATION}", Description: "The amount of time a broker will wait for new records before responding to a fetch request. Default is \"500ms\".", Required: false},
+			{Name: "max_bytes", Value: "{INT}", Description: "The maximum number of bytes to fetch, per partition, in a single request. Default is 1048576.", Required: false},
+		},
+	},
+	"source:mqtt": {
+		Kind:   "source",
+		Scheme: "mqtt",
+		URIs:   []string{"mqtt://[{USERNAME}[:{PASSWORD}]@]{HOST}[:{PORT}]?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "topic", Value: "{STRING}", Description: "A topic filter to subscribe to. Filters may contain the \"+\" and \"#\" wildcards, which must be URL-encoded as \"%2B\" and \"%23\". This parameter may be passed multiple times. Required.", Required: true},
+			{Name: "qos", Value: "{INT}", Description: "The maximum QoS level (0, 1 or 2) for subscriptions. Default is 1.", Required: false},
+			{Name: "client_id", Value: "{STRING}", Description: "The client identifier sent to the broker. Required if `clean_session` is false.", Required: false},
+			{Name: "clean_session", Value: "{BOOLEAN}", Description: "Discard any existing session when connecting. Default is true.", Required: false},
+			{Name: "keepalive", Value: "{DURATION}", Description: "The keep alive interval for the connection. A value of \"0s\" disables keep alives. Default is \"60s\".", Required: false},
+			{Name: "timeout", Value: "{DURATION}", Description: "The amount of time to wait for a connection to be established. Default is \"10s\".", Required: false},
+		},
+	},
+	"source:mqtts": {
+		Kind:   "source",
+		Scheme: "mqtts",
+		URIs:   []string{"mqtt://[{USERNAME}[:{PASSWORD}]@]{HOST}[:{PORT}]?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "topic", Value: "{STRING}", Description: "A topic filter to subscribe to. Filters may contain the \"+\" and \"#\" wildcards, which must be URL-encoded as \"%2B\" and \"%23\". This parameter may be passed multiple times. Required.", Required: true},
+			{Name: "qos", Value: "{INT}", Description: "The maximum QoS level (0, 1 or 2) for subscriptions. Default is 1.", Required: false},
+			{Name: "client_id", Value: "{STRING}", Description: "The client identifier sent to the broker. Required if `clean_session` is false.", Required: false},
+			{Name: "clean_session", Value: "{BOOLEAN}", Description: "Discard any existing session when connecting. Default is true.", Required: false},
+			{Name: "keepalive", Value: "{DURATION}", Description: "The keep alive interval for the connection. A value of \"0s\" disables keep alives. Default is \"60s\".", Required: false},
+			{Name: "timeout", Value: "{DURATION}", Description: "The amount of time to wait for a connection to be established. Default is \"10s\".", Required: false},
+		},
+	},
+	"source:smtp": {
+		Kind:   "source",
+		Scheme: "smtp",
+		URIs:   []string{"smtp://{HOST}:{PORT}?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "domain", Value: "{STRING}", Description: "The domain name the SMTP server identifies itself as. Default is the host of the URI.", Required: false},
+			{Name: "rcpt", Value: "{STRING}", Description: "An address, or \"@{DOMAIN}\" suffix, that messages will be accepted for. This parameter may be passed multiple times. If absent messages are accepted for all recipients.", Required: false},
+			{Name: "max_size", Value: "{INT}", Description: "The maximum size, in bytes, of a message. Default is 10485760.", Required: false},
+			{Name: "max_connections", Value: "{INT}", Description: "The maximum number of concurrent connections. Default is 100.", Required: false},
+			{Name: "timeout", Value: "{DURATION}", Description: "The amount of time to wait for a client to send a command. Default is \"5m\".", Required: false},
+			{Name: "tls_cert", Value: "{PATH}", Description: "and `tls_key={PATH}` The paths to a TLS certificate and key used to support the STARTTLS command.", Required: false},
+			{Name: "require_tls", Value: "{BOOLEAN}", Description: "Require clients to issue a STARTTLS command before sending messages. Default is false.", Required: false},
+		},
+	},
+	"source:stdin": {
+		Kind:   "source",
+		Scheme: "stdin",
+		URIs:   []string{"stdin://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "max_size", Value: "{INT}", Description: "The maximum size, in bytes, of a single line. Default is 1048576.", Required: false},
+		},
+	},
+	"transformation:bitbucketcommits": {
+		Kind:   "transformation",
+		Scheme: "bitbucketcommits",
+		URIs:   []string{"bitbucketcommits://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "exclude_additions", Value: "{BOOLEAN}", Description: "Exclude paths that were added. Default is false.", Required: false},
+			{Name: "exclude_modifications", Value: "{BOOLEAN}", Description: "Exclude paths that were modified. Default is false.", Required: false},
+			{Name: "exclude_deletions", Value: "{BOOLEAN}", Description: "Exclude paths that were removed. Default is false.", Required: false},
+			{Name: "token", Value: "{URI}", Description: "A URI-escaped `gocloud.dev/runtimevar` URI whose value is an access token used to authenticate API requests. Required for private repositories.", Required: false},
+			{Name: "api", Value: "{URL}", Description: "The root URL for the Bitbucket Cloud API. Default is \"https://api.bitbucket.org/2.0\".", Required: false},
+			{Name: "timeout", Value: "{DURATION}", Description: "The amount of time to wait for an API request to complete. Default is \"10s\".", Required: false},
+		},
+	},
+	"transformation:chicken": {
+		Kind:   "transformation",
+		Scheme: "chicken",
+		URIs:   []string{"chicken://{LANGUAGE_TAG}?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "clucking", Value: "{BOOLEAN}", Description: "A boolean flag to indicate whether messages should be transformed in the form of chicken noises.", Required: false},
+		},
+	},
+	"transformation:csv2json": {
+		Kind:   "transformation",
+		Scheme: "csv2json",
+		URIs:   []string{"csv2json://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "delimiter", Value: "{STRING}", Description: "The (URI-escaped) field delimiter. Valid options are a single character or \"tab\". Default is \",\".", Required: false},
+			{Name: "column", Value: "{STRING}", Description: "Zero or more column names. If empty then the first row of each message is used as the list of column names.", Required: false},
+			{Name: "skip_header", Value: "{BOOLEAN}", Description: "Ignore the first row of each message when `column` parameters are present. Default is false.", Required: false},
+		},
+	},
+	"transformation:dedupe": {
+		Kind:   "transformation",
+		Scheme: "dedupe",
+		URIs:   []string{"dedupe://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "store", Value: "{URI}", Description: "A URI-escaped `dedupe.Store` URI used to record message hashes. Default is \"memory://\".", Required: false},
+			{Name: "path", Value: "{PATH}", Description: "Zero or more (dot-separated) paths used to derive message hashes. If empty then the entire message body is hashed.", Required: false},
+			{Name: "ttl", Value: "{DURATION}", Description: "The amount of time that message hashes are recorded. Default is \"1h\".", Required: false},
+		},
+	},
+	"transformation:encrypt": {
+		Kind:   "transformation",
+		Scheme: "encrypt",
+		URIs:   []string{"encrypt://{SCHEME}?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "key", Value: "{URI}", Description: "A URI-escaped `gocloud.dev/runtimevar` URI whose value is the base64-encoded AES key or X25519 public key. Required.", Required: true},
+			{Name: "encoding", Value: "{STRING}", Description: "The encoding applied to encrypted messages. Valid options are \"raw\" and \"base64\". Default is \"raw\".", Required: false},
+		},
+	},
+	"transformation:githubpush": {
+		Kind:   "transformation",
+		Scheme: "githubpush",
+		URIs:   []string{"githubpush://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "branch", Value: "{GLOB}", Description: "Zero or more branch name patterns, for example \"main\" or \"release/*\".", Required: false},
+			{Name: "include_paths", Value: "{GLOB}", Description: "Zero or more (comma-separated) path patterns, for example \"deploy/**\".", Required: false},
+			{Name: "exclude_paths", Value: "{GLOB}", Description: "Zero or more (comma-separated) path patterns, for example \"**/*.md\".", Required: false},
+		},
+	},
+	"transformation:gitlabcommits": {
+		Kind:   "transformation",
+		Scheme: "gitlabcommits",
+		URIs:   []string{"gitlabcommits://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "exclude_additions", Value: "{BOOLEAN}", Description: "Exclude paths that were added. Default is false.", Required: false},
+			{Name: "exclude_modifications", Value: "{BOOLEAN}", Description: "Exclude paths that were modified. Default is false.", Required: false},
+			{Name: "exclude_deletions", Value: "{BOOLEAN}", Description: "Exclude paths that were removed. Default is false.", Required: false},
+		},
+	},
+	"transformation:json2proto": {
+		Kind:   "transformation",
+		Scheme: "json2proto",
+		URIs:   []string{"json2proto://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "descriptor", Value: "{PATH}", Description: "The path to a binary-encoded `FileDescriptorSet` file containing the message type. Required.", Required: true},
+			{Name: "message", Value: "{STRING}", Description: "The fully-qualified name of the message type, for example \"acme.events.v1.OrderCreated\". Required.", Required: true},
+			{Name: "discard_unknown", Value: "{BOOLEAN}", Description: "Ignore unknown JSON properties rather than reporting them as errors. Default is false.", Required: false},
+		},
+	},
+	"transformation:json2xml": {
+		Kind:   "transformation",
+		Scheme: "json2xml",
+		URIs:   []string{"json2xml://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "attribute_prefix", Value: "{STRING}", Description: "The prefix for JSON properties that should be converted to XML attributes. Default is \"@\".", Required: false},
+			{Name: "text_property", Value: "{STRING}", Description: "The JSON property that should be converted to the text content of an XML element. Default is \"#text\".", Required: false},
+			{Name: "root", Value: "{STRING}", Description: "The name of the root element for JSON documents that don't have exactly one top-level property. Default is \"root\".", Required: false},
+			{Name: "declaration", Value: "{BOOLEAN}", Description: "Include an XML declaration in the output. Default is true.", Required: false},
+		},
+	},
+	"transformation:jsonschema": {
+		Kind:   "transformation",
+		Scheme: "jsonschema",
+		URIs:   []string{"jsonschema://{PATH}?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "on_invalid", Value: "{STRING}", Description: "The action to take for invalid messages. Valid options are \"reject\", \"drop\" and \"annotate\". Default is \"reject\".", Required: false},
+			{Name: "property", Value: "{STRING}", Description: "The (top-level) property used to store validation errors when `on_invalid=annotate`. Default is \"webhookd_validation_errors\".", Required: false},
+		},
+	},
+	"transformation:lookup": {
+		Kind:   "transformation",
+		Scheme: "lookup",
+		URIs:   []string{"lookup://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "url", Value: "{TEMPLATE}", Description: "A URI-escaped Go language `text/template` string used to derive the URL for each lookup. Required. Templates are passed a `LookupTemplateData` instance and may use the `path`, `query` and `pathescape` functions.", Required: true},
+			{Name: "header", Value: "{NAME}:{VALUE}", Description: "Zero or more HTTP headers to send with each lookup request.", Required: false},
+			{Name: "property", Value: "{STRING}", Description: "The (top-level) property used to store the results of a lookup. Default is \"lookup\".", Required: false},
+			{Name: "ttl", Value: "{DURATION}", Description: "The amount of time that lookup results are cached. A value of \"0s\" disables caching. Default is \"5m\".", Required: false},
+			{Name: "cache_size", Value: "{INT}", Description: "The maximum number of cached lookup results. Default is 1000.", Required: false},
+			{Name: "timeout", Value: "{DURATION}", Description: "The amount of time to wait for a lookup request to complete. Default is \"5s\".", Required: false},
+			{Name: "required", Value: "{BOOLEAN}", Description: "A boolean flag signaling that failed lookups should be reported as errors rather than leaving the message unaltered. Default is false.", Required: false},
+		},
+	},
+	"transformation:null": {
+		Kind:   "transformation",
+		Scheme: "null",
+		URIs:   []string{"null://"},
+	},
+	"transformation:proto2json": {
+		Kind:   "transformation",
+		Scheme: "proto2json",
+		URIs:   []string{"proto2json://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "descriptor", Value: "{PATH}", Description: "The path to a binary-encoded `FileDescriptorSet` file containing the message type. Required.", Required: true},
+			{Name: "message", Value: "{STRING}", Description: "The fully-qualified name of the message type, for example \"acme.events.v1.OrderCreated\". Required.", Required: true},
+			{Name: "use_proto_names", Value: "{BOOLEAN}", Description: "Use the field names defined in the .proto file rather than their lowerCamelCase equivalents. Default is false.", Required: false},
+			{Name: "emit_defaults", Value: "{BOOLEAN}", Description: "Include fields with default values in the output. Default is false.", Required: false},
+			{Name: "discard_unknown", Value: "{BOOLEAN}", Description: "Ignore unknown fields rather than reporting them as errors. Default is false.", Required: false},
+		},
+	},
+	"transformation:redact": {
+		Kind:   "transformation",
+		Scheme: "redact",
+		URIs:   []string{"redact://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "path", Value: "{PATH}", Description: "Zero or more (dot-separated) paths to redact. Path segments may be \"*\" to match every key in a dictionary or item in a list.", Required: false},
+			{Name: "pattern", Value: "{NAME|REGEXP}", Description: "Zero or more regular expressions, or the names of built-in patterns (\"email\", \"credit_card\" or \"token\"), whose matches will be masked in every string value.", Required: false},
+			{Name: "mode", Value: "{STRING}", Description: "How to redact paths. Valid options are \"mask\" and \"remove\". Default is \"mask\".", Required: false},
+			{Name: "mask", Value: "{STRING}", Description: "The string used to replace redacted values. Default is \"[REDACTED]\".", Required: false},
+		},
+	},
+	"transformation:regexp": {
+		Kind:   "transformation",
+		Scheme: "regexp",
+		URIs:   []string{"regexp://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "pattern", Value: "{REGEXP}", Description: "One or more URI-escaped regular expressions to find. Required.", Required: true},
+			{Name: "replacement", Value: "{STRING}", Description: "The URI-escaped replacement for each `pattern` parameter, in the same order. Replacements may refer to capture groups using the `$1` or `${name}` syntax. Required.", Required: true},
+		},
+	},
+	"transformation:sender": {
+		Kind:   "transformation",
+		Scheme: "sender",
+		URIs:   []string{"sender://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "geoip", Value: "{PATH}", Description: "Zero or more paths to MaxMind DB files, for example GeoLite2-Country.mmdb and GeoLite2-ASN.mmdb.", Required: false},
+			{Name: "rdns", Value: "{BOOLEAN}", Description: "A boolean flag signaling that reverse DNS lookups should be performed. Default is false.", Required: false},
+			{Name: "timeout", Value: "{DURATION}", Description: "The amount of time to wait for reverse DNS lookups. Default is \"1s\".", Required: false},
+			{Name: "property", Value: "{STRING}", Description: "The (top-level) property used to store information about the sender. Default is \"webhookd_sender\".", Required: false},
+		},
+	},
+	"transformation:sign": {
+		Kind:   "transformation",
+		Scheme: "sign",
+		URIs:   []string{"sign://{ALGORITHM}?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "key", Value: "{URI}", Description: "A URI-escaped `gocloud.dev/runtimevar` URI whose value is the shared secret for HMAC algorithms or the base64-encoded Ed25519 private key (or 32-byte seed). Required.", Required: true},
+			{Name: "mode", Value: "{STRING}", Description: "How to attach signatures to messages. Valid options are \"header\" and \"body\". Default is \"header\".", Required: false},
+			{Name: "header", Value: "{STRING}", Description: "The HTTP header used to attach signatures in the \"header\" mode. Default is \"X-Webhookd-Signature\".", Required: false},
+			{Name: "encoding", Value: "{STRING}", Description: "The encoding used for signatures. Valid options are \"hex\" and \"base64\". Default is \"hex\".", Required: false},
+		},
+	},
+	"transformation:split": {
+		Kind:   "transformation",
+		Scheme: "split",
+		URIs:   []string{"split://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "path", Value: "{PATH}", Description: "The (dot-separated) path of the list to split, for example \"commits\". Required.", Required: true},
+			{Name: "include", Value: "{PATH}", Description: "Zero or more (dot-separated) paths in the original message whose values will be added to each item, keyed by path. Items must be JSON objects in order to include values.", Required: false},
+		},
+	},
+	"transformation:truncate": {
+		Kind:   "transformation",
+		Scheme: "truncate",
+		URIs:   []string{"truncate://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "size", Value: "{INT}", Description: "The maximum size of a message in bytes. Required.", Required: true},
+			{Name: "mode", Value: "{STRING}", Description: "How to handle messages that are too large. Valid options are \"truncate\", \"remove\" and \"reject\". Default is \"truncate\".", Required: false},
+			{Name: "marker", Value: "{STRING}", Description: "The string appended to truncated messages. It counts towards the maximum size. Default is \"…[truncated]\".", Required: false},
+			{Name: "path", Value: "{PATH}", Description: "One or more (dot-separated) paths to remove, in order, from JSON-encoded messages that are too large. Path segments may be \"*\" to match every key in a dictionary or item in a list. Required if `mode` is \"remove\".", Required: false},
+		},
+	},
+	"transformation:xml2json": {
+		Kind:   "transformation",
+		Scheme: "xml2json",
+		URIs:   []string{"xml2json://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "attribute_prefix", Value: "{STRING}", Description: "The prefix for JSON properties derived from XML attributes. Default is \"@\".", Required: false},
+			{Name: "text_property", Value: "{STRING}", Description: "The JSON property for the text content of XML elements that also have attributes or children. Default is \"#text\".", Required: false},
+		},
+	},
+	"dispatcher:batch": {
+		Kind:   "dispatcher",
+		Scheme: "batch",
+		URIs:   []string{"batch://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "dispatcher", Value: "{URI}", Description: "The URI-escaped URI of the dispatcher that batches will be relayed to. Required.", Required: true},
+			{Name: "size", Value: "{INT}", Description: "The maximum number of messages in a batch. Default is 100.", Required: false},
+			{Name: "interval", Value: "{DURATION}", Description: "The maximum amount of time a message will wait before its batch is flushed, for example \"30s\". Default is \"10s\".", Required: false},
+			{Name: "format", Value: "{STRING}", Description: "The encoding used for batches. Valid options are \"json\" (a JSON-encoded list) and \"ndjson\" (newline-delimited JSON). Default is \"json\".", Required: false},
+		},
+	},
+	"dispatcher:capture": {
+		Kind:   "dispatcher",
+		Scheme: "capture",
+		URIs:   []string{"capture://{NAME}?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "max", Value: "{INT}", Description: "The maximum number of messages kept in the store, after which the oldest messages are discarded. The most recent value for a store wins. Default is 1000.", Required: false},
+		},
+	},
+	"dispatcher:chaos": {
+		Kind:   "dispatcher",
+		Scheme: "chaos",
+		URIs:   []string{"chaos://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "dispatcher", Value: "{URI}", Description: "The URI-escaped URI of the dispatcher that messages will be relayed to. Required.", Required: true},
+			{Name: "latency", Value: "{DURATION}", Description: "The amount of time to wait before relaying a message, for example \"500ms\".", Required: false},
+			{Name: "jitter", Value: "{DURATION}", Description: "The maximum amount of random time added to `latency`, for example \"250ms\".", Required: false},
+			{Name: "latency_rate", Value: "{FLOAT}", Description: "The probability, between 0 and 1, that latency is injected for a message. Default is 1.", Required: false},
+			{Name: "error_rate", Value: "{FLOAT}", Description: "The probability, between 0 and 1, that an error is returned instead of relaying a message. Default is 0.", Required: false},
+			{Name: "error_code", Value: "{INT}", Description: "The status code of injected errors. Default is 503.", Required: false},
+			{Name: "seed", Value: "{INT}", Description: "An optional seed used to decide whether to inject latency or errors, for reproducible runs.", Required: false},
+		},
+	},
+	"dispatcher:delay": {
+		Kind:   "dispatcher",
+		Scheme: "delay",
+		URIs:   []string{"delay://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "dispatcher", Value: "{URI}", Description: "The URI-escaped URI of the dispatcher that messages will be relayed to. Required.", Required: true},
+			{Name: "delay", Value: "{DURATION}", Description: "The minimum amount of time to wait before relaying a message, for example \"10m\".", Required: false},
+			{Name: "days", Value: "{DAYS}", Description: "An optional comma-separated list of days, or ranges of days, during which messages may be relayed, for example \"mon-fri\".", Required: false},
+			{Name: "hours", Value: "{HH:MM-HH:MM}", Description: "An optional range of hours during which messages may be relayed, for example \"09:00-17:00\".", Required: false},
+			{Name: "timezone", Value: "{TZ}", Description: "The timezone used to interpret `days` and `hours`. Default is \"UTC\".", Required: false},
+			{Name: "spool", Value: "{PATH}", Description: "An optional directory where messages waiting to be relayed are persisted so that they survive a restart.", Required: false},
+		},
+	},
+	"dispatcher:file": {
+		Kind:   "dispatcher",
+		Scheme: "file",
+		URIs:   []string{"file://{PATH}?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "extension", Value: "{STRING}", Description: "An optional file extension to append to each message filename. Default is none.", Required: false},
+		},
+	},
+	"dispatcher:http": {
+		Kind:   "dispatcher",
+		Scheme: "http",
+		URIs:   []string{"http://"},
+	},
+	"dispatcher:https": {
+		Kind:   "dispatcher",
+		Scheme: "https",
+		URIs:   []string{"https://"},
+	},
+	"dispatcher:log": {
+		Kind:   "dispatcher",
+		Scheme: "log",
+		URIs:   []string{"log://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "level", Value: "{STRING}", Description: "The level to dispatch messages at. Valid options are \"debug\", \"info\", \"warning\" and \"error\". Messages below the minimum level assigned to the `aaronland/go-log` package are not emitted. If empty messages are always emitted without a level prefix.", Required: false},
+		},
+	},
+	"dispatcher:null": {
+		Kind:   "dispatcher",
+		Scheme: "null",
+		URIs:   []string{"null://"},
+	},
+	"dispatcher:singleton": {
+		Kind:   "dispatcher",
+		Scheme: "singleton",
+		URIs:   []string{"singleton://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "dispatcher", Value: "{URI}", Description: "The URI-escaped URI of the dispatcher that messages will be relayed to. Required.", Required: true},
+			{Name: "election", Value: "{URI}", Description: "The URI-escaped URI of the `cluster.Elector` used to elect a leader. Default is \"memory://\".", Required: false},
+			{Name: "name", Value: "{STRING}", Description: "The name that processes campaign for leadership of. Default is derived from the (unescaped) `dispatcher` URI.", Required: false},
+		},
+	},
+	"dispatcher:tee": {
+		Kind:   "dispatcher",
+		Scheme: "tee",
+		URIs:   []string{"tee://?{PARAMETERS}"},
+		Parameters: []*Parameter{
+			{Name: "dispatcher", Value: "{URI}", Description: "The URI-escaped URI of the dispatcher that messages will be relayed to. Required.", Required: true},
+			{Name: "sink", Value: "{URI}", Description: "The URI-escaped URI of the dispatcher that messages will be copied to. Default is \"log://?level=debug\".", Required: false},
+			{Name: "sink_only", Value: "{BOOLEAN}", Description: "Only copy messages to the sink without relaying them to `dispatcher`. Default is false.", Required: false},
+		},
+	},
+}
@@ -0,0 +1,73 @@
+// generate is a command line tool to derive descriptions of the components in a go-webhookd source tree from the doc comments
+// of their constructors and write them to a Go source file for the components package.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+
+	"github.com/whosonfirst/go-webhookd/v3/components/internal/parse"
+)
+
+func main() {
+
+	root := flag.String("root", ".", "The root of the go-webhookd source tree.")
+	output := flag.String("output", "descriptions.go", "The path to write descriptions to.")
+
+	flag.Parse()
+
+	descriptions, err := parse.ParseTree(*root)
+
+	if err != nil {
+		log.Fatalf("Failed to parse components, %v", err)
+	}
+
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "// Code generated by internal/generate from the doc comments of component constructors. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package components\n\n")
+	fmt.Fprintf(&buf, "// descriptions is a dictionary of `Component` instances for the components in this package, keyed by kind and scheme.\n")
+	fmt.Fprintf(&buf, "var descriptions = map[string]*Component{\n")
+
+	for _, d := range descriptions {
+
+		fmt.Fprintf(&buf, "%q: {\n", d.Kind+":"+d.Scheme)
+		fmt.Fprintf(&buf, "Kind: %q,\n", d.Kind)
+		fmt.Fprintf(&buf, "Scheme: %q,\n", d.Scheme)
+
+		if len(d.URIs) > 0 {
+			fmt.Fprintf(&buf, "URIs: %#v,\n", d.URIs)
+		}
+
+		if len(d.Parameters) > 0 {
+
+			fmt.Fprintf(&buf, "Parameters: []*Parameter{\n")
+
+			for _, p := range d.Parameters {
+				fmt.Fprintf(&buf, "{Name: %q, Value: %q, Description: %q, Required: %t},\n", p.Name, p.Value, p.Description, p.Required)
+			}
+
+			fmt.Fprintf(&buf, "},\n")
+		}
+
+		fmt.Fprintf(&buf, "},\n")
+	}
+
+	fmt.Fprintf(&buf, "}\n")
+
+	body, err := format.Source(buf.Bytes())
+
+	if err != nil {
+		log.Fatalf("Failed to format descriptions, %v", err)
+	}
+
+	err = os.WriteFile(*output, body, 0644)
+
+	if err != nil {
+		log.Fatalf("Failed to write %s, %v", *output, err)
+	}
+}
@@ -0,0 +1,510 @@
+// Package parse derives descriptions of the components (receivers, sources, transformations and dispatchers) in a package from
+// the doc comments of the constructors they are registered with.
+package parse
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// re_parameter matches a parameter in a constructor's doc comment, for example "* `secret={STRING}` A secret. Required."
+var re_parameter = regexp.MustCompile("^\\* `([A-Za-z0-9_.\\-]+)=([^`]*)`\\s*(.*)$")
+
+// re_include matches a reference to the parameters of another function in a constructor's doc comment, for example "* Any of
+// the parameters supported by `NewBodyOptionsFromQuery`, excluding `form_field`."
+var re_include = regexp.MustCompile("^\\* Any of the parameters supported by `([A-Za-z0-9_]+)`(.*)$")
+
+// re_required matches the sentence "Required." in the description of a parameter.
+var re_required = regexp.MustCompile(`(^|\. )Required\.( |$)`)
+
+// re_quoted matches backtick-quoted names.
+var re_quoted = regexp.MustCompile("`([A-Za-z0-9_.\\-]+)`")
+
+// Parameter is a URI parameter accepted by a component.
+type Parameter struct {
+	// Name is the name of the query parameter.
+	Name string
+	// Value is a description of the expected value, for example "{STRING}".
+	Value string
+	// Description is the description of the parameter.
+	Description string
+	// Required is a boolean flag signaling that the parameter is required.
+	Required bool
+}
+
+// Description describes a component and the URIs it is configured by.
+type Description struct {
+	// Kind is the kind of component, for example "receiver".
+	Kind string
+	// Scheme is the URI scheme the component is registered with.
+	Scheme string
+	// URIs is the list of URI templates the component is configured by.
+	URIs []string
+	// Parameters is the list of URI parameters accepted by the component.
+	Parameters []*Parameter
+}
+
+// registration is a call to register a constructor for a URI scheme.
+type registration struct {
+	scheme      string
+	constructor string
+}
+
+// ParsePackage parses the (non-test) Go files in 'dir' and returns a `Description` for each URI scheme registered, by calls to
+// 'register' in `init` functions, with a constructor. Schemes may be string literals or the elements of (or keys of) slice or map
+// literals that are ranged over. Descriptions are sorted by scheme.
+func ParsePackage(dir string, kind string, register string) ([]*Description, error) {
+
+	fset := token.NewFileSet()
+
+	filter := func(info fs.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}
+
+	pkgs, err := parser.ParseDir(fset, dir, filter, parser.ParseComments)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse '%s', %w", dir, err)
+	}
+
+	funcs := make(map[string]*ast.FuncDecl)
+	vars := make(map[string]ast.Expr)
+	inits := make([]*ast.FuncDecl, 0)
+
+	for _, pkg := range pkgs {
+
+		for _, f := range pkg.Files {
+
+			for _, decl := range f.Decls {
+
+				switch d := decl.(type) {
+				case *ast.FuncDecl:
+
+					if d.Recv != nil {
+						continue
+					}
+
+					if d.Name.Name == "init" {
+						inits = append(inits, d)
+						continue
+					}
+
+					funcs[d.Name.Name] = d
+
+				case *ast.GenDecl:
+
+					for _, spec := range d.Specs {
+
+						vs, ok := spec.(*ast.ValueSpec)
+
+						if !ok {
+							continue
+						}
+
+						for i, name := range vs.Names {
+
+							if i < len(vs.Values) {
+								vars[name.Name] = vs.Values[i]
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+
+	registrations := make([]*registration, 0)
+
+	for _, fn := range inits {
+
+		r, err := registrationsForInit(fn, register, vars)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to derive registrations in '%s', %w", dir, err)
+		}
+
+		registrations = append(registrations, r...)
+	}
+
+	descriptions := make([]*Description, 0)
+	seen := make(map[string]bool)
+
+	for _, r := range registrations {
+
+		if seen[r.scheme] {
+			return nil, fmt.Errorf("Scheme '%s' is registered more than once in '%s'", r.scheme, dir)
+		}
+
+		seen[r.scheme] = true
+
+		fn, ok := funcs[r.constructor]
+
+		if !ok {
+			return nil, fmt.Errorf("Failed to find constructor '%s' for scheme '%s' in '%s'", r.constructor, r.scheme, dir)
+		}
+
+		params, err := parameters(fn, funcs, 0)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to derive parameters for '%s', %w", r.constructor, err)
+		}
+
+		d := &Description{
+			Kind:       kind,
+			Scheme:     r.scheme,
+			URIs:       uris(fn, r.scheme),
+			Parameters: params,
+		}
+
+		descriptions = append(descriptions, d)
+	}
+
+	sort.Slice(descriptions, func(i, j int) bool {
+		return descriptions[i].Scheme < descriptions[j].Scheme
+	})
+
+	return descriptions, nil
+}
+
+// registrationsForInit returns the list of calls to 'register' in the `init` function 'fn'. 'vars' is a dictionary of package-level
+// variables used to resolve the values ranged over by loops.
+func registrationsForInit(fn *ast.FuncDecl, register string, vars map[string]ast.Expr) ([]*registration, error) {
+
+	// The list of schemes for calls to 'register' whose scheme is the key or value of an enclosing range statement
+
+	ranged := make(map[*ast.CallExpr][]string)
+
+	var err error
+
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+
+		rs, ok := n.(*ast.RangeStmt)
+
+		if !ok || err != nil {
+			return true
+		}
+
+		x := rs.X
+
+		if id, ok := x.(*ast.Ident); ok {
+			x = vars[id.Name]
+		}
+
+		lit, ok := x.(*ast.CompositeLit)
+
+		if !ok {
+			return true
+		}
+
+		keys := make([]string, 0)
+		values := make([]string, 0)
+
+		for _, el := range lit.Elts {
+
+			switch e := el.(type) {
+			case *ast.KeyValueExpr:
+
+				k, err2 := stringLiteral(e.Key)
+
+				if err2 != nil {
+					err = err2
+					return false
+				}
+
+				keys = append(keys, k)
+
+			default:
+
+				v, err2 := stringLiteral(e)
+
+				if err2 != nil {
+					err = err2
+					return false
+				}
+
+				values = append(values, v)
+			}
+		}
+
+		var name string
+		var schemes []string
+
+		if _, is_map := lit.Type.(*ast.MapType); is_map || len(keys) > 0 {
+
+			if id, ok := rs.Key.(*ast.Ident); ok {
+				name = id.Name
+				schemes = keys
+			}
+
+		} else if id, ok := rs.Value.(*ast.Ident); ok {
+			name = id.Name
+			schemes = values
+		}
+
+		if name == "" {
+			return true
+		}
+
+		// Nested range statements are inspected after their parents so the innermost one wins
+
+		ast.Inspect(rs.Body, func(n ast.Node) bool {
+
+			call, ok := n.(*ast.CallExpr)
+
+			if !ok || len(call.Args) != 3 {
+				return true
+			}
+
+			if id, ok := call.Args[1].(*ast.Ident); ok && id.Name == name {
+				ranged[call] = schemes
+			}
+
+			return true
+		})
+
+		return true
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	registrations := make([]*registration, 0)
+
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+
+		call, ok := n.(*ast.CallExpr)
+
+		if !ok || err != nil {
+			return true
+		}
+
+		id, ok := call.Fun.(*ast.Ident)
+
+		if !ok || id.Name != register || len(call.Args) != 3 {
+			return true
+		}
+
+		constructor, ok := call.Args[2].(*ast.Ident)
+
+		if !ok {
+			err = fmt.Errorf("Unsupported constructor for call to %s", register)
+			return false
+		}
+
+		var schemes []string
+
+		switch a := call.Args[1].(type) {
+		case *ast.BasicLit:
+
+			s, err2 := stringLiteral(a)
+
+			if err2 != nil {
+				err = err2
+				return false
+			}
+
+			schemes = []string{s}
+
+		case *ast.Ident:
+
+			v, ok := ranged[call]
+
+			if !ok {
+				err = fmt.Errorf("Failed to resolve scheme '%s' for %s", a.Name, constructor.Name)
+				return false
+			}
+
+			schemes = v
+
+		default:
+			err = fmt.Errorf("Unsupported scheme for %s", constructor.Name)
+			return false
+		}
+
+		for _, s := range schemes {
+			registrations = append(registrations, &registration{scheme: s, constructor: constructor.Name})
+		}
+
+		return true
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return registrations, nil
+}
+
+// stringLiteral returns the value of the string literal 'e'.
+func stringLiteral(e ast.Expr) (string, error) {
+
+	lit, ok := e.(*ast.BasicLit)
+
+	if !ok || lit.Kind != token.STRING {
+		return "", fmt.Errorf("Expected string literal")
+	}
+
+	return strconv.Unquote(lit.Value)
+}
+
+// uris returns the URI templates, the indented lines, in the doc comment for 'fn' that start with 'scheme' or, if there are none,
+// all of them.
+func uris(fn *ast.FuncDecl, scheme string) []string {
+
+	all := make([]string, 0)
+	matches := make([]string, 0)
+
+	for _, ln := range docLines(fn) {
+
+		if !strings.HasPrefix(ln, "\t") {
+			continue
+		}
+
+		ln = strings.TrimSpace(ln)
+
+		if ln == "" {
+			continue
+		}
+
+		all = append(all, ln)
+
+		if strings.HasPrefix(ln, scheme+"://") || strings.HasPrefix(ln, scheme+":") {
+			matches = append(matches, ln)
+		}
+	}
+
+	if len(matches) > 0 {
+		return matches
+	}
+
+	return all
+}
+
+// parameters returns the list of parameters in the doc comment for 'fn', including the parameters of any functions it refers to
+// (see `re_include`) which are looked up in 'funcs'.
+func parameters(fn *ast.FuncDecl, funcs map[string]*ast.FuncDecl, depth int) ([]*Parameter, error) {
+
+	if depth > 4 {
+		return nil, fmt.Errorf("Too many nested references to parameters in '%s'", fn.Name.Name)
+	}
+
+	params := make([]*Parameter, 0)
+
+	var current *Parameter
+
+	for _, ln := range docLines(fn) {
+
+		if m := re_parameter.FindStringSubmatch(ln); m != nil {
+
+			current = &Parameter{
+				Name:        m[1],
+				Value:       m[2],
+				Description: strings.TrimSpace(m[3]),
+			}
+
+			params = append(params, current)
+			continue
+		}
+
+		if m := re_include.FindStringSubmatch(ln); m != nil {
+
+			current = nil
+
+			other, ok := funcs[m[1]]
+
+			if !ok {
+				return nil, fmt.Errorf("Failed to find '%s' referred to by '%s'", m[1], fn.Name.Name)
+			}
+
+			other_params, err := parameters(other, funcs, depth+1)
+
+			if err != nil {
+				return nil, err
+			}
+
+			excluded := make(map[string]bool)
+
+			if strings.Contains(m[2], "excluding") {
+
+				for _, q := range re_quoted.FindAllStringSubmatch(m[2], -1) {
+					excluded[q[1]] = true
+				}
+			}
+
+			for _, p := range other_params {
+
+				if !excluded[p.Name] {
+					params = append(params, p)
+				}
+			}
+
+			continue
+		}
+
+		// Parameter descriptions may continue on the following lines, up to the next list item or blank line
+
+		if current != nil && strings.TrimSpace(ln) != "" && !strings.HasPrefix(ln, "* ") && !strings.HasPrefix(ln, "\t") {
+			current.Description = current.Description + " " + strings.TrimSpace(ln)
+			continue
+		}
+
+		current = nil
+	}
+
+	for _, p := range params {
+		p.Required = re_required.MatchString(p.Description)
+	}
+
+	return params, nil
+}
+
+// docLines returns the lines of the doc comment for 'fn'.
+func docLines(fn *ast.FuncDecl) []string {
+
+	if fn.Doc == nil {
+		return nil
+	}
+
+	return strings.Split(fn.Doc.Text(), "\n")
+}
+
+// packages is the list of component packages, relative to the root of the go-webhookd source tree, and the names of the
+// functions used to register their components, by kind.
+var packages = []struct {
+	kind     string
+	register string
+}{
+	{"receiver", "RegisterReceiver"},
+	{"source", "RegisterSource"},
+	{"transformation", "RegisterTransformation"},
+	{"dispatcher", "RegisterDispatcher"},
+}
+
+// ParseTree returns a `Description` for each of the components in the receiver, source, transformation and dispatcher packages
+// of the go-webhookd source tree in 'root', sorted by kind (in that order) and scheme.
+func ParseTree(root string) ([]*Description, error) {
+
+	descriptions := make([]*Description, 0)
+
+	for _, p := range packages {
+
+		d, err := ParsePackage(filepath.Join(root, p.kind), p.kind, p.register)
+
+		if err != nil {
+			return nil, err
+		}
+
+		descriptions = append(descriptions, d...)
+	}
+
+	return descriptions, nil
+}
@@ -0,0 +1,145 @@
+package parse
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const example_source = `package example
+
+import (
+	"context"
+)
+
+var schemes = map[string]string{
+	"alpha": "A",
+	"beta":  "B",
+}
+
+func init() {
+
+	ctx := context.Background()
+
+	err := RegisterExample(ctx, "gamma", NewGammaExample)
+
+	if err != nil {
+		panic(err)
+	}
+
+	for scheme := range schemes {
+		RegisterExample(ctx, scheme, NewAlphaExample)
+	}
+
+	for _, scheme := range []string{"delta", "deltas"} {
+		RegisterExample(ctx, scheme, NewDeltaExample)
+	}
+}
+
+// NewAlphaExample returns a new example configured by 'uri' in the form of:
+//
+//	alpha://?{PARAMETERS}
+//	beta://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * ` + "`secret={STRING}`" + ` A secret used to validate messages. Required.
+// * Any of the parameters supported by ` + "`NewOptions`, excluding `skip`" + `.
+func NewAlphaExample(ctx context.Context, uri string) (interface{}, error) {
+	return nil, nil
+}
+
+// NewGammaExample returns a new example configured by 'uri' in the form of:
+//
+//	gamma://
+func NewGammaExample(ctx context.Context, uri string) (interface{}, error) {
+	return nil, nil
+}
+
+// NewDeltaExample returns a new example configured by 'uri' in the form of:
+//
+//	delta://{HOST}?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * ` + "`timeout={DURATION}`" + ` The amount of time to wait. This description
+// continues on the next line. Default is "10s".
+// * ` + "`client_id={STRING}`" + ` The client identifier. Required if ` + "`clean`" + ` is false.
+//
+// Use the deltas:// scheme to connect using TLS.
+func NewDeltaExample(ctx context.Context, uri string) (interface{}, error) {
+	return nil, nil
+}
+
+// NewOptions returns options derived from 'q'. Valid parameters are:
+// * ` + "`skip={BOOLEAN}`" + ` Skip something.
+// * ` + "`verbose={BOOLEAN}`" + ` Be verbose. Default is false.
+func NewOptions() {}
+`
+
+func TestParsePackage(t *testing.T) {
+
+	dir := t.TempDir()
+
+	err := os.WriteFile(filepath.Join(dir, "example.go"), []byte(example_source), 0644)
+
+	if err != nil {
+		t.Fatalf("Failed to write example source, %v", err)
+	}
+
+	descriptions, err := ParsePackage(dir, "example", "RegisterExample")
+
+	if err != nil {
+		t.Fatalf("Failed to parse package, %v", err)
+	}
+
+	alpha_params := []*Parameter{
+		{Name: "secret", Value: "{STRING}", Description: "A secret used to validate messages. Required.", Required: true},
+		{Name: "verbose", Value: "{BOOLEAN}", Description: "Be verbose. Default is false."},
+	}
+
+	delta_params := []*Parameter{
+		{Name: "timeout", Value: "{DURATION}", Description: `The amount of time to wait. This description continues on the next line. Default is "10s".`},
+		{Name: "client_id", Value: "{STRING}", Description: "The client identifier. Required if `clean` is false."},
+	}
+
+	expected := []*Description{
+		{Kind: "example", Scheme: "alpha", URIs: []string{"alpha://?{PARAMETERS}"}, Parameters: alpha_params},
+		{Kind: "example", Scheme: "beta", URIs: []string{"beta://?{PARAMETERS}"}, Parameters: alpha_params},
+		{Kind: "example", Scheme: "delta", URIs: []string{"delta://{HOST}?{PARAMETERS}"}, Parameters: delta_params},
+		{Kind: "example", Scheme: "deltas", URIs: []string{"delta://{HOST}?{PARAMETERS}"}, Parameters: delta_params},
+		{Kind: "example", Scheme: "gamma", URIs: []string{"gamma://"}, Parameters: []*Parameter{}},
+	}
+
+	if len(descriptions) != len(expected) {
+		t.Fatalf("Expected %d descriptions but got %d", len(expected), len(descriptions))
+	}
+
+	for i, d := range descriptions {
+
+		if !reflect.DeepEqual(d, expected[i]) {
+			t.Fatalf("Unexpected description for '%s': %#v", d.Scheme, d)
+		}
+	}
+}
+
+func TestParseTree(t *testing.T) {
+
+	descriptions, err := ParseTree("../../..")
+
+	if err != nil {
+		t.Fatalf("Failed to parse tree, %v", err)
+	}
+
+	kinds := make(map[string]int)
+
+	for _, d := range descriptions {
+		kinds[d.Kind] += 1
+	}
+
+	for _, p := range packages {
+
+		if kinds[p.kind] == 0 {
+			t.Fatalf("Expected at least one %s", p.kind)
+		}
+	}
+}
@@ -7,27 +7,131 @@ import (
 	"fmt"
 	"io"
 	_ "log"
+	"net/url"
 	"strings"
 
 	"github.com/sfomuseum/runtimevar"
 )
 
+// TENANT_PREFIX is the path prefix for the endpoints of webhooks that belong to a tenant, which are installed at
+// "/t/{TENANT}/{ENDPOINT}".
+const TENANT_PREFIX string = "/t/"
+
 // type WebhookConfig is a struct containing configuration information for a `webhookd` instance.
 type WebhookConfig struct {
 	// Daemon is a valid `aaronland/go-http-server` URI. This determines how the `webhookd` server will be
 	// instantiated and listen for requests.
 	Daemon string `json:"daemon"`
+	// PublicURL is the optional URL, for example "https://hooks.example.com", where the `webhookd` server can be reached by upstream
+	// providers. It is used to register webhooks with their providers.
+	PublicURL string `json:"public_url,omitempty"`
+	// GRPC is an optional URI, in the form of "grpc://{HOST}:{PORT}", used to start a gRPC server, on a separate port, that
+	// delivers messages to the same webhooks as HTTP requests.
+	GRPC string `json:"grpc,omitempty"`
+	// Admin is an optional URI, in the form of "admin://{HOST}:{PORT}?token={TOKEN}", used to start an HTTP server, on a separate
+	// port, serving runtime statistics and, optionally, pprof profiles. See `daemon.AddAdminServer` for details.
+	Admin string `json:"admin,omitempty"`
+	// GraphQL is an optional URI, in the form of "graphql://{PATH}", used to install a GraphQL endpoint that internal clients can
+	// use to deliver messages and subscribe to processed events.
+	GraphQL string `json:"graphql,omitempty"`
+	// OpenAPI is an optional URI, in the form of "openapi://{PATH}", used to install an endpoint serving an OpenAPI 3 document
+	// describing the webhooks defined in the config. See `daemon.AddOpenAPIEndpoint` for details.
+	OpenAPI string `json:"openapi,omitempty"`
+	// Capture is an optional URI, in the form of "capture://{PATH}", used to install an endpoint for inspecting and clearing the
+	// messages captured by `capture://` dispatchers. See `daemon.AddCaptureEndpoint` for details.
+	Capture string `json:"capture,omitempty"`
+	// AccessLog is an optional URI, in the form of "stdout://", "stderr://" or "file://{PATH}", used to write an entry for each
+	// webhook request, separate from application logs. See `daemon.AddAccessLog` for details.
+	AccessLog string `json:"access_log,omitempty"`
+	// Metrics is an optional URI, in the form of "metrics://{HOST}:{PORT}", used to push metrics for each webhook request to
+	// a statsd or DogStatsD server. See `daemon.AddMetrics` for details.
+	Metrics string `json:"metrics,omitempty"`
+	// MetaWebhook is the optional endpoint of the webhook that synthetic events, about `webhookd` itself (for example breaches of
+	// the service level objectives for other webhooks), are delivered to.
+	MetaWebhook string `json:"meta_webhook,omitempty"`
+	// Reporter is an optional URI, for example "sentry://{PUBLIC_KEY}@{HOST}/{PROJECT_ID}", used to report panics recovered while
+	// processing messages to an error tracking service. See the `reporter` package for details.
+	Reporter string `json:"reporter,omitempty"`
+	// AuditLog is an optional URI, in the form of "stdout://", "stderr://" or "file://{PATH}", used to write an append-only
+	// record of each change to the webhooks for a `webhookd` instance. See `daemon.AddAuditLog` for details.
+	AuditLog string `json:"audit_log,omitempty"`
+	// Cluster is an optional URI, for example "redis://{HOST}:{PORT}" or "etcd://{HOST}:{PORT}", used to share delivery claims
+	// between multiple `webhookd` instances so that messages delivered to more than one instance are only dispatched once. See the
+	// `cluster` package for details.
+	Cluster string `json:"cluster,omitempty"`
+	// Election is an optional URI, for example "etcd://{HOST}:{PORT}" or "kubernetes://", used to elect a single `webhookd`
+	// instance to consume messages for each singleton source webhook. If empty, and any webhooks are singletons, "memory://" is
+	// used. See the `cluster` package for details.
+	Election string `json:"election,omitempty"`
+	// Controller is an optional URI, for example "kubernetes://?resource=webhooks", used to add, update and remove webhooks while
+	// `webhookd` is running from resources managed outside of the config file. See the `controller` package for details.
+	Controller string `json:"controller,omitempty"`
+	// Tunnel is an optional URI, for example "ngrok://" or "cloudflared://", used to expose the `webhookd` server to the internet when
+	// it is started so that provider webhooks can be tested against a development machine. See the `tunnel` package for details.
+	Tunnel string `json:"tunnel,omitempty"`
+	// Drift is an optional URI, in the form of "drift://?interval={DURATION}", used to periodically compare the registrations
+	// defined for webhooks with the hooks actually registered with their providers. See `daemon.AddDriftCheck` for details.
+	Drift string `json:"drift,omitempty"`
+	// Usage is an optional URI, for example "memory://" or "file://{PATH}", used to record the number of messages delivered, and
+	// bytes and dispatches processed, by each webhook and tenant. If empty, and any webhooks or tenants define a quota, "memory://"
+	// is used. See the `usage` package for details.
+	Usage string `json:"usage,omitempty"`
 	// Receivers is a dictionary of available receivers where the key is a unique label used to identify the
 	// receiver (in `WebhookWebhooksConfig`) and the value is a URI used to instantiate the reciever.
 	Receivers map[string]string `json:"receivers"`
+	// Sources is an optional dictionary of available sources where the key is a unique label used to identify the source
+	// (in `WebhookWebhooksConfig`) and the value is a URI used to instantiate the source. Sources are used to consume messages
+	// from queues, topics and other pull-based services rather than receiving them over HTTP.
+	Sources map[string]string `json:"sources,omitempty"`
 	// Dispatchers is a dictionary of available dispatchers where the key is a unique label used to identify the
 	// dispatcher (in `WebhookWebhooksConfig`) and the value is a URI used to instantiate the dispatcher.
 	Dispatchers map[string]string `json:"dispatchers"`
 	// Transformations is a dictionary of available transformations where the key is a unique label used to identify the
 	// transformation (in `WebhookWebhooksConfig`) and the value is a URI used to instantiate the transformation.
 	Transformations map[string]string `json:"transformations"`
+	// Pipelines is an optional dictionary of reusable, named transformation pipelines where the key is a unique label used to identify
+	// the pipeline (in `WebhookWebhooksConfig.Transformations` or other pipelines) and the value is a `WebhookPipelineConfig` instance.
+	// Pipeline labels may not be the same as any of the labels in `Transformations`.
+	Pipelines map[string]WebhookPipelineConfig `json:"pipelines,omitempty"`
+	// Middleware is an optional dictionary of available middleware where the key is a unique label used to identify the middleware
+	// (in `GlobalMiddleware` or `WebhookWebhooksConfig`) and the value is a URI used to instantiate the middleware.
+	Middleware map[string]string `json:"middleware,omitempty"`
+	// GlobalMiddleware is an optional list of middleware labels configured in `Middleware` that are applied, in the order they are
+	// listed, to every webhook request.
+	GlobalMiddleware []string `json:"global_middleware,omitempty"`
 	// Webhooks is a list of `WebhookWebhooksConfig` used to configure the webhooks that a `webhookd` instance will respond to.
 	Webhooks []WebhookWebhooksConfig `json:"webhooks"`
+	// Tenants is an optional dictionary of tenants where the key is the name of the tenant and the value is a `WebhookTenantConfig`
+	// instance. The webhooks for each tenant are installed under the "/t/{TENANT}" prefix.
+	Tenants map[string]WebhookTenantConfig `json:"tenants,omitempty"`
+}
+
+// type WebhookTenantConfig is a struct containing configuration information for a tenant, a group of webhooks that is isolated from
+// the webhooks for other tenants. Tenant webhooks may only use the receivers, sources, transformations, pipelines, dispatchers and
+// middleware defined by their tenant.
+type WebhookTenantConfig struct {
+	// Receivers is a dictionary of receiver labels and URIs available to the webhooks for the tenant.
+	Receivers map[string]string `json:"receivers,omitempty"`
+	// Sources is an optional dictionary of source labels and URIs available to the webhooks for the tenant.
+	Sources map[string]string `json:"sources,omitempty"`
+	// Dispatchers is a dictionary of dispatcher labels and URIs available to the webhooks for the tenant.
+	Dispatchers map[string]string `json:"dispatchers,omitempty"`
+	// Transformations is an optional dictionary of transformation labels and URIs available to the webhooks for the tenant.
+	Transformations map[string]string `json:"transformations,omitempty"`
+	// Pipelines is an optional dictionary of pipeline labels and `WebhookPipelineConfig` instances available to the webhooks for the tenant.
+	Pipelines map[string]WebhookPipelineConfig `json:"pipelines,omitempty"`
+	// Middleware is an optional dictionary of middleware labels and URIs available to the webhooks for the tenant.
+	Middleware map[string]string `json:"middleware,omitempty"`
+	// GlobalMiddleware is an optional list of middleware labels configured in `Middleware` that are applied, in the order they are
+	// listed, to every request for the tenant's webhooks. Each middleware is shared by all of the tenant's webhooks so, for example, a
+	// "ratelimit://?key=global" middleware limits the rate of requests for the tenant as a whole.
+	GlobalMiddleware []string `json:"global_middleware,omitempty"`
+	// Labels is an optional dictionary of labels added to the labels of each of the tenant's webhooks.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Quota is an optional `WebhookQuotaConfig` used to limit the number of messages accepted by all of the tenant's webhooks combined.
+	Quota *WebhookQuotaConfig `json:"quota,omitempty"`
+	// Webhooks is the list of `WebhookWebhooksConfig` for the tenant. Endpoints are relative to the "/t/{TENANT}" prefix.
+	Webhooks []WebhookWebhooksConfig `json:"webhooks"`
 }
 
 // type WebhookWebhooksConfig is a struct containing configuration information for an individual webhook.
@@ -37,19 +141,181 @@ type WebhookWebhooksConfig struct {
 	// Receiver the label for a recievier configured in `WebhookConfig.Receivers` that will be used to process an
 	// initial webhook request.
 	Receiver string `json:"receiver"`
+	// Source is the label for a source configured in `WebhookConfig.Sources` that messages will be consumed from. Webhooks may
+	// define a receiver or a source but not both. For webhooks with a source `Endpoint` is optional and only used to identify the
+	// webhook; it defaults to the source label.
+	Source string `json:"source,omitempty"`
 	// Transformations is a list of transformation labels configured in `WebhookConfig.Transformations`. These transformations
 	// will be applied in the order they are listed. The first transformation will be applied to the output of `Receiver` and
 	// subsequent transformations will be applied to the output of the previous transformation.
 	Transformations []string `json:"transformations"`
+	// Middleware is an optional list of middleware labels configured in `WebhookConfig.Middleware` that are applied, in the order
+	// they are listed and after any global middleware, to requests for the webhook.
+	Middleware []string `json:"middleware,omitempty"`
 	// Dispatchers is a list of dispatcher labels configured in `WebhookConfig.Dispatchers`. Each dispatcher takes the output
 	// of the last transformation and relays ("dispatches") it acccording to its internal rules.
 	Dispatchers []string `json:"dispatchers"`
+	// DispatchMode is an optional string, one of "parallel" or "sequential", signaling whether messages are relayed to all of
+	// the dispatchers at the same time or to each dispatcher, in order, after the previous one has completed. Default is "parallel".
+	DispatchMode string `json:"dispatch_mode,omitempty"`
+	// AbortOnFailure is an optional boolean flag signaling that, when dispatching sequentially, messages should not be relayed
+	// to any more dispatchers once a dispatcher has failed.
+	AbortOnFailure bool `json:"abort_on_failure,omitempty"`
+	// SuccessPolicy is an optional string, one of "all", "any" or "quorum", signaling how many dispatchers must succeed for a
+	// message to have been dispatched successfully. Default is "all".
+	SuccessPolicy string `json:"success_policy,omitempty"`
+	// Quorum is the optional number of dispatchers that must succeed when `SuccessPolicy` is "quorum". Default is a majority.
+	Quorum int `json:"quorum,omitempty"`
+	// DebugToken is an optional secret token that must be included with requests for debugging output. If empty then debugging
+	// output is disabled for the webhook.
+	DebugToken string `json:"debug_token,omitempty"`
+	// Streaming is an optional boolean flag signaling that message bodies should be streamed from the receiver to the dispatchers
+	// rather than being read in to memory. Streaming webhooks can not define any transformations and their receiver and dispatchers
+	// must support streaming.
+	Streaming bool `json:"streaming,omitempty"`
+	// Methods is an optional list of HTTP methods that the webhook will accept. If empty only "POST" requests are accepted.
+	Methods []string `json:"methods,omitempty"`
+	// Response is an optional `WebhookResponseConfig` used to define the HTTP response for successfully processed messages.
+	Response *WebhookResponseConfig `json:"response,omitempty"`
+	// Probe is an optional `WebhookProbeConfig` used to define a lightweight HTTP response for requests that providers make to
+	// check that the webhook exists (for example HEAD, GET or OPTIONS requests) rather than passing them to the receiver.
+	Probe *WebhookProbeConfig `json:"probe,omitempty"`
+	// CORS is an optional `WebhookCORSConfig` used to define the cross-origin resource sharing policy for the webhook, for
+	// browser-based tools that send requests to it directly.
+	CORS *WebhookCORSConfig `json:"cors,omitempty"`
+	// Routes is an optional list of `WebhookRouteConfig` used to select dispatchers based on the contents of a message. Routes are
+	// tested in order and the dispatchers for the first matching route are used. If no routes match then `Dispatchers` are used.
+	Routes []WebhookRouteConfig `json:"routes,omitempty"`
+	// Labels is an optional dictionary of labels (for example the team that owns a webhook) added as tags to the metrics for the
+	// webhook and included in its service level objective alerts.
+	Labels map[string]string `json:"labels,omitempty"`
+	// SLO is an optional `WebhookSLOConfig` used to define the service level objectives for the webhook. Breaches are reported as
+	// synthetic events delivered to `WebhookConfig.MetaWebhook`.
+	SLO *WebhookSLOConfig `json:"slo,omitempty"`
+	// Quota is an optional `WebhookQuotaConfig` used to limit the number of messages the webhook will accept. Requests that exceed
+	// the quota receive a 429 Too Many Requests response.
+	Quota *WebhookQuotaConfig `json:"quota,omitempty"`
+	// Registration is an optional `WebhookRegistrationConfig` used to register the webhook with its upstream provider, pointing
+	// at `WebhookConfig.PublicURL`, using the `webhookd-register` tool.
+	Registration *WebhookRegistrationConfig `json:"registration,omitempty"`
+	// Singleton is an optional boolean flag signaling that messages for a source webhook should only be consumed by the `webhookd`
+	// instance elected leader using `WebhookConfig.Election`, while other instances stand by. Only source webhooks may be singletons.
+	Singleton bool `json:"singleton,omitempty"`
+}
+
+// type WebhookRegistrationConfig is a struct containing configuration information for registering a webhook with its upstream
+// provider (for example a GitHub repository or a Stripe account).
+type WebhookRegistrationConfig struct {
+	// Provider is a `provider` package URI, for example "github://{OWNER}/{REPO}?token={TOKEN}", for the account the webhook is
+	// registered with.
+	Provider string `json:"provider"`
+	// Events is an optional list of provider-specific events that will be delivered to the webhook. If empty the provider's
+	// default events are used.
+	Events []string `json:"events,omitempty"`
+	// SecretParameter is the name of the query parameter in the webhook's receiver URI containing the secret shared with the
+	// provider. Default is "secret".
+	SecretParameter string `json:"secret_parameter,omitempty"`
+}
+
+// type WebhookQuotaConfig is a struct containing configuration information for the number of messages that a webhook, or all the
+// webhooks for a tenant, will accept. Periods are calendar days and months in UTC.
+type WebhookQuotaConfig struct {
+	// Daily is the maximum number of messages delivered per day. If 0 there is no daily quota.
+	Daily int64 `json:"daily,omitempty"`
+	// Monthly is the maximum number of messages delivered per month. If 0 there is no monthly quota.
+	Monthly int64 `json:"monthly,omitempty"`
+}
+
+// type WebhookSLOConfig is a struct containing configuration information for the service level objectives of a webhook.
+type WebhookSLOConfig struct {
+	// SuccessRate is the fraction (for example 0.99) of requests that must not return a 5XX status code.
+	SuccessRate float64 `json:"success_rate,omitempty"`
+	// Latency is a duration string (for example "500ms") within which requests must be processed.
+	Latency string `json:"latency,omitempty"`
+	// LatencyTarget is the fraction of requests that must be processed within `Latency`. Default is 0.99.
+	LatencyTarget float64 `json:"latency_target,omitempty"`
+	// Window is a duration string for the sliding window over which objectives are evaluated. Default is "1h".
+	Window string `json:"window,omitempty"`
+	// MinRequests is the minimum number of requests, in a window, before objectives are evaluated. Default is 10.
+	MinRequests int `json:"min_requests,omitempty"`
+	// BurnRate is the rate at which the error budget for an objective may be consumed before it is considered to be breached. Default is 1.
+	BurnRate float64 `json:"burn_rate,omitempty"`
+}
+
+// type WebhookPipelineConfig is a struct containing configuration information for a reusable, named transformation pipeline.
+type WebhookPipelineConfig struct {
+	// Transformations is a list of transformation or pipeline labels that will be applied in the order they are listed.
+	Transformations []string `json:"transformations,omitempty"`
+	// Branches is an optional dictionary of branch names and lists of transformation or pipeline labels. Each branch is applied
+	// to the output of `Transformations` and the output of every branch is then merged according to `Merge`.
+	Branches map[string][]string `json:"branches,omitempty"`
+	// Merge is the strategy used to merge the output of `Branches`. Valid options are "object", "array" and "concat". Default is "object".
+	Merge string `json:"merge,omitempty"`
+}
+
+// type WebhookRouteConfig is a struct containing configuration information for selecting dispatchers based on the contents of a message.
+type WebhookRouteConfig struct {
+	// When is a `predicate` package expression that a message must satisfy for the route to be used. If empty the route matches all messages.
+	When string `json:"when,omitempty"`
+	// Dispatchers is a list of dispatcher labels configured in `WebhookConfig.Dispatchers` that messages matching the route will be relayed to.
+	Dispatchers []string `json:"dispatchers"`
+}
+
+// type WebhookResponseConfig is a struct containing configuration information for the HTTP response sent when a webhook
+// message has been processed successfully.
+type WebhookResponseConfig struct {
+	// Status is the HTTP status code for the response. Valid options are 200, 201, 202 and 204. Default is 200.
+	Status int `json:"status,omitempty"`
+	// Body is an optional Go language `text/template` string used to render the body of the response.
+	Body string `json:"body,omitempty"`
+	// ContentType is the optional content type of the response body.
+	ContentType string `json:"content_type,omitempty"`
+}
+
+// type WebhookProbeConfig is a struct containing configuration information for the HTTP response sent to requests that check
+// whether a webhook exists.
+type WebhookProbeConfig struct {
+	// Methods is the optional list of HTTP methods answered by the probe. Valid options are "GET", "HEAD" and "OPTIONS" and they
+	// can not also be listed in `WebhookWebhooksConfig.Methods`. Default is all three.
+	Methods []string `json:"methods,omitempty"`
+	// Status is the HTTP status code for the response. Valid options are 200 and 204. Default is 200 if there is a body or 204 if not.
+	Status int `json:"status,omitempty"`
+	// Body is the optional (static) body of the response.
+	Body string `json:"body,omitempty"`
+	// ContentType is the optional content type of the response body.
+	ContentType string `json:"content_type,omitempty"`
+	// Headers is an optional dictionary of additional HTTP headers for the response.
+	Headers map[string]string `json:"headers,omitempty"`
+	// MaxAge is the optional number of seconds that clients may cache the response for. Default is 0 (not cacheable).
+	MaxAge int `json:"max_age,omitempty"`
+}
+
+// type WebhookCORSConfig is a struct containing configuration information for the cross-origin resource sharing (CORS) policy
+// of a webhook.
+type WebhookCORSConfig struct {
+	// Origins is the list of origins, for example "https://tools.example.com", allowed to send requests. "*" allows any origin.
+	Origins []string `json:"origins"`
+	// Methods is the optional list of HTTP methods browsers are allowed to use. Default is the methods accepted by the webhook.
+	Methods []string `json:"methods,omitempty"`
+	// Headers is the optional list of request headers browsers are allowed to send. "*" allows any headers. Default is "Content-Type".
+	Headers []string `json:"headers,omitempty"`
+	// ExposeHeaders is the optional list of response headers browsers are allowed to read.
+	ExposeHeaders []string `json:"expose_headers,omitempty"`
+	// MaxAge is the optional number of seconds browsers may cache the results of a preflight request for.
+	MaxAge int `json:"max_age,omitempty"`
 }
 
 // NewConfigFromURI returns a new `WebhookConfig` instance derived from 'uri' which is expected to take the form of
-// a valid `gocloud.dev/runtimevar` URI. The value of that URI is expected to be a JSON-encoded `WebhookConfig` string.
+// a valid `gocloud.dev/runtimevar` URI. The value of that URI is expected to be a JSON-encoded `WebhookConfig` string. If 'uri'
+// takes the form of "env://" or "env://?prefix={PREFIX}" the config is derived from environment variables using `NewConfigFromEnv`.
 func NewConfigFromURI(ctx context.Context, uri string) (*WebhookConfig, error) {
 
+	u, err := url.Parse(uri)
+
+	if err == nil && u.Scheme == ENV_SCHEME {
+		return newConfigFromEnvURI(ctx, u)
+	}
+
 	str_cfg, err := runtimevar.StringVar(ctx, uri)
 
 	if err != nil {
@@ -89,6 +355,30 @@ func (c *WebhookConfig) GetReceiverConfigByName(name string) (string, error) {
 	return config, nil
 }
 
+// GetMiddlewareConfigByName returns the middleware URI for 'name'.
+func (c *WebhookConfig) GetMiddlewareConfigByName(name string) (string, error) {
+
+	config, ok := c.Middleware[name]
+
+	if !ok {
+		return "", fmt.Errorf("Invalid middleware name '%s'", name)
+	}
+
+	return config, nil
+}
+
+// GetSourceConfigByName returns the source URI for 'name'.
+func (c *WebhookConfig) GetSourceConfigByName(name string) (string, error) {
+
+	config, ok := c.Sources[name]
+
+	if !ok {
+		return "", fmt.Errorf("Invalid source name '%s'", name)
+	}
+
+	return config, nil
+}
+
 // GetDispatcherConfigByName returns the dispatcher URI for 'name'.
 func (c *WebhookConfig) GetDispatcherConfigByName(name string) (string, error) {
 
@@ -112,3 +402,112 @@ func (c *WebhookConfig) GetTransformationConfigByName(name string) (string, erro
 
 	return config, nil
 }
+
+// GetPipelineConfigByName returns the `WebhookPipelineConfig` for 'name'.
+func (c *WebhookConfig) GetPipelineConfigByName(name string) (*WebhookPipelineConfig, error) {
+
+	config, ok := c.Pipelines[name]
+
+	if !ok {
+		return nil, fmt.Errorf("Invalid pipeline name '%s'", name)
+	}
+
+	return &config, nil
+}
+
+// GetTenantConfigByName returns the `WebhookTenantConfig` for 'name'.
+func (c *WebhookConfig) GetTenantConfigByName(name string) (*WebhookTenantConfig, error) {
+
+	config, ok := c.Tenants[name]
+
+	if !ok {
+		return nil, fmt.Errorf("Invalid tenant name '%s'", name)
+	}
+
+	return &config, nil
+}
+
+// WebhookConfig returns a `WebhookConfig` instance containing only the components and webhooks defined by 't'. It is used to
+// create the webhooks for a tenant so that they can not refer to components defined outside of the tenant.
+func (t *WebhookTenantConfig) WebhookConfig() *WebhookConfig {
+
+	cfg := &WebhookConfig{
+		Receivers:        t.Receivers,
+		Sources:          t.Sources,
+		Dispatchers:      t.Dispatchers,
+		Transformations:  t.Transformations,
+		Pipelines:        t.Pipelines,
+		Middleware:       t.Middleware,
+		GlobalMiddleware: t.GlobalMiddleware,
+		Webhooks:         t.Webhooks,
+	}
+
+	return cfg
+}
+
+// type WebhookResourceConfig is a struct containing configuration information for a single webhook defined outside of the config
+// file, for example by a Kubernetes object, along with any components that only it uses.
+type WebhookResourceConfig struct {
+	// Receivers is an optional dictionary of receiver labels and URIs available to the webhook.
+	Receivers map[string]string `json:"receivers,omitempty"`
+	// Dispatchers is an optional dictionary of dispatcher labels and URIs available to the webhook.
+	Dispatchers map[string]string `json:"dispatchers,omitempty"`
+	// Transformations is an optional dictionary of transformation labels and URIs available to the webhook.
+	Transformations map[string]string `json:"transformations,omitempty"`
+	// Pipelines is an optional dictionary of pipeline labels and `WebhookPipelineConfig` instances available to the webhook.
+	Pipelines map[string]WebhookPipelineConfig `json:"pipelines,omitempty"`
+	// Middleware is an optional dictionary of middleware labels and URIs available to the webhook.
+	Middleware map[string]string `json:"middleware,omitempty"`
+	// Webhook is the `WebhookWebhooksConfig` for the webhook.
+	Webhook WebhookWebhooksConfig `json:"webhook"`
+}
+
+// WebhookConfig returns a `WebhookConfig` instance containing the webhook defined by 'r' and the components defined by both 'cfg'
+// and 'r'. Components defined by 'r' take precedence over components with the same label defined by 'cfg', which may be nil.
+func (r *WebhookResourceConfig) WebhookConfig(cfg *WebhookConfig) *WebhookConfig {
+
+	if cfg == nil {
+		cfg = &WebhookConfig{}
+	}
+
+	merge := func(a map[string]string, b map[string]string) map[string]string {
+
+		m := make(map[string]string)
+
+		for k, v := range a {
+			m[k] = v
+		}
+
+		for k, v := range b {
+			m[k] = v
+		}
+
+		return m
+	}
+
+	pipelines := make(map[string]WebhookPipelineConfig)
+
+	for k, v := range cfg.Pipelines {
+		pipelines[k] = v
+	}
+
+	for k, v := range r.Pipelines {
+		pipelines[k] = v
+	}
+
+	merged := &WebhookConfig{
+		Receivers:       merge(cfg.Receivers, r.Receivers),
+		Dispatchers:     merge(cfg.Dispatchers, r.Dispatchers),
+		Transformations: merge(cfg.Transformations, r.Transformations),
+		Pipelines:       pipelines,
+		Middleware:      merge(cfg.Middleware, r.Middleware),
+		Webhooks:        []WebhookWebhooksConfig{r.Webhook},
+	}
+
+	return merged
+}
+
+// TenantEndpoint returns the endpoint for the webhook with 'endpoint' that belongs to 'tenant'.
+func TenantEndpoint(tenant string, endpoint string) string {
+	return TENANT_PREFIX + tenant + "/" + strings.TrimPrefix(endpoint, "/")
+}
@@ -141,3 +141,156 @@ func TestGetDispatcherConfigByName(t *testing.T) {
 		t.Fatalf("Unexpected value for %s dispatcher: %s", name, uri)
 	}
 }
+
+func TestGetSourceConfigByName(t *testing.T) {
+
+	cfg := &WebhookConfig{
+		Sources: map[string]string{
+			"kafka": "kafka://localhost:9092/events",
+		},
+	}
+
+	name := "kafka"
+	expected := "kafka://localhost:9092/events"
+
+	uri, err := cfg.GetSourceConfigByName(name)
+
+	if err != nil {
+		t.Fatalf("Failed to get source config for %s, %v", name, err)
+	}
+
+	if uri != expected {
+		t.Fatalf("Unexpected value for %s source: %s", name, uri)
+	}
+
+	_, err = cfg.GetSourceConfigByName("amqp")
+
+	if err == nil {
+		t.Fatalf("Expected unknown source to fail")
+	}
+}
+
+func TestGetTenantConfigByName(t *testing.T) {
+
+	cfg := &WebhookConfig{
+		Receivers: map[string]string{
+			"insecure": "insecure://",
+		},
+		Tenants: map[string]WebhookTenantConfig{
+			"acme": {
+				Receivers: map[string]string{
+					"github": "github://?secret=s33kret",
+				},
+				Webhooks: []WebhookWebhooksConfig{
+					{Endpoint: "/github", Receiver: "github"},
+				},
+			},
+		},
+	}
+
+	tenant, err := cfg.GetTenantConfigByName("acme")
+
+	if err != nil {
+		t.Fatalf("Failed to get tenant config, %v", err)
+	}
+
+	tenant_cfg := tenant.WebhookConfig()
+
+	_, err = tenant_cfg.GetReceiverConfigByName("github")
+
+	if err != nil {
+		t.Fatalf("Failed to get tenant receiver config, %v", err)
+	}
+
+	_, err = tenant_cfg.GetReceiverConfigByName("insecure")
+
+	if err == nil {
+		t.Fatalf("Expected tenant config not to include global receivers")
+	}
+
+	if len(tenant_cfg.Webhooks) != 1 {
+		t.Fatalf("Unexpected number of tenant webhooks, %d", len(tenant_cfg.Webhooks))
+	}
+
+	_, err = cfg.GetTenantConfigByName("globex")
+
+	if err == nil {
+		t.Fatalf("Expected unknown tenant to fail")
+	}
+}
+
+func TestTenantEndpoint(t *testing.T) {
+
+	tests := map[string]string{
+		"/github":        "/t/acme/github",
+		"/repos/{name}":  "/t/acme/repos/{name}",
+		"kafka-consumer": "/t/acme/kafka-consumer",
+	}
+
+	for endpoint, expected := range tests {
+
+		v := TenantEndpoint("acme", endpoint)
+
+		if v != expected {
+			t.Fatalf("Unexpected tenant endpoint for %s: %s", endpoint, v)
+		}
+	}
+}
+
+func TestWebhookResourceConfig(t *testing.T) {
+
+	cfg := &WebhookConfig{
+		Receivers: map[string]string{
+			"insecure": "insecure://",
+			"github":   "github://?secret=s33kret",
+		},
+		Dispatchers: map[string]string{
+			"null": "null://",
+		},
+	}
+
+	r := &WebhookResourceConfig{
+		Receivers: map[string]string{
+			"github": "github://?secret=other",
+		},
+		Webhook: WebhookWebhooksConfig{
+			Endpoint:    "/github",
+			Receiver:    "github",
+			Dispatchers: []string{"null"},
+		},
+	}
+
+	merged := r.WebhookConfig(cfg)
+
+	if len(merged.Webhooks) != 1 || merged.Webhooks[0].Endpoint != "/github" {
+		t.Fatalf("Unexpected webhooks, %v", merged.Webhooks)
+	}
+
+	tests := map[string]string{
+		"insecure": "insecure://",
+		"github":   "github://?secret=other",
+	}
+
+	for name, expected := range tests {
+
+		uri, err := merged.GetReceiverConfigByName(name)
+
+		if err != nil {
+			t.Fatalf("Failed to get receiver config for %s, %v", name, err)
+		}
+
+		if uri != expected {
+			t.Fatalf("Unexpected receiver config for %s, %s", name, uri)
+		}
+	}
+
+	if cfg.Receivers["github"] != "github://?secret=s33kret" {
+		t.Fatalf("Expected original config to be unchanged")
+	}
+
+	_, err := merged.GetDispatcherConfigByName("null")
+
+	if err != nil {
+		t.Fatalf("Failed to get dispatcher config, %v", err)
+	}
+}
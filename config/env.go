@@ -0,0 +1,552 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DEFAULT_ENV_PREFIX is the default prefix for environment variables used to construct a `WebhookConfig` instance.
+const DEFAULT_ENV_PREFIX string = "WEBHOOKD"
+
+// ENV_SCHEME is the URI scheme used by `NewConfigFromURI` to construct a `WebhookConfig` instance from environment variables.
+const ENV_SCHEME string = "env"
+
+// NewConfigFromEnv returns a new `WebhookConfig` instance derived from the environment variables for the current process whose
+// names start with 'prefix' followed by an underscore. If 'prefix' is empty then `DEFAULT_ENV_PREFIX` is used. See
+// `NewConfigFromEnviron` for details.
+func NewConfigFromEnv(ctx context.Context, prefix string) (*WebhookConfig, error) {
+	return NewConfigFromEnviron(ctx, prefix, os.Environ())
+}
+
+// NewConfigFromEnviron returns a new `WebhookConfig` instance derived from the "KEY=VALUE" strings in 'environ' whose keys start
+// with 'prefix' followed by an underscore. The remainder of each key is the upper-cased (JSON) property name of a `WebhookConfig`
+// property, for example:
+//
+//	WEBHOOKD_DAEMON=http://0.0.0.0:8080
+//	WEBHOOKD_GLOBAL_MIDDLEWARE=limit,auth
+//
+// Dictionaries and lists of structs are named in the singular and followed by a dictionary key or list index. Dictionary keys are
+// lower-cased, and may contain underscores unless their values are structs. Lists of strings are comma-separated. For example:
+//
+//	WEBHOOKD_RECEIVER_GITHUB=github://?secret=s33kret
+//	WEBHOOKD_DISPATCHER_LOG=log://
+//	WEBHOOKD_PIPELINE_CLEAN_TRANSFORMATIONS=chicken,null
+//	WEBHOOKD_WEBHOOK_0_ENDPOINT=/github
+//	WEBHOOKD_WEBHOOK_0_RECEIVER=github
+//	WEBHOOKD_WEBHOOK_0_DISPATCHERS=log
+//	WEBHOOKD_WEBHOOK_0_ROUTE_0_WHEN=ref == "refs/heads/main"
+//	WEBHOOKD_TENANT_ACME_WEBHOOK_0_ENDPOINT=/foo
+//
+// List indices need not be contiguous; list items are ordered by index. Keys that do not correspond to a `WebhookConfig` property
+// (for example the WEBHOOKD_CONFIG_URI flag for the `webhookd` tool) are ignored. An error is returned if no keys correspond to a
+// `WebhookConfig` property.
+func NewConfigFromEnviron(ctx context.Context, prefix string, environ []string) (*WebhookConfig, error) {
+
+	if prefix == "" {
+		prefix = DEFAULT_ENV_PREFIX
+	}
+
+	prefix = strings.ToUpper(prefix) + "_"
+
+	vars := make(map[string]string)
+
+	for _, kv := range environ {
+
+		k, v, ok := strings.Cut(kv, "=")
+
+		if !ok || !strings.HasPrefix(k, prefix) {
+			continue
+		}
+
+		vars[strings.TrimPrefix(k, prefix)] = v
+	}
+
+	cfg := &WebhookConfig{}
+
+	count, err := decodeEnv(reflect.ValueOf(cfg).Elem(), vars, prefix)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if count == 0 {
+		return nil, fmt.Errorf("No config environment variables found with prefix '%s'", prefix)
+	}
+
+	return cfg, nil
+}
+
+// EnvironFromConfig returns the sorted list of "KEY=VALUE" strings, whose keys start with 'prefix', that `NewConfigFromEnviron`
+// will decode in to 'cfg'. If 'prefix' is empty then `DEFAULT_ENV_PREFIX` is used. An error is returned if 'cfg' can not be
+// represented as environment variables, for example if a dictionary key is not lower-case.
+func EnvironFromConfig(cfg *WebhookConfig, prefix string) ([]string, error) {
+
+	if prefix == "" {
+		prefix = DEFAULT_ENV_PREFIX
+	}
+
+	prefix = strings.ToUpper(prefix) + "_"
+
+	environ := make([]string, 0)
+
+	err := encodeEnv(reflect.ValueOf(cfg).Elem(), prefix, &environ)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(environ)
+	return environ, nil
+}
+
+// newConfigFromEnvURI returns a new `WebhookConfig` instance derived from environment variables using the optional "prefix"
+// query parameter in 'u'.
+func newConfigFromEnvURI(ctx context.Context, u *url.URL) (*WebhookConfig, error) {
+	return NewConfigFromEnv(ctx, u.Query().Get("prefix"))
+}
+
+// envName returns the upper-cased JSON property name for 'f', in the singular if 'f' is a dictionary or a list of structs, or ""
+// if 'f' is not encoded as JSON.
+func envName(f reflect.StructField) string {
+
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+
+	if name == "" || name == "-" {
+		return ""
+	}
+
+	name = strings.ToUpper(name)
+
+	t := f.Type
+	is_collection := t.Kind() == reflect.Map || (t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Struct)
+
+	if !is_collection {
+		return name
+	}
+
+	switch {
+	case strings.HasSuffix(name, "CHES"):
+		name = strings.TrimSuffix(name, "ES")
+	case strings.HasSuffix(name, "S"):
+		name = strings.TrimSuffix(name, "S")
+	}
+
+	return name
+}
+
+// decodeEnv assigns the values in 'vars', whose keys are relative to 'path', to the properties of the struct 'v'. It returns the
+// number of values that were assigned.
+func decodeEnv(v reflect.Value, vars map[string]string, path string) (int, error) {
+
+	count := 0
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+
+		f := t.Field(i)
+		name := envName(f)
+
+		if name == "" {
+			continue
+		}
+
+		fv := v.Field(i)
+		ft := f.Type
+
+		switch {
+		case ft.Kind() == reflect.Map && ft.Elem().Kind() == reflect.Struct:
+
+			groups, err := groupEnv(vars, name+"_", path)
+
+			if err != nil {
+				return 0, err
+			}
+
+			for key, sub := range groups {
+
+				ev := reflect.New(ft.Elem()).Elem()
+
+				n, err := decodeEnv(ev, sub, path+name+"_"+key+"_")
+
+				if err != nil {
+					return 0, err
+				}
+
+				if fv.IsNil() {
+					fv.Set(reflect.MakeMap(ft))
+				}
+
+				fv.SetMapIndex(reflect.ValueOf(strings.ToLower(key)), ev)
+				count += n
+			}
+
+		case ft.Kind() == reflect.Map:
+
+			for k, str_v := range vars {
+
+				key, ok := cutPrefix(k, name+"_")
+
+				if !ok {
+					continue
+				}
+
+				if key == "" {
+					return 0, fmt.Errorf("Missing key for %s%s", path, k)
+				}
+
+				ev := reflect.New(ft.Elem()).Elem()
+
+				err := setEnvValue(ev, str_v)
+
+				if err != nil {
+					return 0, fmt.Errorf("Invalid value for %s%s, %w", path, k, err)
+				}
+
+				if fv.IsNil() {
+					fv.Set(reflect.MakeMap(ft))
+				}
+
+				fv.SetMapIndex(reflect.ValueOf(strings.ToLower(key)), ev)
+				count += 1
+			}
+
+		case ft.Kind() == reflect.Slice && ft.Elem().Kind() == reflect.Struct:
+
+			groups, err := groupEnv(vars, name+"_", path)
+
+			if err != nil {
+				return 0, err
+			}
+
+			indices := make([]int, 0)
+
+			for key := range groups {
+
+				idx, err := strconv.Atoi(key)
+
+				if err != nil || idx < 0 || strconv.Itoa(idx) != key {
+					return 0, fmt.Errorf("Invalid index '%s' for %s%s", key, path, name)
+				}
+
+				indices = append(indices, idx)
+			}
+
+			sort.Ints(indices)
+
+			for _, idx := range indices {
+
+				key := strconv.Itoa(idx)
+				ev := reflect.New(ft.Elem()).Elem()
+
+				n, err := decodeEnv(ev, groups[key], path+name+"_"+key+"_")
+
+				if err != nil {
+					return 0, err
+				}
+
+				fv.Set(reflect.Append(fv, ev))
+				count += n
+			}
+
+		case ft.Kind() == reflect.Struct || (ft.Kind() == reflect.Ptr && ft.Elem().Kind() == reflect.Struct):
+
+			sub := make(map[string]string)
+
+			for k, str_v := range vars {
+
+				rest, ok := cutPrefix(k, name+"_")
+
+				if ok {
+					sub[rest] = str_v
+				}
+			}
+
+			if len(sub) == 0 {
+				continue
+			}
+
+			sv := fv
+
+			if ft.Kind() == reflect.Ptr {
+				fv.Set(reflect.New(ft.Elem()))
+				sv = fv.Elem()
+			}
+
+			n, err := decodeEnv(sv, sub, path+name+"_")
+
+			if err != nil {
+				return 0, err
+			}
+
+			count += n
+
+		default:
+
+			str_v, ok := vars[name]
+
+			if !ok {
+				continue
+			}
+
+			err := setEnvValue(fv, str_v)
+
+			if err != nil {
+				return 0, fmt.Errorf("Invalid value for %s%s, %w", path, name, err)
+			}
+
+			count += 1
+		}
+	}
+
+	return count, nil
+}
+
+// groupEnv returns the keys in 'vars' that start with 'prefix' grouped by the segment that follows 'prefix', with that segment
+// removed.
+func groupEnv(vars map[string]string, prefix string, path string) (map[string]map[string]string, error) {
+
+	groups := make(map[string]map[string]string)
+
+	for k, v := range vars {
+
+		rest, ok := cutPrefix(k, prefix)
+
+		if !ok {
+			continue
+		}
+
+		key, rest, ok := strings.Cut(rest, "_")
+
+		if !ok || key == "" || rest == "" {
+			return nil, fmt.Errorf("Invalid variable %s%s", path, k)
+		}
+
+		_, exists := groups[key]
+
+		if !exists {
+			groups[key] = make(map[string]string)
+		}
+
+		groups[key][rest] = v
+	}
+
+	return groups, nil
+}
+
+// setEnvValue parses 'str_v' according to the type of 'v' and assigns the result to 'v'.
+func setEnvValue(v reflect.Value, str_v string) error {
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(str_v)
+	case reflect.Bool:
+
+		b, err := strconv.ParseBool(str_v)
+
+		if err != nil {
+			return err
+		}
+
+		v.SetBool(b)
+
+	case reflect.Int, reflect.Int64:
+
+		i, err := strconv.ParseInt(str_v, 10, 64)
+
+		if err != nil {
+			return err
+		}
+
+		v.SetInt(i)
+
+	case reflect.Float64:
+
+		f, err := strconv.ParseFloat(str_v, 64)
+
+		if err != nil {
+			return err
+		}
+
+		v.SetFloat(f)
+
+	case reflect.Slice:
+
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("Unsupported type %s", v.Type())
+		}
+
+		list := make([]string, 0)
+
+		for _, str := range strings.Split(str_v, ",") {
+
+			str = strings.TrimSpace(str)
+
+			if str != "" {
+				list = append(list, str)
+			}
+		}
+
+		v.Set(reflect.ValueOf(list))
+
+	default:
+		return fmt.Errorf("Unsupported type %s", v.Type())
+	}
+
+	return nil
+}
+
+// encodeEnv appends "KEY=VALUE" strings, whose keys start with 'path', for the non-zero properties of the struct 'v' to 'environ'.
+func encodeEnv(v reflect.Value, path string, environ *[]string) error {
+
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+
+		f := t.Field(i)
+		name := envName(f)
+
+		if name == "" {
+			continue
+		}
+
+		fv := v.Field(i)
+		ft := f.Type
+
+		if fv.IsZero() {
+			continue
+		}
+
+		switch {
+		case ft.Kind() == reflect.Map:
+
+			iter := fv.MapRange()
+
+			for iter.Next() {
+
+				key := iter.Key().String()
+
+				if key == "" || key != strings.ToLower(key) {
+					return fmt.Errorf("Invalid key '%s' for %s%s, keys must be lower-case", key, path, name)
+				}
+
+				k := path + name + "_" + strings.ToUpper(key)
+
+				if ft.Elem().Kind() == reflect.Struct {
+
+					if strings.Contains(key, "_") {
+						return fmt.Errorf("Invalid key '%s' for %s%s, keys can not contain underscores", key, path, name)
+					}
+
+					err := encodeEnv(iter.Value(), k+"_", environ)
+
+					if err != nil {
+						return err
+					}
+
+					continue
+				}
+
+				str_v, err := formatEnvValue(iter.Value())
+
+				if err != nil {
+					return fmt.Errorf("Invalid value for %s, %w", k, err)
+				}
+
+				*environ = append(*environ, k+"="+str_v)
+			}
+
+		case ft.Kind() == reflect.Slice && ft.Elem().Kind() == reflect.Struct:
+
+			for idx := 0; idx < fv.Len(); idx++ {
+
+				err := encodeEnv(fv.Index(idx), path+name+"_"+strconv.Itoa(idx)+"_", environ)
+
+				if err != nil {
+					return err
+				}
+			}
+
+		case ft.Kind() == reflect.Struct:
+
+			err := encodeEnv(fv, path+name+"_", environ)
+
+			if err != nil {
+				return err
+			}
+
+		case ft.Kind() == reflect.Ptr && ft.Elem().Kind() == reflect.Struct:
+
+			err := encodeEnv(fv.Elem(), path+name+"_", environ)
+
+			if err != nil {
+				return err
+			}
+
+		default:
+
+			str_v, err := formatEnvValue(fv)
+
+			if err != nil {
+				return fmt.Errorf("Invalid value for %s%s, %w", path, name, err)
+			}
+
+			*environ = append(*environ, path+name+"="+str_v)
+		}
+	}
+
+	return nil
+}
+
+// formatEnvValue returns the string representation of 'v' parsed by `setEnvValue`.
+func formatEnvValue(v reflect.Value) (string, error) {
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64), nil
+	case reflect.Slice:
+
+		if v.Type().Elem().Kind() != reflect.String {
+			return "", fmt.Errorf("Unsupported type %s", v.Type())
+		}
+
+		list := make([]string, v.Len())
+
+		for i := 0; i < v.Len(); i++ {
+
+			str := v.Index(i).String()
+
+			if strings.Contains(str, ",") {
+				return "", fmt.Errorf("List values can not contain commas")
+			}
+
+			list[i] = str
+		}
+
+		return strings.Join(list, ","), nil
+
+	default:
+		return "", fmt.Errorf("Unsupported type %s", v.Type())
+	}
+}
+
+// cutPrefix returns 's' without 'prefix' and true if 's' starts with 'prefix', otherwise it returns 's' and false.
+func cutPrefix(s string, prefix string) (string, bool) {
+
+	if !strings.HasPrefix(s, prefix) {
+		return s, false
+	}
+
+	return strings.TrimPrefix(s, prefix), true
+}
@@ -0,0 +1,172 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestNewConfigFromEnviron(t *testing.T) {
+
+	ctx := context.Background()
+
+	environ := []string{
+		"WEBHOOKD_CONFIG_URI=env://",
+		"WEBHOOKD_DAEMON=http://0.0.0.0:8080",
+		"WEBHOOKD_RECEIVER_INSECURE=insecure://",
+		"WEBHOOKD_DISPATCHER_LOG=log://",
+		"WEBHOOKD_DISPATCHER_NULL_DEV=null://",
+		"WEBHOOKD_PIPELINE_CLEAN_TRANSFORMATIONS=chicken, null",
+		"WEBHOOKD_PIPELINE_CLEAN_BRANCH_A=null",
+		"WEBHOOKD_WEBHOOK_1_ENDPOINT=/bar",
+		"WEBHOOKD_WEBHOOK_1_RECEIVER=insecure",
+		"WEBHOOKD_WEBHOOK_1_DISPATCHERS=null_dev",
+		"WEBHOOKD_WEBHOOK_0_ENDPOINT=/foo",
+		"WEBHOOKD_WEBHOOK_0_RECEIVER=insecure",
+		"WEBHOOKD_WEBHOOK_0_DISPATCHERS=log,null_dev",
+		"WEBHOOKD_WEBHOOK_0_STREAMING=true",
+		"WEBHOOKD_WEBHOOK_0_RESPONSE_STATUS=202",
+		"WEBHOOKD_WEBHOOK_0_SLO_SUCCESS_RATE=0.99",
+		"WEBHOOKD_WEBHOOK_0_ROUTE_0_WHEN=ref == \"main\"",
+		"WEBHOOKD_WEBHOOK_0_LABEL_TEAM=data",
+		"WEBHOOKD_TENANT_ACME_QUOTA_DAILY=100",
+		"WEBHOOKD_TENANT_ACME_WEBHOOK_0_ENDPOINT=/foo",
+		"OTHER_DAEMON=http://localhost:8081",
+	}
+
+	cfg, err := NewConfigFromEnviron(ctx, "", environ)
+
+	if err != nil {
+		t.Fatalf("Failed to create config from environment, %v", err)
+	}
+
+	if cfg.Daemon != "http://0.0.0.0:8080" {
+		t.Fatalf("Unexpected daemon '%s'", cfg.Daemon)
+	}
+
+	if cfg.Dispatchers["null_dev"] != "null://" || len(cfg.Dispatchers) != 2 {
+		t.Fatalf("Unexpected dispatchers %v", cfg.Dispatchers)
+	}
+
+	p := cfg.Pipelines["clean"]
+
+	if len(p.Transformations) != 2 || p.Transformations[1] != "null" || p.Branches["a"][0] != "null" {
+		t.Fatalf("Unexpected pipeline %v", p)
+	}
+
+	if len(cfg.Webhooks) != 2 || cfg.Webhooks[0].Endpoint != "/foo" || cfg.Webhooks[1].Endpoint != "/bar" {
+		t.Fatalf("Unexpected webhooks %v", cfg.Webhooks)
+	}
+
+	wh := cfg.Webhooks[0]
+
+	if len(wh.Dispatchers) != 2 || !wh.Streaming || wh.Response.Status != 202 || wh.SLO.SuccessRate != 0.99 {
+		t.Fatalf("Unexpected webhook %v", wh)
+	}
+
+	if len(wh.Routes) != 1 || wh.Routes[0].When != "ref == \"main\"" || wh.Labels["team"] != "data" {
+		t.Fatalf("Unexpected routes (%v) or labels (%v)", wh.Routes, wh.Labels)
+	}
+
+	if cfg.Webhooks[1].Response != nil {
+		t.Fatalf("Expected webhook without response config")
+	}
+
+	tenant, ok := cfg.Tenants["acme"]
+
+	if !ok || tenant.Quota.Daily != 100 || tenant.Webhooks[0].Endpoint != "/foo" {
+		t.Fatalf("Unexpected tenants %v", cfg.Tenants)
+	}
+
+	other, err := NewConfigFromEnviron(ctx, "other", environ)
+
+	if err != nil {
+		t.Fatalf("Failed to create config from environment with prefix, %v", err)
+	}
+
+	if other.Daemon != "http://localhost:8081" || len(other.Webhooks) != 0 {
+		t.Fatalf("Unexpected config for prefix, %v", other)
+	}
+
+	invalid := [][]string{
+		{"WEBHOOKD_CONFIG_URI=env://"},
+		{"WEBHOOKD_WEBHOOK_0_STREAMING=yes"},
+		{"WEBHOOKD_WEBHOOK_X_ENDPOINT=/foo"},
+		{"WEBHOOKD_WEBHOOK_01_ENDPOINT=/foo"},
+		{"WEBHOOKD_WEBHOOK_0=/foo"},
+		{"WEBHOOKD_RECEIVER_=insecure://"},
+		{"WEBHOOKD_WEBHOOK_0_RESPONSE_STATUS=ok"},
+	}
+
+	for _, environ := range invalid {
+
+		_, err := NewConfigFromEnviron(ctx, "", environ)
+
+		if err == nil {
+			t.Fatalf("Expected %v to fail", environ)
+		}
+	}
+}
+
+func TestEnvironFromConfig(t *testing.T) {
+
+	ctx := context.Background()
+
+	cfg, err := newConfigFromURI()
+
+	if err != nil {
+		t.Fatalf("Failed to create new config from URI, %v", err)
+	}
+
+	environ, err := EnvironFromConfig(cfg, "")
+
+	if err != nil {
+		t.Fatalf("Failed to derive environment from config, %v", err)
+	}
+
+	env_cfg, err := NewConfigFromEnviron(ctx, "", environ)
+
+	if err != nil {
+		t.Fatalf("Failed to create config from environment, %v", err)
+	}
+
+	enc_cfg, _ := json.Marshal(cfg)
+	enc_env, _ := json.Marshal(env_cfg)
+
+	if string(enc_cfg) != string(enc_env) {
+		t.Fatalf("Config derived from environment (%s) does not match original config (%s)", enc_env, enc_cfg)
+	}
+
+	invalid := []*WebhookConfig{
+		{Receivers: map[string]string{"GitHub": "github://"}},
+		{Pipelines: map[string]WebhookPipelineConfig{"clean_up": {Transformations: []string{"null"}}}},
+		{Webhooks: []WebhookWebhooksConfig{{Dispatchers: []string{"a,b"}}}},
+	}
+
+	for _, cfg := range invalid {
+
+		_, err := EnvironFromConfig(cfg, "")
+
+		if err == nil {
+			t.Fatalf("Expected %v to fail", cfg)
+		}
+	}
+}
+
+func TestNewConfigFromEnvURI(t *testing.T) {
+
+	ctx := context.Background()
+
+	t.Setenv("TESTWEBHOOKD_DAEMON", "http://localhost:8082")
+	t.Setenv("TESTWEBHOOKD_WEBHOOK_0_ENDPOINT", "/foo")
+
+	cfg, err := NewConfigFromURI(ctx, "env://?prefix=TESTWEBHOOKD")
+
+	if err != nil {
+		t.Fatalf("Failed to create config from env URI, %v", err)
+	}
+
+	if cfg.Daemon != "http://localhost:8082" || len(cfg.Webhooks) != 1 {
+		t.Fatalf("Unexpected config, %v", cfg)
+	}
+}
@@ -0,0 +1,445 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Versions of the config file format.
+const (
+	// CONFIG_VERSION_1 is the original config format where the daemon is a dictionary of "protocol", "host" and "port" properties
+	// and each receiver, transformation and dispatcher is a dictionary with a "name" property and its own (flat) properties.
+	CONFIG_VERSION_1 string = "1"
+	// CONFIG_VERSION_2 is the current config format where the daemon and each component are defined by a URI string.
+	CONFIG_VERSION_2 string = "2"
+)
+
+// CURRENT_CONFIG_VERSION is the version of the config format used by `WebhookConfig`.
+const CURRENT_CONFIG_VERSION string = CONFIG_VERSION_2
+
+// MigrationReport describes the changes made to a config by `MigrateConfig`.
+type MigrationReport struct {
+	// From is the version of the config format that was migrated.
+	From string
+	// To is the version of the config format that the config was migrated to.
+	To string
+	// Changes is the list of descriptions of the changes that were made, in the order they were made.
+	Changes []string
+}
+
+// migration converts a config, decoded as a generic dictionary, from one version of the config format to the next.
+type migration struct {
+	// from is the version that the migration converts from.
+	from string
+	// to is the version that the migration converts to.
+	to string
+	// migrate updates 'raw' in place and returns the list of descriptions of the changes it made.
+	migrate func(raw map[string]interface{}) ([]string, error)
+}
+
+// migrations is the list of migrations, in the order they are applied. Future versions of the config format add a migration from
+// the previous version here.
+var migrations = []*migration{
+	{from: CONFIG_VERSION_1, to: CONFIG_VERSION_2, migrate: migrateV1},
+}
+
+// componentKeys is the list of config (and tenant config) properties containing dictionaries of component labels and URIs.
+var componentKeys = []string{"receivers", "sources", "transformations", "dispatchers", "middleware"}
+
+// v1Hosts is a dictionary of v1 component names and the v1 property whose value is used as the host of the URI for that component.
+// The properties of all other components are encoded as query parameters.
+var v1Hosts = map[string]string{
+	"chicken": "language",
+}
+
+// MigrateConfig converts the JSON-encoded config in 'body', in any known version of the config format, to the current format
+// and validates the result (see `Validate`). It returns the migrated `WebhookConfig` and a `MigrationReport` describing the changes
+// that were made; if 'body' is already in the current format the report has no changes. Properties that are not part of the
+// current format are an error rather than being silently discarded.
+func MigrateConfig(ctx context.Context, body []byte) (*WebhookConfig, *MigrationReport, error) {
+
+	var raw map[string]interface{}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+
+	err := dec.Decode(&raw)
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to decode config, %w", err)
+	}
+
+	version := ConfigVersion(raw)
+
+	report := &MigrationReport{
+		From:    version,
+		To:      version,
+		Changes: make([]string, 0),
+	}
+
+	for _, m := range migrations {
+
+		if m.from != report.To {
+			continue
+		}
+
+		changes, err := m.migrate(raw)
+
+		if err != nil {
+			return nil, nil, fmt.Errorf("Failed to migrate config from version %s to version %s, %w", m.from, m.to, err)
+		}
+
+		report.To = m.to
+		report.Changes = append(report.Changes, changes...)
+	}
+
+	enc_body, err := json.Marshal(raw)
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to encode migrated config, %w", err)
+	}
+
+	var cfg *WebhookConfig
+
+	dec = json.NewDecoder(bytes.NewReader(enc_body))
+	dec.DisallowUnknownFields()
+
+	err = dec.Decode(&cfg)
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to decode migrated config, %w", err)
+	}
+
+	err = cfg.Validate()
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("Migrated config is invalid, %w", err)
+	}
+
+	return cfg, report, nil
+}
+
+// ConfigVersion returns the version of the config format (one of the CONFIG_VERSION_ constants) used by 'raw', a JSON-encoded
+// config decoded as a generic dictionary.
+func ConfigVersion(raw map[string]interface{}) string {
+
+	if _, ok := raw["daemon"].(map[string]interface{}); ok {
+		return CONFIG_VERSION_1
+	}
+
+	if hasV1Components(raw) {
+		return CONFIG_VERSION_1
+	}
+
+	tenants, _ := raw["tenants"].(map[string]interface{})
+
+	for _, v := range tenants {
+
+		t, ok := v.(map[string]interface{})
+
+		if ok && hasV1Components(t) {
+			return CONFIG_VERSION_1
+		}
+	}
+
+	return CURRENT_CONFIG_VERSION
+}
+
+// hasV1Components returns a boolean value indicating whether any of the components in 'raw' are defined by v1 dictionaries.
+func hasV1Components(raw map[string]interface{}) bool {
+
+	for _, key := range componentKeys {
+
+		components, _ := raw[key].(map[string]interface{})
+
+		for _, v := range components {
+
+			if _, ok := v.(map[string]interface{}); ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// migrateV1 converts the daemon dictionary in 'raw' and each component dictionary, in 'raw' and its tenants, to URI strings.
+func migrateV1(raw map[string]interface{}) ([]string, error) {
+
+	changes := make([]string, 0)
+
+	if d, ok := raw["daemon"].(map[string]interface{}); ok {
+
+		uri, err := v1DaemonURI(d)
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid daemon, %w", err)
+		}
+
+		raw["daemon"] = uri
+		changes = append(changes, fmt.Sprintf("daemon: %s", uri))
+	}
+
+	c, err := migrateV1Components(raw, "")
+
+	if err != nil {
+		return nil, err
+	}
+
+	changes = append(changes, c...)
+
+	tenants, _ := raw["tenants"].(map[string]interface{})
+
+	for _, name := range sortedKeys(tenants) {
+
+		t, ok := tenants[name].(map[string]interface{})
+
+		if !ok {
+			continue
+		}
+
+		c, err := migrateV1Components(t, "tenants."+name+".")
+
+		if err != nil {
+			return nil, err
+		}
+
+		changes = append(changes, c...)
+	}
+
+	return changes, nil
+}
+
+// migrateV1Components converts each component dictionary in 'raw' to a URI string. 'prefix' is prepended to the property names
+// in the descriptions of the changes.
+func migrateV1Components(raw map[string]interface{}, prefix string) ([]string, error) {
+
+	changes := make([]string, 0)
+
+	for _, key := range componentKeys {
+
+		components, _ := raw[key].(map[string]interface{})
+
+		for _, label := range sortedKeys(components) {
+
+			c, ok := components[label].(map[string]interface{})
+
+			if !ok {
+				continue
+			}
+
+			uri, err := v1ComponentURI(label, c)
+
+			if err != nil {
+				return nil, fmt.Errorf("Invalid %s%s.%s, %w", prefix, key, label, err)
+			}
+
+			components[label] = uri
+			changes = append(changes, fmt.Sprintf("%s%s.%s: %s", prefix, key, label, uri))
+		}
+	}
+
+	return changes, nil
+}
+
+// v1DaemonURI returns the URI for the v1 daemon dictionary 'd'. Properties other than "protocol", "host" and "port" are encoded
+// as query parameters.
+func v1DaemonURI(d map[string]interface{}) (string, error) {
+
+	props := make(map[string]interface{})
+
+	for k, v := range d {
+		props[k] = v
+	}
+
+	protocol, err := popString(props, "protocol")
+
+	if err != nil {
+		return "", err
+	}
+
+	if protocol == "" {
+		protocol = "http"
+	}
+
+	host, err := popString(props, "host")
+
+	if err != nil {
+		return "", err
+	}
+
+	if host == "" {
+		host = "localhost"
+	}
+
+	port, err := popString(props, "port")
+
+	if err != nil {
+		return "", err
+	}
+
+	u := &url.URL{
+		Scheme: strings.ToLower(protocol),
+		Host:   host,
+	}
+
+	if port != "" {
+		u.Host = fmt.Sprintf("%s:%s", host, port)
+	}
+
+	q, err := queryFromProperties(props)
+
+	if err != nil {
+		return "", err
+	}
+
+	u.RawQuery = q.Encode()
+
+	return u.String(), nil
+}
+
+// v1ComponentURI returns the URI for the v1 component dictionary 'c' whose label is 'label'. The scheme is derived from the "name"
+// property, or 'label' if it is missing, and the remaining properties are encoded as query parameters (see `v1Hosts`).
+func v1ComponentURI(label string, c map[string]interface{}) (string, error) {
+
+	props := make(map[string]interface{})
+
+	for k, v := range c {
+		props[k] = v
+	}
+
+	name, err := popString(props, "name")
+
+	if err != nil {
+		return "", err
+	}
+
+	if name == "" {
+		name = label
+	}
+
+	u := &url.URL{
+		Scheme: strings.ToLower(name),
+	}
+
+	host_key, ok := v1Hosts[u.Scheme]
+
+	if ok {
+
+		host, err := popString(props, host_key)
+
+		if err != nil {
+			return "", err
+		}
+
+		u.Host = host
+	}
+
+	q, err := queryFromProperties(props)
+
+	if err != nil {
+		return "", err
+	}
+
+	u.RawQuery = q.Encode()
+
+	// url.URL.String omits the "//" for URIs without a host
+
+	uri := u.String()
+
+	if u.Host == "" {
+		uri = strings.Replace(uri, ":", "://", 1)
+	}
+
+	return uri, nil
+}
+
+// popString removes 'key' from 'props' and returns its value as a string. It is an error if the value is not a string, number or boolean.
+func popString(props map[string]interface{}, key string) (string, error) {
+
+	v, ok := props[key]
+
+	if !ok {
+		return "", nil
+	}
+
+	delete(props, key)
+
+	str, err := scalarString(v)
+
+	if err != nil {
+		return "", fmt.Errorf("Invalid %s property, %w", key, err)
+	}
+
+	return str, nil
+}
+
+// queryFromProperties returns the query parameters for 'props'. Lists are encoded as repeated parameters.
+func queryFromProperties(props map[string]interface{}) (url.Values, error) {
+
+	q := url.Values{}
+
+	for _, k := range sortedKeys(props) {
+
+		switch v := props[k].(type) {
+		case []interface{}:
+
+			for _, el := range v {
+
+				str, err := scalarString(el)
+
+				if err != nil {
+					return nil, fmt.Errorf("Invalid %s property, %w", k, err)
+				}
+
+				q.Add(k, str)
+			}
+
+		default:
+
+			str, err := scalarString(v)
+
+			if err != nil {
+				return nil, fmt.Errorf("Invalid %s property, %w", k, err)
+			}
+
+			q.Set(k, str)
+		}
+	}
+
+	return q, nil
+}
+
+// scalarString returns the string representation of the JSON string, number or boolean 'v'.
+func scalarString(v interface{}) (string, error) {
+
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	default:
+		return "", fmt.Errorf("Unsupported value %v", v)
+	}
+}
+
+// sortedKeys returns the keys of 'm' in sorted order.
+func sortedKeys(m map[string]interface{}) []string {
+
+	keys := make([]string, 0, len(m))
+
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
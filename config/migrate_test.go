@@ -0,0 +1,190 @@
+package config
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+)
+
+const example_v1_config string = `{
+	"daemon": {
+		"protocol": "http",
+		"host": "localhost",
+		"port": 8080,
+		"allow_debug": true
+	},
+	"receivers": {
+		"insecure": {
+			"name": "Insecure"
+		},
+		"github": {
+			"name": "GitHub",
+			"secret": "s33kret",
+			"ref": "refs/heads/main"
+		}
+	},
+	"transformations": {
+		"chicken": {
+			"name": "Chicken",
+			"language": "zxx",
+			"clucking": false
+		},
+		"null": "null://"
+	},
+	"dispatchers": {
+		"log": {
+			"name": "Log"
+		},
+		"tee": {
+			"name": "Tee",
+			"dispatcher": ["log://", "null://"]
+		}
+	},
+	"webhooks": [
+		{
+			"endpoint": "/insecure-test",
+			"receiver": "insecure",
+			"transformations": ["chicken"],
+			"dispatchers": ["log"]
+		},
+		{
+			"endpoint": "/github-test",
+			"receiver": "github",
+			"transformations": [],
+			"dispatchers": ["tee"]
+		}
+	]
+}`
+
+func TestMigrateConfig(t *testing.T) {
+
+	ctx := context.Background()
+
+	cfg, report, err := MigrateConfig(ctx, []byte(example_v1_config))
+
+	if err != nil {
+		t.Fatalf("Failed to migrate config, %v", err)
+	}
+
+	if report.From != CONFIG_VERSION_1 || report.To != CURRENT_CONFIG_VERSION {
+		t.Fatalf("Unexpected versions %s -> %s", report.From, report.To)
+	}
+
+	if len(report.Changes) != 6 {
+		t.Fatalf("Expected 6 changes but got %d: %v", len(report.Changes), report.Changes)
+	}
+
+	if cfg.Daemon != "http://localhost:8080?allow_debug=true" {
+		t.Fatalf("Unexpected daemon '%s'", cfg.Daemon)
+	}
+
+	expected := map[string]map[string]string{
+		"receivers": {
+			"insecure": "insecure://",
+			"github":   "github://?ref=refs%2Fheads%2Fmain&secret=s33kret",
+		},
+		"transformations": {
+			"chicken": "chicken://zxx?clucking=false",
+			"null":    "null://",
+		},
+		"dispatchers": {
+			"log": "log://",
+			"tee": "tee://?dispatcher=log%3A%2F%2F&dispatcher=null%3A%2F%2F",
+		},
+	}
+
+	actual := map[string]map[string]string{
+		"receivers":       cfg.Receivers,
+		"transformations": cfg.Transformations,
+		"dispatchers":     cfg.Dispatchers,
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("Unexpected components %v", actual)
+	}
+
+	if len(cfg.Webhooks) != 2 || cfg.Webhooks[0].Receiver != "insecure" {
+		t.Fatalf("Unexpected webhooks %v", cfg.Webhooks)
+	}
+}
+
+func TestMigrateConfigCurrent(t *testing.T) {
+
+	ctx := context.Background()
+
+	body, err := os.ReadFile(example_config)
+
+	if err != nil {
+		t.Fatalf("Failed to read %s, %v", example_config, err)
+	}
+
+	cfg, report, err := MigrateConfig(ctx, body)
+
+	if err != nil {
+		t.Fatalf("Failed to migrate config, %v", err)
+	}
+
+	if report.From != CURRENT_CONFIG_VERSION || len(report.Changes) != 0 {
+		t.Fatalf("Expected current config to be unchanged, %v", report)
+	}
+
+	if cfg.Daemon != "http://localhost:8080" {
+		t.Fatalf("Unexpected daemon '%s'", cfg.Daemon)
+	}
+}
+
+func TestMigrateConfigTenants(t *testing.T) {
+
+	ctx := context.Background()
+
+	body := `{
+		"daemon": "http://localhost:8080",
+		"tenants": {
+			"acme": {
+				"receivers": { "insecure": { "name": "Insecure" } },
+				"dispatchers": { "log": { "name": "Log" } },
+				"webhooks": [ { "endpoint": "/test", "receiver": "insecure", "dispatchers": [ "log" ] } ]
+			}
+		}
+	}`
+
+	cfg, report, err := MigrateConfig(ctx, []byte(body))
+
+	if err != nil {
+		t.Fatalf("Failed to migrate config, %v", err)
+	}
+
+	if report.From != CONFIG_VERSION_1 || len(report.Changes) != 2 {
+		t.Fatalf("Unexpected report %v", report)
+	}
+
+	if cfg.Tenants["acme"].Receivers["insecure"] != "insecure://" {
+		t.Fatalf("Unexpected tenant receivers %v", cfg.Tenants["acme"].Receivers)
+	}
+}
+
+func TestMigrateConfigInvalid(t *testing.T) {
+
+	ctx := context.Background()
+
+	tests := []string{
+		// Not JSON
+		`daemon: http://localhost:8080`,
+		// Nested properties can not be encoded as query parameters
+		`{"daemon": "http://localhost:8080", "receivers": {"insecure": {"name": "Insecure", "options": {"a": 1}}}, "webhooks": []}`,
+		// Unknown properties
+		`{"daemon": {"protocol": "http", "port": 8080}, "receivers": {}, "dispatchers": {}, "webhooks": [], "logging": true}`,
+		// Undefined dispatcher
+		`{"daemon": {"protocol": "http", "port": 8080}, "receivers": {"insecure": {"name": "Insecure"}}, "dispatchers": {}, "webhooks": [{"endpoint": "/test", "receiver": "insecure", "dispatchers": ["log"]}]}`,
+	}
+
+	for _, body := range tests {
+
+		_, _, err := MigrateConfig(ctx, []byte(body))
+
+		if err == nil {
+			t.Fatalf("Expected migrating '%s' to fail", body)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Validate returns an error if 'c' is missing a daemon URI, defines a component URI that can not be parsed or has a webhook, pipeline
+// or global middleware list that refers to a component label that is not defined. The webhooks for each tenant are validated
+// against the components defined by that tenant. Whether the schemes of component URIs have been registered is not checked.
+func (c *WebhookConfig) Validate() error {
+
+	if c.Daemon == "" {
+		return fmt.Errorf("Missing daemon URI")
+	}
+
+	err := validateURI(c.Daemon)
+
+	if err != nil {
+		return fmt.Errorf("Invalid daemon URI, %w", err)
+	}
+
+	err = c.validateComponents()
+
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(c.Tenants))
+
+	for name := range c.Tenants {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+
+		t := c.Tenants[name]
+
+		err := t.WebhookConfig().validateComponents()
+
+		if err != nil {
+			return fmt.Errorf("Invalid tenant '%s', %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// validateComponents returns an error if any of the component URIs in 'c' can not be parsed or any of its webhooks, pipelines or
+// global middleware refer to a component label that is not defined.
+func (c *WebhookConfig) validateComponents() error {
+
+	components := []struct {
+		kind string
+		uris map[string]string
+	}{
+		{"receiver", c.Receivers},
+		{"source", c.Sources},
+		{"transformation", c.Transformations},
+		{"dispatcher", c.Dispatchers},
+		{"middleware", c.Middleware},
+	}
+
+	for _, comp := range components {
+
+		for label, uri := range comp.uris {
+
+			err := validateURI(uri)
+
+			if err != nil {
+				return fmt.Errorf("Invalid URI for %s '%s', %w", comp.kind, label, err)
+			}
+		}
+	}
+
+	has_transformation := func(label string) bool {
+		_, is_transformation := c.Transformations[label]
+		_, is_pipeline := c.Pipelines[label]
+		return is_transformation || is_pipeline
+	}
+
+	for label, p := range c.Pipelines {
+
+		if _, ok := c.Transformations[label]; ok {
+			return fmt.Errorf("Pipeline '%s' has the same label as a transformation", label)
+		}
+
+		labels := append([]string{}, p.Transformations...)
+
+		for _, branch := range p.Branches {
+			labels = append(labels, branch...)
+		}
+
+		for _, t := range labels {
+
+			if !has_transformation(t) {
+				return fmt.Errorf("Pipeline '%s' refers to undefined transformation '%s'", label, t)
+			}
+		}
+	}
+
+	for _, m := range c.GlobalMiddleware {
+
+		if _, ok := c.Middleware[m]; !ok {
+			return fmt.Errorf("Global middleware refers to undefined middleware '%s'", m)
+		}
+	}
+
+	for idx, wh := range c.Webhooks {
+
+		err := c.validateWebhook(wh, has_transformation)
+
+		if err != nil {
+			return fmt.Errorf("Invalid webhook at offset %d (%s), %w", idx, wh.Endpoint, err)
+		}
+	}
+
+	return nil
+}
+
+// validateWebhook returns an error if 'wh' does not define an endpoint and exactly one of a receiver or a source, or refers to a
+// component label that is not defined in 'c'. 'has_transformation' reports whether a label is a transformation or pipeline.
+func (c *WebhookConfig) validateWebhook(wh WebhookWebhooksConfig, has_transformation func(string) bool) error {
+
+	if !strings.HasPrefix(wh.Endpoint, "/") {
+		return fmt.Errorf("Endpoint must start with '/'")
+	}
+
+	switch {
+	case wh.Receiver != "" && wh.Source != "":
+		return fmt.Errorf("Webhooks may not define both a receiver and a source")
+	case wh.Receiver != "":
+
+		if _, ok := c.Receivers[wh.Receiver]; !ok {
+			return fmt.Errorf("Undefined receiver '%s'", wh.Receiver)
+		}
+
+	case wh.Source != "":
+
+		if _, ok := c.Sources[wh.Source]; !ok {
+			return fmt.Errorf("Undefined source '%s'", wh.Source)
+		}
+
+	default:
+		return fmt.Errorf("Missing receiver")
+	}
+
+	for _, t := range wh.Transformations {
+
+		if !has_transformation(t) {
+			return fmt.Errorf("Undefined transformation '%s'", t)
+		}
+	}
+
+	for _, m := range wh.Middleware {
+
+		if _, ok := c.Middleware[m]; !ok {
+			return fmt.Errorf("Undefined middleware '%s'", m)
+		}
+	}
+
+	dispatchers := append([]string{}, wh.Dispatchers...)
+
+	for _, r := range wh.Routes {
+		dispatchers = append(dispatchers, r.Dispatchers...)
+	}
+
+	for _, d := range dispatchers {
+
+		if _, ok := c.Dispatchers[d]; !ok {
+			return fmt.Errorf("Undefined dispatcher '%s'", d)
+		}
+	}
+
+	return nil
+}
+
+// validateURI returns an error if 'uri' can not be parsed or does not have a scheme.
+func validateURI(uri string) error {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return err
+	}
+
+	if u.Scheme == "" {
+		return fmt.Errorf("Missing scheme")
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+
+	cfg, err := newConfigFromURI()
+
+	if err != nil {
+		t.Fatalf("Failed to create new config from URI, %v", err)
+	}
+
+	err = cfg.Validate()
+
+	if err != nil {
+		t.Fatalf("Expected example config to be valid, %v", err)
+	}
+}
+
+func TestValidateInvalid(t *testing.T) {
+
+	valid := func() *WebhookConfig {
+
+		return &WebhookConfig{
+			Daemon:          "http://localhost:8080",
+			Receivers:       map[string]string{"insecure": "insecure://"},
+			Transformations: map[string]string{"null": "null://"},
+			Pipelines: map[string]WebhookPipelineConfig{
+				"p": {Transformations: []string{"null"}},
+			},
+			Dispatchers: map[string]string{"log": "log://"},
+			Webhooks: []WebhookWebhooksConfig{
+				{Endpoint: "/test", Receiver: "insecure", Transformations: []string{"p"}, Dispatchers: []string{"log"}},
+			},
+		}
+	}
+
+	err := valid().Validate()
+
+	if err != nil {
+		t.Fatalf("Expected config to be valid, %v", err)
+	}
+
+	tests := map[string]func(cfg *WebhookConfig){
+		"missing daemon":       func(cfg *WebhookConfig) { cfg.Daemon = "" },
+		"invalid receiver URI": func(cfg *WebhookConfig) { cfg.Receivers["insecure"] = "insecure" },
+		"undefined receiver":   func(cfg *WebhookConfig) { cfg.Webhooks[0].Receiver = "bogus" },
+		"missing receiver":     func(cfg *WebhookConfig) { cfg.Webhooks[0].Receiver = "" },
+		"receiver and source":  func(cfg *WebhookConfig) { cfg.Webhooks[0].Source = "bogus" },
+		"invalid endpoint":     func(cfg *WebhookConfig) { cfg.Webhooks[0].Endpoint = "test" },
+		"undefined dispatcher": func(cfg *WebhookConfig) { cfg.Webhooks[0].Dispatchers = []string{"bogus"} },
+		"undefined route": func(cfg *WebhookConfig) {
+			cfg.Webhooks[0].Routes = []WebhookRouteConfig{{Dispatchers: []string{"bogus"}}}
+		},
+		"undefined pipeline": func(cfg *WebhookConfig) { cfg.Webhooks[0].Transformations = []string{"bogus"} },
+		"undefined branch": func(cfg *WebhookConfig) {
+			cfg.Pipelines["p"] = WebhookPipelineConfig{Branches: map[string][]string{"a": {"bogus"}}}
+		},
+		"pipeline label":       func(cfg *WebhookConfig) { cfg.Pipelines["null"] = WebhookPipelineConfig{} },
+		"undefined middleware": func(cfg *WebhookConfig) { cfg.Webhooks[0].Middleware = []string{"bogus"} },
+		"undefined global":     func(cfg *WebhookConfig) { cfg.GlobalMiddleware = []string{"bogus"} },
+		"undefined tenant label": func(cfg *WebhookConfig) {
+			cfg.Tenants = map[string]WebhookTenantConfig{
+				"acme": {Webhooks: []WebhookWebhooksConfig{{Endpoint: "/test", Receiver: "insecure"}}},
+			}
+		},
+	}
+
+	for label, update := range tests {
+
+		cfg := valid()
+		update(cfg)
+
+		err := cfg.Validate()
+
+		if err == nil {
+			t.Fatalf("Expected config with %s to be invalid", label)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package webhookd
+
+import (
+	"context"
+	"crypto/sha256"
+	"net/http"
+	"sync"
+)
+
+// contextKey is a private type for keys used to store values in a `context.Context` instance.
+type contextKey string
+
+// pathParametersKey is the `context.Context` key used to store path parameters matched by a webhook endpoint.
+const pathParametersKey contextKey = "webhookd.path_parameters"
+
+// WithPathParameters returns a copy of 'ctx' containing the path parameters in 'params'.
+func WithPathParameters(ctx context.Context, params map[string]string) context.Context {
+	return context.WithValue(ctx, pathParametersKey, params)
+}
+
+// PathParameters returns the path parameters stored in 'ctx' or an empty dictionary if there are none.
+func PathParameters(ctx context.Context) map[string]string {
+
+	v := ctx.Value(pathParametersKey)
+
+	if v == nil {
+		return map[string]string{}
+	}
+
+	return v.(map[string]string)
+}
+
+// PathParameter returns the value of the path parameter 'name' stored in 'ctx' or an empty string if it is not present.
+func PathParameter(ctx context.Context, name string) string {
+	return PathParameters(ctx)[name]
+}
+
+// deliveryIDKey is the `context.Context` key used to store the unique identifier for a webhook message.
+const deliveryIDKey contextKey = "webhookd.delivery_id"
+
+// WithDeliveryID returns a copy of 'ctx' containing the unique identifier 'id' for a webhook message.
+func WithDeliveryID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, deliveryIDKey, id)
+}
+
+// DeliveryID returns the unique identifier for a webhook message stored in 'ctx' or an empty string if it is not present.
+func DeliveryID(ctx context.Context) string {
+
+	v := ctx.Value(deliveryIDKey)
+
+	if v == nil {
+		return ""
+	}
+
+	return v.(string)
+}
+
+// remoteAddressKey is the `context.Context` key used to store the network address of the client that sent a webhook message.
+const remoteAddressKey contextKey = "webhookd.remote_address"
+
+// WithRemoteAddress returns a copy of 'ctx' containing the network address 'addr' (without a port) of the client that sent a webhook message.
+func WithRemoteAddress(ctx context.Context, addr string) context.Context {
+	return context.WithValue(ctx, remoteAddressKey, addr)
+}
+
+// RemoteAddress returns the network address of the client that sent a webhook message stored in 'ctx' or an empty string if it is not present.
+func RemoteAddress(ctx context.Context) string {
+
+	v := ctx.Value(remoteAddressKey)
+
+	if v == nil {
+		return ""
+	}
+
+	return v.(string)
+}
+
+// messageHeadersKey is the `context.Context` key used to store the HTTP headers that dispatchers should attach to specific messages.
+const messageHeadersKey contextKey = "webhookd.message_headers"
+
+// messageHeaders is a thread-safe dictionary of HTTP headers keyed by the SHA-256 digest of the message they are attached to.
+type messageHeaders struct {
+	mu      *sync.Mutex
+	headers map[[sha256.Size]byte]http.Header
+}
+
+// WithMessageHeaders returns a copy of 'ctx' that can store HTTP headers for individual messages using `SetMessageHeader`.
+func WithMessageHeaders(ctx context.Context) context.Context {
+
+	h := &messageHeaders{
+		mu:      new(sync.Mutex),
+		headers: make(map[[sha256.Size]byte]http.Header),
+	}
+
+	return context.WithValue(ctx, messageHeadersKey, h)
+}
+
+// SetMessageHeader sets the HTTP header 'name' to 'value' for the message 'body' in 'ctx', for example so that a transformation
+// can pass a signature for 'body' to the dispatchers that send it. Headers are attached to the exact bytes of 'body' so any
+// subsequent transformation of 'body' discards them. It returns false if 'ctx' was not created by `WithMessageHeaders`.
+func SetMessageHeader(ctx context.Context, body []byte, name string, value string) bool {
+
+	v := ctx.Value(messageHeadersKey)
+
+	if v == nil {
+		return false
+	}
+
+	h := v.(*messageHeaders)
+	key := sha256.Sum256(body)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	headers, ok := h.headers[key]
+
+	if !ok {
+		headers = http.Header{}
+		h.headers[key] = headers
+	}
+
+	headers.Set(name, value)
+	return true
+}
+
+// MessageHeaders returns a copy of the HTTP headers for the message 'body' stored in 'ctx' or an empty `http.Header` if there are none.
+func MessageHeaders(ctx context.Context, body []byte) http.Header {
+
+	v := ctx.Value(messageHeadersKey)
+
+	if v == nil {
+		return http.Header{}
+	}
+
+	h := v.(*messageHeaders)
+	key := sha256.Sum256(body)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	headers, ok := h.headers[key]
+
+	if !ok {
+		return http.Header{}
+	}
+
+	return headers.Clone()
+}
+
+// challengeResponseKey is the `context.Context` key used to store the response to a verification challenge sent by a webhook provider.
+const challengeResponseKey contextKey = "webhookd.challenge_response"
+
+// challengeResponse is a thread-safe container for the response to a verification challenge.
+type challengeResponse struct {
+	mu           *sync.Mutex
+	content_type string
+	body         []byte
+	ok           bool
+}
+
+// WithChallengeResponse returns a copy of 'ctx' that can store the response to a verification challenge using `SetChallengeResponse`.
+func WithChallengeResponse(ctx context.Context) context.Context {
+
+	r := &challengeResponse{
+		mu: new(sync.Mutex),
+	}
+
+	return context.WithValue(ctx, challengeResponseKey, r)
+}
+
+// SetChallengeResponse sets the body, and content type, of the response to a verification challenge (for example a URL validation
+// request) sent by a webhook provider. Receivers that answer a challenge should then return a `UnhandledEvent` error so that the
+// challenge is not processed any further. It returns false if 'ctx' was not created by `WithChallengeResponse`.
+func SetChallengeResponse(ctx context.Context, content_type string, body []byte) bool {
+
+	v := ctx.Value(challengeResponseKey)
+
+	if v == nil {
+		return false
+	}
+
+	r := v.(*challengeResponse)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.content_type = content_type
+	r.body = body
+	r.ok = true
+
+	return true
+}
+
+// ChallengeResponse returns the content type and body of the response to a verification challenge stored in 'ctx' and a boolean
+// value indicating whether a response has been set.
+func ChallengeResponse(ctx context.Context) (string, []byte, bool) {
+
+	v := ctx.Value(challengeResponseKey)
+
+	if v == nil {
+		return "", nil, false
+	}
+
+	r := v.(*challengeResponse)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.content_type, r.body, r.ok
+}
+
+// deliveryKey is the `context.Context` key used to store the `Delivery` metadata for a webhook message.
+const deliveryKey contextKey = "webhookd.delivery"
+
+// Delivery is a struct containing metadata about a webhook message, and how it was received, that is available to the
+// transformations and dispatchers that process it.
+type Delivery struct {
+	// Endpoint is the endpoint of the webhook processing the message.
+	Endpoint string
+	// ID is the unique identifier for the message.
+	ID string
+	// EventType is the type of event the message describes, derived from a provider-specific header, or an empty string if unknown.
+	EventType string
+	// Header is the set of HTTP headers sent with the message. For messages consumed from a source these are the headers, if
+	// any, assigned by the source.
+	Header http.Header
+	// RemoteAddress is the network address of the client that sent the message or an empty string for messages consumed from a source.
+	RemoteAddress string
+	// PathParameters is the dictionary of path parameters matched by the webhook endpoint.
+	PathParameters map[string]string
+	// DryRun is a boolean flag signaling that the message will not be dispatched. Transformations with side effects (for example
+	// ones that record state) may choose to skip them.
+	DryRun bool
+}
+
+// WithDelivery returns a copy of 'ctx' containing the metadata in 'd'. The delivery ID, remote address and path parameters are
+// also stored so that they can be retrieved with the `DeliveryID`, `RemoteAddress` and `PathParameters` methods.
+func WithDelivery(ctx context.Context, d *Delivery) context.Context {
+
+	ctx = context.WithValue(ctx, deliveryKey, d)
+	ctx = WithDeliveryID(ctx, d.ID)
+	ctx = WithRemoteAddress(ctx, d.RemoteAddress)
+	ctx = WithPathParameters(ctx, d.PathParameters)
+
+	return ctx
+}
+
+// DeliveryFromContext returns the `Delivery` metadata stored in 'ctx' and a boolean value indicating whether it is present.
+// The returned value is shared by every step processing the message and should not be modified.
+func DeliveryFromContext(ctx context.Context) (*Delivery, bool) {
+
+	v := ctx.Value(deliveryKey)
+
+	if v == nil {
+		return nil, false
+	}
+
+	return v.(*Delivery), true
+}
@@ -0,0 +1,146 @@
+package webhookd
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestPathParameters(t *testing.T) {
+
+	ctx := context.Background()
+
+	if len(PathParameters(ctx)) != 0 {
+		t.Fatalf("Expected empty path parameters")
+	}
+
+	ctx = WithPathParameters(ctx, map[string]string{"owner": "whosonfirst"})
+
+	if PathParameter(ctx, "owner") != "whosonfirst" {
+		t.Fatalf("Unexpected owner parameter: %s", PathParameter(ctx, "owner"))
+	}
+}
+
+func TestDeliveryID(t *testing.T) {
+
+	ctx := context.Background()
+
+	if DeliveryID(ctx) != "" {
+		t.Fatalf("Expected empty delivery ID")
+	}
+
+	ctx = WithDeliveryID(ctx, "1234")
+
+	if DeliveryID(ctx) != "1234" {
+		t.Fatalf("Unexpected delivery ID: %s", DeliveryID(ctx))
+	}
+}
+
+func TestRemoteAddress(t *testing.T) {
+
+	ctx := context.Background()
+
+	if RemoteAddress(ctx) != "" {
+		t.Fatalf("Expected empty remote address")
+	}
+
+	ctx = WithRemoteAddress(ctx, "192.0.2.1")
+
+	if RemoteAddress(ctx) != "192.0.2.1" {
+		t.Fatalf("Unexpected remote address: %s", RemoteAddress(ctx))
+	}
+}
+
+func TestMessageHeaders(t *testing.T) {
+
+	ctx := context.Background()
+
+	if SetMessageHeader(ctx, []byte("hello"), "X-Test", "1") {
+		t.Fatalf("Expected SetMessageHeader to fail without a message headers context")
+	}
+
+	ctx = WithMessageHeaders(ctx)
+
+	if !SetMessageHeader(ctx, []byte("hello"), "X-Test", "1") {
+		t.Fatalf("Failed to set message header")
+	}
+
+	if MessageHeaders(ctx, []byte("hello")).Get("X-Test") != "1" {
+		t.Fatalf("Unexpected X-Test header: %s", MessageHeaders(ctx, []byte("hello")).Get("X-Test"))
+	}
+
+	if len(MessageHeaders(ctx, []byte("world"))) != 0 {
+		t.Fatalf("Expected no headers for a different message")
+	}
+}
+
+func TestChallengeResponse(t *testing.T) {
+
+	ctx := context.Background()
+
+	if SetChallengeResponse(ctx, "text/plain", []byte("hello")) {
+		t.Fatalf("Expected SetChallengeResponse to fail without a challenge response context")
+	}
+
+	ctx = WithChallengeResponse(ctx)
+
+	_, _, ok := ChallengeResponse(ctx)
+
+	if ok {
+		t.Fatalf("Expected no challenge response")
+	}
+
+	if !SetChallengeResponse(ctx, "text/plain", []byte("hello")) {
+		t.Fatalf("Failed to set challenge response")
+	}
+
+	content_type, body, ok := ChallengeResponse(ctx)
+
+	if !ok || content_type != "text/plain" || string(body) != "hello" {
+		t.Fatalf("Unexpected challenge response: %s %s", content_type, string(body))
+	}
+}
+
+func TestDelivery(t *testing.T) {
+
+	ctx := context.Background()
+
+	_, ok := DeliveryFromContext(ctx)
+
+	if ok {
+		t.Fatalf("Expected no delivery")
+	}
+
+	d := &Delivery{
+		Endpoint:       "/github/{repo}",
+		ID:             "1234",
+		EventType:      "push",
+		Header:         http.Header{"X-Github-Event": []string{"push"}},
+		RemoteAddress:  "192.0.2.1",
+		PathParameters: map[string]string{"repo": "go-webhookd"},
+	}
+
+	ctx = WithDelivery(ctx, d)
+
+	v, ok := DeliveryFromContext(ctx)
+
+	if !ok {
+		t.Fatalf("Expected delivery")
+	}
+
+	if v.EventType != "push" || v.Header.Get("X-GitHub-Event") != "push" {
+		t.Fatalf("Unexpected delivery, %v", v)
+	}
+
+	if DeliveryID(ctx) != "1234" {
+		t.Fatalf("Unexpected delivery ID: %s", DeliveryID(ctx))
+	}
+
+	if RemoteAddress(ctx) != "192.0.2.1" {
+		t.Fatalf("Unexpected remote address: %s", RemoteAddress(ctx))
+	}
+
+	if PathParameter(ctx, "repo") != "go-webhookd" {
+		t.Fatalf("Unexpected repo parameter: %s", PathParameter(ctx, "repo"))
+	}
+}
@@ -0,0 +1,131 @@
+// Package controller provides an interface for adding, updating and removing webhooks, while webhookd is running, from resources
+// managed outside of its config file, for example Kubernetes objects managed using GitOps.
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+
+	"github.com/aaronland/go-roster"
+	"github.com/whosonfirst/go-webhookd/v3/config"
+	"github.com/whosonfirst/go-webhookd/v3/secrets"
+)
+
+// Resource is a single webhook defined by a resource managed outside of the webhookd config file.
+type Resource struct {
+	// Name is the unique name of the resource, for example "{NAMESPACE}/{NAME}" for Kubernetes objects.
+	Name string
+	// Config is the `config.WebhookResourceConfig` defined by the resource. It is nil if the resource is invalid.
+	Config *config.WebhookResourceConfig
+	// Error is the reason the resource is invalid, if it is.
+	Error error
+}
+
+// ReconcileFunc is a function used to reconcile the webhooks for a webhookd process with the complete list of resources defined
+// by a `Controller`.
+type ReconcileFunc func(context.Context, []*Resource) error
+
+// Controller is an interface for watching resources that define webhooks outside of the webhookd config file.
+type Controller interface {
+	// Watch calls a `ReconcileFunc` with the complete list of resources, sorted by name, once they have been read and then each
+	// time they change until the context is cancelled or the `ReconcileFunc` returns an error.
+	Watch(context.Context, ReconcileFunc) error
+	// Close releases any resources associated with the controller.
+	Close() error
+}
+
+// controllers is a `aaronland/go-roster.Roster` instance used to maintain a list of registered `Controller` initialization functions.
+var controllers roster.Roster
+
+// ControllerInitializationFunc is a function used to initialize an implementation of the `Controller` interface.
+type ControllerInitializationFunc func(ctx context.Context, uri string) (Controller, error)
+
+// NewController returns a new `Controller` instance derived from 'uri'. The semantics of and requirements for 'uri' as specific to
+// the package implementing the interface.
+func NewController(ctx context.Context, uri string) (Controller, error) {
+
+	err := ensureRoster()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to ensure controller roster, %w", err)
+	}
+
+	uri, err = secrets.ResolveURI(ctx, uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to resolve secrets, %w", err)
+	}
+
+	parsed, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	scheme := parsed.Scheme
+
+	i, err := controllers.Driver(ctx, scheme)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to find initialization function for '%s', %w", scheme, err)
+	}
+
+	init_func := i.(ControllerInitializationFunc)
+	return init_func(ctx, uri)
+}
+
+// RegisterController associates 'scheme' with 'init_func' in an internal list of avilable `Controller` implementations.
+func RegisterController(ctx context.Context, scheme string, init_func ControllerInitializationFunc) error {
+
+	err := ensureRoster()
+
+	if err != nil {
+		return fmt.Errorf("Failed to ensure controller roster, %w", err)
+	}
+
+	return controllers.Register(ctx, scheme, init_func)
+}
+
+// ensureRoster ensures that a `aaronland/go-roster.Roster` instance used to maintain a list of registered `Controller`
+// initialization functions is present
+func ensureRoster() error {
+
+	if controllers == nil {
+
+		r, err := roster.NewDefaultRoster()
+
+		if err != nil {
+			return fmt.Errorf("Failed to create new roster, %w", err)
+		}
+
+		controllers = r
+	}
+
+	return nil
+}
+
+// Schemes returns the list of controller schemes that have been "registered".
+func Schemes() []string {
+
+	ctx := context.Background()
+	drivers := controllers.Drivers(ctx)
+
+	schemes := make([]string, len(drivers))
+
+	for idx, dr := range drivers {
+		schemes[idx] = fmt.Sprintf("%s://", dr)
+	}
+
+	sort.Strings(schemes)
+	return schemes
+}
+
+// sortResources sorts 'resources' by name.
+func sortResources(resources []*Resource) {
+
+	sort.Slice(resources, func(i, j int) bool {
+		return resources[i].Name < resources[j].Name
+	})
+}
@@ -0,0 +1,20 @@
+package controller
+
+import (
+	"context"
+	"testing"
+)
+
+func TestNewController(t *testing.T) {
+
+	ctx := context.Background()
+
+	for _, uri := range []string{"bogus://", "kubernetes://?endpoint=http://localhost:6443&namespace=webhookd&resource=pods"} {
+
+		_, err := NewController(ctx, uri)
+
+		if err == nil {
+			t.Fatalf("Expected %s to fail", uri)
+		}
+	}
+}
@@ -0,0 +1,314 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/whosonfirst/go-webhookd/v3/config"
+	"github.com/whosonfirst/go-webhookd/v3/kubernetes"
+)
+
+// KUBERNETES_GROUP is the API group for `Webhook` custom resources.
+const KUBERNETES_GROUP string = "webhookd.whosonfirst.org"
+
+// KUBERNETES_VERSION is the API version for `Webhook` custom resources.
+const KUBERNETES_VERSION string = "v1"
+
+// KUBERNETES_WEBHOOK_ANNOTATION is the annotation, with a value of "true", that identifies ConfigMaps defining webhooks.
+const KUBERNETES_WEBHOOK_ANNOTATION string = "webhookd.whosonfirst.org/webhook"
+
+// KUBERNETES_WEBHOOK_KEY is the key for the JSON-encoded `config.WebhookResourceConfig` in ConfigMaps defining webhooks.
+const KUBERNETES_WEBHOOK_KEY string = "webhook.json"
+
+// Kinds of Kubernetes resources that define webhooks.
+const (
+	KUBERNETES_RESOURCE_WEBHOOKS   string = "webhooks"
+	KUBERNETES_RESOURCE_CONFIGMAPS string = "configmaps"
+)
+
+// kubernetesWatchTimeout is the number of seconds after which the API server ends each watch, so that it is restarted.
+const kubernetesWatchTimeout int = 300
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterController(ctx, "kubernetes", NewKubernetesController)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// kubernetesObject is the subset of a `Webhook` custom resource or ConfigMap used by `KubernetesController`.
+type kubernetesObject struct {
+	Metadata kubernetes.ObjectMeta `json:"metadata"`
+	Spec     json.RawMessage       `json:"spec,omitempty"`
+	Data     map[string]string     `json:"data,omitempty"`
+}
+
+// kubernetesObjectList is the subset of a list of `Webhook` custom resources or ConfigMaps used by `KubernetesController`.
+type kubernetesObjectList struct {
+	Metadata kubernetes.ListMeta `json:"metadata"`
+	Items    []*kubernetesObject `json:"items"`
+}
+
+// KubernetesController implements the `Controller` interface for webhooks defined by Kubernetes objects, either `Webhook`
+// custom resources (in the "webhookd.whosonfirst.org/v1" API group) whose spec is a `config.WebhookResourceConfig` or ConfigMaps
+// with a "webhookd.whosonfirst.org/webhook: true" annotation whose "webhook.json" key contains a JSON-encoded
+// `config.WebhookResourceConfig`.
+type KubernetesController struct {
+	Controller
+	client   *kubernetes.Client
+	resource string
+	selector string
+}
+
+// NewKubernetesController returns a new `KubernetesController` instance configured by 'uri' in the form of:
+//
+//	kubernetes://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `resource={STRING}` The kind of object that defines webhooks, either "webhooks" (for `Webhook` custom resources) or
+// "configmaps". Default is "webhooks".
+// * `selector={STRING}` An optional label selector used to limit the objects that define webhooks.
+// * `namespace={STRING}` The namespace to watch. Default is the namespace of the pod webhookd is running in.
+// * `endpoint={URL}` The URL of the Kubernetes API server. Default is derived from the `KUBERNETES_SERVICE_HOST` and
+// `KUBERNETES_SERVICE_PORT` environment variables.
+// * `token={STRING}` The bearer token used to authenticate requests. Default is the pod's service account token.
+//
+// The service account must be allowed to "list" and "watch" the objects in the namespace.
+func NewKubernetesController(ctx context.Context, uri string) (Controller, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	resource := KUBERNETES_RESOURCE_WEBHOOKS
+
+	if q.Has("resource") {
+		resource = q.Get("resource")
+	}
+
+	switch resource {
+	case KUBERNETES_RESOURCE_WEBHOOKS, KUBERNETES_RESOURCE_CONFIGMAPS:
+		// pass
+	default:
+		return nil, fmt.Errorf("Invalid ?resource= parameter '%s'", resource)
+	}
+
+	client, err := kubernetes.NewClient(ctx, q)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create Kubernetes client, %w", err)
+	}
+
+	c := &KubernetesController{
+		client:   client,
+		resource: resource,
+		selector: q.Get("selector"),
+	}
+
+	return c, nil
+}
+
+// Watch lists the objects that define webhooks, calls 'fn' with the resources they define and then watches the objects for
+// changes, calling 'fn' after each one, until 'ctx' is cancelled. If the watch expires the objects are listed again.
+func (c *KubernetesController) Watch(ctx context.Context, fn ReconcileFunc) error {
+
+	for {
+
+		var list *kubernetesObjectList
+
+		status, err := c.client.Do(ctx, http.MethodGet, c.path(nil), nil, &list)
+
+		if err != nil {
+			return fmt.Errorf("Failed to list %s, %w", c.resource, err)
+		}
+
+		if status != http.StatusOK {
+			return fmt.Errorf("Failed to list %s, unexpected status %d", c.resource, status)
+		}
+
+		objects := make(map[string]*kubernetesObject)
+
+		for _, obj := range list.Items {
+
+			if c.include(obj) {
+				objects[c.name(obj)] = obj
+			}
+		}
+
+		err = fn(ctx, c.resources(objects))
+
+		if err != nil {
+			return err
+		}
+
+		resource_version := list.Metadata.ResourceVersion
+
+		for {
+
+			expired := false
+
+			q := url.Values{}
+			q.Set("resourceVersion", resource_version)
+			q.Set("allowWatchBookmarks", "true")
+			q.Set("timeoutSeconds", strconv.Itoa(kubernetesWatchTimeout))
+
+			err := c.client.Watch(ctx, c.path(q), func(ev *kubernetes.WatchEvent) error {
+
+				if ev.Type == kubernetes.WATCH_ERROR {
+					expired = true
+					return nil
+				}
+
+				var obj *kubernetesObject
+
+				err := json.Unmarshal(ev.Object, &obj)
+
+				if err != nil {
+					return fmt.Errorf("Failed to decode object, %w", err)
+				}
+
+				resource_version = obj.Metadata.ResourceVersion
+
+				if ev.Type == kubernetes.WATCH_BOOKMARK {
+					return nil
+				}
+
+				name := c.name(obj)
+
+				if ev.Type == kubernetes.WATCH_DELETED || !c.include(obj) {
+
+					_, ok := objects[name]
+
+					if !ok {
+						return nil
+					}
+
+					delete(objects, name)
+
+				} else {
+					objects[name] = obj
+				}
+
+				return fn(ctx, c.resources(objects))
+			})
+
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			if err != nil {
+				return err
+			}
+
+			if expired {
+				break
+			}
+		}
+	}
+}
+
+// Close is a no-op for `KubernetesController` instances.
+func (c *KubernetesController) Close() error {
+	return nil
+}
+
+// path returns the path of the collection of objects that define webhooks, with the query parameters in 'q' (if not nil) and
+// the label selector for 'c'.
+func (c *KubernetesController) path(q url.Values) string {
+
+	var path string
+
+	switch c.resource {
+	case KUBERNETES_RESOURCE_CONFIGMAPS:
+		path = fmt.Sprintf("/api/v1/namespaces/%s/configmaps", url.PathEscape(c.client.Namespace()))
+	default:
+		path = fmt.Sprintf("/apis/%s/%s/namespaces/%s/webhooks", KUBERNETES_GROUP, KUBERNETES_VERSION, url.PathEscape(c.client.Namespace()))
+	}
+
+	if q == nil {
+		q = url.Values{}
+	}
+
+	if c.selector != "" {
+		q.Set("labelSelector", c.selector)
+	}
+
+	if len(q) > 0 {
+		path = path + "?" + q.Encode()
+	}
+
+	return path
+}
+
+// include returns a boolean value indicating whether 'obj' defines a webhook. All `Webhook` custom resources define webhooks but
+// ConfigMaps must be annotated with `KUBERNETES_WEBHOOK_ANNOTATION`.
+func (c *KubernetesController) include(obj *kubernetesObject) bool {
+
+	if c.resource != KUBERNETES_RESOURCE_CONFIGMAPS {
+		return true
+	}
+
+	v, _ := strconv.ParseBool(obj.Metadata.Annotations[KUBERNETES_WEBHOOK_ANNOTATION])
+	return v
+}
+
+// name returns the unique name, in the form of "{NAMESPACE}/{NAME}", for 'obj'.
+func (c *KubernetesController) name(obj *kubernetesObject) string {
+	return obj.Metadata.Namespace + "/" + obj.Metadata.Name
+}
+
+// resources returns the list of `Resource` instances, sorted by name, for 'objects'.
+func (c *KubernetesController) resources(objects map[string]*kubernetesObject) []*Resource {
+
+	resources := make([]*Resource, 0, len(objects))
+
+	for name, obj := range objects {
+
+		r := &Resource{
+			Name: name,
+		}
+
+		var enc []byte
+
+		if c.resource == KUBERNETES_RESOURCE_CONFIGMAPS {
+
+			v, ok := obj.Data[KUBERNETES_WEBHOOK_KEY]
+
+			if ok {
+				enc = []byte(v)
+			}
+
+		} else {
+			enc = obj.Spec
+		}
+
+		var cfg *config.WebhookResourceConfig
+
+		err := json.Unmarshal(enc, &cfg)
+
+		switch {
+		case len(enc) == 0 || (err == nil && cfg == nil):
+			r.Error = fmt.Errorf("Missing webhook definition")
+		case err != nil:
+			r.Error = fmt.Errorf("Failed to decode webhook definition, %w", err)
+		default:
+			r.Config = cfg
+		}
+
+		resources = append(resources, r)
+	}
+
+	sortResources(resources)
+	return resources
+}
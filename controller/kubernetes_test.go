@@ -0,0 +1,144 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestKubernetesController(t *testing.T) {
+
+	ctx := context.Background()
+
+	foo := `{"metadata":{"name":"foo","namespace":"webhookd","resourceVersion":"%s"%s},"data":{"webhook.json":"{\"webhook\":{\"endpoint\":\"/foo\",\"receiver\":\"insecure\",\"dispatchers\":[\"null\"]}}"}}`
+
+	tests := map[string]struct {
+		list     string
+		watches  []string
+		expected []string
+	}{
+		"configmaps": {
+			list: `{"metadata":{"resourceVersion":"1"},"items":[` +
+				fmt.Sprintf(foo, "1", `,"annotations":{"webhookd.whosonfirst.org/webhook":"true"}`) + `,` +
+				`{"metadata":{"name":"other","namespace":"webhookd","resourceVersion":"1"}}]}`,
+			watches: []string{
+				`{"type":"ADDED","object":{"metadata":{"name":"bar","namespace":"webhookd","resourceVersion":"2","annotations":{"webhookd.whosonfirst.org/webhook":"true"}},"data":{}}}`,
+				`{"type":"MODIFIED","object":{"metadata":{"name":"other","namespace":"webhookd","resourceVersion":"3"}}}`,
+				`{"type":"BOOKMARK","object":{"metadata":{"resourceVersion":"4"}}}`,
+				`{"type":"MODIFIED","object":` + fmt.Sprintf(foo, "5", "") + `}`,
+			},
+			expected: []string{
+				"webhookd/foo=/foo",
+				"webhookd/bar=error,webhookd/foo=/foo",
+				"webhookd/bar=error",
+			},
+		},
+		"webhooks": {
+			list: `{"metadata":{"resourceVersion":"1"},"items":[{"metadata":{"name":"foo","namespace":"webhookd"},"spec":{"webhook":{"endpoint":"/foo"}}}]}`,
+			watches: []string{
+				`{"type":"ERROR","object":{"code":410}}`,
+				`{"type":"DELETED","object":{"metadata":{"name":"foo","namespace":"webhookd","resourceVersion":"2"}}}`,
+			},
+			expected: []string{
+				"webhookd/foo=/foo",
+				"webhookd/foo=/foo",
+				"",
+			},
+		},
+	}
+
+	for resource, test := range tests {
+
+		mu := new(sync.Mutex)
+		watches := 0
+		versions := make([]string, 0)
+
+		handler := func(rsp http.ResponseWriter, req *http.Request) {
+
+			q := req.URL.Query()
+
+			if q.Get("labelSelector") != "app=webhookd" {
+				http.Error(rsp, "Bad request", http.StatusBadRequest)
+				return
+			}
+
+			if q.Get("watch") != "1" {
+				rsp.Write([]byte(test.list))
+				return
+			}
+
+			mu.Lock()
+			watches += 1
+			idx := watches
+			versions = append(versions, q.Get("resourceVersion"))
+			mu.Unlock()
+
+			// Deliver one event per watch, after which watches block until the client goes away
+
+			if idx > len(test.watches) {
+				<-req.Context().Done()
+				return
+			}
+
+			fmt.Fprintln(rsp, test.watches[idx-1])
+		}
+
+		ts := httptest.NewServer(http.HandlerFunc(handler))
+
+		uri := fmt.Sprintf("kubernetes://?endpoint=%s&namespace=webhookd&token=s33kret&selector=app%%3Dwebhookd&resource=%s", ts.URL, resource)
+
+		c, err := NewController(ctx, uri)
+
+		if err != nil {
+			t.Fatalf("Failed to create new controller, %v", err)
+		}
+
+		results := make([]string, 0)
+
+		watch_ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+
+		err = c.Watch(watch_ctx, func(ctx context.Context, resources []*Resource) error {
+
+			summary := make([]string, len(resources))
+
+			for idx, r := range resources {
+
+				if r.Error != nil {
+					summary[idx] = r.Name + "=error"
+				} else {
+					summary[idx] = r.Name + "=" + r.Config.Webhook.Endpoint
+				}
+			}
+
+			results = append(results, strings.Join(summary, ","))
+
+			if len(results) == len(test.expected) {
+				cancel()
+			}
+
+			return nil
+		})
+
+		cancel()
+		ts.Close()
+
+		if err != nil {
+			t.Fatalf("Failed to watch %s, %v", resource, err)
+		}
+
+		if strings.Join(results, ";") != strings.Join(test.expected, ";") {
+			t.Fatalf("Unexpected results for %s, %v", resource, results)
+		}
+
+		// Bookmarks update the resource version that watches are restarted from
+
+		if resource == "configmaps" && strings.Join(versions, ",") != "1,2,3,4" {
+			t.Fatalf("Unexpected resource versions, %v", versions)
+		}
+	}
+}
@@ -0,0 +1,516 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+// Valid formats for access logs.
+const (
+	ACCESS_LOG_COMBINED string = "combined"
+	ACCESS_LOG_JSON     string = "json"
+	ACCESS_LOG_LOGFMT   string = "logfmt"
+)
+
+// OUTCOME_SKIPPED is the outcome recorded for dispatchers that a message was not relayed to because an earlier dispatcher, in a
+// sequential dispatch, halted the processing flow or failed.
+const OUTCOME_SKIPPED string = "skipped"
+
+// contextKey is a private type for keys used to store values in a `context.Context` instance.
+type contextKey string
+
+// accessLogEntryKey is the key used to store the `accessLogEntry` for a request in a `context.Context` instance.
+const accessLogEntryKey contextKey = "webhookd.daemon.access_log_entry"
+
+// accessLog writes an entry for each webhook request, separate from application logs, to a sink.
+type accessLog struct {
+	// format is the format of each entry, one of `ACCESS_LOG_COMBINED`, `ACCESS_LOG_JSON` or `ACCESS_LOG_LOGFMT`.
+	format string
+	// writer is the sink that entries are written to.
+	writer io.Writer
+	// mu is a `sync.Mutex` used to ensure that entries are written atomically.
+	mu *sync.Mutex
+}
+
+// accessLogEntry is the record of a single webhook request. Values are populated by the webhook handler, as a request is
+// processed, using the methods for `accessLogEntry` all of which are safe to call on a nil instance.
+type accessLogEntry struct {
+	mu            *sync.Mutex
+	Time          time.Time
+	RemoteAddress string
+	Method        string
+	Path          string
+	Protocol      string
+	Referer       string
+	UserAgent     string
+	Endpoint      string
+	DeliveryID    string
+	EventType     string
+	DryRun        bool
+	Status        int
+	Size          int64
+	Timings       map[string]time.Duration
+	Dispatchers   []*accessLogDispatch
+}
+
+// accessLogDispatch is the result of relaying a message to a dispatcher.
+type accessLogDispatch struct {
+	// Dispatcher is the type of the dispatcher.
+	Dispatcher string `json:"dispatcher"`
+	// Offset is the offset of the dispatcher in the list of dispatchers for the webhook.
+	Offset int `json:"offset"`
+	// Outcome is one of `OUTCOME_DISPATCHED`, `OUTCOME_UNHANDLED`, `OUTCOME_HALTED`, `OUTCOME_FAILED` or `OUTCOME_SKIPPED`.
+	Outcome string `json:"outcome"`
+	// Error is the error message for failed dispatches.
+	Error string `json:"error,omitempty"`
+}
+
+// accessLogResponseWriter is a `http.ResponseWriter` that records the status code and size of a response.
+type accessLogResponseWriter struct {
+	http.ResponseWriter
+	status int
+	size   int64
+}
+
+func (w *accessLogResponseWriter) WriteHeader(status int) {
+
+	if w.status == 0 {
+		w.status = status
+	}
+
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *accessLogResponseWriter) Write(b []byte) (int, error) {
+
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	n, err := w.ResponseWriter.Write(b)
+	w.size += int64(n)
+
+	return n, err
+}
+
+// Flush implements the `http.Flusher` interface if the underlying `http.ResponseWriter` does.
+func (w *accessLogResponseWriter) Flush() {
+
+	f, ok := w.ResponseWriter.(http.Flusher)
+
+	if ok {
+		f.Flush()
+	}
+}
+
+// newAccessLog returns a new `accessLog` instance derived from 'uri'. See `AddAccessLog` for details.
+func newAccessLog(ctx context.Context, uri string) (*accessLog, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse access log URI, %w", err)
+	}
+
+	wr, err := openLogSink(u)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open access log, %w", err)
+	}
+
+	format := ACCESS_LOG_COMBINED
+
+	str_format := u.Query().Get("format")
+
+	switch str_format {
+	case "":
+		// pass
+	case ACCESS_LOG_COMBINED, ACCESS_LOG_JSON, ACCESS_LOG_LOGFMT:
+		format = str_format
+	default:
+		return nil, fmt.Errorf("Invalid ?format= parameter '%s'", str_format)
+	}
+
+	l := &accessLog{
+		format: format,
+		writer: wr,
+		mu:     new(sync.Mutex),
+	}
+
+	return l, nil
+}
+
+// AddAccessLog() configures 'd' to write an entry for each webhook request, separate from application logs, to a sink. Entries
+// include the webhook endpoint, the remote address, the event type, the response status, per-stage timings and the result of each
+// dispatcher. 'uri' is expected to take the form of:
+//
+//	stdout://?{PARAMETERS}
+//	stderr://?{PARAMETERS}
+//	file://{PATH}?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `format={STRING}` The format for each entry, one of "combined" (the Apache combined log format), "json" or "logfmt". The
+// "combined" format only includes the fields defined by that format. Default is "combined".
+func (d *WebhookDaemon) AddAccessLog(ctx context.Context, uri string) error {
+
+	l, err := newAccessLog(ctx, uri)
+
+	if err != nil {
+		return err
+	}
+
+	d.accessLog = l
+	return nil
+}
+
+// accessLogHandler returns a `http.Handler` that writes an access log entry, to 'l' if not nil, for each request processed by
+// 'next'. The same entry is used to emit metrics and to evaluate service level objectives if 'd' has been configured to do so.
+func (d *WebhookDaemon) accessLogHandler(l *accessLog, next http.Handler) http.Handler {
+	logger := log.Default()
+	return d.accessLogHandlerWithLogger(l, next, logger)
+}
+
+// accessLogHandlerWithLogger returns a `http.Handler` that writes an access log entry, to 'l' if not nil, for each request processed
+// by 'next' logging events (for example breaches of service level objectives) to 'logger'.
+func (d *WebhookDaemon) accessLogHandlerWithLogger(l *accessLog, next http.Handler, logger *log.Logger) http.Handler {
+
+	fn := func(rsp http.ResponseWriter, req *http.Request) {
+
+		entry := &accessLogEntry{
+			mu:            new(sync.Mutex),
+			Time:          time.Now(),
+			RemoteAddress: remoteAddress(req, d.RemoteAddressHeader),
+			Method:        req.Method,
+			Path:          redactedRequestURI(req.URL),
+			Protocol:      req.Proto,
+			Referer:       req.Referer(),
+			UserAgent:     req.UserAgent(),
+			EventType:     eventType(req.Header),
+			Timings:       make(map[string]time.Duration),
+		}
+
+		w := &accessLogResponseWriter{
+			ResponseWriter: rsp,
+		}
+
+		ctx := context.WithValue(req.Context(), accessLogEntryKey, entry)
+		next.ServeHTTP(w, req.WithContext(ctx))
+
+		entry.Status = w.status
+		entry.Size = w.size
+
+		if entry.Status == 0 {
+			entry.Status = http.StatusOK
+		}
+
+		if l != nil {
+			l.write(entry)
+		}
+
+		wh, _ := d.getWebhook(entry.Endpoint)
+		d.metrics.record(entry, wh.Labels(), wh.Tenant())
+		d.recordSLO(entry, time.Since(entry.Time), logger)
+	}
+
+	return http.HandlerFunc(fn)
+}
+
+// write writes 'entry' to 'l' in the format that 'l' was instantiated with.
+func (l *accessLog) write(entry *accessLogEntry) {
+
+	var line []byte
+
+	switch l.format {
+	case ACCESS_LOG_JSON:
+		line = entry.json()
+	case ACCESS_LOG_LOGFMT:
+		line = entry.logfmt()
+	default:
+		line = entry.combined()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.writer.Write(append(line, '\n'))
+}
+
+// accessLogEntryFromContext returns the `accessLogEntry` stored in 'ctx' or nil if there is none.
+func accessLogEntryFromContext(ctx context.Context) *accessLogEntry {
+
+	v := ctx.Value(accessLogEntryKey)
+
+	if v == nil {
+		return nil
+	}
+
+	return v.(*accessLogEntry)
+}
+
+// setWebhook records the endpoint of the webhook, and the delivery ID, for a request.
+func (e *accessLogEntry) setWebhook(endpoint string, delivery_id string) {
+
+	if e == nil {
+		return
+	}
+
+	e.Endpoint = endpoint
+	e.DeliveryID = delivery_id
+}
+
+// setDryRun records whether a request is a dry run.
+func (e *accessLogEntry) setDryRun(dryrun bool) {
+
+	if e == nil {
+		return
+	}
+
+	e.DryRun = dryrun
+}
+
+// setTiming records the time, 'd', taken by the processing stage 'stage'.
+func (e *accessLogEntry) setTiming(stage string, d time.Duration) {
+
+	if e == nil {
+		return
+	}
+
+	e.Timings[stage] = d
+}
+
+// addDispatch records the result, 'err', of relaying a message to the dispatcher 'd' at offset 'offset'. It is safe to call
+// concurrently.
+func (e *accessLogEntry) addDispatch(d webhookd.WebhookDispatcher, offset int, err *webhookd.WebhookError) {
+
+	if e == nil {
+		return
+	}
+
+	r := &accessLogDispatch{
+		Dispatcher: fmt.Sprintf("%T", d),
+		Offset:     offset,
+		Outcome:    OUTCOME_DISPATCHED,
+	}
+
+	if err != nil {
+
+		switch err.Code {
+		case webhookd.UnhandledEvent:
+			r.Outcome = OUTCOME_UNHANDLED
+		case webhookd.HaltEvent:
+			r.Outcome = OUTCOME_HALTED
+		default:
+			r.Outcome = OUTCOME_FAILED
+			r.Error = err.Error()
+		}
+	}
+
+	e.mu.Lock()
+	e.Dispatchers = append(e.Dispatchers, r)
+	e.mu.Unlock()
+}
+
+// skipDispatch records that a message was not relayed to the dispatcher 'd' at offset 'offset'. It is safe to call concurrently.
+func (e *accessLogEntry) skipDispatch(d webhookd.WebhookDispatcher, offset int) {
+
+	if e == nil {
+		return
+	}
+
+	r := &accessLogDispatch{
+		Dispatcher: fmt.Sprintf("%T", d),
+		Offset:     offset,
+		Outcome:    OUTCOME_SKIPPED,
+	}
+
+	e.mu.Lock()
+	e.Dispatchers = append(e.Dispatchers, r)
+	e.mu.Unlock()
+}
+
+// combined returns 'e' in the Apache combined log format.
+func (e *accessLogEntry) combined() []byte {
+
+	dash := func(v string) string {
+
+		if v == "" {
+			return "-"
+		}
+
+		return v
+	}
+
+	quote := func(v string) string {
+
+		if v == "" {
+			return `"-"`
+		}
+
+		return strconv.Quote(v)
+	}
+
+	size := "-"
+
+	if e.Size > 0 {
+		size = strconv.FormatInt(e.Size, 10)
+	}
+
+	request := fmt.Sprintf("%s %s %s", e.Method, e.Path, e.Protocol)
+
+	line := fmt.Sprintf("%s - - [%s] %s %d %s %s %s", dash(e.RemoteAddress), e.Time.Format("02/Jan/2006:15:04:05 -0700"), strconv.Quote(request), e.Status, size, quote(e.Referer), quote(e.UserAgent))
+	return []byte(line)
+}
+
+// fields returns the list of keys and values for 'e', in the order they are written, for the structured formats.
+func (e *accessLogEntry) fields() ([]string, map[string]interface{}) {
+
+	keys := []string{
+		"time", "remote_address", "method", "path", "protocol", "endpoint", "delivery_id", "event_type",
+		"status", "size", "referer", "user_agent",
+	}
+
+	values := map[string]interface{}{
+		"time":           e.Time.Format(time.RFC3339Nano),
+		"remote_address": e.RemoteAddress,
+		"method":         e.Method,
+		"path":           e.Path,
+		"protocol":       e.Protocol,
+		"endpoint":       e.Endpoint,
+		"delivery_id":    e.DeliveryID,
+		"event_type":     e.EventType,
+		"status":         e.Status,
+		"size":           e.Size,
+		"referer":        e.Referer,
+		"user_agent":     e.UserAgent,
+	}
+
+	for _, stage := range []string{"receive", "transform", "dispatch", "process"} {
+
+		d, ok := e.Timings[stage]
+
+		if !ok {
+			continue
+		}
+
+		k := stage + "_ms"
+
+		keys = append(keys, k)
+		values[k] = float64(d.Microseconds()) / 1000.0
+	}
+
+	return keys, values
+}
+
+// json returns 'e' as a JSON-encoded object.
+func (e *accessLogEntry) json() []byte {
+
+	keys, values := e.fields()
+
+	obj := newOrderedObject()
+
+	for _, k := range keys {
+		obj.set(k, values[k])
+	}
+
+	dispatchers := e.Dispatchers
+
+	if dispatchers == nil {
+		dispatchers = make([]*accessLogDispatch, 0)
+	}
+
+	obj.set("dispatchers", dispatchers)
+
+	enc, err := json.Marshal(obj)
+
+	if err != nil {
+		return []byte(fmt.Sprintf(`{"error":%q}`, err.Error()))
+	}
+
+	return enc
+}
+
+// logfmt returns 'e' as a line of logfmt-encoded key/value pairs. Dispatcher results are encoded as a space-separated list
+// of "{DISPATCHER}={OUTCOME}" pairs.
+func (e *accessLogEntry) logfmt() []byte {
+
+	keys, values := e.fields()
+
+	results := make([]string, len(e.Dispatchers))
+
+	for idx, r := range e.Dispatchers {
+		results[idx] = fmt.Sprintf("%s=%s", r.Dispatcher, r.Outcome)
+	}
+
+	keys = append(keys, "dispatchers")
+	values["dispatchers"] = strings.Join(results, " ")
+
+	var buf bytes.Buffer
+
+	for idx, k := range keys {
+
+		if idx > 0 {
+			buf.WriteString(" ")
+		}
+
+		v := fmt.Sprintf("%v", values[k])
+
+		if v == "" || strings.ContainsAny(v, " =\"\\") || strings.IndexFunc(v, isLogfmtControl) != -1 {
+			v = strconv.Quote(v)
+		}
+
+		buf.WriteString(k)
+		buf.WriteString("=")
+		buf.WriteString(v)
+	}
+
+	return buf.Bytes()
+}
+
+// isLogfmtControl returns true if 'r' is a control character that must be quoted in logfmt values.
+func isLogfmtControl(r rune) bool {
+	return r < 0x20 || r == 0x7f
+}
+
+// ACCESS_LOG_REDACT_MASK is the string used to replace the values of query parameters in access log entries.
+const ACCESS_LOG_REDACT_MASK string = "[REDACTED]"
+
+// redactedRequestURI returns the path and query of 'u' with the value of every query parameter replaced by
+// `ACCESS_LOG_REDACT_MASK`. Query parameters are used to pass secrets, for example the tokens for some receivers and for
+// debugging output, and access logs are often shipped to other systems so values are never written to them.
+func redactedRequestURI(u *url.URL) string {
+
+	path := u.EscapedPath()
+
+	if path == "" {
+		path = "/"
+	}
+
+	if u.RawQuery == "" {
+		return path
+	}
+
+	params := strings.Split(u.RawQuery, "&")
+
+	for idx, p := range params {
+
+		k, _, has_value := strings.Cut(p, "=")
+
+		if has_value {
+			params[idx] = k + "=" + ACCESS_LOG_REDACT_MASK
+		}
+	}
+
+	return path + "?" + strings.Join(params, "&")
+}
@@ -0,0 +1,210 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/whosonfirst/go-webhookd/v3/config"
+)
+
+func TestAccessLog(t *testing.T) {
+
+	ctx := context.Background()
+
+	cfg := &config.WebhookConfig{
+		Daemon: "http://localhost:8081",
+		Receivers: map[string]string{
+			"insecure": "insecure://",
+		},
+		Dispatchers: map[string]string{
+			"null": "null://",
+		},
+		Webhooks: []config.WebhookWebhooksConfig{
+			{
+				Endpoint:    "/repos/{owner}",
+				Receiver:    "insecure",
+				Dispatchers: []string{"null"},
+			},
+		},
+	}
+
+	d, err := NewWebhookDaemonFromConfig(ctx, cfg)
+
+	if err != nil {
+		t.Fatalf("Failed to create new daemon from config, %v", err)
+	}
+
+	handler, err := d.HandlerFunc()
+
+	if err != nil {
+		t.Fatalf("Failed to create handler func, %v", err)
+	}
+
+	tests := map[string]*regexp.Regexp{
+		"combined": regexp.MustCompile(`^192\.0\.2\.1 - - \[[^\]]+\] "POST /repos/whosonfirst\?ref=\[REDACTED\] HTTP/1\.1" 200 - "-" "webhookd-test"$`),
+		"logfmt":   regexp.MustCompile(`^time=\S+ remote_address=192\.0\.2\.1 method=POST path="/repos/whosonfirst\?ref=\[REDACTED\]" protocol=HTTP/1\.1 endpoint=/repos/\{owner\} delivery_id=1234 event_type=push status=200 size=0 referer="" user_agent=webhookd-test receive_ms=\S+ transform_ms=\S+ dispatch_ms=\S+ process_ms=\S+ dispatchers="\*dispatcher\.NullDispatcher=dispatched"$`),
+	}
+
+	for format, re := range tests {
+
+		var buf bytes.Buffer
+
+		l := &accessLog{
+			format: format,
+			writer: &buf,
+			mu:     new(sync.Mutex),
+		}
+
+		req := httptest.NewRequest("POST", "/repos/whosonfirst?ref=main", strings.NewReader("hello world"))
+		req.RemoteAddr = "192.0.2.1:1234"
+		req.Header.Set("User-Agent", "webhookd-test")
+		req.Header.Set("X-GitHub-Event", "push")
+		req.Header.Set("X-GitHub-Delivery", "1234")
+
+		rec := httptest.NewRecorder()
+		d.accessLogHandler(l, handler).ServeHTTP(rec, req)
+
+		line := strings.TrimSpace(buf.String())
+
+		if !re.MatchString(line) {
+			t.Fatalf("Unexpected %s entry: %s", format, line)
+		}
+	}
+
+	// JSON entries should record the response status for requests that are not processed
+
+	root := t.TempDir()
+	path := filepath.Join(root, "access.log")
+
+	err = d.AddAccessLog(ctx, "file://"+path+"?format=json")
+
+	if err != nil {
+		t.Fatalf("Failed to add access log, %v", err)
+	}
+
+	req := httptest.NewRequest("POST", "/missing", strings.NewReader("hello world"))
+	rec := httptest.NewRecorder()
+
+	d.accessLogHandler(d.accessLog, handler).ServeHTTP(rec, req)
+
+	body, err := os.ReadFile(path)
+
+	if err != nil {
+		t.Fatalf("Failed to read access log, %v", err)
+	}
+
+	var entry map[string]interface{}
+
+	err = json.Unmarshal(body, &entry)
+
+	if err != nil {
+		t.Fatalf("Failed to unmarshal entry, %v", err)
+	}
+
+	if entry["status"] != float64(http.StatusNotFound) || entry["path"] != "/missing" || entry["endpoint"] != "" {
+		t.Fatalf("Unexpected entry: %s", body)
+	}
+}
+
+func TestAccessLogRedactsQuery(t *testing.T) {
+
+	ctx := context.Background()
+
+	cfg := &config.WebhookConfig{
+		Daemon: "http://localhost:8081",
+		Receivers: map[string]string{
+			"insecure": "insecure://",
+		},
+		Dispatchers: map[string]string{
+			"null": "null://",
+		},
+		Webhooks: []config.WebhookWebhooksConfig{
+			{
+				Endpoint:    "/insecure",
+				Receiver:    "insecure",
+				Dispatchers: []string{"null"},
+			},
+		},
+	}
+
+	d, err := NewWebhookDaemonFromConfig(ctx, cfg)
+
+	if err != nil {
+		t.Fatalf("Failed to create new daemon from config, %v", err)
+	}
+
+	handler, err := d.HandlerFunc()
+
+	if err != nil {
+		t.Fatalf("Failed to create handler func, %v", err)
+	}
+
+	for _, format := range []string{"combined", "json", "logfmt"} {
+
+		var buf bytes.Buffer
+
+		l := &accessLog{
+			format: format,
+			writer: &buf,
+			mu:     new(sync.Mutex),
+		}
+
+		req := httptest.NewRequest("POST", "/insecure?token=secret&debug=s33kret&flag", strings.NewReader("hello world"))
+		rec := httptest.NewRecorder()
+
+		d.accessLogHandler(l, handler).ServeHTTP(rec, req)
+
+		line := buf.String()
+
+		if strings.Contains(line, "secret") || strings.Contains(line, "s33kret") {
+			t.Fatalf("Expected %s entry not to contain query values: %s", format, line)
+		}
+
+		if format == "json" {
+
+			var entry map[string]interface{}
+
+			err := json.Unmarshal(buf.Bytes(), &entry)
+
+			if err != nil {
+				t.Fatalf("Failed to unmarshal entry, %v", err)
+			}
+
+			line, _ = entry["path"].(string)
+		}
+
+		if !strings.Contains(line, "/insecure?token=[REDACTED]&debug=[REDACTED]&flag") {
+			t.Fatalf("Expected %s entry to contain redacted query: %s", format, line)
+		}
+	}
+}
+
+func TestNewAccessLogInvalid(t *testing.T) {
+
+	ctx := context.Background()
+
+	tests := []string{
+		"syslog://",
+		"file://",
+		"stdout://?format=xml",
+		"file:///missing/directory/access.log",
+	}
+
+	for _, uri := range tests {
+
+		_, err := newAccessLog(ctx, uri)
+
+		if err == nil {
+			t.Fatalf("Expected %s to fail", uri)
+		}
+	}
+}
@@ -0,0 +1,326 @@
+package daemon
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"net/url"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	aa_log "github.com/aaronland/go-log/v2"
+	"github.com/whosonfirst/go-webhookd/v3"
+	"github.com/whosonfirst/go-webhookd/v3/dispatcher"
+	"github.com/whosonfirst/go-webhookd/v3/webhook"
+)
+
+// ADMIN_RUNTIME_PATH is the path of the admin endpoint that returns runtime statistics for the `webhookd` process.
+const ADMIN_RUNTIME_PATH string = "/diag/runtime"
+
+// ADMIN_PPROF_PATH is the path prefix for the admin endpoints that serve `net/http/pprof` profiles.
+const ADMIN_PPROF_PATH string = "/debug/pprof/"
+
+// adminServer is the configuration for an optional HTTP server, on a separate port, used to diagnose a running `webhookd` process.
+type adminServer struct {
+	// address is the address the admin server listens on.
+	address string
+	// token is the bearer token that clients must present in an "Authorization" header.
+	token string
+	// pprof is a boolean flag signaling that `net/http/pprof` profiles are served.
+	pprof bool
+	// started is the time the admin server was started.
+	started time.Time
+}
+
+// runtimeStats are the statistics returned by the `ADMIN_RUNTIME_PATH` endpoint.
+type runtimeStats struct {
+	Time       time.Time      `json:"time"`
+	Uptime     float64        `json:"uptime_seconds"`
+	GoVersion  string         `json:"go_version"`
+	NumCPU     int            `json:"num_cpu"`
+	GOMAXPROCS int            `json:"gomaxprocs"`
+	Goroutines int            `json:"goroutines"`
+	Memory     *memoryStats   `json:"memory"`
+	GC         *gcStats       `json:"gc"`
+	Queues     []*queueStats  `json:"queues"`
+	Events     *eventHubStats `json:"events"`
+}
+
+// memoryStats are the memory statistics, in bytes, included in `runtimeStats`.
+type memoryStats struct {
+	Alloc       uint64 `json:"alloc"`
+	TotalAlloc  uint64 `json:"total_alloc"`
+	Sys         uint64 `json:"sys"`
+	HeapAlloc   uint64 `json:"heap_alloc"`
+	HeapInuse   uint64 `json:"heap_inuse"`
+	HeapObjects uint64 `json:"heap_objects"`
+	StackInuse  uint64 `json:"stack_inuse"`
+}
+
+// gcStats are the garbage collection statistics included in `runtimeStats`.
+type gcStats struct {
+	NumGC        uint32     `json:"num_gc"`
+	PauseTotalNs uint64     `json:"pause_total_ns"`
+	LastPauseNs  uint64     `json:"last_pause_ns"`
+	LastGC       *time.Time `json:"last_gc,omitempty"`
+	NextGC       uint64     `json:"next_gc"`
+}
+
+// queueStats is the number of messages waiting to be relayed by a `dispatcher.QueuedDispatcher` instance.
+type queueStats struct {
+	Endpoint   string `json:"endpoint"`
+	Dispatcher string `json:"dispatcher"`
+	Offset     int    `json:"offset"`
+	Depth      int    `json:"depth"`
+}
+
+// eventHubStats are the statistics for the subscribers to processed events (for example GraphQL subscriptions).
+type eventHubStats struct {
+	Subscribers int `json:"subscribers"`
+	Backlog     int `json:"backlog"`
+}
+
+// AddAdminServer() configures 'd' to start an HTTP server, on a separate port, used to diagnose performance issues in a running
+// `webhookd` process without rebuilding it. Requests must present a bearer token in an "Authorization" header. The server returns
+// runtime statistics (goroutines, memory, garbage collection and the number of messages queued by dispatchers) at `/diag/runtime`
+// and, optionally, `net/http/pprof` profiles at `/debug/pprof/`. 'uri' is expected to take the form of:
+//
+//	admin://{HOST}:{PORT}?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `token={TOKEN}` The bearer token that clients must present in an "Authorization" header. Required.
+// * `pprof={BOOLEAN}` Serve `net/http/pprof` profiles. Default is false.
+func (d *WebhookDaemon) AddAdminServer(ctx context.Context, uri string) error {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return fmt.Errorf("Failed to parse admin URI, %w", err)
+	}
+
+	if u.Scheme != "admin" {
+		return fmt.Errorf("Invalid admin URI scheme '%s'", u.Scheme)
+	}
+
+	if u.Port() == "" {
+		return fmt.Errorf("Admin URI is missing a port")
+	}
+
+	q := u.Query()
+
+	token := q.Get("token")
+
+	if token == "" {
+		return fmt.Errorf("Admin URI is missing a ?token= parameter")
+	}
+
+	s := &adminServer{
+		address: u.Host,
+		token:   token,
+	}
+
+	if q.Get("pprof") != "" {
+
+		v, err := strconv.ParseBool(q.Get("pprof"))
+
+		if err != nil {
+			return fmt.Errorf("Invalid ?pprof= parameter, %w", err)
+		}
+
+		s.pprof = v
+	}
+
+	d.admin = s
+	return nil
+}
+
+// startAdmin starts the admin server, if 'd' has been configured to do so. It returns a function used to stop the admin server.
+func (d *WebhookDaemon) startAdmin(logger *log.Logger) (func(), error) {
+
+	if d.admin == nil {
+		return func() {}, nil
+	}
+
+	l, err := net.Listen("tcp", d.admin.address)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to listen for admin requests, %w", err)
+	}
+
+	d.admin.started = time.Now()
+
+	svr := &http.Server{
+		Handler:           d.adminHandlerWithLogger(logger),
+		ReadHeaderTimeout: 2 * time.Second,
+	}
+
+	aa_log.Info(logger, "Webhookd listening for admin requests on %s", l.Addr().String())
+
+	go func() {
+
+		err := svr.Serve(l)
+
+		if err != nil && err != http.ErrServerClosed {
+			aa_log.Error(logger, "Admin server stopped, %v", err)
+		}
+	}()
+
+	stop := func() {
+		svr.Close()
+	}
+
+	return stop, nil
+}
+
+// adminHandlerWithLogger returns a `http.Handler` for the admin server of 'd'.
+func (d *WebhookDaemon) adminHandlerWithLogger(logger *log.Logger) http.Handler {
+
+	s := d.admin
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc(ADMIN_RUNTIME_PATH, func(rsp http.ResponseWriter, req *http.Request) {
+
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			rsp.Header().Set("Allow", "GET, HEAD")
+			http.Error(rsp, "405 Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		rsp.Header().Set("Content-Type", "application/json")
+
+		err := json.NewEncoder(rsp).Encode(d.runtimeStats())
+
+		if err != nil {
+			aa_log.Debug(logger, "Failed to write runtime statistics, %v", err)
+		}
+	})
+
+	if s.pprof {
+		mux.HandleFunc(ADMIN_PPROF_PATH, pprof.Index)
+		mux.HandleFunc(ADMIN_PPROF_PATH+"cmdline", pprof.Cmdline)
+		mux.HandleFunc(ADMIN_PPROF_PATH+"profile", pprof.Profile)
+		mux.HandleFunc(ADMIN_PPROF_PATH+"symbol", pprof.Symbol)
+		mux.HandleFunc(ADMIN_PPROF_PATH+"trace", pprof.Trace)
+	}
+
+	fn := func(rsp http.ResponseWriter, req *http.Request) {
+
+		token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			aa_log.Warning(logger, "Unauthorized admin request for %s", req.URL.Path)
+			http.Error(rsp, "401 Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		mux.ServeHTTP(rsp, req)
+	}
+
+	return http.HandlerFunc(fn)
+}
+
+// runtimeStats returns the current `runtimeStats` for 'd'.
+func (d *WebhookDaemon) runtimeStats() *runtimeStats {
+
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	now := time.Now()
+
+	stats := &runtimeStats{
+		Time:       now,
+		GoVersion:  runtime.Version(),
+		NumCPU:     runtime.NumCPU(),
+		GOMAXPROCS: runtime.GOMAXPROCS(0),
+		Goroutines: runtime.NumGoroutine(),
+		Memory: &memoryStats{
+			Alloc:       m.Alloc,
+			TotalAlloc:  m.TotalAlloc,
+			Sys:         m.Sys,
+			HeapAlloc:   m.HeapAlloc,
+			HeapInuse:   m.HeapInuse,
+			HeapObjects: m.HeapObjects,
+			StackInuse:  m.StackInuse,
+		},
+		GC: &gcStats{
+			NumGC:        m.NumGC,
+			PauseTotalNs: m.PauseTotalNs,
+			NextGC:       m.NextGC,
+		},
+		Queues: d.queueStats(),
+		Events: d.events.stats(),
+	}
+
+	if d.admin != nil && !d.admin.started.IsZero() {
+		stats.Uptime = now.Sub(d.admin.started).Seconds()
+	}
+
+	if m.NumGC > 0 {
+		last_gc := time.Unix(0, int64(m.LastGC))
+		stats.GC.LastGC = &last_gc
+		stats.GC.LastPauseNs = m.PauseNs[(m.NumGC+255)%256]
+	}
+
+	return stats
+}
+
+// queueStats returns the number of messages waiting to be relayed by each of the `dispatcher.QueuedDispatcher` instances for the
+// webhooks, including source webhooks, in 'd' sorted by endpoint.
+func (d *WebhookDaemon) queueStats() []*queueStats {
+
+	d.mu.RLock()
+
+	webhooks := make([]webhook.Webhook, 0, len(d.webhooks)+len(d.sources))
+
+	for _, wh := range d.webhooks {
+		webhooks = append(webhooks, wh)
+	}
+
+	for _, s := range d.sources {
+		webhooks = append(webhooks, s.webhook)
+	}
+
+	d.mu.RUnlock()
+
+	sort.Slice(webhooks, func(i, j int) bool {
+		return webhooks[i].Endpoint() < webhooks[j].Endpoint()
+	})
+
+	queues := make([]*queueStats, 0)
+
+	for _, wh := range webhooks {
+
+		dispatchers := append([]webhookd.WebhookDispatcher{}, wh.Dispatchers()...)
+
+		for _, r := range wh.Routes() {
+			dispatchers = append(dispatchers, r.Dispatchers()...)
+		}
+
+		for idx, d := range dispatchers {
+
+			q, ok := d.(dispatcher.QueuedDispatcher)
+
+			if !ok {
+				continue
+			}
+
+			queues = append(queues, &queueStats{
+				Endpoint:   wh.Endpoint(),
+				Dispatcher: fmt.Sprintf("%T", d),
+				Offset:     idx,
+				Depth:      q.QueueDepth(),
+			})
+		}
+	}
+
+	return queues
+}
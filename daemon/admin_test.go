@@ -0,0 +1,179 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/whosonfirst/go-webhookd/v3/config"
+)
+
+func TestAdminServer(t *testing.T) {
+
+	ctx := context.Background()
+
+	cfg := &config.WebhookConfig{
+		Daemon: "http://localhost:8081",
+		Admin:  "admin://localhost:8082?token=s33kret&pprof=true",
+		Receivers: map[string]string{
+			"insecure": "insecure://",
+		},
+		Dispatchers: map[string]string{
+			"batch": "batch://?dispatcher=null%3A%2F%2F&size=10&interval=1h",
+		},
+		Webhooks: []config.WebhookWebhooksConfig{
+			{
+				Endpoint:    "/insecure",
+				Receiver:    "insecure",
+				Dispatchers: []string{"batch"},
+			},
+		},
+	}
+
+	d, err := NewWebhookDaemonFromConfig(ctx, cfg)
+
+	if err != nil {
+		t.Fatalf("Failed to create new daemon from config, %v", err)
+	}
+
+	logger := log.New(io.Discard, "", 0)
+
+	webhook_handler, err := d.HandlerFuncWithLogger(logger)
+
+	if err != nil {
+		t.Fatalf("Failed to create webhook handler, %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+
+		req := httptest.NewRequest(http.MethodPost, "/insecure", bytes.NewReader([]byte("hello world")))
+		rsp := httptest.NewRecorder()
+
+		webhook_handler.ServeHTTP(rsp, req)
+
+		if rsp.Code != http.StatusOK {
+			t.Fatalf("Unexpected status for webhook %d", rsp.Code)
+		}
+	}
+
+	handler := d.adminHandlerWithLogger(logger)
+
+	tests := []struct {
+		path   string
+		token  string
+		status int
+	}{
+		{ADMIN_RUNTIME_PATH, "", http.StatusUnauthorized},
+		{ADMIN_RUNTIME_PATH, "bogus", http.StatusUnauthorized},
+		{ADMIN_RUNTIME_PATH, "s33kret", http.StatusOK},
+		{ADMIN_PPROF_PATH, "", http.StatusUnauthorized},
+		{ADMIN_PPROF_PATH, "s33kret", http.StatusOK},
+		{ADMIN_PPROF_PATH + "cmdline", "s33kret", http.StatusOK},
+	}
+
+	for _, test := range tests {
+
+		req := httptest.NewRequest(http.MethodGet, test.path, nil)
+
+		if test.token != "" {
+			req.Header.Set("Authorization", "Bearer "+test.token)
+		}
+
+		rsp := httptest.NewRecorder()
+
+		handler.ServeHTTP(rsp, req)
+
+		if rsp.Code != test.status {
+			t.Fatalf("Expected status %d for %s (token '%s'), got %d", test.status, test.path, test.token, rsp.Code)
+		}
+
+		if test.status != http.StatusOK || test.path != ADMIN_RUNTIME_PATH {
+			continue
+		}
+
+		var stats runtimeStats
+
+		err := json.Unmarshal(rsp.Body.Bytes(), &stats)
+
+		if err != nil {
+			t.Fatalf("Failed to decode runtime statistics, %v", err)
+		}
+
+		if stats.Goroutines == 0 || stats.GoVersion == "" || stats.Memory == nil || stats.GC == nil || stats.Events == nil {
+			t.Fatalf("Unexpected runtime statistics %v", stats)
+		}
+
+		if len(stats.Queues) != 1 {
+			t.Fatalf("Expected 1 queue, got %d", len(stats.Queues))
+		}
+
+		q := stats.Queues[0]
+
+		if q.Endpoint != "/insecure" || q.Depth != 3 || !strings.Contains(q.Dispatcher, "BatchDispatcher") {
+			t.Fatalf("Unexpected queue statistics %v", q)
+		}
+	}
+}
+
+func TestAdminServerWithoutPprof(t *testing.T) {
+
+	ctx := context.Background()
+
+	d, err := NewWebhookDaemon(ctx, "http://localhost:8081")
+
+	if err != nil {
+		t.Fatalf("Failed to create new daemon, %v", err)
+	}
+
+	err = d.AddAdminServer(ctx, "admin://localhost:8082?token=s33kret")
+
+	if err != nil {
+		t.Fatalf("Failed to add admin server, %v", err)
+	}
+
+	handler := d.adminHandlerWithLogger(log.New(io.Discard, "", 0))
+
+	req := httptest.NewRequest(http.MethodGet, ADMIN_PPROF_PATH, nil)
+	req.Header.Set("Authorization", "Bearer s33kret")
+
+	rsp := httptest.NewRecorder()
+
+	handler.ServeHTTP(rsp, req)
+
+	if rsp.Code != http.StatusNotFound {
+		t.Fatalf("Expected pprof endpoints to be disabled, got %d", rsp.Code)
+	}
+}
+
+func TestAdminServerInvalid(t *testing.T) {
+
+	ctx := context.Background()
+
+	d, err := NewWebhookDaemon(ctx, "http://localhost:8081")
+
+	if err != nil {
+		t.Fatalf("Failed to create new daemon, %v", err)
+	}
+
+	uris := []string{
+		"bogus://localhost:8082?token=s33kret",
+		"admin://localhost?token=s33kret",
+		"admin://localhost:8082",
+		"admin://localhost:8082?token=s33kret&pprof=maybe",
+	}
+
+	for _, uri := range uris {
+
+		err := d.AddAdminServer(ctx, uri)
+
+		if err == nil {
+			t.Fatalf("Expected '%s' to fail", uri)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+	"github.com/whosonfirst/go-webhookd/v3/webhook"
+)
+
+// DEFAULT_AUDIT_ACTOR is the actor recorded in audit records when none has been assigned with `WithAuditActor`.
+const DEFAULT_AUDIT_ACTOR string = "webhookd"
+
+// Actions recorded in audit records.
+const (
+	AUDIT_ADD_WEBHOOK    string = "add_webhook"
+	AUDIT_UPDATE_WEBHOOK string = "update_webhook"
+	AUDIT_REMOVE_WEBHOOK string = "remove_webhook"
+)
+
+// auditActorKey is the key used to store the actor responsible for a change in a `context.Context` instance.
+const auditActorKey contextKey = "webhookd.daemon.audit_actor"
+
+// auditLog writes an append-only record of each change to the webhooks configured for a daemon.
+type auditLog struct {
+	// writer is the sink that records are written to.
+	writer io.Writer
+	// mu is a `sync.Mutex` used to ensure that records are written atomically.
+	mu *sync.Mutex
+}
+
+// auditRecord is a single, JSON-encoded, entry in an audit log.
+type auditRecord struct {
+	// Time is the time the change was made.
+	Time string `json:"time"`
+	// Actor is who, or what, made the change.
+	Actor string `json:"actor"`
+	// Action is the type of change.
+	Action string `json:"action"`
+	// Endpoint is the endpoint of the webhook that was changed.
+	Endpoint string `json:"endpoint"`
+	// Before is the description of the webhook before the change, or nil if it was added.
+	Before *webhookDescription `json:"before"`
+	// After is the description of the webhook after the change, or nil if it was removed.
+	After *webhookDescription `json:"after"`
+	// Diff is the sorted list of properties that changed.
+	Diff []string `json:"diff"`
+}
+
+// webhookDescription is the description of a webhook recorded in audit records.
+type webhookDescription struct {
+	Endpoint        string   `json:"endpoint"`
+	Tenant          string   `json:"tenant,omitempty"`
+	Receiver        string   `json:"receiver,omitempty"`
+	Source          string   `json:"source,omitempty"`
+	Transformations []string `json:"transformations"`
+	Dispatchers     []string `json:"dispatchers"`
+	Routes          int      `json:"routes"`
+	Methods         []string `json:"methods"`
+	Streaming       bool     `json:"streaming"`
+}
+
+// WithAuditActor returns a copy of 'ctx' identifying 'actor' (for example a user name or the name of a controller) as who is
+// responsible for any changes made to a daemon's webhooks with that context.
+func WithAuditActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, auditActorKey, actor)
+}
+
+// auditActor returns the actor stored in 'ctx' or `DEFAULT_AUDIT_ACTOR` if there is none.
+func auditActor(ctx context.Context) string {
+
+	v, ok := ctx.Value(auditActorKey).(string)
+
+	if !ok || v == "" {
+		return DEFAULT_AUDIT_ACTOR
+	}
+
+	return v
+}
+
+// AddAuditLog() configures 'd' to write an append-only record (who, what, when and a diff) of each change to its webhooks to
+// a sink. Audit logs should be added before any webhooks in order to record them. 'uri' is expected to take the form of:
+//
+//	stdout://
+//	stderr://
+//	file://{PATH}
+func (d *WebhookDaemon) AddAuditLog(ctx context.Context, uri string) error {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return fmt.Errorf("Failed to parse audit log URI, %w", err)
+	}
+
+	wr, err := openLogSink(u)
+
+	if err != nil {
+		return fmt.Errorf("Failed to open audit log, %w", err)
+	}
+
+	d.auditLog = &auditLog{
+		writer: wr,
+		mu:     new(sync.Mutex),
+	}
+
+	return nil
+}
+
+// record writes an audit record for 'action', performed by the actor in 'ctx', that changed the webhook for 'endpoint'
+// from 'before' to 'after'. It is safe to call on a nil instance.
+func (l *auditLog) record(ctx context.Context, action string, endpoint string, before *webhookDescription, after *webhookDescription) error {
+
+	if l == nil {
+		return nil
+	}
+
+	r := &auditRecord{
+		Time:     time.Now().UTC().Format(time.RFC3339Nano),
+		Actor:    auditActor(ctx),
+		Action:   action,
+		Endpoint: endpoint,
+		Before:   before,
+		After:    after,
+		Diff:     diffWebhookDescriptions(before, after),
+	}
+
+	enc, err := json.Marshal(r)
+
+	if err != nil {
+		return fmt.Errorf("Failed to encode audit record, %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	_, err = l.writer.Write(append(enc, '\n'))
+
+	if err != nil {
+		return fmt.Errorf("Failed to write audit record, %w", err)
+	}
+
+	return nil
+}
+
+// describeWebhook returns the `webhookDescription` for 'wh', whose messages are consumed from 'src' if not nil.
+func describeWebhook(wh webhook.Webhook, src webhookd.WebhookSource) *webhookDescription {
+
+	desc := &webhookDescription{
+		Endpoint:        wh.Endpoint(),
+		Tenant:          wh.Tenant(),
+		Transformations: make([]string, 0),
+		Dispatchers:     make([]string, 0),
+		Routes:          len(wh.Routes()),
+		Methods:         wh.Methods(),
+		Streaming:       wh.Streaming(),
+	}
+
+	if src != nil {
+		desc.Source = fmt.Sprintf("%T", src)
+		desc.Methods = make([]string, 0)
+	} else {
+		desc.Receiver = fmt.Sprintf("%T", wh.Receiver())
+	}
+
+	for _, t := range wh.Transformations() {
+		desc.Transformations = append(desc.Transformations, fmt.Sprintf("%T", t))
+	}
+
+	for _, d := range wh.Dispatchers() {
+		desc.Dispatchers = append(desc.Dispatchers, fmt.Sprintf("%T", d))
+	}
+
+	return desc
+}
+
+// diffWebhookDescriptions returns the sorted list of (JSON) property names whose values differ between 'before' and 'after'.
+// If either is nil all the properties of the other are returned.
+func diffWebhookDescriptions(before *webhookDescription, after *webhookDescription) []string {
+
+	to_map := func(desc *webhookDescription) map[string]interface{} {
+
+		m := make(map[string]interface{})
+
+		if desc == nil {
+			return m
+		}
+
+		enc, _ := json.Marshal(desc)
+		json.Unmarshal(enc, &m)
+
+		return m
+	}
+
+	m_before := to_map(before)
+	m_after := to_map(after)
+
+	keys := make(map[string]bool)
+
+	for k := range m_before {
+		keys[k] = true
+	}
+
+	for k := range m_after {
+		keys[k] = true
+	}
+
+	diff := make([]string, 0)
+
+	for k := range keys {
+
+		if !reflect.DeepEqual(m_before[k], m_after[k]) {
+			diff = append(diff, k)
+		}
+	}
+
+	sort.Strings(diff)
+	return diff
+}
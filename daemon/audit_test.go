@@ -0,0 +1,157 @@
+package daemon
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/whosonfirst/go-webhookd/v3/config"
+)
+
+func TestAuditLog(t *testing.T) {
+
+	ctx := context.Background()
+
+	root := t.TempDir()
+	path := filepath.Join(root, "audit.log")
+
+	cfg := &config.WebhookConfig{
+		Daemon:   "http://localhost:8081",
+		AuditLog: "file://" + path,
+		Receivers: map[string]string{
+			"insecure": "insecure://",
+		},
+		Dispatchers: map[string]string{
+			"null": "null://",
+		},
+		Webhooks: []config.WebhookWebhooksConfig{
+			{
+				Endpoint:    "/foo",
+				Receiver:    "insecure",
+				Dispatchers: []string{"null"},
+			},
+		},
+	}
+
+	d, err := NewWebhookDaemonFromConfig(ctx, cfg)
+
+	if err != nil {
+		t.Fatalf("Failed to create new daemon from config, %v", err)
+	}
+
+	cfg.Webhooks[0].Endpoint = "/bar"
+
+	err = d.AddWebhooksFromConfig(WithAuditActor(ctx, "alice"), cfg)
+
+	if err != nil {
+		t.Fatalf("Failed to add webhooks, %v", err)
+	}
+
+	fh, err := os.Open(path)
+
+	if err != nil {
+		t.Fatalf("Failed to open audit log, %v", err)
+	}
+
+	defer fh.Close()
+
+	records := make([]*auditRecord, 0)
+	scanner := bufio.NewScanner(fh)
+
+	for scanner.Scan() {
+
+		var r *auditRecord
+
+		err := json.Unmarshal(scanner.Bytes(), &r)
+
+		if err != nil {
+			t.Fatalf("Failed to unmarshal record, %v", err)
+		}
+
+		records = append(records, r)
+	}
+
+	if len(records) != 2 {
+		t.Fatalf("Unexpected number of records: %d", len(records))
+	}
+
+	expected := [][2]string{
+		{"config", "/foo"},
+		{"alice", "/bar"},
+	}
+
+	for i, r := range records {
+
+		if r.Actor != expected[i][0] || r.Endpoint != expected[i][1] || r.Action != AUDIT_ADD_WEBHOOK {
+			t.Fatalf("Unexpected record %d: %s %s %s", i, r.Actor, r.Action, r.Endpoint)
+		}
+
+		if r.Before != nil || r.After == nil || r.After.Receiver != "receiver.InsecureReceiver" {
+			t.Fatalf("Unexpected description for record %d: %v", i, r.After)
+		}
+
+		if strings.Join(r.Diff, ",") != "dispatchers,endpoint,methods,receiver,routes,streaming,transformations" {
+			t.Fatalf("Unexpected diff for record %d: %v", i, r.Diff)
+		}
+	}
+}
+
+func TestDiffWebhookDescriptions(t *testing.T) {
+
+	before := &webhookDescription{
+		Endpoint:        "/foo",
+		Receiver:        "*receiver.InsecureReceiver",
+		Transformations: []string{},
+		Dispatchers:     []string{"*dispatcher.NullDispatcher"},
+		Methods:         []string{"POST"},
+	}
+
+	after := &webhookDescription{
+		Endpoint:        "/foo",
+		Receiver:        "*receiver.InsecureReceiver",
+		Transformations: []string{},
+		Dispatchers:     []string{"*dispatcher.NullDispatcher", "*dispatcher.LogDispatcher"},
+		Methods:         []string{"POST"},
+		Streaming:       true,
+	}
+
+	diff := diffWebhookDescriptions(before, after)
+
+	if strings.Join(diff, ",") != "dispatchers,streaming" {
+		t.Fatalf("Unexpected diff: %v", diff)
+	}
+
+	if len(diffWebhookDescriptions(before, before)) != 0 {
+		t.Fatalf("Expected identical descriptions to have no diff")
+	}
+}
+
+func TestAddAuditLogInvalid(t *testing.T) {
+
+	ctx := context.Background()
+
+	d, err := NewWebhookDaemon(ctx, "http://localhost:8081")
+
+	if err != nil {
+		t.Fatalf("Failed to create new daemon, %v", err)
+	}
+
+	tests := []string{
+		"syslog://",
+		"file://",
+		"file:///missing/directory/audit.log",
+	}
+
+	for _, uri := range tests {
+
+		err := d.AddAuditLog(ctx, uri)
+
+		if err == nil {
+			t.Fatalf("Expected %s to fail", uri)
+		}
+	}
+}
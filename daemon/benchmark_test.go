@@ -0,0 +1,125 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/whosonfirst/go-webhookd/v3/config"
+)
+
+// benchmarkPayload is a small (~200 byte) JSON payload typical of the events sent by webhook providers.
+var benchmarkPayload = []byte(`{"action":"opened","number":1347,"repository":{"id":1296269,"full_name":"octocat/Hello-World"},"sender":{"login":"octocat","id":1},"ref":"refs/heads/main","after":"0d1a26e67d8f5eaf1f6ba5c57fc3c7d91ac0fd1c"}`)
+
+// newBenchmarkHandler returns the webhook handler for a daemon whose webhooks are configured by 'hooks'.
+func newBenchmarkHandler(b *testing.B, hooks []config.WebhookWebhooksConfig) http.Handler {
+
+	ctx := context.Background()
+
+	cfg := &config.WebhookConfig{
+		Daemon: "http://localhost:8080",
+		Receivers: map[string]string{
+			"insecure": "insecure://",
+		},
+		Transformations: map[string]string{
+			"null":   "null://",
+			"redact": "redact://?path=sender.login",
+		},
+		Dispatchers: map[string]string{
+			"null": "null://",
+		},
+		Webhooks: hooks,
+	}
+
+	d, err := NewWebhookDaemonFromConfig(ctx, cfg)
+
+	if err != nil {
+		b.Fatalf("Failed to create new daemon from config, %v", err)
+	}
+
+	handler, err := d.HandlerFuncWithLogger(log.New(io.Discard, "", 0))
+
+	if err != nil {
+		b.Fatalf("Failed to create handler, %v", err)
+	}
+
+	return handler
+}
+
+// benchmarkHandler posts 'body' to 'endpoint' using 'handler' b.N times.
+func benchmarkHandler(b *testing.B, handler http.Handler, endpoint string, body []byte) {
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(body)))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+
+		req := httptest.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+		rsp := httptest.NewRecorder()
+
+		handler.ServeHTTP(rsp, req)
+
+		if rsp.Code != http.StatusOK {
+			b.Fatalf("Unexpected status %d", rsp.Code)
+		}
+	}
+}
+
+func BenchmarkHandlerReceiveDispatch(b *testing.B) {
+
+	handler := newBenchmarkHandler(b, []config.WebhookWebhooksConfig{
+		{Endpoint: "/bench", Receiver: "insecure", Dispatchers: []string{"null"}},
+	})
+
+	benchmarkHandler(b, handler, "/bench", benchmarkPayload)
+}
+
+func BenchmarkHandlerReceiveTransformDispatch(b *testing.B) {
+
+	handler := newBenchmarkHandler(b, []config.WebhookWebhooksConfig{
+		{Endpoint: "/bench", Receiver: "insecure", Transformations: []string{"null"}, Dispatchers: []string{"null"}},
+	})
+
+	benchmarkHandler(b, handler, "/bench", benchmarkPayload)
+}
+
+func BenchmarkHandlerRedact(b *testing.B) {
+
+	handler := newBenchmarkHandler(b, []config.WebhookWebhooksConfig{
+		{Endpoint: "/bench", Receiver: "insecure", Transformations: []string{"redact"}, Dispatchers: []string{"null"}},
+	})
+
+	benchmarkHandler(b, handler, "/bench", benchmarkPayload)
+}
+
+func BenchmarkHandlerParallel(b *testing.B) {
+
+	handler := newBenchmarkHandler(b, []config.WebhookWebhooksConfig{
+		{Endpoint: "/bench", Receiver: "insecure", Transformations: []string{"null"}, Dispatchers: []string{"null", "null"}},
+	})
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(benchmarkPayload)))
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+
+		for pb.Next() {
+
+			req := httptest.NewRequest(http.MethodPost, "/bench", bytes.NewReader(benchmarkPayload))
+			rsp := httptest.NewRecorder()
+
+			handler.ServeHTTP(rsp, req)
+
+			if rsp.Code != http.StatusOK {
+				b.Errorf("Unexpected status %d", rsp.Code)
+				return
+			}
+		}
+	})
+}
@@ -0,0 +1,142 @@
+package daemon
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	aa_log "github.com/aaronland/go-log/v2"
+	"github.com/whosonfirst/go-webhookd/v3/dispatcher"
+)
+
+// captureEndpoint is the configuration for an optional endpoint serving the messages captured by `capture://` dispatchers.
+type captureEndpoint struct {
+	// path is the path the endpoint is installed at.
+	path string
+	// token is the optional bearer token that clients must present in an "Authorization" header.
+	token string
+}
+
+// capturedMessage is the JSON-encoded representation of a `dispatcher.CapturedMessage` returned by the capture endpoint.
+type capturedMessage struct {
+	Name       string    `json:"name"`
+	DeliveryID string    `json:"delivery_id,omitempty"`
+	Body       string    `json:"body"`
+	Time       time.Time `json:"time"`
+}
+
+// AddCaptureEndpoint() configures 'd' to install an endpoint, alongside its webhooks, for inspecting the messages captured by
+// `capture://` dispatchers (see `dispatcher.NewCaptureDispatcher`) so that automated tests can make assertions about the output
+// of transformation pipelines. GET requests return a JSON-encoded list of captured messages and DELETE requests remove them. Both
+// accept an optional `?name=` parameter to limit the request to a single capture store. 'uri' is expected to take the form of:
+//
+//	capture://{PATH}?{PARAMETERS}
+//
+// Where {PATH} is the path the endpoint is installed at. Default is "/capture". Valid {PARAMETERS} are:
+// * `token={TOKEN}` An optional bearer token that clients must present in an "Authorization" header.
+func (d *WebhookDaemon) AddCaptureEndpoint(ctx context.Context, uri string) error {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return fmt.Errorf("Failed to parse capture URI, %w", err)
+	}
+
+	if u.Scheme != "capture" {
+		return fmt.Errorf("Invalid capture URI scheme '%s'", u.Scheme)
+	}
+
+	if u.Host != "" {
+		return fmt.Errorf("Capture URI should not define a host")
+	}
+
+	path := u.Path
+
+	if path == "" {
+		path = "/capture"
+	}
+
+	_, exists := d.getWebhook(path)
+
+	if exists {
+		return fmt.Errorf("Capture endpoint '%s' is already configured as a webhook", path)
+	}
+
+	if d.graphql != nil && d.graphql.path == path {
+		return fmt.Errorf("Capture endpoint '%s' is already configured as the GraphQL endpoint", path)
+	}
+
+	if d.openapi != nil && d.openapi.path == path {
+		return fmt.Errorf("Capture endpoint '%s' is already configured as the OpenAPI endpoint", path)
+	}
+
+	d.capture = &captureEndpoint{
+		path:  path,
+		token: u.Query().Get("token"),
+	}
+
+	return nil
+}
+
+// captureHandlerWithLogger returns a `http.Handler` for the capture endpoint of 'd'.
+func (d *WebhookDaemon) captureHandlerWithLogger(logger *log.Logger) http.Handler {
+
+	e := d.capture
+
+	fn := func(rsp http.ResponseWriter, req *http.Request) {
+
+		if e.token != "" {
+
+			token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+
+			if subtle.ConstantTimeCompare([]byte(token), []byte(e.token)) != 1 {
+				http.Error(rsp, "401 Unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+
+		name := req.URL.Query().Get("name")
+
+		switch req.Method {
+		case http.MethodGet, http.MethodHead:
+
+			captured := dispatcher.CapturedMessages(name)
+			messages := make([]*capturedMessage, len(captured))
+
+			for i, m := range captured {
+
+				messages[i] = &capturedMessage{
+					Name:       m.Name,
+					DeliveryID: m.DeliveryID,
+					Body:       string(m.Body),
+					Time:       m.Time,
+				}
+			}
+
+			rsp.Header().Set("Content-Type", "application/json")
+
+			err := json.NewEncoder(rsp).Encode(messages)
+
+			if err != nil {
+				aa_log.Debug(logger, "Failed to write captured messages, %v", err)
+			}
+
+		case http.MethodDelete:
+
+			dispatcher.ResetCapturedMessages(name)
+			rsp.WriteHeader(http.StatusNoContent)
+
+		default:
+			rsp.Header().Set("Allow", "GET, HEAD, DELETE")
+			http.Error(rsp, "405 Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+
+	return http.HandlerFunc(fn)
+}
@@ -0,0 +1,141 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/whosonfirst/go-webhookd/v3/config"
+	"github.com/whosonfirst/go-webhookd/v3/dispatcher"
+)
+
+func TestCaptureEndpoint(t *testing.T) {
+
+	ctx := context.Background()
+
+	dispatcher.ResetCapturedMessages("")
+	defer dispatcher.ResetCapturedMessages("")
+
+	cfg := &config.WebhookConfig{
+		Daemon:  "http://localhost:8081",
+		Capture: "capture:///_capture?token=s33kret",
+		Receivers: map[string]string{
+			"insecure": "insecure://",
+		},
+		Transformations: map[string]string{
+			"chicken": "chicken://zxx?clucking=false",
+		},
+		Dispatchers: map[string]string{
+			"capture": "capture://pipeline",
+		},
+		Webhooks: []config.WebhookWebhooksConfig{
+			{
+				Endpoint:        "/insecure",
+				Receiver:        "insecure",
+				Transformations: []string{"chicken"},
+				Dispatchers:     []string{"capture"},
+			},
+		},
+	}
+
+	d, err := NewWebhookDaemonFromConfig(ctx, cfg)
+
+	if err != nil {
+		t.Fatalf("Failed to create new daemon from config, %v", err)
+	}
+
+	logger := log.New(io.Discard, "", 0)
+
+	webhook_handler, err := d.HandlerFuncWithLogger(logger)
+
+	if err != nil {
+		t.Fatalf("Failed to create webhook handler, %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/insecure", bytes.NewReader([]byte("hello world")))
+	rsp := httptest.NewRecorder()
+
+	webhook_handler.ServeHTTP(rsp, req)
+
+	if rsp.Code != http.StatusOK {
+		t.Fatalf("Unexpected status for webhook %d", rsp.Code)
+	}
+
+	handler := d.captureHandlerWithLogger(logger)
+
+	tests := []struct {
+		method string
+		token  string
+		status int
+	}{
+		{http.MethodGet, "", http.StatusUnauthorized},
+		{http.MethodPost, "s33kret", http.StatusMethodNotAllowed},
+		{http.MethodGet, "s33kret", http.StatusOK},
+		{http.MethodDelete, "s33kret", http.StatusNoContent},
+	}
+
+	for _, test := range tests {
+
+		req := httptest.NewRequest(test.method, "/_capture?name=pipeline", nil)
+
+		if test.token != "" {
+			req.Header.Set("Authorization", "Bearer "+test.token)
+		}
+
+		rsp := httptest.NewRecorder()
+		handler.ServeHTTP(rsp, req)
+
+		if rsp.Code != test.status {
+			t.Fatalf("Expected %d for %s request but got %d", test.status, test.method, rsp.Code)
+		}
+
+		if test.method != http.MethodGet || test.status != http.StatusOK {
+			continue
+		}
+
+		var messages []*capturedMessage
+
+		err := json.Unmarshal(rsp.Body.Bytes(), &messages)
+
+		if err != nil {
+			t.Fatalf("Failed to decode captured messages, %v", err)
+		}
+
+		if len(messages) != 1 {
+			t.Fatalf("Expected 1 captured message, got %d", len(messages))
+		}
+
+		if messages[0].Name != "pipeline" || messages[0].Body == "hello world" || messages[0].Body == "" {
+			t.Fatalf("Unexpected captured message %v", messages[0])
+		}
+	}
+
+	if len(dispatcher.CapturedMessages("pipeline")) != 0 {
+		t.Fatalf("Expected DELETE request to remove captured messages")
+	}
+}
+
+func TestCaptureEndpointInvalid(t *testing.T) {
+
+	ctx := context.Background()
+
+	d, err := NewWebhookDaemon(ctx, "http://localhost:8081")
+
+	if err != nil {
+		t.Fatalf("Failed to create new daemon, %v", err)
+	}
+
+	for _, uri := range []string{"bogus:///capture", "capture://host/capture"} {
+
+		err := d.AddCaptureEndpoint(ctx, uri)
+
+		if err == nil {
+			t.Fatalf("Expected '%s' to fail", uri)
+		}
+	}
+}
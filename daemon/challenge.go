@@ -0,0 +1,28 @@
+package daemon
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+// writeChallengeResponse writes the response to a verification challenge stored in 'ctx', if present, to 'rsp'. It returns
+// a boolean value indicating whether a response was written.
+func writeChallengeResponse(ctx context.Context, rsp http.ResponseWriter) bool {
+
+	content_type, body, ok := webhookd.ChallengeResponse(ctx)
+
+	if !ok {
+		return false
+	}
+
+	if content_type != "" {
+		rsp.Header().Set("Content-Type", content_type)
+	}
+
+	rsp.WriteHeader(http.StatusOK)
+	rsp.Write(body)
+
+	return true
+}
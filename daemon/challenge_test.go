@@ -0,0 +1,30 @@
+package daemon
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+func TestWriteChallengeResponse(t *testing.T) {
+
+	ctx := webhookd.WithChallengeResponse(context.Background())
+
+	rec := httptest.NewRecorder()
+
+	if writeChallengeResponse(ctx, rec) {
+		t.Fatalf("Expected no challenge response to be written")
+	}
+
+	webhookd.SetChallengeResponse(ctx, "application/json", []byte(`{"challenge":"abc"}`))
+
+	if !writeChallengeResponse(ctx, rec) {
+		t.Fatalf("Expected challenge response to be written")
+	}
+
+	if rec.Header().Get("Content-Type") != "application/json" || rec.Body.String() != `{"challenge":"abc"}` {
+		t.Fatalf("Unexpected challenge response '%s'", rec.Body.String())
+	}
+}
@@ -0,0 +1,87 @@
+package daemon
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	aa_log "github.com/aaronland/go-log/v2"
+	"github.com/whosonfirst/go-webhookd/v3/cluster"
+	"github.com/whosonfirst/go-webhookd/v3/webhook"
+)
+
+// DEFAULT_DELIVERY_TTL is the default amount of time that deliveries claimed in a cluster store are recorded.
+const DEFAULT_DELIVERY_TTL time.Duration = 24 * time.Hour
+
+// AddCluster() configures 'd' to share state with other webhookd processes using the `cluster.Store` instance derived from 'uri'.
+// Before a message is dispatched its delivery is claimed in the store so that when a provider delivers the same message to more
+// than one process, for example when retrying a request that timed out, it is only dispatched once. Only messages with a
+// provider-specific delivery identifier (for example the "X-GitHub-Delivery" header) are claimed unless `DeliveryHash` is true.
+// Only delivery claims (and rate limits and message hashes, for components configured to use a cluster store) are shared; the
+// messages held by `batch` and `delay` dispatchers, for example, belong to the process that received them.
+func (d *WebhookDaemon) AddCluster(ctx context.Context, uri string) error {
+
+	s, err := cluster.NewStore(ctx, uri)
+
+	if err != nil {
+		return fmt.Errorf("Failed to create cluster store, %w", err)
+	}
+
+	d.cluster = s
+	return nil
+}
+
+// claimDelivery claims the delivery for the message in 'req', sent to 'wh', in the cluster store for 'd'. It returns a boolean
+// value indicating whether the delivery was claimed, or may be dispatched anyway, and the key used to claim it. If the message
+// has no provider-specific delivery identifier, and `DeliveryHash` is true, it is claimed using the SHA-256 hash of 'body'.
+// Deliveries are always allowed if 'd' has no cluster store or the message can not be identified.
+func (d *WebhookDaemon) claimDelivery(ctx context.Context, req *http.Request, wh webhook.Webhook, body []byte) (bool, string, error) {
+
+	if d.cluster == nil {
+		return true, "", nil
+	}
+
+	id := providerDeliveryID(req)
+
+	if id == "" && d.DeliveryHash && body != nil {
+		id = fmt.Sprintf("sha256:%x", sha256.Sum256(body))
+	}
+
+	if id == "" {
+		return true, "", nil
+	}
+
+	ttl := d.DeliveryTTL
+
+	if ttl == 0 {
+		ttl = DEFAULT_DELIVERY_TTL
+	}
+
+	key := fmt.Sprintf("delivery:%s:%s", wh.Endpoint(), id)
+
+	ok, err := d.cluster.Claim(ctx, key, ttl)
+
+	if err != nil {
+		return true, "", err
+	}
+
+	return ok, key, nil
+}
+
+// releaseDelivery releases the delivery claimed with 'key', so that the provider may retry it, logging any errors to 'logger'.
+// It is a no-op if 'key' is empty.
+func (d *WebhookDaemon) releaseDelivery(ctx context.Context, key string, logger *log.Logger) {
+
+	if key == "" {
+		return
+	}
+
+	err := d.cluster.Release(ctx, key)
+
+	if err != nil {
+		aa_log.Warning(logger, "Failed to release delivery %s, %v", key, err)
+	}
+}
@@ -0,0 +1,273 @@
+package daemon
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+	"github.com/whosonfirst/go-webhookd/v3/receiver"
+	"github.com/whosonfirst/go-webhookd/v3/webhook"
+)
+
+func TestClusterDeliveries(t *testing.T) {
+
+	ctx := context.Background()
+	logger := log.New(io.Discard, "", 0)
+
+	rcvr, err := receiver.NewReceiver(ctx, "insecure://")
+
+	if err != nil {
+		t.Fatalf("Failed to create receiver, %v", err)
+	}
+
+	calls := make([]string, 0)
+	mu := new(sync.Mutex)
+
+	// Two daemons sharing the same cluster store, one of which always fails to dispatch messages
+
+	handlers := make(map[string]http.HandlerFunc)
+
+	var shared *WebhookDaemon
+
+	for _, name := range []string{"a", "b"} {
+
+		code := 0
+
+		if name == "a" {
+			code = http.StatusBadGateway
+		}
+
+		opts := &webhook.WebhookOptions{
+			Endpoint: "/test",
+			Receiver: rcvr,
+			Dispatchers: []webhookd.WebhookDispatcher{
+				&orderedDispatcher{name: name, code: code, calls: &calls, mu: mu},
+			},
+		}
+
+		wh, err := webhook.NewWebhookWithOptions(ctx, opts)
+
+		if err != nil {
+			t.Fatalf("Failed to create webhook, %v", err)
+		}
+
+		d, err := NewWebhookDaemon(ctx, "http://localhost:8081?outcome_header=true&delivery_ttl=1h")
+
+		if err != nil {
+			t.Fatalf("Failed to create new daemon, %v", err)
+		}
+
+		if shared == nil {
+
+			err = d.AddCluster(ctx, "memory://")
+
+			if err != nil {
+				t.Fatalf("Failed to add cluster, %v", err)
+			}
+
+			shared = d
+		} else {
+			d.cluster = shared.cluster
+		}
+
+		err = d.AddWebhook(ctx, wh)
+
+		if err != nil {
+			t.Fatalf("Failed to add webhook, %v", err)
+		}
+
+		handler, err := d.HandlerFuncWithLogger(logger)
+
+		if err != nil {
+			t.Fatalf("Failed to create handler func, %v", err)
+		}
+
+		handlers[name] = handler
+	}
+
+	tests := []struct {
+		daemon   string
+		delivery string
+		status   int
+		outcome  string
+		calls    string
+	}{
+		// Failed deliveries are released so they can be retried
+		{"a", "1234", http.StatusInternalServerError, OUTCOME_FAILED, "a"},
+		{"b", "1234", http.StatusOK, OUTCOME_DISPATCHED, "a,b"},
+		// Deliveries that have already been dispatched are skipped
+		{"a", "1234", http.StatusOK, OUTCOME_DUPLICATE, "a,b"},
+		{"b", "1234", http.StatusOK, OUTCOME_DUPLICATE, "a,b"},
+		// Messages without a provider-specific delivery ID are always dispatched
+		{"b", "", http.StatusOK, OUTCOME_DISPATCHED, "a,b,b"},
+		{"b", "", http.StatusOK, OUTCOME_DISPATCHED, "a,b,b,b"},
+	}
+
+	for idx, test := range tests {
+
+		req := httptest.NewRequest("POST", "/test", strings.NewReader("hello world"))
+
+		if test.delivery != "" {
+			req.Header.Set("X-GitHub-Delivery", test.delivery)
+		}
+
+		rec := httptest.NewRecorder()
+
+		handlers[test.daemon](rec, req)
+
+		if rec.Code != test.status {
+			t.Fatalf("Unexpected status for test %d: %d", idx, rec.Code)
+		}
+
+		outcome := rec.Header().Get("X-Webhookd-Outcome")
+
+		if !strings.HasPrefix(outcome, test.outcome) {
+			t.Fatalf("Unexpected outcome for test %d: %s", idx, outcome)
+		}
+
+		mu.Lock()
+		str_calls := strings.Join(calls, ",")
+		mu.Unlock()
+
+		if str_calls != test.calls {
+			t.Fatalf("Unexpected calls for test %d: %s", idx, str_calls)
+		}
+	}
+
+	if shared.DeliveryTTL.String() != "1h0m0s" {
+		t.Fatalf("Unexpected delivery TTL, %v", shared.DeliveryTTL)
+	}
+
+	_, err = NewWebhookDaemon(ctx, "http://localhost:8081?delivery_ttl=bogus")
+
+	if err == nil {
+		t.Fatalf("Expected invalid delivery TTL to fail")
+	}
+}
+
+func TestClusterDeliveryHash(t *testing.T) {
+
+	ctx := context.Background()
+	logger := log.New(io.Discard, "", 0)
+
+	rcvr, err := receiver.NewReceiver(ctx, "insecure://")
+
+	if err != nil {
+		t.Fatalf("Failed to create receiver, %v", err)
+	}
+
+	calls := make([]string, 0)
+	mu := new(sync.Mutex)
+
+	handlers := make(map[string]http.HandlerFunc)
+
+	var shared *WebhookDaemon
+
+	for _, name := range []string{"a", "b"} {
+
+		opts := &webhook.WebhookOptions{
+			Endpoint: "/test",
+			Receiver: rcvr,
+			Dispatchers: []webhookd.WebhookDispatcher{
+				&orderedDispatcher{name: name, calls: &calls, mu: mu},
+			},
+		}
+
+		wh, err := webhook.NewWebhookWithOptions(ctx, opts)
+
+		if err != nil {
+			t.Fatalf("Failed to create webhook, %v", err)
+		}
+
+		d, err := NewWebhookDaemon(ctx, "http://localhost:8081?outcome_header=true&delivery_hash=true")
+
+		if err != nil {
+			t.Fatalf("Failed to create new daemon, %v", err)
+		}
+
+		if shared == nil {
+
+			err = d.AddCluster(ctx, "memory://")
+
+			if err != nil {
+				t.Fatalf("Failed to add cluster, %v", err)
+			}
+
+			shared = d
+		} else {
+			d.cluster = shared.cluster
+		}
+
+		err = d.AddWebhook(ctx, wh)
+
+		if err != nil {
+			t.Fatalf("Failed to add webhook, %v", err)
+		}
+
+		handler, err := d.HandlerFuncWithLogger(logger)
+
+		if err != nil {
+			t.Fatalf("Failed to create handler func, %v", err)
+		}
+
+		handlers[name] = handler
+	}
+
+	tests := []struct {
+		daemon   string
+		body     string
+		delivery string
+		outcome  string
+		calls    string
+	}{
+		// Messages without a provider-specific delivery ID are claimed using the hash of their body
+		{"a", "hello world", "", OUTCOME_DISPATCHED, "a"},
+		{"b", "hello world", "", OUTCOME_DUPLICATE, "a"},
+		{"b", "hello again", "", OUTCOME_DISPATCHED, "a,b"},
+		// Provider-specific delivery IDs take precedence
+		{"b", "hello world", "1234", OUTCOME_DISPATCHED, "a,b,b"},
+	}
+
+	for idx, test := range tests {
+
+		req := httptest.NewRequest("POST", "/test", strings.NewReader(test.body))
+
+		if test.delivery != "" {
+			req.Header.Set("X-GitHub-Delivery", test.delivery)
+		}
+
+		rec := httptest.NewRecorder()
+
+		handlers[test.daemon](rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Unexpected status for test %d: %d", idx, rec.Code)
+		}
+
+		outcome := rec.Header().Get("X-Webhookd-Outcome")
+
+		if !strings.HasPrefix(outcome, test.outcome) {
+			t.Fatalf("Unexpected outcome for test %d: %s", idx, outcome)
+		}
+
+		mu.Lock()
+		str_calls := strings.Join(calls, ",")
+		mu.Unlock()
+
+		if str_calls != test.calls {
+			t.Fatalf("Unexpected calls for test %d: %s", idx, str_calls)
+		}
+	}
+
+	_, err = NewWebhookDaemon(ctx, "http://localhost:8081?delivery_hash=bogus")
+
+	if err == nil {
+		t.Fatalf("Expected invalid delivery hash flag to fail")
+	}
+}
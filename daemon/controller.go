@@ -0,0 +1,225 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	aa_log "github.com/aaronland/go-log/v2"
+	"github.com/whosonfirst/go-webhookd/v3/config"
+	"github.com/whosonfirst/go-webhookd/v3/controller"
+)
+
+// managedWebhook is a webhook added to a daemon by a `controller.Controller`.
+type managedWebhook struct {
+	// resource is the name of the `controller.Resource` that defines the webhook.
+	resource string
+	// spec is the JSON-encoded `config.WebhookResourceConfig` the webhook was created from.
+	spec string
+}
+
+// AddController() configures 'd' to add, update and remove webhooks, while it is running, to match the resources defined by the
+// `controller.Controller` instance derived from 'uri'. Resources may use the components defined in 'cfg', which may be nil, as
+// well as any components they define themselves. Webhooks that were not added by the controller, for example those defined in
+// 'cfg', are never changed.
+func (d *WebhookDaemon) AddController(ctx context.Context, uri string, cfg *config.WebhookConfig) error {
+
+	c, err := controller.NewController(ctx, uri)
+
+	if err != nil {
+		return fmt.Errorf("Failed to create controller, %w", err)
+	}
+
+	d.controller = c
+	d.controllerConfig = cfg
+	d.managed = make(map[string]*managedWebhook)
+
+	return nil
+}
+
+// startController watches the resources defined by the controller for 'd', if present, in a separate Go routine until 'ctx' is
+// cancelled. If watching fails it is restarted after a delay that doubles, up to `sourceRetryMax`, each time it fails in quick
+// succession. It returns a `sync.WaitGroup` that can be used to wait for the controller to stop.
+func (d *WebhookDaemon) startController(ctx context.Context, logger *log.Logger) *sync.WaitGroup {
+
+	wg := new(sync.WaitGroup)
+
+	if d.controller == nil {
+		return wg
+	}
+
+	fn := func(ctx context.Context, resources []*controller.Resource) error {
+		d.reconcileWebhooks(ctx, resources, logger)
+		return nil
+	}
+
+	wg.Add(1)
+
+	go func() {
+
+		defer wg.Done()
+		defer d.controller.Close()
+
+		delay := sourceRetryMin
+
+		for {
+
+			aa_log.Info(logger, "Webhookd watching resources using %T", d.controller)
+
+			t1 := time.Now()
+
+			err := d.controller.Watch(ctx, fn)
+
+			if ctx.Err() != nil {
+				return
+			}
+
+			if time.Since(t1) > sourceRetryMax {
+				delay = sourceRetryMin
+			}
+
+			aa_log.Error(logger, "Controller (%T) failed, restarting in %v, %v", d.controller, delay, err)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
+				// pass
+			}
+
+			delay = delay * 2
+
+			if delay > sourceRetryMax {
+				delay = sourceRetryMax
+			}
+		}
+	}()
+
+	return wg
+}
+
+// reconcileWebhooks adds, updates and removes the webhooks added to 'd' by its controller so that they match 'resources'. Each
+// change is recorded in the audit log, if present, as having been made by "controller:{RESOURCE}". Invalid resources are logged
+// and, if they previously defined a valid webhook, that webhook is left unchanged so that a bad edit does not take down a working
+// webhook. Webhooks whose resources have been deleted are removed.
+func (d *WebhookDaemon) reconcileWebhooks(ctx context.Context, resources []*controller.Resource, logger *log.Logger) {
+
+	// The endpoints that should remain after reconciling
+
+	keep := make(map[string]bool)
+
+	keep_resource := func(name string) {
+
+		for endpoint, m := range d.managed {
+
+			if m.resource == name {
+				keep[endpoint] = true
+			}
+		}
+	}
+
+	for _, r := range resources {
+
+		err := d.reconcileWebhook(ctx, r, keep)
+
+		if err != nil {
+			aa_log.Error(logger, "Failed to reconcile webhook for %s, %v", r.Name, err)
+			keep_resource(r.Name)
+		}
+	}
+
+	for endpoint, m := range d.managed {
+
+		if keep[endpoint] {
+			continue
+		}
+
+		err := d.RemoveWebhook(WithAuditActor(ctx, "controller:"+m.resource), endpoint)
+
+		if err != nil {
+			aa_log.Error(logger, "Failed to remove webhook %s for %s, %v", endpoint, m.resource, err)
+			continue
+		}
+
+		delete(d.managed, endpoint)
+		aa_log.Info(logger, "Removed webhook %s for %s", endpoint, m.resource)
+	}
+}
+
+// reconcileWebhook adds or updates the webhook defined by 'r', if it has changed, and records its endpoint in 'keep'.
+func (d *WebhookDaemon) reconcileWebhook(ctx context.Context, r *controller.Resource, keep map[string]bool) error {
+
+	if r.Error != nil {
+		return r.Error
+	}
+
+	hook := r.Config.Webhook
+	endpoint := hook.Endpoint
+
+	switch {
+	case endpoint == "":
+		return fmt.Errorf("Missing endpoint")
+	case strings.HasPrefix(endpoint, config.TENANT_PREFIX):
+		return fmt.Errorf("Endpoint can not use the '%s' prefix reserved for tenants", config.TENANT_PREFIX)
+	case hook.Source != "":
+		return fmt.Errorf("Webhooks added by a controller can not consume messages from a source")
+	case hook.Quota != nil:
+		return fmt.Errorf("Webhooks added by a controller can not define a quota")
+	}
+
+	m, managed := d.managed[endpoint]
+
+	if managed && m.resource != r.Name {
+		return fmt.Errorf("Endpoint '%s' is already defined by %s", endpoint, m.resource)
+	}
+
+	if !managed {
+
+		_, exists := d.lookupSourceWebhook(endpoint)
+
+		if exists {
+			return fmt.Errorf("Endpoint '%s' is already configured", endpoint)
+		}
+	}
+
+	enc, err := json.Marshal(r.Config)
+
+	if err != nil {
+		return fmt.Errorf("Failed to encode webhook definition, %w", err)
+	}
+
+	if managed && m.spec == string(enc) {
+		keep[endpoint] = true
+		return nil
+	}
+
+	wh, _, err := newWebhookFromConfig(ctx, r.Config.WebhookConfig(d.controllerConfig), 0, hook, nil)
+
+	if err != nil {
+		return err
+	}
+
+	ctx = WithAuditActor(ctx, "controller:"+r.Name)
+
+	if managed {
+		err = d.ReplaceWebhook(ctx, wh)
+	} else {
+		err = d.AddWebhook(ctx, wh)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	d.managed[endpoint] = &managedWebhook{
+		resource: r.Name,
+		spec:     string(enc),
+	}
+
+	keep[endpoint] = true
+	return nil
+}
@@ -0,0 +1,156 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/whosonfirst/go-webhookd/v3/config"
+	"github.com/whosonfirst/go-webhookd/v3/controller"
+)
+
+func TestControllerReconcile(t *testing.T) {
+
+	ctx := context.Background()
+	logger := log.New(io.Discard, "", 0)
+
+	audit_path := filepath.Join(t.TempDir(), "audit.log")
+
+	cfg := &config.WebhookConfig{
+		Daemon:     "http://localhost:8081",
+		AuditLog:   "file://" + audit_path,
+		Controller: "kubernetes://?endpoint=http://localhost:6443&namespace=webhookd&token=s33kret",
+		Receivers: map[string]string{
+			"insecure": "insecure://",
+		},
+		Dispatchers: map[string]string{
+			"null": "null://",
+		},
+		Webhooks: []config.WebhookWebhooksConfig{
+			{Endpoint: "/static", Receiver: "insecure", Dispatchers: []string{"null"}},
+		},
+	}
+
+	d, err := NewWebhookDaemonFromConfig(ctx, cfg)
+
+	if err != nil {
+		t.Fatalf("Failed to create new daemon from config, %v", err)
+	}
+
+	handler, err := d.HandlerFunc()
+
+	if err != nil {
+		t.Fatalf("Failed to create handler func, %v", err)
+	}
+
+	resource := func(name string, endpoint string, dispatcher string) *controller.Resource {
+
+		r := &controller.Resource{
+			Name: name,
+			Config: &config.WebhookResourceConfig{
+				Dispatchers: map[string]string{
+					"local": dispatcher,
+				},
+				Webhook: config.WebhookWebhooksConfig{
+					Endpoint:    endpoint,
+					Receiver:    "insecure",
+					Dispatchers: []string{"local"},
+				},
+			},
+		}
+
+		return r
+	}
+
+	check := func(step int, expected map[string]int) {
+
+		for path, code := range expected {
+
+			req := httptest.NewRequest("POST", path, strings.NewReader("hello world"))
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != code {
+				t.Fatalf("Expected %d for %s at step %d, got %d", code, path, step, rec.Code)
+			}
+		}
+	}
+
+	steps := []struct {
+		resources []*controller.Resource
+		expected  map[string]int
+	}{
+		{
+			resources: []*controller.Resource{resource("webhookd/a", "/a", "null://")},
+			expected:  map[string]int{"/a": http.StatusOK, "/static": http.StatusOK},
+		},
+		// Invalid changes leave the existing webhook in place, webhooks can not replace webhooks defined elsewhere
+		{
+			resources: []*controller.Resource{
+				resource("webhookd/a", "/a", "bogus://"),
+				resource("webhookd/b", "/static", "null://"),
+				{Name: "webhookd/c", Error: fmt.Errorf("Invalid")},
+			},
+			expected: map[string]int{"/a": http.StatusOK, "/static": http.StatusOK},
+		},
+		// Changing the endpoint for a resource removes the previous endpoint
+		{
+			resources: []*controller.Resource{resource("webhookd/a", "/b", "null://")},
+			expected:  map[string]int{"/a": http.StatusNotFound, "/b": http.StatusOK},
+		},
+		{
+			resources: []*controller.Resource{},
+			expected:  map[string]int{"/b": http.StatusNotFound, "/static": http.StatusOK},
+		},
+	}
+
+	for idx, step := range steps {
+		d.reconcileWebhooks(ctx, step.resources, logger)
+		check(idx, step.expected)
+	}
+
+	enc, err := os.ReadFile(audit_path)
+
+	if err != nil {
+		t.Fatalf("Failed to read audit log, %v", err)
+	}
+
+	for _, action := range []string{AUDIT_ADD_WEBHOOK, AUDIT_REMOVE_WEBHOOK} {
+
+		if !strings.Contains(string(enc), `"actor":"controller:webhookd/a","action":"`+action+`"`) {
+			t.Fatalf("Missing %s record in audit log, %s", action, enc)
+		}
+	}
+}
+
+func TestControllerWithoutWebhooks(t *testing.T) {
+
+	ctx := context.Background()
+
+	cfg := &config.WebhookConfig{
+		Daemon:     "http://localhost:8081",
+		Controller: "kubernetes://?endpoint=http://localhost:6443&namespace=webhookd",
+	}
+
+	_, err := NewWebhookDaemonFromConfig(ctx, cfg)
+
+	if err != nil {
+		t.Fatalf("Expected daemon with a controller to start without any webhooks, %v", err)
+	}
+
+	cfg.Controller = "kubernetes://?endpoint=http://localhost:6443&namespace=webhookd&resource=pods"
+
+	_, err = NewWebhookDaemonFromConfig(ctx, cfg)
+
+	if err == nil {
+		t.Fatalf("Expected invalid controller to fail")
+	}
+}
@@ -0,0 +1,110 @@
+package daemon
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/whosonfirst/go-webhookd/v3/config"
+)
+
+func TestCORS(t *testing.T) {
+
+	ctx := context.Background()
+
+	cfg := &config.WebhookConfig{
+		Daemon: "http://localhost:8081",
+		Receivers: map[string]string{
+			"insecure": "insecure://",
+		},
+		Middleware: map[string]string{
+			"auth": "auth://?bearer=s33kret",
+		},
+		Dispatchers: map[string]string{
+			"null": "null://",
+		},
+		Webhooks: []config.WebhookWebhooksConfig{
+			{
+				Endpoint:    "/cors",
+				Receiver:    "insecure",
+				Dispatchers: []string{"null"},
+				Middleware:  []string{"auth"},
+				CORS: &config.WebhookCORSConfig{
+					Origins: []string{"https://tools.example.com"},
+					Headers: []string{"Content-Type", "Authorization"},
+					MaxAge:  600,
+				},
+			},
+			{
+				Endpoint:    "/plain",
+				Receiver:    "insecure",
+				Dispatchers: []string{"null"},
+			},
+		},
+	}
+
+	d, err := NewWebhookDaemonFromConfig(ctx, cfg)
+
+	if err != nil {
+		t.Fatalf("Failed to create new daemon from config, %v", err)
+	}
+
+	handler, err := d.HandlerFunc()
+
+	if err != nil {
+		t.Fatalf("Failed to create handler func, %v", err)
+	}
+
+	tests := []struct {
+		method    string
+		path      string
+		origin    string
+		preflight bool
+		token     string
+		status    int
+		allow     string
+	}{
+		{"OPTIONS", "/cors", "https://tools.example.com", true, "", http.StatusNoContent, "https://tools.example.com"},
+		{"OPTIONS", "/cors", "https://evil.example.com", true, "", http.StatusForbidden, ""},
+		{"POST", "/cors", "https://tools.example.com", false, "", http.StatusUnauthorized, "https://tools.example.com"},
+		{"POST", "/cors", "https://tools.example.com", false, "s33kret", http.StatusOK, "https://tools.example.com"},
+		{"POST", "/cors", "https://evil.example.com", false, "s33kret", http.StatusOK, ""},
+		{"OPTIONS", "/plain", "https://tools.example.com", true, "", http.StatusMethodNotAllowed, ""},
+		{"POST", "/plain", "https://tools.example.com", false, "", http.StatusOK, ""},
+	}
+
+	for idx, test := range tests {
+
+		req := httptest.NewRequest(test.method, test.path, strings.NewReader("hello world"))
+		req.Header.Set("Origin", test.origin)
+
+		if test.preflight {
+			req.Header.Set("Access-Control-Request-Method", "POST")
+			req.Header.Set("Access-Control-Request-Headers", "authorization")
+		}
+
+		if test.token != "" {
+			req.Header.Set("Authorization", "Bearer "+test.token)
+		}
+
+		rec := httptest.NewRecorder()
+
+		handler(rec, req)
+
+		if rec.Code != test.status {
+			t.Fatalf("Unexpected status for test %d (%s %s): %d", idx, test.method, test.path, rec.Code)
+		}
+
+		if rec.Header().Get("Access-Control-Allow-Origin") != test.allow {
+			t.Fatalf("Unexpected Access-Control-Allow-Origin header for test %d: '%s'", idx, rec.Header().Get("Access-Control-Allow-Origin"))
+		}
+
+		// Webhook middleware re-enters the handler so make sure CORS headers are only added once
+
+		if test.path == "/cors" && !test.preflight && strings.Join(rec.Header().Values("Vary"), ",") != "Origin" {
+			t.Fatalf("Unexpected Vary headers for test %d: %v", idx, rec.Header().Values("Vary"))
+		}
+	}
+}
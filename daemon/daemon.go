@@ -7,6 +7,7 @@ import (
 	"log"
 	"net/http"
 	"net/url"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -15,10 +16,19 @@ import (
 	server "github.com/aaronland/go-http-server"
 	aa_log "github.com/aaronland/go-log/v2"
 	"github.com/whosonfirst/go-webhookd/v3"
+	"github.com/whosonfirst/go-webhookd/v3/cluster"
 	"github.com/whosonfirst/go-webhookd/v3/config"
+	"github.com/whosonfirst/go-webhookd/v3/controller"
 	"github.com/whosonfirst/go-webhookd/v3/dispatcher"
+	"github.com/whosonfirst/go-webhookd/v3/middleware"
+	"github.com/whosonfirst/go-webhookd/v3/predicate"
 	"github.com/whosonfirst/go-webhookd/v3/receiver"
+	"github.com/whosonfirst/go-webhookd/v3/secrets"
+	wh_server "github.com/whosonfirst/go-webhookd/v3/server"
+	"github.com/whosonfirst/go-webhookd/v3/source"
 	"github.com/whosonfirst/go-webhookd/v3/transformation"
+	"github.com/whosonfirst/go-webhookd/v3/tunnel"
+	"github.com/whosonfirst/go-webhookd/v3/usage"
 	"github.com/whosonfirst/go-webhookd/v3/webhook"
 )
 
@@ -26,10 +36,80 @@ import (
 type WebhookDaemon struct {
 	// server is a `aaronland/go-http-server.Server` instance that handles HTTP requests and responses.
 	server server.Server
-	// webhooks is a dictionary of URIs and their corresponding `webhookd.WebhookHandler` instances.
-	webhooks map[string]webhookd.WebhookHandler
-	// AllowDebug is a boolean flag to enable debugging reporting in webhook responses.
-	AllowDebug bool
+	// webhooks is a dictionary of URIs and their corresponding `webhook.Webhook` instances.
+	webhooks map[string]webhook.Webhook
+	// patterns is a list of `webhook.EndpointPattern` instances for webhooks whose endpoints contain named parameters or wildcards,
+	// sorted so that the most specific patterns are tested first.
+	patterns []*webhook.EndpointPattern
+	// mu is a `sync.RWMutex` used to guard 'webhooks', 'patterns' and 'slos' which may be changed, by a controller, while requests
+	// are being handled.
+	mu *sync.RWMutex
+	// sources is a list of `sourceWebhook` instances for webhooks whose messages are consumed from a `webhookd.WebhookSource`.
+	sources []*sourceWebhook
+	// grpc is the optional configuration for a gRPC server that delivers messages to the same handler as HTTP requests.
+	grpc *grpcServer
+	// admin is the optional configuration for an HTTP server, on a separate port, serving runtime statistics and pprof profiles.
+	admin *adminServer
+	// graphql is the optional configuration for a GraphQL endpoint used to deliver messages and subscribe to processed events.
+	graphql *graphQLEndpoint
+	// openapi is the optional configuration for an endpoint serving an OpenAPI document describing the webhooks for the daemon.
+	openapi *openAPIEndpoint
+	// capture is the optional configuration for an endpoint serving the messages captured by `capture://` dispatchers.
+	capture *captureEndpoint
+	// accessLog is the optional `accessLog` instance used to write an entry for each webhook request.
+	accessLog *accessLog
+	// metrics is the optional `metricsEmitter` instance used to push metrics for each webhook request to a statsd server.
+	metrics *metricsEmitter
+	// slos is a dictionary of endpoints and the `sloTracker` instances used to evaluate their service level objectives.
+	slos map[string]*sloTracker
+	// usage is the optional `usage.Store` instance used to record the usage of each webhook and tenant.
+	usage usage.Store
+	// cluster is the optional `cluster.Store` instance used to claim deliveries so that messages delivered to more than one
+	// webhookd process are only dispatched once.
+	cluster cluster.Store
+	// elector is the optional `cluster.Elector` instance used to elect a single webhookd process to consume messages for each
+	// singleton source webhook.
+	elector cluster.Elector
+	// controller is the optional `controller.Controller` instance used to add, update and remove webhooks while 'd' is running.
+	controller controller.Controller
+	// controllerConfig is the optional `config.WebhookConfig` whose components may be used by the webhooks added by 'controller'.
+	controllerConfig *config.WebhookConfig
+	// managed is a dictionary of endpoints and the `managedWebhook` instances for the webhooks added by 'controller'.
+	managed map[string]*managedWebhook
+	// quotas is a dictionary of usage keys (see `usage.EndpointKey` and `usage.TenantKey`) and their `Quota` instances.
+	quotas map[string]*Quota
+	// middleware is the optional list of `webhookd.WebhookMiddleware` instances applied, in order, to every webhook request.
+	middleware []webhookd.WebhookMiddleware
+	// reporter is the optional `webhookd.WebhookReporter` instance used to report panics recovered while processing messages.
+	reporter webhookd.WebhookReporter
+	// auditLog is the optional `auditLog` instance used to record changes to the webhooks for the daemon.
+	auditLog *auditLog
+	// tunnel is the optional `tunnel.Tunnel` instance used to expose 'server' to the internet when 'd' is started.
+	tunnel tunnel.Tunnel
+	// publicURL is the public URL for 'tunnel' once it has been opened.
+	publicURL string
+	// drift is the optional `driftChecker` instance used to compare the registrations for webhooks with the hooks registered with
+	// their providers.
+	drift *driftChecker
+	// events is the `eventHub` instance used to relay processed events to subscribers.
+	events *eventHub
+	// DryRunToken is the optional token that requests must include, in the `DRYRUN_TOKEN_HEADER` header, to perform a dry run
+	// which receives and transforms a message without dispatching it. If empty dry runs are not allowed.
+	DryRunToken string
+	// RemoteAddressHeader is the optional name of a request header (for example "X-Forwarded-For") used to determine the
+	// network address of the client that sent a webhook message.
+	RemoteAddressHeader string
+	// ResponseHeaders defines which `webhookd`-specific headers are added to webhook responses.
+	ResponseHeaders *ResponseHeaders
+	// DeliveryTTL is the amount of time that deliveries claimed in the cluster store are recorded. If 0 then
+	// `DEFAULT_DELIVERY_TTL` is used.
+	DeliveryTTL time.Duration
+	// DeliveryHash is a boolean flag signaling that messages without a provider-specific delivery identifier should be claimed
+	// in the cluster store using a hash of their body. Streamed messages are never claimed this way.
+	DeliveryHash bool
+	// MetaWebhook is the optional endpoint of the webhook that synthetic events, about the daemon itself (for example breaches of
+	// the service level objectives for other webhooks), are delivered to.
+	MetaWebhook string
 }
 
 // NewWebhookDaemonFromConfig() returns a new `WebhookDaemon` derived from configuration data in 'cfg'.
@@ -41,170 +121,874 @@ func NewWebhookDaemonFromConfig(ctx context.Context, cfg *config.WebhookConfig)
 		return nil, fmt.Errorf("Failed to create new webhookd daemon, %w", err)
 	}
 
-	err = d.AddWebhooksFromConfig(ctx, cfg)
+	if cfg.AuditLog != "" {
+
+		err = d.AddAuditLog(ctx, cfg.AuditLog)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to add audit log to daemon, %w", err)
+		}
+	}
+
+	if cfg.Cluster != "" {
+
+		err = d.AddCluster(ctx, cfg.Cluster)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to add cluster to daemon, %w", err)
+		}
+	}
+
+	if cfg.Election != "" {
+
+		err = d.AddElection(ctx, cfg.Election)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to add election to daemon, %w", err)
+		}
+	}
+
+	if cfg.Tunnel != "" {
+
+		err = d.AddTunnel(ctx, cfg.Tunnel)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to add tunnel to daemon, %w", err)
+		}
+	}
+
+	if cfg.Drift != "" {
+
+		err = d.AddDriftCheck(ctx, cfg.Drift, cfg)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to add drift check to daemon, %w", err)
+		}
+	}
+
+	if cfg.Usage != "" {
+
+		err = d.AddUsage(ctx, cfg.Usage)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to add usage to daemon, %w", err)
+		}
+	}
+
+	global_middleware, err := newMiddlewareFromConfig(ctx, cfg, cfg.GlobalMiddleware)
 
 	if err != nil {
-		return nil, fmt.Errorf("Failed to add webhooks to daemon, %w", err)
+		return nil, fmt.Errorf("Failed to create global middleware, %w", err)
+	}
+
+	for _, mw := range global_middleware {
+		d.AddMiddleware(ctx, mw)
+	}
+
+	if cfg.Controller != "" {
+
+		err = d.AddController(ctx, cfg.Controller, cfg)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to add controller to daemon, %w", err)
+		}
+	}
+
+	// Daemons with a controller may start without any webhooks of their own
+
+	if d.controller == nil || len(cfg.Webhooks) > 0 || len(cfg.Tenants) > 0 {
+
+		err = d.AddWebhooksFromConfig(ctx, cfg)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to add webhooks to daemon, %w", err)
+		}
+	}
+
+	if cfg.MetaWebhook != "" {
+
+		_, ok := d.lookupSourceWebhook(cfg.MetaWebhook)
+
+		if !ok {
+			return nil, fmt.Errorf("Invalid meta webhook '%s', webhook not found", cfg.MetaWebhook)
+		}
+
+		d.MetaWebhook = cfg.MetaWebhook
+	}
+
+	if cfg.GRPC != "" {
+
+		err = d.AddGRPCServer(ctx, cfg.GRPC)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to add gRPC server to daemon, %w", err)
+		}
+	}
+
+	if cfg.Admin != "" {
+
+		err = d.AddAdminServer(ctx, cfg.Admin)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to add admin server to daemon, %w", err)
+		}
+	}
+
+	if cfg.GraphQL != "" {
+
+		err = d.AddGraphQLEndpoint(ctx, cfg.GraphQL)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to add GraphQL endpoint to daemon, %w", err)
+		}
+	}
+
+	if cfg.OpenAPI != "" {
+
+		err = d.AddOpenAPIEndpoint(ctx, cfg.OpenAPI, cfg)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to add OpenAPI endpoint to daemon, %w", err)
+		}
+	}
+
+	if cfg.Capture != "" {
+
+		err = d.AddCaptureEndpoint(ctx, cfg.Capture)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to add capture endpoint to daemon, %w", err)
+		}
+	}
+
+	if cfg.AccessLog != "" {
+
+		err = d.AddAccessLog(ctx, cfg.AccessLog)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to add access log to daemon, %w", err)
+		}
+	}
+
+	if cfg.Metrics != "" {
+
+		err = d.AddMetrics(ctx, cfg.Metrics)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to add metrics to daemon, %w", err)
+		}
+	}
+
+	if cfg.Reporter != "" {
+
+		err = d.AddReporter(ctx, cfg.Reporter)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to add reporter to daemon, %w", err)
+		}
 	}
 
 	return d, nil
 }
 
 // NewWebhookDaemon() returns a `WebhookDaemon` instance derived from 'uri' which is expected to take
-// the form of any valid `aaronland/go-http-server.Server` URI with the following parameters:
-// * `?allow_debug=` An optional boolean flag to enable debugging output in webhook responses.
+// the form of any valid `aaronland/go-http-server.Server` URI (see `server.NewServer` for the timeout and HTTP/2 parameters
+// supported by "http://" and "https://" URIs) with the following parameters:
+// * `?dryrun_token=` An optional token that enables dry runs, which receive and transform a message without dispatching it, for
+// requests with a `?dryrun=1` parameter that include the token in the "X-Webhookd-Dryrun-Token" header.
+// * `?remote_address_header=` The optional name of a request header (for example "X-Forwarded-For") used to determine the network
+// address of the client that sent a webhook message. This should only be used when `webhookd` is deployed behind a proxy.
+// * `?response_header_prefix=` The prefix for the names of the `webhookd`-specific headers added to webhook responses. Default is "X-Webhookd-".
+// * `?timing_headers=` An optional boolean flag to add the "Time-To-Receive", "Time-To-Transform", "Time-To-Dispatch" and
+// "Time-To-Process" headers to webhook responses. Default is true.
+// * `?delivery_id_header=` An optional boolean flag to add a "Delivery-Id" header to webhook responses. Default is false.
+// * `?outcome_header=` An optional boolean flag to add an "Outcome" header, summarizing how a message was processed, to webhook
+// responses. Default is false.
+// * `?delivery_ttl=` The amount of time that deliveries are recorded in the cluster store, if present. Default is "24h".
+// * `?delivery_hash=` An optional boolean flag to claim messages without a provider-specific delivery identifier in the cluster
+// store, if present, using a hash of their body. Default is false.
 func NewWebhookDaemon(ctx context.Context, uri string) (*WebhookDaemon, error) {
 
+	uri, err := secrets.ResolveURI(ctx, uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to resolve secrets, %w", err)
+	}
+
 	u, err := url.Parse(uri)
 
-	if err != nil {
-		return nil, fmt.Errorf("Failed to parse daemon URI, %w", err)
-	}
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse daemon URI, %w", err)
+	}
+
+	q := u.Query()
+
+	// Debugging output is enabled, with a secret token, for individual webhooks. The ?allow_debug parameter is ignored, rather
+	// than rejected, so that existing deployments continue to start.
+
+	if q.Has("allow_debug") {
+		aa_log.Warning(log.Default(), "The ?allow_debug parameter is deprecated and ignored, assign a debug token to individual webhooks instead. It will be rejected in a future release.")
+	}
+
+	response_headers := NewResponseHeaders()
+
+	if q.Has("response_header_prefix") {
+		response_headers.Prefix = q.Get("response_header_prefix")
+	}
+
+	delivery_hash := false
+
+	flags := map[string]*bool{
+		"timing_headers":     &response_headers.Timing,
+		"delivery_id_header": &response_headers.DeliveryID,
+		"outcome_header":     &response_headers.Outcome,
+		"delivery_hash":      &delivery_hash,
+	}
+
+	for k, ptr := range flags {
+
+		str_v := q.Get(k)
+
+		if str_v == "" {
+			continue
+		}
+
+		v, err := strconv.ParseBool(str_v)
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid ?%s parameter, %w", k, err)
+		}
+
+		*ptr = v
+	}
+
+	var delivery_ttl time.Duration
+
+	str_ttl := q.Get("delivery_ttl")
+
+	if str_ttl != "" {
+
+		v, err := time.ParseDuration(str_ttl)
+
+		if err != nil || v <= 0 {
+			return nil, fmt.Errorf("Invalid ?delivery_ttl parameter '%s'", str_ttl)
+		}
+
+		delivery_ttl = v
+	}
+
+	srv, err := wh_server.NewServer(ctx, uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create new server instance, %w", err)
+	}
+
+	webhooks := make(map[string]webhook.Webhook)
+
+	d := WebhookDaemon{
+		server:              srv,
+		webhooks:            webhooks,
+		mu:                  new(sync.RWMutex),
+		DryRunToken:         q.Get("dryrun_token"),
+		RemoteAddressHeader: q.Get("remote_address_header"),
+		ResponseHeaders:     response_headers,
+		DeliveryTTL:         delivery_ttl,
+		DeliveryHash:        delivery_hash,
+		events:              newEventHub(),
+	}
+
+	return &d, nil
+}
+
+// AddWebhooksFromConfig() appends the webhooks, and the webhooks for each tenant, defined in 'cfg' to 'd'. Unless 'ctx' has been
+// assigned an actor with `WithAuditActor` changes are recorded in the audit log, if present, as having been made by "config".
+func (d *WebhookDaemon) AddWebhooksFromConfig(ctx context.Context, cfg *config.WebhookConfig) error {
+
+	if len(cfg.Webhooks) == 0 && len(cfg.Tenants) == 0 {
+		return fmt.Errorf("No webhooks defined")
+	}
+
+	if ctx.Value(auditActorKey) == nil {
+		ctx = WithAuditActor(ctx, "config")
+	}
+
+	if len(cfg.Tenants) > 0 {
+
+		for i, hook := range cfg.Webhooks {
+
+			if strings.HasPrefix(hook.Endpoint, config.TENANT_PREFIX) {
+				return fmt.Errorf("Webhook at offset %d can not use the '%s' prefix reserved for tenants", i+1, config.TENANT_PREFIX)
+			}
+		}
+	}
+
+	err := d.addWebhooksFromConfig(ctx, cfg, nil)
+
+	if err != nil {
+		return err
+	}
+
+	tenants := make([]string, 0, len(cfg.Tenants))
+
+	for name := range cfg.Tenants {
+		tenants = append(tenants, name)
+	}
+
+	sort.Strings(tenants)
+
+	for _, name := range tenants {
+
+		tenant_cfg, err := cfg.GetTenantConfigByName(name)
+
+		if err != nil {
+			return err
+		}
+
+		err = d.AddTenantFromConfig(ctx, name, tenant_cfg)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addWebhooksFromConfig() appends the webhooks defined in 'cfg' to 'd'. If 'tenant' is not nil the webhooks belong to that tenant.
+func (d *WebhookDaemon) addWebhooksFromConfig(ctx context.Context, cfg *config.WebhookConfig, tenant *webhookTenant) error {
+
+	for i, hook := range cfg.Webhooks {
+
+		wh, src, err := newWebhookFromConfig(ctx, cfg, i, hook, tenant)
+
+		if err != nil {
+			return err
+		}
+
+		if src != nil && hook.Singleton {
+			err = d.AddSingletonSourceWebhook(ctx, src, wh)
+		} else if src != nil {
+			err = d.AddSourceWebhook(ctx, src, wh)
+		} else {
+			err = d.AddWebhook(ctx, wh)
+		}
+
+		if err != nil {
+			return fmt.Errorf("Failed to add new webhook for '%s', %w", wh.Endpoint(), err)
+		}
+
+		if hook.Quota != nil {
+
+			q := &Quota{
+				Daily:   hook.Quota.Daily,
+				Monthly: hook.Quota.Monthly,
+			}
+
+			err = d.AddQuota(ctx, wh.Endpoint(), q)
+
+			if err != nil {
+				return fmt.Errorf("Failed to add quota for '%s', %w", wh.Endpoint(), err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// newWebhookFromConfig() returns the `webhook.Webhook` instance for 'hook', the webhook at offset 'i' in 'cfg', and the
+// `webhookd.WebhookSource` instance its messages are consumed from if it has a source. If 'tenant' is not nil the webhook belongs
+// to that tenant.
+func newWebhookFromConfig(ctx context.Context, cfg *config.WebhookConfig, i int, hook config.WebhookWebhooksConfig, tenant *webhookTenant) (webhook.Webhook, webhookd.WebhookSource, error) {
+
+	if hook.Source != "" {
+
+		if hook.Receiver != "" {
+			return webhook.Webhook{}, nil, fmt.Errorf("Webhook at offset %d can not define both a receiver and a source", i+1)
+		}
+
+		if hook.Streaming {
+			return webhook.Webhook{}, nil, fmt.Errorf("Webhook at offset %d can not stream messages from a source", i+1)
+		}
+
+		if hook.SLO != nil {
+			return webhook.Webhook{}, nil, fmt.Errorf("Webhook at offset %d can not define service level objectives for a source", i+1)
+		}
+
+		if len(hook.Middleware) > 0 {
+			return webhook.Webhook{}, nil, fmt.Errorf("Webhook at offset %d can not apply middleware to a source", i+1)
+		}
+
+		if hook.Quota != nil {
+			return webhook.Webhook{}, nil, fmt.Errorf("Webhook at offset %d can not define a quota for a source", i+1)
+		}
+
+		if hook.Endpoint == "" {
+			hook.Endpoint = hook.Source
+		}
+
+	} else if hook.Singleton {
+		return webhook.Webhook{}, nil, fmt.Errorf("Webhook at offset %d can not be a singleton unless it consumes messages from a source", i+1)
+	}
+
+	if hook.Endpoint == "" {
+		return webhook.Webhook{}, nil, fmt.Errorf("Missing endpoint at offset %d", i+1)
+	}
+
+	labels := hook.Labels
+
+	if tenant != nil {
+		hook.Endpoint = config.TenantEndpoint(tenant.name, hook.Endpoint)
+		labels = tenant.mergeLabels(hook.Labels)
+	}
+
+	if hook.Receiver == "" && hook.Source == "" {
+		return webhook.Webhook{}, nil, fmt.Errorf("Missing receiver at offset %d", i+1)
+	}
+
+	if len(hook.Dispatchers) == 0 && len(hook.Routes) == 0 {
+		return webhook.Webhook{}, nil, fmt.Errorf("Missing dispatchers at offset %d", i+1)
+	}
+
+	var rcvr webhookd.WebhookReceiver
+	var src webhookd.WebhookSource
+
+	if hook.Source != "" {
+
+		source_uri, err := cfg.GetSourceConfigByName(hook.Source)
+
+		if err != nil {
+			return webhook.Webhook{}, nil, fmt.Errorf("Failed to get source config for '%s', %w", hook.Source, err)
+		}
+
+		src, err = source.NewSource(ctx, source_uri)
+
+		if err != nil {
+			return webhook.Webhook{}, nil, fmt.Errorf("Failed to add source '%s', %w", hook.Source, err)
+		}
+
+	} else {
+
+		receiver_uri, err := cfg.GetReceiverConfigByName(hook.Receiver)
+
+		if err != nil {
+			return webhook.Webhook{}, nil, fmt.Errorf("Failed to get receiver config for '%s', %w", hook.Receiver, err)
+		}
+
+		rcvr, err = receiver.NewReceiver(ctx, receiver_uri)
+
+		if err != nil {
+			return webhook.Webhook{}, nil, fmt.Errorf("Failed to add receiver '%s', %w", receiver_uri, err)
+		}
+	}
+
+	wh_middleware, err := newMiddlewareFromConfig(ctx, cfg, hook.Middleware)
+
+	if err != nil {
+		return webhook.Webhook{}, nil, fmt.Errorf("Failed to create middleware for '%s', %w", hook.Endpoint, err)
+	}
+
+	if tenant != nil && src == nil {
+		wh_middleware = append(append([]webhookd.WebhookMiddleware{}, tenant.middleware...), wh_middleware...)
+	}
+
+	steps, err := newTransformationsFromConfig(ctx, cfg, hook.Transformations, nil)
+
+	if err != nil {
+		return webhook.Webhook{}, nil, fmt.Errorf("Failed to create transformations for '%s', %w", hook.Endpoint, err)
+	}
+
+	sendto, err := newDispatchersFromConfig(ctx, cfg, hook.Dispatchers)
+
+	if err != nil {
+		return webhook.Webhook{}, nil, err
+	}
+
+	var routes []*webhook.Route
+
+	for idx, r := range hook.Routes {
+
+		var p *predicate.Predicate
+
+		if r.When != "" {
+
+			v, err := predicate.Parse(r.When)
+
+			if err != nil {
+				return webhook.Webhook{}, nil, fmt.Errorf("Failed to parse route at offset %d for '%s', %w", idx, hook.Endpoint, err)
+			}
+
+			p = v
+		}
+
+		route_sendto, err := newDispatchersFromConfig(ctx, cfg, r.Dispatchers)
+
+		if err != nil {
+			return webhook.Webhook{}, nil, err
+		}
+
+		routes = append(routes, webhook.NewRoute(p, route_sendto))
+	}
+
+	var wh_response *webhook.Response
+
+	if hook.Response != nil {
+
+		r, err := webhook.NewResponse(hook.Response.Status, hook.Response.Body, hook.Response.ContentType)
+
+		if err != nil {
+			return webhook.Webhook{}, nil, fmt.Errorf("Failed to create response for '%s', %w", hook.Endpoint, err)
+		}
+
+		wh_response = r
+	}
+
+	var wh_probe *webhook.Probe
+
+	if hook.Probe != nil {
+
+		probe_opts := &webhook.ProbeOptions{
+			Methods:     hook.Probe.Methods,
+			Status:      hook.Probe.Status,
+			Body:        hook.Probe.Body,
+			ContentType: hook.Probe.ContentType,
+			Headers:     hook.Probe.Headers,
+			MaxAge:      hook.Probe.MaxAge,
+		}
+
+		p, err := webhook.NewProbe(probe_opts)
+
+		if err != nil {
+			return webhook.Webhook{}, nil, fmt.Errorf("Failed to create probe for '%s', %w", hook.Endpoint, err)
+		}
+
+		wh_probe = p
+	}
+
+	var wh_cors *webhook.CORS
+
+	if hook.CORS != nil {
+
+		cors_opts := &webhook.CORSOptions{
+			Origins:       hook.CORS.Origins,
+			Methods:       hook.CORS.Methods,
+			Headers:       hook.CORS.Headers,
+			ExposeHeaders: hook.CORS.ExposeHeaders,
+			MaxAge:        hook.CORS.MaxAge,
+		}
+
+		c, err := webhook.NewCORS(cors_opts)
+
+		if err != nil {
+			return webhook.Webhook{}, nil, fmt.Errorf("Failed to create CORS policy for '%s', %w", hook.Endpoint, err)
+		}
+
+		wh_cors = c
+	}
+
+	var wh_slo *webhook.SLO
+
+	if hook.SLO != nil {
+
+		slo_opts := &webhook.SLOOptions{
+			SuccessRate:   hook.SLO.SuccessRate,
+			LatencyTarget: hook.SLO.LatencyTarget,
+			MinRequests:   hook.SLO.MinRequests,
+			BurnRate:      hook.SLO.BurnRate,
+		}
+
+		if hook.SLO.Latency != "" {
+
+			v, err := time.ParseDuration(hook.SLO.Latency)
+
+			if err != nil {
+				return webhook.Webhook{}, nil, fmt.Errorf("Invalid SLO latency for '%s', %w", hook.Endpoint, err)
+			}
+
+			slo_opts.Latency = v
+		}
 
-	q := u.Query()
+		if hook.SLO.Window != "" {
 
-	str_debug := q.Get("allow_debug")
+			v, err := time.ParseDuration(hook.SLO.Window)
 
-	allow_debug := false
+			if err != nil {
+				return webhook.Webhook{}, nil, fmt.Errorf("Invalid SLO window for '%s', %w", hook.Endpoint, err)
+			}
 
-	if str_debug != "" {
+			slo_opts.Window = v
+		}
 
-		v, err := strconv.ParseBool(str_debug)
+		s, err := webhook.NewSLO(slo_opts)
 
 		if err != nil {
-			return nil, fmt.Errorf("Invalid ?allow_debug parameter, %w", err)
+			return webhook.Webhook{}, nil, fmt.Errorf("Failed to create SLO for '%s', %w", hook.Endpoint, err)
 		}
 
-		allow_debug = v
+		wh_slo = s
 	}
 
-	srv, err := server.NewServer(ctx, uri)
+	wh_opts := &webhook.WebhookOptions{
+		Endpoint:        hook.Endpoint,
+		Receiver:        rcvr,
+		Transformations: steps,
+		Dispatchers:     sendto,
+		Streaming:       hook.Streaming,
+		DispatchMode:    hook.DispatchMode,
+		AbortOnFailure:  hook.AbortOnFailure,
+		SuccessPolicy:   hook.SuccessPolicy,
+		Quorum:          hook.Quorum,
+		DebugToken:      hook.DebugToken,
+		Methods:         hook.Methods,
+		Response:        wh_response,
+		Probe:           wh_probe,
+		CORS:            wh_cors,
+		Routes:          routes,
+		Labels:          labels,
+		SLO:             wh_slo,
+		Middleware:      wh_middleware,
+	}
 
-	if err != nil {
-		return nil, fmt.Errorf("Failed to create new server instance, %w", err)
+	if tenant != nil {
+		wh_opts.Tenant = tenant.name
 	}
 
-	webhooks := make(map[string]webhookd.WebhookHandler)
+	wh, err := webhook.NewWebhookWithOptions(ctx, wh_opts)
 
-	d := WebhookDaemon{
-		server:     srv,
-		webhooks:   webhooks,
-		AllowDebug: allow_debug,
+	if err != nil {
+		return webhook.Webhook{}, nil, fmt.Errorf("Failed to create new webhook for '%s', %w", hook.Endpoint, err)
 	}
 
-	return &d, nil
+	return wh, src, nil
 }
 
-// AddWebhooksFromConfig() appends the webhooks defined in 'cfg' to 'd'.
-func (d *WebhookDaemon) AddWebhooksFromConfig(ctx context.Context, cfg *config.WebhookConfig) error {
+// newMiddlewareFromConfig() returns the list of `webhookd.WebhookMiddleware` instances for the middleware labels in 'names'.
+func newMiddlewareFromConfig(ctx context.Context, cfg *config.WebhookConfig, names []string) ([]webhookd.WebhookMiddleware, error) {
 
-	if len(cfg.Webhooks) == 0 {
-		return fmt.Errorf("No webhooks defined")
-	}
+	var chain []webhookd.WebhookMiddleware
 
-	for i, hook := range cfg.Webhooks {
+	for _, name := range names {
 
-		if hook.Endpoint == "" {
-			return fmt.Errorf("Missing endpoint at offset %d", i+1)
+		middleware_uri, err := cfg.GetMiddlewareConfigByName(name)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to get middleware configuration for '%s', %w", name, err)
 		}
 
-		if hook.Receiver == "" {
-			return fmt.Errorf("Missing receiver at offset %d", i+1)
+		mw, err := middleware.NewMiddleware(ctx, middleware_uri)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to create middleware for '%s', %w", name, err)
 		}
 
-		if len(hook.Dispatchers) == 0 {
-			return fmt.Errorf("Missing dispatchers at offset %d", i+1)
+		chain = append(chain, mw)
+	}
+
+	return chain, nil
+}
+
+// AddMiddleware() appends 'mw' to the list of middleware applied to every webhook request for 'd'. Global middleware is applied, in
+// the order it was added, before any middleware for individual webhooks.
+func (d *WebhookDaemon) AddMiddleware(ctx context.Context, mw webhookd.WebhookMiddleware) {
+	d.middleware = append(d.middleware, mw)
+}
+
+// newDispatchersFromConfig() returns the list of `webhookd.WebhookDispatcher` instances for the dispatcher labels in 'names'. Labels
+// starting with "#" are ignored.
+func newDispatchersFromConfig(ctx context.Context, cfg *config.WebhookConfig, names []string) ([]webhookd.WebhookDispatcher, error) {
+
+	var sendto []webhookd.WebhookDispatcher
+
+	for _, name := range names {
+
+		if strings.HasPrefix(name, "#") {
+			continue
 		}
 
-		receiver_uri, err := cfg.GetReceiverConfigByName(hook.Receiver)
+		dispatcher_uri, err := cfg.GetDispatcherConfigByName(name)
 
 		if err != nil {
-			return fmt.Errorf("Failed to get receiver config for '%s', %w", hook.Receiver, err)
+			return nil, fmt.Errorf("Failed to get dispatcher configuration for '%s', %w", name, err)
 		}
 
-		receiver, err := receiver.NewReceiver(ctx, receiver_uri)
+		dispatcher, err := dispatcher.NewDispatcher(ctx, dispatcher_uri)
 
 		if err != nil {
-			return fmt.Errorf("Failed to add receiver '%s', %w", receiver_uri, err)
+			return nil, fmt.Errorf("Failed to create dispatcher for '%s', %w", dispatcher_uri, err)
 		}
 
-		var steps []webhookd.WebhookTransformation
+		sendto = append(sendto, dispatcher)
+	}
 
-		for _, name := range hook.Transformations {
+	return sendto, nil
+}
 
-			if strings.HasPrefix(name, "#") {
-				continue
-			}
+// AddWebhook() adds 'wh' to 'd'. If 'd' has an audit log the change is recorded before it is made and is not made if it can not
+// be recorded.
+func (d *WebhookDaemon) AddWebhook(ctx context.Context, wh webhook.Webhook) error {
 
-			transformation_uri, err := cfg.GetTransformationConfigByName(name)
+	d.mu.Lock()
+	defer d.mu.Unlock()
 
-			if err != nil {
-				return fmt.Errorf("Failed to get transformation configuration for '%s', %w", name, err)
-			}
+	_, ok := d.webhooks[wh.Endpoint()]
 
-			step, err := transformation.NewTransformation(ctx, transformation_uri)
+	if ok {
+		return fmt.Errorf("Endpoint already configured")
+	}
 
-			if err != nil {
-				return fmt.Errorf("Failed to create new transformation for '%s', %w", transformation_uri, err)
-			}
+	return d.putWebhook(ctx, AUDIT_ADD_WEBHOOK, nil, wh)
+}
 
-			steps = append(steps, step)
-		}
+// ReplaceWebhook() replaces the webhook in 'd' with the same endpoint as 'wh'. Requests that are already being processed by
+// the previous webhook are not affected. If 'd' has an audit log the change is recorded before it is made and is not made if it
+// can not be recorded.
+func (d *WebhookDaemon) ReplaceWebhook(ctx context.Context, wh webhook.Webhook) error {
 
-		var sendto []webhookd.WebhookDispatcher
+	d.mu.Lock()
+	defer d.mu.Unlock()
 
-		for _, name := range hook.Dispatchers {
+	existing, ok := d.webhooks[wh.Endpoint()]
 
-			if strings.HasPrefix(name, "#") {
-				continue
-			}
+	if !ok {
+		return fmt.Errorf("Endpoint not configured")
+	}
 
-			dispatcher_uri, err := cfg.GetDispatcherConfigByName(name)
+	return d.putWebhook(ctx, AUDIT_UPDATE_WEBHOOK, describeWebhook(existing, nil), wh)
+}
 
-			if err != nil {
-				return fmt.Errorf("Failed to get dispatcher configuration for '%s', %w", name, err)
-			}
+// RemoveWebhook() removes the webhook for 'endpoint' from 'd'. Requests that are already being processed by the webhook are not
+// affected. Webhooks whose messages are consumed from a source can not be removed. If 'd' has an audit log the change is recorded
+// before it is made and is not made if it can not be recorded.
+func (d *WebhookDaemon) RemoveWebhook(ctx context.Context, endpoint string) error {
 
-			dispatcher, err := dispatcher.NewDispatcher(ctx, dispatcher_uri)
+	d.mu.Lock()
+	defer d.mu.Unlock()
 
-			if err != nil {
-				return fmt.Errorf("Failed to create dispatcher for '%s', %w", dispatcher_uri, err)
-			}
+	existing, ok := d.webhooks[endpoint]
 
-			sendto = append(sendto, dispatcher)
-		}
+	if !ok {
+		return fmt.Errorf("Endpoint not configured")
+	}
+
+	err := d.auditLog.record(ctx, AUDIT_REMOVE_WEBHOOK, endpoint, describeWebhook(existing, nil), nil)
+
+	if err != nil {
+		return err
+	}
+
+	d.removePattern(endpoint)
+
+	delete(d.webhooks, endpoint)
+	delete(d.slos, endpoint)
+
+	return nil
+}
+
+// putWebhook records 'action', changing the webhook for the endpoint of 'wh' from 'before', in the audit log for 'd' and then
+// assigns 'wh' to that endpoint. It is expected that callers will have locked 'd.mu'.
+func (d *WebhookDaemon) putWebhook(ctx context.Context, action string, before *webhookDescription, wh webhook.Webhook) error {
+
+	endpoint := wh.Endpoint()
+
+	var p *webhook.EndpointPattern
 
-		wh, err := webhook.NewWebhook(ctx, hook.Endpoint, receiver, steps, sendto)
+	if webhook.IsEndpointPattern(endpoint) {
+
+		v, err := webhook.NewEndpointPattern(endpoint)
 
 		if err != nil {
-			return fmt.Errorf("Failed to create new webhook for '%s', %w", hook.Endpoint, err)
+			return fmt.Errorf("Invalid endpoint pattern, %w", err)
 		}
 
-		err = d.AddWebhook(ctx, wh)
+		p = v
+	}
+
+	err := d.auditLog.record(ctx, action, endpoint, before, describeWebhook(wh, nil))
 
-		if err != nil {
-			return fmt.Errorf("Failed to add new webhook for '%s', %w", hook.Endpoint, err)
+	if err != nil {
+		return err
+	}
+
+	d.removePattern(endpoint)
+
+	if p != nil {
+
+		d.patterns = append(d.patterns, p)
+
+		sort.SliceStable(d.patterns, func(i, j int) bool {
+			return d.patterns[i].Specificity() > d.patterns[j].Specificity()
+		})
+	}
+
+	if wh.SLO() != nil {
+
+		if d.slos == nil {
+			d.slos = make(map[string]*sloTracker)
 		}
 
+		d.slos[endpoint] = newSLOTracker(wh.SLO())
+
+	} else {
+		delete(d.slos, endpoint)
 	}
 
+	d.webhooks[endpoint] = wh
 	return nil
 }
 
-// AddWebhook() adds 'wh' to 'd'.
-func (d *WebhookDaemon) AddWebhook(ctx context.Context, wh webhook.Webhook) error {
+// removePattern removes the `webhook.EndpointPattern` for 'endpoint', if present, from 'd'. It is expected that callers will have
+// locked 'd.mu'.
+func (d *WebhookDaemon) removePattern(endpoint string) {
 
-	endpoint := wh.Endpoint()
-	_, ok := d.webhooks[endpoint]
+	patterns := make([]*webhook.EndpointPattern, 0, len(d.patterns))
+
+	for _, p := range d.patterns {
+
+		if p.Endpoint() != endpoint {
+			patterns = append(patterns, p)
+		}
+	}
+
+	d.patterns = patterns
+}
+
+// getWebhook returns the `webhook.Webhook` instance, received over HTTP, whose endpoint is 'endpoint'.
+func (d *WebhookDaemon) getWebhook(endpoint string) (webhook.Webhook, bool) {
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	wh, ok := d.webhooks[endpoint]
+	return wh, ok
+}
+
+// lookupWebhook returns the `webhook.Webhook` instance matching 'path' along with any path parameters derived from
+// its endpoint. Exact matches are always preferred over endpoints containing named parameters or wildcards.
+func (d *WebhookDaemon) lookupWebhook(path string) (webhook.Webhook, map[string]string, bool) {
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	wh, ok := d.webhooks[path]
 
 	if ok {
-		return fmt.Errorf("Endpoint already configured")
+		return wh, map[string]string{}, true
 	}
 
-	d.webhooks[endpoint] = wh
-	return nil
+	for _, p := range d.patterns {
+
+		params, ok := p.Match(path)
+
+		if ok {
+			return d.webhooks[p.Endpoint()], params, true
+		}
+	}
+
+	return webhook.Webhook{}, nil, false
 }
 
+// webhookMiddlewareKey is the key used to signal, in a `context.Context` instance, that the middleware for a webhook has been applied.
+const webhookMiddlewareKey contextKey = "webhookd.daemon.webhook_middleware"
+
 // HandlerFunc() returns a `http.HandlerFunc` that handles HTTP (webhook) requests and response for 'd'.
 func (d *WebhookDaemon) HandlerFunc() (http.HandlerFunc, error) {
 	logger := log.Default()
@@ -212,10 +996,15 @@ func (d *WebhookDaemon) HandlerFunc() (http.HandlerFunc, error) {
 }
 
 // HandlerFuncWithLogger() returns a `http.HandlerFunc` that handles HTTP (webhook) requests and response for 'd'
-// logging events to 'logger'.
+// logging events to 'logger'. Global middleware is applied to every request and the middleware for individual webhooks is
+// applied once a request has been matched to a webhook.
 func (d *WebhookDaemon) HandlerFuncWithLogger(logger *log.Logger) (http.HandlerFunc, error) {
 
-	handler := func(rsp http.ResponseWriter, req *http.Request) {
+	var handler func(rsp http.ResponseWriter, req *http.Request)
+
+	handler = func(rsp http.ResponseWriter, req *http.Request) {
+
+		defer d.recoverRequest(rsp, req, logger)
 
 		ctx := req.Context()
 
@@ -224,7 +1013,7 @@ func (d *WebhookDaemon) HandlerFuncWithLogger(logger *log.Logger) (http.HandlerF
 
 		endpoint := req.URL.Path
 
-		wh, ok := d.webhooks[endpoint]
+		wh, params, ok := d.lookupWebhook(endpoint)
 
 		if !ok {
 			aa_log.Warning(logger, "Endpoint not found, %s", endpoint)
@@ -232,6 +1021,123 @@ func (d *WebhookDaemon) HandlerFuncWithLogger(logger *log.Logger) (http.HandlerF
 			return
 		}
 
+		// CORS preflight requests are answered before any middleware for the webhook is applied since browsers never send
+		// credentials with them. Other requests get their CORS headers up front so that error responses are readable too.
+
+		if cors := wh.CORS(); cors != nil && ctx.Value(webhookMiddlewareKey) == nil {
+
+			if webhook.IsPreflight(req) {
+				cors.WritePreflight(rsp, req, wh.Methods())
+				return
+			}
+
+			cors.WriteHeaders(rsp, req)
+		}
+
+		// Probes are answered before any middleware for the webhook is applied since they never reach the receiver
+
+		if probe := wh.Probe(); probe != nil && probe.Handles(req.Method) {
+
+			allow := append(append([]string{}, wh.Methods()...), probe.Methods()...)
+			err := probe.Write(rsp, req, allow)
+
+			if err != nil {
+				aa_log.Debug(logger, "Failed to write probe response for %s, %v", endpoint, err)
+			}
+
+			return
+		}
+
+		if len(wh.Middleware()) > 0 && ctx.Value(webhookMiddlewareKey) == nil {
+
+			next := func(rsp http.ResponseWriter, req *http.Request) {
+				ctx := context.WithValue(req.Context(), webhookMiddlewareKey, true)
+				handler(rsp, req.WithContext(ctx))
+			}
+
+			middleware.Chain(http.HandlerFunc(next), wh.Middleware()...).ServeHTTP(rsp, req)
+			return
+		}
+
+		delivery_id := deliveryID(req)
+
+		delivery := &webhookd.Delivery{
+			Endpoint:       wh.Endpoint(),
+			ID:             delivery_id,
+			EventType:      eventType(req.Header),
+			Header:         req.Header.Clone(),
+			RemoteAddress:  remoteAddress(req, d.RemoteAddressHeader),
+			PathParameters: params,
+		}
+
+		ctx = webhookd.WithDelivery(ctx, delivery)
+		ctx = webhookd.WithMessageHeaders(ctx)
+		ctx = webhookd.WithChallengeResponse(ctx)
+
+		response_headers := d.ResponseHeaders
+
+		if response_headers == nil {
+			response_headers = NewResponseHeaders()
+		}
+
+		response_headers.setDeliveryID(rsp, delivery_id)
+
+		access_log := accessLogEntryFromContext(ctx)
+		access_log.setWebhook(wh.Endpoint(), delivery_id)
+
+		if !wh.AllowsMethod(req.Method) {
+			aa_log.Warning(logger, "Method %s not allowed for %s", req.Method, endpoint)
+			allow := wh.Methods()
+
+			if probe := wh.Probe(); probe != nil {
+				allow = append(append([]string{}, allow...), probe.Methods()...)
+			}
+
+			rsp.Header().Set("Allow", strings.Join(allow, ", "))
+			http.Error(rsp, "405 Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		dryrun, dryrun_err := d.dryRun(req)
+
+		if dryrun_err != nil {
+			aa_log.Warning(logger, "Invalid dry run request for %s, %v", endpoint, dryrun_err)
+			http.Error(rsp, dryrun_err.Error(), dryrun_err.Code)
+			return
+		}
+
+		if dryrun && wh.Streaming() {
+			http.Error(rsp, "Dry runs are not supported by streaming webhooks", http.StatusBadRequest)
+			return
+		}
+
+		debug, debug_err := debugRequested(req, wh)
+
+		if debug_err != nil {
+			aa_log.Warning(logger, "Invalid debug request for %s, %v", endpoint, debug_err)
+			http.Error(rsp, debug_err.Error(), debug_err.Code)
+			return
+		}
+
+		var trace *debugTrace
+
+		if debug {
+
+			if req.Header.Get(DEBUG_TOKEN_HEADER) == "" {
+				aa_log.Warning(logger, "Debug token for %s was sent as a query parameter, which may be recorded in logs, use the %s header instead", endpoint, DEBUG_TOKEN_HEADER)
+			}
+
+			trace = newDebugTrace(wh, delivery_id)
+		}
+
+		delivery.DryRun = dryrun
+		access_log.setDryRun(dryrun)
+
+		if wh.Streaming() {
+			d.handleStream(ctx, rsp, req, wh, response_headers, access_log, logger)
+			return
+		}
+
 		t1 := time.Now()
 
 		var ta time.Time
@@ -253,9 +1159,16 @@ func (d *WebhookDaemon) HandlerFuncWithLogger(logger *log.Logger) (http.HandlerF
 
 		if err != nil {
 
+			response_headers.setErrorOutcome(rsp, "receiver", err)
+
+			if receiver.IsStale(err) {
+				d.metrics.recordStale(wh.Endpoint(), wh.Tenant())
+			}
+
 			switch err.Code {
 			case webhookd.UnhandledEvent, webhookd.HaltEvent:
 				aa_log.Info(logger, "Receiver step (%T)  returned non-fatal error and exiting, %v", rcvr, err)
+				writeChallengeResponse(ctx, rsp)
 				return
 			default:
 				aa_log.Error(logger, "Receiver step (%T) failed, %v", rcvr, err)
@@ -267,15 +1180,22 @@ func (d *WebhookDaemon) HandlerFuncWithLogger(logger *log.Logger) (http.HandlerF
 		tb = time.Since(ta)
 
 		ttr = tb
+		access_log.setTiming("receive", ttr)
+
+		trace.addStage("receiver", 0, rcvr, [][]byte{body})
 
 		ta = time.Now()
 
+		bodies := [][]byte{body}
+
 		for idx, step := range wh.Transformations() {
 
-			body, err = step.Transform(ctx, body)
+			bodies, err = transformation.TransformMessages(ctx, step, bodies)
 
 			if err != nil {
 
+				response_headers.setErrorOutcome(rsp, "transformation", err)
+
 				switch err.Code {
 				case webhookd.UnhandledEvent, webhookd.HaltEvent:
 					aa_log.Info(logger, "Transformation step (%T) at offset %d returned non-fatal error and exiting, %v", step, idx, err)
@@ -287,97 +1207,146 @@ func (d *WebhookDaemon) HandlerFuncWithLogger(logger *log.Logger) (http.HandlerF
 				}
 			}
 
+			trace.addStage("transformation", idx, step, bodies)
+
 			// check to see if there is anything left the transformation
 			// https://github.com/whosonfirst/go-webhookd/v3/issues/7
 		}
 
 		tb = time.Since(ta)
 		ttt = tb
+		access_log.setTiming("transform", ttt)
 
 		// check to see if there is anything to dispatch
 		// https://github.com/whosonfirst/go-webhookd/v3/issues/7
 
 		ta = time.Now()
 
-		wg := new(sync.WaitGroup)
-		ch := make(chan *webhookd.WebhookError)
+		// Transformations may have split the original message in to zero or more
+		// messages, each of which is relayed to the dispatchers independently
 
-		for idx, d := range wh.Dispatchers() {
+		dispatchers_for := func(body []byte) []webhookd.WebhookDispatcher {
 
-			wg.Add(1)
+			if len(wh.Routes()) == 0 {
+				return wh.Dispatchers()
+			}
 
-			go func(idx int, d webhookd.WebhookDispatcher, body []byte) {
+			env := newRoutingEnvironment(req, params, body)
+			return wh.DispatchersForEnvironment(env)
+		}
 
-				defer wg.Done()
+		if dryrun {
 
-				err = d.Dispatch(ctx, body)
+			aa_log.Info(logger, "Dry run for %s (%s), skipping dispatch", endpoint, delivery_id)
+			response_headers.setOutcome(rsp, OUTCOME_DRYRUN, fmt.Sprintf("messages=%d", len(bodies)))
 
-				if err != nil {
+			err := writeDryRun(rsp, wh, delivery_id, bodies, dispatchers_for)
 
-					switch err.Code {
-					case webhookd.UnhandledEvent, webhookd.HaltEvent:
-						aa_log.Info(logger, "Dispatch step (%T) at offset %d returned non-fatal error and exiting, %v", d, idx, err)
-						return
-					default:
-						aa_log.Error(logger, "Dispatch step (%T) at offset %d failed, %v", d, idx, err)
-						ch <- err
-					}
-				}
+			if err != nil {
+				aa_log.Error(logger, "Failed to write dry run for %s, %v", endpoint, err)
+			}
+
+			return
+		}
 
-			}(idx, d, body)
+		claimed, claim_key, claim_err := d.claimDelivery(ctx, req, wh, body)
+
+		if claim_err != nil {
+			aa_log.Warning(logger, "Failed to claim delivery %s for %s, dispatching anyway, %v", delivery_id, endpoint, claim_err)
+		} else if !claimed {
+			aa_log.Info(logger, "Delivery %s for %s has already been claimed, skipping dispatch", delivery_id, endpoint)
+			response_headers.setOutcome(rsp, OUTCOME_DUPLICATE)
+			return
 		}
 
-		// https://github.com/whosonfirst/go-webhookd/issues/14
-		// this is broken as in len(errors) will always be zero even if
-		// there are errors (20190214/thisisaaronland)
+		// Release the claim if dispatching fails, or panics, so that the provider can retry the delivery
 
-		errors := make([]string, 0)
+		succeeded := false
 
-		go func() {
+		defer func() {
 
-			for e := range ch {
-				errors = append(errors, e.Error())
+			if !succeeded {
+				d.releaseDelivery(ctx, claim_key, logger)
 			}
 		}()
 
-		wg.Wait()
+		summary := d.dispatchMessages(ctx, wh, bodies, dispatchers_for, access_log, logger)
 
-		if len(errors) > 0 {
+		if !summary.ok {
 
-			msg := strings.Join(errors, "\n\n")
+			response_headers.setDispatchOutcome(rsp, OUTCOME_FAILED, len(bodies), summary)
+
+			msg := strings.Join(summary.errors, "\n\n")
 			http.Error(rsp, msg, http.StatusInternalServerError)
 			return
 		}
 
+		succeeded = true
+
 		tb = time.Since(ta)
 		ttd = tb
+		access_log.setTiming("dispatch", ttd)
+
+		d.events.publish(delivery_id, wh.Endpoint(), endpoint, bodies)
 
 		t2 := time.Since(t1)
+		access_log.setTiming("process", t2)
+
+		aa_log.Debug(logger, "Time to receive: %v, transform: %v, dispatch: %v, process: %v", ttr, ttt, ttd, t2)
+
+		response_headers.setTiming(rsp, "Receive", ttr)
+		response_headers.setTiming(rsp, "Transform", ttt)
+		response_headers.setTiming(rsp, "Dispatch", ttd)
+		response_headers.setTiming(rsp, "Process", t2)
+
+		response_headers.setDispatchOutcome(rsp, OUTCOME_DISPATCHED, len(bodies), summary)
 
-		aa_log.Debug(logger, "Time to receive: %v", ttr)
-		aa_log.Debug(logger, "Time to transform: %v", ttt)
-		aa_log.Debug(logger, "Time to dispatch: %v", ttd)
-		aa_log.Debug(logger, "Time to process: %v", t2)
+		if debug {
 
-		rsp.Header().Set("X-Webhookd-Time-To-Receive", fmt.Sprintf("%v", ttr))
-		rsp.Header().Set("X-Webhookd-Time-To-Transform", fmt.Sprintf("%v", ttt))
-		rsp.Header().Set("X-Webhookd-Time-To-Dispatch", fmt.Sprintf("%v", ttd))
-		rsp.Header().Set("X-Webhookd-Time-To-Process", fmt.Sprintf("%v", t2))
+			err := trace.write(rsp, bodies)
 
-		if d.AllowDebug {
+			if err != nil {
+				aa_log.Error(logger, "Failed to write debugging output for %s, %v", endpoint, err)
+			}
+
+			return
+		}
+
+		wh_response := wh.Response()
+
+		if wh_response != nil {
+
+			data := &webhook.ResponseTemplateData{
+				DeliveryID: delivery_id,
+				Endpoint:   wh.Endpoint(),
+				EventType:  delivery.EventType,
+				Path:       endpoint,
+				Params:     params,
+			}
 
-			query := req.URL.Query()
-			debug := query.Get("debug")
+			err := wh_response.Write(rsp, data)
 
-			if debug != "" {
-				rsp.Header().Set("Content-Type", "text/plain")
-				rsp.Header().Set("Access-Control-Allow-Origin", "*")
-				rsp.Write(body)
+			if err != nil {
+				aa_log.Error(logger, "Failed to write response for %s, %v", endpoint, err)
 			}
 		}
 	}
 
-	return http.HandlerFunc(handler), nil
+	var h http.Handler = http.HandlerFunc(handler)
+
+	if len(d.middleware) > 0 {
+		h = middleware.Chain(h, d.middleware...)
+	}
+
+	// Assign the remote address before any middleware is applied so that middleware (for example IP filters) uses
+	// the same address as the rest of the daemon
+
+	middleware_handler := func(rsp http.ResponseWriter, req *http.Request) {
+		ctx := webhookd.WithRemoteAddress(req.Context(), remoteAddress(req, d.RemoteAddressHeader))
+		h.ServeHTTP(rsp, req.WithContext(ctx))
+	}
+
+	return http.HandlerFunc(middleware_handler), nil
 }
 
 // Start() causes 'd' to listen for, and process, requests.
@@ -386,7 +1355,9 @@ func (d *WebhookDaemon) Start(ctx context.Context) error {
 	return d.StartWithLogger(ctx, logger)
 }
 
-// StartWithLogger() causes 'd' to listen for, and process, requests logging events to 'logger'.
+// StartWithLogger() causes 'd' to listen for, and process, requests logging events to 'logger'. Messages for webhooks with a
+// source, and messages delivered over gRPC if 'd' has been configured to do so, are processed until the server stops listening
+// for requests.
 func (d *WebhookDaemon) StartWithLogger(ctx context.Context, logger *log.Logger) error {
 
 	handler, err := d.HandlerFuncWithLogger(logger)
@@ -395,11 +1366,105 @@ func (d *WebhookDaemon) StartWithLogger(ctx context.Context, logger *log.Logger)
 		return fmt.Errorf("Failed to create handler func, %w", err)
 	}
 
+	var webhook_handler http.Handler = handler
+
+	if d.usage != nil {
+		webhook_handler = d.usageHandlerWithLogger(webhook_handler, logger)
+	}
+
+	// Webhooks added by a controller may define service level objectives
+
+	if d.accessLog != nil || d.metrics != nil || len(d.slos) > 0 || d.usage != nil || d.controller != nil {
+		webhook_handler = d.accessLogHandlerWithLogger(d.accessLog, webhook_handler, logger)
+	}
+
+	stop_metrics := d.metrics.start(ctx)
+	defer stop_metrics()
+
+	stop_secrets := secrets.DefaultResolver.Start(ctx)
+	defer stop_secrets()
+
+	if d.cluster != nil {
+		defer d.cluster.Close()
+	}
+
+	if d.usage != nil {
+
+		defer func() {
+
+			err := d.usage.Close(ctx)
+
+			if err != nil {
+				aa_log.Warning(logger, "Failed to close usage store, %v", err)
+			}
+		}()
+	}
+
 	mux := http.NewServeMux()
-	mux.HandleFunc("/", handler)
+	mux.Handle("/", webhook_handler)
+
+	if d.graphql != nil {
+		mux.Handle(d.graphql.path, d.graphQLHandlerWithLogger(webhook_handler, logger))
+	}
+
+	if d.openapi != nil {
+		mux.Handle(d.openapi.path, d.openAPIHandlerWithLogger(logger))
+	}
+
+	if d.capture != nil {
+		mux.Handle(d.capture.path, d.captureHandlerWithLogger(logger))
+	}
+
+	stop_grpc, err := d.startGRPC(webhook_handler, logger)
+
+	if err != nil {
+		return fmt.Errorf("Failed to start gRPC server, %w", err)
+	}
+
+	defer stop_grpc()
+
+	stop_admin, err := d.startAdmin(logger)
+
+	if err != nil {
+		return fmt.Errorf("Failed to start admin server, %w", err)
+	}
+
+	defer stop_admin()
+
+	if d.elector != nil {
+		defer d.elector.Close()
+	}
+
+	sources_ctx, cancel := context.WithCancel(ctx)
+	sources_wg := d.startSources(sources_ctx, logger)
+	controller_wg := d.startController(sources_ctx, logger)
+
+	defer func() {
+		cancel()
+		sources_wg.Wait()
+		controller_wg.Wait()
+	}()
 
 	svr := d.server
 
+	// Tunnel clients only connect to the server when they receive a request so it is safe to open the tunnel first
+
+	if d.tunnel != nil {
+
+		err = d.openTunnel(ctx, logger)
+
+		if err != nil {
+			return fmt.Errorf("Failed to open tunnel, %w", err)
+		}
+
+		defer d.tunnel.Close()
+	}
+
+	// Drift checks use the public URL for the tunnel so they are started once it has been opened
+
+	stop_drift := d.startDriftCheck(ctx, logger)
+	defer stop_drift()
+
 	aa_log.Info(logger, "Webhookd listening for requests on %s\n", svr.Address())
 
 	err = svr.ListenAndServe(ctx, mux)
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/whosonfirst/go-webhookd/v3/config"
 	"log"
+	"net"
 	"net/http"
 	"path/filepath"
 	"strings"
@@ -37,6 +38,20 @@ func TestNewWebhookDaemonFromConfig(t *testing.T) {
 		t.Fatalf("Failed to create new daemon from config, %v", err)
 	}
 
+	// Bind the listener before the daemon is started so that the request below is queued, rather than refused, until the
+	// daemon starts serving requests
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatalf("Failed to create listener, %v", err)
+	}
+
+	d.server = &listenerServer{listener: l}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
 	go func() {
 
 		err := d.Start(ctx)
@@ -48,7 +63,7 @@ func TestNewWebhookDaemonFromConfig(t *testing.T) {
 
 	body := strings.NewReader("hello world")
 
-	rsp, err := http.Post("http://localhost:8080/insecure-test", "text/plain", body)
+	rsp, err := http.Post(fmt.Sprintf("http://%s/insecure-test", l.Addr().String()), "text/plain", body)
 
 	if err != nil {
 		t.Fatalf("Failed to issue webhook request, %v", err)
@@ -58,3 +73,35 @@ func TestNewWebhookDaemonFromConfig(t *testing.T) {
 		t.Fatalf("Unexpected HTTP status: %s", rsp.Status)
 	}
 }
+
+// listenerServer is a `aaronland/go-http-server.Server` implementation that serves requests using a listener which has
+// already been bound.
+type listenerServer struct {
+	listener net.Listener
+}
+
+// Address returns the URI of the address that 's' is listening on.
+func (s *listenerServer) Address() string {
+	return "http://" + s.listener.Addr().String()
+}
+
+// ListenAndServe serves requests with 'mux' until 'ctx' is cancelled.
+func (s *listenerServer) ListenAndServe(ctx context.Context, mux http.Handler) error {
+
+	srv := &http.Server{
+		Handler: mux,
+	}
+
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+
+	err := srv.Serve(s.listener)
+
+	if err == http.ErrServerClosed {
+		return nil
+	}
+
+	return err
+}
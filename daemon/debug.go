@@ -0,0 +1,122 @@
+package daemon
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+	"github.com/whosonfirst/go-webhookd/v3/webhook"
+)
+
+// DEBUG_TOKEN_HEADER is the name of the request header used to authenticate requests for debugging output.
+const DEBUG_TOKEN_HEADER string = "X-Webhookd-Debug-Token"
+
+// debugTrace is the JSON-encoded debugging output for a message.
+type debugTrace struct {
+	// Endpoint is the endpoint of the webhook.
+	Endpoint string `json:"endpoint"`
+	// DeliveryID is the unique identifier for the message.
+	DeliveryID string `json:"delivery_id"`
+	// Stages is the list of intermediate message bodies produced by the receiver and each transformation, in order.
+	Stages []*debugStage `json:"stages"`
+	// Messages is the list of final (transformed) message bodies that were dispatched.
+	Messages []string `json:"messages"`
+}
+
+// debugStage is the output of a single processing step.
+type debugStage struct {
+	// Step is the name of the processing step, either "receiver" or "transformation".
+	Step string `json:"step"`
+	// Offset is the offset of the transformation in the list of transformations for the webhook.
+	Offset int `json:"offset"`
+	// Type is the type of the receiver or transformation.
+	Type string `json:"type"`
+	// Bodies is the list of message bodies produced by the step.
+	Bodies []string `json:"bodies"`
+}
+
+// debugRequested returns a boolean value indicating whether 'req' is a request for debugging output, with a `?debug=` parameter,
+// or an error if 'wh' does not have a debug token or the request has not been authenticated with it. The token may be passed in
+// the `DEBUG_TOKEN_HEADER` header, which is preferred, or, if the header is absent, as the value of the `?debug=` parameter.
+func debugRequested(req *http.Request, wh webhook.Webhook) (bool, *webhookd.WebhookError) {
+
+	if req.URL.RawQuery == "" {
+		return false, nil
+	}
+
+	str_debug := req.URL.Query().Get("debug")
+
+	if str_debug == "" {
+		return false, nil
+	}
+
+	token := req.Header.Get(DEBUG_TOKEN_HEADER)
+
+	if token == "" {
+		token = str_debug
+	}
+
+	if wh.DebugToken() == "" || subtle.ConstantTimeCompare([]byte(token), []byte(wh.DebugToken())) != 1 {
+		code := http.StatusForbidden
+		message := "Debugging not allowed"
+		return false, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	return true, nil
+}
+
+// newDebugTrace returns a new `debugTrace` instance for the message with 'delivery_id' processed by 'wh'.
+func newDebugTrace(wh webhook.Webhook, delivery_id string) *debugTrace {
+
+	t := &debugTrace{
+		Endpoint:   wh.Endpoint(),
+		DeliveryID: delivery_id,
+		Stages:     make([]*debugStage, 0),
+		Messages:   make([]string, 0),
+	}
+
+	return t
+}
+
+// addStage records 'bodies' as the output of the processing step 'step', performed by 'v' at offset 'offset'. It is safe to call
+// on a nil instance.
+func (t *debugTrace) addStage(step string, offset int, v interface{}, bodies [][]byte) {
+
+	if t == nil {
+		return
+	}
+
+	s := &debugStage{
+		Step:   step,
+		Offset: offset,
+		Type:   fmt.Sprintf("%T", v),
+		Bodies: make([]string, len(bodies)),
+	}
+
+	for idx, body := range bodies {
+		s.Bodies[idx] = string(body)
+	}
+
+	t.Stages = append(t.Stages, s)
+}
+
+// write writes 't', and the final message 'bodies', to 'rsp' as JSON.
+func (t *debugTrace) write(rsp http.ResponseWriter, bodies [][]byte) error {
+
+	for _, body := range bodies {
+		t.Messages = append(t.Messages, string(body))
+	}
+
+	enc, err := json.Marshal(t)
+
+	if err != nil {
+		return fmt.Errorf("Failed to encode debugging output, %w", err)
+	}
+
+	rsp.Header().Set("Content-Type", "application/json")
+
+	_, err = rsp.Write(enc)
+	return err
+}
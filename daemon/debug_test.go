@@ -0,0 +1,165 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+	"github.com/whosonfirst/go-webhookd/v3/receiver"
+	"github.com/whosonfirst/go-webhookd/v3/transformation"
+	"github.com/whosonfirst/go-webhookd/v3/webhook"
+)
+
+func TestDebug(t *testing.T) {
+
+	ctx := context.Background()
+	logger := log.New(io.Discard, "", 0)
+
+	d, err := NewWebhookDaemon(ctx, "http://localhost:8081")
+
+	if err != nil {
+		t.Fatalf("Failed to create new daemon, %v", err)
+	}
+
+	rcvr, err := receiver.NewReceiver(ctx, "insecure://")
+
+	if err != nil {
+		t.Fatalf("Failed to create receiver, %v", err)
+	}
+
+	tr, err := transformation.NewTransformation(ctx, "chicken://zxx")
+
+	if err != nil {
+		t.Fatalf("Failed to create transformation, %v", err)
+	}
+
+	calls := make([]string, 0)
+
+	opts := &webhook.WebhookOptions{
+		Endpoint:        "/test",
+		Receiver:        rcvr,
+		Transformations: []webhookd.WebhookTransformation{tr},
+		Dispatchers: []webhookd.WebhookDispatcher{
+			&orderedDispatcher{name: "a", calls: &calls, mu: new(sync.Mutex)},
+		},
+		DebugToken: "s33kret",
+	}
+
+	wh, err := webhook.NewWebhookWithOptions(ctx, opts)
+
+	if err != nil {
+		t.Fatalf("Failed to create webhook, %v", err)
+	}
+
+	err = d.AddWebhook(ctx, wh)
+
+	if err != nil {
+		t.Fatalf("Failed to add webhook, %v", err)
+	}
+
+	handler, err := d.HandlerFuncWithLogger(logger)
+
+	if err != nil {
+		t.Fatalf("Failed to create handler func, %v", err)
+	}
+
+	tests := []struct {
+		query  string
+		token  string
+		status int
+		debug  bool
+	}{
+		{"", "", http.StatusOK, false},
+		{"?debug=1", "", http.StatusForbidden, false},
+		{"?debug=wrong", "", http.StatusForbidden, false},
+		{"?debug=1", "wrong", http.StatusForbidden, false},
+		{"?debug=s33kret", "", http.StatusOK, true},
+		{"?debug=1", "s33kret", http.StatusOK, true},
+		// The header takes precedence over the ?debug= parameter
+		{"?debug=s33kret", "wrong", http.StatusForbidden, false},
+	}
+
+	for idx, test := range tests {
+
+		req := httptest.NewRequest("POST", "/test"+test.query, strings.NewReader("hello world"))
+		req.Header.Set("X-Request-Id", "1234")
+
+		if test.token != "" {
+			req.Header.Set(DEBUG_TOKEN_HEADER, test.token)
+		}
+
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+
+		if rec.Code != test.status {
+			t.Fatalf("Unexpected status for test %d: %d", idx, rec.Code)
+		}
+
+		if !test.debug {
+			continue
+		}
+
+		var trace debugTrace
+
+		err := json.Unmarshal(rec.Body.Bytes(), &trace)
+
+		if err != nil {
+			t.Fatalf("Failed to decode debugging output for test %d, %v", idx, err)
+		}
+
+		if trace.Endpoint != "/test" || trace.DeliveryID != "1234" || len(trace.Stages) != 2 || len(trace.Messages) != 1 {
+			t.Fatalf("Unexpected debugging output for test %d: %s", idx, rec.Body.String())
+		}
+
+		receive := trace.Stages[0]
+		transform := trace.Stages[1]
+
+		if receive.Step != "receiver" || receive.Type != "receiver.InsecureReceiver" || receive.Bodies[0] != "hello world" {
+			t.Fatalf("Unexpected receiver stage for test %d: %v", idx, receive)
+		}
+
+		if transform.Step != "transformation" || transform.Offset != 0 || transform.Bodies[0] != trace.Messages[0] || trace.Messages[0] == "hello world" {
+			t.Fatalf("Unexpected transformation stage for test %d: %v", idx, transform)
+		}
+	}
+
+	// Debugging output is returned after messages have been dispatched
+
+	if len(calls) != 3 {
+		t.Fatalf("Expected 3 dispatches, got %d", len(calls))
+	}
+}
+
+func TestDebugDisabled(t *testing.T) {
+
+	ctx := context.Background()
+
+	// The deprecated ?allow_debug parameter is ignored
+
+	_, err := NewWebhookDaemon(ctx, "http://localhost:8081?allow_debug=true")
+
+	if err != nil {
+		t.Fatalf("Expected ?allow_debug parameter to be ignored, %v", err)
+	}
+
+	wh, err := webhook.NewWebhook(ctx, "/test", nil, nil, nil)
+
+	if err != nil {
+		t.Fatalf("Failed to create webhook, %v", err)
+	}
+
+	req := httptest.NewRequest("POST", "/test?debug=1", nil)
+
+	_, wh_err := debugRequested(req, wh)
+
+	if wh_err == nil || wh_err.Code != http.StatusForbidden {
+		t.Fatalf("Expected debugging to be forbidden, %v", wh_err)
+	}
+}
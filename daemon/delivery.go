@@ -0,0 +1,131 @@
+package daemon
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// deliveryHeaders is the list of HTTP headers, used by webhook providers, that are checked (in order) for a unique delivery identifier.
+var deliveryHeaders = []string{
+	"X-GitHub-Delivery",
+	"X-Gitlab-Event-UUID",
+	"X-Request-Id",
+	"X-Delivery-Id",
+	"Webhook-Id",
+}
+
+// eventTypeHeaders is the list of HTTP headers, used by webhook providers, that are checked (in order) for the type of event
+// a webhook message describes.
+var eventTypeHeaders = []string{
+	"X-GitHub-Event",
+	"X-Gitlab-Event",
+	"X-Gitea-Event",
+	"X-Gogs-Event",
+	"X-Event-Key",
+	"X-Buildkite-Event",
+	"X-Webhook-Event",
+	"X-Webhookd-Event",
+}
+
+// deliveryID returns the unique identifier for the webhook message in 'req' derived from a provider-specific request header
+// or, if none are present, a newly generated random (version 4) UUID.
+func deliveryID(req *http.Request) string {
+
+	v := providerDeliveryID(req)
+
+	if v != "" {
+		return v
+	}
+
+	return newUUID()
+}
+
+// providerDeliveryID returns the unique identifier for the webhook message in 'req' derived from a provider-specific request
+// header or an empty string if none are present.
+func providerDeliveryID(req *http.Request) string {
+
+	for _, h := range deliveryHeaders {
+
+		v := req.Header.Get(h)
+
+		if v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
+
+// eventType returns the type of event a webhook message with 'header' describes, derived from a provider-specific header,
+// or an empty string if none are present.
+func eventType(header http.Header) string {
+
+	for _, h := range eventTypeHeaders {
+
+		v := header.Get(h)
+
+		if v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
+
+// newUUID returns a new random (version 4) UUID string.
+func newUUID() string {
+
+	var b [16]byte
+	rand.Read(b[:])
+
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	// This is called for every request so the UUID is encoded by hand rather than with fmt.Sprintf
+
+	var buf [36]byte
+
+	hex.Encode(buf[0:8], b[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], b[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], b[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], b[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:36], b[10:16])
+
+	return string(buf[:])
+}
+
+// remoteAddress returns the network address, without a port, of the client that sent 'req'. If 'header' is not empty and present
+// in 'req' then the last (comma-separated) address in that header is used instead of the address of the connection. This is
+// the address appended by the proxy closest to `webhookd` so it can't be spoofed by clients of that proxy.
+func remoteAddress(req *http.Request, header string) string {
+
+	if header != "" {
+
+		v := req.Header.Values(header)
+
+		if len(v) > 0 {
+
+			addrs := strings.Split(v[len(v)-1], ",")
+			addr := strings.TrimSpace(addrs[len(addrs)-1])
+
+			if addr != "" {
+				return addr
+			}
+		}
+	}
+
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+
+	if err != nil {
+		return req.RemoteAddr
+	}
+
+	return host
+}
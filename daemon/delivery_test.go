@@ -0,0 +1,170 @@
+package daemon
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+	"github.com/whosonfirst/go-webhookd/v3/receiver"
+	"github.com/whosonfirst/go-webhookd/v3/webhook"
+)
+
+// deliveryTransformation is a `webhookd.WebhookTransformation` implementation that records the `webhookd.Delivery`
+// metadata for the messages it transforms.
+type deliveryTransformation struct {
+	webhookd.WebhookTransformation
+	deliveries []*webhookd.Delivery
+}
+
+func (t *deliveryTransformation) Transform(ctx context.Context, body []byte) ([]byte, *webhookd.WebhookError) {
+
+	d, ok := webhookd.DeliveryFromContext(ctx)
+
+	if ok {
+		t.deliveries = append(t.deliveries, d)
+	}
+
+	return body, nil
+}
+
+func TestDeliveryID(t *testing.T) {
+
+	req := httptest.NewRequest("POST", "/", nil)
+	req.Header.Set("X-GitHub-Delivery", "72d3162e-cc78-11e3-81ab-4c9367dc0958")
+
+	if deliveryID(req) != "72d3162e-cc78-11e3-81ab-4c9367dc0958" {
+		t.Fatalf("Unexpected delivery ID: %s", deliveryID(req))
+	}
+
+	req = httptest.NewRequest("POST", "/", nil)
+
+	re := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+	if !re.MatchString(deliveryID(req)) {
+		t.Fatalf("Unexpected generated delivery ID: %s", deliveryID(req))
+	}
+}
+
+func TestRemoteAddress(t *testing.T) {
+
+	req := httptest.NewRequest("POST", "/", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	req.Header.Add("X-Forwarded-For", "198.51.100.1, 203.0.113.1")
+
+	if remoteAddress(req, "") != "192.0.2.1" {
+		t.Fatalf("Unexpected remote address: %s", remoteAddress(req, ""))
+	}
+
+	if remoteAddress(req, "X-Forwarded-For") != "203.0.113.1" {
+		t.Fatalf("Unexpected forwarded remote address: %s", remoteAddress(req, "X-Forwarded-For"))
+	}
+}
+
+func TestEventType(t *testing.T) {
+
+	h := http.Header{}
+
+	if eventType(h) != "" {
+		t.Fatalf("Expected empty event type")
+	}
+
+	h.Set("X-GitHub-Event", "push")
+
+	if eventType(h) != "push" {
+		t.Fatalf("Unexpected event type: %s", eventType(h))
+	}
+}
+
+func TestDelivery(t *testing.T) {
+
+	ctx := context.Background()
+	logger := log.New(io.Discard, "", 0)
+
+	d, err := NewWebhookDaemon(ctx, "http://localhost:8081")
+
+	if err != nil {
+		t.Fatalf("Failed to create new daemon, %v", err)
+	}
+
+	rcvr, err := receiver.NewReceiver(ctx, "insecure://")
+
+	if err != nil {
+		t.Fatalf("Failed to create receiver, %v", err)
+	}
+
+	tr := &deliveryTransformation{}
+
+	wh, err := webhook.NewWebhook(ctx, "/github/{repo}", rcvr, []webhookd.WebhookTransformation{tr}, nil)
+
+	if err != nil {
+		t.Fatalf("Failed to create webhook, %v", err)
+	}
+
+	err = d.AddWebhook(ctx, wh)
+
+	if err != nil {
+		t.Fatalf("Failed to add webhook, %v", err)
+	}
+
+	handler, err := d.HandlerFuncWithLogger(logger)
+
+	if err != nil {
+		t.Fatalf("Failed to create handler func, %v", err)
+	}
+
+	req := httptest.NewRequest("POST", "/github/go-webhookd", strings.NewReader("hello world"))
+	req.RemoteAddr = "192.0.2.1:1234"
+	req.Header.Set("X-GitHub-Delivery", "1234")
+	req.Header.Set("X-GitHub-Event", "push")
+
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Unexpected status: %d", rec.Code)
+	}
+
+	msg := &webhookd.WebhookMessage{
+		ID:   "5678",
+		Body: []byte("hello world"),
+		Header: http.Header{
+			"X-Webhookd-Event": []string{"test"},
+		},
+	}
+
+	wh_err := d.processSourceMessage(ctx, wh, msg, logger)
+
+	if wh_err != nil {
+		t.Fatalf("Failed to process source message, %v", wh_err)
+	}
+
+	if len(tr.deliveries) != 2 {
+		t.Fatalf("Expected 2 deliveries, got %d", len(tr.deliveries))
+	}
+
+	http_delivery := tr.deliveries[0]
+
+	if http_delivery.Endpoint != "/github/{repo}" || http_delivery.ID != "1234" || http_delivery.EventType != "push" {
+		t.Fatalf("Unexpected delivery, %v", http_delivery)
+	}
+
+	if http_delivery.RemoteAddress != "192.0.2.1" || http_delivery.PathParameters["repo"] != "go-webhookd" {
+		t.Fatalf("Unexpected delivery, %v", http_delivery)
+	}
+
+	if http_delivery.Header.Get("X-GitHub-Delivery") != "1234" {
+		t.Fatalf("Unexpected delivery headers, %v", http_delivery.Header)
+	}
+
+	source_delivery := tr.deliveries[1]
+
+	if source_delivery.ID != "5678" || source_delivery.EventType != "test" || source_delivery.RemoteAddress != "" {
+		t.Fatalf("Unexpected source delivery, %v", source_delivery)
+	}
+}
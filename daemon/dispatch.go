@@ -0,0 +1,163 @@
+package daemon
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	aa_log "github.com/aaronland/go-log/v2"
+	"github.com/whosonfirst/go-webhookd/v3"
+	"github.com/whosonfirst/go-webhookd/v3/webhook"
+)
+
+// dispatchSummary is the result of relaying one or more messages to the dispatchers for a webhook.
+type dispatchSummary struct {
+	// dispatches is the number of times a message was relayed to a dispatcher.
+	dispatches int
+	// failures is the number of dispatchers that failed.
+	failures int
+	// errors is the list of errors for the dispatchers that failed.
+	errors []string
+	// ok is true if every message satisfied the success policy for the webhook.
+	ok bool
+}
+
+// dispatchMessages relays each of 'bodies' to the dispatchers returned by 'dispatchers_for' for that body, according to the
+// dispatch mode of 'wh', recording the outcome of each dispatch in 'access_log'. Messages are always relayed independently of
+// one another and each message must satisfy the success policy of 'wh' for the dispatch as a whole to succeed. Dispatchers that
+// are not failures, including those that return non-fatal errors, are considered to have succeeded.
+//
+// When dispatching sequentially a dispatcher that halts the processing flow, or that fails if 'wh' aborts on failure, prevents
+// the message from being relayed to any subsequent dispatchers. Dispatchers skipped after a failure are not considered to have
+// succeeded.
+func (d *WebhookDaemon) dispatchMessages(ctx context.Context, wh webhook.Webhook, bodies [][]byte, dispatchers_for func(body []byte) []webhookd.WebhookDispatcher, access_log *accessLogEntry, logger *log.Logger) *dispatchSummary {
+
+	wg := new(sync.WaitGroup)
+	mu := new(sync.Mutex)
+
+	summary := &dispatchSummary{
+		errors: make([]string, 0),
+	}
+
+	// The number of dispatchers, and the number of successful dispatchers, for each message
+	totals := make([]int, len(bodies))
+	succeeded := make([]int, len(bodies))
+
+	dispatch := func(offset int, idx int, dispatcher webhookd.WebhookDispatcher, body []byte) string {
+
+		err := d.dispatchWithRecovery(ctx, dispatcher, body, wh.Endpoint(), logger)
+		access_log.addDispatch(dispatcher, idx, err)
+
+		outcome := OUTCOME_DISPATCHED
+
+		if err != nil {
+
+			switch err.Code {
+			case webhookd.UnhandledEvent:
+				aa_log.Info(logger, "Dispatch step (%T) at offset %d returned non-fatal error, %v", dispatcher, idx, err)
+				outcome = OUTCOME_UNHANDLED
+			case webhookd.HaltEvent:
+				aa_log.Info(logger, "Dispatch step (%T) at offset %d returned non-fatal error and exiting, %v", dispatcher, idx, err)
+				outcome = OUTCOME_HALTED
+			default:
+				aa_log.Error(logger, "Dispatch step (%T) at offset %d failed, %v", dispatcher, idx, err)
+				outcome = OUTCOME_FAILED
+			}
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		summary.dispatches++
+
+		if outcome == OUTCOME_FAILED {
+			summary.failures++
+			summary.errors = append(summary.errors, err.Error())
+		} else {
+			succeeded[offset]++
+		}
+
+		return outcome
+	}
+
+	dispatch_sequential := func(offset int, dispatchers []webhookd.WebhookDispatcher, body []byte) {
+
+		for idx, dispatcher := range dispatchers {
+
+			outcome := dispatch(offset, idx, dispatcher, body)
+
+			if outcome == OUTCOME_HALTED || (outcome == OUTCOME_FAILED && wh.AbortOnFailure()) {
+
+				for skipped := idx + 1; skipped < len(dispatchers); skipped++ {
+					aa_log.Info(logger, "Skipping dispatch step (%T) at offset %d for %s after dispatch step at offset %d %s", dispatchers[skipped], skipped, wh.Endpoint(), idx, outcome)
+					access_log.skipDispatch(dispatchers[skipped], skipped)
+				}
+
+				// Halting the processing flow is not a failure so dispatchers skipped as a result don't count
+				// against the success policy
+
+				if outcome == OUTCOME_HALTED {
+					mu.Lock()
+					totals[offset] = idx + 1
+					mu.Unlock()
+				}
+
+				return
+			}
+		}
+	}
+
+	for offset, body := range bodies {
+
+		dispatchers := dispatchers_for(body)
+		totals[offset] = len(dispatchers)
+
+		// The common case of a single message (and, when dispatching in parallel, a single dispatcher) is dispatched
+		// without starting any goroutines
+
+		if len(bodies) == 1 && (len(dispatchers) == 1 || wh.DispatchMode() == webhook.DISPATCH_SEQUENTIAL) {
+			dispatch_sequential(offset, dispatchers, body)
+			continue
+		}
+
+		if wh.DispatchMode() != webhook.DISPATCH_SEQUENTIAL {
+
+			for idx, dispatcher := range dispatchers {
+
+				wg.Add(1)
+
+				go func(offset int, idx int, dispatcher webhookd.WebhookDispatcher, body []byte) {
+					defer wg.Done()
+					dispatch(offset, idx, dispatcher, body)
+				}(offset, idx, dispatcher, body)
+			}
+
+			continue
+		}
+
+		wg.Add(1)
+
+		go func(offset int, dispatchers []webhookd.WebhookDispatcher, body []byte) {
+			defer wg.Done()
+			dispatch_sequential(offset, dispatchers, body)
+		}(offset, dispatchers, body)
+	}
+
+	wg.Wait()
+
+	summary.ok = true
+
+	for offset := range bodies {
+
+		if !wh.Succeeded(succeeded[offset], totals[offset]) {
+			summary.ok = false
+			break
+		}
+	}
+
+	if summary.ok && summary.failures > 0 {
+		aa_log.Warning(logger, "%d dispatch step(s) for %s failed but the '%s' success policy was satisfied", summary.failures, wh.Endpoint(), wh.SuccessPolicy())
+	}
+
+	return summary
+}
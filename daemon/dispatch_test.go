@@ -0,0 +1,305 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+	"github.com/whosonfirst/go-webhookd/v3/receiver"
+	"github.com/whosonfirst/go-webhookd/v3/webhook"
+)
+
+// orderedDispatcher is a `webhookd.WebhookDispatcher` implementation that records the order in which it was invoked
+// and returns an error with 'code', if not zero.
+type orderedDispatcher struct {
+	webhookd.WebhookDispatcher
+	name  string
+	code  int
+	calls *[]string
+	mu    *sync.Mutex
+}
+
+func (d *orderedDispatcher) Dispatch(ctx context.Context, body []byte) *webhookd.WebhookError {
+
+	d.mu.Lock()
+	*d.calls = append(*d.calls, d.name)
+	d.mu.Unlock()
+
+	if d.code != 0 {
+		return &webhookd.WebhookError{Code: d.code, Message: d.name}
+	}
+
+	return nil
+}
+
+func TestDispatchMode(t *testing.T) {
+
+	ctx := context.Background()
+	logger := log.New(io.Discard, "", 0)
+
+	rcvr, err := receiver.NewReceiver(ctx, "insecure://")
+
+	if err != nil {
+		t.Fatalf("Failed to create receiver, %v", err)
+	}
+
+	tests := []struct {
+		mode     string
+		abort    bool
+		code     int
+		status   int
+		expected string
+	}{
+		{webhook.DISPATCH_SEQUENTIAL, false, http.StatusBadGateway, http.StatusInternalServerError, "a,b,c"},
+		{webhook.DISPATCH_SEQUENTIAL, true, http.StatusBadGateway, http.StatusInternalServerError, "a,b"},
+		{webhook.DISPATCH_SEQUENTIAL, false, webhookd.HaltEvent, http.StatusOK, "a,b"},
+		{webhook.DISPATCH_SEQUENTIAL, true, webhookd.UnhandledEvent, http.StatusOK, "a,b,c"},
+		// Dispatchers are called in no particular order when dispatching in parallel
+		{webhook.DISPATCH_PARALLEL, false, http.StatusBadGateway, http.StatusInternalServerError, ""},
+	}
+
+	for idx, test := range tests {
+
+		calls := make([]string, 0)
+		mu := new(sync.Mutex)
+
+		dispatchers := []webhookd.WebhookDispatcher{
+			&orderedDispatcher{name: "a", calls: &calls, mu: mu},
+			&orderedDispatcher{name: "b", code: test.code, calls: &calls, mu: mu},
+			&orderedDispatcher{name: "c", calls: &calls, mu: mu},
+		}
+
+		opts := &webhook.WebhookOptions{
+			Endpoint:       "/test",
+			Receiver:       rcvr,
+			Dispatchers:    dispatchers,
+			DispatchMode:   test.mode,
+			AbortOnFailure: test.abort,
+		}
+
+		wh, err := webhook.NewWebhookWithOptions(ctx, opts)
+
+		if err != nil {
+			t.Fatalf("Failed to create webhook for test %d, %v", idx, err)
+		}
+
+		d, err := NewWebhookDaemon(ctx, "http://localhost:8081")
+
+		if err != nil {
+			t.Fatalf("Failed to create new daemon, %v", err)
+		}
+
+		err = d.AddWebhook(ctx, wh)
+
+		if err != nil {
+			t.Fatalf("Failed to add webhook for test %d, %v", idx, err)
+		}
+
+		handler, err := d.HandlerFuncWithLogger(logger)
+
+		if err != nil {
+			t.Fatalf("Failed to create handler func, %v", err)
+		}
+
+		req := httptest.NewRequest("POST", "/test", strings.NewReader("hello world"))
+		rec := httptest.NewRecorder()
+
+		handler(rec, req)
+
+		if rec.Code != test.status {
+			t.Fatalf("Unexpected status for test %d: %d", idx, rec.Code)
+		}
+
+		if test.expected == "" && len(calls) != len(dispatchers) {
+			t.Fatalf("Expected every dispatcher to be called for test %d, got %v", idx, calls)
+		}
+
+		if test.expected != "" && strings.Join(calls, ",") != test.expected {
+			t.Fatalf("Unexpected dispatch order for test %d: %v", idx, calls)
+		}
+
+		// Messages consumed from sources are dispatched the same way
+
+		calls = calls[:0]
+
+		msg := &webhookd.WebhookMessage{
+			ID:   "1",
+			Body: []byte("hello world"),
+		}
+
+		wh_err := d.processSourceMessage(ctx, wh, msg, logger)
+
+		if (wh_err != nil) != (test.status != http.StatusOK) {
+			t.Fatalf("Unexpected source error for test %d: %v", idx, wh_err)
+		}
+
+		if test.expected != "" && strings.Join(calls, ",") != test.expected {
+			t.Fatalf("Unexpected source dispatch order for test %d: %v", idx, calls)
+		}
+	}
+}
+
+func TestDispatchSkipped(t *testing.T) {
+
+	ctx := context.Background()
+	logger := log.New(io.Discard, "", 0)
+
+	calls := make([]string, 0)
+	mu := new(sync.Mutex)
+
+	dispatchers := []webhookd.WebhookDispatcher{
+		&orderedDispatcher{name: "a", code: http.StatusBadGateway, calls: &calls, mu: mu},
+		&orderedDispatcher{name: "b", calls: &calls, mu: mu},
+	}
+
+	opts := &webhook.WebhookOptions{
+		Endpoint:       "/test",
+		Dispatchers:    dispatchers,
+		DispatchMode:   webhook.DISPATCH_SEQUENTIAL,
+		AbortOnFailure: true,
+	}
+
+	wh, err := webhook.NewWebhookWithOptions(ctx, opts)
+
+	if err != nil {
+		t.Fatalf("Failed to create webhook, %v", err)
+	}
+
+	d, err := NewWebhookDaemon(ctx, "http://localhost:8081")
+
+	if err != nil {
+		t.Fatalf("Failed to create new daemon, %v", err)
+	}
+
+	entry := &accessLogEntry{
+		Timings: make(map[string]time.Duration),
+		mu:      new(sync.Mutex),
+	}
+
+	bodies := [][]byte{[]byte("hello"), []byte("world")}
+
+	dispatchers_for := func(body []byte) []webhookd.WebhookDispatcher {
+		return wh.Dispatchers()
+	}
+
+	summary := d.dispatchMessages(ctx, wh, bodies, dispatchers_for, entry, logger)
+
+	if summary.ok || summary.dispatches != 2 || summary.failures != 2 || len(summary.errors) != 2 {
+		t.Fatalf("Unexpected summary, %v", summary)
+	}
+
+	skipped := 0
+
+	for _, r := range entry.Dispatchers {
+
+		if r.Outcome == OUTCOME_SKIPPED {
+			skipped += 1
+		}
+	}
+
+	if skipped != 2 || len(entry.Dispatchers) != 4 {
+		t.Fatalf("Unexpected access log dispatches: %d skipped of %d", skipped, len(entry.Dispatchers))
+	}
+}
+
+func TestDispatchSuccessPolicy(t *testing.T) {
+
+	ctx := context.Background()
+	logger := log.New(io.Discard, "", 0)
+
+	rcvr, err := receiver.NewReceiver(ctx, "insecure://")
+
+	if err != nil {
+		t.Fatalf("Failed to create receiver, %v", err)
+	}
+
+	tests := []struct {
+		policy   string
+		quorum   int
+		failures int
+		status   int
+	}{
+		{webhook.SUCCESS_POLICY_ALL, 0, 0, http.StatusOK},
+		{webhook.SUCCESS_POLICY_ALL, 0, 1, http.StatusInternalServerError},
+		{webhook.SUCCESS_POLICY_ANY, 0, 2, http.StatusOK},
+		{webhook.SUCCESS_POLICY_ANY, 0, 3, http.StatusInternalServerError},
+		{webhook.SUCCESS_POLICY_QUORUM, 0, 1, http.StatusOK},
+		{webhook.SUCCESS_POLICY_QUORUM, 0, 2, http.StatusInternalServerError},
+		{webhook.SUCCESS_POLICY_QUORUM, 1, 2, http.StatusOK},
+	}
+
+	for idx, test := range tests {
+
+		calls := make([]string, 0)
+		mu := new(sync.Mutex)
+
+		dispatchers := make([]webhookd.WebhookDispatcher, 3)
+
+		for i := range dispatchers {
+
+			code := 0
+
+			if i < test.failures {
+				code = http.StatusBadGateway
+			}
+
+			dispatchers[i] = &orderedDispatcher{name: strconv.Itoa(i), code: code, calls: &calls, mu: mu}
+		}
+
+		opts := &webhook.WebhookOptions{
+			Endpoint:      "/test",
+			Receiver:      rcvr,
+			Dispatchers:   dispatchers,
+			SuccessPolicy: test.policy,
+			Quorum:        test.quorum,
+		}
+
+		wh, err := webhook.NewWebhookWithOptions(ctx, opts)
+
+		if err != nil {
+			t.Fatalf("Failed to create webhook for test %d, %v", idx, err)
+		}
+
+		d, err := NewWebhookDaemon(ctx, "http://localhost:8081?outcome_header=true")
+
+		if err != nil {
+			t.Fatalf("Failed to create new daemon, %v", err)
+		}
+
+		err = d.AddWebhook(ctx, wh)
+
+		if err != nil {
+			t.Fatalf("Failed to add webhook for test %d, %v", idx, err)
+		}
+
+		handler, err := d.HandlerFuncWithLogger(logger)
+
+		if err != nil {
+			t.Fatalf("Failed to create handler func, %v", err)
+		}
+
+		req := httptest.NewRequest("POST", "/test", strings.NewReader("hello world"))
+		rec := httptest.NewRecorder()
+
+		handler(rec, req)
+
+		if rec.Code != test.status {
+			t.Fatalf("Unexpected status for test %d: %d", idx, rec.Code)
+		}
+
+		outcome := rec.Header().Get("X-Webhookd-Outcome")
+
+		if test.failures > 0 && !strings.Contains(outcome, fmt.Sprintf("failures=%d", test.failures)) {
+			t.Fatalf("Unexpected outcome header for test %d: %s", idx, outcome)
+		}
+	}
+}
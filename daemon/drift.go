@@ -0,0 +1,252 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	aa_log "github.com/aaronland/go-log/v2"
+	"github.com/whosonfirst/go-webhookd/v3/config"
+	"github.com/whosonfirst/go-webhookd/v3/provider"
+)
+
+// DEFAULT_DRIFT_INTERVAL is the default interval at which the hooks registered with providers are checked for drift.
+const DEFAULT_DRIFT_INTERVAL time.Duration = 1 * time.Hour
+
+// driftKinds is the list of kinds of drift that metrics are emitted for.
+var driftKinds = []string{
+	provider.DRIFT_MISSING,
+	provider.DRIFT_URL,
+	provider.DRIFT_EVENTS,
+	provider.DRIFT_INACTIVE,
+	provider.DRIFT_ERROR,
+}
+
+// driftChecker compares the registrations defined for the webhooks in a config with the hooks registered with their providers.
+type driftChecker struct {
+	// cfg is the `config.WebhookConfig` whose registrations are checked.
+	cfg *config.WebhookConfig
+	// interval is the interval at which checks are performed. If 0 checks are only performed on demand.
+	interval time.Duration
+	// checked is the time the most recent check was performed.
+	checked time.Time
+	// drift is the list of `provider.Drift` instances found by the most recent check.
+	drift []*provider.Drift
+	// mu is a `sync.Mutex` used to guard 'checked' and 'drift' and to ensure that only one check is performed at a time.
+	mu *sync.Mutex
+}
+
+// AddDriftCheck() configures 'd' to periodically compare the registrations (see `config.WebhookRegistrationConfig`) defined for
+// the webhooks in 'cfg' with the hooks actually registered with their providers, reporting missing hooks, hooks with the wrong
+// URL or events and hooks the provider has disabled. Drift is logged, emitted as metrics, if 'd' has been configured to do so,
+// and available from the `Drift` method and the GraphQL endpoint. Hook URLs are derived from the public URL of the tunnel for 'd',
+// if it has one, or `cfg.PublicURL`. 'uri' is expected to take the form of:
+//
+//	drift://?{PARAMETERS}
+//
+// Where {PARAMETERS} may be:
+// * `interval={DURATION}` The interval at which checks are performed. If "0" checks are only performed on demand. Default is "1h".
+func (d *WebhookDaemon) AddDriftCheck(ctx context.Context, uri string, cfg *config.WebhookConfig) error {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return fmt.Errorf("Failed to parse drift URI, %w", err)
+	}
+
+	if u.Scheme != "drift" {
+		return fmt.Errorf("Invalid drift URI scheme '%s'", u.Scheme)
+	}
+
+	interval := DEFAULT_DRIFT_INTERVAL
+
+	str_interval := u.Query().Get("interval")
+
+	if str_interval != "" {
+
+		v, err := time.ParseDuration(str_interval)
+
+		if err != nil || v < 0 {
+			return fmt.Errorf("Invalid ?interval= parameter '%s'", str_interval)
+		}
+
+		interval = v
+	}
+
+	if cfg.PublicURL == "" && d.tunnel == nil {
+		return fmt.Errorf("Drift checks require a public URL or a tunnel")
+	}
+
+	// The public URL for a tunnel isn't known until it is opened so registrations are validated with a placeholder
+
+	registrations, err := provider.RegistrationsFromConfig(ctx, cfg, "http://localhost")
+
+	if err != nil {
+		return fmt.Errorf("Invalid registrations, %w", err)
+	}
+
+	if len(registrations) == 0 {
+		return fmt.Errorf("No webhooks define a registration")
+	}
+
+	d.drift = &driftChecker{
+		cfg:      cfg,
+		interval: interval,
+		mu:       new(sync.Mutex),
+	}
+
+	return nil
+}
+
+// CheckDrift() compares the registrations for the webhooks in 'd' with the hooks registered with their providers, records the
+// result (see `Drift`), emits metrics for it and returns the list of `provider.Drift` instances found.
+func (d *WebhookDaemon) CheckDrift(ctx context.Context) ([]*provider.Drift, error) {
+
+	c := d.drift
+
+	if c == nil {
+		return nil, fmt.Errorf("Drift checks are not configured")
+	}
+
+	registrations, err := provider.RegistrationsFromConfig(ctx, c.cfg, d.PublicURL())
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive registrations, %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	drift := provider.CheckDrift(ctx, registrations)
+
+	c.checked = time.Now()
+	c.drift = drift
+
+	d.metrics.recordDrift(registrations, drift)
+
+	return drift, nil
+}
+
+// Drift() returns the list of `provider.Drift` instances found by, and the time of, the most recent drift check for 'd'. If no
+// checks have been performed the time is zero.
+func (d *WebhookDaemon) Drift() ([]*provider.Drift, time.Time) {
+
+	c := d.drift
+
+	if c == nil {
+		return nil, time.Time{}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.drift, c.checked
+}
+
+// startDriftCheck checks the hooks for 'd' for drift, if configured to do so, immediately and then every interval in a separate Go
+// routine until 'ctx' is cancelled or the returned function is called.
+func (d *WebhookDaemon) startDriftCheck(ctx context.Context, logger *log.Logger) func() {
+
+	if d.drift == nil || d.drift.interval == 0 {
+		return func() {}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan bool)
+
+	check := func() {
+
+		drift, err := d.CheckDrift(ctx)
+
+		if err != nil {
+			aa_log.Warning(logger, "Failed to check hooks for drift, %v", err)
+			return
+		}
+
+		for _, dr := range drift {
+
+			switch dr.Kind {
+			case provider.DRIFT_ERROR:
+				aa_log.Warning(logger, "Failed to check hook for %s for drift, %s", dr.Endpoint, dr.Error)
+			case provider.DRIFT_URL, provider.DRIFT_EVENTS:
+				aa_log.Warning(logger, "Hook for %s has drifted (%s), expected %v but found %v", dr.Endpoint, dr.Kind, dr.Expected, dr.Actual)
+			default:
+				aa_log.Warning(logger, "Hook for %s has drifted (%s)", dr.Endpoint, dr.Kind)
+			}
+		}
+	}
+
+	go func() {
+
+		defer close(done)
+
+		check()
+
+		ticker := time.NewTicker(d.drift.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				check()
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// recordDrift emits gauges for the kinds of drift in 'drift' found by checking 'registrations'. For DogStatsD a gauge, whose value
+// is 0 or 1, is emitted for each kind of drift for each endpoint. For statsd a gauge is emitted with the number of endpoints with
+// each kind of drift. It is safe to call on a nil instance.
+func (m *metricsEmitter) recordDrift(registrations []*provider.Registration, drift []*provider.Drift) {
+
+	if m == nil {
+		return
+	}
+
+	found := make(map[string]bool)
+	counts := make(map[string]int)
+
+	for _, dr := range drift {
+
+		key := dr.Endpoint + " " + dr.Kind
+
+		if !found[key] {
+			found[key] = true
+			counts[dr.Kind] += 1
+		}
+	}
+
+	if m.protocol != METRICS_DOGSTATSD {
+
+		for _, kind := range driftKinds {
+			m.emit("hooks.drift."+kind, strconv.Itoa(counts[kind]), "g")
+		}
+
+		return
+	}
+
+	for _, r := range registrations {
+
+		for _, kind := range driftKinds {
+
+			value := "0"
+
+			if found[r.Endpoint+" "+kind] {
+				value = "1"
+			}
+
+			m.emit("hooks.drift", value, "g", "endpoint:"+r.Endpoint, "kind:"+kind)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/whosonfirst/go-webhookd/v3/config"
+	"github.com/whosonfirst/go-webhookd/v3/provider"
+)
+
+// driftTestProvider implements the `provider.Provider` interface for a fixed list of hooks.
+type driftTestProvider struct {
+	provider.Provider
+	hooks []*provider.Hook
+}
+
+func (p *driftTestProvider) Hooks(ctx context.Context) ([]*provider.Hook, error) {
+	return p.hooks, nil
+}
+
+// driftTestHooks is the list of hooks returned by the "testdrift" provider.
+var driftTestHooks = []*provider.Hook{
+	{ID: "1", URL: "https://hooks.example.com/github", Events: []string{"push"}, Active: true},
+	{ID: "2", URL: "https://hooks.example.com/gitlab", Events: []string{"push"}, Active: false},
+}
+
+func init() {
+
+	ctx := context.Background()
+
+	err := provider.RegisterProvider(ctx, "testdrift", func(ctx context.Context, uri string) (provider.Provider, error) {
+		return &driftTestProvider{hooks: driftTestHooks}, nil
+	})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+func TestDriftCheck(t *testing.T) {
+
+	ctx := context.Background()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatalf("Failed to listen for packets, %v", err)
+	}
+
+	defer conn.Close()
+
+	cfg := &config.WebhookConfig{
+		Daemon:    "http://localhost:8081",
+		PublicURL: "https://hooks.example.com",
+		Drift:     "drift://?interval=0",
+		GraphQL:   "graphql:///graphql",
+		Metrics:   "metrics://" + conn.LocalAddr().String() + "?protocol=dogstatsd&flush_interval=1h",
+		Receivers: map[string]string{
+			"insecure": "insecure://",
+		},
+		Dispatchers: map[string]string{
+			"null": "null://",
+		},
+		Webhooks: []config.WebhookWebhooksConfig{
+			{
+				Endpoint:     "/github",
+				Receiver:     "insecure",
+				Dispatchers:  []string{"null"},
+				Registration: &config.WebhookRegistrationConfig{Provider: "testdrift://", Events: []string{"push", "release"}},
+			},
+			{
+				Endpoint:     "/gitlab",
+				Receiver:     "insecure",
+				Dispatchers:  []string{"null"},
+				Registration: &config.WebhookRegistrationConfig{Provider: "testdrift://"},
+			},
+			{
+				Endpoint:     "/stripe",
+				Receiver:     "insecure",
+				Dispatchers:  []string{"null"},
+				Registration: &config.WebhookRegistrationConfig{Provider: "testdrift://"},
+			},
+		},
+	}
+
+	d, err := NewWebhookDaemonFromConfig(ctx, cfg)
+
+	if err != nil {
+		t.Fatalf("Failed to create new daemon from config, %v", err)
+	}
+
+	_, checked := d.Drift()
+
+	if !checked.IsZero() {
+		t.Fatalf("Expected no drift checks to have been performed")
+	}
+
+	drift, err := d.CheckDrift(ctx)
+
+	if err != nil {
+		t.Fatalf("Failed to check drift, %v", err)
+	}
+
+	expected := [][]string{
+		{"/github", provider.DRIFT_EVENTS},
+		{"/gitlab", provider.DRIFT_INACTIVE},
+		{"/stripe", provider.DRIFT_MISSING},
+	}
+
+	if len(drift) != len(expected) {
+		t.Fatalf("Unexpected drift, %v", drift)
+	}
+
+	for idx, e := range expected {
+
+		if drift[idx].Endpoint != e[0] || drift[idx].Kind != e[1] {
+			t.Fatalf("Unexpected drift at offset %d, %v", idx, drift[idx])
+		}
+	}
+
+	d.metrics.flush()
+
+	buf := make([]byte, 4096)
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+
+	if err != nil {
+		t.Fatalf("Failed to read metrics, %v", err)
+	}
+
+	metrics := string(buf[:n])
+
+	for _, m := range []string{
+		"webhookd.hooks.drift:1|g|#endpoint:/github,kind:events",
+		"webhookd.hooks.drift:0|g|#endpoint:/github,kind:missing",
+		"webhookd.hooks.drift:1|g|#endpoint:/stripe,kind:missing",
+	} {
+
+		if !strings.Contains(metrics, m+"\n") && !strings.HasSuffix(metrics, m) {
+			t.Fatalf("Missing metric '%s' in %s", m, metrics)
+		}
+	}
+
+	logger := log.New(io.Discard, "", 0)
+
+	handler, err := d.HandlerFuncWithLogger(logger)
+
+	if err != nil {
+		t.Fatalf("Failed to create handler func, %v", err)
+	}
+
+	query := `{"query":"{ drift(check: true) { checked drift { endpoint kind hookId expected actual } } }"}`
+
+	req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(query))
+	rec := httptest.NewRecorder()
+
+	d.graphQLHandlerWithLogger(handler, logger).ServeHTTP(rec, req)
+
+	var rsp struct {
+		Data struct {
+			Drift struct {
+				Checked string `json:"checked"`
+				Drift   []struct {
+					Endpoint string   `json:"endpoint"`
+					Kind     string   `json:"kind"`
+					HookID   *string  `json:"hookId"`
+					Expected []string `json:"expected"`
+					Actual   []string `json:"actual"`
+				} `json:"drift"`
+			} `json:"drift"`
+		} `json:"data"`
+	}
+
+	err = json.Unmarshal(rec.Body.Bytes(), &rsp)
+
+	if err != nil {
+		t.Fatalf("Failed to decode GraphQL response, %v", err)
+	}
+
+	report := rsp.Data.Drift
+
+	if report.Checked == "" || len(report.Drift) != 3 {
+		t.Fatalf("Unexpected drift report, %s", rec.Body.String())
+	}
+
+	gh := report.Drift[0]
+
+	if gh.HookID == nil || *gh.HookID != "1" || strings.Join(gh.Expected, ",") != "push,release" || strings.Join(gh.Actual, ",") != "push" {
+		t.Fatalf("Unexpected drift for /github, %s", rec.Body.String())
+	}
+
+	if report.Drift[2].HookID != nil {
+		t.Fatalf("Expected missing hook to not have an ID, %s", rec.Body.String())
+	}
+}
+
+func TestAddDriftCheckInvalid(t *testing.T) {
+
+	ctx := context.Background()
+
+	newConfig := func(public_url string, registration *config.WebhookRegistrationConfig) *config.WebhookConfig {
+
+		return &config.WebhookConfig{
+			PublicURL: public_url,
+			Receivers: map[string]string{"insecure": "insecure://"},
+			Webhooks: []config.WebhookWebhooksConfig{
+				{Endpoint: "/github", Receiver: "insecure", Registration: registration},
+			},
+		}
+	}
+
+	valid := &config.WebhookRegistrationConfig{Provider: "testdrift://"}
+
+	tests := []struct {
+		uri string
+		cfg *config.WebhookConfig
+	}{
+		{"bogus://", newConfig("https://hooks.example.com", valid)},
+		{"drift://?interval=bogus", newConfig("https://hooks.example.com", valid)},
+		{"drift://?interval=-1h", newConfig("https://hooks.example.com", valid)},
+		{"drift://", newConfig("", valid)},
+		{"drift://", newConfig("https://hooks.example.com", nil)},
+		{"drift://", newConfig("https://hooks.example.com", &config.WebhookRegistrationConfig{})},
+	}
+
+	for idx, test := range tests {
+
+		d, err := NewWebhookDaemon(ctx, "http://localhost:8081")
+
+		if err != nil {
+			t.Fatalf("Failed to create new daemon, %v", err)
+		}
+
+		err = d.AddDriftCheck(ctx, test.uri, test.cfg)
+
+		if err == nil {
+			t.Fatalf("Expected drift check %d (%s) to fail", idx, test.uri)
+		}
+	}
+
+	d, err := NewWebhookDaemon(ctx, "http://localhost:8081")
+
+	if err != nil {
+		t.Fatalf("Failed to create new daemon, %v", err)
+	}
+
+	_, err = d.CheckDrift(ctx)
+
+	if err == nil {
+		t.Fatalf("Expected drift check without configuration to fail")
+	}
+}
@@ -0,0 +1,108 @@
+package daemon
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+	"github.com/whosonfirst/go-webhookd/v3/webhook"
+)
+
+// DRYRUN_TOKEN_HEADER is the name of the request header used to authenticate requests for a dry run.
+const DRYRUN_TOKEN_HEADER string = "X-Webhookd-Dryrun-Token"
+
+// dryRunResponse is the JSON-encoded response for a dry run.
+type dryRunResponse struct {
+	// Endpoint is the endpoint of the webhook.
+	Endpoint string `json:"endpoint"`
+	// DeliveryID is the unique identifier for the message.
+	DeliveryID string `json:"delivery_id"`
+	// Messages is the list of messages that would have been dispatched.
+	Messages []*dryRunMessage `json:"messages"`
+}
+
+// dryRunMessage is a single message, and the dispatchers it would have been relayed to, in a dry run.
+type dryRunMessage struct {
+	// Body is the final (transformed) message body.
+	Body string `json:"body"`
+	// Dispatchers is the list of the types of the dispatchers the message would have been relayed to, in order.
+	Dispatchers []string `json:"dispatchers"`
+}
+
+// dryRun returns a boolean value indicating whether 'req' is a request for a dry run, with a `?dryrun=` parameter, or an error
+// if it is a request for a dry run that has not been authenticated with the `DRYRUN_TOKEN_HEADER` header. Dry runs are only
+// allowed if 'd' has a dry run token.
+func (d *WebhookDaemon) dryRun(req *http.Request) (bool, *webhookd.WebhookError) {
+
+	if req.URL.RawQuery == "" {
+		return false, nil
+	}
+
+	str_dryrun := req.URL.Query().Get("dryrun")
+
+	if str_dryrun == "" {
+		return false, nil
+	}
+
+	dryrun, err := strconv.ParseBool(str_dryrun)
+
+	if err != nil {
+		code := http.StatusBadRequest
+		message := "Invalid ?dryrun= parameter"
+		return false, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	if !dryrun {
+		return false, nil
+	}
+
+	token := req.Header.Get(DRYRUN_TOKEN_HEADER)
+
+	if d.DryRunToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(d.DryRunToken)) != 1 {
+		code := http.StatusForbidden
+		message := "Dry run not allowed"
+		return false, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	return true, nil
+}
+
+// writeDryRun writes a JSON-encoded `dryRunResponse` to 'rsp' describing each of 'bodies', processed by 'wh', and the dispatchers
+// returned by 'dispatchers_for' that they would have been relayed to.
+func writeDryRun(rsp http.ResponseWriter, wh webhook.Webhook, delivery_id string, bodies [][]byte, dispatchers_for func(body []byte) []webhookd.WebhookDispatcher) error {
+
+	dr := &dryRunResponse{
+		Endpoint:   wh.Endpoint(),
+		DeliveryID: delivery_id,
+		Messages:   make([]*dryRunMessage, len(bodies)),
+	}
+
+	for idx, body := range bodies {
+
+		m := &dryRunMessage{
+			Body:        string(body),
+			Dispatchers: make([]string, 0),
+		}
+
+		for _, dispatcher := range dispatchers_for(body) {
+			m.Dispatchers = append(m.Dispatchers, fmt.Sprintf("%T", dispatcher))
+		}
+
+		dr.Messages[idx] = m
+	}
+
+	enc, err := json.Marshal(dr)
+
+	if err != nil {
+		return fmt.Errorf("Failed to encode dry run, %w", err)
+	}
+
+	rsp.Header().Set("Content-Type", "application/json")
+	rsp.WriteHeader(http.StatusOK)
+
+	_, err = rsp.Write(enc)
+	return err
+}
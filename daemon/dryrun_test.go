@@ -0,0 +1,155 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+	"github.com/whosonfirst/go-webhookd/v3/receiver"
+	"github.com/whosonfirst/go-webhookd/v3/transformation"
+	"github.com/whosonfirst/go-webhookd/v3/webhook"
+)
+
+func TestDryRun(t *testing.T) {
+
+	ctx := context.Background()
+	logger := log.New(io.Discard, "", 0)
+
+	d, err := NewWebhookDaemon(ctx, "http://localhost:8081?dryrun_token=s33kret&outcome_header=true")
+
+	if err != nil {
+		t.Fatalf("Failed to create new daemon, %v", err)
+	}
+
+	rcvr, err := receiver.NewReceiver(ctx, "insecure://")
+
+	if err != nil {
+		t.Fatalf("Failed to create receiver, %v", err)
+	}
+
+	tr, err := transformation.NewTransformation(ctx, "chicken://zxx")
+
+	if err != nil {
+		t.Fatalf("Failed to create transformation, %v", err)
+	}
+
+	calls := make([]string, 0)
+
+	ds := []webhookd.WebhookDispatcher{
+		&orderedDispatcher{name: "a", calls: &calls, mu: new(sync.Mutex)},
+	}
+
+	wh, err := webhook.NewWebhook(ctx, "/test", rcvr, []webhookd.WebhookTransformation{tr}, ds)
+
+	if err != nil {
+		t.Fatalf("Failed to create webhook, %v", err)
+	}
+
+	err = d.AddWebhook(ctx, wh)
+
+	if err != nil {
+		t.Fatalf("Failed to add webhook, %v", err)
+	}
+
+	handler, err := d.HandlerFuncWithLogger(logger)
+
+	if err != nil {
+		t.Fatalf("Failed to create handler func, %v", err)
+	}
+
+	tests := []struct {
+		query  string
+		token  string
+		status int
+	}{
+		{"?dryrun=1", "", http.StatusForbidden},
+		{"?dryrun=1", "wrong", http.StatusForbidden},
+		{"?dryrun=maybe", "s33kret", http.StatusBadRequest},
+		{"?dryrun=1", "s33kret", http.StatusOK},
+	}
+
+	for idx, test := range tests {
+
+		req := httptest.NewRequest("POST", "/test"+test.query, strings.NewReader("hello world"))
+
+		if test.token != "" {
+			req.Header.Set(DRYRUN_TOKEN_HEADER, test.token)
+		}
+
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+
+		if rec.Code != test.status {
+			t.Fatalf("Unexpected status for test %d: %d", idx, rec.Code)
+		}
+	}
+
+	if len(calls) != 0 {
+		t.Fatalf("Expected no dispatches, got %v", calls)
+	}
+
+	req := httptest.NewRequest("POST", "/test?dryrun=true", strings.NewReader("hello world"))
+	req.Header.Set(DRYRUN_TOKEN_HEADER, "s33kret")
+	req.Header.Set("X-Request-Id", "1234")
+
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if !strings.HasPrefix(rec.Header().Get("X-Webhookd-Outcome"), OUTCOME_DRYRUN) {
+		t.Fatalf("Unexpected outcome header: %s", rec.Header().Get("X-Webhookd-Outcome"))
+	}
+
+	var dr dryRunResponse
+
+	err = json.Unmarshal(rec.Body.Bytes(), &dr)
+
+	if err != nil {
+		t.Fatalf("Failed to decode dry run response, %v", err)
+	}
+
+	if dr.Endpoint != "/test" || dr.DeliveryID != "1234" || len(dr.Messages) != 1 {
+		t.Fatalf("Unexpected dry run response: %s", rec.Body.String())
+	}
+
+	if dr.Messages[0].Body == "hello world" || len(dr.Messages[0].Dispatchers) != 1 || dr.Messages[0].Dispatchers[0] != "*daemon.orderedDispatcher" {
+		t.Fatalf("Unexpected dry run message: %s", rec.Body.String())
+	}
+
+	// Requests with a false ?dryrun= parameter are dispatched as usual
+
+	req = httptest.NewRequest("POST", "/test?dryrun=0", strings.NewReader("hello world"))
+	rec = httptest.NewRecorder()
+
+	handler(rec, req)
+
+	if rec.Code != http.StatusOK || len(calls) != 1 {
+		t.Fatalf("Unexpected status (%d) or dispatches (%v)", rec.Code, calls)
+	}
+}
+
+func TestDryRunDisabled(t *testing.T) {
+
+	ctx := context.Background()
+
+	d, err := NewWebhookDaemon(ctx, "http://localhost:8081")
+
+	if err != nil {
+		t.Fatalf("Failed to create new daemon, %v", err)
+	}
+
+	req := httptest.NewRequest("POST", "/test?dryrun=1", nil)
+	req.Header.Set(DRYRUN_TOKEN_HEADER, "")
+
+	_, wh_err := d.dryRun(req)
+
+	if wh_err == nil || wh_err.Code != http.StatusForbidden {
+		t.Fatalf("Expected dry run to be forbidden, %v", wh_err)
+	}
+}
@@ -0,0 +1,132 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	aa_log "github.com/aaronland/go-log/v2"
+	"github.com/whosonfirst/go-webhookd/v3"
+	"github.com/whosonfirst/go-webhookd/v3/cluster"
+	"github.com/whosonfirst/go-webhookd/v3/webhook"
+)
+
+// AddElection() configures 'd' to use the `cluster.Elector` instance derived from 'uri' to elect a single webhookd process, among
+// all the processes sharing the same elector, to consume messages for each singleton source webhook.
+func (d *WebhookDaemon) AddElection(ctx context.Context, uri string) error {
+
+	e, err := cluster.NewElector(ctx, uri)
+
+	if err != nil {
+		return fmt.Errorf("Failed to create elector, %w", err)
+	}
+
+	d.elector = e
+	return nil
+}
+
+// AddSingletonSourceWebhook() adds 'wh' to 'd' processing messages consumed from 'src', like `AddSourceWebhook`, but only while
+// 'd' is the elected leader for 'wh'. Other processes stand by and take over if the leader stops renewing its leadership. If 'd'
+// has not been configured with `AddElection` then "memory://" is used, which only elects a leader among the daemons in the same
+// process.
+func (d *WebhookDaemon) AddSingletonSourceWebhook(ctx context.Context, src webhookd.WebhookSource, wh webhook.Webhook) error {
+
+	if d.elector == nil {
+
+		err := d.AddElection(ctx, "memory://")
+
+		if err != nil {
+			return err
+		}
+	}
+
+	err := d.AddSourceWebhook(ctx, src, wh)
+
+	if err != nil {
+		return err
+	}
+
+	d.sources[len(d.sources)-1].singleton = true
+	return nil
+}
+
+// leadSource campaigns for leadership of the source for 's' until 'ctx' is cancelled, renewing it every third of the elector's
+// lease duration. Messages are consumed, using `consumeSource`, while 'd' is the leader and consumption stops as soon as
+// leadership is lost or can not be confirmed. Leadership is given up when 'ctx' is cancelled or the source has no more messages to
+// read.
+func (d *WebhookDaemon) leadSource(ctx context.Context, s *sourceWebhook, logger *log.Logger) {
+
+	endpoint := s.webhook.Endpoint()
+	name := fmt.Sprintf("source:%s", endpoint)
+
+	var cancel context.CancelFunc
+	var done chan bool
+
+	stop := func() {
+
+		if cancel != nil {
+			cancel()
+			<-done
+			cancel = nil
+			done = nil
+		}
+	}
+
+	defer func() {
+
+		stop()
+
+		// 'ctx' has probably been cancelled by now
+
+		err := d.elector.Resign(context.Background(), name)
+
+		if err != nil {
+			aa_log.Warning(logger, "Failed to resign leadership for %s, %v", endpoint, err)
+		}
+	}()
+
+	ticker := time.NewTicker(d.elector.LeaseDuration() / 3)
+	defer ticker.Stop()
+
+	for {
+
+		leader, err := d.elector.Campaign(ctx, name)
+
+		if err != nil && ctx.Err() == nil {
+			aa_log.Error(logger, "Failed to campaign for leadership of %s, %v", endpoint, err)
+		}
+
+		switch {
+		case leader && cancel == nil:
+
+			aa_log.Info(logger, "Elected leader for %s as %s", endpoint, d.elector.Identity())
+
+			consume_ctx, consume_cancel := context.WithCancel(ctx)
+
+			cancel = consume_cancel
+			done = make(chan bool)
+
+			go func(done chan bool) {
+				d.consumeSource(consume_ctx, s, logger)
+				close(done)
+			}(done)
+
+		case !leader && cancel != nil:
+
+			aa_log.Warning(logger, "Lost leadership for %s, standing by", endpoint)
+			stop()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-done:
+			// The source has no more messages to read
+			return
+
+		case <-ticker.C:
+			// pass
+		}
+	}
+}
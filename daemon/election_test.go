@@ -0,0 +1,138 @@
+package daemon
+
+import (
+	"context"
+	"io"
+	"log"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+	"github.com/whosonfirst/go-webhookd/v3/config"
+)
+
+func TestSingletonSources(t *testing.T) {
+
+	ctx := context.Background()
+	logger := log.New(io.Discard, "", 0)
+
+	new_daemon := func(identity string) (*WebhookDaemon, *testSource) {
+
+		cfg := &config.WebhookConfig{
+			Daemon:   "http://localhost:8081",
+			Election: "memory://?lease_duration=300ms&identity=" + identity,
+			Sources: map[string]string{
+				"kafka": "kafka://localhost:9092/events",
+			},
+			Dispatchers: map[string]string{
+				"null": "null://",
+			},
+			Webhooks: []config.WebhookWebhooksConfig{
+				{
+					Endpoint:    "singleton-test",
+					Source:      "kafka",
+					Dispatchers: []string{"null"},
+					Singleton:   true,
+				},
+			},
+		}
+
+		d, err := NewWebhookDaemonFromConfig(ctx, cfg)
+
+		if err != nil {
+			t.Fatalf("Failed to create new daemon from config, %v", err)
+		}
+
+		src := &testSource{
+			mu: new(sync.Mutex),
+			messages: []*webhookd.WebhookMessage{
+				{ID: identity, Body: []byte("hello world")},
+			},
+			acked: make(map[string]bool),
+		}
+
+		d.sources[0].source = src
+		return d, src
+	}
+
+	wait := func(src *testSource, id string) bool {
+
+		for i := 0; i < 100; i++ {
+
+			if src.Acked(id) {
+				return true
+			}
+
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		return false
+	}
+
+	d_a, src_a := new_daemon("a")
+	d_b, src_b := new_daemon("b")
+
+	ctx_a, cancel_a := context.WithCancel(ctx)
+	wg_a := d_a.startSources(ctx_a, logger)
+
+	if !wait(src_a, "a") {
+		cancel_a()
+		t.Fatalf("Expected leader to consume messages")
+	}
+
+	ctx_b, cancel_b := context.WithCancel(ctx)
+	wg_b := d_b.startSources(ctx_b, logger)
+
+	defer func() {
+		cancel_b()
+		wg_b.Wait()
+	}()
+
+	if wait(src_b, "b") {
+		cancel_a()
+		t.Fatalf("Expected standby to not consume messages")
+	}
+
+	// Stopping the leader resigns leadership so that the standby takes over
+
+	cancel_a()
+	wg_a.Wait()
+
+	if !wait(src_b, "b") {
+		t.Fatalf("Expected standby to consume messages after the leader stopped")
+	}
+}
+
+func TestSingletonInvalid(t *testing.T) {
+
+	ctx := context.Background()
+
+	cfg := &config.WebhookConfig{
+		Daemon: "http://localhost:8081",
+		Receivers: map[string]string{
+			"insecure": "insecure://",
+		},
+		Dispatchers: map[string]string{
+			"null": "null://",
+		},
+		Webhooks: []config.WebhookWebhooksConfig{
+			{Endpoint: "/foo", Receiver: "insecure", Dispatchers: []string{"null"}, Singleton: true},
+		},
+	}
+
+	_, err := NewWebhookDaemonFromConfig(ctx, cfg)
+
+	if err == nil {
+		t.Fatalf("Expected singleton webhook with a receiver to fail")
+	}
+
+	cfg.Election = "bogus://"
+	cfg.Webhooks[0].Singleton = false
+
+	_, err = NewWebhookDaemonFromConfig(ctx, cfg)
+
+	if err == nil {
+		t.Fatalf("Expected invalid election to fail")
+	}
+}
@@ -0,0 +1,128 @@
+package daemon
+
+import (
+	"sync"
+	"time"
+)
+
+// eventBufferSize is the number of processed events buffered for each subscriber. Events published while a subscriber's
+// buffer is full are dropped for that subscriber.
+const eventBufferSize int = 64
+
+// processedEvent is a (webhook) message that has been successfully relayed to all its dispatchers.
+type processedEvent struct {
+	// DeliveryID is the unique identifier for the delivery the message was part of.
+	DeliveryID string
+	// Endpoint is the endpoint of the webhook that processed the message.
+	Endpoint string
+	// Path is the path (or endpoint for messages consumed from a source) the message was delivered to.
+	Path string
+	// Body is the final (transformed) body of the message.
+	Body []byte
+	// Time is the time the message was processed.
+	Time time.Time
+}
+
+// eventSubscriber is a subscription to processed events.
+type eventSubscriber struct {
+	// endpoint is the optional webhook endpoint to limit events to.
+	endpoint string
+	// events is the channel that events are sent to.
+	events chan *processedEvent
+}
+
+// eventHub relays processed events to any number of subscribers.
+type eventHub struct {
+	mu          *sync.RWMutex
+	subscribers map[*eventSubscriber]bool
+}
+
+// newEventHub returns a new `eventHub` instance.
+func newEventHub() *eventHub {
+
+	h := &eventHub{
+		mu:          new(sync.RWMutex),
+		subscribers: make(map[*eventSubscriber]bool),
+	}
+
+	return h
+}
+
+// subscribe returns a new `eventSubscriber` for events processed by the webhook matching 'endpoint', or all webhooks if
+// 'endpoint' is empty. Subscribers must be removed with `unsubscribe` when they are no longer needed.
+func (h *eventHub) subscribe(endpoint string) *eventSubscriber {
+
+	s := &eventSubscriber{
+		endpoint: endpoint,
+		events:   make(chan *processedEvent, eventBufferSize),
+	}
+
+	h.mu.Lock()
+	h.subscribers[s] = true
+	h.mu.Unlock()
+
+	return s
+}
+
+// unsubscribe removes 's' from 'h'.
+func (h *eventHub) unsubscribe(s *eventSubscriber) {
+
+	h.mu.Lock()
+	delete(h.subscribers, s)
+	h.mu.Unlock()
+}
+
+// publish sends an event for each of 'bodies' to the subscribers of 'h'. It never blocks.
+func (h *eventHub) publish(delivery_id string, endpoint string, path string, bodies [][]byte) {
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if len(h.subscribers) == 0 {
+		return
+	}
+
+	now := time.Now()
+
+	for _, body := range bodies {
+
+		ev := &processedEvent{
+			DeliveryID: delivery_id,
+			Endpoint:   endpoint,
+			Path:       path,
+			Body:       body,
+			Time:       now,
+		}
+
+		for s := range h.subscribers {
+
+			if s.endpoint != "" && s.endpoint != endpoint {
+				continue
+			}
+
+			select {
+			case s.events <- ev:
+				// pass
+			default:
+				// pass
+			}
+		}
+	}
+}
+
+// stats returns the number of subscribers to 'h' and the total number of events waiting to be read by them.
+func (h *eventHub) stats() *eventHubStats {
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	stats := &eventHubStats{
+		Subscribers: len(h.subscribers),
+	}
+
+	for s := range h.subscribers {
+		stats.Backlog += len(s.events)
+	}
+
+	return stats
+}
@@ -0,0 +1,49 @@
+package daemon
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestEventHub(t *testing.T) {
+
+	h := newEventHub()
+
+	all := h.subscribe("")
+	foo := h.subscribe("/foo")
+
+	h.publish("1234", "/foo", "/foo", [][]byte{[]byte("a"), []byte("b")})
+	h.publish("5678", "/bar", "/bar", [][]byte{[]byte("c")})
+
+	if len(all.events) != 3 {
+		t.Fatalf("Expected 3 events for all webhooks, got %d", len(all.events))
+	}
+
+	if len(foo.events) != 2 {
+		t.Fatalf("Expected 2 events for /foo, got %d", len(foo.events))
+	}
+
+	ev := <-foo.events
+
+	if ev.DeliveryID != "1234" || string(ev.Body) != "a" {
+		t.Fatalf("Unexpected event: %s %s", ev.DeliveryID, ev.Body)
+	}
+
+	h.unsubscribe(foo)
+
+	h.publish("1234", "/foo", "/foo", [][]byte{[]byte("d")})
+
+	if len(foo.events) != 1 {
+		t.Fatalf("Expected unsubscribed subscriber to not receive events")
+	}
+
+	// Publishing to a full subscriber should drop events rather than block
+
+	for i := 0; i < eventBufferSize*2; i++ {
+		h.publish(fmt.Sprintf("%d", i), "/foo", "/foo", [][]byte{[]byte("e")})
+	}
+
+	if len(all.events) != eventBufferSize {
+		t.Fatalf("Expected %d buffered events, got %d", eventBufferSize, len(all.events))
+	}
+}
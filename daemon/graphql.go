@@ -0,0 +1,738 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	aa_log "github.com/aaronland/go-log/v2"
+)
+
+// GRAPHQL_SCHEMA is the GraphQL schema exposed by the optional `webhookd` GraphQL endpoint.
+const GRAPHQL_SCHEMA string = `type Query {
+  webhooks: [Webhook!]!
+  drift(check: Boolean): DriftReport!
+}
+
+type Mutation {
+  deliver(endpoint: String!, body: String, headers: [HeaderInput!]): Delivery!
+}
+
+type Subscription {
+  events(endpoint: String): Event!
+}
+
+type Webhook {
+  endpoint: String!
+  source: Boolean!
+  streaming: Boolean!
+  methods: [String!]!
+}
+
+type DriftReport {
+  checked: String
+  drift: [Drift!]!
+}
+
+type Drift {
+  endpoint: String!
+  kind: String!
+  url: String!
+  hookId: String
+  expected: [String!]!
+  actual: [String!]!
+  error: String
+}
+
+type Delivery {
+  status: Int!
+  body: String!
+  headers: [Header!]!
+}
+
+type Event {
+  deliveryId: String!
+  endpoint: String!
+  path: String!
+  body: String!
+  time: String!
+}
+
+type Header {
+  name: String!
+  value: String!
+}
+
+input HeaderInput {
+  name: String!
+  value: String!
+}`
+
+// graphQLMaxRequestSize is the maximum size, in bytes, of a GraphQL request body.
+const graphQLMaxRequestSize int64 = 1 << 20
+
+// graphQLEndpoint is the configuration for an optional GraphQL endpoint used to deliver messages and subscribe to processed events.
+type graphQLEndpoint struct {
+	// path is the path the GraphQL endpoint is installed at.
+	path string
+	// token is the optional bearer token that clients must present in an "Authorization" header.
+	token string
+	// keepalive is the interval at which comments are sent to subscribers to keep idle connections open.
+	keepalive time.Duration
+}
+
+// graphQLRequest is the body of a GraphQL request.
+type graphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// graphQLResponse is the body of a GraphQL response.
+type graphQLResponse struct {
+	Data   interface{}     `json:"data,omitempty"`
+	Errors []*graphQLError `json:"errors,omitempty"`
+}
+
+// graphQLError is an error in a GraphQL response.
+type graphQLError struct {
+	Message    string                 `json:"message"`
+	Path       []string               `json:"path,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// graphQLHandler implements the `http.Handler` interface for the GraphQL endpoint of a `WebhookDaemon` instance.
+type graphQLHandler struct {
+	daemon   *WebhookDaemon
+	endpoint *graphQLEndpoint
+	// handler is the `http.Handler` that messages delivered by the "deliver" mutation are processed by.
+	handler http.Handler
+	logger  *log.Logger
+}
+
+// newGraphQLEndpoint returns a new `graphQLEndpoint` instance derived from 'uri'. See `AddGraphQLEndpoint` for details.
+func newGraphQLEndpoint(ctx context.Context, uri string) (*graphQLEndpoint, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse GraphQL URI, %w", err)
+	}
+
+	if u.Scheme != "graphql" {
+		return nil, fmt.Errorf("Invalid GraphQL URI scheme '%s'", u.Scheme)
+	}
+
+	if u.Host != "" {
+		return nil, fmt.Errorf("GraphQL URI should not define a host")
+	}
+
+	path := u.Path
+
+	if path == "" {
+		path = "/graphql"
+	}
+
+	q := u.Query()
+
+	keepalive := 15 * time.Second
+
+	str_keepalive := q.Get("keepalive")
+
+	if str_keepalive != "" {
+
+		v, err := time.ParseDuration(str_keepalive)
+
+		if err != nil || v <= 0 {
+			return nil, fmt.Errorf("Invalid ?keepalive= parameter '%s'", str_keepalive)
+		}
+
+		keepalive = v
+	}
+
+	e := &graphQLEndpoint{
+		path:      path,
+		token:     q.Get("token"),
+		keepalive: keepalive,
+	}
+
+	return e, nil
+}
+
+// AddGraphQLEndpoint() configures 'd' to install a GraphQL endpoint, alongside its webhooks, that internal clients can use to
+// deliver messages (with a "deliver" mutation) and to subscribe to processed events (with an "events" subscription, over
+// server-sent events). The schema for the endpoint is defined in `GRAPHQL_SCHEMA`. 'uri' is expected to take the form of:
+//
+//	graphql://{PATH}?{PARAMETERS}
+//
+// Where {PATH} is the path the endpoint is installed at. Default is "/graphql". Valid {PARAMETERS} are:
+// * `token={TOKEN}` An optional bearer token that clients must present in an "Authorization" header.
+// * `keepalive={DURATION}` The interval at which comments are sent to subscribers to keep idle connections open. Default is 15s.
+func (d *WebhookDaemon) AddGraphQLEndpoint(ctx context.Context, uri string) error {
+
+	e, err := newGraphQLEndpoint(ctx, uri)
+
+	if err != nil {
+		return err
+	}
+
+	_, exists := d.getWebhook(e.path)
+
+	if exists {
+		return fmt.Errorf("GraphQL endpoint '%s' is already configured as a webhook", e.path)
+	}
+
+	d.graphql = e
+	return nil
+}
+
+// graphQLHandlerWithLogger returns a `http.Handler` for the GraphQL endpoint of 'd' delivering messages to 'handler'.
+func (d *WebhookDaemon) graphQLHandlerWithLogger(handler http.Handler, logger *log.Logger) http.Handler {
+
+	h := &graphQLHandler{
+		daemon:   d,
+		endpoint: d.graphql,
+		handler:  handler,
+		logger:   logger,
+	}
+
+	return h
+}
+
+// ServeHTTP handles GraphQL requests, sent as JSON-encoded POST requests. Queries and mutations are answered with a JSON-encoded
+// response; subscriptions are answered with a stream of server-sent events, one "next" event per processed event, until the
+// client disconnects.
+func (h *graphQLHandler) ServeHTTP(rsp http.ResponseWriter, req *http.Request) {
+
+	if req.Method != http.MethodPost {
+		rsp.Header().Set("Allow", http.MethodPost)
+		http.Error(rsp, "405 Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.endpoint.token != "" {
+
+		token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+
+		if subtle.ConstantTimeCompare([]byte(token), []byte(h.endpoint.token)) != 1 {
+			http.Error(rsp, "401 Unauthorized", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	var gql_req graphQLRequest
+
+	dec := json.NewDecoder(io.LimitReader(req.Body, graphQLMaxRequestSize))
+	dec.UseNumber()
+
+	err := dec.Decode(&gql_req)
+
+	if err != nil {
+		h.writeErrors(rsp, http.StatusBadRequest, fmt.Errorf("Failed to decode request, %w", err))
+		return
+	}
+
+	op, err := parseGraphQL(gql_req.Query)
+
+	if err != nil {
+		h.writeErrors(rsp, http.StatusBadRequest, err)
+		return
+	}
+
+	if gql_req.OperationName != "" && gql_req.OperationName != op.name {
+		h.writeErrors(rsp, http.StatusBadRequest, fmt.Errorf("Unknown operation named \"%s\"", gql_req.OperationName))
+		return
+	}
+
+	vars := make(map[string]interface{})
+
+	for k, v := range op.defaults {
+		vars[k] = resolveGraphQLValue(v, nil)
+	}
+
+	for k, v := range gql_req.Variables {
+		vars[k] = v
+	}
+
+	ctx := req.Context()
+
+	switch op.kind {
+	case "subscription":
+		h.subscribe(ctx, rsp, op, vars)
+		return
+	case "mutation", "query":
+		// pass
+	default:
+		h.writeErrors(rsp, http.StatusBadRequest, fmt.Errorf("Unsupported operation '%s'", op.kind))
+		return
+	}
+
+	data := newOrderedObject()
+	gql_rsp := &graphQLResponse{Data: data}
+
+	// Root fields are resolved in order, which is required for mutations and harmless for queries
+
+	for _, f := range op.selections {
+
+		args := make(map[string]interface{})
+
+		for k, v := range f.arguments {
+			args[k] = resolveGraphQLValue(v, vars)
+		}
+
+		var v interface{}
+		var err error
+
+		if op.kind == "mutation" {
+			v, err = h.resolveMutation(ctx, req, f.name, args)
+		} else {
+			v, err = h.resolveQuery(ctx, f.name, args)
+		}
+
+		if err != nil {
+
+			gql_err, ok := err.(*graphQLError)
+
+			if !ok {
+				gql_err = &graphQLError{Message: err.Error()}
+			}
+
+			gql_err.Path = []string{f.alias}
+			gql_rsp.Errors = append(gql_rsp.Errors, gql_err)
+
+			data.set(f.alias, nil)
+			continue
+		}
+
+		projected, err := projectGraphQLValue(v, f.selections)
+
+		if err != nil {
+			h.writeErrors(rsp, http.StatusBadRequest, err)
+			return
+		}
+
+		data.set(f.alias, projected)
+	}
+
+	rsp.Header().Set("Content-Type", "application/json")
+
+	enc := json.NewEncoder(rsp)
+	err = enc.Encode(gql_rsp)
+
+	if err != nil {
+		aa_log.Error(h.logger, "Failed to encode GraphQL response, %v", err)
+	}
+}
+
+// resolveQuery resolves the query root field 'name'.
+func (h *graphQLHandler) resolveQuery(ctx context.Context, name string, args map[string]interface{}) (interface{}, error) {
+
+	switch name {
+	case "__typename":
+		return "Query", nil
+	case "webhooks":
+		// pass
+	case "drift":
+		return h.resolveDrift(ctx, args)
+	default:
+		return nil, fmt.Errorf("Cannot query field \"%s\" on type \"Query\"", name)
+	}
+
+	d := h.daemon
+
+	webhooks := make([]map[string]interface{}, 0)
+
+	d.mu.RLock()
+
+	for _, wh := range d.webhooks {
+
+		webhooks = append(webhooks, map[string]interface{}{
+			"__typename": "Webhook",
+			"endpoint":   wh.Endpoint(),
+			"source":     false,
+			"streaming":  wh.Streaming(),
+			"methods":    wh.Methods(),
+		})
+	}
+
+	d.mu.RUnlock()
+
+	for _, s := range d.sources {
+
+		webhooks = append(webhooks, map[string]interface{}{
+			"__typename": "Webhook",
+			"endpoint":   s.webhook.Endpoint(),
+			"source":     true,
+			"streaming":  false,
+			"methods":    []string{},
+		})
+	}
+
+	sort.Slice(webhooks, func(i, j int) bool {
+		return webhooks[i]["endpoint"].(string) < webhooks[j]["endpoint"].(string)
+	})
+
+	return webhooks, nil
+}
+
+// resolveDrift resolves the "drift" query returning the result of the most recent drift check or, if the "check" argument is
+// true, of a new check.
+func (h *graphQLHandler) resolveDrift(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+
+	check, ok := args["check"].(bool)
+
+	if !ok && args["check"] != nil {
+		return nil, fmt.Errorf("Argument \"check\" must be a boolean")
+	}
+
+	d := h.daemon
+
+	if d.drift == nil {
+		return nil, fmt.Errorf("Drift checks are not configured")
+	}
+
+	if check {
+
+		_, err := d.CheckDrift(ctx)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	drift, checked := d.Drift()
+
+	report := map[string]interface{}{
+		"__typename": "DriftReport",
+		"checked":    nil,
+		"drift":      make([]map[string]interface{}, 0, len(drift)),
+	}
+
+	if !checked.IsZero() {
+		report["checked"] = checked.Format(time.RFC3339)
+	}
+
+	for _, dr := range drift {
+
+		obj := map[string]interface{}{
+			"__typename": "Drift",
+			"endpoint":   dr.Endpoint,
+			"kind":       dr.Kind,
+			"url":        dr.URL,
+			"hookId":     nil,
+			"expected":   []string{},
+			"actual":     []string{},
+			"error":      nil,
+		}
+
+		if dr.HookID != "" {
+			obj["hookId"] = dr.HookID
+		}
+
+		if dr.Expected != nil {
+			obj["expected"] = dr.Expected
+		}
+
+		if dr.Actual != nil {
+			obj["actual"] = dr.Actual
+		}
+
+		if dr.Error != "" {
+			obj["error"] = dr.Error
+		}
+
+		report["drift"] = append(report["drift"].([]map[string]interface{}), obj)
+	}
+
+	return report, nil
+}
+
+// resolveMutation resolves the mutation root field 'name'. The "deliver" mutation processes a message using the same handler
+// as HTTP requests sent to its endpoint.
+func (h *graphQLHandler) resolveMutation(ctx context.Context, req *http.Request, name string, args map[string]interface{}) (interface{}, error) {
+
+	switch name {
+	case "__typename":
+		return "Mutation", nil
+	case "deliver":
+		// pass
+	default:
+		return nil, fmt.Errorf("Cannot query field \"%s\" on type \"Mutation\"", name)
+	}
+
+	endpoint, err := graphQLStringArgument(args, "endpoint", true)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.HasPrefix(endpoint, "/") {
+		return nil, fmt.Errorf("Invalid endpoint")
+	}
+
+	body, err := graphQLStringArgument(args, "body", false)
+
+	if err != nil {
+		return nil, err
+	}
+
+	http_req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(body))
+
+	if err != nil {
+		return nil, fmt.Errorf("Invalid endpoint, %w", err)
+	}
+
+	http_req.ContentLength = int64(len(body))
+	http_req.RemoteAddr = req.RemoteAddr
+
+	headers, ok := args["headers"].([]interface{})
+
+	if !ok && args["headers"] != nil {
+		return nil, fmt.Errorf("Argument \"headers\" must be a list")
+	}
+
+	for idx, v := range headers {
+
+		obj, ok := v.(map[string]interface{})
+
+		if !ok {
+			return nil, fmt.Errorf("Header at offset %d must be an object", idx)
+		}
+
+		k, err := graphQLStringArgument(obj, "name", true)
+
+		if err != nil {
+			return nil, err
+		}
+
+		v, err := graphQLStringArgument(obj, "value", true)
+
+		if err != nil {
+			return nil, err
+		}
+
+		http_req.Header.Add(k, v)
+	}
+
+	w := &bufferedResponseWriter{
+		header: http.Header{},
+		body:   new(bytes.Buffer),
+	}
+
+	h.handler.ServeHTTP(w, http_req)
+
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	if w.status >= 400 {
+
+		aa_log.Debug(h.logger, "GraphQL delivery to %s failed with status %d", endpoint, w.status)
+
+		err := &graphQLError{
+			Message: strings.TrimSpace(w.body.String()),
+			Extensions: map[string]interface{}{
+				"status": w.status,
+			},
+		}
+
+		return nil, err
+	}
+
+	rsp_headers := make([]map[string]interface{}, 0)
+
+	for k, v := range w.header {
+
+		rsp_headers = append(rsp_headers, map[string]interface{}{
+			"__typename": "Header",
+			"name":       k,
+			"value":      strings.Join(v, ", "),
+		})
+	}
+
+	sort.Slice(rsp_headers, func(i, j int) bool {
+		return rsp_headers[i]["name"].(string) < rsp_headers[j]["name"].(string)
+	})
+
+	delivery := map[string]interface{}{
+		"__typename": "Delivery",
+		"status":     w.status,
+		"body":       w.body.String(),
+		"headers":    rsp_headers,
+	}
+
+	return delivery, nil
+}
+
+// subscribe handles the "events" subscription writing each processed event, projected through the subscription's selection set,
+// as a server-sent event until 'ctx' is cancelled.
+func (h *graphQLHandler) subscribe(ctx context.Context, rsp http.ResponseWriter, op *graphQLOperation, vars map[string]interface{}) {
+
+	if len(op.selections) != 1 {
+		h.writeErrors(rsp, http.StatusBadRequest, fmt.Errorf("Subscriptions must select exactly one root field"))
+		return
+	}
+
+	f := op.selections[0]
+
+	if f.name != "events" {
+		h.writeErrors(rsp, http.StatusBadRequest, fmt.Errorf("Cannot query field \"%s\" on type \"Subscription\"", f.name))
+		return
+	}
+
+	args := make(map[string]interface{})
+
+	for k, v := range f.arguments {
+		args[k] = resolveGraphQLValue(v, vars)
+	}
+
+	endpoint, err := graphQLStringArgument(args, "endpoint", false)
+
+	if err != nil {
+		h.writeErrors(rsp, http.StatusBadRequest, err)
+		return
+	}
+
+	// Validate the selection set before any events are sent
+
+	_, err = projectGraphQLValue(graphQLEvent(&processedEvent{}), f.selections)
+
+	if err != nil {
+		h.writeErrors(rsp, http.StatusBadRequest, err)
+		return
+	}
+
+	flusher, ok := rsp.(http.Flusher)
+
+	if !ok {
+		h.writeErrors(rsp, http.StatusInternalServerError, fmt.Errorf("Streaming is not supported"))
+		return
+	}
+
+	sub := h.daemon.events.subscribe(endpoint)
+	defer h.daemon.events.unsubscribe(sub)
+
+	rsp.Header().Set("Content-Type", "text/event-stream")
+	rsp.Header().Set("Cache-Control", "no-cache")
+	rsp.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ticker := time.NewTicker(h.endpoint.keepalive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+
+			_, err := io.WriteString(rsp, ":\n\n")
+
+			if err != nil {
+				return
+			}
+
+			flusher.Flush()
+
+		case ev := <-sub.events:
+
+			projected, err := projectGraphQLValue(graphQLEvent(ev), f.selections)
+
+			if err != nil {
+				aa_log.Error(h.logger, "Failed to project GraphQL event, %v", err)
+				continue
+			}
+
+			data := newOrderedObject()
+			data.set(f.alias, projected)
+
+			enc, err := json.Marshal(&graphQLResponse{Data: data})
+
+			if err != nil {
+				aa_log.Error(h.logger, "Failed to encode GraphQL event, %v", err)
+				continue
+			}
+
+			_, err = fmt.Fprintf(rsp, "event: next\ndata: %s\n\n", enc)
+
+			if err != nil {
+				return
+			}
+
+			flusher.Flush()
+		}
+	}
+}
+
+// writeErrors writes 'errs' as a GraphQL response with status code 'status'.
+func (h *graphQLHandler) writeErrors(rsp http.ResponseWriter, status int, errs ...error) {
+
+	gql_rsp := &graphQLResponse{
+		Errors: make([]*graphQLError, len(errs)),
+	}
+
+	for idx, err := range errs {
+		gql_rsp.Errors[idx] = &graphQLError{Message: err.Error()}
+	}
+
+	rsp.Header().Set("Content-Type", "application/json")
+	rsp.WriteHeader(status)
+
+	enc := json.NewEncoder(rsp)
+	err := enc.Encode(gql_rsp)
+
+	if err != nil {
+		aa_log.Error(h.logger, "Failed to encode GraphQL errors, %v", err)
+	}
+}
+
+// Error returns the message for 'e'.
+func (e *graphQLError) Error() string {
+	return e.Message
+}
+
+// graphQLEvent returns the GraphQL "Event" object for 'ev'.
+func graphQLEvent(ev *processedEvent) map[string]interface{} {
+
+	return map[string]interface{}{
+		"__typename": "Event",
+		"deliveryId": ev.DeliveryID,
+		"endpoint":   ev.Endpoint,
+		"path":       ev.Path,
+		"body":       string(ev.Body),
+		"time":       ev.Time.Format(time.RFC3339Nano),
+	}
+}
+
+// graphQLStringArgument returns the string value of the argument 'name' in 'args'. If 'required' is true a missing or null
+// argument is an error.
+func graphQLStringArgument(args map[string]interface{}, name string, required bool) (string, error) {
+
+	v, ok := args[name]
+
+	if !ok || v == nil {
+
+		if required {
+			return "", fmt.Errorf("Missing required argument \"%s\"", name)
+		}
+
+		return "", nil
+	}
+
+	str_v, ok := v.(string)
+
+	if !ok {
+		return "", fmt.Errorf("Argument \"%s\" must be a string", name)
+	}
+
+	return str_v, nil
+}
@@ -0,0 +1,639 @@
+package daemon
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// graphQLOperation is a parsed GraphQL operation. Only the subset of the GraphQL language needed by the `webhookd` schema is
+// supported: a single query, mutation or subscription operation with variables, aliases, arguments and nested selection sets.
+// Fragments and directives are not supported.
+type graphQLOperation struct {
+	// kind is the type of operation, one of "query", "mutation" or "subscription".
+	kind string
+	// name is the optional name of the operation.
+	name string
+	// defaults is a dictionary of default values for the operation's variables.
+	defaults map[string]interface{}
+	// selections is the list of root fields selected by the operation.
+	selections []*graphQLField
+}
+
+// graphQLField is a single field in a GraphQL selection set.
+type graphQLField struct {
+	// alias is the key used for the field in results. It defaults to the field name.
+	alias string
+	// name is the name of the field.
+	name string
+	// arguments is a dictionary of arguments for the field. Values are literals, `graphQLVariable` instances, lists or objects.
+	arguments map[string]interface{}
+	// selections is the selection set for the field. It is empty for scalar fields.
+	selections []*graphQLField
+}
+
+// graphQLVariable is a reference to a variable in a GraphQL argument.
+type graphQLVariable string
+
+// graphQLEnum is an enum value in a GraphQL argument.
+type graphQLEnum string
+
+// orderedObject is a JSON object, for example a GraphQL result, whose keys are encoded in the order they were set.
+type orderedObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func newOrderedObject() *orderedObject {
+	return &orderedObject{values: make(map[string]interface{})}
+}
+
+func (o *orderedObject) set(k string, v interface{}) {
+
+	_, exists := o.values[k]
+
+	if !exists {
+		o.keys = append(o.keys, k)
+	}
+
+	o.values[k] = v
+}
+
+// MarshalJSON encodes 'o' as a JSON object preserving the order of its keys.
+func (o *orderedObject) MarshalJSON() ([]byte, error) {
+
+	var buf bytes.Buffer
+	buf.WriteString("{")
+
+	for idx, k := range o.keys {
+
+		if idx > 0 {
+			buf.WriteString(",")
+		}
+
+		enc_k, err := json.Marshal(k)
+
+		if err != nil {
+			return nil, err
+		}
+
+		enc_v, err := json.Marshal(o.values[k])
+
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Write(enc_k)
+		buf.WriteString(":")
+		buf.Write(enc_v)
+	}
+
+	buf.WriteString("}")
+	return buf.Bytes(), nil
+}
+
+// graphQLToken is a single lexical token in a GraphQL document.
+type graphQLToken struct {
+	// kind is one of "punct", "name", "int", "float", "string" or "eof".
+	kind  string
+	value string
+	pos   int
+}
+
+// graphQLParser is a recursive descent parser for GraphQL documents.
+type graphQLParser struct {
+	src string
+	pos int
+	tok graphQLToken
+}
+
+// parseGraphQL parses the GraphQL document 'query' returning its operation.
+func parseGraphQL(query string) (op *graphQLOperation, err error) {
+
+	p := &graphQLParser{src: query}
+
+	defer func() {
+
+		r := recover()
+
+		if r == nil {
+			return
+		}
+
+		perr, ok := r.(graphQLSyntaxError)
+
+		if !ok {
+			panic(r)
+		}
+
+		op = nil
+		err = perr
+	}()
+
+	p.next()
+
+	op = p.parseOperation()
+
+	if p.tok.kind != "eof" {
+		p.fail("Only a single operation is supported")
+	}
+
+	return op, nil
+}
+
+// graphQLSyntaxError is the error returned for invalid GraphQL documents.
+type graphQLSyntaxError struct {
+	message string
+	pos     int
+}
+
+func (e graphQLSyntaxError) Error() string {
+	return fmt.Sprintf("Syntax error at offset %d: %s", e.pos, e.message)
+}
+
+func (p *graphQLParser) fail(msg string, args ...interface{}) {
+	panic(graphQLSyntaxError{message: fmt.Sprintf(msg, args...), pos: p.tok.pos})
+}
+
+// next advances 'p' to the next token, skipping whitespace, commas and comments.
+func (p *graphQLParser) next() {
+
+	for p.pos < len(p.src) {
+
+		c := p.src[p.pos]
+
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',' || c == 0xef || c == 0xbb || c == 0xbf {
+			p.pos++
+			continue
+		}
+
+		if c == '#' {
+
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+
+			continue
+		}
+
+		break
+	}
+
+	start := p.pos
+
+	if p.pos >= len(p.src) {
+		p.tok = graphQLToken{kind: "eof", pos: start}
+		return
+	}
+
+	c := p.src[p.pos]
+
+	switch {
+	case strings.HasPrefix(p.src[p.pos:], "..."):
+		p.pos += 3
+		p.tok = graphQLToken{kind: "punct", value: "...", pos: start}
+	case strings.IndexByte("!$()[]{}:=@|&", c) != -1:
+		p.pos++
+		p.tok = graphQLToken{kind: "punct", value: string(c), pos: start}
+	case c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+
+		for p.pos < len(p.src) {
+
+			c := p.src[p.pos]
+
+			if c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') {
+				p.pos++
+				continue
+			}
+
+			break
+		}
+
+		p.tok = graphQLToken{kind: "name", value: p.src[start:p.pos], pos: start}
+
+	case c == '-' || (c >= '0' && c <= '9'):
+
+		kind := "int"
+		p.pos++
+
+		for p.pos < len(p.src) {
+
+			c := p.src[p.pos]
+
+			if c >= '0' && c <= '9' {
+				p.pos++
+				continue
+			}
+
+			if c == '.' || c == 'e' || c == 'E' || ((c == '+' || c == '-') && (p.src[p.pos-1] == 'e' || p.src[p.pos-1] == 'E')) {
+				kind = "float"
+				p.pos++
+				continue
+			}
+
+			break
+		}
+
+		p.tok = graphQLToken{kind: kind, value: p.src[start:p.pos], pos: start}
+
+	case c == '"':
+		p.tok = graphQLToken{kind: "string", value: p.readString(), pos: start}
+	default:
+		p.tok = graphQLToken{kind: "punct", value: string(c), pos: start}
+		p.fail("Unexpected character '%c'", c)
+	}
+}
+
+// readString reads a (quoted, or triple-quoted block) string starting at the current position of 'p'.
+func (p *graphQLParser) readString() string {
+
+	if strings.HasPrefix(p.src[p.pos:], `"""`) {
+
+		end := strings.Index(p.src[p.pos+3:], `"""`)
+
+		if end == -1 {
+			p.fail("Unterminated string")
+		}
+
+		v := p.src[p.pos+3 : p.pos+3+end]
+		p.pos = p.pos + 3 + end + 3
+
+		return strings.TrimSpace(v)
+	}
+
+	start := p.pos
+	p.pos++
+
+	for p.pos < len(p.src) {
+
+		switch p.src[p.pos] {
+		case '\\':
+			p.pos += 2
+		case '\n':
+			p.fail("Unterminated string")
+		case '"':
+
+			p.pos++
+
+			v, err := strconv.Unquote(p.src[start:p.pos])
+
+			if err != nil {
+				p.fail("Invalid string, %v", err)
+			}
+
+			return v
+
+		default:
+			p.pos++
+		}
+	}
+
+	p.fail("Unterminated string")
+	return ""
+}
+
+// expect advances past the punctuator 'v' or fails.
+func (p *graphQLParser) expect(v string) {
+
+	if p.tok.kind != "punct" || p.tok.value != v {
+		p.fail("Expected '%s'", v)
+	}
+
+	p.next()
+}
+
+// peek returns true if the current token is the punctuator 'v'.
+func (p *graphQLParser) peek(v string) bool {
+	return p.tok.kind == "punct" && p.tok.value == v
+}
+
+// name returns the current name token, advancing past it, or fails.
+func (p *graphQLParser) name() string {
+
+	if p.tok.kind != "name" {
+		p.fail("Expected name")
+	}
+
+	v := p.tok.value
+	p.next()
+
+	return v
+}
+
+func (p *graphQLParser) parseOperation() *graphQLOperation {
+
+	op := &graphQLOperation{
+		kind:     "query",
+		defaults: make(map[string]interface{}),
+	}
+
+	if p.peek("{") {
+		op.selections = p.parseSelectionSet()
+		return op
+	}
+
+	kind := p.name()
+
+	switch kind {
+	case "query", "mutation", "subscription":
+		op.kind = kind
+	case "fragment":
+		p.fail("Fragments are not supported")
+	default:
+		p.fail("Unknown operation type '%s'", kind)
+	}
+
+	if p.tok.kind == "name" {
+		op.name = p.name()
+	}
+
+	if p.peek("(") {
+
+		p.next()
+
+		for !p.peek(")") {
+
+			p.expect("$")
+			name := p.name()
+
+			p.expect(":")
+			p.parseType()
+
+			if p.peek("=") {
+				p.next()
+				op.defaults[name] = p.parseValue(true)
+			}
+		}
+
+		p.next()
+	}
+
+	if p.peek("@") {
+		p.fail("Directives are not supported")
+	}
+
+	op.selections = p.parseSelectionSet()
+	return op
+}
+
+// parseType parses, and discards, a variable type. Variable values are validated by the fields that use them.
+func (p *graphQLParser) parseType() {
+
+	if p.peek("[") {
+		p.next()
+		p.parseType()
+		p.expect("]")
+	} else {
+		p.name()
+	}
+
+	if p.peek("!") {
+		p.next()
+	}
+}
+
+func (p *graphQLParser) parseSelectionSet() []*graphQLField {
+
+	p.expect("{")
+
+	fields := make([]*graphQLField, 0)
+
+	for !p.peek("}") {
+
+		if p.peek("...") {
+			p.fail("Fragments are not supported")
+		}
+
+		f := &graphQLField{
+			name:      p.name(),
+			arguments: make(map[string]interface{}),
+		}
+
+		if p.peek(":") {
+			p.next()
+			f.alias = f.name
+			f.name = p.name()
+		} else {
+			f.alias = f.name
+		}
+
+		if p.peek("(") {
+
+			p.next()
+
+			for !p.peek(")") {
+				k := p.name()
+				p.expect(":")
+				f.arguments[k] = p.parseValue(false)
+			}
+
+			p.next()
+		}
+
+		if p.peek("@") {
+			p.fail("Directives are not supported")
+		}
+
+		if p.peek("{") {
+			f.selections = p.parseSelectionSet()
+		}
+
+		fields = append(fields, f)
+	}
+
+	p.next()
+
+	if len(fields) == 0 {
+		p.fail("Empty selection set")
+	}
+
+	return fields
+}
+
+// parseValue parses an argument value. If 'constant' is true variables are not allowed.
+func (p *graphQLParser) parseValue(constant bool) interface{} {
+
+	tok := p.tok
+
+	switch tok.kind {
+	case "int":
+
+		p.next()
+
+		v, err := strconv.ParseInt(tok.value, 10, 64)
+
+		if err != nil {
+			p.fail("Invalid integer '%s'", tok.value)
+		}
+
+		return v
+
+	case "float":
+
+		p.next()
+
+		v, err := strconv.ParseFloat(tok.value, 64)
+
+		if err != nil {
+			p.fail("Invalid float '%s'", tok.value)
+		}
+
+		return v
+
+	case "string":
+		p.next()
+		return tok.value
+	case "name":
+
+		p.next()
+
+		switch tok.value {
+		case "true":
+			return true
+		case "false":
+			return false
+		case "null":
+			return nil
+		default:
+			return graphQLEnum(tok.value)
+		}
+	}
+
+	switch {
+	case p.peek("$"):
+
+		if constant {
+			p.fail("Variables are not allowed in default values")
+		}
+
+		p.next()
+		return graphQLVariable(p.name())
+
+	case p.peek("["):
+
+		p.next()
+
+		list := make([]interface{}, 0)
+
+		for !p.peek("]") {
+			list = append(list, p.parseValue(constant))
+		}
+
+		p.next()
+		return list
+
+	case p.peek("{"):
+
+		p.next()
+
+		obj := make(map[string]interface{})
+
+		for !p.peek("}") {
+			k := p.name()
+			p.expect(":")
+			obj[k] = p.parseValue(constant)
+		}
+
+		p.next()
+		return obj
+	}
+
+	p.fail("Unexpected token")
+	return nil
+}
+
+// resolveGraphQLValue replaces any `graphQLVariable` instances in 'v' with their values in 'vars'.
+func resolveGraphQLValue(v interface{}, vars map[string]interface{}) interface{} {
+
+	switch t := v.(type) {
+	case graphQLVariable:
+		return vars[string(t)]
+	case graphQLEnum:
+		return string(t)
+	case []interface{}:
+
+		list := make([]interface{}, len(t))
+
+		for idx, item := range t {
+			list[idx] = resolveGraphQLValue(item, vars)
+		}
+
+		return list
+
+	case map[string]interface{}:
+
+		obj := make(map[string]interface{})
+
+		for k, item := range t {
+			obj[k] = resolveGraphQLValue(item, vars)
+		}
+
+		return obj
+
+	default:
+		return v
+	}
+}
+
+// projectGraphQLValue returns the subset of 'v' selected by 'selections'. Objects are represented as `map[string]interface{}`
+// values whose "__typename" key is the name of their type.
+func projectGraphQLValue(v interface{}, selections []*graphQLField) (interface{}, error) {
+
+	switch t := v.(type) {
+	case nil:
+		return nil, nil
+	case []map[string]interface{}:
+
+		list := make([]interface{}, len(t))
+
+		for idx, item := range t {
+
+			projected, err := projectGraphQLValue(item, selections)
+
+			if err != nil {
+				return nil, err
+			}
+
+			list[idx] = projected
+		}
+
+		return list, nil
+
+	case map[string]interface{}:
+
+		if len(selections) == 0 {
+			return nil, fmt.Errorf("Field of type %v must have a selection of subfields", t["__typename"])
+		}
+
+		obj := newOrderedObject()
+
+		for _, f := range selections {
+
+			item, ok := t[f.name]
+
+			if !ok {
+				return nil, fmt.Errorf("Cannot query field \"%s\" on type \"%v\"", f.name, t["__typename"])
+			}
+
+			projected, err := projectGraphQLValue(item, f.selections)
+
+			if err != nil {
+				return nil, err
+			}
+
+			obj.set(f.alias, projected)
+		}
+
+		return obj, nil
+
+	default:
+
+		if len(selections) > 0 {
+			return nil, fmt.Errorf("Scalar fields can not have a selection of subfields")
+		}
+
+		return v, nil
+	}
+}
@@ -0,0 +1,141 @@
+package daemon
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseGraphQL(t *testing.T) {
+
+	query := `
+# Deliver a message
+mutation Deliver($body: String = "hello", $endpoint: String!) {
+  first: deliver(endpoint: $endpoint, body: $body, headers: [{name: "X-Count", value: "1"}]) {
+    status
+    headers { name value }
+  }
+}`
+
+	op, err := parseGraphQL(query)
+
+	if err != nil {
+		t.Fatalf("Failed to parse query, %v", err)
+	}
+
+	if op.kind != "mutation" || op.name != "Deliver" {
+		t.Fatalf("Unexpected operation: %s %s", op.kind, op.name)
+	}
+
+	if op.defaults["body"] != "hello" {
+		t.Fatalf("Unexpected default for $body: %v", op.defaults["body"])
+	}
+
+	if len(op.selections) != 1 {
+		t.Fatalf("Unexpected number of selections: %d", len(op.selections))
+	}
+
+	f := op.selections[0]
+
+	if f.alias != "first" || f.name != "deliver" {
+		t.Fatalf("Unexpected field: %s: %s", f.alias, f.name)
+	}
+
+	if f.arguments["endpoint"] != graphQLVariable("endpoint") {
+		t.Fatalf("Unexpected endpoint argument: %v", f.arguments["endpoint"])
+	}
+
+	args := resolveGraphQLValue(f.arguments["headers"], nil).([]interface{})
+
+	if args[0].(map[string]interface{})["value"] != "1" {
+		t.Fatalf("Unexpected headers argument: %v", args)
+	}
+
+	if len(f.selections) != 2 || len(f.selections[1].selections) != 2 {
+		t.Fatalf("Unexpected selection set")
+	}
+
+	op, err = parseGraphQL(`{ webhooks { endpoint } }`)
+
+	if err != nil {
+		t.Fatalf("Failed to parse shorthand query, %v", err)
+	}
+
+	if op.kind != "query" {
+		t.Fatalf("Unexpected operation: %s", op.kind)
+	}
+}
+
+func TestParseGraphQLInvalid(t *testing.T) {
+
+	tests := []string{
+		``,
+		`{}`,
+		`{ webhooks { endpoint }`,
+		`query { webhooks } query { webhooks }`,
+		`{ webhooks { ...Fields } }`,
+		`fragment Fields on Webhook { endpoint }`,
+		`{ webhooks @include(if: true) { endpoint } }`,
+		`query ($v: String = $w) { webhooks { endpoint } }`,
+		`{ deliver(endpoint: "/foo) { status } }`,
+		`{ webhooks { endpoint } } %`,
+	}
+
+	for _, q := range tests {
+
+		_, err := parseGraphQL(q)
+
+		if err == nil {
+			t.Fatalf("Expected '%s' to fail", q)
+		}
+	}
+}
+
+func TestProjectGraphQLValue(t *testing.T) {
+
+	op, err := parseGraphQL(`{ webhooks { url: endpoint methods } }`)
+
+	if err != nil {
+		t.Fatalf("Failed to parse query, %v", err)
+	}
+
+	v := []map[string]interface{}{
+		{"__typename": "Webhook", "endpoint": "/foo", "methods": []string{"POST"}, "source": false},
+	}
+
+	projected, err := projectGraphQLValue(v, op.selections[0].selections)
+
+	if err != nil {
+		t.Fatalf("Failed to project value, %v", err)
+	}
+
+	enc, err := json.Marshal(projected)
+
+	if err != nil {
+		t.Fatalf("Failed to marshal value, %v", err)
+	}
+
+	if string(enc) != `[{"url":"/foo","methods":["POST"]}]` {
+		t.Fatalf("Unexpected value: %s", enc)
+	}
+
+	invalid := []string{
+		`{ webhooks { missing } }`,
+		`{ webhooks }`,
+		`{ webhooks { endpoint { name } } }`,
+	}
+
+	for _, q := range invalid {
+
+		op, err := parseGraphQL(q)
+
+		if err != nil {
+			t.Fatalf("Failed to parse query '%s', %v", q, err)
+		}
+
+		_, err = projectGraphQLValue(v, op.selections[0].selections)
+
+		if err == nil {
+			t.Fatalf("Expected '%s' to fail", q)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package daemon
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/whosonfirst/go-webhookd/v3/config"
+)
+
+func TestGraphQLEndpoint(t *testing.T) {
+
+	ctx := context.Background()
+
+	cfg := &config.WebhookConfig{
+		Daemon:  "http://localhost:8081",
+		GraphQL: "graphql:///graphql?token=s3cret",
+		Receivers: map[string]string{
+			"insecure": "insecure://",
+		},
+		Dispatchers: map[string]string{
+			"null": "null://",
+		},
+		Webhooks: []config.WebhookWebhooksConfig{
+			{
+				Endpoint:    "/repos/{owner}",
+				Receiver:    "insecure",
+				Dispatchers: []string{"null"},
+				Response: &config.WebhookResponseConfig{
+					Status:      http.StatusAccepted,
+					Body:        `{"id":"{{.DeliveryID}}","owner":"{{index .Params "owner"}}"}`,
+					ContentType: "application/json",
+				},
+			},
+			{
+				Endpoint:    "/insecure",
+				Receiver:    "insecure",
+				Dispatchers: []string{"null"},
+			},
+		},
+	}
+
+	d, err := NewWebhookDaemonFromConfig(ctx, cfg)
+
+	if err != nil {
+		t.Fatalf("Failed to create new daemon from config, %v", err)
+	}
+
+	if d.graphql == nil || d.graphql.path != "/graphql" {
+		t.Fatalf("Expected daemon to be configured with a GraphQL endpoint")
+	}
+
+	logger := log.New(os.Stderr, "", 0)
+
+	handler, err := d.HandlerFuncWithLogger(logger)
+
+	if err != nil {
+		t.Fatalf("Failed to create handler func, %v", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", handler)
+	mux.Handle(d.graphql.path, d.graphQLHandlerWithLogger(handler, logger))
+
+	svr := httptest.NewServer(mux)
+	defer svr.Close()
+
+	post := func(ctx context.Context, token string, query string, vars map[string]interface{}) (*http.Response, error) {
+
+		enc, err := json.Marshal(&graphQLRequest{Query: query, Variables: vars})
+
+		if err != nil {
+			return nil, err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, svr.URL+"/graphql", strings.NewReader(string(enc)))
+
+		if err != nil {
+			return nil, err
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		return http.DefaultClient.Do(req)
+	}
+
+	execute := func(query string, vars map[string]interface{}) (int, string) {
+
+		rsp, err := post(ctx, "s3cret", query, vars)
+
+		if err != nil {
+			t.Fatalf("Failed to execute '%s', %v", query, err)
+		}
+
+		defer rsp.Body.Close()
+
+		var buf strings.Builder
+		_, err = bufio.NewReader(rsp.Body).WriteTo(&buf)
+
+		if err != nil {
+			t.Fatalf("Failed to read response, %v", err)
+		}
+
+		return rsp.StatusCode, strings.TrimSpace(buf.String())
+	}
+
+	tests := []struct {
+		query    string
+		vars     map[string]interface{}
+		status   int
+		expected string
+	}{
+		{
+			query:    `{ webhooks { endpoint source } }`,
+			status:   http.StatusOK,
+			expected: `{"data":{"webhooks":[{"endpoint":"/insecure","source":false},{"endpoint":"/repos/{owner}","source":false}]}}`,
+		},
+		{
+			query:    `mutation ($body: String) { deliver(endpoint: "/repos/whosonfirst", body: $body, headers: [{name: "X-Request-Id", value: "1234"}]) { status body } }`,
+			vars:     map[string]interface{}{"body": "hello world"},
+			status:   http.StatusOK,
+			expected: `{"data":{"deliver":{"status":202,"body":"{\"id\":\"1234\",\"owner\":\"whosonfirst\"}"}}}`,
+		},
+		{
+			query:    `mutation { deliver(endpoint: "/missing") { status } }`,
+			status:   http.StatusOK,
+			expected: `{"data":{"deliver":null},"errors":[{"message":"404 Not found","path":["deliver"],"extensions":{"status":404}}]}`,
+		},
+		{
+			query:    `mutation { deliver { status } }`,
+			status:   http.StatusOK,
+			expected: `{"data":{"deliver":null},"errors":[{"message":"Missing required argument \"endpoint\"","path":["deliver"]}]}`,
+		},
+		{
+			query:    `{ webhooks { missing } }`,
+			status:   http.StatusBadRequest,
+			expected: `{"errors":[{"message":"Cannot query field \"missing\" on type \"Webhook\""}]}`,
+		},
+		{
+			query:  `{ webhooks { endpoint }`,
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+
+		status, body := execute(test.query, test.vars)
+
+		if status != test.status {
+			t.Fatalf("Unexpected status for '%s': %d %s", test.query, status, body)
+		}
+
+		if test.expected != "" && body != test.expected {
+			t.Fatalf("Unexpected body for '%s': %s", test.query, body)
+		}
+	}
+
+	rsp, err := post(ctx, "wrong", `{ webhooks { endpoint } }`, nil)
+
+	if err != nil {
+		t.Fatalf("Failed to execute query, %v", err)
+	}
+
+	rsp.Body.Close()
+
+	if rsp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("Expected invalid token to be rejected, got %d", rsp.StatusCode)
+	}
+
+	// Subscribe to events and then deliver a message over HTTP
+
+	sub_ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	rsp, err = post(sub_ctx, "s3cret", `subscription { events(endpoint: "/insecure") { endpoint path body } }`, nil)
+
+	if err != nil {
+		t.Fatalf("Failed to subscribe, %v", err)
+	}
+
+	defer rsp.Body.Close()
+
+	if rsp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("Unexpected content type: %s", rsp.Header.Get("Content-Type"))
+	}
+
+	// Events for other webhooks should be ignored by the subscriber
+
+	for _, path := range []string{"/repos/sfomuseum", "/insecure"} {
+
+		http_rsp, err := http.Post(svr.URL+path, "text/plain", strings.NewReader("event for "+path))
+
+		if err != nil {
+			t.Fatalf("Failed to deliver message to %s, %v", path, err)
+		}
+
+		http_rsp.Body.Close()
+	}
+
+	scanner := bufio.NewScanner(rsp.Body)
+
+	for scanner.Scan() {
+
+		line := scanner.Text()
+
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+
+		expected := `data: {"data":{"events":{"endpoint":"/insecure","path":"/insecure","body":"event for /insecure"}}}`
+
+		if line != expected {
+			t.Fatalf("Unexpected event: %s", line)
+		}
+
+		return
+	}
+
+	t.Fatalf("Subscription ended without receiving an event, %v", scanner.Err())
+}
+
+func TestNewGraphQLEndpointInvalid(t *testing.T) {
+
+	ctx := context.Background()
+
+	tests := []string{
+		"http:///graphql",
+		"graphql://example.com/graphql",
+		"graphql:///graphql?keepalive=forever",
+		"graphql:///graphql?keepalive=-1s",
+	}
+
+	for _, uri := range tests {
+
+		_, err := newGraphQLEndpoint(ctx, uri)
+
+		if err == nil {
+			t.Fatalf("Expected %s to fail", uri)
+		}
+	}
+}
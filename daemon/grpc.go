@@ -0,0 +1,437 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	aa_log "github.com/aaronland/go-log/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// GRPC_DELIVER_METHOD is the full name of the gRPC method used to deliver webhook messages.
+const GRPC_DELIVER_METHOD string = "/webhookd.v1.Webhookd/Deliver"
+
+// grpcServer is the configuration for an optional gRPC server that delivers webhook messages to the same handler as HTTP requests.
+type grpcServer struct {
+	// address is the address the gRPC server listens on.
+	address string
+	// options are the `grpc.ServerOption` instances used to create the gRPC server.
+	options []grpc.ServerOption
+}
+
+// grpcDeliverer is the interface that the handler for `GRPC_DELIVER_METHOD` must implement.
+type grpcDeliverer interface {
+	deliver(context.Context, *dynamicpb.Message) (*dynamicpb.Message, error)
+}
+
+// grpcDeliverHandler implements the `grpcDeliverer` interface for an `http.Handler`.
+type grpcDeliverHandler struct {
+	handler  http.Handler
+	logger   *log.Logger
+	response protoreflect.MessageDescriptor
+}
+
+// bufferedResponseWriter is a minimal `http.ResponseWriter` implementation used to capture the response for a message delivered
+// over gRPC or GraphQL.
+type bufferedResponseWriter struct {
+	header http.Header
+	status int
+	body   *bytes.Buffer
+}
+
+func (w *bufferedResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *bufferedResponseWriter) Write(b []byte) (int, error) {
+
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	return w.body.Write(b)
+}
+
+func (w *bufferedResponseWriter) WriteHeader(status int) {
+
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+// grpcDescriptors derives the message descriptors for the `webhookd.v1` gRPC service, defined in webhookd.proto, returning
+// the descriptors for the `DeliverRequest` and `DeliverResponse` messages.
+func grpcDescriptors() (protoreflect.MessageDescriptor, protoreflect.MessageDescriptor, error) {
+
+	field := func(name string, number int32, kind descriptorpb.FieldDescriptorProto_Type, label descriptorpb.FieldDescriptorProto_Label, type_name string) *descriptorpb.FieldDescriptorProto {
+
+		f := &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(number),
+			Type:     kind.Enum(),
+			Label:    label.Enum(),
+		}
+
+		if type_name != "" {
+			f.TypeName = proto.String(type_name)
+		}
+
+		return f
+	}
+
+	optional := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+	repeated := descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+
+	message := func(name string, status_kind descriptorpb.FieldDescriptorProto_Type, status_name string) *descriptorpb.DescriptorProto {
+
+		entry := &descriptorpb.DescriptorProto{
+			Name: proto.String("HeadersEntry"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				field("key", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, optional, ""),
+				field("value", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING, optional, ""),
+			},
+			Options: &descriptorpb.MessageOptions{
+				MapEntry: proto.Bool(true),
+			},
+		}
+
+		m := &descriptorpb.DescriptorProto{
+			Name: proto.String(name),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				field(status_name, 1, status_kind, optional, ""),
+				field("headers", 2, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, repeated, fmt.Sprintf(".webhookd.v1.%s.HeadersEntry", name)),
+				field("body", 3, descriptorpb.FieldDescriptorProto_TYPE_BYTES, optional, ""),
+			},
+			NestedType: []*descriptorpb.DescriptorProto{entry},
+		}
+
+		return m
+	}
+
+	fd := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("webhookd.proto"),
+		Package: proto.String("webhookd.v1"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			message("DeliverRequest", descriptorpb.FieldDescriptorProto_TYPE_STRING, "endpoint"),
+			message("DeliverResponse", descriptorpb.FieldDescriptorProto_TYPE_INT32, "status"),
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{
+			{
+				Name: proto.String("Webhookd"),
+				Method: []*descriptorpb.MethodDescriptorProto{
+					{
+						Name:       proto.String("Deliver"),
+						InputType:  proto.String(".webhookd.v1.DeliverRequest"),
+						OutputType: proto.String(".webhookd.v1.DeliverResponse"),
+					},
+				},
+			},
+		},
+	}
+
+	f, err := protodesc.NewFile(fd, nil)
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to create file descriptor, %w", err)
+	}
+
+	messages := f.Messages()
+	return messages.ByName("DeliverRequest"), messages.ByName("DeliverResponse"), nil
+}
+
+// newGRPCServer returns a new `grpcServer` instance derived from 'uri'. See `AddGRPCServer` for details.
+func newGRPCServer(ctx context.Context, uri string) (*grpcServer, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse gRPC URI, %w", err)
+	}
+
+	if u.Scheme != "grpc" {
+		return nil, fmt.Errorf("Invalid gRPC URI scheme '%s'", u.Scheme)
+	}
+
+	if u.Host == "" {
+		return nil, fmt.Errorf("Missing gRPC address")
+	}
+
+	q := u.Query()
+
+	options := make([]grpc.ServerOption, 0)
+
+	str_streams := q.Get("max_concurrent_streams")
+
+	if str_streams != "" {
+
+		v, err := strconv.ParseUint(str_streams, 10, 32)
+
+		if err != nil || v == 0 {
+			return nil, fmt.Errorf("Invalid ?max_concurrent_streams= parameter '%s'", str_streams)
+		}
+
+		options = append(options, grpc.MaxConcurrentStreams(uint32(v)))
+	}
+
+	str_size := q.Get("max_message_size")
+
+	if str_size != "" {
+
+		v, err := strconv.Atoi(str_size)
+
+		if err != nil || v <= 0 {
+			return nil, fmt.Errorf("Invalid ?max_message_size= parameter '%s'", str_size)
+		}
+
+		options = append(options, grpc.MaxRecvMsgSize(v))
+	}
+
+	tls_cert := q.Get("tls_cert")
+	tls_key := q.Get("tls_key")
+
+	if tls_cert != "" || tls_key != "" {
+
+		creds, err := credentials.NewServerTLSFromFile(tls_cert, tls_key)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to load TLS credentials, %w", err)
+		}
+
+		options = append(options, grpc.Creds(creds))
+	}
+
+	s := &grpcServer{
+		address: u.Host,
+		options: options,
+	}
+
+	return s, nil
+}
+
+// AddGRPCServer() configures 'd' to start a gRPC server, on a separate port, in addition to its HTTP server. Messages delivered
+// to the gRPC server are processed by the same handler as HTTP requests. 'uri' is expected to take the form of:
+//
+//	grpc://{HOST}:{PORT}?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `max_concurrent_streams={INT}` The maximum number of messages that will be processed concurrently for each client connection.
+// * `max_message_size={INT}` The maximum size, in bytes, of a message. Default is 4194304.
+// * `tls_cert={PATH}` and `tls_key={PATH}` The paths to a TLS certificate and key used to serve requests over TLS.
+func (d *WebhookDaemon) AddGRPCServer(ctx context.Context, uri string) error {
+
+	s, err := newGRPCServer(ctx, uri)
+
+	if err != nil {
+		return err
+	}
+
+	d.grpc = s
+	return nil
+}
+
+// startGRPC starts listening for gRPC requests, if 'd' has been configured to do so, delivering messages to 'handler'. It returns
+// a function used to stop the gRPC server.
+func (d *WebhookDaemon) startGRPC(handler http.Handler, logger *log.Logger) (func(), error) {
+
+	if d.grpc == nil {
+		return func() {}, nil
+	}
+
+	svr, err := newGRPCServerWithHandler(d.grpc, handler, logger)
+
+	if err != nil {
+		return nil, err
+	}
+
+	l, err := net.Listen("tcp", d.grpc.address)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to listen for gRPC requests, %w", err)
+	}
+
+	aa_log.Info(logger, "Webhookd listening for gRPC requests on %s", l.Addr().String())
+
+	go func() {
+
+		err := svr.Serve(l)
+
+		if err != nil {
+			aa_log.Error(logger, "gRPC server stopped, %v", err)
+		}
+	}()
+
+	return svr.GracefulStop, nil
+}
+
+// newGRPCServerWithHandler returns a new `grpc.Server` instance, configured by 's', that delivers messages to 'handler'.
+func newGRPCServerWithHandler(s *grpcServer, handler http.Handler, logger *log.Logger) (*grpc.Server, error) {
+
+	req_desc, rsp_desc, err := grpcDescriptors()
+
+	if err != nil {
+		return nil, err
+	}
+
+	deliver := func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+
+		req := dynamicpb.NewMessage(req_desc)
+
+		err := dec(req)
+
+		if err != nil {
+			return nil, err
+		}
+
+		h := srv.(grpcDeliverer)
+
+		if interceptor == nil {
+			return h.deliver(ctx, req)
+		}
+
+		info := &grpc.UnaryServerInfo{
+			Server:     srv,
+			FullMethod: GRPC_DELIVER_METHOD,
+		}
+
+		fn := func(ctx context.Context, req interface{}) (interface{}, error) {
+			return h.deliver(ctx, req.(*dynamicpb.Message))
+		}
+
+		return interceptor(ctx, req, info, fn)
+	}
+
+	desc := &grpc.ServiceDesc{
+		ServiceName: "webhookd.v1.Webhookd",
+		HandlerType: (*grpcDeliverer)(nil),
+		Methods: []grpc.MethodDesc{
+			{
+				MethodName: "Deliver",
+				Handler:    deliver,
+			},
+		},
+		Metadata: "webhookd.proto",
+	}
+
+	svr := grpc.NewServer(s.options...)
+
+	h := &grpcDeliverHandler{
+		handler:  handler,
+		logger:   logger,
+		response: rsp_desc,
+	}
+
+	svr.RegisterService(desc, h)
+	return svr, nil
+}
+
+// deliver processes the `DeliverRequest` message 'req' using the HTTP handler for 'h' returning a `DeliverResponse` message.
+// HTTP error responses are returned as gRPC errors.
+func (h *grpcDeliverHandler) deliver(ctx context.Context, req *dynamicpb.Message) (*dynamicpb.Message, error) {
+
+	fields := req.Descriptor().Fields()
+
+	endpoint := req.Get(fields.ByName("endpoint")).String()
+	body := req.Get(fields.ByName("body")).Bytes()
+
+	if !strings.HasPrefix(endpoint, "/") {
+		return nil, status.Error(codes.InvalidArgument, "Invalid endpoint")
+	}
+
+	http_req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid endpoint, %v", err)
+	}
+
+	req.Get(fields.ByName("headers")).Map().Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
+		http_req.Header.Add(k.String(), v.String())
+		return true
+	})
+
+	http_req.ContentLength = int64(len(body))
+
+	p, ok := peer.FromContext(ctx)
+
+	if ok && p.Addr != nil {
+		http_req.RemoteAddr = p.Addr.String()
+	}
+
+	w := &bufferedResponseWriter{
+		header: http.Header{},
+		body:   new(bytes.Buffer),
+	}
+
+	h.handler.ServeHTTP(w, http_req)
+
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	if w.status >= 400 {
+		aa_log.Debug(h.logger, "gRPC delivery to %s failed with status %d", endpoint, w.status)
+		return nil, status.Error(grpcCode(w.status), strings.TrimSpace(w.body.String()))
+	}
+
+	rsp := dynamicpb.NewMessage(h.response)
+	rsp_fields := h.response.Fields()
+
+	rsp.Set(rsp_fields.ByName("status"), protoreflect.ValueOfInt32(int32(w.status)))
+	rsp.Set(rsp_fields.ByName("body"), protoreflect.ValueOfBytes(w.body.Bytes()))
+
+	headers := rsp.Mutable(rsp_fields.ByName("headers")).Map()
+
+	for k, v := range w.header {
+		headers.Set(protoreflect.ValueOfString(k).MapKey(), protoreflect.ValueOfString(strings.Join(v, ", ")))
+	}
+
+	return rsp, nil
+}
+
+// grpcCode returns the gRPC status code corresponding to the HTTP status code 'status'.
+func grpcCode(status int) codes.Code {
+
+	switch status {
+	case http.StatusBadRequest, http.StatusRequestEntityTooLarge:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	}
+
+	if status >= 500 {
+		return codes.Internal
+	}
+
+	return codes.FailedPrecondition
+}
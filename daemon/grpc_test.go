@@ -0,0 +1,168 @@
+package daemon
+
+import (
+	"context"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/whosonfirst/go-webhookd/v3/config"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func TestGRPCDeliver(t *testing.T) {
+
+	ctx := context.Background()
+
+	cfg := &config.WebhookConfig{
+		Daemon: "http://localhost:8081",
+		GRPC:   "grpc://localhost:8091?max_concurrent_streams=10",
+		Receivers: map[string]string{
+			"insecure": "insecure://",
+		},
+		Dispatchers: map[string]string{
+			"null": "null://",
+		},
+		Webhooks: []config.WebhookWebhooksConfig{
+			{
+				Endpoint:    "/repos/{owner}",
+				Receiver:    "insecure",
+				Dispatchers: []string{"null"},
+				Response: &config.WebhookResponseConfig{
+					Status:      http.StatusAccepted,
+					Body:        `{"id":"{{.DeliveryID}}","owner":"{{index .Params "owner"}}"}`,
+					ContentType: "application/json",
+				},
+			},
+		},
+	}
+
+	d, err := NewWebhookDaemonFromConfig(ctx, cfg)
+
+	if err != nil {
+		t.Fatalf("Failed to create new daemon from config, %v", err)
+	}
+
+	if d.grpc == nil || d.grpc.address != "localhost:8091" {
+		t.Fatalf("Expected daemon to be configured with a gRPC server")
+	}
+
+	logger := log.New(os.Stderr, "", 0)
+
+	handler, err := d.HandlerFuncWithLogger(logger)
+
+	if err != nil {
+		t.Fatalf("Failed to create handler func, %v", err)
+	}
+
+	svr, err := newGRPCServerWithHandler(d.grpc, handler, logger)
+
+	if err != nil {
+		t.Fatalf("Failed to create gRPC server, %v", err)
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatalf("Failed to create listener, %v", err)
+	}
+
+	go svr.Serve(l)
+	defer svr.Stop()
+
+	conn, err := grpc.Dial(l.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+
+	if err != nil {
+		t.Fatalf("Failed to dial gRPC server, %v", err)
+	}
+
+	defer conn.Close()
+
+	req_desc, rsp_desc, err := grpcDescriptors()
+
+	if err != nil {
+		t.Fatalf("Failed to derive descriptors, %v", err)
+	}
+
+	deliver := func(endpoint string) (*dynamicpb.Message, error) {
+
+		fields := req_desc.Fields()
+
+		req := dynamicpb.NewMessage(req_desc)
+		req.Set(fields.ByName("endpoint"), protoreflect.ValueOfString(endpoint))
+		req.Set(fields.ByName("body"), protoreflect.ValueOfBytes([]byte("hello world")))
+
+		headers := req.Mutable(fields.ByName("headers")).Map()
+		headers.Set(protoreflect.ValueOfString("X-Request-Id").MapKey(), protoreflect.ValueOfString("1234"))
+
+		rsp := dynamicpb.NewMessage(rsp_desc)
+
+		err := conn.Invoke(ctx, GRPC_DELIVER_METHOD, req, rsp)
+		return rsp, err
+	}
+
+	rsp, err := deliver("/repos/whosonfirst")
+
+	if err != nil {
+		t.Fatalf("Failed to deliver message, %v", err)
+	}
+
+	fields := rsp_desc.Fields()
+
+	if rsp.Get(fields.ByName("status")).Int() != http.StatusAccepted {
+		t.Fatalf("Unexpected status: %d", rsp.Get(fields.ByName("status")).Int())
+	}
+
+	if string(rsp.Get(fields.ByName("body")).Bytes()) != `{"id":"1234","owner":"whosonfirst"}` {
+		t.Fatalf("Unexpected body: %s", rsp.Get(fields.ByName("body")).Bytes())
+	}
+
+	content_type := rsp.Get(fields.ByName("headers")).Map().Get(protoreflect.ValueOfString("Content-Type").MapKey())
+
+	if content_type.String() != "application/json" {
+		t.Fatalf("Unexpected content type: %s", content_type.String())
+	}
+
+	tests := map[string]codes.Code{
+		"/missing": codes.NotFound,
+		"missing":  codes.InvalidArgument,
+	}
+
+	for endpoint, code := range tests {
+
+		_, err := deliver(endpoint)
+
+		if status.Code(err) != code {
+			t.Fatalf("Expected %s for %s but got %v", code, endpoint, err)
+		}
+	}
+}
+
+func TestNewGRPCServerInvalid(t *testing.T) {
+
+	ctx := context.Background()
+
+	tests := []string{
+		"http://localhost:8091",
+		"grpc://",
+		"grpc://localhost:8091?max_concurrent_streams=0",
+		"grpc://localhost:8091?max_message_size=big",
+		"grpc://localhost:8091?tls_cert=/missing/cert.pem",
+	}
+
+	for _, uri := range tests {
+
+		_, err := newGRPCServer(ctx, uri)
+
+		if err == nil {
+			t.Fatalf("Expected %s to fail", uri)
+		}
+	}
+}
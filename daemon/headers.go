@@ -0,0 +1,120 @@
+package daemon
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+// DEFAULT_RESPONSE_HEADER_PREFIX is the default prefix for the names of the `webhookd`-specific headers added to webhook responses.
+const DEFAULT_RESPONSE_HEADER_PREFIX string = "X-Webhookd-"
+
+// Possible values for the first element of the "{PREFIX}Outcome" response header.
+const (
+	OUTCOME_DISPATCHED string = "dispatched"
+	OUTCOME_UNHANDLED  string = "unhandled"
+	OUTCOME_HALTED     string = "halted"
+	OUTCOME_FAILED     string = "failed"
+	OUTCOME_DRYRUN     string = "dryrun"
+	OUTCOME_DUPLICATE  string = "duplicate"
+)
+
+// ResponseHeaders defines which `webhookd`-specific headers are added to webhook responses, and what they are called. Some
+// upstream providers log the response headers for webhook deliveries so these headers should only expose what is necessary.
+type ResponseHeaders struct {
+	// Prefix is the prefix for the names of all the headers. Default is "X-Webhookd-".
+	Prefix string
+	// Timing enables the "{PREFIX}Time-To-Receive", "{PREFIX}Time-To-Transform", "{PREFIX}Time-To-Dispatch" and
+	// "{PREFIX}Time-To-Process" headers. Default is true.
+	Timing bool
+	// DeliveryID enables the "{PREFIX}Delivery-Id" header containing the unique identifier for a delivery. Default is false.
+	DeliveryID bool
+	// Outcome enables the "{PREFIX}Outcome" header summarizing how a message was processed, for example
+	// "dispatched; messages=2; dispatches=4" or "failed; step=transformation". Default is false.
+	Outcome bool
+}
+
+// NewResponseHeaders returns a new `ResponseHeaders` instance with the default settings.
+func NewResponseHeaders() *ResponseHeaders {
+
+	h := &ResponseHeaders{
+		Prefix: DEFAULT_RESPONSE_HEADER_PREFIX,
+		Timing: true,
+	}
+
+	return h
+}
+
+// setTiming sets the "{PREFIX}Time-To-{STEP}" header to 'd', if timing headers are enabled.
+func (h *ResponseHeaders) setTiming(rsp http.ResponseWriter, step string, d time.Duration) {
+
+	if !h.Timing {
+		return
+	}
+
+	rsp.Header().Set(h.Prefix+"Time-To-"+step, d.String())
+}
+
+// setDeliveryID sets the "{PREFIX}Delivery-Id" header to 'delivery_id', if delivery ID headers are enabled.
+func (h *ResponseHeaders) setDeliveryID(rsp http.ResponseWriter, delivery_id string) {
+
+	if !h.DeliveryID {
+		return
+	}
+
+	rsp.Header().Set(h.Prefix+"Delivery-Id", delivery_id)
+}
+
+// setOutcome sets the "{PREFIX}Outcome" header to 'outcome' followed by any 'properties' (in the form of "{KEY}={VALUE}"),
+// if outcome headers are enabled.
+func (h *ResponseHeaders) setOutcome(rsp http.ResponseWriter, outcome string, properties ...string) {
+
+	if !h.Outcome {
+		return
+	}
+
+	parts := append([]string{outcome}, properties...)
+	rsp.Header().Set(h.Prefix+"Outcome", strings.Join(parts, "; "))
+}
+
+// setDispatchOutcome sets the "{PREFIX}Outcome" header to 'outcome' followed by the number of messages, the number of dispatches and,
+// if there were any, the number of failures in 'summary', if outcome headers are enabled.
+func (h *ResponseHeaders) setDispatchOutcome(rsp http.ResponseWriter, outcome string, messages int, summary *dispatchSummary) {
+
+	if !h.Outcome {
+		return
+	}
+
+	properties := []string{
+		"messages=" + strconv.Itoa(messages),
+		"dispatches=" + strconv.Itoa(summary.dispatches),
+	}
+
+	if outcome == OUTCOME_FAILED {
+		properties = append([]string{"step=dispatch"}, properties...)
+	}
+
+	if outcome == OUTCOME_FAILED || summary.failures > 0 {
+		properties = append(properties, "failures="+strconv.Itoa(summary.failures))
+	}
+
+	h.setOutcome(rsp, outcome, properties...)
+}
+
+// setErrorOutcome sets the "{PREFIX}Outcome" header for 'err', returned by 'step', if outcome headers are enabled.
+func (h *ResponseHeaders) setErrorOutcome(rsp http.ResponseWriter, step string, err *webhookd.WebhookError) {
+
+	outcome := OUTCOME_FAILED
+
+	switch err.Code {
+	case webhookd.UnhandledEvent:
+		outcome = OUTCOME_UNHANDLED
+	case webhookd.HaltEvent:
+		outcome = OUTCOME_HALTED
+	}
+
+	h.setOutcome(rsp, outcome, "step="+step)
+}
@@ -0,0 +1,95 @@
+package daemon
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/whosonfirst/go-webhookd/v3/config"
+)
+
+func TestResponseHeaders(t *testing.T) {
+
+	ctx := context.Background()
+
+	tests := map[string]map[string]string{
+		"http://localhost:8081": {
+			"X-Webhookd-Time-To-Process": "*",
+			"X-Webhookd-Delivery-Id":     "",
+			"X-Webhookd-Outcome":         "",
+		},
+		"http://localhost:8081?timing_headers=false&delivery_id_header=true&outcome_header=true&response_header_prefix=X-Hooks-": {
+			"X-Webhookd-Time-To-Process": "",
+			"X-Hooks-Time-To-Process":    "",
+			"X-Hooks-Delivery-Id":        "1234",
+			"X-Hooks-Outcome":            "dispatched; messages=1; dispatches=2",
+		},
+	}
+
+	for uri, expected := range tests {
+
+		cfg := &config.WebhookConfig{
+			Daemon: uri,
+			Receivers: map[string]string{
+				"insecure": "insecure://",
+			},
+			Dispatchers: map[string]string{
+				"null": "null://",
+			},
+			Webhooks: []config.WebhookWebhooksConfig{
+				{
+					Endpoint:    "/insecure",
+					Receiver:    "insecure",
+					Dispatchers: []string{"null", "null"},
+				},
+			},
+		}
+
+		d, err := NewWebhookDaemonFromConfig(ctx, cfg)
+
+		if err != nil {
+			t.Fatalf("Failed to create new daemon from config, %v", err)
+		}
+
+		handler, err := d.HandlerFunc()
+
+		if err != nil {
+			t.Fatalf("Failed to create handler func, %v", err)
+		}
+
+		req := httptest.NewRequest("POST", "/insecure", strings.NewReader("hello world"))
+		req.Header.Set("X-Request-Id", "1234")
+
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Unexpected status for %s: %d", uri, rec.Code)
+		}
+
+		for k, v := range expected {
+
+			switch v {
+			case "*":
+
+				if rec.Header().Get(k) == "" {
+					t.Fatalf("Expected %s header for %s", k, uri)
+				}
+
+			default:
+
+				if rec.Header().Get(k) != v {
+					t.Fatalf("Unexpected %s header for %s: '%s'", k, uri, rec.Header().Get(k))
+				}
+			}
+		}
+	}
+
+	_, err := NewWebhookDaemon(ctx, "http://localhost:8081?outcome_header=maybe")
+
+	if err == nil {
+		t.Fatalf("Expected invalid ?outcome_header parameter to fail")
+	}
+}
@@ -0,0 +1,98 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/whosonfirst/go-webhookd/v3/config"
+)
+
+func TestMethodNotAllowed(t *testing.T) {
+
+	ctx := context.Background()
+
+	root := t.TempDir()
+
+	cfg := &config.WebhookConfig{
+		Daemon: "http://localhost:8081",
+		Receivers: map[string]string{
+			"insecure": "insecure://",
+		},
+		Dispatchers: map[string]string{
+			"null": "null://",
+			"file": fmt.Sprintf("file://%s?extension=txt", root),
+		},
+		Webhooks: []config.WebhookWebhooksConfig{
+			{
+				Endpoint:    "/default",
+				Receiver:    "insecure",
+				Dispatchers: []string{"null"},
+			},
+			{
+				Endpoint:    "/custom",
+				Receiver:    "insecure",
+				Dispatchers: []string{"file"},
+				Methods:     []string{"post", "put"},
+			},
+		},
+	}
+
+	d, err := NewWebhookDaemonFromConfig(ctx, cfg)
+
+	if err != nil {
+		t.Fatalf("Failed to create new daemon from config, %v", err)
+	}
+
+	handler, err := d.HandlerFunc()
+
+	if err != nil {
+		t.Fatalf("Failed to create handler func, %v", err)
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+		allow  string
+		files  int
+	}{
+		{"GET", "/default", http.StatusMethodNotAllowed, "POST", 0},
+		{"POST", "/default", http.StatusOK, "", 0},
+		{"PUT", "/default", http.StatusMethodNotAllowed, "POST", 0},
+		{"DELETE", "/custom", http.StatusMethodNotAllowed, "POST, PUT", 0},
+		{"POST", "/custom", http.StatusOK, "", 1},
+		// Methods other than POST are passed to the receiver and dispatched
+		{"PUT", "/custom", http.StatusOK, "", 2},
+	}
+
+	for _, test := range tests {
+
+		req := httptest.NewRequest(test.method, test.path, strings.NewReader("hello world"))
+		rec := httptest.NewRecorder()
+
+		handler(rec, req)
+
+		if rec.Code != test.status {
+			t.Fatalf("Unexpected status for %s %s: %d", test.method, test.path, rec.Code)
+		}
+
+		if rec.Header().Get("Allow") != test.allow {
+			t.Fatalf("Unexpected Allow header for %s %s: '%s'", test.method, test.path, rec.Header().Get("Allow"))
+		}
+
+		matches, err := filepath.Glob(filepath.Join(root, "*.txt"))
+
+		if err != nil {
+			t.Fatalf("Failed to glob output, %v", err)
+		}
+
+		if len(matches) != test.files {
+			t.Fatalf("Unexpected number of dispatched messages after %s %s: %d", test.method, test.path, len(matches))
+		}
+	}
+}
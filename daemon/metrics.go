@@ -0,0 +1,402 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Valid protocols for metrics emitters.
+const (
+	METRICS_STATSD    string = "statsd"
+	METRICS_DOGSTATSD string = "dogstatsd"
+)
+
+// DEFAULT_METRICS_ADDRESS is the default address of the statsd (or DogStatsD) server that metrics are sent to.
+const DEFAULT_METRICS_ADDRESS string = "localhost:8125"
+
+// DEFAULT_METRICS_PREFIX is the default prefix for the names of metrics.
+const DEFAULT_METRICS_PREFIX string = "webhookd."
+
+// DEFAULT_METRICS_FLUSH_INTERVAL is the default interval at which buffered metrics are sent.
+const DEFAULT_METRICS_FLUSH_INTERVAL time.Duration = 1 * time.Second
+
+// DEFAULT_METRICS_MAX_PACKET_SIZE is the default maximum size, in bytes, of a single packet of metrics. It is small enough
+// to avoid fragmentation on networks with a standard MTU.
+const DEFAULT_METRICS_MAX_PACKET_SIZE int = 1432
+
+// metricsEmitter pushes metrics for each webhook request to a statsd or DogStatsD server.
+type metricsEmitter struct {
+	// protocol is one of `METRICS_STATSD` or `METRICS_DOGSTATSD`.
+	protocol string
+	// conn is the (UDP or Unix datagram) connection that metrics are written to.
+	conn net.Conn
+	// prefix is the prefix for the names of metrics.
+	prefix string
+	// tags is the list of "{KEY}:{VALUE}" tags added to every metric, if the protocol supports them.
+	tags []string
+	// flush_interval is the interval at which buffered metrics are sent. If 0 metrics are sent as they are recorded.
+	flush_interval time.Duration
+	// max_packet_size is the maximum size, in bytes, of a single packet of metrics.
+	max_packet_size int
+	// buf is the buffer of metrics waiting to be sent.
+	buf *bytes.Buffer
+	// mu is a `sync.Mutex` used to guard 'buf'.
+	mu *sync.Mutex
+}
+
+// newMetricsEmitter returns a new `metricsEmitter` instance derived from 'uri'. See `AddMetrics` for details.
+func newMetricsEmitter(ctx context.Context, uri string) (*metricsEmitter, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse metrics URI, %w", err)
+	}
+
+	if u.Scheme != "metrics" {
+		return nil, fmt.Errorf("Invalid scheme '%s'", u.Scheme)
+	}
+
+	q := u.Query()
+
+	m := &metricsEmitter{
+		protocol:        METRICS_STATSD,
+		prefix:          DEFAULT_METRICS_PREFIX,
+		tags:            make([]string, 0),
+		flush_interval:  DEFAULT_METRICS_FLUSH_INTERVAL,
+		max_packet_size: DEFAULT_METRICS_MAX_PACKET_SIZE,
+		buf:             new(bytes.Buffer),
+		mu:              new(sync.Mutex),
+	}
+
+	str_protocol := q.Get("protocol")
+
+	switch str_protocol {
+	case "":
+		// pass
+	case METRICS_STATSD, METRICS_DOGSTATSD:
+		m.protocol = str_protocol
+	default:
+		return nil, fmt.Errorf("Invalid ?protocol= parameter '%s'", str_protocol)
+	}
+
+	if q.Has("prefix") {
+		m.prefix = q.Get("prefix")
+	}
+
+	for _, str_tags := range q["tag"] {
+
+		for _, t := range strings.Split(str_tags, ",") {
+
+			t = strings.TrimSpace(t)
+
+			if t == "" {
+				continue
+			}
+
+			m.tags = append(m.tags, sanitizeMetricsTag(t))
+		}
+	}
+
+	if len(m.tags) > 0 && m.protocol != METRICS_DOGSTATSD {
+		return nil, fmt.Errorf("?tag= parameters are only supported by the %s protocol", METRICS_DOGSTATSD)
+	}
+
+	str_interval := q.Get("flush_interval")
+
+	if str_interval != "" {
+
+		d, err := time.ParseDuration(str_interval)
+
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("Invalid ?flush_interval= parameter '%s'", str_interval)
+		}
+
+		m.flush_interval = d
+	}
+
+	str_size := q.Get("max_packet_size")
+
+	if str_size != "" {
+
+		sz, err := strconv.Atoi(str_size)
+
+		if err != nil || sz < 1 {
+			return nil, fmt.Errorf("Invalid ?max_packet_size= parameter '%s'", str_size)
+		}
+
+		m.max_packet_size = sz
+	}
+
+	network := "udp"
+	address := u.Host
+
+	if address == "" && u.Path != "" {
+		network = "unixgram"
+		address = u.Path
+	}
+
+	if address == "" {
+		address = DEFAULT_METRICS_ADDRESS
+	}
+
+	conn, err := net.Dial(network, address)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to dial metrics server, %w", err)
+	}
+
+	m.conn = conn
+	return m, nil
+}
+
+// AddMetrics() configures 'd' to push metrics for each webhook request to a statsd or DogStatsD server. 'uri' is expected to
+// take the form of:
+//
+//	metrics://{HOST}:{PORT}?{PARAMETERS}
+//	metrics://{PATH_TO_UNIX_SOCKET}?{PARAMETERS}
+//
+// If no host or path is specified metrics are sent to "localhost:8125". Valid {PARAMETERS} are:
+// * `protocol={STRING}` The protocol used to send metrics, one of "statsd" or "dogstatsd". Default is "statsd".
+// * `prefix={STRING}` The prefix for the names of metrics. Default is "webhookd.".
+// * `tag={KEY}:{VALUE}` Zero or more (comma-separated or repeated) tags to add to every metric. Only supported by the "dogstatsd" protocol.
+// * `flush_interval={DURATION}` The interval at which buffered metrics are sent. If "0" metrics are sent as they are recorded. Default is "1s".
+// * `max_packet_size={INT}` The maximum size, in bytes, of a single packet of metrics. Default is 1432.
+func (d *WebhookDaemon) AddMetrics(ctx context.Context, uri string) error {
+
+	m, err := newMetricsEmitter(ctx, uri)
+
+	if err != nil {
+		return err
+	}
+
+	d.metrics = m
+	return nil
+}
+
+// start sends buffered metrics every flush interval until 'ctx' is cancelled or the returned function is called, after which
+// any remaining metrics are sent. It is safe to call on a nil instance.
+func (m *metricsEmitter) start(ctx context.Context) func() {
+
+	if m == nil || m.flush_interval == 0 {
+		return func() {}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan bool)
+
+	go func() {
+
+		defer close(done)
+
+		ticker := time.NewTicker(m.flush_interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				m.flush()
+				return
+			case <-ticker.C:
+				m.flush()
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// record emits the metrics for 'entry'. The "{KEY}:{VALUE}" pairs for 'labels', the labels of the webhook that processed
+// 'entry', and 'tenant', the tenant that webhook belongs to, are added as tags to each metric if the protocol supports them.
+// It is safe to call on a nil instance.
+func (m *metricsEmitter) record(entry *accessLogEntry, labels map[string]string, tenant string) {
+
+	if m == nil {
+		return
+	}
+
+	status := strconv.Itoa(entry.Status)
+	webhook_tags := append([]string{"endpoint:" + entry.Endpoint, "tenant:" + tenant}, sortedLabels(labels)...)
+
+	if m.protocol == METRICS_DOGSTATSD {
+		m.emit("requests", "1", "c", append(webhook_tags, "event_type:"+entry.EventType, "status:"+status)...)
+	} else {
+		m.emit(m.tenantName(tenant, "requests"), "1", "c")
+		m.emit(m.tenantName(tenant, "requests.status."+status), "1", "c")
+	}
+
+	for _, stage := range []string{"receive", "transform", "dispatch", "process"} {
+
+		d, ok := entry.Timings[stage]
+
+		if !ok {
+			continue
+		}
+
+		ms := strconv.FormatFloat(float64(d.Microseconds())/1000.0, 'f', -1, 64)
+		m.emit(m.tenantName(tenant, stage+".time"), ms, "ms", webhook_tags...)
+	}
+
+	for _, r := range entry.Dispatchers {
+
+		if m.protocol == METRICS_DOGSTATSD {
+			m.emit("dispatches", "1", "c", append(webhook_tags, "dispatcher:"+r.Dispatcher, "outcome:"+r.Outcome)...)
+		} else {
+			m.emit(m.tenantName(tenant, "dispatches"), "1", "c")
+			m.emit(m.tenantName(tenant, "dispatches.outcome."+r.Outcome), "1", "c")
+		}
+	}
+}
+
+// recordPanic emits a metric for a panic recovered from processing 'step' for the webhook 'endpoint', which belongs to 'tenant'.
+// It is safe to call on a nil instance.
+func (m *metricsEmitter) recordPanic(step string, endpoint string, tenant string) {
+
+	if m == nil {
+		return
+	}
+
+	if m.protocol == METRICS_DOGSTATSD {
+		m.emit("panics", "1", "c", "endpoint:"+endpoint, "tenant:"+tenant, "step:"+step)
+	} else {
+		m.emit(m.tenantName(tenant, "panics"), "1", "c")
+	}
+}
+
+// recordStale emits a metric for a message rejected by the receiver for the webhook 'endpoint', which belongs to 'tenant', because
+// its timestamp was outside of the allowed window. It is safe to call on a nil instance.
+func (m *metricsEmitter) recordStale(endpoint string, tenant string) {
+
+	if m == nil {
+		return
+	}
+
+	if m.protocol == METRICS_DOGSTATSD {
+		m.emit("receive.stale", "1", "c", "endpoint:"+endpoint, "tenant:"+tenant)
+	} else {
+		m.emit(m.tenantName(tenant, "receive.stale"), "1", "c")
+	}
+}
+
+// tenantName returns the name of the metric 'name' for webhooks belonging to 'tenant'. Since the statsd protocol does not support
+// tags the metrics for each tenant are emitted with a "tenants.{TENANT}." prefix so that they are isolated from each other. For
+// DogStatsD, or if 'tenant' is empty, 'name' is returned unchanged.
+func (m *metricsEmitter) tenantName(tenant string, name string) string {
+
+	if tenant == "" || m.protocol == METRICS_DOGSTATSD {
+		return name
+	}
+
+	return "tenants." + tenant + "." + name
+}
+
+// emit buffers (or sends, if there is no flush interval) the metric 'name' with 'value' and 'kind' (for example "c" or "ms")
+// and, if the protocol supports them, 'tags' in addition to the default tags. Tags with empty values are omitted.
+func (m *metricsEmitter) emit(name string, value string, kind string, tags ...string) {
+
+	var line bytes.Buffer
+
+	line.WriteString(sanitizeMetricsName(m.prefix + name))
+	line.WriteString(":")
+	line.WriteString(value)
+	line.WriteString("|")
+	line.WriteString(kind)
+
+	if m.protocol == METRICS_DOGSTATSD {
+
+		all_tags := append([]string{}, m.tags...)
+
+		for _, t := range tags {
+
+			if strings.HasSuffix(t, ":") {
+				continue
+			}
+
+			all_tags = append(all_tags, sanitizeMetricsTag(t))
+		}
+
+		if len(all_tags) > 0 {
+			line.WriteString("|#")
+			line.WriteString(strings.Join(all_tags, ","))
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.buf.Len() > 0 && m.buf.Len()+1+line.Len() > m.max_packet_size {
+		m.send()
+	}
+
+	if m.buf.Len() > 0 {
+		m.buf.WriteString("\n")
+	}
+
+	m.buf.Write(line.Bytes())
+
+	if m.flush_interval == 0 {
+		m.send()
+	}
+}
+
+// flush sends any buffered metrics.
+func (m *metricsEmitter) flush() {
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.send()
+}
+
+// send writes the buffered metrics as a single packet and resets the buffer. Metrics are sent on a best-effort basis so write
+// errors are ignored. The caller is expected to hold 'mu'.
+func (m *metricsEmitter) send() {
+
+	if m.buf.Len() == 0 {
+		return
+	}
+
+	m.conn.Write(m.buf.Bytes())
+	m.buf.Reset()
+}
+
+// sanitizeMetricsName replaces the characters in 'name' that are reserved by the statsd protocol with underscores.
+func sanitizeMetricsName(name string) string {
+	return strings.NewReplacer(":", "_", "|", "_", "@", "_", "\n", "_", " ", "_").Replace(name)
+}
+
+// sanitizeMetricsTag replaces the characters in 't' that are reserved by the DogStatsD protocol with underscores.
+func sanitizeMetricsTag(t string) string {
+	return strings.NewReplacer(",", "_", "|", "_", "#", "_", "\n", "_", " ", "_").Replace(t)
+}
+
+// sortedLabels returns the "{KEY}:{VALUE}" pairs for 'labels' sorted by key.
+func sortedLabels(labels map[string]string) []string {
+
+	keys := make([]string, 0, len(labels))
+
+	for k := range labels {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+
+	for i, k := range keys {
+		pairs[i] = k + ":" + labels[k]
+	}
+
+	return pairs
+}
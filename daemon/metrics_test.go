@@ -0,0 +1,244 @@
+package daemon
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/whosonfirst/go-webhookd/v3/config"
+)
+
+func TestMetrics(t *testing.T) {
+
+	ctx := context.Background()
+
+	tests := map[string][]string{
+		"protocol=dogstatsd&tag=env:test": {
+			"webhookd.requests:1|c|#env:test,endpoint:/foo,team:data,event_type:push,status:200",
+			"webhookd.dispatches:1|c|#env:test,endpoint:/foo,team:data,dispatcher:*dispatcher.NullDispatcher,outcome:dispatched",
+		},
+		"prefix=hooks.": {
+			"hooks.requests:1|c",
+			"hooks.requests.status.200:1|c",
+			"hooks.dispatches.outcome.dispatched:1|c",
+		},
+	}
+
+	for params, expected := range tests {
+
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+
+		if err != nil {
+			t.Fatalf("Failed to listen for packets, %v", err)
+		}
+
+		defer conn.Close()
+
+		cfg := &config.WebhookConfig{
+			Daemon:  "http://localhost:8081",
+			Metrics: "metrics://" + conn.LocalAddr().String() + "?" + params,
+			Receivers: map[string]string{
+				"insecure": "insecure://",
+			},
+			Dispatchers: map[string]string{
+				"null": "null://",
+			},
+			Webhooks: []config.WebhookWebhooksConfig{
+				{
+					Endpoint:    "/foo",
+					Receiver:    "insecure",
+					Dispatchers: []string{"null"},
+					Labels:      map[string]string{"team": "data"},
+				},
+			},
+		}
+
+		d, err := NewWebhookDaemonFromConfig(ctx, cfg)
+
+		if err != nil {
+			t.Fatalf("Failed to create new daemon from config, %v", err)
+		}
+
+		handler, err := d.HandlerFunc()
+
+		if err != nil {
+			t.Fatalf("Failed to create handler func, %v", err)
+		}
+
+		req := httptest.NewRequest("POST", "/foo", strings.NewReader("hello world"))
+		req.Header.Set("X-GitHub-Event", "push")
+
+		rec := httptest.NewRecorder()
+		d.accessLogHandler(nil, handler).ServeHTTP(rec, req)
+
+		d.metrics.flush()
+
+		buf := make([]byte, 2048)
+
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, _, err := conn.ReadFrom(buf)
+
+		if err != nil {
+			t.Fatalf("Failed to read metrics for %s, %v", params, err)
+		}
+
+		lines := strings.Split(string(buf[:n]), "\n")
+
+		for _, e := range expected {
+
+			found := false
+
+			for _, ln := range lines {
+
+				if ln == e {
+					found = true
+					break
+				}
+			}
+
+			if !found {
+				t.Fatalf("Missing metric '%s' for %s in %v", e, params, lines)
+			}
+		}
+
+		for _, ln := range lines {
+
+			if strings.Contains(ln, ".time:") && !strings.HasSuffix(strings.SplitN(ln, "|#", 2)[0], "|ms") {
+				t.Fatalf("Unexpected timing metric '%s'", ln)
+			}
+		}
+	}
+}
+
+func TestMetricsStale(t *testing.T) {
+
+	ctx := context.Background()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatalf("Failed to listen for packets, %v", err)
+	}
+
+	defer conn.Close()
+
+	cfg := &config.WebhookConfig{
+		Daemon:  "http://localhost:8081",
+		Metrics: "metrics://" + conn.LocalAddr().String() + "?protocol=dogstatsd",
+		Receivers: map[string]string{
+			"zoom": "zoom://?secret_token=s33kret&max_age=1m",
+		},
+		Dispatchers: map[string]string{
+			"null": "null://",
+		},
+		Webhooks: []config.WebhookWebhooksConfig{
+			{
+				Endpoint:    "/zoom",
+				Receiver:    "zoom",
+				Dispatchers: []string{"null"},
+			},
+		},
+	}
+
+	d, err := NewWebhookDaemonFromConfig(ctx, cfg)
+
+	if err != nil {
+		t.Fatalf("Failed to create new daemon from config, %v", err)
+	}
+
+	handler, err := d.HandlerFunc()
+
+	if err != nil {
+		t.Fatalf("Failed to create handler func, %v", err)
+	}
+
+	req := httptest.NewRequest("POST", "/zoom", strings.NewReader("{}"))
+	req.Header.Set("X-Zm-Signature", "v0=1234")
+	req.Header.Set("X-Zm-Request-Timestamp", strconv.FormatInt(time.Now().Add(-1*time.Hour).Unix(), 10))
+
+	rec := httptest.NewRecorder()
+	d.accessLogHandler(nil, handler).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("Unexpected status code %d", rec.Code)
+	}
+
+	d.metrics.flush()
+
+	buf := make([]byte, 2048)
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+
+	if err != nil {
+		t.Fatalf("Failed to read metrics, %v", err)
+	}
+
+	if !strings.Contains(string(buf[:n]), "webhookd.receive.stale:1|c|#endpoint:/zoom") {
+		t.Fatalf("Missing stale metric in %s", string(buf[:n]))
+	}
+}
+
+func TestMetricsMaxPacketSize(t *testing.T) {
+
+	ctx := context.Background()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatalf("Failed to listen for packets, %v", err)
+	}
+
+	defer conn.Close()
+
+	m, err := newMetricsEmitter(ctx, "metrics://"+conn.LocalAddr().String()+"?max_packet_size=40&flush_interval=1h")
+
+	if err != nil {
+		t.Fatalf("Failed to create metrics emitter, %v", err)
+	}
+
+	m.emit("one", "1", "c")
+	m.emit("two", "1", "c")
+	m.emit("three", "1", "c")
+
+	buf := make([]byte, 2048)
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+
+	if err != nil {
+		t.Fatalf("Failed to read metrics, %v", err)
+	}
+
+	if string(buf[:n]) != "webhookd.one:1|c\nwebhookd.two:1|c" {
+		t.Fatalf("Unexpected packet: %s", buf[:n])
+	}
+}
+
+func TestNewMetricsEmitterInvalid(t *testing.T) {
+
+	ctx := context.Background()
+
+	tests := []string{
+		"statsd://localhost:8125",
+		"metrics://localhost:8125?protocol=graphite",
+		"metrics://localhost:8125?tag=env:test",
+		"metrics://localhost:8125?flush_interval=soon",
+		"metrics://localhost:8125?max_packet_size=0",
+		"metrics:///missing/dsd.socket",
+	}
+
+	for _, uri := range tests {
+
+		_, err := newMetricsEmitter(ctx, uri)
+
+		if err == nil {
+			t.Fatalf("Expected %s to fail", uri)
+		}
+	}
+}
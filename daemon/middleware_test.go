@@ -0,0 +1,142 @@
+package daemon
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/whosonfirst/go-webhookd/v3/config"
+)
+
+func TestMiddleware(t *testing.T) {
+
+	ctx := context.Background()
+
+	cfg := &config.WebhookConfig{
+		Daemon: "http://localhost:8081?remote_address_header=X-Forwarded-For",
+		Receivers: map[string]string{
+			"insecure": "insecure://",
+		},
+		Dispatchers: map[string]string{
+			"null": "null://",
+		},
+		Middleware: map[string]string{
+			"ipfilter": "ipfilter://?deny=192.0.2.13",
+			"token":    "auth://?bearer=s33kret",
+		},
+		GlobalMiddleware: []string{"ipfilter"},
+		Webhooks: []config.WebhookWebhooksConfig{
+			{
+				Endpoint:    "/public",
+				Receiver:    "insecure",
+				Dispatchers: []string{"null"},
+			},
+			{
+				Endpoint:    "/private/{id}",
+				Receiver:    "insecure",
+				Dispatchers: []string{"null"},
+				Middleware:  []string{"token"},
+			},
+		},
+	}
+
+	d, err := NewWebhookDaemonFromConfig(ctx, cfg)
+
+	if err != nil {
+		t.Fatalf("Failed to create new daemon from config, %v", err)
+	}
+
+	handler, err := d.HandlerFunc()
+
+	if err != nil {
+		t.Fatalf("Failed to create handler func, %v", err)
+	}
+
+	tests := []struct {
+		path      string
+		forwarded string
+		auth      string
+		expected  int
+	}{
+		{"/public", "192.0.2.1", "", http.StatusOK},
+		{"/public", "192.0.2.13", "", http.StatusForbidden},
+		{"/private/1", "192.0.2.1", "", http.StatusUnauthorized},
+		{"/private/1", "192.0.2.1", "Bearer s33kret", http.StatusOK},
+		{"/private/1", "192.0.2.13", "Bearer s33kret", http.StatusForbidden},
+		{"/missing", "192.0.2.13", "", http.StatusForbidden},
+		{"/missing", "192.0.2.1", "", http.StatusNotFound},
+	}
+
+	for idx, test := range tests {
+
+		req := httptest.NewRequest("POST", test.path, strings.NewReader("hello world"))
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Set("X-Forwarded-For", test.forwarded)
+
+		if test.auth != "" {
+			req.Header.Set("Authorization", test.auth)
+		}
+
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+
+		if rec.Code != test.expected {
+			t.Fatalf("Unexpected status for test %d (%s): %d", idx, test.path, rec.Code)
+		}
+	}
+}
+
+func TestMiddlewareInvalidConfig(t *testing.T) {
+
+	ctx := context.Background()
+
+	tests := map[string]func(cfg *config.WebhookConfig){
+		"unknown global middleware": func(cfg *config.WebhookConfig) {
+			cfg.GlobalMiddleware = []string{"missing"}
+		},
+		"unknown webhook middleware": func(cfg *config.WebhookConfig) {
+			cfg.Webhooks[0].Middleware = []string{"missing"}
+		},
+		"invalid middleware": func(cfg *config.WebhookConfig) {
+			cfg.Middleware = map[string]string{"limit": "ratelimit://"}
+			cfg.Webhooks[0].Middleware = []string{"limit"}
+		},
+		"source middleware": func(cfg *config.WebhookConfig) {
+			cfg.Middleware = map[string]string{"limit": "ratelimit://?rate=1"}
+			cfg.Sources = map[string]string{"kafka": "kafka://localhost:9092/events"}
+			cfg.Webhooks[0].Receiver = ""
+			cfg.Webhooks[0].Source = "kafka"
+			cfg.Webhooks[0].Middleware = []string{"limit"}
+		},
+	}
+
+	for label, fn := range tests {
+
+		cfg := &config.WebhookConfig{
+			Daemon: "http://localhost:8081",
+			Receivers: map[string]string{
+				"insecure": "insecure://",
+			},
+			Dispatchers: map[string]string{
+				"null": "null://",
+			},
+			Webhooks: []config.WebhookWebhooksConfig{
+				{
+					Endpoint:    "/foo",
+					Receiver:    "insecure",
+					Dispatchers: []string{"null"},
+				},
+			},
+		}
+
+		fn(cfg)
+
+		_, err := NewWebhookDaemonFromConfig(ctx, cfg)
+
+		if err == nil {
+			t.Fatalf("Expected %s to fail", label)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package daemon
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	aa_log "github.com/aaronland/go-log/v2"
+	"github.com/whosonfirst/go-webhookd/v3/config"
+	"github.com/whosonfirst/go-webhookd/v3/openapi"
+)
+
+// openAPIEndpoint is the configuration for an optional endpoint serving an OpenAPI document describing the webhooks for a daemon.
+type openAPIEndpoint struct {
+	// path is the path the endpoint is installed at.
+	path string
+	// token is the optional bearer token that clients must present in an "Authorization" header.
+	token string
+	// body is the JSON-encoded `openapi.Document` served by the endpoint.
+	body []byte
+}
+
+// AddOpenAPIEndpoint() configures 'd' to install an endpoint, alongside its webhooks, serving an OpenAPI 3 document describing the
+// webhook endpoints defined in 'cfg' (see `openapi.NewDocumentFromConfig`) so that they can be consumed by API gateways and
+// internal service catalogs. Webhooks added by a controller are not included. 'uri' is expected to take the form of:
+//
+//	openapi://{PATH}?{PARAMETERS}
+//
+// Where {PATH} is the path the endpoint is installed at. Default is "/openapi.json". Valid {PARAMETERS} are:
+// * `token={TOKEN}` An optional bearer token that clients must present in an "Authorization" header.
+// * `title={STRING}` The title of the API. Default is "webhookd".
+// * `version={STRING}` The version of the API. Default is "1.0.0".
+// * `server_url={URL}` The URL of the `webhookd` server. Default is `cfg.PublicURL`, if present.
+func (d *WebhookDaemon) AddOpenAPIEndpoint(ctx context.Context, uri string, cfg *config.WebhookConfig) error {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return fmt.Errorf("Failed to parse OpenAPI URI, %w", err)
+	}
+
+	if u.Scheme != "openapi" {
+		return fmt.Errorf("Invalid OpenAPI URI scheme '%s'", u.Scheme)
+	}
+
+	if u.Host != "" {
+		return fmt.Errorf("OpenAPI URI should not define a host")
+	}
+
+	path := u.Path
+
+	if path == "" {
+		path = "/openapi.json"
+	}
+
+	_, exists := d.getWebhook(path)
+
+	if exists {
+		return fmt.Errorf("OpenAPI endpoint '%s' is already configured as a webhook", path)
+	}
+
+	if d.graphql != nil && d.graphql.path == path {
+		return fmt.Errorf("OpenAPI endpoint '%s' is already configured as the GraphQL endpoint", path)
+	}
+
+	q := u.Query()
+
+	opts := &openapi.Options{
+		Title:     q.Get("title"),
+		Version:   q.Get("version"),
+		ServerURL: q.Get("server_url"),
+	}
+
+	doc, err := openapi.NewDocumentFromConfig(ctx, cfg, opts)
+
+	if err != nil {
+		return fmt.Errorf("Failed to create OpenAPI document, %w", err)
+	}
+
+	body, err := json.Marshal(doc)
+
+	if err != nil {
+		return fmt.Errorf("Failed to encode OpenAPI document, %w", err)
+	}
+
+	d.openapi = &openAPIEndpoint{
+		path:  path,
+		token: q.Get("token"),
+		body:  body,
+	}
+
+	return nil
+}
+
+// openAPIHandlerWithLogger returns a `http.Handler` for the OpenAPI endpoint of 'd'.
+func (d *WebhookDaemon) openAPIHandlerWithLogger(logger *log.Logger) http.Handler {
+
+	e := d.openapi
+
+	fn := func(rsp http.ResponseWriter, req *http.Request) {
+
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			rsp.Header().Set("Allow", "GET, HEAD")
+			http.Error(rsp, "405 Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if e.token != "" {
+
+			token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+
+			if subtle.ConstantTimeCompare([]byte(token), []byte(e.token)) != 1 {
+				http.Error(rsp, "401 Unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+
+		rsp.Header().Set("Content-Type", "application/json")
+
+		_, err := rsp.Write(e.body)
+
+		if err != nil {
+			aa_log.Debug(logger, "Failed to write OpenAPI document, %v", err)
+		}
+	}
+
+	return http.HandlerFunc(fn)
+}
@@ -0,0 +1,102 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/whosonfirst/go-webhookd/v3/config"
+	"github.com/whosonfirst/go-webhookd/v3/openapi"
+)
+
+func TestOpenAPIEndpoint(t *testing.T) {
+
+	ctx := context.Background()
+
+	cfg := &config.WebhookConfig{
+		Daemon:  "http://localhost:8081",
+		OpenAPI: "openapi:///openapi.json?token=s33kret&title=hooks",
+		Receivers: map[string]string{
+			"insecure": "insecure://",
+		},
+		Dispatchers: map[string]string{
+			"null": "null://",
+		},
+		Webhooks: []config.WebhookWebhooksConfig{
+			{
+				Endpoint:    "/insecure",
+				Receiver:    "insecure",
+				Dispatchers: []string{"null"},
+			},
+		},
+	}
+
+	d, err := NewWebhookDaemonFromConfig(ctx, cfg)
+
+	if err != nil {
+		t.Fatalf("Failed to create new daemon from config, %v", err)
+	}
+
+	handler := d.openAPIHandlerWithLogger(log.New(io.Discard, "", 0))
+
+	tests := []struct {
+		method string
+		token  string
+		status int
+	}{
+		{http.MethodGet, "s33kret", http.StatusOK},
+		{http.MethodGet, "", http.StatusUnauthorized},
+		{http.MethodPost, "s33kret", http.StatusMethodNotAllowed},
+	}
+
+	for _, test := range tests {
+
+		req := httptest.NewRequest(test.method, "/openapi.json", nil)
+
+		if test.token != "" {
+			req.Header.Set("Authorization", "Bearer "+test.token)
+		}
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != test.status {
+			t.Fatalf("Expected status %d for %s request, got %d", test.status, test.method, rec.Code)
+		}
+
+		if rec.Code != http.StatusOK {
+			continue
+		}
+
+		var doc openapi.Document
+
+		err := json.Unmarshal(rec.Body.Bytes(), &doc)
+
+		if err != nil {
+			t.Fatalf("Failed to decode OpenAPI document, %v", err)
+		}
+
+		if doc.Info.Title != "hooks" || doc.Paths["/insecure"]["post"] == nil {
+			t.Fatalf("Unexpected OpenAPI document, %s", rec.Body.String())
+		}
+	}
+
+	invalid := []string{
+		"bogus://",
+		"openapi://example.com",
+		"openapi:///insecure",
+	}
+
+	for _, uri := range invalid {
+
+		err := d.AddOpenAPIEndpoint(ctx, uri, cfg)
+
+		if err == nil {
+			t.Fatalf("Expected OpenAPI URI '%s' to fail", uri)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package daemon
+
+import (
+	"context"
+	"testing"
+
+	"github.com/whosonfirst/go-webhookd/v3/receiver"
+	"github.com/whosonfirst/go-webhookd/v3/webhook"
+)
+
+func TestLookupWebhook(t *testing.T) {
+
+	ctx := context.Background()
+
+	d, err := NewWebhookDaemon(ctx, "http://localhost:8081")
+
+	if err != nil {
+		t.Fatalf("Failed to create new daemon, %v", err)
+	}
+
+	r, err := receiver.NewReceiver(ctx, "insecure://")
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	for _, endpoint := range []string{"/hooks/*", "/repos/{owner}/{repo}", "/repos/whosonfirst/special"} {
+
+		wh, err := webhook.NewWebhook(ctx, endpoint, r, nil, nil)
+
+		if err != nil {
+			t.Fatalf("Failed to create webhook for '%s', %v", endpoint, err)
+		}
+
+		err = d.AddWebhook(ctx, wh)
+
+		if err != nil {
+			t.Fatalf("Failed to add webhook for '%s', %v", endpoint, err)
+		}
+	}
+
+	tests := map[string]string{
+		"/repos/whosonfirst/special":     "/repos/whosonfirst/special",
+		"/repos/whosonfirst/go-webhookd": "/repos/{owner}/{repo}",
+		"/hooks/a/b":                     "/hooks/*",
+	}
+
+	for path, expected := range tests {
+
+		wh, _, ok := d.lookupWebhook(path)
+
+		if !ok {
+			t.Fatalf("Failed to find webhook for '%s'", path)
+		}
+
+		if wh.Endpoint() != expected {
+			t.Fatalf("Unexpected webhook for '%s': %s", path, wh.Endpoint())
+		}
+	}
+
+	_, params, _ := d.lookupWebhook("/repos/whosonfirst/go-webhookd")
+
+	if params["owner"] != "whosonfirst" || params["repo"] != "go-webhookd" {
+		t.Fatalf("Unexpected path parameters: %v", params)
+	}
+
+	_, _, ok := d.lookupWebhook("/users/whosonfirst")
+
+	if ok {
+		t.Fatalf("Expected '/users/whosonfirst' not to match")
+	}
+}
+
+func TestReplaceWebhook(t *testing.T) {
+
+	ctx := context.Background()
+
+	d, err := NewWebhookDaemon(ctx, "http://localhost:8081")
+
+	if err != nil {
+		t.Fatalf("Failed to create new daemon, %v", err)
+	}
+
+	r, err := receiver.NewReceiver(ctx, "insecure://")
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	wh, _ := webhook.NewWebhook(ctx, "/repos/{owner}", r, nil, nil)
+
+	err = d.ReplaceWebhook(ctx, wh)
+
+	if err == nil {
+		t.Fatalf("Expected replacing a missing webhook to fail")
+	}
+
+	err = d.AddWebhook(ctx, wh)
+
+	if err != nil {
+		t.Fatalf("Failed to add webhook, %v", err)
+	}
+
+	err = d.ReplaceWebhook(ctx, wh)
+
+	if err != nil {
+		t.Fatalf("Failed to replace webhook, %v", err)
+	}
+
+	if len(d.patterns) != 1 {
+		t.Fatalf("Expected replacing a webhook not to duplicate its pattern, %d", len(d.patterns))
+	}
+
+	_, _, ok := d.lookupWebhook("/repos/whosonfirst")
+
+	if !ok {
+		t.Fatalf("Failed to find replaced webhook")
+	}
+
+	err = d.RemoveWebhook(ctx, "/repos/{owner}")
+
+	if err != nil {
+		t.Fatalf("Failed to remove webhook, %v", err)
+	}
+
+	_, _, ok = d.lookupWebhook("/repos/whosonfirst")
+
+	if ok || len(d.patterns) != 0 {
+		t.Fatalf("Expected webhook and its pattern to be removed")
+	}
+
+	err = d.RemoveWebhook(ctx, "/repos/{owner}")
+
+	if err == nil {
+		t.Fatalf("Expected removing a missing webhook to fail")
+	}
+}
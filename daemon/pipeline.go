@@ -0,0 +1,116 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+	"github.com/whosonfirst/go-webhookd/v3/config"
+	"github.com/whosonfirst/go-webhookd/v3/transformation"
+)
+
+// newTransformationsFromConfig() returns the list of `webhookd.WebhookTransformation` instances for the transformation or pipeline
+// labels in 'names'. Labels starting with "#" are ignored. 'seen' is the list of pipeline labels currently being resolved and is
+// used to detect pipelines that reference themselves.
+func newTransformationsFromConfig(ctx context.Context, cfg *config.WebhookConfig, names []string, seen []string) ([]webhookd.WebhookTransformation, error) {
+
+	var steps []webhookd.WebhookTransformation
+
+	for _, name := range names {
+
+		if strings.HasPrefix(name, "#") {
+			continue
+		}
+
+		_, is_transformation := cfg.Transformations[name]
+		_, is_pipeline := cfg.Pipelines[name]
+
+		if is_transformation && is_pipeline {
+			return nil, fmt.Errorf("'%s' is defined as both a transformation and a pipeline", name)
+		}
+
+		if is_pipeline {
+
+			step, err := newPipelineFromConfig(ctx, cfg, name, seen)
+
+			if err != nil {
+				return nil, fmt.Errorf("Failed to create pipeline for '%s', %w", name, err)
+			}
+
+			steps = append(steps, step)
+			continue
+		}
+
+		transformation_uri, err := cfg.GetTransformationConfigByName(name)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to get transformation configuration for '%s', %w", name, err)
+		}
+
+		step, err := transformation.NewTransformation(ctx, transformation_uri)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to create new transformation for '%s', %w", transformation_uri, err)
+		}
+
+		steps = append(steps, step)
+	}
+
+	return steps, nil
+}
+
+// newPipelineFromConfig() returns a `webhookd.WebhookTransformation` instance for the pipeline labeled 'name'.
+func newPipelineFromConfig(ctx context.Context, cfg *config.WebhookConfig, name string, seen []string) (webhookd.WebhookTransformation, error) {
+
+	for _, other := range seen {
+
+		if other == name {
+			return nil, fmt.Errorf("Pipeline '%s' references itself (%s)", name, strings.Join(append(seen, name), " -> "))
+		}
+	}
+
+	seen = append(seen, name)
+
+	pipeline_cfg, err := cfg.GetPipelineConfigByName(name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pipeline_cfg.Transformations) == 0 && len(pipeline_cfg.Branches) == 0 {
+		return nil, fmt.Errorf("Pipeline does not define any transformations or branches")
+	}
+
+	steps, err := newTransformationsFromConfig(ctx, cfg, pipeline_cfg.Transformations, seen)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pipeline_cfg.Branches) > 0 {
+
+		branches := make(map[string]webhookd.WebhookTransformation)
+
+		for branch_name, branch_names := range pipeline_cfg.Branches {
+
+			branch_steps, err := newTransformationsFromConfig(ctx, cfg, branch_names, seen)
+
+			if err != nil {
+				return nil, fmt.Errorf("Failed to create branch '%s', %w", branch_name, err)
+			}
+
+			branches[branch_name] = transformation.NewPipelineTransformation(branch_steps)
+		}
+
+		b, err := transformation.NewBranchTransformation(branches, pipeline_cfg.Merge)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to create branches, %w", err)
+		}
+
+		steps = append(steps, b)
+	}
+
+	return transformation.NewPipelineTransformation(steps), nil
+}
@@ -0,0 +1,134 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/whosonfirst/go-webhookd/v3/config"
+)
+
+func TestPipelines(t *testing.T) {
+
+	ctx := context.Background()
+
+	root := t.TempDir()
+
+	cfg := &config.WebhookConfig{
+		Daemon: "http://localhost:8081",
+		Receivers: map[string]string{
+			"insecure": "insecure://",
+		},
+		Transformations: map[string]string{
+			"null":    "null://",
+			"chicken": "chicken://zxx",
+		},
+		Pipelines: map[string]config.WebhookPipelineConfig{
+			"sanitize": {
+				Transformations: []string{"null", "null"},
+			},
+			"both": {
+				Transformations: []string{"sanitize"},
+				Branches: map[string][]string{
+					"original": {"null"},
+					"chicken":  {"chicken"},
+				},
+				Merge: "array",
+			},
+		},
+		Dispatchers: map[string]string{
+			"file": fmt.Sprintf("file://%s", root),
+		},
+		Webhooks: []config.WebhookWebhooksConfig{
+			{
+				Endpoint:        "/pipeline",
+				Receiver:        "insecure",
+				Transformations: []string{"both"},
+				Dispatchers:     []string{"file"},
+			},
+		},
+	}
+
+	d, err := NewWebhookDaemonFromConfig(ctx, cfg)
+
+	if err != nil {
+		t.Fatalf("Failed to create new daemon from config, %v", err)
+	}
+
+	handler, err := d.HandlerFunc()
+
+	if err != nil {
+		t.Fatalf("Failed to create handler func, %v", err)
+	}
+
+	req := httptest.NewRequest("POST", "/pipeline", strings.NewReader("hello world"))
+	rec := httptest.NewRecorder()
+
+	handler(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Unexpected status: %d", rec.Code)
+	}
+
+	entries, err := os.ReadDir(root)
+
+	if err != nil {
+		t.Fatalf("Failed to read %s, %v", root, err)
+	}
+
+	if len(entries) != 1 {
+		t.Fatalf("Expected exactly one message, got %d", len(entries))
+	}
+
+	body, err := os.ReadFile(filepath.Join(root, entries[0].Name()))
+
+	if err != nil {
+		t.Fatalf("Failed to read message, %v", err)
+	}
+
+	if string(body) != `["🐔 🐔","hello world"]` {
+		t.Fatalf("Unexpected message '%s'", string(body))
+	}
+}
+
+func TestRecursivePipeline(t *testing.T) {
+
+	ctx := context.Background()
+
+	cfg := &config.WebhookConfig{
+		Daemon: "http://localhost:8081",
+		Receivers: map[string]string{
+			"insecure": "insecure://",
+		},
+		Pipelines: map[string]config.WebhookPipelineConfig{
+			"a": {
+				Transformations: []string{"b"},
+			},
+			"b": {
+				Transformations: []string{"a"},
+			},
+		},
+		Dispatchers: map[string]string{
+			"null": "null://",
+		},
+		Webhooks: []config.WebhookWebhooksConfig{
+			{
+				Endpoint:        "/pipeline",
+				Receiver:        "insecure",
+				Transformations: []string{"a"},
+				Dispatchers:     []string{"null"},
+			},
+		},
+	}
+
+	_, err := NewWebhookDaemonFromConfig(ctx, cfg)
+
+	if err == nil {
+		t.Fatalf("Expected recursive pipeline to fail")
+	}
+}
@@ -0,0 +1,218 @@
+package daemon
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/whosonfirst/go-webhookd/v3/config"
+	"github.com/whosonfirst/go-webhookd/v3/dispatcher"
+	"github.com/whosonfirst/go-webhookd/v3/usage"
+)
+
+func TestProbe(t *testing.T) {
+
+	ctx := context.Background()
+
+	dispatcher.ResetCapturedMessages("probe")
+	defer dispatcher.ResetCapturedMessages("probe")
+
+	cfg := &config.WebhookConfig{
+		Daemon: "http://localhost:8081",
+		Receivers: map[string]string{
+			"insecure": "insecure://",
+		},
+		Dispatchers: map[string]string{
+			"capture": "capture://probe",
+		},
+		Webhooks: []config.WebhookWebhooksConfig{
+			{
+				Endpoint:    "/probe",
+				Receiver:    "insecure",
+				Dispatchers: []string{"capture"},
+				Probe: &config.WebhookProbeConfig{
+					Body:   "ok",
+					MaxAge: 300,
+				},
+			},
+			{
+				Endpoint:    "/head",
+				Receiver:    "insecure",
+				Dispatchers: []string{"capture"},
+				Probe: &config.WebhookProbeConfig{
+					Methods: []string{"HEAD"},
+				},
+			},
+		},
+	}
+
+	d, err := NewWebhookDaemonFromConfig(ctx, cfg)
+
+	if err != nil {
+		t.Fatalf("Failed to create new daemon from config, %v", err)
+	}
+
+	handler, err := d.HandlerFunc()
+
+	if err != nil {
+		t.Fatalf("Failed to create handler func, %v", err)
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+		body   string
+		allow  string
+	}{
+		{"GET", "/probe", http.StatusOK, "ok", ""},
+		{"HEAD", "/probe", http.StatusOK, "", ""},
+		{"OPTIONS", "/probe", http.StatusOK, "ok", "POST, GET, HEAD, OPTIONS"},
+		{"HEAD", "/head", http.StatusNoContent, "", ""},
+		{"GET", "/head", http.StatusMethodNotAllowed, "405 Method not allowed\n", "POST, HEAD"},
+		{"HEAD", "/missing", http.StatusNotFound, "", ""},
+	}
+
+	for _, test := range tests {
+
+		req := httptest.NewRequest(test.method, test.path, nil)
+		rec := httptest.NewRecorder()
+
+		handler(rec, req)
+
+		if rec.Code != test.status {
+			t.Fatalf("Unexpected status for %s %s: %d", test.method, test.path, rec.Code)
+		}
+
+		if test.method != "HEAD" && rec.Body.String() != test.body {
+			t.Fatalf("Unexpected body for %s %s: '%s'", test.method, test.path, rec.Body.String())
+		}
+
+		if rec.Header().Get("Allow") != test.allow {
+			t.Fatalf("Unexpected Allow header for %s %s: '%s'", test.method, test.path, rec.Header().Get("Allow"))
+		}
+	}
+
+	if len(dispatcher.CapturedMessages("probe")) != 0 {
+		t.Fatalf("Expected probes not to be dispatched")
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/probe", strings.NewReader("hello world"))
+	rec := httptest.NewRecorder()
+
+	handler(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Unexpected status for POST /probe: %d", rec.Code)
+	}
+
+	if len(dispatcher.CapturedMessages("probe")) != 1 {
+		t.Fatalf("Expected POST request to be dispatched")
+	}
+}
+
+func TestProbeInvalid(t *testing.T) {
+
+	ctx := context.Background()
+
+	cfg := &config.WebhookConfig{
+		Daemon: "http://localhost:8081",
+		Receivers: map[string]string{
+			"insecure": "insecure://",
+		},
+		Dispatchers: map[string]string{
+			"null": "null://",
+		},
+		Webhooks: []config.WebhookWebhooksConfig{
+			{
+				Endpoint:    "/probe",
+				Receiver:    "insecure",
+				Dispatchers: []string{"null"},
+				Methods:     []string{"POST", "GET"},
+				Probe:       &config.WebhookProbeConfig{},
+			},
+		},
+	}
+
+	_, err := NewWebhookDaemonFromConfig(ctx, cfg)
+
+	if err == nil {
+		t.Fatalf("Expected probe methods that overlap webhook methods to fail")
+	}
+}
+
+func TestProbeQuota(t *testing.T) {
+
+	ctx := context.Background()
+
+	cfg := &config.WebhookConfig{
+		Daemon: "http://localhost:8081",
+		Receivers: map[string]string{
+			"insecure": "insecure://",
+		},
+		Dispatchers: map[string]string{
+			"null": "null://",
+		},
+		Webhooks: []config.WebhookWebhooksConfig{
+			{
+				Endpoint:    "/probe",
+				Receiver:    "insecure",
+				Dispatchers: []string{"null"},
+				Quota:       &config.WebhookQuotaConfig{Daily: 1},
+				Probe:       &config.WebhookProbeConfig{},
+			},
+		},
+	}
+
+	d, err := NewWebhookDaemonFromConfig(ctx, cfg)
+
+	if err != nil {
+		t.Fatalf("Failed to create new daemon from config, %v", err)
+	}
+
+	handler, err := d.HandlerFunc()
+
+	if err != nil {
+		t.Fatalf("Failed to create handler func, %v", err)
+	}
+
+	logger := log.New(io.Discard, "", 0)
+	webhook_handler := d.accessLogHandlerWithLogger(nil, d.usageHandlerWithLogger(handler, logger), logger)
+
+	tests := []struct {
+		method   string
+		expected int
+	}{
+		{"HEAD", http.StatusNoContent},
+		{"POST", http.StatusOK},
+		{"POST", http.StatusTooManyRequests},
+		{"HEAD", http.StatusNoContent},
+	}
+
+	for idx, test := range tests {
+
+		req := httptest.NewRequest(test.method, "/probe", strings.NewReader("hello world"))
+		rec := httptest.NewRecorder()
+
+		webhook_handler.ServeHTTP(rec, req)
+
+		if rec.Code != test.expected {
+			t.Fatalf("Expected %d for test %d (%s), got %d", test.expected, idx, test.method, rec.Code)
+		}
+	}
+
+	u, err := d.usage.Get(ctx, usage.EndpointKey("/probe"), usage.DailyPeriod(time.Now()))
+
+	if err != nil {
+		t.Fatalf("Failed to get usage, %v", err)
+	}
+
+	if u.Deliveries != 1 {
+		t.Fatalf("Expected probes not to be counted towards usage, %v", u)
+	}
+}
@@ -0,0 +1,120 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	aa_log "github.com/aaronland/go-log/v2"
+	"github.com/whosonfirst/go-webhookd/v3"
+	"github.com/whosonfirst/go-webhookd/v3/reporter"
+)
+
+// reporterTimeout is the maximum amount of time to wait for a `webhookd.WebhookReporter` to report a panic.
+const reporterTimeout time.Duration = 30 * time.Second
+
+// AddReporter() configures 'd' to report panics, recovered while processing messages, to the `webhookd.WebhookReporter`
+// instance derived from 'uri'. For example "sentry://{PUBLIC_KEY}@{HOST}/{PROJECT_ID}" or "rollbar://?access_token={TOKEN}".
+func (d *WebhookDaemon) AddReporter(ctx context.Context, uri string) error {
+
+	r, err := reporter.NewReporter(ctx, uri)
+
+	if err != nil {
+		return fmt.Errorf("Failed to create new reporter, %w", err)
+	}
+
+	d.reporter = r
+	return nil
+}
+
+// recoverRequest recovers from a panic while handling 'req' and writes a 500 response to 'rsp'. It must be called directly
+// using `defer`. Panics with `http.ErrAbortHandler`, which is used to abort a response deliberately, are not recovered.
+func (d *WebhookDaemon) recoverRequest(rsp http.ResponseWriter, req *http.Request, logger *log.Logger) {
+
+	v := recover()
+
+	if v == nil {
+		return
+	}
+
+	if v == http.ErrAbortHandler {
+		panic(v)
+	}
+
+	err := d.handlePanic(req.Context(), v, debug.Stack(), "handler", req.URL.Path, logger)
+	http.Error(rsp, err.Error(), err.Code)
+}
+
+// dispatchWithRecovery relays 'body' to 'dispatcher' for the webhook 'endpoint' returning a 500 error if the dispatcher panics.
+func (d *WebhookDaemon) dispatchWithRecovery(ctx context.Context, dispatcher webhookd.WebhookDispatcher, body []byte, endpoint string, logger *log.Logger) (err *webhookd.WebhookError) {
+
+	defer func() {
+
+		v := recover()
+
+		if v != nil {
+			err = d.handlePanic(ctx, v, debug.Stack(), "dispatcher", endpoint, logger)
+		}
+	}()
+
+	return dispatcher.Dispatch(ctx, body)
+}
+
+// dispatchStreamWithRecovery relays 'r' to 'dispatcher' for the webhook 'endpoint' returning a 500 error if the dispatcher panics.
+func (d *WebhookDaemon) dispatchStreamWithRecovery(ctx context.Context, dispatcher webhookd.WebhookStreamingDispatcher, r io.Reader, endpoint string, logger *log.Logger) (err *webhookd.WebhookError) {
+
+	defer func() {
+
+		v := recover()
+
+		if v != nil {
+			err = d.handlePanic(ctx, v, debug.Stack(), "dispatcher", endpoint, logger)
+		}
+	}()
+
+	return dispatcher.DispatchStream(ctx, r)
+}
+
+// handlePanic logs the panic 'v', with stack trace 'stack', recovered from processing 'step' for the webhook 'endpoint'. It also
+// emits a "panics" metric and reports the panic to the reporter for 'd', in a separate Go routine, if either have been configured.
+// It returns a 500 error; the value of the panic is not included in the error message since it may be returned to the client.
+func (d *WebhookDaemon) handlePanic(ctx context.Context, v interface{}, stack []byte, step string, endpoint string, logger *log.Logger) *webhookd.WebhookError {
+
+	p := &webhookd.WebhookPanic{
+		Value:      fmt.Sprintf("%v", v),
+		Stack:      stack,
+		Step:       step,
+		Endpoint:   endpoint,
+		DeliveryID: webhookd.DeliveryID(ctx),
+		Time:       time.Now(),
+	}
+
+	aa_log.Error(logger, "Recovered from panic in %s for %s, %s\n%s", step, endpoint, p.Value, stack)
+
+	wh, _ := d.getWebhook(endpoint)
+	d.metrics.recordPanic(step, endpoint, wh.Tenant())
+
+	if d.reporter != nil {
+
+		go func() {
+
+			ctx, cancel := context.WithTimeout(context.Background(), reporterTimeout)
+			defer cancel()
+
+			err := d.reporter.Report(ctx, p)
+
+			if err != nil {
+				aa_log.Error(logger, "Failed to report panic in %s for %s with %T, %v", step, endpoint, d.reporter, err)
+			}
+		}()
+	}
+
+	code := http.StatusInternalServerError
+	message := fmt.Sprintf("Internal server error (%s)", step)
+
+	return &webhookd.WebhookError{Code: code, Message: message}
+}
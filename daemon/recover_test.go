@@ -0,0 +1,169 @@
+package daemon
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+	"github.com/whosonfirst/go-webhookd/v3/receiver"
+	"github.com/whosonfirst/go-webhookd/v3/webhook"
+)
+
+// panicTransformation is a `webhookd.WebhookTransformation` implementation that always panics.
+type panicTransformation struct {
+	webhookd.WebhookTransformation
+}
+
+func (t *panicTransformation) Transform(ctx context.Context, body []byte) ([]byte, *webhookd.WebhookError) {
+	panic("transformation panicked")
+}
+
+// panicDispatcher is a `webhookd.WebhookDispatcher` implementation that always panics.
+type panicDispatcher struct {
+	webhookd.WebhookDispatcher
+}
+
+func (d *panicDispatcher) Dispatch(ctx context.Context, body []byte) *webhookd.WebhookError {
+	panic("dispatcher panicked")
+}
+
+// testReporter is a `webhookd.WebhookReporter` implementation that relays panics to a channel.
+type testReporter struct {
+	webhookd.WebhookReporter
+	panics chan *webhookd.WebhookPanic
+}
+
+func (r *testReporter) Report(ctx context.Context, p *webhookd.WebhookPanic) error {
+	r.panics <- p
+	return nil
+}
+
+func TestRecoverPanics(t *testing.T) {
+
+	ctx := context.Background()
+
+	d, err := NewWebhookDaemon(ctx, "http://localhost:8081")
+
+	if err != nil {
+		t.Fatalf("Failed to create new daemon, %v", err)
+	}
+
+	rcvr, err := receiver.NewReceiver(ctx, "insecure://")
+
+	if err != nil {
+		t.Fatalf("Failed to create receiver, %v", err)
+	}
+
+	r := &testReporter{
+		panics: make(chan *webhookd.WebhookPanic, 1),
+	}
+
+	d.reporter = r
+
+	tr := []webhookd.WebhookTransformation{&panicTransformation{}}
+	ds := []webhookd.WebhookDispatcher{&panicDispatcher{}}
+
+	wh_transform, err := webhook.NewWebhook(ctx, "/transform", rcvr, tr, nil)
+
+	if err != nil {
+		t.Fatalf("Failed to create webhook, %v", err)
+	}
+
+	wh_dispatch, err := webhook.NewWebhook(ctx, "/dispatch", rcvr, nil, ds)
+
+	if err != nil {
+		t.Fatalf("Failed to create webhook, %v", err)
+	}
+
+	for _, wh := range []webhook.Webhook{wh_transform, wh_dispatch} {
+
+		err = d.AddWebhook(ctx, wh)
+
+		if err != nil {
+			t.Fatalf("Failed to add webhook, %v", err)
+		}
+	}
+
+	logger := log.New(io.Discard, "", 0)
+
+	handler, err := d.HandlerFuncWithLogger(logger)
+
+	if err != nil {
+		t.Fatalf("Failed to create handler func, %v", err)
+	}
+
+	tests := map[string]string{
+		"/transform": "handler",
+		"/dispatch":  "dispatcher",
+	}
+
+	for path, step := range tests {
+
+		req := httptest.NewRequest("POST", path, strings.NewReader("hello world"))
+		req.Header.Set("X-Request-Id", "1234")
+
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+
+		if rec.Code != http.StatusInternalServerError {
+			t.Fatalf("Unexpected status for %s: %d", path, rec.Code)
+		}
+
+		if strings.Contains(rec.Body.String(), "panicked") {
+			t.Fatalf("Response for %s should not include panic value: %s", path, rec.Body.String())
+		}
+
+		select {
+		case p := <-r.panics:
+
+			if p.Step != step || !strings.Contains(p.Value, "panicked") || len(p.Stack) == 0 {
+				t.Fatalf("Unexpected panic report for %s: %s %s", path, p.Step, p.Value)
+			}
+
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for panic report for %s", path)
+		}
+	}
+
+	// Panics processing messages from sources are returned as errors so that messages can be processed again
+
+	msg := &webhookd.WebhookMessage{
+		ID:   "1",
+		Body: []byte("hello world"),
+	}
+
+	wh_err := d.processSourceMessage(ctx, wh_transform, msg, logger)
+
+	if wh_err == nil || wh_err.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected panic to be returned as an error, got %v", wh_err)
+	}
+
+	p := <-r.panics
+
+	if p.Step != "source" || p.DeliveryID != "1" {
+		t.Fatalf("Unexpected panic report for source: %s %s", p.Step, p.DeliveryID)
+	}
+}
+
+func TestAddReporterInvalid(t *testing.T) {
+
+	ctx := context.Background()
+
+	d, err := NewWebhookDaemon(ctx, "http://localhost:8081")
+
+	if err != nil {
+		t.Fatalf("Failed to create new daemon, %v", err)
+	}
+
+	err = d.AddReporter(ctx, "bugsnag://")
+
+	if err == nil {
+		t.Fatalf("Expected invalid reporter to fail")
+	}
+}
@@ -0,0 +1,65 @@
+package daemon
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/whosonfirst/go-webhookd/v3/config"
+)
+
+func TestWebhookResponse(t *testing.T) {
+
+	ctx := context.Background()
+
+	cfg := &config.WebhookConfig{
+		Daemon: "http://localhost:8081",
+		Receivers: map[string]string{
+			"insecure": "insecure://",
+		},
+		Dispatchers: map[string]string{
+			"null": "null://",
+		},
+		Webhooks: []config.WebhookWebhooksConfig{
+			{
+				Endpoint:    "/repos/{owner}",
+				Receiver:    "insecure",
+				Dispatchers: []string{"null"},
+				Response: &config.WebhookResponseConfig{
+					Status:      http.StatusAccepted,
+					Body:        `{"id":"{{.DeliveryID}}","owner":"{{index .Params "owner"}}"}`,
+					ContentType: "application/json",
+				},
+			},
+		},
+	}
+
+	d, err := NewWebhookDaemonFromConfig(ctx, cfg)
+
+	if err != nil {
+		t.Fatalf("Failed to create new daemon from config, %v", err)
+	}
+
+	handler, err := d.HandlerFunc()
+
+	if err != nil {
+		t.Fatalf("Failed to create handler func, %v", err)
+	}
+
+	req := httptest.NewRequest("POST", "/repos/whosonfirst", strings.NewReader("hello world"))
+	req.Header.Set("X-Request-Id", "1234")
+
+	rec := httptest.NewRecorder()
+
+	handler(rec, req)
+
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("Unexpected status: %d", rec.Code)
+	}
+
+	if rec.Body.String() != `{"id":"1234","owner":"whosonfirst"}` {
+		t.Fatalf("Unexpected body: %s", rec.Body.String())
+	}
+}
@@ -0,0 +1,53 @@
+package daemon
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/whosonfirst/go-webhookd/v3/jsonpath"
+	"github.com/whosonfirst/go-webhookd/v3/predicate"
+)
+
+// newRoutingEnvironment returns a new `predicate.Environment` instance for 'req' used to select the dispatchers for a message. The
+// environment contains 'body' decoded as JSON (if possible) along with the following variables:
+// * `header` A dictionary of the request headers, with lower-cased keys.
+// * `params` A dictionary of the path parameters in 'params'.
+// * `method` The request method.
+// * `path` The request path.
+func newRoutingEnvironment(req *http.Request, params map[string]string, body []byte) *predicate.Environment {
+	return newRoutingEnvironmentWithHeaders(req.Header, params, req.Method, req.URL.Path, body)
+}
+
+// newRoutingEnvironmentWithHeaders returns a new `predicate.Environment` instance used to select the dispatchers for a message
+// with 'headers', 'params', 'method' and 'path' variables. It is used directly for messages that were not received over HTTP.
+func newRoutingEnvironmentWithHeaders(headers http.Header, params map[string]string, method string, path string, body []byte) *predicate.Environment {
+
+	doc, _ := jsonpath.Decode(body)
+
+	str_headers := make(map[string]interface{})
+
+	for k, v := range headers {
+
+		if len(v) > 0 {
+			str_headers[strings.ToLower(k)] = v[0]
+		}
+	}
+
+	str_params := make(map[string]interface{})
+
+	for k, v := range params {
+		str_params[k] = v
+	}
+
+	env := &predicate.Environment{
+		Document: doc,
+		Variables: map[string]interface{}{
+			"header": str_headers,
+			"params": str_params,
+			"method": method,
+			"path":   path,
+		},
+	}
+
+	return env
+}
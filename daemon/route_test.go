@@ -0,0 +1,119 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/whosonfirst/go-webhookd/v3/config"
+)
+
+func TestRoutes(t *testing.T) {
+
+	ctx := context.Background()
+
+	deploy_root := t.TempDir()
+	log_root := t.TempDir()
+
+	cfg := &config.WebhookConfig{
+		Daemon: "http://localhost:8081",
+		Receivers: map[string]string{
+			"insecure": "insecure://",
+		},
+		Dispatchers: map[string]string{
+			"deploy": fmt.Sprintf("file://%s", deploy_root),
+			"log":    fmt.Sprintf("file://%s", log_root),
+		},
+		Webhooks: []config.WebhookWebhooksConfig{
+			{
+				Endpoint:    "/push",
+				Receiver:    "insecure",
+				Dispatchers: []string{"log"},
+				Routes: []config.WebhookRouteConfig{
+					{
+						When:        `ref == "refs/heads/main" && $header.x-github-event == "push"`,
+						Dispatchers: []string{"deploy"},
+					},
+				},
+			},
+		},
+	}
+
+	d, err := NewWebhookDaemonFromConfig(ctx, cfg)
+
+	if err != nil {
+		t.Fatalf("Failed to create new daemon from config, %v", err)
+	}
+
+	handler, err := d.HandlerFunc()
+
+	if err != nil {
+		t.Fatalf("Failed to create handler func, %v", err)
+	}
+
+	for _, ref := range []string{"refs/heads/main", "refs/heads/develop"} {
+
+		body := fmt.Sprintf(`{"ref":"%s"}`, ref)
+
+		req := httptest.NewRequest("POST", "/push", strings.NewReader(body))
+		req.Header.Set("X-GitHub-Event", "push")
+
+		rec := httptest.NewRecorder()
+
+		handler(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Unexpected status: %d", rec.Code)
+		}
+	}
+
+	for _, root := range []string{deploy_root, log_root} {
+
+		entries, err := os.ReadDir(root)
+
+		if err != nil {
+			t.Fatalf("Failed to read %s, %v", root, err)
+		}
+
+		if len(entries) != 1 {
+			t.Fatalf("Expected exactly one message in %s, got %d", root, len(entries))
+		}
+	}
+}
+
+func TestInvalidRoute(t *testing.T) {
+
+	ctx := context.Background()
+
+	cfg := &config.WebhookConfig{
+		Daemon: "http://localhost:8081",
+		Receivers: map[string]string{
+			"insecure": "insecure://",
+		},
+		Dispatchers: map[string]string{
+			"null": "null://",
+		},
+		Webhooks: []config.WebhookWebhooksConfig{
+			{
+				Endpoint: "/push",
+				Receiver: "insecure",
+				Routes: []config.WebhookRouteConfig{
+					{
+						When:        `ref ==`,
+						Dispatchers: []string{"null"},
+					},
+				},
+			},
+		},
+	}
+
+	_, err := NewWebhookDaemonFromConfig(ctx, cfg)
+
+	if err == nil {
+		t.Fatalf("Expected invalid route to fail")
+	}
+}
@@ -0,0 +1,37 @@
+package daemon
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+)
+
+// openLogSink returns the `io.Writer` that log entries (for example access or audit log entries) are written to for 'u' which
+// is expected to take the form of "stdout://", "stderr://" or "file://{PATH}". Files are created if necessary and only ever
+// appended to.
+func openLogSink(u *url.URL) (io.Writer, error) {
+
+	switch u.Scheme {
+	case "stdout":
+		return os.Stdout, nil
+	case "stderr":
+		return os.Stderr, nil
+	case "file":
+
+		if u.Path == "" {
+			return nil, fmt.Errorf("Missing path")
+		}
+
+		fh, err := os.OpenFile(u.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return fh, nil
+
+	default:
+		return nil, fmt.Errorf("Invalid scheme '%s'", u.Scheme)
+	}
+}
@@ -0,0 +1,276 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	aa_log "github.com/aaronland/go-log/v2"
+	"github.com/whosonfirst/go-webhookd/v3"
+	"github.com/whosonfirst/go-webhookd/v3/webhook"
+)
+
+// Types of synthetic events delivered to the meta webhook.
+const (
+	META_EVENT_SLO_BREACH    string = "slo_breach"
+	META_EVENT_SLO_RECOVERED string = "slo_recovered"
+)
+
+// Service level objectives that are evaluated for webhooks.
+const (
+	SLO_SUCCESS_RATE string = "success_rate"
+	SLO_LATENCY      string = "latency"
+)
+
+// sloBuckets is the number of buckets that the window for a service level objective is divided in to.
+const sloBuckets int = 60
+
+// sloBucket is the tally of requests for a slice of the window for a service level objective.
+type sloBucket struct {
+	start    time.Time
+	requests int
+	failures int
+	slow     int
+}
+
+// sloTracker evaluates the service level objectives for a webhook over a sliding window.
+type sloTracker struct {
+	// slo is the `webhook.SLO` instance defining the objectives.
+	slo *webhook.SLO
+	// width is the amount of time covered by each bucket.
+	width time.Duration
+	// buckets is a ring of `sloBucket` instances covering the window.
+	buckets []*sloBucket
+	// breached is a dictionary of the objectives that are currently breached.
+	breached map[string]bool
+	// mu is a `sync.Mutex` used to guard 'buckets' and 'breached'.
+	mu *sync.Mutex
+}
+
+// sloEvent is the body of a synthetic event, delivered to the meta webhook, when a service level objective is breached or recovers.
+type sloEvent struct {
+	// Type is one of `META_EVENT_SLO_BREACH` or `META_EVENT_SLO_RECOVERED`.
+	Type string `json:"type"`
+	// Time is the time the event occurred.
+	Time string `json:"time"`
+	// Endpoint is the endpoint of the webhook.
+	Endpoint string `json:"endpoint"`
+	// Labels is the dictionary of labels for the webhook.
+	Labels map[string]string `json:"labels"`
+	// Objective is one of `SLO_SUCCESS_RATE` or `SLO_LATENCY`.
+	Objective string `json:"objective"`
+	// Target is the fraction of requests that must meet the objective.
+	Target float64 `json:"target"`
+	// Observed is the fraction of requests that met the objective during the window.
+	Observed float64 `json:"observed"`
+	// BurnRate is the rate at which the error budget for the objective was consumed during the window.
+	BurnRate float64 `json:"burn_rate"`
+	// Threshold is the burn rate at which the objective is considered to be breached.
+	Threshold float64 `json:"threshold"`
+	// Window is the sliding window over which the objective is evaluated.
+	Window string `json:"window"`
+	// Requests is the number of requests during the window.
+	Requests int `json:"requests"`
+}
+
+// newSLOTracker returns a new `sloTracker` for 'slo'.
+func newSLOTracker(slo *webhook.SLO) *sloTracker {
+
+	width := slo.Window() / time.Duration(sloBuckets)
+
+	if width <= 0 {
+		width = time.Nanosecond
+	}
+
+	buckets := make([]*sloBucket, sloBuckets)
+
+	for i := range buckets {
+		buckets[i] = &sloBucket{}
+	}
+
+	t := &sloTracker{
+		slo:      slo,
+		width:    width,
+		buckets:  buckets,
+		breached: make(map[string]bool),
+		mu:       new(sync.Mutex),
+	}
+
+	return t
+}
+
+// observe records a request, completed at 'now', that either succeeded or failed and took 'latency' to process. It returns
+// the list of (unsent) events for objectives that have been breached, or have recovered, as a result.
+func (t *sloTracker) observe(now time.Time, success bool, latency time.Duration) []*sloEvent {
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	start := now.Truncate(t.width)
+	idx := int((start.UnixNano() / int64(t.width)) % int64(len(t.buckets)))
+
+	b := t.buckets[idx]
+
+	if !b.start.Equal(start) {
+		b.start = start
+		b.requests = 0
+		b.failures = 0
+		b.slow = 0
+	}
+
+	b.requests += 1
+
+	if !success {
+		b.failures += 1
+	}
+
+	if t.slo.Latency() > 0 && latency > t.slo.Latency() {
+		b.slow += 1
+	}
+
+	requests := 0
+	failures := 0
+	slow := 0
+
+	cutoff := start.Add(-t.slo.Window())
+
+	for _, b := range t.buckets {
+
+		if !b.start.After(cutoff) {
+			continue
+		}
+
+		requests += b.requests
+		failures += b.failures
+		slow += b.slow
+	}
+
+	events := make([]*sloEvent, 0)
+
+	if requests < t.slo.MinRequests() {
+		return events
+	}
+
+	evaluate := func(objective string, target float64, misses int) {
+
+		observed := 1.0 - float64(misses)/float64(requests)
+		burn_rate := (float64(misses) / float64(requests)) / (1.0 - target)
+
+		breached := burn_rate >= t.slo.BurnRate()
+
+		if breached == t.breached[objective] {
+			return
+		}
+
+		t.breached[objective] = breached
+
+		ev := &sloEvent{
+			Type:      META_EVENT_SLO_RECOVERED,
+			Time:      now.UTC().Format(time.RFC3339Nano),
+			Objective: objective,
+			Target:    target,
+			Observed:  observed,
+			BurnRate:  burn_rate,
+			Threshold: t.slo.BurnRate(),
+			Window:    t.slo.Window().String(),
+			Requests:  requests,
+		}
+
+		if breached {
+			ev.Type = META_EVENT_SLO_BREACH
+		}
+
+		events = append(events, ev)
+	}
+
+	if t.slo.SuccessRate() > 0 {
+		evaluate(SLO_SUCCESS_RATE, t.slo.SuccessRate(), failures)
+	}
+
+	if t.slo.Latency() > 0 {
+		evaluate(SLO_LATENCY, t.slo.LatencyTarget(), slow)
+	}
+
+	return events
+}
+
+// recordSLO evaluates the service level objectives, if any, for the webhook that processed 'entry' which took 'latency' to
+// complete. Requests succeed if they do not return a 5XX status code. Breaches, and recoveries, are logged to 'logger' and
+// delivered to the meta webhook for 'd' if it has one.
+func (d *WebhookDaemon) recordSLO(entry *accessLogEntry, latency time.Duration, logger *log.Logger) {
+
+	// Dry runs are not dispatched so they don't count towards service level objectives
+
+	if entry.Endpoint == "" || entry.DryRun {
+		return
+	}
+
+	d.mu.RLock()
+	t, ok := d.slos[entry.Endpoint]
+	wh := d.webhooks[entry.Endpoint]
+	d.mu.RUnlock()
+
+	if !ok {
+		return
+	}
+
+	events := t.observe(time.Now(), entry.Status < http.StatusInternalServerError, latency)
+
+	for _, ev := range events {
+
+		ev.Endpoint = entry.Endpoint
+		ev.Labels = wh.Labels()
+
+		switch ev.Type {
+		case META_EVENT_SLO_BREACH:
+			aa_log.Warning(logger, "Service level objective (%s) for %s breached, burn rate %.2f over %s", ev.Objective, ev.Endpoint, ev.BurnRate, ev.Window)
+		default:
+			aa_log.Info(logger, "Service level objective (%s) for %s recovered, burn rate %.2f over %s", ev.Objective, ev.Endpoint, ev.BurnRate, ev.Window)
+		}
+
+		d.deliverMetaEvent(ev.Type, ev, logger)
+	}
+}
+
+// deliverMetaEvent delivers the synthetic event 'ev', of type 'event_type', to the meta webhook for 'd', if it has one, in a
+// separate Go routine. The event type is assigned to the "X-Webhookd-Event" header of the message.
+func (d *WebhookDaemon) deliverMetaEvent(event_type string, ev interface{}, logger *log.Logger) {
+
+	if d.MetaWebhook == "" {
+		return
+	}
+
+	wh, ok := d.lookupSourceWebhook(d.MetaWebhook)
+
+	if !ok {
+		aa_log.Error(logger, "Meta webhook %s not found", d.MetaWebhook)
+		return
+	}
+
+	body, err := json.Marshal(ev)
+
+	if err != nil {
+		aa_log.Error(logger, "Failed to encode %s event, %v", event_type, err)
+		return
+	}
+
+	msg := &webhookd.WebhookMessage{
+		ID:   newUUID(),
+		Body: body,
+		Header: http.Header{
+			"X-Webhookd-Event": []string{event_type},
+		},
+	}
+
+	go func() {
+
+		err := d.processSourceMessage(context.Background(), wh, msg, logger)
+
+		if err != nil {
+			aa_log.Error(logger, "Failed to deliver %s event to meta webhook %s, %v", event_type, d.MetaWebhook, err)
+		}
+	}()
+}
@@ -0,0 +1,205 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/whosonfirst/go-webhookd/v3/config"
+	"github.com/whosonfirst/go-webhookd/v3/webhook"
+)
+
+func TestSLOTracker(t *testing.T) {
+
+	slo, err := webhook.NewSLO(&webhook.SLOOptions{
+		SuccessRate: 0.9,
+		Window:      time.Minute,
+		MinRequests: 4,
+	})
+
+	if err != nil {
+		t.Fatalf("Failed to create SLO, %v", err)
+	}
+
+	tr := newSLOTracker(slo)
+	now := time.Now()
+
+	// Not enough requests to evaluate
+
+	for i := 0; i < 3; i++ {
+
+		events := tr.observe(now, false, 0)
+
+		if len(events) != 0 {
+			t.Fatalf("Unexpected events before minimum number of requests")
+		}
+	}
+
+	events := tr.observe(now, false, 0)
+
+	if len(events) != 1 || events[0].Type != META_EVENT_SLO_BREACH || events[0].Objective != SLO_SUCCESS_RATE || events[0].Requests != 4 {
+		t.Fatalf("Expected breach event, got %v", events)
+	}
+
+	// Breaches are only reported once
+
+	events = tr.observe(now, false, 0)
+
+	if len(events) != 0 {
+		t.Fatalf("Unexpected events for ongoing breach")
+	}
+
+	// Requests outside the window are discarded
+
+	later := now.Add(2 * time.Minute)
+
+	for i := 0; i < 3; i++ {
+		tr.observe(later, true, 0)
+	}
+
+	events = tr.observe(later, true, 0)
+
+	if len(events) != 1 || events[0].Type != META_EVENT_SLO_RECOVERED || events[0].Observed != 1.0 || events[0].Requests != 4 {
+		t.Fatalf("Expected recovered event, got %v", events)
+	}
+}
+
+func TestSLOMetaWebhook(t *testing.T) {
+
+	ctx := context.Background()
+
+	meta_root := t.TempDir()
+
+	cfg := &config.WebhookConfig{
+		Daemon:      "http://localhost:8081",
+		MetaWebhook: "/meta",
+		Receivers: map[string]string{
+			"insecure": "insecure://",
+		},
+		Dispatchers: map[string]string{
+			"null": "null://",
+			"meta": "file://" + meta_root,
+		},
+		Webhooks: []config.WebhookWebhooksConfig{
+			{
+				Endpoint:    "/foo",
+				Receiver:    "insecure",
+				Dispatchers: []string{"null"},
+				Labels:      map[string]string{"team": "data"},
+				SLO: &config.WebhookSLOConfig{
+					Latency:       "1ns",
+					LatencyTarget: 0.5,
+					MinRequests:   2,
+				},
+			},
+			{
+				Endpoint:    "/meta",
+				Receiver:    "insecure",
+				Dispatchers: []string{"meta"},
+			},
+		},
+	}
+
+	d, err := NewWebhookDaemonFromConfig(ctx, cfg)
+
+	if err != nil {
+		t.Fatalf("Failed to create new daemon from config, %v", err)
+	}
+
+	handler, err := d.HandlerFunc()
+
+	if err != nil {
+		t.Fatalf("Failed to create handler func, %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest("POST", "/foo", strings.NewReader("hello world"))
+		rec := httptest.NewRecorder()
+		d.accessLogHandler(nil, handler).ServeHTTP(rec, req)
+	}
+
+	var paths []string
+
+	for i := 0; i < 100; i++ {
+
+		paths, _ = filepath.Glob(filepath.Join(meta_root, "[^.]*"))
+
+		if len(paths) > 0 {
+			break
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if len(paths) != 1 {
+		t.Fatalf("Expected exactly one meta event, got %d", len(paths))
+	}
+
+	body, err := os.ReadFile(paths[0])
+
+	if err != nil {
+		t.Fatalf("Failed to read meta event, %v", err)
+	}
+
+	var ev *sloEvent
+
+	err = json.Unmarshal(body, &ev)
+
+	if err != nil {
+		t.Fatalf("Failed to unmarshal meta event, %v", err)
+	}
+
+	if ev.Type != META_EVENT_SLO_BREACH || ev.Endpoint != "/foo" || ev.Objective != SLO_LATENCY || ev.Labels["team"] != "data" {
+		t.Fatalf("Unexpected meta event: %s", body)
+	}
+}
+
+func TestSLOInvalidConfig(t *testing.T) {
+
+	ctx := context.Background()
+
+	tests := map[string]func(cfg *config.WebhookConfig){
+		"missing meta webhook": func(cfg *config.WebhookConfig) {
+			cfg.MetaWebhook = "/missing"
+		},
+		"invalid latency": func(cfg *config.WebhookConfig) {
+			cfg.Webhooks[0].SLO = &config.WebhookSLOConfig{Latency: "soon"}
+		},
+		"invalid success rate": func(cfg *config.WebhookConfig) {
+			cfg.Webhooks[0].SLO = &config.WebhookSLOConfig{SuccessRate: 99}
+		},
+	}
+
+	for label, fn := range tests {
+
+		cfg := &config.WebhookConfig{
+			Daemon: "http://localhost:8081",
+			Receivers: map[string]string{
+				"insecure": "insecure://",
+			},
+			Dispatchers: map[string]string{
+				"null": "null://",
+			},
+			Webhooks: []config.WebhookWebhooksConfig{
+				{
+					Endpoint:    "/foo",
+					Receiver:    "insecure",
+					Dispatchers: []string{"null"},
+				},
+			},
+		}
+
+		fn(cfg)
+
+		_, err := NewWebhookDaemonFromConfig(ctx, cfg)
+
+		if err == nil {
+			t.Fatalf("Expected %s to fail", label)
+		}
+	}
+}
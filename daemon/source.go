@@ -0,0 +1,266 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	aa_log "github.com/aaronland/go-log/v2"
+	"github.com/whosonfirst/go-webhookd/v3"
+	"github.com/whosonfirst/go-webhookd/v3/transformation"
+	"github.com/whosonfirst/go-webhookd/v3/webhook"
+)
+
+// sourceRetryMin is the amount of time to wait before restarting a source that has failed.
+const sourceRetryMin time.Duration = time.Second
+
+// sourceRetryMax is the maximum amount of time to wait before restarting a source that has failed repeatedly.
+const sourceRetryMax time.Duration = time.Minute
+
+// sourceWebhook is a webhook whose messages are consumed from a `webhookd.WebhookSource` rather than received over HTTP.
+type sourceWebhook struct {
+	// source is the `webhookd.WebhookSource` instance that messages are consumed from.
+	source webhookd.WebhookSource
+	// webhook is the `webhook.Webhook` instance whose transformations, routes and dispatchers are applied to each message.
+	webhook webhook.Webhook
+	// singleton is a boolean flag signaling that messages should only be consumed while 'd' is the elected leader for the webhook.
+	singleton bool
+}
+
+// AddSourceWebhook() adds 'wh' to 'd' processing messages consumed from 'src'. The endpoint for 'wh' is only used to identify
+// it and is not installed as an HTTP handler. If 'd' has an audit log the change is recorded before it is made.
+func (d *WebhookDaemon) AddSourceWebhook(ctx context.Context, src webhookd.WebhookSource, wh webhook.Webhook) error {
+
+	for _, s := range d.sources {
+
+		if s.webhook.Endpoint() == wh.Endpoint() {
+			return fmt.Errorf("Endpoint already configured")
+		}
+	}
+
+	err := d.auditLog.record(ctx, AUDIT_ADD_WEBHOOK, wh.Endpoint(), nil, describeWebhook(wh, src))
+
+	if err != nil {
+		return err
+	}
+
+	s := &sourceWebhook{
+		source:  src,
+		webhook: wh,
+	}
+
+	d.sources = append(d.sources, s)
+	return nil
+}
+
+// startSources starts consuming messages for each of the source webhooks in 'd', in separate Go routines, until 'ctx' is
+// cancelled. It returns a `sync.WaitGroup` that can be used to wait for all the sources to stop.
+func (d *WebhookDaemon) startSources(ctx context.Context, logger *log.Logger) *sync.WaitGroup {
+
+	wg := new(sync.WaitGroup)
+
+	for _, s := range d.sources {
+
+		wg.Add(1)
+
+		go func(s *sourceWebhook) {
+
+			defer wg.Done()
+			defer s.source.Close()
+
+			if s.singleton {
+				d.leadSource(ctx, s, logger)
+			} else {
+				d.consumeSource(ctx, s, logger)
+			}
+		}(s)
+	}
+
+	return wg
+}
+
+// consumeSource consumes messages from the source for 's' until 'ctx' is cancelled or the source has no more messages to read. If
+// the source fails it is restarted after a delay that doubles, up to `sourceRetryMax`, each time it fails in quick succession.
+func (d *WebhookDaemon) consumeSource(ctx context.Context, s *sourceWebhook, logger *log.Logger) {
+
+	endpoint := s.webhook.Endpoint()
+	delay := sourceRetryMin
+
+	fn := func(ctx context.Context, msg *webhookd.WebhookMessage) *webhookd.WebhookError {
+		return d.processSourceMessage(ctx, s.webhook, msg, logger)
+	}
+
+	for {
+
+		aa_log.Info(logger, "Webhookd consuming messages for %s from %T", endpoint, s.source)
+
+		t1 := time.Now()
+
+		err := s.source.Consume(ctx, fn)
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err == nil {
+			aa_log.Info(logger, "Source (%T) for %s has no more messages to read", s.source, endpoint)
+			return
+		}
+
+		if time.Since(t1) > sourceRetryMax {
+			delay = sourceRetryMin
+		}
+
+		aa_log.Error(logger, "Source (%T) for %s failed, restarting in %v, %v", s.source, endpoint, delay, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+			// pass
+		}
+
+		delay = delay * 2
+
+		if delay > sourceRetryMax {
+			delay = sourceRetryMax
+		}
+	}
+}
+
+// ConsumeWebhook() consumes messages from 'src', until 'ctx' is cancelled or 'src' has no more messages to read, applying the
+// transformations, routes and dispatchers for the webhook in 'd' matching 'endpoint' to each message. Unlike webhooks added with
+// `AddSourceWebhook` 'src' is not restarted if it fails and the error is returned. The webhook's receiver, if it has one, is not
+// used to validate messages.
+func (d *WebhookDaemon) ConsumeWebhook(ctx context.Context, endpoint string, src webhookd.WebhookSource, logger *log.Logger) error {
+
+	wh, ok := d.lookupSourceWebhook(endpoint)
+
+	if !ok {
+		return fmt.Errorf("Unknown webhook '%s'", endpoint)
+	}
+
+	defer src.Close()
+
+	fn := func(ctx context.Context, msg *webhookd.WebhookMessage) *webhookd.WebhookError {
+		return d.processSourceMessage(ctx, wh, msg, logger)
+	}
+
+	return src.Consume(ctx, fn)
+}
+
+// lookupSourceWebhook returns the `webhook.Webhook` instance, received over HTTP or consumed from a source, whose endpoint
+// is 'endpoint'.
+func (d *WebhookDaemon) lookupSourceWebhook(endpoint string) (webhook.Webhook, bool) {
+
+	wh, ok := d.getWebhook(endpoint)
+
+	if ok {
+		return wh, true
+	}
+
+	for _, s := range d.sources {
+
+		if s.webhook.Endpoint() == endpoint {
+			return s.webhook, true
+		}
+	}
+
+	return webhook.Webhook{}, false
+}
+
+// processSourceMessage applies the transformations, routes and dispatchers for 'wh' to 'msg'. Messages that can not be transformed
+// because they are invalid (a transformation returns a 4XX error) are logged and dropped rather than being returned to the source
+// since processing them again will not succeed. All other errors, including panics, are returned so that the source can process the
+// message again.
+func (d *WebhookDaemon) processSourceMessage(ctx context.Context, wh webhook.Webhook, msg *webhookd.WebhookMessage, logger *log.Logger) (wh_err *webhookd.WebhookError) {
+
+	defer func() {
+
+		v := recover()
+
+		if v != nil {
+			wh_err = d.handlePanic(ctx, v, debug.Stack(), "source", wh.Endpoint(), logger)
+		}
+	}()
+
+	t1 := time.Now()
+
+	delivery_id := msg.ID
+
+	if delivery_id == "" {
+		delivery_id = newUUID()
+	}
+
+	headers := msg.Header
+
+	if headers == nil {
+		headers = http.Header{}
+	}
+
+	endpoint := wh.Endpoint()
+	params := map[string]string{}
+
+	delivery := &webhookd.Delivery{
+		Endpoint:       endpoint,
+		ID:             delivery_id,
+		EventType:      eventType(headers),
+		Header:         headers,
+		PathParameters: params,
+	}
+
+	ctx = webhookd.WithDelivery(ctx, delivery)
+	ctx = webhookd.WithMessageHeaders(ctx)
+
+	bodies := [][]byte{msg.Body}
+
+	for idx, step := range wh.Transformations() {
+
+		var err *webhookd.WebhookError
+
+		bodies, err = transformation.TransformMessages(ctx, step, bodies)
+
+		if err != nil {
+
+			switch {
+			case err.Code == webhookd.UnhandledEvent || err.Code == webhookd.HaltEvent:
+				aa_log.Info(logger, "Transformation step (%T) at offset %d returned non-fatal error and exiting, %v", step, idx, err)
+				return nil
+			case err.Code >= 400 && err.Code < 500:
+				aa_log.Error(logger, "Transformation step (%T) at offset %d failed, dropping message %s for %s, %v", step, idx, delivery_id, endpoint, err)
+				return nil
+			default:
+				aa_log.Error(logger, "Transformation step (%T) at offset %d failed, %v", step, idx, err)
+				return err
+			}
+		}
+	}
+
+	dispatchers_for := func(body []byte) []webhookd.WebhookDispatcher {
+
+		if len(wh.Routes()) == 0 {
+			return wh.Dispatchers()
+		}
+
+		env := newRoutingEnvironmentWithHeaders(headers, params, "", endpoint, body)
+		return wh.DispatchersForEnvironment(env)
+	}
+
+	summary := d.dispatchMessages(ctx, wh, bodies, dispatchers_for, nil, logger)
+
+	if !summary.ok {
+		code := http.StatusInternalServerError
+		message := strings.Join(summary.errors, "\n\n")
+		return &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	d.events.publish(delivery_id, endpoint, endpoint, bodies)
+
+	aa_log.Debug(logger, "Time to process message %s for %s: %v", delivery_id, endpoint, time.Since(t1))
+	return nil
+}
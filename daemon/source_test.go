@@ -0,0 +1,320 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+	"github.com/whosonfirst/go-webhookd/v3/config"
+)
+
+// testSource is a `webhookd.WebhookSource` implementation that delivers a fixed list of messages, redelivering messages that
+// fail to be processed each time it is consumed.
+type testSource struct {
+	webhookd.WebhookSource
+	mu       *sync.Mutex
+	messages []*webhookd.WebhookMessage
+	acked    map[string]bool
+}
+
+func (s *testSource) Consume(ctx context.Context, fn webhookd.WebhookSourceFunc) error {
+
+	for _, msg := range s.messages {
+
+		if s.Acked(msg.ID) {
+			continue
+		}
+
+		err := fn(ctx, msg)
+
+		if err != nil {
+			return err
+		}
+
+		s.mu.Lock()
+		s.acked[msg.ID] = true
+		s.mu.Unlock()
+	}
+
+	<-ctx.Done()
+	return nil
+}
+
+func (s *testSource) Acked(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.acked[id]
+}
+
+func (s *testSource) Close() error {
+	return nil
+}
+
+func TestSource(t *testing.T) {
+
+	ctx := context.Background()
+
+	deploy_root := t.TempDir()
+	log_root := t.TempDir()
+
+	cfg := &config.WebhookConfig{
+		Daemon: "http://localhost:8081",
+		Sources: map[string]string{
+			"kafka": "kafka://localhost:9092/events",
+		},
+		Transformations: map[string]string{
+			"commits": "split://?path=commits",
+		},
+		Dispatchers: map[string]string{
+			"deploy": fmt.Sprintf("file://%s", deploy_root),
+			"log":    fmt.Sprintf("file://%s", log_root),
+		},
+		Webhooks: []config.WebhookWebhooksConfig{
+			{
+				Source:          "kafka",
+				Transformations: []string{"commits"},
+				Dispatchers:     []string{"log"},
+				Routes: []config.WebhookRouteConfig{
+					{
+						When:        `$header.x-event == "deploy"`,
+						Dispatchers: []string{"deploy"},
+					},
+				},
+			},
+		},
+	}
+
+	d, err := NewWebhookDaemonFromConfig(ctx, cfg)
+
+	if err != nil {
+		t.Fatalf("Failed to create new daemon from config, %v", err)
+	}
+
+	if len(d.sources) != 1 || len(d.webhooks) != 0 {
+		t.Fatalf("Expected exactly one source webhook")
+	}
+
+	if d.sources[0].webhook.Endpoint() != "kafka" {
+		t.Fatalf("Unexpected endpoint for source webhook: %s", d.sources[0].webhook.Endpoint())
+	}
+
+	src := &testSource{
+		mu: new(sync.Mutex),
+		messages: []*webhookd.WebhookMessage{
+			{ID: "1", Body: []byte(`{"commits":[{"id":"a"},{"id":"b"}]}`), Header: http.Header{"X-Event": []string{"deploy"}}},
+			{ID: "2", Body: []byte(`not json`)},
+			{ID: "3", Body: []byte(`{"commits":[{"id":"c"}]}`)},
+		},
+		acked: make(map[string]bool),
+	}
+
+	d.sources[0].source = src
+
+	logger := log.New(os.Stderr, "", 0)
+
+	consume_ctx, cancel := context.WithCancel(ctx)
+	wg := d.startSources(consume_ctx, logger)
+
+	for i := 0; i < 100 && !src.Acked("3"); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	wg.Wait()
+
+	// Invalid messages are dropped rather than being redelivered
+
+	for _, id := range []string{"1", "2", "3"} {
+
+		if !src.Acked(id) {
+			t.Fatalf("Expected message %s to be acknowledged", id)
+		}
+	}
+
+	expected := map[string]int{
+		deploy_root: 2,
+		log_root:    1,
+	}
+
+	for root, count := range expected {
+
+		entries, err := os.ReadDir(root)
+
+		if err != nil {
+			t.Fatalf("Failed to read %s, %v", root, err)
+		}
+
+		if len(entries) != count {
+			t.Fatalf("Expected %d messages in %s, got %d", count, root, len(entries))
+		}
+	}
+}
+
+func TestSourceDispatchError(t *testing.T) {
+
+	ctx := context.Background()
+
+	root := t.TempDir()
+
+	cfg := &config.WebhookConfig{
+		Daemon: "http://localhost:8081",
+		Sources: map[string]string{
+			"kafka": "kafka://localhost:9092/events",
+		},
+		Dispatchers: map[string]string{
+			"file": fmt.Sprintf("file://%s", root),
+		},
+		Webhooks: []config.WebhookWebhooksConfig{
+			{
+				Source:      "kafka",
+				Dispatchers: []string{"file"},
+			},
+		},
+	}
+
+	d, err := NewWebhookDaemonFromConfig(ctx, cfg)
+
+	if err != nil {
+		t.Fatalf("Failed to create new daemon from config, %v", err)
+	}
+
+	// Remove the dispatcher's root directory so that writing messages fails
+
+	err = os.RemoveAll(root)
+
+	if err != nil {
+		t.Fatalf("Failed to remove %s, %v", root, err)
+	}
+
+	logger := log.New(os.Stderr, "", 0)
+
+	msg := &webhookd.WebhookMessage{
+		Body: []byte("hello world"),
+	}
+
+	werr := d.processSourceMessage(ctx, d.sources[0].webhook, msg, logger)
+
+	if werr == nil || werr.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected failed dispatch to return an error, got %v", werr)
+	}
+}
+
+func TestInvalidSource(t *testing.T) {
+
+	ctx := context.Background()
+
+	tests := []config.WebhookWebhooksConfig{
+		{Source: "kafka", Receiver: "insecure", Dispatchers: []string{"null"}},
+		{Source: "kafka", Streaming: true, Dispatchers: []string{"null"}},
+		{Source: "amqp", Dispatchers: []string{"null"}},
+	}
+
+	for idx, hook := range tests {
+
+		cfg := &config.WebhookConfig{
+			Daemon: "http://localhost:8081",
+			Receivers: map[string]string{
+				"insecure": "insecure://",
+			},
+			Sources: map[string]string{
+				"kafka": "kafka://localhost:9092/events",
+			},
+			Dispatchers: map[string]string{
+				"null": "null://",
+			},
+			Webhooks: []config.WebhookWebhooksConfig{hook},
+		}
+
+		_, err := NewWebhookDaemonFromConfig(ctx, cfg)
+
+		if err == nil {
+			t.Fatalf("Expected invalid source webhook at offset %d to fail", idx)
+		}
+	}
+}
+
+func TestConsumeWebhook(t *testing.T) {
+
+	ctx := context.Background()
+
+	root := t.TempDir()
+
+	cfg := &config.WebhookConfig{
+		Daemon: "http://localhost:8081",
+		Receivers: map[string]string{
+			"insecure": "insecure://",
+		},
+		Transformations: map[string]string{
+			"events": "split://?path=events",
+		},
+		Dispatchers: map[string]string{
+			"file": fmt.Sprintf("file://%s", root),
+		},
+		Webhooks: []config.WebhookWebhooksConfig{
+			{
+				Endpoint:        "/syslog",
+				Receiver:        "insecure",
+				Transformations: []string{"events"},
+				Dispatchers:     []string{"file"},
+			},
+		},
+	}
+
+	d, err := NewWebhookDaemonFromConfig(ctx, cfg)
+
+	if err != nil {
+		t.Fatalf("Failed to create new daemon from config, %v", err)
+	}
+
+	src := &testSource{
+		mu: new(sync.Mutex),
+		messages: []*webhookd.WebhookMessage{
+			{ID: "1", Body: []byte(`{"events":[{"id":"a"},{"id":"b"}]}`)},
+			{ID: "2", Body: []byte(`{"events":[{"id":"c"}]}`)},
+		},
+		acked: make(map[string]bool),
+	}
+
+	logger := log.New(os.Stderr, "", 0)
+
+	err = d.ConsumeWebhook(ctx, "/missing", src, logger)
+
+	if err == nil {
+		t.Fatalf("Expected unknown webhook to fail")
+	}
+
+	consume_ctx, cancel := context.WithCancel(ctx)
+	done := make(chan error)
+
+	go func() {
+		done <- d.ConsumeWebhook(consume_ctx, "/syslog", src, logger)
+	}()
+
+	for i := 0; i < 100 && !src.Acked("2"); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+
+	err = <-done
+
+	if err != nil {
+		t.Fatalf("Failed to consume webhook, %v", err)
+	}
+
+	entries, err := os.ReadDir(root)
+
+	if err != nil {
+		t.Fatalf("Failed to read %s, %v", root, err)
+	}
+
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 messages in %s, got %d", root, len(entries))
+	}
+}
@@ -0,0 +1,74 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/whosonfirst/go-webhookd/v3/config"
+)
+
+func TestSplit(t *testing.T) {
+
+	ctx := context.Background()
+
+	root := t.TempDir()
+
+	cfg := &config.WebhookConfig{
+		Daemon: "http://localhost:8081",
+		Receivers: map[string]string{
+			"insecure": "insecure://",
+		},
+		Transformations: map[string]string{
+			"commits": "split://?path=commits",
+		},
+		Dispatchers: map[string]string{
+			"file": fmt.Sprintf("file://%s", root),
+		},
+		Webhooks: []config.WebhookWebhooksConfig{
+			{
+				Endpoint:        "/push",
+				Receiver:        "insecure",
+				Transformations: []string{"commits"},
+				Dispatchers:     []string{"file"},
+			},
+		},
+	}
+
+	d, err := NewWebhookDaemonFromConfig(ctx, cfg)
+
+	if err != nil {
+		t.Fatalf("Failed to create new daemon from config, %v", err)
+	}
+
+	handler, err := d.HandlerFunc()
+
+	if err != nil {
+		t.Fatalf("Failed to create handler func, %v", err)
+	}
+
+	body := `{"commits":[{"id":"a"},{"id":"b"},{"id":"c"}]}`
+
+	req := httptest.NewRequest("POST", "/push", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	handler(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Unexpected status: %d", rec.Code)
+	}
+
+	entries, err := os.ReadDir(root)
+
+	if err != nil {
+		t.Fatalf("Failed to read %s, %v", root, err)
+	}
+
+	if len(entries) != 3 {
+		t.Fatalf("Expected three messages, got %d", len(entries))
+	}
+}
@@ -0,0 +1,232 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	aa_log "github.com/aaronland/go-log/v2"
+	"github.com/whosonfirst/go-webhookd/v3"
+	"github.com/whosonfirst/go-webhookd/v3/receiver"
+	"github.com/whosonfirst/go-webhookd/v3/webhook"
+)
+
+// handleStream processes 'req' for 'wh' streaming the message body from its receiver to its dispatchers without reading it in to memory.
+// Since the body is never held in memory messages processed by streaming webhooks are not published as (GraphQL) events.
+func (d *WebhookDaemon) handleStream(ctx context.Context, rsp http.ResponseWriter, req *http.Request, wh webhook.Webhook, response_headers *ResponseHeaders, access_log *accessLogEntry, logger *log.Logger) {
+
+	t1 := time.Now()
+
+	rcvr := wh.Receiver().(webhookd.WebhookStreamingReceiver)
+
+	r, err := rcvr.ReceiveStream(ctx, req)
+
+	if err != nil {
+
+		response_headers.setErrorOutcome(rsp, "receiver", err)
+
+		if receiver.IsStale(err) {
+			d.metrics.recordStale(wh.Endpoint(), wh.Tenant())
+		}
+
+		switch err.Code {
+		case webhookd.UnhandledEvent, webhookd.HaltEvent:
+			aa_log.Info(logger, "Receiver step (%T)  returned non-fatal error and exiting, %v", rcvr, err)
+			writeChallengeResponse(ctx, rsp)
+			return
+		default:
+			aa_log.Error(logger, "Receiver step (%T) failed, %v", rcvr, err)
+			http.Error(rsp, err.Error(), err.Code)
+			return
+		}
+	}
+
+	if r == nil {
+		return
+	}
+
+	defer r.Close()
+
+	delivery_id := webhookd.DeliveryID(ctx)
+
+	claimed, claim_key, claim_err := d.claimDelivery(ctx, req, wh, nil)
+
+	if claim_err != nil {
+		aa_log.Warning(logger, "Failed to claim delivery %s for %s, dispatching anyway, %v", delivery_id, wh.Endpoint(), claim_err)
+	} else if !claimed {
+		aa_log.Info(logger, "Delivery %s for %s has already been claimed, skipping dispatch", delivery_id, wh.Endpoint())
+		response_headers.setOutcome(rsp, OUTCOME_DUPLICATE)
+		return
+	}
+
+	// Release the claim if streaming or dispatching fails, or panics, so that the provider can retry the delivery
+
+	succeeded := false
+
+	defer func() {
+
+		if !succeeded {
+			d.releaseDelivery(ctx, claim_key, logger)
+		}
+	}()
+
+	dispatchers := wh.Dispatchers()
+	dispatch := d.dispatchStreamWithRecovery
+
+	pipes := make([]*io.PipeWriter, len(dispatchers))
+
+	wg := new(sync.WaitGroup)
+	mu := new(sync.Mutex)
+
+	errs := make([]string, 0)
+
+	for idx, d := range dispatchers {
+
+		pr, pw := io.Pipe()
+		pipes[idx] = pw
+
+		wg.Add(1)
+
+		go func(idx int, d webhookd.WebhookStreamingDispatcher, pr *io.PipeReader) {
+
+			defer wg.Done()
+
+			err := dispatch(ctx, d, pr, wh.Endpoint(), logger)
+			access_log.addDispatch(d, idx, err)
+
+			// Ensure that any remaining writes to this pipe fail rather than block
+			pr.CloseWithError(io.ErrClosedPipe)
+
+			if err != nil {
+
+				switch err.Code {
+				case webhookd.UnhandledEvent, webhookd.HaltEvent:
+					aa_log.Info(logger, "Dispatch step (%T) at offset %d returned non-fatal error and exiting, %v", d, idx, err)
+				default:
+					aa_log.Error(logger, "Dispatch step (%T) at offset %d failed, %v", d, idx, err)
+
+					mu.Lock()
+					errs = append(errs, err.Error())
+					mu.Unlock()
+				}
+			}
+
+		}(idx, d.(webhookd.WebhookStreamingDispatcher), pr)
+	}
+
+	fw := &fanoutWriter{
+		writers: pipes,
+		failed:  make([]bool, len(pipes)),
+	}
+
+	_, copy_err := io.Copy(fw, r)
+
+	for _, pw := range pipes {
+
+		if copy_err != nil {
+			pw.CloseWithError(copy_err)
+		} else {
+			pw.Close()
+		}
+	}
+
+	wg.Wait()
+
+	if copy_err != nil {
+
+		code := http.StatusBadRequest
+
+		switch {
+		case errors.Is(copy_err, receiver.ErrInvalidSignature):
+			code = http.StatusForbidden
+		case errors.Is(copy_err, receiver.ErrBodyTooLarge):
+			code = http.StatusRequestEntityTooLarge
+		}
+
+		response_headers.setOutcome(rsp, OUTCOME_FAILED, "step=receiver")
+
+		aa_log.Error(logger, "Failed to stream message body, %v", copy_err)
+		http.Error(rsp, copy_err.Error(), code)
+		return
+	}
+
+	messages := "messages=1"
+	dispatched := fmt.Sprintf("dispatches=%d", len(dispatchers))
+	failures := fmt.Sprintf("failures=%d", len(errs))
+
+	if !wh.Succeeded(len(dispatchers)-len(errs), len(dispatchers)) {
+		response_headers.setOutcome(rsp, OUTCOME_FAILED, "step=dispatch", messages, dispatched, failures)
+		msg := strings.Join(errs, "\n\n")
+		http.Error(rsp, msg, http.StatusInternalServerError)
+		return
+	}
+
+	succeeded = true
+
+	if len(errs) > 0 {
+		aa_log.Warning(logger, "%d dispatch step(s) for %s failed but the '%s' success policy was satisfied", len(errs), wh.Endpoint(), wh.SuccessPolicy())
+	}
+
+	t2 := time.Since(t1)
+
+	aa_log.Debug(logger, "Time to process: %v", t2)
+
+	access_log.setTiming("process", t2)
+	response_headers.setTiming(rsp, "Process", t2)
+	if len(errs) > 0 {
+		response_headers.setOutcome(rsp, OUTCOME_DISPATCHED, messages, dispatched, failures)
+	} else {
+		response_headers.setOutcome(rsp, OUTCOME_DISPATCHED, messages, dispatched)
+	}
+
+	wh_response := wh.Response()
+
+	if wh_response != nil {
+
+		data := &webhook.ResponseTemplateData{
+			DeliveryID: delivery_id,
+			Endpoint:   wh.Endpoint(),
+			EventType:  eventType(req.Header),
+			Path:       req.URL.Path,
+			Params:     webhookd.PathParameters(ctx),
+		}
+
+		err := wh_response.Write(rsp, data)
+
+		if err != nil {
+			aa_log.Error(logger, "Failed to write response for %s, %v", req.URL.Path, err)
+		}
+	}
+}
+
+// fanoutWriter is an `io.Writer` that writes to multiple `io.PipeWriter` instances. Unlike `io.MultiWriter` a failure
+// to write to one writer (for example because a dispatcher has stopped reading) does not prevent writes to the others.
+type fanoutWriter struct {
+	writers []*io.PipeWriter
+	failed  []bool
+}
+
+// Write writes 'p' to each of the writers that have not previously failed.
+func (fw *fanoutWriter) Write(p []byte) (int, error) {
+
+	for idx, wr := range fw.writers {
+
+		if fw.failed[idx] {
+			continue
+		}
+
+		_, err := wr.Write(p)
+
+		if err != nil {
+			fw.failed[idx] = true
+		}
+	}
+
+	return len(p), nil
+}
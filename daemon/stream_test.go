@@ -0,0 +1,319 @@
+package daemon
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/whosonfirst/go-webhookd/v3/config"
+	"github.com/whosonfirst/go-webhookd/v3/receiver"
+)
+
+func TestStreamingWebhook(t *testing.T) {
+
+	ctx := context.Background()
+
+	root := t.TempDir()
+
+	cfg := &config.WebhookConfig{
+		Daemon: "http://localhost:8081",
+		Receivers: map[string]string{
+			"insecure": "insecure://",
+		},
+		Dispatchers: map[string]string{
+			"file": fmt.Sprintf("file://%s?extension=txt", root),
+			"null": "null://",
+		},
+		Webhooks: []config.WebhookWebhooksConfig{
+			{
+				Endpoint:    "/stream",
+				Receiver:    "insecure",
+				Dispatchers: []string{"file", "null"},
+				Streaming:   true,
+			},
+		},
+	}
+
+	d, err := NewWebhookDaemonFromConfig(ctx, cfg)
+
+	if err != nil {
+		t.Fatalf("Failed to create new daemon from config, %v", err)
+	}
+
+	handler, err := d.HandlerFunc()
+
+	if err != nil {
+		t.Fatalf("Failed to create handler func, %v", err)
+	}
+
+	expected := bytes.Repeat([]byte("hello world "), 100000)
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write(expected)
+	gz.Close()
+
+	req := httptest.NewRequest("POST", "/stream", &buf)
+	req.Header.Set("Content-Encoding", "gzip")
+
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Unexpected status: %d %s", rec.Code, rec.Body.String())
+	}
+
+	matches, err := filepath.Glob(filepath.Join(root, "*.txt"))
+
+	if err != nil {
+		t.Fatalf("Failed to glob output, %v", err)
+	}
+
+	if len(matches) != 1 {
+		t.Fatalf("Expected exactly one output file, got %d", len(matches))
+	}
+
+	body, err := os.ReadFile(matches[0])
+
+	if err != nil {
+		t.Fatalf("Failed to read output, %v", err)
+	}
+
+	if !bytes.Equal(body, expected) {
+		t.Fatalf("Unexpected output")
+	}
+}
+
+func TestStreamingWebhookWithTransformations(t *testing.T) {
+
+	ctx := context.Background()
+
+	cfg := &config.WebhookConfig{
+		Daemon: "http://localhost:8081",
+		Receivers: map[string]string{
+			"insecure": "insecure://",
+		},
+		Transformations: map[string]string{
+			"null": "null://",
+		},
+		Dispatchers: map[string]string{
+			"null": "null://",
+		},
+		Webhooks: []config.WebhookWebhooksConfig{
+			{
+				Endpoint:        "/stream",
+				Receiver:        "insecure",
+				Transformations: []string{"null"},
+				Dispatchers:     []string{"null"},
+				Streaming:       true,
+			},
+		},
+	}
+
+	_, err := NewWebhookDaemonFromConfig(ctx, cfg)
+
+	if err == nil {
+		t.Fatalf("Expected streaming webhook with transformations to fail")
+	}
+}
+
+func TestStreamingWebhookWithHTTPDispatcher(t *testing.T) {
+
+	ctx := context.Background()
+
+	cfg := &config.WebhookConfig{
+		Daemon: "http://localhost:8081",
+		Receivers: map[string]string{
+			"insecure": "insecure://",
+		},
+		Dispatchers: map[string]string{
+			"http": "http://localhost:8080/hook",
+		},
+		Webhooks: []config.WebhookWebhooksConfig{
+			{
+				Endpoint:    "/stream",
+				Receiver:    "insecure",
+				Dispatchers: []string{"http"},
+				Streaming:   true,
+			},
+		},
+	}
+
+	_, err := NewWebhookDaemonFromConfig(ctx, cfg)
+
+	if err == nil {
+		t.Fatalf("Expected streaming webhook with HTTP dispatcher to fail")
+	}
+}
+
+func TestStreamingWebhookClaims(t *testing.T) {
+
+	ctx := context.Background()
+
+	root := t.TempDir()
+
+	cfg := &config.WebhookConfig{
+		Daemon:  "http://localhost:8081?outcome_header=true",
+		Cluster: "memory://",
+		Receivers: map[string]string{
+			"insecure": "insecure://",
+		},
+		Dispatchers: map[string]string{
+			"file": fmt.Sprintf("file://%s?extension=txt", root),
+		},
+		Webhooks: []config.WebhookWebhooksConfig{
+			{
+				Endpoint:    "/stream",
+				Receiver:    "insecure",
+				Dispatchers: []string{"file"},
+				Streaming:   true,
+			},
+		},
+	}
+
+	d, err := NewWebhookDaemonFromConfig(ctx, cfg)
+
+	if err != nil {
+		t.Fatalf("Failed to create new daemon from config, %v", err)
+	}
+
+	handler, err := d.HandlerFunc()
+
+	if err != nil {
+		t.Fatalf("Failed to create handler func, %v", err)
+	}
+
+	tests := []struct {
+		body    string
+		status  int
+		outcome string
+		files   int
+	}{
+		// Messages that fail to stream are released so they can be retried
+		{"", http.StatusBadRequest, OUTCOME_FAILED, 0},
+		{"hello world", http.StatusOK, OUTCOME_DISPATCHED, 1},
+		{"hello world", http.StatusOK, OUTCOME_DUPLICATE, 1},
+	}
+
+	for idx, test := range tests {
+
+		var body io.Reader = strings.NewReader(test.body)
+
+		if test.body == "" {
+			body = iotest.ErrReader(fmt.Errorf("Connection reset"))
+		}
+
+		req := httptest.NewRequest("POST", "/stream", body)
+		req.Header.Set("X-GitHub-Delivery", "1234")
+
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+
+		if rec.Code != test.status {
+			t.Fatalf("Unexpected status for test %d: %d %s", idx, rec.Code, rec.Body.String())
+		}
+
+		outcome := rec.Header().Get("X-Webhookd-Outcome")
+
+		if !strings.HasPrefix(outcome, test.outcome) {
+			t.Fatalf("Unexpected outcome for test %d: %s", idx, outcome)
+		}
+
+		matches, err := filepath.Glob(filepath.Join(root, "*.txt"))
+
+		if err != nil {
+			t.Fatalf("Failed to glob output, %v", err)
+		}
+
+		if len(matches) != test.files {
+			t.Fatalf("Unexpected number of output files for test %d: %d", idx, len(matches))
+		}
+	}
+}
+
+func TestStreamingWebhookSignature(t *testing.T) {
+
+	ctx := context.Background()
+
+	root := t.TempDir()
+
+	cfg := &config.WebhookConfig{
+		Daemon: "http://localhost:8081",
+		Receivers: map[string]string{
+			"gitea": "gitea://?secret=s33kret",
+		},
+		Dispatchers: map[string]string{
+			"file": fmt.Sprintf("file://%s?extension=txt", root),
+		},
+		Webhooks: []config.WebhookWebhooksConfig{
+			{
+				Endpoint:    "/stream",
+				Receiver:    "gitea",
+				Dispatchers: []string{"file"},
+				Streaming:   true,
+			},
+		},
+	}
+
+	d, err := NewWebhookDaemonFromConfig(ctx, cfg)
+
+	if err != nil {
+		t.Fatalf("Failed to create new daemon from config, %v", err)
+	}
+
+	handler, err := d.HandlerFunc()
+
+	if err != nil {
+		t.Fatalf("Failed to create handler func, %v", err)
+	}
+
+	body := bytes.Repeat([]byte("hello world "), 100000)
+
+	tests := []struct {
+		secret string
+		status int
+		files  int
+	}{
+		// Messages that fail validation once their final bytes have been read are never committed
+		{"wr0ng", http.StatusForbidden, 0},
+		{"s33kret", http.StatusOK, 1},
+	}
+
+	for idx, test := range tests {
+
+		mac := hmac.New(sha256.New, []byte(test.secret))
+		mac.Write(body)
+
+		req := httptest.NewRequest("POST", "/stream", bytes.NewReader(body))
+		req.Header.Set(receiver.GITEA_SIGNATURE_HEADER, hex.EncodeToString(mac.Sum(nil)))
+
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+
+		if rec.Code != test.status {
+			t.Fatalf("Unexpected status for test %d: %d %s", idx, rec.Code, rec.Body.String())
+		}
+
+		matches, err := filepath.Glob(filepath.Join(root, "*"))
+
+		if err != nil {
+			t.Fatalf("Failed to glob output, %v", err)
+		}
+
+		if len(matches) != test.files {
+			t.Fatalf("Unexpected number of output files for test %d: %d", idx, len(matches))
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+	"github.com/whosonfirst/go-webhookd/v3/config"
+)
+
+// reTenantName is the regular expression that tenant names must match.
+var reTenantName = regexp.MustCompile(`^[a-z0-9][a-z0-9_\-]*$`)
+
+// webhookTenant is the tenant that webhooks created by `addWebhooksFromConfig` belong to.
+type webhookTenant struct {
+	// name is the name of the tenant.
+	name string
+	// middleware is the list of `webhookd.WebhookMiddleware` instances shared by all of the tenant's webhooks.
+	middleware []webhookd.WebhookMiddleware
+	// labels is the dictionary of labels added to the labels of each of the tenant's webhooks.
+	labels map[string]string
+}
+
+// AddTenantFromConfig() appends the webhooks for the tenant 'name', defined in 'cfg', to 'd'. Tenant webhooks are installed under
+// the "/t/{TENANT}" prefix and may only use the receivers, sources, transformations, pipelines, dispatchers and middleware defined
+// by 'cfg'. Tenant names must be lower-case and may only contain letters, numbers, underscores and dashes.
+func (d *WebhookDaemon) AddTenantFromConfig(ctx context.Context, name string, cfg *config.WebhookTenantConfig) error {
+
+	if !reTenantName.MatchString(name) {
+		return fmt.Errorf("Invalid tenant name '%s'", name)
+	}
+
+	if len(cfg.Webhooks) == 0 {
+		return fmt.Errorf("No webhooks defined for tenant '%s'", name)
+	}
+
+	tenant_cfg := cfg.WebhookConfig()
+
+	tenant_middleware, err := newMiddlewareFromConfig(ctx, tenant_cfg, cfg.GlobalMiddleware)
+
+	if err != nil {
+		return fmt.Errorf("Failed to create global middleware for tenant '%s', %w", name, err)
+	}
+
+	tenant := &webhookTenant{
+		name:       name,
+		middleware: tenant_middleware,
+		labels:     cfg.Labels,
+	}
+
+	err = d.addWebhooksFromConfig(ctx, tenant_cfg, tenant)
+
+	if err != nil {
+		return fmt.Errorf("Failed to add webhooks for tenant '%s', %w", name, err)
+	}
+
+	if cfg.Quota != nil {
+
+		q := &Quota{
+			Daily:   cfg.Quota.Daily,
+			Monthly: cfg.Quota.Monthly,
+		}
+
+		err = d.AddTenantQuota(ctx, name, q)
+
+		if err != nil {
+			return fmt.Errorf("Failed to add quota for tenant '%s', %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// mergeLabels returns a new dictionary containing the labels for 't' and 'labels'. The values in 'labels' take precedence.
+func (t *webhookTenant) mergeLabels(labels map[string]string) map[string]string {
+
+	merged := make(map[string]string)
+
+	for k, v := range t.labels {
+		merged[k] = v
+	}
+
+	for k, v := range labels {
+		merged[k] = v
+	}
+
+	return merged
+}
@@ -0,0 +1,268 @@
+package daemon
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/whosonfirst/go-webhookd/v3/config"
+)
+
+func TestTenants(t *testing.T) {
+
+	ctx := context.Background()
+
+	cfg := &config.WebhookConfig{
+		Daemon: "http://localhost:8081",
+		Receivers: map[string]string{
+			"insecure": "insecure://",
+		},
+		Dispatchers: map[string]string{
+			"null": "null://",
+		},
+		Webhooks: []config.WebhookWebhooksConfig{
+			{
+				Endpoint:    "/foo",
+				Receiver:    "insecure",
+				Dispatchers: []string{"null"},
+			},
+		},
+		Tenants: map[string]config.WebhookTenantConfig{
+			"acme": {
+				Receivers: map[string]string{
+					"insecure": "insecure://",
+				},
+				Dispatchers: map[string]string{
+					"null": "null://",
+				},
+				Middleware: map[string]string{
+					"limit": "ratelimit://?rate=2&period=1h&key=global",
+				},
+				GlobalMiddleware: []string{"limit"},
+				Webhooks: []config.WebhookWebhooksConfig{
+					{
+						Endpoint:    "/foo",
+						Receiver:    "insecure",
+						Dispatchers: []string{"null"},
+					},
+					{
+						Endpoint:    "/repos/{name}",
+						Receiver:    "insecure",
+						Dispatchers: []string{"null"},
+					},
+				},
+			},
+			"globex": {
+				Receivers: map[string]string{
+					"insecure": "insecure://",
+				},
+				Dispatchers: map[string]string{
+					"null": "null://",
+				},
+				Labels: map[string]string{"team": "globex"},
+				Webhooks: []config.WebhookWebhooksConfig{
+					{
+						Endpoint:    "/foo",
+						Receiver:    "insecure",
+						Dispatchers: []string{"null"},
+					},
+				},
+			},
+		},
+	}
+
+	d, err := NewWebhookDaemonFromConfig(ctx, cfg)
+
+	if err != nil {
+		t.Fatalf("Failed to create new daemon from config, %v", err)
+	}
+
+	wh, _, ok := d.lookupWebhook("/t/globex/foo")
+
+	if !ok {
+		t.Fatalf("Failed to find webhook for tenant")
+	}
+
+	if wh.Tenant() != "globex" || wh.Labels()["team"] != "globex" {
+		t.Fatalf("Unexpected tenant (%s) or labels (%v)", wh.Tenant(), wh.Labels())
+	}
+
+	handler, err := d.HandlerFunc()
+
+	if err != nil {
+		t.Fatalf("Failed to create handler func, %v", err)
+	}
+
+	// The rate limit for "acme" is shared by all of its webhooks and does not apply to other tenants
+
+	tests := []struct {
+		path     string
+		expected int
+	}{
+		{"/foo", http.StatusOK},
+		{"/t/acme/foo", http.StatusOK},
+		{"/t/acme/repos/webhookd", http.StatusOK},
+		{"/t/acme/foo", http.StatusTooManyRequests},
+		{"/t/globex/foo", http.StatusOK},
+		{"/t/globex/repos/webhookd", http.StatusNotFound},
+		{"/t/initech/foo", http.StatusNotFound},
+	}
+
+	for idx, test := range tests {
+
+		req := httptest.NewRequest("POST", test.path, strings.NewReader("hello world"))
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != test.expected {
+			t.Fatalf("Expected %d for test %d (%s), got %d", test.expected, idx, test.path, rec.Code)
+		}
+	}
+}
+
+func TestTenantsIsolation(t *testing.T) {
+
+	ctx := context.Background()
+
+	tests := map[string]*config.WebhookConfig{
+		// Tenants can not use components defined outside of the tenant
+		"global receiver": {
+			Receivers: map[string]string{"insecure": "insecure://"},
+			Tenants: map[string]config.WebhookTenantConfig{
+				"acme": {
+					Dispatchers: map[string]string{"null": "null://"},
+					Webhooks: []config.WebhookWebhooksConfig{
+						{Endpoint: "/foo", Receiver: "insecure", Dispatchers: []string{"null"}},
+					},
+				},
+			},
+		},
+		"invalid name": {
+			Tenants: map[string]config.WebhookTenantConfig{
+				"Acme Corp": {
+					Receivers:   map[string]string{"insecure": "insecure://"},
+					Dispatchers: map[string]string{"null": "null://"},
+					Webhooks: []config.WebhookWebhooksConfig{
+						{Endpoint: "/foo", Receiver: "insecure", Dispatchers: []string{"null"}},
+					},
+				},
+			},
+		},
+		"no webhooks": {
+			Tenants: map[string]config.WebhookTenantConfig{
+				"acme": {},
+			},
+		},
+		// Webhooks outside of a tenant can not use the tenant prefix
+		"reserved prefix": {
+			Receivers:   map[string]string{"insecure": "insecure://"},
+			Dispatchers: map[string]string{"null": "null://"},
+			Webhooks: []config.WebhookWebhooksConfig{
+				{Endpoint: "/t/acme/foo", Receiver: "insecure", Dispatchers: []string{"null"}},
+			},
+			Tenants: map[string]config.WebhookTenantConfig{
+				"globex": {
+					Receivers:   map[string]string{"insecure": "insecure://"},
+					Dispatchers: map[string]string{"null": "null://"},
+					Webhooks: []config.WebhookWebhooksConfig{
+						{Endpoint: "/foo", Receiver: "insecure", Dispatchers: []string{"null"}},
+					},
+				},
+			},
+		},
+	}
+
+	for label, cfg := range tests {
+
+		cfg.Daemon = "http://localhost:8081"
+
+		_, err := NewWebhookDaemonFromConfig(ctx, cfg)
+
+		if err == nil {
+			t.Fatalf("Expected config with %s to fail", label)
+		}
+	}
+}
+
+func TestTenantsMetrics(t *testing.T) {
+
+	ctx := context.Background()
+
+	tests := map[string]string{
+		"protocol=dogstatsd": "webhookd.requests:1|c|#endpoint:/t/acme/foo,tenant:acme,status:200",
+		"protocol=statsd":    "webhookd.tenants.acme.requests:1|c",
+	}
+
+	for params, expected := range tests {
+
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+
+		if err != nil {
+			t.Fatalf("Failed to listen for packets, %v", err)
+		}
+
+		defer conn.Close()
+
+		cfg := &config.WebhookConfig{
+			Daemon:  "http://localhost:8081",
+			Metrics: "metrics://" + conn.LocalAddr().String() + "?" + params,
+			Tenants: map[string]config.WebhookTenantConfig{
+				"acme": {
+					Receivers:   map[string]string{"insecure": "insecure://"},
+					Dispatchers: map[string]string{"null": "null://"},
+					Webhooks: []config.WebhookWebhooksConfig{
+						{Endpoint: "/foo", Receiver: "insecure", Dispatchers: []string{"null"}},
+					},
+				},
+			},
+		}
+
+		d, err := NewWebhookDaemonFromConfig(ctx, cfg)
+
+		if err != nil {
+			t.Fatalf("Failed to create new daemon from config, %v", err)
+		}
+
+		handler, err := d.HandlerFunc()
+
+		if err != nil {
+			t.Fatalf("Failed to create handler func, %v", err)
+		}
+
+		req := httptest.NewRequest("POST", "/t/acme/foo", strings.NewReader("hello world"))
+		rec := httptest.NewRecorder()
+
+		d.accessLogHandler(nil, handler).ServeHTTP(rec, req)
+
+		d.metrics.flush()
+
+		buf := make([]byte, 2048)
+
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, _, err := conn.ReadFrom(buf)
+
+		if err != nil {
+			t.Fatalf("Failed to read metrics for %s, %v", params, err)
+		}
+
+		lines := strings.Split(string(buf[:n]), "\n")
+		found := false
+
+		for _, ln := range lines {
+
+			if ln == expected {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			t.Fatalf("Missing metric '%s' for %s in %v", expected, params, lines)
+		}
+	}
+}
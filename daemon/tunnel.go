@@ -0,0 +1,112 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"sort"
+
+	aa_log "github.com/aaronland/go-log/v2"
+	"github.com/whosonfirst/go-webhookd/v3/tunnel"
+)
+
+// AddTunnel() configures 'd' to expose its server to the internet, when it is started, using the `tunnel.Tunnel` instance derived
+// from 'uri' (for example "ngrok://" or "cloudflared://"). This is meant for testing provider webhooks against a development
+// machine without setting up a tunnel manually.
+func (d *WebhookDaemon) AddTunnel(ctx context.Context, uri string) error {
+
+	t, err := tunnel.NewTunnel(ctx, uri)
+
+	if err != nil {
+		return fmt.Errorf("Failed to create tunnel, %w", err)
+	}
+
+	d.tunnel = t
+	return nil
+}
+
+// PublicURL returns the public URL for the tunnel opened when 'd' was started, or "" if 'd' does not have a tunnel or it has not
+// been opened yet.
+func (d *WebhookDaemon) PublicURL() string {
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.publicURL
+}
+
+// openTunnel opens the tunnel for 'd', forwarding requests to the address of its server, and logs the public URL for each of
+// its webhooks.
+func (d *WebhookDaemon) openTunnel(ctx context.Context, logger *log.Logger) error {
+
+	addr, err := tunnelAddress(d.server.Address())
+
+	if err != nil {
+		return err
+	}
+
+	public_url, err := d.tunnel.Open(ctx, addr)
+
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	d.publicURL = public_url
+
+	endpoints := make([]string, 0, len(d.webhooks))
+
+	for endpoint := range d.webhooks {
+		endpoints = append(endpoints, endpoint)
+	}
+
+	d.mu.Unlock()
+
+	sort.Strings(endpoints)
+
+	aa_log.Info(logger, "Webhookd public URL is %s\n", public_url)
+
+	for _, endpoint := range endpoints {
+		aa_log.Info(logger, "Webhook %s is available at %s%s\n", endpoint, public_url, endpoint)
+	}
+
+	return nil
+}
+
+// tunnelAddress returns the URL that a tunnel should forward requests to for a server listening on 'addr'. Servers listening
+// on all interfaces are reached using "localhost".
+func tunnelAddress(addr string) (string, error) {
+
+	u, err := url.Parse(addr)
+
+	if err != nil {
+		return "", fmt.Errorf("Failed to parse server address, %w", err)
+	}
+
+	switch u.Scheme {
+	case "http", "https":
+		// pass
+	default:
+		return "", fmt.Errorf("Unsupported server scheme '%s' for tunnel", u.Scheme)
+	}
+
+	host := u.Hostname()
+
+	switch host {
+	case "", "0.0.0.0", "::":
+		host = "localhost"
+	}
+
+	if u.Port() != "" {
+		host = net.JoinHostPort(host, u.Port())
+	}
+
+	tunnel_u := url.URL{
+		Scheme: u.Scheme,
+		Host:   host,
+	}
+
+	return tunnel_u.String(), nil
+}
@@ -0,0 +1,117 @@
+package daemon
+
+import (
+	"context"
+	"io"
+	"log"
+	"testing"
+
+	"github.com/whosonfirst/go-webhookd/v3/config"
+	"github.com/whosonfirst/go-webhookd/v3/tunnel"
+)
+
+// testTunnel implements the `tunnel.Tunnel` interface for tests, recording the address that requests are forwarded to.
+type testTunnel struct {
+	tunnel.Tunnel
+	addr string
+}
+
+func (t *testTunnel) Open(ctx context.Context, addr string) (string, error) {
+	t.addr = addr
+	return "https://example.ngrok.app", nil
+}
+
+func (t *testTunnel) Close() error {
+	return nil
+}
+
+func TestTunnel(t *testing.T) {
+
+	ctx := context.Background()
+	logger := log.New(io.Discard, "", 0)
+
+	test_tunnel := &testTunnel{}
+
+	err := tunnel.RegisterTunnel(ctx, "testtunnel", func(ctx context.Context, uri string) (tunnel.Tunnel, error) {
+		return test_tunnel, nil
+	})
+
+	if err != nil {
+		t.Fatalf("Failed to register tunnel, %v", err)
+	}
+
+	cfg := &config.WebhookConfig{
+		Daemon:      "http://0.0.0.0:8081",
+		Tunnel:      "testtunnel://",
+		Receivers:   map[string]string{"insecure": "insecure://"},
+		Dispatchers: map[string]string{"null": "null://"},
+		Webhooks: []config.WebhookWebhooksConfig{
+			{Endpoint: "/foo", Receiver: "insecure", Dispatchers: []string{"null"}},
+		},
+	}
+
+	d, err := NewWebhookDaemonFromConfig(ctx, cfg)
+
+	if err != nil {
+		t.Fatalf("Failed to create new daemon from config, %v", err)
+	}
+
+	if d.PublicURL() != "" {
+		t.Fatalf("Expected empty public URL before tunnel is opened")
+	}
+
+	err = d.openTunnel(ctx, logger)
+
+	if err != nil {
+		t.Fatalf("Failed to open tunnel, %v", err)
+	}
+
+	if d.PublicURL() != "https://example.ngrok.app" {
+		t.Fatalf("Unexpected public URL '%s'", d.PublicURL())
+	}
+
+	if test_tunnel.addr != "http://localhost:8081" {
+		t.Fatalf("Unexpected tunnel address '%s'", test_tunnel.addr)
+	}
+
+	cfg.Tunnel = "bogus://"
+
+	_, err = NewWebhookDaemonFromConfig(ctx, cfg)
+
+	if err == nil {
+		t.Fatalf("Expected invalid tunnel to fail")
+	}
+}
+
+func TestTunnelAddress(t *testing.T) {
+
+	tests := []struct {
+		addr     string
+		expected string
+	}{
+		{"http://localhost:8080", "http://localhost:8080"},
+		{"http://0.0.0.0:8080", "http://localhost:8080"},
+		{"http://[::]:8080", "http://localhost:8080"},
+		{"https://127.0.0.1:8443", "https://127.0.0.1:8443"},
+		{"http://example.com", "http://example.com"},
+	}
+
+	for _, test := range tests {
+
+		addr, err := tunnelAddress(test.addr)
+
+		if err != nil {
+			t.Fatalf("Failed to derive tunnel address for %s, %v", test.addr, err)
+		}
+
+		if addr != test.expected {
+			t.Fatalf("Unexpected tunnel address for %s: %s", test.addr, addr)
+		}
+	}
+
+	_, err := tunnelAddress("lambda://")
+
+	if err == nil {
+		t.Fatalf("Expected unsupported scheme to fail")
+	}
+}
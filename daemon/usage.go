@@ -0,0 +1,261 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	aa_log "github.com/aaronland/go-log/v2"
+	"github.com/whosonfirst/go-webhookd/v3/usage"
+	"github.com/whosonfirst/go-webhookd/v3/webhook"
+)
+
+// Quota is the number of messages that a webhook, or all the webhooks for a tenant, will accept per (UTC) day and month.
+type Quota struct {
+	// Daily is the maximum number of messages delivered per day. If 0 there is no daily quota.
+	Daily int64
+	// Monthly is the maximum number of messages delivered per month. If 0 there is no monthly quota.
+	Monthly int64
+}
+
+// usageReadCloser is a `io.ReadCloser` that counts the number of bytes read from a request body.
+type usageReadCloser struct {
+	io.ReadCloser
+	// mu is a `sync.Mutex` used to guard 'n' since request bodies may be read by other goroutines.
+	mu *sync.Mutex
+	// n is the number of bytes read.
+	n int64
+}
+
+func (r *usageReadCloser) Read(b []byte) (int, error) {
+
+	n, err := r.ReadCloser.Read(b)
+
+	r.mu.Lock()
+	r.n += int64(n)
+	r.mu.Unlock()
+
+	return n, err
+}
+
+// size returns the number of bytes read from 'r'.
+func (r *usageReadCloser) size() int64 {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.n
+}
+
+// AddUsage() configures 'd' to record the number of messages delivered, and bytes and dispatches processed, by each webhook and
+// tenant in the `usage.Store` instance derived from 'uri'. Usage should be added before any quotas. Only requests that return a
+// status code less than 400 are recorded; dry runs and messages consumed from sources are not.
+func (d *WebhookDaemon) AddUsage(ctx context.Context, uri string) error {
+
+	s, err := usage.NewStore(ctx, uri)
+
+	if err != nil {
+		return fmt.Errorf("Failed to create usage store, %w", err)
+	}
+
+	d.usage = s
+	return nil
+}
+
+// AddQuota() limits the number of messages that the webhook for 'endpoint' will accept to 'q'. If 'd' has not been configured
+// with a usage store an in-memory store is used.
+func (d *WebhookDaemon) AddQuota(ctx context.Context, endpoint string, q *Quota) error {
+	return d.addQuota(ctx, usage.EndpointKey(endpoint), q)
+}
+
+// AddTenantQuota() limits the number of messages that all of the webhooks for 'tenant', combined, will accept to 'q'. If 'd' has
+// not been configured with a usage store an in-memory store is used.
+func (d *WebhookDaemon) AddTenantQuota(ctx context.Context, tenant string, q *Quota) error {
+	return d.addQuota(ctx, usage.TenantKey(tenant), q)
+}
+
+// addQuota assigns 'q' to the usage key 'key'.
+func (d *WebhookDaemon) addQuota(ctx context.Context, key string, q *Quota) error {
+
+	if q.Daily < 0 || q.Monthly < 0 {
+		return fmt.Errorf("Invalid quota, values must be greater than or equal to 0")
+	}
+
+	if q.Daily == 0 && q.Monthly == 0 {
+		return fmt.Errorf("Missing daily or monthly quota")
+	}
+
+	if d.usage == nil {
+
+		err := d.AddUsage(ctx, "memory://")
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if d.quotas == nil {
+		d.quotas = make(map[string]*Quota)
+	}
+
+	d.quotas[key] = q
+	return nil
+}
+
+// usageHandlerWithLogger returns a `http.Handler` that rejects requests for webhooks that have exceeded their quota, or whose
+// tenant has, with a 429 Too Many Requests response and otherwise records the usage for requests processed by 'next'. It depends
+// on the `accessLogEntry` for a request to count dispatches so it must be wrapped by `accessLogHandlerWithLogger`. Errors reading
+// from, or writing to, the usage store are logged to 'logger' and requests are allowed.
+func (d *WebhookDaemon) usageHandlerWithLogger(next http.Handler, logger *log.Logger) http.Handler {
+
+	fn := func(rsp http.ResponseWriter, req *http.Request) {
+
+		ctx := req.Context()
+
+		wh, _, ok := d.lookupWebhook(req.URL.Path)
+
+		if !ok {
+			next.ServeHTTP(rsp, req)
+			return
+		}
+
+		// Probes and CORS preflight requests never reach the receiver so they are neither limited by, nor counted towards, quotas
+
+		if probe := wh.Probe(); probe != nil && probe.Handles(req.Method) {
+			next.ServeHTTP(rsp, req)
+			return
+		}
+
+		if wh.CORS() != nil && webhook.IsPreflight(req) {
+			next.ServeHTTP(rsp, req)
+			return
+		}
+
+		keys := []string{
+			usage.EndpointKey(wh.Endpoint()),
+		}
+
+		if wh.Tenant() != "" {
+			keys = append(keys, usage.TenantKey(wh.Tenant()))
+		}
+
+		now := time.Now()
+
+		retry_after, err := d.checkQuotas(ctx, keys, now)
+
+		if err != nil {
+			aa_log.Warning(logger, "Failed to check quota for %s, %v", wh.Endpoint(), err)
+		}
+
+		if retry_after > 0 {
+			rsp.Header().Set("Retry-After", strconv.FormatInt(int64(math.Ceil(retry_after.Seconds())), 10))
+			http.Error(rsp, "Quota exceeded", http.StatusTooManyRequests)
+			return
+		}
+
+		body := &usageReadCloser{
+			ReadCloser: req.Body,
+			mu:         new(sync.Mutex),
+		}
+
+		req.Body = body
+
+		w := &accessLogResponseWriter{
+			ResponseWriter: rsp,
+		}
+
+		next.ServeHTTP(w, req)
+
+		if w.status >= http.StatusBadRequest {
+			return
+		}
+
+		entry := accessLogEntryFromContext(ctx)
+
+		if entry == nil || entry.DryRun {
+			return
+		}
+
+		u := &usage.Usage{
+			Deliveries: 1,
+			Bytes:      body.size(),
+		}
+
+		entry.mu.Lock()
+
+		for _, r := range entry.Dispatchers {
+
+			if r.Outcome == OUTCOME_DISPATCHED {
+				u.Dispatches += 1
+			}
+		}
+
+		entry.mu.Unlock()
+
+		for _, k := range keys {
+
+			err := d.usage.Add(ctx, k, u, usage.DailyPeriod(now), usage.MonthlyPeriod(now))
+
+			if err != nil {
+				aa_log.Warning(logger, "Failed to record usage for %s, %v", k, err)
+			}
+		}
+	}
+
+	return http.HandlerFunc(fn)
+}
+
+// checkQuotas returns the duration until the quotas for 'keys', at least one of which has been exceeded at 'now', are reset. If
+// none have been exceeded it returns 0.
+func (d *WebhookDaemon) checkQuotas(ctx context.Context, keys []string, now time.Time) (time.Duration, error) {
+
+	now = now.UTC()
+
+	next_day := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+	next_month := time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+
+	var retry_after time.Duration
+
+	for _, k := range keys {
+
+		q, ok := d.quotas[k]
+
+		if !ok {
+			continue
+		}
+
+		if q.Monthly > 0 {
+
+			u, err := d.usage.Get(ctx, k, usage.MonthlyPeriod(now))
+
+			if err != nil {
+				return 0, err
+			}
+
+			if u.Deliveries >= q.Monthly && next_month.Sub(now) > retry_after {
+				retry_after = next_month.Sub(now)
+			}
+		}
+
+		if q.Daily > 0 {
+
+			u, err := d.usage.Get(ctx, k, usage.DailyPeriod(now))
+
+			if err != nil {
+				return 0, err
+			}
+
+			if u.Deliveries >= q.Daily && next_day.Sub(now) > retry_after {
+				retry_after = next_day.Sub(now)
+			}
+		}
+	}
+
+	return retry_after, nil
+}
@@ -0,0 +1,232 @@
+package daemon
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/whosonfirst/go-webhookd/v3/config"
+	"github.com/whosonfirst/go-webhookd/v3/usage"
+)
+
+func TestQuotas(t *testing.T) {
+
+	ctx := context.Background()
+
+	cfg := &config.WebhookConfig{
+		Daemon: "http://localhost:8081",
+		Receivers: map[string]string{
+			"insecure": "insecure://",
+		},
+		Dispatchers: map[string]string{
+			"null": "null://",
+		},
+		Webhooks: []config.WebhookWebhooksConfig{
+			{
+				Endpoint:    "/foo",
+				Receiver:    "insecure",
+				Dispatchers: []string{"null"},
+				Quota:       &config.WebhookQuotaConfig{Daily: 2},
+			},
+		},
+		Tenants: map[string]config.WebhookTenantConfig{
+			"acme": {
+				Receivers: map[string]string{
+					"insecure": "insecure://",
+				},
+				Dispatchers: map[string]string{
+					"null": "null://",
+				},
+				Quota: &config.WebhookQuotaConfig{Monthly: 3},
+				Webhooks: []config.WebhookWebhooksConfig{
+					{
+						Endpoint:    "/foo",
+						Receiver:    "insecure",
+						Dispatchers: []string{"null"},
+					},
+					{
+						Endpoint:    "/bar",
+						Receiver:    "insecure",
+						Dispatchers: []string{"null"},
+					},
+				},
+			},
+		},
+	}
+
+	d, err := NewWebhookDaemonFromConfig(ctx, cfg)
+
+	if err != nil {
+		t.Fatalf("Failed to create new daemon from config, %v", err)
+	}
+
+	if d.usage == nil {
+		t.Fatalf("Expected quotas to create a usage store")
+	}
+
+	handler, err := d.HandlerFunc()
+
+	if err != nil {
+		t.Fatalf("Failed to create handler func, %v", err)
+	}
+
+	logger := log.Default()
+	webhook_handler := d.accessLogHandlerWithLogger(nil, d.usageHandlerWithLogger(handler, logger), logger)
+
+	tests := []struct {
+		path     string
+		expected int
+	}{
+		{"/foo", http.StatusOK},
+		{"/foo", http.StatusOK},
+		{"/foo", http.StatusTooManyRequests},
+		{"/t/acme/foo", http.StatusOK},
+		{"/t/acme/bar", http.StatusOK},
+		{"/t/acme/foo", http.StatusOK},
+		{"/t/acme/bar", http.StatusTooManyRequests},
+		{"/bar", http.StatusNotFound},
+	}
+
+	for idx, test := range tests {
+
+		req := httptest.NewRequest("POST", test.path, strings.NewReader("hello world"))
+		rec := httptest.NewRecorder()
+
+		webhook_handler.ServeHTTP(rec, req)
+
+		if rec.Code != test.expected {
+			t.Fatalf("Expected %d for test %d (%s), got %d", test.expected, idx, test.path, rec.Code)
+		}
+
+		if rec.Code == http.StatusTooManyRequests && rec.Header().Get("Retry-After") == "" {
+			t.Fatalf("Expected Retry-After header for test %d (%s)", idx, test.path)
+		}
+	}
+
+	now := time.Now()
+
+	u, err := d.usage.Get(ctx, usage.EndpointKey("/foo"), usage.DailyPeriod(now))
+
+	if err != nil {
+		t.Fatalf("Failed to get usage, %v", err)
+	}
+
+	if u.Deliveries != 2 || u.Bytes != 22 || u.Dispatches != 2 {
+		t.Fatalf("Unexpected usage for endpoint, %v", u)
+	}
+
+	u, err = d.usage.Get(ctx, usage.TenantKey("acme"), usage.MonthlyPeriod(now))
+
+	if err != nil {
+		t.Fatalf("Failed to get usage, %v", err)
+	}
+
+	if u.Deliveries != 3 {
+		t.Fatalf("Unexpected usage for tenant, %v", u)
+	}
+}
+
+func TestQuotasInvalid(t *testing.T) {
+
+	ctx := context.Background()
+
+	d, err := NewWebhookDaemon(ctx, "http://localhost:8081")
+
+	if err != nil {
+		t.Fatalf("Failed to create new daemon, %v", err)
+	}
+
+	tests := []*Quota{
+		{},
+		{Daily: -1},
+		{Daily: 10, Monthly: -1},
+	}
+
+	for idx, q := range tests {
+
+		err := d.AddQuota(ctx, "/foo", q)
+
+		if err == nil {
+			t.Fatalf("Expected quota %d to fail", idx)
+		}
+	}
+}
+
+func TestCheckQuotas(t *testing.T) {
+
+	ctx := context.Background()
+
+	d, err := NewWebhookDaemon(ctx, "http://localhost:8081")
+
+	if err != nil {
+		t.Fatalf("Failed to create new daemon, %v", err)
+	}
+
+	err = d.AddQuota(ctx, "/foo", &Quota{Daily: 1, Monthly: 2})
+
+	if err != nil {
+		t.Fatalf("Failed to add quota, %v", err)
+	}
+
+	keys := []string{usage.EndpointKey("/foo")}
+
+	add := func(now time.Time) {
+
+		err := d.usage.Add(ctx, keys[0], &usage.Usage{Deliveries: 1}, usage.DailyPeriod(now), usage.MonthlyPeriod(now))
+
+		if err != nil {
+			t.Fatalf("Failed to add usage, %v", err)
+		}
+	}
+
+	day_one := time.Date(2024, time.January, 30, 12, 0, 0, 0, time.UTC)
+	day_two := time.Date(2024, time.January, 31, 18, 0, 0, 0, time.UTC)
+
+	retry_after, err := d.checkQuotas(ctx, keys, day_one)
+
+	if err != nil {
+		t.Fatalf("Failed to check quotas, %v", err)
+	}
+
+	if retry_after != 0 {
+		t.Fatalf("Unexpected retry after %v", retry_after)
+	}
+
+	add(day_one)
+
+	retry_after, err = d.checkQuotas(ctx, keys, day_one)
+
+	if err != nil {
+		t.Fatalf("Failed to check quotas, %v", err)
+	}
+
+	if retry_after != 12*time.Hour {
+		t.Fatalf("Expected daily quota to reset in 12h, got %v", retry_after)
+	}
+
+	add(day_two)
+
+	retry_after, err = d.checkQuotas(ctx, keys, day_two)
+
+	if err != nil {
+		t.Fatalf("Failed to check quotas, %v", err)
+	}
+
+	if retry_after != 6*time.Hour {
+		t.Fatalf("Expected monthly quota to reset in 6h, got %v", retry_after)
+	}
+
+	retry_after, err = d.checkQuotas(ctx, keys, day_two.Add(6*time.Hour))
+
+	if err != nil {
+		t.Fatalf("Failed to check quotas, %v", err)
+	}
+
+	if retry_after != 0 {
+		t.Fatalf("Expected quotas to reset in February, got %v", retry_after)
+	}
+}
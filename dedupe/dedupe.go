@@ -0,0 +1,102 @@
+// Package dedupe provides an interface for recording which keys, typically derived from the contents of webhook messages,
+// have already been seen within a given period of time.
+package dedupe
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/aaronland/go-roster"
+)
+
+// Store is an interface for recording which keys have been seen.
+type Store interface {
+	// Seen records 'key' for 'ttl' and returns a boolean value indicating whether it had already been recorded and not
+	// expired. Implementations must check and record keys atomically.
+	Seen(context.Context, string, time.Duration) (bool, error)
+	// Close releases any resources associated with the store.
+	Close() error
+}
+
+// stores is a `aaronland/go-roster.Roster` instance used to maintain a list of registered `Store` initialization functions.
+var stores roster.Roster
+
+// StoreInitializationFunc is a function used to initialize an implementation of the `Store` interface.
+type StoreInitializationFunc func(ctx context.Context, uri string) (Store, error)
+
+// NewStore returns a new `Store` instance derived from 'uri'. The semantics of and requirements for 'uri' as specific to the
+// package implementing the interface.
+func NewStore(ctx context.Context, uri string) (Store, error) {
+
+	err := ensureStoreRoster()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to ensure store roster, %w", err)
+	}
+
+	parsed, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	scheme := parsed.Scheme
+
+	i, err := stores.Driver(ctx, scheme)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to find initialization function for '%s', %w", scheme, err)
+	}
+
+	init_func := i.(StoreInitializationFunc)
+	return init_func(ctx, uri)
+}
+
+// RegisterStore associates 'scheme' with 'init_func' in an internal list of avilable `Store` implementations.
+func RegisterStore(ctx context.Context, scheme string, init_func StoreInitializationFunc) error {
+
+	err := ensureStoreRoster()
+
+	if err != nil {
+		return fmt.Errorf("Failed to ensure store roster, %w", err)
+	}
+
+	return stores.Register(ctx, scheme, init_func)
+}
+
+// ensureStoreRoster ensures that a `aaronland/go-roster.Roster` instance used to maintain a list of registered `Store`
+// initialization functions is present
+func ensureStoreRoster() error {
+
+	if stores == nil {
+
+		r, err := roster.NewDefaultRoster()
+
+		if err != nil {
+			return fmt.Errorf("Failed to create new roster, %w", err)
+		}
+
+		stores = r
+	}
+
+	return nil
+}
+
+// Schemes returns the list of schemes that have been "registered".
+func Schemes() []string {
+
+	ctx := context.Background()
+	drivers := stores.Drivers(ctx)
+
+	schemes := make([]string, len(drivers))
+
+	for idx, dr := range drivers {
+		schemes[idx] = fmt.Sprintf("%s://", dr)
+	}
+
+	sort.Strings(schemes)
+	return schemes
+}
@@ -0,0 +1,122 @@
+package dedupe
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DEFAULT_MEMORY_SIZE is the default maximum number of keys stored by a `MemoryStore`.
+const DEFAULT_MEMORY_SIZE int = 100000
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterStore(ctx, "memory", NewMemoryStore)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// MemoryStore implements the `Store` interface for keys stored in memory. Keys are not shared between processes
+// and are lost when the process exits.
+type MemoryStore struct {
+	Store
+	mu   *sync.Mutex
+	size int
+	keys map[string]time.Time
+	now  func() time.Time
+}
+
+// NewMemoryStore returns a new `MemoryStore` instance configured by 'uri' in the form of:
+//
+//	memory://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `size={INT}` The maximum number of keys to store. When the store is full the key closest to expiring is removed. Default is 100000.
+func NewMemoryStore(ctx context.Context, uri string) (Store, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	size := DEFAULT_MEMORY_SIZE
+
+	str_size := u.Query().Get("size")
+
+	if str_size != "" {
+
+		v, err := strconv.Atoi(str_size)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse ?size= parameter, %w", err)
+		}
+
+		if v <= 0 {
+			return nil, fmt.Errorf("Invalid ?size= parameter, must be greater than zero")
+		}
+
+		size = v
+	}
+
+	s := &MemoryStore{
+		mu:   new(sync.Mutex),
+		size: size,
+		keys: make(map[string]time.Time),
+		now:  time.Now,
+	}
+
+	return s, nil
+}
+
+// Seen records 'key' for 'ttl' and returns a boolean value indicating whether it had already been recorded and not expired.
+func (s *MemoryStore) Seen(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+
+	expires, ok := s.keys[key]
+
+	if ok && now.Before(expires) {
+		return true, nil
+	}
+
+	if !ok && len(s.keys) >= s.size {
+
+		var oldest string
+		var oldest_expires time.Time
+
+		for k, v := range s.keys {
+
+			if !now.Before(v) {
+				delete(s.keys, k)
+				continue
+			}
+
+			if oldest == "" || v.Before(oldest_expires) {
+				oldest = k
+				oldest_expires = v
+			}
+		}
+
+		if len(s.keys) >= s.size {
+			delete(s.keys, oldest)
+		}
+	}
+
+	s.keys[key] = now.Add(ttl)
+	return false, nil
+}
+
+// Close is a no-op for `MemoryStore` instances.
+func (s *MemoryStore) Close() error {
+	return nil
+}
@@ -0,0 +1,58 @@
+package dedupe
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStore(t *testing.T) {
+
+	ctx := context.Background()
+
+	s, err := NewStore(ctx, "memory://?size=2")
+
+	if err != nil {
+		t.Fatalf("Failed to create new memory store, %v", err)
+	}
+
+	defer s.Close()
+
+	now := time.Now()
+
+	m := s.(*MemoryStore)
+	m.now = func() time.Time { return now }
+
+	tests := []struct {
+		key      string
+		advance  time.Duration
+		expected bool
+	}{
+		{"a", 0, false},
+		{"a", 0, true},
+		{"a", time.Minute, true},
+		{"a", 2 * time.Minute, false},
+		{"b", 10 * time.Second, false},
+		{"c", 0, false},
+		{"b", 0, true},
+	}
+
+	for i, test := range tests {
+
+		now = now.Add(test.advance)
+
+		seen, err := s.Seen(ctx, test.key, 90*time.Second)
+
+		if err != nil {
+			t.Fatalf("Failed to check key '%s', %v", test.key, err)
+		}
+
+		if seen != test.expected {
+			t.Fatalf("Unexpected result for test %d (%s): %t", i, test.key, seen)
+		}
+	}
+
+	if len(m.keys) != 2 {
+		t.Fatalf("Expected store to contain 2 keys, got %d", len(m.keys))
+	}
+}
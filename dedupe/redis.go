@@ -0,0 +1,153 @@
+package dedupe
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DEFAULT_REDIS_PREFIX is the default prefix for keys stored by a `RedisStore`.
+const DEFAULT_REDIS_PREFIX string = "webhookd:dedupe:"
+
+// DEFAULT_REDIS_TIMEOUT is the default amount of time to wait for a Redis command to complete.
+const DEFAULT_REDIS_TIMEOUT time.Duration = 5 * time.Second
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterStore(ctx, "redis", NewRedisStore)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// RedisStore implements the `Store` interface for keys stored in a Redis server, allowing keys to be shared between
+// multiple webhookd processes.
+type RedisStore struct {
+	Store
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisStore returns a new `RedisStore` instance configured by 'uri' in the form of:
+//
+//	redis://[{USERNAME}:{PASSWORD}@]{HOST}:{PORT}[/{DATABASE}]?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `prefix={STRING}` The prefix for stored keys. Default is "webhookd:dedupe:".
+// * `timeout={DURATION}` The amount of time to wait for a Redis command to complete. Default is "5s".
+//
+// Connections to the Redis server are managed by a `go-redis` connection pool and established when the first key is checked.
+func NewRedisStore(ctx context.Context, uri string) (Store, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	address := u.Host
+
+	if address == "" {
+		return nil, fmt.Errorf("Missing Redis host")
+	}
+
+	if u.Port() == "" {
+		address = net.JoinHostPort(address, "6379")
+	}
+
+	opts := &redis.Options{
+		Addr:            address,
+		DialTimeout:     DEFAULT_REDIS_TIMEOUT,
+		ReadTimeout:     DEFAULT_REDIS_TIMEOUT,
+		WriteTimeout:    DEFAULT_REDIS_TIMEOUT,
+		DisableIdentity: true,
+	}
+
+	if u.User != nil {
+
+		opts.Username = u.User.Username()
+
+		password, ok := u.User.Password()
+
+		if ok {
+			opts.Password = password
+		} else {
+			// redis://{PASSWORD}@{HOST} is a common shorthand when there is no username
+			opts.Password = opts.Username
+			opts.Username = ""
+		}
+	}
+
+	str_db := strings.TrimPrefix(u.Path, "/")
+
+	if str_db != "" {
+
+		v, err := strconv.Atoi(str_db)
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid Redis database '%s', %w", str_db, err)
+		}
+
+		opts.DB = v
+	}
+
+	q := u.Query()
+
+	prefix := DEFAULT_REDIS_PREFIX
+
+	if q.Has("prefix") {
+		prefix = q.Get("prefix")
+	}
+
+	str_timeout := q.Get("timeout")
+
+	if str_timeout != "" {
+
+		v, err := time.ParseDuration(str_timeout)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse ?timeout= parameter, %w", err)
+		}
+
+		opts.DialTimeout = v
+		opts.ReadTimeout = v
+		opts.WriteTimeout = v
+	}
+
+	s := &RedisStore{
+		client: redis.NewClient(opts),
+		prefix: prefix,
+	}
+
+	return s, nil
+}
+
+// Seen records 'key' for 'ttl' and returns a boolean value indicating whether it had already been recorded and not expired.
+// Keys are recorded using the Redis `SET {KEY} 1 NX` command with an expiry of 'ttl'.
+func (s *RedisStore) Seen(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+
+	if ttl < time.Millisecond {
+		ttl = time.Millisecond
+	}
+
+	set, err := s.client.SetNX(ctx, s.prefix+key, "1", ttl).Result()
+
+	if err != nil {
+		return false, fmt.Errorf("Failed to set key, %w", err)
+	}
+
+	return !set, nil
+}
+
+// Close closes the connections to the Redis server.
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
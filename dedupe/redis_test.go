@@ -0,0 +1,83 @@
+package dedupe
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestRedisStore(t *testing.T) {
+
+	ctx := context.Background()
+
+	srv := miniredis.RunT(t)
+	srv.RequireAuth("s33kret")
+
+	uri := fmt.Sprintf("redis://:s33kret@%s/2?prefix=test:", srv.Addr())
+
+	s, err := NewStore(ctx, uri)
+
+	if err != nil {
+		t.Fatalf("Failed to create new redis store, %v", err)
+	}
+
+	defer s.Close()
+
+	for i, expected := range []bool{false, true} {
+
+		seen, err := s.Seen(ctx, "abc", time.Minute)
+
+		if err != nil {
+			t.Fatalf("Failed to check key, %v", err)
+		}
+
+		if seen != expected {
+			t.Fatalf("Unexpected result for check %d: %t", i, seen)
+		}
+	}
+
+	db := srv.DB(2)
+
+	if !db.Exists("test:abc") || db.TTL("test:abc") != time.Minute {
+		t.Fatalf("Expected key to be stored in database 2 with a TTL, got %v", db.TTL("test:abc"))
+	}
+
+	// Expired keys can be recorded again
+
+	srv.FastForward(2 * time.Minute)
+
+	seen, err := s.Seen(ctx, "abc", time.Minute)
+
+	if err != nil {
+		t.Fatalf("Failed to check key, %v", err)
+	}
+
+	if seen {
+		t.Fatalf("Expected expired key not to have been seen")
+	}
+}
+
+func TestRedisStoreAuthFailure(t *testing.T) {
+
+	ctx := context.Background()
+
+	srv := miniredis.RunT(t)
+	srv.RequireAuth("s33kret")
+
+	s, err := NewStore(ctx, fmt.Sprintf("redis://:wr0ng@%s", srv.Addr()))
+
+	if err != nil {
+		t.Fatalf("Failed to create new redis store, %v", err)
+	}
+
+	defer s.Close()
+
+	_, err = s.Seen(ctx, "abc", time.Minute)
+
+	if err == nil {
+		t.Fatalf("Expected invalid password to fail")
+	}
+}
@@ -0,0 +1,332 @@
+package dispatcher
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	aa_log "github.com/aaronland/go-log/v2"
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+const (
+	// BATCH_FORMAT_JSON flushes batches as a JSON-encoded list.
+	BATCH_FORMAT_JSON string = "json"
+	// BATCH_FORMAT_NDJSON flushes batches as newline-delimited JSON.
+	BATCH_FORMAT_NDJSON string = "ndjson"
+)
+
+// DEFAULT_BATCH_SIZE is the default maximum number of messages in a batch.
+const DEFAULT_BATCH_SIZE int = 100
+
+// DEFAULT_BATCH_INTERVAL is the default maximum amount of time a message will wait before its batch is flushed.
+const DEFAULT_BATCH_INTERVAL time.Duration = 10 * time.Second
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterDispatcher(ctx, "batch", NewBatchDispatcher)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// BatchDispatcher implements the `webhookd.WebhookDispatcher` interface for accumulating messages and relaying them, in batches,
+// to another `webhookd.WebhookDispatcher` instance.
+type BatchDispatcher struct {
+	webhookd.WebhookDispatcher
+	// dispatcher is the `webhookd.WebhookDispatcher` instance that batches are relayed to.
+	dispatcher webhookd.WebhookDispatcher
+	// logger is the `log.Logger` instance used to report errors flushing batches in the background.
+	logger *log.Logger
+	// size is the maximum number of messages in a batch.
+	size int
+	// interval is the maximum amount of time a message will wait before its batch is flushed.
+	interval time.Duration
+	// format is the encoding used for batches.
+	format string
+	// mu is a `sync.Mutex` instance used to guard 'pending' and 'timer'.
+	mu *sync.Mutex
+	// pending is the list of messages waiting to be flushed.
+	pending [][]byte
+	// timer is the `time.Timer` instance that will flush 'pending' once 'interval' has elapsed.
+	timer *time.Timer
+}
+
+// BatchDispatcherOptions is a struct containing the options for `NewBatchDispatcherWithOptions`.
+type BatchDispatcherOptions struct {
+	// Dispatcher is the `webhookd.WebhookDispatcher` instance that batches are relayed to.
+	Dispatcher webhookd.WebhookDispatcher
+	// Logger is the `log.Logger` instance used to report errors flushing batches in the background.
+	Logger *log.Logger
+	// Size is the maximum number of messages in a batch. Default is DEFAULT_BATCH_SIZE.
+	Size int
+	// Interval is the maximum amount of time a message will wait before its batch is flushed. Default is DEFAULT_BATCH_INTERVAL.
+	Interval time.Duration
+	// Format is the encoding used for batches. Valid options are BATCH_FORMAT_JSON and BATCH_FORMAT_NDJSON. Default is BATCH_FORMAT_JSON.
+	Format string
+}
+
+// NewBatchDispatcher returns a new `BatchDispatcher` instance configured by 'uri' in the form of:
+//
+//	batch://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `dispatcher={URI}` The URI-escaped URI of the dispatcher that batches will be relayed to. Required.
+// * `size={INT}` The maximum number of messages in a batch. Default is 100.
+// * `interval={DURATION}` The maximum amount of time a message will wait before its batch is flushed, for example "30s". Default is "10s".
+// * `format={STRING}` The encoding used for batches. Valid options are "json" (a JSON-encoded list) and "ndjson" (newline-delimited JSON). Default is "json".
+func NewBatchDispatcher(ctx context.Context, uri string) (webhookd.WebhookDispatcher, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	dispatcher_uri := q.Get("dispatcher")
+
+	if dispatcher_uri == "" {
+		return nil, fmt.Errorf("Missing ?dispatcher= parameter")
+	}
+
+	d, err := NewDispatcher(ctx, dispatcher_uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create dispatcher for '%s', %w", dispatcher_uri, err)
+	}
+
+	opts := &BatchDispatcherOptions{
+		Dispatcher: d,
+		Logger:     log.Default(),
+		Format:     q.Get("format"),
+	}
+
+	str_size := q.Get("size")
+
+	if str_size != "" {
+
+		v, err := strconv.Atoi(str_size)
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid ?size= parameter, %w", err)
+		}
+
+		opts.Size = v
+	}
+
+	str_interval := q.Get("interval")
+
+	if str_interval != "" {
+
+		v, err := time.ParseDuration(str_interval)
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid ?interval= parameter, %w", err)
+		}
+
+		opts.Interval = v
+	}
+
+	return NewBatchDispatcherWithOptions(ctx, opts)
+}
+
+// NewBatchDispatcherWithOptions returns a new `BatchDispatcher` instance configured by 'opts'.
+func NewBatchDispatcherWithOptions(ctx context.Context, opts *BatchDispatcherOptions) (webhookd.WebhookDispatcher, error) {
+
+	if opts.Dispatcher == nil {
+		return nil, fmt.Errorf("Missing dispatcher")
+	}
+
+	size := opts.Size
+
+	switch {
+	case size == 0:
+		size = DEFAULT_BATCH_SIZE
+	case size < 0:
+		return nil, fmt.Errorf("Invalid batch size")
+	}
+
+	interval := opts.Interval
+
+	switch {
+	case interval == 0:
+		interval = DEFAULT_BATCH_INTERVAL
+	case interval < 0:
+		return nil, fmt.Errorf("Invalid batch interval")
+	}
+
+	format := opts.Format
+
+	switch format {
+	case "":
+		format = BATCH_FORMAT_JSON
+	case BATCH_FORMAT_JSON, BATCH_FORMAT_NDJSON:
+		// pass
+	default:
+		return nil, fmt.Errorf("Invalid batch format '%s'", format)
+	}
+
+	logger := opts.Logger
+
+	if logger == nil {
+		logger = log.Default()
+	}
+
+	d := BatchDispatcher{
+		dispatcher: opts.Dispatcher,
+		logger:     logger,
+		size:       size,
+		interval:   interval,
+		format:     format,
+		mu:         new(sync.Mutex),
+		pending:    make([][]byte, 0),
+	}
+
+	return &d, nil
+}
+
+// Dispatch adds 'body' to the current batch. If the batch is full it is flushed immediately, and any error relaying it is
+// returned, otherwise it will be flushed in the background once the interval that 'd' was instantiated with has elapsed.
+func (d *BatchDispatcher) Dispatch(ctx context.Context, body []byte) *webhookd.WebhookError {
+
+	d.mu.Lock()
+
+	d.pending = append(d.pending, body)
+
+	if len(d.pending) < d.size {
+
+		if d.timer == nil {
+			d.timer = time.AfterFunc(d.interval, d.flushInBackground)
+		}
+
+		d.mu.Unlock()
+		return nil
+	}
+
+	batch := d.take()
+	d.mu.Unlock()
+
+	// Use a context that won't be cancelled when the request that happened to fill the batch completes
+
+	return d.dispatch(context.Background(), batch)
+}
+
+// QueueDepth returns the number of messages waiting to be flushed.
+func (d *BatchDispatcher) QueueDepth() int {
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return len(d.pending)
+}
+
+// Flush relays any pending messages immediately.
+func (d *BatchDispatcher) Flush(ctx context.Context) *webhookd.WebhookError {
+
+	d.mu.Lock()
+	batch := d.take()
+	d.mu.Unlock()
+
+	return d.dispatch(ctx, batch)
+}
+
+// flushInBackground relays any pending messages logging, rather than returning, errors.
+func (d *BatchDispatcher) flushInBackground() {
+
+	ctx := context.Background()
+
+	err := d.Flush(ctx)
+
+	if err != nil {
+		aa_log.Error(d.logger, "Failed to flush batch (%T), %v", d.dispatcher, err)
+	}
+}
+
+// take returns the list of pending messages and resets the current batch. It is assumed that the caller holds 'd.mu'.
+func (d *BatchDispatcher) take() [][]byte {
+
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+
+	batch := d.pending
+	d.pending = make([][]byte, 0)
+
+	return batch
+}
+
+// dispatch encodes 'batch' and relays it to the underlying dispatcher.
+func (d *BatchDispatcher) dispatch(ctx context.Context, batch [][]byte) *webhookd.WebhookError {
+
+	if len(batch) == 0 {
+		return nil
+	}
+
+	body, err := encodeBatch(batch, d.format)
+
+	if err != nil {
+		code := http.StatusInternalServerError
+		message := fmt.Sprintf("Failed to encode batch, %v", err)
+		return &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	return d.dispatcher.Dispatch(ctx, body)
+}
+
+// encodeBatch encodes 'batch' according to 'format'. Messages that are not valid JSON are encoded as JSON strings.
+func encodeBatch(batch [][]byte, format string) ([]byte, error) {
+
+	values := make([]json.RawMessage, len(batch))
+
+	for idx, body := range batch {
+
+		body = bytes.TrimSpace(body)
+
+		if json.Valid(body) {
+			values[idx] = json.RawMessage(body)
+			continue
+		}
+
+		enc, err := json.Marshal(string(body))
+
+		if err != nil {
+			return nil, err
+		}
+
+		values[idx] = json.RawMessage(enc)
+	}
+
+	if format == BATCH_FORMAT_NDJSON {
+
+		var buf bytes.Buffer
+
+		for _, v := range values {
+
+			// Compact multi-line messages so that each occupies exactly one line
+
+			err := json.Compact(&buf, v)
+
+			if err != nil {
+				return nil, err
+			}
+
+			buf.WriteByte('\n')
+		}
+
+		return buf.Bytes(), nil
+	}
+
+	return json.Marshal(values)
+}
@@ -0,0 +1,131 @@
+package dispatcher
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBatchDispatcher(t *testing.T) {
+
+	ctx := context.Background()
+
+	root := t.TempDir()
+
+	file_uri := fmt.Sprintf("file://%s", root)
+	batch_uri := fmt.Sprintf("batch://?size=2&interval=1h&dispatcher=%s", url.QueryEscape(file_uri))
+
+	d, err := NewDispatcher(ctx, batch_uri)
+
+	if err != nil {
+		t.Fatalf("Failed to create new dispatcher, %v", err)
+	}
+
+	for i, body := range []string{`{"id": 1}`, "hello world"} {
+
+		err2 := d.Dispatch(ctx, []byte(body))
+
+		if err2 != nil {
+			t.Fatalf("Failed to dispatch message, %v", err2)
+		}
+
+		if i == 0 && d.(QueuedDispatcher).QueueDepth() != 1 {
+			t.Fatalf("Expected one message to be queued")
+		}
+	}
+
+	if d.(QueuedDispatcher).QueueDepth() != 0 {
+		t.Fatalf("Expected queue to be empty after flush")
+	}
+
+	matches, err := filepath.Glob(filepath.Join(root, "*"))
+
+	if err != nil {
+		t.Fatalf("Failed to glob output, %v", err)
+	}
+
+	if len(matches) != 1 {
+		t.Fatalf("Expected exactly one output file, got %d", len(matches))
+	}
+
+	body, err := os.ReadFile(matches[0])
+
+	if err != nil {
+		t.Fatalf("Failed to read output, %v", err)
+	}
+
+	if string(body) != `[{"id":1},"hello world"]` {
+		t.Fatalf("Unexpected output '%s'", string(body))
+	}
+}
+
+func TestBatchDispatcherInterval(t *testing.T) {
+
+	ctx := context.Background()
+
+	root := t.TempDir()
+
+	file_uri := fmt.Sprintf("file://%s", root)
+	batch_uri := fmt.Sprintf("batch://?format=ndjson&interval=10ms&dispatcher=%s", url.QueryEscape(file_uri))
+
+	d, err := NewDispatcher(ctx, batch_uri)
+
+	if err != nil {
+		t.Fatalf("Failed to create new dispatcher, %v", err)
+	}
+
+	for _, body := range []string{"{\n\"id\": 1\n}", `{"id":2}`} {
+
+		err2 := d.Dispatch(ctx, []byte(body))
+
+		if err2 != nil {
+			t.Fatalf("Failed to dispatch message, %v", err2)
+		}
+	}
+
+	var matches []string
+
+	for i := 0; i < 100; i++ {
+
+		matches, err = filepath.Glob(filepath.Join(root, "webhookd-*"))
+
+		if err != nil {
+			t.Fatalf("Failed to glob output, %v", err)
+		}
+
+		if len(matches) > 0 {
+			break
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if len(matches) != 1 {
+		t.Fatalf("Expected exactly one output file, got %d", len(matches))
+	}
+
+	body, err := os.ReadFile(matches[0])
+
+	if err != nil {
+		t.Fatalf("Failed to read output, %v", err)
+	}
+
+	if string(body) != "{\"id\":1}\n{\"id\":2}\n" {
+		t.Fatalf("Unexpected output '%s'", string(body))
+	}
+}
+
+func TestBatchDispatcherMissingDispatcher(t *testing.T) {
+
+	ctx := context.Background()
+
+	_, err := NewDispatcher(ctx, "batch://?size=10")
+
+	if err == nil {
+		t.Fatalf("Expected missing dispatcher to fail")
+	}
+}
@@ -0,0 +1,180 @@
+package dispatcher
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterDispatcher(ctx, "capture", NewCaptureDispatcher)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// CAPTURE_DEFAULT_NAME is the name of the store that messages are captured in if a `capture://` URI does not define one.
+const CAPTURE_DEFAULT_NAME string = "default"
+
+// CAPTURE_DEFAULT_MAX is the default maximum number of messages kept in each capture store.
+const CAPTURE_DEFAULT_MAX int = 1000
+
+// CapturedMessage is a message dispatched by a `CaptureDispatcher` instance.
+type CapturedMessage struct {
+	// Name is the name of the store the message was captured in.
+	Name string
+	// DeliveryID is the delivery ID of the message, if known.
+	DeliveryID string
+	// Body is the body of the message, after any transformations have been applied.
+	Body []byte
+	// Time is the time the message was dispatched.
+	Time time.Time
+}
+
+// captureStores is the dictionary of named lists of captured messages shared by all `CaptureDispatcher` instances in a process.
+var captureStores = make(map[string][]*CapturedMessage)
+
+// captureMu is the mutex used to guard `captureStores`.
+var captureMu = new(sync.RWMutex)
+
+// CaptureDispatcher implements the `webhookd.WebhookDispatcher` interface for dispatching messages to an in-memory store, so that
+// the output of transformation pipelines can be inspected by automated tests. Captured messages are retrieved with `CapturedMessages`
+// or, for a running daemon, the capture endpoint (see `daemon.AddCaptureEndpoint`).
+type CaptureDispatcher struct {
+	webhookd.WebhookDispatcher
+	// name is the name of the store that messages are captured in.
+	name string
+	// max is the maximum number of messages kept in the store.
+	max int
+}
+
+// NewCaptureDispatcher returns a new `CaptureDispatcher` instance configured by 'uri' in the form of:
+//
+//	capture://{NAME}?{PARAMETERS}
+//
+// Where {NAME} is the name of the store that messages are captured in. Dispatchers with the same name share a store. Default is
+// "default". Valid {PARAMETERS} are:
+// * `max={INT}` The maximum number of messages kept in the store, after which the oldest messages are discarded. The most
+// recent value for a store wins. Default is 1000.
+func NewCaptureDispatcher(ctx context.Context, uri string) (webhookd.WebhookDispatcher, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	name := u.Host
+
+	if name == "" {
+		name = CAPTURE_DEFAULT_NAME
+	}
+
+	max := CAPTURE_DEFAULT_MAX
+
+	str_max := u.Query().Get("max")
+
+	if str_max != "" {
+
+		v, err := strconv.Atoi(str_max)
+
+		if err != nil || v <= 0 {
+			return nil, fmt.Errorf("Invalid ?max= parameter '%s'", str_max)
+		}
+
+		max = v
+	}
+
+	d := &CaptureDispatcher{
+		name: name,
+		max:  max,
+	}
+
+	return d, nil
+}
+
+// Dispatch appends a copy of 'body' to the store for 'd'.
+func (d *CaptureDispatcher) Dispatch(ctx context.Context, body []byte) *webhookd.WebhookError {
+
+	m := &CapturedMessage{
+		Name:       d.name,
+		DeliveryID: webhookd.DeliveryID(ctx),
+		Body:       append([]byte(nil), body...),
+		Time:       time.Now(),
+	}
+
+	captureMu.Lock()
+	defer captureMu.Unlock()
+
+	messages := append(captureStores[d.name], m)
+
+	if len(messages) > d.max {
+		messages = messages[len(messages)-d.max:]
+	}
+
+	captureStores[d.name] = messages
+	return nil
+}
+
+// CapturedMessages returns the list of messages captured in the store named 'name', oldest first. If 'name' is empty the messages
+// in all stores are returned, sorted by the time they were dispatched.
+func CapturedMessages(name string) []*CapturedMessage {
+
+	captureMu.RLock()
+	defer captureMu.RUnlock()
+
+	if name != "" {
+		return append([]*CapturedMessage(nil), captureStores[name]...)
+	}
+
+	messages := make([]*CapturedMessage, 0)
+
+	for _, store := range captureStores {
+		messages = append(messages, store...)
+	}
+
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].Time.Before(messages[j].Time)
+	})
+
+	return messages
+}
+
+// CaptureStores returns the sorted list of names of the stores that messages have been captured in.
+func CaptureStores() []string {
+
+	captureMu.RLock()
+	defer captureMu.RUnlock()
+
+	names := make([]string, 0, len(captureStores))
+
+	for name := range captureStores {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// ResetCapturedMessages removes the messages captured in the store named 'name' or, if 'name' is empty, in all stores.
+func ResetCapturedMessages(name string) {
+
+	captureMu.Lock()
+	defer captureMu.Unlock()
+
+	if name != "" {
+		delete(captureStores, name)
+		return
+	}
+
+	captureStores = make(map[string][]*CapturedMessage)
+}
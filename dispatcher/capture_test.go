@@ -0,0 +1,90 @@
+package dispatcher
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+func TestCaptureDispatcher(t *testing.T) {
+
+	ctx := context.Background()
+
+	ResetCapturedMessages("")
+	defer ResetCapturedMessages("")
+
+	d, err := NewDispatcher(ctx, "capture://test?max=2")
+
+	if err != nil {
+		t.Fatalf("Failed to create new dispatcher, %v", err)
+	}
+
+	other, err := NewDispatcher(ctx, "capture://")
+
+	if err != nil {
+		t.Fatalf("Failed to create new dispatcher, %v", err)
+	}
+
+	msg_ctx := webhookd.WithDeliveryID(ctx, "abc")
+
+	for i := 0; i < 3; i++ {
+
+		err2 := d.Dispatch(msg_ctx, []byte(fmt.Sprintf("message %d", i)))
+
+		if err2 != nil {
+			t.Fatalf("Failed to dispatch message, %v", err2)
+		}
+	}
+
+	err2 := other.Dispatch(ctx, []byte("other"))
+
+	if err2 != nil {
+		t.Fatalf("Failed to dispatch message, %v", err2)
+	}
+
+	messages := CapturedMessages("test")
+
+	if len(messages) != 2 {
+		t.Fatalf("Expected 2 messages, got %d", len(messages))
+	}
+
+	if string(messages[0].Body) != "message 1" || string(messages[1].Body) != "message 2" {
+		t.Fatalf("Unexpected messages '%s', '%s'", string(messages[0].Body), string(messages[1].Body))
+	}
+
+	if messages[0].DeliveryID != "abc" {
+		t.Fatalf("Unexpected delivery ID '%s'", messages[0].DeliveryID)
+	}
+
+	if len(CapturedMessages("")) != 3 {
+		t.Fatalf("Expected 3 messages in all stores")
+	}
+
+	stores := CaptureStores()
+
+	if len(stores) != 2 || stores[0] != CAPTURE_DEFAULT_NAME || stores[1] != "test" {
+		t.Fatalf("Unexpected stores %v", stores)
+	}
+
+	ResetCapturedMessages("test")
+
+	if len(CapturedMessages("test")) != 0 || len(CapturedMessages(CAPTURE_DEFAULT_NAME)) != 1 {
+		t.Fatalf("Expected reset to only remove messages in the 'test' store")
+	}
+}
+
+func TestCaptureDispatcherInvalid(t *testing.T) {
+
+	ctx := context.Background()
+
+	for _, uri := range []string{"capture://?max=0", "capture://?max=bogus"} {
+
+		_, err := NewDispatcher(ctx, uri)
+
+		if err == nil {
+			t.Fatalf("Expected '%s' to fail", uri)
+		}
+	}
+}
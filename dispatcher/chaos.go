@@ -0,0 +1,281 @@
+package dispatcher
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterDispatcher(ctx, "chaos", NewChaosDispatcher)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// ChaosDispatcher implements the `webhookd.WebhookDispatcher` interface for relaying messages to another `webhookd.WebhookDispatcher`
+// instance while injecting latency and errors, so that the retry and circuit-breaking behaviour of upstream providers and
+// downstream services can be exercised in staging environments.
+type ChaosDispatcher struct {
+	webhookd.WebhookDispatcher
+	// dispatcher is the `webhookd.WebhookDispatcher` instance that messages are relayed to.
+	dispatcher webhookd.WebhookDispatcher
+	// latency is the amount of time to wait before relaying a message.
+	latency time.Duration
+	// jitter is the maximum amount of random time added to 'latency'.
+	jitter time.Duration
+	// latencyRate is the probability, between 0 and 1, that latency is injected for a message.
+	latencyRate float64
+	// errorRate is the probability, between 0 and 1, that an error is returned instead of relaying a message.
+	errorRate float64
+	// errorCode is the status code of injected errors.
+	errorCode int
+	// random is the `rand.Rand` instance used to decide whether to inject latency or errors.
+	random *rand.Rand
+	// mu is a `sync.Mutex` used to guard 'random' which is not safe for concurrent use.
+	mu *sync.Mutex
+}
+
+// ChaosDispatcherOptions is a struct containing the options for `NewChaosDispatcherWithOptions`.
+type ChaosDispatcherOptions struct {
+	// Dispatcher is the `webhookd.WebhookDispatcher` instance that messages are relayed to.
+	Dispatcher webhookd.WebhookDispatcher
+	// Latency is the amount of time to wait before relaying a message.
+	Latency time.Duration
+	// Jitter is the maximum amount of random time added to `Latency`.
+	Jitter time.Duration
+	// LatencyRate is the probability, between 0 and 1, that latency is injected for a message.
+	LatencyRate float64
+	// ErrorRate is the probability, between 0 and 1, that an error is returned instead of relaying a message.
+	ErrorRate float64
+	// ErrorCode is the status code of injected errors. Default is 503 (Service Unavailable).
+	ErrorCode int
+	// Seed is the optional seed used to decide whether to inject latency or errors. If 0 a seed derived from the current time is used.
+	Seed int64
+}
+
+// NewChaosDispatcher returns a new `ChaosDispatcher` instance configured by 'uri' in the form of:
+//
+//	chaos://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `dispatcher={URI}` The URI-escaped URI of the dispatcher that messages will be relayed to. Required.
+// * `latency={DURATION}` The amount of time to wait before relaying a message, for example "500ms".
+// * `jitter={DURATION}` The maximum amount of random time added to `latency`, for example "250ms".
+// * `latency_rate={FLOAT}` The probability, between 0 and 1, that latency is injected for a message. Default is 1.
+// * `error_rate={FLOAT}` The probability, between 0 and 1, that an error is returned instead of relaying a message. Default is 0.
+// * `error_code={INT}` The status code of injected errors. Default is 503.
+// * `seed={INT}` An optional seed used to decide whether to inject latency or errors, for reproducible runs.
+func NewChaosDispatcher(ctx context.Context, uri string) (webhookd.WebhookDispatcher, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	dispatcher_uri := q.Get("dispatcher")
+
+	if dispatcher_uri == "" {
+		return nil, fmt.Errorf("Missing ?dispatcher= parameter")
+	}
+
+	d, err := NewDispatcher(ctx, dispatcher_uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create dispatcher for '%s', %w", dispatcher_uri, err)
+	}
+
+	opts := &ChaosDispatcherOptions{
+		Dispatcher:  d,
+		LatencyRate: 1.0,
+	}
+
+	durations := map[string]*time.Duration{
+		"latency": &opts.Latency,
+		"jitter":  &opts.Jitter,
+	}
+
+	for k, ptr := range durations {
+
+		str_v := q.Get(k)
+
+		if str_v == "" {
+			continue
+		}
+
+		v, err := time.ParseDuration(str_v)
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid ?%s= parameter, %w", k, err)
+		}
+
+		*ptr = v
+	}
+
+	rates := map[string]*float64{
+		"latency_rate": &opts.LatencyRate,
+		"error_rate":   &opts.ErrorRate,
+	}
+
+	for k, ptr := range rates {
+
+		str_v := q.Get(k)
+
+		if str_v == "" {
+			continue
+		}
+
+		v, err := strconv.ParseFloat(str_v, 64)
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid ?%s= parameter, %w", k, err)
+		}
+
+		*ptr = v
+	}
+
+	str_code := q.Get("error_code")
+
+	if str_code != "" {
+
+		v, err := strconv.Atoi(str_code)
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid ?error_code= parameter, %w", err)
+		}
+
+		opts.ErrorCode = v
+	}
+
+	str_seed := q.Get("seed")
+
+	if str_seed != "" {
+
+		v, err := strconv.ParseInt(str_seed, 10, 64)
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid ?seed= parameter, %w", err)
+		}
+
+		opts.Seed = v
+	}
+
+	return NewChaosDispatcherWithOptions(ctx, opts)
+}
+
+// NewChaosDispatcherWithOptions returns a new `ChaosDispatcher` instance configured by 'opts'.
+func NewChaosDispatcherWithOptions(ctx context.Context, opts *ChaosDispatcherOptions) (webhookd.WebhookDispatcher, error) {
+
+	if opts.Dispatcher == nil {
+		return nil, fmt.Errorf("Missing dispatcher")
+	}
+
+	if opts.Latency < 0 || opts.Jitter < 0 {
+		return nil, fmt.Errorf("Invalid latency")
+	}
+
+	if opts.LatencyRate < 0 || opts.LatencyRate > 1 {
+		return nil, fmt.Errorf("Invalid latency rate, must be between 0 and 1")
+	}
+
+	if opts.ErrorRate < 0 || opts.ErrorRate > 1 {
+		return nil, fmt.Errorf("Invalid error rate, must be between 0 and 1")
+	}
+
+	error_code := opts.ErrorCode
+
+	if error_code == 0 {
+		error_code = http.StatusServiceUnavailable
+	}
+
+	if error_code < 400 || error_code > 599 {
+		return nil, fmt.Errorf("Invalid error code, must be a 4XX or 5XX status code")
+	}
+
+	seed := opts.Seed
+
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	d := ChaosDispatcher{
+		dispatcher:  opts.Dispatcher,
+		latency:     opts.Latency,
+		jitter:      opts.Jitter,
+		latencyRate: opts.LatencyRate,
+		errorRate:   opts.ErrorRate,
+		errorCode:   error_code,
+		random:      rand.New(rand.NewSource(seed)),
+		mu:          new(sync.Mutex),
+	}
+
+	return &d, nil
+}
+
+// Dispatch waits for the latency, if any, injected for 'body' and then either returns an injected error or relays 'body' to the
+// dispatcher that 'd' was instantiated with. If 'ctx' is cancelled while waiting a 504 (Gateway Timeout) error is returned.
+func (d *ChaosDispatcher) Dispatch(ctx context.Context, body []byte) *webhookd.WebhookError {
+
+	wait, fail := d.roll()
+
+	if wait > 0 {
+
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+
+		select {
+		case <-ctx.Done():
+			code := http.StatusGatewayTimeout
+			message := fmt.Sprintf("Context cancelled while waiting for injected latency, %v", ctx.Err())
+			return &webhookd.WebhookError{Code: code, Message: message}
+		case <-timer.C:
+			// pass
+		}
+	}
+
+	if fail {
+		code := d.errorCode
+		message := "Injected error"
+		return &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	return d.dispatcher.Dispatch(ctx, body)
+}
+
+// roll returns the amount of latency to inject for a message and whether to return an error instead of relaying it.
+func (d *ChaosDispatcher) roll() (time.Duration, bool) {
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var wait time.Duration
+
+	if d.latency > 0 || d.jitter > 0 {
+
+		if d.random.Float64() < d.latencyRate {
+
+			wait = d.latency
+
+			if d.jitter > 0 {
+				wait += time.Duration(d.random.Int63n(int64(d.jitter) + 1))
+			}
+		}
+	}
+
+	fail := d.errorRate > 0 && d.random.Float64() < d.errorRate
+
+	return wait, fail
+}
@@ -0,0 +1,106 @@
+package dispatcher
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestChaosDispatcher(t *testing.T) {
+
+	ctx := context.Background()
+
+	ResetCapturedMessages("chaos")
+	defer ResetCapturedMessages("chaos")
+
+	capture_uri := url.QueryEscape("capture://chaos")
+
+	d, err := NewDispatcher(ctx, "chaos://?error_rate=0.5&error_code=502&seed=1234&dispatcher="+capture_uri)
+
+	if err != nil {
+		t.Fatalf("Failed to create new dispatcher, %v", err)
+	}
+
+	failed := 0
+
+	for i := 0; i < 100; i++ {
+
+		err2 := d.Dispatch(ctx, []byte("hello world"))
+
+		if err2 == nil {
+			continue
+		}
+
+		if err2.Code != http.StatusBadGateway {
+			t.Fatalf("Unexpected error code %d", err2.Code)
+		}
+
+		failed += 1
+	}
+
+	if failed == 0 || failed == 100 {
+		t.Fatalf("Expected some but not all messages to fail, %d failed", failed)
+	}
+
+	captured := len(CapturedMessages("chaos"))
+
+	if captured != 100-failed {
+		t.Fatalf("Expected %d messages to be relayed, got %d", 100-failed, captured)
+	}
+}
+
+func TestChaosDispatcherLatency(t *testing.T) {
+
+	ctx := context.Background()
+
+	d, err := NewDispatcher(ctx, "chaos://?latency=50ms&jitter=10ms&dispatcher=null://")
+
+	if err != nil {
+		t.Fatalf("Failed to create new dispatcher, %v", err)
+	}
+
+	t1 := time.Now()
+
+	err2 := d.Dispatch(ctx, []byte("hello world"))
+
+	if err2 != nil {
+		t.Fatalf("Failed to dispatch message, %v", err2)
+	}
+
+	if time.Since(t1) < 50*time.Millisecond {
+		t.Fatalf("Expected latency to be injected")
+	}
+
+	cancel_ctx, cancel := context.WithTimeout(ctx, 5*time.Millisecond)
+	defer cancel()
+
+	err2 = d.Dispatch(cancel_ctx, []byte("hello world"))
+
+	if err2 == nil || err2.Code != http.StatusGatewayTimeout {
+		t.Fatalf("Expected cancelled context to return a gateway timeout, %v", err2)
+	}
+}
+
+func TestChaosDispatcherInvalid(t *testing.T) {
+
+	ctx := context.Background()
+
+	uris := []string{
+		"chaos://",
+		"chaos://?dispatcher=null://&error_rate=2",
+		"chaos://?dispatcher=null://&latency_rate=-1",
+		"chaos://?dispatcher=null://&latency=bogus",
+		"chaos://?dispatcher=null://&error_code=200",
+	}
+
+	for _, uri := range uris {
+
+		_, err := NewDispatcher(ctx, uri)
+
+		if err == nil {
+			t.Fatalf("Expected '%s' to fail", uri)
+		}
+	}
+}
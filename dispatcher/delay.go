@@ -0,0 +1,557 @@
+package dispatcher
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	aa_log "github.com/aaronland/go-log/v2"
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterDispatcher(ctx, "delay", NewDelayDispatcher)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// DELAY_SPOOL_EXTENSION is the file extension for messages persisted in the spool directory of a `DelayDispatcher`.
+const DELAY_SPOOL_EXTENSION string = ".delayed"
+
+// DelayDispatcher implements the `webhookd.WebhookDispatcher` interface for relaying messages to another `webhookd.WebhookDispatcher`
+// instance after a fixed delay or during a recurring window of time.
+type DelayDispatcher struct {
+	webhookd.WebhookDispatcher
+	// dispatcher is the `webhookd.WebhookDispatcher` instance that messages are relayed to.
+	dispatcher webhookd.WebhookDispatcher
+	// logger is the `log.Logger` instance used to report errors relaying messages in the background.
+	logger *log.Logger
+	// delay is the minimum amount of time to wait before relaying a message.
+	delay time.Duration
+	// window is the optional `DispatchWindow` during which messages may be relayed.
+	window *DispatchWindow
+	// spool is the optional directory where messages waiting to be relayed are persisted.
+	spool string
+	// wg is a `sync.WaitGroup` instance used to track messages waiting to be relayed.
+	wg *sync.WaitGroup
+	// waiting is the number of messages waiting to be relayed.
+	waiting *int64
+	// now is the function used to determine the current time.
+	now func() time.Time
+}
+
+// DelayDispatcherOptions is a struct containing the options for `NewDelayDispatcherWithOptions`.
+type DelayDispatcherOptions struct {
+	// Dispatcher is the `webhookd.WebhookDispatcher` instance that messages are relayed to.
+	Dispatcher webhookd.WebhookDispatcher
+	// Logger is the `log.Logger` instance used to report errors relaying messages in the background.
+	Logger *log.Logger
+	// Delay is the minimum amount of time to wait before relaying a message.
+	Delay time.Duration
+	// Window is the optional `DispatchWindow` during which messages may be relayed.
+	Window *DispatchWindow
+	// Spool is the optional directory where messages waiting to be relayed are persisted so that they survive a restart.
+	Spool string
+}
+
+// NewDelayDispatcher returns a new `DelayDispatcher` instance configured by 'uri' in the form of:
+//
+//	delay://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `dispatcher={URI}` The URI-escaped URI of the dispatcher that messages will be relayed to. Required.
+// * `delay={DURATION}` The minimum amount of time to wait before relaying a message, for example "10m".
+// * `days={DAYS}` An optional comma-separated list of days, or ranges of days, during which messages may be relayed, for example "mon-fri".
+// * `hours={HH:MM-HH:MM}` An optional range of hours during which messages may be relayed, for example "09:00-17:00".
+// * `timezone={TZ}` The timezone used to interpret `days` and `hours`. Default is "UTC".
+// * `spool={PATH}` An optional directory where messages waiting to be relayed are persisted so that they survive a restart.
+//
+// At least one of `delay`, `days` or `hours` must be present. If `spool` is absent messages waiting to be relayed are only held
+// in memory and will be lost if the process is stopped.
+func NewDelayDispatcher(ctx context.Context, uri string) (webhookd.WebhookDispatcher, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	dispatcher_uri := q.Get("dispatcher")
+
+	if dispatcher_uri == "" {
+		return nil, fmt.Errorf("Missing ?dispatcher= parameter")
+	}
+
+	d, err := NewDispatcher(ctx, dispatcher_uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create dispatcher for '%s', %w", dispatcher_uri, err)
+	}
+
+	opts := &DelayDispatcherOptions{
+		Dispatcher: d,
+		Logger:     log.Default(),
+	}
+
+	str_delay := q.Get("delay")
+
+	if str_delay != "" {
+
+		v, err := time.ParseDuration(str_delay)
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid ?delay= parameter, %w", err)
+		}
+
+		opts.Delay = v
+	}
+
+	str_days := q.Get("days")
+	str_hours := q.Get("hours")
+
+	if str_days != "" || str_hours != "" {
+
+		w, err := NewDispatchWindow(str_days, str_hours, q.Get("timezone"))
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid dispatch window, %w", err)
+		}
+
+		opts.Window = w
+	}
+
+	opts.Spool = q.Get("spool")
+
+	return NewDelayDispatcherWithOptions(ctx, opts)
+}
+
+// NewDelayDispatcherWithOptions returns a new `DelayDispatcher` instance configured by 'opts'. If 'opts' defines a spool directory
+// any messages persisted there by a previous instance are scheduled to be relayed, immediately if their time has already passed.
+func NewDelayDispatcherWithOptions(ctx context.Context, opts *DelayDispatcherOptions) (webhookd.WebhookDispatcher, error) {
+
+	if opts.Dispatcher == nil {
+		return nil, fmt.Errorf("Missing dispatcher")
+	}
+
+	if opts.Delay < 0 {
+		return nil, fmt.Errorf("Invalid delay")
+	}
+
+	if opts.Delay == 0 && opts.Window == nil {
+		return nil, fmt.Errorf("Missing delay or dispatch window")
+	}
+
+	logger := opts.Logger
+
+	if logger == nil {
+		logger = log.Default()
+	}
+
+	d := DelayDispatcher{
+		dispatcher: opts.Dispatcher,
+		logger:     logger,
+		delay:      opts.Delay,
+		window:     opts.Window,
+		spool:      opts.Spool,
+		wg:         new(sync.WaitGroup),
+		waiting:    new(int64),
+		now:        time.Now,
+	}
+
+	if d.spool != "" {
+
+		info, err := os.Stat(d.spool)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to stat '%s', %w", d.spool, err)
+		}
+
+		if !info.IsDir() {
+			return nil, fmt.Errorf("'%s' is not a directory", d.spool)
+		}
+
+		err = d.restore()
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to recover spooled messages, %w", err)
+		}
+	}
+
+	return &d, nil
+}
+
+// Dispatch schedules 'body' to be relayed once the delay that 'd' was instantiated with has elapsed and the dispatch window, if
+// defined, is open. If the message can be relayed immediately then it is relayed synchronously and any errors are returned. Otherwise
+// the message is relayed in the background and any errors are logged.
+//
+// Messages relayed in the background are held in memory and are lost if the process is stopped before they are relayed unless 'd'
+// was instantiated with a spool directory. In that case each message is written to the spool directory before Dispatch returns, and
+// only removed once it has been relayed successfully, and a `http.StatusServiceUnavailable` error is returned if it can not be written.
+// Messages that fail to be relayed remain in the spool directory and are relayed again the next time a `DelayDispatcher` is created
+// for that directory.
+func (d *DelayDispatcher) Dispatch(ctx context.Context, body []byte) *webhookd.WebhookError {
+
+	now := d.now()
+	t := now.Add(d.delay)
+
+	if d.window != nil {
+		t = d.window.Next(t)
+	}
+
+	wait := t.Sub(now)
+
+	if wait <= 0 {
+		return d.dispatcher.Dispatch(ctx, body)
+	}
+
+	path := ""
+
+	if d.spool != "" {
+
+		v, err := d.persist(t, body)
+
+		if err != nil {
+			code := http.StatusServiceUnavailable
+			message := fmt.Sprintf("Failed to persist delayed message, %v", err)
+			return &webhookd.WebhookError{Code: code, Message: message}
+		}
+
+		path = v
+	}
+
+	d.schedule(wait, body, path)
+	return nil
+}
+
+// schedule relays 'body' in the background after 'wait' has elapsed, removing 'path' if it is not empty and 'body' is relayed successfully.
+func (d *DelayDispatcher) schedule(wait time.Duration, body []byte, path string) {
+
+	d.wg.Add(1)
+	atomic.AddInt64(d.waiting, 1)
+
+	time.AfterFunc(wait, func() {
+
+		defer d.wg.Done()
+		defer atomic.AddInt64(d.waiting, -1)
+
+		// The original request will have completed (and its context cancelled) by now
+
+		err := d.dispatcher.Dispatch(context.Background(), body)
+
+		if err != nil {
+			aa_log.Error(d.logger, "Failed to dispatch delayed message (%T), %v", d.dispatcher, err)
+			return
+		}
+
+		if path != "" {
+
+			err := os.Remove(path)
+
+			if err != nil {
+				aa_log.Error(d.logger, "Failed to remove spooled message '%s', %v", path, err)
+			}
+		}
+	})
+}
+
+// persist writes 'body' to a new file in the spool directory, whose name encodes 't', returning its path.
+func (d *DelayDispatcher) persist(t time.Time, body []byte) (string, error) {
+
+	wr, err := os.CreateTemp(d.spool, ".webhookd-*")
+
+	if err != nil {
+		return "", fmt.Errorf("Failed to create temporary file, %w", err)
+	}
+
+	tmp_path := wr.Name()
+
+	_, err = wr.Write(body)
+
+	if err == nil {
+		err = wr.Sync()
+	}
+
+	if err != nil {
+		wr.Close()
+		os.Remove(tmp_path)
+		return "", fmt.Errorf("Failed to write message, %w", err)
+	}
+
+	err = wr.Close()
+
+	if err != nil {
+		os.Remove(tmp_path)
+		return "", fmt.Errorf("Failed to close temporary file, %w", err)
+	}
+
+	suffix := strings.TrimPrefix(filepath.Base(tmp_path), ".webhookd-")
+	fname := fmt.Sprintf("%d-%s%s", t.UnixNano(), suffix, DELAY_SPOOL_EXTENSION)
+	path := filepath.Join(d.spool, fname)
+
+	err = os.Rename(tmp_path, path)
+
+	if err != nil {
+		os.Remove(tmp_path)
+		return "", fmt.Errorf("Failed to rename temporary file, %w", err)
+	}
+
+	return path, nil
+}
+
+// restore schedules the messages in the spool directory to be relayed at the times encoded in their filenames.
+func (d *DelayDispatcher) restore() error {
+
+	matches, err := filepath.Glob(filepath.Join(d.spool, "*"+DELAY_SPOOL_EXTENSION))
+
+	if err != nil {
+		return fmt.Errorf("Failed to list spool directory, %w", err)
+	}
+
+	now := d.now()
+
+	for _, path := range matches {
+
+		fname := filepath.Base(path)
+		parts := strings.SplitN(fname, "-", 2)
+
+		ns, err := strconv.ParseInt(parts[0], 10, 64)
+
+		if err != nil || len(parts) != 2 {
+			aa_log.Warning(d.logger, "Ignoring spooled message with invalid filename '%s'", path)
+			continue
+		}
+
+		body, err := os.ReadFile(path)
+
+		if err != nil {
+			return fmt.Errorf("Failed to read '%s', %w", path, err)
+		}
+
+		wait := time.Unix(0, ns).Sub(now)
+
+		if wait < 0 {
+			wait = 0
+		}
+
+		d.schedule(wait, body, path)
+	}
+
+	return nil
+}
+
+// QueueDepth returns the number of messages waiting to be relayed.
+func (d *DelayDispatcher) QueueDepth() int {
+	return int(atomic.LoadInt64(d.waiting))
+}
+
+// Wait blocks until all the messages scheduled by 'd' have been relayed.
+func (d *DelayDispatcher) Wait() {
+	d.wg.Wait()
+}
+
+// DispatchWindow is a struct describing a recurring window of time, defined by days of the week and hours of the day, during which
+// messages may be relayed.
+type DispatchWindow struct {
+	// days is a dictionary of the days of the week included in the window.
+	days map[time.Weekday]bool
+	// start is the offset from midnight when the window opens.
+	start time.Duration
+	// end is the offset from midnight when the window closes. If 'end' is less than 'start' the window spans midnight.
+	end time.Duration
+	// location is the `time.Location` used to interpret days and hours.
+	location *time.Location
+}
+
+// weekdays maps three-letter day names to their corresponding `time.Weekday` values.
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// NewDispatchWindow returns a new `DispatchWindow` instance. 'days' is a comma-separated list of three-letter day names or ranges
+// of day names (for example "mon-fri" or "mon,wed,fri"), 'hours' is a range of 24-hour times (for example "09:00-17:00") and 'timezone'
+// is a valid IANA timezone name. An empty 'days' includes every day, an empty 'hours' includes every hour and an empty 'timezone' is
+// interpreted as UTC.
+func NewDispatchWindow(days string, hours string, timezone string) (*DispatchWindow, error) {
+
+	loc := time.UTC
+
+	if timezone != "" {
+
+		v, err := time.LoadLocation(timezone)
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid timezone, %w", err)
+		}
+
+		loc = v
+	}
+
+	w := &DispatchWindow{
+		days:     make(map[time.Weekday]bool),
+		start:    0,
+		end:      24 * time.Hour,
+		location: loc,
+	}
+
+	if days == "" {
+		days = "sun-sat"
+	}
+
+	for _, part := range strings.Split(strings.ToLower(days), ",") {
+
+		part = strings.TrimSpace(part)
+		bounds := strings.SplitN(part, "-", 2)
+
+		first, ok := weekdays[bounds[0]]
+
+		if !ok {
+			return nil, fmt.Errorf("Invalid day '%s'", bounds[0])
+		}
+
+		last := first
+
+		if len(bounds) == 2 {
+
+			v, ok := weekdays[bounds[1]]
+
+			if !ok {
+				return nil, fmt.Errorf("Invalid day '%s'", bounds[1])
+			}
+
+			last = v
+		}
+
+		for d := first; ; d = (d + 1) % 7 {
+
+			w.days[d] = true
+
+			if d == last {
+				break
+			}
+		}
+	}
+
+	if hours != "" {
+
+		bounds := strings.SplitN(hours, "-", 2)
+
+		if len(bounds) != 2 {
+			return nil, fmt.Errorf("Invalid hours '%s'", hours)
+		}
+
+		start, err := parseClock(bounds[0])
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid start time, %w", err)
+		}
+
+		end, err := parseClock(bounds[1])
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid end time, %w", err)
+		}
+
+		if start == end {
+			return nil, fmt.Errorf("Start and end times must be different")
+		}
+
+		w.start = start
+		w.end = end
+	}
+
+	return w, nil
+}
+
+// Contains returns a boolean value indicating whether 't' falls inside 'w'.
+func (w *DispatchWindow) Contains(t time.Time) bool {
+
+	t = t.In(w.location)
+
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, w.location)
+	offset := t.Sub(midnight)
+
+	if w.start < w.end {
+		return w.days[t.Weekday()] && offset >= w.start && offset < w.end
+	}
+
+	// The window spans midnight so it belongs to the day on which it opened
+
+	if offset >= w.start {
+		return w.days[t.Weekday()]
+	}
+
+	if offset < w.end {
+		return w.days[(t.Weekday()+6)%7]
+	}
+
+	return false
+}
+
+// Next returns the earliest time, at or after 't', that falls inside 'w'.
+func (w *DispatchWindow) Next(t time.Time) time.Time {
+
+	if w.Contains(t) {
+		return t
+	}
+
+	local := t.In(w.location)
+
+	for i := 0; i <= 7; i++ {
+
+		day := local.AddDate(0, 0, i)
+		open := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, w.location).Add(w.start)
+
+		if open.After(t) && w.days[open.Weekday()] {
+			return open
+		}
+	}
+
+	// This should be unreachable since there is always at least one day in a window
+
+	return t
+}
+
+// parseClock parses a 24-hour "HH:MM" string in to an offset from midnight.
+func parseClock(str string) (time.Duration, error) {
+
+	parts := strings.SplitN(strings.TrimSpace(str), ":", 2)
+
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("Invalid time '%s'", str)
+	}
+
+	h, err := strconv.Atoi(parts[0])
+
+	if err != nil || h < 0 || h > 24 {
+		return 0, fmt.Errorf("Invalid hour '%s'", parts[0])
+	}
+
+	m, err := strconv.Atoi(parts[1])
+
+	if err != nil || m < 0 || m > 59 || (h == 24 && m != 0) {
+		return 0, fmt.Errorf("Invalid minute '%s'", parts[1])
+	}
+
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute, nil
+}
@@ -0,0 +1,197 @@
+package dispatcher
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDelayDispatcher(t *testing.T) {
+
+	ctx := context.Background()
+
+	root := t.TempDir()
+
+	file_uri := fmt.Sprintf("file://%s", root)
+	delay_uri := fmt.Sprintf("delay://?delay=20ms&dispatcher=%s", url.QueryEscape(file_uri))
+
+	d, err := NewDispatcher(ctx, delay_uri)
+
+	if err != nil {
+		t.Fatalf("Failed to create new dispatcher, %v", err)
+	}
+
+	err2 := d.Dispatch(ctx, []byte("hello world"))
+
+	if err2 != nil {
+		t.Fatalf("Failed to dispatch message, %v", err2)
+	}
+
+	matches, err := filepath.Glob(filepath.Join(root, "webhookd-*"))
+
+	if err != nil {
+		t.Fatalf("Failed to glob output, %v", err)
+	}
+
+	if len(matches) != 0 {
+		t.Fatalf("Expected message to be delayed")
+	}
+
+	if d.(QueuedDispatcher).QueueDepth() != 1 {
+		t.Fatalf("Expected one message to be queued")
+	}
+
+	d.(*DelayDispatcher).Wait()
+
+	if d.(QueuedDispatcher).QueueDepth() != 0 {
+		t.Fatalf("Expected queue to be empty after messages are relayed")
+	}
+
+	matches, err = filepath.Glob(filepath.Join(root, "webhookd-*"))
+
+	if err != nil {
+		t.Fatalf("Failed to glob output, %v", err)
+	}
+
+	if len(matches) != 1 {
+		t.Fatalf("Expected exactly one output file, got %d", len(matches))
+	}
+}
+
+func TestDispatchWindow(t *testing.T) {
+
+	w, err := NewDispatchWindow("mon-fri", "09:00-17:00", "UTC")
+
+	if err != nil {
+		t.Fatalf("Failed to create dispatch window, %v", err)
+	}
+
+	// 2024-01-05 is a Friday
+
+	tests := map[string]string{
+		"2024-01-05T10:00:00Z": "2024-01-05T10:00:00Z",
+		"2024-01-05T08:00:00Z": "2024-01-05T09:00:00Z",
+		"2024-01-05T18:00:00Z": "2024-01-08T09:00:00Z",
+		"2024-01-06T12:00:00Z": "2024-01-08T09:00:00Z",
+	}
+
+	for input, expected := range tests {
+
+		t1, _ := time.Parse(time.RFC3339, input)
+		t2 := w.Next(t1)
+
+		if t2.Format(time.RFC3339) != expected {
+			t.Fatalf("Unexpected next time for %s: %s", input, t2.Format(time.RFC3339))
+		}
+	}
+
+	overnight, err := NewDispatchWindow("fri", "22:00-06:00", "")
+
+	if err != nil {
+		t.Fatalf("Failed to create dispatch window, %v", err)
+	}
+
+	saturday, _ := time.Parse(time.RFC3339, "2024-01-06T03:00:00Z")
+
+	if !overnight.Contains(saturday) {
+		t.Fatalf("Expected overnight window to include early Saturday")
+	}
+
+	_, err = NewDispatchWindow("someday", "", "")
+
+	if err == nil {
+		t.Fatalf("Expected invalid day to fail")
+	}
+}
+
+func TestDelayDispatcherSpool(t *testing.T) {
+
+	ctx := context.Background()
+
+	root := t.TempDir()
+	spool := t.TempDir()
+
+	file_uri := fmt.Sprintf("file://%s", root)
+	delay_uri := fmt.Sprintf("delay://?delay=30m&spool=%s&dispatcher=%s", url.QueryEscape(spool), url.QueryEscape(file_uri))
+
+	d, err := NewDispatcher(ctx, delay_uri)
+
+	if err != nil {
+		t.Fatalf("Failed to create new dispatcher, %v", err)
+	}
+
+	// Pretend the message was received an hour ago so that it is already due when the spool is restored below
+
+	d.(*DelayDispatcher).now = func() time.Time {
+		return time.Now().Add(-1 * time.Hour)
+	}
+
+	err2 := d.Dispatch(ctx, []byte("hello world"))
+
+	if err2 != nil {
+		t.Fatalf("Failed to dispatch message, %v", err2)
+	}
+
+	spooled, err := filepath.Glob(filepath.Join(spool, "*"+DELAY_SPOOL_EXTENSION))
+
+	if err != nil {
+		t.Fatalf("Failed to glob spool, %v", err)
+	}
+
+	if len(spooled) != 1 {
+		t.Fatalf("Expected exactly one spooled message, got %d", len(spooled))
+	}
+
+	// Simulate a restart by creating a new dispatcher for the same spool directory
+
+	d2, err := NewDispatcher(ctx, delay_uri)
+
+	if err != nil {
+		t.Fatalf("Failed to create new dispatcher, %v", err)
+	}
+
+	d2.(*DelayDispatcher).Wait()
+
+	matches, err := filepath.Glob(filepath.Join(root, "webhookd-*"))
+
+	if err != nil {
+		t.Fatalf("Failed to glob output, %v", err)
+	}
+
+	if len(matches) != 1 {
+		t.Fatalf("Expected exactly one output file, got %d", len(matches))
+	}
+
+	spooled, err = filepath.Glob(filepath.Join(spool, "*"+DELAY_SPOOL_EXTENSION))
+
+	if err != nil {
+		t.Fatalf("Failed to glob spool, %v", err)
+	}
+
+	if len(spooled) != 0 {
+		t.Fatalf("Expected spooled message to be removed once relayed")
+	}
+
+	// Messages that can not be persisted are rejected
+
+	err = os.RemoveAll(spool)
+
+	if err != nil {
+		t.Fatalf("Failed to remove spool, %v", err)
+	}
+
+	err2 = d2.Dispatch(ctx, []byte("hello world"))
+
+	if err2 == nil || err2.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected dispatch to fail when message can not be persisted, %v", err2)
+	}
+
+	if d2.(QueuedDispatcher).QueueDepth() != 0 {
+		t.Fatalf("Expected message that could not be persisted not to be queued")
+	}
+}
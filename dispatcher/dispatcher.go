@@ -6,9 +6,11 @@ import (
 	"fmt"
 	"net/url"
 	"sort"
+	"strings"
 
 	"github.com/aaronland/go-roster"
 	"github.com/whosonfirst/go-webhookd/v3"
+	"github.com/whosonfirst/go-webhookd/v3/secrets"
 )
 
 // dispatcher is a `aaronland/go-roster.Roster` instance used to maintain a list of registered `webhookd.WebhookDispatcher` initialization functions.
@@ -17,17 +19,43 @@ var dispatchers roster.Roster
 // DispatcherInitializationFunc is a function used to initialize an implementation of the `webhookd.WebhookDispatcher` interface.
 type DispatcherInitializationFunc func(ctx context.Context, uri string) (webhookd.WebhookDispatcher, error)
 
+// QueuedDispatcher is the interface implemented by `webhookd.WebhookDispatcher` instances that hold messages in memory before
+// relaying them, so that the number of messages waiting can be reported.
+type QueuedDispatcher interface {
+	// QueueDepth returns the number of messages waiting to be relayed.
+	QueueDepth() int
+}
+
 // NewDispatcher() returns a new `webhookd.WebhookDispatcher` instance derived from 'uri'. The semantics of and requirements for
-// 'uri' as specific to the package implementing the interface.
+// 'uri' as specific to the package implementing the interface. If 'uri' contains "{{" it is treated as a template and a
+// `TemplateDispatcher` instance is returned.
 func NewDispatcher(ctx context.Context, uri string) (webhookd.WebhookDispatcher, error) {
 
+	if strings.Contains(uri, "{{") {
+		return NewTemplateDispatcher(ctx, uri)
+	}
+
+	return newDispatcher(ctx, uri)
+}
+
+// newDispatcher() returns a new `webhookd.WebhookDispatcher` instance derived from 'uri' using the initialization function
+// registered for its scheme. If 'uri' contains `secrets://` URIs they are resolved and the dispatcher is wrapped in a
+// `RefreshingDispatcher` so that changes to those secrets are picked up without a restart.
+func newDispatcher(ctx context.Context, uri string) (webhookd.WebhookDispatcher, error) {
+
 	err := ensureDispatcherRoster()
 
 	if err != nil {
 		return nil, fmt.Errorf("Failed to ensure dispatcher roster, %w", err)
 	}
 
-	parsed, err := url.Parse(uri)
+	resolved, err := secrets.ResolveURI(ctx, uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to resolve secrets, %w", err)
+	}
+
+	parsed, err := url.Parse(resolved)
 
 	if err != nil {
 		return nil, fmt.Errorf("Failed to parse URI, %w", err)
@@ -42,7 +70,20 @@ func NewDispatcher(ctx context.Context, uri string) (webhookd.WebhookDispatcher,
 	}
 
 	init_func := i.(DispatcherInitializationFunc)
-	return init_func(ctx, uri)
+
+	d, err := init_func(ctx, resolved)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Dispatchers whose URIs contain secrets are refreshed when those secrets change
+
+	if resolved != uri {
+		d = newRefreshingDispatcher(uri, resolved, d, init_func)
+	}
+
+	return d, nil
 }
 
 // RegisterDispatcher() associates 'scheme' with 'init_func' in an internal list of avilable `webhookd.WebhookDispatcher` implementations.
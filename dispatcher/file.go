@@ -0,0 +1,151 @@
+package dispatcher
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterDispatcher(ctx, "file", NewFileDispatcher)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// FileDispatcher implements the `webhookd.WebhookDispatcher` and `webhookd.WebhookStreamingDispatcher` interfaces for
+// dispatching messages to individual files in a directory.
+type FileDispatcher struct {
+	webhookd.WebhookDispatcher
+	// root is the directory where messages are written.
+	root string
+	// extension is the file extension for messages.
+	extension string
+}
+
+// NewFileDispatcher returns a new `FileDispatcher` instance configured by 'uri' in the form of:
+//
+//	file://{PATH}?{PARAMETERS}
+//
+// Where {PATH} is the directory where messages will be written. Valid {PARAMETERS} are:
+// * `extension={STRING}` An optional file extension to append to each message filename. Default is none.
+//
+// Each message is written to a uniquely named file. Messages are first written to a hidden temporary file which is
+// only renamed once the entire message has been written successfully.
+func NewFileDispatcher(ctx context.Context, uri string) (webhookd.WebhookDispatcher, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	root := u.Path
+
+	if u.Host != "" {
+		root = filepath.Join(u.Host, root)
+	}
+
+	if root == "" {
+		return nil, fmt.Errorf("Missing path")
+	}
+
+	info, err := os.Stat(root)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to stat '%s', %w", root, err)
+	}
+
+	if !info.IsDir() {
+		return nil, fmt.Errorf("'%s' is not a directory", root)
+	}
+
+	ext := u.Query().Get("extension")
+
+	if ext != "" && !strings.HasPrefix(ext, ".") {
+		ext = fmt.Sprintf(".%s", ext)
+	}
+
+	d := FileDispatcher{
+		root:      root,
+		extension: ext,
+	}
+
+	return &d, nil
+}
+
+// Dispatch writes 'body' to a new file in the directory that 'd' was instantiated with.
+func (d *FileDispatcher) Dispatch(ctx context.Context, body []byte) *webhookd.WebhookError {
+	return d.DispatchStream(ctx, bytes.NewReader(body))
+}
+
+// DispatchStream writes the contents of 'r' to a new file in the directory that 'd' was instantiated with.
+func (d *FileDispatcher) DispatchStream(ctx context.Context, r io.Reader) *webhookd.WebhookError {
+
+	select {
+	case <-ctx.Done():
+		return nil
+	default:
+		// pass
+	}
+
+	_, err := d.write(r)
+
+	if err != nil {
+		code := http.StatusInternalServerError
+		message := err.Error()
+		return &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	return nil
+}
+
+// write writes the contents of 'r' to a new file returning its path.
+func (d *FileDispatcher) write(r io.Reader) (string, error) {
+
+	wr, err := os.CreateTemp(d.root, ".webhookd-*")
+
+	if err != nil {
+		return "", fmt.Errorf("Failed to create temporary file, %w", err)
+	}
+
+	tmp_path := wr.Name()
+
+	_, err = io.Copy(wr, r)
+
+	if err != nil {
+		wr.Close()
+		os.Remove(tmp_path)
+		return "", fmt.Errorf("Failed to write message, %w", err)
+	}
+
+	err = wr.Close()
+
+	if err != nil {
+		os.Remove(tmp_path)
+		return "", fmt.Errorf("Failed to close temporary file, %w", err)
+	}
+
+	fname := strings.TrimPrefix(filepath.Base(tmp_path), ".") + d.extension
+	path := filepath.Join(d.root, fname)
+
+	err = os.Rename(tmp_path, path)
+
+	if err != nil {
+		os.Remove(tmp_path)
+		return "", fmt.Errorf("Failed to rename temporary file, %w", err)
+	}
+
+	return path, nil
+}
@@ -0,0 +1,62 @@
+package dispatcher
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileDispatcher(t *testing.T) {
+
+	ctx := context.Background()
+
+	root := t.TempDir()
+
+	d, err := NewDispatcher(ctx, fmt.Sprintf("file://%s?extension=json", root))
+
+	if err != nil {
+		t.Fatalf("Failed to create new dispatcher, %v", err)
+	}
+
+	expected := []byte(`{"hello":"world"}`)
+
+	err2 := d.Dispatch(ctx, expected)
+
+	if err2 != nil {
+		t.Fatalf("Failed to dispatch message, %v", err2)
+	}
+
+	matches, err := filepath.Glob(filepath.Join(root, "*.json"))
+
+	if err != nil {
+		t.Fatalf("Failed to glob output, %v", err)
+	}
+
+	if len(matches) != 1 {
+		t.Fatalf("Expected exactly one output file, got %d", len(matches))
+	}
+
+	body, err := os.ReadFile(matches[0])
+
+	if err != nil {
+		t.Fatalf("Failed to read output, %v", err)
+	}
+
+	if !bytes.Equal(body, expected) {
+		t.Fatalf("Unexpected output '%s'", string(body))
+	}
+}
+
+func TestFileDispatcherMissingDirectory(t *testing.T) {
+
+	ctx := context.Background()
+
+	_, err := NewDispatcher(ctx, "file:///this/path/does/not/exist")
+
+	if err == nil {
+		t.Fatalf("Expected missing directory to fail")
+	}
+}
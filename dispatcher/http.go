@@ -114,7 +114,7 @@ func (d *HTTPDispatcher) Dispatch(ctx context.Context, body []byte) *webhookd.We
 		resp, err = d.client.Get(d.url.String())
 	} else {
 		d.logger.Println("Dispatching POST:", d.url.String(), "forwarding body: ", string(body))
-		resp, err = d.client.Post(d.url.String(), "application/json", bytes.NewBuffer(body))
+		resp, err = d.post(ctx, body)
 	}
 
 	// if we get a nil response the destination is unreachable
@@ -144,3 +144,31 @@ func (d *HTTPDispatcher) Dispatch(ctx context.Context, body []byte) *webhookd.We
 
 	return nil
 }
+
+// HTTPRequestClient is an optional interface for `HTTPClient` implementations, like `http.Client`, that can send arbitrary requests.
+type HTTPRequestClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// post sends 'body' to the URL that 'd' has been instantiated with along with any headers attached to 'body' by
+// `webhookd.SetMessageHeader`. Headers are only sent if the client for 'd' implements the `HTTPRequestClient` interface.
+func (d *HTTPDispatcher) post(ctx context.Context, body []byte) (*http.Response, error) {
+
+	headers := webhookd.MessageHeaders(ctx, body)
+	do_client, ok := d.client.(HTTPRequestClient)
+
+	if len(headers) == 0 || !ok {
+		return d.client.Post(d.url.String(), "application/json", bytes.NewBuffer(body))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.url.String(), bytes.NewBuffer(body))
+
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header = headers
+	req.Header.Set("Content-Type", "application/json")
+
+	return do_client.Do(req)
+}
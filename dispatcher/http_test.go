@@ -10,6 +10,8 @@ import (
 	"net/url"
 	"strings"
 	"testing"
+
+	"github.com/whosonfirst/go-webhookd/v3"
 )
 
 type MockHTTPClient struct {
@@ -69,3 +71,58 @@ func TestNewHTTPDispatcherWithOptions(t *testing.T) {
 		t.Fatalf("Unexpected output from custom writer: '%s'", output)
 	}
 }
+
+type MockHTTPRequestClient struct {
+	MockHTTPClient
+	Request *http.Request
+}
+
+func (m *MockHTTPRequestClient) Do(req *http.Request) (*http.Response, error) {
+	m.Request = req
+	return m.Resp, m.Error
+}
+
+func TestHTTPDispatcherMessageHeaders(t *testing.T) {
+
+	ctx := webhookd.WithMessageHeaders(context.Background())
+
+	body := []byte("hello world")
+	webhookd.SetMessageHeader(ctx, body, "X-Webhookd-Signature", "hmac-sha256=1234")
+
+	parsed, err := url.Parse("http://testing")
+
+	if err != nil {
+		t.Fatalf("Failed to parse url, %v", err)
+	}
+
+	mockClient := &MockHTTPRequestClient{
+		MockHTTPClient: MockHTTPClient{
+			Resp: &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader("Mock response body")),
+			},
+		},
+	}
+
+	logger := log.New(io.Discard, "", 0)
+
+	d, err := NewHTTPDispatcherWithOptions(ctx, &HTTPDispatcherOptions{logger, *parsed, mockClient})
+
+	if err != nil {
+		t.Fatalf("Failed to create new http dispatcher, %v", err)
+	}
+
+	err2 := d.Dispatch(ctx, body)
+
+	if err2 != nil {
+		t.Fatalf("Failed to dispatch message, %v", err2)
+	}
+
+	if mockClient.Request == nil {
+		t.Fatalf("Expected message to be sent with headers")
+	}
+
+	if mockClient.Request.Header.Get("X-Webhookd-Signature") != "hmac-sha256=1234" {
+		t.Fatalf("Unexpected signature header '%s'", mockClient.Request.Header.Get("X-Webhookd-Signature"))
+	}
+}
@@ -2,8 +2,11 @@ package dispatcher
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"net/url"
 
+	aa_log "github.com/aaronland/go-log/v2"
 	"github.com/whosonfirst/go-webhookd/v3"
 )
 
@@ -17,22 +20,50 @@ func init() {
 	}
 }
 
+// logLevels is a dictionary of the `aaronland/go-log` functions used to dispatch messages at a given level.
+var logLevels = map[string]func(...interface{}){
+	"debug":   aa_log.Debug,
+	"info":    aa_log.Info,
+	"warning": aa_log.Warning,
+	"error":   aa_log.Error,
+}
+
 // LogDispatcher implements the `webhookd.WebhookDispatcher` interface for dispatching messages to a `log.Logger` instance.
 type LogDispatcher struct {
 	webhookd.WebhookDispatcher
 	// logger is the `log.Logger` instance associated with the dispatcher.
 	logger *log.Logger
+	// emit is the optional `aaronland/go-log` function used to dispatch messages at a given level.
+	emit func(...interface{})
 }
 
 // NewLogDispatcher returns a new `LogDispatcher` instance configured by 'uri' in the form of:
 //
-//	log://
+//	log://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `level={STRING}` The level to dispatch messages at. Valid options are "debug", "info", "warning" and "error". Messages
+// below the minimum level assigned to the `aaronland/go-log` package are not emitted. If empty messages are always emitted
+// without a level prefix.
 //
 // Messasges are dispatched to the default `log.Default()` instance.
 func NewLogDispatcher(ctx context.Context, uri string) (webhookd.WebhookDispatcher, error) {
 
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
 	logger := log.Default()
-	return NewLogDispatcherWithLogger(ctx, logger)
+
+	level := u.Query().Get("level")
+
+	if level == "" {
+		return NewLogDispatcherWithLogger(ctx, logger)
+	}
+
+	return NewLogDispatcherWithLevel(ctx, logger, level)
 }
 
 // NewLogDispatcher returns a new `LogDispatcher` instance that dispatches messages to 'logger'.
@@ -45,9 +76,31 @@ func NewLogDispatcherWithLogger(ctx context.Context, logger *log.Logger) (webhoo
 	return &d, nil
 }
 
+// NewLogDispatcherWithLevel returns a new `LogDispatcher` instance that dispatches messages to 'logger' at 'level'.
+func NewLogDispatcherWithLevel(ctx context.Context, logger *log.Logger, level string) (webhookd.WebhookDispatcher, error) {
+
+	emit, ok := logLevels[level]
+
+	if !ok {
+		return nil, fmt.Errorf("Invalid log level '%s'", level)
+	}
+
+	d := LogDispatcher{
+		logger: logger,
+		emit:   emit,
+	}
+
+	return &d, nil
+}
+
 // Dispatch sends 'body' to the `log.Logger` that 'd' has been instantiated with.
 func (d *LogDispatcher) Dispatch(ctx context.Context, body []byte) *webhookd.WebhookError {
 
-	d.logger.Println(string(body))
+	if d.emit != nil {
+		d.emit(d.logger, "%s", body)
+		return nil
+	}
+
+	d.logger.Printf("%s\n", body)
 	return nil
 }
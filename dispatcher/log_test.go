@@ -4,8 +4,11 @@ import (
 	"bytes"
 	"context"
 	"log"
+	"regexp"
 	"strings"
 	"testing"
+
+	aa_log "github.com/aaronland/go-log/v2"
 )
 
 func TestNewLogDispatcher(t *testing.T) {
@@ -45,10 +48,46 @@ func TestNewLogDispatcherWithLogger(t *testing.T) {
 		t.Fatalf("Failed to dispatch message, %v", err2)
 	}
 
-	expected := "testing log.go:50: hello world"
+	// Match the file name, but not the line number, reported by the log.Lshortfile flag
+
+	expected := regexp.MustCompile(`^testing log\.go:\d+: hello world$`)
+	output := strings.TrimSpace(buf.String())
+
+	if !expected.MatchString(output) {
+		t.Fatalf("Unexpected output from custom writer: '%s'", output)
+	}
+}
+
+func TestNewLogDispatcherWithLevel(t *testing.T) {
+
+	ctx := context.Background()
+
+	var buf bytes.Buffer
+
+	logger := log.New(&buf, "", 0)
+
+	d, err := NewLogDispatcherWithLevel(ctx, logger, "warning")
+
+	if err != nil {
+		t.Fatalf("Failed to create new dispatcher with level, %v", err)
+	}
+
+	err2 := d.Dispatch(ctx, []byte("hello %s world"))
+
+	if err2 != nil {
+		t.Fatalf("Failed to dispatch message, %v", err2)
+	}
+
+	expected := aa_log.WARNING_PREFIX + " hello %s world"
 	output := strings.TrimSpace(buf.String())
 
 	if output != expected {
 		t.Fatalf("Unexpected output from custom writer: '%s'", output)
 	}
+
+	_, err = NewDispatcher(ctx, "log://?level=verbose")
+
+	if err == nil {
+		t.Fatalf("Expected invalid level to fail")
+	}
 }
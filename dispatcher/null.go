@@ -2,6 +2,9 @@ package dispatcher
 
 import (
 	"context"
+	"io"
+	"net/http"
+
 	"github.com/whosonfirst/go-webhookd/v3"
 )
 
@@ -35,3 +38,17 @@ func (d *NullDispatcher) Dispatch(ctx context.Context, body []byte) *webhookd.We
 
 	return nil
 }
+
+// DispatchStream reads 'r' and sends its contents to nowhere.
+func (d *NullDispatcher) DispatchStream(ctx context.Context, r io.Reader) *webhookd.WebhookError {
+
+	_, err := io.Copy(io.Discard, r)
+
+	if err != nil {
+		code := http.StatusInternalServerError
+		message := err.Error()
+		return &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package dispatcher
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+	"github.com/whosonfirst/go-webhookd/v3/secrets"
+)
+
+// RefreshingDispatcher implements the `webhookd.WebhookDispatcher` interface for dispatchers whose URIs contain `secrets://` URIs.
+// The URI is resolved again before each message is dispatched and, if any of its secrets have changed, a new dispatcher is
+// created.
+type RefreshingDispatcher struct {
+	webhookd.WebhookDispatcher
+	// uri is the unresolved URI of the dispatcher.
+	uri string
+	// resolved is the resolved URI that 'dispatcher' was created with.
+	resolved string
+	// dispatcher is the current `webhookd.WebhookDispatcher` instance.
+	dispatcher webhookd.WebhookDispatcher
+	// init_func is the function used to create new dispatchers.
+	init_func DispatcherInitializationFunc
+	// mu is a `sync.RWMutex` used to guard 'resolved' and 'dispatcher'.
+	mu *sync.RWMutex
+}
+
+// RefreshingStreamingDispatcher implements the `webhookd.WebhookStreamingDispatcher` interface for `RefreshingDispatcher`
+// instances whose dispatchers implement `webhookd.WebhookStreamingDispatcher`.
+type RefreshingStreamingDispatcher struct {
+	*RefreshingDispatcher
+}
+
+// newRefreshingDispatcher returns a new `webhookd.WebhookDispatcher` instance that wraps 'd', created by 'init_func' from
+// 'resolved' which is the resolved value of 'uri'. If 'd' implements the `webhookd.WebhookStreamingDispatcher` interface so does
+// the returned instance.
+func newRefreshingDispatcher(uri string, resolved string, d webhookd.WebhookDispatcher, init_func DispatcherInitializationFunc) webhookd.WebhookDispatcher {
+
+	rd := &RefreshingDispatcher{
+		uri:        uri,
+		resolved:   resolved,
+		dispatcher: d,
+		init_func:  init_func,
+		mu:         new(sync.RWMutex),
+	}
+
+	_, ok := d.(webhookd.WebhookStreamingDispatcher)
+
+	if ok {
+		return &RefreshingStreamingDispatcher{rd}
+	}
+
+	return rd
+}
+
+// Dispatch resolves the URI for 'd', refreshing its dispatcher if necessary, and then calls the `Dispatch` method of that
+// dispatcher.
+func (d *RefreshingDispatcher) Dispatch(ctx context.Context, body []byte) *webhookd.WebhookError {
+	return d.current(ctx).Dispatch(ctx, body)
+}
+
+// DispatchStream resolves the URI for 'd', refreshing its dispatcher if necessary, and then calls the `DispatchStream` method of
+// that dispatcher.
+func (d *RefreshingStreamingDispatcher) DispatchStream(ctx context.Context, r io.Reader) *webhookd.WebhookError {
+
+	current := d.current(ctx)
+
+	streaming, ok := current.(webhookd.WebhookStreamingDispatcher)
+
+	if !ok {
+		code := http.StatusInternalServerError
+		message := "Dispatcher does not support streaming"
+		return &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	return streaming.DispatchStream(ctx, r)
+}
+
+// Dispatcher returns the current `webhookd.WebhookDispatcher` instance for 'd'.
+func (d *RefreshingDispatcher) Dispatcher() webhookd.WebhookDispatcher {
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.dispatcher
+}
+
+// current returns the `webhookd.WebhookDispatcher` instance for the current values of the secrets in the URI for 'd'. If those
+// secrets can not be resolved, or a new dispatcher can not be created with them, the existing dispatcher is returned so that a
+// temporary outage of a secret store does not cause messages to fail. Secrets are cached by the resolver so this is cheap.
+func (d *RefreshingDispatcher) current(ctx context.Context) webhookd.WebhookDispatcher {
+
+	resolved, err := secrets.ResolveURI(ctx, d.uri)
+
+	d.mu.RLock()
+	current := d.dispatcher
+	changed := err == nil && resolved != d.resolved
+	d.mu.RUnlock()
+
+	if !changed {
+		return current
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if resolved == d.resolved {
+		return d.dispatcher
+	}
+
+	new_d, err := d.init_func(ctx, resolved)
+
+	if err != nil {
+		return d.dispatcher
+	}
+
+	d.resolved = resolved
+	d.dispatcher = new_d
+
+	return new_d
+}
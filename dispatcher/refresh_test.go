@@ -0,0 +1,117 @@
+package dispatcher
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+func TestRefreshingDispatcher(t *testing.T) {
+
+	ctx := context.Background()
+
+	root := t.TempDir()
+	secret := filepath.Join(t.TempDir(), "extension")
+
+	err := os.WriteFile(secret, []byte("json\n"), 0600)
+
+	if err != nil {
+		t.Fatalf("Failed to write secret, %v", err)
+	}
+
+	extension := fmt.Sprintf("secrets://file%s?ttl=1ns", secret)
+
+	d, err := NewDispatcher(ctx, fmt.Sprintf("file://%s?extension=%s", root, extension))
+
+	if err != nil {
+		t.Fatalf("Failed to create new dispatcher, %v", err)
+	}
+
+	streaming, ok := d.(webhookd.WebhookStreamingDispatcher)
+
+	if !ok {
+		t.Fatalf("Expected refreshing dispatcher to implement webhookd.WebhookStreamingDispatcher")
+	}
+
+	err2 := d.Dispatch(ctx, []byte(`{"hello":"world"}`))
+
+	if err2 != nil {
+		t.Fatalf("Failed to dispatch message, %v", err2)
+	}
+
+	err = os.WriteFile(secret, []byte("txt\n"), 0600)
+
+	if err != nil {
+		t.Fatalf("Failed to write secret, %v", err)
+	}
+
+	time.Sleep(time.Millisecond)
+
+	err2 = streaming.DispatchStream(ctx, bytes.NewReader([]byte("hello world")))
+
+	if err2 != nil {
+		t.Fatalf("Failed to dispatch message, %v", err2)
+	}
+
+	for _, ext := range []string{"json", "txt"} {
+
+		matches, err := filepath.Glob(filepath.Join(root, "*."+ext))
+
+		if err != nil {
+			t.Fatalf("Failed to glob output, %v", err)
+		}
+
+		if len(matches) != 1 {
+			t.Fatalf("Expected exactly one .%s file, got %d", ext, len(matches))
+		}
+	}
+
+	// The current dispatcher is retained if the secret can no longer be resolved
+
+	err = os.Remove(secret)
+
+	if err != nil {
+		t.Fatalf("Failed to remove secret, %v", err)
+	}
+
+	time.Sleep(time.Millisecond)
+
+	err2 = d.Dispatch(ctx, []byte("hello again"))
+
+	if err2 != nil {
+		t.Fatalf("Failed to dispatch message after secret was removed, %v", err2)
+	}
+
+	matches, err := filepath.Glob(filepath.Join(root, "*.txt"))
+
+	if err != nil {
+		t.Fatalf("Failed to glob output, %v", err)
+	}
+
+	if len(matches) != 2 {
+		t.Fatalf("Expected two .txt files, got %d", len(matches))
+	}
+}
+
+func TestNewDispatcherWithoutSecrets(t *testing.T) {
+
+	ctx := context.Background()
+
+	d, err := NewDispatcher(ctx, "null://")
+
+	if err != nil {
+		t.Fatalf("Failed to create new dispatcher, %v", err)
+	}
+
+	_, ok := d.(*RefreshingDispatcher)
+
+	if ok {
+		t.Fatalf("Expected dispatcher without secrets not to be wrapped")
+	}
+}
@@ -0,0 +1,110 @@
+package dispatcher
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+	"github.com/whosonfirst/go-webhookd/v3/cluster"
+)
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterDispatcher(ctx, "singleton", NewSingletonDispatcher)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// SingletonDispatcher implements the `webhookd.WebhookDispatcher` interface for relaying messages to another
+// `webhookd.WebhookDispatcher` instance only if this process has been elected leader, among all the webhookd processes sharing the
+// same `cluster.Elector`, for the dispatcher. Other processes stand by and skip the message.
+type SingletonDispatcher struct {
+	webhookd.WebhookDispatcher
+	// dispatcher is the `webhookd.WebhookDispatcher` instance that messages are relayed to.
+	dispatcher webhookd.WebhookDispatcher
+	// candidate is the `cluster.Candidate` instance used to determine whether this process is the leader.
+	candidate *cluster.Candidate
+}
+
+// NewSingletonDispatcher returns a new `SingletonDispatcher` instance configured by 'uri' in the form of:
+//
+//	singleton://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `dispatcher={URI}` The URI-escaped URI of the dispatcher that messages will be relayed to. Required.
+// * `election={URI}` The URI-escaped URI of the `cluster.Elector` used to elect a leader. Default is "memory://".
+// * `name={STRING}` The name that processes campaign for leadership of. Default is derived from the (unescaped) `dispatcher` URI.
+func NewSingletonDispatcher(ctx context.Context, uri string) (webhookd.WebhookDispatcher, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	dispatcher_uri := q.Get("dispatcher")
+
+	if dispatcher_uri == "" {
+		return nil, fmt.Errorf("Missing ?dispatcher= parameter")
+	}
+
+	d, err := NewDispatcher(ctx, dispatcher_uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create dispatcher for '%s', %w", dispatcher_uri, err)
+	}
+
+	election_uri := q.Get("election")
+
+	if election_uri == "" {
+		election_uri = "memory://"
+	}
+
+	elector, err := cluster.NewElector(ctx, election_uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create elector, %w", err)
+	}
+
+	name := q.Get("name")
+
+	if name == "" {
+		name = fmt.Sprintf("dispatcher:%x", sha256.Sum256([]byte(dispatcher_uri)))[:27]
+	}
+
+	s := &SingletonDispatcher{
+		dispatcher: d,
+		candidate:  cluster.NewCandidate(elector, name),
+	}
+
+	return s, nil
+}
+
+// Dispatch relays 'body' to the dispatcher that 'd' was instantiated with if this process is the leader. Otherwise it returns a
+// `webhookd.UnhandledEvent` error.
+func (d *SingletonDispatcher) Dispatch(ctx context.Context, body []byte) *webhookd.WebhookError {
+
+	leader, err := d.candidate.IsLeader(ctx)
+
+	if err != nil {
+		code := http.StatusServiceUnavailable
+		message := fmt.Sprintf("Failed to determine leader, %v", err)
+		return &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	if !leader {
+		code := webhookd.UnhandledEvent
+		message := "Not the leader"
+		return &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	return d.dispatcher.Dispatch(ctx, body)
+}
@@ -0,0 +1,61 @@
+package dispatcher
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+func TestSingletonDispatcher(t *testing.T) {
+
+	ctx := context.Background()
+
+	root := t.TempDir()
+
+	file_uri := fmt.Sprintf("file://%s", root)
+
+	new_dispatcher := func(identity string) webhookd.WebhookDispatcher {
+
+		election_uri := fmt.Sprintf("memory://?identity=%s", identity)
+		singleton_uri := fmt.Sprintf("singleton://?name=test&dispatcher=%s&election=%s", url.QueryEscape(file_uri), url.QueryEscape(election_uri))
+
+		d, err := NewDispatcher(ctx, singleton_uri)
+
+		if err != nil {
+			t.Fatalf("Failed to create new dispatcher, %v", err)
+		}
+
+		return d
+	}
+
+	a := new_dispatcher("a")
+	b := new_dispatcher("b")
+
+	err := a.Dispatch(ctx, []byte("hello world"))
+
+	if err != nil {
+		t.Fatalf("Expected leader to dispatch message, %v", err)
+	}
+
+	err = b.Dispatch(ctx, []byte("hello world"))
+
+	if err == nil || err.Code != webhookd.UnhandledEvent {
+		t.Fatalf("Expected standby to skip message, %v", err)
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(root, "webhookd-*"))
+
+	if len(matches) != 1 {
+		t.Fatalf("Expected exactly one output file, got %d", len(matches))
+	}
+
+	_, err2 := NewDispatcher(ctx, "singleton://?election=memory%3A%2F%2F")
+
+	if err2 == nil {
+		t.Fatalf("Expected missing dispatcher to fail")
+	}
+}
@@ -0,0 +1,159 @@
+package dispatcher
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+
+	aa_log "github.com/aaronland/go-log/v2"
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+// DEFAULT_TEE_SINK is the default dispatcher URI that messages are copied to.
+const DEFAULT_TEE_SINK string = "log://?level=debug"
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterDispatcher(ctx, "tee", NewTeeDispatcher)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// TeeDispatcher implements the `webhookd.WebhookDispatcher` interface for relaying messages to another `webhookd.WebhookDispatcher`
+// instance while also copying them to a "sink" dispatcher, typically for debugging.
+type TeeDispatcher struct {
+	webhookd.WebhookDispatcher
+	// dispatcher is the `webhookd.WebhookDispatcher` instance that messages are relayed to.
+	dispatcher webhookd.WebhookDispatcher
+	// sink is the `webhookd.WebhookDispatcher` instance that messages are copied to.
+	sink webhookd.WebhookDispatcher
+	// sink_only is a boolean flag signaling that messages should only be copied to 'sink' and not relayed to 'dispatcher'.
+	sink_only bool
+	// logger is the `log.Logger` instance used to report errors copying messages to 'sink'.
+	logger *log.Logger
+}
+
+// TeeDispatcherOptions is a struct containing the options for `NewTeeDispatcherWithOptions`.
+type TeeDispatcherOptions struct {
+	// Dispatcher is the `webhookd.WebhookDispatcher` instance that messages are relayed to.
+	Dispatcher webhookd.WebhookDispatcher
+	// Sink is the `webhookd.WebhookDispatcher` instance that messages are copied to.
+	Sink webhookd.WebhookDispatcher
+	// SinkOnly is a boolean flag signaling that messages should only be copied to 'Sink' and not relayed to 'Dispatcher'.
+	SinkOnly bool
+	// Logger is the `log.Logger` instance used to report errors copying messages to 'Sink'.
+	Logger *log.Logger
+}
+
+// NewTeeDispatcher returns a new `TeeDispatcher` instance configured by 'uri' in the form of:
+//
+//	tee://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `dispatcher={URI}` The URI-escaped URI of the dispatcher that messages will be relayed to. Required.
+// * `sink={URI}` The URI-escaped URI of the dispatcher that messages will be copied to. Default is "log://?level=debug".
+// * `sink_only={BOOLEAN}` Only copy messages to the sink without relaying them to `dispatcher`. Default is false.
+func NewTeeDispatcher(ctx context.Context, uri string) (webhookd.WebhookDispatcher, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	dispatcher_uri := q.Get("dispatcher")
+
+	if dispatcher_uri == "" {
+		return nil, fmt.Errorf("Missing ?dispatcher= parameter")
+	}
+
+	d, err := NewDispatcher(ctx, dispatcher_uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create dispatcher for '%s', %w", dispatcher_uri, err)
+	}
+
+	sink_uri := q.Get("sink")
+
+	if sink_uri == "" {
+		sink_uri = DEFAULT_TEE_SINK
+	}
+
+	sink, err := NewDispatcher(ctx, sink_uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create sink for '%s', %w", sink_uri, err)
+	}
+
+	opts := &TeeDispatcherOptions{
+		Dispatcher: d,
+		Sink:       sink,
+		Logger:     log.Default(),
+	}
+
+	str_sink_only := q.Get("sink_only")
+
+	if str_sink_only != "" {
+
+		v, err := strconv.ParseBool(str_sink_only)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse ?sink_only= parameter, %w", err)
+		}
+
+		opts.SinkOnly = v
+	}
+
+	return NewTeeDispatcherWithOptions(ctx, opts)
+}
+
+// NewTeeDispatcherWithOptions returns a new `TeeDispatcher` instance configured by 'opts'.
+func NewTeeDispatcherWithOptions(ctx context.Context, opts *TeeDispatcherOptions) (webhookd.WebhookDispatcher, error) {
+
+	if opts.Dispatcher == nil {
+		return nil, fmt.Errorf("Missing dispatcher")
+	}
+
+	if opts.Sink == nil {
+		return nil, fmt.Errorf("Missing sink")
+	}
+
+	logger := opts.Logger
+
+	if logger == nil {
+		logger = log.Default()
+	}
+
+	d := &TeeDispatcher{
+		dispatcher: opts.Dispatcher,
+		sink:       opts.Sink,
+		sink_only:  opts.SinkOnly,
+		logger:     logger,
+	}
+
+	return d, nil
+}
+
+// Dispatch copies 'body' to the sink that 'd' was instantiated with and then relays it to the underlying dispatcher, unless 'd'
+// was instantiated with `sink_only=true`. Errors copying messages to the sink are logged but not returned.
+func (d *TeeDispatcher) Dispatch(ctx context.Context, body []byte) *webhookd.WebhookError {
+
+	err := d.sink.Dispatch(ctx, body)
+
+	if err != nil {
+		aa_log.Warning(d.logger, "Failed to copy message to sink, %v", err)
+	}
+
+	if d.sink_only {
+		return nil
+	}
+
+	return d.dispatcher.Dispatch(ctx, body)
+}
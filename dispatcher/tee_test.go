@@ -0,0 +1,78 @@
+package dispatcher
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net/http"
+	"testing"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+type recordingDispatcher struct {
+	webhookd.WebhookDispatcher
+	messages [][]byte
+	err      *webhookd.WebhookError
+}
+
+func (d *recordingDispatcher) Dispatch(ctx context.Context, body []byte) *webhookd.WebhookError {
+	d.messages = append(d.messages, body)
+	return d.err
+}
+
+func TestTeeDispatcher(t *testing.T) {
+
+	ctx := context.Background()
+
+	_, err := NewDispatcher(ctx, "tee://?dispatcher=null%3A%2F%2F")
+
+	if err != nil {
+		t.Fatalf("Failed to create new tee dispatcher, %v", err)
+	}
+
+	for _, sink_only := range []bool{false, true} {
+
+		d := &recordingDispatcher{}
+		sink := &recordingDispatcher{
+			err: &webhookd.WebhookError{Code: http.StatusInternalServerError, Message: "sink failed"},
+		}
+
+		var buf bytes.Buffer
+
+		tee, err := NewTeeDispatcherWithOptions(ctx, &TeeDispatcherOptions{
+			Dispatcher: d,
+			Sink:       sink,
+			SinkOnly:   sink_only,
+			Logger:     log.New(&buf, "", 0),
+		})
+
+		if err != nil {
+			t.Fatalf("Failed to create new tee dispatcher, %v", err)
+		}
+
+		err2 := tee.Dispatch(ctx, []byte("hello world"))
+
+		if err2 != nil {
+			t.Fatalf("Failed to dispatch message, %v", err2)
+		}
+
+		if len(sink.messages) != 1 {
+			t.Fatalf("Expected message to be copied to sink")
+		}
+
+		expected := 1
+
+		if sink_only {
+			expected = 0
+		}
+
+		if len(d.messages) != expected {
+			t.Fatalf("Expected %d messages to be relayed but got %d", expected, len(d.messages))
+		}
+
+		if !bytes.Contains(buf.Bytes(), []byte("sink failed")) {
+			t.Fatalf("Expected sink error to be logged")
+		}
+	}
+}
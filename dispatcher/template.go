@@ -0,0 +1,175 @@
+package dispatcher
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+// templateDispatcherCacheSize is the maximum number of dispatchers, for distinct rendered URIs, that a `TemplateDispatcher` retains.
+const templateDispatcherCacheSize int = 100
+
+// TemplateData is the data structure passed to templated dispatcher URIs.
+type TemplateData struct {
+	// Endpoint is the endpoint of the webhook processing the message.
+	Endpoint string
+	// EventType is the type of event the message describes or an empty string if unknown.
+	EventType string
+	// DeliveryID is the unique identifier for the message.
+	DeliveryID string
+	// Params is the dictionary of path parameters matched by the webhook endpoint.
+	Params map[string]string
+}
+
+// templateFuncs is the dictionary of functions available to templated dispatcher URIs.
+var templateFuncs = template.FuncMap{
+	"query":      url.QueryEscape,
+	"pathescape": url.PathEscape,
+	"lower":      strings.ToLower,
+}
+
+// TemplateDispatcher implements the `webhookd.WebhookDispatcher` interface for relaying messages to a dispatcher whose URI
+// is derived, for each message, from the `webhookd.Delivery` metadata for that message.
+type TemplateDispatcher struct {
+	webhookd.WebhookDispatcher
+	// template is the `text/template.Template` used to derive the URI of the dispatcher for each message.
+	template *template.Template
+	// dispatchers is a dictionary of `webhookd.WebhookDispatcher` instances keyed by their rendered URI.
+	dispatchers map[string]webhookd.WebhookDispatcher
+	// uris is the list of keys in 'dispatchers' in the order they were added.
+	uris []string
+	// mu is a `sync.Mutex` used to guard 'dispatchers' and 'uris'.
+	mu *sync.Mutex
+}
+
+// NewTemplateDispatcher returns a new `TemplateDispatcher` instance for 'uri' which is a dispatcher URI containing Go language
+// `text/template` actions, for example:
+//
+//	file:///var/spool/webhookd/{{ .EventType | pathescape }}
+//
+// Templates are passed a `TemplateData` instance and may use the `query`, `pathescape` and `lower` functions. The scheme of 'uri'
+// can not be templated. Dispatchers are created the first time a rendered URI is encountered and are reused for subsequent
+// messages with the same URI.
+//
+// This method is called by `NewDispatcher` for any URI that contains "{{" so it is not necessary to call it directly.
+func NewTemplateDispatcher(ctx context.Context, uri string) (webhookd.WebhookDispatcher, error) {
+
+	scheme, _, ok := strings.Cut(uri, "://")
+
+	if !ok || scheme == "" || strings.Contains(scheme, "{{") {
+		return nil, fmt.Errorf("Templated URIs must have a literal scheme")
+	}
+
+	err := ensureDispatcherRoster()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to ensure dispatcher roster, %w", err)
+	}
+
+	_, err = dispatchers.Driver(ctx, scheme)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to find initialization function for '%s', %w", scheme, err)
+	}
+
+	t, err := template.New("dispatcher").Option("missingkey=error").Funcs(templateFuncs).Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI template, %w", err)
+	}
+
+	d := &TemplateDispatcher{
+		template:    t,
+		dispatchers: make(map[string]webhookd.WebhookDispatcher),
+		uris:        make([]string, 0),
+		mu:          new(sync.Mutex),
+	}
+
+	return d, nil
+}
+
+// Dispatch renders the URI template for 'd' using the `webhookd.Delivery` metadata in 'ctx' and relays 'body' to the dispatcher
+// for the resulting URI.
+func (d *TemplateDispatcher) Dispatch(ctx context.Context, body []byte) *webhookd.WebhookError {
+
+	uri, err := d.render(ctx)
+
+	if err != nil {
+		code := http.StatusInternalServerError
+		message := fmt.Sprintf("Failed to render dispatcher URI, %v", err)
+		return &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	dispatcher, err := d.dispatcher(ctx, uri)
+
+	if err != nil {
+		code := http.StatusInternalServerError
+		message := fmt.Sprintf("Failed to create dispatcher for rendered URI, %v", err)
+		return &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	return dispatcher.Dispatch(ctx, body)
+}
+
+// render returns the URI derived from the template for 'd' and the `webhookd.Delivery` metadata in 'ctx'.
+func (d *TemplateDispatcher) render(ctx context.Context) (string, error) {
+
+	data := &TemplateData{
+		Params: webhookd.PathParameters(ctx),
+	}
+
+	delivery, ok := webhookd.DeliveryFromContext(ctx)
+
+	if ok {
+		data.Endpoint = delivery.Endpoint
+		data.EventType = delivery.EventType
+		data.DeliveryID = delivery.ID
+	}
+
+	var buf bytes.Buffer
+
+	err := d.template.Execute(&buf, data)
+
+	if err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// dispatcher returns the `webhookd.WebhookDispatcher` instance for 'uri', creating it if necessary. If there are more than
+// `templateDispatcherCacheSize` dispatchers the oldest is discarded.
+func (d *TemplateDispatcher) dispatcher(ctx context.Context, uri string) (webhookd.WebhookDispatcher, error) {
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	dispatcher, ok := d.dispatchers[uri]
+
+	if ok {
+		return dispatcher, nil
+	}
+
+	dispatcher, err := newDispatcher(ctx, uri)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(d.uris) >= templateDispatcherCacheSize {
+		delete(d.dispatchers, d.uris[0])
+		d.uris = d.uris[1:]
+	}
+
+	d.dispatchers[uri] = dispatcher
+	d.uris = append(d.uris, uri)
+
+	return dispatcher, nil
+}
@@ -0,0 +1,106 @@
+package dispatcher
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+func TestTemplateDispatcher(t *testing.T) {
+
+	ctx := context.Background()
+
+	root := t.TempDir()
+
+	for _, dir := range []string{"push", "release"} {
+
+		err := os.Mkdir(filepath.Join(root, dir), 0755)
+
+		if err != nil {
+			t.Fatalf("Failed to create %s directory, %v", dir, err)
+		}
+	}
+
+	d, err := NewDispatcher(ctx, fmt.Sprintf("file://%s/{{ .EventType | lower | pathescape }}?extension=json", root))
+
+	if err != nil {
+		t.Fatalf("Failed to create new dispatcher, %v", err)
+	}
+
+	_, ok := d.(*TemplateDispatcher)
+
+	if !ok {
+		t.Fatalf("Expected a TemplateDispatcher, got %T", d)
+	}
+
+	for _, event_type := range []string{"push", "Release", "push"} {
+
+		delivery := &webhookd.Delivery{
+			Endpoint:  "/github",
+			ID:        "1234",
+			EventType: event_type,
+			Header:    http.Header{},
+		}
+
+		ctx := webhookd.WithDelivery(ctx, delivery)
+
+		err2 := d.Dispatch(ctx, []byte(`{"hello":"world"}`))
+
+		if err2 != nil {
+			t.Fatalf("Failed to dispatch %s message, %v", event_type, err2)
+		}
+	}
+
+	for dir, expected := range map[string]int{"push": 2, "release": 1} {
+
+		matches, err := filepath.Glob(filepath.Join(root, dir, "*.json"))
+
+		if err != nil {
+			t.Fatalf("Failed to glob output, %v", err)
+		}
+
+		if len(matches) != expected {
+			t.Fatalf("Expected %d output files in %s, got %d", expected, dir, len(matches))
+		}
+	}
+
+	if len(d.(*TemplateDispatcher).dispatchers) != 2 {
+		t.Fatalf("Expected 2 cached dispatchers, got %d", len(d.(*TemplateDispatcher).dispatchers))
+	}
+
+	delivery := &webhookd.Delivery{
+		EventType: "missing",
+	}
+
+	err2 := d.Dispatch(webhookd.WithDelivery(ctx, delivery), []byte(`{"hello":"world"}`))
+
+	if err2 == nil {
+		t.Fatalf("Expected dispatch to a missing directory to fail")
+	}
+}
+
+func TestNewTemplateDispatcherInvalid(t *testing.T) {
+
+	ctx := context.Background()
+
+	uris := []string{
+		"{{ .EventType }}://",
+		"{{ .EventType }}",
+		"unknown://{{ .EventType }}",
+		"null://{{ .EventType",
+	}
+
+	for _, uri := range uris {
+
+		_, err := NewDispatcher(ctx, uri)
+
+		if err == nil {
+			t.Fatalf("Expected '%s' to fail", uri)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+// Package jsonpath provides methods for querying decoded JSON documents using simple dot-separated paths.
+//
+// Paths are a list of dictionary keys or (zero-indexed) list offsets separated by periods, for example
+// "repository.owner.name" or "commits.0.id".
+package jsonpath
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Decode returns the JSON-encoded 'body' decoded in to an `interface{}` value suitable for use with the other methods in this package.
+// Numbers are decoded as `json.Number` values so that they are not silently converted to floating point values.
+func Decode(body []byte) (interface{}, error) {
+
+	var doc interface{}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+
+	err := dec.Decode(&doc)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decode body, %w", err)
+	}
+
+	return doc, nil
+}
+
+// Split returns the individual segments in 'path'.
+func Split(path string) []string {
+
+	path = strings.TrimSpace(path)
+
+	if path == "" || path == "." {
+		return []string{}
+	}
+
+	return strings.Split(strings.TrimPrefix(path, "."), ".")
+}
+
+// Get returns the value at 'path' in 'doc' and a boolean value indicating whether or not it was found.
+func Get(doc interface{}, path string) (interface{}, bool) {
+
+	current := doc
+
+	for _, seg := range Split(path) {
+
+		switch v := current.(type) {
+		case map[string]interface{}:
+
+			next, ok := v[seg]
+
+			if !ok {
+				return nil, false
+			}
+
+			current = next
+
+		case []interface{}:
+
+			idx, err := strconv.Atoi(seg)
+
+			if err != nil || idx < 0 || idx >= len(v) {
+				return nil, false
+			}
+
+			current = v[idx]
+
+		default:
+			return nil, false
+		}
+	}
+
+	return current, true
+}
+
+// GetString returns the value at 'path' in 'doc' as a string and a boolean value indicating whether or not it was found.
+// Strings are returned as-is, other scalar values are formatted using their default string representation and lists and
+// dictionaries are returned as JSON-encoded strings.
+func GetString(doc interface{}, path string) (string, bool) {
+
+	v, ok := Get(doc, path)
+
+	if !ok {
+		return "", false
+	}
+
+	return String(v), true
+}
+
+// String returns the string representation of 'v'. Strings are returned as-is, nil values as an empty string, other scalar values
+// are formatted using their default string representation and lists and dictionaries are returned as JSON-encoded strings.
+func String(v interface{}) string {
+
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case json.Number:
+		return t.String()
+	case bool, float64, int, int64:
+		return fmt.Sprintf("%v", t)
+	default:
+
+		enc, err := json.Marshal(t)
+
+		if err != nil {
+			return fmt.Sprintf("%v", t)
+		}
+
+		return string(enc)
+	}
+}
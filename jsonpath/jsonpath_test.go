@@ -0,0 +1,46 @@
+package jsonpath
+
+import (
+	"testing"
+)
+
+func TestGet(t *testing.T) {
+
+	body := []byte(`{"ref":"refs/heads/main","repository":{"name":"go-webhookd","stars":12},"commits":[{"id":"abc"},{"id":"def"}]}`)
+
+	doc, err := Decode(body)
+
+	if err != nil {
+		t.Fatalf("Failed to decode body, %v", err)
+	}
+
+	tests := map[string]string{
+		"ref":              "refs/heads/main",
+		"repository.name":  "go-webhookd",
+		"repository.stars": "12",
+		"commits.1.id":     "def",
+		"commits.0":        `{"id":"abc"}`,
+	}
+
+	for path, expected := range tests {
+
+		v, ok := GetString(doc, path)
+
+		if !ok {
+			t.Fatalf("Failed to find '%s'", path)
+		}
+
+		if v != expected {
+			t.Fatalf("Unexpected value for '%s': %s", path, v)
+		}
+	}
+
+	for _, path := range []string{"missing", "repository.missing", "commits.2.id", "commits.x", "ref.foo"} {
+
+		_, ok := Get(doc, path)
+
+		if ok {
+			t.Fatalf("Expected '%s' to be missing", path)
+		}
+	}
+}
@@ -0,0 +1,672 @@
+// Package jsonschema provides a minimal JSON Schema validator supporting the commonly used subset of the draft-07 and
+// 2020-12 vocabularies.
+//
+// Supported keywords are: `type`, `enum`, `const`, `properties`, `required`, `additionalProperties`, `patternProperties`,
+// `minProperties`, `maxProperties`, `items` (a single schema), `prefixItems`, `minItems`, `maxItems`, `uniqueItems`, `minLength`,
+// `maxLength`, `pattern`, `minimum`, `maximum`, `exclusiveMinimum`, `exclusiveMaximum`, `multipleOf`, `allOf`, `anyOf`, `oneOf`,
+// `not`, `if`/`then`/`else` and local `$ref` pointers (for example "#/definitions/user" or "#/$defs/user"). Unknown keywords,
+// including `format`, are ignored.
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/whosonfirst/go-webhookd/v3/jsonpath"
+)
+
+// ValidationError is a struct describing a single validation failure.
+type ValidationError struct {
+	// Path is the (dot-separated) path of the value that failed validation. The root value has an empty path.
+	Path string `json:"path"`
+	// Message is a description of the failure.
+	Message string `json:"message"`
+}
+
+// Error returns a string representation of 'e'.
+func (e *ValidationError) Error() string {
+
+	path := e.Path
+
+	if path == "" {
+		path = "(root)"
+	}
+
+	return fmt.Sprintf("%s: %s", path, e.Message)
+}
+
+// Schema is a compiled JSON schema.
+type Schema struct {
+	// root is the decoded schema document.
+	root interface{}
+	// patterns is a cache of compiled regular expressions used by the schema.
+	patterns map[string]*regexp.Regexp
+}
+
+// Open returns a new `Schema` instance derived from the JSON schema file at 'path'.
+func Open(path string) (*Schema, error) {
+
+	body, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read %s, %w", path, err)
+	}
+
+	return Parse(body)
+}
+
+// Parse returns a new `Schema` instance derived from the JSON-encoded schema in 'body'.
+func Parse(body []byte) (*Schema, error) {
+
+	root, err := jsonpath.Decode(body)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decode schema, %w", err)
+	}
+
+	switch root.(type) {
+	case map[string]interface{}, bool:
+		// pass
+	default:
+		return nil, fmt.Errorf("Schema must be an object or a boolean")
+	}
+
+	s := &Schema{
+		root:     root,
+		patterns: make(map[string]*regexp.Regexp),
+	}
+
+	// Compile all the regular expressions up front so that invalid patterns are reported early
+
+	err = s.compilePatterns(root)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Validate validates the JSON-encoded document in 'body' against 's' returning the list of validation failures.
+// An error is returned if 'body' is not valid JSON.
+func (s *Schema) Validate(body []byte) ([]*ValidationError, error) {
+
+	doc, err := jsonpath.Decode(body)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.ValidateDocument(doc), nil
+}
+
+// ValidateDocument validates 'doc', which is expected to have been decoded using `jsonpath.Decode`, against 's' returning
+// the list of validation failures.
+func (s *Schema) ValidateDocument(doc interface{}) []*ValidationError {
+	return s.validate(s.root, doc, "", 0)
+}
+
+// maxDepth is the maximum depth of nested `$ref` pointers, used to guard against recursive schemas.
+const maxDepth int = 64
+
+// validate validates 'v', at 'path', against 'schema'.
+func (s *Schema) validate(schema interface{}, v interface{}, path string, depth int) []*ValidationError {
+
+	errs := make([]*ValidationError, 0)
+
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, &ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if depth > maxDepth {
+		fail("Schema exceeds maximum depth")
+		return errs
+	}
+
+	switch t := schema.(type) {
+	case bool:
+
+		if !t {
+			fail("No values are allowed")
+		}
+
+		return errs
+
+	case map[string]interface{}:
+		// pass
+	default:
+		return errs
+	}
+
+	sch := schema.(map[string]interface{})
+
+	if ref, ok := sch["$ref"].(string); ok {
+
+		target, err := s.resolve(ref)
+
+		if err != nil {
+			fail("%v", err)
+			return errs
+		}
+
+		errs = append(errs, s.validate(target, v, path, depth+1)...)
+	}
+
+	if types, ok := sch["type"]; ok {
+
+		allowed := make([]string, 0)
+
+		switch tt := types.(type) {
+		case string:
+			allowed = append(allowed, tt)
+		case []interface{}:
+
+			for _, t := range tt {
+
+				if str_t, ok := t.(string); ok {
+					allowed = append(allowed, str_t)
+				}
+			}
+		}
+
+		matched := false
+
+		for _, t := range allowed {
+
+			if isType(v, t) {
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			fail("Expected %s but got %s", strings.Join(allowed, " or "), typeOf(v))
+			return errs
+		}
+	}
+
+	if enum, ok := sch["enum"].([]interface{}); ok {
+
+		matched := false
+
+		for _, e := range enum {
+
+			if equal(v, e) {
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			fail("Value is not one of the allowed values")
+		}
+	}
+
+	if c, ok := sch["const"]; ok {
+
+		if !equal(v, c) {
+			fail("Value does not match constant %s", jsonpath.String(c))
+		}
+	}
+
+	switch tv := v.(type) {
+	case map[string]interface{}:
+		errs = append(errs, s.validateObject(sch, tv, path, depth)...)
+	case []interface{}:
+		errs = append(errs, s.validateArray(sch, tv, path, depth)...)
+	case string:
+		errs = append(errs, s.validateString(sch, tv, path)...)
+	case json.Number:
+		errs = append(errs, s.validateNumber(sch, tv, path)...)
+	}
+
+	if all_of, ok := sch["allOf"].([]interface{}); ok {
+
+		for _, sub := range all_of {
+			errs = append(errs, s.validate(sub, v, path, depth+1)...)
+		}
+	}
+
+	if any_of, ok := sch["anyOf"].([]interface{}); ok {
+
+		matched := false
+
+		for _, sub := range any_of {
+
+			if len(s.validate(sub, v, path, depth+1)) == 0 {
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			fail("Value does not match any of the schemas in anyOf")
+		}
+	}
+
+	if one_of, ok := sch["oneOf"].([]interface{}); ok {
+
+		count := 0
+
+		for _, sub := range one_of {
+
+			if len(s.validate(sub, v, path, depth+1)) == 0 {
+				count++
+			}
+		}
+
+		if count != 1 {
+			fail("Value matches %d of the schemas in oneOf, expected exactly one", count)
+		}
+	}
+
+	if not, ok := sch["not"]; ok {
+
+		if len(s.validate(not, v, path, depth+1)) == 0 {
+			fail("Value must not match the schema in not")
+		}
+	}
+
+	if cond, ok := sch["if"]; ok {
+
+		if len(s.validate(cond, v, path, depth+1)) == 0 {
+
+			if then, ok := sch["then"]; ok {
+				errs = append(errs, s.validate(then, v, path, depth+1)...)
+			}
+
+		} else {
+
+			if els, ok := sch["else"]; ok {
+				errs = append(errs, s.validate(els, v, path, depth+1)...)
+			}
+		}
+	}
+
+	return errs
+}
+
+// validateObject validates the object-specific keywords in 'sch' against 'obj'.
+func (s *Schema) validateObject(sch map[string]interface{}, obj map[string]interface{}, path string, depth int) []*ValidationError {
+
+	errs := make([]*ValidationError, 0)
+
+	if required, ok := sch["required"].([]interface{}); ok {
+
+		for _, r := range required {
+
+			name, _ := r.(string)
+
+			if _, ok := obj[name]; !ok {
+				errs = append(errs, &ValidationError{Path: path, Message: fmt.Sprintf("Missing required property '%s'", name)})
+			}
+		}
+	}
+
+	if n, ok := toFloat(sch["minProperties"]); ok && float64(len(obj)) < n {
+		errs = append(errs, &ValidationError{Path: path, Message: fmt.Sprintf("Expected at least %v properties", n)})
+	}
+
+	if n, ok := toFloat(sch["maxProperties"]); ok && float64(len(obj)) > n {
+		errs = append(errs, &ValidationError{Path: path, Message: fmt.Sprintf("Expected at most %v properties", n)})
+	}
+
+	properties, _ := sch["properties"].(map[string]interface{})
+	pattern_properties, _ := sch["patternProperties"].(map[string]interface{})
+	additional, has_additional := sch["additionalProperties"]
+
+	// Sort keys so that errors are reported in a stable order
+
+	keys := make([]string, 0, len(obj))
+
+	for k := range obj {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+
+		child_path := joinPath(path, k)
+		matched := false
+
+		if sub, ok := properties[k]; ok {
+			matched = true
+			errs = append(errs, s.validate(sub, obj[k], child_path, depth+1)...)
+		}
+
+		for p, sub := range pattern_properties {
+
+			if s.patterns[p].MatchString(k) {
+				matched = true
+				errs = append(errs, s.validate(sub, obj[k], child_path, depth+1)...)
+			}
+		}
+
+		if !matched && has_additional {
+			errs = append(errs, s.validate(additional, obj[k], child_path, depth+1)...)
+		}
+	}
+
+	return errs
+}
+
+// validateArray validates the array-specific keywords in 'sch' against 'arr'.
+func (s *Schema) validateArray(sch map[string]interface{}, arr []interface{}, path string, depth int) []*ValidationError {
+
+	errs := make([]*ValidationError, 0)
+
+	if n, ok := toFloat(sch["minItems"]); ok && float64(len(arr)) < n {
+		errs = append(errs, &ValidationError{Path: path, Message: fmt.Sprintf("Expected at least %v items", n)})
+	}
+
+	if n, ok := toFloat(sch["maxItems"]); ok && float64(len(arr)) > n {
+		errs = append(errs, &ValidationError{Path: path, Message: fmt.Sprintf("Expected at most %v items", n)})
+	}
+
+	if unique, _ := sch["uniqueItems"].(bool); unique {
+
+	outer:
+		for i := 0; i < len(arr); i++ {
+
+			for j := i + 1; j < len(arr); j++ {
+
+				if equal(arr[i], arr[j]) {
+					errs = append(errs, &ValidationError{Path: path, Message: fmt.Sprintf("Items at offsets %d and %d are not unique", i, j)})
+					break outer
+				}
+			}
+		}
+	}
+
+	start := 0
+
+	if prefix, ok := sch["prefixItems"].([]interface{}); ok {
+
+		for i, sub := range prefix {
+
+			if i >= len(arr) {
+				break
+			}
+
+			errs = append(errs, s.validate(sub, arr[i], joinPath(path, strconv.Itoa(i)), depth+1)...)
+		}
+
+		start = len(prefix)
+	}
+
+	if items, ok := sch["items"]; ok {
+
+		switch items.(type) {
+		case map[string]interface{}, bool:
+
+			for i := start; i < len(arr); i++ {
+				errs = append(errs, s.validate(items, arr[i], joinPath(path, strconv.Itoa(i)), depth+1)...)
+			}
+		}
+	}
+
+	return errs
+}
+
+// validateString validates the string-specific keywords in 'sch' against 'str'.
+func (s *Schema) validateString(sch map[string]interface{}, str string, path string) []*ValidationError {
+
+	errs := make([]*ValidationError, 0)
+
+	length := float64(utf8.RuneCountInString(str))
+
+	if n, ok := toFloat(sch["minLength"]); ok && length < n {
+		errs = append(errs, &ValidationError{Path: path, Message: fmt.Sprintf("Expected at least %v characters", n)})
+	}
+
+	if n, ok := toFloat(sch["maxLength"]); ok && length > n {
+		errs = append(errs, &ValidationError{Path: path, Message: fmt.Sprintf("Expected at most %v characters", n)})
+	}
+
+	if p, ok := sch["pattern"].(string); ok {
+
+		if !s.patterns[p].MatchString(str) {
+			errs = append(errs, &ValidationError{Path: path, Message: fmt.Sprintf("Value does not match pattern '%s'", p)})
+		}
+	}
+
+	return errs
+}
+
+// validateNumber validates the number-specific keywords in 'sch' against 'n'.
+func (s *Schema) validateNumber(sch map[string]interface{}, n json.Number, path string) []*ValidationError {
+
+	errs := make([]*ValidationError, 0)
+
+	f, err := n.Float64()
+
+	if err != nil {
+		return errs
+	}
+
+	if min, ok := toFloat(sch["minimum"]); ok && f < min {
+		errs = append(errs, &ValidationError{Path: path, Message: fmt.Sprintf("Expected a value greater than or equal to %v", min)})
+	}
+
+	if max, ok := toFloat(sch["maximum"]); ok && f > max {
+		errs = append(errs, &ValidationError{Path: path, Message: fmt.Sprintf("Expected a value less than or equal to %v", max)})
+	}
+
+	if min, ok := toFloat(sch["exclusiveMinimum"]); ok && f <= min {
+		errs = append(errs, &ValidationError{Path: path, Message: fmt.Sprintf("Expected a value greater than %v", min)})
+	}
+
+	if max, ok := toFloat(sch["exclusiveMaximum"]); ok && f >= max {
+		errs = append(errs, &ValidationError{Path: path, Message: fmt.Sprintf("Expected a value less than %v", max)})
+	}
+
+	if m, ok := toFloat(sch["multipleOf"]); ok && m > 0 {
+
+		q := f / m
+
+		if math.Abs(q-math.Round(q)) > 1e-9 {
+			errs = append(errs, &ValidationError{Path: path, Message: fmt.Sprintf("Expected a multiple of %v", m)})
+		}
+	}
+
+	return errs
+}
+
+// resolve returns the schema for the local JSON pointer 'ref'.
+func (s *Schema) resolve(ref string) (interface{}, error) {
+
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("Unsupported reference '%s', only local references are supported", ref)
+	}
+
+	current := s.root
+
+	pointer := strings.TrimPrefix(ref, "#")
+
+	if pointer == "" {
+		return current, nil
+	}
+
+	for _, seg := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+
+		seg = strings.ReplaceAll(strings.ReplaceAll(seg, "~1", "/"), "~0", "~")
+
+		switch t := current.(type) {
+		case map[string]interface{}:
+
+			next, ok := t[seg]
+
+			if !ok {
+				return nil, fmt.Errorf("Unresolvable reference '%s'", ref)
+			}
+
+			current = next
+
+		case []interface{}:
+
+			idx, err := strconv.Atoi(seg)
+
+			if err != nil || idx < 0 || idx >= len(t) {
+				return nil, fmt.Errorf("Unresolvable reference '%s'", ref)
+			}
+
+			current = t[idx]
+
+		default:
+			return nil, fmt.Errorf("Unresolvable reference '%s'", ref)
+		}
+	}
+
+	return current, nil
+}
+
+// compilePatterns compiles every `pattern` and `patternProperties` regular expression in 'v'.
+func (s *Schema) compilePatterns(v interface{}) error {
+
+	switch t := v.(type) {
+	case map[string]interface{}:
+
+		if p, ok := t["pattern"].(string); ok {
+
+			re, err := regexp.Compile(p)
+
+			if err != nil {
+				return fmt.Errorf("Invalid pattern '%s', %w", p, err)
+			}
+
+			s.patterns[p] = re
+		}
+
+		if pp, ok := t["patternProperties"].(map[string]interface{}); ok {
+
+			for p := range pp {
+
+				re, err := regexp.Compile(p)
+
+				if err != nil {
+					return fmt.Errorf("Invalid pattern property '%s', %w", p, err)
+				}
+
+				s.patterns[p] = re
+			}
+		}
+
+		for _, child := range t {
+
+			err := s.compilePatterns(child)
+
+			if err != nil {
+				return err
+			}
+		}
+
+	case []interface{}:
+
+		for _, child := range t {
+
+			err := s.compilePatterns(child)
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// isType returns a boolean value indicating whether 'v' is of the JSON schema type 't'.
+func isType(v interface{}, t string) bool {
+
+	switch t {
+	case "integer":
+
+		n, ok := v.(json.Number)
+
+		if !ok {
+			return false
+		}
+
+		f, err := n.Float64()
+		return err == nil && f == math.Trunc(f)
+
+	case "number":
+		_, ok := v.(json.Number)
+		return ok
+	default:
+		return typeOf(v) == t
+	}
+}
+
+// typeOf returns the JSON schema type of 'v'.
+func typeOf(v interface{}) string {
+
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return "unknown"
+	}
+}
+
+// equal returns a boolean value indicating whether 'a' and 'b' are equal JSON values.
+func equal(a interface{}, b interface{}) bool {
+
+	an, a_ok := a.(json.Number)
+	bn, b_ok := b.(json.Number)
+
+	if a_ok && b_ok {
+
+		af, _ := an.Float64()
+		bf, _ := bn.Float64()
+
+		return af == bf
+	}
+
+	ae, _ := json.Marshal(a)
+	be, _ := json.Marshal(b)
+
+	return bytes.Equal(ae, be)
+}
+
+// toFloat returns 'v' as a float64 value and a boolean value indicating whether 'v' is numeric.
+func toFloat(v interface{}) (float64, bool) {
+
+	n, ok := v.(json.Number)
+
+	if !ok {
+		return 0, false
+	}
+
+	f, err := n.Float64()
+	return f, err == nil
+}
+
+// joinPath appends 'key' to 'path'.
+func joinPath(path string, key string) string {
+
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
@@ -0,0 +1,107 @@
+package jsonschema
+
+import (
+	"testing"
+)
+
+const testSchema string = `{
+	"$schema": "https://json-schema.org/draft/2020-12/schema",
+	"type": "object",
+	"required": [ "ref", "commits" ],
+	"properties": {
+		"ref": { "type": "string", "pattern": "^refs/" },
+		"commits": {
+			"type": "array",
+			"minItems": 1,
+			"items": { "$ref": "#/$defs/commit" }
+		},
+		"count": { "type": "integer", "minimum": 0 }
+	},
+	"additionalProperties": false,
+	"$defs": {
+		"commit": {
+			"type": "object",
+			"required": [ "id" ],
+			"properties": {
+				"id": { "type": "string", "minLength": 7 },
+				"kind": { "enum": [ "add", "remove" ] }
+			}
+		}
+	}
+}`
+
+func TestValidate(t *testing.T) {
+
+	s, err := Parse([]byte(testSchema))
+
+	if err != nil {
+		t.Fatalf("Failed to parse schema, %v", err)
+	}
+
+	tests := map[string]int{
+		`{"ref":"refs/heads/main","commits":[{"id":"1234567"}]}`:                          0,
+		`{"ref":"main","commits":[{"id":"1234567"}]}`:                                     1,
+		`{"ref":"refs/heads/main","commits":[]}`:                                          1,
+		`{"ref":"refs/heads/main","commits":[{"id":"123","kind":"rename"}]}`:              2,
+		`{"ref":"refs/heads/main","commits":[{"id":"1234567"}],"count":1.5,"extra":true}`: 2,
+		`{"commits":[{"id":"1234567"}]}`:                                                  1,
+		`[]`:                                                                              1,
+	}
+
+	for doc, expected := range tests {
+
+		errs, err := s.Validate([]byte(doc))
+
+		if err != nil {
+			t.Fatalf("Failed to validate %s, %v", doc, err)
+		}
+
+		if len(errs) != expected {
+			t.Fatalf("Expected %d errors for %s but got %d: %v", expected, doc, len(errs), errs)
+		}
+	}
+}
+
+func TestValidateCombinators(t *testing.T) {
+
+	s, err := Parse([]byte(`{
+		"oneOf": [
+			{ "type": "string" },
+			{ "type": "number" }
+		],
+		"not": { "const": "forbidden" }
+	}`))
+
+	if err != nil {
+		t.Fatalf("Failed to parse schema, %v", err)
+	}
+
+	tests := map[string]int{
+		`"hello"`:     0,
+		`42`:          0,
+		`true`:        1,
+		`"forbidden"`: 1,
+	}
+
+	for doc, expected := range tests {
+
+		errs, err := s.Validate([]byte(doc))
+
+		if err != nil {
+			t.Fatalf("Failed to validate %s, %v", doc, err)
+		}
+
+		if len(errs) != expected {
+			t.Fatalf("Expected %d errors for %s but got %d: %v", expected, doc, len(errs), errs)
+		}
+	}
+}
+
+func TestParseInvalidPattern(t *testing.T) {
+
+	_, err := Parse([]byte(`{"pattern":"("}`))
+
+	if err == nil {
+		t.Fatalf("Expected invalid pattern to fail")
+	}
+}
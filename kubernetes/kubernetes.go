@@ -0,0 +1,271 @@
+// Package kubernetes provides a minimal client for the Kubernetes API server, used by the components that record state in, or
+// read configuration from, Kubernetes objects.
+package kubernetes
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// SERVICE_ACCOUNT_PATH is the path of the service account credentials mounted in Kubernetes pods.
+const SERVICE_ACCOUNT_PATH string = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// Types of `WatchEvent`.
+const (
+	WATCH_ADDED    string = "ADDED"
+	WATCH_MODIFIED string = "MODIFIED"
+	WATCH_DELETED  string = "DELETED"
+	WATCH_BOOKMARK string = "BOOKMARK"
+	WATCH_ERROR    string = "ERROR"
+)
+
+// WatchEvent is a single change to a Kubernetes object, read from the API server while watching a collection of objects.
+type WatchEvent struct {
+	// Type is the type of change, one of the `WATCH_` constants.
+	Type string `json:"type"`
+	// Object is the JSON-encoded object after the change or, for "ERROR" events, a `Status` object describing the error.
+	Object json.RawMessage `json:"object"`
+}
+
+// ObjectMeta is the subset of the metadata for Kubernetes objects used by webhookd.
+type ObjectMeta struct {
+	Name            string            `json:"name"`
+	Namespace       string            `json:"namespace"`
+	ResourceVersion string            `json:"resourceVersion,omitempty"`
+	Labels          map[string]string `json:"labels,omitempty"`
+	Annotations     map[string]string `json:"annotations,omitempty"`
+}
+
+// ListMeta is the subset of the metadata for lists of Kubernetes objects used by webhookd.
+type ListMeta struct {
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+}
+
+// Client is a minimal client for the Kubernetes API server.
+type Client struct {
+	endpoint   string
+	namespace  string
+	token      string
+	token_path string
+	client     *http.Client
+	// stream_client is the `http.Client` used to watch objects, which does not time out.
+	stream_client *http.Client
+}
+
+// NewClient returns a new `Client` instance configured by the following parameters in 'q':
+// * `endpoint={URL}` The URL of the Kubernetes API server. Default is derived from the `KUBERNETES_SERVICE_HOST` and
+// `KUBERNETES_SERVICE_PORT` environment variables, using the pod's service account CA certificate.
+// * `namespace={STRING}` The namespace for objects. Default is the namespace of the pod webhookd is running in.
+// * `token={STRING}` The bearer token used to authenticate requests. Default is the pod's service account token, which is read
+// before each request since it may be rotated.
+func NewClient(ctx context.Context, q url.Values) (*Client, error) {
+
+	c := &Client{
+		endpoint:      strings.TrimRight(q.Get("endpoint"), "/"),
+		namespace:     q.Get("namespace"),
+		token:         q.Get("token"),
+		client:        &http.Client{Timeout: 10 * time.Second},
+		stream_client: &http.Client{},
+	}
+
+	if c.endpoint == "" {
+
+		host := os.Getenv("KUBERNETES_SERVICE_HOST")
+		port := os.Getenv("KUBERNETES_SERVICE_PORT")
+
+		if host == "" || port == "" {
+			return nil, fmt.Errorf("Missing ?endpoint= parameter and not running in a Kubernetes pod")
+		}
+
+		c.endpoint = "https://" + net.JoinHostPort(host, port)
+
+		pool := x509.NewCertPool()
+
+		ca, err := os.ReadFile(SERVICE_ACCOUNT_PATH + "/ca.crt")
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read service account CA certificate, %w", err)
+		}
+
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("Invalid service account CA certificate")
+		}
+
+		tr := &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: pool},
+		}
+
+		c.client.Transport = tr
+		c.stream_client.Transport = tr
+	}
+
+	if c.namespace == "" {
+
+		ns, err := os.ReadFile(SERVICE_ACCOUNT_PATH + "/namespace")
+
+		if err != nil {
+			return nil, fmt.Errorf("Missing ?namespace= parameter, %w", err)
+		}
+
+		c.namespace = strings.TrimSpace(string(ns))
+	}
+
+	if c.token == "" {
+		c.token_path = SERVICE_ACCOUNT_PATH + "/token"
+	}
+
+	return c, nil
+}
+
+// Namespace returns the namespace for objects read and written by 'c'.
+func (c *Client) Namespace() string {
+	return c.namespace
+}
+
+// Do sends 'body', JSON-encoded if not nil, to 'path' (relative to the API server) using 'method' and decodes a successful
+// (200 OK) response in to 'target' if not nil. It returns the HTTP status code of the response; unsuccessful responses are not
+// considered errors so that callers can handle conflicts and missing objects.
+func (c *Client) Do(ctx context.Context, method string, path string, body interface{}, target interface{}) (int, error) {
+
+	rsp, err := c.send(ctx, c.client, method, path, body)
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer rsp.Body.Close()
+
+	if target == nil || rsp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, rsp.Body)
+		return rsp.StatusCode, nil
+	}
+
+	err = json.NewDecoder(rsp.Body).Decode(target)
+
+	if err != nil {
+		return 0, fmt.Errorf("Failed to decode response, %w", err)
+	}
+
+	return rsp.StatusCode, nil
+}
+
+// Watch watches the collection of objects at 'path' (relative to the API server, including any query parameters other than
+// "watch") and calls 'fn' for each `WatchEvent` until 'ctx' is cancelled, the API server ends the watch or 'fn' returns an error.
+func (c *Client) Watch(ctx context.Context, path string, fn func(*WatchEvent) error) error {
+
+	sep := "?"
+
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+
+	rsp, err := c.send(ctx, c.stream_client, http.MethodGet, path+sep+"watch=1", nil)
+
+	if err != nil {
+		return err
+	}
+
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Failed to watch objects, unexpected status %d", rsp.StatusCode)
+	}
+
+	reader := bufio.NewReader(rsp.Body)
+
+	for {
+
+		ln, err := reader.ReadBytes('\n')
+
+		if len(bytes.TrimSpace(ln)) > 0 {
+
+			var ev *WatchEvent
+
+			dec_err := json.Unmarshal(ln, &ev)
+
+			if dec_err != nil {
+				return fmt.Errorf("Failed to decode watch event, %w", dec_err)
+			}
+
+			fn_err := fn(ev)
+
+			if fn_err != nil {
+				return fn_err
+			}
+		}
+
+		if err == io.EOF || ctx.Err() != nil {
+			return nil
+		}
+
+		if err != nil {
+			return fmt.Errorf("Failed to read watch event, %w", err)
+		}
+	}
+}
+
+// send sends 'body', JSON-encoded if not nil, to 'path' using 'method' and 'http_client'.
+func (c *Client) send(ctx context.Context, http_client *http.Client, method string, path string, body interface{}) (*http.Response, error) {
+
+	var r io.Reader
+
+	if body != nil {
+
+		enc, err := json.Marshal(body)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to encode request, %w", err)
+		}
+
+		r = bytes.NewReader(enc)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.endpoint+path, r)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create request, %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	token := c.token
+
+	if c.token_path != "" {
+
+		v, err := os.ReadFile(c.token_path)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read service account token, %w", err)
+		}
+
+		token = strings.TrimSpace(string(v))
+	}
+
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	rsp, err := http_client.Do(req)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to send request, %w", err)
+	}
+
+	return rsp, nil
+}
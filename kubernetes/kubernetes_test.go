@@ -0,0 +1,109 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestClient(t *testing.T) {
+
+	ctx := context.Background()
+
+	handler := func(rsp http.ResponseWriter, req *http.Request) {
+
+		if req.Header.Get("Authorization") != "Bearer s33kret" {
+			http.Error(rsp, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		switch req.URL.Path {
+		case "/api/v1/namespaces/webhookd/configmaps/example":
+			rsp.Write([]byte(`{"metadata":{"name":"example","namespace":"webhookd","resourceVersion":"7"}}`))
+		case "/api/v1/namespaces/webhookd/configmaps":
+
+			if req.URL.Query().Get("watch") != "1" || req.URL.Query().Get("resourceVersion") != "7" {
+				http.Error(rsp, "Bad request", http.StatusBadRequest)
+				return
+			}
+
+			fmt.Fprintln(rsp, `{"type":"ADDED","object":{"metadata":{"name":"a"}}}`)
+			fmt.Fprintln(rsp, `{"type":"DELETED","object":{"metadata":{"name":"b"}}}`)
+
+		default:
+			http.Error(rsp, "Not found", http.StatusNotFound)
+		}
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(handler))
+	defer ts.Close()
+
+	q := url.Values{}
+	q.Set("endpoint", ts.URL)
+	q.Set("namespace", "webhookd")
+	q.Set("token", "s33kret")
+
+	c, err := NewClient(ctx, q)
+
+	if err != nil {
+		t.Fatalf("Failed to create client, %v", err)
+	}
+
+	if c.Namespace() != "webhookd" {
+		t.Fatalf("Unexpected namespace, %s", c.Namespace())
+	}
+
+	var obj struct {
+		Metadata ObjectMeta `json:"metadata"`
+	}
+
+	status, err := c.Do(ctx, http.MethodGet, "/api/v1/namespaces/webhookd/configmaps/example", nil, &obj)
+
+	if err != nil {
+		t.Fatalf("Failed to get object, %v", err)
+	}
+
+	if status != http.StatusOK || obj.Metadata.ResourceVersion != "7" {
+		t.Fatalf("Unexpected status (%d) or object (%v)", status, obj)
+	}
+
+	status, err = c.Do(ctx, http.MethodGet, "/api/v1/namespaces/webhookd/configmaps/missing", nil, &obj)
+
+	if err != nil || status != http.StatusNotFound {
+		t.Fatalf("Expected missing object to return 404, got %d (%v)", status, err)
+	}
+
+	events := make([]string, 0)
+
+	err = c.Watch(ctx, "/api/v1/namespaces/webhookd/configmaps?resourceVersion=7", func(ev *WatchEvent) error {
+		events = append(events, ev.Type)
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("Failed to watch objects, %v", err)
+	}
+
+	if len(events) != 2 || events[0] != WATCH_ADDED || events[1] != WATCH_DELETED {
+		t.Fatalf("Unexpected events, %v", events)
+	}
+
+	err = c.Watch(ctx, "/api/v1/namespaces/webhookd/configmaps", func(ev *WatchEvent) error {
+		return nil
+	})
+
+	if err == nil {
+		t.Fatalf("Expected invalid watch to fail")
+	}
+
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+
+	_, err = NewClient(ctx, url.Values{})
+
+	if err == nil {
+		t.Fatalf("Expected client without an endpoint to fail")
+	}
+}
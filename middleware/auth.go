@@ -0,0 +1,163 @@
+package middleware
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterMiddleware(ctx, "auth", NewAuthMiddleware)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// AuthMiddleware implements the `webhookd.WebhookMiddleware` interface for requiring that requests are authenticated with a bearer
+// token or HTTP basic authentication credentials.
+type AuthMiddleware struct {
+	webhookd.WebhookMiddleware
+	// tokens is the list of valid bearer tokens.
+	tokens []string
+	// credentials is the list of valid "{USER}:{PASSWORD}" basic authentication credentials.
+	credentials []string
+	// realm is the realm reported in the "WWW-Authenticate" header of unauthorized responses.
+	realm string
+}
+
+// NewAuthMiddleware returns a new `AuthMiddleware` instance configured by 'uri' in the form of:
+//
+//	auth://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `bearer={TOKEN}` Zero or more valid bearer tokens, sent in an "Authorization: Bearer {TOKEN}" header.
+// * `basic={USER}:{PASSWORD}` Zero or more valid HTTP basic authentication credentials.
+// * `realm={STRING}` The realm reported in the "WWW-Authenticate" header of unauthorized responses. Default is "webhookd".
+//
+// At least one bearer token or set of basic authentication credentials must be defined. Requests are authorized if they match any of them.
+func NewAuthMiddleware(ctx context.Context, uri string) (webhookd.WebhookMiddleware, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	tokens := make([]string, 0)
+	credentials := make([]string, 0)
+
+	for _, t := range q["bearer"] {
+
+		if t == "" {
+			return nil, fmt.Errorf("Invalid ?bearer= parameter, empty token")
+		}
+
+		tokens = append(tokens, t)
+	}
+
+	for _, c := range q["basic"] {
+
+		user, _, ok := strings.Cut(c, ":")
+
+		if !ok || user == "" {
+			return nil, fmt.Errorf("Invalid ?basic= parameter, expected {USER}:{PASSWORD}")
+		}
+
+		credentials = append(credentials, c)
+	}
+
+	if len(tokens) == 0 && len(credentials) == 0 {
+		return nil, fmt.Errorf("Missing ?bearer= or ?basic= parameter")
+	}
+
+	realm := q.Get("realm")
+
+	if realm == "" {
+		realm = "webhookd"
+	}
+
+	m := &AuthMiddleware{
+		tokens:      tokens,
+		credentials: credentials,
+		realm:       realm,
+	}
+
+	return m, nil
+}
+
+// Handler returns an `http.Handler` that responds with a "401 Unauthorized" error unless a request has a valid bearer token or
+// basic authentication credentials.
+func (m *AuthMiddleware) Handler(next http.Handler) http.Handler {
+
+	fn := func(rsp http.ResponseWriter, req *http.Request) {
+
+		if !m.authorized(req) {
+
+			if len(m.credentials) > 0 {
+				rsp.Header().Set("WWW-Authenticate", fmt.Sprintf("Basic realm=%q", m.realm))
+			} else {
+				rsp.Header().Set("WWW-Authenticate", fmt.Sprintf("Bearer realm=%q", m.realm))
+			}
+
+			http.Error(rsp, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(rsp, req)
+	}
+
+	return http.HandlerFunc(fn)
+}
+
+// authorized returns true if 'req' has a valid bearer token or basic authentication credentials.
+func (m *AuthMiddleware) authorized(req *http.Request) bool {
+
+	auth := req.Header.Get("Authorization")
+
+	scheme, value, ok := strings.Cut(auth, " ")
+
+	if !ok {
+		return false
+	}
+
+	var candidates []string
+
+	switch strings.ToLower(scheme) {
+	case "bearer":
+		candidates = m.tokens
+	case "basic":
+
+		user, password, ok := req.BasicAuth()
+
+		if !ok {
+			return false
+		}
+
+		candidates = m.credentials
+		value = user + ":" + password
+
+	default:
+		return false
+	}
+
+	authorized := false
+
+	for _, c := range candidates {
+
+		if subtle.ConstantTimeCompare([]byte(c), []byte(value)) == 1 {
+			authorized = true
+		}
+	}
+
+	return authorized
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAuthMiddleware(t *testing.T) {
+
+	ctx := context.Background()
+
+	m, err := NewMiddleware(ctx, "auth://?bearer=s33kret&basic=bob:hunter2")
+
+	if err != nil {
+		t.Fatalf("Failed to create new middleware, %v", err)
+	}
+
+	next := http.HandlerFunc(func(rsp http.ResponseWriter, req *http.Request) {})
+	h := m.Handler(next)
+
+	tests := map[string]int{
+		"":                       http.StatusUnauthorized,
+		"Bearer s33kret":         http.StatusOK,
+		"bearer s33kret":         http.StatusOK,
+		"Bearer hunter2":         http.StatusUnauthorized,
+		"Basic Ym9iOmh1bnRlcjI=": http.StatusOK,
+		"Basic Ym9iOnMzM2tyZXQ=": http.StatusUnauthorized,
+		"Token s33kret":          http.StatusUnauthorized,
+	}
+
+	for auth, expected := range tests {
+
+		req := httptest.NewRequest("POST", "/", nil)
+
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		if rec.Code != expected {
+			t.Fatalf("Unexpected status for '%s': %d", auth, rec.Code)
+		}
+
+		if expected == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+			t.Fatalf("Missing WWW-Authenticate header for '%s'", auth)
+		}
+	}
+}
+
+func TestAuthMiddlewareInvalid(t *testing.T) {
+
+	ctx := context.Background()
+
+	tests := []string{
+		"auth://",
+		"auth://?bearer=",
+		"auth://?basic=bob",
+		"auth://?basic=:hunter2",
+	}
+
+	for _, uri := range tests {
+
+		_, err := NewMiddleware(ctx, uri)
+
+		if err == nil {
+			t.Fatalf("Expected %s to fail", uri)
+		}
+	}
+}
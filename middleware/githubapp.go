@@ -0,0 +1,223 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+// GITHUB_TARGET_ID_HEADER is the HTTP header containing the ID of the resource (for GitHub App webhooks the ID of the app) where
+// a GitHub webhook was created.
+const GITHUB_TARGET_ID_HEADER string = "X-GitHub-Hook-Installation-Target-ID"
+
+// GITHUB_TARGET_TYPE_HEADER is the HTTP header containing the type of the resource where a GitHub webhook was created.
+const GITHUB_TARGET_TYPE_HEADER string = "X-GitHub-Hook-Installation-Target-Type"
+
+// GITHUB_TARGET_TYPE_APP is the value of the `GITHUB_TARGET_TYPE_HEADER` header for GitHub App webhooks.
+const GITHUB_TARGET_TYPE_APP string = "integration"
+
+// contextKey is a private type for keys used to store values in a `context.Context` instance.
+type contextKey string
+
+// gitHubAppKey is the `context.Context` key used to store the `GitHubApp` for a webhook message.
+const gitHubAppKey contextKey = "webhookd.middleware.github_app"
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterMiddleware(ctx, "githubapp", NewGitHubAppMiddleware)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// GitHubApp is the GitHub App, and installation, that a webhook message was delivered by.
+type GitHubApp struct {
+	// AppID is the ID of the GitHub App.
+	AppID int64
+	// InstallationID is the ID of the installation of the GitHub App, derived from the message body. It is 0 for events, for
+	// example changes to the app itself, that are not associated with an installation.
+	InstallationID int64
+}
+
+// gitHubAppEvent is the subset of a GitHub App webhook message used by `GitHubAppMiddleware`.
+type gitHubAppEvent struct {
+	Installation struct {
+		ID int64 `json:"id"`
+	} `json:"installation"`
+}
+
+// GitHubAppMiddleware implements the `webhookd.WebhookMiddleware` interface for requiring that requests are GitHub App webhook
+// messages delivered by specific apps, and installations, and for making those details available to the processing flow.
+type GitHubAppMiddleware struct {
+	webhookd.WebhookMiddleware
+	// app_ids is the list of allowed GitHub App IDs.
+	app_ids map[int64]bool
+	// installation_ids is the optional list of allowed GitHub App installation IDs.
+	installation_ids map[int64]bool
+}
+
+// NewGitHubAppMiddleware returns a new `GitHubAppMiddleware` instance configured by 'uri' in the form of:
+//
+//	githubapp://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `app_id={INT}` One or more valid GitHub App IDs, compared to the "X-GitHub-Hook-Installation-Target-ID" header. Required.
+// * `installation_id={INT}` Zero or more valid GitHub App installation IDs, compared to the `installation.id` property of the
+// message body. If none are defined messages from any installation of the app are allowed.
+//
+// Signatures are not validated by this middleware and should be validated by the webhook's receiver.
+func NewGitHubAppMiddleware(ctx context.Context, uri string) (webhookd.WebhookMiddleware, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	app_ids, err := parseIDs(q, "app_id")
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(app_ids) == 0 {
+		return nil, fmt.Errorf("Missing ?app_id= parameter")
+	}
+
+	installation_ids, err := parseIDs(q, "installation_id")
+
+	if err != nil {
+		return nil, err
+	}
+
+	m := &GitHubAppMiddleware{
+		app_ids:          app_ids,
+		installation_ids: installation_ids,
+	}
+
+	return m, nil
+}
+
+// Handler returns an `http.Handler` that responds with a "403 Forbidden" error for requests that were not delivered by one of the
+// allowed GitHub Apps or installations. The `GitHubApp` for allowed requests is available to receivers, transformations and
+// dispatchers using the `GitHubAppFromContext` method.
+func (m *GitHubAppMiddleware) Handler(next http.Handler) http.Handler {
+
+	fn := func(rsp http.ResponseWriter, req *http.Request) {
+
+		if req.Header.Get(GITHUB_TARGET_TYPE_HEADER) != GITHUB_TARGET_TYPE_APP {
+			http.Error(rsp, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		app_id, err := strconv.ParseInt(req.Header.Get(GITHUB_TARGET_ID_HEADER), 10, 64)
+
+		if err != nil || !m.app_ids[app_id] {
+			http.Error(rsp, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		body, err := io.ReadAll(req.Body)
+
+		if err != nil {
+			http.Error(rsp, "Failed to read body", http.StatusBadRequest)
+			return
+		}
+
+		req.Body = io.NopCloser(bytes.NewReader(body))
+
+		installation_id, err := gitHubInstallationID(req.Header.Get("Content-Type"), body)
+
+		if err != nil && len(m.installation_ids) > 0 {
+			http.Error(rsp, "Invalid body", http.StatusBadRequest)
+			return
+		}
+
+		if len(m.installation_ids) > 0 && !m.installation_ids[installation_id] {
+			http.Error(rsp, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		app := &GitHubApp{
+			AppID:          app_id,
+			InstallationID: installation_id,
+		}
+
+		ctx := context.WithValue(req.Context(), gitHubAppKey, app)
+		next.ServeHTTP(rsp, req.WithContext(ctx))
+	}
+
+	return http.HandlerFunc(fn)
+}
+
+// GitHubAppFromContext returns the `GitHubApp` that the webhook message being processed in 'ctx' was delivered by, and a boolean
+// value indicating whether it was found. It is only found for webhooks using `GitHubAppMiddleware`.
+func GitHubAppFromContext(ctx context.Context) (*GitHubApp, bool) {
+
+	v := ctx.Value(gitHubAppKey)
+
+	if v == nil {
+		return nil, false
+	}
+
+	return v.(*GitHubApp), true
+}
+
+// gitHubInstallationID returns the installation ID in 'body' which is either JSON-encoded or, if 'content_type' is
+// "application/x-www-form-urlencoded", a form with a JSON-encoded "payload" field.
+func gitHubInstallationID(content_type string, body []byte) (int64, error) {
+
+	media_type, _, _ := mime.ParseMediaType(content_type)
+
+	if media_type == "application/x-www-form-urlencoded" {
+
+		form, err := url.ParseQuery(string(body))
+
+		if err != nil {
+			return 0, fmt.Errorf("Failed to parse form, %w", err)
+		}
+
+		body = []byte(form.Get("payload"))
+	}
+
+	var ev gitHubAppEvent
+
+	err := json.Unmarshal(body, &ev)
+
+	if err != nil {
+		return 0, fmt.Errorf("Failed to decode body, %w", err)
+	}
+
+	return ev.Installation.ID, nil
+}
+
+// parseIDs returns the dictionary of numeric IDs defined by the query parameter 'key' in 'q'.
+func parseIDs(q url.Values, key string) (map[int64]bool, error) {
+
+	ids := make(map[int64]bool)
+
+	for _, str_id := range q[key] {
+
+		id, err := strconv.ParseInt(str_id, 10, 64)
+
+		if err != nil || id < 1 {
+			return nil, fmt.Errorf("Invalid ?%s= parameter '%s'", key, str_id)
+		}
+
+		ids[id] = true
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestGitHubAppMiddleware(t *testing.T) {
+
+	ctx := context.Background()
+
+	m, err := NewMiddleware(ctx, "githubapp://?app_id=1234&installation_id=42&installation_id=43")
+
+	if err != nil {
+		t.Fatalf("Failed to create new middleware, %v", err)
+	}
+
+	next := http.HandlerFunc(func(rsp http.ResponseWriter, req *http.Request) {
+
+		app, ok := GitHubAppFromContext(req.Context())
+
+		if !ok {
+			http.Error(rsp, "Missing app", http.StatusInternalServerError)
+			return
+		}
+
+		body, _ := io.ReadAll(req.Body)
+
+		if !strings.Contains(string(body), "installation") {
+			http.Error(rsp, "Missing body", http.StatusInternalServerError)
+			return
+		}
+
+		rsp.Write([]byte(strconv.FormatInt(app.AppID, 10) + "/" + strconv.FormatInt(app.InstallationID, 10)))
+	})
+
+	h := m.Handler(next)
+
+	tests := []struct {
+		target_type  string
+		target_id    string
+		content_type string
+		body         string
+		status       int
+		expected     string
+	}{
+		{"integration", "1234", "application/json", `{"installation":{"id":42}}`, http.StatusOK, "1234/42"},
+		{"integration", "1234", "application/x-www-form-urlencoded", "payload=" + url.QueryEscape(`{"installation":{"id":43}}`), http.StatusOK, "1234/43"},
+		{"integration", "1234", "application/json", `{"installation":{"id":44}}`, http.StatusForbidden, ""},
+		{"integration", "5678", "application/json", `{"installation":{"id":42}}`, http.StatusForbidden, ""},
+		{"repository", "1234", "application/json", `{"installation":{"id":42}}`, http.StatusForbidden, ""},
+		{"", "", "application/json", `{"installation":{"id":42}}`, http.StatusForbidden, ""},
+		{"integration", "1234", "application/json", `not json`, http.StatusBadRequest, ""},
+	}
+
+	for idx, test := range tests {
+
+		req := httptest.NewRequest("POST", "/", strings.NewReader(test.body))
+		req.Header.Set("Content-Type", test.content_type)
+		req.Header.Set(GITHUB_TARGET_TYPE_HEADER, test.target_type)
+		req.Header.Set(GITHUB_TARGET_ID_HEADER, test.target_id)
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		if rec.Code != test.status {
+			t.Fatalf("Unexpected status for test %d: %d", idx, rec.Code)
+		}
+
+		if test.expected != "" && rec.Body.String() != test.expected {
+			t.Fatalf("Unexpected app for test %d: %s", idx, rec.Body.String())
+		}
+	}
+
+	// Without installation IDs messages from any installation, or none, are allowed
+
+	m, err = NewMiddleware(ctx, "githubapp://?app_id=1234")
+
+	if err != nil {
+		t.Fatalf("Failed to create new middleware, %v", err)
+	}
+
+	h = m.Handler(next)
+
+	req := httptest.NewRequest("POST", "/", strings.NewReader(`{"action":"created","installation":null}`))
+	req.Header.Set(GITHUB_TARGET_TYPE_HEADER, GITHUB_TARGET_TYPE_APP)
+	req.Header.Set(GITHUB_TARGET_ID_HEADER, "1234")
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK || rec.Body.String() != "1234/0" {
+		t.Fatalf("Unexpected response: %d %s", rec.Code, rec.Body.String())
+	}
+
+	for _, uri := range []string{"githubapp://", "githubapp://?app_id=abc", "githubapp://?app_id=1234&installation_id=0"} {
+
+		_, err := NewMiddleware(ctx, uri)
+
+		if err == nil {
+			t.Fatalf("Expected %s to fail", uri)
+		}
+	}
+
+	_, ok := GitHubAppFromContext(ctx)
+
+	if ok {
+		t.Fatalf("Expected no app in empty context")
+	}
+}
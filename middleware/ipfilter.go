@@ -0,0 +1,164 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterMiddleware(ctx, "ipfilter", NewIPFilterMiddleware)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// IPFilterMiddleware implements the `webhookd.WebhookMiddleware` interface for allowing or denying requests based on the network
+// address of the client that sent them.
+type IPFilterMiddleware struct {
+	webhookd.WebhookMiddleware
+	// allow is the list of networks that requests are allowed from. If empty requests are allowed from all networks not in 'deny'.
+	allow []*net.IPNet
+	// deny is the list of networks that requests are denied from.
+	deny []*net.IPNet
+}
+
+// NewIPFilterMiddleware returns a new `IPFilterMiddleware` instance configured by 'uri' in the form of:
+//
+//	ipfilter://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `allow={CIDR}` Zero or more (comma-separated or repeated) IP addresses or CIDR ranges that requests are allowed from.
+// * `deny={CIDR}` Zero or more (comma-separated or repeated) IP addresses or CIDR ranges that requests are denied from.
+//
+// Deny rules take precedence over allow rules. If there are no allow rules then requests are allowed from any address that is not denied.
+func NewIPFilterMiddleware(ctx context.Context, uri string) (webhookd.WebhookMiddleware, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	allow, err := parseIPNets(q["allow"])
+
+	if err != nil {
+		return nil, fmt.Errorf("Invalid ?allow= parameter, %w", err)
+	}
+
+	deny, err := parseIPNets(q["deny"])
+
+	if err != nil {
+		return nil, fmt.Errorf("Invalid ?deny= parameter, %w", err)
+	}
+
+	if len(allow) == 0 && len(deny) == 0 {
+		return nil, fmt.Errorf("Missing ?allow= or ?deny= parameter")
+	}
+
+	m := &IPFilterMiddleware{
+		allow: allow,
+		deny:  deny,
+	}
+
+	return m, nil
+}
+
+// Handler returns an `http.Handler` that responds with a "403 Forbidden" error for requests from denied addresses.
+func (m *IPFilterMiddleware) Handler(next http.Handler) http.Handler {
+
+	fn := func(rsp http.ResponseWriter, req *http.Request) {
+
+		if !m.allowed(remoteAddress(req)) {
+			http.Error(rsp, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(rsp, req)
+	}
+
+	return http.HandlerFunc(fn)
+}
+
+// allowed returns true if requests are allowed from 'addr'.
+func (m *IPFilterMiddleware) allowed(addr string) bool {
+
+	ip := net.ParseIP(addr)
+
+	if ip == nil {
+		return false
+	}
+
+	for _, n := range m.deny {
+
+		if n.Contains(ip) {
+			return false
+		}
+	}
+
+	if len(m.allow) == 0 {
+		return true
+	}
+
+	for _, n := range m.allow {
+
+		if n.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseIPNets returns the list of `net.IPNet` instances for the (comma-separated) IP addresses and CIDR ranges in 'values'.
+func parseIPNets(values []string) ([]*net.IPNet, error) {
+
+	nets := make([]*net.IPNet, 0)
+
+	for _, v := range values {
+
+		for _, str_net := range strings.Split(v, ",") {
+
+			str_net = strings.TrimSpace(str_net)
+
+			if str_net == "" {
+				continue
+			}
+
+			if !strings.Contains(str_net, "/") {
+
+				ip := net.ParseIP(str_net)
+
+				if ip == nil {
+					return nil, fmt.Errorf("Invalid IP address '%s'", str_net)
+				}
+
+				if ip.To4() != nil {
+					str_net = str_net + "/32"
+				} else {
+					str_net = str_net + "/128"
+				}
+			}
+
+			_, n, err := net.ParseCIDR(str_net)
+
+			if err != nil {
+				return nil, fmt.Errorf("Invalid CIDR range '%s', %w", str_net, err)
+			}
+
+			nets = append(nets, n)
+		}
+	}
+
+	return nets, nil
+}
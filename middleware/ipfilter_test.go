@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+func TestIPFilterMiddleware(t *testing.T) {
+
+	ctx := context.Background()
+
+	m, err := NewMiddleware(ctx, "ipfilter://?allow=192.0.2.0/24,2001:db8::/32&deny=192.0.2.13")
+
+	if err != nil {
+		t.Fatalf("Failed to create new middleware, %v", err)
+	}
+
+	next := http.HandlerFunc(func(rsp http.ResponseWriter, req *http.Request) {})
+	h := m.Handler(next)
+
+	tests := map[string]int{
+		"192.0.2.1:1234":     http.StatusOK,
+		"192.0.2.13:1234":    http.StatusForbidden,
+		"198.51.100.1:1234":  http.StatusForbidden,
+		"[2001:db8::1]:1234": http.StatusOK,
+	}
+
+	for addr, expected := range tests {
+
+		req := httptest.NewRequest("POST", "/", nil)
+		req.RemoteAddr = addr
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		if rec.Code != expected {
+			t.Fatalf("Unexpected status for %s: %d", addr, rec.Code)
+		}
+	}
+
+	// The remote address in the request context takes precedence
+
+	req := httptest.NewRequest("POST", "/", nil)
+	req.RemoteAddr = "198.51.100.1:1234"
+	req = req.WithContext(webhookd.WithRemoteAddress(req.Context(), "192.0.2.1"))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Unexpected status for context remote address: %d", rec.Code)
+	}
+
+	for _, uri := range []string{"ipfilter://", "ipfilter://?allow=example.com", "ipfilter://?deny=192.0.2.0/99"} {
+
+		_, err := NewMiddleware(ctx, uri)
+
+		if err == nil {
+			t.Fatalf("Expected %s to fail", uri)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+// Package middleware provides methods for applying cross-cutting policies to webhook requests before they are processed.
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+
+	"github.com/aaronland/go-roster"
+	"github.com/whosonfirst/go-webhookd/v3"
+	"github.com/whosonfirst/go-webhookd/v3/secrets"
+)
+
+// middlewares is a `aaronland/go-roster.Roster` instance used to maintain a list of registered `webhookd.WebhookMiddleware` initialization functions.
+var middlewares roster.Roster
+
+// MiddlewareInitializationFunc is a function used to initialize an implementation of the `webhookd.WebhookMiddleware` interface.
+type MiddlewareInitializationFunc func(ctx context.Context, uri string) (webhookd.WebhookMiddleware, error)
+
+// NewMiddleware() returns a new `webhookd.WebhookMiddleware` instance derived from 'uri'. The semantics of and requirements for
+// 'uri' as specific to the package implementing the interface.
+func NewMiddleware(ctx context.Context, uri string) (webhookd.WebhookMiddleware, error) {
+
+	err := ensureMiddlewareRoster()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to ensure middleware roster, %w", err)
+	}
+
+	uri, err = secrets.ResolveURI(ctx, uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to resolve secrets, %w", err)
+	}
+
+	parsed, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	scheme := parsed.Scheme
+
+	i, err := middlewares.Driver(ctx, scheme)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to find initialization function for '%s', %w", scheme, err)
+	}
+
+	init_func := i.(MiddlewareInitializationFunc)
+	return init_func(ctx, uri)
+}
+
+// RegisterMiddleware() associates 'scheme' with 'init_func' in an internal list of avilable `webhookd.WebhookMiddleware` implementations.
+func RegisterMiddleware(ctx context.Context, scheme string, init_func MiddlewareInitializationFunc) error {
+
+	err := ensureMiddlewareRoster()
+
+	if err != nil {
+		return fmt.Errorf("Failed to ensure middleware roster, %w", err)
+	}
+
+	return middlewares.Register(ctx, scheme, init_func)
+}
+
+// ensureMiddlewareRoster() ensures that a `aaronland/go-roster.Roster` instance used to maintain a list of registered `webhookd.WebhookMiddleware`
+// initialization functions is present
+func ensureMiddlewareRoster() error {
+
+	if middlewares == nil {
+
+		r, err := roster.NewDefaultRoster()
+
+		if err != nil {
+			return fmt.Errorf("Failed to create new roster, %w", err)
+		}
+
+		middlewares = r
+	}
+
+	return nil
+}
+
+// Schemes() returns the list of schemes that have been "registered".
+func Schemes() []string {
+	ctx := context.Background()
+	drivers := middlewares.Drivers(ctx)
+
+	schemes := make([]string, len(drivers))
+
+	for idx, dr := range drivers {
+		schemes[idx] = fmt.Sprintf("%s://", dr)
+	}
+
+	sort.Strings(schemes)
+	return schemes
+}
+
+// Chain() returns an `http.Handler` that applies each of 'chain', in order, to a request before passing it to 'next'. The first
+// middleware in 'chain' is the first to see a request.
+func Chain(next http.Handler, chain ...webhookd.WebhookMiddleware) http.Handler {
+
+	h := next
+
+	for i := len(chain) - 1; i >= 0; i-- {
+		h = chain[i].Handler(h)
+	}
+
+	return h
+}
+
+// remoteAddress returns the network address of the client that sent 'req'. If the address has been assigned to the request context
+// (with `webhookd.WithRemoteAddress`) that value is returned, otherwise the host portion of `req.RemoteAddr` is returned.
+func remoteAddress(req *http.Request) string {
+
+	addr := webhookd.RemoteAddress(req.Context())
+
+	if addr != "" {
+		return addr
+	}
+
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+
+	if err != nil {
+		return req.RemoteAddr
+	}
+
+	return host
+}
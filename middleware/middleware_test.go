@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+// headerMiddleware is a `webhookd.WebhookMiddleware` implementation that appends its name to the "X-Chain" request header.
+type headerMiddleware struct {
+	webhookd.WebhookMiddleware
+	name string
+}
+
+func (m *headerMiddleware) Handler(next http.Handler) http.Handler {
+
+	fn := func(rsp http.ResponseWriter, req *http.Request) {
+		req.Header.Add("X-Chain", m.name)
+		next.ServeHTTP(rsp, req)
+	}
+
+	return http.HandlerFunc(fn)
+}
+
+func TestRegisterMiddleware(t *testing.T) {
+
+	ctx := context.Background()
+
+	err := RegisterMiddleware(ctx, "auth", NewAuthMiddleware)
+
+	if err == nil {
+		t.Fatalf("Expected NewAuthMiddleware to be registered already")
+	}
+}
+
+func TestNewMiddleware(t *testing.T) {
+
+	ctx := context.Background()
+
+	uri := "sizelimit://?max_size=1024"
+
+	_, err := NewMiddleware(ctx, uri)
+
+	if err != nil {
+		t.Fatalf("Failed to create new middleware for '%s', %v", uri, err)
+	}
+}
+
+func TestChain(t *testing.T) {
+
+	var chain []string
+
+	next := http.HandlerFunc(func(rsp http.ResponseWriter, req *http.Request) {
+		chain = req.Header.Values("X-Chain")
+	})
+
+	h := Chain(next, &headerMiddleware{name: "a"}, &headerMiddleware{name: "b"})
+
+	req := httptest.NewRequest("POST", "/", nil)
+	rec := httptest.NewRecorder()
+
+	h.ServeHTTP(rec, req)
+
+	if strings.Join(chain, ",") != "a,b" {
+		t.Fatalf("Unexpected chain: %v", chain)
+	}
+}
@@ -0,0 +1,276 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+	"github.com/whosonfirst/go-webhookd/v3/cluster"
+)
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterMiddleware(ctx, "ratelimit", NewRateLimitMiddleware)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Valid keys for rate limiting requests.
+const (
+	RATELIMIT_REMOTE_ADDRESS string = "remote_address"
+	RATELIMIT_GLOBAL         string = "global"
+)
+
+// DEFAULT_RATELIMIT_NAME is the default name used to distinguish the counters of rate limits that share a `cluster.Store`.
+const DEFAULT_RATELIMIT_NAME string = "default"
+
+// rateLimitMaxBuckets is the number of buckets after which idle buckets are discarded.
+const rateLimitMaxBuckets int = 10000
+
+// rateLimitBucket is a token bucket for a single rate limiting key.
+type rateLimitBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// RateLimitMiddleware implements the `webhookd.WebhookMiddleware` interface for limiting the rate of requests, using a token
+// bucket, either globally or for each client.
+type RateLimitMiddleware struct {
+	webhookd.WebhookMiddleware
+	// rate is the number of tokens added to a bucket each second.
+	rate float64
+	// burst is the maximum number of tokens in a bucket.
+	burst float64
+	// key is one of `RATELIMIT_REMOTE_ADDRESS` or `RATELIMIT_GLOBAL`.
+	key string
+	// buckets is a dictionary of keys and their token buckets.
+	buckets map[string]*rateLimitBucket
+	// mu is a `sync.Mutex` used to guard 'buckets'.
+	mu *sync.Mutex
+	// store is the optional `cluster.Store` used to count requests across multiple webhookd processes.
+	store cluster.Store
+	// name is used to distinguish the counters in 'store' from those of other rate limits.
+	name string
+	// limit is the number of requests allowed per 'period' when using 'store'.
+	limit int64
+	// period is the fixed window over which requests are counted when using 'store'.
+	period time.Duration
+}
+
+// NewRateLimitMiddleware returns a new `RateLimitMiddleware` instance configured by 'uri' in the form of:
+//
+//	ratelimit://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `rate={INT}` The number of requests allowed per period. Required.
+// * `period={DURATION}` The period over which `rate` is measured. Default is "1s".
+// * `burst={INT}` The maximum number of requests allowed at once. Default is the value of `rate`.
+// * `key={STRING}` Whether requests are limited for each client ("remote_address") or for all clients ("global"). Default is "remote_address".
+// * `store={URI}` An optional URI-escaped `cluster.Store` URI used to count requests across multiple webhookd processes. Shared rate
+// limits count requests in fixed windows of `period` and `burst` is ignored.
+// * `name={STRING}` The name used to distinguish the counters of rate limits that share a store. Default is "default".
+func NewRateLimitMiddleware(ctx context.Context, uri string) (webhookd.WebhookMiddleware, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	str_rate := q.Get("rate")
+
+	if str_rate == "" {
+		return nil, fmt.Errorf("Missing ?rate= parameter")
+	}
+
+	rate, err := strconv.Atoi(str_rate)
+
+	if err != nil || rate < 1 {
+		return nil, fmt.Errorf("Invalid ?rate= parameter '%s'", str_rate)
+	}
+
+	period := time.Second
+
+	str_period := q.Get("period")
+
+	if str_period != "" {
+
+		v, err := time.ParseDuration(str_period)
+
+		if err != nil || v <= 0 {
+			return nil, fmt.Errorf("Invalid ?period= parameter '%s'", str_period)
+		}
+
+		period = v
+	}
+
+	burst := rate
+
+	str_burst := q.Get("burst")
+
+	if str_burst != "" {
+
+		v, err := strconv.Atoi(str_burst)
+
+		if err != nil || v < 1 {
+			return nil, fmt.Errorf("Invalid ?burst= parameter '%s'", str_burst)
+		}
+
+		burst = v
+	}
+
+	key := RATELIMIT_REMOTE_ADDRESS
+
+	str_key := q.Get("key")
+
+	switch str_key {
+	case "":
+		// pass
+	case RATELIMIT_REMOTE_ADDRESS, RATELIMIT_GLOBAL:
+		key = str_key
+	default:
+		return nil, fmt.Errorf("Invalid ?key= parameter '%s'", str_key)
+	}
+
+	m := &RateLimitMiddleware{
+		rate:    float64(rate) / period.Seconds(),
+		burst:   float64(burst),
+		key:     key,
+		buckets: make(map[string]*rateLimitBucket),
+		mu:      new(sync.Mutex),
+		name:    DEFAULT_RATELIMIT_NAME,
+		limit:   int64(rate),
+		period:  period,
+	}
+
+	store_uri := q.Get("store")
+
+	if store_uri != "" {
+
+		store, err := cluster.NewStore(ctx, store_uri)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to create cluster store, %w", err)
+		}
+
+		m.store = store
+	}
+
+	if q.Has("name") {
+		m.name = q.Get("name")
+	}
+
+	return m, nil
+}
+
+// Handler returns an `http.Handler` that responds with a "429 Too Many Requests" error, and a "Retry-After" header, for requests
+// that exceed the rate limit.
+func (m *RateLimitMiddleware) Handler(next http.Handler) http.Handler {
+
+	fn := func(rsp http.ResponseWriter, req *http.Request) {
+
+		key := RATELIMIT_GLOBAL
+
+		if m.key == RATELIMIT_REMOTE_ADDRESS {
+			key = remoteAddress(req)
+		}
+
+		var ok bool
+		var retry time.Duration
+
+		if m.store != nil {
+			ok, retry = m.allowShared(req.Context(), key, time.Now())
+		} else {
+			ok, retry = m.allow(key, time.Now())
+		}
+
+		if !ok {
+			rsp.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retry.Seconds()))))
+			http.Error(rsp, "Too Many Requests", http.StatusTooManyRequests)
+			return
+		}
+
+		next.ServeHTTP(rsp, req)
+	}
+
+	return http.HandlerFunc(fn)
+}
+
+// allow takes a token from the bucket for 'key' at 'now'. It returns false, and the time until a token will be available, if
+// the bucket is empty.
+func (m *RateLimitMiddleware) allow(key string, now time.Time) (bool, time.Duration) {
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b, ok := m.buckets[key]
+
+	if !ok {
+
+		if len(m.buckets) >= rateLimitMaxBuckets {
+			m.prune(now)
+		}
+
+		b = &rateLimitBucket{
+			tokens:  m.burst,
+			updated: now,
+		}
+
+		m.buckets[key] = b
+	}
+
+	b.tokens = math.Min(m.burst, b.tokens+now.Sub(b.updated).Seconds()*m.rate)
+	b.updated = now
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / m.rate * float64(time.Second))
+		return false, wait
+	}
+
+	b.tokens -= 1
+	return true, 0
+}
+
+// allowShared counts a request for 'key' at 'now' in the fixed window, of 'period', containing 'now'. It returns false, and the time
+// until the window ends, if the count exceeds 'limit'. If the store can not be reached the request is allowed.
+func (m *RateLimitMiddleware) allowShared(ctx context.Context, key string, now time.Time) (bool, time.Duration) {
+
+	window := now.UnixNano() / int64(m.period)
+	ends := time.Unix(0, (window+1)*int64(m.period))
+
+	counter := fmt.Sprintf("ratelimit:%s:%s:%d", m.name, key, window)
+
+	count, err := m.store.Increment(ctx, counter, 1, ends.Sub(now))
+
+	if err != nil {
+		return true, 0
+	}
+
+	if count > m.limit {
+		return false, ends.Sub(now)
+	}
+
+	return true, 0
+}
+
+// prune discards the buckets that would be full at 'now', since they are equivalent to new buckets. The caller is expected to hold 'mu'.
+func (m *RateLimitMiddleware) prune(now time.Time) {
+
+	for k, b := range m.buckets {
+
+		if b.tokens+now.Sub(b.updated).Seconds()*m.rate >= m.burst {
+			delete(m.buckets, k)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+
+	ctx := context.Background()
+
+	m, err := NewMiddleware(ctx, "ratelimit://?rate=2&period=1h")
+
+	if err != nil {
+		t.Fatalf("Failed to create new middleware, %v", err)
+	}
+
+	next := http.HandlerFunc(func(rsp http.ResponseWriter, req *http.Request) {})
+	h := m.Handler(next)
+
+	tests := []struct {
+		addr     string
+		expected int
+	}{
+		{"192.0.2.1:1234", http.StatusOK},
+		{"192.0.2.1:1234", http.StatusOK},
+		{"192.0.2.1:1234", http.StatusTooManyRequests},
+		{"192.0.2.2:1234", http.StatusOK},
+	}
+
+	for idx, test := range tests {
+
+		req := httptest.NewRequest("POST", "/", nil)
+		req.RemoteAddr = test.addr
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		if rec.Code != test.expected {
+			t.Fatalf("Unexpected status for request %d: %d", idx, rec.Code)
+		}
+
+		if test.expected == http.StatusTooManyRequests && rec.Header().Get("Retry-After") != "1800" {
+			t.Fatalf("Unexpected Retry-After header: %s", rec.Header().Get("Retry-After"))
+		}
+	}
+}
+
+func TestRateLimitMiddlewareRefill(t *testing.T) {
+
+	ctx := context.Background()
+
+	v, err := NewRateLimitMiddleware(ctx, "ratelimit://?rate=1&burst=1&key=global")
+
+	if err != nil {
+		t.Fatalf("Failed to create new middleware, %v", err)
+	}
+
+	m := v.(*RateLimitMiddleware)
+	now := time.Now()
+
+	ok, _ := m.allow(RATELIMIT_GLOBAL, now)
+
+	if !ok {
+		t.Fatalf("Expected first request to be allowed")
+	}
+
+	ok, retry := m.allow(RATELIMIT_GLOBAL, now.Add(500*time.Millisecond))
+
+	if ok || retry != 500*time.Millisecond {
+		t.Fatalf("Expected second request to be limited, retry after %v", retry)
+	}
+
+	ok, _ = m.allow(RATELIMIT_GLOBAL, now.Add(1*time.Second))
+
+	if !ok {
+		t.Fatalf("Expected request to be allowed after bucket refills")
+	}
+
+	// Full buckets are discarded when pruning
+
+	m.prune(now.Add(time.Hour))
+
+	if len(m.buckets) != 0 {
+		t.Fatalf("Expected idle buckets to be pruned")
+	}
+
+	for _, uri := range []string{"ratelimit://", "ratelimit://?rate=0", "ratelimit://?rate=1&period=-1s", "ratelimit://?rate=1&burst=x", "ratelimit://?rate=1&key=header"} {
+
+		_, err := NewMiddleware(ctx, uri)
+
+		if err == nil {
+			t.Fatalf("Expected %s to fail", uri)
+		}
+	}
+}
+
+func TestRateLimitMiddlewareShared(t *testing.T) {
+
+	ctx := context.Background()
+
+	v, err := NewRateLimitMiddleware(ctx, "ratelimit://?rate=2&period=1m&key=global&store=memory%3A%2F%2F&name=test")
+
+	if err != nil {
+		t.Fatalf("Failed to create new middleware, %v", err)
+	}
+
+	m := v.(*RateLimitMiddleware)
+	now := time.Date(2024, time.January, 1, 12, 0, 15, 0, time.UTC)
+
+	for i, expected := range []bool{true, true, false} {
+
+		ok, retry := m.allowShared(ctx, RATELIMIT_GLOBAL, now)
+
+		if ok != expected {
+			t.Fatalf("Unexpected result for request %d: %t", i, ok)
+		}
+
+		if !ok && retry != 45*time.Second {
+			t.Fatalf("Unexpected retry after %v", retry)
+		}
+	}
+
+	ok, _ := m.allowShared(ctx, RATELIMIT_GLOBAL, now.Add(time.Minute))
+
+	if !ok {
+		t.Fatalf("Expected request in the next window to be allowed")
+	}
+
+	_, err = NewMiddleware(ctx, "ratelimit://?rate=1&store=bogus%3A%2F%2F")
+
+	if err == nil {
+		t.Fatalf("Expected invalid store to fail")
+	}
+}
@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterMiddleware(ctx, "sizelimit", NewSizeLimitMiddleware)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// SizeLimitMiddleware implements the `webhookd.WebhookMiddleware` interface for limiting the size of request bodies.
+type SizeLimitMiddleware struct {
+	webhookd.WebhookMiddleware
+	// max_size is the maximum size, in bytes, of a request body.
+	max_size int64
+}
+
+// NewSizeLimitMiddleware returns a new `SizeLimitMiddleware` instance configured by 'uri' in the form of:
+//
+//	sizelimit://?max_size={INT}
+//
+// Where `max_size` is the maximum size, in bytes, of a request body.
+func NewSizeLimitMiddleware(ctx context.Context, uri string) (webhookd.WebhookMiddleware, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	str_size := u.Query().Get("max_size")
+
+	if str_size == "" {
+		return nil, fmt.Errorf("Missing ?max_size= parameter")
+	}
+
+	max_size, err := strconv.ParseInt(str_size, 10, 64)
+
+	if err != nil || max_size < 1 {
+		return nil, fmt.Errorf("Invalid ?max_size= parameter '%s'", str_size)
+	}
+
+	m := &SizeLimitMiddleware{
+		max_size: max_size,
+	}
+
+	return m, nil
+}
+
+// Handler returns an `http.Handler` that responds with a "413 Request Entity Too Large" error for requests whose declared content
+// length is too large. Requests without a declared content length have their body limited so that reading past the limit fails.
+func (m *SizeLimitMiddleware) Handler(next http.Handler) http.Handler {
+
+	fn := func(rsp http.ResponseWriter, req *http.Request) {
+
+		if req.ContentLength > m.max_size {
+			http.Error(rsp, "Request Entity Too Large", http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		req.Body = http.MaxBytesReader(rsp, req.Body, m.max_size)
+		next.ServeHTTP(rsp, req)
+	}
+
+	return http.HandlerFunc(fn)
+}
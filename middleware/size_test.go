@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSizeLimitMiddleware(t *testing.T) {
+
+	ctx := context.Background()
+
+	m, err := NewMiddleware(ctx, "sizelimit://?max_size=5")
+
+	if err != nil {
+		t.Fatalf("Failed to create new middleware, %v", err)
+	}
+
+	next := http.HandlerFunc(func(rsp http.ResponseWriter, req *http.Request) {
+
+		_, err := io.ReadAll(req.Body)
+
+		if err != nil {
+			http.Error(rsp, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+	})
+
+	h := m.Handler(next)
+
+	tests := map[string]int{
+		"hello":       http.StatusOK,
+		"hello world": http.StatusRequestEntityTooLarge,
+	}
+
+	for body, expected := range tests {
+
+		req := httptest.NewRequest("POST", "/", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+
+		h.ServeHTTP(rec, req)
+
+		if rec.Code != expected {
+			t.Fatalf("Unexpected status for '%s': %d", body, rec.Code)
+		}
+
+		// Requests without a declared content length are limited while they are read
+
+		req = httptest.NewRequest("POST", "/", strings.NewReader(body))
+		req.ContentLength = -1
+
+		rec = httptest.NewRecorder()
+
+		h.ServeHTTP(rec, req)
+
+		if rec.Code != expected {
+			t.Fatalf("Unexpected status for '%s' without content length: %d", body, rec.Code)
+		}
+	}
+
+	for _, uri := range []string{"sizelimit://", "sizelimit://?max_size=0", "sizelimit://?max_size=1MB"} {
+
+		_, err := NewMiddleware(ctx, uri)
+
+		if err == nil {
+			t.Fatalf("Expected %s to fail", uri)
+		}
+	}
+}
@@ -0,0 +1,524 @@
+// Package mmdb provides a minimal, read-only implementation of the MaxMind DB file format used by GeoIP databases
+// like GeoLite2-Country and GeoLite2-ASN.
+//
+// See https://maxmind.github.io/MaxMind-DB/ for details.
+package mmdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/big"
+	"net"
+	"os"
+)
+
+// metadataMarker is the byte sequence that precedes the metadata section of a MaxMind DB file.
+var metadataMarker = []byte("\xab\xcd\xefMaxMind.com")
+
+// dataSectionSeparatorSize is the number of (zero) bytes between the search tree and data sections of a MaxMind DB file.
+const dataSectionSeparatorSize int = 16
+
+// Metadata is a struct containing the metadata for a MaxMind DB file.
+type Metadata struct {
+	// DatabaseType is the type of database, for example "GeoLite2-Country".
+	DatabaseType string
+	// IPVersion is the IP version (4 or 6) of the search tree.
+	IPVersion int
+	// NodeCount is the number of nodes in the search tree.
+	NodeCount int
+	// RecordSize is the size, in bits, of each record in the search tree.
+	RecordSize int
+	// BuildEpoch is the Unix timestamp when the database was built.
+	BuildEpoch int64
+}
+
+// Reader is a struct for looking up IP addresses in a MaxMind DB file.
+type Reader struct {
+	// buf is the contents of the MaxMind DB file.
+	buf []byte
+	// metadata is the `Metadata` for 'buf'.
+	metadata *Metadata
+	// data is the data section of 'buf'.
+	data []byte
+	// node_size is the size, in bytes, of each node in the search tree.
+	node_size int
+	// ipv4_start is the search tree node for the IPv4 address space in IPv6 databases.
+	ipv4_start int
+}
+
+// Open returns a new `Reader` instance for the MaxMind DB file at 'path'. The entire file is read in to memory.
+func Open(path string) (*Reader, error) {
+
+	buf, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read %s, %w", path, err)
+	}
+
+	return NewReader(buf)
+}
+
+// NewReader returns a new `Reader` instance for the contents of a MaxMind DB file in 'buf'.
+func NewReader(buf []byte) (*Reader, error) {
+
+	idx := bytes.LastIndex(buf, metadataMarker)
+
+	if idx == -1 {
+		return nil, fmt.Errorf("Invalid MaxMind DB file, missing metadata")
+	}
+
+	metadata_start := idx + len(metadataMarker)
+
+	dec := &decoder{buf: buf[metadata_start:]}
+
+	v, _, err := dec.decode(0)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decode metadata, %w", err)
+	}
+
+	m, ok := v.(map[string]interface{})
+
+	if !ok {
+		return nil, fmt.Errorf("Invalid metadata")
+	}
+
+	md := &Metadata{}
+
+	md.NodeCount, _ = toInt(m["node_count"])
+	md.RecordSize, _ = toInt(m["record_size"])
+	md.IPVersion, _ = toInt(m["ip_version"])
+
+	epoch, _ := toInt(m["build_epoch"])
+	md.BuildEpoch = int64(epoch)
+
+	md.DatabaseType, _ = m["database_type"].(string)
+
+	switch md.RecordSize {
+	case 24, 28, 32:
+		// pass
+	default:
+		return nil, fmt.Errorf("Unsupported record size %d", md.RecordSize)
+	}
+
+	switch md.IPVersion {
+	case 4, 6:
+		// pass
+	default:
+		return nil, fmt.Errorf("Unsupported IP version %d", md.IPVersion)
+	}
+
+	node_size := md.RecordSize * 2 / 8
+	tree_size := md.NodeCount * node_size
+
+	if tree_size+dataSectionSeparatorSize > idx {
+		return nil, fmt.Errorf("Invalid MaxMind DB file, search tree exceeds file size")
+	}
+
+	r := &Reader{
+		buf:       buf,
+		metadata:  md,
+		data:      buf[tree_size+dataSectionSeparatorSize : idx],
+		node_size: node_size,
+	}
+
+	if md.IPVersion == 6 {
+
+		// IPv4 addresses are stored in the IPv6 address space as ::a.b.c.d so skip the first 96 bits
+
+		node := 0
+
+		for i := 0; i < 96 && node < md.NodeCount; i++ {
+
+			next, err := r.readNode(node, 0)
+
+			if err != nil {
+				return nil, err
+			}
+
+			node = next
+		}
+
+		r.ipv4_start = node
+	}
+
+	return r, nil
+}
+
+// Metadata returns the `Metadata` for 'r'.
+func (r *Reader) Metadata() *Metadata {
+	return r.metadata
+}
+
+// Lookup returns the record for 'ip' and a boolean value indicating whether or not a record was found. Records are decoded
+// in to `map[string]interface{}`, `[]interface{}`, `string`, `float64`, `float32`, `uint64`, `int`, `bool`, `[]byte` or
+// `*big.Int` values.
+func (r *Reader) Lookup(ip net.IP) (interface{}, bool, error) {
+
+	ipv4 := ip.To4()
+
+	var bits []byte
+	node := 0
+
+	switch {
+	case ipv4 != nil:
+		bits = ipv4
+		node = r.ipv4_start
+	case r.metadata.IPVersion == 4:
+		return nil, false, fmt.Errorf("Can not look up IPv6 address in an IPv4 database")
+	default:
+		bits = ip.To16()
+	}
+
+	if bits == nil {
+		return nil, false, fmt.Errorf("Invalid IP address")
+	}
+
+	node_count := r.metadata.NodeCount
+
+	for i := 0; i < len(bits)*8 && node < node_count; i++ {
+
+		bit := int(bits[i/8]>>(7-uint(i%8))) & 1
+
+		next, err := r.readNode(node, bit)
+
+		if err != nil {
+			return nil, false, err
+		}
+
+		node = next
+	}
+
+	if node == node_count {
+		return nil, false, nil
+	}
+
+	if node < node_count {
+		return nil, false, fmt.Errorf("Invalid search tree, exhausted address bits")
+	}
+
+	offset := node - node_count - dataSectionSeparatorSize
+
+	if offset < 0 || offset >= len(r.data) {
+		return nil, false, fmt.Errorf("Invalid search tree, data pointer out of range")
+	}
+
+	dec := &decoder{buf: r.data}
+
+	v, _, err := dec.decode(offset)
+
+	if err != nil {
+		return nil, false, fmt.Errorf("Failed to decode record, %w", err)
+	}
+
+	return v, true, nil
+}
+
+// readNode returns the value of the left (0) or right (1) record for 'node'.
+func (r *Reader) readNode(node int, bit int) (int, error) {
+
+	offset := node * r.node_size
+
+	if offset+r.node_size > len(r.buf) {
+		return 0, fmt.Errorf("Invalid search tree, node %d out of range", node)
+	}
+
+	b := r.buf[offset : offset+r.node_size]
+
+	switch r.metadata.RecordSize {
+	case 24:
+
+		if bit == 0 {
+			return int(b[0])<<16 | int(b[1])<<8 | int(b[2]), nil
+		}
+
+		return int(b[3])<<16 | int(b[4])<<8 | int(b[5]), nil
+
+	case 28:
+
+		if bit == 0 {
+			return int(b[3]&0xf0)<<20 | int(b[0])<<16 | int(b[1])<<8 | int(b[2]), nil
+		}
+
+		return int(b[3]&0x0f)<<24 | int(b[4])<<16 | int(b[5])<<8 | int(b[6]), nil
+
+	default:
+
+		if bit == 0 {
+			return int(binary.BigEndian.Uint32(b[0:4])), nil
+		}
+
+		return int(binary.BigEndian.Uint32(b[4:8])), nil
+	}
+}
+
+// data types
+const (
+	typeExtended  = 0
+	typePointer   = 1
+	typeString    = 2
+	typeDouble    = 3
+	typeBytes     = 4
+	typeUint16    = 5
+	typeUint32    = 6
+	typeMap       = 7
+	typeInt32     = 8
+	typeUint64    = 9
+	typeUint128   = 10
+	typeArray     = 11
+	typeContainer = 12
+	typeEnd       = 13
+	typeBool      = 14
+	typeFloat     = 15
+)
+
+// decoder decodes values in the MaxMind DB data section format.
+type decoder struct {
+	buf []byte
+}
+
+// decode decodes the value at 'offset' returning the value and the offset of the next value.
+func (d *decoder) decode(offset int) (interface{}, int, error) {
+
+	kind, size, offset, err := d.decodeControl(offset)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if kind == typePointer {
+
+		ptr, next, err := d.decodePointer(size, offset)
+
+		if err != nil {
+			return nil, 0, err
+		}
+
+		v, _, err := d.decode(ptr)
+
+		if err != nil {
+			return nil, 0, err
+		}
+
+		return v, next, nil
+	}
+
+	return d.decodeValue(kind, size, offset)
+}
+
+// decodeControl decodes the control byte(s) at 'offset' returning the type, payload size and offset of the payload.
+func (d *decoder) decodeControl(offset int) (int, int, int, error) {
+
+	if offset >= len(d.buf) {
+		return 0, 0, 0, fmt.Errorf("Unexpected end of data at offset %d", offset)
+	}
+
+	ctrl := d.buf[offset]
+	offset++
+
+	kind := int(ctrl >> 5)
+
+	if kind == typeExtended {
+
+		if offset >= len(d.buf) {
+			return 0, 0, 0, fmt.Errorf("Unexpected end of data at offset %d", offset)
+		}
+
+		kind = 7 + int(d.buf[offset])
+		offset++
+	}
+
+	if kind == typePointer {
+		// Pointers encode their size differently so return the raw control bits
+		return kind, int(ctrl & 0x1f), offset, nil
+	}
+
+	size := int(ctrl & 0x1f)
+
+	if size >= 29 {
+
+		n := size - 28
+
+		if offset+n > len(d.buf) {
+			return 0, 0, 0, fmt.Errorf("Unexpected end of data at offset %d", offset)
+		}
+
+		v := 0
+
+		for _, b := range d.buf[offset : offset+n] {
+			v = v<<8 | int(b)
+		}
+
+		switch size {
+		case 29:
+			size = 29 + v
+		case 30:
+			size = 285 + v
+		default:
+			size = 65821 + v
+		}
+
+		offset += n
+	}
+
+	return kind, size, offset, nil
+}
+
+// decodePointer decodes a pointer whose control bits are 'bits' and payload starts at 'offset'.
+func (d *decoder) decodePointer(bits int, offset int) (int, int, error) {
+
+	n := (bits >> 3) + 1
+
+	if offset+n > len(d.buf) {
+		return 0, 0, fmt.Errorf("Unexpected end of data at offset %d", offset)
+	}
+
+	v := 0
+
+	if n != 4 {
+		v = bits & 0x07
+	}
+
+	for _, b := range d.buf[offset : offset+n] {
+		v = v<<8 | int(b)
+	}
+
+	switch n {
+	case 2:
+		v += 2048
+	case 3:
+		v += 526336
+	}
+
+	return v, offset + n, nil
+}
+
+// decodeValue decodes a value of type 'kind' whose payload is 'size' and starts at 'offset'.
+func (d *decoder) decodeValue(kind int, size int, offset int) (interface{}, int, error) {
+
+	switch kind {
+	case typeMap:
+
+		m := make(map[string]interface{}, size)
+
+		for i := 0; i < size; i++ {
+
+			k, next, err := d.decode(offset)
+
+			if err != nil {
+				return nil, 0, err
+			}
+
+			key, ok := k.(string)
+
+			if !ok {
+				return nil, 0, fmt.Errorf("Invalid map key at offset %d", offset)
+			}
+
+			v, next, err := d.decode(next)
+
+			if err != nil {
+				return nil, 0, err
+			}
+
+			m[key] = v
+			offset = next
+		}
+
+		return m, offset, nil
+
+	case typeArray:
+
+		a := make([]interface{}, size)
+
+		for i := 0; i < size; i++ {
+
+			v, next, err := d.decode(offset)
+
+			if err != nil {
+				return nil, 0, err
+			}
+
+			a[i] = v
+			offset = next
+		}
+
+		return a, offset, nil
+
+	case typeBool:
+		return size != 0, offset, nil
+	case typeContainer, typeEnd:
+		return nil, offset, nil
+	}
+
+	if offset+size > len(d.buf) {
+		return nil, 0, fmt.Errorf("Unexpected end of data at offset %d", offset)
+	}
+
+	b := d.buf[offset : offset+size]
+	next := offset + size
+
+	switch kind {
+	case typeString:
+		return string(b), next, nil
+	case typeBytes:
+		v := make([]byte, size)
+		copy(v, b)
+		return v, next, nil
+	case typeDouble:
+
+		if size != 8 {
+			return nil, 0, fmt.Errorf("Invalid double size %d", size)
+		}
+
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), next, nil
+
+	case typeFloat:
+
+		if size != 4 {
+			return nil, 0, fmt.Errorf("Invalid float size %d", size)
+		}
+
+		return math.Float32frombits(binary.BigEndian.Uint32(b)), next, nil
+
+	case typeUint16, typeUint32, typeUint64:
+
+		var v uint64
+
+		for _, c := range b {
+			v = v<<8 | uint64(c)
+		}
+
+		return v, next, nil
+
+	case typeInt32:
+
+		var v uint32
+
+		for _, c := range b {
+			v = v<<8 | uint32(c)
+		}
+
+		return int(int32(v)), next, nil
+
+	case typeUint128:
+		return new(big.Int).SetBytes(b), next, nil
+	default:
+		return nil, 0, fmt.Errorf("Unsupported data type %d at offset %d", kind, offset)
+	}
+}
+
+// toInt returns 'v' as an int and a boolean value indicating whether 'v' is numeric.
+func toInt(v interface{}) (int, bool) {
+
+	switch t := v.(type) {
+	case uint64:
+		return int(t), true
+	case int:
+		return t, true
+	default:
+		return 0, false
+	}
+}
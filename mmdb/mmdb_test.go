@@ -0,0 +1,186 @@
+package mmdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"sort"
+	"testing"
+)
+
+// encodeValue encodes 'v' in the MaxMind DB data section format. Only the types used by these tests are supported.
+func encodeValue(buf *bytes.Buffer, v interface{}) {
+
+	control := func(kind int, size int) {
+
+		if kind > 7 {
+			buf.WriteByte(byte(size))
+			buf.WriteByte(byte(kind - 7))
+			return
+		}
+
+		buf.WriteByte(byte(kind<<5 | size))
+	}
+
+	switch t := v.(type) {
+	case string:
+		control(typeString, len(t))
+		buf.WriteString(t)
+	case uint32:
+		b := make([]byte, 4)
+		binary.BigEndian.PutUint32(b, t)
+		control(typeUint32, 4)
+		buf.Write(b)
+	case uint16:
+		control(typeUint16, 2)
+		buf.Write([]byte{byte(t >> 8), byte(t)})
+	case map[string]interface{}:
+
+		keys := make([]string, 0, len(t))
+
+		for k := range t {
+			keys = append(keys, k)
+		}
+
+		sort.Strings(keys)
+
+		control(typeMap, len(t))
+
+		for _, k := range keys {
+			encodeValue(buf, k)
+			encodeValue(buf, t[k])
+		}
+	}
+}
+
+// buildDatabase returns a MaxMind DB file, with 24-bit records, containing 'record' for the network whose leading bits are 'prefix'.
+func buildDatabase(ip_version int, prefix []int, record map[string]interface{}) []byte {
+
+	node_count := len(prefix)
+
+	var tree bytes.Buffer
+
+	write := func(v int) {
+		tree.Write([]byte{byte(v >> 16), byte(v >> 8), byte(v)})
+	}
+
+	for i, bit := range prefix {
+
+		next := i + 1
+
+		if i == len(prefix)-1 {
+			next = node_count + dataSectionSeparatorSize
+		}
+
+		if bit == 0 {
+			write(next)
+			write(node_count)
+		} else {
+			write(node_count)
+			write(next)
+		}
+	}
+
+	var buf bytes.Buffer
+
+	buf.Write(tree.Bytes())
+	buf.Write(make([]byte, dataSectionSeparatorSize))
+
+	encodeValue(&buf, record)
+
+	buf.Write(metadataMarker)
+
+	encodeValue(&buf, map[string]interface{}{
+		"node_count":    uint32(node_count),
+		"record_size":   uint16(24),
+		"ip_version":    uint16(ip_version),
+		"database_type": "Test",
+	})
+
+	return buf.Bytes()
+}
+
+// prefixBits returns the leading 'n' bits of 'ip'.
+func prefixBits(ip net.IP, n int) []int {
+
+	bits := make([]int, n)
+
+	for i := 0; i < n; i++ {
+		bits[i] = int(ip[i/8]>>(7-uint(i%8))) & 1
+	}
+
+	return bits
+}
+
+func TestLookup(t *testing.T) {
+
+	record := map[string]interface{}{
+		"country": map[string]interface{}{
+			"iso_code": "CA",
+		},
+		"autonomous_system_number": uint32(64496),
+	}
+
+	tests := map[int][]int{
+		4: prefixBits(net.ParseIP("192.0.2.0").To4(), 24),
+		6: prefixBits(net.ParseIP("192.0.2.0").To16(), 120),
+	}
+
+	// IPv4 addresses in IPv6 databases are stored as ::a.b.c.d rather than ::ffff:a.b.c.d
+
+	for i := 80; i < 96; i++ {
+		tests[6][i] = 0
+	}
+
+	for ip_version, prefix := range tests {
+
+		r, err := NewReader(buildDatabase(ip_version, prefix, record))
+
+		if err != nil {
+			t.Fatalf("Failed to create reader for IPv%d database, %v", ip_version, err)
+		}
+
+		if r.Metadata().DatabaseType != "Test" {
+			t.Fatalf("Unexpected database type: %s", r.Metadata().DatabaseType)
+		}
+
+		v, ok, err := r.Lookup(net.ParseIP("192.0.2.1"))
+
+		if err != nil {
+			t.Fatalf("Failed to look up address in IPv%d database, %v", ip_version, err)
+		}
+
+		if !ok {
+			t.Fatalf("Expected address to be found in IPv%d database", ip_version)
+		}
+
+		m := v.(map[string]interface{})
+
+		if m["country"].(map[string]interface{})["iso_code"] != "CA" {
+			t.Fatalf("Unexpected record: %v", m)
+		}
+
+		if m["autonomous_system_number"] != uint64(64496) {
+			t.Fatalf("Unexpected record: %v", m)
+		}
+
+		_, ok, err = r.Lookup(net.ParseIP("198.51.100.1"))
+
+		if err != nil {
+			t.Fatalf("Failed to look up address in IPv%d database, %v", ip_version, err)
+		}
+
+		if ok {
+			t.Fatalf("Expected address not to be found in IPv%d database", ip_version)
+		}
+	}
+}
+
+func TestNewReaderInvalid(t *testing.T) {
+
+	_, err := NewReader([]byte("hello world"))
+
+	if err == nil {
+		t.Fatalf("Expected invalid database to fail")
+	}
+}
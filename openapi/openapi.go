@@ -0,0 +1,479 @@
+// Package openapi provides methods for deriving an OpenAPI 3 document describing the webhook endpoints defined in a webhookd config
+// so that they can be consumed by API gateways and internal service catalogs.
+package openapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/whosonfirst/go-webhookd/v3/config"
+	"github.com/whosonfirst/go-webhookd/v3/webhook"
+)
+
+// OPENAPI_VERSION is the version of the OpenAPI specification that documents conform to.
+const OPENAPI_VERSION string = "3.0.3"
+
+// DEFAULT_TITLE is the default title for documents.
+const DEFAULT_TITLE string = "webhookd"
+
+// DEFAULT_VERSION is the default version of the API described by documents.
+const DEFAULT_VERSION string = "1.0.0"
+
+// Document is an OpenAPI document.
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       *Info               `json:"info"`
+	Servers    []*Server           `json:"servers,omitempty"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components *Components         `json:"components,omitempty"`
+	Tags       []*Tag              `json:"tags,omitempty"`
+}
+
+// Info is the metadata for the API described by a `Document`.
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Server is a server hosting the API described by a `Document`.
+type Server struct {
+	URL string `json:"url"`
+}
+
+// Tag is a tag used to group operations.
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem is a dictionary of lower-cased HTTP methods and the `Operation` for each.
+type PathItem map[string]*Operation
+
+// Operation is a single HTTP method for a webhook endpoint.
+type Operation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []*Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+	// Receiver is the scheme of the receiver for the webhook.
+	Receiver string `json:"x-webhookd-receiver,omitempty"`
+	// Labels is the dictionary of labels for the webhook.
+	Labels map[string]string `json:"x-webhookd-labels,omitempty"`
+}
+
+// Parameter is a path or header parameter for an `Operation`.
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody is the request body for an `Operation`.
+type RequestBody struct {
+	Description string                `json:"description,omitempty"`
+	Required    bool                  `json:"required"`
+	Content     map[string]*MediaType `json:"content"`
+}
+
+// Response is a response for an `Operation`.
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType is the schema for a request or response body with a given content type.
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Schema is the (minimal) JSON schema for a parameter or body.
+type Schema struct {
+	Type   string `json:"type,omitempty"`
+	Format string `json:"format,omitempty"`
+}
+
+// Components is the set of reusable objects for a `Document`.
+type Components struct {
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme is a method used to authenticate requests for an `Operation`.
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Description  string `json:"description,omitempty"`
+	Name         string `json:"name,omitempty"`
+	In           string `json:"in,omitempty"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// Options defines properties of a `Document` that are not derived from a config.
+type Options struct {
+	// Title is the title of the API. If empty `DEFAULT_TITLE` is used.
+	Title string
+	// Version is the version of the API. If empty `DEFAULT_VERSION` is used.
+	Version string
+	// ServerURL is the URL of the `webhookd` server. If empty the public URL in the config, if present, is used.
+	ServerURL string
+}
+
+// operationIDSanitizer matches the characters that are replaced in operation IDs.
+var operationIDSanitizer = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+
+// NewDocumentFromConfig returns a new `Document` describing the webhook endpoints, including tenant webhook endpoints, defined in
+// 'cfg'. Webhooks whose messages are consumed from a source are not included since they can not be reached over HTTP. The headers
+// and authentication methods expected by each webhook are derived from the scheme of its receiver; receivers that are not part of
+// this package are described without them. 'opts' may be nil.
+func NewDocumentFromConfig(ctx context.Context, cfg *config.WebhookConfig, opts *Options) (*Document, error) {
+
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	info := &Info{
+		Title:   opts.Title,
+		Version: opts.Version,
+	}
+
+	if info.Title == "" {
+		info.Title = DEFAULT_TITLE
+	}
+
+	if info.Version == "" {
+		info.Version = DEFAULT_VERSION
+	}
+
+	doc := &Document{
+		OpenAPI: OPENAPI_VERSION,
+		Info:    info,
+		Paths:   make(map[string]PathItem),
+		Components: &Components{
+			SecuritySchemes: make(map[string]*SecurityScheme),
+		},
+	}
+
+	server_url := opts.ServerURL
+
+	if server_url == "" {
+		server_url = cfg.PublicURL
+	}
+
+	if server_url != "" {
+		doc.Servers = []*Server{
+			{URL: strings.TrimRight(server_url, "/")},
+		}
+	}
+
+	err := doc.addWebhooks(cfg, "", nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tenants := make([]string, 0, len(cfg.Tenants))
+
+	for name := range cfg.Tenants {
+		tenants = append(tenants, name)
+	}
+
+	sort.Strings(tenants)
+
+	for _, name := range tenants {
+
+		t := cfg.Tenants[name]
+
+		err := doc.addWebhooks(t.WebhookConfig(), name, t.Labels)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to add webhooks for tenant '%s', %w", name, err)
+		}
+
+		doc.Tags = append(doc.Tags, &Tag{
+			Name:        name,
+			Description: fmt.Sprintf("Webhooks for the %s tenant, installed under %s", name, config.TenantEndpoint(name, "/")),
+		})
+	}
+
+	if len(doc.Components.SecuritySchemes) == 0 {
+		doc.Components = nil
+	}
+
+	return doc, nil
+}
+
+// addWebhooks adds operations for the webhooks in 'cfg', which belong to 'tenant' if not empty, to 'doc'. 'labels' are the
+// labels for the tenant which are merged with the labels for each webhook.
+func (doc *Document) addWebhooks(cfg *config.WebhookConfig, tenant string, labels map[string]string) error {
+
+	for idx, wh_cfg := range cfg.Webhooks {
+
+		if wh_cfg.Source != "" {
+			continue
+		}
+
+		if wh_cfg.Endpoint == "" {
+			return fmt.Errorf("Missing endpoint at offset %d", idx+1)
+		}
+
+		receiver_uri, err := cfg.GetReceiverConfigByName(wh_cfg.Receiver)
+
+		if err != nil {
+			return fmt.Errorf("Failed to get receiver config for '%s', %w", wh_cfg.Endpoint, err)
+		}
+
+		u, err := url.Parse(receiver_uri)
+
+		if err != nil {
+			return fmt.Errorf("Failed to parse receiver URI for '%s', %w", wh_cfg.Endpoint, err)
+		}
+
+		endpoint := wh_cfg.Endpoint
+
+		if tenant != "" {
+			endpoint = config.TenantEndpoint(tenant, endpoint)
+		}
+
+		path, path_params, err := pathTemplate(endpoint)
+
+		if err != nil {
+			return fmt.Errorf("Invalid endpoint '%s', %w", endpoint, err)
+		}
+
+		_, exists := doc.Paths[path]
+
+		if exists {
+			return fmt.Errorf("Duplicate path '%s' for endpoint '%s'", path, endpoint)
+		}
+
+		methods := wh_cfg.Methods
+
+		if len(methods) == 0 {
+			methods = []string{http.MethodPost}
+		}
+
+		desc := describeReceiver(u.Scheme)
+		security := doc.addSecuritySchemes(u.Scheme, desc)
+
+		item := make(PathItem)
+
+		for _, m := range methods {
+
+			m = strings.ToUpper(m)
+
+			op := &Operation{
+				OperationID: operationID(m, endpoint),
+				Summary:     fmt.Sprintf("Receive %s webhook messages", u.Scheme),
+				Parameters:  append([]*Parameter{}, path_params...),
+				Responses:   responses(wh_cfg.Response),
+				Security:    security,
+				Receiver:    u.Scheme,
+				Labels:      mergeLabels(labels, wh_cfg.Labels),
+			}
+
+			if tenant != "" {
+				op.Tags = []string{tenant}
+			}
+
+			if desc != nil {
+
+				op.Description = desc.description
+
+				for _, h := range desc.headers {
+
+					op.Parameters = append(op.Parameters, &Parameter{
+						Name:        h,
+						In:          "header",
+						Description: fmt.Sprintf("Required by the %s receiver to verify the message.", u.Scheme),
+						Required:    true,
+						Schema:      &Schema{Type: "string"},
+					})
+				}
+			}
+
+			switch m {
+			case http.MethodPost, http.MethodPut, http.MethodPatch:
+
+				op.RequestBody = &RequestBody{
+					Description: "The webhook message.",
+					Required:    true,
+					Content: map[string]*MediaType{
+						"*/*": {Schema: &Schema{Type: "string", Format: "binary"}},
+					},
+				}
+			}
+
+			item[strings.ToLower(m)] = op
+		}
+
+		doc.Paths[path] = item
+	}
+
+	return nil
+}
+
+// addSecuritySchemes adds the security schemes for the receiver 'scheme', described by 'desc', to 'doc' and returns the list of
+// security requirements, any one of which must be satisfied, for operations using that receiver.
+func (doc *Document) addSecuritySchemes(scheme string, desc *receiverDescription) []map[string][]string {
+
+	if desc == nil || len(desc.auth) == 0 {
+		return nil
+	}
+
+	security := make([]map[string][]string, 0, len(desc.auth))
+
+	kinds := make(map[string]int)
+
+	for _, a := range desc.auth {
+		kinds[a.kind] += 1
+	}
+
+	for _, a := range desc.auth {
+
+		name := scheme + "_" + a.kind
+
+		// Distinguish receivers that accept the same kind of authentication in more than one place, for example a token
+		// in either a header or a query parameter
+
+		if kinds[a.kind] > 1 {
+			name = name + "_" + a.in
+		}
+
+		doc.Components.SecuritySchemes[name] = a.securityScheme(scheme)
+
+		security = append(security, map[string][]string{name: {}})
+	}
+
+	return security
+}
+
+// pathTemplate returns the OpenAPI path template, and the list of path parameters, for 'endpoint'. Parameters that match the
+// remainder of a path ("{name...}" or "*") are included as single parameters whose values may contain slashes.
+func pathTemplate(endpoint string) (string, []*Parameter, error) {
+
+	params := make([]*Parameter, 0)
+
+	if !webhook.IsEndpointPattern(endpoint) {
+		return endpoint, params, nil
+	}
+
+	_, err := webhook.NewEndpointPattern(endpoint)
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	segments := strings.Split(endpoint, "/")
+
+	for idx, s := range segments {
+
+		name := ""
+		description := ""
+
+		switch {
+		case s == "*":
+			name = "wildcard"
+			description = "The remainder of the path, which may contain slashes."
+		case strings.HasPrefix(s, "{") && strings.HasSuffix(s, "...}"):
+			name = strings.TrimSuffix(strings.TrimPrefix(s, "{"), "...}")
+			description = "The remainder of the path, which may contain slashes."
+		case strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}"):
+			name = strings.TrimSuffix(strings.TrimPrefix(s, "{"), "}")
+		default:
+			continue
+		}
+
+		segments[idx] = "{" + name + "}"
+
+		params = append(params, &Parameter{
+			Name:        name,
+			In:          "path",
+			Description: description,
+			Required:    true,
+			Schema:      &Schema{Type: "string"},
+		})
+	}
+
+	return strings.Join(segments, "/"), params, nil
+}
+
+// responses returns the responses for a webhook with the response configuration 'rsp_cfg', which may be nil.
+func responses(rsp_cfg *config.WebhookResponseConfig) map[string]*Response {
+
+	status := http.StatusOK
+	content_type := ""
+
+	if rsp_cfg != nil {
+
+		if rsp_cfg.Status != 0 {
+			status = rsp_cfg.Status
+		}
+
+		if rsp_cfg.Body != "" {
+			content_type = rsp_cfg.ContentType
+
+			if content_type == "" {
+				content_type = "text/plain; charset=utf-8"
+			}
+		}
+	}
+
+	ok := &Response{
+		Description: "The message was received and processed.",
+	}
+
+	if content_type != "" {
+		ok.Content = map[string]*MediaType{
+			content_type: {Schema: &Schema{Type: "string"}},
+		}
+	}
+
+	return map[string]*Response{
+		strconv.Itoa(status): ok,
+		"default": {
+			Description: "The message could not be received, transformed or dispatched.",
+		},
+	}
+}
+
+// operationID returns the operation ID for 'method' requests to 'endpoint', for example "post_repos_owner" for POST requests to
+// "/repos/{owner}".
+func operationID(method string, endpoint string) string {
+
+	id := operationIDSanitizer.ReplaceAllString(endpoint, "_")
+	id = strings.Trim(id, "_")
+
+	return strings.ToLower(method) + "_" + id
+}
+
+// mergeLabels returns the union of 'a' and 'b', preferring the values in 'b', or nil if both are empty.
+func mergeLabels(a map[string]string, b map[string]string) map[string]string {
+
+	if len(a) == 0 && len(b) == 0 {
+		return nil
+	}
+
+	labels := make(map[string]string)
+
+	for k, v := range a {
+		labels[k] = v
+	}
+
+	for k, v := range b {
+		labels[k] = v
+	}
+
+	return labels
+}
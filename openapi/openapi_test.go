@@ -0,0 +1,228 @@
+package openapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/whosonfirst/go-webhookd/v3/config"
+)
+
+func TestNewDocumentFromConfig(t *testing.T) {
+
+	ctx := context.Background()
+
+	cfg := &config.WebhookConfig{
+		PublicURL: "https://hooks.example.com/",
+		Receivers: map[string]string{
+			"heroku":   "heroku://?secret=s33kret",
+			"insecure": "insecure://",
+			"custom":   "custom://",
+			"linode":   "linode://?token=s33kret",
+		},
+		Sources: map[string]string{
+			"queue": "memory://",
+		},
+		Webhooks: []config.WebhookWebhooksConfig{
+			{
+				Endpoint: "/heroku",
+				Receiver: "heroku",
+				Labels:   map[string]string{"team": "data"},
+			},
+			{
+				Endpoint: "/repos/{owner}/{path...}",
+				Receiver: "insecure",
+				Methods:  []string{"post", "GET"},
+				Response: &config.WebhookResponseConfig{
+					Status:      http.StatusAccepted,
+					Body:        `{"id":"{{ .DeliveryID }}"}`,
+					ContentType: "application/json",
+				},
+			},
+			{
+				Endpoint: "/custom/*",
+				Receiver: "custom",
+			},
+			{
+				Endpoint: "/linode",
+				Receiver: "linode",
+			},
+			{
+				Source: "queue",
+			},
+		},
+		Tenants: map[string]config.WebhookTenantConfig{
+			"acme": {
+				Labels: map[string]string{"team": "acme"},
+				Receivers: map[string]string{
+					"zoom": "zoom://?secret=s33kret",
+				},
+				Webhooks: []config.WebhookWebhooksConfig{
+					{
+						Endpoint: "/zoom",
+						Receiver: "zoom",
+					},
+				},
+			},
+		},
+	}
+
+	doc, err := NewDocumentFromConfig(ctx, cfg, &Options{Version: "2.0.0"})
+
+	if err != nil {
+		t.Fatalf("Failed to create document, %v", err)
+	}
+
+	if doc.OpenAPI != OPENAPI_VERSION || doc.Info.Title != DEFAULT_TITLE || doc.Info.Version != "2.0.0" {
+		t.Fatalf("Unexpected document info, %v", doc.Info)
+	}
+
+	if len(doc.Servers) != 1 || doc.Servers[0].URL != "https://hooks.example.com" {
+		t.Fatalf("Unexpected servers, %v", doc.Servers)
+	}
+
+	if len(doc.Paths) != 5 {
+		t.Fatalf("Unexpected number of paths, %d", len(doc.Paths))
+	}
+
+	op, ok := doc.Paths["/heroku"]["post"]
+
+	if !ok {
+		t.Fatalf("Missing operation for /heroku")
+	}
+
+	if op.OperationID != "post_heroku" || op.Receiver != "heroku" || op.Labels["team"] != "data" || op.RequestBody == nil {
+		t.Fatalf("Unexpected operation for /heroku, %v", op)
+	}
+
+	if len(op.Security) != 1 || op.Security[0]["heroku_signature"] == nil {
+		t.Fatalf("Unexpected security for /heroku, %v", op.Security)
+	}
+
+	scheme := doc.Components.SecuritySchemes["heroku_signature"]
+
+	if scheme == nil || scheme.Type != "apiKey" || scheme.In != "header" || scheme.Name != "Heroku-Webhook-Hmac-Sha256" {
+		t.Fatalf("Unexpected security scheme, %v", scheme)
+	}
+
+	op, ok = doc.Paths["/linode"]["post"]
+
+	if !ok {
+		t.Fatalf("Missing operation for /linode")
+	}
+
+	if len(op.Security) != 3 || op.Security[0]["linode_token_header"] == nil || op.Security[1]["linode_bearer"] == nil || op.Security[2]["linode_token_query"] == nil {
+		t.Fatalf("Unexpected security for /linode, %v", op.Security)
+	}
+
+	scheme = doc.Components.SecuritySchemes["linode_token_header"]
+
+	if scheme == nil || scheme.Type != "apiKey" || scheme.In != "header" || scheme.Name != "X-Webhookd-Token" {
+		t.Fatalf("Unexpected security scheme, %v", scheme)
+	}
+
+	item, ok := doc.Paths["/repos/{owner}/{path}"]
+
+	if !ok || len(item) != 2 {
+		t.Fatalf("Unexpected path item for /repos/{owner}/{path...}, %v", item)
+	}
+
+	if item["get"].RequestBody != nil || item["post"].RequestBody == nil {
+		t.Fatalf("Expected only POST requests to have a body")
+	}
+
+	params := item["post"].Parameters
+
+	if len(params) != 2 || params[0].Name != "owner" || params[1].Name != "path" || params[1].In != "path" || !params[1].Required {
+		t.Fatalf("Unexpected path parameters, %v", params)
+	}
+
+	rsp, ok := item["post"].Responses["202"]
+
+	if !ok || rsp.Content["application/json"] == nil {
+		t.Fatalf("Unexpected responses, %v", item["post"].Responses)
+	}
+
+	if item["post"].Security != nil {
+		t.Fatalf("Expected insecure receiver to have no security")
+	}
+
+	op, ok = doc.Paths["/custom/{wildcard}"]["post"]
+
+	if !ok || op.Description != "" || op.Security != nil || op.Responses["200"] == nil {
+		t.Fatalf("Unexpected operation for custom receiver, %v", op)
+	}
+
+	op, ok = doc.Paths["/t/acme/zoom"]["post"]
+
+	if !ok {
+		t.Fatalf("Missing operation for tenant webhook")
+	}
+
+	if len(op.Tags) != 1 || op.Tags[0] != "acme" || op.Labels["team"] != "acme" || op.OperationID != "post_t_acme_zoom" {
+		t.Fatalf("Unexpected operation for tenant webhook, %v", op)
+	}
+
+	headers := make([]string, 0)
+
+	for _, p := range op.Parameters {
+
+		if p.In == "header" {
+			headers = append(headers, p.Name)
+		}
+	}
+
+	if len(headers) != 1 || headers[0] != "X-Zm-Request-Timestamp" {
+		t.Fatalf("Unexpected header parameters, %v", headers)
+	}
+
+	if len(doc.Tags) != 1 || doc.Tags[0].Name != "acme" {
+		t.Fatalf("Unexpected tags, %v", doc.Tags)
+	}
+
+	_, err = json.Marshal(doc)
+
+	if err != nil {
+		t.Fatalf("Failed to encode document, %v", err)
+	}
+}
+
+func TestNewDocumentFromConfigInvalid(t *testing.T) {
+
+	ctx := context.Background()
+
+	tests := []config.WebhookWebhooksConfig{
+		{Endpoint: "/missing", Receiver: "missing"},
+		{Receiver: "insecure"},
+		{Endpoint: "/{a}/{a}", Receiver: "insecure"},
+	}
+
+	for idx, wh_cfg := range tests {
+
+		cfg := &config.WebhookConfig{
+			Receivers: map[string]string{"insecure": "insecure://"},
+			Webhooks:  []config.WebhookWebhooksConfig{wh_cfg},
+		}
+
+		_, err := NewDocumentFromConfig(ctx, cfg, nil)
+
+		if err == nil {
+			t.Fatalf("Expected config %d to fail", idx)
+		}
+	}
+
+	cfg := &config.WebhookConfig{
+		Receivers: map[string]string{"insecure": "insecure://"},
+		Webhooks: []config.WebhookWebhooksConfig{
+			{Endpoint: "/repos/{owner}", Receiver: "insecure"},
+			{Endpoint: "/repos/{owner...}", Receiver: "insecure"},
+		},
+	}
+
+	_, err := NewDocumentFromConfig(ctx, cfg, nil)
+
+	if err == nil {
+		t.Fatalf("Expected duplicate paths to fail")
+	}
+}
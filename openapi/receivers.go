@@ -0,0 +1,185 @@
+package openapi
+
+import (
+	"fmt"
+
+	"github.com/whosonfirst/go-webhookd/v3/receiver"
+)
+
+// Kinds of authentication used by receivers.
+const (
+	// AUTH_SIGNATURE is a signature, derived from the message and a shared secret, sent in a header.
+	AUTH_SIGNATURE string = "signature"
+	// AUTH_TOKEN is a shared token sent in a header or query parameter.
+	AUTH_TOKEN string = "token"
+	// AUTH_AUTHORIZATION is a shared value for the "Authorization" header.
+	AUTH_AUTHORIZATION string = "authorization"
+	// AUTH_BASIC is HTTP basic authentication.
+	AUTH_BASIC string = "basic"
+	// AUTH_JWT is a JSON Web Token sent as a bearer token.
+	AUTH_JWT string = "jwt"
+	// AUTH_BEARER is a shared token sent as a bearer token.
+	AUTH_BEARER string = "bearer"
+)
+
+// receiverAuth is a method used by a receiver to authenticate messages.
+type receiverAuth struct {
+	// kind is one of the AUTH_ constants.
+	kind string
+	// in is "header" or "query" for `AUTH_SIGNATURE` and `AUTH_TOKEN` authentication.
+	in string
+	// name is the name of the header or query parameter for `AUTH_SIGNATURE` and `AUTH_TOKEN` authentication.
+	name string
+}
+
+// receiverDescription describes the requests expected by a receiver.
+type receiverDescription struct {
+	// description is an optional description of the receiver.
+	description string
+	// auth is the list of methods, any one of which may be used, to authenticate messages. If empty messages are not authenticated
+	// by the receiver.
+	auth []*receiverAuth
+	// headers is the list of headers, other than those used for authentication, that are required by the receiver.
+	headers []string
+}
+
+// signature returns a `receiverAuth` for a signature sent in the 'header' header.
+func signature(header string) *receiverAuth {
+	return &receiverAuth{kind: AUTH_SIGNATURE, in: "header", name: header}
+}
+
+// token returns a `receiverAuth` for a token sent in 'in' (one of "header" or "query") with the name 'name'.
+func token(in string, name string) *receiverAuth {
+	return &receiverAuth{kind: AUTH_TOKEN, in: in, name: name}
+}
+
+var (
+	authorization = &receiverAuth{kind: AUTH_AUTHORIZATION, in: "header", name: "Authorization"}
+	basic         = &receiverAuth{kind: AUTH_BASIC}
+	jwt           = &receiverAuth{kind: AUTH_JWT}
+	bearer        = &receiverAuth{kind: AUTH_BEARER}
+)
+
+// receiverDescriptions is a dictionary of receiver schemes and the requests they expect. Headers that receivers derive themselves,
+// for example event types, are not included.
+var receiverDescriptions = map[string]*receiverDescription{
+	"airtable":     {auth: []*receiverAuth{signature(receiver.AIRTABLE_SIGNATURE_HEADER)}},
+	"atlassian":    {auth: []*receiverAuth{token("query", "token"), jwt}},
+	"auth0":        {auth: []*receiverAuth{authorization}},
+	"azuredevops":  {auth: []*receiverAuth{basic}},
+	"buildkite":    {auth: []*receiverAuth{token("header", receiver.BUILDKITE_TOKEN_HEADER), signature(receiver.BUILDKITE_SIGNATURE_HEADER)}},
+	"chargebee":    {auth: []*receiverAuth{basic}},
+	"circleci":     {auth: []*receiverAuth{signature(receiver.CIRCLECI_SIGNATURE_HEADER)}},
+	"cloudflare":   {auth: []*receiverAuth{token("header", receiver.CLOUDFLARE_SECRET_HEADER)}},
+	"digitalocean": {auth: []*receiverAuth{token("header", receiver.TOKEN_HEADER), bearer, token("query", "token")}},
+	"dockerhub": {
+		description: "Docker Hub does not sign messages so they should only be accepted over a private URL.",
+		auth:        []*receiverAuth{token("header", receiver.TOKEN_HEADER), bearer, token("query", "token")},
+	},
+	"docusign": {auth: []*receiverAuth{signature(receiver.DOCUSIGN_SIGNATURE_HEADER_PREFIX + "1")}},
+	"fastly":   {auth: []*receiverAuth{token("header", receiver.TOKEN_HEADER), bearer, token("query", "token")}},
+	"gerrit":   {auth: []*receiverAuth{token("header", receiver.TOKEN_HEADER), bearer, token("query", "token")}},
+	"gitea":    {auth: []*receiverAuth{signature(receiver.GITEA_SIGNATURE_HEADER)}},
+	"gogs":     {auth: []*receiverAuth{signature(receiver.GOGS_SIGNATURE_HEADER)}},
+	"heroku":   {auth: []*receiverAuth{signature(receiver.HEROKU_SIGNATURE_HEADER)}},
+	"hubspot": {
+		auth:    []*receiverAuth{signature(receiver.HUBSPOT_SIGNATURE_HEADER)},
+		headers: []string{receiver.HUBSPOT_TIMESTAMP_HEADER},
+	},
+	"insecure": {
+		description: "Messages are not authenticated.",
+	},
+	"intercom": {auth: []*receiverAuth{signature(receiver.INTERCOM_SIGNATURE_HEADER)}},
+	"jwt":      {auth: []*receiverAuth{jwt}},
+	"linear":   {auth: []*receiverAuth{signature(receiver.LINEAR_SIGNATURE_HEADER)}},
+	"linode":   {auth: []*receiverAuth{token("header", receiver.TOKEN_HEADER), bearer, token("query", "token")}},
+	"mailgun": {
+		description: "Messages are signed using the signature property of the (form-encoded or JSON) message body.",
+	},
+	"netlify": {auth: []*receiverAuth{signature(receiver.NETLIFY_SIGNATURE_HEADER)}},
+	"notion":  {auth: []*receiverAuth{signature(receiver.NOTION_SIGNATURE_HEADER)}},
+	"npm":     {auth: []*receiverAuth{signature(receiver.NPM_SIGNATURE_HEADER)}},
+	"okta":    {auth: []*receiverAuth{authorization}},
+	"paddle":  {auth: []*receiverAuth{signature(receiver.PADDLE_SIGNATURE_HEADER)}},
+	"paypal": {
+		description: "Messages are verified using the PayPal API.",
+		headers:     []string{"Paypal-Auth-Algo", "Paypal-Cert-Url", "Paypal-Transmission-Id", "Paypal-Transmission-Sig", "Paypal-Transmission-Time"},
+	},
+	"phabricator": {auth: []*receiverAuth{signature(receiver.PHABRICATOR_SIGNATURE_HEADER)}},
+	"registry":    {auth: []*receiverAuth{token("header", receiver.TOKEN_HEADER), token("query", "token"), authorization}},
+	"rubygems":    {auth: []*receiverAuth{authorization}},
+	"sendgrid": {
+		auth:    []*receiverAuth{signature(receiver.SENDGRID_SIGNATURE_HEADER)},
+		headers: []string{receiver.SENDGRID_TIMESTAMP_HEADER},
+	},
+	"sns": {
+		description: "Messages are signed using the Signature property of the JSON-encoded message body.",
+	},
+	"square": {auth: []*receiverAuth{signature(receiver.SQUARE_SIGNATURE_HEADER)}},
+	"tfc":    {auth: []*receiverAuth{signature(receiver.TFC_SIGNATURE_HEADER)}},
+	"travis": {auth: []*receiverAuth{signature(receiver.TRAVIS_SIGNATURE_HEADER)}},
+	"twilio": {auth: []*receiverAuth{signature(receiver.TWILIO_SIGNATURE_HEADER)}},
+	"vercel": {auth: []*receiverAuth{signature(receiver.VERCEL_SIGNATURE_HEADER)}},
+	"zendesk": {
+		auth:    []*receiverAuth{signature(receiver.ZENDESK_SIGNATURE_HEADER)},
+		headers: []string{receiver.ZENDESK_TIMESTAMP_HEADER},
+	},
+	"zoom": {
+		auth:    []*receiverAuth{signature(receiver.ZOOM_SIGNATURE_HEADER)},
+		headers: []string{receiver.ZOOM_TIMESTAMP_HEADER},
+	},
+}
+
+// describeReceiver returns the `receiverDescription` for the receiver 'scheme', or nil if it is unknown.
+func describeReceiver(scheme string) *receiverDescription {
+	return receiverDescriptions[scheme]
+}
+
+// securityScheme returns the `SecurityScheme` for 'a' as used by the receiver 'scheme'.
+func (a *receiverAuth) securityScheme(scheme string) *SecurityScheme {
+
+	switch a.kind {
+	case AUTH_BASIC:
+
+		return &SecurityScheme{
+			Type:        "http",
+			Scheme:      "basic",
+			Description: fmt.Sprintf("The username and password configured for the %s receiver.", scheme),
+		}
+
+	case AUTH_JWT:
+
+		return &SecurityScheme{
+			Type:         "http",
+			Scheme:       "bearer",
+			BearerFormat: "JWT",
+			Description:  fmt.Sprintf("A JSON Web Token verified by the %s receiver.", scheme),
+		}
+
+	case AUTH_BEARER:
+
+		return &SecurityScheme{
+			Type:        "http",
+			Scheme:      "bearer",
+			Description: fmt.Sprintf("A token shared with the %s receiver.", scheme),
+		}
+
+	case AUTH_SIGNATURE:
+
+		return &SecurityScheme{
+			Type:        "apiKey",
+			In:          a.in,
+			Name:        a.name,
+			Description: fmt.Sprintf("A signature of the message, derived from the secret shared with the %s receiver.", scheme),
+		}
+
+	default:
+
+		return &SecurityScheme{
+			Type:        "apiKey",
+			In:          a.in,
+			Name:        a.name,
+			Description: fmt.Sprintf("A token shared with the %s receiver.", scheme),
+		}
+	}
+}
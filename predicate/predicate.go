@@ -0,0 +1,593 @@
+// Package predicate provides a small expression language for testing decoded webhook messages, and their metadata, against conditions.
+//
+// Expressions compare operands using the `==`, `!=`, `=~` (regular expression match), `!~`, `<`, `<=`, `>` and `>=` operators and
+// may be combined using `&&`, `||`, `!` and parentheses. Operands are either literals (quoted strings, numbers, `true`, `false`
+// and `null`) or paths. Paths are resolved against the message body using the `jsonpath` package unless they start with "$" in
+// which case they are resolved against the variables in an `Environment`, for example `$header.x-github-event`. An operand without
+// an operator is true if it exists and is not `false`, `null`, an empty string or zero. For example:
+//
+//	ref == "refs/heads/main" && !(head_commit.message =~ "\[skip ci\]")
+package predicate
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/whosonfirst/go-webhookd/v3/jsonpath"
+)
+
+// Environment is a struct containing the values that a `Predicate` is evaluated against.
+type Environment struct {
+	// Document is the decoded JSON message body. It may be nil if the message body is not JSON.
+	Document interface{}
+	// Variables is a dictionary of values resolved by "$"-prefixed paths. For example the path "$header.x-github-event"
+	// will resolve the "x-github-event" key in the "header" variable.
+	Variables map[string]interface{}
+}
+
+// Predicate is a compiled predicate expression.
+type Predicate struct {
+	// expression is the original expression string.
+	expression string
+	// root is the root node of the compiled expression.
+	root node
+}
+
+// Parse returns a new `Predicate` instance compiled from 'expr'.
+func Parse(expr string) (*Predicate, error) {
+
+	tokens, err := tokenize(expr)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to tokenize expression, %w", err)
+	}
+
+	p := &parser{tokens: tokens}
+
+	root, err := p.parseOr()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse expression, %w", err)
+	}
+
+	if !p.done() {
+		return nil, fmt.Errorf("Failed to parse expression, unexpected token '%s'", p.peek().value)
+	}
+
+	pr := &Predicate{
+		expression: expr,
+		root:       root,
+	}
+
+	return pr, nil
+}
+
+// String returns the original expression that 'p' was compiled from.
+func (p *Predicate) String() string {
+	return p.expression
+}
+
+// Match returns a boolean value indicating whether 'env' satisfies 'p'.
+func (p *Predicate) Match(env *Environment) bool {
+	return truthy(p.root.eval(env))
+}
+
+// node is the interface for nodes in a compiled expression.
+type node interface {
+	eval(*Environment) interface{}
+}
+
+// literalNode is a node for literal values.
+type literalNode struct {
+	value interface{}
+}
+
+func (n *literalNode) eval(env *Environment) interface{} {
+	return n.value
+}
+
+// pathNode is a node for values resolved from an `Environment`.
+type pathNode struct {
+	path string
+}
+
+func (n *pathNode) eval(env *Environment) interface{} {
+
+	if env == nil {
+		return nil
+	}
+
+	path := n.path
+
+	if !strings.HasPrefix(path, "$") {
+		v, _ := jsonpath.Get(env.Document, path)
+		return v
+	}
+
+	segments := strings.SplitN(strings.TrimPrefix(path, "$"), ".", 2)
+
+	v, ok := env.Variables[segments[0]]
+
+	if !ok {
+		return nil
+	}
+
+	if len(segments) == 1 {
+		return v
+	}
+
+	v, _ = jsonpath.Get(v, segments[1])
+	return v
+}
+
+// notNode is a node for logical negation.
+type notNode struct {
+	child node
+}
+
+func (n *notNode) eval(env *Environment) interface{} {
+	return !truthy(n.child.eval(env))
+}
+
+// logicalNode is a node for `&&` and `||` operations.
+type logicalNode struct {
+	op    string
+	left  node
+	right node
+}
+
+func (n *logicalNode) eval(env *Environment) interface{} {
+
+	left := truthy(n.left.eval(env))
+
+	if n.op == "&&" {
+		return left && truthy(n.right.eval(env))
+	}
+
+	return left || truthy(n.right.eval(env))
+}
+
+// compareNode is a node for comparison operations.
+type compareNode struct {
+	op    string
+	left  node
+	right node
+	re    *regexp.Regexp
+}
+
+func (n *compareNode) eval(env *Environment) interface{} {
+
+	left := n.left.eval(env)
+
+	switch n.op {
+	case "=~", "!~":
+
+		if left == nil {
+			return n.op == "!~"
+		}
+
+		matches := n.re.MatchString(jsonpath.String(left))
+
+		if n.op == "=~" {
+			return matches
+		}
+
+		return !matches
+	}
+
+	right := n.right.eval(env)
+
+	switch n.op {
+	case "==":
+		return equals(left, right)
+	case "!=":
+		return !equals(left, right)
+	}
+
+	lf, lok := number(left)
+	rf, rok := number(right)
+
+	if !lok || !rok {
+
+		if left == nil || right == nil {
+			return false
+		}
+
+		ls := jsonpath.String(left)
+		rs := jsonpath.String(right)
+
+		switch n.op {
+		case "<":
+			return ls < rs
+		case "<=":
+			return ls <= rs
+		case ">":
+			return ls > rs
+		default:
+			return ls >= rs
+		}
+	}
+
+	switch n.op {
+	case "<":
+		return lf < rf
+	case "<=":
+		return lf <= rf
+	case ">":
+		return lf > rf
+	default:
+		return lf >= rf
+	}
+}
+
+// equals returns a boolean value indicating whether 'a' and 'b' are equal. Numbers are compared numerically
+// and everything else is compared using its string representation.
+func equals(a interface{}, b interface{}) bool {
+
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+
+	af, aok := number(a)
+	bf, bok := number(b)
+
+	if aok && bok {
+		return af == bf
+	}
+
+	return jsonpath.String(a) == jsonpath.String(b)
+}
+
+// number returns 'v' as a float64 value and a boolean value indicating whether 'v' is numeric.
+func number(v interface{}) (float64, bool) {
+
+	switch t := v.(type) {
+	case json.Number:
+		f, err := t.Float64()
+		return f, err == nil
+	case float64:
+		return t, true
+	case int:
+		return float64(t), true
+	case int64:
+		return float64(t), true
+	default:
+		return 0, false
+	}
+}
+
+// truthy returns a boolean value indicating whether 'v' is considered true.
+func truthy(v interface{}) bool {
+
+	switch t := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return t
+	case string:
+		return t != ""
+	case json.Number, float64, int, int64:
+		f, _ := number(t)
+		return f != 0
+	case []interface{}:
+		return len(t) > 0
+	case map[string]interface{}:
+		return len(t) > 0
+	default:
+		return true
+	}
+}
+
+// token types
+const (
+	tokenPath = iota
+	tokenString
+	tokenNumber
+	tokenOperator
+	tokenLParen
+	tokenRParen
+)
+
+// token is a lexical token in an expression.
+type token struct {
+	kind  int
+	value string
+}
+
+// operators is the list of operators, longest first.
+var operators = []string{"&&", "||", "==", "!=", "=~", "!~", "<=", ">=", "<", ">", "!"}
+
+// tokenize returns the list of tokens in 'expr'.
+func tokenize(expr string) ([]token, error) {
+
+	tokens := make([]token, 0)
+	i := 0
+
+	for i < len(expr) {
+
+		c := expr[i]
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{tokenLParen, "("})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{tokenRParen, ")"})
+			i++
+		case c == '"' || c == '\'':
+
+			var sb strings.Builder
+			j := i + 1
+			closed := false
+
+			for j < len(expr) {
+
+				if expr[j] == '\\' && j+1 < len(expr) {
+
+					next := expr[j+1]
+
+					// Preserve escapes other than quotes and backslashes so that regular expressions work as expected
+
+					if next != c && next != '\\' {
+						sb.WriteByte('\\')
+					}
+
+					sb.WriteByte(next)
+					j += 2
+					continue
+				}
+
+				if expr[j] == c {
+					closed = true
+					break
+				}
+
+				sb.WriteByte(expr[j])
+				j++
+			}
+
+			if !closed {
+				return nil, fmt.Errorf("Unterminated string at offset %d", i)
+			}
+
+			tokens = append(tokens, token{tokenString, sb.String()})
+			i = j + 1
+
+		default:
+
+			matched := false
+
+			for _, op := range operators {
+
+				if strings.HasPrefix(expr[i:], op) {
+					tokens = append(tokens, token{tokenOperator, op})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+
+			if matched {
+				continue
+			}
+
+			j := i
+
+			for j < len(expr) && !strings.ContainsRune(" \t\r\n()\"'&|=!<>~", rune(expr[j])) {
+				j++
+			}
+
+			if j == i {
+				return nil, fmt.Errorf("Unexpected character '%c' at offset %d", c, i)
+			}
+
+			word := expr[i:j]
+
+			_, err := strconv.ParseFloat(word, 64)
+
+			if err == nil {
+				tokens = append(tokens, token{tokenNumber, word})
+			} else {
+				tokens = append(tokens, token{tokenPath, word})
+			}
+
+			i = j
+		}
+	}
+
+	return tokens, nil
+}
+
+// parser is a recursive-descent parser for a list of tokens.
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	p.pos++
+	return t
+}
+
+func (p *parser) parseOr() (node, error) {
+
+	left, err := p.parseAnd()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for !p.done() && p.peek().kind == tokenOperator && p.peek().value == "||" {
+
+		p.next()
+
+		right, err := p.parseAnd()
+
+		if err != nil {
+			return nil, err
+		}
+
+		left = &logicalNode{op: "||", left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+
+	left, err := p.parseUnary()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for !p.done() && p.peek().kind == tokenOperator && p.peek().value == "&&" {
+
+		p.next()
+
+		right, err := p.parseUnary()
+
+		if err != nil {
+			return nil, err
+		}
+
+		left = &logicalNode{op: "&&", left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+
+	if p.done() {
+		return nil, fmt.Errorf("Unexpected end of expression")
+	}
+
+	t := p.peek()
+
+	if t.kind == tokenOperator && t.value == "!" {
+
+		p.next()
+
+		child, err := p.parseUnary()
+
+		if err != nil {
+			return nil, err
+		}
+
+		return &notNode{child: child}, nil
+	}
+
+	if t.kind == tokenLParen {
+
+		p.next()
+
+		n, err := p.parseOr()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if p.done() || p.next().kind != tokenRParen {
+			return nil, fmt.Errorf("Missing closing parenthesis")
+		}
+
+		return n, nil
+	}
+
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (node, error) {
+
+	left, err := p.parseOperand()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if p.done() || p.peek().kind != tokenOperator {
+		return left, nil
+	}
+
+	op := p.peek().value
+
+	switch op {
+	case "==", "!=", "=~", "!~", "<", "<=", ">", ">=":
+		p.next()
+	default:
+		return left, nil
+	}
+
+	right, err := p.parseOperand()
+
+	if err != nil {
+		return nil, err
+	}
+
+	n := &compareNode{op: op, left: left, right: right}
+
+	if op == "=~" || op == "!~" {
+
+		lit, ok := right.(*literalNode)
+
+		if !ok {
+			return nil, fmt.Errorf("The right-hand side of '%s' must be a literal string", op)
+		}
+
+		re, err := regexp.Compile(jsonpath.String(lit.value))
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid regular expression, %w", err)
+		}
+
+		n.re = re
+	}
+
+	return n, nil
+}
+
+func (p *parser) parseOperand() (node, error) {
+
+	if p.done() {
+		return nil, fmt.Errorf("Unexpected end of expression")
+	}
+
+	t := p.next()
+
+	switch t.kind {
+	case tokenString:
+		return &literalNode{value: t.value}, nil
+	case tokenNumber:
+		return &literalNode{value: json.Number(t.value)}, nil
+	case tokenPath:
+
+		switch t.value {
+		case "true":
+			return &literalNode{value: true}, nil
+		case "false":
+			return &literalNode{value: false}, nil
+		case "null":
+			return &literalNode{value: nil}, nil
+		}
+
+		return &pathNode{path: t.value}, nil
+
+	default:
+		return nil, fmt.Errorf("Unexpected token '%s'", t.value)
+	}
+}
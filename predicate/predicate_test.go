@@ -0,0 +1,71 @@
+package predicate
+
+import (
+	"testing"
+
+	"github.com/whosonfirst/go-webhookd/v3/jsonpath"
+)
+
+func TestPredicate(t *testing.T) {
+
+	body := []byte(`{"ref":"refs/heads/main","deleted":false,"commits":[{"id":"abc","message":"Fix things [skip ci]"}],"repository":{"name":"go-webhookd","size":42}}`)
+
+	doc, err := jsonpath.Decode(body)
+
+	if err != nil {
+		t.Fatalf("Failed to decode body, %v", err)
+	}
+
+	env := &Environment{
+		Document: doc,
+		Variables: map[string]interface{}{
+			"header": map[string]interface{}{
+				"x-github-event": "push",
+			},
+		},
+	}
+
+	tests := map[string]bool{
+		`ref == "refs/heads/main"`:                               true,
+		`ref == 'refs/heads/develop'`:                            false,
+		`ref != "refs/heads/develop"`:                            true,
+		`ref =~ "^refs/heads/"`:                                  true,
+		`commits.0.message =~ "\[skip ci\]"`:                     true,
+		`commits.0.message !~ "\[skip ci\]"`:                     false,
+		`repository.size > 40 && repository.size <= 42`:          true,
+		`repository.size == 42.0`:                                true,
+		`deleted`:                                                false,
+		`!deleted`:                                               true,
+		`missing`:                                                false,
+		`missing == null`:                                        true,
+		`$header.x-github-event == "push"`:                       true,
+		`$header.x-github-event == "ping" || ref =~ "main$"`:     true,
+		`!($header.x-github-event == "push" && deleted == true)`: true,
+		`commits`: true,
+	}
+
+	for expr, expected := range tests {
+
+		p, err := Parse(expr)
+
+		if err != nil {
+			t.Fatalf("Failed to parse '%s', %v", expr, err)
+		}
+
+		if p.Match(env) != expected {
+			t.Fatalf("Unexpected result for '%s', expected %t", expr, expected)
+		}
+	}
+}
+
+func TestInvalidPredicate(t *testing.T) {
+
+	for _, expr := range []string{`ref ==`, `(ref == "main"`, `ref == "main`, `ref =~ "["`, `ref =~ other`, `ref == "a" "b"`, `&&`} {
+
+		_, err := Parse(expr)
+
+		if err == nil {
+			t.Fatalf("Expected '%s' to fail", expr)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package provider
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Kinds of drift between a `Registration` and the hooks registered with its provider.
+const (
+	// DRIFT_MISSING signals that there is no hook registered for the webhook.
+	DRIFT_MISSING string = "missing"
+	// DRIFT_URL signals that a hook is registered for the webhook's endpoint but with a different URL (for example a previous
+	// public URL).
+	DRIFT_URL string = "url"
+	// DRIFT_EVENTS signals that the events delivered to the hook are not the events defined by the registration.
+	DRIFT_EVENTS string = "events"
+	// DRIFT_INACTIVE signals that the provider is not delivering messages to the hook, for example because it was disabled after
+	// repeated delivery failures.
+	DRIFT_INACTIVE string = "inactive"
+	// DRIFT_ERROR signals that the hooks registered with the provider could not be retrieved.
+	DRIFT_ERROR string = "error"
+)
+
+// Drift is a difference between a `Registration` and the hooks registered with its provider.
+type Drift struct {
+	// Endpoint is the endpoint of the webhook, including any tenant prefix.
+	Endpoint string `json:"endpoint"`
+	// Kind is one of the DRIFT_ constants.
+	Kind string `json:"kind"`
+	// URL is the URL that the hook should be registered with.
+	URL string `json:"url"`
+	// HookID is the provider-specific identifier of the registered hook, if there is one.
+	HookID string `json:"hook_id,omitempty"`
+	// Expected is the list of expected values (the URL or events) for `DRIFT_URL` and `DRIFT_EVENTS` drift.
+	Expected []string `json:"expected,omitempty"`
+	// Actual is the list of actual values (the URL or events) for `DRIFT_URL` and `DRIFT_EVENTS` drift.
+	Actual []string `json:"actual,omitempty"`
+	// Error is the reason the hooks could not be retrieved for `DRIFT_ERROR` drift.
+	Error string `json:"error,omitempty"`
+}
+
+// CheckDrift compares each of 'registrations' with the hooks registered with its provider and returns the list of `Drift`
+// instances found, in the same order as 'registrations'. The hooks for each distinct provider URI are only retrieved once.
+// Failing to create a provider, or to retrieve its hooks, is reported as `DRIFT_ERROR` drift for each of its registrations
+// rather than as an error so that one unavailable provider does not hide the drift for others.
+func CheckDrift(ctx context.Context, registrations []*Registration) []*Drift {
+
+	hooks := make(map[string][]*Hook)
+	errors := make(map[string]error)
+
+	drift := make([]*Drift, 0)
+
+	for _, r := range registrations {
+
+		_, ok := hooks[r.Provider]
+
+		if !ok && errors[r.Provider] == nil {
+
+			p, err := NewProvider(ctx, r.Provider)
+
+			if err == nil {
+				hooks[r.Provider], err = p.Hooks(ctx)
+			}
+
+			if err != nil {
+				errors[r.Provider] = err
+			}
+		}
+
+		err := errors[r.Provider]
+
+		if err != nil {
+
+			drift = append(drift, &Drift{
+				Endpoint: r.Endpoint,
+				Kind:     DRIFT_ERROR,
+				URL:      r.Hook.URL,
+				Error:    err.Error(),
+			})
+
+			continue
+		}
+
+		drift = append(drift, compareHooks(r, hooks[r.Provider])...)
+	}
+
+	return drift
+}
+
+// compareHooks returns the list of `Drift` instances for 'r' given the hooks, 'hooks', registered with its provider.
+func compareHooks(r *Registration, hooks []*Hook) []*Drift {
+
+	var match *Hook
+
+	for _, h := range hooks {
+
+		if h.URL == r.Hook.URL {
+			match = h
+			break
+		}
+	}
+
+	if match == nil {
+
+		// Look for a hook delivering to the same endpoint at a different URL, for example after the public URL has changed
+
+		for _, h := range hooks {
+
+			if hookPath(h.URL) != "" && hookPath(h.URL) == hookPath(r.Hook.URL) {
+
+				d := &Drift{
+					Endpoint: r.Endpoint,
+					Kind:     DRIFT_URL,
+					URL:      r.Hook.URL,
+					HookID:   h.ID,
+					Expected: []string{r.Hook.URL},
+					Actual:   []string{h.URL},
+				}
+
+				return []*Drift{d}
+			}
+		}
+
+		d := &Drift{
+			Endpoint: r.Endpoint,
+			Kind:     DRIFT_MISSING,
+			URL:      r.Hook.URL,
+		}
+
+		return []*Drift{d}
+	}
+
+	drift := make([]*Drift, 0)
+
+	// Registrations without events use the provider's default events so there is nothing to compare them with
+
+	if len(r.Hook.Events) > 0 && !sameEvents(r.Hook.Events, match.Events) {
+
+		drift = append(drift, &Drift{
+			Endpoint: r.Endpoint,
+			Kind:     DRIFT_EVENTS,
+			URL:      r.Hook.URL,
+			HookID:   match.ID,
+			Expected: sortedEvents(r.Hook.Events),
+			Actual:   sortedEvents(match.Events),
+		})
+	}
+
+	if !match.Active {
+
+		drift = append(drift, &Drift{
+			Endpoint: r.Endpoint,
+			Kind:     DRIFT_INACTIVE,
+			URL:      r.Hook.URL,
+			HookID:   match.ID,
+		})
+	}
+
+	return drift
+}
+
+// hookPath returns the path of 'hook_url', or "" if it can not be parsed.
+func hookPath(hook_url string) string {
+
+	u, err := url.Parse(hook_url)
+
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimRight(u.Path, "/")
+}
+
+// sameEvents returns a boolean value indicating whether 'a' and 'b' contain the same events, ignoring order and duplicates.
+func sameEvents(a []string, b []string) bool {
+	return strings.Join(sortedEvents(a), ",") == strings.Join(sortedEvents(b), ",")
+}
+
+// sortedEvents returns a sorted copy of 'events' with duplicates removed.
+func sortedEvents(events []string) []string {
+
+	seen := make(map[string]bool)
+	sorted := make([]string, 0, len(events))
+
+	for _, e := range events {
+
+		if seen[e] {
+			continue
+		}
+
+		seen[e] = true
+		sorted = append(sorted, e)
+	}
+
+	sort.Strings(sorted)
+	return sorted
+}
@@ -0,0 +1,98 @@
+package provider
+
+import (
+	"context"
+	"testing"
+)
+
+// driftProvider is the `testProvider` instance returned for "testdrift://" URIs.
+var driftProvider = &testProvider{}
+
+func init() {
+
+	ctx := context.Background()
+
+	err := RegisterProvider(ctx, "testdrift", func(ctx context.Context, uri string) (Provider, error) {
+		return driftProvider, nil
+	})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+func TestCheckDrift(t *testing.T) {
+
+	ctx := context.Background()
+
+	driftProvider.hooks = []*Hook{
+		{ID: "1", URL: "https://hooks.example.com/ok", Events: []string{"release", "push"}, Active: true},
+		{ID: "2", URL: "https://old.example.com/moved/", Events: []string{"push"}, Active: true},
+		{ID: "3", URL: "https://hooks.example.com/events", Events: []string{"push"}, Active: true},
+		{ID: "4", URL: "https://hooks.example.com/disabled", Events: []string{"push"}, Active: false},
+		{ID: "5", URL: "https://hooks.example.com/defaults", Events: []string{"push"}, Active: true},
+	}
+
+	newRegistration := func(endpoint string, provider_uri string, events ...string) *Registration {
+
+		return &Registration{
+			Endpoint: endpoint,
+			Provider: provider_uri,
+			Hook: &Hook{
+				URL:    "https://hooks.example.com" + endpoint,
+				Events: events,
+				Active: true,
+			},
+		}
+	}
+
+	registrations := []*Registration{
+		newRegistration("/ok", "testdrift://", "push", "release", "push"),
+		newRegistration("/moved", "testdrift://", "push"),
+		newRegistration("/events", "testdrift://", "push", "release"),
+		newRegistration("/disabled", "testdrift://", "push"),
+		newRegistration("/defaults", "testdrift://"),
+		newRegistration("/missing", "testdrift://", "push"),
+		newRegistration("/unavailable", "bogus://"),
+	}
+
+	drift := CheckDrift(ctx, registrations)
+
+	expected := []struct {
+		endpoint string
+		kind     string
+		hook_id  string
+		actual   string
+	}{
+		{"/moved", DRIFT_URL, "2", "https://old.example.com/moved/"},
+		{"/events", DRIFT_EVENTS, "3", "push"},
+		{"/disabled", DRIFT_INACTIVE, "4", ""},
+		{"/missing", DRIFT_MISSING, "", ""},
+		{"/unavailable", DRIFT_ERROR, "", ""},
+	}
+
+	if len(drift) != len(expected) {
+		t.Fatalf("Expected %d drift, got %d", len(expected), len(drift))
+	}
+
+	for idx, e := range expected {
+
+		d := drift[idx]
+
+		if d.Endpoint != e.endpoint || d.Kind != e.kind || d.HookID != e.hook_id {
+			t.Fatalf("Unexpected drift at offset %d, %v", idx, d)
+		}
+
+		if e.actual != "" && (len(d.Actual) != 1 || d.Actual[0] != e.actual) {
+			t.Fatalf("Unexpected actual value for drift at offset %d, %v", idx, d.Actual)
+		}
+	}
+
+	if drift[1].Expected[0] != "push" || drift[1].Expected[1] != "release" {
+		t.Fatalf("Unexpected expected events, %v", drift[1].Expected)
+	}
+
+	if drift[4].Error == "" {
+		t.Fatalf("Expected error for unavailable provider")
+	}
+}
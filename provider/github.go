@@ -0,0 +1,229 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterProvider(ctx, "github", NewGitHubProvider)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// DEFAULT_GITHUB_API is the default root URL for the GitHub API.
+const DEFAULT_GITHUB_API string = "https://api.github.com"
+
+// githubHook is a webhook as represented by the GitHub API.
+type githubHook struct {
+	ID     int64            `json:"id,omitempty"`
+	Name   string           `json:"name,omitempty"`
+	Active bool             `json:"active"`
+	Events []string         `json:"events,omitempty"`
+	Config githubHookConfig `json:"config"`
+}
+
+// githubHookConfig is the configuration for a webhook as represented by the GitHub API.
+type githubHookConfig struct {
+	URL         string `json:"url"`
+	ContentType string `json:"content_type,omitempty"`
+	Secret      string `json:"secret,omitempty"`
+	InsecureSSL string `json:"insecure_ssl,omitempty"`
+}
+
+// GitHubProvider implements the `Provider` interface for webhooks registered with a GitHub repository or organization.
+type GitHubProvider struct {
+	Provider
+	client *http.Client
+	api    string
+	path   string
+	token  string
+}
+
+// NewGitHubProvider returns a new `GitHubProvider` instance configured by 'uri' in the form of:
+//
+//	github://{OWNER}/{REPO}?{PARAMETERS}
+//	github://{ORGANIZATION}?{PARAMETERS}
+//
+// Where {PARAMETERS} may be:
+// * `token={STRING}`. A GitHub access token with permission to manage the webhooks for the repository or organization. Required.
+// * `api={URL}`. The root URL for the GitHub API, for example for GitHub Enterprise Server. Default is "https://api.github.com".
+func NewGitHubProvider(ctx context.Context, uri string) (Provider, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	token := q.Get("token")
+
+	if token == "" {
+		return nil, fmt.Errorf("Missing token parameter")
+	}
+
+	api := DEFAULT_GITHUB_API
+
+	if q.Get("api") != "" {
+		api = strings.TrimRight(q.Get("api"), "/")
+	}
+
+	owner := u.Host
+	repo := strings.Trim(u.Path, "/")
+
+	var path string
+
+	switch {
+	case owner == "":
+		return nil, fmt.Errorf("Missing owner or organization")
+	case repo == "":
+		path = fmt.Sprintf("/orgs/%s/hooks", url.PathEscape(owner))
+	case strings.Contains(repo, "/"):
+		return nil, fmt.Errorf("Invalid repository '%s'", repo)
+	default:
+		path = fmt.Sprintf("/repos/%s/%s/hooks", url.PathEscape(owner), url.PathEscape(repo))
+	}
+
+	p := &GitHubProvider{
+		client: &http.Client{Timeout: 30 * time.Second},
+		api:    api,
+		path:   path,
+		token:  token,
+	}
+
+	return p, nil
+}
+
+// Hooks returns the list of webhooks registered with the GitHub repository or organization.
+func (p *GitHubProvider) Hooks(ctx context.Context) ([]*Hook, error) {
+
+	hooks := make([]*Hook, 0)
+
+	for page := 1; ; page++ {
+
+		var gh_hooks []*githubHook
+
+		err := p.do(ctx, http.MethodGet, fmt.Sprintf("%s?per_page=100&page=%d", p.path, page), nil, &gh_hooks)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, h := range gh_hooks {
+			hooks = append(hooks, h.hook())
+		}
+
+		if len(gh_hooks) < 100 {
+			break
+		}
+	}
+
+	return hooks, nil
+}
+
+// CreateHook creates a new webhook, delivering JSON-encoded messages, for the GitHub repository or organization. If 'hook' has
+// no events then GitHub's default ("push") is used.
+func (p *GitHubProvider) CreateHook(ctx context.Context, hook *Hook) (*Hook, error) {
+
+	gh_hook := newGitHubHook(hook)
+	gh_hook.Name = "web"
+
+	var created *githubHook
+
+	err := p.do(ctx, http.MethodPost, p.path, gh_hook, &created)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return created.hook(), nil
+}
+
+// UpdateHook updates an existing webhook for the GitHub repository or organization.
+func (p *GitHubProvider) UpdateHook(ctx context.Context, hook *Hook) (*Hook, error) {
+
+	if hook.ID == "" {
+		return nil, fmt.Errorf("Missing hook ID")
+	}
+
+	var updated *githubHook
+
+	err := p.do(ctx, http.MethodPatch, fmt.Sprintf("%s/%s", p.path, url.PathEscape(hook.ID)), newGitHubHook(hook), &updated)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return updated.hook(), nil
+}
+
+// do sends a 'method' request for 'path', with the JSON-encoded 'body' if not nil, to the GitHub API and decodes the response
+// in to 'target'.
+func (p *GitHubProvider) do(ctx context.Context, method string, path string, body interface{}, target interface{}) error {
+
+	var buf bytes.Buffer
+
+	if body != nil {
+
+		err := json.NewEncoder(&buf).Encode(body)
+
+		if err != nil {
+			return fmt.Errorf("Failed to encode request, %w", err)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, p.api+path, &buf)
+
+	if err != nil {
+		return fmt.Errorf("Failed to create request, %w", err)
+	}
+
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", p.token))
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	return doRequest(p.client, req, target)
+}
+
+// newGitHubHook returns the GitHub API representation of 'hook'.
+func newGitHubHook(hook *Hook) *githubHook {
+
+	return &githubHook{
+		Active: true,
+		Events: hook.Events,
+		Config: githubHookConfig{
+			URL:         hook.URL,
+			ContentType: "json",
+			Secret:      hook.Secret,
+			InsecureSSL: "0",
+		},
+	}
+}
+
+// hook returns the `Hook` for 'h'. Secrets are not returned by the GitHub API.
+func (h *githubHook) hook() *Hook {
+
+	return &Hook{
+		ID:     strconv.FormatInt(h.ID, 10),
+		URL:    h.Config.URL,
+		Events: h.Events,
+		Active: h.Active,
+	}
+}
@@ -0,0 +1,162 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeGitHubServer is an in-memory implementation of the GitHub API for the webhooks for a repository.
+type fakeGitHubServer struct {
+	mu    *sync.Mutex
+	hooks []*githubHook
+}
+
+func (s *fakeGitHubServer) ServeHTTP(rsp http.ResponseWriter, req *http.Request) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if req.Header.Get("Authorization") != "Bearer s33kret" {
+		http.Error(rsp, `{"message":"Bad credentials"}`, http.StatusUnauthorized)
+		return
+	}
+
+	switch {
+	case req.Method == http.MethodGet && req.URL.Path == "/repos/whosonfirst/go-webhookd/hooks":
+
+		var page, per_page int
+
+		fmt.Sscanf(req.URL.Query().Get("page"), "%d", &page)
+		fmt.Sscanf(req.URL.Query().Get("per_page"), "%d", &per_page)
+
+		start := (page - 1) * per_page
+		end := start + per_page
+
+		if start > len(s.hooks) {
+			start = len(s.hooks)
+		}
+
+		if end > len(s.hooks) {
+			end = len(s.hooks)
+		}
+
+		json.NewEncoder(rsp).Encode(s.hooks[start:end])
+
+	case req.Method == http.MethodPost && req.URL.Path == "/repos/whosonfirst/go-webhookd/hooks":
+
+		var h *githubHook
+		json.NewDecoder(req.Body).Decode(&h)
+
+		if h.Name != "web" || h.Config.ContentType != "json" || h.Config.Secret == "" {
+			http.Error(rsp, `{"message":"Invalid hook"}`, http.StatusUnprocessableEntity)
+			return
+		}
+
+		h.ID = int64(len(s.hooks) + 1)
+		h.Config.Secret = "********"
+		s.hooks = append(s.hooks, h)
+
+		rsp.WriteHeader(http.StatusCreated)
+		json.NewEncoder(rsp).Encode(h)
+
+	case req.Method == http.MethodPatch && strings.HasPrefix(req.URL.Path, "/repos/whosonfirst/go-webhookd/hooks/"):
+
+		var h *githubHook
+		json.NewDecoder(req.Body).Decode(&h)
+
+		for _, existing := range s.hooks {
+
+			if fmt.Sprintf("/repos/whosonfirst/go-webhookd/hooks/%d", existing.ID) == req.URL.Path {
+				existing.Events = h.Events
+				existing.Active = h.Active
+				existing.Config.URL = h.Config.URL
+				json.NewEncoder(rsp).Encode(existing)
+				return
+			}
+		}
+
+		http.Error(rsp, `{"message":"Not Found"}`, http.StatusNotFound)
+
+	default:
+		http.Error(rsp, `{"message":"Not Found"}`, http.StatusNotFound)
+	}
+}
+
+func TestGitHubProvider(t *testing.T) {
+
+	ctx := context.Background()
+
+	fake := &fakeGitHubServer{
+		mu:    new(sync.Mutex),
+		hooks: make([]*githubHook, 0),
+	}
+
+	// Add enough hooks that new hooks are on the second page of results
+
+	for i := 1; i <= 100; i++ {
+		h := &githubHook{ID: int64(i), Active: true, Events: []string{"push"}, Config: githubHookConfig{URL: fmt.Sprintf("https://example.com/%d", i)}}
+		fake.hooks = append(fake.hooks, h)
+	}
+
+	svr := httptest.NewServer(fake)
+	defer svr.Close()
+
+	q := url.Values{}
+	q.Set("token", "s33kret")
+	q.Set("api", svr.URL)
+
+	p, err := NewProvider(ctx, "github://whosonfirst/go-webhookd?"+q.Encode())
+
+	if err != nil {
+		t.Fatalf("Failed to create provider, %v", err)
+	}
+
+	hook := &Hook{
+		URL:    "https://example.com/github",
+		Secret: "github-s33kret",
+		Events: []string{"push", "release"},
+	}
+
+	registered, created, err := Register(ctx, p, hook)
+
+	if err != nil {
+		t.Fatalf("Failed to register hook, %v", err)
+	}
+
+	if !created || registered.ID != "101" || !registered.Active || len(registered.Events) != 2 {
+		t.Fatalf("Unexpected created hook %v", registered)
+	}
+
+	hook.Events = []string{"push"}
+
+	registered, created, err = Register(ctx, p, hook)
+
+	if err != nil {
+		t.Fatalf("Failed to register hook a second time, %v", err)
+	}
+
+	if created || registered.ID != "101" || len(registered.Events) != 1 {
+		t.Fatalf("Unexpected updated hook %v", registered)
+	}
+
+	q.Set("token", "bogus")
+
+	p, err = NewProvider(ctx, "github://whosonfirst/go-webhookd?"+q.Encode())
+
+	if err != nil {
+		t.Fatalf("Failed to create provider, %v", err)
+	}
+
+	_, err = p.Hooks(ctx)
+
+	if err == nil || !strings.Contains(err.Error(), "401") {
+		t.Fatalf("Expected invalid token to fail, %v", err)
+	}
+}
@@ -0,0 +1,287 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterProvider(ctx, "gitlab", NewGitLabProvider)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// DEFAULT_GITLAB_API is the default root URL for the GitLab API.
+const DEFAULT_GITLAB_API string = "https://gitlab.com"
+
+// gitlabEvents is the list of events that can be delivered to GitLab project webhooks. The GitLab API represents each event as
+// a boolean "{EVENT}_events" property.
+var gitlabEvents = []string{
+	"push",
+	"tag_push",
+	"issues",
+	"confidential_issues",
+	"merge_requests",
+	"note",
+	"confidential_note",
+	"job",
+	"pipeline",
+	"wiki_page",
+	"deployment",
+	"releases",
+}
+
+// GitLabProvider implements the `Provider` interface for webhooks registered with a GitLab project.
+type GitLabProvider struct {
+	Provider
+	client *http.Client
+	api    string
+	path   string
+	token  string
+}
+
+// NewGitLabProvider returns a new `GitLabProvider` instance configured by 'uri' in the form of:
+//
+//	gitlab://{NAMESPACE}/{PROJECT}?{PARAMETERS}
+//	gitlab://{PROJECT_ID}?{PARAMETERS}
+//
+// Where {PARAMETERS} may be:
+// * `token={STRING}`. A GitLab access token with permission to manage the webhooks for the project. Required.
+// * `api={URL}`. The root URL for the GitLab instance. Default is "https://gitlab.com".
+//
+// Events are the names of GitLab webhook triggers without the "_events" suffix, for example "push", "tag_push" or "merge_requests".
+func NewGitLabProvider(ctx context.Context, uri string) (Provider, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	token := q.Get("token")
+
+	if token == "" {
+		return nil, fmt.Errorf("Missing token parameter")
+	}
+
+	api := DEFAULT_GITLAB_API
+
+	if q.Get("api") != "" {
+		api = strings.TrimRight(q.Get("api"), "/")
+	}
+
+	project := strings.Trim(u.Host+u.Path, "/")
+
+	if project == "" {
+		return nil, fmt.Errorf("Missing project")
+	}
+
+	p := &GitLabProvider{
+		client: &http.Client{Timeout: 30 * time.Second},
+		api:    api,
+		path:   fmt.Sprintf("/api/v4/projects/%s/hooks", url.PathEscape(project)),
+		token:  token,
+	}
+
+	return p, nil
+}
+
+// Hooks returns the list of webhooks registered with the GitLab project.
+func (p *GitLabProvider) Hooks(ctx context.Context) ([]*Hook, error) {
+
+	hooks := make([]*Hook, 0)
+
+	for page := 1; ; page++ {
+
+		var gl_hooks []map[string]interface{}
+
+		err := p.do(ctx, http.MethodGet, fmt.Sprintf("%s?per_page=100&page=%d", p.path, page), nil, &gl_hooks)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, h := range gl_hooks {
+			hooks = append(hooks, gitlabHook(h))
+		}
+
+		if len(gl_hooks) < 100 {
+			break
+		}
+	}
+
+	return hooks, nil
+}
+
+// CreateHook creates a new webhook for the GitLab project. If 'hook' has no events then "push" is used.
+func (p *GitLabProvider) CreateHook(ctx context.Context, hook *Hook) (*Hook, error) {
+
+	body, err := newGitLabHook(hook)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var created map[string]interface{}
+
+	err = p.do(ctx, http.MethodPost, p.path, body, &created)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return gitlabHook(created), nil
+}
+
+// UpdateHook updates an existing webhook for the GitLab project.
+func (p *GitLabProvider) UpdateHook(ctx context.Context, hook *Hook) (*Hook, error) {
+
+	if hook.ID == "" {
+		return nil, fmt.Errorf("Missing hook ID")
+	}
+
+	body, err := newGitLabHook(hook)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var updated map[string]interface{}
+
+	err = p.do(ctx, http.MethodPut, fmt.Sprintf("%s/%s", p.path, url.PathEscape(hook.ID)), body, &updated)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return gitlabHook(updated), nil
+}
+
+// do sends a 'method' request for 'path', with the JSON-encoded 'body' if not nil, to the GitLab API and decodes the response
+// in to 'target'.
+func (p *GitLabProvider) do(ctx context.Context, method string, path string, body interface{}, target interface{}) error {
+
+	var buf bytes.Buffer
+
+	if body != nil {
+
+		err := json.NewEncoder(&buf).Encode(body)
+
+		if err != nil {
+			return fmt.Errorf("Failed to encode request, %w", err)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, p.api+path, &buf)
+
+	if err != nil {
+		return fmt.Errorf("Failed to create request, %w", err)
+	}
+
+	req.Header.Set("PRIVATE-TOKEN", p.token)
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	return doRequest(p.client, req, target)
+}
+
+// newGitLabHook returns the GitLab API representation of 'hook'. Every event is set explicitly since GitLab enables "push" events
+// by default.
+func newGitLabHook(hook *Hook) (map[string]interface{}, error) {
+
+	events := hook.Events
+
+	if len(events) == 0 {
+		events = []string{"push"}
+	}
+
+	enabled := make(map[string]bool)
+
+	for _, e := range events {
+
+		if !isGitLabEvent(e) {
+			return nil, fmt.Errorf("Unsupported GitLab event '%s'", e)
+		}
+
+		enabled[e] = true
+	}
+
+	body := map[string]interface{}{
+		"url":                     hook.URL,
+		"enable_ssl_verification": true,
+	}
+
+	if hook.Secret != "" {
+		body["token"] = hook.Secret
+	}
+
+	for _, e := range gitlabEvents {
+		body[e+"_events"] = enabled[e]
+	}
+
+	return body, nil
+}
+
+// gitlabHook returns the `Hook` for the GitLab API representation 'h'. Secrets are not returned by the GitLab API. Hooks that
+// GitLab has disabled, after repeated delivery failures, are not active.
+func gitlabHook(h map[string]interface{}) *Hook {
+
+	hook := &Hook{
+		Events: make([]string, 0),
+		Active: true,
+	}
+
+	status, ok := h["alert_status"].(string)
+
+	if ok && status != "executable" {
+		hook.Active = false
+	}
+
+	switch id := h["id"].(type) {
+	case float64:
+		hook.ID = strconv.FormatInt(int64(id), 10)
+	case string:
+		hook.ID = id
+	}
+
+	hook.URL, _ = h["url"].(string)
+
+	for _, e := range gitlabEvents {
+
+		enabled, _ := h[e+"_events"].(bool)
+
+		if enabled {
+			hook.Events = append(hook.Events, e)
+		}
+	}
+
+	return hook
+}
+
+// isGitLabEvent returns a boolean value indicating whether 'e' is one of `gitlabEvents`.
+func isGitLabEvent(e string) bool {
+
+	for _, v := range gitlabEvents {
+
+		if v == e {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,127 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+)
+
+func TestGitLabProvider(t *testing.T) {
+
+	ctx := context.Background()
+
+	mu := new(sync.Mutex)
+	hooks := make([]map[string]interface{}, 0)
+
+	handler := func(rsp http.ResponseWriter, req *http.Request) {
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if req.Header.Get("PRIVATE-TOKEN") != "s33kret" {
+			http.Error(rsp, `{"message":"401 Unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+
+		// Project paths are URL-encoded
+
+		if req.URL.EscapedPath() != "/api/v4/projects/whosonfirst%2Fgo-webhookd/hooks" && req.URL.EscapedPath() != "/api/v4/projects/whosonfirst%2Fgo-webhookd/hooks/1" {
+			http.Error(rsp, `{"message":"404 Not Found"}`, http.StatusNotFound)
+			return
+		}
+
+		switch req.Method {
+		case http.MethodGet:
+			json.NewEncoder(rsp).Encode(hooks)
+		case http.MethodPost:
+
+			var h map[string]interface{}
+			json.NewDecoder(req.Body).Decode(&h)
+
+			h["id"] = 1
+			delete(h, "token")
+
+			hooks = append(hooks, h)
+
+			rsp.WriteHeader(http.StatusCreated)
+			json.NewEncoder(rsp).Encode(h)
+
+		case http.MethodPut:
+
+			var h map[string]interface{}
+			json.NewDecoder(req.Body).Decode(&h)
+
+			h["id"] = 1
+			h["alert_status"] = "executable"
+			delete(h, "token")
+
+			hooks[0] = h
+			json.NewEncoder(rsp).Encode(h)
+		}
+	}
+
+	svr := httptest.NewServer(http.HandlerFunc(handler))
+	defer svr.Close()
+
+	q := url.Values{}
+	q.Set("token", "s33kret")
+	q.Set("api", svr.URL)
+
+	p, err := NewProvider(ctx, "gitlab://whosonfirst/go-webhookd?"+q.Encode())
+
+	if err != nil {
+		t.Fatalf("Failed to create provider, %v", err)
+	}
+
+	// GitLab enables push events by default so they must be disabled explicitly
+
+	hook := &Hook{
+		URL:    "https://example.com/gitlab",
+		Secret: "gitlab-s33kret",
+		Events: []string{"merge_requests", "tag_push"},
+	}
+
+	registered, created, err := Register(ctx, p, hook)
+
+	if err != nil {
+		t.Fatalf("Failed to register hook, %v", err)
+	}
+
+	if !created || registered.ID != "1" || len(registered.Events) != 2 || registered.Events[0] != "tag_push" {
+		t.Fatalf("Unexpected created hook %v", registered)
+	}
+
+	if hooks[0]["push_events"] != false {
+		t.Fatalf("Expected push events to be disabled")
+	}
+
+	hook.Events = nil
+
+	registered, created, err = Register(ctx, p, hook)
+
+	if err != nil {
+		t.Fatalf("Failed to register hook a second time, %v", err)
+	}
+
+	if created || len(registered.Events) != 1 || registered.Events[0] != "push" || !registered.Active {
+		t.Fatalf("Unexpected updated hook %v", registered)
+	}
+
+	hooks[0]["alert_status"] = "disabled"
+
+	existing, err := FindHook(ctx, p, hook.URL)
+
+	if err != nil || existing == nil || existing.Active {
+		t.Fatalf("Expected disabled hook to be inactive, %v %v", existing, err)
+	}
+
+	_, err = p.CreateHook(ctx, &Hook{URL: "https://example.com/gitlab", Events: []string{"bogus"}})
+
+	if err == nil {
+		t.Fatalf("Expected unsupported event to fail")
+	}
+}
@@ -0,0 +1,223 @@
+// Package provider provides an interface for managing the webhooks registered with an upstream provider (for example a GitHub
+// repository or a Stripe account) so that they can be created, or updated, to deliver messages to a webhookd daemon.
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/aaronland/go-roster"
+	"github.com/whosonfirst/go-webhookd/v3/secrets"
+)
+
+// Hook is a webhook registered with a provider.
+type Hook struct {
+	// ID is the provider-specific identifier for the hook. It is empty for hooks that have not been created yet.
+	ID string `json:"id,omitempty"`
+	// URL is the URL that the provider delivers messages to.
+	URL string `json:"url"`
+	// Secret is the shared secret used to sign messages. Most providers do not return secrets for existing hooks, in which case
+	// it is empty. Providers that generate their own secrets (for example Stripe) return them when a hook is created.
+	Secret string `json:"-"`
+	// Events is the list of provider-specific events that are delivered.
+	Events []string `json:"events"`
+	// Active is a boolean flag indicating whether the provider is delivering messages to the hook.
+	Active bool `json:"active"`
+}
+
+// Provider is an interface for managing the webhooks registered with an upstream provider.
+type Provider interface {
+	// Hooks returns the list of hooks registered with the provider.
+	Hooks(context.Context) ([]*Hook, error)
+	// CreateHook registers a new hook with the provider and returns it. If the hook has no events the provider's default events
+	// are used.
+	CreateHook(context.Context, *Hook) (*Hook, error)
+	// UpdateHook updates the existing hook, with the same ID, registered with the provider and returns it.
+	UpdateHook(context.Context, *Hook) (*Hook, error)
+}
+
+// providers is a `aaronland/go-roster.Roster` instance used to maintain a list of registered `Provider` initialization functions.
+var providers roster.Roster
+
+// ProviderInitializationFunc is a function used to initialize an implementation of the `Provider` interface.
+type ProviderInitializationFunc func(ctx context.Context, uri string) (Provider, error)
+
+// NewProvider returns a new `Provider` instance derived from 'uri'. The semantics of and requirements for 'uri' as specific to the
+// package implementing the interface.
+func NewProvider(ctx context.Context, uri string) (Provider, error) {
+
+	err := ensureRoster()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to ensure provider roster, %w", err)
+	}
+
+	uri, err = secrets.ResolveURI(ctx, uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to resolve secrets, %w", err)
+	}
+
+	parsed, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	scheme := parsed.Scheme
+
+	i, err := providers.Driver(ctx, scheme)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to find initialization function for '%s', %w", scheme, err)
+	}
+
+	init_func := i.(ProviderInitializationFunc)
+	return init_func(ctx, uri)
+}
+
+// RegisterProvider associates 'scheme' with 'init_func' in an internal list of avilable `Provider` implementations.
+func RegisterProvider(ctx context.Context, scheme string, init_func ProviderInitializationFunc) error {
+
+	err := ensureRoster()
+
+	if err != nil {
+		return fmt.Errorf("Failed to ensure provider roster, %w", err)
+	}
+
+	return providers.Register(ctx, scheme, init_func)
+}
+
+// ensureRoster ensures that a `aaronland/go-roster.Roster` instance used to maintain a list of registered `Provider`
+// initialization functions is present
+func ensureRoster() error {
+
+	if providers == nil {
+
+		r, err := roster.NewDefaultRoster()
+
+		if err != nil {
+			return fmt.Errorf("Failed to create new roster, %w", err)
+		}
+
+		providers = r
+	}
+
+	return nil
+}
+
+// Schemes returns the list of schemes that have been "registered".
+func Schemes() []string {
+
+	ctx := context.Background()
+	drivers := providers.Drivers(ctx)
+
+	schemes := make([]string, len(drivers))
+
+	for idx, dr := range drivers {
+		schemes[idx] = fmt.Sprintf("%s://", dr)
+	}
+
+	sort.Strings(schemes)
+	return schemes
+}
+
+// Register creates 'hook' with 'p', or updates the existing hook with the same URL, and returns the registered hook and a boolean
+// value indicating whether it was created.
+func Register(ctx context.Context, p Provider, hook *Hook) (*Hook, bool, error) {
+
+	existing, err := FindHook(ctx, p, hook.URL)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	if existing == nil {
+
+		created, err := p.CreateHook(ctx, hook)
+
+		if err != nil {
+			return nil, false, fmt.Errorf("Failed to create hook, %w", err)
+		}
+
+		return created, true, nil
+	}
+
+	update := *hook
+	update.ID = existing.ID
+
+	updated, err := p.UpdateHook(ctx, &update)
+
+	if err != nil {
+		return nil, false, fmt.Errorf("Failed to update hook, %w", err)
+	}
+
+	return updated, false, nil
+}
+
+// FindHook returns the hook registered with 'p' that delivers messages to 'hook_url', or nil if there is none.
+func FindHook(ctx context.Context, p Provider, hook_url string) (*Hook, error) {
+
+	hooks, err := p.Hooks(ctx)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list hooks, %w", err)
+	}
+
+	for _, h := range hooks {
+
+		if h.URL == hook_url {
+			return h, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// doRequest executes 'req' with 'client' and decodes the JSON-encoded response body in to 'target', if not nil. Responses with a
+// status code other than 2XX are returned as errors.
+func doRequest(client *http.Client, req *http.Request, target interface{}) error {
+
+	rsp, err := client.Do(req)
+
+	if err != nil {
+		return fmt.Errorf("Failed to execute request, %w", err)
+	}
+
+	defer rsp.Body.Close()
+
+	body, err := io.ReadAll(rsp.Body)
+
+	if err != nil {
+		return fmt.Errorf("Failed to read response, %w", err)
+	}
+
+	if rsp.StatusCode < 200 || rsp.StatusCode > 299 {
+
+		msg := strings.TrimSpace(string(body))
+
+		if len(msg) > 256 {
+			msg = msg[:256]
+		}
+
+		return fmt.Errorf("%s %s returned unexpected status %d, %s", req.Method, req.URL.Path, rsp.StatusCode, msg)
+	}
+
+	if target == nil {
+		return nil
+	}
+
+	err = json.Unmarshal(body, target)
+
+	if err != nil {
+		return fmt.Errorf("Failed to decode response, %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+// testProvider implements the `Provider` interface for hooks stored in memory.
+type testProvider struct {
+	Provider
+	hooks []*Hook
+}
+
+func (p *testProvider) Hooks(ctx context.Context) ([]*Hook, error) {
+	return p.hooks, nil
+}
+
+func (p *testProvider) CreateHook(ctx context.Context, hook *Hook) (*Hook, error) {
+
+	h := *hook
+	h.ID = fmt.Sprintf("%d", len(p.hooks)+1)
+
+	p.hooks = append(p.hooks, &h)
+	return &h, nil
+}
+
+func (p *testProvider) UpdateHook(ctx context.Context, hook *Hook) (*Hook, error) {
+
+	for idx, h := range p.hooks {
+
+		if h.ID == hook.ID {
+			updated := *hook
+			p.hooks[idx] = &updated
+			return &updated, nil
+		}
+	}
+
+	return nil, fmt.Errorf("Hook not found")
+}
+
+func TestNewProvider(t *testing.T) {
+
+	ctx := context.Background()
+
+	tests := []struct {
+		uri      string
+		expected bool
+	}{
+		{"github://whosonfirst/go-webhookd?token=s33kret", true},
+		{"github://whosonfirst?token=s33kret&api=https://github.example.com/api/v3", true},
+		{"gitlab://whosonfirst/go-webhookd?token=s33kret", true},
+		{"gitlab://1234?token=s33kret", true},
+		{"stripe://?api_key=sk_test_s33kret", true},
+		{"github://whosonfirst/go-webhookd", false},
+		{"github://?token=s33kret", false},
+		{"github://whosonfirst/go-webhookd/extra?token=s33kret", false},
+		{"gitlab://?token=s33kret", false},
+		{"stripe://", false},
+		{"bogus://", false},
+	}
+
+	for _, test := range tests {
+
+		_, err := NewProvider(ctx, test.uri)
+
+		if test.expected && err != nil {
+			t.Fatalf("Failed to create provider for '%s', %v", test.uri, err)
+		}
+
+		if !test.expected && err == nil {
+			t.Fatalf("Expected provider for '%s' to fail", test.uri)
+		}
+	}
+}
+
+func TestRegister(t *testing.T) {
+
+	ctx := context.Background()
+
+	p := &testProvider{
+		hooks: []*Hook{
+			{ID: "1", URL: "https://example.com/other", Events: []string{"push"}, Active: true},
+		},
+	}
+
+	hook := &Hook{
+		URL:    "https://example.com/github",
+		Secret: "s33kret",
+		Events: []string{"push"},
+	}
+
+	registered, created, err := Register(ctx, p, hook)
+
+	if err != nil {
+		t.Fatalf("Failed to register hook, %v", err)
+	}
+
+	if !created || registered.ID != "2" {
+		t.Fatalf("Expected hook to be created, %v", registered)
+	}
+
+	hook.Events = []string{"push", "release"}
+
+	registered, created, err = Register(ctx, p, hook)
+
+	if err != nil {
+		t.Fatalf("Failed to register hook a second time, %v", err)
+	}
+
+	if created || registered.ID != "2" || len(registered.Events) != 2 || len(p.hooks) != 2 {
+		t.Fatalf("Expected hook to be updated, %v", registered)
+	}
+
+	existing, err := FindHook(ctx, p, "https://example.com/missing")
+
+	if err != nil || existing != nil {
+		t.Fatalf("Expected missing hook to not be found, %v %v", existing, err)
+	}
+}
@@ -0,0 +1,170 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/whosonfirst/go-webhookd/v3/config"
+	"github.com/whosonfirst/go-webhookd/v3/secrets"
+	"github.com/whosonfirst/go-webhookd/v3/webhook"
+)
+
+// DEFAULT_SECRET_PARAMETER is the default name of the query parameter in a receiver URI containing the secret shared with a provider.
+const DEFAULT_SECRET_PARAMETER string = "secret"
+
+// Registration is a hook that should be registered with a provider for a webhook defined in a `config.WebhookConfig`.
+type Registration struct {
+	// Endpoint is the endpoint of the webhook, including any tenant prefix.
+	Endpoint string
+	// Provider is the `Provider` URI for the account the hook is registered with.
+	Provider string
+	// Hook is the hook that should be registered.
+	Hook *Hook
+}
+
+// RegistrationsFromConfig returns the list of `Registration` instances for the webhooks, including tenant webhooks, in 'cfg' that
+// define a `config.WebhookRegistrationConfig`. Hook URLs are derived from 'public_url', or `cfg.PublicURL` if empty, and hook
+// secrets from the (first) secret in the webhook's receiver URI.
+func RegistrationsFromConfig(ctx context.Context, cfg *config.WebhookConfig, public_url string) ([]*Registration, error) {
+
+	if public_url == "" {
+		public_url = cfg.PublicURL
+	}
+
+	registrations := make([]*Registration, 0)
+
+	add := func(prefix string, receivers map[string]string, webhooks []config.WebhookWebhooksConfig) error {
+
+		for _, wh_cfg := range webhooks {
+
+			if wh_cfg.Registration == nil {
+				continue
+			}
+
+			if public_url == "" {
+				return fmt.Errorf("Missing public URL")
+			}
+
+			r, err := newRegistration(ctx, public_url, prefix, receivers, wh_cfg)
+
+			if err != nil {
+				return fmt.Errorf("Invalid registration for '%s%s', %w", prefix, wh_cfg.Endpoint, err)
+			}
+
+			registrations = append(registrations, r)
+		}
+
+		return nil
+	}
+
+	err := add("", cfg.Receivers, cfg.Webhooks)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tenants := make([]string, 0, len(cfg.Tenants))
+
+	for name := range cfg.Tenants {
+		tenants = append(tenants, name)
+	}
+
+	sort.Strings(tenants)
+
+	for _, name := range tenants {
+
+		t := cfg.Tenants[name]
+
+		err := add(config.TENANT_PREFIX+name, t.Receivers, t.Webhooks)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return registrations, nil
+}
+
+// newRegistration returns a new `Registration` for 'wh_cfg', whose endpoint is installed under 'prefix', using the receivers in
+// 'receivers'.
+func newRegistration(ctx context.Context, public_url string, prefix string, receivers map[string]string, wh_cfg config.WebhookWebhooksConfig) (*Registration, error) {
+
+	reg_cfg := wh_cfg.Registration
+
+	if reg_cfg.Provider == "" {
+		return nil, fmt.Errorf("Missing provider")
+	}
+
+	if wh_cfg.Receiver == "" {
+		return nil, fmt.Errorf("Only webhooks with a receiver can be registered")
+	}
+
+	if webhook.IsEndpointPattern(wh_cfg.Endpoint) {
+		return nil, fmt.Errorf("Webhooks with endpoint patterns can not be registered")
+	}
+
+	receiver_uri, ok := receivers[wh_cfg.Receiver]
+
+	if !ok {
+		return nil, fmt.Errorf("Invalid receiver '%s'", wh_cfg.Receiver)
+	}
+
+	secret, err := receiverSecret(ctx, receiver_uri, reg_cfg.SecretParameter)
+
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := prefix + wh_cfg.Endpoint
+
+	r := &Registration{
+		Endpoint: endpoint,
+		Provider: reg_cfg.Provider,
+		Hook: &Hook{
+			URL:    strings.TrimRight(public_url, "/") + endpoint,
+			Secret: secret,
+			Events: reg_cfg.Events,
+			Active: true,
+		},
+	}
+
+	return r, nil
+}
+
+// receiverSecret returns the first secret in the 'param' query parameter of 'receiver_uri', or "" if there is none. Secrets may be
+// repeated, or comma-separated, while being rotated in which case the first (new) value is used.
+func receiverSecret(ctx context.Context, receiver_uri string, param string) (string, error) {
+
+	if param == "" {
+		param = DEFAULT_SECRET_PARAMETER
+	}
+
+	receiver_uri, err := secrets.ResolveURI(ctx, receiver_uri)
+
+	if err != nil {
+		return "", fmt.Errorf("Failed to resolve secrets for receiver, %w", err)
+	}
+
+	u, err := url.Parse(receiver_uri)
+
+	if err != nil {
+		return "", fmt.Errorf("Failed to parse receiver URI, %w", err)
+	}
+
+	for _, v := range u.Query()[param] {
+
+		for _, s := range strings.Split(v, ",") {
+
+			s = strings.TrimSpace(s)
+
+			if s != "" {
+				return s, nil
+			}
+		}
+	}
+
+	return "", nil
+}
@@ -0,0 +1,114 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/whosonfirst/go-webhookd/v3/config"
+)
+
+func TestRegistrationsFromConfig(t *testing.T) {
+
+	ctx := context.Background()
+
+	cfg := &config.WebhookConfig{
+		PublicURL: "https://hooks.example.com/",
+		Receivers: map[string]string{
+			"github": "github://?secret=new-s33kret,old-s33kret",
+			"gitlab": "gitlab://?token=gitlab-s33kret",
+		},
+		Webhooks: []config.WebhookWebhooksConfig{
+			{
+				Endpoint: "/github",
+				Receiver: "github",
+				Registration: &config.WebhookRegistrationConfig{
+					Provider: "github://whosonfirst/go-webhookd?token=s33kret",
+					Events:   []string{"push", "release"},
+				},
+			},
+			{
+				Endpoint: "/unregistered",
+				Receiver: "github",
+			},
+		},
+		Tenants: map[string]config.WebhookTenantConfig{
+			"acme": {
+				Receivers: map[string]string{
+					"gitlab": "gitlab://?token=acme-s33kret",
+				},
+				Webhooks: []config.WebhookWebhooksConfig{
+					{
+						Endpoint: "/gitlab",
+						Receiver: "gitlab",
+						Registration: &config.WebhookRegistrationConfig{
+							Provider:        "gitlab://acme/project?token=s33kret",
+							SecretParameter: "token",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	registrations, err := RegistrationsFromConfig(ctx, cfg, "")
+
+	if err != nil {
+		t.Fatalf("Failed to derive registrations from config, %v", err)
+	}
+
+	if len(registrations) != 2 {
+		t.Fatalf("Unexpected number of registrations, %d", len(registrations))
+	}
+
+	r := registrations[0]
+
+	if r.Endpoint != "/github" || r.Hook.URL != "https://hooks.example.com/github" || r.Hook.Secret != "new-s33kret" || len(r.Hook.Events) != 2 {
+		t.Fatalf("Unexpected registration %v (%v)", r, r.Hook)
+	}
+
+	r = registrations[1]
+
+	if r.Endpoint != "/t/acme/gitlab" || r.Hook.URL != "https://hooks.example.com/t/acme/gitlab" || r.Hook.Secret != "acme-s33kret" {
+		t.Fatalf("Unexpected tenant registration %v (%v)", r, r.Hook)
+	}
+
+	registrations, err = RegistrationsFromConfig(ctx, cfg, "https://example.ngrok.app")
+
+	if err != nil {
+		t.Fatalf("Failed to derive registrations from config with public URL, %v", err)
+	}
+
+	if registrations[0].Hook.URL != "https://example.ngrok.app/github" {
+		t.Fatalf("Unexpected hook URL %s", registrations[0].Hook.URL)
+	}
+
+	invalid := []config.WebhookWebhooksConfig{
+		{Endpoint: "/github", Receiver: "missing", Registration: &config.WebhookRegistrationConfig{Provider: "github://a/b?token=x"}},
+		{Endpoint: "/github", Receiver: "github", Registration: &config.WebhookRegistrationConfig{}},
+		{Endpoint: "/repos/{name}", Receiver: "github", Registration: &config.WebhookRegistrationConfig{Provider: "github://a/b?token=x"}},
+		{Endpoint: "/github", Source: "kafka", Registration: &config.WebhookRegistrationConfig{Provider: "github://a/b?token=x"}},
+	}
+
+	for idx, wh_cfg := range invalid {
+
+		cfg := &config.WebhookConfig{
+			PublicURL: "https://hooks.example.com",
+			Receivers: map[string]string{"github": "github://?secret=s33kret"},
+			Webhooks:  []config.WebhookWebhooksConfig{wh_cfg},
+		}
+
+		_, err := RegistrationsFromConfig(ctx, cfg, "")
+
+		if err == nil {
+			t.Fatalf("Expected invalid registration %d to fail", idx)
+		}
+	}
+
+	cfg.PublicURL = ""
+
+	_, err = RegistrationsFromConfig(ctx, cfg, "")
+
+	if err == nil {
+		t.Fatalf("Expected missing public URL to fail")
+	}
+}
@@ -0,0 +1,211 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterProvider(ctx, "stripe", NewStripeProvider)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// DEFAULT_STRIPE_API is the default root URL for the Stripe API.
+const DEFAULT_STRIPE_API string = "https://api.stripe.com"
+
+// stripeEndpoint is a webhook endpoint as represented by the Stripe API.
+type stripeEndpoint struct {
+	ID            string   `json:"id"`
+	URL           string   `json:"url"`
+	Status        string   `json:"status"`
+	EnabledEvents []string `json:"enabled_events"`
+	Secret        string   `json:"secret,omitempty"`
+}
+
+// stripeEndpointList is a page of webhook endpoints as represented by the Stripe API.
+type stripeEndpointList struct {
+	Data    []*stripeEndpoint `json:"data"`
+	HasMore bool              `json:"has_more"`
+}
+
+// StripeProvider implements the `Provider` interface for webhook endpoints registered with a Stripe account. Stripe generates
+// the signing secret for an endpoint when it is created, and it can not be changed, so the secret of a `Hook` is ignored and the
+// generated secret is returned by `CreateHook`.
+type StripeProvider struct {
+	Provider
+	client  *http.Client
+	api     string
+	api_key string
+}
+
+// NewStripeProvider returns a new `StripeProvider` instance configured by 'uri' in the form of:
+//
+//	stripe://?{PARAMETERS}
+//
+// Where {PARAMETERS} may be:
+// * `api_key={STRING}`. A Stripe secret, or restricted, API key with permission to manage webhook endpoints. Required.
+// * `api={URL}`. The root URL for the Stripe API. Default is "https://api.stripe.com".
+func NewStripeProvider(ctx context.Context, uri string) (Provider, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	api_key := q.Get("api_key")
+
+	if api_key == "" {
+		return nil, fmt.Errorf("Missing api_key parameter")
+	}
+
+	api := DEFAULT_STRIPE_API
+
+	if q.Get("api") != "" {
+		api = strings.TrimRight(q.Get("api"), "/")
+	}
+
+	p := &StripeProvider{
+		client:  &http.Client{Timeout: 30 * time.Second},
+		api:     api,
+		api_key: api_key,
+	}
+
+	return p, nil
+}
+
+// Hooks returns the list of webhook endpoints registered with the Stripe account.
+func (p *StripeProvider) Hooks(ctx context.Context) ([]*Hook, error) {
+
+	hooks := make([]*Hook, 0)
+	starting_after := ""
+
+	for {
+
+		q := url.Values{}
+		q.Set("limit", "100")
+
+		if starting_after != "" {
+			q.Set("starting_after", starting_after)
+		}
+
+		var list *stripeEndpointList
+
+		err := p.do(ctx, http.MethodGet, "/v1/webhook_endpoints?"+q.Encode(), nil, &list)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, e := range list.Data {
+			hooks = append(hooks, e.hook())
+		}
+
+		if !list.HasMore || len(list.Data) == 0 {
+			break
+		}
+
+		starting_after = list.Data[len(list.Data)-1].ID
+	}
+
+	return hooks, nil
+}
+
+// CreateHook creates a new webhook endpoint for the Stripe account and returns it, including the signing secret generated by
+// Stripe. If 'hook' has no events then all events ("*") are delivered.
+func (p *StripeProvider) CreateHook(ctx context.Context, hook *Hook) (*Hook, error) {
+
+	form := newStripeForm(hook)
+
+	var created *stripeEndpoint
+
+	err := p.do(ctx, http.MethodPost, "/v1/webhook_endpoints", form, &created)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return created.hook(), nil
+}
+
+// UpdateHook updates, and enables, an existing webhook endpoint for the Stripe account.
+func (p *StripeProvider) UpdateHook(ctx context.Context, hook *Hook) (*Hook, error) {
+
+	if hook.ID == "" {
+		return nil, fmt.Errorf("Missing hook ID")
+	}
+
+	form := newStripeForm(hook)
+	form.Set("disabled", "false")
+
+	var updated *stripeEndpoint
+
+	err := p.do(ctx, http.MethodPost, fmt.Sprintf("/v1/webhook_endpoints/%s", url.PathEscape(hook.ID)), form, &updated)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return updated.hook(), nil
+}
+
+// do sends a 'method' request for 'path', with the form-encoded 'form' if not nil, to the Stripe API and decodes the response
+// in to 'target'.
+func (p *StripeProvider) do(ctx context.Context, method string, path string, form url.Values, target interface{}) error {
+
+	req, err := http.NewRequestWithContext(ctx, method, p.api+path, strings.NewReader(form.Encode()))
+
+	if err != nil {
+		return fmt.Errorf("Failed to create request, %w", err)
+	}
+
+	req.SetBasicAuth(p.api_key, "")
+
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	return doRequest(p.client, req, target)
+}
+
+// newStripeForm returns the form-encoded Stripe API representation of 'hook'.
+func newStripeForm(hook *Hook) url.Values {
+
+	events := hook.Events
+
+	if len(events) == 0 {
+		events = []string{"*"}
+	}
+
+	form := url.Values{}
+	form.Set("url", hook.URL)
+
+	for _, e := range events {
+		form.Add("enabled_events[]", e)
+	}
+
+	return form
+}
+
+// hook returns the `Hook` for 'e'.
+func (e *stripeEndpoint) hook() *Hook {
+
+	return &Hook{
+		ID:     e.ID,
+		URL:    e.URL,
+		Secret: e.Secret,
+		Events: e.EnabledEvents,
+		Active: e.Status == "enabled",
+	}
+}
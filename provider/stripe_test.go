@@ -0,0 +1,149 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestStripeProvider(t *testing.T) {
+
+	ctx := context.Background()
+
+	mu := new(sync.Mutex)
+	endpoints := make([]*stripeEndpoint, 0)
+
+	for i := 1; i <= 3; i++ {
+		endpoints = append(endpoints, &stripeEndpoint{ID: fmt.Sprintf("we_%d", i), URL: fmt.Sprintf("https://example.com/%d", i), Status: "enabled", EnabledEvents: []string{"*"}})
+	}
+
+	handler := func(rsp http.ResponseWriter, req *http.Request) {
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		api_key, _, ok := req.BasicAuth()
+
+		if !ok || api_key != "sk_test_s33kret" {
+			http.Error(rsp, `{"error":{"message":"Invalid API Key provided"}}`, http.StatusUnauthorized)
+			return
+		}
+
+		switch {
+		case req.Method == http.MethodGet && req.URL.Path == "/v1/webhook_endpoints":
+
+			// Return two endpoints per page to test pagination
+
+			start := 0
+
+			for idx, e := range endpoints {
+
+				if e.ID == req.URL.Query().Get("starting_after") {
+					start = idx + 1
+				}
+			}
+
+			end := start + 2
+
+			if end > len(endpoints) {
+				end = len(endpoints)
+			}
+
+			list := &stripeEndpointList{
+				Data:    endpoints[start:end],
+				HasMore: end < len(endpoints),
+			}
+
+			json.NewEncoder(rsp).Encode(list)
+
+		case req.Method == http.MethodPost && req.URL.Path == "/v1/webhook_endpoints":
+
+			req.ParseForm()
+
+			e := &stripeEndpoint{
+				ID:            fmt.Sprintf("we_%d", len(endpoints)+1),
+				URL:           req.PostForm.Get("url"),
+				Status:        "enabled",
+				EnabledEvents: req.PostForm["enabled_events[]"],
+			}
+
+			endpoints = append(endpoints, e)
+
+			created := *e
+			created.Secret = "whsec_generated"
+
+			json.NewEncoder(rsp).Encode(created)
+
+		case req.Method == http.MethodPost && strings.HasPrefix(req.URL.Path, "/v1/webhook_endpoints/"):
+
+			req.ParseForm()
+
+			for _, e := range endpoints {
+
+				if "/v1/webhook_endpoints/"+e.ID == req.URL.Path {
+
+					e.URL = req.PostForm.Get("url")
+					e.EnabledEvents = req.PostForm["enabled_events[]"]
+
+					if req.PostForm.Get("disabled") == "false" {
+						e.Status = "enabled"
+					}
+
+					json.NewEncoder(rsp).Encode(e)
+					return
+				}
+			}
+
+			http.Error(rsp, `{"error":{"message":"No such webhook endpoint"}}`, http.StatusNotFound)
+
+		default:
+			http.Error(rsp, `{"error":{"message":"Unrecognized request URL"}}`, http.StatusNotFound)
+		}
+	}
+
+	svr := httptest.NewServer(http.HandlerFunc(handler))
+	defer svr.Close()
+
+	q := url.Values{}
+	q.Set("api_key", "sk_test_s33kret")
+	q.Set("api", svr.URL)
+
+	p, err := NewProvider(ctx, "stripe://?"+q.Encode())
+
+	if err != nil {
+		t.Fatalf("Failed to create provider, %v", err)
+	}
+
+	hook := &Hook{
+		URL: "https://example.com/stripe",
+	}
+
+	registered, created, err := Register(ctx, p, hook)
+
+	if err != nil {
+		t.Fatalf("Failed to register hook, %v", err)
+	}
+
+	if !created || registered.ID != "we_4" || registered.Secret != "whsec_generated" || registered.Events[0] != "*" {
+		t.Fatalf("Unexpected created hook %v", registered)
+	}
+
+	endpoints[3].Status = "disabled"
+	hook.Events = []string{"charge.succeeded", "charge.failed"}
+
+	registered, created, err = Register(ctx, p, hook)
+
+	if err != nil {
+		t.Fatalf("Failed to register hook a second time, %v", err)
+	}
+
+	if created || registered.ID != "we_4" || registered.Secret != "" || len(registered.Events) != 2 || !registered.Active {
+		t.Fatalf("Unexpected updated hook %v", registered)
+	}
+}
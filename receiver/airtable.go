@@ -0,0 +1,132 @@
+package receiver
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+// AIRTABLE_SIGNATURE_HEADER is the HTTP header containing the signature for an Airtable webhook notification.
+const AIRTABLE_SIGNATURE_HEADER string = "X-Airtable-Content-Mac"
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterReceiver(ctx, "airtable", NewAirtableReceiver)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// AirtableReceiver implements the `webhookd.WebhookReceiver` interface for receiving Airtable webhook notifications.
+type AirtableReceiver struct {
+	webhookd.WebhookReceiver
+	// mac_secrets is the list of (decoded) MAC secrets, returned by Airtable when a webhook is created, used to validate notification signatures.
+	mac_secrets []string
+	// body_options is the `BodyOptions` instance used to decode message bodies.
+	body_options *BodyOptions
+}
+
+// NewAirtableReceiver returns a new `AirtableReceiver` instance configured by 'uri' in the form of:
+//
+//	airtable://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `mac_secret={STRING}` The (URI-escaped) base64-encoded `macSecretBase64` value returned by Airtable when a webhook is created. Multiple comma-separated values may be defined so that secrets can be rotated. Required.
+// * Any of the parameters supported by `NewBodyOptionsFromQuery`.
+//
+// Airtable notifications only signal that a webhook has new payloads, which must be retrieved using the Airtable API.
+func NewAirtableReceiver(ctx context.Context, uri string) (webhookd.WebhookReceiver, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	str_secrets := parseSecrets(q, "mac_secret")
+
+	if len(str_secrets) == 0 {
+		return nil, fmt.Errorf("Missing ?mac_secret= parameter")
+	}
+
+	mac_secrets := make([]string, len(str_secrets))
+
+	for idx, str_secret := range str_secrets {
+
+		mac_secret, err := base64.StdEncoding.DecodeString(str_secret)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to decode ?mac_secret= parameter, %w", err)
+		}
+
+		mac_secrets[idx] = string(mac_secret)
+	}
+
+	body_opts, err := NewBodyOptionsFromQuery(q)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive body options, %w", err)
+	}
+
+	wh := AirtableReceiver{
+		mac_secrets:  mac_secrets,
+		body_options: body_opts,
+	}
+
+	return wh, nil
+}
+
+// Receive returns the body of the Airtable notification in 'req' after validating its `X-Airtable-Content-MAC` header.
+func (wh AirtableReceiver) Receive(ctx context.Context, req *http.Request) ([]byte, *webhookd.WebhookError) {
+
+	select {
+	case <-ctx.Done():
+		return nil, nil
+	default:
+		// pass
+	}
+
+	sig := req.Header.Get(AIRTABLE_SIGNATURE_HEADER)
+
+	if sig == "" {
+		code := http.StatusBadRequest
+		message := fmt.Sprintf("Missing %s header", AIRTABLE_SIGNATURE_HEADER)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	raw, body, err := ReadBody(ctx, req, wh.body_options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	verifiable := VerifiableBody(raw, body, wh.body_options)
+
+	verify := func(mac_secret string) bool {
+
+		mac := hmac.New(sha256.New, []byte(mac_secret))
+		mac.Write(verifiable)
+
+		expected := "hmac-sha256=" + hex.EncodeToString(mac.Sum(nil))
+		return hmac.Equal([]byte(expected), []byte(sig))
+	}
+
+	if !anySecret(wh.mac_secrets, verify) {
+		code := http.StatusForbidden
+		message := "Invalid signature"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	return body, nil
+}
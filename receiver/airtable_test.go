@@ -0,0 +1,69 @@
+package receiver
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestAirtableReceiver(t *testing.T) {
+
+	ctx := context.Background()
+
+	secret := []byte("s33kret-mac-secret")
+	str_secret := base64.StdEncoding.EncodeToString(secret)
+
+	r, err := NewReceiver(ctx, "airtable://?mac_secret="+url.QueryEscape(str_secret))
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	body := []byte(`{"base":{"id":"app00000000000000"},"webhook":{"id":"ach00000000000000"},"timestamp":"2022-02-01T21:25:05.663Z"}`)
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+
+	sig := "hmac-sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	tests := map[string]int{
+		string(body):                          0,
+		`{"base":{"id":"app00000000000001"}}`: http.StatusForbidden,
+	}
+
+	for msg, expected := range tests {
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/airtable", bytes.NewReader([]byte(msg)))
+
+		if err != nil {
+			t.Fatalf("Failed to create new request, %v", err)
+		}
+
+		req.Header.Set(AIRTABLE_SIGNATURE_HEADER, sig)
+
+		rsp, err2 := r.Receive(ctx, req)
+
+		if expected == 0 {
+
+			if err2 != nil {
+				t.Fatalf("Failed to receive message, %v", err2)
+			}
+
+			if !bytes.Equal(rsp, body) {
+				t.Fatalf("Unexpected output '%s'", string(rsp))
+			}
+
+			continue
+		}
+
+		if err2 == nil || err2.Code != expected {
+			t.Fatalf("Expected %d for %s but got %v", expected, msg, err2)
+		}
+	}
+}
@@ -0,0 +1,287 @@
+package receiver
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+// ATLASSIAN_EVENT_HEADER is the HTTP header that `AtlassianReceiver` assigns the normalized event type of a message to.
+const ATLASSIAN_EVENT_HEADER string = "X-Atlassian-Event"
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterReceiver(ctx, "atlassian", NewAtlassianReceiver)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// atlassianEvent is the subset of a Jira or Confluence webhook message used to derive its event type.
+type atlassianEvent struct {
+	WebhookEvent string `json:"webhookEvent"`
+	Event        string `json:"event"`
+}
+
+// atlassianClaims is the subset of the claims in an Atlassian Connect JWT used by `AtlassianReceiver`.
+type atlassianClaims struct {
+	Issuer    string `json:"iss"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+	QSH       string `json:"qsh"`
+}
+
+// AtlassianReceiver implements the `webhookd.WebhookReceiver` interface for receiving Jira and Confluence webhook messages.
+type AtlassianReceiver struct {
+	webhookd.WebhookReceiver
+	// shared_secrets is the list of Atlassian Connect shared secrets used to validate JWT tokens.
+	shared_secrets []string
+	// client_key is the optional Atlassian Connect client key that JWT tokens must be issued by.
+	client_key string
+	// tokens is the list of valid values for the `token` query parameter that requests must include.
+	tokens []string
+	// base_path is the path prefix removed from request paths before computing query string hashes.
+	base_path string
+	// body_options is the `BodyOptions` instance used to decode message bodies.
+	body_options *BodyOptions
+}
+
+// NewAtlassianReceiver returns a new `AtlassianReceiver` instance configured by 'uri' in the form of:
+//
+//	atlassian://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `shared_secret={STRING}` One or more (comma-separated) Atlassian Connect shared secrets used to validate the JWT token included with each request.
+// * `client_key={STRING}` The Atlassian Connect client key that JWT tokens must be issued by. If empty tokens from any client are accepted.
+// * `token={STRING}` One or more (comma-separated) valid values for the `token` query parameter that requests must include.
+// * `base_path={STRING}` The path prefix, relative to the Atlassian Connect app base URL, removed from request paths before computing query string hashes.
+// * Any of the parameters supported by `NewBodyOptionsFromQuery`.
+//
+// At least one of `shared_secret` or `token` must be present.
+func NewAtlassianReceiver(ctx context.Context, uri string) (webhookd.WebhookReceiver, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	shared_secrets := parseSecrets(q, "shared_secret")
+	tokens := parseSecrets(q, "token")
+
+	if len(shared_secrets) == 0 && len(tokens) == 0 {
+		return nil, fmt.Errorf("Missing ?shared_secret= or ?token= parameter")
+	}
+
+	body_opts, err := NewBodyOptionsFromQuery(q)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive body options, %w", err)
+	}
+
+	wh := AtlassianReceiver{
+		shared_secrets: shared_secrets,
+		client_key:     q.Get("client_key"),
+		tokens:         tokens,
+		base_path:      strings.TrimRight(q.Get("base_path"), "/"),
+		body_options:   body_opts,
+	}
+
+	return wh, nil
+}
+
+// Receive returns the body of the Jira or Confluence message in 'req' after validating its JWT token or shared token. The
+// normalized event type of the message, for example "jira:issue_updated" or "confluence:page_created", is assigned to the
+// `X-Atlassian-Event` header of 'req'.
+func (wh AtlassianReceiver) Receive(ctx context.Context, req *http.Request) ([]byte, *webhookd.WebhookError) {
+
+	select {
+	case <-ctx.Done():
+		return nil, nil
+	default:
+		// pass
+	}
+
+	if len(wh.tokens) > 0 && !constantTimeEqualAny(req.URL.Query().Get("token"), wh.tokens) {
+		code := http.StatusUnauthorized
+		message := "Unauthorized"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	if len(wh.shared_secrets) > 0 {
+
+		err := wh.verifyJWT(req)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_, body, err := ReadBody(ctx, req, wh.body_options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	body, err = DecodeBody(ctx, req, body, wh.body_options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	event := atlassianEventType(req, body)
+
+	if event != "" {
+		req.Header.Set(ATLASSIAN_EVENT_HEADER, event)
+	}
+
+	return body, nil
+}
+
+// verifyJWT returns an error if 'req' does not include a valid Atlassian Connect JWT token, either in its `Authorization` header
+// or its `jwt` query parameter.
+func (wh AtlassianReceiver) verifyJWT(req *http.Request) *webhookd.WebhookError {
+
+	unauthorized := func(message string) *webhookd.WebhookError {
+		code := http.StatusUnauthorized
+		return &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	token := req.URL.Query().Get("jwt")
+
+	if v := req.Header.Get("Authorization"); strings.HasPrefix(v, "JWT ") {
+		token = strings.TrimSpace(strings.TrimPrefix(v, "JWT "))
+	}
+
+	if token == "" {
+		return unauthorized("Missing JWT token")
+	}
+
+	var claims atlassianClaims
+
+	err := verifyHS256JWT(token, wh.shared_secrets, &claims)
+
+	if err != nil {
+		return unauthorized(err.Error())
+	}
+
+	if wh.client_key != "" && claims.Issuer != wh.client_key {
+		return unauthorized("Invalid JWT issuer")
+	}
+
+	if claims.ExpiresAt == 0 || time.Now().Unix() > claims.ExpiresAt {
+		return unauthorized("JWT token has expired")
+	}
+
+	path := req.URL.Path
+
+	if wh.base_path != "" && strings.HasPrefix(path, wh.base_path) {
+		path = strings.TrimPrefix(path, wh.base_path)
+	}
+
+	qsh := atlassianQueryStringHash(req.Method, path, req.URL.Query())
+
+	if !hmac.Equal([]byte(qsh), []byte(claims.QSH)) {
+		return unauthorized("Invalid query string hash")
+	}
+
+	return nil
+}
+
+// atlassianQueryStringHash returns the Atlassian Connect query string hash for a request with 'method', 'path' and 'query'.
+func atlassianQueryStringHash(method string, path string, query url.Values) string {
+
+	path = strings.TrimRight(path, "/")
+
+	if path == "" {
+		path = "/"
+	}
+
+	path = strings.ReplaceAll(path, "&", "%26")
+
+	keys := make([]string, 0, len(query))
+
+	for k := range query {
+
+		if k != "jwt" {
+			keys = append(keys, k)
+		}
+	}
+
+	sort.Strings(keys)
+
+	params := make([]string, len(keys))
+
+	for idx, k := range keys {
+
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+
+		for i, v := range values {
+			values[i] = atlassianEscape(v)
+		}
+
+		params[idx] = atlassianEscape(k) + "=" + strings.Join(values, ",")
+	}
+
+	canonical := strings.ToUpper(method) + "&" + path + "&" + strings.Join(params, "&")
+
+	sum := sha256.Sum256([]byte(canonical))
+	return hex.EncodeToString(sum[:])
+}
+
+// atlassianEscape percent-encodes 's' according to the rules for Atlassian Connect canonical requests.
+func atlassianEscape(s string) string {
+	s = url.QueryEscape(s)
+	s = strings.ReplaceAll(s, "+", "%20")
+	s = strings.ReplaceAll(s, "*", "%2A")
+	s = strings.ReplaceAll(s, "%7E", "~")
+	return s
+}
+
+// atlassianEventType returns the normalized event type for the Jira or Confluence message in 'req' and 'body'. Jira messages
+// include their event type in the `webhookEvent` property. Confluence messages include it in the `event` property or, for
+// Atlassian Connect apps, it may be passed as the `event` query parameter of the webhook URL.
+func atlassianEventType(req *http.Request, body []byte) string {
+
+	var ev atlassianEvent
+	json.Unmarshal(body, &ev)
+
+	switch {
+	case ev.WebhookEvent != "":
+
+		if strings.Contains(ev.WebhookEvent, ":") {
+			return ev.WebhookEvent
+		}
+
+		return "jira:" + ev.WebhookEvent
+
+	case ev.Event != "":
+		return "confluence:" + ev.Event
+	}
+
+	if v := req.URL.Query().Get("event"); v != "" {
+
+		if strings.Contains(v, ":") {
+			return v
+		}
+
+		return "confluence:" + v
+	}
+
+	return ""
+}
@@ -0,0 +1,153 @@
+package receiver
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func atlassianTestJWT(secret string, claims string) string {
+
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	payload := base64.RawURLEncoding.EncodeToString([]byte(claims))
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(header + "." + payload))
+
+	return header + "." + payload + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestAtlassianQueryStringHash(t *testing.T) {
+
+	// https://developer.atlassian.com/cloud/jira/platform/understanding-jwt-for-connect-apps/#qsh
+
+	q, err := url.ParseQuery("zee_last=param&repeated=parameter%201&first=param&repeated=parameter%202&empty=&multiple=param%201%2Cparam%202&jwt=ignored")
+
+	if err != nil {
+		t.Fatalf("Failed to parse query, %v", err)
+	}
+
+	canonical := "GET&/path/to/service&empty=&first=param&multiple=param%201%2Cparam%202&repeated=parameter%201,parameter%202&zee_last=param"
+
+	sum := sha256.Sum256([]byte(canonical))
+	expected := hex.EncodeToString(sum[:])
+
+	qsh := atlassianQueryStringHash("get", "/path/to/service/", q)
+
+	if qsh != expected {
+		t.Fatalf("Unexpected query string hash '%s'", qsh)
+	}
+}
+
+func TestAtlassianReceiver(t *testing.T) {
+
+	ctx := context.Background()
+
+	r, err := NewReceiver(ctx, "atlassian://?shared_secret=s33kret&client_key=jira-1&base_path=/hooks")
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	qsh := atlassianQueryStringHash("POST", "/jira", url.Values{})
+	exp := time.Now().Add(3 * time.Minute).Unix()
+
+	tests := map[string]int{
+		atlassianTestJWT("s33kret", fmt.Sprintf(`{"iss":"jira-1","exp":%d,"qsh":"%s"}`, exp, qsh)):    0,
+		atlassianTestJWT("wr0ng", fmt.Sprintf(`{"iss":"jira-1","exp":%d,"qsh":"%s"}`, exp, qsh)):      http.StatusUnauthorized,
+		atlassianTestJWT("s33kret", fmt.Sprintf(`{"iss":"jira-2","exp":%d,"qsh":"%s"}`, exp, qsh)):    http.StatusUnauthorized,
+		atlassianTestJWT("s33kret", fmt.Sprintf(`{"iss":"jira-1","exp":1500000000,"qsh":"%s"}`, qsh)): http.StatusUnauthorized,
+		atlassianTestJWT("s33kret", fmt.Sprintf(`{"iss":"jira-1","exp":%d,"qsh":"abc"}`, exp)):        http.StatusUnauthorized,
+	}
+
+	body := []byte(`{"webhookEvent":"jira:issue_updated","issue":{"key":"ABC-1"}}`)
+
+	for token, expected := range tests {
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/hooks/jira", bytes.NewReader(body))
+
+		if err != nil {
+			t.Fatalf("Failed to create new request, %v", err)
+		}
+
+		req.Header.Set("Authorization", "JWT "+token)
+
+		rsp, err2 := r.Receive(ctx, req)
+
+		if expected == 0 {
+
+			if err2 != nil {
+				t.Fatalf("Failed to receive message, %v", err2)
+			}
+
+			if !bytes.Equal(rsp, body) {
+				t.Fatalf("Unexpected output '%s'", string(rsp))
+			}
+
+			if req.Header.Get(ATLASSIAN_EVENT_HEADER) != "jira:issue_updated" {
+				t.Fatalf("Unexpected event header '%s'", req.Header.Get(ATLASSIAN_EVENT_HEADER))
+			}
+
+			continue
+		}
+
+		if err2 == nil || err2.Code != expected {
+			t.Fatalf("Expected %d for %s but got %v", expected, token, err2)
+		}
+	}
+}
+
+func TestAtlassianReceiverToken(t *testing.T) {
+
+	ctx := context.Background()
+
+	r, err := NewReceiver(ctx, "atlassian://?token=s33kret")
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	tests := map[string]string{
+		"http://localhost:8080/confluence?token=s33kret":                    `{"event":"page_created","page":{"id":1}}`,
+		"http://localhost:8080/confluence?token=s33kret&event=page_created": `{"page":{"id":1}}`,
+	}
+
+	for uri, body := range tests {
+
+		req, err := http.NewRequest("POST", uri, bytes.NewReader([]byte(body)))
+
+		if err != nil {
+			t.Fatalf("Failed to create new request, %v", err)
+		}
+
+		_, err2 := r.Receive(ctx, req)
+
+		if err2 != nil {
+			t.Fatalf("Failed to receive message, %v", err2)
+		}
+
+		if req.Header.Get(ATLASSIAN_EVENT_HEADER) != "confluence:page_created" {
+			t.Fatalf("Unexpected event header '%s' for %s", req.Header.Get(ATLASSIAN_EVENT_HEADER), uri)
+		}
+	}
+
+	req, err := http.NewRequest("POST", "http://localhost:8080/confluence?token=wr0ng", bytes.NewReader([]byte(`{}`)))
+
+	if err != nil {
+		t.Fatalf("Failed to create new request, %v", err)
+	}
+
+	_, err2 := r.Receive(ctx, req)
+
+	if err2 == nil || err2.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected invalid token to fail, %v", err2)
+	}
+}
@@ -0,0 +1,141 @@
+package receiver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterReceiver(ctx, "auth0", NewAuth0Receiver)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Auth0Receiver implements the `webhookd.WebhookReceiver` interface for receiving Auth0 log stream (custom webhook) messages.
+type Auth0Receiver struct {
+	webhookd.WebhookReceiver
+	// authorizations is the list of valid values for the `Authorization` header that requests must include.
+	authorizations []string
+	// body_options is the `BodyOptions` instance used to decode message bodies.
+	body_options *BodyOptions
+}
+
+// NewAuth0Receiver returns a new `Auth0Receiver` instance configured by 'uri' in the form of:
+//
+//	auth0://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `authorization={STRING}` One or more (comma-separated) valid values for the `Authorization` header, as configured for the log stream, that requests must include. Required.
+// * Any of the parameters supported by `NewBodyOptionsFromQuery`.
+func NewAuth0Receiver(ctx context.Context, uri string) (webhookd.WebhookReceiver, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	authorizations := parseSecrets(q, "authorization")
+
+	if len(authorizations) == 0 {
+		return nil, fmt.Errorf("Missing ?authorization= parameter")
+	}
+
+	body_opts, err := NewBodyOptionsFromQuery(q)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive body options, %w", err)
+	}
+
+	wh := Auth0Receiver{
+		authorizations: authorizations,
+		body_options:   body_opts,
+	}
+
+	return wh, nil
+}
+
+// Receive returns the log events in the Auth0 log stream message in 'req' as a JSON-encoded list, after checking its `Authorization`
+// header. Log streams may be configured to send events as a JSON array, JSON lines or a single JSON object; all three formats are
+// converted to a JSON array.
+func (wh Auth0Receiver) Receive(ctx context.Context, req *http.Request) ([]byte, *webhookd.WebhookError) {
+
+	select {
+	case <-ctx.Done():
+		return nil, nil
+	default:
+		// pass
+	}
+
+	if !constantTimeEqualAny(req.Header.Get("Authorization"), wh.authorizations) {
+		code := http.StatusUnauthorized
+		message := "Unauthorized"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	_, body, err := ReadBody(ctx, req, wh.body_options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return auth0Events(body)
+}
+
+// auth0Events returns the JSON array, JSON lines or JSON object in 'body' as a JSON array.
+func auth0Events(body []byte) ([]byte, *webhookd.WebhookError) {
+
+	trimmed := bytes.TrimSpace(body)
+
+	if bytes.HasPrefix(trimmed, []byte("[")) {
+
+		if !json.Valid(trimmed) {
+			code := http.StatusBadRequest
+			message := "Message body is not valid JSON"
+			return nil, &webhookd.WebhookError{Code: code, Message: message}
+		}
+
+		return trimmed, nil
+	}
+
+	events := make([]json.RawMessage, 0)
+
+	dec := json.NewDecoder(bytes.NewReader(trimmed))
+
+	for dec.More() {
+
+		var ev json.RawMessage
+
+		err := dec.Decode(&ev)
+
+		if err != nil {
+			code := http.StatusBadRequest
+			message := fmt.Sprintf("Failed to decode log event, %v", err)
+			return nil, &webhookd.WebhookError{Code: code, Message: message}
+		}
+
+		events = append(events, ev)
+	}
+
+	enc, err := json.Marshal(events)
+
+	if err != nil {
+		code := http.StatusInternalServerError
+		message := fmt.Sprintf("Failed to encode log events, %v", err)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	return enc, nil
+}
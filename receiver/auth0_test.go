@@ -0,0 +1,58 @@
+package receiver
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestAuth0Receiver(t *testing.T) {
+
+	ctx := context.Background()
+
+	r, err := NewReceiver(ctx, "auth0://?authorization=Bearer%20s33kret")
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	tests := map[string]string{
+		`[{"log_id":"1"},{"log_id":"2"}]`:          `[{"log_id":"1"},{"log_id":"2"}]`,
+		"{\"log_id\":\"1\"}\n{\"log_id\":\"2\"}\n": `[{"log_id":"1"},{"log_id":"2"}]`,
+		`{"log_id":"1"}`:                           `[{"log_id":"1"}]`,
+	}
+
+	for msg, expected := range tests {
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/auth0", bytes.NewReader([]byte(msg)))
+
+		if err != nil {
+			t.Fatalf("Failed to create new request, %v", err)
+		}
+
+		req.Header.Set("Authorization", "Bearer s33kret")
+
+		rsp, err2 := r.Receive(ctx, req)
+
+		if err2 != nil {
+			t.Fatalf("Failed to receive message, %v", err2)
+		}
+
+		if string(rsp) != expected {
+			t.Fatalf("Unexpected output '%s'", string(rsp))
+		}
+	}
+
+	req, err := http.NewRequest("POST", "http://localhost:8080/auth0", bytes.NewReader([]byte(`[]`)))
+
+	if err != nil {
+		t.Fatalf("Failed to create new request, %v", err)
+	}
+
+	_, err2 := r.Receive(ctx, req)
+
+	if err2 == nil || err2.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected unauthenticated request to fail, %v", err2)
+	}
+}
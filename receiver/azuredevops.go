@@ -0,0 +1,136 @@
+package receiver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+// AZUREDEVOPS_EVENT_HEADER is the HTTP header, set by `AzureDevOpsReceiver`, containing the event type of an Azure DevOps service hook message.
+const AZUREDEVOPS_EVENT_HEADER string = "X-Azure-Devops-Event"
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterReceiver(ctx, "azuredevops", NewAzureDevOpsReceiver)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// azureDevOpsEvent is the subset of an Azure DevOps service hook message used by `AzureDevOpsReceiver`.
+type azureDevOpsEvent struct {
+	EventType string `json:"eventType"`
+}
+
+// AzureDevOpsReceiver implements the `webhookd.WebhookReceiver` interface for receiving Azure DevOps service hook ("Web Hooks" consumer) messages.
+type AzureDevOpsReceiver struct {
+	webhookd.WebhookReceiver
+	// username is the basic authentication username that requests must include.
+	username string
+	// password is the basic authentication password that requests must include.
+	password string
+	// body_options is the `BodyOptions` instance used to decode message bodies.
+	body_options *BodyOptions
+}
+
+// NewAzureDevOpsReceiver returns a new `AzureDevOpsReceiver` instance configured by 'uri' in the form of:
+//
+//	azuredevops://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `username={STRING}` The basic authentication username, as configured for the service hook subscription, that requests must include. Required.
+// * `password={STRING}` The basic authentication password, as configured for the service hook subscription, that requests must include. Required.
+// * Any of the parameters supported by `NewBodyOptionsFromQuery`.
+func NewAzureDevOpsReceiver(ctx context.Context, uri string) (webhookd.WebhookReceiver, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	username := q.Get("username")
+
+	if username == "" {
+		return nil, fmt.Errorf("Missing ?username= parameter")
+	}
+
+	password := q.Get("password")
+
+	if password == "" {
+		return nil, fmt.Errorf("Missing ?password= parameter")
+	}
+
+	body_opts, err := NewBodyOptionsFromQuery(q)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive body options, %w", err)
+	}
+
+	wh := AzureDevOpsReceiver{
+		username:     username,
+		password:     password,
+		body_options: body_opts,
+	}
+
+	return wh, nil
+}
+
+// Receive returns the body of the Azure DevOps service hook message in 'req' after checking its basic authentication credentials.
+// The `X-Azure-Devops-Event` header of 'req' is set to the message's `eventType` property (for example "git.push" or "build.complete").
+func (wh AzureDevOpsReceiver) Receive(ctx context.Context, req *http.Request) ([]byte, *webhookd.WebhookError) {
+
+	select {
+	case <-ctx.Done():
+		return nil, nil
+	default:
+		// pass
+	}
+
+	username, password, ok := req.BasicAuth()
+
+	// Check both values, regardless of whether the first matches, so that timing doesn't reveal which one was wrong
+
+	username_ok := constantTimeEqual(username, wh.username)
+	password_ok := constantTimeEqual(password, wh.password)
+
+	if !ok || !username_ok || !password_ok {
+		code := http.StatusUnauthorized
+		message := "Unauthorized"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	_, body, err := ReadBody(ctx, req, wh.body_options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var ev azureDevOpsEvent
+
+	decode_err := json.Unmarshal(body, &ev)
+
+	if decode_err != nil {
+		code := http.StatusBadRequest
+		message := fmt.Sprintf("Failed to decode message, %v", decode_err)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	if ev.EventType == "" {
+		code := http.StatusBadRequest
+		message := "Message is missing eventType property"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	req.Header.Set(AZUREDEVOPS_EVENT_HEADER, ev.EventType)
+
+	return body, nil
+}
@@ -0,0 +1,69 @@
+package receiver
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestAzureDevOpsReceiver(t *testing.T) {
+
+	ctx := context.Background()
+
+	r, err := NewReceiver(ctx, "azuredevops://?username=webhookd&password=s33kret")
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	body := []byte(`{"id":"1","eventType":"git.push","publisherId":"tfs","resource":{"refUpdates":[]}}`)
+
+	tests := []struct {
+		username string
+		password string
+		body     []byte
+		expected int
+	}{
+		{"webhookd", "s33kret", body, 0},
+		{"webhookd", "wr0ng", body, http.StatusUnauthorized},
+		{"", "", body, http.StatusUnauthorized},
+		{"webhookd", "s33kret", []byte(`{"id":"1"}`), http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/azuredevops", bytes.NewReader(test.body))
+
+		if err != nil {
+			t.Fatalf("Failed to create new request, %v", err)
+		}
+
+		if test.username != "" {
+			req.SetBasicAuth(test.username, test.password)
+		}
+
+		rsp, err2 := r.Receive(ctx, req)
+
+		if test.expected == 0 {
+
+			if err2 != nil {
+				t.Fatalf("Failed to receive message, %v", err2)
+			}
+
+			if !bytes.Equal(rsp, test.body) {
+				t.Fatalf("Unexpected output '%s'", string(rsp))
+			}
+
+			if req.Header.Get(AZUREDEVOPS_EVENT_HEADER) != "git.push" {
+				t.Fatalf("Unexpected %s header '%s'", AZUREDEVOPS_EVENT_HEADER, req.Header.Get(AZUREDEVOPS_EVENT_HEADER))
+			}
+
+			continue
+		}
+
+		if err2 == nil || err2.Code != test.expected {
+			t.Fatalf("Expected %d for %s:%s but got %v", test.expected, test.username, test.password, err2)
+		}
+	}
+}
@@ -0,0 +1,370 @@
+package receiver
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+// CONTENT_TYPE_FORM is the content type for URL-encoded form bodies.
+const CONTENT_TYPE_FORM string = "application/x-www-form-urlencoded"
+
+// CONTENT_TYPE_MULTIPART is the content type for multipart form bodies.
+const CONTENT_TYPE_MULTIPART string = "multipart/form-data"
+
+// DEFAULT_MAX_DECOMPRESSED_BYTES is the default maximum number of bytes that a compressed message body may be decompressed to.
+const DEFAULT_MAX_DECOMPRESSED_BYTES int64 = 64 << 20
+
+// maxMultipartMemory is the maximum number of bytes of a multipart body that will be stored in memory (the rest is written to temporary files).
+const maxMultipartMemory int64 = 32 << 20
+
+// BodyOptions is a struct containing options for decoding the body of a webhook message on arrival.
+type BodyOptions struct {
+	// FormField is the name of a form field whose value will be used as the message body for form-encoded and multipart requests.
+	FormField string
+	// FormToJSON is a boolean flag signaling that all the fields in form-encoded and multipart requests should be encoded as a JSON dictionary.
+	FormToJSON bool
+	// Decompress is a boolean flag signaling that message bodies with a `Content-Encoding` header of "gzip" or "deflate" should be decompressed.
+	Decompress bool
+	// MaxDecompressedBytes is the maximum number of bytes that a compressed message body may be decompressed to.
+	MaxDecompressedBytes int64
+	// VerifyRaw is a boolean flag signaling that receivers which validate message signatures should do so using the raw (compressed)
+	// bytes of a message body rather than its decompressed bytes.
+	VerifyRaw bool
+}
+
+// NewBodyOptionsFromQuery returns a new `BodyOptions` instance derived from 'q'. Valid parameters are:
+// * `form_field={NAME}` The name of a form field whose value will be used as the message body.
+// * `form_json={BOOLEAN}` A boolean flag to indicate that all form fields should be encoded as a JSON dictionary.
+// * `decompress={BOOLEAN}` A boolean flag to indicate that gzip and deflate encoded bodies should be decompressed. Default is true.
+// * `max_decompressed_bytes={INT}` The maximum number of bytes that a compressed body may be decompressed to. Default is `DEFAULT_MAX_DECOMPRESSED_BYTES`.
+// * `verify_raw={BOOLEAN}` A boolean flag to indicate that message signatures should be validated using the raw (compressed) bytes of a message body. Default is false.
+func NewBodyOptionsFromQuery(q url.Values) (*BodyOptions, error) {
+
+	opts := &BodyOptions{
+		FormField:            q.Get("form_field"),
+		Decompress:           true,
+		MaxDecompressedBytes: DEFAULT_MAX_DECOMPRESSED_BYTES,
+	}
+
+	str_decompress := q.Get("decompress")
+
+	if str_decompress != "" {
+
+		v, err := strconv.ParseBool(str_decompress)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse ?decompress= parameter, %w", err)
+		}
+
+		opts.Decompress = v
+	}
+
+	str_max := q.Get("max_decompressed_bytes")
+
+	if str_max != "" {
+
+		v, err := strconv.ParseInt(str_max, 10, 64)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse ?max_decompressed_bytes= parameter, %w", err)
+		}
+
+		opts.MaxDecompressedBytes = v
+	}
+
+	str_verify := q.Get("verify_raw")
+
+	if str_verify != "" {
+
+		v, err := strconv.ParseBool(str_verify)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse ?verify_raw= parameter, %w", err)
+		}
+
+		opts.VerifyRaw = v
+	}
+
+	str_json := q.Get("form_json")
+
+	if str_json != "" {
+
+		v, err := strconv.ParseBool(str_json)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse ?form_json= parameter, %w", err)
+		}
+
+		opts.FormToJSON = v
+	}
+
+	if opts.FormField != "" && opts.FormToJSON {
+		return nil, fmt.Errorf("?form_field= and ?form_json= parameters are mutually exclusive")
+	}
+
+	return opts, nil
+}
+
+// ReadBody reads the body of 'req' returning both the raw bytes, as they were sent, and the message body after it has been decompressed
+// according to the rules defined in 'opts'. If the body of 'req' was not compressed (or 'opts' disables decompression) both values will
+// be the same.
+func ReadBody(ctx context.Context, req *http.Request, opts *BodyOptions) ([]byte, []byte, *webhookd.WebhookError) {
+
+	raw, err := readAll(req.Body, req.ContentLength)
+
+	if err != nil {
+		code := http.StatusInternalServerError
+		message := err.Error()
+		return nil, nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	if opts == nil || !opts.Decompress {
+		return raw, raw, nil
+	}
+
+	body, err2 := DecompressBody(ctx, req, raw, opts.MaxDecompressedBytes)
+
+	if err2 != nil {
+		return nil, nil, err2
+	}
+
+	return raw, body, nil
+}
+
+// DecompressBody returns 'body' decompressed according to the `Content-Encoding` header of 'req'. Supported encodings are "gzip"
+// and "deflate". If 'max_bytes' is greater than zero and the decompressed body exceeds that many bytes an error is returned.
+func DecompressBody(ctx context.Context, req *http.Request, body []byte, max_bytes int64) ([]byte, *webhookd.WebhookError) {
+
+	encoding := strings.ToLower(strings.TrimSpace(req.Header.Get("Content-Encoding")))
+
+	var r io.ReadCloser
+
+	switch encoding {
+	case "", "identity":
+		return body, nil
+	case "gzip", "x-gzip":
+
+		gz, err := gzip.NewReader(bytes.NewReader(body))
+
+		if err != nil {
+			code := http.StatusBadRequest
+			message := fmt.Sprintf("Failed to create gzip reader, %v", err)
+			return nil, &webhookd.WebhookError{Code: code, Message: message}
+		}
+
+		r = gz
+
+	case "deflate":
+
+		// RFC 9110 says "deflate" means zlib-wrapped data but some senders
+		// send raw deflate data so fall back to that if necessary
+
+		zr, err := zlib.NewReader(bytes.NewReader(body))
+
+		if err != nil {
+			r = flate.NewReader(bytes.NewReader(body))
+		} else {
+			r = zr
+		}
+
+	default:
+		code := http.StatusUnsupportedMediaType
+		message := fmt.Sprintf("Unsupported content encoding '%s'", encoding)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	defer r.Close()
+
+	var lr io.Reader = r
+
+	if max_bytes > 0 {
+		lr = io.LimitReader(r, max_bytes+1)
+	}
+
+	decompressed, err := io.ReadAll(lr)
+
+	if err != nil {
+		code := http.StatusBadRequest
+		message := fmt.Sprintf("Failed to decompress body, %v", err)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	if max_bytes > 0 && int64(len(decompressed)) > max_bytes {
+		code := http.StatusRequestEntityTooLarge
+		message := "Decompressed body exceeds maximum size"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	return decompressed, nil
+}
+
+// VerifiableBody returns either 'raw' or 'body' depending on whether 'opts' signals that message signatures should be validated
+// using the raw (compressed) bytes of a message body.
+func VerifiableBody(raw []byte, body []byte, opts *BodyOptions) []byte {
+
+	if opts != nil && opts.VerifyRaw {
+		return raw
+	}
+
+	return body
+}
+
+// DecodeBody returns 'body' decoded according to the content type of 'req' and the rules defined in 'opts'. If 'opts' does
+// not define any form-related rules, or 'req' is not a form-encoded or multipart request, then 'body' is returned unaltered.
+func DecodeBody(ctx context.Context, req *http.Request, body []byte, opts *BodyOptions) ([]byte, *webhookd.WebhookError) {
+
+	if opts == nil || (opts.FormField == "" && !opts.FormToJSON) {
+		return body, nil
+	}
+
+	content_type, params, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+
+	if err != nil {
+		return body, nil
+	}
+
+	var values url.Values
+	var files map[string][]*multipart.FileHeader
+
+	switch content_type {
+	case CONTENT_TYPE_FORM:
+
+		v, err := url.ParseQuery(string(body))
+
+		if err != nil {
+			code := http.StatusBadRequest
+			message := fmt.Sprintf("Failed to parse form body, %v", err)
+			return nil, &webhookd.WebhookError{Code: code, Message: message}
+		}
+
+		values = v
+
+	case CONTENT_TYPE_MULTIPART:
+
+		boundary, ok := params["boundary"]
+
+		if !ok {
+			code := http.StatusBadRequest
+			message := "Missing multipart boundary"
+			return nil, &webhookd.WebhookError{Code: code, Message: message}
+		}
+
+		mr := multipart.NewReader(bytes.NewReader(body), boundary)
+		form, err := mr.ReadForm(maxMultipartMemory)
+
+		if err != nil {
+			code := http.StatusBadRequest
+			message := fmt.Sprintf("Failed to parse multipart body, %v", err)
+			return nil, &webhookd.WebhookError{Code: code, Message: message}
+		}
+
+		defer form.RemoveAll()
+
+		values = url.Values(form.Value)
+		files = form.File
+
+	default:
+		return body, nil
+	}
+
+	if opts.FormField != "" {
+		return decodeFormField(opts.FormField, values, files)
+	}
+
+	return decodeFormJSON(values, files)
+}
+
+// decodeFormField returns the value of the form field 'name' in either 'values' or 'files'.
+func decodeFormField(name string, values url.Values, files map[string][]*multipart.FileHeader) ([]byte, *webhookd.WebhookError) {
+
+	if v, ok := values[name]; ok && len(v) > 0 {
+		return []byte(v[0]), nil
+	}
+
+	if fh, ok := files[name]; ok && len(fh) > 0 {
+
+		r, err := fh[0].Open()
+
+		if err != nil {
+			code := http.StatusInternalServerError
+			message := fmt.Sprintf("Failed to open multipart file '%s', %v", name, err)
+			return nil, &webhookd.WebhookError{Code: code, Message: message}
+		}
+
+		defer r.Close()
+
+		body, err := io.ReadAll(r)
+
+		if err != nil {
+			code := http.StatusInternalServerError
+			message := fmt.Sprintf("Failed to read multipart file '%s', %v", name, err)
+			return nil, &webhookd.WebhookError{Code: code, Message: message}
+		}
+
+		return body, nil
+	}
+
+	code := http.StatusBadRequest
+	message := fmt.Sprintf("Missing form field '%s'", name)
+	return nil, &webhookd.WebhookError{Code: code, Message: message}
+}
+
+// decodeFormJSON returns 'values' and 'files' encoded as a JSON dictionary. Fields with a single value are encoded as strings and
+// fields with multiple values are encoded as lists of strings. Files are encoded as dictionaries describing the file but not its contents.
+func decodeFormJSON(values url.Values, files map[string][]*multipart.FileHeader) ([]byte, *webhookd.WebhookError) {
+
+	doc := make(map[string]interface{})
+
+	for k, v := range values {
+
+		if len(v) == 1 {
+			doc[k] = v[0]
+		} else {
+			doc[k] = v
+		}
+	}
+
+	for k, fh := range files {
+
+		details := make([]map[string]interface{}, len(fh))
+
+		for idx, f := range fh {
+
+			details[idx] = map[string]interface{}{
+				"filename":     f.Filename,
+				"content_type": f.Header.Get("Content-Type"),
+				"size":         f.Size,
+			}
+		}
+
+		if len(details) == 1 {
+			doc[k] = details[0]
+		} else {
+			doc[k] = details
+		}
+	}
+
+	body, err := json.Marshal(doc)
+
+	if err != nil {
+		code := http.StatusInternalServerError
+		message := fmt.Sprintf("Failed to encode form as JSON, %v", err)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	return body, nil
+}
@@ -0,0 +1,267 @@
+package receiver
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestNewBodyOptionsFromQuery(t *testing.T) {
+
+	q := url.Values{}
+	q.Set("form_field", "payload")
+	q.Set("form_json", "true")
+
+	_, err := NewBodyOptionsFromQuery(q)
+
+	if err == nil {
+		t.Fatalf("Expected mutually exclusive parameters to fail")
+	}
+}
+
+func TestDecodeBodyFormField(t *testing.T) {
+
+	ctx := context.Background()
+
+	r, err := NewReceiver(ctx, "insecure://?form_field=payload")
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	form := url.Values{}
+	form.Set("payload", `{"hello":"world"}`)
+
+	req, err := http.NewRequest("POST", "http://localhost:8080/insecure", strings.NewReader(form.Encode()))
+
+	if err != nil {
+		t.Fatalf("Failed to create new request, %v", err)
+	}
+
+	req.Header.Set("Content-Type", CONTENT_TYPE_FORM)
+
+	body, err2 := r.Receive(ctx, req)
+
+	if err2 != nil {
+		t.Fatalf("Failed to receive message, %v", err2)
+	}
+
+	if string(body) != `{"hello":"world"}` {
+		t.Fatalf("Unexpected output '%s'", string(body))
+	}
+}
+
+func TestDecodeBodyMultipartJSON(t *testing.T) {
+
+	ctx := context.Background()
+
+	r, err := NewReceiver(ctx, "insecure://?form_json=true")
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	var buf bytes.Buffer
+	wr := multipart.NewWriter(&buf)
+
+	wr.WriteField("event", "delivered")
+	wr.WriteField("tag", "a")
+	wr.WriteField("tag", "b")
+
+	fw, err := wr.CreateFormFile("attachment", "hello.txt")
+
+	if err != nil {
+		t.Fatalf("Failed to create form file, %v", err)
+	}
+
+	fw.Write([]byte("hello world"))
+	wr.Close()
+
+	req, err := http.NewRequest("POST", "http://localhost:8080/insecure", &buf)
+
+	if err != nil {
+		t.Fatalf("Failed to create new request, %v", err)
+	}
+
+	req.Header.Set("Content-Type", wr.FormDataContentType())
+
+	body, err2 := r.Receive(ctx, req)
+
+	if err2 != nil {
+		t.Fatalf("Failed to receive message, %v", err2)
+	}
+
+	var doc map[string]interface{}
+
+	err = json.Unmarshal(body, &doc)
+
+	if err != nil {
+		t.Fatalf("Failed to unmarshal body, %v", err)
+	}
+
+	if doc["event"] != "delivered" {
+		t.Fatalf("Unexpected event value: %v", doc["event"])
+	}
+
+	tags, ok := doc["tag"].([]interface{})
+
+	if !ok || len(tags) != 2 {
+		t.Fatalf("Unexpected tag value: %v", doc["tag"])
+	}
+
+	attachment, ok := doc["attachment"].(map[string]interface{})
+
+	if !ok || attachment["filename"] != "hello.txt" {
+		t.Fatalf("Unexpected attachment value: %v", doc["attachment"])
+	}
+}
+
+func TestDecodeBodyPassthrough(t *testing.T) {
+
+	ctx := context.Background()
+
+	r, err := NewReceiver(ctx, "insecure://?form_field=payload")
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	expected := []byte(`{"hello":"world"}`)
+
+	req, err := http.NewRequest("POST", "http://localhost:8080/insecure", bytes.NewReader(expected))
+
+	if err != nil {
+		t.Fatalf("Failed to create new request, %v", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	body, err2 := r.Receive(ctx, req)
+
+	if err2 != nil {
+		t.Fatalf("Failed to receive message, %v", err2)
+	}
+
+	if !bytes.Equal(body, expected) {
+		t.Fatalf("Unexpected output '%s'", string(body))
+	}
+}
+
+func TestReadBodyGzip(t *testing.T) {
+
+	ctx := context.Background()
+
+	r, err := NewReceiver(ctx, "insecure://")
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	expected := []byte("hello world")
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write(expected)
+	gz.Close()
+
+	req, err := http.NewRequest("POST", "http://localhost:8080/insecure", &buf)
+
+	if err != nil {
+		t.Fatalf("Failed to create new request, %v", err)
+	}
+
+	req.Header.Set("Content-Encoding", "gzip")
+
+	body, err2 := r.Receive(ctx, req)
+
+	if err2 != nil {
+		t.Fatalf("Failed to receive message, %v", err2)
+	}
+
+	if !bytes.Equal(body, expected) {
+		t.Fatalf("Unexpected output '%s'", string(body))
+	}
+}
+
+func TestReadBodyDeflate(t *testing.T) {
+
+	ctx := context.Background()
+
+	expected := []byte("hello world")
+
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	zw.Write(expected)
+	zw.Close()
+
+	compressed := buf.Bytes()
+
+	req, err := http.NewRequest("POST", "http://localhost:8080/insecure", bytes.NewReader(compressed))
+
+	if err != nil {
+		t.Fatalf("Failed to create new request, %v", err)
+	}
+
+	req.Header.Set("Content-Encoding", "deflate")
+
+	opts, err := NewBodyOptionsFromQuery(url.Values{"verify_raw": []string{"true"}})
+
+	if err != nil {
+		t.Fatalf("Failed to create body options, %v", err)
+	}
+
+	raw, body, err2 := ReadBody(ctx, req, opts)
+
+	if err2 != nil {
+		t.Fatalf("Failed to read body, %v", err2)
+	}
+
+	if !bytes.Equal(body, expected) {
+		t.Fatalf("Unexpected output '%s'", string(body))
+	}
+
+	if !bytes.Equal(VerifiableBody(raw, body, opts), compressed) {
+		t.Fatalf("Expected verifiable body to be raw bytes")
+	}
+}
+
+func TestReadBodyMaxDecompressedBytes(t *testing.T) {
+
+	ctx := context.Background()
+
+	r, err := NewReceiver(ctx, "insecure://?max_decompressed_bytes=4")
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write([]byte("hello world"))
+	gz.Close()
+
+	req, err := http.NewRequest("POST", "http://localhost:8080/insecure", &buf)
+
+	if err != nil {
+		t.Fatalf("Failed to create new request, %v", err)
+	}
+
+	req.Header.Set("Content-Encoding", "gzip")
+
+	_, err2 := r.Receive(ctx, req)
+
+	if err2 == nil {
+		t.Fatalf("Expected oversized body to fail")
+	}
+
+	if err2.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("Unexpected error code: %d", err2.Code)
+	}
+}
@@ -0,0 +1,203 @@
+package receiver
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+// BUILDKITE_TOKEN_HEADER is the HTTP header containing the token for a Buildkite webhook message.
+const BUILDKITE_TOKEN_HEADER string = "X-Buildkite-Token"
+
+// BUILDKITE_SIGNATURE_HEADER is the HTTP header containing the signature for a Buildkite webhook message.
+const BUILDKITE_SIGNATURE_HEADER string = "X-Buildkite-Signature"
+
+// BUILDKITE_EVENT_HEADER is the HTTP header containing the event type for a Buildkite webhook message.
+const BUILDKITE_EVENT_HEADER string = "X-Buildkite-Event"
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterReceiver(ctx, "buildkite", NewBuildkiteReceiver)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// BuildkiteReceiver implements the `webhookd.WebhookReceiver` interface for receiving Buildkite webhook messages. Messages
+// are validated using either a plain token or an HMAC signature, depending on how the webhook was configured in Buildkite.
+type BuildkiteReceiver struct {
+	webhookd.WebhookReceiver
+	// tokens is the list of valid tokens that messages must include in the `X-Buildkite-Token` header.
+	tokens []string
+	// secrets is the list of secrets used to validate message signatures in the `X-Buildkite-Signature` header.
+	secrets []string
+	// freshness is the `FreshnessOptions` instance used to reject messages with stale timestamps.
+	freshness *FreshnessOptions
+	// body_options is the `BodyOptions` instance used to decode message bodies.
+	body_options *BodyOptions
+}
+
+// NewBuildkiteReceiver returns a new `BuildkiteReceiver` instance configured by 'uri' in the form of:
+//
+//	buildkite://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `token={STRING}` One or more (comma-separated) tokens that messages may include in the `X-Buildkite-Token` header.
+// * `secret={STRING}` One or more (comma-separated) secrets used to validate message signatures in the `X-Buildkite-Signature` header.
+// * `max_age={DURATION}` The maximum age of a signed message, derived from its timestamp. A value of "0s" disables this check. Default is "5m".
+// * `max_skew={DURATION}` The maximum amount of time that the timestamp of a message may be ahead of the current time. A value of "0s" disables this check. Default is the value of `max_age`.
+// * Any of the parameters supported by `NewBodyOptionsFromQuery`.
+//
+// Exactly one of the `token` or `secret` parameters must be present.
+func NewBuildkiteReceiver(ctx context.Context, uri string) (webhookd.WebhookReceiver, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	tokens := parseSecrets(q, "token")
+	secrets := parseSecrets(q, "secret")
+
+	if len(tokens) == 0 && len(secrets) == 0 {
+		return nil, fmt.Errorf("Missing ?token= or ?secret= parameter")
+	}
+
+	if len(tokens) > 0 && len(secrets) > 0 {
+		return nil, fmt.Errorf("?token= and ?secret= parameters are mutually exclusive")
+	}
+
+	freshness, err := NewFreshnessOptionsFromQuery(q)
+
+	if err != nil {
+		return nil, err
+	}
+
+	body_opts, err := NewBodyOptionsFromQuery(q)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive body options, %w", err)
+	}
+
+	wh := BuildkiteReceiver{
+		tokens:       tokens,
+		secrets:      secrets,
+		freshness:    freshness,
+		body_options: body_opts,
+	}
+
+	return wh, nil
+}
+
+// Receive returns the body of the Buildkite message in 'req' after validating its `X-Buildkite-Token` or `X-Buildkite-Signature` header.
+func (wh BuildkiteReceiver) Receive(ctx context.Context, req *http.Request) ([]byte, *webhookd.WebhookError) {
+
+	select {
+	case <-ctx.Done():
+		return nil, nil
+	default:
+		// pass
+	}
+
+	if len(wh.tokens) > 0 {
+
+		token := req.Header.Get(BUILDKITE_TOKEN_HEADER)
+
+		if token == "" {
+			code := http.StatusBadRequest
+			message := fmt.Sprintf("Missing %s header", BUILDKITE_TOKEN_HEADER)
+			return nil, &webhookd.WebhookError{Code: code, Message: message}
+		}
+
+		if !constantTimeEqualAny(token, wh.tokens) {
+			code := http.StatusForbidden
+			message := "Invalid token"
+			return nil, &webhookd.WebhookError{Code: code, Message: message}
+		}
+
+		_, body, err := ReadBody(ctx, req, wh.body_options)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return DecodeBody(ctx, req, body, wh.body_options)
+	}
+
+	header := req.Header.Get(BUILDKITE_SIGNATURE_HEADER)
+
+	if header == "" {
+		code := http.StatusBadRequest
+		message := fmt.Sprintf("Missing %s header", BUILDKITE_SIGNATURE_HEADER)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	// The header is in the form of "timestamp={TIMESTAMP},signature={SIGNATURE}"
+
+	var str_ts string
+	var sig string
+
+	for _, pair := range strings.Split(header, ",") {
+
+		parts := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+
+		if len(parts) != 2 {
+			continue
+		}
+
+		switch parts[0] {
+		case "timestamp":
+			str_ts = parts[1]
+		case "signature":
+			sig = parts[1]
+		}
+	}
+
+	if sig == "" {
+		code := http.StatusBadRequest
+		message := fmt.Sprintf("Invalid %s header", BUILDKITE_SIGNATURE_HEADER)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	err := verifyTimestamp(str_ts, wh.freshness)
+
+	if err != nil {
+		return nil, err
+	}
+
+	raw, body, err := ReadBody(ctx, req, wh.body_options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	verify := func(secret string) bool {
+
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(str_ts + "."))
+		mac.Write(VerifiableBody(raw, body, wh.body_options))
+
+		expected := hex.EncodeToString(mac.Sum(nil))
+		return hmac.Equal([]byte(expected), []byte(sig))
+	}
+
+	if !anySecret(wh.secrets, verify) {
+		code := http.StatusForbidden
+		message := "Invalid signature"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	return DecodeBody(ctx, req, body, wh.body_options)
+}
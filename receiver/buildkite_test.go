@@ -0,0 +1,124 @@
+package receiver
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestBuildkiteReceiverToken(t *testing.T) {
+
+	ctx := context.Background()
+
+	r, err := NewReceiver(ctx, "buildkite://?token=s33kret")
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	body := []byte(`{"event":"build.finished","build":{"state":"passed"}}`)
+
+	tests := map[string]int{
+		"s33kret": 0,
+		"wr0ng":   http.StatusForbidden,
+		"":        http.StatusBadRequest,
+	}
+
+	for token, expected := range tests {
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/buildkite", bytes.NewReader(body))
+
+		if err != nil {
+			t.Fatalf("Failed to create new request, %v", err)
+		}
+
+		if token != "" {
+			req.Header.Set(BUILDKITE_TOKEN_HEADER, token)
+		}
+
+		rsp, err2 := r.Receive(ctx, req)
+
+		if expected == 0 {
+
+			if err2 != nil {
+				t.Fatalf("Failed to receive message, %v", err2)
+			}
+
+			if !bytes.Equal(rsp, body) {
+				t.Fatalf("Unexpected output '%s'", string(rsp))
+			}
+
+			continue
+		}
+
+		if err2 == nil || err2.Code != expected {
+			t.Fatalf("Expected %d for '%s' but got %v", expected, token, err2)
+		}
+	}
+}
+
+func TestBuildkiteReceiverSignature(t *testing.T) {
+
+	ctx := context.Background()
+
+	r, err := NewReceiver(ctx, "buildkite://?secret=s33kret")
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	body := []byte(`{"event":"build.finished","build":{"state":"passed"}}`)
+
+	sign := func(ts int64) string {
+		str_ts := strconv.FormatInt(ts, 10)
+		mac := hmac.New(sha256.New, []byte("s33kret"))
+		mac.Write([]byte(str_ts + "." + string(body)))
+		return fmt.Sprintf("timestamp=%s,signature=%s", str_ts, hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	now := time.Now().Unix()
+
+	tests := map[string]int{
+		sign(now):        0,
+		sign(now - 3600): http.StatusForbidden,
+		fmt.Sprintf("timestamp=%d,signature=%s", now, "00"): http.StatusForbidden,
+		"signature=00": http.StatusBadRequest,
+	}
+
+	for header, expected := range tests {
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/buildkite", bytes.NewReader(body))
+
+		if err != nil {
+			t.Fatalf("Failed to create new request, %v", err)
+		}
+
+		req.Header.Set(BUILDKITE_SIGNATURE_HEADER, header)
+
+		rsp, err2 := r.Receive(ctx, req)
+
+		if expected == 0 {
+
+			if err2 != nil {
+				t.Fatalf("Failed to receive message, %v", err2)
+			}
+
+			if !bytes.Equal(rsp, body) {
+				t.Fatalf("Unexpected output '%s'", string(rsp))
+			}
+
+			continue
+		}
+
+		if err2 == nil || err2.Code != expected {
+			t.Fatalf("Expected %d for '%s' but got %v", expected, header, err2)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package receiver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+// CHARGEBEE_EVENT_HEADER is the HTTP header, set by `ChargebeeReceiver`, containing the event type of a Chargebee webhook message.
+const CHARGEBEE_EVENT_HEADER string = "X-Chargebee-Event"
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterReceiver(ctx, "chargebee", NewChargebeeReceiver)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// ChargebeeReceiver implements the `webhookd.WebhookReceiver` interface for receiving Chargebee webhook messages.
+type ChargebeeReceiver struct {
+	webhookd.WebhookReceiver
+	// username is the basic authentication username that requests must include.
+	username string
+	// password is the basic authentication password that requests must include.
+	password string
+	// body_options is the `BodyOptions` instance used to decode message bodies.
+	body_options *BodyOptions
+}
+
+// NewChargebeeReceiver returns a new `ChargebeeReceiver` instance configured by 'uri' in the form of:
+//
+//	chargebee://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `username={STRING}` The basic authentication username, as configured for the webhook, that requests must include. Required.
+// * `password={STRING}` The basic authentication password, as configured for the webhook, that requests must include. Required.
+// * Any of the parameters supported by `NewBodyOptionsFromQuery`.
+//
+// Chargebee does not sign webhook messages; basic authentication is the verification mechanism it recommends.
+func NewChargebeeReceiver(ctx context.Context, uri string) (webhookd.WebhookReceiver, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	username := q.Get("username")
+
+	if username == "" {
+		return nil, fmt.Errorf("Missing ?username= parameter")
+	}
+
+	password := q.Get("password")
+
+	if password == "" {
+		return nil, fmt.Errorf("Missing ?password= parameter")
+	}
+
+	body_opts, err := NewBodyOptionsFromQuery(q)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive body options, %w", err)
+	}
+
+	wh := ChargebeeReceiver{
+		username:     username,
+		password:     password,
+		body_options: body_opts,
+	}
+
+	return wh, nil
+}
+
+// Receive returns the body of the Chargebee message in 'req' after checking its basic authentication credentials. The
+// `X-Chargebee-Event` header of 'req' is set to the message's `event_type` property (for example "subscription_created").
+func (wh ChargebeeReceiver) Receive(ctx context.Context, req *http.Request) ([]byte, *webhookd.WebhookError) {
+
+	select {
+	case <-ctx.Done():
+		return nil, nil
+	default:
+		// pass
+	}
+
+	username, password, ok := req.BasicAuth()
+
+	username_ok := constantTimeEqual(username, wh.username)
+	password_ok := constantTimeEqual(password, wh.password)
+
+	if !ok || !username_ok || !password_ok {
+		code := http.StatusUnauthorized
+		message := "Unauthorized"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	_, body, err := ReadBody(ctx, req, wh.body_options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var ev struct {
+		EventType string `json:"event_type"`
+	}
+
+	if json.Unmarshal(body, &ev) == nil && ev.EventType != "" {
+		req.Header.Set(CHARGEBEE_EVENT_HEADER, ev.EventType)
+	}
+
+	return DecodeBody(ctx, req, body, wh.body_options)
+}
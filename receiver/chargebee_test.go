@@ -0,0 +1,68 @@
+package receiver
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestChargebeeReceiver(t *testing.T) {
+
+	ctx := context.Background()
+
+	r, err := NewReceiver(ctx, "chargebee://?username=webhookd&password=s33kret")
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	body := []byte(`{"id":"ev_1","event_type":"subscription_created","content":{"subscription":{"id":"sub_1"}}}`)
+
+	tests := []struct {
+		username string
+		password string
+		body     []byte
+		expected int
+	}{
+		{"webhookd", "s33kret", body, 0},
+		{"webhookd", "wr0ng", body, http.StatusUnauthorized},
+		{"", "", body, http.StatusUnauthorized},
+	}
+
+	for _, test := range tests {
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/chargebee", bytes.NewReader(test.body))
+
+		if err != nil {
+			t.Fatalf("Failed to create new request, %v", err)
+		}
+
+		if test.username != "" {
+			req.SetBasicAuth(test.username, test.password)
+		}
+
+		rsp, err2 := r.Receive(ctx, req)
+
+		if test.expected == 0 {
+
+			if err2 != nil {
+				t.Fatalf("Failed to receive message, %v", err2)
+			}
+
+			if !bytes.Equal(rsp, test.body) {
+				t.Fatalf("Unexpected output '%s'", string(rsp))
+			}
+
+			if req.Header.Get(CHARGEBEE_EVENT_HEADER) != "subscription_created" {
+				t.Fatalf("Unexpected %s header '%s'", CHARGEBEE_EVENT_HEADER, req.Header.Get(CHARGEBEE_EVENT_HEADER))
+			}
+
+			continue
+		}
+
+		if err2 == nil || err2.Code != test.expected {
+			t.Fatalf("Expected %d for %s:%s but got %v", test.expected, test.username, test.password, err2)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package receiver
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+// CIRCLECI_SIGNATURE_HEADER is the HTTP header containing the signature for a CircleCI webhook message.
+const CIRCLECI_SIGNATURE_HEADER string = "Circleci-Signature"
+
+// CIRCLECI_EVENT_HEADER is the HTTP header containing the event type for a CircleCI webhook message.
+const CIRCLECI_EVENT_HEADER string = "Circleci-Event-Type"
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterReceiver(ctx, "circleci", NewCircleCIReceiver)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// CircleCIReceiver implements the `webhookd.WebhookReceiver` interface for receiving CircleCI webhook messages.
+type CircleCIReceiver struct {
+	webhookd.WebhookReceiver
+	// secrets is the list of webhook secrets used to validate message signatures.
+	secrets []string
+	// body_options is the `BodyOptions` instance used to decode message bodies.
+	body_options *BodyOptions
+}
+
+// NewCircleCIReceiver returns a new `CircleCIReceiver` instance configured by 'uri' in the form of:
+//
+//	circleci://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `secret={STRING}` One or more (comma-separated) webhook secrets used to validate message signatures. Required.
+// * Any of the parameters supported by `NewBodyOptionsFromQuery`.
+func NewCircleCIReceiver(ctx context.Context, uri string) (webhookd.WebhookReceiver, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	secrets := parseSecrets(q, "secret")
+
+	if len(secrets) == 0 {
+		return nil, fmt.Errorf("Missing ?secret= parameter")
+	}
+
+	body_opts, err := NewBodyOptionsFromQuery(q)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive body options, %w", err)
+	}
+
+	wh := CircleCIReceiver{
+		secrets:      secrets,
+		body_options: body_opts,
+	}
+
+	return wh, nil
+}
+
+// Receive returns the body of the CircleCI message in 'req' after validating its `circleci-signature` header.
+func (wh CircleCIReceiver) Receive(ctx context.Context, req *http.Request) ([]byte, *webhookd.WebhookError) {
+
+	select {
+	case <-ctx.Done():
+		return nil, nil
+	default:
+		// pass
+	}
+
+	header := req.Header.Get(CIRCLECI_SIGNATURE_HEADER)
+
+	if header == "" {
+		code := http.StatusBadRequest
+		message := fmt.Sprintf("Missing %s header", CIRCLECI_SIGNATURE_HEADER)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	// The header is a comma-separated list of {VERSION}={SIGNATURE} pairs; only "v1" signatures are currently defined
+
+	signatures := make([]string, 0)
+
+	for _, pair := range strings.Split(header, ",") {
+
+		parts := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+
+		if len(parts) == 2 && parts[0] == "v1" {
+			signatures = append(signatures, parts[1])
+		}
+	}
+
+	if len(signatures) == 0 {
+		code := http.StatusBadRequest
+		message := fmt.Sprintf("Invalid %s header", CIRCLECI_SIGNATURE_HEADER)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	raw, body, err := ReadBody(ctx, req, wh.body_options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	verify := func(secret string) bool {
+
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(VerifiableBody(raw, body, wh.body_options))
+
+		expected := hex.EncodeToString(mac.Sum(nil))
+
+		for _, sig := range signatures {
+
+			if hmac.Equal([]byte(expected), []byte(sig)) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	ok := anySecret(wh.secrets, verify)
+
+	if !ok {
+		code := http.StatusForbidden
+		message := "Invalid signature"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	return DecodeBody(ctx, req, body, wh.body_options)
+}
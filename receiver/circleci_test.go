@@ -0,0 +1,69 @@
+package receiver
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"testing"
+)
+
+func TestCircleCIReceiver(t *testing.T) {
+
+	ctx := context.Background()
+
+	r, err := NewReceiver(ctx, "circleci://?secret=s33kret")
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	body := []byte(`{"type":"workflow-completed","workflow":{"status":"success"}}`)
+
+	mac := hmac.New(sha256.New, []byte("s33kret"))
+	mac.Write(body)
+
+	sig := hex.EncodeToString(mac.Sum(nil))
+
+	tests := map[string]int{
+		"v1=" + sig:           0,
+		"v2=abc,v1=" + sig:    0,
+		"v1=" + sig[1:] + "0": http.StatusForbidden,
+		"v2=" + sig:           http.StatusBadRequest,
+		"":                    http.StatusBadRequest,
+	}
+
+	for header, expected := range tests {
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/circleci", bytes.NewReader(body))
+
+		if err != nil {
+			t.Fatalf("Failed to create new request, %v", err)
+		}
+
+		if header != "" {
+			req.Header.Set(CIRCLECI_SIGNATURE_HEADER, header)
+		}
+
+		rsp, err2 := r.Receive(ctx, req)
+
+		if expected == 0 {
+
+			if err2 != nil {
+				t.Fatalf("Failed to receive message with '%s', %v", header, err2)
+			}
+
+			if !bytes.Equal(rsp, body) {
+				t.Fatalf("Unexpected output '%s'", string(rsp))
+			}
+
+			continue
+		}
+
+		if err2 == nil || err2.Code != expected {
+			t.Fatalf("Expected %d for '%s' but got %v", expected, header, err2)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package receiver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+// CLOUDFLARE_SECRET_HEADER is the HTTP header containing the secret for a Cloudflare notification webhook message.
+const CLOUDFLARE_SECRET_HEADER string = "Cf-Webhook-Auth"
+
+// CLOUDFLARE_EVENT_HEADER is the HTTP header, set by `CloudflareReceiver`, containing the alert type of a Cloudflare notification.
+const CLOUDFLARE_EVENT_HEADER string = "X-Cloudflare-Event"
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterReceiver(ctx, "cloudflare", NewCloudflareReceiver)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// CloudflareReceiver implements the `webhookd.WebhookReceiver` interface for receiving Cloudflare notification webhook messages.
+type CloudflareReceiver struct {
+	webhookd.WebhookReceiver
+	// secrets is the list of valid values for the `cf-webhook-auth` header that requests must include.
+	secrets []string
+	// body_options is the `BodyOptions` instance used to decode message bodies.
+	body_options *BodyOptions
+}
+
+// NewCloudflareReceiver returns a new `CloudflareReceiver` instance configured by 'uri' in the form of:
+//
+//	cloudflare://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `secret={STRING}` One or more (comma-separated) secrets, defined when the webhook destination was created, that requests must include in the `cf-webhook-auth` header. Required.
+// * Any of the parameters supported by `NewBodyOptionsFromQuery`.
+func NewCloudflareReceiver(ctx context.Context, uri string) (webhookd.WebhookReceiver, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	secrets := parseSecrets(q, "secret")
+
+	if len(secrets) == 0 {
+		return nil, fmt.Errorf("Missing ?secret= parameter")
+	}
+
+	body_opts, err := NewBodyOptionsFromQuery(q)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive body options, %w", err)
+	}
+
+	wh := CloudflareReceiver{
+		secrets:      secrets,
+		body_options: body_opts,
+	}
+
+	return wh, nil
+}
+
+// Receive returns the body of the Cloudflare message in 'req' after checking its `cf-webhook-auth` header. If the message has an
+// `alert_type` property (for example "universal_ssl_event_type" or "real_origin_monitoring") the `X-Cloudflare-Event` header of
+// 'req' is set to its value.
+func (wh CloudflareReceiver) Receive(ctx context.Context, req *http.Request) ([]byte, *webhookd.WebhookError) {
+
+	select {
+	case <-ctx.Done():
+		return nil, nil
+	default:
+		// pass
+	}
+
+	secret := req.Header.Get(CLOUDFLARE_SECRET_HEADER)
+
+	if secret == "" {
+		code := http.StatusBadRequest
+		message := fmt.Sprintf("Missing %s header", CLOUDFLARE_SECRET_HEADER)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	if !constantTimeEqualAny(secret, wh.secrets) {
+		code := http.StatusUnauthorized
+		message := "Unauthorized"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	_, body, err := ReadBody(ctx, req, wh.body_options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var ev struct {
+		AlertType string `json:"alert_type"`
+	}
+
+	if json.Unmarshal(body, &ev) == nil && ev.AlertType != "" {
+		req.Header.Set(CLOUDFLARE_EVENT_HEADER, ev.AlertType)
+	}
+
+	return DecodeBody(ctx, req, body, wh.body_options)
+}
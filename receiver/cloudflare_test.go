@@ -0,0 +1,63 @@
+package receiver
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestCloudflareReceiver(t *testing.T) {
+
+	ctx := context.Background()
+
+	r, err := NewReceiver(ctx, "cloudflare://?secret=s33kret")
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	body := []byte(`{"name":"Origin monitoring","text":"Origin is unreachable","alert_type":"real_origin_monitoring"}`)
+
+	tests := map[string]int{
+		"s33kret": 0,
+		"wr0ng":   http.StatusUnauthorized,
+		"":        http.StatusBadRequest,
+	}
+
+	for secret, expected := range tests {
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/cloudflare", bytes.NewReader(body))
+
+		if err != nil {
+			t.Fatalf("Failed to create new request, %v", err)
+		}
+
+		if secret != "" {
+			req.Header.Set(CLOUDFLARE_SECRET_HEADER, secret)
+		}
+
+		rsp, err2 := r.Receive(ctx, req)
+
+		if expected == 0 {
+
+			if err2 != nil {
+				t.Fatalf("Failed to receive message, %v", err2)
+			}
+
+			if !bytes.Equal(rsp, body) {
+				t.Fatalf("Unexpected output '%s'", string(rsp))
+			}
+
+			if req.Header.Get(CLOUDFLARE_EVENT_HEADER) != "real_origin_monitoring" {
+				t.Fatalf("Unexpected %s header '%s'", CLOUDFLARE_EVENT_HEADER, req.Header.Get(CLOUDFLARE_EVENT_HEADER))
+			}
+
+			continue
+		}
+
+		if err2 == nil || err2.Code != expected {
+			t.Fatalf("Expected %d for '%s' but got %v", expected, secret, err2)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package receiver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterReceiver(ctx, "dockerhub", NewDockerHubReceiver)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// dockerHubNotification is the subset of a Docker Hub webhook notification used by `DockerHubReceiver`.
+type dockerHubNotification struct {
+	PushData *struct {
+		Tag string `json:"tag"`
+	} `json:"push_data"`
+	Repository *struct {
+		RepoName string `json:"repo_name"`
+	} `json:"repository"`
+}
+
+// DockerHubReceiver implements the `webhookd.WebhookReceiver` interface for receiving Docker Hub webhook notifications.
+type DockerHubReceiver struct {
+	webhookd.WebhookReceiver
+	// options is the `registryOptions` instance used to authenticate and decode notifications.
+	options *registryOptions
+	// body_options is the `BodyOptions` instance used to decode message bodies.
+	body_options *BodyOptions
+}
+
+// NewDockerHubReceiver returns a new `DockerHubReceiver` instance configured by 'uri' in the form of:
+//
+//	dockerhub://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `token={STRING}` One or more (comma-separated) valid tokens that requests must include. Required.
+// * `normalize={BOOLEAN}` Return notifications as a JSON-encoded list of `RegistryEvent` instances. Default is false.
+// * Any of the parameters supported by `NewBodyOptionsFromQuery`.
+//
+// Docker Hub does not sign its notifications, or allow custom headers to be configured, so the token must be included in the
+// webhook URL configured in Docker Hub, for example "https://example.com/dockerhub?token={TOKEN}". Requests from other clients
+// may also include it in an "X-Webhookd-Token" or "Authorization: Bearer {TOKEN}" header.
+func NewDockerHubReceiver(ctx context.Context, uri string) (webhookd.WebhookReceiver, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	if len(parseSecrets(q, "token")) == 0 {
+		return nil, fmt.Errorf("Missing ?token= parameter")
+	}
+
+	opts, err := newRegistryOptions(q)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Docker Hub can not be configured to send custom headers
+	opts.authorizations = nil
+
+	body_opts, err := NewBodyOptionsFromQuery(q)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive body options, %w", err)
+	}
+
+	wh := DockerHubReceiver{
+		options:      opts,
+		body_options: body_opts,
+	}
+
+	return wh, nil
+}
+
+// Receive returns the body of the Docker Hub notification in 'req' after checking that it was sent with the expected token.
+func (wh DockerHubReceiver) Receive(ctx context.Context, req *http.Request) ([]byte, *webhookd.WebhookError) {
+
+	select {
+	case <-ctx.Done():
+		return nil, nil
+	default:
+		// pass
+	}
+
+	err := wh.options.authenticate(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	_, body, err := ReadBody(ctx, req, wh.body_options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var n dockerHubNotification
+
+	decode_err := json.Unmarshal(body, &n)
+
+	if decode_err != nil || n.PushData == nil || n.Repository == nil {
+		code := http.StatusBadRequest
+		message := "Unrecognized Docker Hub notification"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	if !wh.options.normalize {
+		return body, nil
+	}
+
+	events := []*RegistryEvent{
+		{
+			Action:     "push",
+			Repository: n.Repository.RepoName,
+			Tag:        n.PushData.Tag,
+			Source:     REGISTRY_SOURCE_DOCKERHUB,
+		},
+	}
+
+	return encodeRegistryEvents(events)
+}
@@ -0,0 +1,103 @@
+package receiver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+const testDockerHubNotification string = `{"callback_url":"https://registry.hub.docker.com/u/example/app/hook/1/","push_data":{"pushed_at":1417566161,"pusher":"example","tag":"latest"},"repository":{"name":"app","namespace":"example","repo_name":"example/app"}}`
+
+func TestDockerHubReceiver(t *testing.T) {
+
+	ctx := context.Background()
+
+	r, err := NewReceiver(ctx, "dockerhub://?token=s33kret&normalize=true")
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	req, err := http.NewRequest("POST", "http://localhost:8080/dockerhub?token=s33kret", bytes.NewReader([]byte(testDockerHubNotification)))
+
+	if err != nil {
+		t.Fatalf("Failed to create new request, %v", err)
+	}
+
+	body, err2 := r.Receive(ctx, req)
+
+	if err2 != nil {
+		t.Fatalf("Failed to receive message, %v", err2)
+	}
+
+	var events []*RegistryEvent
+
+	err = json.Unmarshal(body, &events)
+
+	if err != nil {
+		t.Fatalf("Failed to decode events, %v", err)
+	}
+
+	if len(events) != 1 || events[0].Repository != "example/app" || events[0].Tag != "latest" || events[0].Source != REGISTRY_SOURCE_DOCKERHUB {
+		t.Fatalf("Unexpected output '%s'", string(body))
+	}
+}
+
+func TestDockerHubReceiverErrors(t *testing.T) {
+
+	ctx := context.Background()
+
+	_, err := NewReceiver(ctx, "dockerhub://")
+
+	if err == nil {
+		t.Fatalf("Expected receiver without token to fail")
+	}
+
+	r, err := NewReceiver(ctx, "dockerhub://?token=s33kret")
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	tests := map[string]int{
+		"http://localhost:8080/dockerhub?token=s33kret": http.StatusBadRequest,
+		"http://localhost:8080/dockerhub?token=wrong":   http.StatusUnauthorized,
+		"http://localhost:8080/dockerhub":               http.StatusUnauthorized,
+	}
+
+	for uri, expected := range tests {
+
+		req, err := http.NewRequest("POST", uri, bytes.NewReader([]byte(`{"hello":"world"}`)))
+
+		if err != nil {
+			t.Fatalf("Failed to create new request, %v", err)
+		}
+
+		_, err2 := r.Receive(ctx, req)
+
+		if err2 == nil || err2.Code != expected {
+			t.Fatalf("Expected %d for %s but got %v", expected, uri, err2)
+		}
+	}
+
+	// Tokens may also be sent in a header, in which case the (invalid) message is authenticated and then rejected
+
+	for _, h := range [][2]string{{TOKEN_HEADER, "s33kret"}, {"Authorization", "Bearer s33kret"}} {
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/dockerhub", bytes.NewReader([]byte(`{"hello":"world"}`)))
+
+		if err != nil {
+			t.Fatalf("Failed to create new request, %v", err)
+		}
+
+		req.Header.Set(h[0], h[1])
+
+		_, err2 := r.Receive(ctx, req)
+
+		if err2 == nil || err2.Code != http.StatusBadRequest {
+			t.Fatalf("Expected %d for %s header but got %v", http.StatusBadRequest, h[0], err2)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package receiver
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+// DOCUSIGN_SIGNATURE_HEADER_PREFIX is the prefix for the (numbered) HTTP headers containing the signatures for a DocuSign Connect message.
+const DOCUSIGN_SIGNATURE_HEADER_PREFIX string = "X-Docusign-Signature-"
+
+// DOCUSIGN_EVENT_HEADER is the HTTP header that `DocuSignReceiver` assigns the normalized event type of a message to.
+const DOCUSIGN_EVENT_HEADER string = "X-Docusign-Event"
+
+// maxDocuSignSignatures is the maximum number of signature headers, one for each Connect HMAC key, that DocuSign will send.
+const maxDocuSignSignatures int = 100
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterReceiver(ctx, "docusign", NewDocuSignReceiver)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// docuSignJSONEvent is the subset of a JSON-encoded DocuSign Connect message used by `DocuSignReceiver`.
+type docuSignJSONEvent struct {
+	Event string `json:"event"`
+}
+
+// docuSignXMLEvent is the subset of an XML-encoded (legacy) DocuSign Connect message used by `DocuSignReceiver`.
+type docuSignXMLEvent struct {
+	XMLName        xml.Name `xml:"DocuSignEnvelopeInformation"`
+	EnvelopeStatus struct {
+		Status string `xml:"Status"`
+	} `xml:"EnvelopeStatus"`
+}
+
+// DocuSignReceiver implements the `webhookd.WebhookReceiver` interface for receiving DocuSign Connect messages.
+type DocuSignReceiver struct {
+	webhookd.WebhookReceiver
+	// keys is the list of Connect HMAC keys used to validate message signatures.
+	keys []string
+	// body_options is the `BodyOptions` instance used to decode message bodies.
+	body_options *BodyOptions
+}
+
+// NewDocuSignReceiver returns a new `DocuSignReceiver` instance configured by 'uri' in the form of:
+//
+//	docusign://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `key={STRING}` One or more (comma-separated) Connect HMAC keys used to validate message signatures. Required.
+// * Any of the parameters supported by `NewBodyOptionsFromQuery`.
+//
+// Multiple keys may be defined so that keys can be rotated without interrupting delivery. A message is valid if any of its
+// `X-DocuSign-Signature-{N}` headers matches any of the keys.
+func NewDocuSignReceiver(ctx context.Context, uri string) (webhookd.WebhookReceiver, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	keys := parseSecrets(q, "key")
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("Missing ?key= parameter")
+	}
+
+	body_opts, err := NewBodyOptionsFromQuery(q)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive body options, %w", err)
+	}
+
+	wh := DocuSignReceiver{
+		keys:         keys,
+		body_options: body_opts,
+	}
+
+	return wh, nil
+}
+
+// Receive returns the body of the DocuSign Connect message in 'req' after validating its `X-DocuSign-Signature-{N}` headers.
+// Both JSON and (legacy) XML messages are supported and are returned unaltered. The normalized event type of the message, for
+// example "envelope-completed", is assigned to the `X-DocuSign-Event` header of 'req'.
+func (wh DocuSignReceiver) Receive(ctx context.Context, req *http.Request) ([]byte, *webhookd.WebhookError) {
+
+	select {
+	case <-ctx.Done():
+		return nil, nil
+	default:
+		// pass
+	}
+
+	sigs := make([]string, 0)
+
+	for i := 1; i <= maxDocuSignSignatures; i++ {
+
+		v := req.Header.Get(fmt.Sprintf("%s%d", DOCUSIGN_SIGNATURE_HEADER_PREFIX, i))
+
+		if v == "" {
+			break
+		}
+
+		sigs = append(sigs, v)
+	}
+
+	if len(sigs) == 0 {
+		code := http.StatusBadRequest
+		message := fmt.Sprintf("Missing %s1 header", DOCUSIGN_SIGNATURE_HEADER_PREFIX)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	raw, body, err := ReadBody(ctx, req, wh.body_options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !wh.verify(VerifiableBody(raw, body, wh.body_options), sigs) {
+		code := http.StatusForbidden
+		message := "Invalid signature"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	event, err := docuSignEventType(body)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if event != "" {
+		req.Header.Set(DOCUSIGN_EVENT_HEADER, event)
+	}
+
+	return body, nil
+}
+
+// verify returns a boolean value indicating whether any of 'sigs' is a valid signature for 'body' using any of the keys in 'wh'.
+func (wh DocuSignReceiver) verify(body []byte, sigs []string) bool {
+
+	for _, k := range wh.keys {
+
+		mac := hmac.New(sha256.New, []byte(k))
+		mac.Write(body)
+
+		expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+		for _, sig := range sigs {
+
+			if hmac.Equal([]byte(expected), []byte(sig)) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// docuSignEventType returns the normalized event type for the JSON or XML DocuSign Connect message 'body'. XML messages, which
+// don't include an explicit event type, are assigned "envelope-" followed by the lower-cased envelope status.
+func docuSignEventType(body []byte) (string, *webhookd.WebhookError) {
+
+	trimmed := bytes.TrimSpace(body)
+
+	if bytes.HasPrefix(trimmed, []byte("<")) {
+
+		var ev docuSignXMLEvent
+
+		dec := xml.NewDecoder(bytes.NewReader(trimmed))
+
+		dec.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+			return input, nil
+		}
+
+		err := dec.Decode(&ev)
+
+		if err != nil {
+			code := http.StatusBadRequest
+			message := fmt.Sprintf("Failed to parse XML message, %v", err)
+			return "", &webhookd.WebhookError{Code: code, Message: message}
+		}
+
+		if ev.EnvelopeStatus.Status == "" {
+			return "", nil
+		}
+
+		return "envelope-" + strings.ToLower(ev.EnvelopeStatus.Status), nil
+	}
+
+	var ev docuSignJSONEvent
+
+	err := json.Unmarshal(trimmed, &ev)
+
+	if err != nil {
+		code := http.StatusBadRequest
+		message := fmt.Sprintf("Failed to parse JSON message, %v", err)
+		return "", &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	return ev.Event, nil
+}
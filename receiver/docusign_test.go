@@ -0,0 +1,77 @@
+package receiver
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"testing"
+)
+
+func TestDocuSignReceiver(t *testing.T) {
+
+	ctx := context.Background()
+
+	r, err := NewReceiver(ctx, "docusign://?key=0ld&key=n3w")
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	sign := func(key string, body []byte) string {
+		mac := hmac.New(sha256.New, []byte(key))
+		mac.Write(body)
+		return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	}
+
+	tests := map[string]string{
+		`{"event":"envelope-completed","apiVersion":"v2.1","data":{"envelopeId":"abc"}}`:                                                                                                      "envelope-completed",
+		`<?xml version="1.0" encoding="utf-8"?><DocuSignEnvelopeInformation><EnvelopeStatus><EnvelopeID>abc</EnvelopeID><Status>Sent</Status></EnvelopeStatus></DocuSignEnvelopeInformation>`: "envelope-sent",
+	}
+
+	for msg, expected := range tests {
+
+		body := []byte(msg)
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/docusign", bytes.NewReader(body))
+
+		if err != nil {
+			t.Fatalf("Failed to create new request, %v", err)
+		}
+
+		// The first signature was computed using a key that has been retired
+
+		req.Header.Set("X-DocuSign-Signature-1", sign("r3tired", body))
+		req.Header.Set("X-DocuSign-Signature-2", sign("n3w", body))
+
+		rsp, err2 := r.Receive(ctx, req)
+
+		if err2 != nil {
+			t.Fatalf("Failed to receive message, %v", err2)
+		}
+
+		if !bytes.Equal(rsp, body) {
+			t.Fatalf("Unexpected output '%s'", string(rsp))
+		}
+
+		if req.Header.Get(DOCUSIGN_EVENT_HEADER) != expected {
+			t.Fatalf("Unexpected event header '%s'", req.Header.Get(DOCUSIGN_EVENT_HEADER))
+		}
+
+		req, err = http.NewRequest("POST", "http://localhost:8080/docusign", bytes.NewReader(body))
+
+		if err != nil {
+			t.Fatalf("Failed to create new request, %v", err)
+		}
+
+		req.Header.Set("X-DocuSign-Signature-1", sign("r3tired", body))
+
+		_, err2 = r.Receive(ctx, req)
+
+		if err2 == nil || err2.Code != http.StatusForbidden {
+			t.Fatalf("Expected invalid signature to fail, %v", err2)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package receiver
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+// FASTLY_CHALLENGE_PATH is the path that Fastly requests to verify ownership of an HTTPS logging endpoint.
+const FASTLY_CHALLENGE_PATH string = "/.well-known/fastly/logging/challenge"
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterReceiver(ctx, "fastly", NewFastlyReceiver)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// FastlyReceiver implements the `webhookd.WebhookReceiver` interface for receiving Fastly alert notifications and HTTPS log streaming messages.
+type FastlyReceiver struct {
+	webhookd.WebhookReceiver
+	// tokens is the list of valid values for the `token` query parameter that requests must include.
+	tokens []string
+	// service_ids is the list of Fastly service IDs allowed to stream logs to the endpoint.
+	service_ids []string
+	// body_options is the `BodyOptions` instance used to decode message bodies.
+	body_options *BodyOptions
+}
+
+// NewFastlyReceiver returns a new `FastlyReceiver` instance configured by 'uri' in the form of:
+//
+//	fastly://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `token={STRING}` One or more (comma-separated) valid tokens that requests must include. Required.
+// * `service_id={STRING}` Zero or more Fastly service IDs allowed to stream logs to the endpoint. If empty any service is allowed.
+// * Any of the parameters supported by `NewBodyOptionsFromQuery`.
+//
+// Fastly does not sign alert notifications or log streaming messages so they are authenticated using a token which requests may
+// include in an "X-Webhookd-Token" header, an "Authorization: Bearer {TOKEN}" header or a `token` query parameter in the URL configured
+// in Fastly, for example "https://example.com/fastly?token={TOKEN}".
+func NewFastlyReceiver(ctx context.Context, uri string) (webhookd.WebhookReceiver, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	tokens := parseSecrets(q, "token")
+
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("Missing ?token= parameter")
+	}
+
+	body_opts, err := NewBodyOptionsFromQuery(q)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive body options, %w", err)
+	}
+
+	wh := FastlyReceiver{
+		tokens:       tokens,
+		service_ids:  q["service_id"],
+		body_options: body_opts,
+	}
+
+	return wh, nil
+}
+
+// Receive returns the body of the Fastly message in 'req' after checking its token. `GET` requests for the
+// HTTPS logging endpoint challenge ("/.well-known/fastly/logging/challenge"), which are not authenticated, are answered using
+// `webhookd.SetChallengeResponse` and return a `webhookd.UnhandledEvent` error.
+func (wh FastlyReceiver) Receive(ctx context.Context, req *http.Request) ([]byte, *webhookd.WebhookError) {
+
+	select {
+	case <-ctx.Done():
+		return nil, nil
+	default:
+		// pass
+	}
+
+	if req.Method == "GET" && strings.HasSuffix(req.URL.Path, FASTLY_CHALLENGE_PATH) {
+		return nil, wh.answerChallenge(ctx)
+	}
+
+	if !constantTimeEqualAny(requestToken(req), wh.tokens) {
+		code := http.StatusUnauthorized
+		message := "Unauthorized"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	_, body, err := ReadBody(ctx, req, wh.body_options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodeBody(ctx, req, body, wh.body_options)
+}
+
+// answerChallenge answers the HTTPS logging endpoint challenge with the SHA-256 hashes of the allowed service IDs, one per
+// line, or "*" if any service is allowed.
+func (wh FastlyReceiver) answerChallenge(ctx context.Context) *webhookd.WebhookError {
+
+	lines := make([]string, 0, len(wh.service_ids))
+
+	for _, id := range wh.service_ids {
+		sum := sha256.Sum256([]byte(id))
+		lines = append(lines, hex.EncodeToString(sum[:]))
+	}
+
+	if len(lines) == 0 {
+		lines = append(lines, "*")
+	}
+
+	enc := []byte(strings.Join(lines, "\n") + "\n")
+
+	if !webhookd.SetChallengeResponse(ctx, "text/plain", enc) {
+		code := http.StatusInternalServerError
+		message := "Context does not support challenge responses"
+		return &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	code := webhookd.UnhandledEvent
+	message := "Answered logging endpoint challenge"
+	return &webhookd.WebhookError{Code: code, Message: message}
+}
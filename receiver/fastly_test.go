@@ -0,0 +1,98 @@
+package receiver
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"testing"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+func TestFastlyReceiver(t *testing.T) {
+
+	ctx := webhookd.WithChallengeResponse(context.Background())
+
+	r, err := NewReceiver(ctx, "fastly://?token=s33kret&service_id=SU1Z0isxPaozGVKXdv0eY")
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	req, err := http.NewRequest("GET", "http://localhost:8080"+FASTLY_CHALLENGE_PATH, nil)
+
+	if err != nil {
+		t.Fatalf("Failed to create new request, %v", err)
+	}
+
+	_, err2 := r.Receive(ctx, req)
+
+	if err2 == nil || err2.Code != webhookd.UnhandledEvent {
+		t.Fatalf("Expected challenge to return an unhandled event, %v", err2)
+	}
+
+	sum := sha256.Sum256([]byte("SU1Z0isxPaozGVKXdv0eY"))
+
+	_, enc, ok := webhookd.ChallengeResponse(ctx)
+
+	if !ok || string(enc) != hex.EncodeToString(sum[:])+"\n" {
+		t.Fatalf("Unexpected challenge response '%s'", string(enc))
+	}
+
+	body := []byte(`{"service_id":"SU1Z0isxPaozGVKXdv0eY","status":"503"}`)
+
+	tests := map[string]int{
+		"http://localhost:8080/fastly?token=s33kret": 0,
+		"http://localhost:8080/fastly?token=wr0ng":   http.StatusUnauthorized,
+		"http://localhost:8080/fastly":               http.StatusUnauthorized,
+	}
+
+	for uri, expected := range tests {
+
+		req, err := http.NewRequest("POST", uri, bytes.NewReader(body))
+
+		if err != nil {
+			t.Fatalf("Failed to create new request, %v", err)
+		}
+
+		rsp, err2 := r.Receive(ctx, req)
+
+		if expected == 0 {
+
+			if err2 != nil {
+				t.Fatalf("Failed to receive message, %v", err2)
+			}
+
+			if !bytes.Equal(rsp, body) {
+				t.Fatalf("Unexpected output '%s'", string(rsp))
+			}
+
+			continue
+		}
+
+		if err2 == nil || err2.Code != expected {
+			t.Fatalf("Expected %d for %s but got %v", expected, uri, err2)
+		}
+	}
+
+	// Tokens may also be sent in a header
+
+	for _, h := range [][2]string{{TOKEN_HEADER, "s33kret"}, {"Authorization", "Bearer s33kret"}} {
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/fastly", bytes.NewReader(body))
+
+		if err != nil {
+			t.Fatalf("Failed to create new request, %v", err)
+		}
+
+		req.Header.Set(h[0], h[1])
+
+		_, err2 := r.Receive(ctx, req)
+
+		if err2 != nil {
+			t.Fatalf("Failed to receive message with %s header, %v", h[0], err2)
+		}
+	}
+}
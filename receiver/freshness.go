@@ -0,0 +1,101 @@
+package receiver
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+// DEFAULT_WEBHOOK_MAX_AGE is the default maximum age of a signed webhook message, derived from its timestamp.
+const DEFAULT_WEBHOOK_MAX_AGE time.Duration = 5 * time.Minute
+
+// staleTimestampMessage is the message of the error returned for webhook messages whose timestamps are outside of the allowed window.
+const staleTimestampMessage string = "Timestamp outside of allowed window"
+
+// FreshnessOptions is a struct containing options for rejecting webhook messages, derived from their timestamps, that are too old or
+// that were sent from a clock that is too far ahead of the current time.
+type FreshnessOptions struct {
+	// MaxAge is the maximum age of a message. If zero or less the age of a message is not checked.
+	MaxAge time.Duration
+	// MaxSkew is the maximum amount of time that the timestamp of a message may be ahead of the current time. If zero or less
+	// timestamps in the future are not checked.
+	MaxSkew time.Duration
+}
+
+// NewFreshnessOptionsFromQuery returns a new `FreshnessOptions` instance derived from 'q'. Valid parameters are:
+// * `max_age={DURATION}` The maximum age of a message, derived from its timestamp. A value of "0s" disables this check. Default is `DEFAULT_WEBHOOK_MAX_AGE`.
+// * `max_skew={DURATION}` The maximum amount of time that the timestamp of a message may be ahead of the current time. A value of "0s" disables this check. Default is the value of `max_age`.
+func NewFreshnessOptionsFromQuery(q url.Values) (*FreshnessOptions, error) {
+
+	opts := &FreshnessOptions{
+		MaxAge: DEFAULT_WEBHOOK_MAX_AGE,
+	}
+
+	str_age := q.Get("max_age")
+
+	if str_age != "" {
+
+		v, err := time.ParseDuration(str_age)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse ?max_age= parameter, %w", err)
+		}
+
+		opts.MaxAge = v
+	}
+
+	opts.MaxSkew = opts.MaxAge
+
+	str_skew := q.Get("max_skew")
+
+	if str_skew != "" {
+
+		v, err := time.ParseDuration(str_skew)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse ?max_skew= parameter, %w", err)
+		}
+
+		opts.MaxSkew = v
+	}
+
+	return opts, nil
+}
+
+// IsStale returns a boolean value indicating whether 'err' was returned by a receiver because the timestamp of a webhook message
+// was outside of the window allowed by its `FreshnessOptions`.
+func IsStale(err *webhookd.WebhookError) bool {
+	return err != nil && err.Code == http.StatusForbidden && err.Message == staleTimestampMessage
+}
+
+// verifyTimestamp returns an error if the Unix timestamp 'str_ts' is outside of the window allowed by 'opts'.
+func verifyTimestamp(str_ts string, opts *FreshnessOptions) *webhookd.WebhookError {
+
+	ts, err := strconv.ParseInt(str_ts, 10, 64)
+
+	if err != nil {
+		code := http.StatusBadRequest
+		message := "Missing or invalid timestamp"
+		return &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	return verifyTime(time.Unix(ts, 0), opts)
+}
+
+// verifyTime returns an error if 't' is more than `opts.MaxAge` before, or more than `opts.MaxSkew` after, the current time.
+func verifyTime(t time.Time, opts *FreshnessOptions) *webhookd.WebhookError {
+
+	d := time.Since(t)
+
+	if (opts.MaxAge > 0 && d > opts.MaxAge) || (opts.MaxSkew > 0 && -d > opts.MaxSkew) {
+		code := http.StatusForbidden
+		message := staleTimestampMessage
+		return &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package receiver
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+func TestNewFreshnessOptionsFromQuery(t *testing.T) {
+
+	tests := map[string][2]time.Duration{
+		"":                         {DEFAULT_WEBHOOK_MAX_AGE, DEFAULT_WEBHOOK_MAX_AGE},
+		"max_age=10m":              {10 * time.Minute, 10 * time.Minute},
+		"max_age=10m&max_skew=30s": {10 * time.Minute, 30 * time.Second},
+		"max_age=0s":               {0, 0},
+		"max_skew=0s":              {DEFAULT_WEBHOOK_MAX_AGE, 0},
+	}
+
+	for str_q, expected := range tests {
+
+		q, _ := url.ParseQuery(str_q)
+
+		opts, err := NewFreshnessOptionsFromQuery(q)
+
+		if err != nil {
+			t.Fatalf("Failed to derive freshness options for '%s', %v", str_q, err)
+		}
+
+		if opts.MaxAge != expected[0] || opts.MaxSkew != expected[1] {
+			t.Fatalf("Unexpected freshness options for '%s': %v", str_q, opts)
+		}
+	}
+
+	for _, str_q := range []string{"max_age=five", "max_skew=1"} {
+
+		q, _ := url.ParseQuery(str_q)
+
+		_, err := NewFreshnessOptionsFromQuery(q)
+
+		if err == nil {
+			t.Fatalf("Expected '%s' to fail", str_q)
+		}
+	}
+}
+
+func TestVerifyTimestamp(t *testing.T) {
+
+	opts := &FreshnessOptions{
+		MaxAge:  5 * time.Minute,
+		MaxSkew: 30 * time.Second,
+	}
+
+	now := time.Now()
+
+	tests := map[string]int{
+		strconv.FormatInt(now.Unix(), 10):                      0,
+		strconv.FormatInt(now.Add(-4*time.Minute).Unix(), 10):  0,
+		strconv.FormatInt(now.Add(10*time.Second).Unix(), 10):  0,
+		strconv.FormatInt(now.Add(-10*time.Minute).Unix(), 10): http.StatusForbidden,
+		strconv.FormatInt(now.Add(2*time.Minute).Unix(), 10):   http.StatusForbidden,
+		"yesterday": http.StatusBadRequest,
+	}
+
+	for ts, expected := range tests {
+
+		err := verifyTimestamp(ts, opts)
+
+		if expected == 0 {
+
+			if err != nil {
+				t.Fatalf("Unexpected error for %s, %v", ts, err)
+			}
+
+			continue
+		}
+
+		if err == nil || err.Code != expected {
+			t.Fatalf("Expected %d for %s but got %v", expected, ts, err)
+		}
+
+		if IsStale(err) != (expected == http.StatusForbidden) {
+			t.Fatalf("Unexpected staleness for %s, %v", ts, err)
+		}
+	}
+
+	err := verifyTime(now.Add(-24*time.Hour), &FreshnessOptions{})
+
+	if err != nil {
+		t.Fatalf("Expected disabled freshness check to pass, %v", err)
+	}
+
+	if IsStale(&webhookd.WebhookError{Code: http.StatusForbidden, Message: "Invalid signature"}) {
+		t.Fatalf("Unexpected staleness for invalid signature")
+	}
+}
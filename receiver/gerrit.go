@@ -0,0 +1,108 @@
+package receiver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+// GERRIT_EVENT_HEADER is the HTTP header, set by `GerritReceiver`, containing the event type of a Gerrit webhook message.
+const GERRIT_EVENT_HEADER string = "X-Gerrit-Event"
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterReceiver(ctx, "gerrit", NewGerritReceiver)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// GerritReceiver implements the `webhookd.WebhookReceiver` interface for receiving messages sent by the Gerrit webhooks plugin.
+type GerritReceiver struct {
+	webhookd.WebhookReceiver
+	// tokens is the list of valid values for the `token` query parameter that requests must include.
+	tokens []string
+	// body_options is the `BodyOptions` instance used to decode message bodies.
+	body_options *BodyOptions
+}
+
+// NewGerritReceiver returns a new `GerritReceiver` instance configured by 'uri' in the form of:
+//
+//	gerrit://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `token={STRING}` One or more (comma-separated) valid tokens that requests must include. Required.
+// * Any of the parameters supported by `NewBodyOptionsFromQuery`.
+//
+// The Gerrit webhooks plugin does not sign messages so they are authenticated using a token which requests may include in an
+// "X-Webhookd-Token" header, an "Authorization: Bearer {TOKEN}" header or a `token` query parameter in the URL configured in Gerrit,
+// for example "https://example.com/gerrit?token={TOKEN}".
+func NewGerritReceiver(ctx context.Context, uri string) (webhookd.WebhookReceiver, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	tokens := parseSecrets(q, "token")
+
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("Missing ?token= parameter")
+	}
+
+	body_opts, err := NewBodyOptionsFromQuery(q)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive body options, %w", err)
+	}
+
+	wh := GerritReceiver{
+		tokens:       tokens,
+		body_options: body_opts,
+	}
+
+	return wh, nil
+}
+
+// Receive returns the body of the Gerrit message in 'req' after checking its token. The `X-Gerrit-Event` header
+// of 'req' is set to the message's `type` property (for example "patchset-created").
+func (wh GerritReceiver) Receive(ctx context.Context, req *http.Request) ([]byte, *webhookd.WebhookError) {
+
+	select {
+	case <-ctx.Done():
+		return nil, nil
+	default:
+		// pass
+	}
+
+	if !constantTimeEqualAny(requestToken(req), wh.tokens) {
+		code := http.StatusUnauthorized
+		message := "Unauthorized"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	_, body, err := ReadBody(ctx, req, wh.body_options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var ev struct {
+		Type string `json:"type"`
+	}
+
+	if json.Unmarshal(body, &ev) == nil && ev.Type != "" {
+		req.Header.Set(GERRIT_EVENT_HEADER, ev.Type)
+	}
+
+	return DecodeBody(ctx, req, body, wh.body_options)
+}
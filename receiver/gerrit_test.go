@@ -0,0 +1,84 @@
+package receiver
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestGerritReceiver(t *testing.T) {
+
+	ctx := context.Background()
+
+	r, err := NewReceiver(ctx, "gerrit://?token=s33kret")
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	body := []byte(`{"type":"patchset-created","change":{"project":"example","number":1234}}`)
+
+	tests := map[string]int{
+		"http://localhost:8080/gerrit?token=s33kret": 0,
+		"http://localhost:8080/gerrit?token=s3kret":  http.StatusUnauthorized,
+		"http://localhost:8080/gerrit":               http.StatusUnauthorized,
+	}
+
+	for uri, expected := range tests {
+
+		req, err := http.NewRequest("POST", uri, bytes.NewReader(body))
+
+		if err != nil {
+			t.Fatalf("Failed to create new request, %v", err)
+		}
+
+		rsp, err2 := r.Receive(ctx, req)
+
+		if expected == 0 {
+
+			if err2 != nil {
+				t.Fatalf("Failed to receive message, %v", err2)
+			}
+
+			if !bytes.Equal(rsp, body) {
+				t.Fatalf("Unexpected output '%s'", string(rsp))
+			}
+
+			if req.Header.Get(GERRIT_EVENT_HEADER) != "patchset-created" {
+				t.Fatalf("Unexpected %s header '%s'", GERRIT_EVENT_HEADER, req.Header.Get(GERRIT_EVENT_HEADER))
+			}
+
+			continue
+		}
+
+		if err2 == nil || err2.Code != expected {
+			t.Fatalf("Expected %d for %s but got %v", expected, uri, err2)
+		}
+	}
+
+	// Tokens may also be sent in a header
+
+	for _, h := range [][2]string{{TOKEN_HEADER, "s33kret"}, {"Authorization", "Bearer s33kret"}} {
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/gerrit", bytes.NewReader(body))
+
+		if err != nil {
+			t.Fatalf("Failed to create new request, %v", err)
+		}
+
+		req.Header.Set(h[0], h[1])
+
+		_, err2 := r.Receive(ctx, req)
+
+		if err2 != nil {
+			t.Fatalf("Failed to receive message with %s header, %v", h[0], err2)
+		}
+	}
+
+	_, err = NewReceiver(ctx, "gerrit://")
+
+	if err == nil {
+		t.Fatalf("Expected receiver without a token to fail")
+	}
+}
@@ -0,0 +1,166 @@
+package receiver
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+	"github.com/whosonfirst/go-webhookd/v3/signature"
+)
+
+// GITEA_SIGNATURE_HEADER is the HTTP header containing the signature for a Gitea webhook message.
+const GITEA_SIGNATURE_HEADER string = "X-Gitea-Signature"
+
+// GOGS_SIGNATURE_HEADER is the HTTP header containing the signature for a Gogs webhook message. Gitea also sends this header for
+// compatibility with Gogs.
+const GOGS_SIGNATURE_HEADER string = "X-Gogs-Signature"
+
+// giteaSignatureHeaders is a dictionary of receiver schemes and the HTTP header containing the signature for their messages.
+var giteaSignatureHeaders = map[string]string{
+	"gitea": GITEA_SIGNATURE_HEADER,
+	"gogs":  GOGS_SIGNATURE_HEADER,
+}
+
+func init() {
+
+	ctx := context.Background()
+
+	for scheme := range giteaSignatureHeaders {
+
+		err := RegisterReceiver(ctx, scheme, NewGiteaReceiver)
+
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+// GiteaReceiver implements the `webhookd.WebhookReceiver` interface for receiving Gitea and Gogs webhook messages.
+type GiteaReceiver struct {
+	webhookd.WebhookReceiver
+	// secrets is the list of webhook secrets used to validate message signatures.
+	secrets []string
+	// signature_header is the HTTP header containing the signature for a message.
+	signature_header string
+	// body_options is the `BodyOptions` instance used to decode message bodies.
+	body_options *BodyOptions
+}
+
+// NewGiteaReceiver returns a new `GiteaReceiver` instance configured by 'uri' in the form of:
+//
+//	gitea://?{PARAMETERS}
+//	gogs://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `secret={STRING}` One or more (comma-separated) webhook secrets used to validate message signatures. Required.
+// * Any of the parameters supported by `NewBodyOptionsFromQuery`.
+//
+// The "gitea" scheme validates the "X-Gitea-Signature" header and the "gogs" scheme validates the "X-Gogs-Signature" header.
+func NewGiteaReceiver(ctx context.Context, uri string) (webhookd.WebhookReceiver, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	signature_header, ok := giteaSignatureHeaders[u.Scheme]
+
+	if !ok {
+		return nil, fmt.Errorf("Unsupported scheme '%s'", u.Scheme)
+	}
+
+	q := u.Query()
+
+	secrets := parseSecrets(q, "secret")
+
+	if len(secrets) == 0 {
+		return nil, fmt.Errorf("Missing ?secret= parameter")
+	}
+
+	body_opts, err := NewBodyOptionsFromQuery(q)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive body options, %w", err)
+	}
+
+	wh := GiteaReceiver{
+		secrets:          secrets,
+		signature_header: signature_header,
+		body_options:     body_opts,
+	}
+
+	return wh, nil
+}
+
+// Receive returns the body of the Gitea or Gogs message in 'req' after validating its hex-encoded HMAC-SHA256 signature header.
+func (wh GiteaReceiver) Receive(ctx context.Context, req *http.Request) ([]byte, *webhookd.WebhookError) {
+
+	select {
+	case <-ctx.Done():
+		return nil, nil
+	default:
+		// pass
+	}
+
+	sig := req.Header.Get(wh.signature_header)
+
+	if sig == "" {
+		code := http.StatusBadRequest
+		message := fmt.Sprintf("Missing %s header", wh.signature_header)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	raw, body, err := ReadBody(ctx, req, wh.body_options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	verifiable := VerifiableBody(raw, body, wh.body_options)
+
+	verify := func(secret string) bool {
+		sum := signature.HMAC(sha256.New, []byte(secret), verifiable)
+		return signature.Equal(hex.EncodeToString(sum), strings.ToLower(sig))
+	}
+
+	if !anySecret(wh.secrets, verify) {
+		code := http.StatusForbidden
+		message := "Invalid signature"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	return DecodeBody(ctx, req, body, wh.body_options)
+}
+
+// ReceiveStream returns an `io.ReadCloser` for the body of the Gitea or Gogs message in 'req' which validates its hex-encoded
+// HMAC-SHA256 signature header as it is read, returning `ErrInvalidSignature` rather than `io.EOF` if validation fails.
+func (wh GiteaReceiver) ReceiveStream(ctx context.Context, req *http.Request) (io.ReadCloser, *webhookd.WebhookError) {
+
+	select {
+	case <-ctx.Done():
+		return nil, nil
+	default:
+		// pass
+	}
+
+	sig := req.Header.Get(wh.signature_header)
+
+	if sig == "" {
+		code := http.StatusBadRequest
+		message := fmt.Sprintf("Missing %s header", wh.signature_header)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	verify := func(sum []byte) bool {
+		return signature.Equal(hex.EncodeToString(sum), strings.ToLower(sig))
+	}
+
+	return ReadVerifiedBodyStream(ctx, req, wh.body_options, sha256.New, wh.secrets, verify)
+}
@@ -0,0 +1,82 @@
+package receiver
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"testing"
+)
+
+func TestGiteaReceiver(t *testing.T) {
+
+	ctx := context.Background()
+
+	body := []byte(`{"ref":"refs/heads/main","repository":{"full_name":"example/repo"}}`)
+
+	mac := hmac.New(sha256.New, []byte("s33kret"))
+	mac.Write(body)
+
+	sig := hex.EncodeToString(mac.Sum(nil))
+
+	for scheme, header := range map[string]string{"gitea": GITEA_SIGNATURE_HEADER, "gogs": GOGS_SIGNATURE_HEADER} {
+
+		r, err := NewReceiver(ctx, scheme+"://?secret=s33kret")
+
+		if err != nil {
+			t.Fatalf("Failed to create new %s receiver, %v", scheme, err)
+		}
+
+		tests := []struct {
+			header   string
+			sig      string
+			body     []byte
+			expected int
+		}{
+			{header, sig, body, 0},
+			{header, sig, []byte(`{"ref":"refs/heads/other"}`), http.StatusForbidden},
+			{header, "", body, http.StatusBadRequest},
+			{"X-Other-Signature", sig, body, http.StatusBadRequest},
+		}
+
+		for idx, test := range tests {
+
+			req, err := http.NewRequest("POST", "http://localhost:8080/"+scheme, bytes.NewReader(test.body))
+
+			if err != nil {
+				t.Fatalf("Failed to create new request, %v", err)
+			}
+
+			if test.sig != "" {
+				req.Header.Set(test.header, test.sig)
+			}
+
+			rsp, err2 := r.Receive(ctx, req)
+
+			if test.expected == 0 {
+
+				if err2 != nil {
+					t.Fatalf("Failed to receive %s message, %v", scheme, err2)
+				}
+
+				if !bytes.Equal(rsp, body) {
+					t.Fatalf("Unexpected output '%s'", string(rsp))
+				}
+
+				continue
+			}
+
+			if err2 == nil || err2.Code != test.expected {
+				t.Fatalf("Expected %d for %s test %d but got %v", test.expected, scheme, idx, err2)
+			}
+		}
+	}
+
+	_, err := NewReceiver(ctx, "gitea://")
+
+	if err == nil {
+		t.Fatalf("Expected receiver without a secret to fail")
+	}
+}
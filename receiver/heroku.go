@@ -0,0 +1,142 @@
+package receiver
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+// HEROKU_SIGNATURE_HEADER is the HTTP header containing the signature for a Heroku app webhook message.
+const HEROKU_SIGNATURE_HEADER string = "Heroku-Webhook-Hmac-Sha256"
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterReceiver(ctx, "heroku", NewHerokuReceiver)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// HerokuReceiver implements the `webhookd.WebhookReceiver` interface for receiving Heroku app webhook messages.
+type HerokuReceiver struct {
+	webhookd.WebhookReceiver
+	// secrets is the list of webhook secrets used to validate message signatures.
+	secrets []string
+	// body_options is the `BodyOptions` instance used to decode message bodies.
+	body_options *BodyOptions
+}
+
+// NewHerokuReceiver returns a new `HerokuReceiver` instance configured by 'uri' in the form of:
+//
+//	heroku://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `secret={STRING}` One or more (comma-separated) webhook secrets used to validate message signatures. Required.
+// * Any of the parameters supported by `NewBodyOptionsFromQuery`.
+func NewHerokuReceiver(ctx context.Context, uri string) (webhookd.WebhookReceiver, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	secrets := parseSecrets(q, "secret")
+
+	if len(secrets) == 0 {
+		return nil, fmt.Errorf("Missing ?secret= parameter")
+	}
+
+	body_opts, err := NewBodyOptionsFromQuery(q)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive body options, %w", err)
+	}
+
+	wh := HerokuReceiver{
+		secrets:      secrets,
+		body_options: body_opts,
+	}
+
+	return wh, nil
+}
+
+// Receive returns the body of the Heroku message in 'req' after validating its `Heroku-Webhook-Hmac-SHA256` header.
+func (wh HerokuReceiver) Receive(ctx context.Context, req *http.Request) ([]byte, *webhookd.WebhookError) {
+
+	select {
+	case <-ctx.Done():
+		return nil, nil
+	default:
+		// pass
+	}
+
+	sig := req.Header.Get(HEROKU_SIGNATURE_HEADER)
+
+	if sig == "" {
+		code := http.StatusBadRequest
+		message := fmt.Sprintf("Missing %s header", HEROKU_SIGNATURE_HEADER)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	raw, body, err := ReadBody(ctx, req, wh.body_options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	verify := func(secret string) bool {
+
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(VerifiableBody(raw, body, wh.body_options))
+
+		expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+		return hmac.Equal([]byte(expected), []byte(sig))
+	}
+
+	if !anySecret(wh.secrets, verify) {
+		code := http.StatusForbidden
+		message := "Invalid signature"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	return DecodeBody(ctx, req, body, wh.body_options)
+}
+
+// ReceiveStream returns an `io.ReadCloser` for the body of the Heroku message in 'req' which validates its signature header
+// as it is read, returning `ErrInvalidSignature` rather than `io.EOF` if validation fails.
+func (wh HerokuReceiver) ReceiveStream(ctx context.Context, req *http.Request) (io.ReadCloser, *webhookd.WebhookError) {
+
+	select {
+	case <-ctx.Done():
+		return nil, nil
+	default:
+		// pass
+	}
+
+	sig := req.Header.Get(HEROKU_SIGNATURE_HEADER)
+
+	if sig == "" {
+		code := http.StatusBadRequest
+		message := fmt.Sprintf("Missing %s header", HEROKU_SIGNATURE_HEADER)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	verify := func(sum []byte) bool {
+		expected := base64.StdEncoding.EncodeToString(sum)
+		return hmac.Equal([]byte(expected), []byte(sig))
+	}
+
+	return ReadVerifiedBodyStream(ctx, req, wh.body_options, sha256.New, wh.secrets, verify)
+}
@@ -0,0 +1,64 @@
+package receiver
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"testing"
+)
+
+func TestHerokuReceiver(t *testing.T) {
+
+	ctx := context.Background()
+
+	r, err := NewReceiver(ctx, "heroku://?secret=s33kret")
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	body := []byte(`{"action":"update","resource":"release","data":{"version":12}}`)
+
+	mac := hmac.New(sha256.New, []byte("s33kret"))
+	mac.Write(body)
+
+	sig := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	tests := map[string]int{
+		string(body): 0,
+		`{"action":"update","resource":"release","data":{"version":13}}`: http.StatusForbidden,
+	}
+
+	for msg, expected := range tests {
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/heroku", bytes.NewReader([]byte(msg)))
+
+		if err != nil {
+			t.Fatalf("Failed to create new request, %v", err)
+		}
+
+		req.Header.Set(HEROKU_SIGNATURE_HEADER, sig)
+
+		rsp, err2 := r.Receive(ctx, req)
+
+		if expected == 0 {
+
+			if err2 != nil {
+				t.Fatalf("Failed to receive message, %v", err2)
+			}
+
+			if !bytes.Equal(rsp, body) {
+				t.Fatalf("Unexpected output '%s'", string(rsp))
+			}
+
+			continue
+		}
+
+		if err2 == nil || err2.Code != expected {
+			t.Fatalf("Expected %d for %s but got %v", expected, msg, err2)
+		}
+	}
+}
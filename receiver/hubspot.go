@@ -0,0 +1,173 @@
+package receiver
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+// HUBSPOT_SIGNATURE_HEADER is the HTTP header containing the (v3) signature for a HubSpot webhook message.
+const HUBSPOT_SIGNATURE_HEADER string = "X-Hubspot-Signature-V3"
+
+// HUBSPOT_TIMESTAMP_HEADER is the HTTP header containing the timestamp, in milliseconds, for a HubSpot webhook message.
+const HUBSPOT_TIMESTAMP_HEADER string = "X-Hubspot-Request-Timestamp"
+
+// hubspotURLDecoder decodes the URL-encoded characters that HubSpot decodes before computing (v3) signatures.
+var hubspotURLDecoder = strings.NewReplacer(
+	"%3A", ":", "%2F", "/", "%3F", "?", "%40", "@", "%21", "!", "%24", "$",
+	"%27", "'", "%28", "(", "%29", ")", "%2A", "*", "%2C", ",", "%3B", ";",
+)
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterReceiver(ctx, "hubspot", NewHubSpotReceiver)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// HubSpotReceiver implements the `webhookd.WebhookReceiver` interface for receiving HubSpot webhook messages.
+type HubSpotReceiver struct {
+	webhookd.WebhookReceiver
+	// client_secrets is the list of HubSpot app client secrets used to validate message signatures.
+	client_secrets []string
+	// base_url is the public URL of the webhook endpoint, used to validate message signatures when webhookd is behind a proxy.
+	base_url *url.URL
+	// freshness is the `FreshnessOptions` instance used to reject messages with stale timestamps.
+	freshness *FreshnessOptions
+	// body_options is the `BodyOptions` instance used to decode message bodies.
+	body_options *BodyOptions
+}
+
+// NewHubSpotReceiver returns a new `HubSpotReceiver` instance configured by 'uri' in the form of:
+//
+//	hubspot://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `client_secret={STRING}` One or more (comma-separated) HubSpot app client secrets used to validate message signatures. Required.
+// * `url={URL}` The (URI-escaped) public URL of the webhook endpoint, without a query string. If empty the URL is derived from
+// the request and its `X-Forwarded-Proto` and `X-Forwarded-Host` headers.
+// * `max_age={DURATION}` The maximum age of a message, derived from its timestamp. A value of "0s" disables this check. Default is "5m".
+// * `max_skew={DURATION}` The maximum amount of time that the timestamp of a message may be ahead of the current time. A value of "0s" disables this check. Default is the value of `max_age`.
+// * Any of the parameters supported by `NewBodyOptionsFromQuery`.
+func NewHubSpotReceiver(ctx context.Context, uri string) (webhookd.WebhookReceiver, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	client_secrets := parseSecrets(q, "client_secret")
+
+	if len(client_secrets) == 0 {
+		return nil, fmt.Errorf("Missing ?client_secret= parameter")
+	}
+
+	freshness, err := NewFreshnessOptionsFromQuery(q)
+
+	if err != nil {
+		return nil, err
+	}
+
+	body_opts, err := NewBodyOptionsFromQuery(q)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive body options, %w", err)
+	}
+
+	wh := HubSpotReceiver{
+		client_secrets: client_secrets,
+		freshness:      freshness,
+		body_options:   body_opts,
+	}
+
+	str_url := q.Get("url")
+
+	if str_url != "" {
+
+		base_url, err := url.Parse(str_url)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse ?url= parameter, %w", err)
+		}
+
+		wh.base_url = base_url
+	}
+
+	return wh, nil
+}
+
+// Receive returns the body of the HubSpot message in 'req' after validating its `X-HubSpot-Signature-v3` header.
+func (wh HubSpotReceiver) Receive(ctx context.Context, req *http.Request) ([]byte, *webhookd.WebhookError) {
+
+	select {
+	case <-ctx.Done():
+		return nil, nil
+	default:
+		// pass
+	}
+
+	sig := req.Header.Get(HUBSPOT_SIGNATURE_HEADER)
+	str_ts := req.Header.Get(HUBSPOT_TIMESTAMP_HEADER)
+
+	if sig == "" || str_ts == "" {
+		code := http.StatusBadRequest
+		message := fmt.Sprintf("Missing %s or %s header", HUBSPOT_SIGNATURE_HEADER, HUBSPOT_TIMESTAMP_HEADER)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	ts, parse_err := strconv.ParseInt(str_ts, 10, 64)
+
+	if parse_err != nil {
+		code := http.StatusBadRequest
+		message := "Missing or invalid timestamp"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	err := verifyTime(time.UnixMilli(ts), wh.freshness)
+
+	if err != nil {
+		return nil, err
+	}
+
+	raw, body, err := ReadBody(ctx, req, wh.body_options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	request_url := hubspotURLDecoder.Replace(publicURL(req, wh.base_url).String())
+
+	verify := func(secret string) bool {
+
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(req.Method + request_url))
+		mac.Write(VerifiableBody(raw, body, wh.body_options))
+		mac.Write([]byte(str_ts))
+
+		expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+		return hmac.Equal([]byte(expected), []byte(sig))
+	}
+
+	if !anySecret(wh.client_secrets, verify) {
+		code := http.StatusForbidden
+		message := "Invalid signature"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	return DecodeBody(ctx, req, body, wh.body_options)
+}
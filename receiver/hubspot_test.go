@@ -0,0 +1,73 @@
+package receiver
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestHubSpotReceiver(t *testing.T) {
+
+	ctx := context.Background()
+
+	r, err := NewReceiver(ctx, "hubspot://?client_secret=s33kret&url=https%3A%2F%2Fwebhookd.example.com%2Fhubspot")
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	body := []byte(`[{"eventId":1,"subscriptionType":"contact.creation"}]`)
+	ts := strconv.FormatInt(time.Now().UnixMilli(), 10)
+
+	// Note that the "%3A" in the query string is decoded before the signature is computed
+
+	mac := hmac.New(sha256.New, []byte("s33kret"))
+	mac.Write([]byte("POSThttps://webhookd.example.com/hubspot?portal=a:b"))
+	mac.Write(body)
+	mac.Write([]byte(ts))
+
+	sig := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	tests := map[string]int{
+		ts: 0,
+		strconv.FormatInt(time.Now().Add(-1*time.Hour).UnixMilli(), 10): http.StatusForbidden,
+		strconv.FormatInt(time.Now().UnixMilli()+1, 10):                 http.StatusForbidden,
+	}
+
+	for str_ts, expected := range tests {
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/hubspot?portal=a%3Ab", bytes.NewReader(body))
+
+		if err != nil {
+			t.Fatalf("Failed to create new request, %v", err)
+		}
+
+		req.Header.Set(HUBSPOT_SIGNATURE_HEADER, sig)
+		req.Header.Set(HUBSPOT_TIMESTAMP_HEADER, str_ts)
+
+		rsp, err2 := r.Receive(ctx, req)
+
+		if expected == 0 {
+
+			if err2 != nil {
+				t.Fatalf("Failed to receive message, %v", err2)
+			}
+
+			if !bytes.Equal(rsp, body) {
+				t.Fatalf("Unexpected output '%s'", string(rsp))
+			}
+
+			continue
+		}
+
+		if err2 == nil || err2.Code != expected {
+			t.Fatalf("Expected %d for %s but got %v", expected, str_ts, err2)
+		}
+	}
+}
@@ -2,8 +2,10 @@ package receiver
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 
 	"github.com/whosonfirst/go-webhookd/v3"	
 )
@@ -21,14 +23,33 @@ func init() {
 // LogReceiver implements the `webhookd.WebhookReceiver` interface for receiving webhook messages in an insecure fashion.
 type InsecureReceiver struct {
 	webhookd.WebhookReceiver
+	// body_options is the `BodyOptions` instance used to decode message bodies.
+	body_options *BodyOptions
 }
 
 // NewInsecureReceiver returns a new `InsecureReceiver` instance configured by 'uri' in the form of:
 //
-//	insecure://
+//	insecure://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are any of the parameters supported by `NewBodyOptionsFromQuery`.
 func NewInsecureReceiver(ctx context.Context, uri string) (webhookd.WebhookReceiver, error) {
 
-	wh := InsecureReceiver{}
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	body_opts, err := NewBodyOptionsFromQuery(u.Query())
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive body options, %w", err)
+	}
+
+	wh := InsecureReceiver{
+		body_options: body_opts,
+	}
+
 	return wh, nil
 }
 
@@ -42,25 +63,24 @@ func (wh InsecureReceiver) Receive(ctx context.Context, req *http.Request) ([]by
 		// pass
 	}
 
-	if req.Method != "POST" {
+	_, body, err := ReadBody(ctx, req, wh.body_options)
 
-		code := http.StatusMethodNotAllowed
-		message := "Method not allowed"
-
-		err := &webhookd.WebhookError{Code: code, Message: message}
+	if err != nil {
 		return nil, err
 	}
 
-	body, err := io.ReadAll(req.Body)
-
-	if err != nil {
+	return DecodeBody(ctx, req, body, wh.body_options)
+}
 
-		code := http.StatusInternalServerError
-		message := err.Error()
+// ReceiveStream returns an `io.ReadCloser` for the body of the message in 'req'. It does not check its provenance or validate the message body in any way. You should not use this in production.
+func (wh InsecureReceiver) ReceiveStream(ctx context.Context, req *http.Request) (io.ReadCloser, *webhookd.WebhookError) {
 
-		err := &webhookd.WebhookError{Code: code, Message: message}
-		return nil, err
+	select {
+	case <-ctx.Done():
+		return nil, nil
+	default:
+		// pass
 	}
 
-	return body, nil
+	return ReadBodyStream(ctx, req, wh.body_options)
 }
@@ -0,0 +1,142 @@
+package receiver
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+// INTERCOM_SIGNATURE_HEADER is the HTTP header containing the signature for an Intercom webhook message.
+const INTERCOM_SIGNATURE_HEADER string = "X-Hub-Signature"
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterReceiver(ctx, "intercom", NewIntercomReceiver)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// IntercomReceiver implements the `webhookd.WebhookReceiver` interface for receiving Intercom webhook messages.
+type IntercomReceiver struct {
+	webhookd.WebhookReceiver
+	// client_secrets is the list of Intercom app client secrets used to validate message signatures.
+	client_secrets []string
+	// body_options is the `BodyOptions` instance used to decode message bodies.
+	body_options *BodyOptions
+}
+
+// NewIntercomReceiver returns a new `IntercomReceiver` instance configured by 'uri' in the form of:
+//
+//	intercom://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `client_secret={STRING}` One or more (comma-separated) Intercom app client secrets used to validate message signatures. Required.
+// * Any of the parameters supported by `NewBodyOptionsFromQuery`.
+func NewIntercomReceiver(ctx context.Context, uri string) (webhookd.WebhookReceiver, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	client_secrets := parseSecrets(q, "client_secret")
+
+	if len(client_secrets) == 0 {
+		return nil, fmt.Errorf("Missing ?client_secret= parameter")
+	}
+
+	body_opts, err := NewBodyOptionsFromQuery(q)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive body options, %w", err)
+	}
+
+	wh := IntercomReceiver{
+		client_secrets: client_secrets,
+		body_options:   body_opts,
+	}
+
+	return wh, nil
+}
+
+// Receive returns the body of the Intercom message in 'req' after validating its `X-Hub-Signature` header.
+func (wh IntercomReceiver) Receive(ctx context.Context, req *http.Request) ([]byte, *webhookd.WebhookError) {
+
+	select {
+	case <-ctx.Done():
+		return nil, nil
+	default:
+		// pass
+	}
+
+	sig := req.Header.Get(INTERCOM_SIGNATURE_HEADER)
+
+	if sig == "" {
+		code := http.StatusBadRequest
+		message := fmt.Sprintf("Missing %s header", INTERCOM_SIGNATURE_HEADER)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	raw, body, err := ReadBody(ctx, req, wh.body_options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	verify := func(secret string) bool {
+
+		mac := hmac.New(sha1.New, []byte(secret))
+		mac.Write(VerifiableBody(raw, body, wh.body_options))
+
+		expected := "sha1=" + hex.EncodeToString(mac.Sum(nil))
+		return hmac.Equal([]byte(expected), []byte(sig))
+	}
+
+	if !anySecret(wh.client_secrets, verify) {
+		code := http.StatusForbidden
+		message := "Invalid signature"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	return DecodeBody(ctx, req, body, wh.body_options)
+}
+
+// ReceiveStream returns an `io.ReadCloser` for the body of the Intercom message in 'req' which validates its signature header
+// as it is read, returning `ErrInvalidSignature` rather than `io.EOF` if validation fails.
+func (wh IntercomReceiver) ReceiveStream(ctx context.Context, req *http.Request) (io.ReadCloser, *webhookd.WebhookError) {
+
+	select {
+	case <-ctx.Done():
+		return nil, nil
+	default:
+		// pass
+	}
+
+	sig := req.Header.Get(INTERCOM_SIGNATURE_HEADER)
+
+	if sig == "" {
+		code := http.StatusBadRequest
+		message := fmt.Sprintf("Missing %s header", INTERCOM_SIGNATURE_HEADER)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	verify := func(sum []byte) bool {
+		expected := "sha1=" + hex.EncodeToString(sum)
+		return hmac.Equal([]byte(expected), []byte(sig))
+	}
+
+	return ReadVerifiedBodyStream(ctx, req, wh.body_options, sha1.New, wh.client_secrets, verify)
+}
@@ -0,0 +1,64 @@
+package receiver
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"testing"
+)
+
+func TestIntercomReceiver(t *testing.T) {
+
+	ctx := context.Background()
+
+	r, err := NewReceiver(ctx, "intercom://?client_secret=s33kret")
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	body := []byte(`{"type":"notification_event","topic":"conversation.user.created"}`)
+
+	mac := hmac.New(sha1.New, []byte("s33kret"))
+	mac.Write(body)
+
+	sig := "sha1=" + hex.EncodeToString(mac.Sum(nil))
+
+	tests := map[string]int{
+		string(body): 0,
+		`{"type":"notification_event","topic":"conversation.user.replied"}`: http.StatusForbidden,
+	}
+
+	for msg, expected := range tests {
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/intercom", bytes.NewReader([]byte(msg)))
+
+		if err != nil {
+			t.Fatalf("Failed to create new request, %v", err)
+		}
+
+		req.Header.Set(INTERCOM_SIGNATURE_HEADER, sig)
+
+		rsp, err2 := r.Receive(ctx, req)
+
+		if expected == 0 {
+
+			if err2 != nil {
+				t.Fatalf("Failed to receive message, %v", err2)
+			}
+
+			if !bytes.Equal(rsp, body) {
+				t.Fatalf("Unexpected output '%s'", string(rsp))
+			}
+
+			continue
+		}
+
+		if err2 == nil || err2.Code != expected {
+			t.Fatalf("Expected %d for %s but got %v", expected, msg, err2)
+		}
+	}
+}
@@ -0,0 +1,733 @@
+package receiver
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+// DEFAULT_JWT_LEEWAY is the default amount of clock skew allowed when checking the time-based claims of a JWT.
+const DEFAULT_JWT_LEEWAY time.Duration = time.Minute
+
+// DEFAULT_JWKS_TTL is the default amount of time that a JSON Web Key Set is cached.
+const DEFAULT_JWKS_TTL time.Duration = time.Hour
+
+// DEFAULT_JWKS_TIMEOUT is the default amount of time to wait for a JSON Web Key Set request to complete.
+const DEFAULT_JWKS_TIMEOUT time.Duration = 10 * time.Second
+
+// JWT_SUBJECT_HEADER is the HTTP header, set by `JWTReceiver`, containing the subject ("sub") claim of a verified JWT.
+const JWT_SUBJECT_HEADER string = "X-Jwt-Subject"
+
+// minJWKSRefresh is the minimum amount of time between requests for a JSON Web Key Set triggered by an unknown key ID.
+const minJWKSRefresh time.Duration = time.Minute
+
+// maxJWKSSize is the maximum size of a JSON Web Key Set response body.
+const maxJWKSSize int64 = 1 << 20
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterReceiver(ctx, "jwt", NewJWTReceiver)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// jwtHeader is the subset of a JWT header used to verify its signature.
+type jwtHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+}
+
+// jwtToken is a parsed, but not verified, JWT.
+type jwtToken struct {
+	header jwtHeader
+	// signing_input is the encoded header and claims that the signature is computed over.
+	signing_input string
+	// claims is the decoded JSON claims.
+	claims []byte
+	// signature is the decoded signature.
+	signature []byte
+}
+
+// jwtClaims is the subset of registered JWT claims checked by `JWTReceiver`.
+type jwtClaims struct {
+	Issuer    string          `json:"iss"`
+	Subject   string          `json:"sub"`
+	Audience  json.RawMessage `json:"aud"`
+	ExpiresAt *float64        `json:"exp"`
+	NotBefore *float64        `json:"nbf"`
+}
+
+// JWTReceiver implements the `webhookd.WebhookReceiver` interface for receiving messages authenticated by a JWT bearer token.
+type JWTReceiver struct {
+	webhookd.WebhookReceiver
+	// secrets is the list of shared secrets used to verify tokens signed with an HMAC algorithm.
+	secrets []string
+	// jwks is the `jwksCache` used to retrieve the public keys for tokens signed with an RSA, ECDSA or EdDSA algorithm.
+	jwks *jwksCache
+	// audience is the optional list of audiences that a token must be issued for.
+	audience []string
+	// issuer is the optional issuer that a token must be issued by.
+	issuer string
+	// leeway is the amount of clock skew allowed when checking the time-based claims of a token.
+	leeway time.Duration
+	// body_options is the `BodyOptions` instance used to decode message bodies.
+	body_options *BodyOptions
+}
+
+// NewJWTReceiver returns a new `JWTReceiver` instance configured by 'uri' in the form of:
+//
+//	jwt://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `secret={STRING}` One or more (comma-separated) shared secrets used to verify tokens signed with the HS256, HS384 or HS512 algorithms.
+// * `jwks={URL}` The URL of a JSON Web Key Set used to verify tokens signed with the RS*, PS*, ES* or EdDSA algorithms.
+// * `audience={STRING}` Zero or more audiences; if present a token's "aud" claim must contain at least one of them.
+// * `issuer={STRING}` If present a token's "iss" claim must match this value.
+// * `leeway={DURATION}` The amount of clock skew allowed when checking the "exp" and "nbf" claims. Default is "1m".
+// * `timeout={DURATION}` The amount of time to wait for a JSON Web Key Set request to complete. Default is "10s".
+// * Any of the parameters supported by `NewBodyOptionsFromQuery`.
+//
+// At least one of the `secret` or `jwks` parameters must be present.
+func NewJWTReceiver(ctx context.Context, uri string) (webhookd.WebhookReceiver, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	secrets := parseSecrets(q, "secret")
+	jwks_url := q.Get("jwks")
+
+	if len(secrets) == 0 && jwks_url == "" {
+		return nil, fmt.Errorf("Missing ?secret= or ?jwks= parameter")
+	}
+
+	leeway := DEFAULT_JWT_LEEWAY
+	timeout := DEFAULT_JWKS_TIMEOUT
+
+	durations := map[string]*time.Duration{
+		"leeway":  &leeway,
+		"timeout": &timeout,
+	}
+
+	for k, ptr := range durations {
+
+		str_v := q.Get(k)
+
+		if str_v == "" {
+			continue
+		}
+
+		v, err := time.ParseDuration(str_v)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse ?%s= parameter, %w", k, err)
+		}
+
+		*ptr = v
+	}
+
+	body_opts, err := NewBodyOptionsFromQuery(q)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive body options, %w", err)
+	}
+
+	wh := JWTReceiver{
+		secrets:      secrets,
+		audience:     q["audience"],
+		issuer:       q.Get("issuer"),
+		leeway:       leeway,
+		body_options: body_opts,
+	}
+
+	if jwks_url != "" {
+
+		_, err := url.Parse(jwks_url)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse ?jwks= parameter, %w", err)
+		}
+
+		wh.jwks = &jwksCache{
+			mu:     new(sync.Mutex),
+			url:    jwks_url,
+			client: &http.Client{Timeout: timeout},
+			keys:   make(map[string]interface{}),
+		}
+	}
+
+	return wh, nil
+}
+
+// Receive returns the body of the message in 'req' after verifying the JWT in its `Authorization: Bearer` header. The
+// `X-Jwt-Subject` header of 'req' is set to the token's "sub" claim.
+func (wh JWTReceiver) Receive(ctx context.Context, req *http.Request) ([]byte, *webhookd.WebhookError) {
+
+	select {
+	case <-ctx.Done():
+		return nil, nil
+	default:
+		// pass
+	}
+
+	authorization := req.Header.Get("Authorization")
+
+	if !strings.HasPrefix(authorization, "Bearer ") {
+		code := http.StatusUnauthorized
+		message := "Missing bearer token"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	token := strings.TrimSpace(strings.TrimPrefix(authorization, "Bearer "))
+
+	claims, verify_err := wh.verify(ctx, token)
+
+	if verify_err != nil {
+		code := http.StatusUnauthorized
+		message := fmt.Sprintf("Invalid token, %v", verify_err)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	req.Header.Del(JWT_SUBJECT_HEADER)
+
+	if claims.Subject != "" {
+		req.Header.Set(JWT_SUBJECT_HEADER, claims.Subject)
+	}
+
+	_, body, err := ReadBody(ctx, req, wh.body_options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodeBody(ctx, req, body, wh.body_options)
+}
+
+// verify verifies the signature and registered claims of 'token'.
+func (wh JWTReceiver) verify(ctx context.Context, token string) (*jwtClaims, error) {
+
+	t, err := parseJWT(token)
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case strings.HasPrefix(t.header.Algorithm, "HS"):
+
+		if len(wh.secrets) == 0 {
+			return nil, fmt.Errorf("Unsupported JWT algorithm")
+		}
+
+		err = verifyJWTSecrets(t, wh.secrets)
+
+	default:
+
+		if wh.jwks == nil {
+			return nil, fmt.Errorf("Unsupported JWT algorithm")
+		}
+
+		key, key_err := wh.jwks.Key(ctx, t.header.KeyID)
+
+		if key_err != nil {
+			return nil, key_err
+		}
+
+		err = verifyJWTSignature(t, key)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var claims jwtClaims
+
+	err = json.Unmarshal(t.claims, &claims)
+
+	if err != nil {
+		return nil, fmt.Errorf("Invalid JWT claims")
+	}
+
+	now := float64(time.Now().Unix())
+	leeway := wh.leeway.Seconds()
+
+	if claims.ExpiresAt != nil && now > *claims.ExpiresAt+leeway {
+		return nil, fmt.Errorf("JWT has expired")
+	}
+
+	if claims.NotBefore != nil && now < *claims.NotBefore-leeway {
+		return nil, fmt.Errorf("JWT is not valid yet")
+	}
+
+	if wh.issuer != "" && claims.Issuer != wh.issuer {
+		return nil, fmt.Errorf("Invalid JWT issuer")
+	}
+
+	if len(wh.audience) > 0 && !jwtHasAudience(claims.Audience, wh.audience) {
+		return nil, fmt.Errorf("Invalid JWT audience")
+	}
+
+	return &claims, nil
+}
+
+// jwtHasAudience returns a boolean value indicating whether the "aud" claim 'aud', which may be a string or a list of strings,
+// contains any of 'audience'.
+func jwtHasAudience(aud json.RawMessage, audience []string) bool {
+
+	var candidates []string
+
+	var str_aud string
+
+	if json.Unmarshal(aud, &str_aud) == nil {
+		candidates = []string{str_aud}
+	} else if json.Unmarshal(aud, &candidates) != nil {
+		return false
+	}
+
+	for _, c := range candidates {
+
+		for _, a := range audience {
+
+			if c == a {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// parseJWT parses, but does not verify, the compact-serialized JWT 'token'.
+func parseJWT(token string) (*jwtToken, error) {
+
+	parts := strings.Split(token, ".")
+
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("Invalid JWT token")
+	}
+
+	enc_header, err := base64.RawURLEncoding.DecodeString(parts[0])
+
+	if err != nil {
+		return nil, fmt.Errorf("Invalid JWT header")
+	}
+
+	t := &jwtToken{
+		signing_input: parts[0] + "." + parts[1],
+	}
+
+	err = json.Unmarshal(enc_header, &t.header)
+
+	if err != nil {
+		return nil, fmt.Errorf("Invalid JWT header")
+	}
+
+	t.signature, err = base64.RawURLEncoding.DecodeString(parts[2])
+
+	if err != nil {
+		return nil, fmt.Errorf("Invalid JWT signature")
+	}
+
+	t.claims, err = base64.RawURLEncoding.DecodeString(parts[1])
+
+	if err != nil {
+		return nil, fmt.Errorf("Invalid JWT claims")
+	}
+
+	return t, nil
+}
+
+// verifyJWTSignature verifies the signature of 't' using 'key', which must be a `[]byte` secret for HMAC algorithms, an
+// `*rsa.PublicKey` for RS* and PS* algorithms, an `*ecdsa.PublicKey` for ES* algorithms or an `ed25519.PublicKey` for EdDSA.
+func verifyJWTSignature(t *jwtToken, key interface{}) error {
+
+	hashes := map[string]crypto.Hash{
+		"256": crypto.SHA256,
+		"384": crypto.SHA384,
+		"512": crypto.SHA512,
+	}
+
+	alg := t.header.Algorithm
+
+	if alg == "EdDSA" {
+
+		public_key, ok := key.(ed25519.PublicKey)
+
+		if !ok || !ed25519.Verify(public_key, []byte(t.signing_input), t.signature) {
+			return fmt.Errorf("Invalid JWT signature")
+		}
+
+		return nil
+	}
+
+	if len(alg) != 5 {
+		return fmt.Errorf("Unsupported JWT algorithm")
+	}
+
+	h, ok := hashes[alg[2:]]
+
+	if !ok {
+		return fmt.Errorf("Unsupported JWT algorithm")
+	}
+
+	var digest []byte
+
+	switch h {
+	case crypto.SHA256:
+		sum := sha256.Sum256([]byte(t.signing_input))
+		digest = sum[:]
+	case crypto.SHA384:
+		sum := sha512.Sum384([]byte(t.signing_input))
+		digest = sum[:]
+	default:
+		sum := sha512.Sum512([]byte(t.signing_input))
+		digest = sum[:]
+	}
+
+	valid := false
+
+	switch alg[:2] {
+	case "HS":
+
+		if secret, ok := key.([]byte); ok {
+
+			var mac_hash func() hash.Hash
+
+			switch h {
+			case crypto.SHA256:
+				mac_hash = sha256.New
+			case crypto.SHA384:
+				mac_hash = sha512.New384
+			default:
+				mac_hash = sha512.New
+			}
+
+			mac := hmac.New(mac_hash, secret)
+			mac.Write([]byte(t.signing_input))
+
+			valid = hmac.Equal(mac.Sum(nil), t.signature)
+		}
+
+	case "RS":
+
+		if public_key, ok := key.(*rsa.PublicKey); ok {
+			valid = rsa.VerifyPKCS1v15(public_key, h, digest, t.signature) == nil
+		}
+
+	case "PS":
+
+		if public_key, ok := key.(*rsa.PublicKey); ok {
+			opts := &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}
+			valid = rsa.VerifyPSS(public_key, h, digest, t.signature, opts) == nil
+		}
+
+	case "ES":
+
+		if public_key, ok := key.(*ecdsa.PublicKey); ok {
+
+			// ECDSA signatures are the concatenation of the (fixed-length) R and S values rather than ASN.1
+
+			size := (public_key.Curve.Params().BitSize + 7) / 8
+
+			if len(t.signature) == 2*size {
+				r := new(big.Int).SetBytes(t.signature[:size])
+				s := new(big.Int).SetBytes(t.signature[size:])
+				valid = ecdsa.Verify(public_key, digest, r, s)
+			}
+		}
+
+	default:
+		return fmt.Errorf("Unsupported JWT algorithm")
+	}
+
+	if !valid {
+		return fmt.Errorf("Invalid JWT signature")
+	}
+
+	return nil
+}
+
+// verifyJWTSecrets verifies the (HMAC) signature of 't' using any of 'secrets'.
+func verifyJWTSecrets(t *jwtToken, secrets []string) error {
+
+	verify := func(secret string) bool {
+		return verifyJWTSignature(t, []byte(secret)) == nil
+	}
+
+	if !anySecret(secrets, verify) {
+		return fmt.Errorf("Invalid JWT signature")
+	}
+
+	return nil
+}
+
+// verifyHS256JWT validates the HMAC-SHA256 signature of the JWT 'token' using any of 'secrets' and decodes its claims in to 'claims'.
+func verifyHS256JWT(token string, secrets []string, claims interface{}) error {
+
+	t, err := parseJWT(token)
+
+	if err != nil {
+		return err
+	}
+
+	if t.header.Algorithm != "HS256" {
+		return fmt.Errorf("Unsupported JWT algorithm")
+	}
+
+	err = verifyJWTSecrets(t, secrets)
+
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(t.claims, claims)
+
+	if err != nil {
+		return fmt.Errorf("Invalid JWT claims")
+	}
+
+	return nil
+}
+
+// jwksCache is a cache of the public keys in a JSON Web Key Set.
+type jwksCache struct {
+	mu     *sync.Mutex
+	url    string
+	client *http.Client
+	// keys is the dictionary of public keys keyed by their ID.
+	keys map[string]interface{}
+	// expires is the time after which the keys will be retrieved again.
+	expires time.Time
+	// fetched is the time the keys were last retrieved.
+	fetched time.Time
+}
+
+// Key returns the public key with ID 'kid'. If 'kid' is empty and the key set contains a single key then that key is returned.
+// The key set is retrieved again if the cache has expired or, at most once a minute, if 'kid' is not present.
+func (c *jwksCache) Key(ctx context.Context, kid string) (interface{}, error) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+
+	key, ok := c.lookup(kid)
+
+	if ok && now.Before(c.expires) {
+		return key, nil
+	}
+
+	if !ok && now.Before(c.expires) && now.Sub(c.fetched) < minJWKSRefresh {
+		return nil, fmt.Errorf("Unknown JWT key ID")
+	}
+
+	err := c.fetch(ctx)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to retrieve JSON Web Key Set, %w", err)
+	}
+
+	key, ok = c.lookup(kid)
+
+	if !ok {
+		return nil, fmt.Errorf("Unknown JWT key ID")
+	}
+
+	return key, nil
+}
+
+// lookup returns the cached public key with ID 'kid'.
+func (c *jwksCache) lookup(kid string) (interface{}, bool) {
+
+	if kid == "" && len(c.keys) == 1 {
+
+		for _, k := range c.keys {
+			return k, true
+		}
+	}
+
+	k, ok := c.keys[kid]
+	return k, ok
+}
+
+// fetch retrieves the JSON Web Key Set and replaces the cached public keys.
+func (c *jwksCache) fetch(ctx context.Context) error {
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+
+	if err != nil {
+		return fmt.Errorf("Failed to create request, %w", err)
+	}
+
+	rsp, err := c.client.Do(req)
+
+	if err != nil {
+		return fmt.Errorf("Failed to execute request, %w", err)
+	}
+
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Request returned unexpected status %s", rsp.Status)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+
+	err = json.NewDecoder(io.LimitReader(rsp.Body, maxJWKSSize)).Decode(&set)
+
+	if err != nil {
+		return fmt.Errorf("Failed to decode response, %w", err)
+	}
+
+	keys := make(map[string]interface{})
+
+	for _, jwk := range set.Keys {
+
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		k, err := jwk.PublicKey()
+
+		if err != nil {
+			// Skip key types that aren't supported rather than rejecting the entire set
+			continue
+		}
+
+		keys[jwk.KeyID] = k
+	}
+
+	now := time.Now()
+
+	c.keys = keys
+	c.fetched = now
+	c.expires = now.Add(DEFAULT_JWKS_TTL)
+
+	return nil
+}
+
+// jsonWebKey is the subset of a JSON Web Key used to derive RSA, EC and OKP public keys.
+type jsonWebKey struct {
+	KeyType string `json:"kty"`
+	KeyID   string `json:"kid"`
+	Use     string `json:"use"`
+	N       string `json:"n"`
+	E       string `json:"e"`
+	Curve   string `json:"crv"`
+	X       string `json:"x"`
+	Y       string `json:"y"`
+}
+
+// PublicKey returns the public key described by 'jwk'.
+func (jwk jsonWebKey) PublicKey() (interface{}, error) {
+
+	decode := func(v string) (*big.Int, error) {
+
+		b, err := base64.RawURLEncoding.DecodeString(v)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return new(big.Int).SetBytes(b), nil
+	}
+
+	switch jwk.KeyType {
+	case "RSA":
+
+		n, err := decode(jwk.N)
+
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := decode(jwk.E)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+
+		curves := map[string]elliptic.Curve{
+			"P-256": elliptic.P256(),
+			"P-384": elliptic.P384(),
+			"P-521": elliptic.P521(),
+		}
+
+		curve, ok := curves[jwk.Curve]
+
+		if !ok {
+			return nil, fmt.Errorf("Unsupported curve '%s'", jwk.Curve)
+		}
+
+		x, err := decode(jwk.X)
+
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := decode(jwk.Y)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if !curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("Invalid EC public key")
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+
+	case "OKP":
+
+		if jwk.Curve != "Ed25519" {
+			return nil, fmt.Errorf("Unsupported curve '%s'", jwk.Curve)
+		}
+
+		b, err := base64.RawURLEncoding.DecodeString(jwk.X)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(b) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("Invalid Ed25519 public key")
+		}
+
+		return ed25519.PublicKey(b), nil
+
+	default:
+		return nil, fmt.Errorf("Unsupported key type '%s'", jwk.KeyType)
+	}
+}
@@ -0,0 +1,221 @@
+package receiver
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// jwtTestToken returns a JWT for 'claims' signed with 'key' using 'alg'.
+func jwtTestToken(t *testing.T, alg string, kid string, key interface{}, claims string) string {
+
+	header := fmt.Sprintf(`{"alg":"%s","typ":"JWT","kid":"%s"}`, alg, kid)
+	input := base64.RawURLEncoding.EncodeToString([]byte(header)) + "." + base64.RawURLEncoding.EncodeToString([]byte(claims))
+
+	digest := sha256.Sum256([]byte(input))
+
+	var sig []byte
+
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(input))
+		sig = mac.Sum(nil)
+	case *rsa.PrivateKey:
+
+		v, err := rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+
+		if err != nil {
+			t.Fatalf("Failed to sign token, %v", err)
+		}
+
+		sig = v
+
+	case *ecdsa.PrivateKey:
+
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+
+		if err != nil {
+			t.Fatalf("Failed to sign token, %v", err)
+		}
+
+		sig = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+
+	case ed25519.PrivateKey:
+		sig = ed25519.Sign(k, []byte(input))
+	}
+
+	return input + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func TestJWTReceiverSecret(t *testing.T) {
+
+	ctx := context.Background()
+
+	r, err := NewReceiver(ctx, "jwt://?secret=s33kret&issuer=builds&audience=webhookd&audience=other")
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	now := time.Now().Unix()
+	secret := []byte("s33kret")
+
+	tests := map[string]int{
+		jwtTestToken(t, "HS256", "", secret, fmt.Sprintf(`{"iss":"builds","sub":"ci","aud":"webhookd","exp":%d}`, now+60)):          0,
+		jwtTestToken(t, "HS256", "", secret, fmt.Sprintf(`{"iss":"builds","sub":"ci","aud":["x","other"],"exp":%d}`, now+60)):       0,
+		jwtTestToken(t, "HS256", "", []byte("wr0ng"), fmt.Sprintf(`{"iss":"builds","sub":"ci","aud":"webhookd","exp":%d}`, now+60)): http.StatusUnauthorized,
+		jwtTestToken(t, "HS256", "", secret, fmt.Sprintf(`{"iss":"builds","sub":"ci","aud":"webhookd","exp":%d}`, now-3600)):        http.StatusUnauthorized,
+		jwtTestToken(t, "HS256", "", secret, fmt.Sprintf(`{"iss":"builds","sub":"ci","aud":"webhookd","nbf":%d}`, now+3600)):        http.StatusUnauthorized,
+		jwtTestToken(t, "HS256", "", secret, `{"iss":"other","sub":"ci","aud":"webhookd"}`):                                         http.StatusUnauthorized,
+		jwtTestToken(t, "HS256", "", secret, `{"iss":"builds","sub":"ci","aud":"nobody"}`):                                          http.StatusUnauthorized,
+		jwtTestToken(t, "none", "", secret, `{"iss":"builds","sub":"ci","aud":"webhookd"}`):                                         http.StatusUnauthorized,
+		"": http.StatusUnauthorized,
+	}
+
+	body := []byte(`{"status":"ok"}`)
+
+	for token, expected := range tests {
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/jwt", bytes.NewReader(body))
+
+		if err != nil {
+			t.Fatalf("Failed to create new request, %v", err)
+		}
+
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		rsp, err2 := r.Receive(ctx, req)
+
+		if expected == 0 {
+
+			if err2 != nil {
+				t.Fatalf("Failed to receive message, %v", err2)
+			}
+
+			if !bytes.Equal(rsp, body) {
+				t.Fatalf("Unexpected output '%s'", string(rsp))
+			}
+
+			if req.Header.Get(JWT_SUBJECT_HEADER) != "ci" {
+				t.Fatalf("Unexpected %s header '%s'", JWT_SUBJECT_HEADER, req.Header.Get(JWT_SUBJECT_HEADER))
+			}
+
+			continue
+		}
+
+		if err2 == nil || err2.Code != expected {
+			t.Fatalf("Expected %d for %s but got %v", expected, token, err2)
+		}
+	}
+}
+
+func TestJWTReceiverJWKS(t *testing.T) {
+
+	ctx := context.Background()
+
+	rsa_key, err := rsa.GenerateKey(rand.Reader, 2048)
+
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key, %v", err)
+	}
+
+	ec_key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	if err != nil {
+		t.Fatalf("Failed to generate EC key, %v", err)
+	}
+
+	ed_public, ed_private, err := ed25519.GenerateKey(rand.Reader)
+
+	if err != nil {
+		t.Fatalf("Failed to generate Ed25519 key, %v", err)
+	}
+
+	enc := base64.RawURLEncoding.EncodeToString
+
+	jwks := map[string]interface{}{
+		"keys": []map[string]string{
+			{"kty": "RSA", "kid": "rsa-1", "use": "sig", "n": enc(rsa_key.N.Bytes()), "e": enc(big.NewInt(int64(rsa_key.E)).Bytes())},
+			{"kty": "EC", "kid": "ec-1", "crv": "P-256", "x": enc(ec_key.X.FillBytes(make([]byte, 32))), "y": enc(ec_key.Y.FillBytes(make([]byte, 32)))},
+			{"kty": "OKP", "kid": "ed-1", "crv": "Ed25519", "x": enc(ed_public)},
+		},
+	}
+
+	jwks_requests := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(rsp http.ResponseWriter, req *http.Request) {
+		jwks_requests += 1
+		json.NewEncoder(rsp).Encode(jwks)
+	}))
+
+	defer server.Close()
+
+	r, err := NewReceiver(ctx, fmt.Sprintf("jwt://?jwks=%s&audience=webhookd", url.QueryEscape(server.URL)))
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	claims := `{"sub":"ci","aud":"webhookd"}`
+
+	tests := map[string]int{
+		jwtTestToken(t, "RS256", "rsa-1", rsa_key, claims):             0,
+		jwtTestToken(t, "ES256", "ec-1", ec_key, claims):               0,
+		jwtTestToken(t, "EdDSA", "ed-1", ed_private, claims):           0,
+		jwtTestToken(t, "RS256", "ec-1", rsa_key, claims):              http.StatusUnauthorized,
+		jwtTestToken(t, "HS256", "rsa-1", []byte("s33kret"), claims):   http.StatusUnauthorized,
+		jwtTestToken(t, "RS256", "rsa-unknown", rsa_key, claims):       http.StatusUnauthorized,
+		jwtTestToken(t, "RS256", "rsa-1", rsa_key, `{"aud":"nobody"}`): http.StatusUnauthorized,
+	}
+
+	for token, expected := range tests {
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/jwt", bytes.NewReader([]byte(`{}`)))
+
+		if err != nil {
+			t.Fatalf("Failed to create new request, %v", err)
+		}
+
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		_, err2 := r.Receive(ctx, req)
+
+		if expected == 0 {
+
+			if err2 != nil {
+				t.Fatalf("Failed to receive message, %v", err2)
+			}
+
+			continue
+		}
+
+		if err2 == nil || err2.Code != expected {
+			t.Fatalf("Expected %d for %s but got %v", expected, token, err2)
+		}
+	}
+
+	// Unknown key IDs should not trigger more than one additional request per minute
+
+	if jwks_requests > 2 {
+		t.Fatalf("Expected JSON Web Key Set to be retrieved at most twice but was retrieved %d times", jwks_requests)
+	}
+}
@@ -0,0 +1,148 @@
+package receiver
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+// LINEAR_SIGNATURE_HEADER is the HTTP header containing the signature for a Linear webhook message.
+const LINEAR_SIGNATURE_HEADER string = "Linear-Signature"
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterReceiver(ctx, "linear", NewLinearReceiver)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// linearEvent is the subset of a Linear webhook message used by `LinearReceiver`.
+type linearEvent struct {
+	WebhookTimestamp int64 `json:"webhookTimestamp"`
+}
+
+// LinearReceiver implements the `webhookd.WebhookReceiver` interface for receiving Linear webhook messages.
+type LinearReceiver struct {
+	webhookd.WebhookReceiver
+	// secrets is the list of Linear webhook signing secrets used to validate message signatures.
+	secrets []string
+	// freshness is the `FreshnessOptions` instance used to reject messages with stale timestamps.
+	freshness *FreshnessOptions
+	// body_options is the `BodyOptions` instance used to decode message bodies.
+	body_options *BodyOptions
+}
+
+// NewLinearReceiver returns a new `LinearReceiver` instance configured by 'uri' in the form of:
+//
+//	linear://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `secret={STRING}` One or more (comma-separated) Linear webhook signing secrets used to validate message signatures. Required.
+// * `max_age={DURATION}` The maximum age of a message, derived from its `webhookTimestamp` property. A value of "0s" disables this check. Default is "5m".
+// * `max_skew={DURATION}` The maximum amount of time that the timestamp of a message may be ahead of the current time. A value of "0s" disables this check. Default is the value of `max_age`.
+// * Any of the parameters supported by `NewBodyOptionsFromQuery`.
+func NewLinearReceiver(ctx context.Context, uri string) (webhookd.WebhookReceiver, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	secrets := parseSecrets(q, "secret")
+
+	if len(secrets) == 0 {
+		return nil, fmt.Errorf("Missing ?secret= parameter")
+	}
+
+	freshness, err := NewFreshnessOptionsFromQuery(q)
+
+	if err != nil {
+		return nil, err
+	}
+
+	body_opts, err := NewBodyOptionsFromQuery(q)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive body options, %w", err)
+	}
+
+	wh := LinearReceiver{
+		secrets:      secrets,
+		freshness:    freshness,
+		body_options: body_opts,
+	}
+
+	return wh, nil
+}
+
+// Receive returns the body of the Linear message in 'req' after validating its `Linear-Signature` header.
+func (wh LinearReceiver) Receive(ctx context.Context, req *http.Request) ([]byte, *webhookd.WebhookError) {
+
+	select {
+	case <-ctx.Done():
+		return nil, nil
+	default:
+		// pass
+	}
+
+	sig := req.Header.Get(LINEAR_SIGNATURE_HEADER)
+
+	if sig == "" {
+		code := http.StatusBadRequest
+		message := fmt.Sprintf("Missing %s header", LINEAR_SIGNATURE_HEADER)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	raw, body, err := ReadBody(ctx, req, wh.body_options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	verify := func(secret string) bool {
+
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(VerifiableBody(raw, body, wh.body_options))
+
+		expected := hex.EncodeToString(mac.Sum(nil))
+		return hmac.Equal([]byte(expected), []byte(sig))
+	}
+
+	if !anySecret(wh.secrets, verify) {
+		code := http.StatusForbidden
+		message := "Invalid signature"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	var ev linearEvent
+
+	decode_err := json.Unmarshal(body, &ev)
+
+	if decode_err != nil || ev.WebhookTimestamp == 0 {
+		code := http.StatusBadRequest
+		message := "Missing or invalid timestamp"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	err = verifyTime(time.UnixMilli(ev.WebhookTimestamp), wh.freshness)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return body, nil
+}
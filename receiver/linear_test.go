@@ -0,0 +1,79 @@
+package receiver
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestLinearReceiver(t *testing.T) {
+
+	ctx := context.Background()
+
+	r, err := NewReceiver(ctx, "linear://?secret=s33kret")
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	tests := map[string]int{
+		fmt.Sprintf(`{"action":"create","type":"Issue","webhookTimestamp":%d}`, time.Now().UnixMilli()):                   0,
+		fmt.Sprintf(`{"action":"create","type":"Issue","webhookTimestamp":%d}`, time.Now().Add(-1*time.Hour).UnixMilli()): http.StatusForbidden,
+		`{"action":"create","type":"Issue"}`: http.StatusBadRequest,
+	}
+
+	for msg, expected := range tests {
+
+		body := []byte(msg)
+
+		mac := hmac.New(sha256.New, []byte("s33kret"))
+		mac.Write(body)
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/linear", bytes.NewReader(body))
+
+		if err != nil {
+			t.Fatalf("Failed to create new request, %v", err)
+		}
+
+		req.Header.Set(LINEAR_SIGNATURE_HEADER, hex.EncodeToString(mac.Sum(nil)))
+
+		rsp, err2 := r.Receive(ctx, req)
+
+		if expected == 0 {
+
+			if err2 != nil {
+				t.Fatalf("Failed to receive message, %v", err2)
+			}
+
+			if !bytes.Equal(rsp, body) {
+				t.Fatalf("Unexpected output '%s'", string(rsp))
+			}
+
+			continue
+		}
+
+		if err2 == nil || err2.Code != expected {
+			t.Fatalf("Expected %d for %s but got %v", expected, msg, err2)
+		}
+	}
+
+	req, err := http.NewRequest("POST", "http://localhost:8080/linear", bytes.NewReader([]byte(`{}`)))
+
+	if err != nil {
+		t.Fatalf("Failed to create new request, %v", err)
+	}
+
+	req.Header.Set(LINEAR_SIGNATURE_HEADER, "abc")
+
+	_, err2 := r.Receive(ctx, req)
+
+	if err2 == nil || err2.Code != http.StatusForbidden {
+		t.Fatalf("Expected invalid signature to fail, %v", err2)
+	}
+}
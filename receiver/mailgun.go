@@ -0,0 +1,186 @@
+package receiver
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterReceiver(ctx, "mailgun", NewMailgunReceiver)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// mailgunSignature is the signature block included with Mailgun webhook messages.
+type mailgunSignature struct {
+	Timestamp string `json:"timestamp"`
+	Token     string `json:"token"`
+	Signature string `json:"signature"`
+}
+
+// mailgunMessage is the subset of a (JSON-encoded) Mailgun webhook message used by `MailgunReceiver`.
+type mailgunMessage struct {
+	Signature *mailgunSignature `json:"signature"`
+}
+
+// MailgunReceiver implements the `webhookd.WebhookReceiver` interface for receiving Mailgun webhook messages.
+type MailgunReceiver struct {
+	webhookd.WebhookReceiver
+	// signing_keys is the list of Mailgun webhook signing keys used to validate message signatures.
+	signing_keys []string
+	// freshness is the `FreshnessOptions` instance used to reject messages with stale timestamps.
+	freshness *FreshnessOptions
+	// body_options is the `BodyOptions` instance used to decode message bodies.
+	body_options *BodyOptions
+}
+
+// NewMailgunReceiver returns a new `MailgunReceiver` instance configured by 'uri' in the form of:
+//
+//	mailgun://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `signing_key={STRING}` One or more (comma-separated) Mailgun webhook signing keys used to validate message signatures. Required.
+// * `max_age={DURATION}` The maximum age of a message, derived from its timestamp. A value of "0s" disables this check. Default is "5m".
+// * `max_skew={DURATION}` The maximum amount of time that the timestamp of a message may be ahead of the current time. A value of "0s" disables this check. Default is the value of `max_age`.
+// * Any of the parameters supported by `NewBodyOptionsFromQuery`, excluding `form_field` and `form_json`.
+//
+// Both JSON-encoded webhook messages and legacy form-encoded messages are supported. Form-encoded messages are converted to a
+// JSON dictionary.
+func NewMailgunReceiver(ctx context.Context, uri string) (webhookd.WebhookReceiver, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	signing_keys := parseSecrets(q, "signing_key")
+
+	if len(signing_keys) == 0 {
+		return nil, fmt.Errorf("Missing ?signing_key= parameter")
+	}
+
+	freshness, err := NewFreshnessOptionsFromQuery(q)
+
+	if err != nil {
+		return nil, err
+	}
+
+	body_opts, err := NewBodyOptionsFromQuery(q)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive body options, %w", err)
+	}
+
+	if body_opts.FormField != "" || body_opts.FormToJSON {
+		return nil, fmt.Errorf("?form_field= and ?form_json= parameters are not supported")
+	}
+
+	wh := MailgunReceiver{
+		signing_keys: signing_keys,
+		freshness:    freshness,
+		body_options: body_opts,
+	}
+
+	return wh, nil
+}
+
+// Receive returns the body of the Mailgun message in 'req' after validating its signature.
+func (wh MailgunReceiver) Receive(ctx context.Context, req *http.Request) ([]byte, *webhookd.WebhookError) {
+
+	select {
+	case <-ctx.Done():
+		return nil, nil
+	default:
+		// pass
+	}
+
+	_, body, err := ReadBody(ctx, req, wh.body_options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	content_type, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+
+	var sig *mailgunSignature
+
+	switch content_type {
+	case CONTENT_TYPE_FORM, CONTENT_TYPE_MULTIPART:
+
+		// Legacy webhooks include the signature as top-level form fields
+
+		opts := &BodyOptions{FormToJSON: true}
+
+		enc, err := DecodeBody(ctx, req, body, opts)
+
+		if err != nil {
+			return nil, err
+		}
+
+		var s mailgunSignature
+
+		decode_err := json.Unmarshal(enc, &s)
+
+		if decode_err != nil {
+			code := http.StatusBadRequest
+			message := "Missing or invalid signature"
+			return nil, &webhookd.WebhookError{Code: code, Message: message}
+		}
+
+		sig = &s
+		body = enc
+
+	default:
+
+		var m mailgunMessage
+
+		decode_err := json.Unmarshal(body, &m)
+
+		if decode_err != nil || m.Signature == nil {
+			code := http.StatusBadRequest
+			message := "Missing or invalid signature"
+			return nil, &webhookd.WebhookError{Code: code, Message: message}
+		}
+
+		sig = m.Signature
+	}
+
+	err = verifyTimestamp(sig.Timestamp, wh.freshness)
+
+	if err != nil {
+		return nil, err
+	}
+
+	verify := func(secret string) bool {
+
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(sig.Timestamp + sig.Token))
+
+		expected := hex.EncodeToString(mac.Sum(nil))
+		return hmac.Equal([]byte(expected), []byte(sig.Signature))
+	}
+
+	if !anySecret(wh.signing_keys, verify) {
+		code := http.StatusForbidden
+		message := "Invalid signature"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	return body, nil
+}
@@ -0,0 +1,108 @@
+package receiver
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func mailgunTestSignature(key string, ts string, token string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(ts + token))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestMailgunReceiver(t *testing.T) {
+
+	ctx := context.Background()
+
+	r, err := NewReceiver(ctx, "mailgun://?signing_key=s33kret")
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	sig := mailgunTestSignature("s33kret", ts, "abc123")
+
+	tests := map[string]int{
+		fmt.Sprintf(`{"signature":{"timestamp":"%s","token":"abc123","signature":"%s"},"event-data":{"event":"delivered"}}`, ts, sig):     0,
+		fmt.Sprintf(`{"signature":{"timestamp":"%s","token":"abc124","signature":"%s"},"event-data":{"event":"delivered"}}`, ts, sig):     http.StatusForbidden,
+		fmt.Sprintf(`{"signature":{"timestamp":"1500000000","token":"abc123","signature":"%s"},"event-data":{"event":"delivered"}}`, sig): http.StatusForbidden,
+		`{"event-data":{"event":"delivered"}}`: http.StatusBadRequest,
+	}
+
+	for msg, expected := range tests {
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/mailgun", bytes.NewReader([]byte(msg)))
+
+		if err != nil {
+			t.Fatalf("Failed to create new request, %v", err)
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+
+		body, err2 := r.Receive(ctx, req)
+
+		if expected == 0 {
+
+			if err2 != nil {
+				t.Fatalf("Failed to receive message, %v", err2)
+			}
+
+			if !bytes.Equal(body, []byte(msg)) {
+				t.Fatalf("Unexpected output '%s'", string(body))
+			}
+
+			continue
+		}
+
+		if err2 == nil || err2.Code != expected {
+			t.Fatalf("Expected %d for %s but got %v", expected, msg, err2)
+		}
+	}
+}
+
+func TestMailgunReceiverForm(t *testing.T) {
+
+	ctx := context.Background()
+
+	r, err := NewReceiver(ctx, "mailgun://?signing_key=s33kret&max_age=0s")
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	form := url.Values{
+		"timestamp": []string{"1500000000"},
+		"token":     []string{"abc123"},
+		"signature": []string{mailgunTestSignature("s33kret", "1500000000", "abc123")},
+		"event":     []string{"delivered"},
+	}
+
+	req, err := http.NewRequest("POST", "http://localhost:8080/mailgun", bytes.NewReader([]byte(form.Encode())))
+
+	if err != nil {
+		t.Fatalf("Failed to create new request, %v", err)
+	}
+
+	req.Header.Set("Content-Type", CONTENT_TYPE_FORM)
+
+	body, err2 := r.Receive(ctx, req)
+
+	if err2 != nil {
+		t.Fatalf("Failed to receive message, %v", err2)
+	}
+
+	if !bytes.Contains(body, []byte(`"event":"delivered"`)) {
+		t.Fatalf("Unexpected output '%s'", string(body))
+	}
+}
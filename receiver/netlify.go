@@ -0,0 +1,125 @@
+package receiver
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+// NETLIFY_SIGNATURE_HEADER is the HTTP header containing the JSON Web Signature for a Netlify deploy notification.
+const NETLIFY_SIGNATURE_HEADER string = "X-Webhook-Signature"
+
+// NETLIFY_ISSUER is the issuer of the JSON Web Signatures for Netlify deploy notifications.
+const NETLIFY_ISSUER string = "netlify"
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterReceiver(ctx, "netlify", NewNetlifyReceiver)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// NetlifyReceiver implements the `webhookd.WebhookReceiver` interface for receiving Netlify deploy notifications.
+type NetlifyReceiver struct {
+	webhookd.WebhookReceiver
+	// secrets is the list of JWS secret tokens used to validate message signatures.
+	secrets []string
+	// body_options is the `BodyOptions` instance used to decode message bodies.
+	body_options *BodyOptions
+}
+
+// NewNetlifyReceiver returns a new `NetlifyReceiver` instance configured by 'uri' in the form of:
+//
+//	netlify://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `secret={STRING}` One or more (comma-separated) JWS secret tokens, defined in the deploy notification settings, used to validate message signatures. Required.
+// * Any of the parameters supported by `NewBodyOptionsFromQuery`.
+func NewNetlifyReceiver(ctx context.Context, uri string) (webhookd.WebhookReceiver, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	secrets := parseSecrets(q, "secret")
+
+	if len(secrets) == 0 {
+		return nil, fmt.Errorf("Missing ?secret= parameter")
+	}
+
+	body_opts, err := NewBodyOptionsFromQuery(q)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive body options, %w", err)
+	}
+
+	wh := NetlifyReceiver{
+		secrets:      secrets,
+		body_options: body_opts,
+	}
+
+	return wh, nil
+}
+
+// Receive returns the body of the Netlify message in 'req' after validating the JSON Web Signature in its `X-Webhook-Signature`
+// header, which must be issued by "netlify" and include the SHA-256 hash of the body.
+func (wh NetlifyReceiver) Receive(ctx context.Context, req *http.Request) ([]byte, *webhookd.WebhookError) {
+
+	select {
+	case <-ctx.Done():
+		return nil, nil
+	default:
+		// pass
+	}
+
+	token := req.Header.Get(NETLIFY_SIGNATURE_HEADER)
+
+	if token == "" {
+		code := http.StatusBadRequest
+		message := fmt.Sprintf("Missing %s header", NETLIFY_SIGNATURE_HEADER)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	var claims struct {
+		Issuer string `json:"iss"`
+		SHA256 string `json:"sha256"`
+	}
+
+	jwt_err := verifyHS256JWT(token, wh.secrets, &claims)
+
+	if jwt_err != nil || claims.Issuer != NETLIFY_ISSUER {
+		code := http.StatusForbidden
+		message := "Invalid signature"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	raw, body, err := ReadBody(ctx, req, wh.body_options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(VerifiableBody(raw, body, wh.body_options))
+
+	if !hmac.Equal([]byte(hex.EncodeToString(sum[:])), []byte(strings.ToLower(claims.SHA256))) {
+		code := http.StatusForbidden
+		message := "Invalid signature"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	return DecodeBody(ctx, req, body, wh.body_options)
+}
@@ -0,0 +1,64 @@
+package receiver
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestNetlifyReceiver(t *testing.T) {
+
+	ctx := context.Background()
+
+	r, err := NewReceiver(ctx, "netlify://?secret=s33kret")
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	body := []byte(`{"id":"1","state":"ready","context":"production"}`)
+
+	sum := sha256.Sum256(body)
+	hash := hex.EncodeToString(sum[:])
+
+	tests := map[string]int{
+		atlassianTestJWT("s33kret", fmt.Sprintf(`{"iss":"netlify","sha256":"%s"}`, hash)): 0,
+		atlassianTestJWT("wr0ng", fmt.Sprintf(`{"iss":"netlify","sha256":"%s"}`, hash)):   http.StatusForbidden,
+		atlassianTestJWT("s33kret", fmt.Sprintf(`{"iss":"example","sha256":"%s"}`, hash)): http.StatusForbidden,
+		atlassianTestJWT("s33kret", `{"iss":"netlify","sha256":"abc"}`):                   http.StatusForbidden,
+	}
+
+	for token, expected := range tests {
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/netlify", bytes.NewReader(body))
+
+		if err != nil {
+			t.Fatalf("Failed to create new request, %v", err)
+		}
+
+		req.Header.Set(NETLIFY_SIGNATURE_HEADER, token)
+
+		rsp, err2 := r.Receive(ctx, req)
+
+		if expected == 0 {
+
+			if err2 != nil {
+				t.Fatalf("Failed to receive message, %v", err2)
+			}
+
+			if !bytes.Equal(rsp, body) {
+				t.Fatalf("Unexpected output '%s'", string(rsp))
+			}
+
+			continue
+		}
+
+		if err2 == nil || err2.Code != expected {
+			t.Fatalf("Expected %d for %s but got %v", expected, token, err2)
+		}
+	}
+}
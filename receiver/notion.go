@@ -0,0 +1,143 @@
+package receiver
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+// NOTION_SIGNATURE_HEADER is the HTTP header containing the signature for a Notion webhook message.
+const NOTION_SIGNATURE_HEADER string = "X-Notion-Signature"
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterReceiver(ctx, "notion", NewNotionReceiver)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// notionVerification is the message Notion sends to verify a new webhook subscription.
+type notionVerification struct {
+	VerificationToken string `json:"verification_token"`
+}
+
+// NotionReceiver implements the `webhookd.WebhookReceiver` interface for receiving Notion webhook messages.
+type NotionReceiver struct {
+	webhookd.WebhookReceiver
+	// verification_tokens is the list of Notion verification tokens used to validate message signatures.
+	verification_tokens []string
+	// body_options is the `BodyOptions` instance used to decode message bodies.
+	body_options *BodyOptions
+}
+
+// NewNotionReceiver returns a new `NotionReceiver` instance configured by 'uri' in the form of:
+//
+//	notion://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `verification_token={STRING}` One or more (comma-separated) verification tokens, sent by Notion when a webhook subscription is created, used to validate message signatures.
+// * Any of the parameters supported by `NewBodyOptionsFromQuery`.
+//
+// If `verification_token` is empty the receiver will only accept the verification request that Notion sends when a webhook
+// subscription is created, and reject all other messages, so that the token can be retrieved from the webhookd logs.
+func NewNotionReceiver(ctx context.Context, uri string) (webhookd.WebhookReceiver, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	body_opts, err := NewBodyOptionsFromQuery(q)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive body options, %w", err)
+	}
+
+	wh := NotionReceiver{
+		verification_tokens: parseSecrets(q, "verification_token"),
+		body_options:        body_opts,
+	}
+
+	return wh, nil
+}
+
+// Receive returns the body of the Notion message in 'req' after validating its `X-Notion-Signature` header. Verification
+// requests return a `webhookd.UnhandledEvent` error, whose message contains the verification token if the receiver has not
+// been configured with one.
+func (wh NotionReceiver) Receive(ctx context.Context, req *http.Request) ([]byte, *webhookd.WebhookError) {
+
+	select {
+	case <-ctx.Done():
+		return nil, nil
+	default:
+		// pass
+	}
+
+	raw, body, err := ReadBody(ctx, req, wh.body_options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sig := req.Header.Get(NOTION_SIGNATURE_HEADER)
+
+	// Verification requests are not signed
+
+	if sig == "" {
+
+		var v notionVerification
+
+		decode_err := json.Unmarshal(body, &v)
+
+		if decode_err != nil || v.VerificationToken == "" {
+			code := http.StatusBadRequest
+			message := fmt.Sprintf("Missing %s header", NOTION_SIGNATURE_HEADER)
+			return nil, &webhookd.WebhookError{Code: code, Message: message}
+		}
+
+		code := webhookd.UnhandledEvent
+		message := "Received verification request"
+
+		if len(wh.verification_tokens) == 0 {
+			message = fmt.Sprintf("Received verification request, verification token is '%s'", v.VerificationToken)
+		}
+
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	if len(wh.verification_tokens) == 0 {
+		code := http.StatusForbidden
+		message := "Receiver does not have a verification token"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	verify := func(verification_token string) bool {
+
+		mac := hmac.New(sha256.New, []byte(verification_token))
+		mac.Write(VerifiableBody(raw, body, wh.body_options))
+
+		expected := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+		return hmac.Equal([]byte(expected), []byte(sig))
+	}
+
+	if !anySecret(wh.verification_tokens, verify) {
+		code := http.StatusForbidden
+		message := "Invalid signature"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	return body, nil
+}
@@ -0,0 +1,104 @@
+package receiver
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+func TestNotionReceiverVerification(t *testing.T) {
+
+	ctx := context.Background()
+
+	r, err := NewReceiver(ctx, "notion://")
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	req, err := http.NewRequest("POST", "http://localhost:8080/notion", bytes.NewReader([]byte(`{"verification_token":"secret_abc123"}`)))
+
+	if err != nil {
+		t.Fatalf("Failed to create new request, %v", err)
+	}
+
+	_, err2 := r.Receive(ctx, req)
+
+	if err2 == nil || err2.Code != webhookd.UnhandledEvent || !strings.Contains(err2.Message, "secret_abc123") {
+		t.Fatalf("Expected verification request to report token, %v", err2)
+	}
+
+	req, err = http.NewRequest("POST", "http://localhost:8080/notion", bytes.NewReader([]byte(`{"type":"page.created"}`)))
+
+	if err != nil {
+		t.Fatalf("Failed to create new request, %v", err)
+	}
+
+	req.Header.Set(NOTION_SIGNATURE_HEADER, "sha256=abc")
+
+	_, err2 = r.Receive(ctx, req)
+
+	if err2 == nil || err2.Code != http.StatusForbidden {
+		t.Fatalf("Expected message without a configured token to fail, %v", err2)
+	}
+}
+
+func TestNotionReceiver(t *testing.T) {
+
+	ctx := context.Background()
+
+	r, err := NewReceiver(ctx, "notion://?verification_token=secret_abc123")
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	body := []byte(`{"id":"1","type":"page.created","entity":{"id":"2","type":"page"}}`)
+
+	mac := hmac.New(sha256.New, []byte("secret_abc123"))
+	mac.Write(body)
+
+	sig := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	tests := map[string]int{
+		string(body): 0,
+		`{"id":"1","type":"page.deleted","entity":{"id":"2","type":"page"}}`: http.StatusForbidden,
+	}
+
+	for msg, expected := range tests {
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/notion", bytes.NewReader([]byte(msg)))
+
+		if err != nil {
+			t.Fatalf("Failed to create new request, %v", err)
+		}
+
+		req.Header.Set(NOTION_SIGNATURE_HEADER, sig)
+
+		rsp, err2 := r.Receive(ctx, req)
+
+		if expected == 0 {
+
+			if err2 != nil {
+				t.Fatalf("Failed to receive message, %v", err2)
+			}
+
+			if !bytes.Equal(rsp, body) {
+				t.Fatalf("Unexpected output '%s'", string(rsp))
+			}
+
+			continue
+		}
+
+		if err2 == nil || err2.Code != expected {
+			t.Fatalf("Expected %d for %s but got %v", expected, msg, err2)
+		}
+	}
+}
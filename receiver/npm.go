@@ -0,0 +1,127 @@
+package receiver
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+// NPM_SIGNATURE_HEADER is the HTTP header containing the signature for an npm hook message.
+const NPM_SIGNATURE_HEADER string = "X-Npm-Signature"
+
+// NPM_EVENT_HEADER is the HTTP header, set by `NPMReceiver`, containing the event type of an npm hook message.
+const NPM_EVENT_HEADER string = "X-Npm-Event"
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterReceiver(ctx, "npm", NewNPMReceiver)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// NPMReceiver implements the `webhookd.WebhookReceiver` interface for receiving npm hook messages.
+type NPMReceiver struct {
+	webhookd.WebhookReceiver
+	// secrets is the list of hook secrets used to validate message signatures.
+	secrets []string
+	// body_options is the `BodyOptions` instance used to decode message bodies.
+	body_options *BodyOptions
+}
+
+// NewNPMReceiver returns a new `NPMReceiver` instance configured by 'uri' in the form of:
+//
+//	npm://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `secret={STRING}` One or more (comma-separated) secrets, defined when the hook was created, used to validate message signatures. Required.
+// * Any of the parameters supported by `NewBodyOptionsFromQuery`.
+func NewNPMReceiver(ctx context.Context, uri string) (webhookd.WebhookReceiver, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	secrets := parseSecrets(q, "secret")
+
+	if len(secrets) == 0 {
+		return nil, fmt.Errorf("Missing ?secret= parameter")
+	}
+
+	body_opts, err := NewBodyOptionsFromQuery(q)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive body options, %w", err)
+	}
+
+	wh := NPMReceiver{
+		secrets:      secrets,
+		body_options: body_opts,
+	}
+
+	return wh, nil
+}
+
+// Receive returns the body of the npm message in 'req' after validating its `x-npm-signature` header. The `X-Npm-Event` header
+// of 'req' is set to the message's `event` property (for example "package:publish").
+func (wh NPMReceiver) Receive(ctx context.Context, req *http.Request) ([]byte, *webhookd.WebhookError) {
+
+	select {
+	case <-ctx.Done():
+		return nil, nil
+	default:
+		// pass
+	}
+
+	sig := req.Header.Get(NPM_SIGNATURE_HEADER)
+
+	if sig == "" {
+		code := http.StatusBadRequest
+		message := fmt.Sprintf("Missing %s header", NPM_SIGNATURE_HEADER)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	raw, body, err := ReadBody(ctx, req, wh.body_options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	verify := func(secret string) bool {
+
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(VerifiableBody(raw, body, wh.body_options))
+
+		expected := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+		return hmac.Equal([]byte(expected), []byte(sig))
+	}
+
+	if !anySecret(wh.secrets, verify) {
+		code := http.StatusForbidden
+		message := "Invalid signature"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	var ev struct {
+		Event string `json:"event"`
+	}
+
+	if json.Unmarshal(body, &ev) == nil && ev.Event != "" {
+		req.Header.Set(NPM_EVENT_HEADER, ev.Event)
+	}
+
+	return DecodeBody(ctx, req, body, wh.body_options)
+}
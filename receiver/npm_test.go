@@ -0,0 +1,68 @@
+package receiver
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"testing"
+)
+
+func TestNPMReceiver(t *testing.T) {
+
+	ctx := context.Background()
+
+	r, err := NewReceiver(ctx, "npm://?secret=s33kret")
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	body := []byte(`{"event":"package:publish","name":"@example/widget","type":"package","change":{"version":"1.2.3"}}`)
+
+	mac := hmac.New(sha256.New, []byte("s33kret"))
+	mac.Write(body)
+
+	sig := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	tests := map[string]int{
+		string(body): 0,
+		`{"event":"package:unpublish","name":"@example/widget"}`: http.StatusForbidden,
+	}
+
+	for msg, expected := range tests {
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/npm", bytes.NewReader([]byte(msg)))
+
+		if err != nil {
+			t.Fatalf("Failed to create new request, %v", err)
+		}
+
+		req.Header.Set(NPM_SIGNATURE_HEADER, sig)
+
+		rsp, err2 := r.Receive(ctx, req)
+
+		if expected == 0 {
+
+			if err2 != nil {
+				t.Fatalf("Failed to receive message, %v", err2)
+			}
+
+			if !bytes.Equal(rsp, body) {
+				t.Fatalf("Unexpected output '%s'", string(rsp))
+			}
+
+			if req.Header.Get(NPM_EVENT_HEADER) != "package:publish" {
+				t.Fatalf("Unexpected %s header '%s'", NPM_EVENT_HEADER, req.Header.Get(NPM_EVENT_HEADER))
+			}
+
+			continue
+		}
+
+		if err2 == nil || err2.Code != expected {
+			t.Fatalf("Expected %d for %s but got %v", expected, msg, err2)
+		}
+	}
+}
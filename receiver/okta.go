@@ -0,0 +1,154 @@
+package receiver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+// OKTA_VERIFICATION_HEADER is the HTTP header containing the one-time verification challenge for an Okta event hook.
+const OKTA_VERIFICATION_HEADER string = "X-Okta-Verification-Challenge"
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterReceiver(ctx, "okta", NewOktaReceiver)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// OktaReceiver implements the `webhookd.WebhookReceiver` interface for receiving Okta event hook messages.
+type OktaReceiver struct {
+	webhookd.WebhookReceiver
+	// authorizations is the list of valid values for the `Authorization` header that requests must include.
+	authorizations []string
+	// headers are the additional HTTP headers that requests must include.
+	headers http.Header
+	// body_options is the `BodyOptions` instance used to decode message bodies.
+	body_options *BodyOptions
+}
+
+// NewOktaReceiver returns a new `OktaReceiver` instance configured by 'uri' in the form of:
+//
+//	okta://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `authorization={STRING}` One or more (comma-separated) valid values for the `Authorization` header, as configured for the event hook, that requests must include. Required.
+// * `header={NAME}:{VALUE}` Zero or more custom headers, as configured for the event hook, that requests must include.
+// * Any of the parameters supported by `NewBodyOptionsFromQuery`.
+//
+// Okta verifies event hooks using a GET request so the webhook must be configured to accept both the GET and POST methods.
+func NewOktaReceiver(ctx context.Context, uri string) (webhookd.WebhookReceiver, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	authorizations := parseSecrets(q, "authorization")
+
+	if len(authorizations) == 0 {
+		return nil, fmt.Errorf("Missing ?authorization= parameter")
+	}
+
+	headers := http.Header{}
+
+	for _, h := range q["header"] {
+
+		parts := strings.SplitN(h, ":", 2)
+
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("Invalid ?header= parameter '%s'", h)
+		}
+
+		headers.Add(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]))
+	}
+
+	body_opts, err := NewBodyOptionsFromQuery(q)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive body options, %w", err)
+	}
+
+	wh := OktaReceiver{
+		authorizations: authorizations,
+		headers:        headers,
+		body_options:   body_opts,
+	}
+
+	return wh, nil
+}
+
+// Receive returns the body of the Okta event hook message in 'req' after checking its `Authorization` and custom headers.
+// One-time verification requests are answered using `webhookd.SetChallengeResponse` and return a `webhookd.UnhandledEvent` error.
+func (wh OktaReceiver) Receive(ctx context.Context, req *http.Request) ([]byte, *webhookd.WebhookError) {
+
+	select {
+	case <-ctx.Done():
+		return nil, nil
+	default:
+		// pass
+	}
+
+	ok := constantTimeEqualAny(req.Header.Get("Authorization"), wh.authorizations)
+
+	for k := range wh.headers {
+		ok = ok && constantTimeEqual(req.Header.Get(k), wh.headers.Get(k))
+	}
+
+	if !ok {
+		code := http.StatusUnauthorized
+		message := "Unauthorized"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	if req.Method == "GET" {
+		return nil, wh.answerChallenge(ctx, req.Header.Get(OKTA_VERIFICATION_HEADER))
+	}
+
+	_, body, err := ReadBody(ctx, req, wh.body_options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodeBody(ctx, req, body, wh.body_options)
+}
+
+// answerChallenge answers the one-time verification challenge 'challenge'.
+func (wh OktaReceiver) answerChallenge(ctx context.Context, challenge string) *webhookd.WebhookError {
+
+	if challenge == "" {
+		code := http.StatusBadRequest
+		message := fmt.Sprintf("Missing %s header", OKTA_VERIFICATION_HEADER)
+		return &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	enc, err := json.Marshal(map[string]string{"verification": challenge})
+
+	if err != nil {
+		code := http.StatusInternalServerError
+		message := fmt.Sprintf("Failed to encode verification response, %v", err)
+		return &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	if !webhookd.SetChallengeResponse(ctx, "application/json", enc) {
+		code := http.StatusInternalServerError
+		message := "Context does not support challenge responses"
+		return &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	code := webhookd.UnhandledEvent
+	message := "Answered event hook verification challenge"
+	return &webhookd.WebhookError{Code: code, Message: message}
+}
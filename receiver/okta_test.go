@@ -0,0 +1,79 @@
+package receiver
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+func TestOktaReceiver(t *testing.T) {
+
+	ctx := webhookd.WithChallengeResponse(context.Background())
+
+	r, err := NewReceiver(ctx, "okta://?authorization=s33kret&header=X-Environment:production")
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	req, err := http.NewRequest("GET", "http://localhost:8080/okta", nil)
+
+	if err != nil {
+		t.Fatalf("Failed to create new request, %v", err)
+	}
+
+	req.Header.Set("Authorization", "s33kret")
+	req.Header.Set("X-Environment", "production")
+	req.Header.Set(OKTA_VERIFICATION_HEADER, "abc123")
+
+	_, err2 := r.Receive(ctx, req)
+
+	if err2 == nil || err2.Code != webhookd.UnhandledEvent {
+		t.Fatalf("Expected verification to return an unhandled event, %v", err2)
+	}
+
+	_, enc, ok := webhookd.ChallengeResponse(ctx)
+
+	if !ok || string(enc) != `{"verification":"abc123"}` {
+		t.Fatalf("Unexpected verification response '%s'", string(enc))
+	}
+
+	body := []byte(`{"eventType":"com.okta.event_hook","data":{"events":[]}}`)
+
+	req, err = http.NewRequest("POST", "http://localhost:8080/okta", bytes.NewReader(body))
+
+	if err != nil {
+		t.Fatalf("Failed to create new request, %v", err)
+	}
+
+	req.Header.Set("Authorization", "s33kret")
+	req.Header.Set("X-Environment", "production")
+
+	rsp, err2 := r.Receive(ctx, req)
+
+	if err2 != nil {
+		t.Fatalf("Failed to receive message, %v", err2)
+	}
+
+	if !bytes.Equal(rsp, body) {
+		t.Fatalf("Unexpected output '%s'", string(rsp))
+	}
+
+	req, err = http.NewRequest("POST", "http://localhost:8080/okta", bytes.NewReader(body))
+
+	if err != nil {
+		t.Fatalf("Failed to create new request, %v", err)
+	}
+
+	req.Header.Set("Authorization", "s33kret")
+	req.Header.Set("X-Environment", "staging")
+
+	_, err2 = r.Receive(ctx, req)
+
+	if err2 == nil || err2.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected invalid custom header to fail, %v", err2)
+	}
+}
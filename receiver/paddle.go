@@ -0,0 +1,184 @@
+package receiver
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+// PADDLE_SIGNATURE_HEADER is the HTTP header containing the signature for a Paddle Billing notification.
+const PADDLE_SIGNATURE_HEADER string = "Paddle-Signature"
+
+// PADDLE_EVENT_HEADER is the HTTP header, set by `PaddleReceiver`, containing the event type of a Paddle Billing notification.
+const PADDLE_EVENT_HEADER string = "X-Paddle-Event"
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterReceiver(ctx, "paddle", NewPaddleReceiver)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// PaddleReceiver implements the `webhookd.WebhookReceiver` interface for receiving Paddle Billing notifications.
+type PaddleReceiver struct {
+	webhookd.WebhookReceiver
+	// secrets is the list of notification destination secret keys used to validate message signatures.
+	secrets []string
+	// freshness is the `FreshnessOptions` instance used to reject messages with stale timestamps.
+	freshness *FreshnessOptions
+	// body_options is the `BodyOptions` instance used to decode message bodies.
+	body_options *BodyOptions
+}
+
+// NewPaddleReceiver returns a new `PaddleReceiver` instance configured by 'uri' in the form of:
+//
+//	paddle://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `secret={STRING}` One or more (comma-separated) notification destination secret keys used to validate message signatures. Required.
+// * `max_age={DURATION}` The maximum age of a message, derived from its timestamp. A value of "0s" disables this check. Default is "5m".
+// * `max_skew={DURATION}` The maximum amount of time that the timestamp of a message may be ahead of the current time. A value of "0s" disables this check. Default is the value of `max_age`.
+// * Any of the parameters supported by `NewBodyOptionsFromQuery`.
+func NewPaddleReceiver(ctx context.Context, uri string) (webhookd.WebhookReceiver, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	secrets := parseSecrets(q, "secret")
+
+	if len(secrets) == 0 {
+		return nil, fmt.Errorf("Missing ?secret= parameter")
+	}
+
+	freshness, err := NewFreshnessOptionsFromQuery(q)
+
+	if err != nil {
+		return nil, err
+	}
+
+	body_opts, err := NewBodyOptionsFromQuery(q)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive body options, %w", err)
+	}
+
+	wh := PaddleReceiver{
+		secrets:      secrets,
+		freshness:    freshness,
+		body_options: body_opts,
+	}
+
+	return wh, nil
+}
+
+// Receive returns the body of the Paddle notification in 'req' after validating its `Paddle-Signature` header. The `X-Paddle-Event`
+// header of 'req' is set to the notification's `event_type` property (for example "subscription.created").
+func (wh PaddleReceiver) Receive(ctx context.Context, req *http.Request) ([]byte, *webhookd.WebhookError) {
+
+	select {
+	case <-ctx.Done():
+		return nil, nil
+	default:
+		// pass
+	}
+
+	header := req.Header.Get(PADDLE_SIGNATURE_HEADER)
+
+	if header == "" {
+		code := http.StatusBadRequest
+		message := fmt.Sprintf("Missing %s header", PADDLE_SIGNATURE_HEADER)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	// The header is in the form of "ts={TIMESTAMP};h1={SIGNATURE}". There may be more than one "h1" signature while
+	// a secret key is being rotated.
+
+	var str_ts string
+	signatures := make([]string, 0)
+
+	for _, pair := range strings.Split(header, ";") {
+
+		parts := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+
+		if len(parts) != 2 {
+			continue
+		}
+
+		switch parts[0] {
+		case "ts":
+			str_ts = parts[1]
+		case "h1":
+			signatures = append(signatures, parts[1])
+		}
+	}
+
+	if len(signatures) == 0 {
+		code := http.StatusBadRequest
+		message := fmt.Sprintf("Invalid %s header", PADDLE_SIGNATURE_HEADER)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	err := verifyTimestamp(str_ts, wh.freshness)
+
+	if err != nil {
+		return nil, err
+	}
+
+	raw, body, err := ReadBody(ctx, req, wh.body_options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	verify := func(secret string) bool {
+
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(str_ts + ":"))
+		mac.Write(VerifiableBody(raw, body, wh.body_options))
+
+		expected := hex.EncodeToString(mac.Sum(nil))
+
+		for _, sig := range signatures {
+
+			if hmac.Equal([]byte(expected), []byte(sig)) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	ok := anySecret(wh.secrets, verify)
+
+	if !ok {
+		code := http.StatusForbidden
+		message := "Invalid signature"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	var ev struct {
+		EventType string `json:"event_type"`
+	}
+
+	if json.Unmarshal(body, &ev) == nil && ev.EventType != "" {
+		req.Header.Set(PADDLE_EVENT_HEADER, ev.EventType)
+	}
+
+	return DecodeBody(ctx, req, body, wh.body_options)
+}
@@ -0,0 +1,76 @@
+package receiver
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestPaddleReceiver(t *testing.T) {
+
+	ctx := context.Background()
+
+	r, err := NewReceiver(ctx, "paddle://?secret=s33kret")
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	body := []byte(`{"event_id":"evt_1","event_type":"subscription.created","data":{"id":"sub_1"}}`)
+
+	sign := func(key string, ts int64) string {
+		mac := hmac.New(sha256.New, []byte(key))
+		mac.Write([]byte(fmt.Sprintf("%d:%s", ts, body)))
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+
+	now := time.Now().Unix()
+
+	tests := map[string]int{
+		fmt.Sprintf("ts=%d;h1=%s", now, sign("s33kret", now)):                         0,
+		fmt.Sprintf("ts=%d;h1=%s;h1=%s", now, sign("0ld", now), sign("s33kret", now)): 0,
+		fmt.Sprintf("ts=%d;h1=%s", now, sign("wr0ng", now)):                           http.StatusForbidden,
+		fmt.Sprintf("ts=%d;h1=%s", now-3600, sign("s33kret", now-3600)):               http.StatusForbidden,
+		fmt.Sprintf("ts=%d", now):                                                     http.StatusBadRequest,
+	}
+
+	for header, expected := range tests {
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/paddle", bytes.NewReader(body))
+
+		if err != nil {
+			t.Fatalf("Failed to create new request, %v", err)
+		}
+
+		req.Header.Set(PADDLE_SIGNATURE_HEADER, header)
+
+		rsp, err2 := r.Receive(ctx, req)
+
+		if expected == 0 {
+
+			if err2 != nil {
+				t.Fatalf("Failed to receive message, %v", err2)
+			}
+
+			if !bytes.Equal(rsp, body) {
+				t.Fatalf("Unexpected output '%s'", string(rsp))
+			}
+
+			if req.Header.Get(PADDLE_EVENT_HEADER) != "subscription.created" {
+				t.Fatalf("Unexpected %s header '%s'", PADDLE_EVENT_HEADER, req.Header.Get(PADDLE_EVENT_HEADER))
+			}
+
+			continue
+		}
+
+		if err2 == nil || err2.Code != expected {
+			t.Fatalf("Expected %d for '%s' but got %v", expected, header, err2)
+		}
+	}
+}
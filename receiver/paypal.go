@@ -0,0 +1,320 @@
+package receiver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+// DEFAULT_PAYPAL_API is the default root URL for the PayPal REST API.
+const DEFAULT_PAYPAL_API string = "https://api-m.paypal.com"
+
+// PAYPAL_SANDBOX_API is the root URL for the PayPal sandbox REST API.
+const PAYPAL_SANDBOX_API string = "https://api-m.sandbox.paypal.com"
+
+// DEFAULT_PAYPAL_TIMEOUT is the default amount of time to wait for a PayPal API request to complete.
+const DEFAULT_PAYPAL_TIMEOUT time.Duration = 10 * time.Second
+
+// paypalHeaders is the list of HTTP headers, sent with each PayPal webhook message, that are required to verify its signature.
+var paypalHeaders = map[string]string{
+	"auth_algo":         "Paypal-Auth-Algo",
+	"cert_url":          "Paypal-Cert-Url",
+	"transmission_id":   "Paypal-Transmission-Id",
+	"transmission_sig":  "Paypal-Transmission-Sig",
+	"transmission_time": "Paypal-Transmission-Time",
+}
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterReceiver(ctx, "paypal", NewPayPalReceiver)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// PayPalReceiver implements the `webhookd.WebhookReceiver` interface for receiving PayPal webhook messages. Signatures are
+// verified using the PayPal "verify-webhook-signature" API.
+type PayPalReceiver struct {
+	webhookd.WebhookReceiver
+	// api is the root URL for the PayPal REST API.
+	api string
+	// client_id is the PayPal REST API client ID.
+	client_id string
+	// client_secret is the PayPal REST API client secret.
+	client_secret string
+	// webhook_id is the ID of the webhook, as assigned by PayPal.
+	webhook_id string
+	// client is the `http.Client` used to perform API requests.
+	client *http.Client
+	// token is the `paypalToken` used to cache API access tokens.
+	token *paypalToken
+	// body_options is the `BodyOptions` instance used to decode message bodies.
+	body_options *BodyOptions
+}
+
+// paypalToken is a cached PayPal API access token.
+type paypalToken struct {
+	mu      *sync.Mutex
+	value   string
+	expires time.Time
+}
+
+// NewPayPalReceiver returns a new `PayPalReceiver` instance configured by 'uri' in the form of:
+//
+//	paypal://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `client_id={STRING}` The PayPal REST API client ID. Required.
+// * `client_secret={STRING}` The PayPal REST API client secret. Required.
+// * `webhook_id={STRING}` The ID of the webhook, as assigned by PayPal. Required.
+// * `sandbox={BOOLEAN}` Use the PayPal sandbox API. Default is false.
+// * `api={URL}` The root URL for the PayPal REST API. Default is "https://api-m.paypal.com".
+// * `timeout={DURATION}` The amount of time to wait for a PayPal API request to complete. Default is "10s".
+// * Any of the parameters supported by `NewBodyOptionsFromQuery`.
+func NewPayPalReceiver(ctx context.Context, uri string) (webhookd.WebhookReceiver, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	required := []string{"client_id", "client_secret", "webhook_id"}
+
+	for _, k := range required {
+
+		if q.Get(k) == "" {
+			return nil, fmt.Errorf("Missing ?%s= parameter", k)
+		}
+	}
+
+	api := DEFAULT_PAYPAL_API
+
+	if q.Get("sandbox") != "" {
+
+		v, err := strconv.ParseBool(q.Get("sandbox"))
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse ?sandbox= parameter, %w", err)
+		}
+
+		if v {
+			api = PAYPAL_SANDBOX_API
+		}
+	}
+
+	if q.Get("api") != "" {
+		api = strings.TrimRight(q.Get("api"), "/")
+	}
+
+	timeout := DEFAULT_PAYPAL_TIMEOUT
+
+	if q.Get("timeout") != "" {
+
+		v, err := time.ParseDuration(q.Get("timeout"))
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse ?timeout= parameter, %w", err)
+		}
+
+		timeout = v
+	}
+
+	body_opts, err := NewBodyOptionsFromQuery(q)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive body options, %w", err)
+	}
+
+	wh := PayPalReceiver{
+		api:           api,
+		client_id:     q.Get("client_id"),
+		client_secret: q.Get("client_secret"),
+		webhook_id:    q.Get("webhook_id"),
+		client:        &http.Client{Timeout: timeout},
+		token:         &paypalToken{mu: new(sync.Mutex)},
+		body_options:  body_opts,
+	}
+
+	return wh, nil
+}
+
+// Receive returns the body of the PayPal message in 'req' after verifying its signature with the PayPal API.
+func (wh PayPalReceiver) Receive(ctx context.Context, req *http.Request) ([]byte, *webhookd.WebhookError) {
+
+	select {
+	case <-ctx.Done():
+		return nil, nil
+	default:
+		// pass
+	}
+
+	verification := map[string]interface{}{
+		"webhook_id": wh.webhook_id,
+	}
+
+	for k, h := range paypalHeaders {
+
+		v := req.Header.Get(h)
+
+		if v == "" {
+			code := http.StatusBadRequest
+			message := fmt.Sprintf("Missing %s header", h)
+			return nil, &webhookd.WebhookError{Code: code, Message: message}
+		}
+
+		verification[k] = v
+	}
+
+	_, body, err := ReadBody(ctx, req, wh.body_options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !json.Valid(body) {
+		code := http.StatusBadRequest
+		message := "Message body is not valid JSON"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	verification["webhook_event"] = json.RawMessage(body)
+
+	ok, verify_err := wh.verify(ctx, verification)
+
+	if verify_err != nil {
+		code := http.StatusBadGateway
+		message := fmt.Sprintf("Failed to verify signature, %v", verify_err)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	if !ok {
+		code := http.StatusForbidden
+		message := "Invalid signature"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	return body, nil
+}
+
+// verify returns a boolean value indicating whether the PayPal API reports that 'verification' has a valid signature.
+func (wh PayPalReceiver) verify(ctx context.Context, verification map[string]interface{}) (bool, error) {
+
+	token, err := wh.accessToken(ctx)
+
+	if err != nil {
+		return false, fmt.Errorf("Failed to retrieve access token, %w", err)
+	}
+
+	enc, err := json.Marshal(verification)
+
+	if err != nil {
+		return false, fmt.Errorf("Failed to encode verification request, %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, wh.api+"/v1/notifications/verify-webhook-signature", bytes.NewReader(enc))
+
+	if err != nil {
+		return false, fmt.Errorf("Failed to create request, %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	var rsp struct {
+		VerificationStatus string `json:"verification_status"`
+	}
+
+	err = wh.do(req, &rsp)
+
+	if err != nil {
+		return false, err
+	}
+
+	return rsp.VerificationStatus == "SUCCESS", nil
+}
+
+// accessToken returns a (cached) PayPal API access token.
+func (wh PayPalReceiver) accessToken(ctx context.Context) (string, error) {
+
+	wh.token.mu.Lock()
+	defer wh.token.mu.Unlock()
+
+	if wh.token.value != "" && time.Now().Before(wh.token.expires) {
+		return wh.token.value, nil
+	}
+
+	form := url.Values{
+		"grant_type": []string{"client_credentials"},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, wh.api+"/v1/oauth2/token", strings.NewReader(form.Encode()))
+
+	if err != nil {
+		return "", fmt.Errorf("Failed to create request, %w", err)
+	}
+
+	req.Header.Set("Content-Type", CONTENT_TYPE_FORM)
+	req.SetBasicAuth(wh.client_id, wh.client_secret)
+
+	var rsp struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+
+	err = wh.do(req, &rsp)
+
+	if err != nil {
+		return "", err
+	}
+
+	if rsp.AccessToken == "" {
+		return "", fmt.Errorf("Response does not contain an access token")
+	}
+
+	// Refresh tokens a minute before they actually expire
+
+	wh.token.value = rsp.AccessToken
+	wh.token.expires = time.Now().Add(time.Duration(rsp.ExpiresIn)*time.Second - time.Minute)
+
+	return wh.token.value, nil
+}
+
+// do executes 'req' and decodes its JSON-encoded response in to 'v'.
+func (wh PayPalReceiver) do(req *http.Request, v interface{}) error {
+
+	rsp, err := wh.client.Do(req)
+
+	if err != nil {
+		return fmt.Errorf("Failed to execute request, %w", err)
+	}
+
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode < 200 || rsp.StatusCode > 299 {
+		io.Copy(io.Discard, rsp.Body)
+		return fmt.Errorf("API returned unexpected status %s", rsp.Status)
+	}
+
+	err = json.NewDecoder(rsp.Body).Decode(v)
+
+	if err != nil {
+		return fmt.Errorf("Failed to decode response, %w", err)
+	}
+
+	return nil
+}
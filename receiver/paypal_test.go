@@ -0,0 +1,132 @@
+package receiver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+)
+
+func TestPayPalReceiver(t *testing.T) {
+
+	ctx := context.Background()
+
+	var token_requests int32
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/v1/oauth2/token", func(rsp http.ResponseWriter, req *http.Request) {
+
+		atomic.AddInt32(&token_requests, 1)
+
+		id, secret, ok := req.BasicAuth()
+
+		if !ok || id != "client" || secret != "s33kret" {
+			http.Error(rsp, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		rsp.Write([]byte(`{"access_token":"t0ken","expires_in":3600}`))
+	})
+
+	mux.HandleFunc("/v1/notifications/verify-webhook-signature", func(rsp http.ResponseWriter, req *http.Request) {
+
+		if req.Header.Get("Authorization") != "Bearer t0ken" {
+			http.Error(rsp, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var v struct {
+			TransmissionSig string          `json:"transmission_sig"`
+			WebhookID       string          `json:"webhook_id"`
+			WebhookEvent    json.RawMessage `json:"webhook_event"`
+		}
+
+		err := json.NewDecoder(req.Body).Decode(&v)
+
+		if err != nil {
+			http.Error(rsp, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		status := "FAILURE"
+
+		if v.TransmissionSig == "good" && v.WebhookID == "WH-1" && len(v.WebhookEvent) > 0 {
+			status = "SUCCESS"
+		}
+
+		rsp.Write([]byte(`{"verification_status":"` + status + `"}`))
+	})
+
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	r_uri := "paypal://?client_id=client&client_secret=s33kret&webhook_id=WH-1&api=" + url.QueryEscape(s.URL)
+
+	r, err := NewReceiver(ctx, r_uri)
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	body := []byte(`{"id":"WH-123","event_type":"PAYMENT.CAPTURE.COMPLETED"}`)
+
+	tests := map[string]int{
+		"good": 0,
+		"bad":  http.StatusForbidden,
+	}
+
+	for sig, expected := range tests {
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/paypal", bytes.NewReader(body))
+
+		if err != nil {
+			t.Fatalf("Failed to create new request, %v", err)
+		}
+
+		req.Header.Set("Paypal-Auth-Algo", "SHA256withRSA")
+		req.Header.Set("Paypal-Cert-Url", "https://api.paypal.com/v1/notifications/certs/CERT-1")
+		req.Header.Set("Paypal-Transmission-Id", "1234")
+		req.Header.Set("Paypal-Transmission-Sig", sig)
+		req.Header.Set("Paypal-Transmission-Time", "2024-01-01T00:00:00Z")
+
+		rsp, err2 := r.Receive(ctx, req)
+
+		if expected == 0 {
+
+			if err2 != nil {
+				t.Fatalf("Failed to receive message, %v", err2)
+			}
+
+			if !bytes.Equal(rsp, body) {
+				t.Fatalf("Unexpected output '%s'", string(rsp))
+			}
+
+			continue
+		}
+
+		if err2 == nil || err2.Code != expected {
+			t.Fatalf("Expected %d for %s but got %v", expected, sig, err2)
+		}
+	}
+
+	if atomic.LoadInt32(&token_requests) != 1 {
+		t.Fatalf("Expected access token to be cached")
+	}
+
+	req, err := http.NewRequest("POST", "http://localhost:8080/paypal", bytes.NewReader(body))
+
+	if err != nil {
+		t.Fatalf("Failed to create new request, %v", err)
+	}
+
+	_, err2 := r.Receive(ctx, req)
+
+	if err2 == nil || err2.Code != http.StatusBadRequest {
+		t.Fatalf("Expected request without headers to fail, %v", err2)
+	}
+}
@@ -0,0 +1,140 @@
+package receiver
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+	"github.com/whosonfirst/go-webhookd/v3/signature"
+)
+
+// PHABRICATOR_SIGNATURE_HEADER is the HTTP header containing the signature for a Phabricator webhook message.
+const PHABRICATOR_SIGNATURE_HEADER string = "X-Phabricator-Webhook-Signature"
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterReceiver(ctx, "phabricator", NewPhabricatorReceiver)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// PhabricatorReceiver implements the `webhookd.WebhookReceiver` interface for receiving Phabricator webhook messages.
+type PhabricatorReceiver struct {
+	webhookd.WebhookReceiver
+	// hmac_keys is the list of webhook HMAC keys used to validate message signatures.
+	hmac_keys []string
+	// body_options is the `BodyOptions` instance used to decode message bodies.
+	body_options *BodyOptions
+}
+
+// NewPhabricatorReceiver returns a new `PhabricatorReceiver` instance configured by 'uri' in the form of:
+//
+//	phabricator://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `hmac_key={STRING}` One or more (comma-separated) HMAC keys, shown on the webhook's page in Phabricator, used to validate message signatures. Required.
+// * Any of the parameters supported by `NewBodyOptionsFromQuery`.
+func NewPhabricatorReceiver(ctx context.Context, uri string) (webhookd.WebhookReceiver, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	hmac_keys := parseSecrets(q, "hmac_key")
+
+	if len(hmac_keys) == 0 {
+		return nil, fmt.Errorf("Missing ?hmac_key= parameter")
+	}
+
+	body_opts, err := NewBodyOptionsFromQuery(q)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive body options, %w", err)
+	}
+
+	wh := PhabricatorReceiver{
+		hmac_keys:    hmac_keys,
+		body_options: body_opts,
+	}
+
+	return wh, nil
+}
+
+// Receive returns the body of the Phabricator message in 'req' after validating its `X-Phabricator-Webhook-Signature` header.
+func (wh PhabricatorReceiver) Receive(ctx context.Context, req *http.Request) ([]byte, *webhookd.WebhookError) {
+
+	select {
+	case <-ctx.Done():
+		return nil, nil
+	default:
+		// pass
+	}
+
+	sig := req.Header.Get(PHABRICATOR_SIGNATURE_HEADER)
+
+	if sig == "" {
+		code := http.StatusBadRequest
+		message := fmt.Sprintf("Missing %s header", PHABRICATOR_SIGNATURE_HEADER)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	raw, body, err := ReadBody(ctx, req, wh.body_options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	verifiable := VerifiableBody(raw, body, wh.body_options)
+
+	verify := func(secret string) bool {
+		sum := signature.HMAC(sha256.New, []byte(secret), verifiable)
+		return signature.Equal(hex.EncodeToString(sum), strings.ToLower(sig))
+	}
+
+	if !anySecret(wh.hmac_keys, verify) {
+		code := http.StatusForbidden
+		message := "Invalid signature"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	return DecodeBody(ctx, req, body, wh.body_options)
+}
+
+// ReceiveStream returns an `io.ReadCloser` for the body of the Phabricator message in 'req' which validates its HMAC-SHA256
+// signature header as it is read, returning `ErrInvalidSignature` rather than `io.EOF` if validation fails.
+func (wh PhabricatorReceiver) ReceiveStream(ctx context.Context, req *http.Request) (io.ReadCloser, *webhookd.WebhookError) {
+
+	select {
+	case <-ctx.Done():
+		return nil, nil
+	default:
+		// pass
+	}
+
+	sig := req.Header.Get(PHABRICATOR_SIGNATURE_HEADER)
+
+	if sig == "" {
+		code := http.StatusBadRequest
+		message := fmt.Sprintf("Missing %s header", PHABRICATOR_SIGNATURE_HEADER)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	verify := func(sum []byte) bool {
+		return signature.Equal(hex.EncodeToString(sum), strings.ToLower(sig))
+	}
+
+	return ReadVerifiedBodyStream(ctx, req, wh.body_options, sha256.New, wh.hmac_keys, verify)
+}
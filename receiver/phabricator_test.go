@@ -0,0 +1,79 @@
+package receiver
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"testing"
+)
+
+func TestPhabricatorReceiver(t *testing.T) {
+
+	ctx := context.Background()
+
+	r, err := NewReceiver(ctx, "phabricator://?hmac_key=s33kret")
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	body := []byte(`{"object":{"type":"TASK","phid":"PHID-TASK-1234"},"triggers":[],"action":{"test":false,"silent":false,"secure":false,"epoch":1614265330},"transactions":[]}`)
+
+	mac := hmac.New(sha256.New, []byte("s33kret"))
+	mac.Write(body)
+
+	sig := hex.EncodeToString(mac.Sum(nil))
+
+	tests := map[string]int{
+		string(body):                       0,
+		`{"object":{"type":"DREV"}}`:       http.StatusForbidden,
+		`{"object":{"type":"TASK"},"x":1}`: http.StatusForbidden,
+	}
+
+	for msg, expected := range tests {
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/phabricator", bytes.NewReader([]byte(msg)))
+
+		if err != nil {
+			t.Fatalf("Failed to create new request, %v", err)
+		}
+
+		req.Header.Set(PHABRICATOR_SIGNATURE_HEADER, sig)
+
+		rsp, err2 := r.Receive(ctx, req)
+
+		if expected == 0 {
+
+			if err2 != nil {
+				t.Fatalf("Failed to receive message, %v", err2)
+			}
+
+			if !bytes.Equal(rsp, body) {
+				t.Fatalf("Unexpected output '%s'", string(rsp))
+			}
+
+			continue
+		}
+
+		if err2 == nil || err2.Code != expected {
+			t.Fatalf("Expected %d for %s but got %v", expected, msg, err2)
+		}
+	}
+
+	req, _ := http.NewRequest("POST", "http://localhost:8080/phabricator", bytes.NewReader(body))
+
+	_, err2 := r.Receive(ctx, req)
+
+	if err2 == nil || err2.Code != http.StatusBadRequest {
+		t.Fatalf("Expected missing signature to fail, %v", err2)
+	}
+
+	_, err = NewReceiver(ctx, "phabricator://")
+
+	if err == nil {
+		t.Fatalf("Expected receiver without an HMAC key to fail")
+	}
+}
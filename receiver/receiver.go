@@ -9,6 +9,7 @@ import (
 
 	"github.com/aaronland/go-roster"
 	"github.com/whosonfirst/go-webhookd/v3"	
+	"github.com/whosonfirst/go-webhookd/v3/secrets"
 )
 
 // receiver is a `aaronland/go-roster.Roster` instance used to maintain a list of registered `webhookd.WebhookReceiver` initialization functions.
@@ -18,7 +19,8 @@ var receivers roster.Roster
 type ReceiverInitializationFunc func(ctx context.Context, uri string) (webhookd.WebhookReceiver, error)
 
 // NewReceiver() returns a new `webhookd.WebhookReceiver` instance derived from 'uri'. The semantics of and requirements for
-// 'uri' as specific to the package implementing the interface.
+// 'uri' as specific to the package implementing the interface. If 'uri' contains `secrets://` URIs they are resolved and the
+// receiver is wrapped in a `RefreshingReceiver` so that changes to those secrets are picked up without a restart.
 func NewReceiver(ctx context.Context, uri string) (webhookd.WebhookReceiver, error) {
 
 	err := ensureReceiverRoster()
@@ -27,7 +29,13 @@ func NewReceiver(ctx context.Context, uri string) (webhookd.WebhookReceiver, err
 		return nil, fmt.Errorf("Failed to ensure receiver roster, %w", err)
 	}
 
-	parsed, err := url.Parse(uri)
+	resolved, err := secrets.ResolveURI(ctx, uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to resolve secrets, %w", err)
+	}
+
+	parsed, err := url.Parse(resolved)
 
 	if err != nil {
 		return nil, fmt.Errorf("Failed to parse URI, %w", err)
@@ -42,7 +50,20 @@ func NewReceiver(ctx context.Context, uri string) (webhookd.WebhookReceiver, err
 	}
 
 	init_func := i.(ReceiverInitializationFunc)
-	return init_func(ctx, uri)
+
+	r, err := init_func(ctx, resolved)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Receivers whose URIs contain secrets are refreshed when those secrets change
+
+	if resolved != uri {
+		r = newRefreshingReceiver(uri, resolved, r, init_func)
+	}
+
+	return r, nil
 }
 
 // RegisterReceiver() associates 'scheme' with 'init_func' in an internal list of avilable `webhookd.WebhookReceiver` implementations.
@@ -2,6 +2,9 @@ package receiver
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -28,3 +31,36 @@ func TestNewReceiver(t *testing.T) {
 		t.Fatalf("Failed to create new receiver for '%s', %v", uri, err)
 	}
 }
+
+func TestNewReceiverSecrets(t *testing.T) {
+
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "secret")
+
+	err := os.WriteFile(path, []byte("s3cret\n"), 0600)
+
+	if err != nil {
+		t.Fatalf("Failed to write secret, %v", err)
+	}
+
+	uri := fmt.Sprintf("heroku://?secret=secrets://file%s", path)
+
+	r, err := NewReceiver(ctx, uri)
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver for '%s', %v", uri, err)
+	}
+
+	secrets := r.(*RefreshingStreamingReceiver).Receiver().(HerokuReceiver).secrets
+
+	if len(secrets) != 1 || secrets[0] != "s3cret" {
+		t.Fatalf("Expected secret to be resolved, got %v", secrets)
+	}
+
+	_, err = NewReceiver(ctx, "heroku://?secret=secrets://file/does/not/exist")
+
+	if err == nil {
+		t.Fatalf("Expected unresolvable secret to fail")
+	}
+}
@@ -0,0 +1,121 @@
+package receiver
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+	"github.com/whosonfirst/go-webhookd/v3/secrets"
+)
+
+// RefreshingReceiver implements the `webhookd.WebhookReceiver` interface for receivers whose URIs contain `secrets://` URIs. The
+// URI is resolved again before each message is received and, if any of its secrets have changed, a new receiver is created.
+type RefreshingReceiver struct {
+	webhookd.WebhookReceiver
+	// uri is the unresolved URI of the receiver.
+	uri string
+	// resolved is the resolved URI that 'receiver' was created with.
+	resolved string
+	// receiver is the current `webhookd.WebhookReceiver` instance.
+	receiver webhookd.WebhookReceiver
+	// init_func is the function used to create new receivers.
+	init_func ReceiverInitializationFunc
+	// mu is a `sync.RWMutex` used to guard 'resolved' and 'receiver'.
+	mu *sync.RWMutex
+}
+
+// RefreshingStreamingReceiver implements the `webhookd.WebhookStreamingReceiver` interface for `RefreshingReceiver` instances
+// whose receivers implement `webhookd.WebhookStreamingReceiver`.
+type RefreshingStreamingReceiver struct {
+	*RefreshingReceiver
+}
+
+// newRefreshingReceiver returns a new `webhookd.WebhookReceiver` instance that wraps 'r', created by 'init_func' from 'resolved'
+// which is the resolved value of 'uri'. If 'r' implements the `webhookd.WebhookStreamingReceiver` interface so does the
+// returned instance.
+func newRefreshingReceiver(uri string, resolved string, r webhookd.WebhookReceiver, init_func ReceiverInitializationFunc) webhookd.WebhookReceiver {
+
+	rr := &RefreshingReceiver{
+		uri:       uri,
+		resolved:  resolved,
+		receiver:  r,
+		init_func: init_func,
+		mu:        new(sync.RWMutex),
+	}
+
+	_, ok := r.(webhookd.WebhookStreamingReceiver)
+
+	if ok {
+		return &RefreshingStreamingReceiver{rr}
+	}
+
+	return rr
+}
+
+// Receive resolves the URI for 'r', refreshing its receiver if necessary, and then calls the `Receive` method of that receiver.
+func (r *RefreshingReceiver) Receive(ctx context.Context, req *http.Request) ([]byte, *webhookd.WebhookError) {
+	return r.current(ctx).Receive(ctx, req)
+}
+
+// ReceiveStream resolves the URI for 'r', refreshing its receiver if necessary, and then calls the `ReceiveStream` method of that
+// receiver.
+func (r *RefreshingStreamingReceiver) ReceiveStream(ctx context.Context, req *http.Request) (io.ReadCloser, *webhookd.WebhookError) {
+
+	current := r.current(ctx)
+
+	streaming, ok := current.(webhookd.WebhookStreamingReceiver)
+
+	if !ok {
+		code := http.StatusInternalServerError
+		message := "Receiver does not support streaming"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	return streaming.ReceiveStream(ctx, req)
+}
+
+// Receiver returns the current `webhookd.WebhookReceiver` instance for 'r'.
+func (r *RefreshingReceiver) Receiver() webhookd.WebhookReceiver {
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.receiver
+}
+
+// current returns the `webhookd.WebhookReceiver` instance for the current values of the secrets in the URI for 'r'. If those
+// secrets can not be resolved, or a new receiver can not be created with them, the existing receiver is returned so that a
+// temporary outage of a secret store does not cause messages to fail. Secrets are cached by the resolver so this is cheap.
+func (r *RefreshingReceiver) current(ctx context.Context) webhookd.WebhookReceiver {
+
+	resolved, err := secrets.ResolveURI(ctx, r.uri)
+
+	r.mu.RLock()
+	current := r.receiver
+	changed := err == nil && resolved != r.resolved
+	r.mu.RUnlock()
+
+	if !changed {
+		return current
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if resolved == r.resolved {
+		return r.receiver
+	}
+
+	new_r, err := r.init_func(ctx, resolved)
+
+	if err != nil {
+		return r.receiver
+	}
+
+	r.resolved = resolved
+	r.receiver = new_r
+
+	return new_r
+}
@@ -0,0 +1,86 @@
+package receiver
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRefreshingReceiver(t *testing.T) {
+
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "secret")
+
+	err := os.WriteFile(path, []byte("s3cret\n"), 0600)
+
+	if err != nil {
+		t.Fatalf("Failed to write secret, %v", err)
+	}
+
+	r, err := NewReceiver(ctx, fmt.Sprintf("heroku://?secret=secrets://file%s?ttl=1ns", path))
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	// Heroku receivers support streaming so they are wrapped in a RefreshingStreamingReceiver
+
+	_, ok := r.(*RefreshingStreamingReceiver)
+
+	if !ok {
+		t.Fatalf("Expected receiver with secrets to be a RefreshingStreamingReceiver")
+	}
+
+	body := []byte(`{"action":"update","resource":"release","data":{"version":12}}`)
+
+	receive := func(secret string) int {
+
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/heroku", bytes.NewReader(body))
+
+		if err != nil {
+			t.Fatalf("Failed to create new request, %v", err)
+		}
+
+		req.Header.Set(HEROKU_SIGNATURE_HEADER, base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+
+		_, err2 := r.Receive(ctx, req)
+
+		if err2 != nil {
+			return err2.Code
+		}
+
+		return 0
+	}
+
+	if code := receive("s3cret"); code != 0 {
+		t.Fatalf("Expected message signed with current secret to succeed, got %d", code)
+	}
+
+	err = os.WriteFile(path, []byte("r0tated\n"), 0600)
+
+	if err != nil {
+		t.Fatalf("Failed to write secret, %v", err)
+	}
+
+	time.Sleep(time.Millisecond)
+
+	if code := receive("r0tated"); code != 0 {
+		t.Fatalf("Expected message signed with rotated secret to succeed, got %d", code)
+	}
+
+	if code := receive("s3cret"); code != http.StatusForbidden {
+		t.Fatalf("Expected message signed with old secret to fail, got %d", code)
+	}
+}
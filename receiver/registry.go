@@ -0,0 +1,385 @@
+package receiver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+const (
+	// REGISTRY_SOURCE_DISTRIBUTION is the source for notifications sent by the CNCF Distribution registry (and compatible registries).
+	REGISTRY_SOURCE_DISTRIBUTION string = "distribution"
+	// REGISTRY_SOURCE_HARBOR is the source for notifications sent by Harbor.
+	REGISTRY_SOURCE_HARBOR string = "harbor"
+	// REGISTRY_SOURCE_QUAY is the source for notifications sent by Quay.
+	REGISTRY_SOURCE_QUAY string = "quay"
+	// REGISTRY_SOURCE_DOCKERHUB is the source for notifications sent by Docker Hub.
+	REGISTRY_SOURCE_DOCKERHUB string = "dockerhub"
+)
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterReceiver(ctx, "registry", NewRegistryReceiver)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// RegistryEvent is a normalized representation of a container registry notification.
+type RegistryEvent struct {
+	// Action is the action that triggered the notification, for example "push".
+	Action string `json:"action"`
+	// Repository is the name of the repository, including its namespace.
+	Repository string `json:"repository"`
+	// Tag is the tag associated with the event, if known.
+	Tag string `json:"tag,omitempty"`
+	// Digest is the digest of the artifact associated with the event, if known.
+	Digest string `json:"digest,omitempty"`
+	// Source is the kind of registry that sent the notification.
+	Source string `json:"source"`
+}
+
+// distributionEnvelope is the subset of a CNCF Distribution notification envelope used by `RegistryReceiver`.
+type distributionEnvelope struct {
+	Events []struct {
+		Action string `json:"action"`
+		Target struct {
+			Repository string `json:"repository"`
+			Tag        string `json:"tag"`
+			Digest     string `json:"digest"`
+		} `json:"target"`
+	} `json:"events"`
+}
+
+// harborEnvelope is the subset of a Harbor notification used by `RegistryReceiver`.
+type harborEnvelope struct {
+	Type      string `json:"type"`
+	EventData *struct {
+		Resources []struct {
+			Digest string `json:"digest"`
+			Tag    string `json:"tag"`
+		} `json:"resources"`
+		Repository struct {
+			RepoFullName string `json:"repo_full_name"`
+		} `json:"repository"`
+	} `json:"event_data"`
+}
+
+// quayEnvelope is the subset of a Quay repository push notification used by `RegistryReceiver`.
+type quayEnvelope struct {
+	Repository  string   `json:"repository"`
+	DockerURL   string   `json:"docker_url"`
+	UpdatedTags []string `json:"updated_tags"`
+}
+
+// registryOptions is a struct containing the options used to authenticate and decode registry notifications.
+type registryOptions struct {
+	// tokens is the list of valid values for the `token` query parameter that requests must include.
+	tokens []string
+	// authorizations is the list of valid values for the `Authorization` header that requests must include.
+	authorizations []string
+	// normalize is a boolean flag signaling that notifications should be returned as a list of `RegistryEvent` instances.
+	normalize bool
+}
+
+// newRegistryOptions returns a new `registryOptions` instance derived from 'q'.
+func newRegistryOptions(q url.Values) (*registryOptions, error) {
+
+	opts := &registryOptions{
+		tokens:         parseSecrets(q, "token"),
+		authorizations: parseSecrets(q, "authorization"),
+	}
+
+	if len(opts.tokens) == 0 && len(opts.authorizations) == 0 {
+		return nil, fmt.Errorf("Missing ?token= or ?authorization= parameter")
+	}
+
+	str_normalize := q.Get("normalize")
+
+	if str_normalize != "" {
+
+		v, err := strconv.ParseBool(str_normalize)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse ?normalize= parameter, %w", err)
+		}
+
+		opts.normalize = v
+	}
+
+	return opts, nil
+}
+
+// authenticate returns an error if 'req' does not include the token or `Authorization` header defined in 'opts'.
+func (opts *registryOptions) authenticate(req *http.Request) *webhookd.WebhookError {
+
+	ok := true
+
+	if len(opts.tokens) > 0 {
+
+		token := requestToken(req)
+
+		// The Authorization header is reserved for the `authorization` values if both are defined
+
+		if len(opts.authorizations) > 0 {
+
+			token = req.Header.Get(TOKEN_HEADER)
+
+			if token == "" {
+				token = req.URL.Query().Get("token")
+			}
+		}
+
+		ok = ok && constantTimeEqualAny(token, opts.tokens)
+	}
+
+	if len(opts.authorizations) > 0 {
+		ok = ok && constantTimeEqualAny(req.Header.Get("Authorization"), opts.authorizations)
+	}
+
+	if !ok {
+		code := http.StatusUnauthorized
+		message := "Unauthorized"
+		return &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	return nil
+}
+
+// RegistryReceiver implements the `webhookd.WebhookReceiver` interface for receiving notifications from container registries
+// that use the CNCF Distribution, Harbor or Quay notification formats.
+type RegistryReceiver struct {
+	webhookd.WebhookReceiver
+	// options is the `registryOptions` instance used to authenticate and decode notifications.
+	options *registryOptions
+	// body_options is the `BodyOptions` instance used to decode message bodies.
+	body_options *BodyOptions
+}
+
+// NewRegistryReceiver returns a new `RegistryReceiver` instance configured by 'uri' in the form of:
+//
+//	registry://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `token={STRING}` One or more (comma-separated) valid tokens that requests must include.
+// * `authorization={STRING}` One or more (comma-separated) valid values for the `Authorization` header that requests must include.
+// * `normalize={BOOLEAN}` Return notifications as a JSON-encoded list of `RegistryEvent` instances. Default is false.
+// * Any of the parameters supported by `NewBodyOptionsFromQuery`.
+//
+// At least one of `token` or `authorization` must be present. Tokens may be included in an "X-Webhookd-Token" header, a `token`
+// query parameter or, if `authorization` is absent, an "Authorization: Bearer {TOKEN}" header.
+func NewRegistryReceiver(ctx context.Context, uri string) (webhookd.WebhookReceiver, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	opts, err := newRegistryOptions(q)
+
+	if err != nil {
+		return nil, err
+	}
+
+	body_opts, err := NewBodyOptionsFromQuery(q)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive body options, %w", err)
+	}
+
+	wh := RegistryReceiver{
+		options:      opts,
+		body_options: body_opts,
+	}
+
+	return wh, nil
+}
+
+// Receive returns the body of the notification in 'req' after checking that it was sent with the expected token or `Authorization`
+// header and that it is a CNCF Distribution, Harbor or Quay notification.
+func (wh RegistryReceiver) Receive(ctx context.Context, req *http.Request) ([]byte, *webhookd.WebhookError) {
+
+	select {
+	case <-ctx.Done():
+		return nil, nil
+	default:
+		// pass
+	}
+
+	err := wh.options.authenticate(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	_, body, err := ReadBody(ctx, req, wh.body_options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	body, err = DecodeBody(ctx, req, body, wh.body_options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := parseRegistryNotification(body)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !wh.options.normalize {
+		return body, nil
+	}
+
+	return encodeRegistryEvents(events)
+}
+
+// ReceiveStream returns an `io.ReadCloser` for the body of the notification in 'req' after checking that it was sent with the
+// expected token or `Authorization` header. Notifications are not read in to memory so their format is not checked and they
+// can not be normalized.
+func (wh RegistryReceiver) ReceiveStream(ctx context.Context, req *http.Request) (io.ReadCloser, *webhookd.WebhookError) {
+
+	select {
+	case <-ctx.Done():
+		return nil, nil
+	default:
+		// pass
+	}
+
+	if wh.options.normalize {
+		code := http.StatusInternalServerError
+		message := "Normalizing notifications is not supported for streaming requests"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	err := wh.options.authenticate(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ReadBodyStream(ctx, req, wh.body_options)
+}
+
+// parseRegistryNotification returns the list of `RegistryEvent` instances in the CNCF Distribution, Harbor or Quay notification 'body'.
+func parseRegistryNotification(body []byte) ([]*RegistryEvent, *webhookd.WebhookError) {
+
+	var probe map[string]json.RawMessage
+
+	err := json.Unmarshal(body, &probe)
+
+	if err != nil {
+		code := http.StatusBadRequest
+		message := fmt.Sprintf("Failed to decode notification, %v", err)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	events := make([]*RegistryEvent, 0)
+
+	switch {
+	case probe["events"] != nil:
+
+		var env distributionEnvelope
+
+		err = json.Unmarshal(body, &env)
+
+		if err != nil {
+			break
+		}
+
+		for _, e := range env.Events {
+
+			events = append(events, &RegistryEvent{
+				Action:     e.Action,
+				Repository: e.Target.Repository,
+				Tag:        e.Target.Tag,
+				Digest:     e.Target.Digest,
+				Source:     REGISTRY_SOURCE_DISTRIBUTION,
+			})
+		}
+
+		return events, nil
+
+	case probe["event_data"] != nil && probe["type"] != nil:
+
+		var env harborEnvelope
+
+		err = json.Unmarshal(body, &env)
+
+		if err != nil || env.EventData == nil {
+			break
+		}
+
+		// Harbor event types look like "PUSH_ARTIFACT" or "DELETE_ARTIFACT"
+
+		action := strings.ToLower(strings.SplitN(env.Type, "_", 2)[0])
+
+		for _, r := range env.EventData.Resources {
+
+			events = append(events, &RegistryEvent{
+				Action:     action,
+				Repository: env.EventData.Repository.RepoFullName,
+				Tag:        r.Tag,
+				Digest:     r.Digest,
+				Source:     REGISTRY_SOURCE_HARBOR,
+			})
+		}
+
+		return events, nil
+
+	case probe["repository"] != nil && probe["updated_tags"] != nil:
+
+		var env quayEnvelope
+
+		err = json.Unmarshal(body, &env)
+
+		if err != nil {
+			break
+		}
+
+		for _, tag := range env.UpdatedTags {
+
+			events = append(events, &RegistryEvent{
+				Action:     "push",
+				Repository: env.Repository,
+				Tag:        tag,
+				Source:     REGISTRY_SOURCE_QUAY,
+			})
+		}
+
+		return events, nil
+	}
+
+	code := http.StatusBadRequest
+	message := "Unrecognized registry notification"
+	return nil, &webhookd.WebhookError{Code: code, Message: message}
+}
+
+// encodeRegistryEvents returns 'events' as a JSON-encoded list.
+func encodeRegistryEvents(events []*RegistryEvent) ([]byte, *webhookd.WebhookError) {
+
+	enc, err := json.Marshal(events)
+
+	if err != nil {
+		code := http.StatusInternalServerError
+		message := fmt.Sprintf("Failed to encode events, %v", err)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	return enc, nil
+}
@@ -0,0 +1,251 @@
+package receiver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+func TestRegistryReceiver(t *testing.T) {
+
+	ctx := context.Background()
+
+	r, err := NewReceiver(ctx, "registry://?authorization=Bearer%20s33kret&normalize=true")
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	tests := map[string]*RegistryEvent{
+		`{"events":[{"id":"1","action":"push","target":{"mediaType":"application/vnd.docker.distribution.manifest.v2+json","digest":"sha256:abc","repository":"example/app","tag":"v1"}}]}`: {
+			Action:     "push",
+			Repository: "example/app",
+			Tag:        "v1",
+			Digest:     "sha256:abc",
+			Source:     REGISTRY_SOURCE_DISTRIBUTION,
+		},
+		`{"type":"PUSH_ARTIFACT","occur_at":1586922308,"operator":"admin","event_data":{"resources":[{"digest":"sha256:def","tag":"v2","resource_url":"harbor.example.com/library/app:v2"}],"repository":{"name":"app","namespace":"library","repo_full_name":"library/app"}}}`: {
+			Action:     "push",
+			Repository: "library/app",
+			Tag:        "v2",
+			Digest:     "sha256:def",
+			Source:     REGISTRY_SOURCE_HARBOR,
+		},
+		`{"repository":"example/app","namespace":"example","name":"app","docker_url":"quay.io/example/app","updated_tags":["v3"]}`: {
+			Action:     "push",
+			Repository: "example/app",
+			Tag:        "v3",
+			Source:     REGISTRY_SOURCE_QUAY,
+		},
+	}
+
+	for notification, expected := range tests {
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/registry", bytes.NewReader([]byte(notification)))
+
+		if err != nil {
+			t.Fatalf("Failed to create new request, %v", err)
+		}
+
+		req.Header.Set("Authorization", "Bearer s33kret")
+
+		body, err2 := r.Receive(ctx, req)
+
+		if err2 != nil {
+			t.Fatalf("Failed to receive message, %v", err2)
+		}
+
+		var events []*RegistryEvent
+
+		err = json.Unmarshal(body, &events)
+
+		if err != nil {
+			t.Fatalf("Failed to decode events, %v", err)
+		}
+
+		if len(events) != 1 || *events[0] != *expected {
+			t.Fatalf("Unexpected output '%s'", string(body))
+		}
+	}
+}
+
+func TestRegistryReceiverErrors(t *testing.T) {
+
+	ctx := context.Background()
+
+	_, err := NewReceiver(ctx, "registry://")
+
+	if err == nil {
+		t.Fatalf("Expected receiver without token or authorization to fail")
+	}
+
+	r, err := NewReceiver(ctx, "registry://?token=s33kret")
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	tests := map[string]int{
+		`{"hello":"world"}`: http.StatusBadRequest,
+		`not json`:          http.StatusBadRequest,
+	}
+
+	for notification, expected := range tests {
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/registry?token=s33kret", bytes.NewReader([]byte(notification)))
+
+		if err != nil {
+			t.Fatalf("Failed to create new request, %v", err)
+		}
+
+		_, err2 := r.Receive(ctx, req)
+
+		if err2 == nil || err2.Code != expected {
+			t.Fatalf("Expected %d for %s but got %v", expected, notification, err2)
+		}
+	}
+
+	req, err := http.NewRequest("POST", "http://localhost:8080/registry", bytes.NewReader([]byte(`{"events":[]}`)))
+
+	if err != nil {
+		t.Fatalf("Failed to create new request, %v", err)
+	}
+
+	_, err2 := r.Receive(ctx, req)
+
+	if err2 == nil || err2.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected unauthenticated request to fail, %v", err2)
+	}
+}
+
+func TestRegistryReceiverStream(t *testing.T) {
+
+	ctx := context.Background()
+
+	body := []byte(`{"events":[{"action":"push","target":{"repository":"example/app","tag":"latest"}}]}`)
+
+	r, err := NewReceiver(ctx, "registry://?token=s33kret")
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	sr := r.(webhookd.WebhookStreamingReceiver)
+
+	tests := map[string]int{
+		"http://localhost:8080/registry?token=s33kret": 0,
+		"http://localhost:8080/registry?token=wr0ng":   http.StatusUnauthorized,
+	}
+
+	for uri, expected := range tests {
+
+		req, err := http.NewRequest("POST", uri, bytes.NewReader(body))
+
+		if err != nil {
+			t.Fatalf("Failed to create new request, %v", err)
+		}
+
+		rsp, err2 := sr.ReceiveStream(ctx, req)
+
+		if expected != 0 {
+
+			if err2 == nil || err2.Code != expected {
+				t.Fatalf("Expected %d for %s but got %v", expected, uri, err2)
+			}
+
+			continue
+		}
+
+		if err2 != nil {
+			t.Fatalf("Failed to receive stream, %v", err2)
+		}
+
+		out, err := io.ReadAll(rsp)
+		rsp.Close()
+
+		if err != nil {
+			t.Fatalf("Failed to read stream, %v", err)
+		}
+
+		if !bytes.Equal(out, body) {
+			t.Fatalf("Unexpected output '%s'", string(out))
+		}
+	}
+
+	r, err = NewReceiver(ctx, "registry://?token=s33kret&normalize=true")
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	req, err := http.NewRequest("POST", "http://localhost:8080/registry?token=s33kret", bytes.NewReader(body))
+
+	if err != nil {
+		t.Fatalf("Failed to create new request, %v", err)
+	}
+
+	_, err2 := r.(webhookd.WebhookStreamingReceiver).ReceiveStream(ctx, req)
+
+	if err2 == nil {
+		t.Fatalf("Expected streaming normalized notifications to fail")
+	}
+}
+
+func TestRegistryReceiverTokenHeaders(t *testing.T) {
+
+	ctx := context.Background()
+
+	body := []byte(`{"events":[{"action":"push","target":{"repository":"example/app","tag":"latest"}}]}`)
+
+	tests := []struct {
+		uri      string
+		headers  map[string]string
+		expected int
+	}{
+		{"registry://?token=s33kret", map[string]string{TOKEN_HEADER: "s33kret"}, 0},
+		{"registry://?token=s33kret", map[string]string{"Authorization": "Bearer s33kret"}, 0},
+		{"registry://?token=s33kret", map[string]string{TOKEN_HEADER: "wr0ng"}, http.StatusUnauthorized},
+		// The Authorization header is not used for tokens if authorizations are defined
+		{"registry://?token=s33kret&authorization=Basic%20abc", map[string]string{TOKEN_HEADER: "s33kret", "Authorization": "Basic abc"}, 0},
+		{"registry://?token=s33kret&authorization=Basic%20abc", map[string]string{"Authorization": "Bearer s33kret"}, http.StatusUnauthorized},
+	}
+
+	for idx, test := range tests {
+
+		r, err := NewReceiver(ctx, test.uri)
+
+		if err != nil {
+			t.Fatalf("Failed to create new receiver for test %d, %v", idx, err)
+		}
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/registry", bytes.NewReader(body))
+
+		if err != nil {
+			t.Fatalf("Failed to create new request, %v", err)
+		}
+
+		for k, v := range test.headers {
+			req.Header.Set(k, v)
+		}
+
+		_, err2 := r.Receive(ctx, req)
+
+		if test.expected == 0 {
+
+			if err2 != nil {
+				t.Fatalf("Failed to receive message for test %d, %v", idx, err2)
+			}
+
+			continue
+		}
+
+		if err2 == nil || err2.Code != test.expected {
+			t.Fatalf("Expected %d for test %d but got %v", test.expected, idx, err2)
+		}
+	}
+}
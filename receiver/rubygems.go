@@ -0,0 +1,121 @@
+package receiver
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterReceiver(ctx, "rubygems", NewRubyGemsReceiver)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// RubyGemsReceiver implements the `webhookd.WebhookReceiver` interface for receiving RubyGems.org web hook messages.
+type RubyGemsReceiver struct {
+	webhookd.WebhookReceiver
+	// api_keys is the list of RubyGems.org API keys, of the account that registered the web hook, used to validate messages.
+	api_keys []string
+	// body_options is the `BodyOptions` instance used to decode message bodies.
+	body_options *BodyOptions
+}
+
+// NewRubyGemsReceiver returns a new `RubyGemsReceiver` instance configured by 'uri' in the form of:
+//
+//	rubygems://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `api_key={STRING}` One or more (comma-separated) RubyGems.org API keys, of the account that registered the web hook, used to validate messages. Required.
+// * Any of the parameters supported by `NewBodyOptionsFromQuery`.
+func NewRubyGemsReceiver(ctx context.Context, uri string) (webhookd.WebhookReceiver, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	api_keys := parseSecrets(q, "api_key")
+
+	if len(api_keys) == 0 {
+		return nil, fmt.Errorf("Missing ?api_key= parameter")
+	}
+
+	body_opts, err := NewBodyOptionsFromQuery(q)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive body options, %w", err)
+	}
+
+	wh := RubyGemsReceiver{
+		api_keys:     api_keys,
+		body_options: body_opts,
+	}
+
+	return wh, nil
+}
+
+// Receive returns the body of the RubyGems.org message in 'req' after validating its `Authorization` header, which is the
+// SHA-256 hash of the gem's name and version followed by the API key.
+func (wh RubyGemsReceiver) Receive(ctx context.Context, req *http.Request) ([]byte, *webhookd.WebhookError) {
+
+	select {
+	case <-ctx.Done():
+		return nil, nil
+	default:
+		// pass
+	}
+
+	authorization := req.Header.Get("Authorization")
+
+	if authorization == "" {
+		code := http.StatusBadRequest
+		message := "Missing Authorization header"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	_, body, err := ReadBody(ctx, req, wh.body_options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var gem struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+
+	decode_err := json.Unmarshal(body, &gem)
+
+	if decode_err != nil || gem.Name == "" || gem.Version == "" {
+		code := http.StatusBadRequest
+		message := "Message is missing name or version property"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	verify := func(api_key string) bool {
+		sum := sha256.Sum256([]byte(gem.Name + gem.Version + api_key))
+		return constantTimeEqual(hex.EncodeToString(sum[:]), authorization)
+	}
+
+	if !anySecret(wh.api_keys, verify) {
+		code := http.StatusUnauthorized
+		message := "Unauthorized"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	return DecodeBody(ctx, req, body, wh.body_options)
+}
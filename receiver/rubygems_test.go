@@ -0,0 +1,62 @@
+package receiver
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"testing"
+)
+
+func TestRubyGemsReceiver(t *testing.T) {
+
+	ctx := context.Background()
+
+	r, err := NewReceiver(ctx, "rubygems://?api_key=s33kret")
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	body := []byte(`{"name":"rails","version":"7.1.0","platform":"ruby","project_uri":"https://rubygems.org/gems/rails"}`)
+
+	sum := sha256.Sum256([]byte("rails7.1.0s33kret"))
+	authorization := hex.EncodeToString(sum[:])
+
+	tests := map[string]int{
+		string(body): 0,
+		`{"name":"rails","version":"7.1.1","platform":"ruby"}`: http.StatusUnauthorized,
+		`{"name":"rails"}`: http.StatusBadRequest,
+	}
+
+	for msg, expected := range tests {
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/rubygems", bytes.NewReader([]byte(msg)))
+
+		if err != nil {
+			t.Fatalf("Failed to create new request, %v", err)
+		}
+
+		req.Header.Set("Authorization", authorization)
+
+		rsp, err2 := r.Receive(ctx, req)
+
+		if expected == 0 {
+
+			if err2 != nil {
+				t.Fatalf("Failed to receive message, %v", err2)
+			}
+
+			if !bytes.Equal(rsp, body) {
+				t.Fatalf("Unexpected output '%s'", string(rsp))
+			}
+
+			continue
+		}
+
+		if err2 == nil || err2.Code != expected {
+			t.Fatalf("Expected %d for %s but got %v", expected, msg, err2)
+		}
+	}
+}
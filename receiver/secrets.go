@@ -0,0 +1,61 @@
+package receiver
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/whosonfirst/go-webhookd/v3/signature"
+)
+
+// parseSecrets returns the list of secrets defined by the query parameter 'key' in 'q'. The parameter may be repeated and each
+// value may contain a comma-separated list of secrets so that a secret can be rotated by defining both the old and new values
+// until the provider has switched to the new one. Empty values are ignored.
+func parseSecrets(q url.Values, key string) []string {
+
+	secrets := make([]string, 0)
+
+	for _, v := range q[key] {
+
+		for _, s := range strings.Split(v, ",") {
+
+			s = strings.TrimSpace(s)
+
+			if s != "" {
+				secrets = append(secrets, s)
+			}
+		}
+	}
+
+	return secrets
+}
+
+// anySecret returns a boolean value indicating whether 'fn' returns true for any of 'secrets'. 'fn' is invoked for every secret,
+// even after a match, so that the time taken does not reveal which secret matched.
+func anySecret(secrets []string, fn func(string) bool) bool {
+
+	ok := false
+
+	for _, s := range secrets {
+
+		if fn(s) {
+			ok = true
+		}
+	}
+
+	return ok
+}
+
+// constantTimeEqual returns a boolean value indicating whether 'a' and 'b' are equal, in constant time.
+func constantTimeEqual(a string, b string) bool {
+	return signature.Equal(a, b)
+}
+
+// constantTimeEqualAny returns a boolean value indicating whether 'v' is equal to any of 'secrets', in constant time.
+func constantTimeEqualAny(v string, secrets []string) bool {
+
+	fn := func(s string) bool {
+		return constantTimeEqual(v, s)
+	}
+
+	return anySecret(secrets, fn)
+}
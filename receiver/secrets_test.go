@@ -0,0 +1,106 @@
+package receiver
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestParseSecrets(t *testing.T) {
+
+	tests := map[string]string{
+		"":                        "",
+		"secret=a":                "a",
+		"secret=a,b":              "a|b",
+		"secret=a,%20b,&secret=c": "a|b|c",
+		"secret=,&other=d":        "",
+	}
+
+	for str_q, expected := range tests {
+
+		q, _ := url.ParseQuery(str_q)
+
+		secrets := parseSecrets(q, "secret")
+
+		if strings.Join(secrets, "|") != expected {
+			t.Fatalf("Unexpected secrets for '%s': %v", str_q, secrets)
+		}
+	}
+}
+
+func TestConstantTimeEqualAny(t *testing.T) {
+
+	secrets := []string{"old", "new"}
+
+	for _, v := range []string{"old", "new"} {
+
+		if !constantTimeEqualAny(v, secrets) {
+			t.Fatalf("Expected '%s' to match", v)
+		}
+	}
+
+	for _, v := range []string{"", "other", "old,new"} {
+
+		if constantTimeEqualAny(v, secrets) {
+			t.Fatalf("Expected '%s' not to match", v)
+		}
+	}
+
+	if constantTimeEqualAny("", []string{}) {
+		t.Fatalf("Expected empty list of secrets not to match")
+	}
+}
+
+func TestSecretRotation(t *testing.T) {
+
+	ctx := context.Background()
+
+	r, err := NewReceiver(ctx, "heroku://?secret=n3w,0ld")
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	body := []byte(`{"action":"update","resource":"release","data":{"version":12}}`)
+
+	tests := map[string]int{
+		"n3w":   0,
+		"0ld":   0,
+		"0ther": http.StatusForbidden,
+	}
+
+	for secret, expected := range tests {
+
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/heroku", bytes.NewReader(body))
+
+		if err != nil {
+			t.Fatalf("Failed to create new request, %v", err)
+		}
+
+		req.Header.Set(HEROKU_SIGNATURE_HEADER, base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+
+		_, err2 := r.Receive(ctx, req)
+
+		if expected == 0 {
+
+			if err2 != nil {
+				t.Fatalf("Failed to receive message signed with '%s', %v", secret, err2)
+			}
+
+			continue
+		}
+
+		if err2 == nil || err2.Code != expected {
+			t.Fatalf("Expected %d for message signed with '%s' but got %v", expected, secret, err2)
+		}
+	}
+}
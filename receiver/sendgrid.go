@@ -0,0 +1,158 @@
+package receiver
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+// SENDGRID_SIGNATURE_HEADER is the HTTP header containing the signature for a SendGrid event webhook message.
+const SENDGRID_SIGNATURE_HEADER string = "X-Twilio-Email-Event-Webhook-Signature"
+
+// SENDGRID_TIMESTAMP_HEADER is the HTTP header containing the timestamp for a SendGrid event webhook message.
+const SENDGRID_TIMESTAMP_HEADER string = "X-Twilio-Email-Event-Webhook-Timestamp"
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterReceiver(ctx, "sendgrid", NewSendGridReceiver)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// SendGridReceiver implements the `webhookd.WebhookReceiver` interface for receiving SendGrid signed event webhook messages.
+type SendGridReceiver struct {
+	webhookd.WebhookReceiver
+	// public_key is the ECDSA public key used to validate message signatures.
+	public_key *ecdsa.PublicKey
+	// freshness is the `FreshnessOptions` instance used to reject messages with stale timestamps.
+	freshness *FreshnessOptions
+	// body_options is the `BodyOptions` instance used to decode message bodies.
+	body_options *BodyOptions
+}
+
+// NewSendGridReceiver returns a new `SendGridReceiver` instance configured by 'uri' in the form of:
+//
+//	sendgrid://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `public_key={STRING}` The (URI-escaped) base64-encoded verification key for the signed event webhook, as shown in the SendGrid
+// settings. Required.
+// * `max_age={DURATION}` The maximum age of a message, derived from its timestamp. A value of "0s" disables this check. Default is "5m".
+// * `max_skew={DURATION}` The maximum amount of time that the timestamp of a message may be ahead of the current time. A value of "0s" disables this check. Default is the value of `max_age`.
+// * Any of the parameters supported by `NewBodyOptionsFromQuery`.
+func NewSendGridReceiver(ctx context.Context, uri string) (webhookd.WebhookReceiver, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	str_key := q.Get("public_key")
+
+	if str_key == "" {
+		return nil, fmt.Errorf("Missing ?public_key= parameter")
+	}
+
+	der, err := base64.StdEncoding.DecodeString(str_key)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decode ?public_key= parameter, %w", err)
+	}
+
+	k, err := x509.ParsePKIXPublicKey(der)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse ?public_key= parameter, %w", err)
+	}
+
+	public_key, ok := k.(*ecdsa.PublicKey)
+
+	if !ok {
+		return nil, fmt.Errorf("?public_key= parameter is not an ECDSA public key")
+	}
+
+	freshness, err := NewFreshnessOptionsFromQuery(q)
+
+	if err != nil {
+		return nil, err
+	}
+
+	body_opts, err := NewBodyOptionsFromQuery(q)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive body options, %w", err)
+	}
+
+	wh := SendGridReceiver{
+		public_key:   public_key,
+		freshness:    freshness,
+		body_options: body_opts,
+	}
+
+	return wh, nil
+}
+
+// Receive returns the body of the SendGrid message in 'req' after validating its signature.
+func (wh SendGridReceiver) Receive(ctx context.Context, req *http.Request) ([]byte, *webhookd.WebhookError) {
+
+	select {
+	case <-ctx.Done():
+		return nil, nil
+	default:
+		// pass
+	}
+
+	str_sig := req.Header.Get(SENDGRID_SIGNATURE_HEADER)
+	ts := req.Header.Get(SENDGRID_TIMESTAMP_HEADER)
+
+	if str_sig == "" || ts == "" {
+		code := http.StatusBadRequest
+		message := fmt.Sprintf("Missing %s or %s header", SENDGRID_SIGNATURE_HEADER, SENDGRID_TIMESTAMP_HEADER)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	err := verifyTimestamp(ts, wh.freshness)
+
+	if err != nil {
+		return nil, err
+	}
+
+	raw, body, err := ReadBody(ctx, req, wh.body_options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sig, decode_err := base64.StdEncoding.DecodeString(str_sig)
+
+	if decode_err != nil {
+		code := http.StatusBadRequest
+		message := "Invalid signature encoding"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	h := sha256.New()
+	h.Write([]byte(ts))
+	h.Write(VerifiableBody(raw, body, wh.body_options))
+
+	if !ecdsa.VerifyASN1(wh.public_key, h.Sum(nil), sig) {
+		code := http.StatusForbidden
+		message := "Invalid signature"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	return DecodeBody(ctx, req, body, wh.body_options)
+}
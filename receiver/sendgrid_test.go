@@ -0,0 +1,91 @@
+package receiver
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSendGridReceiver(t *testing.T) {
+
+	ctx := context.Background()
+
+	private_key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	if err != nil {
+		t.Fatalf("Failed to generate key, %v", err)
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(&private_key.PublicKey)
+
+	if err != nil {
+		t.Fatalf("Failed to marshal public key, %v", err)
+	}
+
+	r_uri := "sendgrid://?public_key=" + url.QueryEscape(base64.StdEncoding.EncodeToString(der))
+
+	r, err := NewReceiver(ctx, r_uri)
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	body := []byte(`[{"email":"example@test.com","event":"delivered"}]`)
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+
+	h := sha256.Sum256(append([]byte(ts), body...))
+
+	sig, err := ecdsa.SignASN1(rand.Reader, private_key, h[:])
+
+	if err != nil {
+		t.Fatalf("Failed to sign message, %v", err)
+	}
+
+	str_sig := base64.StdEncoding.EncodeToString(sig)
+
+	tests := map[string]int{
+		string(body): 0,
+		`[{"email":"example@test.com","event":"bounce"}]`: http.StatusForbidden,
+	}
+
+	for msg, expected := range tests {
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/sendgrid", bytes.NewReader([]byte(msg)))
+
+		if err != nil {
+			t.Fatalf("Failed to create new request, %v", err)
+		}
+
+		req.Header.Set(SENDGRID_SIGNATURE_HEADER, str_sig)
+		req.Header.Set(SENDGRID_TIMESTAMP_HEADER, ts)
+
+		rsp, err2 := r.Receive(ctx, req)
+
+		if expected == 0 {
+
+			if err2 != nil {
+				t.Fatalf("Failed to receive message, %v", err2)
+			}
+
+			if !bytes.Equal(rsp, body) {
+				t.Fatalf("Unexpected output '%s'", string(rsp))
+			}
+
+			continue
+		}
+
+		if err2 == nil || err2.Code != expected {
+			t.Fatalf("Expected %d for %s but got %v", expected, msg, err2)
+		}
+	}
+}
@@ -0,0 +1,495 @@
+package receiver
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+// DEFAULT_SNS_TIMEOUT is the default amount of time to wait for requests to AWS to complete.
+const DEFAULT_SNS_TIMEOUT time.Duration = 10 * time.Second
+
+const (
+	// SNS_TYPE_NOTIFICATION is the type of SNS messages that contain a notification published to a topic.
+	SNS_TYPE_NOTIFICATION string = "Notification"
+	// SNS_TYPE_SUBSCRIPTION_CONFIRMATION is the type of SNS messages sent to confirm a new subscription.
+	SNS_TYPE_SUBSCRIPTION_CONFIRMATION string = "SubscriptionConfirmation"
+	// SNS_TYPE_UNSUBSCRIBE_CONFIRMATION is the type of SNS messages sent to confirm that a subscription has been deleted.
+	SNS_TYPE_UNSUBSCRIBE_CONFIRMATION string = "UnsubscribeConfirmation"
+)
+
+// maxSNSCertificateSize is the maximum size of an SNS signing certificate.
+const maxSNSCertificateSize int64 = 64 << 10
+
+// snsHostPattern is the regular expression that the hosts of SNS signing certificate and subscription URLs must match.
+var snsHostPattern = regexp.MustCompile(`^sns\.[a-z0-9\-]+\.amazonaws\.com(\.cn)?$`)
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterReceiver(ctx, "sns", NewSNSReceiver)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// snsMessage is an AWS SNS message delivered to an HTTP or HTTPS subscription.
+type snsMessage struct {
+	Type             string `json:"Type"`
+	MessageId        string `json:"MessageId"`
+	Token            string `json:"Token"`
+	TopicArn         string `json:"TopicArn"`
+	Subject          string `json:"Subject"`
+	Message          string `json:"Message"`
+	SubscribeURL     string `json:"SubscribeURL"`
+	Timestamp        string `json:"Timestamp"`
+	SignatureVersion string `json:"SignatureVersion"`
+	Signature        string `json:"Signature"`
+	SigningCertURL   string `json:"SigningCertURL"`
+}
+
+// SNSReceiver implements the `webhookd.WebhookReceiver` interface for receiving AWS SNS messages delivered to an HTTPS subscription.
+type SNSReceiver struct {
+	webhookd.WebhookReceiver
+	// topics is the optional dictionary of topic ARNs that messages are allowed to be sent from.
+	topics map[string]bool
+	// confirm is a boolean flag signaling that subscriptions should be confirmed automatically.
+	confirm bool
+	// unwrap is a boolean flag signaling that the inner payload of notifications should be returned rather than the SNS message.
+	unwrap bool
+	// hosts is the regular expression that the hosts of signing certificate and subscription URLs must match.
+	hosts *regexp.Regexp
+	// client is the `http.Client` used to retrieve signing certificates and confirm subscriptions.
+	client *http.Client
+	// certificates is the `snsCertificates` used to cache signing certificates.
+	certificates *snsCertificates
+	// body_options is the `BodyOptions` instance used to decode message bodies.
+	body_options *BodyOptions
+}
+
+// snsCertificates is a cache of SNS signing certificates keyed by URL.
+type snsCertificates struct {
+	mu    *sync.RWMutex
+	certs map[string]*x509.Certificate
+}
+
+// NewSNSReceiver returns a new `SNSReceiver` instance configured by 'uri' in the form of:
+//
+//	sns://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `topic={ARN}` Zero or more topic ARNs that messages are allowed to be sent from. If empty messages from any topic are accepted.
+// * `confirm={BOOLEAN}` Confirm subscriptions automatically. Default is true if one or more `topic` parameters are present and false
+// otherwise. Enabling it without any `topic` parameters is an error since any AWS account could subscribe the receiver to its own topics.
+// * `unwrap={BOOLEAN}` Return the inner payload of notifications rather than the entire SNS message. Default is true.
+// * `timeout={DURATION}` The amount of time to wait for requests to AWS to complete. Default is "10s".
+// * Any of the parameters supported by `NewBodyOptionsFromQuery`, excluding `form_field` and `form_json`.
+func NewSNSReceiver(ctx context.Context, uri string) (webhookd.WebhookReceiver, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	topics := make(map[string]bool)
+
+	for _, arn := range q["topic"] {
+		topics[arn] = true
+	}
+
+	confirm := len(topics) > 0
+	unwrap := true
+
+	flags := map[string]*bool{
+		"confirm": &confirm,
+		"unwrap":  &unwrap,
+	}
+
+	for k, ptr := range flags {
+
+		str_v := q.Get(k)
+
+		if str_v == "" {
+			continue
+		}
+
+		v, err := strconv.ParseBool(str_v)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse ?%s= parameter, %w", k, err)
+		}
+
+		*ptr = v
+	}
+
+	if confirm && len(topics) == 0 {
+		return nil, fmt.Errorf("?confirm= parameter requires one or more ?topic= parameters")
+	}
+
+	timeout := DEFAULT_SNS_TIMEOUT
+
+	str_timeout := q.Get("timeout")
+
+	if str_timeout != "" {
+
+		v, err := time.ParseDuration(str_timeout)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse ?timeout= parameter, %w", err)
+		}
+
+		timeout = v
+	}
+
+	body_opts, err := NewBodyOptionsFromQuery(q)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive body options, %w", err)
+	}
+
+	if body_opts.FormField != "" || body_opts.FormToJSON {
+		return nil, fmt.Errorf("?form_field= and ?form_json= parameters are not supported")
+	}
+
+	certs := &snsCertificates{
+		mu:    new(sync.RWMutex),
+		certs: make(map[string]*x509.Certificate),
+	}
+
+	wh := SNSReceiver{
+		topics:       topics,
+		confirm:      confirm,
+		unwrap:       unwrap,
+		hosts:        snsHostPattern,
+		client:       &http.Client{Timeout: timeout},
+		certificates: certs,
+		body_options: body_opts,
+	}
+
+	return wh, nil
+}
+
+// Receive returns the SNS notification in 'req', or its inner payload, after verifying its signature. Subscription confirmation
+// messages are confirmed, if enabled, and along with unsubscribe confirmation messages return a `webhookd.UnhandledEvent` error.
+func (wh SNSReceiver) Receive(ctx context.Context, req *http.Request) ([]byte, *webhookd.WebhookError) {
+
+	select {
+	case <-ctx.Done():
+		return nil, nil
+	default:
+		// pass
+	}
+
+	_, body, err := ReadBody(ctx, req, wh.body_options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var msg snsMessage
+
+	decode_err := json.Unmarshal(body, &msg)
+
+	if decode_err != nil || msg.Type == "" {
+		code := http.StatusBadRequest
+		message := "Invalid SNS message"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	if len(wh.topics) > 0 && !wh.topics[msg.TopicArn] {
+		code := http.StatusForbidden
+		message := fmt.Sprintf("Messages from topic '%s' are not allowed", msg.TopicArn)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	err = wh.verify(ctx, &msg)
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch msg.Type {
+	case SNS_TYPE_NOTIFICATION:
+
+		if wh.unwrap {
+			return []byte(msg.Message), nil
+		}
+
+		return body, nil
+
+	case SNS_TYPE_SUBSCRIPTION_CONFIRMATION:
+
+		if wh.confirm {
+
+			err = wh.subscribe(ctx, &msg)
+
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		code := webhookd.UnhandledEvent
+		message := fmt.Sprintf("Subscription confirmation for topic '%s'", msg.TopicArn)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+
+	case SNS_TYPE_UNSUBSCRIBE_CONFIRMATION:
+
+		code := webhookd.UnhandledEvent
+		message := fmt.Sprintf("Unsubscribe confirmation for topic '%s'", msg.TopicArn)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+
+	default:
+		code := http.StatusBadRequest
+		message := fmt.Sprintf("Unsupported SNS message type '%s'", msg.Type)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+}
+
+// verify returns an error if the signature for 'msg' fails to validate.
+func (wh SNSReceiver) verify(ctx context.Context, msg *snsMessage) *webhookd.WebhookError {
+
+	var hash crypto.Hash
+
+	switch msg.SignatureVersion {
+	case "1":
+		hash = crypto.SHA1
+	case "2":
+		hash = crypto.SHA256
+	default:
+		code := http.StatusBadRequest
+		message := fmt.Sprintf("Unsupported signature version '%s'", msg.SignatureVersion)
+		return &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(msg.Signature)
+
+	if err != nil {
+		code := http.StatusBadRequest
+		message := "Invalid signature encoding"
+		return &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	cert, err := wh.certificate(ctx, msg.SigningCertURL)
+
+	if err != nil {
+		code := http.StatusForbidden
+		message := fmt.Sprintf("Failed to retrieve signing certificate, %v", err)
+		return &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	public_key, ok := cert.PublicKey.(*rsa.PublicKey)
+
+	if !ok {
+		code := http.StatusForbidden
+		message := "Signing certificate does not contain an RSA public key"
+		return &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	var digest []byte
+
+	str_to_sign := snsStringToSign(msg)
+
+	switch hash {
+	case crypto.SHA1:
+		sum := sha1.Sum([]byte(str_to_sign))
+		digest = sum[:]
+	default:
+		sum := sha256.Sum256([]byte(str_to_sign))
+		digest = sum[:]
+	}
+
+	err = rsa.VerifyPKCS1v15(public_key, hash, digest, sig)
+
+	if err != nil {
+		code := http.StatusForbidden
+		message := "Invalid signature"
+		return &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	return nil
+}
+
+// snsStringToSign returns the string that AWS signs for 'msg'.
+func snsStringToSign(msg *snsMessage) string {
+
+	var fields [][2]string
+
+	switch msg.Type {
+	case SNS_TYPE_NOTIFICATION:
+
+		fields = [][2]string{
+			{"Message", msg.Message},
+			{"MessageId", msg.MessageId},
+		}
+
+		if msg.Subject != "" {
+			fields = append(fields, [2]string{"Subject", msg.Subject})
+		}
+
+		fields = append(fields, [][2]string{
+			{"Timestamp", msg.Timestamp},
+			{"TopicArn", msg.TopicArn},
+			{"Type", msg.Type},
+		}...)
+
+	default:
+
+		fields = [][2]string{
+			{"Message", msg.Message},
+			{"MessageId", msg.MessageId},
+			{"SubscribeURL", msg.SubscribeURL},
+			{"Timestamp", msg.Timestamp},
+			{"Token", msg.Token},
+			{"TopicArn", msg.TopicArn},
+			{"Type", msg.Type},
+		}
+	}
+
+	var b strings.Builder
+
+	for _, f := range fields {
+		b.WriteString(f[0])
+		b.WriteString("\n")
+		b.WriteString(f[1])
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// checkURL returns an error if 'str_url' is not an HTTPS URL whose host matches the hosts that 'wh' was instantiated with.
+func (wh SNSReceiver) checkURL(str_url string) (*url.URL, error) {
+
+	u, err := url.Parse(str_url)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URL, %w", err)
+	}
+
+	if u.Scheme != "https" || !wh.hosts.MatchString(u.Hostname()) {
+		return nil, fmt.Errorf("Untrusted URL '%s'", str_url)
+	}
+
+	return u, nil
+}
+
+// certificate returns the (cached) signing certificate at 'cert_url'.
+func (wh SNSReceiver) certificate(ctx context.Context, cert_url string) (*x509.Certificate, error) {
+
+	wh.certificates.mu.RLock()
+	cert, ok := wh.certificates.certs[cert_url]
+	wh.certificates.mu.RUnlock()
+
+	if ok {
+		return cert, nil
+	}
+
+	u, err := wh.checkURL(cert_url)
+
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create request, %w", err)
+	}
+
+	rsp, err := wh.client.Do(req)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to execute request, %w", err)
+	}
+
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Request returned unexpected status %s", rsp.Status)
+	}
+
+	enc, err := io.ReadAll(io.LimitReader(rsp.Body, maxSNSCertificateSize))
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read certificate, %w", err)
+	}
+
+	block, _ := pem.Decode(enc)
+
+	if block == nil {
+		return nil, fmt.Errorf("Failed to decode certificate")
+	}
+
+	cert, err = x509.ParseCertificate(block.Bytes)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse certificate, %w", err)
+	}
+
+	wh.certificates.mu.Lock()
+	wh.certificates.certs[cert_url] = cert
+	wh.certificates.mu.Unlock()
+
+	return cert, nil
+}
+
+// subscribe confirms the subscription described by 'msg' by retrieving its `SubscribeURL`.
+func (wh SNSReceiver) subscribe(ctx context.Context, msg *snsMessage) *webhookd.WebhookError {
+
+	u, err := wh.checkURL(msg.SubscribeURL)
+
+	if err != nil {
+		code := http.StatusForbidden
+		message := err.Error()
+		return &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+
+	if err != nil {
+		code := http.StatusInternalServerError
+		message := fmt.Sprintf("Failed to create request, %v", err)
+		return &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	rsp, err := wh.client.Do(req)
+
+	if err != nil {
+		code := http.StatusBadGateway
+		message := fmt.Sprintf("Failed to confirm subscription, %v", err)
+		return &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	defer rsp.Body.Close()
+
+	io.Copy(io.Discard, rsp.Body)
+
+	if rsp.StatusCode != http.StatusOK {
+		code := http.StatusBadGateway
+		message := fmt.Sprintf("Failed to confirm subscription, request returned unexpected status %s", rsp.Status)
+		return &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	return nil
+}
@@ -0,0 +1,235 @@
+package receiver
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+func TestSNSReceiver(t *testing.T) {
+
+	ctx := context.Background()
+
+	private_key, err := rsa.GenerateKey(rand.Reader, 2048)
+
+	if err != nil {
+		t.Fatalf("Failed to generate key, %v", err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "sns.amazonaws.com"},
+		NotBefore:    time.Now().Add(-1 * time.Hour),
+		NotAfter:     time.Now().Add(1 * time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &private_key.PublicKey, private_key)
+
+	if err != nil {
+		t.Fatalf("Failed to create certificate, %v", err)
+	}
+
+	cert_pem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+
+	var confirmed int32
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/cert.pem", func(rsp http.ResponseWriter, req *http.Request) {
+		rsp.Write(cert_pem)
+	})
+
+	mux.HandleFunc("/confirm", func(rsp http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&confirmed, 1)
+		rsp.Write([]byte(`<ConfirmSubscriptionResponse/>`))
+	})
+
+	s := httptest.NewTLSServer(mux)
+	defer s.Close()
+
+	r, err := NewReceiver(ctx, "sns://?topic=arn:aws:sns:us-east-1:123456789012:example")
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	sns_r := r.(SNSReceiver)
+	sns_r.hosts = regexp.MustCompile(`^127\.0\.0\.1$`)
+	sns_r.client = s.Client()
+
+	sign := func(msg *snsMessage) []byte {
+
+		msg.SignatureVersion = "2"
+		msg.SigningCertURL = s.URL + "/cert.pem"
+
+		sum := sha256.Sum256([]byte(snsStringToSign(msg)))
+
+		sig, err := rsa.SignPKCS1v15(rand.Reader, private_key, crypto.SHA256, sum[:])
+
+		if err != nil {
+			t.Fatalf("Failed to sign message, %v", err)
+		}
+
+		msg.Signature = base64.StdEncoding.EncodeToString(sig)
+
+		enc, err := json.Marshal(msg)
+
+		if err != nil {
+			t.Fatalf("Failed to encode message, %v", err)
+		}
+
+		return enc
+	}
+
+	receive := func(body []byte) ([]byte, int) {
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/sns", bytes.NewReader(body))
+
+		if err != nil {
+			t.Fatalf("Failed to create new request, %v", err)
+		}
+
+		rsp, err2 := sns_r.Receive(ctx, req)
+
+		if err2 != nil {
+			return nil, err2.Code
+		}
+
+		return rsp, 0
+	}
+
+	confirmation := sign(&snsMessage{
+		Type:         SNS_TYPE_SUBSCRIPTION_CONFIRMATION,
+		MessageId:    "1",
+		Token:        "t0ken",
+		TopicArn:     "arn:aws:sns:us-east-1:123456789012:example",
+		Message:      "You have chosen to subscribe to the topic",
+		SubscribeURL: s.URL + "/confirm",
+		Timestamp:    "2024-01-01T00:00:00.000Z",
+	})
+
+	_, code := receive(confirmation)
+
+	if code != webhookd.UnhandledEvent || atomic.LoadInt32(&confirmed) != 1 {
+		t.Fatalf("Expected subscription to be confirmed, %d", code)
+	}
+
+	notification := &snsMessage{
+		Type:      SNS_TYPE_NOTIFICATION,
+		MessageId: "2",
+		TopicArn:  "arn:aws:sns:us-east-1:123456789012:example",
+		Subject:   "Hello",
+		Message:   `{"hello":"world"}`,
+		Timestamp: "2024-01-01T00:00:00.000Z",
+	}
+
+	body, code := receive(sign(notification))
+
+	if code != 0 || string(body) != `{"hello":"world"}` {
+		t.Fatalf("Unexpected output '%s' (%d)", string(body), code)
+	}
+
+	notification.Message = `{"hello":"tampered"}`
+
+	enc, err := json.Marshal(notification)
+
+	if err != nil {
+		t.Fatalf("Failed to encode message, %v", err)
+	}
+
+	_, code = receive(enc)
+
+	if code != http.StatusForbidden {
+		t.Fatalf("Expected tampered message to fail, %d", code)
+	}
+
+	notification.TopicArn = "arn:aws:sns:us-east-1:123456789012:other"
+
+	_, code = receive(sign(notification))
+
+	if code != http.StatusForbidden {
+		t.Fatalf("Expected message from other topic to fail, %d", code)
+	}
+
+	foreign_confirmation := sign(&snsMessage{
+		Type:         SNS_TYPE_SUBSCRIPTION_CONFIRMATION,
+		MessageId:    "3",
+		Token:        "t0ken",
+		TopicArn:     "arn:aws:sns:us-east-1:210987654321:foreign",
+		Message:      "You have chosen to subscribe to the topic",
+		SubscribeURL: s.URL + "/confirm",
+		Timestamp:    "2024-01-01T00:00:00.000Z",
+	})
+
+	_, code = receive(foreign_confirmation)
+
+	if code != http.StatusForbidden || atomic.LoadInt32(&confirmed) != 1 {
+		t.Fatalf("Expected subscription confirmation for foreign topic to fail, %d", code)
+	}
+
+	// Without a topic allowlist subscriptions are not confirmed
+
+	r, err = NewReceiver(ctx, "sns://")
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	sns_r = r.(SNSReceiver)
+	sns_r.hosts = regexp.MustCompile(`^127\.0\.0\.1$`)
+	sns_r.client = s.Client()
+
+	_, code = receive(foreign_confirmation)
+
+	if code != webhookd.UnhandledEvent || atomic.LoadInt32(&confirmed) != 1 {
+		t.Fatalf("Expected subscription confirmation without topics to be ignored, %d", code)
+	}
+
+	_, err = NewReceiver(ctx, "sns://?confirm=true")
+
+	if err == nil {
+		t.Fatalf("Expected receiver confirming subscriptions without topics to fail")
+	}
+}
+
+func TestSNSReceiverUntrustedCertificate(t *testing.T) {
+
+	ctx := context.Background()
+
+	r, err := NewReceiver(ctx, "sns://")
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	msg := []byte(`{"Type":"Notification","Message":"hello","SignatureVersion":"1","Signature":"YWJj","SigningCertURL":"https://example.com/cert.pem"}`)
+
+	req, err := http.NewRequest("POST", "http://localhost:8080/sns", bytes.NewReader(msg))
+
+	if err != nil {
+		t.Fatalf("Failed to create new request, %v", err)
+	}
+
+	_, err2 := r.Receive(ctx, req)
+
+	if err2 == nil || err2.Code != http.StatusForbidden {
+		t.Fatalf("Expected untrusted certificate URL to fail, %v", err2)
+	}
+}
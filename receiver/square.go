@@ -0,0 +1,127 @@
+package receiver
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+// SQUARE_SIGNATURE_HEADER is the HTTP header containing the signature for a Square webhook message.
+const SQUARE_SIGNATURE_HEADER string = "X-Square-Hmacsha256-Signature"
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterReceiver(ctx, "square", NewSquareReceiver)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// SquareReceiver implements the `webhookd.WebhookReceiver` interface for receiving Square webhook messages.
+type SquareReceiver struct {
+	webhookd.WebhookReceiver
+	// signature_keys is the list of Square webhook signature keys used to validate message signatures.
+	signature_keys []string
+	// notification_url is the notification URL of the webhook subscription, as configured in Square.
+	notification_url string
+	// body_options is the `BodyOptions` instance used to decode message bodies.
+	body_options *BodyOptions
+}
+
+// NewSquareReceiver returns a new `SquareReceiver` instance configured by 'uri' in the form of:
+//
+//	square://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `signature_key={STRING}` One or more (comma-separated) Square webhook signature keys used to validate message signatures. Required.
+// * `url={URL}` The (URI-escaped) notification URL of the webhook subscription, exactly as configured in Square. Required.
+// * Any of the parameters supported by `NewBodyOptionsFromQuery`.
+func NewSquareReceiver(ctx context.Context, uri string) (webhookd.WebhookReceiver, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	signature_keys := parseSecrets(q, "signature_key")
+
+	if len(signature_keys) == 0 {
+		return nil, fmt.Errorf("Missing ?signature_key= parameter")
+	}
+
+	notification_url := q.Get("url")
+
+	if notification_url == "" {
+		return nil, fmt.Errorf("Missing ?url= parameter")
+	}
+
+	body_opts, err := NewBodyOptionsFromQuery(q)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive body options, %w", err)
+	}
+
+	wh := SquareReceiver{
+		signature_keys:   signature_keys,
+		notification_url: notification_url,
+		body_options:     body_opts,
+	}
+
+	return wh, nil
+}
+
+// Receive returns the body of the Square message in 'req' after validating its `X-Square-Hmacsha256-Signature` header.
+func (wh SquareReceiver) Receive(ctx context.Context, req *http.Request) ([]byte, *webhookd.WebhookError) {
+
+	select {
+	case <-ctx.Done():
+		return nil, nil
+	default:
+		// pass
+	}
+
+	sig := req.Header.Get(SQUARE_SIGNATURE_HEADER)
+
+	if sig == "" {
+		code := http.StatusBadRequest
+		message := fmt.Sprintf("Missing %s header", SQUARE_SIGNATURE_HEADER)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	raw, body, err := ReadBody(ctx, req, wh.body_options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Square signs the notification URL followed by the body
+
+	verify := func(secret string) bool {
+
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(wh.notification_url))
+		mac.Write(VerifiableBody(raw, body, wh.body_options))
+
+		expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+		return hmac.Equal([]byte(expected), []byte(sig))
+	}
+
+	if !anySecret(wh.signature_keys, verify) {
+		code := http.StatusForbidden
+		message := "Invalid signature"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	return DecodeBody(ctx, req, body, wh.body_options)
+}
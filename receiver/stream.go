@@ -0,0 +1,383 @@
+package receiver
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"context"
+	"crypto/hmac"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+// ErrInvalidSignature is returned by `VerifyingReader` when the signature for a message body fails to validate.
+var ErrInvalidSignature = errors.New("Invalid signature")
+
+// ErrBodyTooLarge is returned by the readers in this package when a message body exceeds its maximum size.
+var ErrBodyTooLarge = errors.New("Body exceeds maximum size")
+
+// bufferPool is a `sync.Pool` of `bytes.Buffer` instances used to read message bodies.
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+// maxPooledBufferSize is the maximum capacity of a `bytes.Buffer` that will be returned to `bufferPool`.
+const maxPooledBufferSize int = 4 << 20
+
+// readAll reads 'r' until EOF using a pooled buffer and returns a copy of the data that was read. If 'size_hint'
+// is greater than zero the buffer is grown to that size before reading.
+func readAll(r io.Reader, size_hint int64) ([]byte, error) {
+
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+
+	defer func() {
+		if buf.Cap() <= maxPooledBufferSize {
+			bufferPool.Put(buf)
+		}
+	}()
+
+	if size_hint > 0 && size_hint <= int64(maxPooledBufferSize) {
+		buf.Grow(int(size_hint))
+	}
+
+	_, err := buf.ReadFrom(r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	body := make([]byte, buf.Len())
+	copy(body, buf.Bytes())
+
+	return body, nil
+}
+
+// ReadBodyStream returns an `io.ReadCloser` for the body of 'req' which is decompressed, as it is read, according to the
+// rules defined in 'opts'. Form-related rules in 'opts' are not supported by streaming reads and will trigger an error.
+func ReadBodyStream(ctx context.Context, req *http.Request, opts *BodyOptions) (io.ReadCloser, *webhookd.WebhookError) {
+
+	if opts == nil {
+		return req.Body, nil
+	}
+
+	if opts.FormField != "" || opts.FormToJSON {
+		code := http.StatusInternalServerError
+		message := "Form decoding is not supported for streaming requests"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	if !opts.Decompress {
+		return req.Body, nil
+	}
+
+	encoding := strings.ToLower(strings.TrimSpace(req.Header.Get("Content-Encoding")))
+
+	var r io.ReadCloser
+
+	switch encoding {
+	case "", "identity":
+		return req.Body, nil
+	case "gzip", "x-gzip":
+
+		gz, err := gzip.NewReader(req.Body)
+
+		if err != nil {
+			code := http.StatusBadRequest
+			message := fmt.Sprintf("Failed to create gzip reader, %v", err)
+			return nil, &webhookd.WebhookError{Code: code, Message: message}
+		}
+
+		r = gz
+
+	case "deflate":
+
+		// Streaming reads can't rewind the body to fall back to raw deflate
+		// data the way DecompressBody does so sniff for a zlib header instead
+
+		r = newDeflateReader(req.Body)
+
+	default:
+		code := http.StatusUnsupportedMediaType
+		message := fmt.Sprintf("Unsupported content encoding '%s'", encoding)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	sr := &streamReader{
+		Reader:  r,
+		closers: []io.Closer{r, req.Body},
+	}
+
+	if opts.MaxDecompressedBytes > 0 {
+		sr.Reader = &limitedReader{r: r, remaining: opts.MaxDecompressedBytes}
+	}
+
+	return sr, nil
+}
+
+// ReadVerifiedBodyStream returns an `io.ReadCloser` for the body of 'req', as returned by `ReadBodyStream`, which computes
+// the HMAC digest of the body using the hash function 'h' and each of 'secrets' as it is read. Once the body has been read
+// `ErrInvalidSignature` is returned, instead of `io.EOF`, unless 'verify' returns true for the digest of at least one secret.
+// If 'opts' signals that signatures should be validated using the raw (compressed) bytes of a message body then the digest
+// is computed before the body is decompressed and any remaining raw bytes are read once the decompressed body is exhausted.
+func ReadVerifiedBodyStream(ctx context.Context, req *http.Request, opts *BodyOptions, h func() hash.Hash, secrets []string, verify func([]byte) bool) (io.ReadCloser, *webhookd.WebhookError) {
+
+	if len(secrets) == 0 {
+		code := http.StatusInternalServerError
+		message := "Missing secrets"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	macs := make([]hash.Hash, len(secrets))
+
+	for idx, secret := range secrets {
+		macs[idx] = hmac.New(h, []byte(secret))
+	}
+
+	set := &hmacSet{macs: macs}
+
+	verify_any := func(sums []byte) bool {
+
+		ok := false
+		size := macs[0].Size()
+
+		// Check every digest, rather than returning early, so the time taken does not depend on which secret matched
+
+		for i := 0; i+size <= len(sums); i += size {
+
+			if verify(sums[i : i+size]) {
+				ok = true
+			}
+		}
+
+		return ok
+	}
+
+	if opts == nil || !opts.VerifyRaw {
+
+		r, err := ReadBodyStream(ctx, req, opts)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return NewVerifyingReader(r, set, verify_any), nil
+	}
+
+	vr := NewVerifyingReader(req.Body, set, verify_any)
+
+	raw_req := new(http.Request)
+	*raw_req = *req
+	raw_req.Body = vr
+
+	r, err := ReadBodyStream(ctx, raw_req, opts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	dr := &drainingReader{
+		r:     r,
+		drain: vr,
+	}
+
+	return dr, nil
+}
+
+// VerifyingReader is an `io.ReadCloser` that computes a hash of the bytes it reads and validates that hash once
+// the underlying reader returns `io.EOF`. If validation fails `ErrInvalidSignature` is returned instead of `io.EOF`.
+type VerifyingReader struct {
+	io.ReadCloser
+	// r is the underlying reader.
+	r io.ReadCloser
+	// hash is the `hash.Hash` instance used to compute a digest of the bytes that have been read.
+	hash hash.Hash
+	// verify is a function used to validate the final digest.
+	verify func([]byte) bool
+	// err is the sticky error returned once the underlying reader has been exhausted.
+	err error
+}
+
+// NewVerifyingReader returns a new `VerifyingReader` instance for 'r' that will compute a digest using 'h' and validate
+// it, once all the bytes in 'r' have been read, using 'verify'.
+func NewVerifyingReader(r io.ReadCloser, h hash.Hash, verify func([]byte) bool) *VerifyingReader {
+
+	vr := &VerifyingReader{
+		r:      r,
+		hash:   h,
+		verify: verify,
+	}
+
+	return vr
+}
+
+// Read reads up to len(p) bytes in to 'p' updating the underlying digest.
+func (vr *VerifyingReader) Read(p []byte) (int, error) {
+
+	if vr.err != nil {
+		return 0, vr.err
+	}
+
+	n, err := vr.r.Read(p)
+
+	if n > 0 {
+		vr.hash.Write(p[:n])
+	}
+
+	if err == io.EOF {
+
+		if !vr.verify(vr.hash.Sum(nil)) {
+			err = ErrInvalidSignature
+		}
+
+		vr.err = err
+	}
+
+	return n, err
+}
+
+// Close closes the underlying reader.
+func (vr *VerifyingReader) Close() error {
+	return vr.r.Close()
+}
+
+// hmacSet is a `hash.Hash` that writes to multiple HMAC instances, one for each of a list of secrets. Its digest is the
+// concatenation of the digests of each instance, in order.
+type hmacSet struct {
+	macs []hash.Hash
+}
+
+// Write writes 'p' to each of the HMAC instances in 's'.
+func (s *hmacSet) Write(p []byte) (int, error) {
+
+	for _, m := range s.macs {
+		m.Write(p)
+	}
+
+	return len(p), nil
+}
+
+// Sum appends the concatenated digests of each of the HMAC instances in 's' to 'b'.
+func (s *hmacSet) Sum(b []byte) []byte {
+
+	for _, m := range s.macs {
+		b = m.Sum(b)
+	}
+
+	return b
+}
+
+// Reset resets each of the HMAC instances in 's'.
+func (s *hmacSet) Reset() {
+
+	for _, m := range s.macs {
+		m.Reset()
+	}
+}
+
+// Size returns the length, in bytes, of the concatenated digests of the HMAC instances in 's'.
+func (s *hmacSet) Size() int {
+	return s.macs[0].Size() * len(s.macs)
+}
+
+// BlockSize returns the block size of the HMAC instances in 's'.
+func (s *hmacSet) BlockSize() int {
+	return s.macs[0].BlockSize()
+}
+
+// drainingReader is an `io.ReadCloser` that, once its reader is exhausted, reads the remaining bytes from another reader
+// (typically the compressed body underlying a decompressing reader) so that any errors it returns at EOF are reported.
+type drainingReader struct {
+	r     io.ReadCloser
+	drain io.Reader
+}
+
+// Read reads up to len(p) bytes in to 'p'.
+func (dr *drainingReader) Read(p []byte) (int, error) {
+
+	n, err := dr.r.Read(p)
+
+	if err == io.EOF {
+
+		_, drain_err := io.Copy(io.Discard, dr.drain)
+
+		if drain_err != nil {
+			err = drain_err
+		}
+	}
+
+	return n, err
+}
+
+// Close closes the underlying reader.
+func (dr *drainingReader) Close() error {
+	return dr.r.Close()
+}
+
+// streamReader is an `io.ReadCloser` that closes multiple `io.Closer` instances.
+type streamReader struct {
+	io.Reader
+	closers []io.Closer
+}
+
+// Close closes all the `io.Closer` instances associated with 'sr'.
+func (sr *streamReader) Close() error {
+
+	var first error
+
+	for _, c := range sr.closers {
+
+		err := c.Close()
+
+		if err != nil && first == nil {
+			first = err
+		}
+	}
+
+	return first
+}
+
+// limitedReader is an `io.Reader` that returns `ErrBodyTooLarge` once more than a fixed number of bytes have been read.
+type limitedReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+// Read reads up to len(p) bytes in to 'p'.
+func (lr *limitedReader) Read(p []byte) (int, error) {
+
+	n, err := lr.r.Read(p)
+	lr.remaining -= int64(n)
+
+	if lr.remaining < 0 {
+		return n, ErrBodyTooLarge
+	}
+
+	return n, err
+}
+
+// newDeflateReader returns an `io.ReadCloser` for "deflate" encoded data in 'r' sniffing whether or not it is zlib-wrapped.
+func newDeflateReader(r io.Reader) io.ReadCloser {
+
+	br := bufio.NewReader(r)
+	hdr, _ := br.Peek(2)
+
+	// zlib headers are two bytes where the low nibble of the first byte is 8 (deflate)
+	// and the two bytes, read as a big-endian uint16, are a multiple of 31 (RFC 1950)
+
+	if len(hdr) == 2 && hdr[0]&0x0f == 8 && (uint16(hdr[0])<<8|uint16(hdr[1]))%31 == 0 {
+		br.Discard(2)
+	}
+
+	return flate.NewReader(br)
+}
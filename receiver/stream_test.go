@@ -0,0 +1,279 @@
+package receiver
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+func TestReceiveStream(t *testing.T) {
+
+	ctx := context.Background()
+
+	r, err := NewReceiver(ctx, "insecure://")
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	sr, ok := r.(webhookd.WebhookStreamingReceiver)
+
+	if !ok {
+		t.Fatalf("Expected insecure receiver to support streaming")
+	}
+
+	expected := []byte("hello world")
+
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	zw.Write(expected)
+	zw.Close()
+
+	req, err := http.NewRequest("POST", "http://localhost:8080/insecure", &buf)
+
+	if err != nil {
+		t.Fatalf("Failed to create new request, %v", err)
+	}
+
+	req.Header.Set("Content-Encoding", "deflate")
+
+	body_r, err2 := sr.ReceiveStream(ctx, req)
+
+	if err2 != nil {
+		t.Fatalf("Failed to receive stream, %v", err2)
+	}
+
+	defer body_r.Close()
+
+	body, err := io.ReadAll(body_r)
+
+	if err != nil {
+		t.Fatalf("Failed to read stream, %v", err)
+	}
+
+	if !bytes.Equal(body, expected) {
+		t.Fatalf("Unexpected output '%s'", string(body))
+	}
+}
+
+func TestVerifyingReader(t *testing.T) {
+
+	secret := []byte("s33kret")
+	body := []byte("hello world")
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	expected := mac.Sum(nil)
+
+	verify := func(sum []byte) bool {
+		return hmac.Equal(sum, expected)
+	}
+
+	vr := NewVerifyingReader(io.NopCloser(bytes.NewReader(body)), hmac.New(sha256.New, secret), verify)
+
+	_, err := io.ReadAll(vr)
+
+	if err != nil {
+		t.Fatalf("Failed to verify body, %v", err)
+	}
+
+	vr = NewVerifyingReader(io.NopCloser(bytes.NewReader([]byte("goodbye world"))), hmac.New(sha256.New, secret), verify)
+
+	_, err = io.ReadAll(vr)
+
+	if !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("Expected invalid signature, got %v", err)
+	}
+}
+
+func TestReadVerifiedBodyStream(t *testing.T) {
+
+	ctx := context.Background()
+
+	body := bytes.Repeat([]byte("hello world "), 1000)
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write(body)
+	gz.Close()
+
+	compressed := buf.Bytes()
+
+	sign := func(secret string, data []byte) []byte {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(data)
+		return mac.Sum(nil)
+	}
+
+	tests := []struct {
+		verify_raw bool
+		signed     []byte
+		secret     string
+		ok         bool
+	}{
+		{false, body, "s33kret", true},
+		{false, body, "n3wer", true},
+		{false, body, "wr0ng", false},
+		{false, compressed, "s33kret", false},
+		{true, compressed, "s33kret", true},
+		{true, compressed, "wr0ng", false},
+		{true, body, "s33kret", false},
+	}
+
+	for idx, test := range tests {
+
+		opts := &BodyOptions{
+			Decompress: true,
+			VerifyRaw:  test.verify_raw,
+		}
+
+		expected := sign(test.secret, test.signed)
+
+		verify := func(sum []byte) bool {
+			return hmac.Equal(sum, expected)
+		}
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/stream", bytes.NewReader(compressed))
+
+		if err != nil {
+			t.Fatalf("Failed to create new request, %v", err)
+		}
+
+		req.Header.Set("Content-Encoding", "gzip")
+
+		r, err2 := ReadVerifiedBodyStream(ctx, req, opts, sha256.New, []string{"s33kret", "n3wer"}, verify)
+
+		if err2 != nil {
+			t.Fatalf("Failed to create verified stream for test %d, %v", idx, err2)
+		}
+
+		out, err := io.ReadAll(r)
+		r.Close()
+
+		if !test.ok {
+
+			if !errors.Is(err, ErrInvalidSignature) {
+				t.Fatalf("Expected invalid signature for test %d, got %v", idx, err)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("Failed to verify body for test %d, %v", idx, err)
+		}
+
+		if !bytes.Equal(out, body) {
+			t.Fatalf("Unexpected output for test %d", idx)
+		}
+	}
+}
+
+func TestReceiveStreamSignatures(t *testing.T) {
+
+	ctx := context.Background()
+
+	body := []byte(`{"hello":"world"}`)
+
+	hex_sha256 := func(secret string) string {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+
+	tests := []struct {
+		uri    string
+		header string
+		sign   func(string) string
+	}{
+		{"gitea://?secret=s33kret", GITEA_SIGNATURE_HEADER, hex_sha256},
+		{"gogs://?secret=s33kret", GOGS_SIGNATURE_HEADER, hex_sha256},
+		{"phabricator://?hmac_key=s33kret", PHABRICATOR_SIGNATURE_HEADER, hex_sha256},
+		{"heroku://?secret=s33kret", HEROKU_SIGNATURE_HEADER, func(secret string) string {
+			mac := hmac.New(sha256.New, []byte(secret))
+			mac.Write(body)
+			return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+		}},
+		{"intercom://?client_secret=s33kret", INTERCOM_SIGNATURE_HEADER, func(secret string) string {
+			mac := hmac.New(sha1.New, []byte(secret))
+			mac.Write(body)
+			return "sha1=" + hex.EncodeToString(mac.Sum(nil))
+		}},
+	}
+
+	for _, test := range tests {
+
+		r, err := NewReceiver(ctx, test.uri)
+
+		if err != nil {
+			t.Fatalf("Failed to create receiver for %s, %v", test.uri, err)
+		}
+
+		sr, ok := r.(webhookd.WebhookStreamingReceiver)
+
+		if !ok {
+			t.Fatalf("Expected %s to support streaming", test.uri)
+		}
+
+		for _, secret := range []string{"s33kret", "wr0ng"} {
+
+			req, err := http.NewRequest("POST", "http://localhost:8080/stream", bytes.NewReader(body))
+
+			if err != nil {
+				t.Fatalf("Failed to create new request, %v", err)
+			}
+
+			req.Header.Set(test.header, test.sign(secret))
+
+			rsp, err2 := sr.ReceiveStream(ctx, req)
+
+			if err2 != nil {
+				t.Fatalf("Failed to receive stream for %s, %v", test.uri, err2)
+			}
+
+			out, err := io.ReadAll(rsp)
+			rsp.Close()
+
+			if secret != "s33kret" {
+
+				if !errors.Is(err, ErrInvalidSignature) {
+					t.Fatalf("Expected invalid signature for %s, got %v", test.uri, err)
+				}
+
+				continue
+			}
+
+			if err != nil {
+				t.Fatalf("Failed to read stream for %s, %v", test.uri, err)
+			}
+
+			if !bytes.Equal(out, body) {
+				t.Fatalf("Unexpected output for %s", test.uri)
+			}
+		}
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/stream", bytes.NewReader(body))
+
+		if err != nil {
+			t.Fatalf("Failed to create new request, %v", err)
+		}
+
+		_, err2 := sr.ReceiveStream(ctx, req)
+
+		if err2 == nil || err2.Code != http.StatusBadRequest {
+			t.Fatalf("Expected missing signature for %s to fail, %v", test.uri, err2)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package receiver
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+// TFC_SIGNATURE_HEADER is the HTTP header containing the signature for a Terraform Cloud notification.
+const TFC_SIGNATURE_HEADER string = "X-Tfe-Notification-Signature"
+
+// TFC_TRIGGER_VERIFICATION is the trigger for the notification Terraform Cloud sends to verify a notification configuration.
+const TFC_TRIGGER_VERIFICATION string = "verification"
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterReceiver(ctx, "tfc", NewTFCReceiver)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// tfcNotification is the subset of a Terraform Cloud notification payload used by `TFCReceiver`.
+type tfcNotification struct {
+	Notifications []struct {
+		Trigger string `json:"trigger"`
+	} `json:"notifications"`
+}
+
+// TFCReceiver implements the `webhookd.WebhookReceiver` interface for receiving Terraform Cloud (and Terraform Enterprise) run notifications.
+type TFCReceiver struct {
+	webhookd.WebhookReceiver
+	// tokens is the list of notification tokens used to validate message signatures.
+	tokens []string
+	// body_options is the `BodyOptions` instance used to decode message bodies.
+	body_options *BodyOptions
+}
+
+// NewTFCReceiver returns a new `TFCReceiver` instance configured by 'uri' in the form of:
+//
+//	tfc://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `token={STRING}` One or more (comma-separated) tokens, defined in the notification configuration, used to validate message signatures. Required.
+// * Any of the parameters supported by `NewBodyOptionsFromQuery`.
+func NewTFCReceiver(ctx context.Context, uri string) (webhookd.WebhookReceiver, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	tokens := parseSecrets(q, "token")
+
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("Missing ?token= parameter")
+	}
+
+	body_opts, err := NewBodyOptionsFromQuery(q)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive body options, %w", err)
+	}
+
+	wh := TFCReceiver{
+		tokens:       tokens,
+		body_options: body_opts,
+	}
+
+	return wh, nil
+}
+
+// Receive returns the body of the Terraform Cloud notification in 'req' after validating its `X-TFE-Notification-Signature` header.
+// Verification notifications return a `webhookd.UnhandledEvent` error.
+func (wh TFCReceiver) Receive(ctx context.Context, req *http.Request) ([]byte, *webhookd.WebhookError) {
+
+	select {
+	case <-ctx.Done():
+		return nil, nil
+	default:
+		// pass
+	}
+
+	sig := req.Header.Get(TFC_SIGNATURE_HEADER)
+
+	if sig == "" {
+		code := http.StatusBadRequest
+		message := fmt.Sprintf("Missing %s header", TFC_SIGNATURE_HEADER)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	raw, body, err := ReadBody(ctx, req, wh.body_options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	verify := func(secret string) bool {
+
+		mac := hmac.New(sha512.New, []byte(secret))
+		mac.Write(VerifiableBody(raw, body, wh.body_options))
+
+		expected := hex.EncodeToString(mac.Sum(nil))
+		return hmac.Equal([]byte(expected), []byte(sig))
+	}
+
+	if !anySecret(wh.tokens, verify) {
+		code := http.StatusForbidden
+		message := "Invalid signature"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	var n tfcNotification
+
+	decode_err := json.Unmarshal(body, &n)
+
+	if decode_err != nil {
+		code := http.StatusBadRequest
+		message := fmt.Sprintf("Failed to decode notification, %v", decode_err)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	if len(n.Notifications) > 0 && n.Notifications[0].Trigger == TFC_TRIGGER_VERIFICATION {
+		code := webhookd.UnhandledEvent
+		message := "Received verification notification"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	return body, nil
+}
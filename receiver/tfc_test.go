@@ -0,0 +1,73 @@
+package receiver
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"net/http"
+	"testing"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+func TestTFCReceiver(t *testing.T) {
+
+	ctx := context.Background()
+
+	r, err := NewReceiver(ctx, "tfc://?token=s33kret")
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	sign := func(key string, body []byte) string {
+		mac := hmac.New(sha512.New, []byte(key))
+		mac.Write(body)
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+
+	completed := []byte(`{"payload_version":1,"run_id":"run-1","notifications":[{"trigger":"run:completed","run_status":"applied"}]}`)
+	verification := []byte(`{"payload_version":1,"notifications":[{"trigger":"verification"}]}`)
+
+	tests := []struct {
+		body     []byte
+		sig      string
+		expected int
+	}{
+		{completed, sign("s33kret", completed), 0},
+		{completed, sign("wr0ng", completed), http.StatusForbidden},
+		{verification, sign("s33kret", verification), webhookd.UnhandledEvent},
+	}
+
+	for _, test := range tests {
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/tfc", bytes.NewReader(test.body))
+
+		if err != nil {
+			t.Fatalf("Failed to create new request, %v", err)
+		}
+
+		req.Header.Set(TFC_SIGNATURE_HEADER, test.sig)
+
+		rsp, err2 := r.Receive(ctx, req)
+
+		if test.expected == 0 {
+
+			if err2 != nil {
+				t.Fatalf("Failed to receive message, %v", err2)
+			}
+
+			if !bytes.Equal(rsp, test.body) {
+				t.Fatalf("Unexpected output '%s'", string(rsp))
+			}
+
+			continue
+		}
+
+		if err2 == nil || err2.Code != test.expected {
+			t.Fatalf("Expected %d for %s but got %v", test.expected, string(test.body), err2)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package receiver
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+// TOKEN_HEADER is the HTTP header that may contain the token for a message, as an alternative to the `token` query parameter.
+const TOKEN_HEADER string = "X-Webhookd-Token"
+
+// tokenSchemes is the list of receiver schemes for providers which authenticate messages using a shared token rather than a signature.
+var tokenSchemes = []string{
+	"digitalocean",
+	"linode",
+}
+
+func init() {
+
+	ctx := context.Background()
+
+	for _, scheme := range tokenSchemes {
+
+		err := RegisterReceiver(ctx, scheme, NewTokenReceiver)
+
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+// TokenReceiver implements the `webhookd.WebhookReceiver` interface for receiving DigitalOcean and Linode (Akamai Cloud)
+// monitoring alert webhook messages, which are authenticated using a shared token rather than a signature.
+type TokenReceiver struct {
+	webhookd.WebhookReceiver
+	// tokens is the list of valid tokens that requests must include.
+	tokens []string
+	// body_options is the `BodyOptions` instance used to decode message bodies.
+	body_options *BodyOptions
+}
+
+// NewTokenReceiver returns a new `TokenReceiver` instance configured by 'uri' in the form of:
+//
+//	digitalocean://?{PARAMETERS}
+//	linode://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `token={STRING}` One or more (comma-separated) valid tokens that requests must include. Required.
+// * Any of the parameters supported by `NewBodyOptionsFromQuery`.
+//
+// DigitalOcean and Linode do not sign webhook messages so they are authenticated using a token which requests may include in
+// an "X-Webhookd-Token" header, an "Authorization: Bearer {TOKEN}" header or, since neither provider allows custom headers to
+// be configured for all webhooks, a `token` query parameter in the URL configured with the provider, for example
+// "https://example.com/linode?token={TOKEN}". In that case the URL itself is a secret and should be treated as one.
+func NewTokenReceiver(ctx context.Context, uri string) (webhookd.WebhookReceiver, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	supported := false
+
+	for _, scheme := range tokenSchemes {
+
+		if u.Scheme == scheme {
+			supported = true
+			break
+		}
+	}
+
+	if !supported {
+		return nil, fmt.Errorf("Unsupported scheme '%s'", u.Scheme)
+	}
+
+	q := u.Query()
+
+	tokens := parseSecrets(q, "token")
+
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("Missing ?token= parameter")
+	}
+
+	body_opts, err := NewBodyOptionsFromQuery(q)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive body options, %w", err)
+	}
+
+	wh := TokenReceiver{
+		tokens:       tokens,
+		body_options: body_opts,
+	}
+
+	return wh, nil
+}
+
+// Receive returns the body of the message in 'req' after checking its token.
+func (wh TokenReceiver) Receive(ctx context.Context, req *http.Request) ([]byte, *webhookd.WebhookError) {
+
+	select {
+	case <-ctx.Done():
+		return nil, nil
+	default:
+		// pass
+	}
+
+	if !constantTimeEqualAny(requestToken(req), wh.tokens) {
+		code := http.StatusUnauthorized
+		message := "Unauthorized"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	_, body, err := ReadBody(ctx, req, wh.body_options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodeBody(ctx, req, body, wh.body_options)
+}
+
+// ReceiveStream returns an `io.ReadCloser` for the body of the message in 'req' after checking its token.
+func (wh TokenReceiver) ReceiveStream(ctx context.Context, req *http.Request) (io.ReadCloser, *webhookd.WebhookError) {
+
+	select {
+	case <-ctx.Done():
+		return nil, nil
+	default:
+		// pass
+	}
+
+	if !constantTimeEqualAny(requestToken(req), wh.tokens) {
+		code := http.StatusUnauthorized
+		message := "Unauthorized"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	return ReadBodyStream(ctx, req, wh.body_options)
+}
+
+// requestToken returns the token in 'req' reading, in order, the `X-Webhookd-Token` header, the `Authorization: Bearer` header
+// and the `token` query parameter.
+func requestToken(req *http.Request) string {
+
+	token := req.Header.Get(TOKEN_HEADER)
+
+	if token != "" {
+		return token
+	}
+
+	authorization := req.Header.Get("Authorization")
+
+	if len(authorization) > 7 && strings.EqualFold(authorization[:7], "Bearer ") {
+		return strings.TrimSpace(authorization[7:])
+	}
+
+	return req.URL.Query().Get("token")
+}
@@ -0,0 +1,125 @@
+package receiver
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+func TestTokenReceiver(t *testing.T) {
+
+	ctx := context.Background()
+
+	body := []byte(`{"alert_id":1,"label":"High memory usage","status":"alert"}`)
+
+	for _, scheme := range []string{"digitalocean", "linode"} {
+
+		r, err := NewReceiver(ctx, scheme+"://?token=s33kret,n3wer")
+
+		if err != nil {
+			t.Fatalf("Failed to create new %s receiver, %v", scheme, err)
+		}
+
+		tests := []struct {
+			query    string
+			headers  map[string]string
+			expected int
+		}{
+			{"?token=s33kret", nil, 0},
+			{"?token=n3wer", nil, 0},
+			{"", map[string]string{TOKEN_HEADER: "s33kret"}, 0},
+			{"", map[string]string{"Authorization": "Bearer s33kret"}, 0},
+			{"?token=wr0ng", nil, http.StatusUnauthorized},
+			{"", map[string]string{TOKEN_HEADER: "wr0ng"}, http.StatusUnauthorized},
+			{"", map[string]string{"Authorization": "Basic s33kret"}, http.StatusUnauthorized},
+			// The header takes precedence over the query parameter
+			{"?token=s33kret", map[string]string{TOKEN_HEADER: "wr0ng"}, http.StatusUnauthorized},
+			{"", nil, http.StatusUnauthorized},
+		}
+
+		for idx, test := range tests {
+
+			req, err := http.NewRequest("POST", "http://localhost:8080/"+scheme+test.query, bytes.NewReader(body))
+
+			if err != nil {
+				t.Fatalf("Failed to create new request, %v", err)
+			}
+
+			for k, v := range test.headers {
+				req.Header.Set(k, v)
+			}
+
+			rsp, err2 := r.Receive(ctx, req)
+
+			if test.expected == 0 {
+
+				if err2 != nil {
+					t.Fatalf("Failed to receive %s message for test %d, %v", scheme, idx, err2)
+				}
+
+				if !bytes.Equal(rsp, body) {
+					t.Fatalf("Unexpected output for %s test %d '%s'", scheme, idx, string(rsp))
+				}
+
+				continue
+			}
+
+			if err2 == nil || err2.Code != test.expected {
+				t.Fatalf("Expected %d for %s test %d but got %v", test.expected, scheme, idx, err2)
+			}
+		}
+	}
+
+	// Streaming requests are authenticated in the same way
+
+	r, err := NewReceiver(ctx, "linode://?token=s33kret")
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	sr := r.(webhookd.WebhookStreamingReceiver)
+
+	for token, expected := range map[string]int{"s33kret": 0, "wr0ng": http.StatusUnauthorized} {
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/linode", bytes.NewReader(body))
+
+		if err != nil {
+			t.Fatalf("Failed to create new request, %v", err)
+		}
+
+		req.Header.Set(TOKEN_HEADER, token)
+
+		rsp, err2 := sr.ReceiveStream(ctx, req)
+
+		if expected != 0 {
+
+			if err2 == nil || err2.Code != expected {
+				t.Fatalf("Expected %d for streamed token '%s' but got %v", expected, token, err2)
+			}
+
+			continue
+		}
+
+		if err2 != nil {
+			t.Fatalf("Failed to receive stream, %v", err2)
+		}
+
+		out, err := io.ReadAll(rsp)
+		rsp.Close()
+
+		if err != nil || !bytes.Equal(out, body) {
+			t.Fatalf("Unexpected streamed output '%s', %v", string(out), err)
+		}
+	}
+
+	_, err = NewReceiver(ctx, "linode://")
+
+	if err == nil {
+		t.Fatalf("Expected receiver without token to fail")
+	}
+}
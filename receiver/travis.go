@@ -0,0 +1,331 @@
+package receiver
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+// DEFAULT_TRAVIS_API is the default root URL for the Travis CI API.
+const DEFAULT_TRAVIS_API string = "https://api.travis-ci.com"
+
+// DEFAULT_TRAVIS_TIMEOUT is the default amount of time to wait for a Travis CI API request to complete.
+const DEFAULT_TRAVIS_TIMEOUT time.Duration = 10 * time.Second
+
+// DEFAULT_TRAVIS_KEY_TTL is the default amount of time that a public key retrieved from the Travis CI API is cached.
+const DEFAULT_TRAVIS_KEY_TTL time.Duration = time.Hour
+
+// TRAVIS_SIGNATURE_HEADER is the HTTP header containing the signature for a Travis CI webhook notification.
+const TRAVIS_SIGNATURE_HEADER string = "Signature"
+
+// TRAVIS_EVENT_HEADER is the HTTP header, set by `TravisReceiver`, containing the type of build described by a Travis CI webhook notification.
+const TRAVIS_EVENT_HEADER string = "X-Travis-Event"
+
+// maxTravisConfigSize is the maximum size of a Travis CI API config response body.
+const maxTravisConfigSize int64 = 1 << 20
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterReceiver(ctx, "travis", NewTravisReceiver)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// TravisReceiver implements the `webhookd.WebhookReceiver` interface for receiving Travis CI webhook notifications.
+type TravisReceiver struct {
+	webhookd.WebhookReceiver
+	// api is the root URL for the Travis CI API, used to retrieve the public key for validating signatures.
+	api string
+	// client is the `http.Client` used to perform API requests.
+	client *http.Client
+	// key is the `travisKey` used to store (or cache) the public key for validating signatures.
+	key *travisKey
+	// body_options is the `BodyOptions` instance used to decode message bodies.
+	body_options *BodyOptions
+}
+
+// travisKey is a static or cached Travis CI public key.
+type travisKey struct {
+	mu      *sync.Mutex
+	value   *rsa.PublicKey
+	static  bool
+	expires time.Time
+}
+
+// NewTravisReceiver returns a new `TravisReceiver` instance configured by 'uri' in the form of:
+//
+//	travis://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `public_key={STRING}` The (URI-escaped) PEM or base64-encoded DER public key used to validate signatures. If empty the key is
+// retrieved from the Travis CI API.
+// * `api={URL}` The root URL for the Travis CI API. Default is "https://api.travis-ci.com".
+// * `timeout={DURATION}` The amount of time to wait for a Travis CI API request to complete. Default is "10s".
+// * Any of the parameters supported by `NewBodyOptionsFromQuery`, excluding `form_field` and `form_json`.
+func NewTravisReceiver(ctx context.Context, uri string) (webhookd.WebhookReceiver, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	key := &travisKey{
+		mu: new(sync.Mutex),
+	}
+
+	str_key := q.Get("public_key")
+
+	if str_key != "" {
+
+		v, err := parseTravisPublicKey(str_key)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse ?public_key= parameter, %w", err)
+		}
+
+		key.value = v
+		key.static = true
+	}
+
+	api := DEFAULT_TRAVIS_API
+
+	if q.Get("api") != "" {
+		api = strings.TrimRight(q.Get("api"), "/")
+	}
+
+	timeout := DEFAULT_TRAVIS_TIMEOUT
+
+	if q.Get("timeout") != "" {
+
+		v, err := time.ParseDuration(q.Get("timeout"))
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse ?timeout= parameter, %w", err)
+		}
+
+		timeout = v
+	}
+
+	body_opts, err := NewBodyOptionsFromQuery(q)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive body options, %w", err)
+	}
+
+	if body_opts.FormField != "" || body_opts.FormToJSON {
+		return nil, fmt.Errorf("?form_field= and ?form_json= parameters are not supported")
+	}
+
+	wh := TravisReceiver{
+		api:          api,
+		client:       &http.Client{Timeout: timeout},
+		key:          key,
+		body_options: body_opts,
+	}
+
+	return wh, nil
+}
+
+// Receive returns the (JSON-encoded) `payload` form field of the Travis CI notification in 'req' after validating its `Signature`
+// header. The `X-Travis-Event` header of 'req' is set to the type of build ("push", "pull_request", "cron" or "api") described by the notification.
+func (wh TravisReceiver) Receive(ctx context.Context, req *http.Request) ([]byte, *webhookd.WebhookError) {
+
+	select {
+	case <-ctx.Done():
+		return nil, nil
+	default:
+		// pass
+	}
+
+	str_sig := req.Header.Get(TRAVIS_SIGNATURE_HEADER)
+
+	if str_sig == "" {
+		code := http.StatusBadRequest
+		message := fmt.Sprintf("Missing %s header", TRAVIS_SIGNATURE_HEADER)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	sig, decode_err := base64.StdEncoding.DecodeString(str_sig)
+
+	if decode_err != nil {
+		code := http.StatusBadRequest
+		message := fmt.Sprintf("Invalid %s header", TRAVIS_SIGNATURE_HEADER)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	_, body, err := ReadBody(ctx, req, wh.body_options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	params, parse_err := url.ParseQuery(string(body))
+
+	if parse_err != nil {
+		code := http.StatusBadRequest
+		message := fmt.Sprintf("Failed to parse form body, %v", parse_err)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	payload := params.Get("payload")
+
+	if payload == "" {
+		code := http.StatusBadRequest
+		message := "Missing form field 'payload'"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	public_key, key_err := wh.publicKey(ctx)
+
+	if key_err != nil {
+		code := http.StatusBadGateway
+		message := fmt.Sprintf("Failed to retrieve public key, %v", key_err)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	digest := sha1.Sum([]byte(payload))
+
+	verify_err := rsa.VerifyPKCS1v15(public_key, crypto.SHA1, digest[:], sig)
+
+	if verify_err != nil {
+		code := http.StatusForbidden
+		message := "Invalid signature"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	var notification struct {
+		Type string `json:"type"`
+	}
+
+	json_err := json.Unmarshal([]byte(payload), &notification)
+
+	if json_err != nil {
+		code := http.StatusBadRequest
+		message := fmt.Sprintf("Failed to decode payload, %v", json_err)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	if notification.Type != "" {
+		req.Header.Set(TRAVIS_EVENT_HEADER, notification.Type)
+	}
+
+	return []byte(payload), nil
+}
+
+// publicKey returns the public key used to validate signatures, retrieving it from the Travis CI API if necessary.
+func (wh TravisReceiver) publicKey(ctx context.Context) (*rsa.PublicKey, error) {
+
+	wh.key.mu.Lock()
+	defer wh.key.mu.Unlock()
+
+	if wh.key.static || (wh.key.value != nil && time.Now().Before(wh.key.expires)) {
+		return wh.key.value, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, wh.api+"/config", nil)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create request, %w", err)
+	}
+
+	rsp, err := wh.client.Do(req)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to execute request, %w", err)
+	}
+
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API returned unexpected status %s", rsp.Status)
+	}
+
+	var config struct {
+		Config struct {
+			Notifications struct {
+				Webhook struct {
+					PublicKey string `json:"public_key"`
+				} `json:"webhook"`
+			} `json:"notifications"`
+		} `json:"config"`
+	}
+
+	err = json.NewDecoder(io.LimitReader(rsp.Body, maxTravisConfigSize)).Decode(&config)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decode response, %w", err)
+	}
+
+	public_key, err := parseTravisPublicKey(config.Config.Notifications.Webhook.PublicKey)
+
+	if err != nil {
+		return nil, err
+	}
+
+	wh.key.value = public_key
+	wh.key.expires = time.Now().Add(DEFAULT_TRAVIS_KEY_TTL)
+
+	return public_key, nil
+}
+
+// parseTravisPublicKey parses 'str_key', a PEM or base64-encoded DER public key, returning an RSA public key.
+func parseTravisPublicKey(str_key string) (*rsa.PublicKey, error) {
+
+	var der []byte
+
+	if block, _ := pem.Decode([]byte(str_key)); block != nil {
+		der = block.Bytes
+	} else {
+
+		v, err := base64.StdEncoding.DecodeString(str_key)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to decode public key, %w", err)
+		}
+
+		der = v
+	}
+
+	k, err := x509.ParsePKIXPublicKey(der)
+
+	if err != nil {
+
+		// Older keys are PKCS #1 encoded ("BEGIN RSA PUBLIC KEY")
+
+		pkcs1, pkcs1_err := x509.ParsePKCS1PublicKey(der)
+
+		if pkcs1_err != nil {
+			return nil, fmt.Errorf("Failed to parse public key, %w", err)
+		}
+
+		return pkcs1, nil
+	}
+
+	public_key, ok := k.(*rsa.PublicKey)
+
+	if !ok {
+		return nil, fmt.Errorf("Public key is not an RSA public key")
+	}
+
+	return public_key, nil
+}
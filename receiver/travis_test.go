@@ -0,0 +1,119 @@
+package receiver
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestTravisReceiver(t *testing.T) {
+
+	ctx := context.Background()
+
+	private_key, err := rsa.GenerateKey(rand.Reader, 2048)
+
+	if err != nil {
+		t.Fatalf("Failed to generate key, %v", err)
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(&private_key.PublicKey)
+
+	if err != nil {
+		t.Fatalf("Failed to marshal public key, %v", err)
+	}
+
+	enc_pem := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+
+	config_requests := 0
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/config", func(rsp http.ResponseWriter, req *http.Request) {
+		config_requests += 1
+		fmt.Fprintf(rsp, `{"config":{"notifications":{"webhook":{"public_key":%q}}}}`, string(enc_pem))
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	sign := func(payload string) string {
+		digest := sha1.Sum([]byte(payload))
+		sig, _ := rsa.SignPKCS1v15(rand.Reader, private_key, crypto.SHA1, digest[:])
+		return base64.StdEncoding.EncodeToString(sig)
+	}
+
+	payload := `{"id":1,"type":"push","state":"passed","status_message":"Passed"}`
+
+	uris := []string{
+		fmt.Sprintf("travis://?api=%s", url.QueryEscape(server.URL)),
+		fmt.Sprintf("travis://?public_key=%s", url.QueryEscape(string(enc_pem))),
+		fmt.Sprintf("travis://?public_key=%s", url.QueryEscape(base64.StdEncoding.EncodeToString(der))),
+	}
+
+	for _, uri := range uris {
+
+		r, err := NewReceiver(ctx, uri)
+
+		if err != nil {
+			t.Fatalf("Failed to create new receiver for %s, %v", uri, err)
+		}
+
+		tests := map[string]int{
+			sign(payload): 0,
+			sign(strings.Replace(payload, "passed", "failed", 1)): http.StatusForbidden,
+		}
+
+		for sig, expected := range tests {
+
+			form := url.Values{"payload": []string{payload}}
+
+			req, err := http.NewRequest("POST", "http://localhost:8080/travis", bytes.NewReader([]byte(form.Encode())))
+
+			if err != nil {
+				t.Fatalf("Failed to create new request, %v", err)
+			}
+
+			req.Header.Set("Content-Type", CONTENT_TYPE_FORM)
+			req.Header.Set(TRAVIS_SIGNATURE_HEADER, sig)
+
+			rsp, err2 := r.Receive(ctx, req)
+
+			if expected == 0 {
+
+				if err2 != nil {
+					t.Fatalf("Failed to receive message, %v", err2)
+				}
+
+				if string(rsp) != payload {
+					t.Fatalf("Unexpected output '%s'", string(rsp))
+				}
+
+				if req.Header.Get(TRAVIS_EVENT_HEADER) != "push" {
+					t.Fatalf("Unexpected %s header '%s'", TRAVIS_EVENT_HEADER, req.Header.Get(TRAVIS_EVENT_HEADER))
+				}
+
+				continue
+			}
+
+			if err2 == nil || err2.Code != expected {
+				t.Fatalf("Expected %d but got %v", expected, err2)
+			}
+		}
+	}
+
+	if config_requests != 1 {
+		t.Fatalf("Expected public key to be retrieved once but was retrieved %d times", config_requests)
+	}
+}
@@ -0,0 +1,247 @@
+package receiver
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+// TWILIO_SIGNATURE_HEADER is the HTTP header containing the signature for a Twilio request.
+const TWILIO_SIGNATURE_HEADER string = "X-Twilio-Signature"
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterReceiver(ctx, "twilio", NewTwilioReceiver)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// TwilioReceiver implements the `webhookd.WebhookReceiver` interface for receiving Twilio webhook requests.
+type TwilioReceiver struct {
+	webhookd.WebhookReceiver
+	// auth_tokens is the list of Twilio auth tokens used to validate request signatures.
+	auth_tokens []string
+	// base_url is the public URL of the webhook endpoint, used to validate request signatures when webhookd is behind a proxy.
+	base_url *url.URL
+	// body_options is the `BodyOptions` instance used to decode message bodies.
+	body_options *BodyOptions
+}
+
+// NewTwilioReceiver returns a new `TwilioReceiver` instance configured by 'uri' in the form of:
+//
+//	twilio://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `auth_token={STRING}` One or more (comma-separated) Twilio auth tokens, for example the primary and secondary auth tokens for an account, used to validate request signatures. Required.
+// * `url={URL}` The (URI-escaped) public URL of the webhook endpoint, without a query string. If empty the URL is derived from
+// the request and its `X-Forwarded-Proto` and `X-Forwarded-Host` headers.
+// * Any of the parameters supported by `NewBodyOptionsFromQuery`, excluding `form_field` and `form_json`.
+//
+// Form-encoded requests are converted to a JSON dictionary. JSON-encoded requests are returned unaltered.
+func NewTwilioReceiver(ctx context.Context, uri string) (webhookd.WebhookReceiver, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	auth_tokens := parseSecrets(q, "auth_token")
+
+	if len(auth_tokens) == 0 {
+		return nil, fmt.Errorf("Missing ?auth_token= parameter")
+	}
+
+	body_opts, err := NewBodyOptionsFromQuery(q)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive body options, %w", err)
+	}
+
+	if body_opts.FormField != "" || body_opts.FormToJSON {
+		return nil, fmt.Errorf("?form_field= and ?form_json= parameters are not supported")
+	}
+
+	wh := TwilioReceiver{
+		auth_tokens:  auth_tokens,
+		body_options: body_opts,
+	}
+
+	str_url := q.Get("url")
+
+	if str_url != "" {
+
+		base_url, err := url.Parse(str_url)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse ?url= parameter, %w", err)
+		}
+
+		wh.base_url = base_url
+	}
+
+	return wh, nil
+}
+
+// Receive returns the body of the Twilio request in 'req' after validating its `X-Twilio-Signature` header. Form-encoded
+// bodies are returned as a JSON dictionary.
+func (wh TwilioReceiver) Receive(ctx context.Context, req *http.Request) ([]byte, *webhookd.WebhookError) {
+
+	select {
+	case <-ctx.Done():
+		return nil, nil
+	default:
+		// pass
+	}
+
+	sig := req.Header.Get(TWILIO_SIGNATURE_HEADER)
+
+	if sig == "" {
+		code := http.StatusBadRequest
+		message := fmt.Sprintf("Missing %s header", TWILIO_SIGNATURE_HEADER)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	_, body, err := ReadBody(ctx, req, wh.body_options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	request_url := publicURL(req, wh.base_url)
+
+	content_type, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+
+	// JSON-encoded requests are signed using the URL alone, which includes a SHA-256 hash of the body
+
+	if content_type != CONTENT_TYPE_FORM {
+
+		body_hash := request_url.Query().Get("bodySHA256")
+
+		if body_hash == "" {
+			code := http.StatusBadRequest
+			message := "Missing bodySHA256 parameter"
+			return nil, &webhookd.WebhookError{Code: code, Message: message}
+		}
+
+		if !wh.validate(request_url, nil, sig) {
+			return nil, twilioForbidden()
+		}
+
+		sum := sha256.Sum256(body)
+
+		if !hmac.Equal([]byte(hex.EncodeToString(sum[:])), []byte(strings.ToLower(body_hash))) {
+			return nil, twilioForbidden()
+		}
+
+		return body, nil
+	}
+
+	params, parse_err := url.ParseQuery(string(body))
+
+	if parse_err != nil {
+		code := http.StatusBadRequest
+		message := fmt.Sprintf("Failed to parse form body, %v", parse_err)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	if !wh.validate(request_url, params, sig) {
+		return nil, twilioForbidden()
+	}
+
+	return decodeFormJSON(params, nil)
+}
+
+// validate returns a boolean value indicating whether 'sig' is a valid signature for 'request_url' and 'params'. Because Twilio
+// does not always include the port number of a URL when computing signatures both variants of 'request_url' are tried.
+func (wh TwilioReceiver) validate(request_url *url.URL, params url.Values, sig string) bool {
+
+	candidates := []string{request_url.String()}
+
+	alt := *request_url
+
+	if alt.Port() == "" {
+
+		switch alt.Scheme {
+		case "https":
+			alt.Host = alt.Host + ":443"
+		case "http":
+			alt.Host = alt.Host + ":80"
+		}
+
+	} else {
+		alt.Host = alt.Hostname()
+	}
+
+	candidates = append(candidates, alt.String())
+
+	verify := func(auth_token string) bool {
+
+		for _, c := range candidates {
+
+			expected := computeTwilioSignature(auth_token, c, params)
+
+			if hmac.Equal([]byte(expected), []byte(sig)) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return anySecret(wh.auth_tokens, verify)
+}
+
+// computeTwilioSignature returns the base64-encoded HMAC-SHA1 signature for 'request_url' and 'params' using 'auth_token'.
+func computeTwilioSignature(auth_token string, request_url string, params url.Values) string {
+
+	var b strings.Builder
+	b.WriteString(request_url)
+
+	keys := make([]string, 0, len(params))
+
+	for k := range params {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+
+		values := append([]string(nil), params[k]...)
+		sort.Strings(values)
+
+		for _, v := range values {
+			b.WriteString(k)
+			b.WriteString(v)
+		}
+	}
+
+	mac := hmac.New(sha1.New, []byte(auth_token))
+	mac.Write([]byte(b.String()))
+
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// twilioForbidden returns a `webhookd.WebhookError` for requests whose signature fails to validate.
+func twilioForbidden() *webhookd.WebhookError {
+	code := http.StatusForbidden
+	message := "Invalid signature"
+	return &webhookd.WebhookError{Code: code, Message: message}
+}
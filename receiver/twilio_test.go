@@ -0,0 +1,142 @@
+package receiver
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestComputeTwilioSignature(t *testing.T) {
+
+	params := url.Values{
+		"To":      []string{"+18005551212"},
+		"CallSid": []string{"CA1234567890ABCDE"},
+		"Digits":  []string{"1234"},
+	}
+
+	// The URL followed by each key and value, sorted by key
+
+	signed := "https://example.com/myapp.php?foo=1&bar=2CallSidCA1234567890ABCDEDigits1234To+18005551212"
+
+	mac := hmac.New(sha1.New, []byte("12345"))
+	mac.Write([]byte(signed))
+
+	expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	sig := computeTwilioSignature("12345", "https://example.com/myapp.php?foo=1&bar=2", params)
+
+	if sig != expected {
+		t.Fatalf("Unexpected signature '%s'", sig)
+	}
+}
+
+func TestTwilioReceiver(t *testing.T) {
+
+	ctx := context.Background()
+
+	r, err := NewReceiver(ctx, "twilio://?auth_token=s33kret&url=https%3A%2F%2Fwebhookd.example.com%2Ftwilio")
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	params := url.Values{
+		"MessageSid": []string{"SM123"},
+		"Body":       []string{"hello world"},
+	}
+
+	sig := computeTwilioSignature("s33kret", "https://webhookd.example.com/twilio", params)
+
+	req, err := http.NewRequest("POST", "http://localhost:8080/twilio", bytes.NewReader([]byte(params.Encode())))
+
+	if err != nil {
+		t.Fatalf("Failed to create new request, %v", err)
+	}
+
+	req.Header.Set("Content-Type", CONTENT_TYPE_FORM)
+	req.Header.Set(TWILIO_SIGNATURE_HEADER, sig)
+
+	body, err2 := r.Receive(ctx, req)
+
+	if err2 != nil {
+		t.Fatalf("Failed to receive message, %v", err2)
+	}
+
+	var doc map[string]string
+
+	err = json.Unmarshal(body, &doc)
+
+	if err != nil {
+		t.Fatalf("Failed to decode message, %v", err)
+	}
+
+	if doc["MessageSid"] != "SM123" || doc["Body"] != "hello world" {
+		t.Fatalf("Unexpected output '%s'", string(body))
+	}
+
+	params.Set("Body", "goodbye world")
+
+	req, err = http.NewRequest("POST", "http://localhost:8080/twilio", bytes.NewReader([]byte(params.Encode())))
+
+	if err != nil {
+		t.Fatalf("Failed to create new request, %v", err)
+	}
+
+	req.Header.Set("Content-Type", CONTENT_TYPE_FORM)
+	req.Header.Set(TWILIO_SIGNATURE_HEADER, sig)
+
+	_, err2 = r.Receive(ctx, req)
+
+	if err2 == nil || err2.Code != http.StatusForbidden {
+		t.Fatalf("Expected tampered request to fail, %v", err2)
+	}
+}
+
+func TestTwilioReceiverJSON(t *testing.T) {
+
+	ctx := context.Background()
+
+	r, err := NewReceiver(ctx, "twilio://?auth_token=s33kret")
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	body := []byte(`{"hello":"world"}`)
+	sum := sha256.Sum256(body)
+
+	request_url := "https://webhookd.example.com/twilio?bodySHA256=" + hex.EncodeToString(sum[:])
+
+	// Twilio signs the URL without a port number but the proxy in front of webhookd adds one
+
+	sig := computeTwilioSignature("s33kret", request_url, nil)
+
+	req, err := http.NewRequest("POST", "http://localhost:8080/twilio?bodySHA256="+hex.EncodeToString(sum[:]), bytes.NewReader(body))
+
+	if err != nil {
+		t.Fatalf("Failed to create new request, %v", err)
+	}
+
+	req.Host = "webhookd.example.com:443"
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Forwarded-Proto", "https")
+	req.Header.Set(TWILIO_SIGNATURE_HEADER, sig)
+
+	rsp, err2 := r.Receive(ctx, req)
+
+	if err2 != nil {
+		t.Fatalf("Failed to receive message, %v", err2)
+	}
+
+	if !bytes.Equal(rsp, body) {
+		t.Fatalf("Unexpected output '%s'", string(rsp))
+	}
+}
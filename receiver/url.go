@@ -0,0 +1,44 @@
+package receiver
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// publicURL returns the full URL that the sender of 'req' used to deliver it, which some providers include when computing
+// signatures. If 'base_url' is not nil its scheme, host and path are used; otherwise they are derived from 'req' and its
+// `X-Forwarded-Proto` and `X-Forwarded-Host` headers. The query string is always derived from 'req'.
+func publicURL(req *http.Request, base_url *url.URL) *url.URL {
+
+	u := &url.URL{
+		Path:     req.URL.Path,
+		RawQuery: req.URL.RawQuery,
+	}
+
+	if base_url != nil {
+		u.Scheme = base_url.Scheme
+		u.Host = base_url.Host
+		u.User = base_url.User
+		u.Path = base_url.Path
+		return u
+	}
+
+	u.Scheme = "http"
+
+	if req.TLS != nil {
+		u.Scheme = "https"
+	}
+
+	if v := req.Header.Get("X-Forwarded-Proto"); v != "" {
+		u.Scheme = strings.TrimSpace(strings.Split(v, ",")[0])
+	}
+
+	u.Host = req.Host
+
+	if v := req.Header.Get("X-Forwarded-Host"); v != "" {
+		u.Host = strings.TrimSpace(strings.Split(v, ",")[0])
+	}
+
+	return u
+}
@@ -0,0 +1,37 @@
+package receiver
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestPublicURL(t *testing.T) {
+
+	req, err := http.NewRequest("POST", "http://localhost:8080/hooks/test?a=b", nil)
+
+	if err != nil {
+		t.Fatalf("Failed to create new request, %v", err)
+	}
+
+	req.Header.Set("X-Forwarded-Proto", "https")
+	req.Header.Set("X-Forwarded-Host", "webhookd.example.com")
+
+	u := publicURL(req, nil)
+
+	if u.String() != "https://webhookd.example.com/hooks/test?a=b" {
+		t.Fatalf("Unexpected URL '%s'", u.String())
+	}
+
+	base_url, err := url.Parse("https://public.example.com/test")
+
+	if err != nil {
+		t.Fatalf("Failed to parse URL, %v", err)
+	}
+
+	u = publicURL(req, base_url)
+
+	if u.String() != "https://public.example.com/test?a=b" {
+		t.Fatalf("Unexpected URL '%s'", u.String())
+	}
+}
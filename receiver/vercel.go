@@ -0,0 +1,127 @@
+package receiver
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+// VERCEL_SIGNATURE_HEADER is the HTTP header containing the signature for a Vercel webhook message.
+const VERCEL_SIGNATURE_HEADER string = "X-Vercel-Signature"
+
+// VERCEL_EVENT_HEADER is the HTTP header, set by `VercelReceiver`, containing the event type of a Vercel webhook message.
+const VERCEL_EVENT_HEADER string = "X-Vercel-Event"
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterReceiver(ctx, "vercel", NewVercelReceiver)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// VercelReceiver implements the `webhookd.WebhookReceiver` interface for receiving Vercel webhook messages.
+type VercelReceiver struct {
+	webhookd.WebhookReceiver
+	// secrets is the list of webhook secrets used to validate message signatures.
+	secrets []string
+	// body_options is the `BodyOptions` instance used to decode message bodies.
+	body_options *BodyOptions
+}
+
+// NewVercelReceiver returns a new `VercelReceiver` instance configured by 'uri' in the form of:
+//
+//	vercel://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `secret={STRING}` One or more (comma-separated) webhook secrets used to validate message signatures. Required.
+// * Any of the parameters supported by `NewBodyOptionsFromQuery`.
+func NewVercelReceiver(ctx context.Context, uri string) (webhookd.WebhookReceiver, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	secrets := parseSecrets(q, "secret")
+
+	if len(secrets) == 0 {
+		return nil, fmt.Errorf("Missing ?secret= parameter")
+	}
+
+	body_opts, err := NewBodyOptionsFromQuery(q)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive body options, %w", err)
+	}
+
+	wh := VercelReceiver{
+		secrets:      secrets,
+		body_options: body_opts,
+	}
+
+	return wh, nil
+}
+
+// Receive returns the body of the Vercel message in 'req' after validating its `x-vercel-signature` header. The `X-Vercel-Event`
+// header of 'req' is set to the message's `type` property (for example "deployment.succeeded").
+func (wh VercelReceiver) Receive(ctx context.Context, req *http.Request) ([]byte, *webhookd.WebhookError) {
+
+	select {
+	case <-ctx.Done():
+		return nil, nil
+	default:
+		// pass
+	}
+
+	sig := req.Header.Get(VERCEL_SIGNATURE_HEADER)
+
+	if sig == "" {
+		code := http.StatusBadRequest
+		message := fmt.Sprintf("Missing %s header", VERCEL_SIGNATURE_HEADER)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	raw, body, err := ReadBody(ctx, req, wh.body_options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	verify := func(secret string) bool {
+
+		mac := hmac.New(sha1.New, []byte(secret))
+		mac.Write(VerifiableBody(raw, body, wh.body_options))
+
+		expected := hex.EncodeToString(mac.Sum(nil))
+		return hmac.Equal([]byte(expected), []byte(sig))
+	}
+
+	if !anySecret(wh.secrets, verify) {
+		code := http.StatusForbidden
+		message := "Invalid signature"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	var ev struct {
+		Type string `json:"type"`
+	}
+
+	if json.Unmarshal(body, &ev) == nil && ev.Type != "" {
+		req.Header.Set(VERCEL_EVENT_HEADER, ev.Type)
+	}
+
+	return DecodeBody(ctx, req, body, wh.body_options)
+}
@@ -0,0 +1,68 @@
+package receiver
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"testing"
+)
+
+func TestVercelReceiver(t *testing.T) {
+
+	ctx := context.Background()
+
+	r, err := NewReceiver(ctx, "vercel://?secret=s33kret")
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	body := []byte(`{"id":"1","type":"deployment.succeeded","payload":{"deployment":{"url":"example.vercel.app"}}}`)
+
+	mac := hmac.New(sha1.New, []byte("s33kret"))
+	mac.Write(body)
+
+	sig := hex.EncodeToString(mac.Sum(nil))
+
+	tests := map[string]int{
+		string(body):                           0,
+		`{"id":"1","type":"deployment.error"}`: http.StatusForbidden,
+	}
+
+	for msg, expected := range tests {
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/vercel", bytes.NewReader([]byte(msg)))
+
+		if err != nil {
+			t.Fatalf("Failed to create new request, %v", err)
+		}
+
+		req.Header.Set(VERCEL_SIGNATURE_HEADER, sig)
+
+		rsp, err2 := r.Receive(ctx, req)
+
+		if expected == 0 {
+
+			if err2 != nil {
+				t.Fatalf("Failed to receive message, %v", err2)
+			}
+
+			if !bytes.Equal(rsp, body) {
+				t.Fatalf("Unexpected output '%s'", string(rsp))
+			}
+
+			if req.Header.Get(VERCEL_EVENT_HEADER) != "deployment.succeeded" {
+				t.Fatalf("Unexpected %s header '%s'", VERCEL_EVENT_HEADER, req.Header.Get(VERCEL_EVENT_HEADER))
+			}
+
+			continue
+		}
+
+		if err2 == nil || err2.Code != expected {
+			t.Fatalf("Expected %d for %s but got %v", expected, msg, err2)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package receiver
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+// ZENDESK_SIGNATURE_HEADER is the HTTP header containing the signature for a Zendesk webhook message.
+const ZENDESK_SIGNATURE_HEADER string = "X-Zendesk-Webhook-Signature"
+
+// ZENDESK_TIMESTAMP_HEADER is the HTTP header containing the timestamp for a Zendesk webhook message.
+const ZENDESK_TIMESTAMP_HEADER string = "X-Zendesk-Webhook-Signature-Timestamp"
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterReceiver(ctx, "zendesk", NewZendeskReceiver)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// ZendeskReceiver implements the `webhookd.WebhookReceiver` interface for receiving Zendesk webhook messages.
+type ZendeskReceiver struct {
+	webhookd.WebhookReceiver
+	// secrets is the list of Zendesk webhook signing secrets used to validate message signatures.
+	secrets []string
+	// freshness is the `FreshnessOptions` instance used to reject messages with stale timestamps.
+	freshness *FreshnessOptions
+	// body_options is the `BodyOptions` instance used to decode message bodies.
+	body_options *BodyOptions
+}
+
+// NewZendeskReceiver returns a new `ZendeskReceiver` instance configured by 'uri' in the form of:
+//
+//	zendesk://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `secret={STRING}` One or more (comma-separated) Zendesk webhook signing secrets used to validate message signatures. Required.
+// * `max_age={DURATION}` The maximum age of a message, derived from its timestamp. A value of "0s" disables this check. Default is "5m".
+// * `max_skew={DURATION}` The maximum amount of time that the timestamp of a message may be ahead of the current time. A value of "0s" disables this check. Default is the value of `max_age`.
+// * Any of the parameters supported by `NewBodyOptionsFromQuery`.
+func NewZendeskReceiver(ctx context.Context, uri string) (webhookd.WebhookReceiver, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	secrets := parseSecrets(q, "secret")
+
+	if len(secrets) == 0 {
+		return nil, fmt.Errorf("Missing ?secret= parameter")
+	}
+
+	freshness, err := NewFreshnessOptionsFromQuery(q)
+
+	if err != nil {
+		return nil, err
+	}
+
+	body_opts, err := NewBodyOptionsFromQuery(q)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive body options, %w", err)
+	}
+
+	wh := ZendeskReceiver{
+		secrets:      secrets,
+		freshness:    freshness,
+		body_options: body_opts,
+	}
+
+	return wh, nil
+}
+
+// Receive returns the body of the Zendesk message in 'req' after validating its `X-Zendesk-Webhook-Signature` header.
+func (wh ZendeskReceiver) Receive(ctx context.Context, req *http.Request) ([]byte, *webhookd.WebhookError) {
+
+	select {
+	case <-ctx.Done():
+		return nil, nil
+	default:
+		// pass
+	}
+
+	sig := req.Header.Get(ZENDESK_SIGNATURE_HEADER)
+	ts := req.Header.Get(ZENDESK_TIMESTAMP_HEADER)
+
+	if sig == "" || ts == "" {
+		code := http.StatusBadRequest
+		message := fmt.Sprintf("Missing %s or %s header", ZENDESK_SIGNATURE_HEADER, ZENDESK_TIMESTAMP_HEADER)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	t, parse_err := time.Parse(time.RFC3339, ts)
+
+	if parse_err != nil {
+		code := http.StatusBadRequest
+		message := "Missing or invalid timestamp"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	err := verifyTime(t, wh.freshness)
+
+	if err != nil {
+		return nil, err
+	}
+
+	raw, body, err := ReadBody(ctx, req, wh.body_options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	verify := func(secret string) bool {
+
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(ts))
+		mac.Write(VerifiableBody(raw, body, wh.body_options))
+
+		expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+		return hmac.Equal([]byte(expected), []byte(sig))
+	}
+
+	if !anySecret(wh.secrets, verify) {
+		code := http.StatusForbidden
+		message := "Invalid signature"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	return DecodeBody(ctx, req, body, wh.body_options)
+}
@@ -0,0 +1,192 @@
+package receiver
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+// ZOOM_SIGNATURE_HEADER is the HTTP header containing the signature for a Zoom webhook message.
+const ZOOM_SIGNATURE_HEADER string = "X-Zm-Signature"
+
+// ZOOM_TIMESTAMP_HEADER is the HTTP header containing the timestamp for a Zoom webhook message.
+const ZOOM_TIMESTAMP_HEADER string = "X-Zm-Request-Timestamp"
+
+// ZOOM_EVENT_URL_VALIDATION is the event type of the challenge Zoom sends to validate a webhook endpoint.
+const ZOOM_EVENT_URL_VALIDATION string = "endpoint.url_validation"
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterReceiver(ctx, "zoom", NewZoomReceiver)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// zoomEvent is the subset of a Zoom webhook message used by `ZoomReceiver`.
+type zoomEvent struct {
+	Event   string `json:"event"`
+	Payload struct {
+		PlainToken string `json:"plainToken"`
+	} `json:"payload"`
+}
+
+// ZoomReceiver implements the `webhookd.WebhookReceiver` interface for receiving Zoom webhook messages.
+type ZoomReceiver struct {
+	webhookd.WebhookReceiver
+	// secret_tokens is the list of Zoom app secret tokens used to validate message signatures. The first is used to answer URL validation challenges.
+	secret_tokens []string
+	// freshness is the `FreshnessOptions` instance used to reject messages with stale timestamps.
+	freshness *FreshnessOptions
+	// body_options is the `BodyOptions` instance used to decode message bodies.
+	body_options *BodyOptions
+}
+
+// NewZoomReceiver returns a new `ZoomReceiver` instance configured by 'uri' in the form of:
+//
+//	zoom://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `secret_token={STRING}` One or more (comma-separated) Zoom app secret tokens used to validate message signatures. The first is used to answer URL validation challenges. Required.
+// * `max_age={DURATION}` The maximum age of a message, derived from its timestamp. A value of "0s" disables this check. Default is "5m".
+// * `max_skew={DURATION}` The maximum amount of time that the timestamp of a message may be ahead of the current time. A value of "0s" disables this check. Default is the value of `max_age`.
+// * Any of the parameters supported by `NewBodyOptionsFromQuery`.
+func NewZoomReceiver(ctx context.Context, uri string) (webhookd.WebhookReceiver, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	secret_tokens := parseSecrets(q, "secret_token")
+
+	if len(secret_tokens) == 0 {
+		return nil, fmt.Errorf("Missing ?secret_token= parameter")
+	}
+
+	freshness, err := NewFreshnessOptionsFromQuery(q)
+
+	if err != nil {
+		return nil, err
+	}
+
+	body_opts, err := NewBodyOptionsFromQuery(q)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive body options, %w", err)
+	}
+
+	wh := ZoomReceiver{
+		secret_tokens: secret_tokens,
+		freshness:     freshness,
+		body_options:  body_opts,
+	}
+
+	return wh, nil
+}
+
+// Receive returns the body of the Zoom message in 'req' after validating its `X-Zm-Signature` header. URL validation challenges
+// are answered using `webhookd.SetChallengeResponse` and return a `webhookd.UnhandledEvent` error.
+func (wh ZoomReceiver) Receive(ctx context.Context, req *http.Request) ([]byte, *webhookd.WebhookError) {
+
+	select {
+	case <-ctx.Done():
+		return nil, nil
+	default:
+		// pass
+	}
+
+	sig := req.Header.Get(ZOOM_SIGNATURE_HEADER)
+	ts := req.Header.Get(ZOOM_TIMESTAMP_HEADER)
+
+	if sig == "" || ts == "" {
+		code := http.StatusBadRequest
+		message := fmt.Sprintf("Missing %s or %s header", ZOOM_SIGNATURE_HEADER, ZOOM_TIMESTAMP_HEADER)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	err := verifyTimestamp(ts, wh.freshness)
+
+	if err != nil {
+		return nil, err
+	}
+
+	raw, body, err := ReadBody(ctx, req, wh.body_options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	verify := func(secret string) bool {
+
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte("v0:" + ts + ":"))
+		mac.Write(VerifiableBody(raw, body, wh.body_options))
+
+		expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+		return hmac.Equal([]byte(expected), []byte(sig))
+	}
+
+	if !anySecret(wh.secret_tokens, verify) {
+		code := http.StatusForbidden
+		message := "Invalid signature"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	var ev zoomEvent
+	json.Unmarshal(body, &ev)
+
+	if ev.Event == ZOOM_EVENT_URL_VALIDATION {
+		return nil, wh.answerChallenge(ctx, ev.Payload.PlainToken)
+	}
+
+	return DecodeBody(ctx, req, body, wh.body_options)
+}
+
+// answerChallenge answers the URL validation challenge for 'plain_token'.
+func (wh ZoomReceiver) answerChallenge(ctx context.Context, plain_token string) *webhookd.WebhookError {
+
+	if plain_token == "" {
+		code := http.StatusBadRequest
+		message := "Missing plainToken"
+		return &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	mac := hmac.New(sha256.New, []byte(wh.secret_tokens[0]))
+	mac.Write([]byte(plain_token))
+
+	challenge := map[string]string{
+		"plainToken":     plain_token,
+		"encryptedToken": hex.EncodeToString(mac.Sum(nil)),
+	}
+
+	enc, err := json.Marshal(challenge)
+
+	if err != nil {
+		code := http.StatusInternalServerError
+		message := fmt.Sprintf("Failed to encode challenge response, %v", err)
+		return &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	if !webhookd.SetChallengeResponse(ctx, "application/json", enc) {
+		code := http.StatusInternalServerError
+		message := "Context does not support challenge responses"
+		return &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	code := webhookd.UnhandledEvent
+	message := "Answered URL validation challenge"
+	return &webhookd.WebhookError{Code: code, Message: message}
+}
@@ -0,0 +1,105 @@
+package receiver
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+func zoomTestRequest(t *testing.T, secret string, body []byte) *http.Request {
+
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + ts + ":"))
+	mac.Write(body)
+
+	req, err := http.NewRequest("POST", "http://localhost:8080/zoom", bytes.NewReader(body))
+
+	if err != nil {
+		t.Fatalf("Failed to create new request, %v", err)
+	}
+
+	req.Header.Set(ZOOM_TIMESTAMP_HEADER, ts)
+	req.Header.Set(ZOOM_SIGNATURE_HEADER, "v0="+hex.EncodeToString(mac.Sum(nil)))
+
+	return req
+}
+
+func TestZoomReceiver(t *testing.T) {
+
+	ctx := context.Background()
+
+	r, err := NewReceiver(ctx, "zoom://?secret_token=s33kret")
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	body := []byte(`{"event":"meeting.started","payload":{"object":{"id":"1234"}}}`)
+
+	rsp, err2 := r.Receive(ctx, zoomTestRequest(t, "s33kret", body))
+
+	if err2 != nil {
+		t.Fatalf("Failed to receive message, %v", err2)
+	}
+
+	if !bytes.Equal(rsp, body) {
+		t.Fatalf("Unexpected output '%s'", string(rsp))
+	}
+
+	_, err2 = r.Receive(ctx, zoomTestRequest(t, "wr0ng", body))
+
+	if err2 == nil || err2.Code != http.StatusForbidden {
+		t.Fatalf("Expected invalid signature to fail, %v", err2)
+	}
+}
+
+func TestZoomReceiverChallenge(t *testing.T) {
+
+	ctx := webhookd.WithChallengeResponse(context.Background())
+
+	r, err := NewReceiver(ctx, "zoom://?secret_token=s33kret")
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	body := []byte(`{"event":"endpoint.url_validation","payload":{"plainToken":"qgg8vlvZRS6UYooatFL8Aw"},"event_ts":1654503849680}`)
+
+	_, err2 := r.Receive(ctx, zoomTestRequest(t, "s33kret", body))
+
+	if err2 == nil || err2.Code != webhookd.UnhandledEvent {
+		t.Fatalf("Expected challenge to return an unhandled event, %v", err2)
+	}
+
+	content_type, enc, ok := webhookd.ChallengeResponse(ctx)
+
+	if !ok || content_type != "application/json" {
+		t.Fatalf("Expected challenge response")
+	}
+
+	var challenge map[string]string
+
+	err = json.Unmarshal(enc, &challenge)
+
+	if err != nil {
+		t.Fatalf("Failed to decode challenge response, %v", err)
+	}
+
+	mac := hmac.New(sha256.New, []byte("s33kret"))
+	mac.Write([]byte("qgg8vlvZRS6UYooatFL8Aw"))
+
+	if challenge["plainToken"] != "qgg8vlvZRS6UYooatFL8Aw" || challenge["encryptedToken"] != hex.EncodeToString(mac.Sum(nil)) {
+		t.Fatalf("Unexpected challenge response '%s'", string(enc))
+	}
+}
@@ -0,0 +1,161 @@
+// Package recorder provides methods for capturing webhook deliveries, while proxying them to a destination, and replaying them
+// at a later time.
+package recorder
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Delivery is a single captured webhook delivery. Recordings are stored as newline-delimited JSON-encoded `Delivery` instances.
+type Delivery struct {
+	// Time is the time the delivery was received.
+	Time time.Time `json:"time"`
+	// Method is the HTTP method of the delivery.
+	Method string `json:"method"`
+	// Path is the path, and query, of the delivery.
+	Path string `json:"path"`
+	// Header is the HTTP headers sent with the delivery.
+	Header http.Header `json:"header"`
+	// Body is the body of the delivery. It is base64-encoded in recordings.
+	Body []byte `json:"body"`
+}
+
+// Recorder is an `http.Handler` that writes each request it receives to a recording before relaying it, unchanged, to a
+// destination.
+type Recorder struct {
+	http.Handler
+	proxy  *httputil.ReverseProxy
+	writer io.Writer
+	mu     *sync.Mutex
+}
+
+// NewRecorder returns a new `Recorder` instance that writes deliveries to 'wr' and proxies them to 'destination'.
+func NewRecorder(destination string, wr io.Writer) (*Recorder, error) {
+
+	u, err := url.Parse(destination)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse destination, %w", err)
+	}
+
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("Invalid destination '%s'", destination)
+	}
+
+	proxy := httputil.NewSingleHostReverseProxy(u)
+
+	// Preserve the Host header of the destination rather than that of the client
+
+	director := proxy.Director
+
+	proxy.Director = func(req *http.Request) {
+		director(req)
+		req.Host = u.Host
+	}
+
+	r := &Recorder{
+		proxy:  proxy,
+		writer: wr,
+		mu:     new(sync.Mutex),
+	}
+
+	return r, nil
+}
+
+// ServeHTTP records 'req' and then relays it to the destination for 'r'. Requests that can not be recorded are not relayed.
+func (r *Recorder) ServeHTTP(rsp http.ResponseWriter, req *http.Request) {
+
+	body, err := io.ReadAll(req.Body)
+
+	if err != nil {
+		http.Error(rsp, "Failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	d := &Delivery{
+		Time:   time.Now(),
+		Method: req.Method,
+		Path:   req.URL.RequestURI(),
+		Header: req.Header.Clone(),
+		Body:   body,
+	}
+
+	err = r.Write(d)
+
+	if err != nil {
+		http.Error(rsp, "Failed to record delivery", http.StatusInternalServerError)
+		return
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+
+	r.proxy.ServeHTTP(rsp, req)
+}
+
+// Write appends 'd' to the recording for 'r'. It is safe to call concurrently.
+func (r *Recorder) Write(d *Delivery) error {
+
+	enc, err := json.Marshal(d)
+
+	if err != nil {
+		return fmt.Errorf("Failed to encode delivery, %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, err = r.writer.Write(append(enc, '\n'))
+
+	if err != nil {
+		return fmt.Errorf("Failed to write delivery, %w", err)
+	}
+
+	return nil
+}
+
+// ReadDeliveries reads the newline-delimited JSON-encoded `Delivery` instances in 'r' and invokes 'cb' for each one, in order.
+// Reading stops as soon as 'cb' returns an error.
+func ReadDeliveries(r io.Reader, cb func(*Delivery) error) error {
+
+	reader := bufio.NewReader(r)
+
+	for {
+
+		line, err := reader.ReadBytes('\n')
+
+		if len(bytes.TrimSpace(line)) > 0 {
+
+			var d *Delivery
+
+			dec_err := json.Unmarshal(line, &d)
+
+			if dec_err != nil {
+				return fmt.Errorf("Failed to decode delivery, %w", dec_err)
+			}
+
+			cb_err := cb(d)
+
+			if cb_err != nil {
+				return cb_err
+			}
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return fmt.Errorf("Failed to read recording, %w", err)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package recorder
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecorder(t *testing.T) {
+
+	received := make([]string, 0)
+
+	destination := httptest.NewServer(http.HandlerFunc(func(rsp http.ResponseWriter, req *http.Request) {
+
+		body, _ := io.ReadAll(req.Body)
+		received = append(received, req.Method+" "+req.URL.RequestURI()+" "+string(body))
+
+		rsp.WriteHeader(http.StatusAccepted)
+	}))
+
+	defer destination.Close()
+
+	var buf bytes.Buffer
+
+	r, err := NewRecorder(destination.URL, &buf)
+
+	if err != nil {
+		t.Fatalf("Failed to create recorder, %v", err)
+	}
+
+	for _, path := range []string{"/github?hello=world", "/slack"} {
+
+		req := httptest.NewRequest("POST", path, strings.NewReader("hello world"))
+		req.Header.Set("X-GitHub-Event", "push")
+
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusAccepted {
+			t.Fatalf("Unexpected status for %s: %d", path, rec.Code)
+		}
+	}
+
+	if strings.Join(received, ",") != "POST /github?hello=world hello world,POST /slack hello world" {
+		t.Fatalf("Unexpected requests relayed to destination: %v", received)
+	}
+
+	deliveries := make([]*Delivery, 0)
+
+	err = ReadDeliveries(&buf, func(d *Delivery) error {
+		deliveries = append(deliveries, d)
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("Failed to read deliveries, %v", err)
+	}
+
+	if len(deliveries) != 2 {
+		t.Fatalf("Expected 2 deliveries, got %d", len(deliveries))
+	}
+
+	d := deliveries[0]
+
+	if d.Method != "POST" || d.Path != "/github?hello=world" || string(d.Body) != "hello world" || d.Header.Get("X-GitHub-Event") != "push" || d.Time.IsZero() {
+		t.Fatalf("Unexpected delivery: %v", d)
+	}
+}
+
+func TestNewRecorderInvalid(t *testing.T) {
+
+	for _, uri := range []string{"", "localhost:8080", "/path"} {
+
+		_, err := NewRecorder(uri, io.Discard)
+
+		if err == nil {
+			t.Fatalf("Expected destination '%s' to fail", uri)
+		}
+	}
+}
+
+func TestReadDeliveriesInvalid(t *testing.T) {
+
+	err := ReadDeliveries(strings.NewReader("{\"method\":\"POST\"}\nnot json\n"), func(d *Delivery) error {
+		return nil
+	})
+
+	if err == nil {
+		t.Fatalf("Expected invalid recording to fail")
+	}
+}
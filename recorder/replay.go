@@ -0,0 +1,165 @@
+package recorder
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	aa_log "github.com/aaronland/go-log/v2"
+)
+
+// skipHeaders are the (canonicalized) names of headers that are not copied from a recorded delivery when it is replayed.
+var skipHeaders = map[string]bool{
+	"Connection":          true,
+	"Content-Length":      true,
+	"Host":                true,
+	"Keep-Alive":          true,
+	"Proxy-Connection":    true,
+	"Te":                  true,
+	"Trailer":             true,
+	"Transfer-Encoding":   true,
+	"Upgrade":             true,
+	"X-Forwarded-For":     true,
+	"X-Forwarded-Host":    true,
+	"X-Forwarded-Proto":   true,
+	"Proxy-Authorization": true,
+}
+
+// ReplayOptions is a struct containing the options for `Replay`.
+type ReplayOptions struct {
+	// Target is the URL that deliveries are sent to. The path, and query, of each delivery are appended to it.
+	Target string
+	// Speed is the factor by which the original timing of deliveries is accelerated. For example 1 replays deliveries at their
+	// original timing and 10 replays them ten times faster. If 0 deliveries are sent as quickly as possible.
+	Speed float64
+	// Client is the optional `http.Client` used to send deliveries. If nil `http.DefaultClient` is used.
+	Client *http.Client
+	// Logger is the optional `log.Logger` used to report the outcome of each delivery.
+	Logger *log.Logger
+}
+
+// Replay sends each of the deliveries in the recording 'r', in order, to the target defined in 'opts'. Deliveries that fail, or
+// that do not receive a 2XX response, are logged and replaying continues; an error describing the number of failures is returned
+// once all the deliveries have been sent.
+func Replay(ctx context.Context, r io.Reader, opts *ReplayOptions) error {
+
+	target, err := url.Parse(opts.Target)
+
+	if err != nil {
+		return fmt.Errorf("Failed to parse target, %w", err)
+	}
+
+	if target.Scheme == "" || target.Host == "" {
+		return fmt.Errorf("Invalid target '%s'", opts.Target)
+	}
+
+	if opts.Speed < 0 {
+		return fmt.Errorf("Invalid speed, %f", opts.Speed)
+	}
+
+	client := opts.Client
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	logger := opts.Logger
+
+	if logger == nil {
+		logger = log.New(io.Discard, "", 0)
+	}
+
+	var first time.Time
+	var started time.Time
+
+	count := 0
+	failures := 0
+
+	replay := func(d *Delivery) error {
+
+		if count == 0 {
+			first = d.Time
+			started = time.Now()
+		}
+
+		count += 1
+
+		if opts.Speed > 0 {
+
+			offset := time.Duration(float64(d.Time.Sub(first)) / opts.Speed)
+			wait := time.Until(started.Add(offset))
+
+			if wait > 0 {
+
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-time.After(wait):
+					// pass
+				}
+			}
+		}
+
+		uri := strings.TrimRight(target.String(), "/") + d.Path
+
+		req, err := http.NewRequestWithContext(ctx, d.Method, uri, bytes.NewReader(d.Body))
+
+		if err != nil {
+			return fmt.Errorf("Failed to create request for delivery %d, %w", count, err)
+		}
+
+		for k, v := range d.Header {
+
+			k = http.CanonicalHeaderKey(k)
+
+			if skipHeaders[k] {
+				continue
+			}
+
+			req.Header[k] = v
+		}
+
+		rsp, err := client.Do(req)
+
+		if err != nil {
+
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			aa_log.Error(logger, "Failed to replay delivery %d (%s %s), %v", count, d.Method, d.Path, err)
+			failures += 1
+			return nil
+		}
+
+		io.Copy(io.Discard, rsp.Body)
+		rsp.Body.Close()
+
+		if rsp.StatusCode < 200 || rsp.StatusCode > 299 {
+			aa_log.Warning(logger, "Replayed delivery %d (%s %s) returned %s", count, d.Method, d.Path, rsp.Status)
+			failures += 1
+			return nil
+		}
+
+		aa_log.Info(logger, "Replayed delivery %d (%s %s) returned %s", count, d.Method, d.Path, rsp.Status)
+		return nil
+	}
+
+	err = ReadDeliveries(r, replay)
+
+	if err != nil {
+		return err
+	}
+
+	if failures > 0 {
+		return fmt.Errorf("%d of %d deliveries failed", failures, count)
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package recorder
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReplay(t *testing.T) {
+
+	ctx := context.Background()
+
+	mu := new(sync.Mutex)
+	received := make([]*http.Request, 0)
+	bodies := make([]string, 0)
+
+	target := httptest.NewServer(http.HandlerFunc(func(rsp http.ResponseWriter, req *http.Request) {
+
+		body, _ := io.ReadAll(req.Body)
+
+		mu.Lock()
+		received = append(received, req)
+		bodies = append(bodies, string(body))
+		mu.Unlock()
+
+		if req.URL.Path == "/fail" {
+			rsp.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+
+	defer target.Close()
+
+	now := time.Now()
+
+	deliveries := []*Delivery{
+		{Time: now, Method: "POST", Path: "/github?hello=world", Header: http.Header{"X-Github-Event": {"push"}, "Content-Length": {"99"}}, Body: []byte("one")},
+		{Time: now.Add(200 * time.Millisecond), Method: "PUT", Path: "/slack", Header: http.Header{}, Body: []byte("two")},
+	}
+
+	var buf bytes.Buffer
+
+	r, err := NewRecorder("http://localhost:8080", &buf)
+
+	if err != nil {
+		t.Fatalf("Failed to create recorder, %v", err)
+	}
+
+	for _, d := range deliveries {
+
+		err := r.Write(d)
+
+		if err != nil {
+			t.Fatalf("Failed to write delivery, %v", err)
+		}
+	}
+
+	recording := buf.Bytes()
+
+	// Replay at the original timing
+
+	opts := &ReplayOptions{
+		Target: target.URL,
+		Speed:  1,
+	}
+
+	t1 := time.Now()
+
+	err = Replay(ctx, bytes.NewReader(recording), opts)
+
+	if err != nil {
+		t.Fatalf("Failed to replay deliveries, %v", err)
+	}
+
+	if time.Since(t1) < 200*time.Millisecond {
+		t.Fatalf("Expected replay to preserve original timing")
+	}
+
+	if len(received) != 2 {
+		t.Fatalf("Expected 2 deliveries, got %d", len(received))
+	}
+
+	req := received[0]
+
+	if req.Method != "POST" || req.URL.RequestURI() != "/github?hello=world" || req.Header.Get("X-GitHub-Event") != "push" || bodies[0] != "one" {
+		t.Fatalf("Unexpected replayed delivery: %s %s %v %s", req.Method, req.URL.RequestURI(), req.Header, bodies[0])
+	}
+
+	if received[1].Method != "PUT" || bodies[1] != "two" {
+		t.Fatalf("Unexpected replayed delivery: %s %s", received[1].Method, bodies[1])
+	}
+
+	// Replay as quickly as possible, with a failing delivery
+
+	r.Write(&Delivery{Time: now.Add(time.Hour), Method: "POST", Path: "/fail"})
+
+	opts.Speed = 0
+	t1 = time.Now()
+
+	err = Replay(ctx, bytes.NewReader(buf.Bytes()), opts)
+
+	if err == nil || err.Error() != "1 of 3 deliveries failed" {
+		t.Fatalf("Expected one failed delivery, %v", err)
+	}
+
+	if time.Since(t1) > time.Minute {
+		t.Fatalf("Expected replay to ignore original timing")
+	}
+}
+
+func TestReplayInvalid(t *testing.T) {
+
+	ctx := context.Background()
+
+	invalid := []*ReplayOptions{
+		{Target: "/path"},
+		{Target: "http://localhost:8080", Speed: -1},
+	}
+
+	for idx, opts := range invalid {
+
+		err := Replay(ctx, bytes.NewReader(nil), opts)
+
+		if err == nil {
+			t.Fatalf("Expected options at offset %d to fail", idx)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package reporter
+
+import (
+	"context"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterReporter(ctx, "null", NewNullReporter)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// NullReporter implements the `webhookd.WebhookReporter` interface for reporting panics to nowhere.
+type NullReporter struct {
+	webhookd.WebhookReporter
+}
+
+// NewNullReporter returns a new `NullReporter` instance that reports panics to nowhere configured by 'uri' in the form of:
+//
+//	null://
+func NewNullReporter(ctx context.Context, uri string) (webhookd.WebhookReporter, error) {
+
+	r := NullReporter{}
+	return &r, nil
+}
+
+// Report sends 'p' to nowhere.
+func (r *NullReporter) Report(ctx context.Context, p *webhookd.WebhookPanic) error {
+	return nil
+}
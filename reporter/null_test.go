@@ -0,0 +1,31 @@
+package reporter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+func TestNullReporter(t *testing.T) {
+
+	ctx := context.Background()
+
+	r, err := NewReporter(ctx, "null://")
+
+	if err != nil {
+		t.Fatalf("Failed to create new reporter, %v", err)
+	}
+
+	p := &webhookd.WebhookPanic{
+		Value: "boom",
+		Time:  time.Now(),
+	}
+
+	err = r.Report(ctx, p)
+
+	if err != nil {
+		t.Fatalf("Failed to report panic, %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+// Package reporter provides methods for reporting unexpected failures, like panics, to external error tracking services.
+package reporter
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+
+	"github.com/aaronland/go-roster"
+	"github.com/whosonfirst/go-webhookd/v3"
+	"github.com/whosonfirst/go-webhookd/v3/secrets"
+)
+
+// reporters is a `aaronland/go-roster.Roster` instance used to maintain a list of registered `webhookd.WebhookReporter` initialization functions.
+var reporters roster.Roster
+
+// ReporterInitializationFunc is a function used to initialize an implementation of the `webhookd.WebhookReporter` interface.
+type ReporterInitializationFunc func(ctx context.Context, uri string) (webhookd.WebhookReporter, error)
+
+// NewReporter() returns a new `webhookd.WebhookReporter` instance derived from 'uri'. The semantics of and requirements for
+// 'uri' as specific to the package implementing the interface.
+func NewReporter(ctx context.Context, uri string) (webhookd.WebhookReporter, error) {
+
+	err := ensureReporterRoster()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to ensure reporter roster, %w", err)
+	}
+
+	uri, err = secrets.ResolveURI(ctx, uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to resolve secrets, %w", err)
+	}
+
+	parsed, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	scheme := parsed.Scheme
+
+	i, err := reporters.Driver(ctx, scheme)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to find initialization function for '%s', %w", scheme, err)
+	}
+
+	init_func := i.(ReporterInitializationFunc)
+	return init_func(ctx, uri)
+}
+
+// RegisterReporter() associates 'scheme' with 'init_func' in an internal list of avilable `webhookd.WebhookReporter` implementations.
+func RegisterReporter(ctx context.Context, scheme string, init_func ReporterInitializationFunc) error {
+
+	err := ensureReporterRoster()
+
+	if err != nil {
+		return fmt.Errorf("Failed to ensure reporter roster, %w", err)
+	}
+
+	return reporters.Register(ctx, scheme, init_func)
+}
+
+// ensureReporterRoster() ensures that a `aaronland/go-roster.Roster` instance used to maintain a list of registered `webhookd.WebhookReporter`
+// initialization functions is present
+func ensureReporterRoster() error {
+
+	if reporters == nil {
+
+		r, err := roster.NewDefaultRoster()
+
+		if err != nil {
+			return fmt.Errorf("Failed to create new roster, %w", err)
+		}
+
+		reporters = r
+	}
+
+	return nil
+}
+
+// Schemes() returns the list of schemes that have been "registered".
+func Schemes() []string {
+	ctx := context.Background()
+	drivers := reporters.Drivers(ctx)
+
+	schemes := make([]string, len(drivers))
+
+	for idx, dr := range drivers {
+		schemes[idx] = fmt.Sprintf("%s://", dr)
+	}
+
+	sort.Strings(schemes)
+	return schemes
+}
@@ -0,0 +1,30 @@
+package reporter
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRegisterReporter(t *testing.T) {
+
+	ctx := context.Background()
+
+	err := RegisterReporter(ctx, "null", NewNullReporter)
+
+	if err == nil {
+		t.Fatalf("Expected NewNullReporter to be registered already")
+	}
+}
+
+func TestNewReporter(t *testing.T) {
+
+	ctx := context.Background()
+
+	uri := "null://"
+
+	_, err := NewReporter(ctx, uri)
+
+	if err != nil {
+		t.Fatalf("Failed to create new reporter for '%s', %v", uri, err)
+	}
+}
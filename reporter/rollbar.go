@@ -0,0 +1,141 @@
+package reporter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterReporter(ctx, "rollbar", NewRollbarReporter)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// ROLLBAR_ENDPOINT is the URL of the Rollbar API used to create items.
+const ROLLBAR_ENDPOINT string = "https://api.rollbar.com/api/1/item/"
+
+// DEFAULT_ROLLBAR_ENVIRONMENT is the default name of the environment that items are reported for.
+const DEFAULT_ROLLBAR_ENVIRONMENT string = "production"
+
+// RollbarReporter implements the `webhookd.WebhookReporter` interface for reporting panics to Rollbar.
+type RollbarReporter struct {
+	webhookd.WebhookReporter
+	// endpoint is the URL of the Rollbar API used to create items.
+	endpoint string
+	// access_token is a Rollbar project access token with the "post_server_item" scope.
+	access_token string
+	// environment is the name of the environment that items are reported for.
+	environment string
+	// client is the `http.Client` used to send items.
+	client *http.Client
+}
+
+// NewRollbarReporter returns a new `RollbarReporter` instance configured by 'uri' in the form of:
+//
+//	rollbar://?access_token={TOKEN}&environment={ENVIRONMENT}
+//
+// Where `access_token` is a Rollbar project access token with the "post_server_item" scope and `environment` is the optional name
+// of the environment that items are reported for. Default is "production".
+func NewRollbarReporter(ctx context.Context, uri string) (webhookd.WebhookReporter, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	access_token := q.Get("access_token")
+
+	if access_token == "" {
+		return nil, fmt.Errorf("Missing ?access_token= parameter")
+	}
+
+	environment := q.Get("environment")
+
+	if environment == "" {
+		environment = DEFAULT_ROLLBAR_ENVIRONMENT
+	}
+
+	r := &RollbarReporter{
+		endpoint:     ROLLBAR_ENDPOINT,
+		access_token: access_token,
+		environment:  environment,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+
+	return r, nil
+}
+
+// Report sends 'p' to Rollbar as an item with the level "error".
+func (r *RollbarReporter) Report(ctx context.Context, p *webhookd.WebhookPanic) error {
+
+	host, _ := os.Hostname()
+
+	item := map[string]interface{}{
+		"access_token": r.access_token,
+		"data": map[string]interface{}{
+			"environment": r.environment,
+			"level":       "error",
+			"platform":    "go",
+			"language":    "go",
+			"timestamp":   p.Time.Unix(),
+			"body": map[string]interface{}{
+				"message": map[string]string{
+					"body": fmt.Sprintf("panic: %s\n\n%s", p.Value, p.Stack),
+				},
+			},
+			"server": map[string]string{
+				"host": host,
+			},
+			"custom": map[string]string{
+				"step":        p.Step,
+				"endpoint":    p.Endpoint,
+				"delivery_id": p.DeliveryID,
+			},
+		},
+	}
+
+	enc, err := json.Marshal(item)
+
+	if err != nil {
+		return fmt.Errorf("Failed to encode item, %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.endpoint, bytes.NewReader(enc))
+
+	if err != nil {
+		return fmt.Errorf("Failed to create request, %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	rsp, err := r.client.Do(req)
+
+	if err != nil {
+		return fmt.Errorf("Failed to send item, %w", err)
+	}
+
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode < 200 || rsp.StatusCode > 299 {
+		return fmt.Errorf("Rollbar returned unexpected status, %s", rsp.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package reporter
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+func TestRollbarReporter(t *testing.T) {
+
+	ctx := context.Background()
+
+	_, err := NewReporter(ctx, "rollbar://")
+
+	if err == nil {
+		t.Fatalf("Expected reporter without access token to fail")
+	}
+
+	r, err := NewReporter(ctx, "rollbar://?access_token=s33kret")
+
+	if err != nil {
+		t.Fatalf("Failed to create new reporter, %v", err)
+	}
+
+	var item map[string]interface{}
+
+	handler := func(rsp http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		json.Unmarshal(body, &item)
+	}
+
+	svr := httptest.NewServer(http.HandlerFunc(handler))
+	defer svr.Close()
+
+	r.(*RollbarReporter).endpoint = svr.URL
+
+	p := &webhookd.WebhookPanic{
+		Value:      "boom",
+		Step:       "handler",
+		DeliveryID: "1234",
+		Time:       time.Now(),
+	}
+
+	err = r.Report(ctx, p)
+
+	if err != nil {
+		t.Fatalf("Failed to report panic, %v", err)
+	}
+
+	data := item["data"].(map[string]interface{})
+
+	if item["access_token"] != "s33kret" || data["environment"] != DEFAULT_ROLLBAR_ENVIRONMENT || data["custom"].(map[string]interface{})["delivery_id"] != "1234" {
+		t.Fatalf("Unexpected item: %v", item)
+	}
+
+	// Errors returned by Rollbar are reported
+
+	svr.Config.Handler = http.HandlerFunc(func(rsp http.ResponseWriter, req *http.Request) {
+		http.Error(rsp, "Unauthorized", http.StatusUnauthorized)
+	})
+
+	err = r.Report(ctx, p)
+
+	if err == nil {
+		t.Fatalf("Expected error response to fail")
+	}
+}
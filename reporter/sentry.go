@@ -0,0 +1,167 @@
+package reporter
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterReporter(ctx, "sentry", NewSentryReporter)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// SentryReporter implements the `webhookd.WebhookReporter` interface for reporting panics to Sentry.
+type SentryReporter struct {
+	webhookd.WebhookReporter
+	// endpoint is the URL of the Sentry "store" API for the project.
+	endpoint string
+	// key is the public key for the project.
+	key string
+	// environment is the optional name of the environment that events are reported for.
+	environment string
+	// release is the optional release that events are reported for.
+	release string
+	// client is the `http.Client` used to send events.
+	client *http.Client
+}
+
+// NewSentryReporter returns a new `SentryReporter` instance configured by 'uri' which is expected to be a Sentry DSN with the
+// "sentry" scheme, in the form of:
+//
+//	sentry://{PUBLIC_KEY}@{HOST}/{PROJECT_ID}?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `environment={STRING}` The optional name of the environment (for example "production") that events are reported for.
+// * `release={STRING}` The optional release (for example a version number) that events are reported for.
+func NewSentryReporter(ctx context.Context, uri string) (webhookd.WebhookReporter, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	if u.User == nil || u.User.Username() == "" {
+		return nil, fmt.Errorf("Missing public key")
+	}
+
+	if u.Host == "" {
+		return nil, fmt.Errorf("Missing host")
+	}
+
+	project_id := path.Base(u.Path)
+	prefix := path.Dir(u.Path)
+
+	if project_id == "" || project_id == "." || project_id == "/" {
+		return nil, fmt.Errorf("Missing project ID")
+	}
+
+	prefix = strings.TrimSuffix(prefix, "/")
+
+	q := u.Query()
+
+	r := &SentryReporter{
+		endpoint:    fmt.Sprintf("https://%s%s/api/%s/store/", u.Host, prefix, project_id),
+		key:         u.User.Username(),
+		environment: q.Get("environment"),
+		release:     q.Get("release"),
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+
+	return r, nil
+}
+
+// Report sends 'p' to Sentry as an event with the level "error".
+func (r *SentryReporter) Report(ctx context.Context, p *webhookd.WebhookPanic) error {
+
+	event_id := make([]byte, 16)
+
+	_, err := rand.Read(event_id)
+
+	if err != nil {
+		return fmt.Errorf("Failed to generate event ID, %w", err)
+	}
+
+	server_name, _ := os.Hostname()
+
+	event := map[string]interface{}{
+		"event_id":    hex.EncodeToString(event_id),
+		"timestamp":   p.Time.UTC().Format(time.RFC3339),
+		"level":       "error",
+		"platform":    "go",
+		"logger":      "webhookd",
+		"server_name": server_name,
+		"exception": map[string]interface{}{
+			"values": []map[string]interface{}{
+				{
+					"type":  "panic",
+					"value": p.Value,
+				},
+			},
+		},
+		"tags": map[string]string{
+			"step":     p.Step,
+			"endpoint": p.Endpoint,
+		},
+		"extra": map[string]string{
+			"delivery_id": p.DeliveryID,
+			"stack":       string(p.Stack),
+		},
+	}
+
+	if r.environment != "" {
+		event["environment"] = r.environment
+	}
+
+	if r.release != "" {
+		event["release"] = r.release
+	}
+
+	enc, err := json.Marshal(event)
+
+	if err != nil {
+		return fmt.Errorf("Failed to encode event, %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.endpoint, bytes.NewReader(enc))
+
+	if err != nil {
+		return fmt.Errorf("Failed to create request, %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", fmt.Sprintf("Sentry sentry_version=7, sentry_client=go-webhookd/3, sentry_key=%s", r.key))
+
+	rsp, err := r.client.Do(req)
+
+	if err != nil {
+		return fmt.Errorf("Failed to send event, %w", err)
+	}
+
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode < 200 || rsp.StatusCode > 299 {
+		return fmt.Errorf("Sentry returned unexpected status, %s", rsp.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package reporter
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+func TestSentryReporter(t *testing.T) {
+
+	ctx := context.Background()
+
+	r, err := NewReporter(ctx, "sentry://abc123@sentry.example.com/prefix/42?environment=test")
+
+	if err != nil {
+		t.Fatalf("Failed to create new reporter, %v", err)
+	}
+
+	sr := r.(*SentryReporter)
+
+	if sr.endpoint != "https://sentry.example.com/prefix/api/42/store/" {
+		t.Fatalf("Unexpected endpoint: %s", sr.endpoint)
+	}
+
+	var event map[string]interface{}
+	var auth string
+
+	handler := func(rsp http.ResponseWriter, req *http.Request) {
+
+		auth = req.Header.Get("X-Sentry-Auth")
+
+		body, _ := io.ReadAll(req.Body)
+		json.Unmarshal(body, &event)
+	}
+
+	svr := httptest.NewServer(http.HandlerFunc(handler))
+	defer svr.Close()
+
+	sr.endpoint = svr.URL
+
+	p := &webhookd.WebhookPanic{
+		Value:    "boom",
+		Stack:    []byte("goroutine 1 [running]:"),
+		Step:     "dispatcher",
+		Endpoint: "/foo",
+		Time:     time.Now(),
+	}
+
+	err = r.Report(ctx, p)
+
+	if err != nil {
+		t.Fatalf("Failed to report panic, %v", err)
+	}
+
+	if !strings.Contains(auth, "sentry_key=abc123") {
+		t.Fatalf("Unexpected auth header: %s", auth)
+	}
+
+	if event["environment"] != "test" || event["tags"].(map[string]interface{})["endpoint"] != "/foo" || len(event["event_id"].(string)) != 32 {
+		t.Fatalf("Unexpected event: %v", event)
+	}
+}
+
+func TestSentryReporterInvalid(t *testing.T) {
+
+	ctx := context.Background()
+
+	tests := []string{
+		"sentry://sentry.example.com/42",
+		"sentry://abc123@/42",
+		"sentry://abc123@sentry.example.com",
+		"sentry://abc123@sentry.example.com/",
+	}
+
+	for _, uri := range tests {
+
+		_, err := NewReporter(ctx, uri)
+
+		if err == nil {
+			t.Fatalf("Expected %s to fail", uri)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+// Package scaffold provides methods for generating the skeletons of new receivers, transformations and dispatchers.
+package scaffold
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/whosonfirst/go-webhookd/v3/dispatcher"
+	"github.com/whosonfirst/go-webhookd/v3/receiver"
+	"github.com/whosonfirst/go-webhookd/v3/transformation"
+)
+
+// Kinds of components that can be generated.
+const (
+	// KIND_RECEIVER generates a `webhookd.WebhookReceiver` in the receiver package.
+	KIND_RECEIVER string = "receiver"
+	// KIND_TRANSFORMATION generates a `webhookd.WebhookTransformation` in the transformation package.
+	KIND_TRANSFORMATION string = "transformation"
+	// KIND_DISPATCHER generates a `webhookd.WebhookDispatcher` in the dispatcher package.
+	KIND_DISPATCHER string = "dispatcher"
+)
+
+//go:embed templates/*.tmpl
+var templates_fs embed.FS
+
+// re_name is the pattern that names (URI schemes) for new components must match.
+var re_name = regexp.MustCompile(`^[a-z][a-z0-9]*$`)
+
+// re_type is the pattern that type names for new components must match.
+var re_type = regexp.MustCompile(`^[A-Z][A-Za-z0-9]*$`)
+
+// Options defines configuration options for generating a new component.
+type Options struct {
+	// Root is the root of the go-webhookd source tree that files are written to. Default is the current working directory.
+	Root string
+	// Type is the prefix for the names of the types and constructor in the generated code. Default is 'name' with its first letter
+	// capitalized.
+	Type string
+}
+
+// templateVars are the variables passed to the templates for a new component.
+type templateVars struct {
+	// Name is the URI scheme the component is registered with.
+	Name string
+	// Type is the prefix for the names of the types and constructor for the component.
+	Type string
+	// Const is the prefix for the names of any constants for the component.
+	Const string
+}
+
+// Kinds returns the list of kinds of components that can be generated.
+func Kinds() []string {
+	return []string{KIND_RECEIVER, KIND_TRANSFORMATION, KIND_DISPATCHER}
+}
+
+// Generate writes a new component of kind 'kind' (one of the KIND_ constants), registered with the URI scheme 'name', and a
+// table-driven test for it to the package for 'kind' in the source tree defined by 'opts'. It returns the list of paths written.
+// It is an error if either file already exists or 'name' is already registered.
+func Generate(ctx context.Context, kind string, name string, opts *Options) ([]string, error) {
+
+	if !re_name.MatchString(name) {
+		return nil, fmt.Errorf("Invalid name '%s', names must be lowercase letters and numbers starting with a letter", name)
+	}
+
+	var schemes []string
+
+	switch kind {
+	case KIND_RECEIVER:
+		schemes = receiver.Schemes()
+	case KIND_TRANSFORMATION:
+		schemes = transformation.Schemes()
+	case KIND_DISPATCHER:
+		schemes = dispatcher.Schemes()
+	default:
+		return nil, fmt.Errorf("Invalid kind '%s', expected one of: %s", kind, strings.Join(Kinds(), ", "))
+	}
+
+	for _, s := range schemes {
+
+		if strings.EqualFold(s, name+"://") {
+			return nil, fmt.Errorf("A %s named '%s' is already registered", kind, name)
+		}
+	}
+
+	root := opts.Root
+
+	if root == "" {
+		root = "."
+	}
+
+	type_name := opts.Type
+
+	if type_name == "" {
+		type_name = strings.ToUpper(name[:1]) + name[1:]
+	}
+
+	if !re_type.MatchString(type_name) {
+		return nil, fmt.Errorf("Invalid type name '%s', type names must be letters and numbers starting with an uppercase letter", type_name)
+	}
+
+	vars := &templateVars{
+		Name:  name,
+		Type:  type_name,
+		Const: strings.ToUpper(name),
+	}
+
+	pkg_root := filepath.Join(root, kind)
+
+	info, err := os.Stat(pkg_root)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to find %s package in '%s', %w", kind, root, err)
+	}
+
+	if !info.IsDir() {
+		return nil, fmt.Errorf("'%s' is not a directory", pkg_root)
+	}
+
+	files := map[string]string{
+		filepath.Join(pkg_root, name+".go"):      kind + ".go.tmpl",
+		filepath.Join(pkg_root, name+"_test.go"): kind + "_test.go.tmpl",
+	}
+
+	paths := []string{
+		filepath.Join(pkg_root, name+".go"),
+		filepath.Join(pkg_root, name+"_test.go"),
+	}
+
+	for _, path := range paths {
+
+		_, err := os.Stat(path)
+
+		if err == nil {
+			return nil, fmt.Errorf("'%s' already exists", path)
+		}
+
+		if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("Failed to stat '%s', %w", path, err)
+		}
+	}
+
+	// Render everything before writing anything so a failure doesn't leave a partial component behind
+
+	rendered := make(map[string][]byte)
+
+	for _, path := range paths {
+
+		body, err := render(files[path], vars)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to render '%s', %w", path, err)
+		}
+
+		rendered[path] = body
+	}
+
+	for _, path := range paths {
+
+		err := os.WriteFile(path, rendered[path], 0644)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to write '%s', %w", path, err)
+		}
+	}
+
+	return paths, nil
+}
+
+// render executes the template 'name' with 'vars' and returns the result formatted with `go/format`.
+func render(name string, vars *templateVars) ([]byte, error) {
+
+	t, err := template.ParseFS(templates_fs, "templates/"+name)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse template, %w", err)
+	}
+
+	var buf bytes.Buffer
+
+	err = t.Execute(&buf, vars)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to execute template, %w", err)
+	}
+
+	return format.Source(buf.Bytes())
+}
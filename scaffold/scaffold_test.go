@@ -0,0 +1,137 @@
+package scaffold
+
+import (
+	"context"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+
+	ctx := context.Background()
+
+	tests := []struct {
+		kind      string
+		type_name string
+		expected  []string
+	}{
+		{KIND_RECEIVER, "", []string{"NewAcmeReceiver", "ACME_SIGNATURE_HEADER", `RegisterReceiver(ctx, "acme"`}},
+		{KIND_TRANSFORMATION, "", []string{"NewAcmeTransformation", `RegisterTransformation(ctx, "acme"`}},
+		{KIND_DISPATCHER, "ACME", []string{"NewACMEDispatcher", `RegisterDispatcher(ctx, "acme"`}},
+	}
+
+	for _, test := range tests {
+
+		root := t.TempDir()
+
+		err := os.Mkdir(filepath.Join(root, test.kind), 0755)
+
+		if err != nil {
+			t.Fatalf("Failed to create %s package, %v", test.kind, err)
+		}
+
+		opts := &Options{
+			Root: root,
+			Type: test.type_name,
+		}
+
+		paths, err := Generate(ctx, test.kind, "acme", opts)
+
+		if err != nil {
+			t.Fatalf("Failed to generate %s, %v", test.kind, err)
+		}
+
+		if len(paths) != 2 {
+			t.Fatalf("Expected 2 paths for %s but got %d", test.kind, len(paths))
+		}
+
+		fset := token.NewFileSet()
+
+		for _, path := range paths {
+
+			f, err := parser.ParseFile(fset, path, nil, 0)
+
+			if err != nil {
+				t.Fatalf("Failed to parse '%s', %v", path, err)
+			}
+
+			if f.Name.Name != test.kind {
+				t.Fatalf("Unexpected package '%s' for '%s'", f.Name.Name, path)
+			}
+		}
+
+		body, err := os.ReadFile(paths[0])
+
+		if err != nil {
+			t.Fatalf("Failed to read '%s', %v", paths[0], err)
+		}
+
+		for _, str := range test.expected {
+
+			if !strings.Contains(string(body), str) {
+				t.Fatalf("Expected '%s' to contain '%s'", paths[0], str)
+			}
+		}
+
+		_, err = Generate(ctx, test.kind, "acme", opts)
+
+		if err == nil {
+			t.Fatalf("Expected generating %s a second time to fail", test.kind)
+		}
+	}
+}
+
+func TestGenerateInvalid(t *testing.T) {
+
+	ctx := context.Background()
+
+	root := t.TempDir()
+
+	for _, kind := range Kinds() {
+
+		err := os.Mkdir(filepath.Join(root, kind), 0755)
+
+		if err != nil {
+			t.Fatalf("Failed to create %s package, %v", kind, err)
+		}
+	}
+
+	tests := []struct {
+		kind      string
+		name      string
+		type_name string
+	}{
+		{"widget", "acme", ""},
+		{KIND_RECEIVER, "Acme", ""},
+		{KIND_RECEIVER, "acme-corp", ""},
+		{KIND_RECEIVER, "1acme", ""},
+		{KIND_RECEIVER, "acme", "acme"},
+		{KIND_RECEIVER, "insecure", ""},
+		{KIND_DISPATCHER, "null", ""},
+		{KIND_TRANSFORMATION, "null", ""},
+	}
+
+	for _, test := range tests {
+
+		opts := &Options{
+			Root: root,
+			Type: test.type_name,
+		}
+
+		_, err := Generate(ctx, test.kind, test.name, opts)
+
+		if err == nil {
+			t.Fatalf("Expected generating %s '%s' (%s) to fail", test.kind, test.name, test.type_name)
+		}
+	}
+
+	_, err := Generate(ctx, KIND_RECEIVER, "acme", &Options{Root: filepath.Join(root, "missing")})
+
+	if err == nil {
+		t.Fatalf("Expected generating a receiver in a missing source tree to fail")
+	}
+}
@@ -0,0 +1,211 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterProvider(ctx, "aws", NewAWSProvider)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// awsGetSecretValueResponse is the subset of an AWS Secrets Manager `GetSecretValue` response used by `AWSProvider`.
+type awsGetSecretValueResponse struct {
+	SecretString string `json:"SecretString"`
+	SecretBinary string `json:"SecretBinary"`
+}
+
+// AWSProvider implements the `Provider` interface for secrets stored in AWS Secrets Manager.
+type AWSProvider struct {
+	Provider
+	// session is the AWS session whose credentials are used to sign requests.
+	session *session.Session
+	// region is the AWS region of the secret.
+	region string
+	// endpoint is the URL of the Secrets Manager API.
+	endpoint string
+	// secret_id is the name or ARN of the secret.
+	secret_id string
+	// version_id is the optional ID of the version of the secret to retrieve.
+	version_id string
+	// version_stage is the optional staging label of the version of the secret to retrieve.
+	version_stage string
+	// field is the optional name of the property to return if the secret is a JSON-encoded dictionary.
+	field string
+	// client is the `http.Client` used to send requests.
+	client *http.Client
+}
+
+// NewAWSProvider returns a new `AWSProvider` instance configured by 'uri' in the form of:
+//
+//	secrets://aws/{SECRET_ID}?{PARAMETERS}
+//
+// Where {SECRET_ID} is the name or (URL-escaped) ARN of the secret. Valid {PARAMETERS} are:
+// * `region={STRING}` The AWS region of the secret. Optional; if empty the region is derived from the default AWS configuration.
+// * `field={STRING}` The name of the property to return if the secret is a JSON-encoded dictionary. Optional.
+// * `version_id={STRING}` The ID of the version of the secret to retrieve. Optional.
+// * `version_stage={STRING}` The staging label of the version of the secret to retrieve. Optional; if empty AWS uses "AWSCURRENT".
+// * `endpoint={URL}` A custom Secrets Manager endpoint. Optional.
+//
+// Requests are signed using credentials from the default AWS credentials chain.
+func NewAWSProvider(ctx context.Context, uri string) (Provider, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	secret_id := strings.TrimPrefix(u.Path, "/")
+
+	if secret_id == "" {
+		return nil, fmt.Errorf("Missing secret ID")
+	}
+
+	q := u.Query()
+
+	cfg := aws.NewConfig()
+
+	region := q.Get("region")
+
+	if region != "" {
+		cfg = cfg.WithRegion(region)
+	}
+
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            *cfg,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create AWS session, %w", err)
+	}
+
+	region = aws.StringValue(sess.Config.Region)
+
+	if region == "" {
+		return nil, fmt.Errorf("Missing ?region= parameter")
+	}
+
+	endpoint := q.Get("endpoint")
+
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://secretsmanager.%s.amazonaws.com/", region)
+	}
+
+	p := &AWSProvider{
+		session:       sess,
+		region:        region,
+		endpoint:      endpoint,
+		secret_id:     secret_id,
+		version_id:    q.Get("version_id"),
+		version_stage: q.Get("version_stage"),
+		field:         q.Get("field"),
+		client:        &http.Client{Timeout: 10 * time.Second},
+	}
+
+	return p, nil
+}
+
+// Secret returns the value of the Secrets Manager secret for 'p'.
+func (p *AWSProvider) Secret(ctx context.Context) (*Secret, error) {
+
+	params := map[string]string{
+		"SecretId": p.secret_id,
+	}
+
+	if p.version_id != "" {
+		params["VersionId"] = p.version_id
+	}
+
+	if p.version_stage != "" {
+		params["VersionStage"] = p.version_stage
+	}
+
+	body, err := json.Marshal(params)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to encode request, %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint, bytes.NewReader(body))
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create request, %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+
+	signer := v4.NewSigner(p.session.Config.Credentials)
+
+	_, err = signer.Sign(req, bytes.NewReader(body), "secretsmanager", p.region, time.Now())
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to sign request, %w", err)
+	}
+
+	rsp, err := p.client.Do(req)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to send request, %w", err)
+	}
+
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, rsp.Body)
+		return nil, fmt.Errorf("Secrets Manager request failed, %s", rsp.Status)
+	}
+
+	var sm_rsp *awsGetSecretValueResponse
+
+	err = json.NewDecoder(rsp.Body).Decode(&sm_rsp)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decode response, %w", err)
+	}
+
+	value := sm_rsp.SecretString
+
+	if value == "" && sm_rsp.SecretBinary != "" {
+
+		dec, err := base64.StdEncoding.DecodeString(sm_rsp.SecretBinary)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to decode binary secret, %w", err)
+		}
+
+		value = string(dec)
+	}
+
+	value, err = extractField(value, p.field)
+
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Secret{
+		Value: value,
+	}
+
+	return s, nil
+}
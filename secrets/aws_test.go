@@ -0,0 +1,106 @@
+package secrets
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAWSProvider(t *testing.T) {
+
+	ctx := context.Background()
+
+	handler := func(rsp http.ResponseWriter, req *http.Request) {
+
+		if req.Header.Get("X-Amz-Target") != "secretsmanager.GetSecretValue" {
+			http.Error(rsp, "Bad request", http.StatusBadRequest)
+			return
+		}
+
+		if !strings.Contains(req.Header.Get("Authorization"), "Credential=AKIDEXAMPLE/") {
+			http.Error(rsp, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		var params map[string]string
+
+		err := json.NewDecoder(req.Body).Decode(&params)
+
+		if err != nil {
+			http.Error(rsp, "Bad request", http.StatusBadRequest)
+			return
+		}
+
+		body := make(map[string]string)
+
+		switch params["SecretId"] {
+		case "webhookd/github":
+			body["SecretString"] = "s3cret"
+		case "webhookd/json":
+			body["SecretString"] = fmt.Sprintf(`{"token":"%s"}`, params["VersionStage"])
+		case "webhookd/binary":
+			body["SecretBinary"] = base64.StdEncoding.EncodeToString([]byte("b1nary"))
+		default:
+			http.Error(rsp, "Not found", http.StatusBadRequest)
+			return
+		}
+
+		json.NewEncoder(rsp).Encode(body)
+	}
+
+	svr := httptest.NewServer(http.HandlerFunc(handler))
+	defer svr.Close()
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "s3cret")
+
+	tests := map[string]string{
+		"secrets://aws/webhookd/github":                                    "s3cret",
+		"secrets://aws/webhookd/json?field=token&version_stage=AWSPENDING": "AWSPENDING",
+		"secrets://aws/webhookd/binary":                                    "b1nary",
+	}
+
+	for uri, expected := range tests {
+
+		uri = fmt.Sprintf("%s%sregion=us-east-1&endpoint=%s", uri, separator(uri), svr.URL)
+
+		p, err := NewAWSProvider(ctx, uri)
+
+		if err != nil {
+			t.Fatalf("Failed to create provider for '%s', %v", uri, err)
+		}
+
+		s, err := p.Secret(ctx)
+
+		if err != nil {
+			t.Fatalf("Failed to retrieve secret for '%s', %v", uri, err)
+		}
+
+		if s.Value != expected {
+			t.Fatalf("Unexpected value for '%s': %s", uri, s.Value)
+		}
+	}
+
+	p, err := NewAWSProvider(ctx, fmt.Sprintf("secrets://aws/webhookd/missing?region=us-east-1&endpoint=%s", svr.URL))
+
+	if err != nil {
+		t.Fatalf("Failed to create provider, %v", err)
+	}
+
+	_, err = p.Secret(ctx)
+
+	if err == nil {
+		t.Fatalf("Expected missing secret to fail")
+	}
+
+	_, err = NewAWSProvider(ctx, "secrets://aws/?region=us-east-1")
+
+	if err == nil {
+		t.Fatalf("Expected missing secret ID to fail")
+	}
+}
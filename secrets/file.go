@@ -0,0 +1,82 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterProvider(ctx, "file", NewFileProvider)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// FileProvider implements the `Provider` interface for secrets stored in local files, for example Kubernetes or Docker secrets
+// mounted in to a container.
+type FileProvider struct {
+	Provider
+	// path is the absolute path of the file containing the secret.
+	path string
+	// field is the optional name of the property to return if the file contains a JSON-encoded dictionary.
+	field string
+}
+
+// NewFileProvider returns a new `FileProvider` instance configured by 'uri' in the form of:
+//
+//	secrets://file/{PATH}?{PARAMETERS}
+//
+// Where {PATH} is the absolute path of the file containing the secret. Valid {PARAMETERS} are:
+// * `field={STRING}` The name of the property to return if the file contains a JSON-encoded dictionary. Optional.
+//
+// The file is read each time the secret is resolved so changes to it are picked up when its cached value expires. Trailing
+// newlines are removed.
+func NewFileProvider(ctx context.Context, uri string) (Provider, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	if u.Path == "" || u.Path == "/" {
+		return nil, fmt.Errorf("Missing file path")
+	}
+
+	p := &FileProvider{
+		path:  u.Path,
+		field: u.Query().Get("field"),
+	}
+
+	return p, nil
+}
+
+// Secret returns the contents of the file for 'p'.
+func (p *FileProvider) Secret(ctx context.Context) (*Secret, error) {
+
+	body, err := os.ReadFile(p.path)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read secret file, %w", err)
+	}
+
+	value := strings.TrimRight(string(body), "\r\n")
+
+	value, err = extractField(value, p.field)
+
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Secret{
+		Value: value,
+	}
+
+	return s, nil
+}
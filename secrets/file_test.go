@@ -0,0 +1,80 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileProvider(t *testing.T) {
+
+	ctx := context.Background()
+
+	dir := t.TempDir()
+
+	plain := filepath.Join(dir, "token")
+	encoded := filepath.Join(dir, "credentials.json")
+
+	err := os.WriteFile(plain, []byte("s3cret\n"), 0600)
+
+	if err != nil {
+		t.Fatalf("Failed to write secret, %v", err)
+	}
+
+	err = os.WriteFile(encoded, []byte(`{"username":"webhookd","password":"s3cret"}`), 0600)
+
+	if err != nil {
+		t.Fatalf("Failed to write secret, %v", err)
+	}
+
+	tests := map[string]string{
+		fmt.Sprintf("secrets://file%s", plain):                  "s3cret",
+		fmt.Sprintf("secrets://file%s?field=password", encoded): "s3cret",
+	}
+
+	for uri, expected := range tests {
+
+		p, err := NewFileProvider(ctx, uri)
+
+		if err != nil {
+			t.Fatalf("Failed to create provider for '%s', %v", uri, err)
+		}
+
+		s, err := p.Secret(ctx)
+
+		if err != nil {
+			t.Fatalf("Failed to retrieve secret for '%s', %v", uri, err)
+		}
+
+		if s.Value != expected {
+			t.Fatalf("Unexpected value for '%s': %s", uri, s.Value)
+		}
+	}
+
+	for _, uri := range []string{
+		fmt.Sprintf("secrets://file%s?field=password", plain),
+		fmt.Sprintf("secrets://file%s?field=token", encoded),
+		fmt.Sprintf("secrets://file%s/missing", dir),
+	} {
+
+		p, err := NewFileProvider(ctx, uri)
+
+		if err != nil {
+			t.Fatalf("Failed to create provider for '%s', %v", uri, err)
+		}
+
+		_, err = p.Secret(ctx)
+
+		if err == nil {
+			t.Fatalf("Expected '%s' to fail", uri)
+		}
+	}
+
+	_, err = NewFileProvider(ctx, "secrets://file/")
+
+	if err == nil {
+		t.Fatalf("Expected missing path to fail")
+	}
+}
@@ -0,0 +1,210 @@
+package secrets
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// DEFAULT_GCP_ENDPOINT is the default URL of the GCP Secret Manager API.
+const DEFAULT_GCP_ENDPOINT string = "https://secretmanager.googleapis.com"
+
+// DEFAULT_GCE_METADATA_HOST is the default host of the GCE metadata server, used to retrieve access tokens.
+const DEFAULT_GCE_METADATA_HOST string = "metadata.google.internal"
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterProvider(ctx, "gcp", NewGCPProvider)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// gcpAccessResponse is the subset of a GCP Secret Manager `AccessSecretVersion` response used by `GCPProvider`.
+type gcpAccessResponse struct {
+	Payload struct {
+		Data string `json:"data"`
+	} `json:"payload"`
+}
+
+// gcpTokenResponse is the subset of a GCE metadata server token response used by `GCPProvider`.
+type gcpTokenResponse struct {
+	AccessToken string `json:"access_token"`
+}
+
+// GCPProvider implements the `Provider` interface for secrets stored in GCP Secret Manager.
+type GCPProvider struct {
+	Provider
+	// name is the resource name of the secret version.
+	name string
+	// endpoint is the URL of the Secret Manager API.
+	endpoint string
+	// field is the optional name of the property to return if the secret is a JSON-encoded dictionary.
+	field string
+	// client is the `http.Client` used to send requests.
+	client *http.Client
+}
+
+// NewGCPProvider returns a new `GCPProvider` instance configured by 'uri' in the form of:
+//
+//	secrets://gcp/projects/{PROJECT}/secrets/{SECRET}/versions/{VERSION}?{PARAMETERS}
+//
+// Where "/versions/{VERSION}" is optional and defaults to "/versions/latest". Valid {PARAMETERS} are:
+// * `field={STRING}` The name of the property to return if the secret is a JSON-encoded dictionary. Optional.
+// * `endpoint={URL}` A custom Secret Manager endpoint. Optional.
+//
+// Requests are authenticated using the access token in the `GOOGLE_OAUTH_ACCESS_TOKEN` environment variable or, if it is not
+// set, one retrieved from the GCE metadata server.
+func NewGCPProvider(ctx context.Context, uri string) (Provider, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	name := strings.Trim(u.Path, "/")
+	parts := strings.Split(name, "/")
+
+	switch {
+	case len(parts) == 4 && parts[0] == "projects" && parts[2] == "secrets":
+		name = name + "/versions/latest"
+	case len(parts) == 6 && parts[0] == "projects" && parts[2] == "secrets" && parts[4] == "versions":
+		// pass
+	default:
+		return nil, fmt.Errorf("Invalid secret name '%s'", name)
+	}
+
+	q := u.Query()
+
+	endpoint := q.Get("endpoint")
+
+	if endpoint == "" {
+		endpoint = DEFAULT_GCP_ENDPOINT
+	}
+
+	p := &GCPProvider{
+		name:     name,
+		endpoint: strings.TrimRight(endpoint, "/"),
+		field:    q.Get("field"),
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+
+	return p, nil
+}
+
+// Secret returns the value of the Secret Manager secret version for 'p'.
+func (p *GCPProvider) Secret(ctx context.Context) (*Secret, error) {
+
+	token, err := p.accessToken(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	uri := fmt.Sprintf("%s/v1/%s:access", p.endpoint, p.name)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create request, %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	var access_rsp *gcpAccessResponse
+
+	err = p.do(req, &access_rsp)
+
+	if err != nil {
+		return nil, fmt.Errorf("Secret Manager request failed, %w", err)
+	}
+
+	dec, err := base64.StdEncoding.DecodeString(access_rsp.Payload.Data)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decode secret payload, %w", err)
+	}
+
+	value, err := extractField(string(dec), p.field)
+
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Secret{
+		Value: value,
+	}
+
+	return s, nil
+}
+
+// accessToken returns the OAuth2 access token used to authenticate requests.
+func (p *GCPProvider) accessToken(ctx context.Context) (string, error) {
+
+	token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN")
+
+	if token != "" {
+		return token, nil
+	}
+
+	host := os.Getenv("GCE_METADATA_HOST")
+
+	if host == "" {
+		host = DEFAULT_GCE_METADATA_HOST
+	}
+
+	uri := fmt.Sprintf("http://%s/computeMetadata/v1/instance/service-accounts/default/token", host)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+
+	if err != nil {
+		return "", fmt.Errorf("Failed to create metadata request, %w", err)
+	}
+
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	var token_rsp *gcpTokenResponse
+
+	err = p.do(req, &token_rsp)
+
+	if err != nil {
+		return "", fmt.Errorf("Failed to retrieve access token, %w", err)
+	}
+
+	return token_rsp.AccessToken, nil
+}
+
+// do sends 'req' and decodes the JSON-encoded response in to 'target'.
+func (p *GCPProvider) do(req *http.Request, target interface{}) error {
+
+	rsp, err := p.client.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, rsp.Body)
+		return fmt.Errorf("%s", rsp.Status)
+	}
+
+	err = json.NewDecoder(rsp.Body).Decode(target)
+
+	if err != nil {
+		return fmt.Errorf("Failed to decode response, %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,134 @@
+package secrets
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGCPProvider(t *testing.T) {
+
+	ctx := context.Background()
+
+	handler := func(rsp http.ResponseWriter, req *http.Request) {
+
+		if req.URL.Path == "/computeMetadata/v1/instance/service-accounts/default/token" {
+
+			if req.Header.Get("Metadata-Flavor") != "Google" {
+				http.Error(rsp, "Forbidden", http.StatusForbidden)
+				return
+			}
+
+			json.NewEncoder(rsp).Encode(map[string]string{"access_token": "metadata-t0ken"})
+			return
+		}
+
+		token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+
+		if token != "t0ken" && token != "metadata-t0ken" {
+			http.Error(rsp, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var value string
+
+		switch req.URL.Path {
+		case "/v1/projects/example/secrets/github/versions/latest:access":
+			value = "s3cret"
+		case "/v1/projects/example/secrets/github/versions/3:access":
+			value = `{"secret":"v3"}`
+		default:
+			http.Error(rsp, "Not found", http.StatusNotFound)
+			return
+		}
+
+		body := map[string]interface{}{
+			"name": req.URL.Path,
+			"payload": map[string]string{
+				"data": base64.StdEncoding.EncodeToString([]byte(value)),
+			},
+		}
+
+		json.NewEncoder(rsp).Encode(body)
+	}
+
+	svr := httptest.NewServer(http.HandlerFunc(handler))
+	defer svr.Close()
+
+	t.Setenv("GOOGLE_OAUTH_ACCESS_TOKEN", "t0ken")
+
+	tests := map[string]string{
+		"secrets://gcp/projects/example/secrets/github":                         "s3cret",
+		"secrets://gcp/projects/example/secrets/github/versions/3?field=secret": "v3",
+	}
+
+	for uri, expected := range tests {
+
+		uri = fmt.Sprintf("%s%sendpoint=%s", uri, separator(uri), svr.URL)
+
+		p, err := NewGCPProvider(ctx, uri)
+
+		if err != nil {
+			t.Fatalf("Failed to create provider for '%s', %v", uri, err)
+		}
+
+		s, err := p.Secret(ctx)
+
+		if err != nil {
+			t.Fatalf("Failed to retrieve secret for '%s', %v", uri, err)
+		}
+
+		if s.Value != expected {
+			t.Fatalf("Unexpected value for '%s': %s", uri, s.Value)
+		}
+	}
+
+	// Access tokens are retrieved from the metadata server if they are not set in the environment
+
+	t.Setenv("GOOGLE_OAUTH_ACCESS_TOKEN", "")
+	t.Setenv("GCE_METADATA_HOST", strings.TrimPrefix(svr.URL, "http://"))
+
+	p, err := NewGCPProvider(ctx, fmt.Sprintf("secrets://gcp/projects/example/secrets/github?endpoint=%s", svr.URL))
+
+	if err != nil {
+		t.Fatalf("Failed to create provider, %v", err)
+	}
+
+	s, err := p.Secret(ctx)
+
+	if err != nil {
+		t.Fatalf("Failed to retrieve secret using metadata token, %v", err)
+	}
+
+	if s.Value != "s3cret" {
+		t.Fatalf("Unexpected value: %s", s.Value)
+	}
+
+	for _, uri := range []string{
+		"secrets://gcp/example/github",
+		"secrets://gcp/projects/example/secrets",
+		"secrets://gcp/projects/example/secrets/github/latest/1",
+	} {
+
+		_, err := NewGCPProvider(ctx, uri)
+
+		if err == nil {
+			t.Fatalf("Expected '%s' to fail", uri)
+		}
+	}
+}
+
+// separator returns the character used to append a query parameter to 'uri'.
+func separator(uri string) string {
+
+	if strings.Contains(uri, "?") {
+		return "&"
+	}
+
+	return "?"
+}
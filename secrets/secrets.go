@@ -0,0 +1,433 @@
+// Package secrets provides methods for resolving `secrets://` URIs, used in place of secret values in the URIs for receivers,
+// transformations, dispatchers and other components, using external secret stores like HashiCorp Vault, AWS Secrets Manager or
+// GCP Secret Manager.
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aaronland/go-roster"
+)
+
+// SCHEME is the URI scheme for secret references, in the form of "secrets://{PROVIDER}/{PATH}?{PARAMETERS}".
+const SCHEME string = "secrets"
+
+// DEFAULT_TTL is the default amount of time that a resolved secret is cached for.
+const DEFAULT_TTL time.Duration = 5 * time.Minute
+
+// DEFAULT_RENEW_INTERVAL is the default interval at which `Resolver.Start` checks for leases that need to be renewed.
+const DEFAULT_RENEW_INTERVAL time.Duration = 30 * time.Second
+
+// Secret is the value of a secret returned by a `Provider`.
+type Secret struct {
+	// Value is the value of the secret.
+	Value string
+	// TTL is the amount of time that the value may be cached for. If 0 the default for the `Resolver` is used.
+	TTL time.Duration
+	// LeaseID is the optional identifier for the lease (for example a Vault lease) associated with the secret.
+	LeaseID string
+	// Renewable is a boolean flag signaling that the lease for the secret can be renewed using a `Renewer`.
+	Renewable bool
+}
+
+// Provider is an interface for retrieving a single secret from a secret store.
+type Provider interface {
+	// Secret returns the current value of the secret.
+	Secret(context.Context) (*Secret, error)
+}
+
+// Renewer is an optional interface for `Provider` implementations whose secrets have leases that can be renewed.
+type Renewer interface {
+	Provider
+	// Renew extends the lease for a secret, returning the new duration of the lease.
+	Renew(context.Context, *Secret) (time.Duration, error)
+}
+
+// providers is a `aaronland/go-roster.Roster` instance used to maintain a list of registered `Provider` initialization functions.
+var providers roster.Roster
+
+// ProviderInitializationFunc is a function used to initialize an implementation of the `Provider` interface.
+type ProviderInitializationFunc func(ctx context.Context, uri string) (Provider, error)
+
+// NewProvider returns a new `Provider` instance for the secret defined by 'uri', which takes the form of:
+//
+//	secrets://{PROVIDER}/{PATH}?{PARAMETERS}
+//
+// The semantics of {PATH} and {PARAMETERS} are specific to the package implementing the provider.
+func NewProvider(ctx context.Context, uri string) (Provider, error) {
+
+	err := ensureProviderRoster()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to ensure provider roster, %w", err)
+	}
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	if u.Scheme != SCHEME {
+		return nil, fmt.Errorf("Invalid scheme '%s'", u.Scheme)
+	}
+
+	i, err := providers.Driver(ctx, u.Host)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to find initialization function for '%s', %w", u.Host, err)
+	}
+
+	init_func := i.(ProviderInitializationFunc)
+	return init_func(ctx, uri)
+}
+
+// RegisterProvider associates 'name' with 'init_func' in an internal list of avilable `Provider` implementations.
+func RegisterProvider(ctx context.Context, name string, init_func ProviderInitializationFunc) error {
+
+	err := ensureProviderRoster()
+
+	if err != nil {
+		return fmt.Errorf("Failed to ensure provider roster, %w", err)
+	}
+
+	return providers.Register(ctx, name, init_func)
+}
+
+// ensureProviderRoster ensures that a `aaronland/go-roster.Roster` instance used to maintain a list of registered `Provider`
+// initialization functions is present
+func ensureProviderRoster() error {
+
+	if providers == nil {
+
+		r, err := roster.NewDefaultRoster()
+
+		if err != nil {
+			return fmt.Errorf("Failed to create new roster, %w", err)
+		}
+
+		providers = r
+	}
+
+	return nil
+}
+
+// Providers returns the list of provider names that have been "registered".
+func Providers() []string {
+	ctx := context.Background()
+	drivers := providers.Drivers(ctx)
+
+	names := make([]string, len(drivers))
+
+	for idx, dr := range drivers {
+		names[idx] = strings.ToLower(dr)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// cachedSecret is a secret cached by a `Resolver`.
+type cachedSecret struct {
+	// provider is the `Provider` used to retrieve the secret.
+	provider Provider
+	// secret is the most recent value of the secret.
+	secret *Secret
+	// ttl is the amount of time the secret is cached for.
+	ttl time.Duration
+	// expires is the time after which the secret will be retrieved again.
+	expires time.Time
+}
+
+// Resolver resolves, and caches, the values of `secrets://` URIs.
+type Resolver struct {
+	// ttl is the default amount of time that secrets are cached for.
+	ttl time.Duration
+	// cache is the dictionary of cached secrets keyed by their URIs.
+	cache map[string]*cachedSecret
+	// mu is a `sync.Mutex` used to guard 'cache'.
+	mu *sync.Mutex
+}
+
+// DefaultResolver is the `Resolver` used by the package-level `Resolve` and `ResolveURI` methods.
+var DefaultResolver = NewResolver(DEFAULT_TTL)
+
+// NewResolver returns a new `Resolver` instance that caches secrets for 'ttl' unless their provider, or the `ttl` parameter of
+// a `secrets://` URI, specifies otherwise.
+func NewResolver(ttl time.Duration) *Resolver {
+
+	r := &Resolver{
+		ttl:   ttl,
+		cache: make(map[string]*cachedSecret),
+		mu:    new(sync.Mutex),
+	}
+
+	return r
+}
+
+// Resolve returns the value of the secret defined by the `secrets://` URI 'uri' using `DefaultResolver`.
+func Resolve(ctx context.Context, uri string) (string, error) {
+	return DefaultResolver.Resolve(ctx, uri)
+}
+
+// ResolveURI returns a copy of 'uri' with the `secrets://` URIs in its query parameters replaced by their values using
+// `DefaultResolver`.
+func ResolveURI(ctx context.Context, uri string) (string, error) {
+	return DefaultResolver.ResolveURI(ctx, uri)
+}
+
+// IsSecretURI returns a boolean value indicating whether 'v' is a `secrets://` URI.
+func IsSecretURI(v string) bool {
+	return strings.HasPrefix(v, SCHEME+"://")
+}
+
+// Resolve returns the value of the secret defined by the `secrets://` URI 'uri'. Values are cached until they expire. In addition
+// to the parameters for each provider, 'uri' may contain a `ttl={DURATION}` parameter defining how long its value is cached for.
+func (r *Resolver) Resolve(ctx context.Context, uri string) (string, error) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.cache[uri]
+
+	if ok && time.Now().Before(c.expires) {
+		return c.secret.Value, nil
+	}
+
+	if !ok {
+
+		u, err := url.Parse(uri)
+
+		if err != nil {
+			return "", fmt.Errorf("Failed to parse secret URI, %w", err)
+		}
+
+		ttl := time.Duration(0)
+
+		str_ttl := u.Query().Get("ttl")
+
+		if str_ttl != "" {
+
+			v, err := time.ParseDuration(str_ttl)
+
+			if err != nil {
+				return "", fmt.Errorf("Failed to parse ?ttl= parameter, %w", err)
+			}
+
+			ttl = v
+		}
+
+		p, err := NewProvider(ctx, uri)
+
+		if err != nil {
+			return "", fmt.Errorf("Failed to create secrets provider for '%s', %w", u.Host, err)
+		}
+
+		c = &cachedSecret{
+			provider: p,
+			ttl:      ttl,
+		}
+	}
+
+	s, err := c.provider.Secret(ctx)
+
+	if err != nil {
+		return "", fmt.Errorf("Failed to retrieve secret '%s', %w", redactURI(uri), err)
+	}
+
+	c.secret = s
+	c.expires = time.Now().Add(r.expiresIn(c))
+
+	r.cache[uri] = c
+	return s.Value, nil
+}
+
+// ResolveURI returns a copy of 'uri' with any `secrets://` URIs in its query parameters replaced by their values. Parameters may
+// contain a comma-separated list of values, some or all of which are `secrets://` URIs. If 'uri' does not contain any `secrets://`
+// URIs it is returned unchanged. `secrets://` URIs with more than one parameter of their own should be URL-escaped.
+func (r *Resolver) ResolveURI(ctx context.Context, uri string) (string, error) {
+
+	if !strings.Contains(uri, SCHEME+"://") && !strings.Contains(strings.ToLower(uri), SCHEME+"%3a%2f%2f") {
+		return uri, nil
+	}
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return "", fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+	changed := false
+
+	for k, values := range q {
+
+		for idx, v := range values {
+
+			parts := strings.Split(v, ",")
+
+			for i, p := range parts {
+
+				if !IsSecretURI(p) {
+					continue
+				}
+
+				value, err := r.Resolve(ctx, p)
+
+				if err != nil {
+					return "", fmt.Errorf("Failed to resolve ?%s= parameter, %w", k, err)
+				}
+
+				parts[i] = value
+				changed = true
+			}
+
+			values[idx] = strings.Join(parts, ",")
+		}
+	}
+
+	if !changed {
+		return uri, nil
+	}
+
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// Renew renews the leases for cached secrets that have used two thirds of their lifetime. Secrets whose leases can not be renewed
+// are removed from the cache so that they are retrieved again the next time they are resolved.
+func (r *Resolver) Renew(ctx context.Context) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+
+	for uri, c := range r.cache {
+
+		if !c.secret.Renewable || c.secret.LeaseID == "" {
+			continue
+		}
+
+		renewer, ok := c.provider.(Renewer)
+
+		if !ok {
+			continue
+		}
+
+		lifetime := r.expiresIn(c)
+
+		if c.expires.Sub(now) > lifetime/3 {
+			continue
+		}
+
+		d, err := renewer.Renew(ctx, c.secret)
+
+		if err != nil {
+			delete(r.cache, uri)
+			continue
+		}
+
+		c.secret.TTL = d
+		c.expires = now.Add(r.expiresIn(c))
+	}
+}
+
+// Start renews leases, using `Renew`, every `DEFAULT_RENEW_INTERVAL` until 'ctx' is cancelled or the returned function is
+// invoked.
+func (r *Resolver) Start(ctx context.Context) func() {
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan bool)
+
+	go func() {
+
+		defer close(done)
+
+		ticker := time.NewTicker(DEFAULT_RENEW_INTERVAL)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				r.Renew(ctx)
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// expiresIn returns the amount of time that 'c' is cached for.
+func (r *Resolver) expiresIn(c *cachedSecret) time.Duration {
+
+	switch {
+	case c.ttl > 0:
+		return c.ttl
+	case c.secret != nil && c.secret.TTL > 0:
+		return c.secret.TTL
+	default:
+		return r.ttl
+	}
+}
+
+// extractField returns the value of the property 'field' in the JSON-encoded dictionary 'value'. If 'field' is empty 'value' is
+// returned unchanged.
+func extractField(value string, field string) (string, error) {
+
+	if field == "" {
+		return value, nil
+	}
+
+	var data map[string]interface{}
+
+	err := json.Unmarshal([]byte(value), &data)
+
+	if err != nil {
+		return "", fmt.Errorf("Failed to decode secret as JSON, %w", err)
+	}
+
+	return fieldValue(data, field)
+}
+
+// fieldValue returns the string value of the property 'field' in 'data'.
+func fieldValue(data map[string]interface{}, field string) (string, error) {
+
+	v, ok := data[field]
+
+	if !ok {
+		return "", fmt.Errorf("Secret does not have a '%s' field", field)
+	}
+
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	default:
+		enc, err := json.Marshal(v)
+
+		if err != nil {
+			return "", fmt.Errorf("Failed to encode '%s' field, %w", field, err)
+		}
+
+		return string(enc), nil
+	}
+}
+
+// redactURI returns 'uri' without its query parameters, for use in error messages.
+func redactURI(uri string) string {
+
+	before, _, _ := strings.Cut(uri, "?")
+	return before
+}
@@ -0,0 +1,282 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testProvider is a `Provider` (and `Renewer`) used to test `Resolver`.
+type testProvider struct {
+	calls  int
+	renews int
+	fail   bool
+	secret *Secret
+}
+
+func (p *testProvider) Secret(ctx context.Context) (*Secret, error) {
+	p.calls += 1
+	s := *p.secret
+	return &s, nil
+}
+
+func (p *testProvider) Renew(ctx context.Context, s *Secret) (time.Duration, error) {
+
+	p.renews += 1
+
+	if p.fail {
+		return 0, errors.New("Lease expired")
+	}
+
+	return time.Hour, nil
+}
+
+func TestRegisterProvider(t *testing.T) {
+
+	ctx := context.Background()
+
+	err := RegisterProvider(ctx, "file", NewFileProvider)
+
+	if err == nil {
+		t.Fatalf("Expected NewFileProvider to be registered already")
+	}
+
+	names := strings.Join(Providers(), ",")
+
+	if names != "aws,file,gcp,vault" {
+		t.Fatalf("Unexpected providers: %s", names)
+	}
+}
+
+func TestNewProvider(t *testing.T) {
+
+	ctx := context.Background()
+
+	_, err := NewProvider(ctx, "secrets://file/run/secrets/token")
+
+	if err != nil {
+		t.Fatalf("Failed to create provider, %v", err)
+	}
+
+	for _, uri := range []string{"file:///run/secrets/token", "secrets://bogus/token"} {
+
+		_, err := NewProvider(ctx, uri)
+
+		if err == nil {
+			t.Fatalf("Expected '%s' to fail", uri)
+		}
+	}
+}
+
+func TestResolverCache(t *testing.T) {
+
+	ctx := context.Background()
+
+	r := NewResolver(time.Hour)
+
+	p := &testProvider{secret: &Secret{Value: "s3cret"}}
+
+	uri := "secrets://test/token"
+	r.cache[uri] = &cachedSecret{provider: p}
+
+	for i := 0; i < 3; i++ {
+
+		v, err := r.Resolve(ctx, uri)
+
+		if err != nil {
+			t.Fatalf("Failed to resolve secret, %v", err)
+		}
+
+		if v != "s3cret" {
+			t.Fatalf("Unexpected value: %s", v)
+		}
+	}
+
+	if p.calls != 1 {
+		t.Fatalf("Expected secret to be retrieved once, not %d times", p.calls)
+	}
+
+	r.cache[uri].expires = time.Now().Add(-time.Second)
+
+	_, err := r.Resolve(ctx, uri)
+
+	if err != nil {
+		t.Fatalf("Failed to resolve secret, %v", err)
+	}
+
+	if p.calls != 2 {
+		t.Fatalf("Expected expired secret to be retrieved again")
+	}
+}
+
+func TestResolverTTL(t *testing.T) {
+
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "token")
+
+	err := os.WriteFile(path, []byte("s3cret\n"), 0600)
+
+	if err != nil {
+		t.Fatalf("Failed to write secret, %v", err)
+	}
+
+	r := NewResolver(time.Hour)
+
+	uri := fmt.Sprintf("secrets://file%s?ttl=1ns", path)
+
+	v, err := r.Resolve(ctx, uri)
+
+	if err != nil {
+		t.Fatalf("Failed to resolve secret, %v", err)
+	}
+
+	if v != "s3cret" {
+		t.Fatalf("Unexpected value: %s", v)
+	}
+
+	err = os.WriteFile(path, []byte("rotated"), 0600)
+
+	if err != nil {
+		t.Fatalf("Failed to write secret, %v", err)
+	}
+
+	time.Sleep(time.Millisecond)
+
+	v, err = r.Resolve(ctx, uri)
+
+	if err != nil {
+		t.Fatalf("Failed to resolve secret, %v", err)
+	}
+
+	if v != "rotated" {
+		t.Fatalf("Expected rotated secret, got %s", v)
+	}
+
+	_, err = r.Resolve(ctx, "secrets://file/tmp/token?ttl=soon")
+
+	if err == nil {
+		t.Fatalf("Expected invalid ?ttl= parameter to fail")
+	}
+}
+
+func TestResolveURI(t *testing.T) {
+
+	ctx := context.Background()
+
+	dir := t.TempDir()
+
+	for name, value := range map[string]string{"a": "secret-a", "b": "secret-b", "c": `{"key":"secret-c","other":"x"}`} {
+
+		err := os.WriteFile(filepath.Join(dir, name), []byte(value), 0600)
+
+		if err != nil {
+			t.Fatalf("Failed to write secret, %v", err)
+		}
+	}
+
+	r := NewResolver(time.Hour)
+
+	nested := url.QueryEscape(fmt.Sprintf("secrets://file%s/c?field=key&ttl=1m", dir))
+
+	uri := fmt.Sprintf("github://?secret=secrets://file%s/a,old&token=secrets://file%s/b&key=%s&ref=main", dir, dir, nested)
+
+	v, err := r.ResolveURI(ctx, uri)
+
+	if err != nil {
+		t.Fatalf("Failed to resolve URI, %v", err)
+	}
+
+	u, err := url.Parse(v)
+
+	if err != nil {
+		t.Fatalf("Failed to parse resolved URI, %v", err)
+	}
+
+	q := u.Query()
+
+	expected := map[string]string{
+		"secret": "secret-a,old",
+		"token":  "secret-b",
+		"key":    "secret-c",
+		"ref":    "main",
+	}
+
+	for k, e := range expected {
+
+		if q.Get(k) != e {
+			t.Fatalf("Unexpected value for ?%s= parameter: %s", k, q.Get(k))
+		}
+	}
+
+	plain := "github://?secret=s3cret&ref=main"
+
+	v, err = r.ResolveURI(ctx, plain)
+
+	if err != nil {
+		t.Fatalf("Failed to resolve URI, %v", err)
+	}
+
+	if v != plain {
+		t.Fatalf("Expected URI without secrets to be unchanged, got %s", v)
+	}
+
+	_, err = r.ResolveURI(ctx, "github://?secret=secrets://file/does/not/exist%3Ffield=password")
+
+	if err == nil {
+		t.Fatalf("Expected missing secret to fail")
+	}
+
+	if strings.Contains(err.Error(), "password") {
+		t.Fatalf("Expected error to omit secret parameters, %v", err)
+	}
+}
+
+func TestResolverRenew(t *testing.T) {
+
+	ctx := context.Background()
+
+	r := NewResolver(time.Hour)
+
+	now := time.Now()
+
+	renewable := &testProvider{secret: &Secret{Value: "a", LeaseID: "lease/a", Renewable: true, TTL: time.Minute}}
+	fresh := &testProvider{secret: &Secret{Value: "b", LeaseID: "lease/b", Renewable: true, TTL: time.Minute}}
+	expired := &testProvider{fail: true, secret: &Secret{Value: "c", LeaseID: "lease/c", Renewable: true, TTL: time.Minute}}
+	static := &testProvider{secret: &Secret{Value: "d"}}
+
+	r.cache["secrets://test/a"] = &cachedSecret{provider: renewable, secret: renewable.secret, expires: now.Add(10 * time.Second)}
+	r.cache["secrets://test/b"] = &cachedSecret{provider: fresh, secret: fresh.secret, expires: now.Add(55 * time.Second)}
+	r.cache["secrets://test/c"] = &cachedSecret{provider: expired, secret: expired.secret, expires: now.Add(10 * time.Second)}
+	r.cache["secrets://test/d"] = &cachedSecret{provider: static, secret: static.secret, expires: now.Add(time.Second)}
+
+	r.Renew(ctx)
+
+	if renewable.renews != 1 {
+		t.Fatalf("Expected lease to be renewed")
+	}
+
+	if r.cache["secrets://test/a"].expires.Before(now.Add(50 * time.Minute)) {
+		t.Fatalf("Expected renewed lease to be extended")
+	}
+
+	if fresh.renews != 0 {
+		t.Fatalf("Expected fresh lease not to be renewed")
+	}
+
+	if static.renews != 0 {
+		t.Fatalf("Expected secret without a lease not to be renewed")
+	}
+
+	_, ok := r.cache["secrets://test/c"]
+
+	if ok {
+		t.Fatalf("Expected secret whose lease could not be renewed to be evicted")
+	}
+}
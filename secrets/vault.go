@@ -0,0 +1,210 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// DEFAULT_VAULT_ADDR is the default address of the HashiCorp Vault server if neither the `addr` parameter or the `VAULT_ADDR`
+// environment variable are set.
+const DEFAULT_VAULT_ADDR string = "http://127.0.0.1:8200"
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterProvider(ctx, "vault", NewVaultProvider)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// vaultResponse is the subset of a HashiCorp Vault secret response used by `VaultProvider`.
+type vaultResponse struct {
+	LeaseID       string                 `json:"lease_id"`
+	LeaseDuration int64                  `json:"lease_duration"`
+	Renewable     bool                   `json:"renewable"`
+	Data          map[string]interface{} `json:"data"`
+}
+
+// VaultProvider implements the `Provider` interface for secrets stored in HashiCorp Vault.
+type VaultProvider struct {
+	Provider
+	// addr is the address of the Vault server.
+	addr string
+	// path is the path of the secret, relative to the "/v1/" API prefix.
+	path string
+	// field is the name of the field in the secret's data to return.
+	field string
+	// token is the Vault token used to authenticate requests.
+	token string
+	// namespace is the optional Vault Enterprise namespace for requests.
+	namespace string
+	// client is the `http.Client` used to send requests.
+	client *http.Client
+}
+
+// NewVaultProvider returns a new `VaultProvider` instance configured by 'uri' in the form of:
+//
+//	secrets://vault/{PATH}?{PARAMETERS}
+//
+// Where {PATH} is the path of the secret, for example "secret/data/webhookd" for a KV version 2 secrets engine mounted at
+// "secret". Valid {PARAMETERS} are:
+// * `field={STRING}` The name of the field in the secret's data to return. Required.
+// * `addr={URL}` The address of the Vault server. Optional; if empty the `VAULT_ADDR` environment variable is used and then
+// "http://127.0.0.1:8200".
+//
+// Requests are authenticated using the token in the `VAULT_TOKEN` environment variable and, if set, sent to the Vault Enterprise
+// namespace in the `VAULT_NAMESPACE` environment variable. Secrets from KV version 2 engines are unwrapped automatically. Leases
+// for dynamic secrets are renewed by the `Resolver`.
+func NewVaultProvider(ctx context.Context, uri string) (Provider, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	path := strings.Trim(u.Path, "/")
+
+	if path == "" {
+		return nil, fmt.Errorf("Missing secret path")
+	}
+
+	q := u.Query()
+
+	field := q.Get("field")
+
+	if field == "" {
+		return nil, fmt.Errorf("Missing ?field= parameter")
+	}
+
+	addr := q.Get("addr")
+
+	if addr == "" {
+		addr = os.Getenv("VAULT_ADDR")
+	}
+
+	if addr == "" {
+		addr = DEFAULT_VAULT_ADDR
+	}
+
+	p := &VaultProvider{
+		addr:      strings.TrimRight(addr, "/"),
+		path:      path,
+		field:     field,
+		token:     os.Getenv("VAULT_TOKEN"),
+		namespace: os.Getenv("VAULT_NAMESPACE"),
+		client:    &http.Client{Timeout: 10 * time.Second},
+	}
+
+	return p, nil
+}
+
+// Secret returns the value of the field for 'p' in the Vault secret for 'p'.
+func (p *VaultProvider) Secret(ctx context.Context) (*Secret, error) {
+
+	var vault_rsp *vaultResponse
+
+	err := p.do(ctx, http.MethodGet, "/v1/"+p.path, nil, &vault_rsp)
+
+	if err != nil {
+		return nil, err
+	}
+
+	data := vault_rsp.Data
+
+	// KV version 2 secrets are wrapped in a nested "data" property
+
+	nested, ok := data["data"].(map[string]interface{})
+
+	if ok {
+		data = nested
+	}
+
+	value, err := fieldValue(data, p.field)
+
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Secret{
+		Value:     value,
+		TTL:       time.Duration(vault_rsp.LeaseDuration) * time.Second,
+		LeaseID:   vault_rsp.LeaseID,
+		Renewable: vault_rsp.Renewable,
+	}
+
+	return s, nil
+}
+
+// Renew renews the Vault lease for 's', returning the new duration of the lease.
+func (p *VaultProvider) Renew(ctx context.Context, s *Secret) (time.Duration, error) {
+
+	body, err := json.Marshal(map[string]string{"lease_id": s.LeaseID})
+
+	if err != nil {
+		return 0, fmt.Errorf("Failed to encode renewal request, %w", err)
+	}
+
+	var vault_rsp *vaultResponse
+
+	err = p.do(ctx, http.MethodPut, "/v1/sys/leases/renew", body, &vault_rsp)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(vault_rsp.LeaseDuration) * time.Second, nil
+}
+
+// do sends a request with 'method' and 'body' to 'path' on the Vault server for 'p' and decodes the response in to 'target'.
+func (p *VaultProvider) do(ctx context.Context, method string, path string, body []byte, target interface{}) error {
+
+	req, err := http.NewRequestWithContext(ctx, method, p.addr+path, bytes.NewReader(body))
+
+	if err != nil {
+		return fmt.Errorf("Failed to create Vault request, %w", err)
+	}
+
+	if p.token != "" {
+		req.Header.Set("X-Vault-Token", p.token)
+	}
+
+	if p.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", p.namespace)
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	rsp, err := p.client.Do(req)
+
+	if err != nil {
+		return fmt.Errorf("Failed to send Vault request, %w", err)
+	}
+
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, rsp.Body)
+		return fmt.Errorf("Vault request failed, %s", rsp.Status)
+	}
+
+	err = json.NewDecoder(rsp.Body).Decode(target)
+
+	if err != nil {
+		return fmt.Errorf("Failed to decode Vault response, %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,136 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestVaultProvider(t *testing.T) {
+
+	ctx := context.Background()
+
+	renewed := ""
+
+	handler := func(rsp http.ResponseWriter, req *http.Request) {
+
+		if req.Header.Get("X-Vault-Token") != "t0ken" {
+			http.Error(rsp, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		var body interface{}
+
+		switch {
+		case req.Method == http.MethodGet && req.URL.Path == "/v1/secret/data/webhookd":
+			body = map[string]interface{}{
+				"data": map[string]interface{}{
+					"data":     map[string]interface{}{"github": "s3cret"},
+					"metadata": map[string]interface{}{"version": 2},
+				},
+			}
+		case req.Method == http.MethodGet && req.URL.Path == "/v1/database/creds/webhookd":
+			body = map[string]interface{}{
+				"lease_id":       "database/creds/webhookd/abc",
+				"lease_duration": 3600,
+				"renewable":      true,
+				"data":           map[string]interface{}{"password": "p4ssword"},
+			}
+		case req.Method == http.MethodPut && req.URL.Path == "/v1/sys/leases/renew":
+
+			var renew_req map[string]string
+			json.NewDecoder(req.Body).Decode(&renew_req)
+			renewed = renew_req["lease_id"]
+
+			body = map[string]interface{}{
+				"lease_id":       renewed,
+				"lease_duration": 7200,
+				"renewable":      true,
+			}
+		default:
+			http.Error(rsp, "Not found", http.StatusNotFound)
+			return
+		}
+
+		json.NewEncoder(rsp).Encode(body)
+	}
+
+	svr := httptest.NewServer(http.HandlerFunc(handler))
+	defer svr.Close()
+
+	t.Setenv("VAULT_ADDR", svr.URL)
+	t.Setenv("VAULT_TOKEN", "t0ken")
+
+	p, err := NewVaultProvider(ctx, "secrets://vault/secret/data/webhookd?field=github")
+
+	if err != nil {
+		t.Fatalf("Failed to create provider, %v", err)
+	}
+
+	s, err := p.Secret(ctx)
+
+	if err != nil {
+		t.Fatalf("Failed to retrieve secret, %v", err)
+	}
+
+	if s.Value != "s3cret" || s.Renewable {
+		t.Fatalf("Unexpected secret: %v", s)
+	}
+
+	p, err = NewVaultProvider(ctx, fmt.Sprintf("secrets://vault/database/creds/webhookd?field=password&addr=%s", svr.URL))
+
+	if err != nil {
+		t.Fatalf("Failed to create provider, %v", err)
+	}
+
+	s, err = p.Secret(ctx)
+
+	if err != nil {
+		t.Fatalf("Failed to retrieve secret, %v", err)
+	}
+
+	if s.Value != "p4ssword" || s.TTL != time.Hour || !s.Renewable || s.LeaseID != "database/creds/webhookd/abc" {
+		t.Fatalf("Unexpected secret: %v", s)
+	}
+
+	d, err := p.(Renewer).Renew(ctx, s)
+
+	if err != nil {
+		t.Fatalf("Failed to renew lease, %v", err)
+	}
+
+	if d != 2*time.Hour || renewed != s.LeaseID {
+		t.Fatalf("Unexpected renewal, %v (%s)", d, renewed)
+	}
+
+	for _, uri := range []string{
+		"secrets://vault/secret/data/webhookd?field=gitlab",
+		"secrets://vault/secret/data/missing?field=github",
+	} {
+
+		p, err := NewVaultProvider(ctx, uri)
+
+		if err != nil {
+			t.Fatalf("Failed to create provider for '%s', %v", uri, err)
+		}
+
+		_, err = p.Secret(ctx)
+
+		if err == nil {
+			t.Fatalf("Expected '%s' to fail", uri)
+		}
+	}
+
+	for _, uri := range []string{"secrets://vault/?field=github", "secrets://vault/secret/data/webhookd"} {
+
+		_, err := NewVaultProvider(ctx, uri)
+
+		if err == nil {
+			t.Fatalf("Expected '%s' to fail", uri)
+		}
+	}
+}
@@ -0,0 +1,460 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	aa_server "github.com/aaronland/go-http-server"
+	aa_log "github.com/aaronland/go-log/v2"
+)
+
+func init() {
+
+	ctx := context.Background()
+	err := aa_server.RegisterServer(ctx, "handoff", NewHandoffServer)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// HANDOFF_LISTENER_ENV is the name of the environment variable containing the file descriptor of the listener inherited by
+// a process started by `HandoffServer` during an upgrade.
+const HANDOFF_LISTENER_ENV string = "WEBHOOKD_HANDOFF_LISTENER_FD"
+
+// HANDOFF_READY_ENV is the name of the environment variable containing the file descriptor that a process started by
+// `HandoffServer` during an upgrade writes to once it is ready to accept requests.
+const HANDOFF_READY_ENV string = "WEBHOOKD_HANDOFF_READY_FD"
+
+// HandoffServer implements the `aaronland/go-http-server.Server` interface for a server that can hand its listening socket off
+// to a new process, for example after the `webhookd` binary has been upgraded, without refusing connections or dropping
+// in-flight requests. When the process receives a SIGHUP signal it starts a new copy of its (current) executable, with the same
+// arguments and environment, which inherits the listening socket. Once the new process is accepting requests the old process
+// stops accepting requests, waits for in-flight requests to complete and exits. If the new process fails to start, or is not
+// ready in time, it is stopped and the old process carries on as before.
+type HandoffServer struct {
+	aa_server.Server
+	http_server     *httpServer
+	address         string
+	reuseport       bool
+	cert            string
+	key             string
+	pid_file        string
+	upgrade_timeout time.Duration
+	drain_timeout   time.Duration
+	logger          *log.Logger
+	// command returns the `exec.Cmd` used to start the new process during an upgrade.
+	command func() (*exec.Cmd, error)
+}
+
+// NewHandoffServer returns a new `HandoffServer` instance configured by 'uri' in the form of:
+//
+//	handoff://{HOST}:{PORT}?{PARAMETERS}
+//
+// Where {HOST} and {PORT} are the address and port to listen for requests on. Valid parameters are:
+// * `reuseport={BOOLEAN}` Set the SO_REUSEPORT option on the listening socket so that other processes can listen on the same
+// address and port at the same time, for example when a new process is started by a process supervisor before the old one is
+// stopped. Not supported on Windows. Default is false.
+// * `pid_file={PATH}` The path of a file that the process ID of the process accepting requests is written to, so that scripts
+// know which process to signal. It is updated by each new process during an upgrade.
+// * `upgrade_timeout={SECONDS}` The amount of time to wait for a new process to be ready to accept requests. Default is 30 seconds.
+// * `drain_timeout={SECONDS}` The amount of time to wait for in-flight requests to complete before exiting. Default is 60 seconds.
+// * `cert={PATH}` The path of a TLS certificate. If present requests are served over HTTPS. Requires `key`.
+// * `key={PATH}` The path of a TLS key. Requires `cert`.
+// * `read_timeout={SECONDS}` A custom setting for HTTP read timeouts. Default is 2 seconds.
+// * `write_timeout={SECONDS}` A custom setting for HTTP write timeouts. Default is 10 seconds.
+// * `idle_timeout={SECONDS}` A custom setting for HTTP idle timeouts. Default is 15 seconds.
+// * `header_timeout={SECONDS}` A custom setting for HTTP header timeouts. Default is 2 seconds.
+// * `max_header_bytes={INT}` The maximum size, in bytes, of the request headers. Default is 1048576.
+// * `http2={BOOLEAN}` Serve HTTP/2 requests over TLS. Default is true.
+// * `h2c={BOOLEAN}` Serve HTTP/2 requests without TLS ("h2c"). Default is false.
+// * `http2_max_concurrent_streams={INT}` The maximum number of concurrent HTTP/2 streams for each client connection. Default is 250.
+func NewHandoffServer(ctx context.Context, uri string) (aa_server.Server, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	if u.Port() == "" {
+		return nil, fmt.Errorf("Missing port")
+	}
+
+	q := u.Query()
+
+	http_server, err := newHTTPServer(q)
+
+	if err != nil {
+		return nil, err
+	}
+
+	s := &HandoffServer{
+		http_server:     http_server,
+		address:         u.Host,
+		cert:            q.Get("cert"),
+		key:             q.Get("key"),
+		pid_file:        q.Get("pid_file"),
+		upgrade_timeout: 30 * time.Second,
+		drain_timeout:   60 * time.Second,
+		logger:          log.Default(),
+		command:         newHandoffCommand,
+	}
+
+	if (s.cert == "") != (s.key == "") {
+		return nil, fmt.Errorf("TLS certificates require both ?cert and ?key parameters")
+	}
+
+	if q.Get("reuseport") != "" {
+
+		v, err := strconv.ParseBool(q.Get("reuseport"))
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid ?reuseport parameter, %w", err)
+		}
+
+		if v && !reusePortSupported {
+			return nil, fmt.Errorf("The ?reuseport parameter is not supported on this platform")
+		}
+
+		s.reuseport = v
+	}
+
+	timeouts := map[string]*time.Duration{
+		"upgrade_timeout": &s.upgrade_timeout,
+		"drain_timeout":   &s.drain_timeout,
+	}
+
+	for k, ptr := range timeouts {
+
+		str_v := q.Get(k)
+
+		if str_v == "" {
+			continue
+		}
+
+		v, err := strconv.Atoi(str_v)
+
+		if err != nil || v <= 0 {
+			return nil, fmt.Errorf("Invalid ?%s parameter '%s'", k, str_v)
+		}
+
+		*ptr = time.Duration(v) * time.Second
+	}
+
+	return s, nil
+}
+
+// Address returns the fully-qualified URI where the server can be contacted.
+func (s *HandoffServer) Address() string {
+
+	scheme := "http"
+
+	if s.cert != "" {
+		scheme = "https"
+	}
+
+	return fmt.Sprintf("%s://%s", scheme, s.address)
+}
+
+// ListenAndServe listens for requests, using 'mux' for routing, until 'ctx' is cancelled, the process receives an interrupt or
+// SIGTERM signal or the listening socket has been handed off to a new process after a SIGHUP signal. In all cases in-flight
+// requests are allowed to complete before returning.
+func (s *HandoffServer) ListenAndServe(ctx context.Context, mux http.Handler) error {
+
+	l, inherited, err := s.listen(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	s.http_server.setHandler(mux)
+
+	err_ch := make(chan error, 1)
+
+	go func() {
+
+		var err error
+
+		if s.cert != "" {
+			err = s.http_server.ServeTLS(l, s.cert, s.key)
+		} else {
+			err = s.http_server.Serve(l)
+		}
+
+		if err != nil && err != http.ErrServerClosed {
+			err_ch <- err
+		}
+	}()
+
+	if s.pid_file != "" {
+
+		err := os.WriteFile(s.pid_file, []byte(strconv.Itoa(os.Getpid())+"\n"), 0644)
+
+		if err != nil {
+			aa_log.Warning(s.logger, "Failed to write PID file, %v", err)
+		}
+	}
+
+	// Let the process that started this one know that it can stop accepting requests
+
+	if inherited {
+
+		err := signalHandoffReady()
+
+		if err != nil {
+			aa_log.Warning(s.logger, "Failed to signal that handoff is complete, %v", err)
+		}
+	}
+
+	sig_ch := make(chan os.Signal, 1)
+	signal.Notify(sig_ch, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+
+	defer signal.Stop(sig_ch)
+
+	var serve_err error
+
+loop:
+	for {
+
+		select {
+		case <-ctx.Done():
+			break loop
+		case serve_err = <-err_ch:
+			break loop
+		case sig := <-sig_ch:
+
+			if sig != syscall.SIGHUP {
+				break loop
+			}
+
+			aa_log.Info(s.logger, "Starting new process to hand off listener for %s", s.Address())
+
+			err := s.upgrade(ctx, l)
+
+			if err != nil {
+				aa_log.Error(s.logger, "Failed to hand off listener, continuing to accept requests, %v", err)
+				continue
+			}
+
+			aa_log.Info(s.logger, "Handed off listener to new process, waiting for in-flight requests to complete")
+			break loop
+		}
+	}
+
+	shutdown_ctx, cancel := context.WithTimeout(context.Background(), s.drain_timeout)
+	defer cancel()
+
+	err = s.http_server.Shutdown(shutdown_ctx)
+
+	if serve_err != nil {
+		return serve_err
+	}
+
+	if err != nil {
+		return fmt.Errorf("Failed to shut down server, %w", err)
+	}
+
+	return nil
+}
+
+// listen returns the listener for 's', and a boolean value indicating whether it was inherited from the process that started
+// this one, or a new listener if there is nothing to inherit.
+func (s *HandoffServer) listen(ctx context.Context) (net.Listener, bool, error) {
+
+	str_fd := os.Getenv(HANDOFF_LISTENER_ENV)
+
+	if str_fd != "" {
+
+		fd, err := strconv.Atoi(str_fd)
+
+		if err != nil {
+			return nil, false, fmt.Errorf("Invalid %s environment variable, %w", HANDOFF_LISTENER_ENV, err)
+		}
+
+		f := os.NewFile(uintptr(fd), "listener")
+		defer f.Close()
+
+		l, err := net.FileListener(f)
+
+		if err != nil {
+			return nil, false, fmt.Errorf("Failed to create inherited listener, %w", err)
+		}
+
+		os.Unsetenv(HANDOFF_LISTENER_ENV)
+		return l, true, nil
+	}
+
+	lc := &net.ListenConfig{}
+
+	if s.reuseport {
+		lc.Control = reusePort
+	}
+
+	l, err := lc.Listen(ctx, "tcp", s.address)
+
+	if err != nil {
+		return nil, false, fmt.Errorf("Failed to listen on %s, %w", s.address, err)
+	}
+
+	return l, false, nil
+}
+
+// upgrade starts a new process which inherits 'l' and waits until it is ready to accept requests. If the new process exits,
+// or is not ready before the upgrade timeout for 's', it is stopped and an error is returned.
+func (s *HandoffServer) upgrade(ctx context.Context, l net.Listener) error {
+
+	fl, ok := l.(interface{ File() (*os.File, error) })
+
+	if !ok {
+		return fmt.Errorf("Listener (%T) can not be handed off", l)
+	}
+
+	f, err := fl.File()
+
+	if err != nil {
+		return fmt.Errorf("Failed to derive file for listener, %w", err)
+	}
+
+	defer f.Close()
+
+	r, w, err := os.Pipe()
+
+	if err != nil {
+		return fmt.Errorf("Failed to create pipe, %w", err)
+	}
+
+	defer r.Close()
+
+	cmd, err := s.command()
+
+	if err != nil {
+		w.Close()
+		return fmt.Errorf("Failed to create command, %w", err)
+	}
+
+	// ExtraFiles are assigned file descriptors starting at 3
+
+	cmd.ExtraFiles = []*os.File{f, w}
+	cmd.Env = append(handoffEnviron(cmd.Env), HANDOFF_LISTENER_ENV+"=3", HANDOFF_READY_ENV+"=4")
+
+	err = cmd.Start()
+
+	w.Close()
+
+	if err != nil {
+		return fmt.Errorf("Failed to start new process, %w", err)
+	}
+
+	// The read fails with io.EOF if the new process exits, closing its end of the pipe, before it is ready
+
+	ready_ch := make(chan error, 1)
+
+	go func() {
+		b := make([]byte, 1)
+		_, err := r.Read(b)
+		ready_ch <- err
+	}()
+
+	timer := time.NewTimer(s.upgrade_timeout)
+	defer timer.Stop()
+
+	var upgrade_err error
+
+	select {
+	case err := <-ready_ch:
+
+		if err != nil {
+			upgrade_err = fmt.Errorf("New process exited before it was ready, %w", err)
+		}
+
+	case <-timer.C:
+		upgrade_err = fmt.Errorf("Timed out waiting for new process to be ready")
+	case <-ctx.Done():
+		upgrade_err = ctx.Err()
+	}
+
+	if upgrade_err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return upgrade_err
+	}
+
+	return cmd.Process.Release()
+}
+
+// newHandoffCommand returns an `exec.Cmd` instance that starts a new copy of the current executable with the same arguments,
+// standard input and output and environment as the current process.
+func newHandoffCommand() (*exec.Cmd, error) {
+
+	exe, err := os.Executable()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to determine executable, %w", err)
+	}
+
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = os.Environ()
+
+	return cmd, nil
+}
+
+// handoffEnviron returns a copy of 'env', or the environment of the current process if it is nil, without any of the handoff
+// environment variables.
+func handoffEnviron(env []string) []string {
+
+	if env == nil {
+		env = os.Environ()
+	}
+
+	handoff_env := make([]string, 0, len(env))
+
+	for _, kv := range env {
+
+		if strings.HasPrefix(kv, HANDOFF_LISTENER_ENV+"=") || strings.HasPrefix(kv, HANDOFF_READY_ENV+"=") {
+			continue
+		}
+
+		handoff_env = append(handoff_env, kv)
+	}
+
+	return handoff_env
+}
+
+// signalHandoffReady notifies the process that started this one, if any, that it is ready to accept requests.
+func signalHandoffReady() error {
+
+	str_fd := os.Getenv(HANDOFF_READY_ENV)
+
+	if str_fd == "" {
+		return nil
+	}
+
+	os.Unsetenv(HANDOFF_READY_ENV)
+
+	fd, err := strconv.Atoi(str_fd)
+
+	if err != nil {
+		return fmt.Errorf("Invalid %s environment variable, %w", HANDOFF_READY_ENV, err)
+	}
+
+	f := os.NewFile(uintptr(fd), "ready")
+	defer f.Close()
+
+	_, err = f.Write([]byte{1})
+	return err
+}
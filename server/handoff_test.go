@@ -0,0 +1,295 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// handoffChildEnv is the name of the environment variable used to run `TestHandoffChild` as the new process during an upgrade.
+const handoffChildEnv string = "WEBHOOKD_TEST_HANDOFF_CHILD"
+
+func TestNewHandoffServer(t *testing.T) {
+
+	ctx := context.Background()
+
+	s, err := NewHandoffServer(ctx, "handoff://localhost:8080?upgrade_timeout=5&drain_timeout=10")
+
+	if err != nil {
+		t.Fatalf("Failed to create handoff server, %v", err)
+	}
+
+	if s.Address() != "http://localhost:8080" {
+		t.Fatalf("Unexpected address '%s'", s.Address())
+	}
+
+	hs := s.(*HandoffServer)
+
+	if hs.upgrade_timeout != 5*time.Second || hs.drain_timeout != 10*time.Second {
+		t.Fatalf("Unexpected timeouts %v, %v", hs.upgrade_timeout, hs.drain_timeout)
+	}
+
+	for _, uri := range []string{"handoff://localhost", "handoff://localhost:8080?reuseport=bogus", "handoff://localhost:8080?drain_timeout=0", "handoff://localhost:8080?cert=cert.pem"} {
+
+		_, err := NewHandoffServer(ctx, uri)
+
+		if err == nil {
+			t.Fatalf("Expected '%s' to fail", uri)
+		}
+	}
+}
+
+func TestHandoffEnviron(t *testing.T) {
+
+	env := []string{
+		"HOME=/root",
+		HANDOFF_LISTENER_ENV + "=3",
+		HANDOFF_READY_ENV + "=4",
+	}
+
+	handoff_env := handoffEnviron(env)
+
+	if len(handoff_env) != 1 || handoff_env[0] != "HOME=/root" {
+		t.Fatalf("Unexpected environment %v", handoff_env)
+	}
+}
+
+func TestHandoffServerReusePort(t *testing.T) {
+
+	if !reusePortSupported {
+		t.Skip("SO_REUSEPORT is not supported")
+	}
+
+	ctx := context.Background()
+
+	addr := freeAddress(t)
+
+	uri := fmt.Sprintf("handoff://%s?reuseport=true", addr)
+
+	listeners := make([]net.Listener, 2)
+
+	for i := range listeners {
+
+		s, err := NewHandoffServer(ctx, uri)
+
+		if err != nil {
+			t.Fatalf("Failed to create handoff server, %v", err)
+		}
+
+		l, _, err := s.(*HandoffServer).listen(ctx)
+
+		if err != nil {
+			t.Fatalf("Failed to listen on %s with SO_REUSEPORT, %v", addr, err)
+		}
+
+		defer l.Close()
+		listeners[i] = l
+	}
+}
+
+func TestHandoffServerUpgrade(t *testing.T) {
+
+	if runtime.GOOS == "windows" {
+		t.Skip("Listener handoff is not supported on Windows")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	addr := freeAddress(t)
+	pid_file := filepath.Join(t.TempDir(), "webhookd.pid")
+
+	uri := fmt.Sprintf("handoff://%s?pid_file=%s&upgrade_timeout=10", addr, pid_file)
+
+	s, err := NewHandoffServer(ctx, uri)
+
+	if err != nil {
+		t.Fatalf("Failed to create handoff server, %v", err)
+	}
+
+	hs := s.(*HandoffServer)
+
+	hs.command = func() (*exec.Cmd, error) {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestHandoffChild$")
+		cmd.Env = append(os.Environ(), handoffChildEnv+"="+uri)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		return cmd, nil
+	}
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/", func(rsp http.ResponseWriter, req *http.Request) {
+		rsp.Write([]byte("parent"))
+	})
+
+	mux.HandleFunc("/slow", func(rsp http.ResponseWriter, req *http.Request) {
+		time.Sleep(500 * time.Millisecond)
+		rsp.Write([]byte("parent"))
+	})
+
+	done_ch := make(chan error, 1)
+
+	go func() {
+		done_ch <- s.ListenAndServe(ctx, mux)
+	}()
+
+	url := s.Address()
+
+	waitForBody(t, url+"/", "parent")
+
+	slow_ch := make(chan string, 1)
+
+	go func() {
+		slow_ch <- get(url + "/slow")
+	}()
+
+	// Give the slow request time to be accepted by the old process
+
+	time.Sleep(100 * time.Millisecond)
+
+	p, err := os.FindProcess(os.Getpid())
+
+	if err != nil {
+		t.Fatalf("Failed to find current process, %v", err)
+	}
+
+	err = p.Signal(syscall.SIGHUP)
+
+	if err != nil {
+		t.Fatalf("Failed to send SIGHUP, %v", err)
+	}
+
+	select {
+	case err := <-done_ch:
+
+		if err != nil {
+			t.Fatalf("Server returned an error after handoff, %v", err)
+		}
+
+	case <-ctx.Done():
+		t.Fatalf("Timed out waiting for handoff")
+	}
+
+	slow_body := <-slow_ch
+
+	if slow_body != "parent" {
+		t.Fatalf("Expected in-flight request to complete but got '%s'", slow_body)
+	}
+
+	// The new process exits after serving this request
+
+	body := get(url + "/")
+
+	if body != "child" {
+		t.Fatalf("Expected request to be handled by new process but got '%s'", body)
+	}
+
+	pid, err := os.ReadFile(pid_file)
+
+	if err != nil {
+		t.Fatalf("Failed to read PID file, %v", err)
+	}
+
+	if strings.TrimSpace(string(pid)) == fmt.Sprintf("%d", os.Getpid()) {
+		t.Fatalf("Expected PID file to be updated by new process")
+	}
+}
+
+// TestHandoffChild is run, as a separate process, by `TestHandoffServerUpgrade` as the new process that the listener is handed
+// off to. It serves a single request.
+func TestHandoffChild(t *testing.T) {
+
+	uri := os.Getenv(handoffChildEnv)
+
+	if uri == "" {
+		t.Skip("Only run as part of TestHandoffServerUpgrade")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	s, err := NewHandoffServer(ctx, uri)
+
+	if err != nil {
+		t.Fatalf("Failed to create handoff server, %v", err)
+	}
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/", func(rsp http.ResponseWriter, req *http.Request) {
+		rsp.Write([]byte("child"))
+		cancel()
+	})
+
+	err = s.ListenAndServe(ctx, mux)
+
+	if err != nil {
+		t.Fatalf("Failed to serve requests, %v", err)
+	}
+}
+
+// freeAddress returns a local address with a port that is not in use.
+func freeAddress(t *testing.T) string {
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatalf("Failed to find free port, %v", err)
+	}
+
+	defer l.Close()
+	return l.Addr().String()
+}
+
+// get returns the body of the response to a GET request for 'url' or the error message if the request fails.
+func get(url string) string {
+
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			DisableKeepAlives: true,
+		},
+	}
+
+	rsp, err := client.Get(url)
+
+	if err != nil {
+		return err.Error()
+	}
+
+	defer rsp.Body.Close()
+
+	body, err := io.ReadAll(rsp.Body)
+
+	if err != nil {
+		return err.Error()
+	}
+
+	return string(body)
+}
+
+// waitForBody waits for a GET request for 'url' to return 'expected'.
+func waitForBody(t *testing.T, url string, expected string) {
+
+	for i := 0; i < 50; i++ {
+
+		if get(url) == expected {
+			return
+		}
+
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	t.Fatalf("Timed out waiting for %s", url)
+}
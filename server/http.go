@@ -0,0 +1,168 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+
+	aa_server "github.com/aaronland/go-http-server"
+)
+
+// HTTPServer implements the `aaronland/go-http-server.Server` interface for a basic `net/http` server whose timeouts, header
+// limits and HTTP/2 settings are configurable. It is used for "http://" and "https://" daemon URIs (see `NewServer`).
+type HTTPServer struct {
+	aa_server.Server
+	http_server *httpServer
+	address     string
+	cert        string
+	key         string
+}
+
+// NewServer returns a new `aaronland/go-http-server.Server` instance configured by 'uri'. "http://" and "https://" URIs return
+// a new `HTTPServer` instance and all other URIs are passed to `aaronland/go-http-server.NewServer`.
+func NewServer(ctx context.Context, uri string) (aa_server.Server, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	switch u.Scheme {
+	case "http", "https":
+		return NewHTTPServer(ctx, uri)
+	default:
+		return aa_server.NewServer(ctx, uri)
+	}
+}
+
+// NewHTTPServer returns a new `HTTPServer` instance configured by 'uri' in the form of:
+//
+//	{SCHEME}://{HOST}:{PORT}?{PARAMETERS}
+//
+// Where {SCHEME} is either "http" or "https" and {HOST} and {PORT} are the address and port to listen for requests on. Requests
+// are only served over HTTPS if the `cert` and `key` parameters are present, regardless of {SCHEME}. Valid parameters are:
+// * `cert={PATH}` The path of a TLS certificate. If present requests are served over HTTPS. Requires `key`.
+// * `key={PATH}` The path of a TLS key. Requires `cert`.
+// * `read_timeout={SECONDS}` A custom setting for HTTP read timeouts. Default is 2 seconds.
+// * `write_timeout={SECONDS}` A custom setting for HTTP write timeouts. Default is 10 seconds.
+// * `idle_timeout={SECONDS}` A custom setting for HTTP idle timeouts. Default is 15 seconds.
+// * `header_timeout={SECONDS}` A custom setting for HTTP header timeouts. Default is 2 seconds.
+// * `max_header_bytes={INT}` The maximum size, in bytes, of the request headers. Default is 1048576.
+// * `http2={BOOLEAN}` Serve HTTP/2 requests over TLS. Default is true.
+// * `h2c={BOOLEAN}` Serve HTTP/2 requests without TLS ("h2c"). Default is false.
+// * `http2_max_concurrent_streams={INT}` The maximum number of concurrent HTTP/2 streams for each client connection. Default is 250.
+func NewHTTPServer(ctx context.Context, uri string) (aa_server.Server, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	http_server, err := newHTTPServer(q)
+
+	if err != nil {
+		return nil, err
+	}
+
+	s := &HTTPServer{
+		http_server: http_server,
+		address:     u.Host,
+		cert:        q.Get("cert"),
+		key:         q.Get("key"),
+	}
+
+	if (s.cert == "") != (s.key == "") {
+		return nil, fmt.Errorf("TLS certificates require both ?cert and ?key parameters")
+	}
+
+	for _, path := range []string{s.cert, s.key} {
+
+		if path == "" {
+			continue
+		}
+
+		_, err := os.Stat(path)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to stat '%s', %w", path, err)
+		}
+	}
+
+	return s, nil
+}
+
+// Address returns the fully-qualified URI where the server instance can be contacted.
+func (s *HTTPServer) Address() string {
+
+	scheme := "http"
+
+	if s.cert != "" {
+		scheme = "https"
+	}
+
+	return fmt.Sprintf("%s://%s", scheme, s.address)
+}
+
+// ListenAndServe listens for requests, using 'mux' for routing, until 'ctx' is cancelled or the process receives an interrupt
+// signal. In-flight requests are allowed to complete before returning.
+func (s *HTTPServer) ListenAndServe(ctx context.Context, mux http.Handler) error {
+
+	s.http_server.setHandler(mux)
+
+	l, err := net.Listen("tcp", s.address)
+
+	if err != nil {
+		return fmt.Errorf("Failed to listen on %s, %w", s.address, err)
+	}
+
+	if s.cert != "" {
+
+		// The TLS config for the server already lists the "h2" protocol if HTTP/2 is enabled
+
+		srv := s.http_server.Server
+
+		cfg := srv.TLSConfig.Clone()
+
+		if cfg == nil {
+			cfg = &tls.Config{}
+		}
+
+		cert, err := tls.LoadX509KeyPair(s.cert, s.key)
+
+		if err != nil {
+			l.Close()
+			return fmt.Errorf("Failed to load TLS certificate, %w", err)
+		}
+
+		cfg.Certificates = []tls.Certificate{cert}
+
+		if !containsString(cfg.NextProtos, "http/1.1") {
+			cfg.NextProtos = append(cfg.NextProtos, "http/1.1")
+		}
+
+		l = tls.NewListener(l, cfg)
+	}
+
+	return serveListeners(ctx, s.http_server.Server, []net.Listener{l})
+}
+
+// containsString returns a boolean value indicating whether 'candidates' contains 's'.
+func containsString(candidates []string, s string) bool {
+
+	for _, c := range candidates {
+
+		if c == s {
+			return true
+		}
+	}
+
+	return false
+}
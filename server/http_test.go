@@ -0,0 +1,257 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/net/http2"
+)
+
+// writeCertificate writes a self-signed TLS certificate, and its key, for "127.0.0.1" to a temporary directory and returns
+// their paths.
+func writeCertificate(t *testing.T) (string, string) {
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	if err != nil {
+		t.Fatalf("Failed to generate key, %v", err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+
+	if err != nil {
+		t.Fatalf("Failed to create certificate, %v", err)
+	}
+
+	key_der, err := x509.MarshalECPrivateKey(key)
+
+	if err != nil {
+		t.Fatalf("Failed to marshal key, %v", err)
+	}
+
+	root := t.TempDir()
+
+	cert_path := filepath.Join(root, "cert.pem")
+	key_path := filepath.Join(root, "key.pem")
+
+	err = os.WriteFile(cert_path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+
+	if err != nil {
+		t.Fatalf("Failed to write certificate, %v", err)
+	}
+
+	err = os.WriteFile(key_path, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: key_der}), 0600)
+
+	if err != nil {
+		t.Fatalf("Failed to write key, %v", err)
+	}
+
+	return cert_path, key_path
+}
+
+// startHTTPServer starts a new `HTTPServer` instance, configured by 'uri', that responds with the protocol of each request. It
+// returns a function used to stop the server.
+func startHTTPServer(t *testing.T, uri string) func() {
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s, err := NewServer(ctx, uri)
+
+	if err != nil {
+		t.Fatalf("Failed to create new server for '%s', %v", uri, err)
+	}
+
+	if _, ok := s.(*HTTPServer); !ok {
+		t.Fatalf("Expected '%s' to return a HTTPServer, got %T", uri, s)
+	}
+
+	handler := http.HandlerFunc(func(rsp http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(rsp, req.Proto)
+	})
+
+	done_ch := make(chan error, 1)
+
+	go func() {
+		done_ch <- s.ListenAndServe(ctx, handler)
+	}()
+
+	stop := func() {
+
+		cancel()
+
+		select {
+		case err := <-done_ch:
+
+			if err != nil {
+				t.Fatalf("Failed to serve requests, %v", err)
+			}
+
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for server to shut down")
+		}
+	}
+
+	return stop
+}
+
+// protoMajor returns the major HTTP version of the response to a GET request, sent to 'uri' using 'client', retrying until the
+// server is listening.
+func protoMajor(t *testing.T, client *http.Client, uri string) (int, error) {
+
+	var last_err error
+
+	for i := 0; i < 50; i++ {
+
+		rsp, err := client.Get(uri)
+
+		if err != nil {
+			last_err = err
+			time.Sleep(20 * time.Millisecond)
+			continue
+		}
+
+		rsp.Body.Close()
+		return rsp.ProtoMajor, nil
+	}
+
+	return 0, last_err
+}
+
+func TestNewServer(t *testing.T) {
+
+	ctx := context.Background()
+
+	s, err := NewServer(ctx, "https://localhost:8080?read_timeout=5")
+
+	if err != nil {
+		t.Fatalf("Failed to create new server, %v", err)
+	}
+
+	if s.Address() != "http://localhost:8080" {
+		t.Fatalf("Unexpected address, %s", s.Address())
+	}
+
+	_, err = NewServer(ctx, "bogus://localhost:8080")
+
+	if err == nil {
+		t.Fatalf("Expected unregistered scheme to fail")
+	}
+
+	uris := []string{
+		"http://localhost:8080?cert=cert.pem",
+		"http://localhost:8080?cert=missing.pem&key=missing.pem",
+		"http://localhost:8080?h2c=true&http2=false",
+		"http://localhost:8080?max_header_bytes=0",
+		"http://localhost:8080?http2_max_concurrent_streams=bogus",
+	}
+
+	for _, uri := range uris {
+
+		_, err := NewHTTPServer(ctx, uri)
+
+		if err == nil {
+			t.Fatalf("Expected '%s' to fail", uri)
+		}
+	}
+}
+
+func TestHTTPServerH2C(t *testing.T) {
+
+	addr := freeAddress(t)
+
+	stop := startHTTPServer(t, fmt.Sprintf("http://%s?h2c=true", addr))
+	defer stop()
+
+	h2c_client := &http.Client{
+		Timeout: 5 * time.Second,
+		Transport: &http2.Transport{
+			AllowHTTP: true,
+			DialTLS: func(network string, addr string, cfg *tls.Config) (net.Conn, error) {
+				return net.Dial(network, addr)
+			},
+		},
+	}
+
+	v, err := protoMajor(t, h2c_client, "http://"+addr)
+
+	if err != nil {
+		t.Fatalf("Failed to send h2c request, %v", err)
+	}
+
+	if v != 2 {
+		t.Fatalf("Expected HTTP/2 response, got HTTP/%d", v)
+	}
+
+	v, err = protoMajor(t, &http.Client{Timeout: 5 * time.Second}, "http://"+addr)
+
+	if err != nil {
+		t.Fatalf("Failed to send HTTP/1.1 request, %v", err)
+	}
+
+	if v != 1 {
+		t.Fatalf("Expected HTTP/1.1 response, got HTTP/%d", v)
+	}
+}
+
+func TestHTTPServerTLS(t *testing.T) {
+
+	cert_path, key_path := writeCertificate(t)
+
+	client := &http.Client{
+		Timeout: 5 * time.Second,
+		Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+			ForceAttemptHTTP2: true,
+		},
+	}
+
+	tests := []struct {
+		http2 bool
+		proto int
+	}{
+		{true, 2},
+		{false, 1},
+	}
+
+	for _, test := range tests {
+
+		addr := freeAddress(t)
+		uri := fmt.Sprintf("https://%s?cert=%s&key=%s&http2=%t", addr, cert_path, key_path, test.http2)
+
+		stop := startHTTPServer(t, uri)
+
+		v, err := protoMajor(t, client, "https://"+addr)
+
+		stop()
+
+		if err != nil {
+			t.Fatalf("Failed to send request with http2=%t, %v", test.http2, err)
+		}
+
+		if v != test.proto {
+			t.Fatalf("Expected HTTP/%d response with http2=%t, got HTTP/%d", test.proto, test.http2, v)
+		}
+	}
+}
@@ -0,0 +1,16 @@
+//go:build windows || plan9 || js || wasip1
+
+package server
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// reusePortSupported is a boolean flag signaling whether the SO_REUSEPORT socket option is supported on this platform.
+const reusePortSupported bool = false
+
+// reusePort returns an error since the SO_REUSEPORT socket option is not supported on this platform.
+func reusePort(network string, address string, c syscall.RawConn) error {
+	return fmt.Errorf("SO_REUSEPORT is not supported on this platform")
+}
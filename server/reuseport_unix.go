@@ -0,0 +1,28 @@
+//go:build !windows && !plan9 && !js && !wasip1
+
+package server
+
+import (
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// reusePortSupported is a boolean flag signaling whether the SO_REUSEPORT socket option is supported on this platform.
+const reusePortSupported bool = true
+
+// reusePort sets the SO_REUSEPORT option on the socket for 'c'. It is used as the `Control` function of a `net.ListenConfig`.
+func reusePort(network string, address string, c syscall.RawConn) error {
+
+	var opt_err error
+
+	err := c.Control(func(fd uintptr) {
+		opt_err = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEPORT, 1)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return opt_err
+}
@@ -0,0 +1,223 @@
+// Package server provides additional `aaronland/go-http-server.Server` implementations, registered with that package's
+// `RegisterServer` method, for the webhookd daemon.
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// DEFAULT_MAX_HEADER_BYTES is the default maximum size, in bytes, of the request headers, including the request line, that a
+// server will read.
+const DEFAULT_MAX_HEADER_BYTES int = http.DefaultMaxHeaderBytes
+
+// httpServer is a `http.Server` instance and the HTTP/2 settings used to serve requests with it.
+type httpServer struct {
+	*http.Server
+	// h2c is the `http2.Server` instance used to serve HTTP/2 requests without TLS ("h2c"). If nil then h2c is disabled.
+	h2c *http2.Server
+}
+
+// setHandler assigns 'handler' to 's', wrapped so that it also serves h2c requests if h2c is enabled.
+func (s *httpServer) setHandler(handler http.Handler) {
+
+	if s.h2c != nil {
+		handler = h2c.NewHandler(handler, s.h2c)
+	}
+
+	s.Handler = handler
+}
+
+// newHTTPServer returns a new `httpServer` instance configured by the following parameters in 'q':
+// * `read_timeout={SECONDS}` A custom setting for HTTP read timeouts. Default is 2 seconds.
+// * `write_timeout={SECONDS}` A custom setting for HTTP write timeouts. Default is 10 seconds.
+// * `idle_timeout={SECONDS}` A custom setting for HTTP idle timeouts. Default is 15 seconds.
+// * `header_timeout={SECONDS}` A custom setting for HTTP header timeouts. Default is 2 seconds.
+// * `max_header_bytes={INT}` The maximum size, in bytes, of the request headers. Default is 1048576.
+// * `http2={BOOLEAN}` Serve HTTP/2 requests over TLS. Default is true.
+// * `h2c={BOOLEAN}` Serve HTTP/2 requests without TLS ("h2c"), using either prior knowledge or an "Upgrade: h2c" header. Default is false.
+// * `http2_max_concurrent_streams={INT}` The maximum number of concurrent HTTP/2 streams for each client connection. Default is 250.
+// The default timeouts match those of the `aaronland/go-http-server` "http://" server.
+func newHTTPServer(q url.Values) (*httpServer, error) {
+
+	srv := &http.Server{
+		ReadTimeout:       2 * time.Second,
+		WriteTimeout:      10 * time.Second,
+		IdleTimeout:       15 * time.Second,
+		ReadHeaderTimeout: 2 * time.Second,
+		MaxHeaderBytes:    DEFAULT_MAX_HEADER_BYTES,
+	}
+
+	timeouts := map[string]*time.Duration{
+		"read_timeout":   &srv.ReadTimeout,
+		"write_timeout":  &srv.WriteTimeout,
+		"idle_timeout":   &srv.IdleTimeout,
+		"header_timeout": &srv.ReadHeaderTimeout,
+	}
+
+	for k, ptr := range timeouts {
+
+		str_v := q.Get(k)
+
+		if str_v == "" {
+			continue
+		}
+
+		v, err := strconv.Atoi(str_v)
+
+		if err != nil || v < 0 {
+			return nil, fmt.Errorf("Invalid ?%s parameter '%s'", k, str_v)
+		}
+
+		*ptr = time.Duration(v) * time.Second
+	}
+
+	h2s := &http2.Server{
+		IdleTimeout: srv.IdleTimeout,
+	}
+
+	sizes := map[string]func(int){
+		"max_header_bytes": func(v int) {
+			srv.MaxHeaderBytes = v
+		},
+		"http2_max_concurrent_streams": func(v int) {
+			h2s.MaxConcurrentStreams = uint32(v)
+		},
+	}
+
+	for k, set := range sizes {
+
+		str_v := q.Get(k)
+
+		if str_v == "" {
+			continue
+		}
+
+		v, err := strconv.Atoi(str_v)
+
+		if err != nil || v <= 0 {
+			return nil, fmt.Errorf("Invalid ?%s parameter '%s'", k, str_v)
+		}
+
+		set(v)
+	}
+
+	enable_http2 := true
+	enable_h2c := false
+
+	flags := map[string]*bool{
+		"http2": &enable_http2,
+		"h2c":   &enable_h2c,
+	}
+
+	for k, ptr := range flags {
+
+		str_v := q.Get(k)
+
+		if str_v == "" {
+			continue
+		}
+
+		v, err := strconv.ParseBool(str_v)
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid ?%s parameter, %w", k, err)
+		}
+
+		*ptr = v
+	}
+
+	if enable_h2c && !enable_http2 {
+		return nil, fmt.Errorf("The ?h2c parameter requires that HTTP/2 be enabled")
+	}
+
+	s := &httpServer{
+		Server: srv,
+	}
+
+	if !enable_http2 {
+
+		// A non-nil, empty map disables the HTTP/2 support built in to net/http
+
+		srv.TLSNextProto = make(map[string]func(*http.Server, *tls.Conn, http.Handler))
+		return s, nil
+	}
+
+	err := http2.ConfigureServer(srv, h2s)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to configure HTTP/2, %w", err)
+	}
+
+	if enable_h2c {
+		s.h2c = h2s
+	}
+
+	return s, nil
+}
+
+// serveListeners serves requests, using 'srv', for each of 'listeners' until 'ctx' is cancelled, the process receives an
+// interrupt signal or any of the listeners fails. The server is shut down gracefully before returning.
+func serveListeners(ctx context.Context, srv *http.Server, listeners []net.Listener) error {
+
+	sigint := make(chan os.Signal, 1)
+	signal.Notify(sigint, os.Interrupt)
+
+	defer signal.Stop(sigint)
+
+	err_ch := make(chan error, len(listeners))
+	wg := new(sync.WaitGroup)
+
+	for _, l := range listeners {
+
+		wg.Add(1)
+
+		go func(l net.Listener) {
+
+			defer wg.Done()
+
+			err := srv.Serve(l)
+
+			if err != nil && err != http.ErrServerClosed {
+				err_ch <- err
+			}
+		}(l)
+	}
+
+	var serve_err error
+
+	select {
+	case <-ctx.Done():
+		// pass
+	case <-sigint:
+		// pass
+	case serve_err = <-err_ch:
+		// pass
+	}
+
+	err := srv.Shutdown(context.Background())
+
+	wg.Wait()
+
+	if serve_err != nil {
+		return serve_err
+	}
+
+	if err != nil {
+		return fmt.Errorf("Failed to shut down server, %w", err)
+	}
+
+	return nil
+}
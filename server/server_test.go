@@ -0,0 +1,134 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestNewHTTPServer(t *testing.T) {
+
+	q := url.Values{}
+	q.Set("read_timeout", "5")
+	q.Set("header_timeout", "1")
+
+	srv, err := newHTTPServer(q)
+
+	if err != nil {
+		t.Fatalf("Failed to create HTTP server, %v", err)
+	}
+
+	if srv.ReadTimeout != 5*time.Second || srv.ReadHeaderTimeout != time.Second || srv.WriteTimeout != 10*time.Second {
+		t.Fatalf("Unexpected timeouts %v, %v, %v", srv.ReadTimeout, srv.ReadHeaderTimeout, srv.WriteTimeout)
+	}
+
+	if srv.MaxHeaderBytes != DEFAULT_MAX_HEADER_BYTES || srv.h2c != nil || len(srv.TLSConfig.NextProtos) == 0 {
+		t.Fatalf("Unexpected default HTTP/2 settings %d, %v, %v", srv.MaxHeaderBytes, srv.h2c, srv.TLSConfig.NextProtos)
+	}
+
+	q = url.Values{}
+	q.Set("max_header_bytes", "4096")
+	q.Set("h2c", "true")
+	q.Set("http2_max_concurrent_streams", "10")
+
+	srv, err = newHTTPServer(q)
+
+	if err != nil {
+		t.Fatalf("Failed to create HTTP server, %v", err)
+	}
+
+	if srv.MaxHeaderBytes != 4096 || srv.h2c == nil || srv.h2c.MaxConcurrentStreams != 10 {
+		t.Fatalf("Unexpected HTTP/2 settings %d, %v", srv.MaxHeaderBytes, srv.h2c)
+	}
+
+	q = url.Values{}
+	q.Set("http2", "false")
+
+	srv, err = newHTTPServer(q)
+
+	if err != nil {
+		t.Fatalf("Failed to create HTTP server, %v", err)
+	}
+
+	if srv.TLSNextProto == nil || len(srv.TLSNextProto) != 0 {
+		t.Fatalf("Expected HTTP/2 to be disabled")
+	}
+
+	for _, v := range []string{"bogus", "-1", "1s"} {
+
+		q := url.Values{}
+		q.Set("idle_timeout", v)
+
+		_, err := newHTTPServer(q)
+
+		if err == nil {
+			t.Fatalf("Expected idle_timeout=%s to fail", v)
+		}
+	}
+}
+
+func TestServeListeners(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	srv, err := newHTTPServer(url.Values{})
+
+	if err != nil {
+		t.Fatalf("Failed to create HTTP server, %v", err)
+	}
+
+	srv.Handler = http.HandlerFunc(func(rsp http.ResponseWriter, req *http.Request) {
+		rsp.WriteHeader(http.StatusAccepted)
+	})
+
+	listeners := make([]net.Listener, 2)
+
+	for i := range listeners {
+
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+
+		if err != nil {
+			t.Fatalf("Failed to listen, %v", err)
+		}
+
+		listeners[i] = l
+	}
+
+	done_ch := make(chan error, 1)
+
+	go func() {
+		done_ch <- serveListeners(ctx, srv.Server, listeners)
+	}()
+
+	for _, l := range listeners {
+
+		rsp, err := http.Get("http://" + l.Addr().String())
+
+		if err != nil {
+			t.Fatalf("Failed to send request to %s, %v", l.Addr(), err)
+		}
+
+		rsp.Body.Close()
+
+		if rsp.StatusCode != http.StatusAccepted {
+			t.Fatalf("Unexpected status %d", rsp.StatusCode)
+		}
+	}
+
+	cancel()
+
+	select {
+	case err := <-done_ch:
+
+		if err != nil {
+			t.Fatalf("Failed to serve listeners, %v", err)
+		}
+
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for server to shut down")
+	}
+}
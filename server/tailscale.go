@@ -0,0 +1,311 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	aa_server "github.com/aaronland/go-http-server"
+)
+
+func init() {
+
+	ctx := context.Background()
+	err := aa_server.RegisterServer(ctx, "tailscale", NewTailscaleServer)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// DEFAULT_TAILSCALE_SOCKET is the default path to the unix socket for the local `tailscaled` API.
+const DEFAULT_TAILSCALE_SOCKET string = "/var/run/tailscale/tailscaled.sock"
+
+// tailscaleLocalAPIHost is the host name that requests to the local `tailscaled` API are sent to.
+const tailscaleLocalAPIHost string = "local-tailscaled.sock"
+
+// tailscaleStatus is the subset of the response from the local `tailscaled` API "status" endpoint used by `TailscaleServer`.
+type tailscaleStatus struct {
+	BackendState string `json:"BackendState"`
+	Self         *struct {
+		HostName     string   `json:"HostName"`
+		DNSName      string   `json:"DNSName"`
+		TailscaleIPs []string `json:"TailscaleIPs"`
+	} `json:"Self"`
+}
+
+// TailscaleServer implements the `aaronland/go-http-server.Server` interface for a server that only listens for requests on
+// the Tailscale addresses of the `tailscaled` node running on the same host, so that it can only be reached from the tailnet.
+type TailscaleServer struct {
+	aa_server.Server
+	client      *http.Client
+	http_server *httpServer
+	dns_name    string
+	ips         []string
+	port        string
+	tls         bool
+}
+
+// NewTailscaleServer returns a new `TailscaleServer` instance configured by 'uri' in the form of:
+//
+//	tailscale://{HOSTNAME}:{PORT}?{PARAMETERS}
+//
+// Where {HOSTNAME} is the optional name of the Tailscale node running on the same host and {PORT} is the optional port to listen
+// on. Default is 80, or 443 if `tls` is true. The local `tailscaled` must be running and logged in. Valid parameters are:
+// * `socket={PATH}` The path to the unix socket for the local `tailscaled` API. Default is "/var/run/tailscale/tailscaled.sock".
+// * `tls={BOOLEAN}` Serve requests over HTTPS using the certificate for the node's MagicDNS name issued by Tailscale. HTTPS
+// certificates must be enabled for the tailnet.
+// * `read_timeout={SECONDS}` A custom setting for HTTP read timeouts. Default is 2 seconds.
+// * `write_timeout={SECONDS}` A custom setting for HTTP write timeouts. Default is 10 seconds.
+// * `idle_timeout={SECONDS}` A custom setting for HTTP idle timeouts. Default is 15 seconds.
+// * `header_timeout={SECONDS}` A custom setting for HTTP header timeouts. Default is 2 seconds.
+// * `max_header_bytes={INT}` The maximum size, in bytes, of the request headers. Default is 1048576.
+// * `http2={BOOLEAN}` Serve HTTP/2 requests over TLS. Default is true.
+// * `h2c={BOOLEAN}` Serve HTTP/2 requests without TLS ("h2c"). Default is false.
+// * `http2_max_concurrent_streams={INT}` The maximum number of concurrent HTTP/2 streams for each client connection. Default is 250.
+func NewTailscaleServer(ctx context.Context, uri string) (aa_server.Server, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	socket := DEFAULT_TAILSCALE_SOCKET
+
+	if q.Get("socket") != "" {
+		socket = q.Get("socket")
+	}
+
+	use_tls := false
+
+	if q.Get("tls") != "" {
+
+		v, err := strconv.ParseBool(q.Get("tls"))
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid ?tls parameter, %w", err)
+		}
+
+		use_tls = v
+	}
+
+	port := u.Port()
+
+	if port == "" {
+
+		port = "80"
+
+		if use_tls {
+			port = "443"
+		}
+	}
+
+	http_server, err := newHTTPServer(q)
+
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{
+		Timeout: 30 * time.Second,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network string, addr string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+		},
+	}
+
+	s := &TailscaleServer{
+		client:      client,
+		http_server: http_server,
+		port:        port,
+		tls:         use_tls,
+	}
+
+	status, err := s.status(ctx)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to retrieve status from tailscaled, %w", err)
+	}
+
+	if status.BackendState != "Running" || status.Self == nil {
+		return nil, fmt.Errorf("Tailscale is not running (state is '%s')", status.BackendState)
+	}
+
+	dns_name := strings.TrimSuffix(status.Self.DNSName, ".")
+	hostname := u.Hostname()
+
+	if hostname != "" {
+
+		short_name, _, _ := strings.Cut(dns_name, ".")
+
+		if !strings.EqualFold(hostname, status.Self.HostName) && !strings.EqualFold(hostname, short_name) && !strings.EqualFold(hostname, dns_name) {
+			return nil, fmt.Errorf("Tailscale node is named '%s', not '%s'", status.Self.HostName, hostname)
+		}
+	}
+
+	if len(status.Self.TailscaleIPs) == 0 {
+		return nil, fmt.Errorf("Tailscale node has no addresses")
+	}
+
+	if use_tls && dns_name == "" {
+		return nil, fmt.Errorf("Tailscale node has no MagicDNS name, which is required for TLS")
+	}
+
+	s.dns_name = dns_name
+	s.ips = status.Self.TailscaleIPs
+
+	return s, nil
+}
+
+// Address returns the fully-qualified URI where the server can be contacted from the tailnet.
+func (s *TailscaleServer) Address() string {
+
+	scheme := "http"
+	default_port := "80"
+
+	if s.tls {
+		scheme = "https"
+		default_port = "443"
+	}
+
+	host := s.dns_name
+
+	if host == "" {
+		host = s.ips[0]
+	}
+
+	if strings.Contains(host, ":") {
+		host = fmt.Sprintf("[%s]", host)
+	}
+
+	if s.port != default_port {
+		host = fmt.Sprintf("%s:%s", host, s.port)
+	}
+
+	return fmt.Sprintf("%s://%s", scheme, host)
+}
+
+// ListenAndServe listens for requests on each of the Tailscale addresses for the local node, using 'mux' for routing, until
+// 'ctx' is cancelled or the process receives an interrupt signal.
+func (s *TailscaleServer) ListenAndServe(ctx context.Context, mux http.Handler) error {
+
+	s.http_server.setHandler(mux)
+
+	if s.tls {
+
+		cert, err := s.certificate(ctx)
+
+		if err != nil {
+			return fmt.Errorf("Failed to retrieve TLS certificate from tailscaled, %w", err)
+		}
+
+		// The TLS config may already have been assigned the protocols for HTTP/2
+
+		if s.http_server.TLSConfig == nil {
+			s.http_server.TLSConfig = &tls.Config{}
+		}
+
+		s.http_server.TLSConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	listeners := make([]net.Listener, 0, len(s.ips))
+
+	for _, ip := range s.ips {
+
+		l, err := net.Listen("tcp", net.JoinHostPort(ip, s.port))
+
+		if err != nil {
+
+			for _, l := range listeners {
+				l.Close()
+			}
+
+			return fmt.Errorf("Failed to listen on %s, %w", ip, err)
+		}
+
+		if s.tls {
+			l = tls.NewListener(l, s.http_server.TLSConfig)
+		}
+
+		listeners = append(listeners, l)
+	}
+
+	return serveListeners(ctx, s.http_server.Server, listeners)
+}
+
+// status returns the status of the local `tailscaled` node.
+func (s *TailscaleServer) status(ctx context.Context) (*tailscaleStatus, error) {
+
+	body, err := s.localAPI(ctx, "/localapi/v0/status")
+
+	if err != nil {
+		return nil, err
+	}
+
+	var status *tailscaleStatus
+
+	err = json.Unmarshal(body, &status)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decode status, %w", err)
+	}
+
+	return status, nil
+}
+
+// certificate returns the TLS certificate, issued by Tailscale, for the MagicDNS name of the local `tailscaled` node.
+func (s *TailscaleServer) certificate(ctx context.Context) (tls.Certificate, error) {
+
+	// The "pair" response contains the PEM-encoded private key followed by the PEM-encoded certificate chain
+
+	body, err := s.localAPI(ctx, fmt.Sprintf("/localapi/v0/cert/%s?type=pair", url.PathEscape(s.dns_name)))
+
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	return tls.X509KeyPair(body, body)
+}
+
+// localAPI returns the body of a GET request for 'path' sent to the local `tailscaled` API.
+func (s *TailscaleServer) localAPI(ctx context.Context, path string) ([]byte, error) {
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+tailscaleLocalAPIHost+path, nil)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create request, %w", err)
+	}
+
+	rsp, err := s.client.Do(req)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to execute request, %w", err)
+	}
+
+	defer rsp.Body.Close()
+
+	body, err := io.ReadAll(rsp.Body)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read response, %w", err)
+	}
+
+	if rsp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Unexpected status %d, %s", rsp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return body, nil
+}
@@ -0,0 +1,242 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	aa_server "github.com/aaronland/go-http-server"
+)
+
+// startFakeTailscaled starts a local `tailscaled` API, listening on a unix socket, for a node with 'state' and returns the path
+// to the socket.
+func startFakeTailscaled(t *testing.T, state string) string {
+
+	socket := filepath.Join(t.TempDir(), "tailscaled.sock")
+
+	l, err := net.Listen("unix", socket)
+
+	if err != nil {
+		t.Fatalf("Failed to listen on unix socket, %v", err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	if err != nil {
+		t.Fatalf("Failed to generate key, %v", err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "laptop.tail1234.ts.net"},
+		DNSNames:     []string{"laptop.tail1234.ts.net"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+
+	if err != nil {
+		t.Fatalf("Failed to create certificate, %v", err)
+	}
+
+	key_der, err := x509.MarshalECPrivateKey(key)
+
+	if err != nil {
+		t.Fatalf("Failed to marshal key, %v", err)
+	}
+
+	pair := string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: key_der})) + string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/localapi/v0/status", func(rsp http.ResponseWriter, req *http.Request) {
+
+		if req.Host != tailscaleLocalAPIHost {
+			http.Error(rsp, "Invalid host", http.StatusForbidden)
+			return
+		}
+
+		fmt.Fprintf(rsp, `{"BackendState":"%s","Self":{"HostName":"laptop","DNSName":"laptop.tail1234.ts.net.","TailscaleIPs":["127.0.0.1"]}}`, state)
+	})
+
+	mux.HandleFunc("/localapi/v0/cert/laptop.tail1234.ts.net", func(rsp http.ResponseWriter, req *http.Request) {
+
+		if req.URL.Query().Get("type") != "pair" {
+			http.Error(rsp, "Invalid type", http.StatusBadRequest)
+			return
+		}
+
+		rsp.Write([]byte(pair))
+	})
+
+	srv := &http.Server{Handler: mux}
+
+	go srv.Serve(l)
+
+	t.Cleanup(func() {
+		srv.Close()
+	})
+
+	return socket
+}
+
+// freePort returns a port that is not currently in use on 127.0.0.1.
+func freePort(t *testing.T) string {
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatalf("Failed to listen, %v", err)
+	}
+
+	defer l.Close()
+
+	_, port, _ := net.SplitHostPort(l.Addr().String())
+	return port
+}
+
+func TestNewTailscaleServer(t *testing.T) {
+
+	ctx := context.Background()
+
+	socket := startFakeTailscaled(t, "Running")
+	stopped := startFakeTailscaled(t, "NeedsLogin")
+
+	tests := []struct {
+		uri      string
+		socket   string
+		address  string
+		expected bool
+	}{
+		{"tailscale://", socket, "http://laptop.tail1234.ts.net", true},
+		{"tailscale://laptop:8080", socket, "http://laptop.tail1234.ts.net:8080", true},
+		{"tailscale://LAPTOP.tail1234.ts.net?tls=true", socket, "https://laptop.tail1234.ts.net", true},
+		{"tailscale://desktop", socket, "", false},
+		{"tailscale://?tls=bogus", socket, "", false},
+		{"tailscale://?read_timeout=bogus", socket, "", false},
+		{"tailscale://", stopped, "", false},
+		{"tailscale://", filepath.Join(t.TempDir(), "missing.sock"), "", false},
+	}
+
+	for _, test := range tests {
+
+		u, _ := url.Parse(test.uri)
+		q := u.Query()
+		q.Set("socket", test.socket)
+		u.RawQuery = q.Encode()
+
+		s, err := aa_server.NewServer(ctx, u.String())
+
+		if test.expected && err != nil {
+			t.Fatalf("Failed to create server for '%s', %v", test.uri, err)
+		}
+
+		if !test.expected {
+
+			if err == nil {
+				t.Fatalf("Expected server for '%s' to fail", test.uri)
+			}
+
+			continue
+		}
+
+		if s.Address() != test.address {
+			t.Fatalf("Unexpected address for '%s', %s", test.uri, s.Address())
+		}
+	}
+}
+
+func TestTailscaleServer(t *testing.T) {
+
+	socket := startFakeTailscaled(t, "Running")
+
+	for _, use_tls := range []bool{false, true} {
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		port := freePort(t)
+
+		q := url.Values{}
+		q.Set("socket", socket)
+		q.Set("tls", fmt.Sprintf("%t", use_tls))
+
+		s, err := aa_server.NewServer(ctx, fmt.Sprintf("tailscale://laptop:%s?%s", port, q.Encode()))
+
+		if err != nil {
+			t.Fatalf("Failed to create server, %v", err)
+		}
+
+		handler := http.HandlerFunc(func(rsp http.ResponseWriter, req *http.Request) {
+			rsp.Write([]byte("hello world"))
+		})
+
+		done_ch := make(chan error, 1)
+
+		go func() {
+			done_ch <- s.ListenAndServe(ctx, handler)
+		}()
+
+		client := &http.Client{
+			Timeout: 5 * time.Second,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			},
+		}
+
+		scheme := "http"
+
+		if use_tls {
+			scheme = "https"
+		}
+
+		var rsp *http.Response
+
+		for i := 0; i < 50; i++ {
+
+			rsp, err = client.Get(fmt.Sprintf("%s://127.0.0.1:%s/", scheme, port))
+
+			if err == nil {
+				break
+			}
+
+			time.Sleep(20 * time.Millisecond)
+		}
+
+		if err != nil {
+			t.Fatalf("Failed to send request (tls=%t), %v", use_tls, err)
+		}
+
+		rsp.Body.Close()
+
+		if rsp.StatusCode != http.StatusOK {
+			t.Fatalf("Unexpected status %d", rsp.StatusCode)
+		}
+
+		if use_tls && !strings.HasSuffix(rsp.TLS.PeerCertificates[0].Subject.CommonName, ".ts.net") {
+			t.Fatalf("Unexpected certificate %s", rsp.TLS.PeerCertificates[0].Subject)
+		}
+
+		cancel()
+
+		err = <-done_ch
+
+		if err != nil {
+			t.Fatalf("Failed to serve requests, %v", err)
+		}
+	}
+}
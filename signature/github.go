@@ -0,0 +1,65 @@
+package signature
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+)
+
+// GITHUB_SHA1_HEADER is the HTTP header containing the (legacy) SHA-1 signature for a GitHub webhook message.
+const GITHUB_SHA1_HEADER string = "X-Hub-Signature"
+
+// GITHUB_SHA256_HEADER is the HTTP header containing the SHA-256 signature for a GitHub webhook message.
+const GITHUB_SHA256_HEADER string = "X-Hub-Signature-256"
+
+// SignGitHubSHA1 returns the value of the `GITHUB_SHA1_HEADER` header for 'body' signed with 'secret', in the form of "sha1={HEX}".
+func SignGitHubSHA1(secret string, body []byte) string {
+	return "sha1=" + hex.EncodeToString(HMAC(sha1.New, []byte(secret), body))
+}
+
+// SignGitHubSHA256 returns the value of the `GITHUB_SHA256_HEADER` header for 'body' signed with 'secret', in the form of
+// "sha256={HEX}".
+func SignGitHubSHA256(secret string, body []byte) string {
+	return "sha256=" + hex.EncodeToString(HMAC(sha256.New, []byte(secret), body))
+}
+
+// VerifyGitHubSHA1 verifies that 'sig', the value of the `GITHUB_SHA1_HEADER` header, is the signature for 'body' signed with 'secret'.
+func VerifyGitHubSHA1(secret string, body []byte, sig string) error {
+	return verifySignature(sig, SignGitHubSHA1(secret, body))
+}
+
+// VerifyGitHubSHA256 verifies that 'sig', the value of the `GITHUB_SHA256_HEADER` header, is the signature for 'body' signed
+// with 'secret'.
+func VerifyGitHubSHA256(secret string, body []byte, sig string) error {
+	return verifySignature(sig, SignGitHubSHA256(secret, body))
+}
+
+// VerifyGitHub verifies the signature for 'body' signed with 'secret' using the GitHub signature headers in 'h'. The SHA-256
+// signature, in the `GITHUB_SHA256_HEADER` header, is preferred when it is present and the message is not valid if it fails even
+// if there is also a valid SHA-1 signature. If 'require_sha256' is true then messages without a SHA-256 signature are not valid;
+// otherwise the (deprecated) SHA-1 signature, in the `GITHUB_SHA1_HEADER` header, is verified instead.
+func VerifyGitHub(secret string, body []byte, h http.Header, require_sha256 bool) error {
+
+	sig := h.Get(GITHUB_SHA256_HEADER)
+
+	if sig != "" || require_sha256 {
+		return VerifyGitHubSHA256(secret, body, sig)
+	}
+
+	return VerifyGitHubSHA1(secret, body, h.Get(GITHUB_SHA1_HEADER))
+}
+
+// verifySignature returns `ErrMissingSignature` if 'sig' is empty or `ErrInvalidSignature` if it does not equal 'expected'.
+func verifySignature(sig string, expected string) error {
+
+	if sig == "" {
+		return ErrMissingSignature
+	}
+
+	if !Equal(sig, expected) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
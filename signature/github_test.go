@@ -0,0 +1,100 @@
+package signature
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+// https://docs.github.com/en/webhooks/using-webhooks/validating-webhook-deliveries#testing-the-webhook-payload-validation
+
+const github_secret string = "It's a Secret to Everybody"
+
+const github_body string = "Hello, World!"
+
+func TestGitHubSHA256(t *testing.T) {
+
+	expected := "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17"
+
+	sig := SignGitHubSHA256(github_secret, []byte(github_body))
+
+	if sig != expected {
+		t.Fatalf("Unexpected signature: %s", sig)
+	}
+
+	if VerifyGitHubSHA256(github_secret, []byte(github_body), expected) != nil {
+		t.Fatalf("Expected signature to be valid")
+	}
+
+	if !errors.Is(VerifyGitHubSHA256("s33kret", []byte(github_body), expected), ErrInvalidSignature) {
+		t.Fatalf("Expected signature with wrong secret to be invalid")
+	}
+
+	if !errors.Is(VerifyGitHubSHA256(github_secret, []byte(github_body), ""), ErrMissingSignature) {
+		t.Fatalf("Expected empty signature to be missing")
+	}
+}
+
+func TestGitHubSHA1(t *testing.T) {
+
+	expected := "sha1=01dc10d0c83e72ed246219cdd91669667fe2ca59"
+
+	sig := SignGitHubSHA1(github_secret, []byte(github_body))
+
+	if sig != expected {
+		t.Fatalf("Unexpected signature: %s", sig)
+	}
+
+	if VerifyGitHubSHA1(github_secret, []byte(github_body), expected) != nil {
+		t.Fatalf("Expected signature to be valid")
+	}
+
+	// SHA-256 signatures are not valid SHA-1 signatures
+
+	if !errors.Is(VerifyGitHubSHA1(github_secret, []byte(github_body), SignGitHubSHA256(github_secret, []byte(github_body))), ErrInvalidSignature) {
+		t.Fatalf("Expected SHA-256 signature to be invalid")
+	}
+}
+
+func TestVerifyGitHub(t *testing.T) {
+
+	sha1 := SignGitHubSHA1(github_secret, []byte(github_body))
+	sha256 := SignGitHubSHA256(github_secret, []byte(github_body))
+
+	tests := []struct {
+		sha1     string
+		sha256   string
+		require  bool
+		expected error
+	}{
+		{sha1, sha256, false, nil},
+		{sha1, sha256, true, nil},
+		{"", sha256, true, nil},
+		{sha1, "", false, nil},
+		{sha1, "", true, ErrMissingSignature},
+		// SHA-256 signatures are preferred when present
+		{sha1, "sha256=deadbeef", false, ErrInvalidSignature},
+		{"sha1=deadbeef", sha256, false, nil},
+		{"sha1=deadbeef", "", false, ErrInvalidSignature},
+		{"", "", false, ErrMissingSignature},
+	}
+
+	for idx, test := range tests {
+
+		h := http.Header{}
+
+		if test.sha1 != "" {
+			h.Set(GITHUB_SHA1_HEADER, test.sha1)
+		}
+
+		if test.sha256 != "" {
+			h.Set(GITHUB_SHA256_HEADER, test.sha256)
+		}
+
+		err := VerifyGitHub(github_secret, []byte(github_body), h, test.require)
+
+		if !errors.Is(err, test.expected) {
+			t.Fatalf("Unexpected result for test %d: %v", idx, err)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package signature
+
+// GITLAB_TOKEN_HEADER is the HTTP header containing the secret token for a GitLab webhook message.
+const GITLAB_TOKEN_HEADER string = "X-Gitlab-Token"
+
+// SignGitLab returns the value of the `GITLAB_TOKEN_HEADER` header for messages authenticated with 'token'. GitLab does not sign
+// message bodies; the secret token is sent verbatim.
+func SignGitLab(token string) string {
+	return token
+}
+
+// VerifyGitLab verifies that 'sig', the value of the `GITLAB_TOKEN_HEADER` header, is 'token'.
+func VerifyGitLab(token string, sig string) error {
+	return verifySignature(sig, SignGitLab(token))
+}
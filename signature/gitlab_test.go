@@ -0,0 +1,21 @@
+package signature
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestGitLab(t *testing.T) {
+
+	if VerifyGitLab("s33kret", SignGitLab("s33kret")) != nil {
+		t.Fatalf("Expected token to be valid")
+	}
+
+	if !errors.Is(VerifyGitLab("s33kret", "s3kret"), ErrInvalidSignature) {
+		t.Fatalf("Expected wrong token to be invalid")
+	}
+
+	if !errors.Is(VerifyGitLab("s33kret", ""), ErrMissingSignature) {
+		t.Fatalf("Expected empty token to be missing")
+	}
+}
@@ -0,0 +1,86 @@
+// Package signature provides methods for generating and verifying the signatures that webhook providers use to authenticate their
+// messages. Verification methods compare signatures in constant time and return one of the errors defined in this package, which
+// may be wrapped, so that programs other than webhookd can reuse the same verification logic.
+package signature
+
+import (
+	"crypto/hmac"
+	"crypto/subtle"
+	"errors"
+	"hash"
+	"math"
+	"strconv"
+	"time"
+)
+
+// ErrMissingSignature is returned when a message does not have a signature.
+var ErrMissingSignature = errors.New("Missing signature")
+
+// ErrInvalidSignature is returned when the signature for a message fails to validate.
+var ErrInvalidSignature = errors.New("Invalid signature")
+
+// ErrInvalidTimestamp is returned when the timestamp for a message is missing or can not be parsed.
+var ErrInvalidTimestamp = errors.New("Missing or invalid timestamp")
+
+// ErrExpiredTimestamp is returned when the timestamp for a message is outside of the allowed window.
+var ErrExpiredTimestamp = errors.New("Timestamp outside of allowed window")
+
+// Equal returns a boolean value indicating whether 'a' and 'b' are equal. The comparison is performed in constant time, relative
+// to the length of the strings, in order to prevent timing attacks.
+func Equal(a string, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// HMAC returns the HMAC digest of 'parts', concatenated in order, computed using the hash function 'h' and 'secret'.
+func HMAC(h func() hash.Hash, secret []byte, parts ...[]byte) []byte {
+
+	mac := hmac.New(h, secret)
+
+	for _, p := range parts {
+		mac.Write(p)
+	}
+
+	return mac.Sum(nil)
+}
+
+// VerifyTime returns `ErrExpiredTimestamp` if 't' is more than 'tolerance' before or after the current time. If 'tolerance' is zero
+// or less it always returns nil.
+func VerifyTime(t time.Time, tolerance time.Duration) error {
+
+	if tolerance <= 0 {
+		return nil
+	}
+
+	delta := time.Since(t)
+
+	if math.Abs(float64(delta)) > float64(tolerance) {
+		return ErrExpiredTimestamp
+	}
+
+	return nil
+}
+
+// ParseUnixTimestamp returns the `time.Time` for the Unix timestamp, in seconds, 'str_ts' or `ErrInvalidTimestamp` if it can not
+// be parsed.
+func ParseUnixTimestamp(str_ts string) (time.Time, error) {
+
+	ts, err := strconv.ParseInt(str_ts, 10, 64)
+
+	if err != nil {
+		return time.Time{}, ErrInvalidTimestamp
+	}
+
+	return time.Unix(ts, 0), nil
+}
+
+// verifyUnixTimestamp parses the Unix timestamp 'str_ts' and verifies that it is within 'tolerance' of the current time.
+func verifyUnixTimestamp(str_ts string, tolerance time.Duration) error {
+
+	t, err := ParseUnixTimestamp(str_ts)
+
+	if err != nil {
+		return err
+	}
+
+	return VerifyTime(t, tolerance)
+}
@@ -0,0 +1,70 @@
+package signature
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestEqual(t *testing.T) {
+
+	if !Equal("hello", "hello") {
+		t.Fatalf("Expected strings to be equal")
+	}
+
+	if Equal("hello", "world") || Equal("hello", "hell") || Equal("", "hello") {
+		t.Fatalf("Expected strings not to be equal")
+	}
+}
+
+func TestHMAC(t *testing.T) {
+
+	expected := "757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17"
+
+	sum := HMAC(sha256.New, []byte("It's a Secret to Everybody"), []byte("Hello, "), []byte("World!"))
+
+	if hex.EncodeToString(sum) != expected {
+		t.Fatalf("Unexpected digest: %x", sum)
+	}
+}
+
+func TestVerifyTime(t *testing.T) {
+
+	now := time.Now()
+
+	if VerifyTime(now.Add(-1*time.Minute), 5*time.Minute) != nil {
+		t.Fatalf("Expected recent time to be valid")
+	}
+
+	if !errors.Is(VerifyTime(now.Add(-10*time.Minute), 5*time.Minute), ErrExpiredTimestamp) {
+		t.Fatalf("Expected old time to be expired")
+	}
+
+	if !errors.Is(VerifyTime(now.Add(10*time.Minute), 5*time.Minute), ErrExpiredTimestamp) {
+		t.Fatalf("Expected future time to be expired")
+	}
+
+	if VerifyTime(time.Unix(0, 0), 0) != nil {
+		t.Fatalf("Expected zero tolerance to disable check")
+	}
+}
+
+func TestParseUnixTimestamp(t *testing.T) {
+
+	ts, err := ParseUnixTimestamp("1531420618")
+
+	if err != nil || ts.Unix() != 1531420618 {
+		t.Fatalf("Failed to parse timestamp, %v", err)
+	}
+
+	for _, str_ts := range []string{"", "yesterday", "1.5"} {
+
+		_, err := ParseUnixTimestamp(str_ts)
+
+		if !errors.Is(err, ErrInvalidTimestamp) {
+			t.Fatalf("Expected '%s' to be invalid, %v", str_ts, err)
+		}
+	}
+}
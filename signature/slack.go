@@ -0,0 +1,39 @@
+package signature
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+)
+
+// SLACK_SIGNATURE_HEADER is the HTTP header containing the signature for a Slack webhook message.
+const SLACK_SIGNATURE_HEADER string = "X-Slack-Signature"
+
+// SLACK_TIMESTAMP_HEADER is the HTTP header containing the timestamp for a Slack webhook message.
+const SLACK_TIMESTAMP_HEADER string = "X-Slack-Request-Timestamp"
+
+// SignSlack returns the value of the `SLACK_SIGNATURE_HEADER` header for 'body', sent at 't', signed with 'secret' in the form
+// of "v0={HEX}". The value of the `SLACK_TIMESTAMP_HEADER` header is the Unix timestamp of 't'.
+func SignSlack(secret string, body []byte, t time.Time) string {
+	return signSlack(secret, body, strconv.FormatInt(t.Unix(), 10))
+}
+
+// VerifySlack verifies that 'sig', the value of the `SLACK_SIGNATURE_HEADER` header, is the signature for 'body' signed with
+// 'secret' and that 'ts', the value of the `SLACK_TIMESTAMP_HEADER` header, is within 'tolerance' of the current time. If
+// 'tolerance' is zero or less only the format of 'ts' is checked.
+func VerifySlack(secret string, body []byte, ts string, sig string, tolerance time.Duration) error {
+
+	err := verifyUnixTimestamp(ts, tolerance)
+
+	if err != nil {
+		return err
+	}
+
+	return verifySignature(sig, signSlack(secret, body, ts))
+}
+
+// signSlack returns the signature for 'body' and the timestamp string 'ts' signed with 'secret'.
+func signSlack(secret string, body []byte, ts string) string {
+	return "v0=" + hex.EncodeToString(HMAC(sha256.New, []byte(secret), []byte("v0:"+ts+":"), body))
+}
@@ -0,0 +1,48 @@
+package signature
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// https://api.slack.com/authentication/verifying-requests-from-slack
+
+const slack_secret string = "8f742231b10e8888abcd99yyyzzz85a5"
+
+const slack_body string = "token=xyzz0WbapA4vBCDEFasx0q6G&team_id=T1DC2JH3J&team_domain=testteamnow&channel_id=G8PSS9T3V&channel_name=foobar&user_id=U2CERLKJA&user_name=roadrunner&command=%2Fwebhook-collect&text=&response_url=https%3A%2F%2Fhooks.slack.com%2Fcommands%2FT1DC2JH3J%2F397700885554%2F96rGlfmibIGlgcZRskXaIFfN&trigger_id=398738663015.47445629121.803a0bc887a14d10d2c447fce8b6703c"
+
+func TestSlack(t *testing.T) {
+
+	expected := "v0=a2114d57b48eac39b9ad189dd8316235a7b4a8d21a10bd27519666489c69b503"
+
+	sig := SignSlack(slack_secret, []byte(slack_body), time.Unix(1531420618, 0))
+
+	if sig != expected {
+		t.Fatalf("Unexpected signature: %s", sig)
+	}
+
+	if VerifySlack(slack_secret, []byte(slack_body), "1531420618", expected, 0) != nil {
+		t.Fatalf("Expected signature to be valid")
+	}
+
+	if !errors.Is(VerifySlack(slack_secret, []byte(slack_body), "1531420618", expected, 5*time.Minute), ErrExpiredTimestamp) {
+		t.Fatalf("Expected old timestamp to be expired")
+	}
+
+	if !errors.Is(VerifySlack(slack_secret, []byte(slack_body), "1531420619", expected, 0), ErrInvalidSignature) {
+		t.Fatalf("Expected signature with wrong timestamp to be invalid")
+	}
+
+	if !errors.Is(VerifySlack(slack_secret, []byte(slack_body), "", expected, 0), ErrInvalidTimestamp) {
+		t.Fatalf("Expected missing timestamp to be invalid")
+	}
+
+	now := time.Now()
+	sig = SignSlack(slack_secret, []byte(slack_body), now)
+
+	if VerifySlack(slack_secret, []byte(slack_body), strconv.FormatInt(now.Unix(), 10), sig, 5*time.Minute) != nil {
+		t.Fatalf("Expected current signature to be valid")
+	}
+}
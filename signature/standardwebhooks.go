@@ -0,0 +1,98 @@
+package signature
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// STANDARD_WEBHOOKS_ID_HEADER is the HTTP header containing the unique identifier for a Standard Webhooks message.
+const STANDARD_WEBHOOKS_ID_HEADER string = "Webhook-Id"
+
+// STANDARD_WEBHOOKS_TIMESTAMP_HEADER is the HTTP header containing the timestamp for a Standard Webhooks message.
+const STANDARD_WEBHOOKS_TIMESTAMP_HEADER string = "Webhook-Timestamp"
+
+// STANDARD_WEBHOOKS_SIGNATURE_HEADER is the HTTP header containing the signatures for a Standard Webhooks message.
+const STANDARD_WEBHOOKS_SIGNATURE_HEADER string = "Webhook-Signature"
+
+// STANDARD_WEBHOOKS_SECRET_PREFIX is the prefix for base64-encoded Standard Webhooks secrets.
+const STANDARD_WEBHOOKS_SECRET_PREFIX string = "whsec_"
+
+// SignStandardWebhooks returns the value of the `STANDARD_WEBHOOKS_SIGNATURE_HEADER` header for 'body', with the identifier 'id'
+// sent at 't', signed with 'secret' in the form of "v1,{BASE64}". 'secret' is the base64-encoded secret, optionally prefixed by
+// `STANDARD_WEBHOOKS_SECRET_PREFIX`, defined by the Standard Webhooks specification (https://www.standardwebhooks.com/).
+func SignStandardWebhooks(secret string, id string, t time.Time, body []byte) (string, error) {
+
+	key, err := decodeStandardWebhooksSecret(secret)
+
+	if err != nil {
+		return "", err
+	}
+
+	return signStandardWebhooks(key, id, strconv.FormatInt(t.Unix(), 10), body), nil
+}
+
+// VerifyStandardWebhooks verifies that 'sigs', the space-separated value of the `STANDARD_WEBHOOKS_SIGNATURE_HEADER` header,
+// contains at least one "v1" signature for 'body' signed with 'secret' and that 'ts', the value of the
+// `STANDARD_WEBHOOKS_TIMESTAMP_HEADER` header, is within 'tolerance' of the current time. 'id' is the value of the
+// `STANDARD_WEBHOOKS_ID_HEADER` header. If 'tolerance' is zero or less only the format of 'ts' is checked.
+func VerifyStandardWebhooks(secret string, id string, ts string, sigs string, body []byte, tolerance time.Duration) error {
+
+	key, err := decodeStandardWebhooksSecret(secret)
+
+	if err != nil {
+		return err
+	}
+
+	err = verifyUnixTimestamp(ts, tolerance)
+
+	if err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(sigs) == "" {
+		return ErrMissingSignature
+	}
+
+	expected := signStandardWebhooks(key, id, ts, body)
+
+	for _, sig := range strings.Fields(sigs) {
+
+		// Signatures for other (for example asymmetric "v1a") schemes are ignored
+
+		if !strings.HasPrefix(sig, "v1,") {
+			continue
+		}
+
+		if Equal(sig, expected) {
+			return nil
+		}
+	}
+
+	return ErrInvalidSignature
+}
+
+// decodeStandardWebhooksSecret returns the key for the base64-encoded 'secret', optionally prefixed by `STANDARD_WEBHOOKS_SECRET_PREFIX`.
+func decodeStandardWebhooksSecret(secret string) ([]byte, error) {
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(secret, STANDARD_WEBHOOKS_SECRET_PREFIX))
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decode secret, %w", err)
+	}
+
+	if len(key) == 0 {
+		return nil, fmt.Errorf("Empty secret")
+	}
+
+	return key, nil
+}
+
+// signStandardWebhooks returns the signature for 'body', with the identifier 'id' and the timestamp string 'ts', signed with 'key'.
+func signStandardWebhooks(key []byte, id string, ts string, body []byte) string {
+	sum := HMAC(sha256.New, key, []byte(id+"."+ts+"."), body)
+	return "v1," + base64.StdEncoding.EncodeToString(sum)
+}
@@ -0,0 +1,75 @@
+package signature
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// https://github.com/standard-webhooks/standard-webhooks/blob/main/spec/standard-webhooks.md
+
+const standard_webhooks_secret string = "whsec_MfKQ9r8GKYqrTwjUPD8ILPZIo2LaLaSw"
+
+const standard_webhooks_id string = "msg_p5jXN8AQM9LWM0D4loKWxJek"
+
+const standard_webhooks_body string = `{"test": 2432232314}`
+
+func TestStandardWebhooks(t *testing.T) {
+
+	expected := "v1,g0hM9SsE+OTPJTGt/tmIKtSyZlE3uFJELVlNIOLJ1OE="
+
+	sig, err := SignStandardWebhooks(standard_webhooks_secret, standard_webhooks_id, time.Unix(1614265330, 0), []byte(standard_webhooks_body))
+
+	if err != nil {
+		t.Fatalf("Failed to sign message, %v", err)
+	}
+
+	if sig != expected {
+		t.Fatalf("Unexpected signature: %s", sig)
+	}
+
+	tests := []struct {
+		id       string
+		ts       string
+		sigs     string
+		expected error
+	}{
+		{standard_webhooks_id, "1614265330", expected, nil},
+		{standard_webhooks_id, "1614265330", "v1a,cafe v1,deadbeef " + expected, nil},
+		{standard_webhooks_id, "1614265330", "v1,deadbeef", ErrInvalidSignature},
+		{"msg_other", "1614265330", expected, ErrInvalidSignature},
+		{standard_webhooks_id, "1614265331", expected, ErrInvalidSignature},
+		{standard_webhooks_id, "1614265330", "", ErrMissingSignature},
+		{standard_webhooks_id, "", expected, ErrInvalidTimestamp},
+	}
+
+	for idx, test := range tests {
+
+		err := VerifyStandardWebhooks(standard_webhooks_secret, test.id, test.ts, test.sigs, []byte(standard_webhooks_body), 0)
+
+		if !errors.Is(err, test.expected) {
+			t.Fatalf("Unexpected result for test %d: %v", idx, err)
+		}
+	}
+
+	// Secrets without a prefix are also accepted
+
+	err = VerifyStandardWebhooks("MfKQ9r8GKYqrTwjUPD8ILPZIo2LaLaSw", standard_webhooks_id, "1614265330", expected, []byte(standard_webhooks_body), 0)
+
+	if err != nil {
+		t.Fatalf("Expected signature to be valid, %v", err)
+	}
+
+	if !errors.Is(VerifyStandardWebhooks(standard_webhooks_secret, standard_webhooks_id, "1614265330", expected, []byte(standard_webhooks_body), 5*time.Minute), ErrExpiredTimestamp) {
+		t.Fatalf("Expected old timestamp to be expired")
+	}
+
+	for _, secret := range []string{"", "whsec_", "whsec_not base64!"} {
+
+		_, err := SignStandardWebhooks(secret, standard_webhooks_id, time.Now(), []byte(standard_webhooks_body))
+
+		if err == nil {
+			t.Fatalf("Expected secret '%s' to fail", secret)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package signature
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// STRIPE_SIGNATURE_HEADER is the HTTP header containing the timestamp and signatures for a Stripe webhook message.
+const STRIPE_SIGNATURE_HEADER string = "Stripe-Signature"
+
+// SignStripe returns the value of the `STRIPE_SIGNATURE_HEADER` header for 'body', sent at 't', signed with 'secret' in the form
+// of "t={TIMESTAMP},v1={HEX}".
+func SignStripe(secret string, body []byte, t time.Time) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	return fmt.Sprintf("t=%s,v1=%s", ts, signStripe(secret, body, ts))
+}
+
+// VerifyStripe verifies that 'header', the value of the `STRIPE_SIGNATURE_HEADER` header, contains a timestamp within 'tolerance'
+// of the current time and at least one "v1" signature for 'body' signed with 'secret'. If 'tolerance' is zero or less only the
+// format of the timestamp is checked. Stripe sends more than one signature while a secret is being rolled.
+func VerifyStripe(secret string, body []byte, header string, tolerance time.Duration) error {
+
+	var ts string
+	sigs := make([]string, 0)
+
+	for _, part := range strings.Split(header, ",") {
+
+		k, v, ok := strings.Cut(strings.TrimSpace(part), "=")
+
+		if !ok {
+			continue
+		}
+
+		switch k {
+		case "t":
+			ts = v
+		case "v1":
+			sigs = append(sigs, v)
+		}
+	}
+
+	err := verifyUnixTimestamp(ts, tolerance)
+
+	if err != nil {
+		return err
+	}
+
+	if len(sigs) == 0 {
+		return ErrMissingSignature
+	}
+
+	expected := signStripe(secret, body, ts)
+
+	for _, sig := range sigs {
+
+		if Equal(sig, expected) {
+			return nil
+		}
+	}
+
+	return ErrInvalidSignature
+}
+
+// signStripe returns the hex-encoded signature for 'body' and the timestamp string 'ts' signed with 'secret'.
+func signStripe(secret string, body []byte, ts string) string {
+	return hex.EncodeToString(HMAC(sha256.New, []byte(secret), []byte(ts+"."), body))
+}
@@ -0,0 +1,55 @@
+package signature
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+const stripe_secret string = "whsec_test"
+
+const stripe_body string = `{"id":"evt_test"}`
+
+func TestStripe(t *testing.T) {
+
+	expected := "t=1614265330,v1=586d5ecca9d6436fe7866c6d056c549d9cd0abc9306b9dfcf4dccbf3098d3c7e"
+
+	sig := SignStripe(stripe_secret, []byte(stripe_body), time.Unix(1614265330, 0))
+
+	if sig != expected {
+		t.Fatalf("Unexpected signature: %s", sig)
+	}
+
+	tests := []struct {
+		header   string
+		expected error
+	}{
+		{expected, nil},
+		// Multiple signatures, and unknown schemes, while a secret is being rolled
+		{"t=1614265330,v1=deadbeef,v0=cafe,v1=586d5ecca9d6436fe7866c6d056c549d9cd0abc9306b9dfcf4dccbf3098d3c7e", nil},
+		{"t=1614265330,v1=deadbeef", ErrInvalidSignature},
+		{"t=1614265331,v1=586d5ecca9d6436fe7866c6d056c549d9cd0abc9306b9dfcf4dccbf3098d3c7e", ErrInvalidSignature},
+		{"t=1614265330", ErrMissingSignature},
+		{"v1=586d5ecca9d6436fe7866c6d056c549d9cd0abc9306b9dfcf4dccbf3098d3c7e", ErrInvalidTimestamp},
+		{"", ErrInvalidTimestamp},
+	}
+
+	for idx, test := range tests {
+
+		err := VerifyStripe(stripe_secret, []byte(stripe_body), test.header, 0)
+
+		if !errors.Is(err, test.expected) {
+			t.Fatalf("Unexpected result for test %d: %v", idx, err)
+		}
+	}
+
+	if !errors.Is(VerifyStripe(stripe_secret, []byte(stripe_body), expected, 5*time.Minute), ErrExpiredTimestamp) {
+		t.Fatalf("Expected old timestamp to be expired")
+	}
+
+	sig = SignStripe(stripe_secret, []byte(stripe_body), time.Now())
+
+	if VerifyStripe(stripe_secret, []byte(stripe_body), sig, 5*time.Minute) != nil {
+		t.Fatalf("Expected current signature to be valid")
+	}
+}
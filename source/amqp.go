@@ -0,0 +1,390 @@
+package source
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+// DEFAULT_AMQP_HEARTBEAT is the default interval for heartbeats exchanged with an AMQP server.
+const DEFAULT_AMQP_HEARTBEAT time.Duration = 60 * time.Second
+
+// DEFAULT_AMQP_TIMEOUT is the default amount of time to wait for a connection to an AMQP server to be established.
+const DEFAULT_AMQP_TIMEOUT time.Duration = 10 * time.Second
+
+// DEFAULT_AMQP_PREFETCH is the default number of unacknowledged messages an AMQP server will deliver.
+const DEFAULT_AMQP_PREFETCH int = 1
+
+// Headers added to messages read from an AMQP queue, in addition to any message headers.
+const (
+	AMQP_EXCHANGE_HEADER    string = "X-Amqp-Exchange"
+	AMQP_ROUTING_KEY_HEADER string = "X-Amqp-Routing-Key"
+	AMQP_REDELIVERED_HEADER string = "X-Amqp-Redelivered"
+)
+
+func init() {
+
+	ctx := context.Background()
+
+	for _, scheme := range []string{"amqp", "amqps"} {
+
+		err := RegisterSource(ctx, scheme, NewAMQPSource)
+
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+// AMQPSource implements the `webhookd.WebhookSource` interface for consuming messages from an AMQP 0-9-1 (for example RabbitMQ) queue
+// using the RabbitMQ `amqp091-go` client.
+type AMQPSource struct {
+	webhookd.WebhookSource
+	// address is the URI of the AMQP server, without any credentials, vhost or parameters.
+	address string
+	// queue is the name of the queue to consume.
+	queue string
+	// consumer_tag is the optional consumer tag used to identify the consumer.
+	consumer_tag string
+	// prefetch is the number of unacknowledged messages the server will deliver.
+	prefetch int
+	// requeue is a boolean flag signaling that messages which fail to be processed should be returned to the queue.
+	requeue bool
+	// config is the `amqp.Config` used to connect to the server.
+	config amqp.Config
+	// mu is a `sync.Mutex` used to guard 'conn'.
+	mu *sync.Mutex
+	// conn is the current connection to the server.
+	conn *amqp.Connection
+}
+
+// NewAMQPSource returns a new `AMQPSource` instance configured by 'uri' in the form of:
+//
+//	amqp://[{USERNAME}:{PASSWORD}@]{HOST}[:{PORT}][/{VHOST}]?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `queue={STRING}` The name of the queue to consume. Required.
+// * `consumer_tag={STRING}` The consumer tag used to identify the consumer. Default is a unique tag generated by the client.
+// * `prefetch={INT}` The number of unacknowledged messages the server will deliver. Default is 1.
+// * `requeue={BOOLEAN}` Return messages that fail to be processed to the queue. Default is true.
+// * `heartbeat={DURATION}` The interval for heartbeats exchanged with the server. If the server proposes a shorter interval that is used instead. Default is "60s".
+// * `timeout={DURATION}` The amount of time to wait for a connection to be established. Default is "10s".
+//
+// Use the `amqps://` scheme to connect using TLS. The default username and password are "guest" and the default vhost is "/".
+func NewAMQPSource(ctx context.Context, uri string) (webhookd.WebhookSource, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("Missing AMQP host")
+	}
+
+	q := u.Query()
+
+	queue := q.Get("queue")
+
+	if queue == "" {
+		return nil, fmt.Errorf("Missing ?queue= parameter")
+	}
+
+	username := "guest"
+	password := "guest"
+
+	if u.User != nil {
+		username = u.User.Username()
+		password, _ = u.User.Password()
+	}
+
+	config := amqp.Config{
+		SASL:      []amqp.Authentication{&amqp.PlainAuth{Username: username, Password: password}},
+		Vhost:     "/",
+		Heartbeat: DEFAULT_AMQP_HEARTBEAT,
+	}
+
+	port := "5672"
+
+	if u.Scheme == "amqps" {
+		port = "5671"
+		config.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12, ServerName: u.Hostname()}
+	}
+
+	if u.Port() != "" {
+		port = u.Port()
+	}
+
+	vhost := strings.TrimPrefix(u.Path, "/")
+
+	if vhost != "" {
+		config.Vhost = vhost
+	}
+
+	timeout := DEFAULT_AMQP_TIMEOUT
+
+	durations := map[string]*time.Duration{
+		"heartbeat": &config.Heartbeat,
+		"timeout":   &timeout,
+	}
+
+	for k, ptr := range durations {
+
+		str_v := q.Get(k)
+
+		if str_v == "" {
+			continue
+		}
+
+		v, err := time.ParseDuration(str_v)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse ?%s= parameter, %w", k, err)
+		}
+
+		if v <= 0 {
+			return nil, fmt.Errorf("Invalid ?%s= parameter '%s'", k, str_v)
+		}
+
+		*ptr = v
+	}
+
+	config.Dial = amqp.DefaultDial(timeout)
+
+	address := url.URL{
+		Scheme: u.Scheme,
+		Host:   net.JoinHostPort(u.Hostname(), port),
+		Path:   "/",
+	}
+
+	prefetch := DEFAULT_AMQP_PREFETCH
+
+	str_prefetch := q.Get("prefetch")
+
+	if str_prefetch != "" {
+
+		v, err := strconv.Atoi(str_prefetch)
+
+		if err != nil || v < 0 || v > 65535 {
+			return nil, fmt.Errorf("Invalid ?prefetch= parameter '%s'", str_prefetch)
+		}
+
+		prefetch = v
+	}
+
+	requeue := true
+
+	str_requeue := q.Get("requeue")
+
+	if str_requeue != "" {
+
+		v, err := strconv.ParseBool(str_requeue)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse ?requeue= parameter, %w", err)
+		}
+
+		requeue = v
+	}
+
+	s := &AMQPSource{
+		address:      address.String(),
+		queue:        queue,
+		consumer_tag: q.Get("consumer_tag"),
+		prefetch:     prefetch,
+		requeue:      requeue,
+		config:       config,
+		mu:           new(sync.Mutex),
+	}
+
+	return s, nil
+}
+
+// Consume reads messages from the queue that 's' was instantiated with, passing each one to 'fn', until 'ctx' is cancelled.
+// Each message body is the body of the AMQP message and its headers are the message headers along with `X-Amqp-Exchange`,
+// `X-Amqp-Routing-Key` and `X-Amqp-Redelivered` headers and, if present, `Content-Type`, `Content-Encoding` and `X-Amqp-Type`
+// headers derived from the message properties. Messages are acknowledged once 'fn' returns without error. If 'fn' returns an
+// error and 's' was instantiated with `requeue=true` the message is returned to the queue and an error is returned, so the
+// message will be processed again once the source is restarted. Otherwise the message is rejected (and dead-lettered if the
+// queue is configured to do so) and consuming continues.
+func (s *AMQPSource) Consume(ctx context.Context, fn webhookd.WebhookSourceFunc) error {
+
+	conn, err := amqp.DialConfig(s.address, s.config)
+
+	if err != nil {
+		return fmt.Errorf("Failed to connect to AMQP server, %w", err)
+	}
+
+	s.mu.Lock()
+	s.conn = conn
+	s.mu.Unlock()
+
+	defer s.Close()
+
+	closed := conn.NotifyClose(make(chan *amqp.Error, 1))
+
+	ch, err := conn.Channel()
+
+	if err != nil {
+		return fmt.Errorf("Failed to open channel, %w", err)
+	}
+
+	err = ch.Qos(s.prefetch, 0, false)
+
+	if err != nil {
+		return fmt.Errorf("Failed to set prefetch count, %w", err)
+	}
+
+	deliveries, err := ch.Consume(s.queue, s.consumer_tag, false, false, false, false, nil)
+
+	if err != nil {
+		return fmt.Errorf("Failed to consume queue '%s', %w", s.queue, err)
+	}
+
+	for {
+
+		var d amqp.Delivery
+		var ok bool
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case d, ok = <-deliveries:
+			// pass
+		}
+
+		if !ok {
+
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			err := <-closed
+
+			if err != nil {
+				return fmt.Errorf("Connection to AMQP server closed, %w", err)
+			}
+
+			return fmt.Errorf("Connection to AMQP server closed")
+		}
+
+		msg := s.message(d)
+
+		werr := fn(ctx, msg)
+
+		if werr == nil {
+
+			err = d.Ack(false)
+
+			if err != nil {
+				return fmt.Errorf("Failed to acknowledge message, %w", err)
+			}
+
+			continue
+		}
+
+		err = d.Nack(false, s.requeue)
+
+		if err != nil {
+			return fmt.Errorf("Failed to reject message, %w", err)
+		}
+
+		if s.requeue {
+			return fmt.Errorf("Failed to process message, %w", werr)
+		}
+	}
+}
+
+// Close closes the current connection to the server, if there is one.
+func (s *AMQPSource) Close() error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		return nil
+	}
+
+	err := s.conn.Close()
+	s.conn = nil
+
+	return err
+}
+
+// message returns a new `webhookd.WebhookMessage` instance for 'd'.
+func (s *AMQPSource) message(d amqp.Delivery) *webhookd.WebhookMessage {
+
+	headers := http.Header{}
+
+	for k, v := range d.Headers {
+
+		str_v, ok := amqpHeaderValue(v)
+
+		if ok {
+			headers.Set(k, str_v)
+		}
+	}
+
+	headers.Set(AMQP_EXCHANGE_HEADER, d.Exchange)
+	headers.Set(AMQP_ROUTING_KEY_HEADER, d.RoutingKey)
+	headers.Set(AMQP_REDELIVERED_HEADER, strconv.FormatBool(d.Redelivered))
+
+	properties := map[string]string{
+		"Content-Type":     d.ContentType,
+		"Content-Encoding": d.ContentEncoding,
+		"X-Amqp-Type":      d.Type,
+	}
+
+	for header, v := range properties {
+
+		if v != "" {
+			headers.Set(header, v)
+		}
+	}
+
+	msg := &webhookd.WebhookMessage{
+		ID:     d.MessageId,
+		Body:   d.Body,
+		Header: headers,
+	}
+
+	return msg
+}
+
+// amqpHeaderValue returns the string representation of the AMQP header value 'v' and a boolean value indicating whether it is a
+// scalar value. Nested tables, arrays and void values are omitted.
+func amqpHeaderValue(v interface{}) (string, bool) {
+
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case []byte:
+		return string(v), true
+	case bool:
+		return strconv.FormatBool(v), true
+	case int8, int16, int32, int64, uint8, uint16, uint32:
+		return fmt.Sprintf("%d", v), true
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case amqp.Decimal:
+		return strconv.FormatFloat(float64(v.Value)/math.Pow10(int(v.Scale)), 'f', -1, 64), true
+	case time.Time:
+		return strconv.FormatInt(v.Unix(), 10), true
+	default:
+		return "", false
+	}
+}
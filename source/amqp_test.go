@@ -0,0 +1,664 @@
+package source
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+// AMQP frame types, class and method identifiers used by `amqpTestBroker`.
+const (
+	amqpFrameMethod byte = 1
+	amqpFrameHeader byte = 2
+	amqpFrameBody   byte = 3
+	amqpFrameEnd    byte = 0xce
+
+	amqpConnectionClass uint16 = 10
+	amqpChannelClass    uint16 = 20
+	amqpBasicClass      uint16 = 60
+
+	amqpConnectionStart   uint16 = 10
+	amqpConnectionStartOk uint16 = 11
+	amqpConnectionTune    uint16 = 30
+	amqpConnectionTuneOk  uint16 = 31
+	amqpConnectionOpen    uint16 = 40
+	amqpConnectionOpenOk  uint16 = 41
+	amqpConnectionClose   uint16 = 50
+	amqpConnectionCloseOk uint16 = 51
+	amqpChannelOpen       uint16 = 10
+	amqpChannelOpenOk     uint16 = 11
+	amqpBasicQos          uint16 = 10
+	amqpBasicQosOk        uint16 = 11
+	amqpBasicConsume      uint16 = 20
+	amqpBasicConsumeOk    uint16 = 21
+	amqpBasicDeliver      uint16 = 60
+	amqpBasicAck          uint16 = 80
+	amqpBasicNack         uint16 = 120
+
+	amqpChannel uint16 = 1
+)
+
+// amqpEncoder encodes the AMQP protocol primitives written by `amqpTestBroker`.
+type amqpEncoder struct {
+	buf bytes.Buffer
+}
+
+func (e *amqpEncoder) octet(v byte) {
+	e.buf.WriteByte(v)
+}
+
+func (e *amqpEncoder) short(v uint16) {
+	binary.Write(&e.buf, binary.BigEndian, v)
+}
+
+func (e *amqpEncoder) long(v uint32) {
+	binary.Write(&e.buf, binary.BigEndian, v)
+}
+
+func (e *amqpEncoder) longlong(v uint64) {
+	binary.Write(&e.buf, binary.BigEndian, v)
+}
+
+func (e *amqpEncoder) shortstr(v string) {
+	e.octet(byte(len(v)))
+	e.buf.WriteString(v)
+}
+
+func (e *amqpEncoder) longstr(v string) {
+	e.long(uint32(len(v)))
+	e.buf.WriteString(v)
+}
+
+func (e *amqpEncoder) table(v map[string]string) {
+
+	fields := new(amqpEncoder)
+
+	for k, str_v := range v {
+		fields.shortstr(k)
+		fields.octet('S')
+		fields.longstr(str_v)
+	}
+
+	e.long(uint32(fields.buf.Len()))
+	e.buf.Write(fields.buf.Bytes())
+}
+
+// amqpDecoder decodes the AMQP protocol primitives read by `amqpTestBroker`. Reads past the end of the buffer return zero values.
+type amqpDecoder struct {
+	b   []byte
+	off int
+}
+
+func (d *amqpDecoder) take(n int) []byte {
+
+	if d.off+n > len(d.b) {
+		d.off = len(d.b)
+		return make([]byte, n)
+	}
+
+	v := d.b[d.off : d.off+n]
+	d.off += n
+	return v
+}
+
+func (d *amqpDecoder) octet() byte {
+	return d.take(1)[0]
+}
+
+func (d *amqpDecoder) short() uint16 {
+	return binary.BigEndian.Uint16(d.take(2))
+}
+
+func (d *amqpDecoder) long() uint32 {
+	return binary.BigEndian.Uint32(d.take(4))
+}
+
+func (d *amqpDecoder) longlong() uint64 {
+	return binary.BigEndian.Uint64(d.take(8))
+}
+
+func (d *amqpDecoder) shortstr() string {
+	return string(d.take(int(d.octet())))
+}
+
+func (d *amqpDecoder) longstr() string {
+	return string(d.take(int(d.long())))
+}
+
+// table skips over a field table.
+func (d *amqpDecoder) table() {
+	d.take(int(d.long()))
+}
+
+// amqpTestMessage is a message delivered by `amqpTestBroker`.
+type amqpTestMessage struct {
+	id      string
+	body    string
+	headers map[string]string
+}
+
+// amqpTestBroker is a minimal AMQP 0-9-1 server, with a single queue, used to test `AMQPSource` against the `amqp091-go` client.
+type amqpTestBroker struct {
+	listener    net.Listener
+	mu          *sync.Mutex
+	messages    []amqpTestMessage
+	acked       map[string]bool
+	rejected    map[string]bool
+	redelivered map[string]bool
+}
+
+func newAMQPTestBroker(t *testing.T, messages []amqpTestMessage) *amqpTestBroker {
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatalf("Failed to create listener, %v", err)
+	}
+
+	b := &amqpTestBroker{
+		listener:    l,
+		mu:          new(sync.Mutex),
+		messages:    messages,
+		acked:       make(map[string]bool),
+		rejected:    make(map[string]bool),
+		redelivered: make(map[string]bool),
+	}
+
+	go func() {
+
+		for {
+
+			conn, err := l.Accept()
+
+			if err != nil {
+				return
+			}
+
+			go b.serve(conn)
+		}
+	}()
+
+	t.Cleanup(func() {
+		l.Close()
+	})
+
+	return b
+}
+
+func (b *amqpTestBroker) Acked(id string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.acked[id]
+}
+
+func (b *amqpTestBroker) Rejected(id string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.rejected[id]
+}
+
+func (b *amqpTestBroker) send(conn net.Conn, kind byte, channel uint16, enc *amqpEncoder) {
+
+	frame := new(amqpEncoder)
+	frame.octet(kind)
+	frame.short(channel)
+	frame.long(uint32(enc.buf.Len()))
+	frame.buf.Write(enc.buf.Bytes())
+	frame.octet(amqpFrameEnd)
+
+	conn.Write(frame.buf.Bytes())
+}
+
+func (b *amqpTestBroker) method(class uint16, id uint16) *amqpEncoder {
+	enc := new(amqpEncoder)
+	enc.short(class)
+	enc.short(id)
+	return enc
+}
+
+func (b *amqpTestBroker) read(r *bufio.Reader) (byte, *amqpDecoder, error) {
+
+	var hdr [7]byte
+
+	_, err := io.ReadFull(r, hdr[:])
+
+	if err != nil {
+		return 0, nil, err
+	}
+
+	payload := make([]byte, binary.BigEndian.Uint32(hdr[3:7])+1)
+
+	_, err = io.ReadFull(r, payload)
+
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return hdr[0], &amqpDecoder{b: payload[:len(payload)-1]}, nil
+}
+
+// expect reads frames until it reads the method 'class' and 'id'.
+func (b *amqpTestBroker) expect(r *bufio.Reader, class uint16, id uint16) (*amqpDecoder, error) {
+
+	for {
+
+		kind, dec, err := b.read(r)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if kind != amqpFrameMethod {
+			continue
+		}
+
+		if dec.short() == class && dec.short() == id {
+			return dec, nil
+		}
+	}
+}
+
+func (b *amqpTestBroker) serve(conn net.Conn) {
+
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+
+	var proto [8]byte
+
+	_, err := io.ReadFull(r, proto[:])
+
+	if err != nil {
+		return
+	}
+
+	enc := b.method(amqpConnectionClass, amqpConnectionStart)
+	enc.octet(0)
+	enc.octet(9)
+	enc.table(map[string]string{"product": "test"})
+	enc.longstr("PLAIN")
+	enc.longstr("en_US")
+	b.send(conn, amqpFrameMethod, 0, enc)
+
+	dec, err := b.expect(r, amqpConnectionClass, amqpConnectionStartOk)
+
+	if err != nil {
+		return
+	}
+
+	dec.table()
+	dec.shortstr()
+
+	if dec.longstr() != "\x00user\x00pass" {
+		return
+	}
+
+	enc = b.method(amqpConnectionClass, amqpConnectionTune)
+	enc.short(0)
+	enc.long(131072)
+	enc.short(0)
+	b.send(conn, amqpFrameMethod, 0, enc)
+
+	_, err = b.expect(r, amqpConnectionClass, amqpConnectionTuneOk)
+
+	if err != nil {
+		return
+	}
+
+	dec, err = b.expect(r, amqpConnectionClass, amqpConnectionOpen)
+
+	if err != nil || dec.shortstr() != "test" {
+		return
+	}
+
+	enc = b.method(amqpConnectionClass, amqpConnectionOpenOk)
+	enc.shortstr("")
+	b.send(conn, amqpFrameMethod, 0, enc)
+
+	_, err = b.expect(r, amqpChannelClass, amqpChannelOpen)
+
+	if err != nil {
+		return
+	}
+
+	enc = b.method(amqpChannelClass, amqpChannelOpenOk)
+	enc.longstr("")
+	b.send(conn, amqpFrameMethod, amqpChannel, enc)
+
+	_, err = b.expect(r, amqpBasicClass, amqpBasicQos)
+
+	if err != nil {
+		return
+	}
+
+	b.send(conn, amqpFrameMethod, amqpChannel, b.method(amqpBasicClass, amqpBasicQosOk))
+
+	dec, err = b.expect(r, amqpBasicClass, amqpBasicConsume)
+
+	if err != nil {
+		return
+	}
+
+	dec.short()
+
+	if dec.shortstr() != "events" {
+		return
+	}
+
+	consumer_tag := dec.shortstr()
+
+	enc = b.method(amqpBasicClass, amqpBasicConsumeOk)
+	enc.shortstr(consumer_tag)
+	b.send(conn, amqpFrameMethod, amqpChannel, enc)
+
+	tags := make(map[uint64]string)
+	tag := uint64(0)
+
+	b.mu.Lock()
+
+	for _, m := range b.messages {
+
+		if b.acked[m.id] || (b.rejected[m.id] && !b.redelivered[m.id]) {
+			continue
+		}
+
+		tag += 1
+		tags[tag] = m.id
+
+		enc = b.method(amqpBasicClass, amqpBasicDeliver)
+		enc.shortstr(consumer_tag)
+		enc.longlong(tag)
+
+		if b.rejected[m.id] {
+			enc.octet(1)
+		} else {
+			enc.octet(0)
+		}
+
+		enc.shortstr("webhooks")
+		enc.shortstr("github.push")
+		b.send(conn, amqpFrameMethod, amqpChannel, enc)
+
+		enc = new(amqpEncoder)
+		enc.short(amqpBasicClass)
+		enc.short(0)
+		enc.longlong(uint64(len(m.body)))
+		enc.short(1<<15 | 1<<13 | 1<<7)
+		enc.shortstr("application/json")
+		enc.table(m.headers)
+		enc.shortstr(m.id)
+		b.send(conn, amqpFrameHeader, amqpChannel, enc)
+
+		// Send bodies in two frames to test reassembly
+
+		half := len(m.body) / 2
+
+		for _, part := range []string{m.body[:half], m.body[half:]} {
+			enc = new(amqpEncoder)
+			enc.buf.WriteString(part)
+			b.send(conn, amqpFrameBody, amqpChannel, enc)
+		}
+	}
+
+	b.mu.Unlock()
+
+	for {
+
+		kind, dec, err := b.read(r)
+
+		if err != nil {
+			return
+		}
+
+		if kind != amqpFrameMethod {
+			continue
+		}
+
+		class := dec.short()
+		id := dec.short()
+
+		switch {
+		case class == amqpBasicClass && id == amqpBasicAck:
+			b.mu.Lock()
+			b.acked[tags[dec.longlong()]] = true
+			b.mu.Unlock()
+		case class == amqpBasicClass && id == amqpBasicNack:
+			msg_id := tags[dec.longlong()]
+			requeue := dec.octet()&2 != 0
+			b.mu.Lock()
+			b.rejected[msg_id] = true
+			b.redelivered[msg_id] = requeue
+			b.mu.Unlock()
+		case class == amqpConnectionClass && id == amqpConnectionClose:
+			b.send(conn, amqpFrameMethod, 0, b.method(amqpConnectionClass, amqpConnectionCloseOk))
+			return
+		}
+	}
+}
+
+func TestAMQPSource(t *testing.T) {
+
+	ctx := context.Background()
+
+	messages := []amqpTestMessage{
+		{id: "m1", body: `{"event":"one"}`, headers: map[string]string{"x-event": "push"}},
+		{id: "m2", body: `{"event":"two"}`},
+		{id: "m3", body: `{"event":"three"}`},
+	}
+
+	broker := newAMQPTestBroker(t, messages)
+
+	uri := fmt.Sprintf("amqp://user:pass@%s/test?queue=events", broker.listener.Addr().String())
+
+	s, err := NewSource(ctx, uri)
+
+	if err != nil {
+		t.Fatalf("Failed to create new source, %v", err)
+	}
+
+	// The first attempt fails to process the second message, which is returned to the queue
+
+	seen := make([]*webhookd.WebhookMessage, 0)
+
+	err = s.Consume(ctx, func(ctx context.Context, msg *webhookd.WebhookMessage) *webhookd.WebhookError {
+
+		if msg.ID == "m2" {
+			return &webhookd.WebhookError{Code: 500, Message: "Failed"}
+		}
+
+		seen = append(seen, msg)
+		return nil
+	})
+
+	if err == nil {
+		t.Fatalf("Expected failed message to return an error")
+	}
+
+	if len(seen) != 1 {
+		t.Fatalf("Expected exactly one message to be processed, got %d", len(seen))
+	}
+
+	msg := seen[0]
+
+	if string(msg.Body) != `{"event":"one"}` || msg.Header.Get("X-Event") != "push" || msg.Header.Get(AMQP_ROUTING_KEY_HEADER) != "github.push" || msg.Header.Get("Content-Type") != "application/json" {
+		t.Fatalf("Unexpected message: %v", msg)
+	}
+
+	for i := 0; i < 50 && !broker.Rejected("m2"); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if !broker.Acked("m1") || !broker.Rejected("m2") {
+		t.Fatalf("Expected first message to be acknowledged and second message to be rejected")
+	}
+
+	// The second attempt receives the requeued message and any remaining messages
+
+	consume_ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ch := make(chan *webhookd.WebhookMessage, len(messages))
+	done := make(chan error)
+
+	go func() {
+
+		done <- s.Consume(consume_ctx, func(ctx context.Context, msg *webhookd.WebhookMessage) *webhookd.WebhookError {
+			ch <- msg
+			return nil
+		})
+	}()
+
+	for _, expected := range []string{"m2", "m3"} {
+
+		select {
+		case msg := <-ch:
+
+			if msg.ID != expected {
+				t.Fatalf("Expected %s but got %s", expected, msg.ID)
+			}
+
+			if expected == "m2" && msg.Header.Get(AMQP_REDELIVERED_HEADER) != "true" {
+				t.Fatalf("Expected requeued message to be marked as redelivered")
+			}
+
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for message")
+		}
+	}
+
+	for i := 0; i < 50 && !broker.Acked("m3"); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if !broker.Acked("m2") || !broker.Acked("m3") {
+		t.Fatalf("Expected all messages to be acknowledged")
+	}
+
+	cancel()
+
+	select {
+	case err := <-done:
+
+		if err != nil {
+			t.Fatalf("Expected cancelled source to return without error, %v", err)
+		}
+
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for source to stop")
+	}
+}
+
+func TestAMQPSourceNoRequeue(t *testing.T) {
+
+	ctx := context.Background()
+
+	messages := []amqpTestMessage{
+		{id: "m1", body: `{"event":"one"}`},
+		{id: "m2", body: `{"event":"two"}`},
+	}
+
+	broker := newAMQPTestBroker(t, messages)
+
+	uri := fmt.Sprintf("amqp://user:pass@%s/test?queue=events&requeue=false", broker.listener.Addr().String())
+
+	s, err := NewSource(ctx, uri)
+
+	if err != nil {
+		t.Fatalf("Failed to create new source, %v", err)
+	}
+
+	consume_ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	done := make(chan error)
+
+	go func() {
+
+		done <- s.Consume(consume_ctx, func(ctx context.Context, msg *webhookd.WebhookMessage) *webhookd.WebhookError {
+
+			if msg.ID == "m1" {
+				return &webhookd.WebhookError{Code: 500, Message: "Failed"}
+			}
+
+			return nil
+		})
+	}()
+
+	for i := 0; i < 100 && !broker.Acked("m2"); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if !broker.Rejected("m1") || !broker.Acked("m2") {
+		t.Fatalf("Expected first message to be rejected and consuming to continue")
+	}
+
+	cancel()
+
+	err = <-done
+
+	if err != nil {
+		t.Fatalf("Expected cancelled source to return without error, %v", err)
+	}
+}
+
+func TestNewAMQPSourceInvalid(t *testing.T) {
+
+	ctx := context.Background()
+
+	tests := []string{
+		"amqp://localhost",
+		"amqp:///?queue=events",
+		"amqp://localhost?queue=events&prefetch=-1",
+		"amqp://localhost?queue=events&requeue=maybe",
+		"amqps://localhost?queue=events&heartbeat=soon",
+	}
+
+	for _, uri := range tests {
+
+		_, err := NewSource(ctx, uri)
+
+		if err == nil {
+			t.Fatalf("Expected %s to fail", uri)
+		}
+	}
+}
+
+func TestAMQPHeaderValue(t *testing.T) {
+
+	tests := map[string]interface{}{
+		"push":  "push",
+		"true":  true,
+		"-1":    int32(-1),
+		"65535": uint16(65535),
+		"0.5":   float64(0.5),
+		"19.99": amqp.Decimal{Scale: 2, Value: 1999},
+	}
+
+	for expected, v := range tests {
+
+		str_v, ok := amqpHeaderValue(v)
+
+		if !ok || str_v != expected {
+			t.Fatalf("Expected %s for %v but got %s", expected, v, str_v)
+		}
+	}
+
+	for _, v := range []interface{}{nil, amqp.Table{}, []interface{}{"a"}} {
+
+		_, ok := amqpHeaderValue(v)
+
+		if ok {
+			t.Fatalf("Expected %v to be omitted", v)
+		}
+	}
+}
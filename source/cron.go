@@ -0,0 +1,184 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+// Headers added to messages generated by a cron source.
+const (
+	CRON_SCHEDULE_HEADER string = "X-Cron-Schedule"
+	CRON_TIME_HEADER     string = "X-Cron-Time"
+)
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterSource(ctx, "cron", NewCronSource)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// CronSource implements the `webhookd.WebhookSource` interface for generating synthetic messages on a schedule.
+type CronSource struct {
+	webhookd.WebhookSource
+	// expr is the schedule expression that 'schedule' was parsed from.
+	expr string
+	// schedule is the parsed schedule used to derive the times at which messages are generated.
+	schedule *cronSchedule
+	// location is the time zone used to evaluate 'schedule'.
+	location *time.Location
+	// body is the optional fixed body for each message. If nil a JSON-encoded `cronEvent` is used.
+	body []byte
+}
+
+// cronEvent is the default body for messages generated by `CronSource`.
+type cronEvent struct {
+	// Schedule is the schedule expression that the message was generated for.
+	Schedule string `json:"schedule"`
+	// Time is the (RFC3339) time that the message was scheduled for.
+	Time string `json:"time"`
+}
+
+// NewCronSource returns a new `CronSource` instance configured by 'uri' in the form of:
+//
+//	cron://?schedule={SCHEDULE}&{PARAMETERS}
+//
+// Where {SCHEDULE} is a (URL-encoded) standard five-field cron expression (minute, hour, day of month, month and day of week),
+// one of the "@yearly", "@monthly", "@weekly", "@daily" or "@hourly" descriptors or "@every {DURATION}". Valid {PARAMETERS} are:
+// * `tz={STRING}` The IANA time zone used to evaluate the schedule. Default is "UTC".
+// * `body={STRING}` A fixed body for each message. Default is a JSON object containing the schedule and the time the message was scheduled for.
+func NewCronSource(ctx context.Context, uri string) (webhookd.WebhookSource, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	expr := q.Get("schedule")
+
+	if expr == "" {
+		return nil, fmt.Errorf("Missing ?schedule= parameter")
+	}
+
+	schedule, err := parseCronSchedule(expr)
+
+	if err != nil {
+		return nil, fmt.Errorf("Invalid ?schedule= parameter, %w", err)
+	}
+
+	location := time.UTC
+
+	tz := q.Get("tz")
+
+	if tz != "" {
+
+		location, err = time.LoadLocation(tz)
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid ?tz= parameter, %w", err)
+		}
+	}
+
+	s := &CronSource{
+		expr:     expr,
+		schedule: schedule,
+		location: location,
+	}
+
+	if q.Has("body") {
+		s.body = []byte(q.Get("body"))
+	}
+
+	return s, nil
+}
+
+// Consume generates a message, passing it to 'fn', each time the schedule that 's' was instantiated with fires until 'ctx' is
+// cancelled. Each message has `X-Cron-Schedule` and `X-Cron-Time` headers and its ID is derived from the time it was scheduled
+// for. If 'fn' returns an error it is returned and that message is not generated again.
+func (s *CronSource) Consume(ctx context.Context, fn webhookd.WebhookSourceFunc) error {
+
+	for {
+
+		now := time.Now().In(s.location)
+		t := s.schedule.next(now)
+
+		if t.IsZero() {
+			return fmt.Errorf("Schedule '%s' has no upcoming times", s.expr)
+		}
+
+		timer := time.NewTimer(t.Sub(now))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+			// pass
+		}
+
+		msg, err := s.message(t)
+
+		if err != nil {
+			return err
+		}
+
+		werr := fn(ctx, msg)
+
+		if werr != nil {
+			return fmt.Errorf("Failed to process message for %v, %w", t, werr)
+		}
+	}
+}
+
+// Close is a no-op since `CronSource` does not hold any resources.
+func (s *CronSource) Close() error {
+	return nil
+}
+
+// message returns a new `webhookd.WebhookMessage` instance for the time 't'.
+func (s *CronSource) message(t time.Time) (*webhookd.WebhookMessage, error) {
+
+	str_t := t.Format(time.RFC3339)
+
+	body := s.body
+
+	if body == nil {
+
+		ev := cronEvent{
+			Schedule: s.expr,
+			Time:     str_t,
+		}
+
+		enc, err := json.Marshal(ev)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to encode message, %w", err)
+		}
+
+		body = enc
+	}
+
+	headers := http.Header{}
+	headers.Set(CRON_SCHEDULE_HEADER, s.expr)
+	headers.Set(CRON_TIME_HEADER, str_t)
+
+	msg := &webhookd.WebhookMessage{
+		ID:     fmt.Sprintf("cron-%d", t.Unix()),
+		Body:   body,
+		Header: headers,
+	}
+
+	return msg, nil
+}
@@ -0,0 +1,300 @@
+package source
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronDescriptors are the predefined schedules that may be used in place of a cron expression.
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// cronMonths are the names that may be used in place of numbers in the month field of a cron expression.
+var cronMonths = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+// cronWeekdays are the names that may be used in place of numbers in the day of week field of a cron expression.
+var cronWeekdays = map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+// cronSchedule is a parsed cron expression, or a fixed interval, used to derive the times at which events are generated.
+type cronSchedule struct {
+	// every is the fixed interval between events, for "@every {DURATION}" schedules. If zero the fields below are used.
+	every time.Duration
+	// minutes, hours, days, months and weekdays are the sets of values matched by each field of a cron expression.
+	minutes  map[int]bool
+	hours    map[int]bool
+	days     map[int]bool
+	months   map[int]bool
+	weekdays map[int]bool
+	// any_day and any_weekday signal that the day of month and day of week fields, respectively, are "*".
+	any_day     bool
+	any_weekday bool
+}
+
+// parseCronSchedule parses 'expr' which may be a standard five-field cron expression (minute, hour, day of month, month
+// and day of week), one of the predefined "@yearly", "@monthly", "@weekly", "@daily" or "@hourly" descriptors or
+// "@every {DURATION}".
+func parseCronSchedule(expr string) (*cronSchedule, error) {
+
+	expr = strings.TrimSpace(expr)
+
+	if strings.HasPrefix(expr, "@every ") {
+
+		d, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(expr, "@every ")))
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid interval, %w", err)
+		}
+
+		if d < time.Second {
+			return nil, fmt.Errorf("Interval must be at least one second")
+		}
+
+		return &cronSchedule{every: d}, nil
+	}
+
+	if strings.HasPrefix(expr, "@") {
+
+		v, ok := cronDescriptors[strings.ToLower(expr)]
+
+		if !ok {
+			return nil, fmt.Errorf("Unsupported descriptor '%s'", expr)
+		}
+
+		expr = v
+	}
+
+	fields := strings.Fields(expr)
+
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("Expected 5 fields but got %d", len(fields))
+	}
+
+	s := &cronSchedule{
+		any_day:     fields[2] == "*",
+		any_weekday: fields[4] == "*",
+	}
+
+	var err error
+
+	s.minutes, err = parseCronField(fields[0], 0, 59, nil)
+
+	if err != nil {
+		return nil, fmt.Errorf("Invalid minute field, %w", err)
+	}
+
+	s.hours, err = parseCronField(fields[1], 0, 23, nil)
+
+	if err != nil {
+		return nil, fmt.Errorf("Invalid hour field, %w", err)
+	}
+
+	s.days, err = parseCronField(fields[2], 1, 31, nil)
+
+	if err != nil {
+		return nil, fmt.Errorf("Invalid day of month field, %w", err)
+	}
+
+	s.months, err = parseCronField(fields[3], 1, 12, cronMonths)
+
+	if err != nil {
+		return nil, fmt.Errorf("Invalid month field, %w", err)
+	}
+
+	s.weekdays, err = parseCronField(fields[4], 0, 7, cronWeekdays)
+
+	if err != nil {
+		return nil, fmt.Errorf("Invalid day of week field, %w", err)
+	}
+
+	// Both 0 and 7 are Sunday
+
+	if s.weekdays[7] {
+		s.weekdays[0] = true
+	}
+
+	return s, nil
+}
+
+// parseCronField parses a single field of a cron expression, a comma-separated list of "*", values or ranges optionally
+// followed by a "/{STEP}", returning the set of values between 'min' and 'max' it matches.
+func parseCronField(field string, min int, max int, names map[string]int) (map[int]bool, error) {
+
+	values := make(map[int]bool)
+
+	for _, part := range strings.Split(field, ",") {
+
+		step := 1
+		expr := part
+
+		idx := strings.Index(part, "/")
+
+		if idx != -1 {
+
+			v, err := strconv.Atoi(part[idx+1:])
+
+			if err != nil || v < 1 {
+				return nil, fmt.Errorf("Invalid step in '%s'", part)
+			}
+
+			step = v
+			expr = part[:idx]
+		}
+
+		start := min
+		end := max
+
+		switch {
+		case expr == "*":
+			// pass
+		case strings.Contains(expr, "-"):
+
+			bounds := strings.SplitN(expr, "-", 2)
+
+			v_start, err := parseCronValue(bounds[0], min, max, names)
+
+			if err != nil {
+				return nil, err
+			}
+
+			v_end, err := parseCronValue(bounds[1], min, max, names)
+
+			if err != nil {
+				return nil, err
+			}
+
+			if v_end < v_start {
+				return nil, fmt.Errorf("Invalid range '%s'", expr)
+			}
+
+			start = v_start
+			end = v_end
+
+		default:
+
+			v, err := parseCronValue(expr, min, max, names)
+
+			if err != nil {
+				return nil, err
+			}
+
+			start = v
+
+			// A single value is only expanded to the end of the range if it has a step (for example "5/15")
+
+			if idx == -1 {
+				end = v
+			}
+		}
+
+		for i := start; i <= end; i += step {
+			values[i] = true
+		}
+	}
+
+	return values, nil
+}
+
+// parseCronValue parses a single numeric, or named, value in a cron expression ensuring that it is between 'min' and 'max'.
+func parseCronValue(str_v string, min int, max int, names map[string]int) (int, error) {
+
+	v, ok := names[strings.ToLower(str_v)]
+
+	if ok {
+		return v, nil
+	}
+
+	v, err := strconv.Atoi(str_v)
+
+	if err != nil {
+		return 0, fmt.Errorf("Invalid value '%s'", str_v)
+	}
+
+	if v < min || v > max {
+		return 0, fmt.Errorf("Value %d is outside the range %d-%d", v, min, max)
+	}
+
+	return v, nil
+}
+
+// next returns the first time, after 't', matching 's'. It returns the zero time if there is no matching time in the next
+// five years (for example "0 0 31 2 *").
+func (s *cronSchedule) next(t time.Time) time.Time {
+
+	if s.every > 0 {
+		return t.Truncate(time.Second).Add(s.every)
+	}
+
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+
+		if !s.months[int(t.Month())] {
+			t = cronAdvance(t, time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location()))
+			continue
+		}
+
+		if !s.matchDay(t) {
+			t = cronAdvance(t, time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location()))
+			continue
+		}
+
+		if !s.hours[t.Hour()] {
+			t = cronAdvance(t, time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location()))
+			continue
+		}
+
+		if !s.minutes[t.Minute()] {
+			t = t.Add(time.Minute)
+			continue
+		}
+
+		return t
+	}
+
+	return time.Time{}
+}
+
+// cronAdvance returns 'candidate' if it is after 't'. Otherwise, because 'candidate' falls in a daylight saving time gap
+// and was normalized to a time before 't', it returns the start of the hour following 't'.
+func cronAdvance(t time.Time, candidate time.Time) time.Time {
+
+	if candidate.After(t) {
+		return candidate
+	}
+
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location()).Add(time.Hour)
+}
+
+// matchDay returns true if the day of 't' matches 's'. As with cron(8), if both the day of month and day of week fields
+// are restricted a day matching either field matches.
+func (s *cronSchedule) matchDay(t time.Time) bool {
+
+	day := s.days[t.Day()]
+	weekday := s.weekdays[int(t.Weekday())]
+
+	switch {
+	case s.any_day && s.any_weekday:
+		return true
+	case s.any_day:
+		return weekday
+	case s.any_weekday:
+		return day
+	default:
+		return day || weekday
+	}
+}
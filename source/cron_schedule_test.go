@@ -0,0 +1,116 @@
+package source
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronScheduleNext(t *testing.T) {
+
+	// Wednesday, January 15 2025
+
+	from := time.Date(2025, 1, 15, 10, 30, 15, 0, time.UTC)
+
+	tests := map[string]string{
+		"* * * * *":            "2025-01-15T10:31:00Z",
+		"*/15 * * * *":         "2025-01-15T10:45:00Z",
+		"5/15 * * * *":         "2025-01-15T10:35:00Z",
+		"0 * * * *":            "2025-01-15T11:00:00Z",
+		"0 2 * * *":            "2025-01-16T02:00:00Z",
+		"30 9-17 * * mon-fri":  "2025-01-15T11:30:00Z",
+		"0 0 * * 0":            "2025-01-19T00:00:00Z",
+		"0 0 * * 7":            "2025-01-19T00:00:00Z",
+		"0 0 1 * *":            "2025-02-01T00:00:00Z",
+		"0 0 1,15 * *":         "2025-02-01T00:00:00Z",
+		"0 0 29 feb *":         "2028-02-29T00:00:00Z",
+		"0 12 13 * fri":        "2025-01-17T12:00:00Z",
+		"@hourly":              "2025-01-15T11:00:00Z",
+		"@daily":               "2025-01-16T00:00:00Z",
+		"@weekly":              "2025-01-19T00:00:00Z",
+		"@monthly":             "2025-02-01T00:00:00Z",
+		"@yearly":              "2026-01-01T00:00:00Z",
+		"@every 90s":           "2025-01-15T10:31:45Z",
+		"0 0 31 2 *":           "",
+		"15 10 15 1 wed":       "2025-01-22T10:15:00Z",
+		"15 10 15 jan *":       "2026-01-15T10:15:00Z",
+		"15 10,11 15 jan *":    "2025-01-15T11:15:00Z",
+		"0-10/5 22-23 * DEC *": "2025-12-01T22:00:00Z",
+	}
+
+	for expr, expected := range tests {
+
+		s, err := parseCronSchedule(expr)
+
+		if err != nil {
+			t.Fatalf("Failed to parse '%s', %v", expr, err)
+		}
+
+		next := s.next(from)
+
+		if expected == "" {
+
+			if !next.IsZero() {
+				t.Fatalf("Expected '%s' to have no upcoming times, got %v", expr, next)
+			}
+
+			continue
+		}
+
+		if next.Format(time.RFC3339) != expected {
+			t.Fatalf("Expected '%s' to be %s but got %s", expr, expected, next.Format(time.RFC3339))
+		}
+	}
+}
+
+func TestCronScheduleTimeZone(t *testing.T) {
+
+	loc, err := time.LoadLocation("America/New_York")
+
+	if err != nil {
+		t.Skipf("Time zone database is not available, %v", err)
+	}
+
+	s, err := parseCronSchedule("30 2 * * *")
+
+	if err != nil {
+		t.Fatalf("Failed to parse schedule, %v", err)
+	}
+
+	// 02:30 does not exist on March 9 2025 so the next time is the following day
+
+	from := time.Date(2025, 3, 9, 0, 0, 0, 0, loc)
+	next := s.next(from)
+
+	if next.Format(time.RFC3339) != "2025-03-10T02:30:00-04:00" {
+		t.Fatalf("Unexpected next time: %s", next.Format(time.RFC3339))
+	}
+}
+
+func TestParseCronScheduleInvalid(t *testing.T) {
+
+	tests := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"10-5 * * * *",
+		"* * * foo *",
+		"@sometimes",
+		"@every soon",
+		"@every 10ms",
+	}
+
+	for _, expr := range tests {
+
+		_, err := parseCronSchedule(expr)
+
+		if err == nil {
+			t.Fatalf("Expected '%s' to fail", expr)
+		}
+	}
+}
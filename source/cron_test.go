@@ -0,0 +1,134 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+func TestCronSource(t *testing.T) {
+
+	ctx := context.Background()
+
+	s, err := NewSource(ctx, "cron://?schedule=%40every+1s&tz=UTC")
+
+	if err != nil {
+		t.Fatalf("Failed to create new source, %v", err)
+	}
+
+	defer s.Close()
+
+	consume_ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ch := make(chan *webhookd.WebhookMessage, 2)
+	done := make(chan error)
+
+	go func() {
+
+		done <- s.Consume(consume_ctx, func(ctx context.Context, msg *webhookd.WebhookMessage) *webhookd.WebhookError {
+			ch <- msg
+			return nil
+		})
+	}()
+
+	times := make([]time.Time, 0)
+
+	for i := 0; i < 2; i++ {
+
+		select {
+		case msg := <-ch:
+
+			if msg.Header.Get(CRON_SCHEDULE_HEADER) != "@every 1s" || msg.ID == "" {
+				t.Fatalf("Unexpected message: %v", msg)
+			}
+
+			var ev cronEvent
+
+			err := json.Unmarshal(msg.Body, &ev)
+
+			if err != nil {
+				t.Fatalf("Failed to decode message body, %v", err)
+			}
+
+			if ev.Schedule != "@every 1s" || ev.Time != msg.Header.Get(CRON_TIME_HEADER) {
+				t.Fatalf("Unexpected message body: %s", msg.Body)
+			}
+
+			ev_t, err := time.Parse(time.RFC3339, ev.Time)
+
+			if err != nil {
+				t.Fatalf("Failed to parse time, %v", err)
+			}
+
+			times = append(times, ev_t)
+
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for message")
+		}
+	}
+
+	if times[1].Sub(times[0]) != time.Second {
+		t.Fatalf("Expected messages to be one second apart, got %v", times[1].Sub(times[0]))
+	}
+
+	cancel()
+
+	select {
+	case err := <-done:
+
+		if err != nil {
+			t.Fatalf("Expected cancelled source to return without error, %v", err)
+		}
+
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for source to stop")
+	}
+}
+
+func TestCronSourceBody(t *testing.T) {
+
+	ctx := context.Background()
+
+	s, err := NewSource(ctx, "cron://?schedule=%40every+1s&body=%7B%22event%22%3A%22heartbeat%22%7D")
+
+	if err != nil {
+		t.Fatalf("Failed to create new source, %v", err)
+	}
+
+	err = s.Consume(ctx, func(ctx context.Context, msg *webhookd.WebhookMessage) *webhookd.WebhookError {
+
+		if string(msg.Body) != `{"event":"heartbeat"}` {
+			t.Fatalf("Unexpected message body: %s", msg.Body)
+		}
+
+		return &webhookd.WebhookError{Code: 500, Message: "Failed"}
+	})
+
+	if err == nil {
+		t.Fatalf("Expected failed message to return an error")
+	}
+}
+
+func TestNewCronSourceInvalid(t *testing.T) {
+
+	ctx := context.Background()
+
+	tests := []string{
+		"cron://",
+		"cron://?schedule=%2A+%2A+%2A",
+		"cron://?schedule=%40daily&tz=Nowhere%2FSpecial",
+	}
+
+	for _, uri := range tests {
+
+		_, err := NewSource(ctx, uri)
+
+		if err == nil {
+			t.Fatalf("Expected %s to fail", uri)
+		}
+	}
+}
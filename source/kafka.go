@@ -0,0 +1,420 @@
+package source
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/sasl/plain"
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+// DEFAULT_KAFKA_CLIENT_ID is the default client ID sent to Kafka brokers.
+const DEFAULT_KAFKA_CLIENT_ID string = "webhookd"
+
+// DEFAULT_KAFKA_TIMEOUT is the default amount of time to wait for a connection to a Kafka broker to be established.
+const DEFAULT_KAFKA_TIMEOUT time.Duration = 10 * time.Second
+
+// DEFAULT_KAFKA_MAX_WAIT is the default amount of time a Kafka broker will wait for new records before responding to a fetch request.
+const DEFAULT_KAFKA_MAX_WAIT time.Duration = 500 * time.Millisecond
+
+// DEFAULT_KAFKA_MAX_BYTES is the default maximum number of bytes to fetch, per partition, in a single request.
+const DEFAULT_KAFKA_MAX_BYTES int32 = 1 << 20
+
+// kafkaMaxPollRecords is the maximum number of records processed between offset commits.
+const kafkaMaxPollRecords int = 500
+
+const (
+	// KAFKA_OFFSET_OLDEST starts consuming partitions without a committed offset from the oldest available record.
+	KAFKA_OFFSET_OLDEST string = "oldest"
+	// KAFKA_OFFSET_NEWEST starts consuming partitions without a committed offset from the next record to be written.
+	KAFKA_OFFSET_NEWEST string = "newest"
+)
+
+// Headers added to messages read from Kafka, in addition to any record headers.
+const (
+	KAFKA_TOPIC_HEADER     string = "X-Kafka-Topic"
+	KAFKA_PARTITION_HEADER string = "X-Kafka-Partition"
+	KAFKA_OFFSET_HEADER    string = "X-Kafka-Offset"
+	KAFKA_KEY_HEADER       string = "X-Kafka-Key"
+)
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterSource(ctx, "kafka", NewKafkaSource)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// KafkaSource implements the `webhookd.WebhookSource` interface for consuming messages from a Kafka topic.
+type KafkaSource struct {
+	webhookd.WebhookSource
+	// topic is the name of the topic to consume.
+	topic string
+	// group is the optional consumer group that 's' joins.
+	group string
+	// opts are the `kgo.Opt` options used to create a new `kgo.Client` each time the source is consumed.
+	opts []kgo.Opt
+	// mu is a `sync.Mutex` used to guard 'client'.
+	mu *sync.Mutex
+	// client is the `kgo.Client` used to consume the topic while the source is being consumed.
+	client *kgo.Client
+}
+
+// NewKafkaSource returns a new `KafkaSource` instance configured by 'uri' in the form of:
+//
+//	kafka://[{USERNAME}:{PASSWORD}@]{HOST}:{PORT}[,{HOST}:{PORT}...]/{TOPIC}?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `group={STRING}` The consumer group to join. Partitions are balanced between the members of the group and the offsets of
+// processed messages are committed to the group. If empty offsets are not stored and each partition is consumed starting from
+// `offset` every time the source is started.
+// * `partition={INT}` Zero or more partitions to consume, without a consumer group. Default is all the partitions for the topic.
+// * `offset={STRING}` Where to start consuming partitions without a committed offset. Valid options are "oldest" and "newest". Default is "newest".
+// * `client_id={STRING}` The client ID sent to brokers. Default is "webhookd".
+// * `tls={BOOLEAN}` Connect to brokers using TLS. Default is false.
+// * `timeout={DURATION}` The amount of time to wait for a connection to a broker to be established. Default is "10s".
+// * `max_wait={DURATION}` The amount of time a broker will wait for new records before responding to a fetch request. Default is "500ms".
+// * `max_bytes={INT}` The maximum number of bytes to fetch, per partition, in a single request. Default is 1048576.
+//
+// If a username and password are present they are used to authenticate with brokers using SASL PLAIN. The source uses the
+// franz-go (github.com/twmb/franz-go) client. Offsets are committed once every record returned by a poll has been processed, or
+// a record fails to be processed, so messages may be processed more than once if webhookd is stopped unexpectedly or the
+// group is rebalanced.
+func NewKafkaSource(ctx context.Context, uri string) (webhookd.WebhookSource, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	brokers := make([]string, 0)
+
+	for _, addr := range strings.Split(u.Host, ",") {
+
+		addr = strings.TrimSpace(addr)
+
+		if addr == "" {
+			continue
+		}
+
+		_, _, err := net.SplitHostPort(addr)
+
+		if err != nil {
+			addr = net.JoinHostPort(addr, "9092")
+		}
+
+		brokers = append(brokers, addr)
+	}
+
+	if len(brokers) == 0 {
+		return nil, fmt.Errorf("Missing Kafka brokers")
+	}
+
+	topic := strings.TrimPrefix(u.Path, "/")
+
+	if topic == "" {
+		return nil, fmt.Errorf("Missing Kafka topic")
+	}
+
+	q := u.Query()
+
+	start := kgo.NewOffset().AtEnd()
+
+	switch q.Get("offset") {
+	case "", KAFKA_OFFSET_NEWEST:
+		// pass
+	case KAFKA_OFFSET_OLDEST:
+		start = kgo.NewOffset().AtStart()
+	default:
+		return nil, fmt.Errorf("Invalid ?offset= parameter '%s'", q.Get("offset"))
+	}
+
+	partitions := make(map[int32]kgo.Offset)
+
+	for _, str_p := range q["partition"] {
+
+		p, err := strconv.ParseInt(str_p, 10, 32)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse ?partition= parameter, %w", err)
+		}
+
+		partitions[int32(p)] = start
+	}
+
+	group := q.Get("group")
+
+	if group != "" && len(partitions) > 0 {
+		return nil, fmt.Errorf("?partition= parameters can not be used with a consumer group since partitions are assigned by the group")
+	}
+
+	client_id := DEFAULT_KAFKA_CLIENT_ID
+
+	if q.Has("client_id") {
+		client_id = q.Get("client_id")
+	}
+
+	timeout := DEFAULT_KAFKA_TIMEOUT
+	max_wait := DEFAULT_KAFKA_MAX_WAIT
+
+	durations := map[string]*time.Duration{
+		"timeout":  &timeout,
+		"max_wait": &max_wait,
+	}
+
+	for k, ptr := range durations {
+
+		str_v := q.Get(k)
+
+		if str_v == "" {
+			continue
+		}
+
+		v, err := time.ParseDuration(str_v)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse ?%s= parameter, %w", k, err)
+		}
+
+		*ptr = v
+	}
+
+	max_bytes := DEFAULT_KAFKA_MAX_BYTES
+
+	str_bytes := q.Get("max_bytes")
+
+	if str_bytes != "" {
+
+		v, err := strconv.ParseInt(str_bytes, 10, 32)
+
+		if err != nil || v <= 0 {
+			return nil, fmt.Errorf("Invalid ?max_bytes= parameter '%s'", str_bytes)
+		}
+
+		max_bytes = int32(v)
+	}
+
+	opts := []kgo.Opt{
+		kgo.SeedBrokers(brokers...),
+		kgo.ClientID(client_id),
+		kgo.DialTimeout(timeout),
+		kgo.FetchMaxWait(max_wait),
+		kgo.FetchMaxPartitionBytes(max_bytes),
+		kgo.ConsumeResetOffset(start),
+	}
+
+	switch {
+	case group != "":
+
+		// Offsets are committed explicitly, once messages have been processed, and the group is not rebalanced while
+		// messages are being processed so that partitions are not revoked before their offsets are committed
+
+		opts = append(opts,
+			kgo.ConsumerGroup(group),
+			kgo.ConsumeTopics(topic),
+			kgo.DisableAutoCommit(),
+			kgo.BlockRebalanceOnPoll(),
+		)
+
+	case len(partitions) > 0:
+		opts = append(opts, kgo.ConsumePartitions(map[string]map[int32]kgo.Offset{topic: partitions}))
+	default:
+		opts = append(opts, kgo.ConsumeTopics(topic))
+	}
+
+	str_tls := q.Get("tls")
+
+	if str_tls != "" {
+
+		v, err := strconv.ParseBool(str_tls)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse ?tls= parameter, %w", err)
+		}
+
+		if v {
+			opts = append(opts, kgo.DialTLSConfig(&tls.Config{MinVersion: tls.VersionTLS12}))
+		}
+	}
+
+	if u.User != nil {
+
+		password, _ := u.User.Password()
+
+		auth := plain.Auth{
+			User: u.User.Username(),
+			Pass: password,
+		}
+
+		opts = append(opts, kgo.SASL(auth.AsMechanism()))
+	}
+
+	s := &KafkaSource{
+		topic: topic,
+		group: group,
+		opts:  opts,
+		mu:    new(sync.Mutex),
+	}
+
+	return s, nil
+}
+
+// Consume reads records from the topic that 's' was instantiated with, passing each one to 'fn', until 'ctx' is cancelled.
+// Each message body is the value of a record and its headers are the record headers along with `X-Kafka-Topic`, `X-Kafka-Partition`,
+// `X-Kafka-Offset` and (if present) `X-Kafka-Key` headers. If 'fn' returns an error then the offsets for the records that have
+// already been processed are committed and an error is returned, so the record that failed will be processed again the next
+// time the source is started.
+func (s *KafkaSource) Consume(ctx context.Context, fn webhookd.WebhookSourceFunc) error {
+
+	client, err := kgo.NewClient(s.opts...)
+
+	if err != nil {
+		return fmt.Errorf("Failed to create Kafka client, %w", err)
+	}
+
+	s.mu.Lock()
+	s.client = client
+	s.mu.Unlock()
+
+	defer s.Close()
+
+	for {
+
+		fetches := client.PollRecords(ctx, kafkaMaxPollRecords)
+
+		if ctx.Err() != nil || fetches.IsClientClosed() {
+			return nil
+		}
+
+		for _, fetch_err := range fetches.Errors() {
+
+			if errors.Is(fetch_err.Err, context.Canceled) || errors.Is(fetch_err.Err, kgo.ErrClientClosed) {
+				return nil
+			}
+
+			return fmt.Errorf("Failed to fetch records for topic '%s' partition %d, %w", fetch_err.Topic, fetch_err.Partition, fetch_err.Err)
+		}
+
+		processed := make([]*kgo.Record, 0)
+
+		var werr *webhookd.WebhookError
+		var failed *webhookd.WebhookMessage
+
+		iter := fetches.RecordIter()
+
+		for !iter.Done() {
+
+			rec := iter.Next()
+			msg := s.message(rec)
+
+			werr = fn(ctx, msg)
+
+			if werr != nil {
+				failed = msg
+				break
+			}
+
+			processed = append(processed, rec)
+		}
+
+		err = s.commit(client, processed)
+
+		if werr != nil {
+			return fmt.Errorf("Failed to process message %s, %w", failed.ID, werr)
+		}
+
+		if err != nil {
+
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			return err
+		}
+
+		if s.group != "" {
+			client.AllowRebalance()
+		}
+	}
+}
+
+// Close closes the Kafka client, leaving the consumer group if 's' has one, if the source is being consumed.
+func (s *KafkaSource) Close() error {
+
+	s.mu.Lock()
+	client := s.client
+	s.client = nil
+	s.mu.Unlock()
+
+	// Rebalances are blocked while records are being processed so they must be allowed before leaving the group
+
+	if client != nil {
+		client.CloseAllowingRebalance()
+	}
+
+	return nil
+}
+
+// message returns a new `webhookd.WebhookMessage` instance for 'rec'.
+func (s *KafkaSource) message(rec *kgo.Record) *webhookd.WebhookMessage {
+
+	str_partition := strconv.Itoa(int(rec.Partition))
+	str_offset := strconv.FormatInt(rec.Offset, 10)
+
+	headers := make(http.Header)
+
+	for _, h := range rec.Headers {
+		headers.Add(h.Key, string(h.Value))
+	}
+
+	headers.Set(KAFKA_TOPIC_HEADER, rec.Topic)
+	headers.Set(KAFKA_PARTITION_HEADER, str_partition)
+	headers.Set(KAFKA_OFFSET_HEADER, str_offset)
+
+	if len(rec.Key) > 0 {
+		headers.Set(KAFKA_KEY_HEADER, string(rec.Key))
+	}
+
+	msg := &webhookd.WebhookMessage{
+		ID:     fmt.Sprintf("%s-%s-%s", rec.Topic, str_partition, str_offset),
+		Body:   rec.Value,
+		Header: headers,
+	}
+
+	return msg
+}
+
+// commit commits the offsets of 'records' to the consumer group for 's'. It is a no-op if 's' does not have a consumer group.
+func (s *KafkaSource) commit(client *kgo.Client, records []*kgo.Record) error {
+
+	if s.group == "" || len(records) == 0 {
+		return nil
+	}
+
+	// Commit even if 'ctx' has been cancelled so that the records which were processed are not processed again
+
+	commit_ctx, cancel := context.WithTimeout(context.Background(), DEFAULT_KAFKA_TIMEOUT)
+	defer cancel()
+
+	err := client.CommitRecords(commit_ctx, records...)
+
+	if err != nil {
+		return fmt.Errorf("Failed to commit offsets for group '%s', %w", s.group, err)
+	}
+
+	return nil
+}
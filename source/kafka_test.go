@@ -0,0 +1,236 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kfake"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+// newKafkaTestCluster returns a new in-process Kafka cluster with a topic named 'topic', with 'partitions' partitions, containing
+// 'records'.
+func newKafkaTestCluster(t *testing.T, topic string, partitions int32, records []*kgo.Record) *kfake.Cluster {
+
+	c, err := kfake.NewCluster(kfake.NumBrokers(1), kfake.SeedTopics(partitions, topic))
+
+	if err != nil {
+		t.Fatalf("Failed to create Kafka cluster, %v", err)
+	}
+
+	t.Cleanup(c.Close)
+
+	producer, err := kgo.NewClient(kgo.SeedBrokers(c.ListenAddrs()...), kgo.RecordPartitioner(kgo.ManualPartitioner()))
+
+	if err != nil {
+		t.Fatalf("Failed to create Kafka producer, %v", err)
+	}
+
+	defer producer.Close()
+
+	for _, r := range records {
+		r.Topic = topic
+	}
+
+	err = producer.ProduceSync(context.Background(), records...).FirstErr()
+
+	if err != nil {
+		t.Fatalf("Failed to produce records, %v", err)
+	}
+
+	return c
+}
+
+func TestKafkaSource(t *testing.T) {
+
+	ctx := context.Background()
+
+	records := []*kgo.Record{
+		{Value: []byte("one"), Headers: []kgo.RecordHeader{{Key: "X-Event", Value: []byte("push")}}},
+		{Key: []byte("k"), Value: []byte("two")},
+		{Value: []byte("three")},
+	}
+
+	c := newKafkaTestCluster(t, "events", 1, records)
+
+	uri := fmt.Sprintf("kafka://%s/events?group=webhookd&offset=oldest&max_wait=10ms", strings.Join(c.ListenAddrs(), ","))
+
+	s, err := NewSource(ctx, uri)
+
+	if err != nil {
+		t.Fatalf("Failed to create new source, %v", err)
+	}
+
+	// The first attempt fails to process the second message
+
+	seen := make([]*webhookd.WebhookMessage, 0)
+
+	err = s.Consume(ctx, func(ctx context.Context, msg *webhookd.WebhookMessage) *webhookd.WebhookError {
+
+		if string(msg.Body) == "two" {
+			return &webhookd.WebhookError{Code: 500, Message: "Failed"}
+		}
+
+		seen = append(seen, msg)
+		return nil
+	})
+
+	if err == nil {
+		t.Fatalf("Expected failed message to return an error")
+	}
+
+	if len(seen) != 1 {
+		t.Fatalf("Expected exactly one message to be processed, got %d", len(seen))
+	}
+
+	if seen[0].ID != "events-0-0" || seen[0].Header.Get("X-Event") != "push" || seen[0].Header.Get(KAFKA_TOPIC_HEADER) != "events" {
+		t.Fatalf("Unexpected message: %v", seen[0])
+	}
+
+	// The second attempt resumes from the committed offset
+
+	consume_ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ch := make(chan *webhookd.WebhookMessage, len(records))
+	done := make(chan error)
+
+	go func() {
+
+		done <- s.Consume(consume_ctx, func(ctx context.Context, msg *webhookd.WebhookMessage) *webhookd.WebhookError {
+			ch <- msg
+			return nil
+		})
+	}()
+
+	for _, expected := range []string{"two", "three"} {
+
+		select {
+		case msg := <-ch:
+
+			if string(msg.Body) != expected {
+				t.Fatalf("Expected %s but got %s", expected, string(msg.Body))
+			}
+
+			if expected == "two" && msg.Header.Get(KAFKA_KEY_HEADER) != "k" {
+				t.Fatalf("Unexpected key header '%s'", msg.Header.Get(KAFKA_KEY_HEADER))
+			}
+
+		case <-time.After(10 * time.Second):
+			t.Fatalf("Timed out waiting for message")
+		}
+	}
+
+	cancel()
+
+	select {
+	case err := <-done:
+
+		if err != nil {
+			t.Fatalf("Expected cancelled source to return without error, %v", err)
+		}
+
+	case <-time.After(10 * time.Second):
+		t.Fatalf("Timed out waiting for source to stop")
+	}
+}
+
+func TestKafkaSourceConsumerGroup(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	records := make([]*kgo.Record, 0)
+
+	for i := 0; i < 20; i++ {
+		r := &kgo.Record{Partition: int32(i % 4), Value: []byte(fmt.Sprintf("%d", i))}
+		records = append(records, r)
+	}
+
+	c := newKafkaTestCluster(t, "events", 4, records)
+
+	uri := fmt.Sprintf("kafka://%s/events?group=webhookd&offset=oldest&max_wait=10ms", strings.Join(c.ListenAddrs(), ","))
+
+	// Sources sharing a group divide the partitions between themselves so each record is only processed once
+
+	ch := make(chan string, len(records)*2)
+
+	wg := new(sync.WaitGroup)
+
+	for i := 0; i < 2; i++ {
+
+		s, err := NewSource(ctx, uri)
+
+		if err != nil {
+			t.Fatalf("Failed to create new source, %v", err)
+		}
+
+		wg.Add(1)
+
+		go func() {
+
+			defer wg.Done()
+
+			s.Consume(ctx, func(ctx context.Context, msg *webhookd.WebhookMessage) *webhookd.WebhookError {
+				ch <- string(msg.Body)
+				return nil
+			})
+		}()
+	}
+
+	seen := make(map[string]int)
+
+	for len(seen) < len(records) {
+
+		select {
+		case body := <-ch:
+			seen[body] += 1
+		case <-time.After(10 * time.Second):
+			t.Fatalf("Timed out waiting for records, got %d", len(seen))
+		}
+	}
+
+	cancel()
+	wg.Wait()
+
+	close(ch)
+
+	for body := range ch {
+		seen[body] += 1
+	}
+
+	for body, count := range seen {
+
+		if count != 1 {
+			t.Fatalf("Expected record %s to be processed once but it was processed %d times", body, count)
+		}
+	}
+}
+
+func TestNewKafkaSourceInvalid(t *testing.T) {
+
+	ctx := context.Background()
+
+	tests := []string{
+		"kafka:///events",
+		"kafka://localhost:9092",
+		"kafka://localhost:9092/events?offset=middle",
+		"kafka://localhost:9092/events?partition=a",
+		"kafka://localhost:9092/events?max_bytes=0",
+		"kafka://localhost:9092/events?group=webhookd&partition=0",
+	}
+
+	for _, uri := range tests {
+
+		_, err := NewSource(ctx, uri)
+
+		if err == nil {
+			t.Fatalf("Expected %s to fail", uri)
+		}
+	}
+}
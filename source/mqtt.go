@@ -0,0 +1,368 @@
+package source
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+// DEFAULT_MQTT_KEEPALIVE is the default keep alive interval for connections to an MQTT broker.
+const DEFAULT_MQTT_KEEPALIVE time.Duration = 60 * time.Second
+
+// DEFAULT_MQTT_TIMEOUT is the default amount of time to wait for a connection to an MQTT broker to be established.
+const DEFAULT_MQTT_TIMEOUT time.Duration = 10 * time.Second
+
+// DEFAULT_MQTT_QOS is the default maximum QoS level for MQTT subscriptions.
+const DEFAULT_MQTT_QOS int = 1
+
+// mqttMaxPending is the maximum number of messages received from an MQTT broker that may be waiting to be processed before the
+// client stops reading from the broker.
+const mqttMaxPending int = 1024
+
+// Headers added to messages read from an MQTT broker.
+const (
+	MQTT_TOPIC_HEADER  string = "X-Mqtt-Topic"
+	MQTT_QOS_HEADER    string = "X-Mqtt-Qos"
+	MQTT_RETAIN_HEADER string = "X-Mqtt-Retain"
+	MQTT_DUP_HEADER    string = "X-Mqtt-Dup"
+)
+
+func init() {
+
+	ctx := context.Background()
+
+	for _, scheme := range []string{"mqtt", "mqtts"} {
+
+		err := RegisterSource(ctx, scheme, NewMQTTSource)
+
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+// MQTTSource implements the `webhookd.WebhookSource` interface for consuming messages published to one or more MQTT topics using
+// the Eclipse Paho MQTT client.
+type MQTTSource struct {
+	webhookd.WebhookSource
+	// topics is the list of topic filters to subscribe to.
+	topics []string
+	// qos is the maximum QoS level for subscriptions.
+	qos byte
+	// opts are the options used to connect to the broker.
+	opts *mqtt.ClientOptions
+	// mu is a `sync.Mutex` used to guard 'client'.
+	mu *sync.Mutex
+	// client is the current client connected to the broker.
+	client mqtt.Client
+}
+
+// NewMQTTSource returns a new `MQTTSource` instance configured by 'uri' in the form of:
+//
+//	mqtt://[{USERNAME}[:{PASSWORD}]@]{HOST}[:{PORT}]?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `topic={STRING}` A topic filter to subscribe to. Filters may contain the "+" and "#" wildcards, which must be URL-encoded as "%2B" and "%23". This parameter may be passed multiple times. Required.
+// * `qos={INT}` The maximum QoS level (0, 1 or 2) for subscriptions. Default is 1.
+// * `client_id={STRING}` The client identifier sent to the broker. Required if `clean_session` is false.
+// * `clean_session={BOOLEAN}` Discard any existing session when connecting. Default is true.
+// * `keepalive={DURATION}` The keep alive interval for the connection. A value of "0s" disables keep alives. Default is "60s".
+// * `timeout={DURATION}` The amount of time to wait for a connection to be established. Default is "10s".
+//
+// Use the `mqtts://` scheme to connect using TLS.
+func NewMQTTSource(ctx context.Context, uri string) (webhookd.WebhookSource, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("Missing MQTT host")
+	}
+
+	q := u.Query()
+
+	topics := q["topic"]
+
+	if len(topics) == 0 {
+		return nil, fmt.Errorf("Missing ?topic= parameter")
+	}
+
+	for _, t := range topics {
+
+		err := validMQTTTopicFilter(t)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	qos := DEFAULT_MQTT_QOS
+
+	str_qos := q.Get("qos")
+
+	if str_qos != "" {
+
+		v, err := strconv.Atoi(str_qos)
+
+		if err != nil || v < 0 || v > 2 {
+			return nil, fmt.Errorf("Invalid ?qos= parameter '%s'", str_qos)
+		}
+
+		qos = v
+	}
+
+	client_id := q.Get("client_id")
+	clean_session := true
+
+	str_clean := q.Get("clean_session")
+
+	if str_clean != "" {
+
+		v, err := strconv.ParseBool(str_clean)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse ?clean_session= parameter, %w", err)
+		}
+
+		clean_session = v
+	}
+
+	if !clean_session && client_id == "" {
+		return nil, fmt.Errorf("Missing ?client_id= parameter, required when clean_session is false")
+	}
+
+	keepalive := DEFAULT_MQTT_KEEPALIVE
+	timeout := DEFAULT_MQTT_TIMEOUT
+
+	durations := map[string]*time.Duration{
+		"keepalive": &keepalive,
+		"timeout":   &timeout,
+	}
+
+	for k, ptr := range durations {
+
+		str_v := q.Get(k)
+
+		if str_v == "" {
+			continue
+		}
+
+		v, err := time.ParseDuration(str_v)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse ?%s= parameter, %w", k, err)
+		}
+
+		*ptr = v
+	}
+
+	if keepalive < 0 || keepalive > 65535*time.Second {
+		return nil, fmt.Errorf("Invalid ?keepalive= parameter")
+	}
+
+	opts := mqtt.NewClientOptions()
+	opts.SetClientID(client_id)
+	opts.SetCleanSession(clean_session)
+	opts.SetKeepAlive(keepalive)
+	opts.SetConnectTimeout(timeout)
+	opts.SetAutoReconnect(false)
+	opts.SetAutoAckDisabled(true)
+
+	scheme := "tcp"
+	port := "1883"
+
+	if u.Scheme == "mqtts" {
+		scheme = "ssl"
+		port = "8883"
+		opts.SetTLSConfig(&tls.Config{MinVersion: tls.VersionTLS12, ServerName: u.Hostname()})
+	}
+
+	if u.Port() != "" {
+		port = u.Port()
+	}
+
+	opts.AddBroker(fmt.Sprintf("%s://%s", scheme, net.JoinHostPort(u.Hostname(), port)))
+
+	if u.User != nil {
+		password, _ := u.User.Password()
+		opts.SetUsername(u.User.Username())
+		opts.SetPassword(password)
+	}
+
+	s := &MQTTSource{
+		topics: topics,
+		qos:    byte(qos),
+		opts:   opts,
+		mu:     new(sync.Mutex),
+	}
+
+	return s, nil
+}
+
+// Consume subscribes to the topics that 's' was instantiated with, passing each message published to them to 'fn', until 'ctx'
+// is cancelled. Each message body is the payload of the MQTT message and its headers are `X-Mqtt-Topic`, `X-Mqtt-Qos`,
+// `X-Mqtt-Retain` and `X-Mqtt-Dup`. QoS 1 and 2 messages are acknowledged once 'fn' returns without error. If 'fn' returns an
+// error the message is not acknowledged and an error is returned; brokers will only redeliver the message once the source is
+// restarted if 's' was instantiated with a fixed `client_id` and `clean_session=false`.
+func (s *MQTTSource) Consume(ctx context.Context, fn webhookd.WebhookSourceFunc) error {
+
+	// Messages are handed off to the loop below, in the order they were received, rather than processed by the message handler
+	// so that slow messages don't stop the client from reading from the broker.
+
+	done := make(chan bool)
+
+	pending := make(chan mqtt.Message, mqttMaxPending)
+	lost := make(chan error, 1)
+
+	handler := func(c mqtt.Client, m mqtt.Message) {
+
+		select {
+		case pending <- m:
+			// pass
+		case <-done:
+			// pass
+		}
+	}
+
+	opts := *s.opts
+	opts.SetDefaultPublishHandler(handler)
+
+	opts.SetConnectionLostHandler(func(c mqtt.Client, err error) {
+		lost <- err
+	})
+
+	client := mqtt.NewClient(&opts)
+
+	t := client.Connect()
+	t.Wait()
+
+	err := t.Error()
+
+	if err != nil {
+		return fmt.Errorf("Failed to connect to MQTT broker, %w", err)
+	}
+
+	s.mu.Lock()
+	s.client = client
+	s.mu.Unlock()
+
+	defer s.Close()
+	defer close(done)
+
+	filters := make(map[string]byte)
+
+	for _, topic := range s.topics {
+		filters[topic] = s.qos
+	}
+
+	sub := client.SubscribeMultiple(filters, handler)
+
+	select {
+	case <-ctx.Done():
+		return nil
+	case <-sub.Done():
+		// pass
+	}
+
+	err = sub.Error()
+
+	if err != nil {
+		return fmt.Errorf("Failed to subscribe, %w", err)
+	}
+
+	for topic, code := range sub.(*mqtt.SubscribeToken).Result() {
+
+		if code == 0x80 {
+			return fmt.Errorf("Failed to subscribe, broker rejected topic filter '%s'", topic)
+		}
+	}
+
+	for {
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-lost:
+			return fmt.Errorf("Connection to MQTT broker lost, %w", err)
+		case m := <-pending:
+
+			werr := fn(ctx, s.message(m))
+
+			if werr != nil {
+				return fmt.Errorf("Failed to process message, %w", werr)
+			}
+
+			m.Ack()
+		}
+	}
+}
+
+// Close disconnects the current client from the broker, if there is one.
+func (s *MQTTSource) Close() error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.client == nil {
+		return nil
+	}
+
+	s.client.Disconnect(250)
+	s.client = nil
+
+	return nil
+}
+
+// message returns a new `webhookd.WebhookMessage` instance for 'm'.
+func (s *MQTTSource) message(m mqtt.Message) *webhookd.WebhookMessage {
+
+	headers := http.Header{}
+	headers.Set(MQTT_TOPIC_HEADER, m.Topic())
+	headers.Set(MQTT_QOS_HEADER, strconv.Itoa(int(m.Qos())))
+	headers.Set(MQTT_RETAIN_HEADER, strconv.FormatBool(m.Retained()))
+	headers.Set(MQTT_DUP_HEADER, strconv.FormatBool(m.Duplicate()))
+
+	msg := &webhookd.WebhookMessage{
+		Body:   m.Payload(),
+		Header: headers,
+	}
+
+	return msg
+}
+
+// validMQTTTopicFilter returns an error if 'filter' is not a valid MQTT topic filter. The multi-level wildcard ("#") must be the
+// last character in a filter and both wildcards must occupy an entire level of a filter.
+func validMQTTTopicFilter(filter string) error {
+
+	if filter == "" {
+		return fmt.Errorf("Topic filter is empty")
+	}
+
+	levels := strings.Split(filter, "/")
+
+	for i, level := range levels {
+
+		if strings.Contains(level, "#") && (level != "#" || i != len(levels)-1) {
+			return fmt.Errorf("Invalid use of '#' wildcard in topic filter '%s'", filter)
+		}
+
+		if strings.Contains(level, "+") && level != "+" {
+			return fmt.Errorf("Invalid use of '+' wildcard in topic filter '%s'", filter)
+		}
+	}
+
+	return nil
+}
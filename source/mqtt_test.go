@@ -0,0 +1,520 @@
+package source
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+// MQTT control packet types used by `mqttTestBroker`.
+const (
+	mqttConnect    byte = 1
+	mqttConnack    byte = 2
+	mqttPublish    byte = 3
+	mqttPuback     byte = 4
+	mqttSubscribe  byte = 8
+	mqttSuback     byte = 9
+	mqttPingreq    byte = 12
+	mqttPingresp   byte = 13
+	mqttDisconnect byte = 14
+)
+
+// mqttTestMessage is an application message published by `mqttTestBroker`.
+type mqttTestMessage struct {
+	topic     string
+	qos       byte
+	retain    bool
+	dup       bool
+	packet_id uint16
+	payload   []byte
+}
+
+// mqttTestPacket is a single MQTT control packet read by `mqttTestBroker`.
+type mqttTestPacket struct {
+	kind  byte
+	flags byte
+	body  []byte
+}
+
+// appendMQTTString appends the length-prefixed UTF-8 string 'v' to 'b'.
+func appendMQTTString(b []byte, v string) []byte {
+	b = append(b, byte(len(v)>>8), byte(len(v)))
+	return append(b, v...)
+}
+
+// readMQTTString reads a length-prefixed UTF-8 string from the start of 'b' returning it and the remainder of 'b'.
+func readMQTTString(b []byte) (string, []byte, error) {
+
+	if len(b) < 2 {
+		return "", nil, io.ErrUnexpectedEOF
+	}
+
+	n := int(binary.BigEndian.Uint16(b))
+
+	if len(b) < 2+n {
+		return "", nil, io.ErrUnexpectedEOF
+	}
+
+	return string(b[2 : 2+n]), b[2+n:], nil
+}
+
+// appendMQTTLength appends the MQTT "remaining length" encoding of 'n' to 'b'.
+func appendMQTTLength(b []byte, n int) []byte {
+
+	for {
+
+		d := byte(n % 128)
+		n = n / 128
+
+		if n > 0 {
+			d = d | 0x80
+		}
+
+		b = append(b, d)
+
+		if n == 0 {
+			return b
+		}
+	}
+}
+
+// writeMQTTPacket writes a control packet of type 'kind' with 'flags' and 'body' to 'conn'.
+func writeMQTTPacket(conn net.Conn, kind byte, flags byte, body []byte) {
+
+	b := []byte{kind<<4 | flags}
+	b = appendMQTTLength(b, len(body))
+	b = append(b, body...)
+
+	conn.Write(b)
+}
+
+// readMQTTPacket reads the next control packet from 'r'.
+func readMQTTPacket(r *bufio.Reader) (*mqttTestPacket, error) {
+
+	hdr, err := r.ReadByte()
+
+	if err != nil {
+		return nil, err
+	}
+
+	size := 0
+	multiplier := 1
+
+	for {
+
+		d, err := r.ReadByte()
+
+		if err != nil {
+			return nil, err
+		}
+
+		size += int(d&0x7f) * multiplier
+
+		if d&0x80 == 0 {
+			break
+		}
+
+		multiplier = multiplier * 128
+	}
+
+	body := make([]byte, size)
+
+	_, err = io.ReadFull(r, body)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &mqttTestPacket{kind: hdr >> 4, flags: hdr & 0x0f, body: body}, nil
+}
+
+// writeMQTTPublish writes the PUBLISH packet for 'm' to 'conn'.
+func writeMQTTPublish(conn net.Conn, m *mqttTestMessage) {
+
+	flags := m.qos << 1
+
+	if m.retain {
+		flags |= 0x01
+	}
+
+	if m.dup {
+		flags |= 0x08
+	}
+
+	body := appendMQTTString(nil, m.topic)
+
+	if m.qos > 0 {
+		body = append(body, byte(m.packet_id>>8), byte(m.packet_id))
+	}
+
+	body = append(body, m.payload...)
+
+	writeMQTTPacket(conn, mqttPublish, flags, body)
+}
+
+// mqttTestBroker is a minimal MQTT 3.1.1 broker, with a single persistent session, used to test `MQTTSource` against the Paho
+// client.
+type mqttTestBroker struct {
+	listener  net.Listener
+	mu        *sync.Mutex
+	messages  []*mqttTestMessage
+	acked     map[uint16]bool
+	delivered map[uint16]bool
+	filters   []string
+}
+
+func newMQTTTestBroker(t *testing.T, messages []*mqttTestMessage) *mqttTestBroker {
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatalf("Failed to create listener, %v", err)
+	}
+
+	b := &mqttTestBroker{
+		listener:  l,
+		mu:        new(sync.Mutex),
+		messages:  messages,
+		acked:     make(map[uint16]bool),
+		delivered: make(map[uint16]bool),
+	}
+
+	go func() {
+
+		for {
+
+			conn, err := l.Accept()
+
+			if err != nil {
+				return
+			}
+
+			go b.serve(conn)
+		}
+	}()
+
+	t.Cleanup(func() {
+		l.Close()
+	})
+
+	return b
+}
+
+func (b *mqttTestBroker) Acked(id uint16) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.acked[id]
+}
+
+func (b *mqttTestBroker) Filters() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.filters
+}
+
+func (b *mqttTestBroker) serve(conn net.Conn) {
+
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+
+	p, err := readMQTTPacket(r)
+
+	if err != nil || p.kind != mqttConnect {
+		return
+	}
+
+	proto, rest, err := readMQTTString(p.body)
+
+	if err != nil || proto != "MQTT" || len(rest) < 4 {
+		return
+	}
+
+	client_id, rest, err := readMQTTString(rest[4:])
+
+	if err != nil {
+		return
+	}
+
+	username, rest, _ := readMQTTString(rest)
+	password, _, _ := readMQTTString(rest)
+
+	if client_id != "bridge" || username != "user" || password != "pass" {
+		writeMQTTPacket(conn, mqttConnack, 0, []byte{0, 4})
+		return
+	}
+
+	writeMQTTPacket(conn, mqttConnack, 0, []byte{0, 0})
+
+	p, err = readMQTTPacket(r)
+
+	if err != nil || p.kind != mqttSubscribe || p.flags != 0x02 {
+		return
+	}
+
+	filters := make([]string, 0)
+	codes := make([]byte, 0)
+
+	rest = p.body[2:]
+
+	for len(rest) > 0 {
+
+		f, r, err := readMQTTString(rest)
+
+		if err != nil || len(r) < 1 {
+			return
+		}
+
+		filters = append(filters, f)
+		codes = append(codes, r[0])
+		rest = r[1:]
+	}
+
+	b.mu.Lock()
+	b.filters = filters
+	b.mu.Unlock()
+
+	writeMQTTPacket(conn, mqttSuback, 0, append(p.body[:2:2], codes...))
+
+	// Unacknowledged messages are redelivered, with the DUP flag set, on subsequent connections
+
+	b.mu.Lock()
+
+	for _, m := range b.messages {
+
+		if b.acked[m.packet_id] {
+			continue
+		}
+
+		out := *m
+		out.dup = b.delivered[m.packet_id]
+
+		b.delivered[m.packet_id] = true
+		writeMQTTPublish(conn, &out)
+	}
+
+	b.mu.Unlock()
+
+	for {
+
+		p, err := readMQTTPacket(r)
+
+		if err != nil {
+			return
+		}
+
+		switch p.kind {
+		case mqttPuback:
+			b.mu.Lock()
+			b.acked[uint16(p.body[0])<<8|uint16(p.body[1])] = true
+			b.mu.Unlock()
+		case mqttPingreq:
+			writeMQTTPacket(conn, mqttPingresp, 0, nil)
+		case mqttDisconnect:
+			return
+		}
+	}
+}
+
+func TestMQTTSource(t *testing.T) {
+
+	ctx := context.Background()
+
+	messages := []*mqttTestMessage{
+		{topic: "sensors/kitchen/temperature", qos: 1, packet_id: 1, payload: []byte(`{"value":"one"}`)},
+		{topic: "sensors/hall/temperature", qos: 1, packet_id: 2, payload: []byte(`{"value":"two"}`)},
+		{topic: "alerts/fire", qos: 1, packet_id: 3, retain: true, payload: []byte(`{"value":"three"}`)},
+	}
+
+	broker := newMQTTTestBroker(t, messages)
+
+	uri := fmt.Sprintf("mqtt://user:pass@%s?topic=sensors/%%2B/temperature&topic=alerts/%%23&client_id=bridge&clean_session=false", broker.listener.Addr().String())
+
+	s, err := NewSource(ctx, uri)
+
+	if err != nil {
+		t.Fatalf("Failed to create new source, %v", err)
+	}
+
+	// The first attempt fails to process the second message, which is not acknowledged
+
+	seen := make([]*webhookd.WebhookMessage, 0)
+
+	err = s.Consume(ctx, func(ctx context.Context, msg *webhookd.WebhookMessage) *webhookd.WebhookError {
+
+		if string(msg.Body) == `{"value":"two"}` {
+			return &webhookd.WebhookError{Code: 500, Message: "Failed"}
+		}
+
+		seen = append(seen, msg)
+		return nil
+	})
+
+	if err == nil {
+		t.Fatalf("Expected failed message to return an error")
+	}
+
+	filters := broker.Filters()
+	sort.Strings(filters)
+
+	if len(filters) != 2 || filters[0] != "alerts/#" || filters[1] != "sensors/+/temperature" {
+		t.Fatalf("Unexpected subscription: %v", filters)
+	}
+
+	if len(seen) != 1 {
+		t.Fatalf("Expected exactly one message to be processed, got %d", len(seen))
+	}
+
+	msg := seen[0]
+
+	if msg.Header.Get(MQTT_TOPIC_HEADER) != "sensors/kitchen/temperature" || msg.Header.Get(MQTT_QOS_HEADER) != "1" || msg.Header.Get(MQTT_DUP_HEADER) != "false" {
+		t.Fatalf("Unexpected message: %v", msg)
+	}
+
+	for i := 0; i < 50 && !broker.Acked(1); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if !broker.Acked(1) || broker.Acked(2) {
+		t.Fatalf("Expected only the first message to be acknowledged")
+	}
+
+	// The second attempt receives the unacknowledged message and any remaining messages
+
+	consume_ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ch := make(chan *webhookd.WebhookMessage, len(messages))
+	done := make(chan error)
+
+	go func() {
+
+		done <- s.Consume(consume_ctx, func(ctx context.Context, msg *webhookd.WebhookMessage) *webhookd.WebhookError {
+			ch <- msg
+			return nil
+		})
+	}()
+
+	for _, expected := range []string{"sensors/hall/temperature", "alerts/fire"} {
+
+		select {
+		case msg := <-ch:
+
+			if msg.Header.Get(MQTT_TOPIC_HEADER) != expected {
+				t.Fatalf("Expected %s but got %s", expected, msg.Header.Get(MQTT_TOPIC_HEADER))
+			}
+
+			if expected == "sensors/hall/temperature" && msg.Header.Get(MQTT_DUP_HEADER) != "true" {
+				t.Fatalf("Expected redelivered message to be marked as a duplicate")
+			}
+
+			if expected == "alerts/fire" && msg.Header.Get(MQTT_RETAIN_HEADER) != "true" {
+				t.Fatalf("Expected retained message to be marked as retained")
+			}
+
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for message")
+		}
+	}
+
+	for i := 0; i < 50 && !broker.Acked(3); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if !broker.Acked(2) || !broker.Acked(3) {
+		t.Fatalf("Expected all messages to be acknowledged")
+	}
+
+	cancel()
+
+	select {
+	case err := <-done:
+
+		if err != nil {
+			t.Fatalf("Expected cancelled source to return without error, %v", err)
+		}
+
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for source to stop")
+	}
+}
+
+func TestMQTTSourceConnectionRefused(t *testing.T) {
+
+	ctx := context.Background()
+
+	broker := newMQTTTestBroker(t, nil)
+
+	uri := fmt.Sprintf("mqtt://user:secret@%s?topic=sensors/%%23&client_id=bridge", broker.listener.Addr().String())
+
+	s, err := NewSource(ctx, uri)
+
+	if err != nil {
+		t.Fatalf("Failed to create new source, %v", err)
+	}
+
+	err = s.Consume(ctx, func(ctx context.Context, msg *webhookd.WebhookMessage) *webhookd.WebhookError {
+		return nil
+	})
+
+	if err == nil {
+		t.Fatalf("Expected invalid credentials to fail")
+	}
+}
+
+func TestNewMQTTSourceInvalid(t *testing.T) {
+
+	ctx := context.Background()
+
+	tests := []string{
+		"mqtt://localhost",
+		"mqtt:///?topic=sensors",
+		"mqtt://localhost?topic=sensors/%23/temperature",
+		"mqtt://localhost?topic=sensors/kitchen%2B",
+		"mqtt://localhost?topic=sensors&qos=3",
+		"mqtt://localhost?topic=sensors&clean_session=false",
+		"mqtts://localhost?topic=sensors&keepalive=soon",
+	}
+
+	for _, uri := range tests {
+
+		_, err := NewSource(ctx, uri)
+
+		if err == nil {
+			t.Fatalf("Expected %s to fail", uri)
+		}
+	}
+}
+
+func TestValidMQTTTopicFilter(t *testing.T) {
+
+	tests := map[string]bool{
+		"sensors/kitchen/temperature": true,
+		"sensors/+/temperature":       true,
+		"sensors/#":                   true,
+		"#":                           true,
+		"+/+":                         true,
+		"":                            false,
+		"sensors/#/temperature":       false,
+		"sensors/kitchen#":            false,
+		"sensors/kitchen+":            false,
+	}
+
+	for filter, expected := range tests {
+
+		err := validMQTTTopicFilter(filter)
+
+		if (err == nil) != expected {
+			t.Fatalf("Unexpected result for '%s', %v", filter, err)
+		}
+	}
+}
@@ -0,0 +1,285 @@
+package source
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+// DEFAULT_PIPE_MAX_SIZE is the default maximum size, in bytes, of a single line read from a pipe.
+const DEFAULT_PIPE_MAX_SIZE int = 1 << 20
+
+func init() {
+
+	ctx := context.Background()
+
+	for _, scheme := range []string{"stdin", "fifo"} {
+
+		err := RegisterSource(ctx, scheme, NewPipeSource)
+
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+// PipeSource implements the `webhookd.WebhookSource` interface for consuming newline-delimited messages (for example NDJSON)
+// from STDIN or a named pipe (FIFO).
+type PipeSource struct {
+	webhookd.WebhookSource
+	// path is the path to the named pipe to read from. If empty messages are read from STDIN.
+	path string
+	// max_size is the maximum size, in bytes, of a single line.
+	max_size int
+	// mu is a `sync.Mutex` used to guard 'fh', 'lines', 'done' and 'err'.
+	mu *sync.Mutex
+	// fh is the file that lines are currently being read from.
+	fh *os.File
+	// lines is the channel that lines read from 'fh' are sent to. It is closed once 'fh' has been read completely or can not be read.
+	lines chan []byte
+	// done is the channel used to signal that the Go routine reading from 'fh' should stop.
+	done chan bool
+	// err is the error, if any, that caused reading from 'fh' to stop.
+	err error
+}
+
+// NewPipeSource returns a new `PipeSource` instance configured by 'uri' in the form of:
+//
+//	stdin://?{PARAMETERS}
+//	fifo://{PATH}?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `max_size={INT}` The maximum size, in bytes, of a single line. Default is 1048576.
+func NewPipeSource(ctx context.Context, uri string) (webhookd.WebhookSource, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	path := ""
+
+	if u.Scheme == "fifo" {
+
+		path = u.Path
+
+		if path == "" {
+			return nil, fmt.Errorf("Missing FIFO path")
+		}
+	}
+
+	q := u.Query()
+
+	max_size := DEFAULT_PIPE_MAX_SIZE
+
+	str_size := q.Get("max_size")
+
+	if str_size != "" {
+
+		v, err := strconv.Atoi(str_size)
+
+		if err != nil || v <= 0 {
+			return nil, fmt.Errorf("Invalid ?max_size= parameter '%s'", str_size)
+		}
+
+		max_size = v
+	}
+
+	s := &PipeSource{
+		path:     path,
+		max_size: max_size,
+		mu:       new(sync.Mutex),
+	}
+
+	return s, nil
+}
+
+// Consume reads lines from STDIN or the named pipe that 's' was instantiated with, passing each non-empty line to 'fn' as the
+// body of a message, until 'ctx' is cancelled or, in the case of STDIN, there is no more input to read in which case it returns
+// nil. Named pipes are opened for both reading and writing so that they remain open, and can be written to by successive
+// processes, until 'ctx' is cancelled. Lines can not be read again so if 'fn' returns an error that line is skipped and an error
+// is returned; reading resumes with the next line the next time Consume is called.
+func (s *PipeSource) Consume(ctx context.Context, fn webhookd.WebhookSourceFunc) error {
+
+	lines, err := s.open()
+
+	if err != nil {
+		return err
+	}
+
+	for {
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case line, ok := <-lines:
+
+			if !ok {
+
+				s.mu.Lock()
+
+				err := s.err
+
+				if s.fh != nil && s.fh != os.Stdin {
+					s.fh.Close()
+				}
+
+				s.fh = nil
+				s.lines = nil
+				s.mu.Unlock()
+
+				if err != nil {
+					return fmt.Errorf("Failed to read line, %w", err)
+				}
+
+				return nil
+			}
+
+			msg := &webhookd.WebhookMessage{
+				Body: line,
+			}
+
+			werr := fn(ctx, msg)
+
+			if werr != nil {
+				return fmt.Errorf("Failed to process message, %w", werr)
+			}
+		}
+	}
+}
+
+// Close stops reading from, and closes, the named pipe that 's' was instantiated with. STDIN is never closed.
+func (s *PipeSource) Close() error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.done == nil {
+		return nil
+	}
+
+	close(s.done)
+	s.done = nil
+
+	fh := s.fh
+
+	s.fh = nil
+	s.lines = nil
+
+	if fh == nil || fh == os.Stdin {
+		return nil
+	}
+
+	return fh.Close()
+}
+
+// open returns the channel that lines read from STDIN or the named pipe that 's' was instantiated with are sent to, opening the
+// pipe and starting to read from it if necessary.
+func (s *PipeSource) open() (chan []byte, error) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.lines != nil {
+		return s.lines, nil
+	}
+
+	fh := os.Stdin
+
+	if s.path != "" {
+
+		info, err := os.Stat(s.path)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to stat %s, %w", s.path, err)
+		}
+
+		if info.Mode()&os.ModeNamedPipe == 0 {
+			return nil, fmt.Errorf("%s is not a named pipe", s.path)
+		}
+
+		// Opening the pipe for writing as well as reading means that opening it does not block waiting for a
+		// writer and reading from it does not return EOF when a writer closes it.
+
+		fh, err = os.OpenFile(s.path, os.O_RDWR, 0)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to open %s, %w", s.path, err)
+		}
+	}
+
+	if s.done != nil {
+		close(s.done)
+	}
+
+	lines := make(chan []byte)
+	done := make(chan bool)
+
+	s.fh = fh
+	s.lines = lines
+	s.done = done
+	s.err = nil
+
+	go s.read(fh, lines, done)
+
+	return lines, nil
+}
+
+// read reads lines from 'fh' sending each non-empty line to 'lines' until 'fh' has been read completely or 'done' is closed.
+func (s *PipeSource) read(fh io.Reader, lines chan []byte, done chan bool) {
+
+	defer close(lines)
+
+	size := 4096
+
+	if s.max_size < size {
+		size = s.max_size
+	}
+
+	scanner := bufio.NewScanner(fh)
+	scanner.Buffer(make([]byte, 0, size), s.max_size)
+
+	for scanner.Scan() {
+
+		line := bytes.TrimSpace(scanner.Bytes())
+
+		if len(line) == 0 {
+			continue
+		}
+
+		body := make([]byte, len(line))
+		copy(body, line)
+
+		select {
+		case lines <- body:
+			// pass
+		case <-done:
+			return
+		}
+	}
+
+	err := scanner.Err()
+
+	if err != nil {
+
+		select {
+		case <-done:
+			return
+		default:
+			// pass
+		}
+
+		s.mu.Lock()
+		s.err = err
+		s.mu.Unlock()
+	}
+}
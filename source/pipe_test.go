@@ -0,0 +1,239 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+func TestPipeSourceStdin(t *testing.T) {
+
+	ctx := context.Background()
+
+	r, w, err := os.Pipe()
+
+	if err != nil {
+		t.Fatalf("Failed to create pipe, %v", err)
+	}
+
+	stdin := os.Stdin
+	os.Stdin = r
+
+	defer func() {
+		os.Stdin = stdin
+		r.Close()
+	}()
+
+	go func() {
+		fmt.Fprintf(w, "{\"event\":\"one\"}\n\n  {\"event\":\"two\"}  \n{\"event\":\"three\"}\n{\"event\":\"four\"}")
+		w.Close()
+	}()
+
+	s, err := NewSource(ctx, "stdin://")
+
+	if err != nil {
+		t.Fatalf("Failed to create new source, %v", err)
+	}
+
+	defer s.Close()
+
+	// The first attempt fails to process the third line, which is skipped
+
+	seen := make([]string, 0)
+
+	fn := func(ctx context.Context, msg *webhookd.WebhookMessage) *webhookd.WebhookError {
+
+		if string(msg.Body) == `{"event":"three"}` {
+			return &webhookd.WebhookError{Code: 500, Message: "Failed"}
+		}
+
+		seen = append(seen, string(msg.Body))
+		return nil
+	}
+
+	err = s.Consume(ctx, fn)
+
+	if err == nil {
+		t.Fatalf("Expected failed message to return an error")
+	}
+
+	// The second attempt reads the remaining lines and returns without error once there is no more input
+
+	err = s.Consume(ctx, fn)
+
+	if err != nil {
+		t.Fatalf("Expected source to return without error, %v", err)
+	}
+
+	expected := []string{`{"event":"one"}`, `{"event":"two"}`, `{"event":"four"}`}
+
+	if len(seen) != len(expected) {
+		t.Fatalf("Unexpected messages: %v", seen)
+	}
+
+	for idx, body := range expected {
+
+		if seen[idx] != body {
+			t.Fatalf("Expected %s at offset %d but got %s", body, idx, seen[idx])
+		}
+	}
+}
+
+func TestPipeSourceMaxSize(t *testing.T) {
+
+	ctx := context.Background()
+
+	r, w, err := os.Pipe()
+
+	if err != nil {
+		t.Fatalf("Failed to create pipe, %v", err)
+	}
+
+	stdin := os.Stdin
+	os.Stdin = r
+
+	defer func() {
+		os.Stdin = stdin
+		r.Close()
+	}()
+
+	go func() {
+		fmt.Fprintf(w, "{\"event\":\"one\"}\n")
+		w.Close()
+	}()
+
+	s, err := NewSource(ctx, "stdin://?max_size=8")
+
+	if err != nil {
+		t.Fatalf("Failed to create new source, %v", err)
+	}
+
+	defer s.Close()
+
+	err = s.Consume(ctx, func(ctx context.Context, msg *webhookd.WebhookMessage) *webhookd.WebhookError {
+		return nil
+	})
+
+	if err == nil {
+		t.Fatalf("Expected line longer than max_size to fail")
+	}
+}
+
+func TestPipeSourceFIFO(t *testing.T) {
+
+	ctx := context.Background()
+
+	mkfifo, err := exec.LookPath("mkfifo")
+
+	if err != nil {
+		t.Skip("mkfifo is not available")
+	}
+
+	path := filepath.Join(t.TempDir(), "webhookd.fifo")
+
+	err = exec.Command(mkfifo, path).Run()
+
+	if err != nil {
+		t.Fatalf("Failed to create FIFO, %v", err)
+	}
+
+	s, err := NewSource(ctx, fmt.Sprintf("fifo://%s", path))
+
+	if err != nil {
+		t.Fatalf("Failed to create new source, %v", err)
+	}
+
+	defer s.Close()
+
+	consume_ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ch := make(chan string)
+	done := make(chan error)
+
+	go func() {
+
+		done <- s.Consume(consume_ctx, func(ctx context.Context, msg *webhookd.WebhookMessage) *webhookd.WebhookError {
+			ch <- string(msg.Body)
+			return nil
+		})
+	}()
+
+	// Successive writers do not cause the source to stop reading
+
+	for _, body := range []string{`{"event":"one"}`, `{"event":"two"}`} {
+
+		fh, err := os.OpenFile(path, os.O_WRONLY, 0)
+
+		if err != nil {
+			t.Fatalf("Failed to open FIFO for writing, %v", err)
+		}
+
+		fmt.Fprintln(fh, body)
+		fh.Close()
+
+		select {
+		case v := <-ch:
+
+			if v != body {
+				t.Fatalf("Expected %s but got %s", body, v)
+			}
+
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for message")
+		}
+	}
+
+	cancel()
+
+	select {
+	case err := <-done:
+
+		if err != nil {
+			t.Fatalf("Expected cancelled source to return without error, %v", err)
+		}
+
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for source to stop")
+	}
+}
+
+func TestNewPipeSourceInvalid(t *testing.T) {
+
+	ctx := context.Background()
+
+	tests := []string{
+		"fifo://",
+		"stdin://?max_size=0",
+		"fifo:///tmp/webhookd.fifo?max_size=big",
+	}
+
+	for _, uri := range tests {
+
+		_, err := NewSource(ctx, uri)
+
+		if err == nil {
+			t.Fatalf("Expected %s to fail", uri)
+		}
+	}
+
+	s, err := NewSource(ctx, fmt.Sprintf("fifo://%s", t.TempDir()))
+
+	if err != nil {
+		t.Fatalf("Failed to create new source, %v", err)
+	}
+
+	err = s.Consume(ctx, func(ctx context.Context, msg *webhookd.WebhookMessage) *webhookd.WebhookError {
+		return nil
+	})
+
+	if err == nil {
+		t.Fatalf("Expected path that is not a named pipe to fail")
+	}
+}
@@ -0,0 +1,717 @@
+package source
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+// DEFAULT_SMTP_MAX_SIZE is the default maximum size, in bytes, of an email message received by a `SMTPSource`.
+const DEFAULT_SMTP_MAX_SIZE int64 = 10 << 20
+
+// DEFAULT_SMTP_MAX_CONNECTIONS is the default maximum number of concurrent connections accepted by a `SMTPSource`.
+const DEFAULT_SMTP_MAX_CONNECTIONS int = 100
+
+// DEFAULT_SMTP_TIMEOUT is the default amount of time a `SMTPSource` will wait for a client to send a command.
+const DEFAULT_SMTP_TIMEOUT time.Duration = 5 * time.Minute
+
+// smtpMaxRecipients is the maximum number of recipients for a single email message.
+const smtpMaxRecipients int = 100
+
+// Headers added to messages received by a `SMTPSource`.
+const (
+	SMTP_FROM_HEADER           string = "X-Smtp-From"
+	SMTP_TO_HEADER             string = "X-Smtp-To"
+	SMTP_REMOTE_ADDRESS_HEADER string = "X-Smtp-Remote-Address"
+)
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterSource(ctx, "smtp", NewSMTPSource)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// SMTPSource implements the `webhookd.WebhookSource` interface for receiving email messages with an embedded SMTP server. Each
+// message is converted in to a JSON document containing its envelope, headers, text and HTML bodies and the metadata (but
+// not the contents) of its attachments.
+type SMTPSource struct {
+	webhookd.WebhookSource
+	// address is the address the SMTP server listens on.
+	address string
+	// domain is the domain name the SMTP server identifies itself as.
+	domain string
+	// recipients is the optional list of addresses, or "@{DOMAIN}" suffixes, that messages will be accepted for.
+	recipients []string
+	// max_size is the maximum size, in bytes, of a message.
+	max_size int64
+	// max_connections is the maximum number of concurrent connections.
+	max_connections int
+	// timeout is the amount of time to wait for a client to send a command.
+	timeout time.Duration
+	// tls is the optional TLS configuration used for STARTTLS.
+	tls *tls.Config
+	// require_tls signals that clients must issue a STARTTLS command before sending messages.
+	require_tls bool
+	// mu is a `sync.Mutex` used to guard 'listener'.
+	mu *sync.Mutex
+	// listener is the current `net.Listener` accepting connections.
+	listener net.Listener
+}
+
+// smtpSession is the state of a single SMTP connection.
+type smtpSession struct {
+	source *SMTPSource
+	conn   net.Conn
+	text   *textproto.Conn
+	// helo is the name the client identified itself with.
+	helo string
+	// from is the envelope sender of the current message.
+	from string
+	// to is the list of envelope recipients for the current message.
+	to []string
+}
+
+// NewSMTPSource returns a new `SMTPSource` instance configured by 'uri' in the form of:
+//
+//	smtp://{HOST}:{PORT}?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `domain={STRING}` The domain name the SMTP server identifies itself as. Default is the host of the URI.
+// * `rcpt={STRING}` An address, or "@{DOMAIN}" suffix, that messages will be accepted for. This parameter may be passed multiple times. If absent messages are accepted for all recipients.
+// * `max_size={INT}` The maximum size, in bytes, of a message. Default is 10485760.
+// * `max_connections={INT}` The maximum number of concurrent connections. Default is 100.
+// * `timeout={DURATION}` The amount of time to wait for a client to send a command. Default is "5m".
+// * `tls_cert={PATH}` and `tls_key={PATH}` The paths to a TLS certificate and key used to support the STARTTLS command.
+// * `require_tls={BOOLEAN}` Require clients to issue a STARTTLS command before sending messages. Default is false.
+func NewSMTPSource(ctx context.Context, uri string) (webhookd.WebhookSource, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	port := u.Port()
+
+	if port == "" {
+		port = "25"
+	}
+
+	q := u.Query()
+
+	domain := q.Get("domain")
+
+	if domain == "" {
+		domain = u.Hostname()
+	}
+
+	if domain == "" {
+		domain = "localhost"
+	}
+
+	recipients := make([]string, len(q["rcpt"]))
+
+	for idx, r := range q["rcpt"] {
+
+		if !strings.Contains(r, "@") {
+			return nil, fmt.Errorf("Invalid ?rcpt= parameter '%s'", r)
+		}
+
+		recipients[idx] = strings.ToLower(r)
+	}
+
+	max_size := DEFAULT_SMTP_MAX_SIZE
+
+	str_size := q.Get("max_size")
+
+	if str_size != "" {
+
+		v, err := strconv.ParseInt(str_size, 10, 64)
+
+		if err != nil || v <= 0 {
+			return nil, fmt.Errorf("Invalid ?max_size= parameter '%s'", str_size)
+		}
+
+		max_size = v
+	}
+
+	max_connections := DEFAULT_SMTP_MAX_CONNECTIONS
+
+	str_connections := q.Get("max_connections")
+
+	if str_connections != "" {
+
+		v, err := strconv.Atoi(str_connections)
+
+		if err != nil || v <= 0 {
+			return nil, fmt.Errorf("Invalid ?max_connections= parameter '%s'", str_connections)
+		}
+
+		max_connections = v
+	}
+
+	timeout := DEFAULT_SMTP_TIMEOUT
+
+	str_timeout := q.Get("timeout")
+
+	if str_timeout != "" {
+
+		v, err := time.ParseDuration(str_timeout)
+
+		if err != nil || v <= 0 {
+			return nil, fmt.Errorf("Invalid ?timeout= parameter '%s'", str_timeout)
+		}
+
+		timeout = v
+	}
+
+	var tls_config *tls.Config
+
+	tls_cert := q.Get("tls_cert")
+	tls_key := q.Get("tls_key")
+
+	if tls_cert != "" || tls_key != "" {
+
+		cert, err := tls.LoadX509KeyPair(tls_cert, tls_key)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to load TLS certificate, %w", err)
+		}
+
+		tls_config = &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			MinVersion:   tls.VersionTLS12,
+		}
+	}
+
+	require_tls := false
+
+	str_require := q.Get("require_tls")
+
+	if str_require != "" {
+
+		v, err := strconv.ParseBool(str_require)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse ?require_tls= parameter, %w", err)
+		}
+
+		require_tls = v
+	}
+
+	if require_tls && tls_config == nil {
+		return nil, fmt.Errorf("?require_tls= parameter requires ?tls_cert= and ?tls_key= parameters")
+	}
+
+	s := &SMTPSource{
+		address:         net.JoinHostPort(u.Hostname(), port),
+		domain:          domain,
+		recipients:      recipients,
+		max_size:        max_size,
+		max_connections: max_connections,
+		timeout:         timeout,
+		tls:             tls_config,
+		require_tls:     require_tls,
+		mu:              new(sync.Mutex),
+	}
+
+	return s, nil
+}
+
+// Consume listens for SMTP connections, passing each email message received to 'fn', until 'ctx' is cancelled. Each message
+// body is a JSON document and its headers are `X-Smtp-From`, `X-Smtp-To` (one for each recipient) and `X-Smtp-Remote-Address`.
+// A message is only accepted once 'fn' returns without error; otherwise the client is sent a temporary failure and is expected
+// to deliver the message again later. Connections are handled concurrently so 'fn' may be called concurrently.
+func (s *SMTPSource) Consume(ctx context.Context, fn webhookd.WebhookSourceFunc) error {
+
+	l, err := net.Listen("tcp", s.address)
+
+	if err != nil {
+		return fmt.Errorf("Failed to listen for SMTP connections, %w", err)
+	}
+
+	s.mu.Lock()
+	s.listener = l
+	s.mu.Unlock()
+
+	done := make(chan bool)
+	defer close(done)
+
+	go func() {
+
+		select {
+		case <-ctx.Done():
+			l.Close()
+		case <-done:
+			// pass
+		}
+	}()
+
+	wg := new(sync.WaitGroup)
+	defer wg.Wait()
+
+	throttle := make(chan bool, s.max_connections)
+
+	for {
+
+		conn, err := l.Accept()
+
+		if err != nil {
+
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			l.Close()
+			return fmt.Errorf("Failed to accept SMTP connection, %w", err)
+		}
+
+		select {
+		case throttle <- true:
+			// pass
+		default:
+			fmt.Fprintf(conn, "421 %s Too many connections, try again later\r\n", s.domain)
+			conn.Close()
+			continue
+		}
+
+		wg.Add(1)
+
+		go func() {
+
+			defer func() {
+				<-throttle
+				wg.Done()
+			}()
+
+			s.serve(ctx, conn, fn)
+		}()
+	}
+}
+
+// Close stops 's' from accepting new connections.
+func (s *SMTPSource) Close() error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.listener == nil {
+		return nil
+	}
+
+	err := s.listener.Close()
+	s.listener = nil
+
+	if err != nil && !errors.Is(err, net.ErrClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// addr returns the address that 's' is listening on, or nil if it is not listening.
+func (s *SMTPSource) addr() net.Addr {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.listener == nil {
+		return nil
+	}
+
+	return s.listener.Addr()
+}
+
+// serve handles the SMTP conversation for 'conn' until the client quits, the connection fails or 'ctx' is cancelled.
+func (s *SMTPSource) serve(ctx context.Context, conn net.Conn, fn webhookd.WebhookSourceFunc) {
+
+	done := make(chan bool)
+	defer close(done)
+
+	go func() {
+
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+			// pass
+		}
+	}()
+
+	sess := &smtpSession{
+		source: s,
+		conn:   conn,
+		text:   textproto.NewConn(conn),
+	}
+
+	defer func() {
+		sess.text.Close()
+	}()
+
+	sess.reply(220, fmt.Sprintf("%s ESMTP webhookd", s.domain))
+
+	for {
+
+		conn.SetReadDeadline(time.Now().Add(s.timeout))
+
+		line, err := sess.text.ReadLine()
+
+		if err != nil {
+			return
+		}
+
+		verb := line
+		args := ""
+
+		idx := strings.Index(line, " ")
+
+		if idx != -1 {
+			verb = line[:idx]
+			args = strings.TrimSpace(line[idx+1:])
+		}
+
+		switch strings.ToUpper(verb) {
+		case "HELO":
+			sess.helo = args
+			sess.reset()
+			sess.reply(250, s.domain)
+		case "EHLO":
+			sess.helo = args
+			sess.reset()
+			sess.ehlo()
+		case "STARTTLS":
+
+			if !sess.starttls() {
+				return
+			}
+
+		case "MAIL":
+			sess.mail(args)
+		case "RCPT":
+			sess.rcpt(args)
+		case "DATA":
+
+			if !sess.data(ctx, fn) {
+				return
+			}
+
+		case "RSET":
+			sess.reset()
+			sess.reply(250, "OK")
+		case "NOOP":
+			sess.reply(250, "OK")
+		case "VRFY":
+			sess.reply(252, "Cannot verify user")
+		case "QUIT":
+			sess.reply(221, "Bye")
+			return
+		default:
+			sess.reply(502, "Command not implemented")
+		}
+	}
+}
+
+// reply writes a single line response with status 'code' to the client.
+func (sess *smtpSession) reply(code int, msg string) error {
+	return sess.text.PrintfLine("%d %s", code, msg)
+}
+
+// reset discards the envelope of the current message.
+func (sess *smtpSession) reset() {
+	sess.from = ""
+	sess.to = nil
+}
+
+// isTLS returns true if the connection for 'sess' is encrypted.
+func (sess *smtpSession) isTLS() bool {
+	_, ok := sess.conn.(*tls.Conn)
+	return ok
+}
+
+// ehlo writes the response, listing supported extensions, for the EHLO command.
+func (sess *smtpSession) ehlo() {
+
+	lines := []string{
+		sess.source.domain,
+		fmt.Sprintf("SIZE %d", sess.source.max_size),
+		"8BITMIME",
+	}
+
+	if sess.source.tls != nil && !sess.isTLS() {
+		lines = append(lines, "STARTTLS")
+	}
+
+	for idx, l := range lines {
+
+		sep := "-"
+
+		if idx == len(lines)-1 {
+			sep = " "
+		}
+
+		sess.text.PrintfLine("250%s%s", sep, l)
+	}
+}
+
+// starttls upgrades the connection for 'sess' to TLS. It returns false if the connection can no longer be used.
+func (sess *smtpSession) starttls() bool {
+
+	if sess.source.tls == nil || sess.isTLS() {
+		sess.reply(502, "Command not implemented")
+		return true
+	}
+
+	sess.reply(220, "Ready to start TLS")
+
+	tls_conn := tls.Server(sess.conn, sess.source.tls)
+	tls_conn.SetDeadline(time.Now().Add(sess.source.timeout))
+
+	err := tls_conn.Handshake()
+
+	if err != nil {
+		return false
+	}
+
+	tls_conn.SetDeadline(time.Time{})
+
+	// Per RFC 3207 the client must start over, discarding any knowledge obtained before the TLS negotiation
+
+	sess.conn = tls_conn
+	sess.text = textproto.NewConn(tls_conn)
+	sess.helo = ""
+	sess.reset()
+
+	return true
+}
+
+// mail handles the MAIL command with arguments 'args'.
+func (sess *smtpSession) mail(args string) {
+
+	if sess.helo == "" {
+		sess.reply(503, "Send HELO or EHLO first")
+		return
+	}
+
+	if sess.source.require_tls && !sess.isTLS() {
+		sess.reply(530, "Must issue a STARTTLS command first")
+		return
+	}
+
+	if sess.from != "" {
+		sess.reply(503, "Sender already specified")
+		return
+	}
+
+	addr, params, ok := parseSMTPPath(args, "FROM:")
+
+	if !ok {
+		sess.reply(501, "Syntax: MAIL FROM:<address>")
+		return
+	}
+
+	for _, p := range params {
+
+		k, v, _ := strings.Cut(p, "=")
+
+		if strings.EqualFold(k, "SIZE") {
+
+			size, err := strconv.ParseInt(v, 10, 64)
+
+			if err == nil && size > sess.source.max_size {
+				sess.reply(552, "Message size exceeds maximum")
+				return
+			}
+		}
+	}
+
+	// The null reverse-path "<>" is used for bounces
+
+	if addr == "" {
+		addr = "<>"
+	}
+
+	sess.from = addr
+	sess.reply(250, "OK")
+}
+
+// rcpt handles the RCPT command with arguments 'args'.
+func (sess *smtpSession) rcpt(args string) {
+
+	if sess.from == "" {
+		sess.reply(503, "Send MAIL first")
+		return
+	}
+
+	addr, _, ok := parseSMTPPath(args, "TO:")
+
+	if !ok || addr == "" {
+		sess.reply(501, "Syntax: RCPT TO:<address>")
+		return
+	}
+
+	if len(sess.to) >= smtpMaxRecipients {
+		sess.reply(452, "Too many recipients")
+		return
+	}
+
+	if !sess.source.accepts(addr) {
+		sess.reply(550, "No such user here")
+		return
+	}
+
+	sess.to = append(sess.to, addr)
+	sess.reply(250, "OK")
+}
+
+// data handles the DATA command, reading the message and passing it to 'fn'. It returns false if the connection can no longer
+// be used.
+func (sess *smtpSession) data(ctx context.Context, fn webhookd.WebhookSourceFunc) bool {
+
+	if len(sess.to) == 0 {
+		sess.reply(503, "Send RCPT first")
+		return true
+	}
+
+	sess.reply(354, "End data with <CR><LF>.<CR><LF>")
+
+	sess.conn.SetReadDeadline(time.Now().Add(sess.source.timeout))
+
+	dr := sess.text.DotReader()
+
+	body, err := io.ReadAll(io.LimitReader(dr, sess.source.max_size+1))
+
+	if err != nil {
+		return false
+	}
+
+	defer sess.reset()
+
+	if int64(len(body)) > sess.source.max_size {
+
+		_, err := io.Copy(io.Discard, dr)
+
+		if err != nil {
+			return false
+		}
+
+		sess.reply(552, "Message size exceeds maximum")
+		return true
+	}
+
+	email, err := parseSMTPEmail(sess.from, sess.to, body)
+
+	if err != nil {
+		sess.reply(554, fmt.Sprintf("Failed to parse message, %v", err))
+		return true
+	}
+
+	enc, err := json.Marshal(email)
+
+	if err != nil {
+		sess.reply(451, "Local error in processing")
+		return true
+	}
+
+	headers := http.Header{}
+	headers.Set(SMTP_FROM_HEADER, sess.from)
+	headers.Set(SMTP_REMOTE_ADDRESS_HEADER, sess.conn.RemoteAddr().String())
+
+	for _, addr := range sess.to {
+		headers.Add(SMTP_TO_HEADER, addr)
+	}
+
+	msg := &webhookd.WebhookMessage{
+		ID:     email.MessageID,
+		Body:   enc,
+		Header: headers,
+	}
+
+	werr := fn(ctx, msg)
+
+	if werr != nil {
+		sess.reply(451, "Local error in processing, try again later")
+		return true
+	}
+
+	sess.reply(250, "OK")
+	return true
+}
+
+// accepts returns true if messages for the recipient 'addr' are accepted by 's'.
+func (s *SMTPSource) accepts(addr string) bool {
+
+	if len(s.recipients) == 0 {
+		return true
+	}
+
+	addr = strings.ToLower(addr)
+
+	for _, r := range s.recipients {
+
+		if strings.HasPrefix(r, "@") {
+
+			if strings.HasSuffix(addr, r) {
+				return true
+			}
+
+			continue
+		}
+
+		if addr == r {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseSMTPPath parses the arguments of a MAIL or RCPT command, in the form of "{PREFIX}<{ADDRESS}> {PARAMETERS}", returning
+// the address and any (ESMTP) parameters.
+func parseSMTPPath(args string, prefix string) (string, []string, bool) {
+
+	if len(args) < len(prefix) || !strings.EqualFold(args[:len(prefix)], prefix) {
+		return "", nil, false
+	}
+
+	args = strings.TrimSpace(args[len(prefix):])
+
+	if !strings.HasPrefix(args, "<") {
+		return "", nil, false
+	}
+
+	end := strings.Index(args, ">")
+
+	if end == -1 {
+		return "", nil, false
+	}
+
+	addr := args[1:end]
+
+	// Strip any (obsolete) source route, for example "<@a.example,@b.example:user@c.example>"
+
+	idx := strings.LastIndex(addr, ":")
+
+	if idx != -1 && strings.HasPrefix(addr, "@") {
+		addr = addr[idx+1:]
+	}
+
+	params := strings.Fields(args[end+1:])
+	return addr, params, true
+}
@@ -0,0 +1,253 @@
+package source
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// smtpMaxDepth is the maximum depth of nested multipart bodies that will be parsed in an email message.
+const smtpMaxDepth int = 10
+
+// smtpEmail is the JSON representation of an email message received by a `SMTPSource`.
+type smtpEmail struct {
+	// From is the envelope sender of the message.
+	From string `json:"from"`
+	// To is the list of envelope recipients of the message.
+	To []string `json:"to"`
+	// Subject is the (decoded) subject of the message.
+	Subject string `json:"subject,omitempty"`
+	// Date is the date of the message, formatted as RFC 3339.
+	Date string `json:"date,omitempty"`
+	// MessageID is the value of the message's "Message-Id" header without angle brackets.
+	MessageID string `json:"message_id,omitempty"`
+	// Headers is the dictionary of the message's (decoded) top-level headers.
+	Headers map[string][]string `json:"headers"`
+	// Text is the first plain text part of the message.
+	Text string `json:"text,omitempty"`
+	// HTML is the first HTML part of the message.
+	HTML string `json:"html,omitempty"`
+	// Attachments is the list of metadata for the message's attachments. Attachment contents are not included.
+	Attachments []*smtpAttachment `json:"attachments"`
+}
+
+// smtpAttachment is the metadata for an attachment in an email message.
+type smtpAttachment struct {
+	// Filename is the (decoded) filename of the attachment.
+	Filename string `json:"filename,omitempty"`
+	// ContentType is the media type of the attachment.
+	ContentType string `json:"content_type"`
+	// Size is the size, in bytes, of the (decoded) attachment.
+	Size int64 `json:"size"`
+	// ContentID is the value of the attachment's "Content-Id" header without angle brackets.
+	ContentID string `json:"content_id,omitempty"`
+}
+
+// parseSMTPEmail parses the RFC 5322 message 'data' received from the envelope sender 'from' for the envelope recipients 'to'.
+func parseSMTPEmail(from string, to []string, data []byte) (*smtpEmail, error) {
+
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read message, %w", err)
+	}
+
+	dec := new(mime.WordDecoder)
+
+	email := &smtpEmail{
+		From:        from,
+		To:          to,
+		Headers:     make(map[string][]string),
+		Attachments: make([]*smtpAttachment, 0),
+	}
+
+	for k, values := range msg.Header {
+
+		decoded := make([]string, len(values))
+
+		for idx, v := range values {
+			decoded[idx] = decodeSMTPHeader(dec, v)
+		}
+
+		email.Headers[k] = decoded
+	}
+
+	email.Subject = decodeSMTPHeader(dec, msg.Header.Get("Subject"))
+	email.MessageID = strings.Trim(strings.TrimSpace(msg.Header.Get("Message-Id")), "<>")
+
+	dt, err := msg.Header.Date()
+
+	if err == nil {
+		email.Date = dt.Format(time.RFC3339)
+	}
+
+	err = email.walk(dec, textproto.MIMEHeader(msg.Header), msg.Body, 0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return email, nil
+}
+
+// walk parses the message part with headers 'header' and body 'body', recursing in to multipart bodies, and populates the
+// text, HTML and attachments of 'e'.
+func (e *smtpEmail) walk(dec *mime.WordDecoder, header textproto.MIMEHeader, body io.Reader, depth int) error {
+
+	if depth > smtpMaxDepth {
+		return fmt.Errorf("Message exceeds maximum multipart depth of %d", smtpMaxDepth)
+	}
+
+	media_type := "text/plain"
+	params := map[string]string{}
+
+	content_type := header.Get("Content-Type")
+
+	if content_type != "" {
+
+		t, p, err := mime.ParseMediaType(content_type)
+
+		// Invalid content types are treated as "application/octet-stream" per RFC 2045
+
+		if err != nil {
+			t = "application/octet-stream"
+			p = map[string]string{}
+		}
+
+		media_type = t
+		params = p
+	}
+
+	if strings.HasPrefix(media_type, "multipart/") {
+
+		boundary := params["boundary"]
+
+		if boundary == "" {
+			return fmt.Errorf("Missing multipart boundary")
+		}
+
+		mr := multipart.NewReader(body, boundary)
+
+		for {
+
+			part, err := mr.NextPart()
+
+			if err == io.EOF {
+				return nil
+			}
+
+			if err != nil {
+				return fmt.Errorf("Failed to read multipart body, %w", err)
+			}
+
+			err = e.walk(dec, part.Header, part, depth+1)
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	switch strings.ToLower(header.Get("Content-Transfer-Encoding")) {
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	}
+
+	disposition := ""
+	filename := params["name"]
+
+	str_disposition := header.Get("Content-Disposition")
+
+	if str_disposition != "" {
+
+		d, p, err := mime.ParseMediaType(str_disposition)
+
+		if err == nil {
+
+			disposition = d
+
+			if p["filename"] != "" {
+				filename = p["filename"]
+			}
+		}
+	}
+
+	is_text := media_type == "text/plain" || media_type == "text/html"
+
+	if disposition == "attachment" || filename != "" || !is_text {
+
+		size, err := io.Copy(io.Discard, body)
+
+		if err != nil {
+			return fmt.Errorf("Failed to read attachment, %w", err)
+		}
+
+		a := &smtpAttachment{
+			Filename:    decodeSMTPHeader(dec, filename),
+			ContentType: media_type,
+			Size:        size,
+			ContentID:   strings.Trim(strings.TrimSpace(header.Get("Content-Id")), "<>"),
+		}
+
+		e.Attachments = append(e.Attachments, a)
+		return nil
+	}
+
+	b, err := io.ReadAll(body)
+
+	if err != nil {
+		return fmt.Errorf("Failed to read %s body, %w", media_type, err)
+	}
+
+	text := decodeSMTPCharset(params["charset"], b)
+
+	switch {
+	case media_type == "text/plain" && e.Text == "":
+		e.Text = text
+	case media_type == "text/html" && e.HTML == "":
+		e.HTML = text
+	}
+
+	return nil
+}
+
+// decodeSMTPHeader decodes any RFC 2047 encoded words in 'v', returning 'v' unchanged if it can not be decoded.
+func decodeSMTPHeader(dec *mime.WordDecoder, v string) string {
+
+	decoded, err := dec.DecodeHeader(v)
+
+	if err != nil {
+		return v
+	}
+
+	return decoded
+}
+
+// decodeSMTPCharset returns 'b' as a string converted from 'charset' to UTF-8. Only the ISO-8859-1 character set is converted;
+// all other character sets are assumed to be compatible with UTF-8.
+func decodeSMTPCharset(charset string, b []byte) string {
+
+	switch strings.ToLower(charset) {
+	case "iso-8859-1", "latin1":
+
+		runes := make([]rune, len(b))
+
+		for idx, c := range b {
+			runes[idx] = rune(c)
+		}
+
+		return string(runes)
+	}
+
+	return string(b)
+}
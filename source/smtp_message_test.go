@@ -0,0 +1,102 @@
+package source
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseSMTPEmail(t *testing.T) {
+
+	body := strings.Join([]string{
+		"From: Sender <sender@example.net>",
+		"To: hooks@example.com",
+		"Subject: Build report",
+		"Message-Id: <abcd@example.net>",
+		"MIME-Version: 1.0",
+		`Content-Type: multipart/mixed; boundary="outer"`,
+		"",
+		"--outer",
+		`Content-Type: multipart/alternative; boundary="inner"`,
+		"",
+		"--inner",
+		"Content-Type: text/plain; charset=iso-8859-1",
+		"Content-Transfer-Encoding: quoted-printable",
+		"",
+		"Caf=E9 is open",
+		"--inner",
+		"Content-Type: text/html; charset=utf-8",
+		"",
+		"<p>Café is open</p>",
+		"--inner--",
+		"--outer",
+		"Content-Type: application/pdf",
+		`Content-Disposition: attachment; filename="report.pdf"`,
+		"Content-Transfer-Encoding: base64",
+		"",
+		"aGVsbG8gd29y",
+		"bGQ=",
+		"--outer",
+		"Content-Type: image/png; name=logo.png",
+		"Content-Id: <logo@example.net>",
+		"",
+		"PNG",
+		"--outer--",
+		"",
+	}, "\r\n")
+
+	email, err := parseSMTPEmail("sender@example.net", []string{"hooks@example.com"}, []byte(body))
+
+	if err != nil {
+		t.Fatalf("Failed to parse email, %v", err)
+	}
+
+	if email.Subject != "Build report" || email.MessageID != "abcd@example.net" {
+		t.Fatalf("Unexpected email: %v", email)
+	}
+
+	if email.Text != "Café is open" {
+		t.Fatalf("Unexpected text: '%s'", email.Text)
+	}
+
+	if email.HTML != "<p>Café is open</p>" {
+		t.Fatalf("Unexpected HTML: '%s'", email.HTML)
+	}
+
+	if email.Headers["From"][0] != "Sender <sender@example.net>" {
+		t.Fatalf("Unexpected headers: %v", email.Headers)
+	}
+
+	if len(email.Attachments) != 2 {
+		t.Fatalf("Expected 2 attachments, got %d", len(email.Attachments))
+	}
+
+	pdf := email.Attachments[0]
+
+	if pdf.Filename != "report.pdf" || pdf.ContentType != "application/pdf" || pdf.Size != 11 {
+		t.Fatalf("Unexpected attachment: %v", pdf)
+	}
+
+	png := email.Attachments[1]
+
+	if png.Filename != "logo.png" || png.ContentID != "logo@example.net" {
+		t.Fatalf("Unexpected attachment: %v", png)
+	}
+}
+
+func TestParseSMTPEmailInvalid(t *testing.T) {
+
+	tests := []string{
+		"not an email",
+		"Content-Type: multipart/mixed\r\n\r\nhello\r\n",
+		strings.Repeat("Content-Type: multipart/mixed; boundary=x\r\n\r\n--x\r\n", 12),
+	}
+
+	for _, body := range tests {
+
+		_, err := parseSMTPEmail("sender@example.net", []string{"hooks@example.com"}, []byte(body))
+
+		if err == nil {
+			t.Fatalf("Expected '%s' to fail", body)
+		}
+	}
+}
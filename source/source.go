@@ -0,0 +1,98 @@
+// Package source provides an interface for consuming webhook messages from queues, topics and other pull-based sources.
+package source
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+
+	"github.com/aaronland/go-roster"
+	"github.com/whosonfirst/go-webhookd/v3"
+	"github.com/whosonfirst/go-webhookd/v3/secrets"
+)
+
+// sources is a `aaronland/go-roster.Roster` instance used to maintain a list of registered `webhookd.WebhookSource` initialization functions.
+var sources roster.Roster
+
+// SourceInitializationFunc is a function used to initialize an implementation of the `webhookd.WebhookSource` interface.
+type SourceInitializationFunc func(ctx context.Context, uri string) (webhookd.WebhookSource, error)
+
+// NewSource() returns a new `webhookd.WebhookSource` instance derived from 'uri'. The semantics of and requirements for
+// 'uri' as specific to the package implementing the interface.
+func NewSource(ctx context.Context, uri string) (webhookd.WebhookSource, error) {
+
+	err := ensureSourceRoster()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to ensure source roster, %w", err)
+	}
+
+	uri, err = secrets.ResolveURI(ctx, uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to resolve secrets, %w", err)
+	}
+
+	parsed, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	scheme := parsed.Scheme
+
+	i, err := sources.Driver(ctx, scheme)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to find initialization function for '%s', %w", scheme, err)
+	}
+
+	init_func := i.(SourceInitializationFunc)
+	return init_func(ctx, uri)
+}
+
+// RegisterSource() associates 'scheme' with 'init_func' in an internal list of avilable `webhookd.WebhookSource` implementations.
+func RegisterSource(ctx context.Context, scheme string, init_func SourceInitializationFunc) error {
+
+	err := ensureSourceRoster()
+
+	if err != nil {
+		return fmt.Errorf("Failed to ensure source roster, %w", err)
+	}
+
+	return sources.Register(ctx, scheme, init_func)
+}
+
+// ensureSourceRoster() ensures that a `aaronland/go-roster.Roster` instance used to maintain a list of registered `webhookd.WebhookSource`
+// initialization functions is present
+func ensureSourceRoster() error {
+
+	if sources == nil {
+
+		r, err := roster.NewDefaultRoster()
+
+		if err != nil {
+			return fmt.Errorf("Failed to create new roster, %w", err)
+		}
+
+		sources = r
+	}
+
+	return nil
+}
+
+// Schemes() returns the list of schemes that have been "registered".
+func Schemes() []string {
+	ctx := context.Background()
+	drivers := sources.Drivers(ctx)
+
+	schemes := make([]string, len(drivers))
+
+	for idx, dr := range drivers {
+		schemes[idx] = fmt.Sprintf("%s://", dr)
+	}
+
+	sort.Strings(schemes)
+	return schemes
+}
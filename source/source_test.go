@@ -0,0 +1,30 @@
+package source
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRegisterSource(t *testing.T) {
+
+	ctx := context.Background()
+
+	err := RegisterSource(ctx, "kafka", NewKafkaSource)
+
+	if err == nil {
+		t.Fatalf("Expected NewKafkaSource to be registered already")
+	}
+}
+
+func TestNewSource(t *testing.T) {
+
+	ctx := context.Background()
+
+	uri := "kafka://localhost:9092/events"
+
+	_, err := NewSource(ctx, uri)
+
+	if err != nil {
+		t.Fatalf("Failed to create new source for '%s', %v", uri, err)
+	}
+}
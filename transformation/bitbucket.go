@@ -0,0 +1,296 @@
+package transformation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sfomuseum/runtimevar"
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+// DEFAULT_BITBUCKET_API is the default root URL for the Bitbucket Cloud API.
+const DEFAULT_BITBUCKET_API string = "https://api.bitbucket.org/2.0"
+
+// DEFAULT_BITBUCKET_TIMEOUT is the default amount of time to wait for a Bitbucket API request to complete.
+const DEFAULT_BITBUCKET_TIMEOUT time.Duration = 10 * time.Second
+
+// maxBitbucketPages is the maximum number of diffstat pages requested for a single commit.
+const maxBitbucketPages int = 50
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterTransformation(ctx, "bitbucketcommits", NewBitbucketCommitsTransformation)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// bitbucketPushEvent is the subset of a Bitbucket Cloud "repo:push" event used by `BitbucketCommitsTransformation`.
+type bitbucketPushEvent struct {
+	Repository struct {
+		Name     string `json:"name"`
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+	Push *struct {
+		Changes []struct {
+			Commits []struct {
+				Hash string `json:"hash"`
+			} `json:"commits"`
+		} `json:"changes"`
+	} `json:"push"`
+}
+
+// bitbucketDiffStat is a page of results from the Bitbucket Cloud diffstat API.
+type bitbucketDiffStat struct {
+	Values []struct {
+		Status string `json:"status"`
+		Old    *struct {
+			Path string `json:"path"`
+		} `json:"old"`
+		New *struct {
+			Path string `json:"path"`
+		} `json:"new"`
+	} `json:"values"`
+	Next string `json:"next"`
+}
+
+// BitbucketCommitsTransformation implements the `webhookd.WebhookTransformation` interface for extracting the list of files
+// changed by the commits in a Bitbucket Cloud push event.
+type BitbucketCommitsTransformation struct {
+	webhookd.WebhookTransformation
+	options *commitsOptions
+	// api is the root URL for the Bitbucket Cloud API.
+	api string
+	// token is the optional access token used to authenticate API requests.
+	token string
+	// client is the `http.Client` used to perform API requests.
+	client *http.Client
+}
+
+// NewBitbucketCommitsTransformation returns a new `BitbucketCommitsTransformation` instance configured by 'uri' in the form of:
+//
+//	bitbucketcommits://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `exclude_additions={BOOLEAN}` Exclude paths that were added. Default is false.
+// * `exclude_modifications={BOOLEAN}` Exclude paths that were modified. Default is false.
+// * `exclude_deletions={BOOLEAN}` Exclude paths that were removed. Default is false.
+// * `token={URI}` A URI-escaped `gocloud.dev/runtimevar` URI whose value is an access token used to authenticate API requests. Required for private repositories.
+// * `api={URL}` The root URL for the Bitbucket Cloud API. Default is "https://api.bitbucket.org/2.0".
+// * `timeout={DURATION}` The amount of time to wait for an API request to complete. Default is "10s".
+//
+// Bitbucket push events do not include the list of files changed by each commit so they are retrieved from the diffstat API.
+func NewBitbucketCommitsTransformation(ctx context.Context, uri string) (webhookd.WebhookTransformation, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	opts, err := newCommitsOptions(q)
+
+	if err != nil {
+		return nil, err
+	}
+
+	api := q.Get("api")
+
+	if api == "" {
+		api = DEFAULT_BITBUCKET_API
+	}
+
+	token := ""
+
+	token_uri := q.Get("token")
+
+	if token_uri != "" {
+
+		v, err := runtimevar.StringVar(ctx, token_uri)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read token, %w", err)
+		}
+
+		token = strings.TrimSpace(v)
+	}
+
+	timeout := DEFAULT_BITBUCKET_TIMEOUT
+
+	str_timeout := q.Get("timeout")
+
+	if str_timeout != "" {
+
+		v, err := time.ParseDuration(str_timeout)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse ?timeout= parameter, %w", err)
+		}
+
+		timeout = v
+	}
+
+	tr := BitbucketCommitsTransformation{
+		options: opts,
+		api:     strings.TrimRight(api, "/"),
+		token:   token,
+		client:  &http.Client{Timeout: timeout},
+	}
+
+	return &tr, nil
+}
+
+// Transform returns a CSV-encoded list of rows, consisting of commit hash, repository name and path, for each file changed by
+// the commits in the Bitbucket Cloud push event 'body'. Events that are not push events return a `webhookd.UnhandledEvent` error.
+// Renamed files are reported as a deletion of the old path and an addition of the new path.
+func (tr *BitbucketCommitsTransformation) Transform(ctx context.Context, body []byte) ([]byte, *webhookd.WebhookError) {
+
+	var event bitbucketPushEvent
+
+	err := json.Unmarshal(body, &event)
+
+	if err != nil {
+		code := http.StatusBadRequest
+		message := fmt.Sprintf("Failed to decode push event, %v", err)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	if event.Push == nil {
+		code := webhookd.UnhandledEvent
+		message := "Unhandled event, not a push event"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	commits := make([]*commitFiles, 0)
+
+	for _, change := range event.Push.Changes {
+
+		for _, c := range change.Commits {
+
+			files, err := tr.diffStat(ctx, event.Repository.FullName, c.Hash)
+
+			if err != nil {
+				code := http.StatusBadGateway
+				message := fmt.Sprintf("Failed to retrieve files for commit %s, %v", c.Hash, err)
+				return nil, &webhookd.WebhookError{Code: code, Message: message}
+			}
+
+			commits = append(commits, files)
+		}
+	}
+
+	enc, err := encodeCommitFiles(tr.options, event.Repository.Name, commits)
+
+	if err != nil {
+		code := http.StatusInternalServerError
+		message := err.Error()
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	return enc, nil
+}
+
+// diffStat retrieves the list of files changed by the commit 'hash' in the repository 'full_name' from the diffstat API.
+func (tr *BitbucketCommitsTransformation) diffStat(ctx context.Context, full_name string, hash string) (*commitFiles, error) {
+
+	files := &commitFiles{
+		hash:     hash,
+		added:    make([]string, 0),
+		modified: make([]string, 0),
+		removed:  make([]string, 0),
+	}
+
+	next := fmt.Sprintf("%s/repositories/%s/diffstat/%s", tr.api, full_name, url.PathEscape(hash))
+
+	for i := 0; next != "" && i < maxBitbucketPages; i++ {
+
+		var page bitbucketDiffStat
+
+		err := tr.get(ctx, next, &page)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, v := range page.Values {
+
+			switch v.Status {
+			case "added":
+
+				if v.New != nil {
+					files.added = append(files.added, v.New.Path)
+				}
+
+			case "removed":
+
+				if v.Old != nil {
+					files.removed = append(files.removed, v.Old.Path)
+				}
+
+			case "renamed":
+
+				if v.Old != nil {
+					files.removed = append(files.removed, v.Old.Path)
+				}
+
+				if v.New != nil {
+					files.added = append(files.added, v.New.Path)
+				}
+
+			default:
+
+				if v.New != nil {
+					files.modified = append(files.modified, v.New.Path)
+				}
+			}
+		}
+
+		next = page.Next
+	}
+
+	return files, nil
+}
+
+// get retrieves 'api_url' and decodes its JSON-encoded body in to 'v'.
+func (tr *BitbucketCommitsTransformation) get(ctx context.Context, api_url string, v interface{}) error {
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, api_url, nil)
+
+	if err != nil {
+		return fmt.Errorf("Failed to create request, %w", err)
+	}
+
+	if tr.token != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", tr.token))
+	}
+
+	rsp, err := tr.client.Do(req)
+
+	if err != nil {
+		return fmt.Errorf("Failed to execute request, %w", err)
+	}
+
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode != http.StatusOK {
+		return fmt.Errorf("API returned unexpected status %s", rsp.Status)
+	}
+
+	err = json.NewDecoder(io.LimitReader(rsp.Body, maxLookupResponseSize)).Decode(v)
+
+	if err != nil {
+		return fmt.Errorf("Failed to decode response, %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package transformation
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestBitbucketCommitsTransformation(t *testing.T) {
+
+	ctx := context.Background()
+
+	var srv *httptest.Server
+
+	srv = httptest.NewServer(http.HandlerFunc(func(rsp http.ResponseWriter, req *http.Request) {
+
+		if req.Header.Get("Authorization") != "Bearer s33kret" {
+			http.Error(rsp, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		switch req.URL.Path {
+		case "/repositories/acme/example/diffstat/abc":
+
+			fmt.Fprintf(rsp, `{"values":[{"status":"added","new":{"path":"a.txt"}},{"status":"modified","old":{"path":"b.txt"},"new":{"path":"b.txt"}}],"next":"%s/page2"}`, srv.URL)
+
+		case "/page2":
+
+			rsp.Write([]byte(`{"values":[{"status":"renamed","old":{"path":"c.txt"},"new":{"path":"d.txt"}},{"status":"removed","old":{"path":"e.txt"}}]}`))
+
+		default:
+			http.NotFound(rsp, req)
+		}
+	}))
+
+	defer srv.Close()
+
+	uri := fmt.Sprintf("bitbucketcommits://?api=%s&token=%s", url.QueryEscape(srv.URL), url.QueryEscape("constant://?val=s33kret"))
+
+	tr, err := NewTransformation(ctx, uri)
+
+	if err != nil {
+		t.Fatalf("Failed to create new bitbucketcommits transformation, %v", err)
+	}
+
+	body := `{
+		"repository": { "name": "example", "full_name": "acme/example" },
+		"push": { "changes": [ { "commits": [ { "hash": "abc" } ] } ] }
+	}`
+
+	output, err2 := tr.Transform(ctx, []byte(body))
+
+	if err2 != nil {
+		t.Fatalf("Failed to transform body, %v", err2)
+	}
+
+	expected := "abc,example,a.txt\nabc,example,d.txt\nabc,example,b.txt\nabc,example,c.txt\nabc,example,e.txt\n"
+
+	if string(output) != expected {
+		t.Fatalf("Unexpected output '%s'", string(output))
+	}
+}
@@ -0,0 +1,103 @@
+package transformation
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// commitsOptions is a struct containing the options shared by the commit-to-file-list transformations.
+type commitsOptions struct {
+	exclude_additions     bool
+	exclude_modifications bool
+	exclude_deletions     bool
+}
+
+// newCommitsOptions returns a new `commitsOptions` instance derived from 'q'.
+func newCommitsOptions(q url.Values) (*commitsOptions, error) {
+
+	opts := &commitsOptions{}
+
+	flags := map[string]*bool{
+		"exclude_additions":     &opts.exclude_additions,
+		"exclude_modifications": &opts.exclude_modifications,
+		"exclude_deletions":     &opts.exclude_deletions,
+	}
+
+	for k, ptr := range flags {
+
+		str_v := q.Get(k)
+
+		if str_v == "" {
+			continue
+		}
+
+		v, err := strconv.ParseBool(str_v)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse ?%s= parameter, %w", k, err)
+		}
+
+		*ptr = v
+	}
+
+	return opts, nil
+}
+
+// commitFiles is the list of paths added, modified and removed by a single commit.
+type commitFiles struct {
+	hash     string
+	added    []string
+	modified []string
+	removed  []string
+}
+
+// encodeCommitFiles returns a CSV-encoded list of rows, consisting of commit hash, repository name and path, for each
+// path in 'commits' that is not excluded by 'opts'. This is the same format produced by the `githubcommits://`
+// transformation in the `whosonfirst/go-webhookd-github` package.
+func encodeCommitFiles(opts *commitsOptions, repo string, commits []*commitFiles) ([]byte, error) {
+
+	var buf bytes.Buffer
+	wr := csv.NewWriter(&buf)
+
+	for _, c := range commits {
+
+		groups := make([][]string, 0, 3)
+
+		if !opts.exclude_additions {
+			groups = append(groups, c.added)
+		}
+
+		if !opts.exclude_modifications {
+			groups = append(groups, c.modified)
+		}
+
+		if !opts.exclude_deletions {
+			groups = append(groups, c.removed)
+		}
+
+		for _, paths := range groups {
+
+			for _, path := range paths {
+
+				err := wr.Write([]string{c.hash, repo, path})
+
+				if err != nil {
+					return nil, fmt.Errorf("Failed to write row, %w", err)
+				}
+			}
+		}
+	}
+
+	wr.Flush()
+
+	err := wr.Error()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to flush rows, %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
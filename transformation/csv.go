@@ -0,0 +1,188 @@
+package transformation
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterTransformation(ctx, "csv2json", NewCSVToJSONTransformation)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// CSVToJSONTransformation implements the `webhookd.WebhookTransformation` interface for converting CSV or TSV-encoded messages to
+// a JSON-encoded list of objects.
+type CSVToJSONTransformation struct {
+	webhookd.WebhookTransformation
+	// delimiter is the field delimiter.
+	delimiter rune
+	// columns is the list of column names. If empty then the first row of each message is used.
+	columns []string
+	// skip_header is a boolean flag signaling that the first row of each message should be ignored when 'columns' is not empty.
+	skip_header bool
+}
+
+// NewCSVToJSONTransformation returns a new `CSVToJSONTransformation` instance configured by 'uri' in the form of:
+//
+//	csv2json://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `delimiter={STRING}` The (URI-escaped) field delimiter. Valid options are a single character or "tab". Default is ",".
+// * `column={STRING}` Zero or more column names. If empty then the first row of each message is used as the list of column names.
+// * `skip_header={BOOLEAN}` Ignore the first row of each message when `column` parameters are present. Default is false.
+func NewCSVToJSONTransformation(ctx context.Context, uri string) (webhookd.WebhookTransformation, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	delimiter := ','
+
+	str_delimiter := q.Get("delimiter")
+
+	switch str_delimiter {
+	case "":
+		// pass
+	case "tab", `\t`:
+		delimiter = '\t'
+	default:
+
+		r, size := utf8.DecodeRuneInString(str_delimiter)
+
+		if size != len(str_delimiter) || r == utf8.RuneError || r == '"' || r == '\r' || r == '\n' {
+			return nil, fmt.Errorf("Invalid ?delimiter= parameter '%s'", str_delimiter)
+		}
+
+		delimiter = r
+	}
+
+	columns := make([]string, 0)
+
+	for _, c := range q["column"] {
+
+		for _, name := range strings.Split(c, ",") {
+
+			name = strings.TrimSpace(name)
+
+			if name != "" {
+				columns = append(columns, name)
+			}
+		}
+	}
+
+	skip_header := false
+
+	str_skip := q.Get("skip_header")
+
+	if str_skip != "" {
+
+		v, err := strconv.ParseBool(str_skip)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse ?skip_header= parameter, %w", err)
+		}
+
+		skip_header = v
+	}
+
+	tr := CSVToJSONTransformation{
+		delimiter:   delimiter,
+		columns:     columns,
+		skip_header: skip_header,
+	}
+
+	return &tr, nil
+}
+
+// Transform converts the CSV or TSV rows in 'body' to a JSON-encoded list of objects keyed by column name. Fields beyond the
+// last column are ignored and missing fields are omitted.
+func (tr *CSVToJSONTransformation) Transform(ctx context.Context, body []byte) ([]byte, *webhookd.WebhookError) {
+
+	r := csv.NewReader(bytes.NewReader(body))
+	r.Comma = tr.delimiter
+	r.FieldsPerRecord = -1
+
+	columns := tr.columns
+	first := true
+
+	rows := make([]map[string]string, 0)
+
+	for {
+
+		record, err := r.Read()
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			code := http.StatusBadRequest
+			message := fmt.Sprintf("Failed to parse CSV, %v", err)
+			return nil, &webhookd.WebhookError{Code: code, Message: message}
+		}
+
+		if first {
+
+			first = false
+
+			if len(columns) == 0 {
+
+				// Strip the byte order mark that some spreadsheet applications write
+
+				if len(record) > 0 {
+					record[0] = strings.TrimPrefix(record[0], "\ufeff")
+				}
+
+				columns = record
+				continue
+			}
+
+			if tr.skip_header {
+				continue
+			}
+		}
+
+		row := make(map[string]string)
+
+		for i, v := range record {
+
+			if i >= len(columns) {
+				break
+			}
+
+			row[columns[i]] = v
+		}
+
+		rows = append(rows, row)
+	}
+
+	enc, err := json.Marshal(rows)
+
+	if err != nil {
+		code := http.StatusInternalServerError
+		message := fmt.Sprintf("Failed to encode message, %v", err)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	return enc, nil
+}
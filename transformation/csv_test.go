@@ -0,0 +1,51 @@
+package transformation
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCSVToJSONTransformation(t *testing.T) {
+
+	ctx := context.Background()
+
+	tests := map[string][]string{
+		"csv2json://": []string{
+			"\ufeffid,name\n1,\"Doe, Jane\"\n2,Bob,extra\n3\n",
+			`[{"id":"1","name":"Doe, Jane"},{"id":"2","name":"Bob"},{"id":"3"}]`,
+		},
+		"csv2json://?delimiter=tab&column=id,name&skip_header=true": []string{
+			"a\tb\n1\tAlice\n",
+			`[{"id":"1","name":"Alice"}]`,
+		},
+		"csv2json://?delimiter=%3B&column=id&column=name": []string{
+			"1;Alice\n",
+			`[{"id":"1","name":"Alice"}]`,
+		},
+	}
+
+	for uri, details := range tests {
+
+		tr, err := NewTransformation(ctx, uri)
+
+		if err != nil {
+			t.Fatalf("Failed to create new transformation for %s, %v", uri, err)
+		}
+
+		output, err2 := tr.Transform(ctx, []byte(details[0]))
+
+		if err2 != nil {
+			t.Fatalf("Failed to transform body for %s, %v", uri, err2)
+		}
+
+		if string(output) != details[1] {
+			t.Fatalf("Unexpected output for %s '%s'", uri, string(output))
+		}
+	}
+
+	_, err := NewTransformation(ctx, "csv2json://?delimiter=ab")
+
+	if err == nil {
+		t.Fatalf("Expected invalid delimiter to fail")
+	}
+}
@@ -0,0 +1,175 @@
+package transformation
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+	"github.com/whosonfirst/go-webhookd/v3/dedupe"
+	"github.com/whosonfirst/go-webhookd/v3/jsonpath"
+)
+
+// DEFAULT_DEDUPE_STORE is the default `dedupe.Store` URI used to record message hashes.
+const DEFAULT_DEDUPE_STORE string = "memory://"
+
+// DEFAULT_DEDUPE_TTL is the default amount of time that message hashes are recorded.
+const DEFAULT_DEDUPE_TTL time.Duration = time.Hour
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterTransformation(ctx, "dedupe", NewDedupeTransformation)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// DedupeTransformation implements the `webhookd.WebhookTransformation` interface for dropping messages whose contents have
+// already been seen within a given period of time.
+type DedupeTransformation struct {
+	webhookd.WebhookTransformation
+	// store is the `dedupe.Store` instance used to record message hashes.
+	store dedupe.Store
+	// paths is the optional list of `jsonpath` paths used to derive message hashes.
+	paths []string
+	// ttl is the amount of time that message hashes are recorded.
+	ttl time.Duration
+}
+
+// NewDedupeTransformation returns a new `DedupeTransformation` instance configured by 'uri' in the form of:
+//
+//	dedupe://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `store={URI}` A URI-escaped `dedupe.Store` URI used to record message hashes. Default is "memory://".
+// * `path={PATH}` Zero or more (dot-separated) paths used to derive message hashes. If empty then the entire message body is hashed.
+// * `ttl={DURATION}` The amount of time that message hashes are recorded. Default is "1h".
+func NewDedupeTransformation(ctx context.Context, uri string) (webhookd.WebhookTransformation, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	store_uri := q.Get("store")
+
+	if store_uri == "" {
+		store_uri = DEFAULT_DEDUPE_STORE
+	}
+
+	store, err := dedupe.NewStore(ctx, store_uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create dedupe store, %w", err)
+	}
+
+	ttl := DEFAULT_DEDUPE_TTL
+
+	str_ttl := q.Get("ttl")
+
+	if str_ttl != "" {
+
+		v, err := time.ParseDuration(str_ttl)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse ?ttl= parameter, %w", err)
+		}
+
+		if v <= 0 {
+			return nil, fmt.Errorf("Invalid ?ttl= parameter, must be greater than zero")
+		}
+
+		ttl = v
+	}
+
+	tr := DedupeTransformation{
+		store: store,
+		paths: q["path"],
+		ttl:   ttl,
+	}
+
+	return &tr, nil
+}
+
+// Transform returns 'body' unaltered if its hash has not been seen within the TTL that 'tr' was instantiated with, otherwise
+// it returns a `webhookd.HaltEvent` error.
+func (tr *DedupeTransformation) Transform(ctx context.Context, body []byte) ([]byte, *webhookd.WebhookError) {
+
+	key, err := tr.hash(body)
+
+	if err != nil {
+		code := http.StatusBadRequest
+		message := err.Error()
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	// Checking whether a message has been seen also records it so messages in a dry run, which will not be dispatched,
+	// are passed through unchecked
+
+	delivery, ok := webhookd.DeliveryFromContext(ctx)
+
+	if ok && delivery.DryRun {
+		return body, nil
+	}
+
+	seen, err := tr.store.Seen(ctx, key, tr.ttl)
+
+	if err != nil {
+		code := http.StatusServiceUnavailable
+		message := fmt.Sprintf("Failed to check for duplicate message, %v", err)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	if seen {
+		code := webhookd.HaltEvent
+		message := fmt.Sprintf("Duplicate message %s", key)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	return body, nil
+}
+
+// hash returns the hex-encoded SHA-256 hash for 'body', or for the values of the paths that 'tr' was instantiated with.
+func (tr *DedupeTransformation) hash(body []byte) (string, error) {
+
+	h := sha256.New()
+
+	if len(tr.paths) == 0 {
+		h.Write(body)
+		return hex.EncodeToString(h.Sum(nil)), nil
+	}
+
+	doc, err := jsonpath.Decode(body)
+
+	if err != nil {
+		return "", err
+	}
+
+	for _, path := range tr.paths {
+
+		// Missing values are hashed as null so that the position of each path is preserved
+
+		v, _ := jsonpath.Get(doc, path)
+
+		enc, err := json.Marshal(v)
+
+		if err != nil {
+			return "", fmt.Errorf("Failed to encode '%s', %w", path, err)
+		}
+
+		h.Write(enc)
+		h.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
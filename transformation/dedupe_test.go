@@ -0,0 +1,80 @@
+package transformation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+func TestDedupeTransformation(t *testing.T) {
+
+	ctx := context.Background()
+
+	tests := map[string][][]byte{
+		"dedupe://": [][]byte{
+			[]byte(`{"id":1,"ts":1}`),
+			[]byte(`{"id":1,"ts":2}`),
+			[]byte(`{"id":1,"ts":1}`),
+		},
+		"dedupe://?path=id&ttl=1m": [][]byte{
+			[]byte(`{"id":1,"ts":1}`),
+			[]byte(`{"id":2,"ts":2}`),
+			[]byte(`{"id":1,"ts":3}`),
+		},
+	}
+
+	for uri, bodies := range tests {
+
+		tr, err := NewTransformation(ctx, uri)
+
+		if err != nil {
+			t.Fatalf("Failed to create new transformation for %s, %v", uri, err)
+		}
+
+		for i, body := range bodies {
+
+			_, err2 := tr.Transform(ctx, body)
+
+			if i < 2 && err2 != nil {
+				t.Fatalf("Unexpected error for %s message %d, %v", uri, i, err2)
+			}
+
+			if i == 2 && (err2 == nil || err2.Code != webhookd.HaltEvent) {
+				t.Fatalf("Expected duplicate message for %s to halt, %v", uri, err2)
+			}
+		}
+	}
+}
+
+func TestDedupeTransformationDryRun(t *testing.T) {
+
+	ctx := context.Background()
+
+	tr, err := NewTransformation(ctx, "dedupe://")
+
+	if err != nil {
+		t.Fatalf("Failed to create new transformation, %v", err)
+	}
+
+	body := []byte(`{"id":1}`)
+
+	dryrun_ctx := webhookd.WithDelivery(ctx, &webhookd.Delivery{DryRun: true})
+
+	for i := 0; i < 2; i++ {
+
+		_, err2 := tr.Transform(dryrun_ctx, body)
+
+		if err2 != nil {
+			t.Fatalf("Unexpected error for dry run %d, %v", i, err2)
+		}
+	}
+
+	// Messages in a dry run should not be recorded as having been seen
+
+	_, err2 := tr.Transform(ctx, body)
+
+	if err2 != nil {
+		t.Fatalf("Unexpected error after dry run, %v", err2)
+	}
+}
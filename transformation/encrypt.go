@@ -0,0 +1,261 @@
+package transformation
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/sfomuseum/runtimevar"
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+const (
+	// ENCRYPT_AES_GCM encrypts messages with a shared AES key using Galois/Counter Mode.
+	ENCRYPT_AES_GCM string = "aes-gcm"
+	// ENCRYPT_X25519 encrypts messages for the holder of an X25519 private key using an ephemeral key exchange and AES-256-GCM.
+	ENCRYPT_X25519 string = "x25519"
+)
+
+const (
+	// ENCRYPT_ENCODING_RAW returns encrypted messages as raw bytes.
+	ENCRYPT_ENCODING_RAW string = "raw"
+	// ENCRYPT_ENCODING_BASE64 returns encrypted messages as standard base64-encoded strings.
+	ENCRYPT_ENCODING_BASE64 string = "base64"
+)
+
+// encryptX25519Info is the HKDF "info" parameter used to derive AES keys from X25519 shared secrets.
+const encryptX25519Info string = "webhookd-x25519-aes-256-gcm"
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterTransformation(ctx, "encrypt", NewEncryptTransformation)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// EncryptTransformation implements the `webhookd.WebhookTransformation` interface for encrypting messages.
+type EncryptTransformation struct {
+	webhookd.WebhookTransformation
+	// scheme is the encryption scheme used to encrypt messages.
+	scheme string
+	// aead is the cipher used by the "aes-gcm" scheme.
+	aead cipher.AEAD
+	// recipient is the public key used by the "x25519" scheme.
+	recipient *ecdh.PublicKey
+	// encoding is the encoding applied to encrypted messages.
+	encoding string
+}
+
+// NewEncryptTransformation returns a new `EncryptTransformation` instance configured by 'uri' in the form of:
+//
+//	encrypt://{SCHEME}?{PARAMETERS}
+//
+// Valid {SCHEME} values are:
+// * `aes-gcm` Encrypt messages with a shared 128, 192 or 256-bit AES key using Galois/Counter Mode.
+// * `x25519` Encrypt messages for the holder of the private key corresponding to an X25519 public key.
+//
+// Valid {PARAMETERS} are:
+// * `key={URI}` A URI-escaped `gocloud.dev/runtimevar` URI whose value is the base64-encoded AES key or X25519 public key. Required.
+// * `encoding={STRING}` The encoding applied to encrypted messages. Valid options are "raw" and "base64". Default is "raw".
+func NewEncryptTransformation(ctx context.Context, uri string) (webhookd.WebhookTransformation, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	key_uri := q.Get("key")
+
+	if key_uri == "" {
+		return nil, fmt.Errorf("Missing ?key= parameter")
+	}
+
+	str_key, err := runtimevar.StringVar(ctx, key_uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read key, %w", err)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(str_key))
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decode key, %w", err)
+	}
+
+	encoding := q.Get("encoding")
+
+	switch encoding {
+	case "":
+		encoding = ENCRYPT_ENCODING_RAW
+	case ENCRYPT_ENCODING_RAW, ENCRYPT_ENCODING_BASE64:
+		// pass
+	default:
+		return nil, fmt.Errorf("Invalid ?encoding= parameter '%s'", encoding)
+	}
+
+	tr := EncryptTransformation{
+		scheme:   u.Host,
+		encoding: encoding,
+	}
+
+	switch tr.scheme {
+	case ENCRYPT_AES_GCM:
+
+		aead, err := newAESGCM(key)
+
+		if err != nil {
+			return nil, err
+		}
+
+		tr.aead = aead
+
+	case ENCRYPT_X25519:
+
+		pub, err := ecdh.X25519().NewPublicKey(key)
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid X25519 public key, %w", err)
+		}
+
+		tr.recipient = pub
+
+	default:
+		return nil, fmt.Errorf("Invalid or unsupported encryption scheme '%s'", tr.scheme)
+	}
+
+	return &tr, nil
+}
+
+// Transform encrypts 'body'. For the "aes-gcm" scheme the output is the nonce followed by the sealed message. For the "x25519"
+// scheme the output is the ephemeral public key followed by the nonce and the sealed message, where the AES-256-GCM key is derived
+// from the X25519 shared secret using HKDF-SHA256 with the ephemeral and recipient public keys as salt.
+func (tr *EncryptTransformation) Transform(ctx context.Context, body []byte) ([]byte, *webhookd.WebhookError) {
+
+	var enc []byte
+	var err error
+
+	switch tr.scheme {
+	case ENCRYPT_X25519:
+		enc, err = tr.sealX25519(body)
+	default:
+		enc, err = sealAESGCM(tr.aead, nil, body)
+	}
+
+	if err != nil {
+		code := http.StatusInternalServerError
+		message := fmt.Sprintf("Failed to encrypt message, %v", err)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	if tr.encoding == ENCRYPT_ENCODING_BASE64 {
+		enc = []byte(base64.StdEncoding.EncodeToString(enc))
+	}
+
+	return enc, nil
+}
+
+// sealX25519 encrypts 'body' for the recipient public key in 'tr' using a new ephemeral key pair.
+func (tr *EncryptTransformation) sealX25519(body []byte) ([]byte, error) {
+
+	ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to generate ephemeral key, %w", err)
+	}
+
+	shared, err := ephemeral.ECDH(tr.recipient)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive shared secret, %w", err)
+	}
+
+	ephemeral_pub := ephemeral.PublicKey().Bytes()
+
+	salt := make([]byte, 0, len(ephemeral_pub)*2)
+	salt = append(salt, ephemeral_pub...)
+	salt = append(salt, tr.recipient.Bytes()...)
+
+	aead, err := newAESGCM(hkdfSHA256(shared, salt, []byte(encryptX25519Info), 32))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return sealAESGCM(aead, ephemeral_pub, body)
+}
+
+// newAESGCM returns a new AES-GCM `cipher.AEAD` instance for 'key'.
+func newAESGCM(key []byte) (cipher.AEAD, error) {
+
+	block, err := aes.NewCipher(key)
+
+	if err != nil {
+		return nil, fmt.Errorf("Invalid AES key, %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create GCM cipher, %w", err)
+	}
+
+	return aead, nil
+}
+
+// sealAESGCM encrypts 'body' with 'aead' and a random nonce, appending the nonce and sealed message to 'prefix'.
+func sealAESGCM(aead cipher.AEAD, prefix []byte, body []byte) ([]byte, error) {
+
+	nonce := make([]byte, aead.NonceSize())
+
+	_, err := io.ReadFull(rand.Reader, nonce)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to generate nonce, %w", err)
+	}
+
+	out := make([]byte, 0, len(prefix)+len(nonce)+len(body)+aead.Overhead())
+	out = append(out, prefix...)
+	out = append(out, nonce...)
+
+	return aead.Seal(out, nonce, body, nil), nil
+}
+
+// hkdfSHA256 derives a 'length' byte key from 'secret' using HKDF (RFC 5869) with SHA-256.
+func hkdfSHA256(secret []byte, salt []byte, info []byte, length int) []byte {
+
+	extract := hmac.New(sha256.New, salt)
+	extract.Write(secret)
+	prk := extract.Sum(nil)
+
+	out := make([]byte, 0, length)
+	prev := make([]byte, 0)
+
+	for i := byte(1); len(out) < length; i++ {
+
+		expand := hmac.New(sha256.New, prk)
+		expand.Write(prev)
+		expand.Write(info)
+		expand.Write([]byte{i})
+		prev = expand.Sum(nil)
+
+		out = append(out, prev...)
+	}
+
+	return out[:length]
+}
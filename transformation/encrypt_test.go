@@ -0,0 +1,142 @@
+package transformation
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"testing"
+)
+
+func TestEncryptTransformationAESGCM(t *testing.T) {
+
+	ctx := context.Background()
+
+	key := make([]byte, 32)
+	rand.Read(key)
+
+	key_uri := fmt.Sprintf("constant://?val=%s", url.QueryEscape(base64.StdEncoding.EncodeToString(key)))
+	uri := fmt.Sprintf("encrypt://aes-gcm?encoding=base64&key=%s", url.QueryEscape(key_uri))
+
+	tr, err := NewTransformation(ctx, uri)
+
+	if err != nil {
+		t.Fatalf("Failed to create new encrypt transformation, %v", err)
+	}
+
+	body := []byte(`{"secret":"hello world"}`)
+
+	output, err2 := tr.Transform(ctx, body)
+
+	if err2 != nil {
+		t.Fatalf("Failed to transform body, %v", err2)
+	}
+
+	enc, err := base64.StdEncoding.DecodeString(string(output))
+
+	if err != nil {
+		t.Fatalf("Failed to decode output, %v", err)
+	}
+
+	aead, err := newAESGCM(key)
+
+	if err != nil {
+		t.Fatalf("Failed to create cipher, %v", err)
+	}
+
+	nonce := enc[:aead.NonceSize()]
+
+	plain, err := aead.Open(nil, nonce, enc[aead.NonceSize():], nil)
+
+	if err != nil {
+		t.Fatalf("Failed to decrypt output, %v", err)
+	}
+
+	if string(plain) != string(body) {
+		t.Fatalf("Unexpected decrypted output '%s'", string(plain))
+	}
+}
+
+func TestEncryptTransformationX25519(t *testing.T) {
+
+	ctx := context.Background()
+
+	priv, err := ecdh.X25519().GenerateKey(rand.Reader)
+
+	if err != nil {
+		t.Fatalf("Failed to generate key, %v", err)
+	}
+
+	key_uri := fmt.Sprintf("constant://?val=%s", url.QueryEscape(base64.StdEncoding.EncodeToString(priv.PublicKey().Bytes())))
+	uri := fmt.Sprintf("encrypt://x25519?key=%s", url.QueryEscape(key_uri))
+
+	tr, err := NewTransformation(ctx, uri)
+
+	if err != nil {
+		t.Fatalf("Failed to create new encrypt transformation, %v", err)
+	}
+
+	body := []byte(`{"secret":"hello world"}`)
+
+	enc, err2 := tr.Transform(ctx, body)
+
+	if err2 != nil {
+		t.Fatalf("Failed to transform body, %v", err2)
+	}
+
+	ephemeral, err := ecdh.X25519().NewPublicKey(enc[:32])
+
+	if err != nil {
+		t.Fatalf("Failed to parse ephemeral key, %v", err)
+	}
+
+	shared, err := priv.ECDH(ephemeral)
+
+	if err != nil {
+		t.Fatalf("Failed to derive shared secret, %v", err)
+	}
+
+	salt := append(enc[:32:32], priv.PublicKey().Bytes()...)
+
+	aead, err := newAESGCM(hkdfSHA256(shared, salt, []byte(encryptX25519Info), 32))
+
+	if err != nil {
+		t.Fatalf("Failed to create cipher, %v", err)
+	}
+
+	nonce := enc[32 : 32+aead.NonceSize()]
+
+	plain, err := aead.Open(nil, nonce, enc[32+aead.NonceSize():], nil)
+
+	if err != nil {
+		t.Fatalf("Failed to decrypt output, %v", err)
+	}
+
+	if string(plain) != string(body) {
+		t.Fatalf("Unexpected decrypted output '%s'", string(plain))
+	}
+}
+
+func TestHKDFSHA256(t *testing.T) {
+
+	// RFC 5869, test case 1
+
+	ikm := make([]byte, 22)
+
+	for i := range ikm {
+		ikm[i] = 0x0b
+	}
+
+	salt := []byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c}
+	info := []byte{0xf0, 0xf1, 0xf2, 0xf3, 0xf4, 0xf5, 0xf6, 0xf7, 0xf8, 0xf9}
+
+	okm := hkdfSHA256(ikm, salt, info, 42)
+
+	expected := "3cb25f25faacd57a90434f64d0362f2a2d2d0a90cf1a5a4c5db02d56ecc4c5bf34007208d5b887185865"
+
+	if fmt.Sprintf("%x", okm) != expected {
+		t.Fatalf("Unexpected output %x", okm)
+	}
+}
@@ -0,0 +1,220 @@
+package transformation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterTransformation(ctx, "githubpush", NewGitHubPushFilterTransformation)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// gitHubPushEvent is the subset of a GitHub push event used by `GitHubPushFilterTransformation`.
+type gitHubPushEvent struct {
+	Ref     string `json:"ref"`
+	Commits []struct {
+		Added    []string `json:"added"`
+		Modified []string `json:"modified"`
+		Removed  []string `json:"removed"`
+	} `json:"commits"`
+}
+
+// GitHubPushFilterTransformation implements the `webhookd.WebhookTransformation` interface for dropping GitHub push events
+// that don't match a set of branch and path filters.
+type GitHubPushFilterTransformation struct {
+	webhookd.WebhookTransformation
+	// branches is the list of patterns that a branch must match.
+	branches []*regexp.Regexp
+	// include_paths is the list of patterns that at least one changed path must match.
+	include_paths []*regexp.Regexp
+	// exclude_paths is the list of patterns for changed paths that should be ignored.
+	exclude_paths []*regexp.Regexp
+}
+
+// NewGitHubPushFilterTransformation returns a new `GitHubPushFilterTransformation` instance configured by 'uri' in the form of:
+//
+//	githubpush://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `branch={GLOB}` Zero or more branch name patterns, for example "main" or "release/*".
+// * `include_paths={GLOB}` Zero or more (comma-separated) path patterns, for example "deploy/**".
+// * `exclude_paths={GLOB}` Zero or more (comma-separated) path patterns, for example "**/*.md".
+//
+// Patterns may use "*" to match any sequence of characters except "/", "**" to match any sequence of characters including "/"
+// and "?" to match any single character except "/".
+func NewGitHubPushFilterTransformation(ctx context.Context, uri string) (webhookd.WebhookTransformation, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	tr := GitHubPushFilterTransformation{}
+
+	patterns := map[string]*[]*regexp.Regexp{
+		"branch":        &tr.branches,
+		"include_paths": &tr.include_paths,
+		"exclude_paths": &tr.exclude_paths,
+	}
+
+	for k, ptr := range patterns {
+
+		for _, v := range q[k] {
+
+			for _, glob := range strings.Split(v, ",") {
+
+				glob = strings.TrimSpace(glob)
+
+				if glob == "" {
+					continue
+				}
+
+				re, err := compileGlob(glob)
+
+				if err != nil {
+					return nil, fmt.Errorf("Invalid ?%s= parameter '%s', %w", k, glob, err)
+				}
+
+				*ptr = append(*ptr, re)
+			}
+		}
+	}
+
+	if len(tr.branches) == 0 && len(tr.include_paths) == 0 && len(tr.exclude_paths) == 0 {
+		return nil, fmt.Errorf("Missing ?branch=, ?include_paths= or ?exclude_paths= parameters")
+	}
+
+	return &tr, nil
+}
+
+// Transform returns 'body' unaltered if the GitHub push event it contains matches the filters that 'tr' was instantiated with,
+// otherwise it returns a `webhookd.HaltEvent` error. An event matches if its branch matches at least one `branch` pattern and at
+// least one of the paths changed by its commits matches an `include_paths` pattern and does not match any `exclude_paths` pattern.
+// Empty lists of patterns always match. Events that are not push events return a `webhookd.UnhandledEvent` error.
+func (tr *GitHubPushFilterTransformation) Transform(ctx context.Context, body []byte) ([]byte, *webhookd.WebhookError) {
+
+	var event gitHubPushEvent
+
+	err := json.Unmarshal(body, &event)
+
+	if err != nil {
+		code := http.StatusBadRequest
+		message := fmt.Sprintf("Failed to decode push event, %v", err)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	if event.Ref == "" {
+		code := webhookd.UnhandledEvent
+		message := "Unhandled event, not a push event"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	if len(tr.branches) > 0 {
+
+		branch := strings.TrimPrefix(event.Ref, "refs/heads/")
+
+		if branch == event.Ref || !matchAnyGlob(tr.branches, branch) {
+			code := webhookd.HaltEvent
+			message := fmt.Sprintf("Ref '%s' does not match branch filter", event.Ref)
+			return nil, &webhookd.WebhookError{Code: code, Message: message}
+		}
+	}
+
+	if len(tr.include_paths) == 0 && len(tr.exclude_paths) == 0 {
+		return body, nil
+	}
+
+	for _, c := range event.Commits {
+
+		for _, paths := range [][]string{c.Added, c.Modified, c.Removed} {
+
+			for _, path := range paths {
+
+				if len(tr.include_paths) > 0 && !matchAnyGlob(tr.include_paths, path) {
+					continue
+				}
+
+				if matchAnyGlob(tr.exclude_paths, path) {
+					continue
+				}
+
+				return body, nil
+			}
+		}
+	}
+
+	code := webhookd.HaltEvent
+	message := "No changed paths match path filters"
+	return nil, &webhookd.WebhookError{Code: code, Message: message}
+}
+
+// compileGlob returns a regular expression equivalent to the glob pattern 'glob'.
+func compileGlob(glob string) (*regexp.Regexp, error) {
+
+	var b strings.Builder
+	b.WriteString("^")
+
+	for i := 0; i < len(glob); i++ {
+
+		c := glob[i]
+
+		switch c {
+		case '*':
+
+			if i+1 < len(glob) && glob[i+1] == '*' {
+
+				i++
+
+				// "**/" also matches zero directories
+
+				if i+1 < len(glob) && glob[i+1] == '/' {
+					i++
+					b.WriteString("(?:.*/)?")
+				} else {
+					b.WriteString(".*")
+				}
+
+			} else {
+				b.WriteString("[^/]*")
+			}
+
+		case '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	b.WriteString("$")
+
+	return regexp.Compile(b.String())
+}
+
+// matchAnyGlob returns a boolean value indicating whether 'str' matches any of 'patterns'.
+func matchAnyGlob(patterns []*regexp.Regexp, str string) bool {
+
+	for _, re := range patterns {
+
+		if re.MatchString(str) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,91 @@
+package transformation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+func TestCompileGlob(t *testing.T) {
+
+	tests := map[string]map[string]bool{
+		"deploy/**": {
+			"deploy/app.yaml":      true,
+			"deploy/prod/app.yaml": true,
+			"src/deploy/app.yaml":  false,
+			"deployment/app.yaml":  false,
+		},
+		"**/*.md": {
+			"README.md":      true,
+			"docs/index.md":  true,
+			"docs/index.mdx": false,
+		},
+		"release/*": {
+			"release/1.0":     true,
+			"release/1.0/fix": false,
+		},
+		"v?.go": {
+			"v1.go":  true,
+			"v10.go": false,
+		},
+	}
+
+	for glob, paths := range tests {
+
+		re, err := compileGlob(glob)
+
+		if err != nil {
+			t.Fatalf("Failed to compile %s, %v", glob, err)
+		}
+
+		for path, expected := range paths {
+
+			if re.MatchString(path) != expected {
+				t.Fatalf("Unexpected result matching %s against %s", path, glob)
+			}
+		}
+	}
+}
+
+func TestGitHubPushFilterTransformation(t *testing.T) {
+
+	ctx := context.Background()
+
+	tr, err := NewTransformation(ctx, "githubpush://?branch=main&include_paths=deploy/**&exclude_paths=**/*.md")
+
+	if err != nil {
+		t.Fatalf("Failed to create new githubpush transformation, %v", err)
+	}
+
+	tests := map[string]int{
+		`{"ref":"refs/heads/main","commits":[{"added":["deploy/app.yaml"]}]}`:                         0,
+		`{"ref":"refs/heads/main","commits":[{"modified":["src/main.go"]},{"removed":["deploy/x"]}]}`: 0,
+		`{"ref":"refs/heads/dev","commits":[{"added":["deploy/app.yaml"]}]}`:                          webhookd.HaltEvent,
+		`{"ref":"refs/tags/main","commits":[{"added":["deploy/app.yaml"]}]}`:                          webhookd.HaltEvent,
+		`{"ref":"refs/heads/main","commits":[{"modified":["deploy/README.md","src/main.go"]}]}`:       webhookd.HaltEvent,
+		`{"zen":"Keep it logically awesome."}`:                                                        webhookd.UnhandledEvent,
+	}
+
+	for body, expected := range tests {
+
+		output, err2 := tr.Transform(ctx, []byte(body))
+
+		if expected == 0 {
+
+			if err2 != nil {
+				t.Fatalf("Unexpected error for %s, %v", body, err2)
+			}
+
+			if string(output) != body {
+				t.Fatalf("Expected %s to be unaltered", body)
+			}
+
+			continue
+		}
+
+		if err2 == nil || err2.Code != expected {
+			t.Fatalf("Expected error code %d for %s, %v", expected, body, err2)
+		}
+	}
+}
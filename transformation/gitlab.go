@@ -0,0 +1,114 @@
+package transformation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterTransformation(ctx, "gitlabcommits", NewGitLabCommitsTransformation)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// gitLabPushEvent is the subset of a GitLab push event used by `GitLabCommitsTransformation`.
+type gitLabPushEvent struct {
+	ObjectKind string `json:"object_kind"`
+	Repository struct {
+		Name string `json:"name"`
+	} `json:"repository"`
+	Commits []struct {
+		ID       string   `json:"id"`
+		Added    []string `json:"added"`
+		Modified []string `json:"modified"`
+		Removed  []string `json:"removed"`
+	} `json:"commits"`
+}
+
+// GitLabCommitsTransformation implements the `webhookd.WebhookTransformation` interface for extracting the list of files
+// changed by the commits in a GitLab push event.
+type GitLabCommitsTransformation struct {
+	webhookd.WebhookTransformation
+	options *commitsOptions
+}
+
+// NewGitLabCommitsTransformation returns a new `GitLabCommitsTransformation` instance configured by 'uri' in the form of:
+//
+//	gitlabcommits://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `exclude_additions={BOOLEAN}` Exclude paths that were added. Default is false.
+// * `exclude_modifications={BOOLEAN}` Exclude paths that were modified. Default is false.
+// * `exclude_deletions={BOOLEAN}` Exclude paths that were removed. Default is false.
+func NewGitLabCommitsTransformation(ctx context.Context, uri string) (webhookd.WebhookTransformation, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	opts, err := newCommitsOptions(u.Query())
+
+	if err != nil {
+		return nil, err
+	}
+
+	tr := GitLabCommitsTransformation{
+		options: opts,
+	}
+
+	return &tr, nil
+}
+
+// Transform returns a CSV-encoded list of rows, consisting of commit hash, repository name and path, for each file changed by
+// the commits in the GitLab push event 'body'. Events that are not push events return a `webhookd.UnhandledEvent` error.
+func (tr *GitLabCommitsTransformation) Transform(ctx context.Context, body []byte) ([]byte, *webhookd.WebhookError) {
+
+	var event gitLabPushEvent
+
+	err := json.Unmarshal(body, &event)
+
+	if err != nil {
+		code := http.StatusBadRequest
+		message := fmt.Sprintf("Failed to decode push event, %v", err)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	if event.ObjectKind != "push" {
+		code := webhookd.UnhandledEvent
+		message := fmt.Sprintf("Unhandled event '%s'", event.ObjectKind)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	commits := make([]*commitFiles, len(event.Commits))
+
+	for i, c := range event.Commits {
+
+		commits[i] = &commitFiles{
+			hash:     c.ID,
+			added:    c.Added,
+			modified: c.Modified,
+			removed:  c.Removed,
+		}
+	}
+
+	enc, err := encodeCommitFiles(tr.options, event.Repository.Name, commits)
+
+	if err != nil {
+		code := http.StatusInternalServerError
+		message := err.Error()
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	return enc, nil
+}
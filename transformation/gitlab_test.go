@@ -0,0 +1,46 @@
+package transformation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+func TestGitLabCommitsTransformation(t *testing.T) {
+
+	ctx := context.Background()
+
+	tr, err := NewTransformation(ctx, "gitlabcommits://?exclude_deletions=true")
+
+	if err != nil {
+		t.Fatalf("Failed to create new gitlabcommits transformation, %v", err)
+	}
+
+	body := `{
+		"object_kind": "push",
+		"repository": { "name": "example" },
+		"commits": [
+			{ "id": "abc", "added": [ "a.txt" ], "modified": [ "b.txt" ], "removed": [ "c.txt" ] },
+			{ "id": "def", "added": [], "modified": [ "dir/d, e.txt" ], "removed": [] }
+		]
+	}`
+
+	output, err2 := tr.Transform(ctx, []byte(body))
+
+	if err2 != nil {
+		t.Fatalf("Failed to transform body, %v", err2)
+	}
+
+	expected := "abc,example,a.txt\nabc,example,b.txt\ndef,example,\"dir/d, e.txt\"\n"
+
+	if string(output) != expected {
+		t.Fatalf("Unexpected output '%s'", string(output))
+	}
+
+	_, err2 = tr.Transform(ctx, []byte(`{"object_kind":"tag_push"}`))
+
+	if err2 == nil || err2.Code != webhookd.UnhandledEvent {
+		t.Fatalf("Expected tag push event to be unhandled, %v", err2)
+	}
+}
@@ -0,0 +1,173 @@
+package transformation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+	"github.com/whosonfirst/go-webhookd/v3/jsonpath"
+	"github.com/whosonfirst/go-webhookd/v3/jsonschema"
+)
+
+const (
+	// SCHEMA_INVALID_REJECT rejects invalid messages with a 422 Unprocessable Entity error.
+	SCHEMA_INVALID_REJECT string = "reject"
+	// SCHEMA_INVALID_DROP silently drops invalid messages by halting processing.
+	SCHEMA_INVALID_DROP string = "drop"
+	// SCHEMA_INVALID_ANNOTATE adds the list of validation errors to invalid messages and continues processing.
+	SCHEMA_INVALID_ANNOTATE string = "annotate"
+)
+
+// DEFAULT_SCHEMA_PROPERTY is the default property used to store validation errors for annotated messages.
+const DEFAULT_SCHEMA_PROPERTY string = "webhookd_validation_errors"
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterTransformation(ctx, "jsonschema", NewJSONSchemaTransformation)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// JSONSchemaTransformation implements the `webhookd.WebhookTransformation` interface for validating JSON-encoded messages
+// against a JSON schema.
+type JSONSchemaTransformation struct {
+	webhookd.WebhookTransformation
+	// schema is the `jsonschema.Schema` instance that messages are validated against.
+	schema *jsonschema.Schema
+	// on_invalid is the action to take for invalid messages.
+	on_invalid string
+	// property is the (top-level) property used to store validation errors for annotated messages.
+	property string
+}
+
+// NewJSONSchemaTransformation returns a new `JSONSchemaTransformation` instance configured by 'uri' in the form of:
+//
+//	jsonschema://{PATH}?{PARAMETERS}
+//
+// Where {PATH} is the path to a JSON schema file. Valid {PARAMETERS} are:
+// * `on_invalid={STRING}` The action to take for invalid messages. Valid options are "reject", "drop" and "annotate". Default is "reject".
+// * `property={STRING}` The (top-level) property used to store validation errors when `on_invalid=annotate`. Default is "webhookd_validation_errors".
+//
+// See the `jsonschema` package for details about which JSON schema keywords are supported.
+func NewJSONSchemaTransformation(ctx context.Context, uri string) (webhookd.WebhookTransformation, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	path := u.Path
+
+	if u.Host != "" {
+		path = filepath.Join(u.Host, path)
+	}
+
+	if path == "" {
+		return nil, fmt.Errorf("Missing schema path")
+	}
+
+	schema, err := jsonschema.Open(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load schema, %w", err)
+	}
+
+	q := u.Query()
+
+	on_invalid := q.Get("on_invalid")
+
+	switch on_invalid {
+	case "":
+		on_invalid = SCHEMA_INVALID_REJECT
+	case SCHEMA_INVALID_REJECT, SCHEMA_INVALID_DROP, SCHEMA_INVALID_ANNOTATE:
+		// pass
+	default:
+		return nil, fmt.Errorf("Invalid ?on_invalid= parameter '%s'", on_invalid)
+	}
+
+	property := q.Get("property")
+
+	if property == "" {
+		property = DEFAULT_SCHEMA_PROPERTY
+	}
+
+	tr := JSONSchemaTransformation{
+		schema:     schema,
+		on_invalid: on_invalid,
+		property:   property,
+	}
+
+	return &tr, nil
+}
+
+// Transform validates 'body' against the schema that 'tr' was instantiated with. Valid messages are returned unaltered. Invalid
+// messages are rejected, dropped or annotated depending on how 'tr' was instantiated. Messages that are not valid JSON are always rejected.
+func (tr *JSONSchemaTransformation) Transform(ctx context.Context, body []byte) ([]byte, *webhookd.WebhookError) {
+
+	errs, err := tr.schema.Validate(body)
+
+	if err != nil {
+		code := http.StatusUnprocessableEntity
+		message := fmt.Sprintf("Invalid JSON, %v", err)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	if len(errs) == 0 {
+		return body, nil
+	}
+
+	switch tr.on_invalid {
+	case SCHEMA_INVALID_DROP:
+		code := webhookd.HaltEvent
+		message := fmt.Sprintf("Message failed schema validation, %s", joinValidationErrors(errs))
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	case SCHEMA_INVALID_ANNOTATE:
+
+		doc, _ := jsonpath.Decode(body)
+		obj, ok := doc.(map[string]interface{})
+
+		if !ok {
+			code := http.StatusUnprocessableEntity
+			message := fmt.Sprintf("Can not annotate message that is not a JSON object, %s", joinValidationErrors(errs))
+			return nil, &webhookd.WebhookError{Code: code, Message: message}
+		}
+
+		obj[tr.property] = errs
+
+		enc, err := json.Marshal(obj)
+
+		if err != nil {
+			code := http.StatusInternalServerError
+			message := fmt.Sprintf("Failed to encode message, %v", err)
+			return nil, &webhookd.WebhookError{Code: code, Message: message}
+		}
+
+		return enc, nil
+
+	default:
+		code := http.StatusUnprocessableEntity
+		message := fmt.Sprintf("Message failed schema validation, %s", joinValidationErrors(errs))
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+}
+
+// joinValidationErrors returns a single string representation of 'errs'.
+func joinValidationErrors(errs []*jsonschema.ValidationError) string {
+
+	messages := make([]string, len(errs))
+
+	for idx, e := range errs {
+		messages[idx] = e.Error()
+	}
+
+	return strings.Join(messages, "; ")
+}
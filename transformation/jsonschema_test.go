@@ -0,0 +1,72 @@
+package transformation
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+func TestJSONSchemaTransformation(t *testing.T) {
+
+	ctx := context.Background()
+
+	schema_path := filepath.Join(t.TempDir(), "schema.json")
+
+	err := os.WriteFile(schema_path, []byte(`{"type":"object","required":["id"]}`), 0644)
+
+	if err != nil {
+		t.Fatalf("Failed to write schema, %v", err)
+	}
+
+	tests := map[string]int{
+		"reject":   http.StatusUnprocessableEntity,
+		"drop":     webhookd.HaltEvent,
+		"annotate": 0,
+	}
+
+	for on_invalid, expected := range tests {
+
+		tr, err := NewTransformation(ctx, fmt.Sprintf("jsonschema://%s?on_invalid=%s", schema_path, on_invalid))
+
+		if err != nil {
+			t.Fatalf("Failed to create new jsonschema transformation, %v", err)
+		}
+
+		valid := []byte(`{"id":1}`)
+
+		output, err2 := tr.Transform(ctx, valid)
+
+		if err2 != nil {
+			t.Fatalf("Failed to transform valid body, %v", err2)
+		}
+
+		if string(output) != string(valid) {
+			t.Fatalf("Unexpected output '%s'", string(output))
+		}
+
+		output, err2 = tr.Transform(ctx, []byte(`{"name":"bob"}`))
+
+		if expected == 0 {
+
+			if err2 != nil {
+				t.Fatalf("Failed to annotate invalid body, %v", err2)
+			}
+
+			if !strings.Contains(string(output), "webhookd_validation_errors") {
+				t.Fatalf("Unexpected output '%s'", string(output))
+			}
+
+			continue
+		}
+
+		if err2 == nil || err2.Code != expected {
+			t.Fatalf("Expected error code %d for '%s', got %v", expected, on_invalid, err2)
+		}
+	}
+}
@@ -0,0 +1,429 @@
+package transformation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+	"github.com/whosonfirst/go-webhookd/v3/jsonpath"
+)
+
+// DEFAULT_LOOKUP_PROPERTY is the default property used to store the results of a lookup.
+const DEFAULT_LOOKUP_PROPERTY string = "lookup"
+
+// DEFAULT_LOOKUP_TTL is the default amount of time that lookup results are cached.
+const DEFAULT_LOOKUP_TTL time.Duration = 5 * time.Minute
+
+// DEFAULT_LOOKUP_TIMEOUT is the default amount of time to wait for a lookup request to complete.
+const DEFAULT_LOOKUP_TIMEOUT time.Duration = 5 * time.Second
+
+// DEFAULT_LOOKUP_CACHE_SIZE is the default maximum number of cached lookup results.
+const DEFAULT_LOOKUP_CACHE_SIZE int = 1000
+
+// maxLookupResponseSize is the maximum size of a lookup response body.
+const maxLookupResponseSize int64 = 1 << 20
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterTransformation(ctx, "lookup", NewLookupTransformation)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// LookupTemplateData is the data structure passed to lookup URL templates.
+type LookupTemplateData struct {
+	// Body is the decoded JSON message body.
+	Body interface{}
+	// Params is the dictionary of path parameters matched by the webhook endpoint.
+	Params map[string]string
+	// DeliveryID is the unique identifier for the webhook message being processed.
+	DeliveryID string
+	// Endpoint is the endpoint of the webhook processing the message.
+	Endpoint string
+	// EventType is the type of event the message describes or an empty string if unknown.
+	EventType string
+}
+
+// lookupTemplateFuncs is the dictionary of functions available to lookup URL templates.
+var lookupTemplateFuncs = template.FuncMap{
+	"path": func(doc interface{}, path string) (string, error) {
+
+		v, ok := jsonpath.GetString(doc, path)
+
+		if !ok {
+			return "", fmt.Errorf("Message does not contain '%s'", path)
+		}
+
+		return v, nil
+	},
+	"query":      url.QueryEscape,
+	"pathescape": url.PathEscape,
+}
+
+// LookupTransformation implements the `webhookd.WebhookTransformation` interface for enriching JSON-encoded messages with the
+// results of a request to an external HTTP API.
+type LookupTransformation struct {
+	webhookd.WebhookTransformation
+	// template is the `text/template.Template` used to derive the URL for each lookup.
+	template *template.Template
+	// headers are the HTTP headers sent with each lookup request.
+	headers http.Header
+	// property is the (top-level) property used to store the results of a lookup.
+	property string
+	// required is a boolean flag signaling that failed lookups should be reported as errors.
+	required bool
+	// client is the `http.Client` used to perform lookups.
+	client *http.Client
+	// cache is the `lookupCache` used to store the results of lookups.
+	cache *lookupCache
+}
+
+// NewLookupTransformation returns a new `LookupTransformation` instance configured by 'uri' in the form of:
+//
+//	lookup://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `url={TEMPLATE}` A URI-escaped Go language `text/template` string used to derive the URL for each lookup. Required. Templates are
+// passed a `LookupTemplateData` instance and may use the `path`, `query` and `pathescape` functions.
+// * `header={NAME}:{VALUE}` Zero or more HTTP headers to send with each lookup request.
+// * `property={STRING}` The (top-level) property used to store the results of a lookup. Default is "lookup".
+// * `ttl={DURATION}` The amount of time that lookup results are cached. A value of "0s" disables caching. Default is "5m".
+// * `cache_size={INT}` The maximum number of cached lookup results. Default is 1000.
+// * `timeout={DURATION}` The amount of time to wait for a lookup request to complete. Default is "5s".
+// * `required={BOOLEAN}` A boolean flag signaling that failed lookups should be reported as errors rather than leaving the message unaltered. Default is false.
+func NewLookupTransformation(ctx context.Context, uri string) (webhookd.WebhookTransformation, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	str_url := q.Get("url")
+
+	if str_url == "" {
+		return nil, fmt.Errorf("Missing ?url= parameter")
+	}
+
+	t, err := template.New("lookup").Option("missingkey=error").Funcs(lookupTemplateFuncs).Parse(str_url)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse ?url= template, %w", err)
+	}
+
+	headers := http.Header{}
+
+	for _, h := range q["header"] {
+
+		parts := strings.SplitN(h, ":", 2)
+
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("Invalid ?header= parameter '%s'", h)
+		}
+
+		headers.Add(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]))
+	}
+
+	property := q.Get("property")
+
+	if property == "" {
+		property = DEFAULT_LOOKUP_PROPERTY
+	}
+
+	ttl := DEFAULT_LOOKUP_TTL
+	timeout := DEFAULT_LOOKUP_TIMEOUT
+
+	durations := map[string]*time.Duration{
+		"ttl":     &ttl,
+		"timeout": &timeout,
+	}
+
+	for k, ptr := range durations {
+
+		str_v := q.Get(k)
+
+		if str_v == "" {
+			continue
+		}
+
+		v, err := time.ParseDuration(str_v)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse ?%s= parameter, %w", k, err)
+		}
+
+		*ptr = v
+	}
+
+	cache_size := DEFAULT_LOOKUP_CACHE_SIZE
+
+	str_size := q.Get("cache_size")
+
+	if str_size != "" {
+
+		v, err := strconv.Atoi(str_size)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse ?cache_size= parameter, %w", err)
+		}
+
+		cache_size = v
+	}
+
+	required := false
+
+	str_required := q.Get("required")
+
+	if str_required != "" {
+
+		v, err := strconv.ParseBool(str_required)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse ?required= parameter, %w", err)
+		}
+
+		required = v
+	}
+
+	tr := LookupTransformation{
+		template: t,
+		headers:  headers,
+		property: property,
+		required: required,
+		client:   &http.Client{Timeout: timeout},
+		cache:    newLookupCache(ttl, cache_size),
+	}
+
+	return &tr, nil
+}
+
+// Transform adds the results of a lookup, whose URL is derived from 'body', to the property that 'tr' was instantiated with.
+// 'body' is expected to be a JSON-encoded object. JSON-encoded lookup responses are added as-is and all other responses are added
+// as strings. If the lookup fails and 'tr' was not instantiated with `required=true` then 'body' is returned unaltered.
+func (tr *LookupTransformation) Transform(ctx context.Context, body []byte) ([]byte, *webhookd.WebhookError) {
+
+	doc, err := jsonpath.Decode(body)
+
+	if err != nil {
+		code := http.StatusBadRequest
+		message := err.Error()
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	obj, ok := doc.(map[string]interface{})
+
+	if !ok {
+		code := http.StatusBadRequest
+		message := "Message body is not a JSON object"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	data := &LookupTemplateData{
+		Body:       doc,
+		Params:     webhookd.PathParameters(ctx),
+		DeliveryID: webhookd.DeliveryID(ctx),
+	}
+
+	delivery, ok := webhookd.DeliveryFromContext(ctx)
+
+	if ok {
+		data.Endpoint = delivery.Endpoint
+		data.EventType = delivery.EventType
+	}
+
+	var buf bytes.Buffer
+
+	err = tr.template.Execute(&buf, data)
+
+	if err != nil {
+
+		if !tr.required {
+			return body, nil
+		}
+
+		code := http.StatusBadRequest
+		message := fmt.Sprintf("Failed to derive lookup URL, %v", err)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	lookup_url := buf.String()
+
+	result, ok := tr.cache.Get(lookup_url)
+
+	if !ok {
+
+		v, err := tr.fetch(ctx, lookup_url)
+
+		if err != nil {
+
+			if !tr.required {
+				return body, nil
+			}
+
+			code := http.StatusBadGateway
+			message := fmt.Sprintf("Failed to perform lookup, %v", err)
+			return nil, &webhookd.WebhookError{Code: code, Message: message}
+		}
+
+		tr.cache.Set(lookup_url, v)
+		result = v
+	}
+
+	obj[tr.property] = result
+
+	enc, err := json.Marshal(obj)
+
+	if err != nil {
+		code := http.StatusInternalServerError
+		message := fmt.Sprintf("Failed to encode message, %v", err)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	return enc, nil
+}
+
+// fetch retrieves 'lookup_url' returning its (JSON-decoded) body.
+func (tr *LookupTransformation) fetch(ctx context.Context, lookup_url string) (interface{}, error) {
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, lookup_url, nil)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create request, %w", err)
+	}
+
+	for k, v := range tr.headers {
+		req.Header[k] = v
+	}
+
+	rsp, err := tr.client.Do(req)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to execute request, %w", err)
+	}
+
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode < 200 || rsp.StatusCode > 299 {
+		return nil, fmt.Errorf("Lookup returned unexpected status %s", rsp.Status)
+	}
+
+	rsp_body, err := io.ReadAll(io.LimitReader(rsp.Body, maxLookupResponseSize))
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read response, %w", err)
+	}
+
+	v, err := jsonpath.Decode(rsp_body)
+
+	if err != nil {
+		return string(rsp_body), nil
+	}
+
+	return v, nil
+}
+
+// lookupCacheItem is a cached lookup result.
+type lookupCacheItem struct {
+	value   interface{}
+	expires time.Time
+}
+
+// lookupCache is a simple TTL cache for lookup results.
+type lookupCache struct {
+	mu    *sync.Mutex
+	ttl   time.Duration
+	size  int
+	items map[string]*lookupCacheItem
+	now   func() time.Time
+}
+
+// newLookupCache returns a new `lookupCache` instance that will store up to 'size' items for 'ttl'.
+func newLookupCache(ttl time.Duration, size int) *lookupCache {
+
+	c := &lookupCache{
+		mu:    new(sync.Mutex),
+		ttl:   ttl,
+		size:  size,
+		items: make(map[string]*lookupCacheItem),
+		now:   time.Now,
+	}
+
+	return c
+}
+
+// Get returns the cached value for 'key' and a boolean value indicating whether it was found and has not expired.
+func (c *lookupCache) Get(key string) (interface{}, bool) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	item, ok := c.items[key]
+
+	if !ok {
+		return nil, false
+	}
+
+	if !c.now().Before(item.expires) {
+		delete(c.items, key)
+		return nil, false
+	}
+
+	return item.value, true
+}
+
+// Set caches 'value' for 'key'. If the cache is full then expired items are removed and, if it is still full, the item closest
+// to expiring is removed.
+func (c *lookupCache) Set(key string, value interface{}) {
+
+	if c.ttl <= 0 || c.size <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+
+	if len(c.items) >= c.size {
+
+		var oldest string
+		var oldest_expires time.Time
+
+		for k, item := range c.items {
+
+			if !now.Before(item.expires) {
+				delete(c.items, k)
+				continue
+			}
+
+			if oldest == "" || item.expires.Before(oldest_expires) {
+				oldest = k
+				oldest_expires = item.expires
+			}
+		}
+
+		if len(c.items) >= c.size {
+			delete(c.items, oldest)
+		}
+	}
+
+	c.items[key] = &lookupCacheItem{
+		value:   value,
+		expires: now.Add(c.ttl),
+	}
+}
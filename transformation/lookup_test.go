@@ -0,0 +1,115 @@
+package transformation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+)
+
+func TestLookupTransformation(t *testing.T) {
+
+	ctx := context.Background()
+
+	var count int32
+
+	handler := func(rsp http.ResponseWriter, req *http.Request) {
+
+		atomic.AddInt32(&count, 1)
+
+		if req.Header.Get("Authorization") != "Bearer s33kret" {
+			http.Error(rsp, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		rsp.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(rsp, `{"name":"Example %s"}`, req.URL.Query().Get("user"))
+	}
+
+	s := httptest.NewServer(http.HandlerFunc(handler))
+	defer s.Close()
+
+	lookup_url := fmt.Sprintf(`%s/users?user={{ path .Body "author.username" | query }}`, s.URL)
+
+	q := url.Values{}
+	q.Set("url", lookup_url)
+	q.Set("property", "author_details")
+	q.Set("header", "Authorization: Bearer s33kret")
+	q.Set("required", "true")
+
+	tr, err := NewTransformation(ctx, fmt.Sprintf("lookup://?%s", q.Encode()))
+
+	if err != nil {
+		t.Fatalf("Failed to create new lookup transformation, %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+
+		output, err2 := tr.Transform(ctx, []byte(`{"author":{"username":"bob"}}`))
+
+		if err2 != nil {
+			t.Fatalf("Failed to transform body, %v", err2)
+		}
+
+		var doc map[string]interface{}
+
+		err = json.Unmarshal(output, &doc)
+
+		if err != nil {
+			t.Fatalf("Failed to unmarshal output, %v", err)
+		}
+
+		details, ok := doc["author_details"].(map[string]interface{})
+
+		if !ok || details["name"] != "Example bob" {
+			t.Fatalf("Unexpected output '%s'", string(output))
+		}
+	}
+
+	if atomic.LoadInt32(&count) != 1 {
+		t.Fatalf("Expected lookup to be cached, got %d requests", count)
+	}
+
+	_, err2 := tr.Transform(ctx, []byte(`{"author":{}}`))
+
+	if err2 == nil {
+		t.Fatalf("Expected missing path to fail")
+	}
+}
+
+func TestLookupTransformationOptional(t *testing.T) {
+
+	ctx := context.Background()
+
+	handler := func(rsp http.ResponseWriter, req *http.Request) {
+		http.Error(rsp, "Not found", http.StatusNotFound)
+	}
+
+	s := httptest.NewServer(http.HandlerFunc(handler))
+	defer s.Close()
+
+	q := url.Values{}
+	q.Set("url", s.URL)
+
+	tr, err := NewTransformation(ctx, fmt.Sprintf("lookup://?%s", q.Encode()))
+
+	if err != nil {
+		t.Fatalf("Failed to create new lookup transformation, %v", err)
+	}
+
+	input := `{"hello":"world"}`
+
+	output, err2 := tr.Transform(ctx, []byte(input))
+
+	if err2 != nil {
+		t.Fatalf("Failed to transform body, %v", err2)
+	}
+
+	if string(output) != input {
+		t.Fatalf("Unexpected output '%s'", string(output))
+	}
+}
@@ -0,0 +1,212 @@
+package transformation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+const (
+	// MERGE_OBJECT merges the output of each branch in to a JSON object keyed by branch name.
+	MERGE_OBJECT string = "object"
+	// MERGE_ARRAY merges the output of each branch in to a JSON array, sorted by branch name.
+	MERGE_ARRAY string = "array"
+	// MERGE_CONCAT concatenates the output of each branch, sorted by branch name.
+	MERGE_CONCAT string = "concat"
+)
+
+// PipelineTransformation implements the `webhookd.WebhookTransformation` and `webhookd.WebhookSplittingTransformation` interfaces
+// for applying a list of transformations in order. It is used to implement named, reusable sub-pipelines.
+type PipelineTransformation struct {
+	webhookd.WebhookTransformation
+	// steps is the list of `webhookd.WebhookTransformation` instances to apply.
+	steps []webhookd.WebhookTransformation
+}
+
+// NewPipelineTransformation returns a new `PipelineTransformation` instance that will apply 'steps' in order. The first
+// step is applied to the input of the `Transform` method and subsequent steps are applied to the output of the previous step.
+func NewPipelineTransformation(steps []webhookd.WebhookTransformation) *PipelineTransformation {
+
+	p := PipelineTransformation{
+		steps: steps,
+	}
+
+	return &p
+}
+
+// Transform applies each of the transformations in 'p' to 'body' in order. Any error, including non-fatal errors, are
+// returned immediately.
+func (p *PipelineTransformation) Transform(ctx context.Context, body []byte) ([]byte, *webhookd.WebhookError) {
+
+	var err *webhookd.WebhookError
+
+	for _, step := range p.steps {
+
+		body, err = step.Transform(ctx, body)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return body, nil
+}
+
+// Split applies each of the transformations in 'p' to 'body' in order, using `TransformMessages`, so that any transformations
+// which emit multiple messages are honoured.
+func (p *PipelineTransformation) Split(ctx context.Context, body []byte) ([][]byte, *webhookd.WebhookError) {
+
+	bodies := [][]byte{body}
+
+	var err *webhookd.WebhookError
+
+	for _, step := range p.steps {
+
+		bodies, err = TransformMessages(ctx, step, bodies)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return bodies, nil
+}
+
+// BranchTransformation implements the `webhookd.WebhookTransformation` interface for applying multiple transformations to
+// the same input and merging their outputs.
+type BranchTransformation struct {
+	webhookd.WebhookTransformation
+	// names is the sorted list of branch names.
+	names []string
+	// branches is a dictionary of branch names and their corresponding `webhookd.WebhookTransformation` instances.
+	branches map[string]webhookd.WebhookTransformation
+	// merge is the strategy used to merge the output of each branch.
+	merge string
+}
+
+// NewBranchTransformation returns a new `BranchTransformation` instance that will apply each of the transformations in
+// 'branches' to the same input and merge their output using the 'merge' strategy. Valid strategies are:
+// * `object` (MERGE_OBJECT) The output of each branch is added to a JSON object keyed by branch name. This is the default.
+// * `array` (MERGE_ARRAY) The output of each branch is appended to a JSON array, sorted by branch name.
+// * `concat` (MERGE_CONCAT) The output of each branch is concatenated, sorted by branch name.
+//
+// For the `object` and `array` strategies branch output that is not valid JSON is encoded as a JSON string.
+func NewBranchTransformation(branches map[string]webhookd.WebhookTransformation, merge string) (*BranchTransformation, error) {
+
+	if len(branches) == 0 {
+		return nil, fmt.Errorf("No branches defined")
+	}
+
+	switch merge {
+	case "":
+		merge = MERGE_OBJECT
+	case MERGE_OBJECT, MERGE_ARRAY, MERGE_CONCAT:
+		// pass
+	default:
+		return nil, fmt.Errorf("Invalid merge strategy '%s'", merge)
+	}
+
+	names := make([]string, 0, len(branches))
+
+	for name := range branches {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	b := BranchTransformation{
+		names:    names,
+		branches: branches,
+		merge:    merge,
+	}
+
+	return &b, nil
+}
+
+// Transform applies each of the branches in 'b' to 'body' and returns their merged output. Branches that return a non-fatal
+// error (`webhookd.UnhandledEvent` or `webhookd.HaltEvent`) are excluded from the merged output. If every branch returns a
+// non-fatal error then the last of those errors is returned. Fatal errors are returned immediately.
+func (b *BranchTransformation) Transform(ctx context.Context, body []byte) ([]byte, *webhookd.WebhookError) {
+
+	names := make([]string, 0, len(b.names))
+	outputs := make([][]byte, 0, len(b.names))
+
+	var halt *webhookd.WebhookError
+
+	for _, name := range b.names {
+
+		// Make sure that branches can't see each other's changes to the input
+
+		input := make([]byte, len(body))
+		copy(input, body)
+
+		output, err := b.branches[name].Transform(ctx, input)
+
+		if err != nil {
+
+			switch err.Code {
+			case webhookd.UnhandledEvent, webhookd.HaltEvent:
+				halt = err
+				continue
+			default:
+				message := fmt.Sprintf("Branch '%s' failed, %v", name, err)
+				return nil, &webhookd.WebhookError{Code: err.Code, Message: message}
+			}
+		}
+
+		names = append(names, name)
+		outputs = append(outputs, output)
+	}
+
+	if len(outputs) == 0 {
+		return nil, halt
+	}
+
+	if b.merge == MERGE_CONCAT {
+		return bytes.Join(outputs, nil), nil
+	}
+
+	values := make([]json.RawMessage, len(outputs))
+
+	for idx, output := range outputs {
+
+		if json.Valid(output) {
+			values[idx] = json.RawMessage(output)
+			continue
+		}
+
+		enc, _ := json.Marshal(string(output))
+		values[idx] = json.RawMessage(enc)
+	}
+
+	var v interface{}
+
+	switch b.merge {
+	case MERGE_ARRAY:
+		v = values
+	default:
+
+		doc := make(map[string]json.RawMessage)
+
+		for idx, name := range names {
+			doc[name] = values[idx]
+		}
+
+		v = doc
+	}
+
+	merged, err := json.Marshal(v)
+
+	if err != nil {
+		code := http.StatusInternalServerError
+		message := fmt.Sprintf("Failed to merge branches, %v", err)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	return merged, nil
+}
@@ -0,0 +1,90 @@
+package transformation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+func TestPipelineTransformation(t *testing.T) {
+
+	ctx := context.Background()
+
+	chicken, err := NewTransformation(ctx, "chicken://zxx")
+
+	if err != nil {
+		t.Fatalf("Failed to create new chicken transformation, %v", err)
+	}
+
+	null, err := NewTransformation(ctx, "null://")
+
+	if err != nil {
+		t.Fatalf("Failed to create new null transformation, %v", err)
+	}
+
+	p := NewPipelineTransformation([]webhookd.WebhookTransformation{null, chicken})
+
+	output, err2 := p.Transform(ctx, []byte("hello world"))
+
+	if err2 != nil {
+		t.Fatalf("Failed to transform body, %v", err2)
+	}
+
+	if string(output) != "🐔 🐔" {
+		t.Fatalf("Unexpected output '%s'", string(output))
+	}
+}
+
+func TestBranchTransformation(t *testing.T) {
+
+	ctx := context.Background()
+
+	chicken, err := NewTransformation(ctx, "chicken://zxx")
+
+	if err != nil {
+		t.Fatalf("Failed to create new chicken transformation, %v", err)
+	}
+
+	null, err := NewTransformation(ctx, "null://")
+
+	if err != nil {
+		t.Fatalf("Failed to create new null transformation, %v", err)
+	}
+
+	branches := map[string]webhookd.WebhookTransformation{
+		"original": null,
+		"chicken":  chicken,
+	}
+
+	tests := map[string]string{
+		MERGE_OBJECT: `{"chicken":"🐔 🐔","original":"hello world"}`,
+		MERGE_ARRAY:  `["🐔 🐔","hello world"]`,
+		MERGE_CONCAT: `🐔 🐔hello world`,
+	}
+
+	for merge, expected := range tests {
+
+		b, err := NewBranchTransformation(branches, merge)
+
+		if err != nil {
+			t.Fatalf("Failed to create new branch transformation for '%s', %v", merge, err)
+		}
+
+		output, err2 := b.Transform(ctx, []byte("hello world"))
+
+		if err2 != nil {
+			t.Fatalf("Failed to transform body for '%s', %v", merge, err2)
+		}
+
+		if string(output) != expected {
+			t.Fatalf("Unexpected output for '%s': %s", merge, string(output))
+		}
+	}
+
+	_, err = NewBranchTransformation(branches, "zip")
+
+	if err == nil {
+		t.Fatalf("Expected invalid merge strategy to fail")
+	}
+}
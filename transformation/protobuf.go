@@ -0,0 +1,272 @@
+package transformation
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func init() {
+
+	ctx := context.Background()
+
+	err := RegisterTransformation(ctx, "proto2json", NewProtobufToJSONTransformation)
+
+	if err != nil {
+		panic(err)
+	}
+
+	err = RegisterTransformation(ctx, "json2proto", NewJSONToProtobufTransformation)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// protobufOptions is a struct containing the options shared by the protobuf transformations.
+type protobufOptions struct {
+	// message is the descriptor for the message type being encoded or decoded.
+	message protoreflect.MessageDescriptor
+	// use_proto_names is a boolean flag signaling that JSON properties should use the field names defined in the .proto file
+	// rather than their lowerCamelCase equivalents.
+	use_proto_names bool
+	// emit_defaults is a boolean flag signaling that fields with default values should be included in JSON output.
+	emit_defaults bool
+	// discard_unknown is a boolean flag signaling that unknown fields should be ignored rather than reported as errors.
+	discard_unknown bool
+}
+
+// newProtobufOptions returns a new `protobufOptions` instance derived from 'uri'.
+func newProtobufOptions(uri string) (*protobufOptions, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	descriptor_path := q.Get("descriptor")
+
+	if descriptor_path == "" {
+		return nil, fmt.Errorf("Missing ?descriptor= parameter")
+	}
+
+	message_name := q.Get("message")
+
+	if message_name == "" {
+		return nil, fmt.Errorf("Missing ?message= parameter")
+	}
+
+	md, err := loadProtobufMessageDescriptor(descriptor_path, message_name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &protobufOptions{
+		message: md,
+	}
+
+	flags := map[string]*bool{
+		"use_proto_names": &opts.use_proto_names,
+		"emit_defaults":   &opts.emit_defaults,
+		"discard_unknown": &opts.discard_unknown,
+	}
+
+	for k, ptr := range flags {
+
+		str_v := q.Get(k)
+
+		if str_v == "" {
+			continue
+		}
+
+		v, err := strconv.ParseBool(str_v)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse ?%s= parameter, %w", k, err)
+		}
+
+		*ptr = v
+	}
+
+	return opts, nil
+}
+
+// loadProtobufMessageDescriptor returns the descriptor for the message type named 'message_name' in the (binary-encoded)
+// `FileDescriptorSet` file at 'path'. Descriptor sets can be produced using `protoc --include_imports --descriptor_set_out={PATH}`.
+func loadProtobufMessageDescriptor(path string, message_name string) (protoreflect.MessageDescriptor, error) {
+
+	body, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read descriptor set, %w", err)
+	}
+
+	var fds descriptorpb.FileDescriptorSet
+
+	err = proto.Unmarshal(body, &fds)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse descriptor set, %w", err)
+	}
+
+	files, err := protodesc.NewFiles(&fds)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load descriptor set, %w", err)
+	}
+
+	d, err := files.FindDescriptorByName(protoreflect.FullName(message_name))
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to find message '%s' in descriptor set, %w", message_name, err)
+	}
+
+	md, ok := d.(protoreflect.MessageDescriptor)
+
+	if !ok {
+		return nil, fmt.Errorf("'%s' is not a message type", message_name)
+	}
+
+	return md, nil
+}
+
+// ProtobufToJSONTransformation implements the `webhookd.WebhookTransformation` interface for converting protobuf-encoded
+// messages to JSON.
+type ProtobufToJSONTransformation struct {
+	webhookd.WebhookTransformation
+	options *protobufOptions
+}
+
+// NewProtobufToJSONTransformation returns a new `ProtobufToJSONTransformation` instance configured by 'uri' in the form of:
+//
+//	proto2json://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `descriptor={PATH}` The path to a binary-encoded `FileDescriptorSet` file containing the message type. Required.
+// * `message={STRING}` The fully-qualified name of the message type, for example "acme.events.v1.OrderCreated". Required.
+// * `use_proto_names={BOOLEAN}` Use the field names defined in the .proto file rather than their lowerCamelCase equivalents. Default is false.
+// * `emit_defaults={BOOLEAN}` Include fields with default values in the output. Default is false.
+// * `discard_unknown={BOOLEAN}` Ignore unknown fields rather than reporting them as errors. Default is false.
+func NewProtobufToJSONTransformation(ctx context.Context, uri string) (webhookd.WebhookTransformation, error) {
+
+	opts, err := newProtobufOptions(uri)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tr := ProtobufToJSONTransformation{
+		options: opts,
+	}
+
+	return &tr, nil
+}
+
+// Transform converts the protobuf-encoded message in 'body' to JSON, using the canonical protobuf JSON mapping.
+func (tr *ProtobufToJSONTransformation) Transform(ctx context.Context, body []byte) ([]byte, *webhookd.WebhookError) {
+
+	msg := dynamicpb.NewMessage(tr.options.message)
+
+	unmarshal_opts := proto.UnmarshalOptions{
+		DiscardUnknown: tr.options.discard_unknown,
+	}
+
+	err := unmarshal_opts.Unmarshal(body, msg)
+
+	if err != nil {
+		code := http.StatusBadRequest
+		message := fmt.Sprintf("Failed to decode protobuf message, %v", err)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	marshal_opts := protojson.MarshalOptions{
+		UseProtoNames:   tr.options.use_proto_names,
+		EmitUnpopulated: tr.options.emit_defaults,
+	}
+
+	enc, err := marshal_opts.Marshal(msg)
+
+	if err != nil {
+		code := http.StatusInternalServerError
+		message := fmt.Sprintf("Failed to encode message, %v", err)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	return enc, nil
+}
+
+// JSONToProtobufTransformation implements the `webhookd.WebhookTransformation` interface for converting JSON-encoded
+// messages to protobuf.
+type JSONToProtobufTransformation struct {
+	webhookd.WebhookTransformation
+	options *protobufOptions
+}
+
+// NewJSONToProtobufTransformation returns a new `JSONToProtobufTransformation` instance configured by 'uri' in the form of:
+//
+//	json2proto://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `descriptor={PATH}` The path to a binary-encoded `FileDescriptorSet` file containing the message type. Required.
+// * `message={STRING}` The fully-qualified name of the message type, for example "acme.events.v1.OrderCreated". Required.
+// * `discard_unknown={BOOLEAN}` Ignore unknown JSON properties rather than reporting them as errors. Default is false.
+//
+// This is the inverse of the `proto2json://` transformation.
+func NewJSONToProtobufTransformation(ctx context.Context, uri string) (webhookd.WebhookTransformation, error) {
+
+	opts, err := newProtobufOptions(uri)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tr := JSONToProtobufTransformation{
+		options: opts,
+	}
+
+	return &tr, nil
+}
+
+// Transform converts the JSON-encoded message in 'body' to protobuf. JSON properties may use either the field names
+// defined in the .proto file or their lowerCamelCase equivalents.
+func (tr *JSONToProtobufTransformation) Transform(ctx context.Context, body []byte) ([]byte, *webhookd.WebhookError) {
+
+	msg := dynamicpb.NewMessage(tr.options.message)
+
+	unmarshal_opts := protojson.UnmarshalOptions{
+		DiscardUnknown: tr.options.discard_unknown,
+	}
+
+	err := unmarshal_opts.Unmarshal(body, msg)
+
+	if err != nil {
+		code := http.StatusBadRequest
+		message := fmt.Sprintf("Failed to decode JSON message, %v", err)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	enc, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+
+	if err != nil {
+		code := http.StatusInternalServerError
+		message := fmt.Sprintf("Failed to encode protobuf message, %v", err)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	return enc, nil
+}
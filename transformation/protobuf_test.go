@@ -0,0 +1,133 @@
+package transformation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// writeTestDescriptorSet writes a descriptor set containing the "webhookd.test.Event" message type to a temporary file
+// and returns its path.
+func writeTestDescriptorSet(t *testing.T) string {
+
+	fds := &descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{
+			{
+				Name:    proto.String("event.proto"),
+				Package: proto.String("webhookd.test"),
+				Syntax:  proto.String("proto3"),
+				MessageType: []*descriptorpb.DescriptorProto{
+					{
+						Name: proto.String("Event"),
+						Field: []*descriptorpb.FieldDescriptorProto{
+							{
+								Name:     proto.String("event_id"),
+								JsonName: proto.String("eventId"),
+								Number:   proto.Int32(1),
+								Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+								Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+							},
+							{
+								Name:     proto.String("count"),
+								JsonName: proto.String("count"),
+								Number:   proto.Int32(2),
+								Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+								Type:     descriptorpb.FieldDescriptorProto_TYPE_INT32.Enum(),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	body, err := proto.Marshal(fds)
+
+	if err != nil {
+		t.Fatalf("Failed to marshal descriptor set, %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "event.pb")
+
+	err = os.WriteFile(path, body, 0644)
+
+	if err != nil {
+		t.Fatalf("Failed to write descriptor set, %v", err)
+	}
+
+	return path
+}
+
+func TestProtobufTransformations(t *testing.T) {
+
+	ctx := context.Background()
+
+	path := writeTestDescriptorSet(t)
+
+	to_json_uri := fmt.Sprintf("proto2json://?descriptor=%s&message=webhookd.test.Event&use_proto_names=true", url.QueryEscape(path))
+	to_proto_uri := fmt.Sprintf("json2proto://?descriptor=%s&message=webhookd.test.Event", url.QueryEscape(path))
+
+	to_json, err := NewTransformation(ctx, to_json_uri)
+
+	if err != nil {
+		t.Fatalf("Failed to create new proto2json transformation, %v", err)
+	}
+
+	to_proto, err := NewTransformation(ctx, to_proto_uri)
+
+	if err != nil {
+		t.Fatalf("Failed to create new json2proto transformation, %v", err)
+	}
+
+	enc, err2 := to_proto.Transform(ctx, []byte(`{"eventId":"abc","count":3}`))
+
+	if err2 != nil {
+		t.Fatalf("Failed to encode protobuf message, %v", err2)
+	}
+
+	expected_enc := []byte{0x0a, 0x03, 'a', 'b', 'c', 0x10, 0x03}
+
+	if !bytes.Equal(enc, expected_enc) {
+		t.Fatalf("Unexpected protobuf encoding %x", enc)
+	}
+
+	output, err2 := to_json.Transform(ctx, enc)
+
+	if err2 != nil {
+		t.Fatalf("Failed to decode protobuf message, %v", err2)
+	}
+
+	// protojson deliberately varies its whitespace so compact the output before comparing it
+
+	compact := new(bytes.Buffer)
+
+	err = json.Compact(compact, output)
+
+	if err != nil {
+		t.Fatalf("Failed to compact output, %v", err)
+	}
+
+	if compact.String() != `{"event_id":"abc","count":3}` {
+		t.Fatalf("Unexpected output '%s'", string(output))
+	}
+
+	_, err2 = to_proto.Transform(ctx, []byte(`{"unknown":true}`))
+
+	if err2 == nil {
+		t.Fatalf("Expected unknown property to fail")
+	}
+
+	_, err = NewTransformation(ctx, fmt.Sprintf("proto2json://?descriptor=%s&message=webhookd.test.Missing", url.QueryEscape(path)))
+
+	if err == nil {
+		t.Fatalf("Expected missing message type to fail")
+	}
+}
@@ -0,0 +1,349 @@
+package transformation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+	"github.com/whosonfirst/go-webhookd/v3/jsonpath"
+)
+
+// DEFAULT_REDACT_MASK is the default string used to replace redacted values.
+const DEFAULT_REDACT_MASK string = "[REDACTED]"
+
+const (
+	// REDACT_MODE_MASK replaces redacted values with a mask.
+	REDACT_MODE_MASK string = "mask"
+	// REDACT_MODE_REMOVE removes redacted properties entirely. Values matched by patterns are always masked.
+	REDACT_MODE_REMOVE string = "remove"
+)
+
+// redactWildcard is the path segment that matches every key in a dictionary or every item in a list.
+const redactWildcard string = "*"
+
+// redactPatterns is a dictionary of named regular expressions for common kinds of sensitive data.
+var redactPatterns = map[string]*regexp.Regexp{
+	"email":       regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`),
+	"credit_card": regexp.MustCompile(`\b(?:\d[ \-]?){12,18}\d\b`),
+	"token":       regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,}|xox[abprs]-[A-Za-z0-9\-]{10,}|sk_(?:live|test)_[A-Za-z0-9]{16,}|AKIA[0-9A-Z]{16}|eyJ[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+)`),
+}
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterTransformation(ctx, "redact", NewRedactTransformation)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// RedactTransformation implements the `webhookd.WebhookTransformation` interface for removing or masking sensitive data in messages.
+type RedactTransformation struct {
+	webhookd.WebhookTransformation
+	// paths is the list of `jsonpath` paths to redact.
+	paths []string
+	// patterns is the list of regular expressions whose matches will be masked.
+	patterns []*regexp.Regexp
+	// luhn is a dictionary of patterns whose matches must also pass the Luhn checksum, used by payment card numbers, to be masked.
+	luhn map[*regexp.Regexp]bool
+	// mode is the redaction mode for 'paths'.
+	mode string
+	// mask is the string used to replace redacted values.
+	mask string
+}
+
+// NewRedactTransformation returns a new `RedactTransformation` instance configured by 'uri' in the form of:
+//
+//	redact://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `path={PATH}` Zero or more (dot-separated) paths to redact. Path segments may be "*" to match every key in a dictionary or item in a list.
+// * `pattern={NAME|REGEXP}` Zero or more regular expressions, or the names of built-in patterns ("email", "credit_card" or "token"), whose matches will be masked in every string value.
+// * `mode={STRING}` How to redact paths. Valid options are "mask" and "remove". Default is "mask".
+// * `mask={STRING}` The string used to replace redacted values. Default is "[REDACTED]".
+//
+// At least one `path` or `pattern` parameter must be present.
+func NewRedactTransformation(ctx context.Context, uri string) (webhookd.WebhookTransformation, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	paths := q["path"]
+
+	patterns := make([]*regexp.Regexp, 0)
+	luhn := make(map[*regexp.Regexp]bool)
+
+	for _, p := range q["pattern"] {
+
+		re, ok := redactPatterns[p]
+
+		if !ok {
+
+			v, err := regexp.Compile(p)
+
+			if err != nil {
+				return nil, fmt.Errorf("Invalid ?pattern= parameter '%s', %w", p, err)
+			}
+
+			re = v
+		}
+
+		if p == "credit_card" {
+			luhn[re] = true
+		}
+
+		patterns = append(patterns, re)
+	}
+
+	if len(paths) == 0 && len(patterns) == 0 {
+		return nil, fmt.Errorf("Missing ?path= or ?pattern= parameters")
+	}
+
+	mode := q.Get("mode")
+
+	switch mode {
+	case "":
+		mode = REDACT_MODE_MASK
+	case REDACT_MODE_MASK, REDACT_MODE_REMOVE:
+		// pass
+	default:
+		return nil, fmt.Errorf("Invalid ?mode= parameter '%s'", mode)
+	}
+
+	mask := DEFAULT_REDACT_MASK
+
+	if q.Has("mask") {
+		mask = q.Get("mask")
+	}
+
+	tr := RedactTransformation{
+		paths:    paths,
+		patterns: patterns,
+		luhn:     luhn,
+		mode:     mode,
+		mask:     mask,
+	}
+
+	return &tr, nil
+}
+
+// Transform removes or masks the sensitive data in 'body'. If 'body' is not valid JSON then only patterns are applied, to the
+// entire body.
+func (tr *RedactTransformation) Transform(ctx context.Context, body []byte) ([]byte, *webhookd.WebhookError) {
+
+	doc, err := jsonpath.Decode(body)
+
+	if err != nil {
+
+		if len(tr.paths) > 0 {
+			code := http.StatusBadRequest
+			message := err.Error()
+			return nil, &webhookd.WebhookError{Code: code, Message: message}
+		}
+
+		return []byte(tr.redactString(string(body))), nil
+	}
+
+	for _, path := range tr.paths {
+		doc = tr.redactPath(doc, jsonpath.Split(path))
+	}
+
+	if len(tr.patterns) > 0 {
+		doc = tr.redactValues(doc)
+	}
+
+	enc, err := json.Marshal(doc)
+
+	if err != nil {
+		code := http.StatusInternalServerError
+		message := fmt.Sprintf("Failed to encode message, %v", err)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	return enc, nil
+}
+
+// redactPath redacts the value at 'segments' in 'v' returning the updated value.
+func (tr *RedactTransformation) redactPath(v interface{}, segments []string) interface{} {
+
+	if len(segments) == 0 {
+		return tr.mask
+	}
+
+	seg := segments[0]
+	last := len(segments) == 1
+
+	switch t := v.(type) {
+	case map[string]interface{}:
+
+		keys := []string{seg}
+
+		if seg == redactWildcard {
+
+			keys = make([]string, 0, len(t))
+
+			for k := range t {
+				keys = append(keys, k)
+			}
+		}
+
+		for _, k := range keys {
+
+			child, ok := t[k]
+
+			if !ok {
+				continue
+			}
+
+			if last && tr.mode == REDACT_MODE_REMOVE {
+				delete(t, k)
+				continue
+			}
+
+			t[k] = tr.redactPath(child, segments[1:])
+		}
+
+		return t
+
+	case []interface{}:
+
+		indices := make([]int, 0)
+
+		if seg == redactWildcard {
+
+			for i := range t {
+				indices = append(indices, i)
+			}
+
+		} else {
+
+			i, err := strconv.Atoi(seg)
+
+			if err == nil && i >= 0 && i < len(t) {
+				indices = append(indices, i)
+			}
+		}
+
+		if last && tr.mode == REDACT_MODE_REMOVE {
+
+			remove := make(map[int]bool)
+
+			for _, i := range indices {
+				remove[i] = true
+			}
+
+			kept := make([]interface{}, 0, len(t))
+
+			for i, item := range t {
+
+				if !remove[i] {
+					kept = append(kept, item)
+				}
+			}
+
+			return kept
+		}
+
+		for _, i := range indices {
+			t[i] = tr.redactPath(t[i], segments[1:])
+		}
+
+		return t
+
+	default:
+		return v
+	}
+}
+
+// redactValues masks the matches for the patterns in 'tr' in every string value in 'v' returning the updated value.
+func (tr *RedactTransformation) redactValues(v interface{}) interface{} {
+
+	switch t := v.(type) {
+	case map[string]interface{}:
+
+		for k, child := range t {
+			t[k] = tr.redactValues(child)
+		}
+
+		return t
+
+	case []interface{}:
+
+		for i, child := range t {
+			t[i] = tr.redactValues(child)
+		}
+
+		return t
+
+	case string:
+		return tr.redactString(t)
+	default:
+		return v
+	}
+}
+
+// redactString masks the matches for the patterns in 'tr' in 'str'.
+func (tr *RedactTransformation) redactString(str string) string {
+
+	for _, re := range tr.patterns {
+
+		str = re.ReplaceAllStringFunc(str, func(m string) string {
+
+			if tr.luhn[re] && !isLuhn(m) {
+				return m
+			}
+
+			return tr.mask
+		})
+	}
+
+	return str
+}
+
+// isLuhn returns a boolean value indicating whether the digits in 'str' pass the Luhn checksum used by payment card numbers.
+func isLuhn(str string) bool {
+
+	digits := make([]int, 0, len(str))
+
+	for _, r := range str {
+
+		if r >= '0' && r <= '9' {
+			digits = append(digits, int(r-'0'))
+		}
+	}
+
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+
+	sum := 0
+
+	for i := 0; i < len(digits); i++ {
+
+		d := digits[len(digits)-1-i]
+
+		if i%2 == 1 {
+
+			d = d * 2
+
+			if d > 9 {
+				d -= 9
+			}
+		}
+
+		sum += d
+	}
+
+	return sum%10 == 0
+}
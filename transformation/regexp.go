@@ -0,0 +1,98 @@
+package transformation
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterTransformation(ctx, "regexp", NewRegexpTransformation)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// regexpRule is a single find and replace rule.
+type regexpRule struct {
+	// pattern is the regular expression to find.
+	pattern *regexp.Regexp
+	// replacement is the template used to replace matches for 'pattern'.
+	replacement []byte
+}
+
+// RegexpTransformation implements the `webhookd.WebhookTransformation` interface for applying regular expression find
+// and replace rules to messages.
+type RegexpTransformation struct {
+	webhookd.WebhookTransformation
+	// rules is the ordered list of rules to apply.
+	rules []*regexpRule
+}
+
+// NewRegexpTransformation returns a new `RegexpTransformation` instance configured by 'uri' in the form of:
+//
+//	regexp://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `pattern={REGEXP}` One or more URI-escaped regular expressions to find. Required.
+// * `replacement={STRING}` The URI-escaped replacement for each `pattern` parameter, in the same order. Replacements may refer
+// to capture groups using the `$1` or `${name}` syntax. Required.
+func NewRegexpTransformation(ctx context.Context, uri string) (webhookd.WebhookTransformation, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	patterns := q["pattern"]
+	replacements := q["replacement"]
+
+	if len(patterns) == 0 {
+		return nil, fmt.Errorf("Missing ?pattern= parameter")
+	}
+
+	if len(patterns) != len(replacements) {
+		return nil, fmt.Errorf("Expected %d ?replacement= parameters but got %d", len(patterns), len(replacements))
+	}
+
+	rules := make([]*regexpRule, len(patterns))
+
+	for i, p := range patterns {
+
+		re, err := regexp.Compile(p)
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid ?pattern= parameter '%s', %w", p, err)
+		}
+
+		rules[i] = &regexpRule{
+			pattern:     re,
+			replacement: []byte(replacements[i]),
+		}
+	}
+
+	tr := RegexpTransformation{
+		rules: rules,
+	}
+
+	return &tr, nil
+}
+
+// Transform applies the rules that 'tr' was instantiated with, in order, to the raw bytes of 'body'.
+func (tr *RegexpTransformation) Transform(ctx context.Context, body []byte) ([]byte, *webhookd.WebhookError) {
+
+	for _, r := range tr.rules {
+		body = r.pattern.ReplaceAll(body, r.replacement)
+	}
+
+	return body, nil
+}
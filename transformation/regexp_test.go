@@ -0,0 +1,46 @@
+package transformation
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"testing"
+)
+
+func TestRegexpTransformation(t *testing.T) {
+
+	ctx := context.Background()
+
+	q := url.Values{}
+	q.Add("pattern", `https?://([a-z0-9\-]+)\.internal\.example\.com`)
+	q.Add("replacement", "https://${1}.example.com")
+	q.Add("pattern", `"token":"[^"]*"`)
+	q.Add("replacement", `"token":""`)
+
+	uri := fmt.Sprintf("regexp://?%s", q.Encode())
+
+	tr, err := NewTransformation(ctx, uri)
+
+	if err != nil {
+		t.Fatalf("Failed to create new regexp transformation, %v", err)
+	}
+
+	input := `{"url":"http://ci.internal.example.com/job/1","token":"s33kret"}`
+	expected := `{"url":"https://ci.example.com/job/1","token":""}`
+
+	output, err2 := tr.Transform(ctx, []byte(input))
+
+	if err2 != nil {
+		t.Fatalf("Failed to transform body, %v", err2)
+	}
+
+	if string(output) != expected {
+		t.Fatalf("Unexpected output '%s'", string(output))
+	}
+
+	_, err = NewTransformation(ctx, "regexp://?pattern=a")
+
+	if err == nil {
+		t.Fatalf("Expected missing replacement to fail")
+	}
+}
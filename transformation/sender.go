@@ -0,0 +1,245 @@
+package transformation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+	"github.com/whosonfirst/go-webhookd/v3/jsonpath"
+	"github.com/whosonfirst/go-webhookd/v3/mmdb"
+)
+
+// DEFAULT_SENDER_PROPERTY is the default property used to store information about the sender of a message.
+const DEFAULT_SENDER_PROPERTY string = "webhookd_sender"
+
+// DEFAULT_RDNS_TIMEOUT is the default amount of time to wait for reverse DNS lookups.
+const DEFAULT_RDNS_TIMEOUT time.Duration = 1 * time.Second
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterTransformation(ctx, "sender", NewSenderTransformation)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// SenderTransformation implements the `webhookd.WebhookTransformation` interface for annotating JSON-encoded messages with
+// information about the client that sent them: its network address, the country and autonomous system (ASN) for that address
+// derived from one or more MaxMind DB (GeoIP) files and the hostnames for that address derived from reverse DNS lookups.
+type SenderTransformation struct {
+	webhookd.WebhookTransformation
+	// property is the (top-level) property used to store information about the sender.
+	property string
+	// databases is the list of `mmdb.Reader` instances used to look up network addresses.
+	databases []*mmdb.Reader
+	// rdns is a boolean flag signaling that reverse DNS lookups should be performed.
+	rdns bool
+	// timeout is the amount of time to wait for reverse DNS lookups.
+	timeout time.Duration
+	// resolver is the `net.Resolver` instance used for reverse DNS lookups.
+	resolver *net.Resolver
+}
+
+// SenderInfo is a struct containing information about the client that sent a message.
+type SenderInfo struct {
+	// Address is the network address of the client.
+	Address string `json:"address"`
+	// Country is the ISO 3166-1 country code for 'Address'.
+	Country string `json:"country,omitempty"`
+	// ASN is the autonomous system number for 'Address'.
+	ASN uint64 `json:"asn,omitempty"`
+	// ASOrganization is the name of the organization associated with 'ASN'.
+	ASOrganization string `json:"as_organization,omitempty"`
+	// Hostnames is the list of hostnames for 'Address' derived from reverse DNS lookups.
+	Hostnames []string `json:"hostnames,omitempty"`
+}
+
+// NewSenderTransformation returns a new `SenderTransformation` instance configured by 'uri' in the form of:
+//
+//	sender://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `geoip={PATH}` Zero or more paths to MaxMind DB files, for example GeoLite2-Country.mmdb and GeoLite2-ASN.mmdb.
+// * `rdns={BOOLEAN}` A boolean flag signaling that reverse DNS lookups should be performed. Default is false.
+// * `timeout={DURATION}` The amount of time to wait for reverse DNS lookups. Default is "1s".
+// * `property={STRING}` The (top-level) property used to store information about the sender. Default is "webhookd_sender".
+//
+// The network address of the sender is derived using the `webhookd.RemoteAddress` method.
+func NewSenderTransformation(ctx context.Context, uri string) (webhookd.WebhookTransformation, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	property := q.Get("property")
+
+	if property == "" {
+		property = DEFAULT_SENDER_PROPERTY
+	}
+
+	databases := make([]*mmdb.Reader, 0)
+
+	for _, path := range q["geoip"] {
+
+		r, err := mmdb.Open(path)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to open GeoIP database, %w", err)
+		}
+
+		databases = append(databases, r)
+	}
+
+	rdns := false
+
+	str_rdns := q.Get("rdns")
+
+	if str_rdns != "" {
+
+		v, err := strconv.ParseBool(str_rdns)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse ?rdns= parameter, %w", err)
+		}
+
+		rdns = v
+	}
+
+	timeout := DEFAULT_RDNS_TIMEOUT
+
+	str_timeout := q.Get("timeout")
+
+	if str_timeout != "" {
+
+		v, err := time.ParseDuration(str_timeout)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse ?timeout= parameter, %w", err)
+		}
+
+		timeout = v
+	}
+
+	tr := SenderTransformation{
+		property:  property,
+		databases: databases,
+		rdns:      rdns,
+		timeout:   timeout,
+		resolver:  net.DefaultResolver,
+	}
+
+	return &tr, nil
+}
+
+// Transform adds information about the client that sent 'body' to the property that 'tr' was instantiated with. 'body' is
+// expected to be a JSON-encoded object. Failures to look up GeoIP or reverse DNS information are not considered errors.
+func (tr *SenderTransformation) Transform(ctx context.Context, body []byte) ([]byte, *webhookd.WebhookError) {
+
+	doc, err := jsonpath.Decode(body)
+
+	if err != nil {
+		code := http.StatusBadRequest
+		message := err.Error()
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	obj, ok := doc.(map[string]interface{})
+
+	if !ok {
+		code := http.StatusBadRequest
+		message := "Message body is not a JSON object"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	obj[tr.property] = tr.lookup(ctx, webhookd.RemoteAddress(ctx))
+
+	enc, err := json.Marshal(obj)
+
+	if err != nil {
+		code := http.StatusInternalServerError
+		message := fmt.Sprintf("Failed to encode message, %v", err)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	return enc, nil
+}
+
+// lookup returns a `SenderInfo` instance for 'addr'.
+func (tr *SenderTransformation) lookup(ctx context.Context, addr string) *SenderInfo {
+
+	info := &SenderInfo{
+		Address: addr,
+	}
+
+	ip := net.ParseIP(addr)
+
+	if ip == nil {
+		return info
+	}
+
+	for _, db := range tr.databases {
+
+		v, ok, err := db.Lookup(ip)
+
+		if err != nil || !ok {
+			continue
+		}
+
+		if info.Country == "" {
+
+			country, ok := jsonpath.GetString(v, "country.iso_code")
+
+			if ok {
+				info.Country = country
+			}
+		}
+
+		if info.ASN == 0 {
+
+			asn, ok := jsonpath.Get(v, "autonomous_system_number")
+
+			if ok {
+				info.ASN, _ = asn.(uint64)
+			}
+		}
+
+		if info.ASOrganization == "" {
+
+			org, ok := jsonpath.GetString(v, "autonomous_system_organization")
+
+			if ok {
+				info.ASOrganization = org
+			}
+		}
+	}
+
+	if tr.rdns {
+
+		rdns_ctx, cancel := context.WithTimeout(ctx, tr.timeout)
+		defer cancel()
+
+		names, err := tr.resolver.LookupAddr(rdns_ctx, addr)
+
+		if err == nil {
+
+			for _, name := range names {
+				info.Hostnames = append(info.Hostnames, strings.TrimSuffix(name, "."))
+			}
+		}
+	}
+
+	return info
+}
@@ -0,0 +1,47 @@
+package transformation
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+func TestSenderTransformation(t *testing.T) {
+
+	ctx := context.Background()
+	ctx = webhookd.WithRemoteAddress(ctx, "192.0.2.1")
+
+	tr, err := NewTransformation(ctx, "sender://?property=sender")
+
+	if err != nil {
+		t.Fatalf("Failed to create new sender transformation, %v", err)
+	}
+
+	output, err2 := tr.Transform(ctx, []byte(`{"hello":"world"}`))
+
+	if err2 != nil {
+		t.Fatalf("Failed to transform body, %v", err2)
+	}
+
+	var doc map[string]interface{}
+
+	err = json.Unmarshal(output, &doc)
+
+	if err != nil {
+		t.Fatalf("Failed to unmarshal output, %v", err)
+	}
+
+	sender, ok := doc["sender"].(map[string]interface{})
+
+	if !ok || sender["address"] != "192.0.2.1" {
+		t.Fatalf("Unexpected output '%s'", string(output))
+	}
+
+	_, err2 = tr.Transform(ctx, []byte(`[1, 2, 3]`))
+
+	if err2 == nil {
+		t.Fatalf("Expected non-object body to fail")
+	}
+}
@@ -0,0 +1,240 @@
+package transformation
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/sfomuseum/runtimevar"
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+const (
+	// SIGN_HMAC_SHA256 signs messages with a shared secret using HMAC-SHA256.
+	SIGN_HMAC_SHA256 string = "hmac-sha256"
+	// SIGN_HMAC_SHA512 signs messages with a shared secret using HMAC-SHA512.
+	SIGN_HMAC_SHA512 string = "hmac-sha512"
+	// SIGN_ED25519 signs messages with an Ed25519 private key.
+	SIGN_ED25519 string = "ed25519"
+)
+
+const (
+	// SIGN_MODE_HEADER attaches signatures to messages as HTTP headers for dispatchers that support them.
+	SIGN_MODE_HEADER string = "header"
+	// SIGN_MODE_BODY wraps messages in a JSON envelope containing the signature.
+	SIGN_MODE_BODY string = "body"
+)
+
+// DEFAULT_SIGN_HEADER is the default HTTP header used to attach signatures to messages.
+const DEFAULT_SIGN_HEADER string = "X-Webhookd-Signature"
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterTransformation(ctx, "sign", NewSignTransformation)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// SignedMessage is the JSON envelope used to return signed messages when the "body" mode is used.
+type SignedMessage struct {
+	// Payload is the original message, exactly as it was signed.
+	Payload string `json:"payload"`
+	// Algorithm is the algorithm used to create the signature.
+	Algorithm string `json:"algorithm"`
+	// Signature is the encoded signature for 'Payload'.
+	Signature string `json:"signature"`
+}
+
+// SignTransformation implements the `webhookd.WebhookTransformation` interface for attaching signatures to messages so that
+// downstream consumers can verify that they were not altered after being processed by webhookd.
+type SignTransformation struct {
+	webhookd.WebhookTransformation
+	// algorithm is the algorithm used to create signatures.
+	algorithm string
+	// secret is the shared secret used by HMAC algorithms.
+	secret []byte
+	// private_key is the private key used by the "ed25519" algorithm.
+	private_key ed25519.PrivateKey
+	// mode is the method used to attach signatures to messages.
+	mode string
+	// header is the HTTP header used to attach signatures to messages in the "header" mode.
+	header string
+	// encoding is the encoding used for signatures.
+	encoding string
+}
+
+// NewSignTransformation returns a new `SignTransformation` instance configured by 'uri' in the form of:
+//
+//	sign://{ALGORITHM}?{PARAMETERS}
+//
+// Valid {ALGORITHM} values are "hmac-sha256", "hmac-sha512" and "ed25519".
+//
+// Valid {PARAMETERS} are:
+// * `key={URI}` A URI-escaped `gocloud.dev/runtimevar` URI whose value is the shared secret for HMAC algorithms or the base64-encoded
+// Ed25519 private key (or 32-byte seed). Required.
+// * `mode={STRING}` How to attach signatures to messages. Valid options are "header" and "body". Default is "header".
+// * `header={STRING}` The HTTP header used to attach signatures in the "header" mode. Default is "X-Webhookd-Signature".
+// * `encoding={STRING}` The encoding used for signatures. Valid options are "hex" and "base64". Default is "hex".
+func NewSignTransformation(ctx context.Context, uri string) (webhookd.WebhookTransformation, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	key_uri := q.Get("key")
+
+	if key_uri == "" {
+		return nil, fmt.Errorf("Missing ?key= parameter")
+	}
+
+	str_key, err := runtimevar.StringVar(ctx, key_uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read key, %w", err)
+	}
+
+	str_key = strings.TrimSpace(str_key)
+
+	tr := SignTransformation{
+		algorithm: u.Host,
+		mode:      q.Get("mode"),
+		header:    q.Get("header"),
+		encoding:  q.Get("encoding"),
+	}
+
+	switch tr.algorithm {
+	case SIGN_HMAC_SHA256, SIGN_HMAC_SHA512:
+
+		if str_key == "" {
+			return nil, fmt.Errorf("Shared secret is empty")
+		}
+
+		tr.secret = []byte(str_key)
+
+	case SIGN_ED25519:
+
+		key, err := base64.StdEncoding.DecodeString(str_key)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to decode private key, %w", err)
+		}
+
+		switch len(key) {
+		case ed25519.SeedSize:
+			tr.private_key = ed25519.NewKeyFromSeed(key)
+		case ed25519.PrivateKeySize:
+			tr.private_key = ed25519.PrivateKey(key)
+		default:
+			return nil, fmt.Errorf("Invalid Ed25519 private key length %d", len(key))
+		}
+
+	default:
+		return nil, fmt.Errorf("Invalid or unsupported signing algorithm '%s'", tr.algorithm)
+	}
+
+	switch tr.mode {
+	case "":
+		tr.mode = SIGN_MODE_HEADER
+	case SIGN_MODE_HEADER, SIGN_MODE_BODY:
+		// pass
+	default:
+		return nil, fmt.Errorf("Invalid ?mode= parameter '%s'", tr.mode)
+	}
+
+	if tr.header == "" {
+		tr.header = DEFAULT_SIGN_HEADER
+	}
+
+	switch tr.encoding {
+	case "":
+		tr.encoding = "hex"
+	case "hex", "base64":
+		// pass
+	default:
+		return nil, fmt.Errorf("Invalid ?encoding= parameter '%s'", tr.encoding)
+	}
+
+	return &tr, nil
+}
+
+// Transform signs 'body'. In the "header" mode 'body' is returned unaltered and the signature is attached to it, in the form
+// of "{ALGORITHM}={SIGNATURE}", using `webhookd.SetMessageHeader`. In the "body" mode 'body' is returned wrapped in a JSON-encoded
+// `SignedMessage` envelope.
+func (tr *SignTransformation) Transform(ctx context.Context, body []byte) ([]byte, *webhookd.WebhookError) {
+
+	sig := tr.encode(tr.sign(body))
+
+	if tr.mode == SIGN_MODE_HEADER {
+
+		if !webhookd.SetMessageHeader(ctx, body, tr.header, fmt.Sprintf("%s=%s", tr.algorithm, sig)) {
+			code := http.StatusInternalServerError
+			message := "Context does not support message headers"
+			return nil, &webhookd.WebhookError{Code: code, Message: message}
+		}
+
+		return body, nil
+	}
+
+	msg := SignedMessage{
+		Payload:   string(body),
+		Algorithm: tr.algorithm,
+		Signature: sig,
+	}
+
+	enc, err := json.Marshal(msg)
+
+	if err != nil {
+		code := http.StatusInternalServerError
+		message := fmt.Sprintf("Failed to encode message, %v", err)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	return enc, nil
+}
+
+// sign returns the raw signature for 'body'.
+func (tr *SignTransformation) sign(body []byte) []byte {
+
+	var h func() hash.Hash
+
+	switch tr.algorithm {
+	case SIGN_ED25519:
+		return ed25519.Sign(tr.private_key, body)
+	case SIGN_HMAC_SHA512:
+		h = sha512.New
+	default:
+		h = sha256.New
+	}
+
+	mac := hmac.New(h, tr.secret)
+	mac.Write(body)
+
+	return mac.Sum(nil)
+}
+
+// encode returns 'sig' encoded using the encoding that 'tr' was instantiated with.
+func (tr *SignTransformation) encode(sig []byte) string {
+
+	if tr.encoding == "base64" {
+		return base64.StdEncoding.EncodeToString(sig)
+	}
+
+	return hex.EncodeToString(sig)
+}
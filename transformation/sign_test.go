@@ -0,0 +1,105 @@
+package transformation
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+func TestSignTransformationHeader(t *testing.T) {
+
+	ctx := webhookd.WithMessageHeaders(context.Background())
+
+	key_uri := "constant://?val=s33kret"
+	uri := fmt.Sprintf("sign://hmac-sha256?key=%s", url.QueryEscape(key_uri))
+
+	tr, err := NewTransformation(ctx, uri)
+
+	if err != nil {
+		t.Fatalf("Failed to create new sign transformation, %v", err)
+	}
+
+	body := []byte(`{"hello":"world"}`)
+
+	output, err2 := tr.Transform(ctx, body)
+
+	if err2 != nil {
+		t.Fatalf("Failed to transform body, %v", err2)
+	}
+
+	if string(output) != string(body) {
+		t.Fatalf("Expected body to be unaltered but got '%s'", string(output))
+	}
+
+	mac := hmac.New(sha256.New, []byte("s33kret"))
+	mac.Write(body)
+
+	expected := "hmac-sha256=" + hex.EncodeToString(mac.Sum(nil))
+	header := webhookd.MessageHeaders(ctx, body).Get(DEFAULT_SIGN_HEADER)
+
+	if header != expected {
+		t.Fatalf("Unexpected signature header '%s'", header)
+	}
+
+	_, err2 = tr.Transform(context.Background(), body)
+
+	if err2 == nil {
+		t.Fatalf("Expected header mode to fail without a message headers context")
+	}
+}
+
+func TestSignTransformationBody(t *testing.T) {
+
+	ctx := context.Background()
+
+	seed := make([]byte, ed25519.SeedSize)
+	priv := ed25519.NewKeyFromSeed(seed)
+
+	key_uri := fmt.Sprintf("constant://?val=%s", url.QueryEscape(base64.StdEncoding.EncodeToString(seed)))
+	uri := fmt.Sprintf("sign://ed25519?mode=body&encoding=base64&key=%s", url.QueryEscape(key_uri))
+
+	tr, err := NewTransformation(ctx, uri)
+
+	if err != nil {
+		t.Fatalf("Failed to create new sign transformation, %v", err)
+	}
+
+	body := []byte(`{"hello":"world"}`)
+
+	output, err2 := tr.Transform(ctx, body)
+
+	if err2 != nil {
+		t.Fatalf("Failed to transform body, %v", err2)
+	}
+
+	var msg SignedMessage
+
+	err = json.Unmarshal(output, &msg)
+
+	if err != nil {
+		t.Fatalf("Failed to decode output, %v", err)
+	}
+
+	if msg.Payload != string(body) || msg.Algorithm != SIGN_ED25519 {
+		t.Fatalf("Unexpected output '%s'", string(output))
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(msg.Signature)
+
+	if err != nil {
+		t.Fatalf("Failed to decode signature, %v", err)
+	}
+
+	if !ed25519.Verify(priv.Public().(ed25519.PublicKey), []byte(msg.Payload), sig) {
+		t.Fatalf("Failed to verify signature")
+	}
+}
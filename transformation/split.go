@@ -0,0 +1,212 @@
+package transformation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+	"github.com/whosonfirst/go-webhookd/v3/jsonpath"
+)
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterTransformation(ctx, "split", NewSplitTransformation)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// SplitTransformation implements the `webhookd.WebhookTransformation` and `webhookd.WebhookSplittingTransformation` interfaces
+// for splitting a list of items in a JSON-encoded message in to individual messages.
+type SplitTransformation struct {
+	webhookd.WebhookTransformation
+	// path is the `jsonpath` path of the list to split.
+	path string
+	// include is a list of `jsonpath` paths in the original message to copy in to each item.
+	include []string
+}
+
+// NewSplitTransformation returns a new `SplitTransformation` instance configured by 'uri' in the form of:
+//
+//	split://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `path={PATH}` The (dot-separated) path of the list to split, for example "commits". Required.
+// * `include={PATH}` Zero or more (dot-separated) paths in the original message whose values will be added to each item, keyed by path.
+// Items must be JSON objects in order to include values.
+func NewSplitTransformation(ctx context.Context, uri string) (webhookd.WebhookTransformation, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	path := q.Get("path")
+
+	if path == "" {
+		return nil, fmt.Errorf("Missing ?path= parameter")
+	}
+
+	tr := SplitTransformation{
+		path:    path,
+		include: q["include"],
+	}
+
+	return &tr, nil
+}
+
+// Transform returns the items that 'body' is split in to as a JSON-encoded list. This is the behaviour when a `SplitTransformation`
+// is used by consumers which do not support the `webhookd.WebhookSplittingTransformation` interface.
+func (tr *SplitTransformation) Transform(ctx context.Context, body []byte) ([]byte, *webhookd.WebhookError) {
+
+	items, err := tr.Split(ctx, body)
+
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]json.RawMessage, len(items))
+
+	for idx, item := range items {
+		values[idx] = json.RawMessage(item)
+	}
+
+	enc, enc_err := json.Marshal(values)
+
+	if enc_err != nil {
+		code := http.StatusInternalServerError
+		message := fmt.Sprintf("Failed to encode items, %v", enc_err)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	return enc, nil
+}
+
+// Split returns each of the items in the list at the path that 'tr' was instantiated with as individual JSON-encoded messages.
+func (tr *SplitTransformation) Split(ctx context.Context, body []byte) ([][]byte, *webhookd.WebhookError) {
+
+	doc, err := jsonpath.Decode(body)
+
+	if err != nil {
+		code := http.StatusBadRequest
+		message := err.Error()
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	v, ok := jsonpath.Get(doc, tr.path)
+
+	if !ok {
+		code := webhookd.UnhandledEvent
+		message := fmt.Sprintf("Message does not contain '%s'", tr.path)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	list, ok := v.([]interface{})
+
+	if !ok {
+		code := http.StatusBadRequest
+		message := fmt.Sprintf("'%s' is not a list", tr.path)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	items := make([][]byte, len(list))
+
+	for idx, item := range list {
+
+		if len(tr.include) > 0 {
+
+			obj, ok := item.(map[string]interface{})
+
+			if !ok {
+				code := http.StatusBadRequest
+				message := fmt.Sprintf("Item at offset %d of '%s' is not an object", idx, tr.path)
+				return nil, &webhookd.WebhookError{Code: code, Message: message}
+			}
+
+			for _, path := range tr.include {
+
+				include_v, ok := jsonpath.Get(doc, path)
+
+				if ok {
+					obj[path] = include_v
+				}
+			}
+		}
+
+		enc, err := json.Marshal(item)
+
+		if err != nil {
+			code := http.StatusInternalServerError
+			message := fmt.Sprintf("Failed to encode item at offset %d, %v", idx, err)
+			return nil, &webhookd.WebhookError{Code: code, Message: message}
+		}
+
+		items[idx] = enc
+	}
+
+	return items, nil
+}
+
+// TransformMessages applies 'step' to each message in 'bodies' returning the resultant messages. If 'step' implements the
+// `webhookd.WebhookSplittingTransformation` interface its `Split` method is used and each message may yield zero or more messages.
+// Messages for which 'step' returns a non-fatal error (`webhookd.UnhandledEvent` or `webhookd.HaltEvent`) are dropped. If every
+// message is dropped then the last of those errors is returned. Fatal errors are returned immediately.
+func TransformMessages(ctx context.Context, step webhookd.WebhookTransformation, bodies [][]byte) ([][]byte, *webhookd.WebhookError) {
+
+	splitter, is_splitter := step.(webhookd.WebhookSplittingTransformation)
+
+	results := make([][]byte, 0, len(bodies))
+
+	var halt *webhookd.WebhookError
+
+	for _, body := range bodies {
+
+		if is_splitter {
+
+			items, err := splitter.Split(ctx, body)
+
+			if err != nil {
+
+				switch err.Code {
+				case webhookd.UnhandledEvent, webhookd.HaltEvent:
+					halt = err
+					continue
+				default:
+					return nil, err
+				}
+			}
+
+			results = append(results, items...)
+			continue
+		}
+
+		output, err := step.Transform(ctx, body)
+
+		if err != nil {
+
+			switch err.Code {
+			case webhookd.UnhandledEvent, webhookd.HaltEvent:
+				halt = err
+				continue
+			default:
+				return nil, err
+			}
+		}
+
+		results = append(results, output)
+	}
+
+	if len(results) == 0 && halt != nil {
+		return nil, halt
+	}
+
+	return results, nil
+}
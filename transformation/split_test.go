@@ -0,0 +1,98 @@
+package transformation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+)
+
+func TestSplitTransformation(t *testing.T) {
+
+	ctx := context.Background()
+
+	tr, err := NewTransformation(ctx, "split://?path=commits&include=ref")
+
+	if err != nil {
+		t.Fatalf("Failed to create new split transformation, %v", err)
+	}
+
+	splitter, ok := tr.(webhookd.WebhookSplittingTransformation)
+
+	if !ok {
+		t.Fatalf("Expected split transformation to implement webhookd.WebhookSplittingTransformation")
+	}
+
+	body := []byte(`{"ref":"refs/heads/main","commits":[{"id":"a"},{"id":"b"}]}`)
+
+	items, err2 := splitter.Split(ctx, body)
+
+	if err2 != nil {
+		t.Fatalf("Failed to split body, %v", err2)
+	}
+
+	expected := []string{
+		`{"id":"a","ref":"refs/heads/main"}`,
+		`{"id":"b","ref":"refs/heads/main"}`,
+	}
+
+	if len(items) != len(expected) {
+		t.Fatalf("Unexpected number of items: %d", len(items))
+	}
+
+	for idx, item := range items {
+
+		if string(item) != expected[idx] {
+			t.Fatalf("Unexpected item at offset %d: %s", idx, string(item))
+		}
+	}
+
+	output, err2 := tr.Transform(ctx, body)
+
+	if err2 != nil {
+		t.Fatalf("Failed to transform body, %v", err2)
+	}
+
+	if string(output) != `[{"id":"a","ref":"refs/heads/main"},{"id":"b","ref":"refs/heads/main"}]` {
+		t.Fatalf("Unexpected output '%s'", string(output))
+	}
+}
+
+func TestTransformMessages(t *testing.T) {
+
+	ctx := context.Background()
+
+	split, err := NewTransformation(ctx, "split://?path=items")
+
+	if err != nil {
+		t.Fatalf("Failed to create new split transformation, %v", err)
+	}
+
+	null, err := NewTransformation(ctx, "null://")
+
+	if err != nil {
+		t.Fatalf("Failed to create new null transformation, %v", err)
+	}
+
+	p := NewPipelineTransformation([]webhookd.WebhookTransformation{null, split, null})
+
+	bodies, err2 := TransformMessages(ctx, p, [][]byte{[]byte(`{"items":[1,2,3]}`), []byte(`{"items":[4]}`)})
+
+	if err2 != nil {
+		t.Fatalf("Failed to transform messages, %v", err2)
+	}
+
+	if len(bodies) != 4 {
+		t.Fatalf("Unexpected number of messages: %d", len(bodies))
+	}
+
+	if string(bodies[3]) != "4" {
+		t.Fatalf("Unexpected message '%s'", string(bodies[3]))
+	}
+
+	_, err2 = TransformMessages(ctx, split, [][]byte{[]byte(`{"other":[]}`)})
+
+	if err2 == nil || err2.Code != webhookd.UnhandledEvent {
+		t.Fatalf("Expected missing list to be an unhandled event, %v", err2)
+	}
+}
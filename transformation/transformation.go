@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"github.com/aaronland/go-roster"
 	"github.com/whosonfirst/go-webhookd/v3"
+	"github.com/whosonfirst/go-webhookd/v3/secrets"
 	"net/url"
 	"sort"
 )
@@ -26,6 +27,12 @@ func NewTransformation(ctx context.Context, uri string) (webhookd.WebhookTransfo
 		return nil, fmt.Errorf("Failed to ensure transformation roster, %w", err)
 	}
 
+	uri, err = secrets.ResolveURI(ctx, uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to resolve secrets, %w", err)
+	}
+
 	parsed, err := url.Parse(uri)
 
 	if err != nil {
@@ -0,0 +1,202 @@
+package transformation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+	"github.com/whosonfirst/go-webhookd/v3/jsonpath"
+)
+
+// DEFAULT_TRUNCATE_MARKER is the default string appended to truncated messages.
+const DEFAULT_TRUNCATE_MARKER string = "…[truncated]"
+
+const (
+	// TRUNCATE_MODE_TRUNCATE truncates messages that are too large and appends a marker.
+	TRUNCATE_MODE_TRUNCATE string = "truncate"
+	// TRUNCATE_MODE_REMOVE removes properties from JSON-encoded messages that are too large.
+	TRUNCATE_MODE_REMOVE string = "remove"
+	// TRUNCATE_MODE_REJECT rejects messages that are too large.
+	TRUNCATE_MODE_REJECT string = "reject"
+)
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterTransformation(ctx, "truncate", NewTruncateTransformation)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// TruncateTransformation implements the `webhookd.WebhookTransformation` interface for enforcing a maximum message size.
+type TruncateTransformation struct {
+	webhookd.WebhookTransformation
+	// size is the maximum size of a message in bytes.
+	size int
+	// mode is how messages that are too large are handled.
+	mode string
+	// marker is the string appended to truncated messages.
+	marker string
+	// paths is the ordered list of `jsonpath` paths to remove from messages that are too large.
+	paths []string
+}
+
+// NewTruncateTransformation returns a new `TruncateTransformation` instance configured by 'uri' in the form of:
+//
+//	truncate://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `size={INT}` The maximum size of a message in bytes. Required.
+// * `mode={STRING}` How to handle messages that are too large. Valid options are "truncate", "remove" and "reject". Default is "truncate".
+// * `marker={STRING}` The string appended to truncated messages. It counts towards the maximum size. Default is "…[truncated]".
+// * `path={PATH}` One or more (dot-separated) paths to remove, in order, from JSON-encoded messages that are too large. Path segments
+// may be "*" to match every key in a dictionary or item in a list. Required if `mode` is "remove".
+func NewTruncateTransformation(ctx context.Context, uri string) (webhookd.WebhookTransformation, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	str_size := q.Get("size")
+
+	if str_size == "" {
+		return nil, fmt.Errorf("Missing ?size= parameter")
+	}
+
+	size, err := strconv.Atoi(str_size)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse ?size= parameter, %w", err)
+	}
+
+	if size <= 0 {
+		return nil, fmt.Errorf("Invalid ?size= parameter, must be greater than zero")
+	}
+
+	marker := DEFAULT_TRUNCATE_MARKER
+
+	if q.Has("marker") {
+		marker = q.Get("marker")
+	}
+
+	mode := q.Get("mode")
+	paths := q["path"]
+
+	switch mode {
+	case "":
+		mode = TRUNCATE_MODE_TRUNCATE
+	case TRUNCATE_MODE_TRUNCATE, TRUNCATE_MODE_REJECT:
+		// pass
+	case TRUNCATE_MODE_REMOVE:
+
+		if len(paths) == 0 {
+			return nil, fmt.Errorf("Missing ?path= parameter")
+		}
+
+	default:
+		return nil, fmt.Errorf("Invalid ?mode= parameter '%s'", mode)
+	}
+
+	if mode == TRUNCATE_MODE_TRUNCATE && len(marker) >= size {
+		return nil, fmt.Errorf("?marker= parameter must be smaller than ?size= parameter")
+	}
+
+	tr := TruncateTransformation{
+		size:   size,
+		mode:   mode,
+		marker: marker,
+		paths:  paths,
+	}
+
+	return &tr, nil
+}
+
+// Transform returns 'body' unaltered if it is not larger than the maximum size that 'tr' was instantiated with. Otherwise
+// 'body' is truncated (on a UTF-8 character boundary), has properties removed until it is small enough or is rejected with
+// a `413 Request Entity Too Large` error. Messages that are still too large after removing properties are also rejected.
+func (tr *TruncateTransformation) Transform(ctx context.Context, body []byte) ([]byte, *webhookd.WebhookError) {
+
+	if len(body) <= tr.size {
+		return body, nil
+	}
+
+	switch tr.mode {
+	case TRUNCATE_MODE_TRUNCATE:
+		return tr.truncate(body), nil
+	case TRUNCATE_MODE_REMOVE:
+		return tr.remove(body)
+	default:
+		return nil, tr.tooLarge(len(body))
+	}
+}
+
+// truncate returns 'body' truncated to the maximum size that 'tr' was instantiated with, including the marker.
+func (tr *TruncateTransformation) truncate(body []byte) []byte {
+
+	n := tr.size - len(tr.marker)
+
+	for n > 0 && !utf8.RuneStart(body[n]) {
+		n--
+	}
+
+	out := make([]byte, 0, n+len(tr.marker))
+	out = append(out, body[:n]...)
+	out = append(out, tr.marker...)
+
+	return out
+}
+
+// remove removes the paths that 'tr' was instantiated with from 'body', in order, until it is no larger than the maximum size.
+func (tr *TruncateTransformation) remove(body []byte) ([]byte, *webhookd.WebhookError) {
+
+	doc, err := jsonpath.Decode(body)
+
+	if err != nil {
+		code := http.StatusBadRequest
+		message := err.Error()
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	redact := &RedactTransformation{
+		mode: REDACT_MODE_REMOVE,
+	}
+
+	for _, path := range tr.paths {
+
+		doc = redact.redactPath(doc, jsonpath.Split(path))
+
+		enc, err := json.Marshal(doc)
+
+		if err != nil {
+			code := http.StatusInternalServerError
+			message := fmt.Sprintf("Failed to encode message, %v", err)
+			return nil, &webhookd.WebhookError{Code: code, Message: message}
+		}
+
+		if len(enc) <= tr.size {
+			return enc, nil
+		}
+
+		body = enc
+	}
+
+	return nil, tr.tooLarge(len(body))
+}
+
+// tooLarge returns a `413 Request Entity Too Large` error for a message of 'size' bytes.
+func (tr *TruncateTransformation) tooLarge(size int) *webhookd.WebhookError {
+	code := http.StatusRequestEntityTooLarge
+	message := fmt.Sprintf("Message size (%d bytes) exceeds maximum size (%d bytes)", size, tr.size)
+	return &webhookd.WebhookError{Code: code, Message: message}
+}
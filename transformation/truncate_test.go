@@ -0,0 +1,74 @@
+package transformation
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestTruncateTransformation(t *testing.T) {
+
+	ctx := context.Background()
+
+	tests := map[string][]string{
+		"truncate://?size=100": []string{
+			"hello world",
+			"hello world",
+		},
+		"truncate://?size=10&marker=...": []string{
+			"hello wörld",
+			"hello w...",
+		},
+		"truncate://?size=11&marker=...": []string{
+			"hello wörld",
+			"hello w...",
+		},
+		"truncate://?size=40&mode=remove&path=commits.*.diff&path=commits": []string{
+			`{"commits":[{"id":"a","diff":"+++ a very long diff"}],"ref":"main"}`,
+			`{"commits":[{"id":"a"}],"ref":"main"}`,
+		},
+		"truncate://?size=20&mode=remove&path=commits.*.diff&path=commits": []string{
+			`{"commits":[{"id":"a","diff":"+++ a very long diff"}],"ref":"main"}`,
+			`{"ref":"main"}`,
+		},
+	}
+
+	for uri, details := range tests {
+
+		tr, err := NewTransformation(ctx, uri)
+
+		if err != nil {
+			t.Fatalf("Failed to create new transformation for %s, %v", uri, err)
+		}
+
+		output, err2 := tr.Transform(ctx, []byte(details[0]))
+
+		if err2 != nil {
+			t.Fatalf("Failed to transform body for %s, %v", uri, err2)
+		}
+
+		if string(output) != details[1] {
+			t.Fatalf("Unexpected output for %s '%s'", uri, string(output))
+		}
+	}
+
+	reject_tests := map[string]string{
+		"truncate://?size=5&mode=reject":           "hello world",
+		"truncate://?size=5&mode=remove&path=diff": `{"diff":"","id":"abcdef"}`,
+	}
+
+	for uri, body := range reject_tests {
+
+		tr, err := NewTransformation(ctx, uri)
+
+		if err != nil {
+			t.Fatalf("Failed to create new transformation for %s, %v", uri, err)
+		}
+
+		_, err2 := tr.Transform(ctx, []byte(body))
+
+		if err2 == nil || err2.Code != http.StatusRequestEntityTooLarge {
+			t.Fatalf("Expected %s to reject message, %v", uri, err2)
+		}
+	}
+}
@@ -0,0 +1,444 @@
+package transformation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+	"github.com/whosonfirst/go-webhookd/v3/jsonpath"
+)
+
+// DEFAULT_XML_ATTRIBUTE_PREFIX is the default prefix for JSON properties derived from XML attributes.
+const DEFAULT_XML_ATTRIBUTE_PREFIX string = "@"
+
+// DEFAULT_XML_TEXT_PROPERTY is the default JSON property for the text content of XML elements that also have attributes or children.
+const DEFAULT_XML_TEXT_PROPERTY string = "#text"
+
+// DEFAULT_XML_ROOT is the default name of the root element for JSON documents that don't have exactly one top-level property.
+const DEFAULT_XML_ROOT string = "root"
+
+func init() {
+
+	ctx := context.Background()
+
+	err := RegisterTransformation(ctx, "xml2json", NewXMLToJSONTransformation)
+
+	if err != nil {
+		panic(err)
+	}
+
+	err = RegisterTransformation(ctx, "json2xml", NewJSONToXMLTransformation)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// xmlOptions is a struct containing the options shared by the XML transformations.
+type xmlOptions struct {
+	// attribute_prefix is the prefix for JSON properties derived from XML attributes.
+	attribute_prefix string
+	// text_property is the JSON property for the text content of XML elements that also have attributes or children.
+	text_property string
+}
+
+// newXMLOptions returns a new `xmlOptions` instance derived from 'q'.
+func newXMLOptions(q url.Values) *xmlOptions {
+
+	opts := &xmlOptions{
+		attribute_prefix: DEFAULT_XML_ATTRIBUTE_PREFIX,
+		text_property:    DEFAULT_XML_TEXT_PROPERTY,
+	}
+
+	if q.Has("attribute_prefix") {
+		opts.attribute_prefix = q.Get("attribute_prefix")
+	}
+
+	if q.Has("text_property") {
+		opts.text_property = q.Get("text_property")
+	}
+
+	return opts
+}
+
+// XMLToJSONTransformation implements the `webhookd.WebhookTransformation` interface for converting XML-encoded messages to JSON.
+type XMLToJSONTransformation struct {
+	webhookd.WebhookTransformation
+	options *xmlOptions
+}
+
+// NewXMLToJSONTransformation returns a new `XMLToJSONTransformation` instance configured by 'uri' in the form of:
+//
+//	xml2json://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `attribute_prefix={STRING}` The prefix for JSON properties derived from XML attributes. Default is "@".
+// * `text_property={STRING}` The JSON property for the text content of XML elements that also have attributes or children. Default is "#text".
+//
+// Elements are converted to JSON objects keyed by element name, repeated elements are converted to lists and elements with
+// no attributes or children are converted to strings. Namespace prefixes are removed from element and attribute names.
+func NewXMLToJSONTransformation(ctx context.Context, uri string) (webhookd.WebhookTransformation, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	tr := XMLToJSONTransformation{
+		options: newXMLOptions(u.Query()),
+	}
+
+	return &tr, nil
+}
+
+// xmlNode is an intermediate representation of an XML element.
+type xmlNode struct {
+	name     string
+	attrs    []xml.Attr
+	children []*xmlNode
+	text     strings.Builder
+}
+
+// Transform converts the XML document in 'body' to JSON.
+func (tr *XMLToJSONTransformation) Transform(ctx context.Context, body []byte) ([]byte, *webhookd.WebhookError) {
+
+	dec := xml.NewDecoder(bytes.NewReader(body))
+
+	// Don't fail on documents which declare non-UTF-8 encodings; they will be treated as UTF-8
+
+	dec.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+
+	var root *xmlNode
+	stack := make([]*xmlNode, 0)
+
+	for {
+
+		tok, err := dec.Token()
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			code := http.StatusBadRequest
+			message := fmt.Sprintf("Failed to parse XML, %v", err)
+			return nil, &webhookd.WebhookError{Code: code, Message: message}
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+
+			n := &xmlNode{
+				name:  t.Name.Local,
+				attrs: t.Attr,
+			}
+
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, n)
+			} else if root == nil {
+				root = n
+			}
+
+			stack = append(stack, n)
+
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(t)
+			}
+		}
+	}
+
+	if root == nil {
+		code := http.StatusBadRequest
+		message := "XML document does not contain any elements"
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	doc := map[string]interface{}{
+		root.name: tr.convert(root),
+	}
+
+	enc, err := json.Marshal(doc)
+
+	if err != nil {
+		code := http.StatusInternalServerError
+		message := fmt.Sprintf("Failed to encode message, %v", err)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	return enc, nil
+}
+
+// convert returns the JSON representation of 'n'.
+func (tr *XMLToJSONTransformation) convert(n *xmlNode) interface{} {
+
+	text := strings.TrimSpace(n.text.String())
+
+	attrs := make([]xml.Attr, 0, len(n.attrs))
+
+	for _, a := range n.attrs {
+
+		// Namespace declarations are not meaningful once prefixes have been removed
+
+		if a.Name.Space == "xmlns" || a.Name.Local == "xmlns" {
+			continue
+		}
+
+		attrs = append(attrs, a)
+	}
+
+	if len(attrs) == 0 && len(n.children) == 0 {
+		return text
+	}
+
+	obj := make(map[string]interface{})
+
+	for _, a := range attrs {
+		obj[tr.options.attribute_prefix+a.Name.Local] = a.Value
+	}
+
+	for _, child := range n.children {
+
+		v := tr.convert(child)
+
+		existing, ok := obj[child.name]
+
+		if !ok {
+			obj[child.name] = v
+			continue
+		}
+
+		list, ok := existing.([]interface{})
+
+		if !ok {
+			list = []interface{}{existing}
+		}
+
+		obj[child.name] = append(list, v)
+	}
+
+	if text != "" {
+		obj[tr.options.text_property] = text
+	}
+
+	return obj
+}
+
+// JSONToXMLTransformation implements the `webhookd.WebhookTransformation` interface for converting JSON-encoded messages to XML.
+type JSONToXMLTransformation struct {
+	webhookd.WebhookTransformation
+	options *xmlOptions
+	// root is the name of the root element for JSON documents that don't have exactly one top-level property.
+	root string
+	// declaration is a boolean flag signaling that an XML declaration should be included in the output.
+	declaration bool
+}
+
+// NewJSONToXMLTransformation returns a new `JSONToXMLTransformation` instance configured by 'uri' in the form of:
+//
+//	json2xml://?{PARAMETERS}
+//
+// Valid {PARAMETERS} are:
+// * `attribute_prefix={STRING}` The prefix for JSON properties that should be converted to XML attributes. Default is "@".
+// * `text_property={STRING}` The JSON property that should be converted to the text content of an XML element. Default is "#text".
+// * `root={STRING}` The name of the root element for JSON documents that don't have exactly one top-level property. Default is "root".
+// * `declaration={BOOLEAN}` Include an XML declaration in the output. Default is true.
+//
+// This is the inverse of the `xml2json://` transformation.
+func NewJSONToXMLTransformation(ctx context.Context, uri string) (webhookd.WebhookTransformation, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	root := q.Get("root")
+
+	if root == "" {
+		root = DEFAULT_XML_ROOT
+	}
+
+	declaration := true
+
+	str_declaration := q.Get("declaration")
+
+	if str_declaration != "" {
+
+		v, err := strconv.ParseBool(str_declaration)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse ?declaration= parameter, %w", err)
+		}
+
+		declaration = v
+	}
+
+	tr := JSONToXMLTransformation{
+		options:     newXMLOptions(q),
+		root:        root,
+		declaration: declaration,
+	}
+
+	return &tr, nil
+}
+
+// Transform converts the JSON document in 'body' to XML.
+func (tr *JSONToXMLTransformation) Transform(ctx context.Context, body []byte) ([]byte, *webhookd.WebhookError) {
+
+	doc, err := jsonpath.Decode(body)
+
+	if err != nil {
+		code := http.StatusBadRequest
+		message := err.Error()
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	name := tr.root
+	value := doc
+
+	if obj, ok := doc.(map[string]interface{}); ok && len(obj) == 1 {
+
+		for k, v := range obj {
+
+			if _, is_list := v.([]interface{}); !is_list && !strings.HasPrefix(k, tr.options.attribute_prefix) {
+				name = k
+				value = v
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+
+	if tr.declaration {
+		buf.WriteString(xml.Header)
+	}
+
+	enc := xml.NewEncoder(&buf)
+
+	err = tr.encode(enc, name, value)
+
+	if err == nil {
+		err = enc.Flush()
+	}
+
+	if err != nil {
+		code := http.StatusBadRequest
+		message := fmt.Sprintf("Failed to encode XML, %v", err)
+		return nil, &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// encode writes 'v' to 'enc' as one or more elements named 'name'.
+func (tr *JSONToXMLTransformation) encode(enc *xml.Encoder, name string, v interface{}) error {
+
+	if list, ok := v.([]interface{}); ok {
+
+		for _, item := range list {
+
+			err := tr.encode(enc, name, item)
+
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+
+	obj, is_obj := v.(map[string]interface{})
+
+	if !is_obj {
+
+		err := enc.EncodeToken(start)
+
+		if err != nil {
+			return err
+		}
+
+		if v != nil {
+
+			err = enc.EncodeToken(xml.CharData(jsonpath.String(v)))
+
+			if err != nil {
+				return err
+			}
+		}
+
+		return enc.EncodeToken(start.End())
+	}
+
+	// Sort keys so that output is stable
+
+	keys := make([]string, 0, len(obj))
+
+	for k := range obj {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	children := make([]string, 0, len(keys))
+	text := ""
+
+	for _, k := range keys {
+
+		switch {
+		case k == tr.options.text_property:
+			text = jsonpath.String(obj[k])
+		case tr.options.attribute_prefix != "" && strings.HasPrefix(k, tr.options.attribute_prefix):
+			attr := xml.Attr{Name: xml.Name{Local: strings.TrimPrefix(k, tr.options.attribute_prefix)}, Value: jsonpath.String(obj[k])}
+			start.Attr = append(start.Attr, attr)
+		default:
+			children = append(children, k)
+		}
+	}
+
+	err := enc.EncodeToken(start)
+
+	if err != nil {
+		return err
+	}
+
+	if text != "" {
+
+		err = enc.EncodeToken(xml.CharData(text))
+
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, k := range children {
+
+		err = tr.encode(enc, k, obj[k])
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return enc.EncodeToken(start.End())
+}
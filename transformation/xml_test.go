@@ -0,0 +1,72 @@
+package transformation
+
+import (
+	"context"
+	"testing"
+)
+
+func TestXMLToJSONTransformation(t *testing.T) {
+
+	ctx := context.Background()
+
+	tr, err := NewTransformation(ctx, "xml2json://")
+
+	if err != nil {
+		t.Fatalf("Failed to create new xml2json transformation, %v", err)
+	}
+
+	input := `<?xml version="1.0" encoding="ISO-8859-1"?>
+<build xmlns="http://example.com/ns" number="42">
+	<status>SUCCESS</status>
+	<artifact>a.jar</artifact>
+	<artifact>b.jar</artifact>
+	<url type="console">https://ci.example.com/42</url>
+</build>`
+
+	output, err2 := tr.Transform(ctx, []byte(input))
+
+	if err2 != nil {
+		t.Fatalf("Failed to transform body, %v", err2)
+	}
+
+	expected := `{"build":{"@number":"42","artifact":["a.jar","b.jar"],"status":"SUCCESS","url":{"#text":"https://ci.example.com/42","@type":"console"}}}`
+
+	if string(output) != expected {
+		t.Fatalf("Unexpected output '%s'", string(output))
+	}
+
+	_, err2 = tr.Transform(ctx, []byte("<broken>"))
+
+	if err2 == nil {
+		t.Fatalf("Expected invalid XML to fail")
+	}
+}
+
+func TestJSONToXMLTransformation(t *testing.T) {
+
+	ctx := context.Background()
+
+	tr, err := NewTransformation(ctx, "json2xml://?declaration=false")
+
+	if err != nil {
+		t.Fatalf("Failed to create new json2xml transformation, %v", err)
+	}
+
+	tests := map[string]string{
+		`{"build":{"@number":"42","artifact":["a.jar","b.jar"],"status":"SUCCESS","url":{"#text":"https://ci.example.com/42","@type":"console"}}}`: `<build number="42"><artifact>a.jar</artifact><artifact>b.jar</artifact><status>SUCCESS</status><url type="console">https://ci.example.com/42</url></build>`,
+		`{"a":1,"b":"<&>"}`: `<root><a>1</a><b>&lt;&amp;&gt;</b></root>`,
+	}
+
+	for input, expected := range tests {
+
+		output, err2 := tr.Transform(ctx, []byte(input))
+
+		if err2 != nil {
+			t.Fatalf("Failed to transform body, %v", err2)
+		}
+
+		if string(output) != expected {
+			t.Fatalf("Unexpected output '%s'", string(output))
+		}
+	}
+}
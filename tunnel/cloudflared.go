@@ -0,0 +1,130 @@
+package tunnel
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterTunnel(ctx, "cloudflared", NewCloudflaredTunnel)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// reQuickTunnelURL is the regular expression used to find the public URL for a Cloudflare "quick" tunnel.
+var reQuickTunnelURL = regexp.MustCompile(`https://[a-z0-9\-]+\.trycloudflare\.com`)
+
+// CloudflaredTunnel implements the `Tunnel` interface for Cloudflare Tunnels established using the `cloudflared` program.
+type CloudflaredTunnel struct {
+	Tunnel
+	path     string
+	token    string
+	hostname string
+	timeout  time.Duration
+	process  *process
+}
+
+// NewCloudflaredTunnel returns a new `CloudflaredTunnel` instance configured by 'uri' in the form of:
+//
+//	cloudflared://?{PARAMETERS}
+//
+// Where {PARAMETERS} may be:
+// * `path={STRING}`. The path to the `cloudflared` program. Default is "cloudflared".
+// * `token={STRING}`. An optional token for a named tunnel. If empty a "quick" tunnel, with a random trycloudflare.com hostname,
+// is used.
+// * `hostname={STRING}`. The public hostname routed to the named tunnel. Required if `token` is present.
+// * `timeout={DURATION}`. The amount of time to wait for the tunnel to be established. Default is 30s.
+func NewCloudflaredTunnel(ctx context.Context, uri string) (Tunnel, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	timeout, err := timeoutOption(q)
+
+	if err != nil {
+		return nil, err
+	}
+
+	t := &CloudflaredTunnel{
+		path:     "cloudflared",
+		token:    q.Get("token"),
+		hostname: q.Get("hostname"),
+		timeout:  timeout,
+	}
+
+	if q.Get("path") != "" {
+		t.path = q.Get("path")
+	}
+
+	if t.token != "" && t.hostname == "" {
+		return nil, fmt.Errorf("Missing hostname parameter for named tunnel")
+	}
+
+	return t, nil
+}
+
+// Open starts the `cloudflared` program forwarding requests to 'addr' and returns the public URL for the tunnel.
+func (t *CloudflaredTunnel) Open(ctx context.Context, addr string) (string, error) {
+
+	args := []string{"tunnel", "--no-autoupdate", "--url", addr}
+	env := make([]string, 0)
+
+	match := matchQuickTunnel
+
+	if t.token != "" {
+
+		args = append(args, "run")
+
+		// Pass the token as an environment variable so that it is not visible in the process list
+
+		env = append(env, fmt.Sprintf("TUNNEL_TOKEN=%s", t.token))
+
+		public_url := fmt.Sprintf("https://%s", t.hostname)
+
+		match = func(line string) (string, error) {
+
+			if strings.Contains(line, "Registered tunnel connection") {
+				return public_url, nil
+			}
+
+			return "", nil
+		}
+	}
+
+	p, public_url, err := startProcess(ctx, t.path, args, env, t.timeout, match)
+
+	if err != nil {
+		return "", fmt.Errorf("Failed to open cloudflared tunnel, %w", err)
+	}
+
+	t.process = p
+	return public_url, nil
+}
+
+// Close stops the `cloudflared` program.
+func (t *CloudflaredTunnel) Close() error {
+
+	if t.process == nil {
+		return nil
+	}
+
+	return t.process.stop()
+}
+
+// matchQuickTunnel returns the trycloudflare.com URL in 'line', if present.
+func matchQuickTunnel(line string) (string, error) {
+	return reQuickTunnelURL.FindString(line), nil
+}
@@ -0,0 +1,74 @@
+package tunnel
+
+import (
+	"context"
+	"net/url"
+	"testing"
+)
+
+func TestCloudflaredTunnel(t *testing.T) {
+
+	ctx := context.Background()
+
+	path := writeScript(t, `echo "2024-01-01T00:00:00Z INF Requesting new quick Tunnel on trycloudflare.com..." >&2
+echo "2024-01-01T00:00:00Z INF |  https://random-words-here.trycloudflare.com                                             |" >&2
+exec sleep 60`)
+
+	q := url.Values{}
+	q.Set("path", path)
+
+	tn, err := NewTunnel(ctx, "cloudflared://?"+q.Encode())
+
+	if err != nil {
+		t.Fatalf("Failed to create tunnel, %v", err)
+	}
+
+	public_url, err := tn.Open(ctx, "http://localhost:8080")
+
+	if err != nil {
+		t.Fatalf("Failed to open tunnel, %v", err)
+	}
+
+	if public_url != "https://random-words-here.trycloudflare.com" {
+		t.Fatalf("Unexpected public URL '%s'", public_url)
+	}
+
+	err = tn.Close()
+
+	if err != nil {
+		t.Fatalf("Failed to close tunnel, %v", err)
+	}
+}
+
+func TestCloudflaredNamedTunnel(t *testing.T) {
+
+	ctx := context.Background()
+
+	// The named tunnel is only established if the token is passed as an environment variable
+
+	path := writeScript(t, `if [ "$TUNNEL_TOKEN" = "s33kret" ]; then echo "2024-01-01T00:00:00Z INF Registered tunnel connection connIndex=0" >&2; fi
+exec sleep 60`)
+
+	q := url.Values{}
+	q.Set("path", path)
+	q.Set("token", "s33kret")
+	q.Set("hostname", "hooks.example.com")
+
+	tn, err := NewTunnel(ctx, "cloudflared://?"+q.Encode())
+
+	if err != nil {
+		t.Fatalf("Failed to create tunnel, %v", err)
+	}
+
+	public_url, err := tn.Open(ctx, "http://localhost:8080")
+
+	if err != nil {
+		t.Fatalf("Failed to open tunnel, %v", err)
+	}
+
+	defer tn.Close()
+
+	if public_url != "https://hooks.example.com" {
+		t.Fatalf("Unexpected public URL '%s'", public_url)
+	}
+}
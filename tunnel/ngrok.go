@@ -0,0 +1,147 @@
+package tunnel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterTunnel(ctx, "ngrok", NewNgrokTunnel)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// ngrokLogEntry is a single JSON-encoded log entry written by the `ngrok` program.
+type ngrokLogEntry struct {
+	Level   string `json:"lvl"`
+	Message string `json:"msg"`
+	URL     string `json:"url"`
+	Error   string `json:"err"`
+}
+
+// NgrokTunnel implements the `Tunnel` interface for tunnels established using the `ngrok` program.
+type NgrokTunnel struct {
+	Tunnel
+	path      string
+	authtoken string
+	domain    string
+	region    string
+	timeout   time.Duration
+	process   *process
+}
+
+// NewNgrokTunnel returns a new `NgrokTunnel` instance configured by 'uri' in the form of:
+//
+//	ngrok://?{PARAMETERS}
+//
+// Where {PARAMETERS} may be:
+// * `path={STRING}`. The path to the `ngrok` program. Default is "ngrok".
+// * `authtoken={STRING}`. An optional ngrok authentication token. If empty the token in the ngrok config file is used.
+// * `domain={STRING}`. An optional static domain, reserved with ngrok, for the public URL.
+// * `region={STRING}`. An optional ngrok region.
+// * `timeout={DURATION}`. The amount of time to wait for the tunnel to be established. Default is 30s.
+func NewNgrokTunnel(ctx context.Context, uri string) (Tunnel, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	timeout, err := timeoutOption(q)
+
+	if err != nil {
+		return nil, err
+	}
+
+	t := &NgrokTunnel{
+		path:      "ngrok",
+		authtoken: q.Get("authtoken"),
+		domain:    q.Get("domain"),
+		region:    q.Get("region"),
+		timeout:   timeout,
+	}
+
+	if q.Get("path") != "" {
+		t.path = q.Get("path")
+	}
+
+	return t, nil
+}
+
+// Open starts the `ngrok` program forwarding requests to 'addr' and returns the public URL for the tunnel.
+func (t *NgrokTunnel) Open(ctx context.Context, addr string) (string, error) {
+
+	args := []string{"http", addr, "--log", "stdout", "--log-format", "json"}
+	env := make([]string, 0)
+
+	if t.domain != "" {
+		args = append(args, "--domain", t.domain)
+	}
+
+	if t.region != "" {
+		args = append(args, "--region", t.region)
+	}
+
+	// Pass the token as an environment variable so that it is not visible in the process list
+
+	if t.authtoken != "" {
+		env = append(env, fmt.Sprintf("NGROK_AUTHTOKEN=%s", t.authtoken))
+	}
+
+	p, public_url, err := startProcess(ctx, t.path, args, env, t.timeout, matchNgrok)
+
+	if err != nil {
+		return "", fmt.Errorf("Failed to open ngrok tunnel, %w", err)
+	}
+
+	t.process = p
+	return public_url, nil
+}
+
+// Close stops the `ngrok` program.
+func (t *NgrokTunnel) Close() error {
+
+	if t.process == nil {
+		return nil
+	}
+
+	return t.process.stop()
+}
+
+// matchNgrok returns the public URL in a "started tunnel" log entry or the error in a log entry for a fatal error. Errors written
+// before logging has started (for example for an invalid config file) are prefixed with "ERROR:".
+func matchNgrok(line string) (string, error) {
+
+	if strings.HasPrefix(line, "ERROR:") {
+		return "", fmt.Errorf("%s", strings.TrimSpace(strings.TrimPrefix(line, "ERROR:")))
+	}
+
+	var entry ngrokLogEntry
+
+	err := json.Unmarshal([]byte(line), &entry)
+
+	if err != nil {
+		return "", nil
+	}
+
+	if entry.Level == "crit" {
+		return "", fmt.Errorf("%s, %s", entry.Message, entry.Error)
+	}
+
+	if entry.Message == "started tunnel" && entry.URL != "" {
+		return entry.URL, nil
+	}
+
+	return "", nil
+}
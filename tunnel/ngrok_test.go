@@ -0,0 +1,72 @@
+package tunnel
+
+import (
+	"context"
+	"net/url"
+	"testing"
+)
+
+func TestNgrokTunnel(t *testing.T) {
+
+	ctx := context.Background()
+
+	// The token is passed as an environment variable and the upstream address as the second argument
+
+	path := writeScript(t, `echo '{"lvl":"info","msg":"open config file","path":"ngrok.yml"}'
+echo "{\"lvl\":\"info\",\"msg\":\"started tunnel\",\"addr\":\"$2\",\"url\":\"https://$NGROK_AUTHTOKEN.ngrok.app\"}"
+exec sleep 60`)
+
+	q := url.Values{}
+	q.Set("path", path)
+	q.Set("authtoken", "s33kret")
+
+	tn, err := NewTunnel(ctx, "ngrok://?"+q.Encode())
+
+	if err != nil {
+		t.Fatalf("Failed to create tunnel, %v", err)
+	}
+
+	public_url, err := tn.Open(ctx, "http://localhost:8080")
+
+	if err != nil {
+		t.Fatalf("Failed to open tunnel, %v", err)
+	}
+
+	defer tn.Close()
+
+	if public_url != "https://s33kret.ngrok.app" {
+		t.Fatalf("Unexpected public URL '%s'", public_url)
+	}
+}
+
+func TestMatchNgrok(t *testing.T) {
+
+	tests := []struct {
+		line     string
+		url      string
+		expected bool
+	}{
+		{`{"lvl":"info","msg":"started tunnel","url":"https://example.ngrok.app"}`, "https://example.ngrok.app", true},
+		{`{"lvl":"info","msg":"client session established"}`, "", true},
+		{`{"lvl":"crit","msg":"command failed","err":"authentication failed"}`, "", false},
+		{`ERROR:  authentication failed: Usage of ngrok requires a verified account and authtoken.`, "", false},
+		{`t=2024-01-01T00:00:00+0000 lvl=info msg="no configuration paths supplied"`, "", true},
+	}
+
+	for idx, test := range tests {
+
+		u, err := matchNgrok(test.line)
+
+		if test.expected && err != nil {
+			t.Fatalf("Unexpected error for test %d, %v", idx, err)
+		}
+
+		if !test.expected && err == nil {
+			t.Fatalf("Expected test %d to fail", idx)
+		}
+
+		if u != test.url {
+			t.Fatalf("Unexpected URL for test %d, '%s'", idx, u)
+		}
+	}
+}
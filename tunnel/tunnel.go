@@ -0,0 +1,246 @@
+// Package tunnel provides an interface for exposing a local webhookd server to the internet, using a tunnelling service like ngrok
+// or Cloudflare Tunnel, so that provider webhooks can be tested against a development machine.
+package tunnel
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aaronland/go-roster"
+	"github.com/whosonfirst/go-webhookd/v3/secrets"
+)
+
+// DEFAULT_TIMEOUT is the default amount of time to wait for a tunnel to be established.
+const DEFAULT_TIMEOUT time.Duration = 30 * time.Second
+
+// Tunnel is an interface for exposing a local webhookd server to the internet.
+type Tunnel interface {
+	// Open establishes a tunnel that forwards requests to 'addr' (for example "http://localhost:8080") and returns the public
+	// URL for the tunnel.
+	Open(context.Context, string) (string, error)
+	// Close closes the tunnel and releases any resources associated with it.
+	Close() error
+}
+
+// tunnels is a `aaronland/go-roster.Roster` instance used to maintain a list of registered `Tunnel` initialization functions.
+var tunnels roster.Roster
+
+// TunnelInitializationFunc is a function used to initialize an implementation of the `Tunnel` interface.
+type TunnelInitializationFunc func(ctx context.Context, uri string) (Tunnel, error)
+
+// NewTunnel returns a new `Tunnel` instance derived from 'uri'. The semantics of and requirements for 'uri' as specific to the
+// package implementing the interface.
+func NewTunnel(ctx context.Context, uri string) (Tunnel, error) {
+
+	err := ensureRoster()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to ensure tunnel roster, %w", err)
+	}
+
+	uri, err = secrets.ResolveURI(ctx, uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to resolve secrets, %w", err)
+	}
+
+	parsed, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	scheme := parsed.Scheme
+
+	i, err := tunnels.Driver(ctx, scheme)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to find initialization function for '%s', %w", scheme, err)
+	}
+
+	init_func := i.(TunnelInitializationFunc)
+	return init_func(ctx, uri)
+}
+
+// RegisterTunnel associates 'scheme' with 'init_func' in an internal list of avilable `Tunnel` implementations.
+func RegisterTunnel(ctx context.Context, scheme string, init_func TunnelInitializationFunc) error {
+
+	err := ensureRoster()
+
+	if err != nil {
+		return fmt.Errorf("Failed to ensure tunnel roster, %w", err)
+	}
+
+	return tunnels.Register(ctx, scheme, init_func)
+}
+
+// ensureRoster ensures that a `aaronland/go-roster.Roster` instance used to maintain a list of registered `Tunnel`
+// initialization functions is present
+func ensureRoster() error {
+
+	if tunnels == nil {
+
+		r, err := roster.NewDefaultRoster()
+
+		if err != nil {
+			return fmt.Errorf("Failed to create new roster, %w", err)
+		}
+
+		tunnels = r
+	}
+
+	return nil
+}
+
+// Schemes returns the list of schemes that have been "registered".
+func Schemes() []string {
+
+	ctx := context.Background()
+	drivers := tunnels.Drivers(ctx)
+
+	schemes := make([]string, len(drivers))
+
+	for idx, dr := range drivers {
+		schemes[idx] = fmt.Sprintf("%s://", dr)
+	}
+
+	sort.Strings(schemes)
+	return schemes
+}
+
+// timeoutOption returns the duration for the "timeout" parameter in 'q' or `DEFAULT_TIMEOUT` if it is not present.
+func timeoutOption(q url.Values) (time.Duration, error) {
+
+	if q.Get("timeout") == "" {
+		return DEFAULT_TIMEOUT, nil
+	}
+
+	timeout, err := time.ParseDuration(q.Get("timeout"))
+
+	if err != nil {
+		return 0, fmt.Errorf("Invalid timeout parameter, %w", err)
+	}
+
+	if timeout <= 0 {
+		return 0, fmt.Errorf("Invalid timeout parameter, must be greater than zero")
+	}
+
+	return timeout, nil
+}
+
+// matchFunc is a function used to inspect each line of output from a tunnel process. It returns the public URL for the tunnel, an
+// error if the tunnel failed, or neither if the line should be ignored.
+type matchFunc func(line string) (string, error)
+
+// process is a tunnel client (for example `ngrok` or `cloudflared`) running in a separate process.
+type process struct {
+	cmd *exec.Cmd
+	mu  *sync.Mutex
+}
+
+// startProcess starts the program at 'path' with 'args' and 'env', in addition to the environment for the current process, and
+// passes each line the program writes to STDOUT or STDERR to 'match' until it returns a public URL or an error, the program exits
+// or 'timeout' elapses. The program is stopped if a public URL is not returned.
+func startProcess(ctx context.Context, path string, args []string, env []string, timeout time.Duration, match matchFunc) (*process, string, error) {
+
+	r, w, err := os.Pipe()
+
+	if err != nil {
+		return nil, "", fmt.Errorf("Failed to create pipe, %w", err)
+	}
+
+	cmd := exec.Command(path, args...)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdout = w
+	cmd.Stderr = w
+
+	err = cmd.Start()
+
+	w.Close()
+
+	if err != nil {
+		r.Close()
+		return nil, "", fmt.Errorf("Failed to start %s, %w", path, err)
+	}
+
+	p := &process{
+		cmd: cmd,
+		mu:  new(sync.Mutex),
+	}
+
+	type result struct {
+		url string
+		err error
+	}
+
+	result_ch := make(chan result, 1)
+
+	go func() {
+
+		defer r.Close()
+
+		scanner := bufio.NewScanner(r)
+		done := false
+
+		for scanner.Scan() {
+
+			// Keep reading output, once the tunnel has been established, so the process never blocks writing to the pipe
+
+			if done {
+				continue
+			}
+
+			u, err := match(scanner.Text())
+
+			if err != nil || u != "" {
+				result_ch <- result{url: u, err: err}
+				done = true
+			}
+		}
+
+		if !done {
+			result_ch <- result{err: fmt.Errorf("%s exited before the tunnel was established", path)}
+		}
+	}()
+
+	var rsp result
+
+	select {
+	case <-ctx.Done():
+		rsp.err = ctx.Err()
+	case <-time.After(timeout):
+		rsp.err = fmt.Errorf("Timed out waiting for tunnel to be established")
+	case rsp = <-result_ch:
+		// pass
+	}
+
+	if rsp.err != nil {
+		p.stop()
+		return nil, "", rsp.err
+	}
+
+	return p, rsp.url, nil
+}
+
+// stop stops 'p' and waits for it to exit. It is safe to call more than once.
+func (p *process) stop() error {
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.cmd.ProcessState != nil {
+		return nil
+	}
+
+	p.cmd.Process.Kill()
+	p.cmd.Wait()
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package tunnel
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeScript writes a shell script containing 'body' to a temporary directory and returns its path.
+func writeScript(t *testing.T, body string) string {
+
+	path := filepath.Join(t.TempDir(), "tunnel.sh")
+
+	err := os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0755)
+
+	if err != nil {
+		t.Fatalf("Failed to write script, %v", err)
+	}
+
+	return path
+}
+
+func TestNewTunnel(t *testing.T) {
+
+	ctx := context.Background()
+
+	tests := []struct {
+		uri      string
+		expected bool
+	}{
+		{"ngrok://", true},
+		{"ngrok://?authtoken=s33kret&domain=example.ngrok.app&timeout=1m", true},
+		{"cloudflared://", true},
+		{"cloudflared://?token=s33kret&hostname=hooks.example.com", true},
+		{"ngrok://?timeout=bogus", false},
+		{"ngrok://?timeout=-1s", false},
+		{"cloudflared://?token=s33kret", false},
+		{"bogus://", false},
+	}
+
+	for _, test := range tests {
+
+		_, err := NewTunnel(ctx, test.uri)
+
+		if test.expected && err != nil {
+			t.Fatalf("Failed to create tunnel for '%s', %v", test.uri, err)
+		}
+
+		if !test.expected && err == nil {
+			t.Fatalf("Expected tunnel for '%s' to fail", test.uri)
+		}
+	}
+}
+
+func TestStartProcess(t *testing.T) {
+
+	ctx := context.Background()
+
+	match := func(line string) (string, error) {
+
+		if strings.HasPrefix(line, "url ") {
+			return strings.TrimPrefix(line, "url "), nil
+		}
+
+		return "", nil
+	}
+
+	path := writeScript(t, "echo starting\necho \"url https://$1.example.com\" >&2\nexec sleep 60")
+
+	p, public_url, err := startProcess(ctx, path, []string{"test"}, nil, time.Second*5, match)
+
+	if err != nil {
+		t.Fatalf("Failed to start process, %v", err)
+	}
+
+	if public_url != "https://test.example.com" {
+		t.Fatalf("Unexpected public URL '%s'", public_url)
+	}
+
+	err = p.stop()
+
+	if err != nil {
+		t.Fatalf("Failed to stop process, %v", err)
+	}
+
+	err = p.stop()
+
+	if err != nil {
+		t.Fatalf("Failed to stop process a second time, %v", err)
+	}
+
+	// Processes that exit, or never report a URL, fail
+
+	path = writeScript(t, "echo starting")
+
+	_, _, err = startProcess(ctx, path, nil, nil, time.Second*5, match)
+
+	if err == nil {
+		t.Fatalf("Expected process that exits to fail")
+	}
+
+	path = writeScript(t, "exec sleep 60")
+
+	_, _, err = startProcess(ctx, path, nil, nil, time.Millisecond*100, match)
+
+	if err == nil {
+		t.Fatalf("Expected process that times out to fail")
+	}
+
+	_, _, err = startProcess(ctx, filepath.Join(t.TempDir(), "missing"), nil, nil, time.Second, match)
+
+	if err == nil {
+		t.Fatalf("Expected missing program to fail")
+	}
+}
@@ -0,0 +1,167 @@
+package usage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DEFAULT_FILE_FLUSH_INTERVAL is the default minimum interval between writes of a `FileStore` to disk.
+const DEFAULT_FILE_FLUSH_INTERVAL time.Duration = 10 * time.Second
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterStore(ctx, "file", NewFileStore)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// FileStore implements the `Store` interface for recording usage in memory and persisting it, as JSON, to a local file.
+type FileStore struct {
+	*MemoryStore
+	// path is the path of the file that usage is persisted to.
+	path string
+	// flush_interval is the minimum interval between writes to 'path'.
+	flush_interval time.Duration
+	// flushed is the time that usage was last written to 'path'.
+	flushed time.Time
+}
+
+// NewFileStore returns a new `FileStore` instance configured by 'uri' in the form of:
+//
+//	file://{PATH}?{PARAMETERS}
+//
+// Where {PATH} is the path of a JSON file that usage is persisted to. If it exists its usage is loaded when the store is created.
+// Valid {PARAMETERS} are:
+// * `flush_interval={DURATION}` The minimum interval between writes to {PATH}. If 0 usage is written after every change. Default
+// is "10s".
+//
+// Usage recorded since the last write is persisted when the store is closed.
+func NewFileStore(ctx context.Context, uri string) (Store, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	if u.Path == "" {
+		return nil, fmt.Errorf("Missing path")
+	}
+
+	flush_interval := DEFAULT_FILE_FLUSH_INTERVAL
+
+	str_interval := u.Query().Get("flush_interval")
+
+	if str_interval != "" {
+
+		v, err := time.ParseDuration(str_interval)
+
+		if err != nil || v < 0 {
+			return nil, fmt.Errorf("Invalid ?flush_interval= parameter '%s'", str_interval)
+		}
+
+		flush_interval = v
+	}
+
+	m, _ := NewMemoryStore(ctx, "memory://")
+
+	s := &FileStore{
+		MemoryStore:    m.(*MemoryStore),
+		path:           u.Path,
+		flush_interval: flush_interval,
+		flushed:        time.Now(),
+	}
+
+	body, err := os.ReadFile(s.path)
+
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		// pass
+	case err != nil:
+		return nil, fmt.Errorf("Failed to read usage file, %w", err)
+	default:
+
+		err = json.Unmarshal(body, &s.usage)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to decode usage file, %w", err)
+		}
+	}
+
+	return s, nil
+}
+
+// Add adds 'u' to the usage for 'key' in each of 'periods' and, if the flush interval has elapsed, writes all the usage for 's'
+// to disk.
+func (s *FileStore) Add(ctx context.Context, key string, u *Usage, periods ...string) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.add(key, u, periods...)
+
+	if time.Since(s.flushed) < s.flush_interval {
+		return nil
+	}
+
+	return s.write()
+}
+
+// Close writes all the usage for 's' to disk.
+func (s *FileStore) Close(ctx context.Context) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.write()
+}
+
+// write atomically replaces the file for 's' with its usage. Callers are expected to hold the lock for 's'.
+func (s *FileStore) write() error {
+
+	enc, err := json.Marshal(s.usage)
+
+	if err != nil {
+		return fmt.Errorf("Failed to encode usage, %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".usage-*.json")
+
+	if err != nil {
+		return fmt.Errorf("Failed to create temporary file, %w", err)
+	}
+
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(enc)
+
+	if err != nil {
+		tmp.Close()
+		return fmt.Errorf("Failed to write usage, %w", err)
+	}
+
+	err = tmp.Close()
+
+	if err != nil {
+		return fmt.Errorf("Failed to close temporary file, %w", err)
+	}
+
+	err = os.Rename(tmp.Name(), s.path)
+
+	if err != nil {
+		return fmt.Errorf("Failed to replace usage file, %w", err)
+	}
+
+	s.flushed = time.Now()
+	return nil
+}
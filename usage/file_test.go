@@ -0,0 +1,113 @@
+package usage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileStore(t *testing.T) {
+
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "usage.json")
+	uri := fmt.Sprintf("file://%s?flush_interval=1h", path)
+
+	s, err := NewStore(ctx, uri)
+
+	if err != nil {
+		t.Fatalf("Failed to create new store, %v", err)
+	}
+
+	key := EndpointKey("/foo")
+
+	err = s.Add(ctx, key, &Usage{Deliveries: 1, Bytes: 10, Dispatches: 1}, "2024-01-31", "2024-01")
+
+	if err != nil {
+		t.Fatalf("Failed to add usage, %v", err)
+	}
+
+	_, err = os.Stat(path)
+
+	if !os.IsNotExist(err) {
+		t.Fatalf("Expected usage not to be written before the flush interval")
+	}
+
+	err = s.Close(ctx)
+
+	if err != nil {
+		t.Fatalf("Failed to close store, %v", err)
+	}
+
+	s, err = NewStore(ctx, uri)
+
+	if err != nil {
+		t.Fatalf("Failed to reopen store, %v", err)
+	}
+
+	u, err := s.Get(ctx, key, "2024-01")
+
+	if err != nil {
+		t.Fatalf("Failed to get usage, %v", err)
+	}
+
+	if u.Deliveries != 1 || u.Bytes != 10 || u.Dispatches != 1 {
+		t.Fatalf("Unexpected usage, %v", u)
+	}
+}
+
+func TestFileStoreFlush(t *testing.T) {
+
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "usage.json")
+	uri := fmt.Sprintf("file://%s?flush_interval=0s", path)
+
+	s, err := NewStore(ctx, uri)
+
+	if err != nil {
+		t.Fatalf("Failed to create new store, %v", err)
+	}
+
+	err = s.Add(ctx, TenantKey("acme"), &Usage{Deliveries: 1}, "2024-01")
+
+	if err != nil {
+		t.Fatalf("Failed to add usage, %v", err)
+	}
+
+	_, err = os.Stat(path)
+
+	if err != nil {
+		t.Fatalf("Expected usage to be written, %v", err)
+	}
+}
+
+func TestFileStoreInvalid(t *testing.T) {
+
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "usage.json")
+
+	err := os.WriteFile(path, []byte("{"), 0644)
+
+	if err != nil {
+		t.Fatalf("Failed to write file, %v", err)
+	}
+
+	tests := []string{
+		"file://",
+		fmt.Sprintf("file://%s?flush_interval=bogus", path),
+		fmt.Sprintf("file://%s", path),
+	}
+
+	for _, uri := range tests {
+
+		_, err := NewStore(ctx, uri)
+
+		if err == nil {
+			t.Fatalf("Expected %s to fail", uri)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package usage
+
+import (
+	"context"
+	"sync"
+)
+
+func init() {
+
+	ctx := context.Background()
+	err := RegisterStore(ctx, "memory", NewMemoryStore)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// MemoryStore implements the `Store` interface for recording usage in memory. Usage is lost when the process exits.
+type MemoryStore struct {
+	Store
+	// usage is a dictionary of keys and a dictionary of periods and their usage.
+	usage map[string]map[string]*Usage
+	// mu is a `sync.RWMutex` used to guard 'usage'.
+	mu *sync.RWMutex
+}
+
+// NewMemoryStore returns a new `MemoryStore` instance configured by 'uri' in the form of:
+//
+//	memory://
+func NewMemoryStore(ctx context.Context, uri string) (Store, error) {
+
+	s := &MemoryStore{
+		usage: make(map[string]map[string]*Usage),
+		mu:    new(sync.RWMutex),
+	}
+
+	return s, nil
+}
+
+// Add adds 'u' to the usage for 'key' in each of 'periods'.
+func (s *MemoryStore) Add(ctx context.Context, key string, u *Usage, periods ...string) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.add(key, u, periods...)
+	return nil
+}
+
+// Get returns the usage for 'key' in 'period'.
+func (s *MemoryStore) Get(ctx context.Context, key string, period string) (*Usage, error) {
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	u := new(Usage)
+
+	total, ok := s.usage[key][period]
+
+	if ok {
+		*u = *total
+	}
+
+	return u, nil
+}
+
+// Close is a no-op.
+func (s *MemoryStore) Close(ctx context.Context) error {
+	return nil
+}
+
+// add adds 'u' to the usage for 'key' in each of 'periods'. Callers are expected to hold the lock for 's'.
+func (s *MemoryStore) add(key string, u *Usage, periods ...string) {
+
+	by_period, ok := s.usage[key]
+
+	if !ok {
+		by_period = make(map[string]*Usage)
+		s.usage[key] = by_period
+	}
+
+	for _, p := range periods {
+
+		total, ok := by_period[p]
+
+		if !ok {
+			total = new(Usage)
+			by_period[p] = total
+		}
+
+		total.add(u)
+	}
+}
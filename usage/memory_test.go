@@ -0,0 +1,64 @@
+package usage
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMemoryStore(t *testing.T) {
+
+	ctx := context.Background()
+
+	s, err := NewStore(ctx, "memory://")
+
+	if err != nil {
+		t.Fatalf("Failed to create new store, %v", err)
+	}
+
+	key := EndpointKey("/foo")
+
+	for i := 0; i < 2; i++ {
+
+		err := s.Add(ctx, key, &Usage{Deliveries: 1, Bytes: 10, Dispatches: 2}, "2024-01-31", "2024-01")
+
+		if err != nil {
+			t.Fatalf("Failed to add usage, %v", err)
+		}
+	}
+
+	err = s.Add(ctx, key, &Usage{Deliveries: 1, Bytes: 5}, "2024-01-30", "2024-01")
+
+	if err != nil {
+		t.Fatalf("Failed to add usage, %v", err)
+	}
+
+	tests := map[string]Usage{
+		"2024-01-31": {Deliveries: 2, Bytes: 20, Dispatches: 4},
+		"2024-01-30": {Deliveries: 1, Bytes: 5},
+		"2024-01":    {Deliveries: 3, Bytes: 25, Dispatches: 4},
+		"2024-02":    {},
+	}
+
+	for period, expected := range tests {
+
+		u, err := s.Get(ctx, key, period)
+
+		if err != nil {
+			t.Fatalf("Failed to get usage for %s, %v", period, err)
+		}
+
+		if *u != expected {
+			t.Fatalf("Unexpected usage for %s, %v", period, u)
+		}
+	}
+
+	u, err := s.Get(ctx, TenantKey("acme"), "2024-01")
+
+	if err != nil {
+		t.Fatalf("Failed to get usage, %v", err)
+	}
+
+	if u.Deliveries != 0 {
+		t.Fatalf("Unexpected usage for unknown key, %v", u)
+	}
+}
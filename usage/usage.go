@@ -0,0 +1,146 @@
+// Package usage provides methods for recording, and retrieving, the number of webhook messages delivered, and bytes and dispatches
+// processed, over time.
+package usage
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/aaronland/go-roster"
+	"github.com/whosonfirst/go-webhookd/v3/secrets"
+)
+
+// Usage is the usage recorded for a single key (for example a webhook endpoint) over a single period.
+type Usage struct {
+	// Deliveries is the number of messages delivered.
+	Deliveries int64 `json:"deliveries"`
+	// Bytes is the total size, in bytes, of the messages delivered.
+	Bytes int64 `json:"bytes"`
+	// Dispatches is the number of times messages were relayed to a dispatcher successfully.
+	Dispatches int64 `json:"dispatches"`
+}
+
+// Store is an interface for recording, and retrieving, usage.
+type Store interface {
+	// Add adds the values of a `Usage` instance to the usage recorded for a key in each of one or more periods.
+	Add(context.Context, string, *Usage, ...string) error
+	// Get returns the usage recorded for a key in a period. If no usage has been recorded a zero-value `Usage` is returned.
+	Get(context.Context, string, string) (*Usage, error)
+	// Close persists any usage that has not been written yet and releases any resources held by the store.
+	Close(context.Context) error
+}
+
+// stores is a `aaronland/go-roster.Roster` instance used to maintain a list of registered `Store` initialization functions.
+var stores roster.Roster
+
+// StoreInitializationFunc is a function used to initialize an implementation of the `Store` interface.
+type StoreInitializationFunc func(ctx context.Context, uri string) (Store, error)
+
+// NewStore() returns a new `Store` instance derived from 'uri'. The semantics of and requirements for 'uri' as specific to the
+// package implementing the interface.
+func NewStore(ctx context.Context, uri string) (Store, error) {
+
+	err := ensureStoreRoster()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to ensure store roster, %w", err)
+	}
+
+	uri, err = secrets.ResolveURI(ctx, uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to resolve secrets, %w", err)
+	}
+
+	parsed, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	scheme := parsed.Scheme
+
+	i, err := stores.Driver(ctx, scheme)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to find initialization function for '%s', %w", scheme, err)
+	}
+
+	init_func := i.(StoreInitializationFunc)
+	return init_func(ctx, uri)
+}
+
+// RegisterStore() associates 'scheme' with 'init_func' in an internal list of avilable `Store` implementations.
+func RegisterStore(ctx context.Context, scheme string, init_func StoreInitializationFunc) error {
+
+	err := ensureStoreRoster()
+
+	if err != nil {
+		return fmt.Errorf("Failed to ensure store roster, %w", err)
+	}
+
+	return stores.Register(ctx, scheme, init_func)
+}
+
+// ensureStoreRoster() ensures that a `aaronland/go-roster.Roster` instance used to maintain a list of registered `Store`
+// initialization functions is present
+func ensureStoreRoster() error {
+
+	if stores == nil {
+
+		r, err := roster.NewDefaultRoster()
+
+		if err != nil {
+			return fmt.Errorf("Failed to create new roster, %w", err)
+		}
+
+		stores = r
+	}
+
+	return nil
+}
+
+// Schemes() returns the list of schemes that have been "registered".
+func Schemes() []string {
+	ctx := context.Background()
+	drivers := stores.Drivers(ctx)
+
+	schemes := make([]string, len(drivers))
+
+	for idx, dr := range drivers {
+		schemes[idx] = fmt.Sprintf("%s://", dr)
+	}
+
+	sort.Strings(schemes)
+	return schemes
+}
+
+// DailyPeriod returns the (UTC) daily period, in the form of "YYYY-MM-DD", for 't'.
+func DailyPeriod(t time.Time) string {
+	return t.UTC().Format("2006-01-02")
+}
+
+// MonthlyPeriod returns the (UTC) monthly period, in the form of "YYYY-MM", for 't'.
+func MonthlyPeriod(t time.Time) string {
+	return t.UTC().Format("2006-01")
+}
+
+// EndpointKey returns the key used to record usage for the webhook with 'endpoint'.
+func EndpointKey(endpoint string) string {
+	return "endpoint:" + endpoint
+}
+
+// TenantKey returns the key used to record usage for the webhooks belonging to 'tenant'.
+func TenantKey(tenant string) string {
+	return "tenant:" + tenant
+}
+
+// add adds the values of 'u' to 'total'.
+func (total *Usage) add(u *Usage) {
+	total.Deliveries += u.Deliveries
+	total.Bytes += u.Bytes
+	total.Dispatches += u.Dispatches
+}
@@ -0,0 +1,38 @@
+package usage
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestNewStore(t *testing.T) {
+
+	ctx := context.Background()
+
+	_, err := NewStore(ctx, "memory://")
+
+	if err != nil {
+		t.Fatalf("Failed to create new store, %v", err)
+	}
+
+	_, err = NewStore(ctx, "bogus://")
+
+	if err == nil {
+		t.Fatalf("Expected unregistered scheme to fail")
+	}
+}
+
+func TestPeriods(t *testing.T) {
+
+	loc := time.FixedZone("test", -8*60*60)
+	now := time.Date(2024, time.January, 31, 20, 0, 0, 0, loc)
+
+	if DailyPeriod(now) != "2024-02-01" {
+		t.Fatalf("Unexpected daily period, %s", DailyPeriod(now))
+	}
+
+	if MonthlyPeriod(now) != "2024-02" {
+		t.Fatalf("Unexpected monthly period, %s", MonthlyPeriod(now))
+	}
+}
@@ -0,0 +1,277 @@
+package webhook
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// DEFAULT_CORS_HEADERS is the list of request headers a `CORS` policy allows if no other headers are defined.
+var DEFAULT_CORS_HEADERS = []string{"Content-Type"}
+
+// CORS is a struct defining the cross-origin resource sharing (CORS) policy for a webhook, used when browser-based tools send
+// requests to a webhook directly.
+type CORS struct {
+	// origins is the dictionary of allowed origins.
+	origins map[string]bool
+	// any_origin is a boolean flag signaling that requests from any origin are allowed.
+	any_origin bool
+	// methods is the optional list of allowed methods. If empty the methods accepted by the webhook are allowed.
+	methods []string
+	// headers is the dictionary of allowed request headers, keyed by their canonical name.
+	headers map[string]bool
+	// any_header is a boolean flag signaling that any request headers are allowed.
+	any_header bool
+	// allow_headers is the value of the "Access-Control-Allow-Headers" header when 'any_header' is false.
+	allow_headers string
+	// expose_headers is the value of the "Access-Control-Expose-Headers" header.
+	expose_headers string
+	// max_age is the value of the "Access-Control-Max-Age" header.
+	max_age string
+}
+
+// CORSOptions is a struct containing the options for `NewCORS`.
+type CORSOptions struct {
+	// Origins is the list of origins, for example "https://tools.example.com", that are allowed to send requests. "*" allows
+	// requests from any origin. Required.
+	Origins []string
+	// Methods is the optional list of HTTP methods that browsers are allowed to use. If empty the methods accepted by the
+	// webhook are allowed.
+	Methods []string
+	// Headers is the optional list of request headers that browsers are allowed to send. "*" allows any headers. If empty then
+	// `DEFAULT_CORS_HEADERS` is used.
+	Headers []string
+	// ExposeHeaders is the optional list of response headers that browsers are allowed to read, in addition to the CORS-safelisted
+	// response headers.
+	ExposeHeaders []string
+	// MaxAge is the optional number of seconds that browsers may cache the results of a preflight request for.
+	MaxAge int
+}
+
+// NewCORS returns a new `CORS` instance configured by 'opts'.
+func NewCORS(opts *CORSOptions) (*CORS, error) {
+
+	if len(opts.Origins) == 0 {
+		return nil, fmt.Errorf("CORS policies must define at least one origin")
+	}
+
+	if opts.MaxAge < 0 {
+		return nil, fmt.Errorf("Invalid CORS max age, %d", opts.MaxAge)
+	}
+
+	c := &CORS{
+		origins: make(map[string]bool),
+		headers: make(map[string]bool),
+	}
+
+	for _, o := range opts.Origins {
+
+		o = strings.TrimSpace(o)
+
+		if o == "*" {
+			c.any_origin = true
+			continue
+		}
+
+		u, err := url.Parse(o)
+
+		if err != nil || u.Scheme == "" || u.Host == "" || (u.Path != "" && u.Path != "/") || u.RawQuery != "" {
+			return nil, fmt.Errorf("Invalid CORS origin '%s'", o)
+		}
+
+		c.origins[strings.ToLower(u.Scheme+"://"+u.Host)] = true
+	}
+
+	seen := make(map[string]bool)
+
+	for _, m := range opts.Methods {
+
+		m = strings.ToUpper(strings.TrimSpace(m))
+
+		if m == "" || seen[m] {
+			continue
+		}
+
+		seen[m] = true
+		c.methods = append(c.methods, m)
+	}
+
+	headers := opts.Headers
+
+	if len(headers) == 0 {
+		headers = DEFAULT_CORS_HEADERS
+	}
+
+	allow_headers := make([]string, 0)
+
+	for _, h := range headers {
+
+		h = strings.TrimSpace(h)
+
+		if h == "*" {
+			c.any_header = true
+			continue
+		}
+
+		if h == "" {
+			return nil, fmt.Errorf("CORS headers can not be empty")
+		}
+
+		h = http.CanonicalHeaderKey(h)
+
+		if c.headers[h] {
+			continue
+		}
+
+		c.headers[h] = true
+		allow_headers = append(allow_headers, h)
+	}
+
+	c.allow_headers = strings.Join(allow_headers, ", ")
+
+	expose_headers := make([]string, 0)
+
+	for _, h := range opts.ExposeHeaders {
+
+		h = strings.TrimSpace(h)
+
+		if h == "" {
+			return nil, fmt.Errorf("CORS expose headers can not be empty")
+		}
+
+		expose_headers = append(expose_headers, http.CanonicalHeaderKey(h))
+	}
+
+	c.expose_headers = strings.Join(expose_headers, ", ")
+
+	if opts.MaxAge > 0 {
+		c.max_age = strconv.Itoa(opts.MaxAge)
+	}
+
+	return c, nil
+}
+
+// AllowsOrigin returns a boolean value indicating whether 'c' allows requests from 'origin'.
+func (c *CORS) AllowsOrigin(origin string) bool {
+
+	if origin == "" {
+		return false
+	}
+
+	return c.any_origin || c.origins[strings.ToLower(origin)]
+}
+
+// IsPreflight returns a boolean value indicating whether 'req' is a CORS preflight request.
+func IsPreflight(req *http.Request) bool {
+	return req.Method == http.MethodOptions && req.Header.Get("Origin") != "" && req.Header.Get("Access-Control-Request-Method") != ""
+}
+
+// WriteHeaders adds the CORS headers for the (non-preflight) request 'req' to 'rsp' if its origin is allowed by 'c'.
+func (c *CORS) WriteHeaders(rsp http.ResponseWriter, req *http.Request) {
+
+	origin := req.Header.Get("Origin")
+
+	h := rsp.Header()
+	c.setOrigin(h, origin)
+
+	if !c.AllowsOrigin(origin) {
+		return
+	}
+
+	if c.expose_headers != "" {
+		h.Set("Access-Control-Expose-Headers", c.expose_headers)
+	}
+}
+
+// WritePreflight writes the response to the CORS preflight request 'req' to 'rsp'. Preflight requests for an origin, method or
+// headers that are not allowed by 'c' receive a 403 Forbidden response. 'allow' is the list of HTTP methods accepted by the
+// webhook and is used if 'c' does not define its own methods.
+func (c *CORS) WritePreflight(rsp http.ResponseWriter, req *http.Request, allow []string) {
+
+	origin := req.Header.Get("Origin")
+
+	h := rsp.Header()
+	c.setOrigin(h, origin)
+	h.Add("Vary", "Access-Control-Request-Method")
+	h.Add("Vary", "Access-Control-Request-Headers")
+
+	if !c.AllowsOrigin(origin) {
+		http.Error(rsp, "403 Forbidden", http.StatusForbidden)
+		return
+	}
+
+	methods := c.methods
+
+	if len(methods) == 0 {
+		methods = allow
+	}
+
+	method := req.Header.Get("Access-Control-Request-Method")
+	allowed := false
+
+	for _, m := range methods {
+
+		if m == method {
+			allowed = true
+			break
+		}
+	}
+
+	if !allowed {
+		http.Error(rsp, "403 Forbidden", http.StatusForbidden)
+		return
+	}
+
+	requested := req.Header.Get("Access-Control-Request-Headers")
+
+	if !c.any_header {
+
+		for _, name := range strings.Split(requested, ",") {
+
+			name = strings.TrimSpace(name)
+
+			if name != "" && !c.headers[http.CanonicalHeaderKey(name)] {
+				http.Error(rsp, "403 Forbidden", http.StatusForbidden)
+				return
+			}
+		}
+	}
+
+	h.Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+
+	switch {
+	case c.any_header && requested != "":
+		h.Set("Access-Control-Allow-Headers", requested)
+	case !c.any_header && c.allow_headers != "":
+		h.Set("Access-Control-Allow-Headers", c.allow_headers)
+	}
+
+	if c.max_age != "" {
+		h.Set("Access-Control-Max-Age", c.max_age)
+	}
+
+	rsp.WriteHeader(http.StatusNoContent)
+}
+
+// setOrigin sets the "Access-Control-Allow-Origin" header in 'h' if 'origin' is allowed by 'c'.
+func (c *CORS) setOrigin(h http.Header, origin string) {
+
+	// Responses vary by origin unless every origin is allowed
+
+	if !c.any_origin {
+		h.Add("Vary", "Origin")
+	}
+
+	if !c.AllowsOrigin(origin) {
+		return
+	}
+
+	if c.any_origin {
+		h.Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+
+	h.Set("Access-Control-Allow-Origin", origin)
+}
@@ -0,0 +1,162 @@
+package webhook
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewCORS(t *testing.T) {
+
+	opts := &CORSOptions{
+		Origins:       []string{"https://tools.example.com"},
+		Headers:       []string{"content-type", "Authorization"},
+		ExposeHeaders: []string{"x-webhookd-delivery-id"},
+		MaxAge:        600,
+	}
+
+	c, err := NewCORS(opts)
+
+	if err != nil {
+		t.Fatalf("Failed to create new CORS policy, %v", err)
+	}
+
+	if !c.AllowsOrigin("https://TOOLS.example.com") || c.AllowsOrigin("https://evil.example.com") || c.AllowsOrigin("") {
+		t.Fatalf("Unexpected allowed origins")
+	}
+
+	tests := []struct {
+		origin  string
+		method  string
+		headers string
+		status  int
+		allow   string
+	}{
+		{"https://tools.example.com", "POST", "Content-Type, authorization", http.StatusNoContent, "https://tools.example.com"},
+		{"https://tools.example.com", "POST", "", http.StatusNoContent, "https://tools.example.com"},
+		{"https://tools.example.com", "DELETE", "", http.StatusForbidden, "https://tools.example.com"},
+		{"https://tools.example.com", "POST", "X-Custom", http.StatusForbidden, "https://tools.example.com"},
+		{"https://evil.example.com", "POST", "", http.StatusForbidden, ""},
+	}
+
+	for idx, test := range tests {
+
+		req := httptest.NewRequest(http.MethodOptions, "/", nil)
+		req.Header.Set("Origin", test.origin)
+		req.Header.Set("Access-Control-Request-Method", test.method)
+
+		if test.headers != "" {
+			req.Header.Set("Access-Control-Request-Headers", test.headers)
+		}
+
+		if !IsPreflight(req) {
+			t.Fatalf("Expected test %d to be a preflight request", idx)
+		}
+
+		rec := httptest.NewRecorder()
+
+		c.WritePreflight(rec, req, []string{"POST"})
+
+		if rec.Code != test.status {
+			t.Fatalf("Unexpected status for test %d: %d", idx, rec.Code)
+		}
+
+		if rec.Header().Get("Access-Control-Allow-Origin") != test.allow {
+			t.Fatalf("Unexpected Access-Control-Allow-Origin header for test %d: '%s'", idx, rec.Header().Get("Access-Control-Allow-Origin"))
+		}
+
+		if test.status != http.StatusNoContent {
+			continue
+		}
+
+		if rec.Header().Get("Access-Control-Allow-Methods") != "POST" {
+			t.Fatalf("Unexpected Access-Control-Allow-Methods header for test %d: '%s'", idx, rec.Header().Get("Access-Control-Allow-Methods"))
+		}
+
+		if rec.Header().Get("Access-Control-Allow-Headers") != "Content-Type, Authorization" {
+			t.Fatalf("Unexpected Access-Control-Allow-Headers header for test %d: '%s'", idx, rec.Header().Get("Access-Control-Allow-Headers"))
+		}
+
+		if rec.Header().Get("Access-Control-Max-Age") != "600" {
+			t.Fatalf("Unexpected Access-Control-Max-Age header for test %d: '%s'", idx, rec.Header().Get("Access-Control-Max-Age"))
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set("Origin", "https://tools.example.com")
+
+	if IsPreflight(req) {
+		t.Fatalf("Expected POST request not to be a preflight request")
+	}
+
+	rec := httptest.NewRecorder()
+
+	c.WriteHeaders(rec, req)
+
+	if rec.Header().Get("Access-Control-Allow-Origin") != "https://tools.example.com" {
+		t.Fatalf("Unexpected Access-Control-Allow-Origin header: '%s'", rec.Header().Get("Access-Control-Allow-Origin"))
+	}
+
+	if rec.Header().Get("Access-Control-Expose-Headers") != "X-Webhookd-Delivery-Id" {
+		t.Fatalf("Unexpected Access-Control-Expose-Headers header: '%s'", rec.Header().Get("Access-Control-Expose-Headers"))
+	}
+
+	if rec.Header().Get("Vary") != "Origin" {
+		t.Fatalf("Unexpected Vary header: '%s'", rec.Header().Get("Vary"))
+	}
+}
+
+func TestNewCORSAnyOrigin(t *testing.T) {
+
+	c, err := NewCORS(&CORSOptions{Origins: []string{"*"}, Headers: []string{"*"}, Methods: []string{"post", "put"}})
+
+	if err != nil {
+		t.Fatalf("Failed to create new CORS policy, %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodOptions, "/", nil)
+	req.Header.Set("Origin", "https://anywhere.example.com")
+	req.Header.Set("Access-Control-Request-Method", "PUT")
+	req.Header.Set("Access-Control-Request-Headers", "X-Custom")
+
+	rec := httptest.NewRecorder()
+
+	c.WritePreflight(rec, req, []string{"POST"})
+
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("Unexpected status: %d", rec.Code)
+	}
+
+	if rec.Header().Get("Access-Control-Allow-Origin") != "*" {
+		t.Fatalf("Unexpected Access-Control-Allow-Origin header: '%s'", rec.Header().Get("Access-Control-Allow-Origin"))
+	}
+
+	if rec.Header().Get("Access-Control-Allow-Methods") != "POST, PUT" {
+		t.Fatalf("Unexpected Access-Control-Allow-Methods header: '%s'", rec.Header().Get("Access-Control-Allow-Methods"))
+	}
+
+	if rec.Header().Get("Access-Control-Allow-Headers") != "X-Custom" {
+		t.Fatalf("Unexpected Access-Control-Allow-Headers header: '%s'", rec.Header().Get("Access-Control-Allow-Headers"))
+	}
+}
+
+func TestNewCORSInvalid(t *testing.T) {
+
+	tests := []*CORSOptions{
+		{},
+		{Origins: []string{"tools.example.com"}},
+		{Origins: []string{"https://tools.example.com/path"}},
+		{Origins: []string{"*"}, MaxAge: -1},
+		{Origins: []string{"*"}, Headers: []string{""}},
+		{Origins: []string{"*"}, ExposeHeaders: []string{" "}},
+	}
+
+	for idx, opts := range tests {
+
+		_, err := NewCORS(opts)
+
+		if err == nil {
+			t.Fatalf("Expected CORS options at offset %d to fail", idx)
+		}
+	}
+}
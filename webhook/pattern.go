@@ -0,0 +1,178 @@
+package webhook
+
+import (
+	"fmt"
+	"strings"
+)
+
+// WILDCARD is the path parameter name used to store the remainder of a path matched by a trailing "*" segment.
+const WILDCARD string = "*"
+
+// EndpointPattern is a struct for matching relative URIs against an endpoint containing named "{parameter}" segments
+// or a trailing wildcard segment, for example "/repos/{owner}/{repo}" or "/hooks/*".
+type EndpointPattern struct {
+	// endpoint is the original endpoint string the pattern was derived from.
+	endpoint string
+	// segments is the list of path segments (literal values or parameter names) in the pattern.
+	segments []string
+	// params is a list of boolean flags indicating whether the corresponding segment is a named parameter.
+	params []bool
+	// rest is the name of the parameter used to capture the remainder of a path, if the pattern ends in a wildcard segment.
+	rest string
+}
+
+// IsEndpointPattern returns a boolean value indicating whether 'endpoint' contains any named "{parameter}" or wildcard segments.
+func IsEndpointPattern(endpoint string) bool {
+	return strings.Contains(endpoint, "{") || strings.HasSuffix(endpoint, "/*") || endpoint == "*"
+}
+
+// NewEndpointPattern returns a new `EndpointPattern` instance derived from 'endpoint'. Segments in the form of "{name}" match
+// exactly one path segment. A final segment in the form of "{name...}" or "*" matches the remainder of a path (including
+// zero segments) which is stored as the "name" or `WILDCARD` parameter respectively.
+func NewEndpointPattern(endpoint string) (*EndpointPattern, error) {
+
+	p := &EndpointPattern{
+		endpoint: endpoint,
+		segments: make([]string, 0),
+		params:   make([]bool, 0),
+	}
+
+	parts := splitPath(endpoint)
+	seen := make(map[string]bool)
+
+	for idx, part := range parts {
+
+		is_last := idx == len(parts)-1
+
+		switch {
+		case part == "*":
+
+			if !is_last {
+				return nil, fmt.Errorf("Wildcard segment must be the last segment in '%s'", endpoint)
+			}
+
+			p.rest = WILDCARD
+
+		case strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}"):
+
+			name := strings.TrimSuffix(strings.TrimPrefix(part, "{"), "}")
+			is_rest := strings.HasSuffix(name, "...")
+
+			if is_rest {
+				name = strings.TrimSuffix(name, "...")
+			}
+
+			if name == "" {
+				return nil, fmt.Errorf("Empty parameter name in '%s'", endpoint)
+			}
+
+			if seen[name] {
+				return nil, fmt.Errorf("Duplicate parameter name '%s' in '%s'", name, endpoint)
+			}
+
+			seen[name] = true
+
+			if is_rest {
+
+				if !is_last {
+					return nil, fmt.Errorf("Parameter '%s' must be the last segment in '%s'", name, endpoint)
+				}
+
+				p.rest = name
+				continue
+			}
+
+			p.segments = append(p.segments, name)
+			p.params = append(p.params, true)
+
+		case strings.ContainsAny(part, "{}"):
+			return nil, fmt.Errorf("Invalid segment '%s' in '%s'", part, endpoint)
+
+		default:
+			p.segments = append(p.segments, part)
+			p.params = append(p.params, false)
+		}
+	}
+
+	return p, nil
+}
+
+// Endpoint returns the original endpoint string that 'p' was derived from.
+func (p *EndpointPattern) Endpoint() string {
+	return p.endpoint
+}
+
+// Match returns the path parameters matched by 'path' and a boolean value indicating whether 'path' matched 'p' at all.
+func (p *EndpointPattern) Match(path string) (map[string]string, bool) {
+
+	parts := splitPath(path)
+
+	if len(parts) < len(p.segments) {
+		return nil, false
+	}
+
+	if len(parts) > len(p.segments) && p.rest == "" {
+		return nil, false
+	}
+
+	params := make(map[string]string)
+
+	for idx, seg := range p.segments {
+
+		if p.params[idx] {
+
+			if parts[idx] == "" {
+				return nil, false
+			}
+
+			params[seg] = parts[idx]
+			continue
+		}
+
+		if parts[idx] != seg {
+			return nil, false
+		}
+	}
+
+	if p.rest != "" {
+		params[p.rest] = strings.Join(parts[len(p.segments):], "/")
+	}
+
+	return params, true
+}
+
+// Specificity returns a score used to order patterns so that more specific patterns are tested first. Literal
+// segments score higher than parameters and patterns ending in a wildcard score lower than those that don't.
+func (p *EndpointPattern) Specificity() int {
+
+	score := 0
+
+	for _, is_param := range p.params {
+
+		if is_param {
+			score += 1
+		} else {
+			score += 2
+		}
+	}
+
+	score = score * 2
+
+	if p.rest == "" {
+		score += 1
+	}
+
+	return score
+}
+
+// splitPath splits 'path' in to its segments ignoring leading and trailing slashes.
+func splitPath(path string) []string {
+
+	path = strings.Trim(path, "/")
+
+	if path == "" {
+		return []string{}
+	}
+
+	return strings.Split(path, "/")
+}
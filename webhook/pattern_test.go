@@ -0,0 +1,68 @@
+package webhook
+
+import (
+	"testing"
+)
+
+func TestEndpointPattern(t *testing.T) {
+
+	tests := []struct {
+		endpoint string
+		path     string
+		match    bool
+		params   map[string]string
+	}{
+		{"/repos/{owner}/{repo}", "/repos/whosonfirst/go-webhookd", true, map[string]string{"owner": "whosonfirst", "repo": "go-webhookd"}},
+		{"/repos/{owner}/{repo}", "/repos/whosonfirst", false, nil},
+		{"/repos/{owner}/{repo}", "/repos/whosonfirst/go-webhookd/issues", false, nil},
+		{"/repos/{owner}/{repo}", "/users/whosonfirst/go-webhookd", false, nil},
+		{"/hooks/*", "/hooks/a/b/c", true, map[string]string{"*": "a/b/c"}},
+		{"/hooks/*", "/hooks", true, map[string]string{"*": ""}},
+		{"/files/{path...}", "/files/a/b.txt", true, map[string]string{"path": "a/b.txt"}},
+	}
+
+	for _, test := range tests {
+
+		p, err := NewEndpointPattern(test.endpoint)
+
+		if err != nil {
+			t.Fatalf("Failed to create pattern for '%s', %v", test.endpoint, err)
+		}
+
+		params, ok := p.Match(test.path)
+
+		if ok != test.match {
+			t.Fatalf("Unexpected match result for '%s' against '%s': %t", test.path, test.endpoint, ok)
+		}
+
+		for k, v := range test.params {
+
+			if params[k] != v {
+				t.Fatalf("Unexpected value for '%s' parameter matching '%s' against '%s': '%s'", k, test.path, test.endpoint, params[k])
+			}
+		}
+	}
+}
+
+func TestInvalidEndpointPattern(t *testing.T) {
+
+	for _, endpoint := range []string{"/hooks/*/foo", "/repos/{owner}/{owner}", "/repos/{}", "/repos/x{owner}", "/files/{path...}/foo"} {
+
+		_, err := NewEndpointPattern(endpoint)
+
+		if err == nil {
+			t.Fatalf("Expected '%s' to be an invalid pattern", endpoint)
+		}
+	}
+}
+
+func TestEndpointPatternSpecificity(t *testing.T) {
+
+	a, _ := NewEndpointPattern("/repos/{owner}/settings")
+	b, _ := NewEndpointPattern("/repos/{owner}/{repo}")
+	c, _ := NewEndpointPattern("/repos/*")
+
+	if !(a.Specificity() > b.Specificity() && b.Specificity() > c.Specificity()) {
+		t.Fatalf("Unexpected specificity ordering: %d, %d, %d", a.Specificity(), b.Specificity(), c.Specificity())
+	}
+}
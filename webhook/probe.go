@@ -0,0 +1,191 @@
+package webhook
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// DEFAULT_PROBE_METHODS is the list of HTTP methods a `Probe` answers if no other methods are defined.
+var DEFAULT_PROBE_METHODS = []string{http.MethodGet, http.MethodHead, http.MethodOptions}
+
+// Probe is a struct used to write a lightweight, precomputed HTTP response to requests that providers make to check that a
+// webhook endpoint exists (for example a HEAD request before the first delivery or an OPTIONS preflight request) rather than
+// passing them to the webhook's receiver.
+type Probe struct {
+	// methods is the list of HTTP methods the probe answers.
+	methods []string
+	// status is the HTTP status code for the response.
+	status int
+	// header is the list of HTTP headers for the response.
+	header http.Header
+	// body is the body of the response.
+	body []byte
+}
+
+// ProbeOptions is a struct containing the options for `NewProbe`.
+type ProbeOptions struct {
+	// Methods is the list of HTTP methods the probe answers. Valid options are "GET", "HEAD" and "OPTIONS". If empty then
+	// `DEFAULT_PROBE_METHODS` is used.
+	Methods []string
+	// Status is the HTTP status code for the response. Valid options are 200 and 204. If zero then 200 is used unless there is no
+	// body in which case 204 is used.
+	Status int
+	// Body is the optional body of the response.
+	Body string
+	// ContentType is the content type of the response body. If empty then `DEFAULT_RESPONSE_CONTENT_TYPE` is used.
+	ContentType string
+	// Headers is an optional dictionary of additional HTTP headers for the response.
+	Headers map[string]string
+	// MaxAge is the optional number of seconds that clients may cache the response for. If zero then the response is not cacheable.
+	MaxAge int
+}
+
+// NewProbe returns a new `Probe` instance configured by 'opts'. The response is rendered once, when the probe is created, and
+// written as-is to every probe request.
+func NewProbe(opts *ProbeOptions) (*Probe, error) {
+
+	methods := make([]string, 0)
+	seen := make(map[string]bool)
+
+	for _, m := range opts.Methods {
+
+		m = strings.ToUpper(strings.TrimSpace(m))
+
+		switch m {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			// pass
+		default:
+			return nil, fmt.Errorf("Invalid probe method '%s'", m)
+		}
+
+		if seen[m] {
+			continue
+		}
+
+		seen[m] = true
+		methods = append(methods, m)
+	}
+
+	if len(methods) == 0 {
+		methods = append(methods, DEFAULT_PROBE_METHODS...)
+	}
+
+	status := opts.Status
+
+	switch status {
+	case 0:
+
+		status = http.StatusOK
+
+		if opts.Body == "" {
+			status = http.StatusNoContent
+		}
+
+	case http.StatusOK:
+		// pass
+	case http.StatusNoContent:
+
+		if opts.Body != "" {
+			return nil, fmt.Errorf("A probe body can not be defined for status %d", status)
+		}
+
+	default:
+		return nil, fmt.Errorf("Invalid probe status %d", status)
+	}
+
+	if opts.MaxAge < 0 {
+		return nil, fmt.Errorf("Invalid probe max age, %d", opts.MaxAge)
+	}
+
+	header := make(http.Header)
+
+	for k, v := range opts.Headers {
+
+		if k == "" {
+			return nil, fmt.Errorf("Probe headers can not have empty names")
+		}
+
+		header.Set(k, v)
+	}
+
+	if opts.MaxAge > 0 {
+		header.Set("Cache-Control", fmt.Sprintf("public, max-age=%d", opts.MaxAge))
+	} else if header.Get("Cache-Control") == "" {
+		header.Set("Cache-Control", "no-store")
+	}
+
+	if opts.Body != "" {
+
+		content_type := opts.ContentType
+
+		if content_type == "" {
+			content_type = DEFAULT_RESPONSE_CONTENT_TYPE
+		}
+
+		header.Set("Content-Type", content_type)
+		header.Set("Content-Length", strconv.Itoa(len(opts.Body)))
+	}
+
+	p := &Probe{
+		methods: methods,
+		status:  status,
+		header:  header,
+		body:    []byte(opts.Body),
+	}
+
+	return p, nil
+}
+
+// Methods returns the list of HTTP methods that 'p' answers.
+func (p *Probe) Methods() []string {
+	return p.methods
+}
+
+// Handles returns a boolean value indicating whether 'p' answers requests with the HTTP method 'method'.
+func (p *Probe) Handles(method string) bool {
+
+	for _, m := range p.methods {
+
+		if m == method {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Status returns the HTTP status code for 'p'.
+func (p *Probe) Status() int {
+	return p.status
+}
+
+// Write writes the headers, status code and body for 'p' to 'rsp'. The body is omitted for "HEAD" requests. 'allow' is the list
+// of HTTP methods, included in the "Allow" header of responses to "OPTIONS" requests, accepted by the endpoint being probed.
+func (p *Probe) Write(rsp http.ResponseWriter, req *http.Request, allow []string) error {
+
+	h := rsp.Header()
+
+	for k, v := range p.header {
+		h[k] = v
+	}
+
+	if req.Method == http.MethodOptions {
+		h.Set("Allow", strings.Join(allow, ", "))
+	}
+
+	rsp.WriteHeader(p.status)
+
+	if req.Method == http.MethodHead || len(p.body) == 0 {
+		return nil
+	}
+
+	_, err := rsp.Write(p.body)
+
+	if err != nil {
+		return fmt.Errorf("Failed to write probe response, %w", err)
+	}
+
+	return nil
+}
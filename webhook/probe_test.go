@@ -0,0 +1,169 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewProbe(t *testing.T) {
+
+	opts := &ProbeOptions{
+		Body:        `{"ok":true}`,
+		ContentType: "application/json",
+		Headers:     map[string]string{"X-Probe": "webhookd"},
+		MaxAge:      60,
+	}
+
+	p, err := NewProbe(opts)
+
+	if err != nil {
+		t.Fatalf("Failed to create new probe, %v", err)
+	}
+
+	if p.Status() != http.StatusOK {
+		t.Fatalf("Unexpected status: %d", p.Status())
+	}
+
+	for _, m := range DEFAULT_PROBE_METHODS {
+
+		if !p.Handles(m) {
+			t.Fatalf("Expected probe to handle %s", m)
+		}
+	}
+
+	if p.Handles(http.MethodPost) {
+		t.Fatalf("Expected probe not to handle POST")
+	}
+
+	tests := []struct {
+		method string
+		body   string
+		allow  string
+	}{
+		{http.MethodGet, `{"ok":true}`, ""},
+		{http.MethodHead, "", ""},
+		{http.MethodOptions, `{"ok":true}`, "POST, GET"},
+	}
+
+	for _, test := range tests {
+
+		req := httptest.NewRequest(test.method, "/", nil)
+		rec := httptest.NewRecorder()
+
+		err := p.Write(rec, req, []string{"POST", "GET"})
+
+		if err != nil {
+			t.Fatalf("Failed to write probe for %s, %v", test.method, err)
+		}
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Unexpected status for %s: %d", test.method, rec.Code)
+		}
+
+		if rec.Body.String() != test.body {
+			t.Fatalf("Unexpected body for %s: '%s'", test.method, rec.Body.String())
+		}
+
+		if rec.Header().Get("Allow") != test.allow {
+			t.Fatalf("Unexpected Allow header for %s: '%s'", test.method, rec.Header().Get("Allow"))
+		}
+
+		if rec.Header().Get("Content-Type") != "application/json" {
+			t.Fatalf("Unexpected content type for %s: %s", test.method, rec.Header().Get("Content-Type"))
+		}
+
+		if rec.Header().Get("Cache-Control") != "public, max-age=60" {
+			t.Fatalf("Unexpected Cache-Control header for %s: %s", test.method, rec.Header().Get("Cache-Control"))
+		}
+
+		if rec.Header().Get("X-Probe") != "webhookd" {
+			t.Fatalf("Unexpected X-Probe header for %s: %s", test.method, rec.Header().Get("X-Probe"))
+		}
+	}
+}
+
+func TestNewProbeNoBody(t *testing.T) {
+
+	p, err := NewProbe(&ProbeOptions{Methods: []string{"head"}})
+
+	if err != nil {
+		t.Fatalf("Failed to create new probe, %v", err)
+	}
+
+	if p.Status() != http.StatusNoContent {
+		t.Fatalf("Unexpected status: %d", p.Status())
+	}
+
+	if p.Handles(http.MethodGet) {
+		t.Fatalf("Expected probe not to handle GET")
+	}
+
+	rec := httptest.NewRecorder()
+
+	err = p.Write(rec, httptest.NewRequest(http.MethodHead, "/", nil), nil)
+
+	if err != nil {
+		t.Fatalf("Failed to write probe, %v", err)
+	}
+
+	if rec.Header().Get("Cache-Control") != "no-store" {
+		t.Fatalf("Unexpected Cache-Control header: %s", rec.Header().Get("Cache-Control"))
+	}
+}
+
+func TestNewProbeInvalid(t *testing.T) {
+
+	tests := []*ProbeOptions{
+		{Methods: []string{"POST"}},
+		{Status: http.StatusAccepted},
+		{Status: http.StatusNoContent, Body: "hello"},
+		{MaxAge: -1},
+		{Headers: map[string]string{"": "empty"}},
+	}
+
+	for idx, opts := range tests {
+
+		_, err := NewProbe(opts)
+
+		if err == nil {
+			t.Fatalf("Expected probe options at offset %d to fail", idx)
+		}
+	}
+}
+
+func TestWebhookProbeMethods(t *testing.T) {
+
+	ctx := context.Background()
+
+	p, err := NewProbe(&ProbeOptions{})
+
+	if err != nil {
+		t.Fatalf("Failed to create new probe, %v", err)
+	}
+
+	opts := &WebhookOptions{
+		Endpoint: "/probe",
+		Methods:  []string{"POST", "GET"},
+		Probe:    p,
+	}
+
+	_, err = NewWebhookWithOptions(ctx, opts)
+
+	if err == nil {
+		t.Fatalf("Expected webhook with overlapping probe methods to fail")
+	}
+
+	opts.Methods = nil
+
+	wh, err := NewWebhookWithOptions(ctx, opts)
+
+	if err != nil {
+		t.Fatalf("Failed to create webhook with probe, %v", err)
+	}
+
+	if wh.Probe() != p {
+		t.Fatalf("Unexpected probe for webhook")
+	}
+}
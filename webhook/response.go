@@ -0,0 +1,112 @@
+package webhook
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"text/template"
+)
+
+// DEFAULT_RESPONSE_CONTENT_TYPE is the default content type for templated response bodies.
+const DEFAULT_RESPONSE_CONTENT_TYPE string = "text/plain; charset=utf-8"
+
+// Response is a struct used to write the HTTP response for a successfully processed webhook message.
+type Response struct {
+	// status is the HTTP status code for the response.
+	status int
+	// content_type is the content type of the response body.
+	content_type string
+	// template is the optional `text/template.Template` instance used to render the response body.
+	template *template.Template
+}
+
+// ResponseTemplateData is the data structure passed to response body templates.
+type ResponseTemplateData struct {
+	// DeliveryID is the unique identifier for the webhook message being processed.
+	DeliveryID string
+	// Endpoint is the endpoint of the webhook that processed the message.
+	Endpoint string
+	// EventType is the type of event the message describes or an empty string if unknown.
+	EventType string
+	// Path is the path of the request that the message was delivered to.
+	Path string
+	// Params is the dictionary of path parameters matched by the webhook endpoint.
+	Params map[string]string
+}
+
+// NewResponse returns a new `Response` instance that will write 'status' and, optionally, a body derived from the 'body' template.
+// Valid status codes are 200, 201, 202 and 204. If 'status' is zero then 200 is used. 'body' is parsed as a Go language
+// `text/template` template and is passed a `ResponseTemplateData` instance when rendered. If 'content_type' is empty then
+// `DEFAULT_RESPONSE_CONTENT_TYPE` is used.
+func NewResponse(status int, body string, content_type string) (*Response, error) {
+
+	switch status {
+	case 0:
+		status = http.StatusOK
+	case http.StatusOK, http.StatusCreated, http.StatusAccepted:
+		// pass
+	case http.StatusNoContent:
+
+		if body != "" {
+			return nil, fmt.Errorf("A response body can not be defined for status %d", status)
+		}
+
+	default:
+		return nil, fmt.Errorf("Invalid response status %d", status)
+	}
+
+	if content_type == "" {
+		content_type = DEFAULT_RESPONSE_CONTENT_TYPE
+	}
+
+	r := &Response{
+		status:       status,
+		content_type: content_type,
+	}
+
+	if body != "" {
+
+		t, err := template.New("response").Option("missingkey=zero").Parse(body)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse response template, %w", err)
+		}
+
+		r.template = t
+	}
+
+	return r, nil
+}
+
+// Status returns the HTTP status code for 'r'.
+func (r *Response) Status() int {
+	return r.status
+}
+
+// Write writes the status code and rendered body for 'r' to 'rsp'.
+func (r *Response) Write(rsp http.ResponseWriter, data *ResponseTemplateData) error {
+
+	if r.template == nil {
+		rsp.WriteHeader(r.status)
+		return nil
+	}
+
+	var buf bytes.Buffer
+
+	err := r.template.Execute(&buf, data)
+
+	if err != nil {
+		return fmt.Errorf("Failed to render response template, %w", err)
+	}
+
+	rsp.Header().Set("Content-Type", r.content_type)
+	rsp.WriteHeader(r.status)
+
+	_, err = rsp.Write(buf.Bytes())
+
+	if err != nil {
+		return fmt.Errorf("Failed to write response, %w", err)
+	}
+
+	return nil
+}
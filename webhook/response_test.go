@@ -0,0 +1,62 @@
+package webhook
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewResponse(t *testing.T) {
+
+	r, err := NewResponse(http.StatusAccepted, `{"id":"{{.DeliveryID}}","owner":"{{index .Params "owner"}}"}`, "application/json")
+
+	if err != nil {
+		t.Fatalf("Failed to create new response, %v", err)
+	}
+
+	data := &ResponseTemplateData{
+		DeliveryID: "1234",
+		Params:     map[string]string{"owner": "whosonfirst"},
+	}
+
+	rec := httptest.NewRecorder()
+
+	err = r.Write(rec, data)
+
+	if err != nil {
+		t.Fatalf("Failed to write response, %v", err)
+	}
+
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("Unexpected status: %d", rec.Code)
+	}
+
+	if rec.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("Unexpected content type: %s", rec.Header().Get("Content-Type"))
+	}
+
+	if rec.Body.String() != `{"id":"1234","owner":"whosonfirst"}` {
+		t.Fatalf("Unexpected body: %s", rec.Body.String())
+	}
+}
+
+func TestNewResponseInvalid(t *testing.T) {
+
+	_, err := NewResponse(http.StatusNoContent, "hello", "")
+
+	if err == nil {
+		t.Fatalf("Expected 204 response with body to fail")
+	}
+
+	_, err = NewResponse(http.StatusTeapot, "", "")
+
+	if err == nil {
+		t.Fatalf("Expected 418 response to fail")
+	}
+
+	_, err = NewResponse(http.StatusOK, "{{.DeliveryID", "")
+
+	if err == nil {
+		t.Fatalf("Expected invalid template to fail")
+	}
+}
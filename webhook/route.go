@@ -0,0 +1,42 @@
+package webhook
+
+import (
+	"github.com/whosonfirst/go-webhookd/v3"
+	"github.com/whosonfirst/go-webhookd/v3/predicate"
+)
+
+// Route is a struct that associates a `predicate.Predicate` instance with a list of `webhookd.WebhookDispatcher` instances
+// that messages satisfying the predicate will be relayed to.
+type Route struct {
+	// predicate is the `predicate.Predicate` instance that a message must satisfy. If nil the route matches all messages.
+	predicate *predicate.Predicate
+	// dispatchers is the list of zero or more `webhookd.WebhookDispatcher` instances for the route.
+	dispatchers []webhookd.WebhookDispatcher
+}
+
+// NewRoute returns a new `Route` instance that relays messages satisfying 'p' to 'dispatchers'. If 'p' is nil
+// the route matches all messages.
+func NewRoute(p *predicate.Predicate, dispatchers []webhookd.WebhookDispatcher) *Route {
+
+	r := &Route{
+		predicate:   p,
+		dispatchers: dispatchers,
+	}
+
+	return r
+}
+
+// Matches returns a boolean value indicating whether 'env' satisfies the predicate for 'r'.
+func (r *Route) Matches(env *predicate.Environment) bool {
+
+	if r.predicate == nil {
+		return true
+	}
+
+	return r.predicate.Match(env)
+}
+
+// Dispatchers returns the list of `webhookd.WebhookDispatcher` instances for 'r'.
+func (r *Route) Dispatchers() []webhookd.WebhookDispatcher {
+	return r.dispatchers
+}
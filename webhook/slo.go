@@ -0,0 +1,138 @@
+package webhook
+
+import (
+	"fmt"
+	"time"
+)
+
+// DEFAULT_SLO_WINDOW is the default window over which service level objectives are evaluated.
+const DEFAULT_SLO_WINDOW time.Duration = 1 * time.Hour
+
+// DEFAULT_SLO_MIN_REQUESTS is the default minimum number of requests, in a window, before service level objectives are evaluated.
+const DEFAULT_SLO_MIN_REQUESTS int = 10
+
+// DEFAULT_SLO_LATENCY_TARGET is the default fraction of requests that must be processed within the latency objective.
+const DEFAULT_SLO_LATENCY_TARGET float64 = 0.99
+
+// DEFAULT_SLO_BURN_RATE is the default rate at which the error budget for an objective may be consumed before it is considered
+// to be breached. A burn rate of 1 means that the budget would be exactly used up by the end of the window.
+const DEFAULT_SLO_BURN_RATE float64 = 1.0
+
+// SLOOptions is a struct containing the options for `NewSLO`.
+type SLOOptions struct {
+	// SuccessRate is the fraction (greater than 0 and less than 1) of requests that must succeed. Requests succeed if they do not
+	// return a 5XX status code. If 0 there is no success rate objective.
+	SuccessRate float64
+	// Latency is the duration within which requests must be processed. If 0 there is no latency objective.
+	Latency time.Duration
+	// LatencyTarget is the fraction (greater than 0 and less than 1) of requests that must be processed within `Latency`. If 0
+	// then `DEFAULT_SLO_LATENCY_TARGET` is used.
+	LatencyTarget float64
+	// Window is the sliding window over which objectives are evaluated. If 0 then `DEFAULT_SLO_WINDOW` is used.
+	Window time.Duration
+	// MinRequests is the minimum number of requests, in a window, before objectives are evaluated. If 0 then
+	// `DEFAULT_SLO_MIN_REQUESTS` is used.
+	MinRequests int
+	// BurnRate is the rate at which the error budget for an objective may be consumed before it is considered to be breached. If 0
+	// then `DEFAULT_SLO_BURN_RATE` is used.
+	BurnRate float64
+}
+
+// SLO is a struct defining the service level objectives (success rate and latency) for a webhook.
+type SLO struct {
+	success_rate   float64
+	latency        time.Duration
+	latency_target float64
+	window         time.Duration
+	min_requests   int
+	burn_rate      float64
+}
+
+// NewSLO returns a new `SLO` instance configured by 'opts'. At least one of a success rate or a latency objective must be defined.
+func NewSLO(opts *SLOOptions) (*SLO, error) {
+
+	if opts.SuccessRate == 0 && opts.Latency == 0 {
+		return nil, fmt.Errorf("Missing success rate or latency objective")
+	}
+
+	if opts.SuccessRate < 0 || opts.SuccessRate >= 1 {
+		return nil, fmt.Errorf("Invalid success rate, %v", opts.SuccessRate)
+	}
+
+	if opts.Latency < 0 {
+		return nil, fmt.Errorf("Invalid latency, %v", opts.Latency)
+	}
+
+	if opts.LatencyTarget < 0 || opts.LatencyTarget >= 1 {
+		return nil, fmt.Errorf("Invalid latency target, %v", opts.LatencyTarget)
+	}
+
+	if opts.Window < 0 {
+		return nil, fmt.Errorf("Invalid window, %v", opts.Window)
+	}
+
+	if opts.MinRequests < 0 {
+		return nil, fmt.Errorf("Invalid minimum number of requests, %d", opts.MinRequests)
+	}
+
+	if opts.BurnRate < 0 {
+		return nil, fmt.Errorf("Invalid burn rate, %v", opts.BurnRate)
+	}
+
+	s := &SLO{
+		success_rate:   opts.SuccessRate,
+		latency:        opts.Latency,
+		latency_target: opts.LatencyTarget,
+		window:         opts.Window,
+		min_requests:   opts.MinRequests,
+		burn_rate:      opts.BurnRate,
+	}
+
+	if s.latency > 0 && s.latency_target == 0 {
+		s.latency_target = DEFAULT_SLO_LATENCY_TARGET
+	}
+
+	if s.window == 0 {
+		s.window = DEFAULT_SLO_WINDOW
+	}
+
+	if s.min_requests == 0 {
+		s.min_requests = DEFAULT_SLO_MIN_REQUESTS
+	}
+
+	if s.burn_rate == 0 {
+		s.burn_rate = DEFAULT_SLO_BURN_RATE
+	}
+
+	return s, nil
+}
+
+// SuccessRate() returns the fraction of requests that must succeed, or 0 if there is no success rate objective.
+func (s *SLO) SuccessRate() float64 {
+	return s.success_rate
+}
+
+// Latency() returns the duration within which requests must be processed, or 0 if there is no latency objective.
+func (s *SLO) Latency() time.Duration {
+	return s.latency
+}
+
+// LatencyTarget() returns the fraction of requests that must be processed within `Latency()`.
+func (s *SLO) LatencyTarget() float64 {
+	return s.latency_target
+}
+
+// Window() returns the sliding window over which objectives are evaluated.
+func (s *SLO) Window() time.Duration {
+	return s.window
+}
+
+// MinRequests() returns the minimum number of requests, in a window, before objectives are evaluated.
+func (s *SLO) MinRequests() int {
+	return s.min_requests
+}
+
+// BurnRate() returns the rate at which the error budget for an objective may be consumed before it is considered to be breached.
+func (s *SLO) BurnRate() float64 {
+	return s.burn_rate
+}
@@ -0,0 +1,46 @@
+package webhook
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewSLO(t *testing.T) {
+
+	s, err := NewSLO(&SLOOptions{Latency: 500 * time.Millisecond})
+
+	if err != nil {
+		t.Fatalf("Failed to create new SLO, %v", err)
+	}
+
+	if s.SuccessRate() != 0 || s.Latency() != 500*time.Millisecond {
+		t.Fatalf("Unexpected objectives: %v %v", s.SuccessRate(), s.Latency())
+	}
+
+	if s.LatencyTarget() != DEFAULT_SLO_LATENCY_TARGET || s.Window() != DEFAULT_SLO_WINDOW || s.MinRequests() != DEFAULT_SLO_MIN_REQUESTS || s.BurnRate() != DEFAULT_SLO_BURN_RATE {
+		t.Fatalf("Unexpected defaults: %v %v %d %v", s.LatencyTarget(), s.Window(), s.MinRequests(), s.BurnRate())
+	}
+}
+
+func TestNewSLOInvalid(t *testing.T) {
+
+	tests := []*SLOOptions{
+		{},
+		{SuccessRate: 1},
+		{SuccessRate: -0.5},
+		{Latency: -1 * time.Second},
+		{Latency: time.Second, LatencyTarget: 1.5},
+		{SuccessRate: 0.99, Window: -1 * time.Hour},
+		{SuccessRate: 0.99, MinRequests: -1},
+		{SuccessRate: 0.99, BurnRate: -2},
+	}
+
+	for idx, opts := range tests {
+
+		_, err := NewSLO(opts)
+
+		if err == nil {
+			t.Fatalf("Expected options at offset %d to fail", idx)
+		}
+	}
+}
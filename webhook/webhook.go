@@ -3,8 +3,12 @@ package webhook
 
 import (
 	"context"
-	
+	"fmt"
+	"net/http"
+	"strings"
+
 	"github.com/whosonfirst/go-webhookd/v3"
+	"github.com/whosonfirst/go-webhookd/v3/predicate"
 )
 
 // type Webhook defines a struct that implements the `webhookd.WebhookHandler` interface for definining and configuring an individual webhook.
@@ -18,16 +22,259 @@ type Webhook struct {
 	transformations []webhookd.WebhookTransformation
 	// dispatchers is a list of zero or more `webhookd.WebhookDispatcher` instances which will be to relay the body of a webhook message after it's been transformed.
 	dispatchers []webhookd.WebhookDispatcher
+	// streaming is a boolean flag signaling that message bodies should be streamed from the receiver to the dispatchers.
+	streaming bool
+	// methods is the list of HTTP methods that the webhook will accept.
+	methods []string
+	// response is the optional `Response` instance used to write the HTTP response for successfully processed messages.
+	response *Response
+	// probe is the optional `Probe` instance used to answer requests that check whether the webhook exists.
+	probe *Probe
+	// cors is the optional `CORS` instance defining the cross-origin resource sharing policy for the webhook.
+	cors *CORS
+	// routes is an optional list of `Route` instances used to select dispatchers based on the contents of a message.
+	routes []*Route
+	// labels is an optional dictionary of labels used to identify the webhook in metrics and alerts.
+	labels map[string]string
+	// slo is the optional `SLO` instance defining the service level objectives for the webhook.
+	slo *SLO
+	// middleware is an optional list of `webhookd.WebhookMiddleware` instances applied to requests before they are processed.
+	middleware []webhookd.WebhookMiddleware
+	// dispatch_mode is one of `DISPATCH_PARALLEL` or `DISPATCH_SEQUENTIAL`.
+	dispatch_mode string
+	// abort_on_failure is a boolean flag signaling that sequential dispatching should stop after the first failure.
+	abort_on_failure bool
+	// success_policy is one of `SUCCESS_POLICY_ALL`, `SUCCESS_POLICY_ANY` or `SUCCESS_POLICY_QUORUM`.
+	success_policy string
+	// quorum is the number of dispatchers that must succeed when 'success_policy' is `SUCCESS_POLICY_QUORUM`.
+	quorum int
+	// debug_token is the optional secret token required to request debugging output for the webhook.
+	debug_token string
+	// tenant is the optional name of the tenant that the webhook belongs to.
+	tenant string
+}
+
+// DEFAULT_METHOD is the HTTP method a webhook accepts if no other methods are defined.
+const DEFAULT_METHOD string = http.MethodPost
+
+// Valid modes for relaying a message to the dispatchers for a webhook.
+const (
+	// DISPATCH_PARALLEL relays a message to all of its dispatchers at the same time.
+	DISPATCH_PARALLEL string = "parallel"
+	// DISPATCH_SEQUENTIAL relays a message to each of its dispatchers, in order, after the previous dispatcher has completed.
+	DISPATCH_SEQUENTIAL string = "sequential"
+)
+
+// Valid policies for deciding whether a message was dispatched successfully.
+const (
+	// SUCCESS_POLICY_ALL requires that every dispatcher succeed.
+	SUCCESS_POLICY_ALL string = "all"
+	// SUCCESS_POLICY_ANY requires that at least one dispatcher succeed.
+	SUCCESS_POLICY_ANY string = "any"
+	// SUCCESS_POLICY_QUORUM requires that a quorum (by default a majority) of dispatchers succeed.
+	SUCCESS_POLICY_QUORUM string = "quorum"
+)
+
+// WebhookOptions is a struct containing the options for `NewWebhookWithOptions`.
+type WebhookOptions struct {
+	// Endpoint is the relative URI of the webhook.
+	Endpoint string
+	// Receiver is the `webhookd.WebhookReceiver` instance used to process a webhook message on arrival.
+	Receiver webhookd.WebhookReceiver
+	// Transformations is a list of zero or more `webhookd.WebhookTransformation` instances that will be applied to a message after receipt.
+	Transformations []webhookd.WebhookTransformation
+	// Dispatchers is a list of zero or more `webhookd.WebhookDispatcher` instances which will be to relay the body of a webhook message after it's been transformed.
+	Dispatchers []webhookd.WebhookDispatcher
+	// Streaming is a boolean flag signaling that message bodies should be streamed from the receiver to the dispatchers rather than
+	// being read in to memory. It requires that the receiver implement the `webhookd.WebhookStreamingReceiver` interface, that there
+	// are no transformations and that all the dispatchers implement the `webhookd.WebhookStreamingDispatcher` interface.
+	Streaming bool
+	// Methods is the list of HTTP methods that the webhook will accept. If empty only `DEFAULT_METHOD` is accepted.
+	Methods []string
+	// Response is an optional `Response` instance used to write the HTTP response for successfully processed messages.
+	Response *Response
+	// Probe is an optional `Probe` instance used to answer requests that check whether the webhook exists (for example a HEAD
+	// request before the first delivery) without passing them to the receiver. The methods it answers can not overlap `Methods`.
+	Probe *Probe
+	// CORS is an optional `CORS` instance defining the cross-origin resource sharing policy for the webhook. If nil then no CORS
+	// headers are sent.
+	CORS *CORS
+	// Routes is an optional list of `Route` instances used to select dispatchers based on the contents of a message. Routes
+	// are tested in order and the dispatchers for the first matching route are used. If no routes match then `Dispatchers`
+	// are used.
+	Routes []*Route
+	// Labels is an optional dictionary of labels (for example the team that owns a webhook) used to identify the webhook in metrics
+	// and alerts.
+	Labels map[string]string
+	// SLO is an optional `SLO` instance defining the service level objectives for the webhook.
+	SLO *SLO
+	// Middleware is an optional list of `webhookd.WebhookMiddleware` instances applied, in order, to requests for the webhook before
+	// they are passed to the receiver.
+	Middleware []webhookd.WebhookMiddleware
+	// DispatchMode is one of `DISPATCH_PARALLEL` or `DISPATCH_SEQUENTIAL`. If empty then `DISPATCH_PARALLEL` is used. Streaming
+	// webhooks can only use `DISPATCH_PARALLEL`.
+	DispatchMode string
+	// AbortOnFailure is a boolean flag signaling that, when dispatching sequentially, a message should not be relayed to any more
+	// dispatchers once a dispatcher has failed. It requires that `DispatchMode` be `DISPATCH_SEQUENTIAL`.
+	AbortOnFailure bool
+	// SuccessPolicy is one of `SUCCESS_POLICY_ALL`, `SUCCESS_POLICY_ANY` or `SUCCESS_POLICY_QUORUM` and determines how many
+	// dispatchers must succeed for a message to have been dispatched successfully. If empty then `SUCCESS_POLICY_ALL` is used.
+	SuccessPolicy string
+	// Quorum is the number of dispatchers that must succeed when `SuccessPolicy` is `SUCCESS_POLICY_QUORUM`. If 0 then a majority
+	// of dispatchers must succeed.
+	Quorum int
+	// DebugToken is an optional secret token required to request debugging output for the webhook. If empty then debugging
+	// output is disabled. Streaming webhooks can not be debugged.
+	DebugToken string
+	// Tenant is the optional name of the tenant that the webhook belongs to. Metrics for webhooks that belong to a tenant are
+	// isolated from those of other tenants.
+	Tenant string
 }
 
 // NewWebhook return a new `Wehook` instance.
 func NewWebhook(ctx context.Context, endpoint string, rc webhookd.WebhookReceiver, tr []webhookd.WebhookTransformation, ds []webhookd.WebhookDispatcher) (Webhook, error) {
 
+	opts := &WebhookOptions{
+		Endpoint:        endpoint,
+		Receiver:        rc,
+		Transformations: tr,
+		Dispatchers:     ds,
+	}
+
+	return NewWebhookWithOptions(ctx, opts)
+}
+
+// NewWebhookWithOptions return a new `Wehook` instance configured by 'opts'.
+func NewWebhookWithOptions(ctx context.Context, opts *WebhookOptions) (Webhook, error) {
+
+	if opts.Streaming {
+
+		_, ok := opts.Receiver.(webhookd.WebhookStreamingReceiver)
+
+		if !ok {
+			return Webhook{}, fmt.Errorf("Receiver (%T) does not support streaming", opts.Receiver)
+		}
+
+		if len(opts.Transformations) > 0 {
+			return Webhook{}, fmt.Errorf("Streaming webhooks can not have transformations")
+		}
+
+		if len(opts.Routes) > 0 {
+			return Webhook{}, fmt.Errorf("Streaming webhooks can not have routes")
+		}
+
+		for idx, d := range opts.Dispatchers {
+
+			_, ok := d.(webhookd.WebhookStreamingDispatcher)
+
+			if !ok {
+				return Webhook{}, fmt.Errorf("Dispatcher (%T) at offset %d does not support streaming", d, idx)
+			}
+		}
+	}
+
+	dispatch_mode := opts.DispatchMode
+
+	switch dispatch_mode {
+	case "":
+		dispatch_mode = DISPATCH_PARALLEL
+	case DISPATCH_PARALLEL, DISPATCH_SEQUENTIAL:
+		// pass
+	default:
+		return Webhook{}, fmt.Errorf("Invalid dispatch mode '%s'", dispatch_mode)
+	}
+
+	if opts.Streaming && dispatch_mode != DISPATCH_PARALLEL {
+		return Webhook{}, fmt.Errorf("Streaming webhooks can only dispatch messages in parallel")
+	}
+
+	if opts.AbortOnFailure && dispatch_mode != DISPATCH_SEQUENTIAL {
+		return Webhook{}, fmt.Errorf("Aborting on failure requires that messages be dispatched sequentially")
+	}
+
+	success_policy := opts.SuccessPolicy
+
+	switch success_policy {
+	case "":
+		success_policy = SUCCESS_POLICY_ALL
+	case SUCCESS_POLICY_ALL, SUCCESS_POLICY_ANY, SUCCESS_POLICY_QUORUM:
+		// pass
+	default:
+		return Webhook{}, fmt.Errorf("Invalid success policy '%s'", success_policy)
+	}
+
+	if opts.Quorum < 0 {
+		return Webhook{}, fmt.Errorf("Invalid quorum, %d", opts.Quorum)
+	}
+
+	if opts.Quorum > 0 && success_policy != SUCCESS_POLICY_QUORUM {
+		return Webhook{}, fmt.Errorf("A quorum requires the '%s' success policy", SUCCESS_POLICY_QUORUM)
+	}
+
+	if opts.Streaming && opts.DebugToken != "" {
+		return Webhook{}, fmt.Errorf("Streaming webhooks can not be debugged")
+	}
+
+	labels := make(map[string]string)
+
+	for k, v := range opts.Labels {
+
+		if k == "" {
+			return Webhook{}, fmt.Errorf("Labels can not have empty names")
+		}
+
+		labels[k] = v
+	}
+
+	methods := make([]string, 0)
+	seen := make(map[string]bool)
+
+	for _, m := range opts.Methods {
+
+		m = strings.ToUpper(strings.TrimSpace(m))
+
+		if m == "" || seen[m] {
+			continue
+		}
+
+		seen[m] = true
+		methods = append(methods, m)
+	}
+
+	if len(methods) == 0 {
+		methods = []string{DEFAULT_METHOD}
+	}
+
+	if opts.Probe != nil {
+
+		for _, m := range opts.Probe.Methods() {
+
+			if seen[m] {
+				return Webhook{}, fmt.Errorf("Probe method %s is also accepted by the webhook", m)
+			}
+		}
+	}
+
 	wh := Webhook{
-		endpoint:        endpoint,
-		receiver:        rc,
-		transformations: tr,
-		dispatchers:     ds,
+		methods:          methods,
+		endpoint:         opts.Endpoint,
+		receiver:         opts.Receiver,
+		transformations:  opts.Transformations,
+		dispatchers:      opts.Dispatchers,
+		streaming:        opts.Streaming,
+		response:         opts.Response,
+		probe:            opts.Probe,
+		cors:             opts.CORS,
+		routes:           opts.Routes,
+		labels:           labels,
+		slo:              opts.SLO,
+		middleware:       opts.Middleware,
+		dispatch_mode:    dispatch_mode,
+		abort_on_failure: opts.AbortOnFailure,
+		success_policy:   success_policy,
+		quorum:           opts.Quorum,
+		debug_token:      opts.DebugToken,
+		tenant:           opts.Tenant,
 	}
 
 	return wh, nil
@@ -52,3 +299,140 @@ func (wh Webhook) Transformations() []webhookd.WebhookTransformation {
 func (wh Webhook) Dispatchers() []webhookd.WebhookDispatcher {
 	return wh.dispatchers
 }
+
+// Streaming() returns a boolean flag signaling whether message bodies should be streamed from the receiver to the dispatchers.
+func (wh Webhook) Streaming() bool {
+	return wh.streaming
+}
+
+// Methods() returns the list of HTTP methods that the webhook will accept.
+func (wh Webhook) Methods() []string {
+	return wh.methods
+}
+
+// AllowsMethod() returns a boolean value indicating whether the webhook will accept requests with the HTTP method 'method'.
+func (wh Webhook) AllowsMethod(method string) bool {
+
+	for _, m := range wh.methods {
+
+		if m == method {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Response() returns the `Response` instance used to write the HTTP response for successfully processed messages. It may be nil.
+func (wh Webhook) Response() *Response {
+	return wh.response
+}
+
+// Probe() returns the `Probe` instance used to answer requests that check whether the webhook exists. It may be nil.
+func (wh Webhook) Probe() *Probe {
+	return wh.probe
+}
+
+// CORS() returns the `CORS` instance defining the cross-origin resource sharing policy for the webhook. It may be nil.
+func (wh Webhook) CORS() *CORS {
+	return wh.cors
+}
+
+// Routes() returns the list of `Route` instances used to select dispatchers based on the contents of a message.
+func (wh Webhook) Routes() []*Route {
+	return wh.routes
+}
+
+// Tenant() returns the name of the tenant that the webhook belongs to or an empty string if it does not belong to a tenant.
+func (wh Webhook) Tenant() string {
+	return wh.tenant
+}
+
+// Labels() returns the dictionary of labels used to identify the webhook in metrics and alerts.
+func (wh Webhook) Labels() map[string]string {
+	return wh.labels
+}
+
+// SLO() returns the `SLO` instance defining the service level objectives for the webhook. It may be nil.
+func (wh Webhook) SLO() *SLO {
+	return wh.slo
+}
+
+// Middleware() returns the list of `webhookd.WebhookMiddleware` instances applied to requests before they are processed.
+func (wh Webhook) Middleware() []webhookd.WebhookMiddleware {
+	return wh.middleware
+}
+
+// DispatchMode() returns the mode, one of `DISPATCH_PARALLEL` or `DISPATCH_SEQUENTIAL`, used to relay messages to the dispatchers
+// for the webhook.
+func (wh Webhook) DispatchMode() string {
+	return wh.dispatch_mode
+}
+
+// AbortOnFailure() returns a boolean value indicating whether, when dispatching sequentially, a message should not be relayed
+// to any more dispatchers once a dispatcher has failed.
+func (wh Webhook) AbortOnFailure() bool {
+	return wh.abort_on_failure
+}
+
+// DebugToken() returns the secret token required to request debugging output for the webhook. If empty then debugging output
+// is disabled.
+func (wh Webhook) DebugToken() string {
+	return wh.debug_token
+}
+
+// SuccessPolicy() returns the policy, one of `SUCCESS_POLICY_ALL`, `SUCCESS_POLICY_ANY` or `SUCCESS_POLICY_QUORUM`, used to decide
+// whether a message was dispatched successfully.
+func (wh Webhook) SuccessPolicy() string {
+	return wh.success_policy
+}
+
+// Quorum() returns the number of dispatchers that must succeed when the success policy is `SUCCESS_POLICY_QUORUM` or 0 if a
+// majority of dispatchers must succeed.
+func (wh Webhook) Quorum() int {
+	return wh.quorum
+}
+
+// Succeeded() returns a boolean value indicating whether a message that was relayed to 'total' dispatchers, of which 'succeeded'
+// succeeded, satisfies the success policy for the webhook. A message with no dispatchers always succeeds. A quorum larger than
+// 'total' requires that every dispatcher succeed.
+func (wh Webhook) Succeeded(succeeded int, total int) bool {
+
+	if total == 0 {
+		return true
+	}
+
+	switch wh.success_policy {
+	case SUCCESS_POLICY_ANY:
+		return succeeded > 0
+	case SUCCESS_POLICY_QUORUM:
+
+		quorum := wh.quorum
+
+		if quorum == 0 {
+			quorum = total/2 + 1
+		}
+
+		if quorum > total {
+			quorum = total
+		}
+
+		return succeeded >= quorum
+	default:
+		return succeeded == total
+	}
+}
+
+// DispatchersForEnvironment() returns the dispatchers for the first route satisfied by 'env'. If the webhook has no
+// routes, or none of them are satisfied, the default list of dispatchers is returned.
+func (wh Webhook) DispatchersForEnvironment(env *predicate.Environment) []webhookd.WebhookDispatcher {
+
+	for _, r := range wh.routes {
+
+		if r.Matches(env) {
+			return r.Dispatchers()
+		}
+	}
+
+	return wh.dispatchers
+}
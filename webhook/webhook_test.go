@@ -49,3 +49,185 @@ func TestWebhook(t *testing.T) {
 	}
 
 }
+
+func TestWebhookMethods(t *testing.T) {
+
+	ctx := context.Background()
+
+	r, err := receiver.NewReceiver(ctx, "insecure://")
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	wh, err := NewWebhook(ctx, "/insecure", r, nil, nil)
+
+	if err != nil {
+		t.Fatalf("Failed to create new webhook, %v", err)
+	}
+
+	if !wh.AllowsMethod("POST") || wh.AllowsMethod("GET") {
+		t.Fatalf("Unexpected default methods: %v", wh.Methods())
+	}
+
+	opts := &WebhookOptions{
+		Endpoint: "/insecure",
+		Receiver: r,
+		Methods:  []string{"put", "PUT", "patch"},
+	}
+
+	wh, err = NewWebhookWithOptions(ctx, opts)
+
+	if err != nil {
+		t.Fatalf("Failed to create new webhook, %v", err)
+	}
+
+	if len(wh.Methods()) != 2 || !wh.AllowsMethod("PUT") || !wh.AllowsMethod("PATCH") || wh.AllowsMethod("POST") {
+		t.Fatalf("Unexpected methods: %v", wh.Methods())
+	}
+}
+
+func TestWebhookDispatchMode(t *testing.T) {
+
+	ctx := context.Background()
+
+	r, err := receiver.NewReceiver(ctx, "insecure://")
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	wh, err := NewWebhook(ctx, "/insecure", r, nil, nil)
+
+	if err != nil {
+		t.Fatalf("Failed to create new webhook, %v", err)
+	}
+
+	if wh.DispatchMode() != DISPATCH_PARALLEL || wh.AbortOnFailure() {
+		t.Fatalf("Unexpected default dispatch mode: %s", wh.DispatchMode())
+	}
+
+	opts := &WebhookOptions{
+		Endpoint:       "/insecure",
+		Receiver:       r,
+		DispatchMode:   DISPATCH_SEQUENTIAL,
+		AbortOnFailure: true,
+	}
+
+	wh, err = NewWebhookWithOptions(ctx, opts)
+
+	if err != nil {
+		t.Fatalf("Failed to create new webhook, %v", err)
+	}
+
+	if wh.DispatchMode() != DISPATCH_SEQUENTIAL || !wh.AbortOnFailure() {
+		t.Fatalf("Unexpected dispatch mode: %s", wh.DispatchMode())
+	}
+
+	invalid := []*WebhookOptions{
+		{Endpoint: "/insecure", Receiver: r, DispatchMode: "random"},
+		{Endpoint: "/insecure", Receiver: r, AbortOnFailure: true},
+		{Endpoint: "/insecure", Receiver: r, Streaming: true, DispatchMode: DISPATCH_SEQUENTIAL},
+	}
+
+	for idx, opts := range invalid {
+
+		_, err := NewWebhookWithOptions(ctx, opts)
+
+		if err == nil {
+			t.Fatalf("Expected options at offset %d to fail", idx)
+		}
+	}
+}
+
+func TestWebhookSucceeded(t *testing.T) {
+
+	ctx := context.Background()
+
+	tests := []struct {
+		policy    string
+		quorum    int
+		succeeded int
+		total     int
+		expected  bool
+	}{
+		{"", 0, 3, 3, true},
+		{SUCCESS_POLICY_ALL, 0, 2, 3, false},
+		{SUCCESS_POLICY_ALL, 0, 0, 0, true},
+		{SUCCESS_POLICY_ANY, 0, 1, 3, true},
+		{SUCCESS_POLICY_ANY, 0, 0, 3, false},
+		{SUCCESS_POLICY_QUORUM, 0, 2, 3, true},
+		{SUCCESS_POLICY_QUORUM, 0, 2, 4, false},
+		{SUCCESS_POLICY_QUORUM, 2, 2, 4, true},
+		{SUCCESS_POLICY_QUORUM, 5, 3, 3, true},
+	}
+
+	for idx, test := range tests {
+
+		opts := &WebhookOptions{
+			Endpoint:      "/test",
+			SuccessPolicy: test.policy,
+			Quorum:        test.quorum,
+		}
+
+		wh, err := NewWebhookWithOptions(ctx, opts)
+
+		if err != nil {
+			t.Fatalf("Failed to create new webhook for test %d, %v", idx, err)
+		}
+
+		if wh.Succeeded(test.succeeded, test.total) != test.expected {
+			t.Fatalf("Unexpected result for test %d", idx)
+		}
+	}
+
+	invalid := []*WebhookOptions{
+		{Endpoint: "/test", SuccessPolicy: "most"},
+		{Endpoint: "/test", Quorum: 2},
+		{Endpoint: "/test", SuccessPolicy: SUCCESS_POLICY_QUORUM, Quorum: -1},
+	}
+
+	for idx, opts := range invalid {
+
+		_, err := NewWebhookWithOptions(ctx, opts)
+
+		if err == nil {
+			t.Fatalf("Expected options at offset %d to fail", idx)
+		}
+	}
+}
+
+func TestWebhookDebugToken(t *testing.T) {
+
+	ctx := context.Background()
+
+	r, err := receiver.NewReceiver(ctx, "insecure://")
+
+	if err != nil {
+		t.Fatalf("Failed to create new receiver, %v", err)
+	}
+
+	opts := &WebhookOptions{
+		Endpoint:   "/insecure",
+		Receiver:   r,
+		DebugToken: "s33kret",
+	}
+
+	wh, err := NewWebhookWithOptions(ctx, opts)
+
+	if err != nil {
+		t.Fatalf("Failed to create new webhook, %v", err)
+	}
+
+	if wh.DebugToken() != "s33kret" {
+		t.Fatalf("Unexpected debug token: %s", wh.DebugToken())
+	}
+
+	opts.Streaming = true
+
+	_, err = NewWebhookWithOptions(ctx, opts)
+
+	if err == nil {
+		t.Fatalf("Expected streaming webhook with a debug token to fail")
+	}
+}
@@ -3,7 +3,9 @@ package webhookd
 
 import (
 	"context"
+	"io"
 	"net/http"
+	"time"
 )
 
 // type WebhookHandler is an interface for definining and configuring an individual webhooks.
@@ -24,14 +26,84 @@ type WebhookReceiver interface {
 	Receive(context.Context, *http.Request) ([]byte, *WebhookError)
 }
 
+// WebhookStreamingReceiver is an optional interface for `WebhookReceiver` implementations that can process a webhook message on arrival without reading its entire body in to memory.
+type WebhookStreamingReceiver interface {
+	WebhookReceiver
+	// ReceiveStream() returns an `io.ReadCloser` for the body of an `http.Request` instance. Any validation of the message body should be performed incrementally as it is read, with failures reported as an error once the final bytes have been read.
+	ReceiveStream(context.Context, *http.Request) (io.ReadCloser, *WebhookError)
+}
+
 // WebhookTransformation is an interface that defines methods for altering (transforming) the body of a (webhook) message after receipt.
 type WebhookTransformation interface {
 	// Transforms() alters the body of a (webhook) message (according to rules defined by the package implementing the `WebhookTransformation` interface).
 	Transform(context.Context, []byte) ([]byte, *WebhookError)
 }
 
+// WebhookSplittingTransformation is an optional interface for `WebhookTransformation` implementations that can emit multiple messages from a single message.
+type WebhookSplittingTransformation interface {
+	WebhookTransformation
+	// Split() returns zero or more (webhook) messages derived from the body of a single message (according to rules defined by the package implementing the `WebhookSplittingTransformation` interface). Each message is processed by any subsequent transformations and dispatchers independently.
+	Split(context.Context, []byte) ([][]byte, *WebhookError)
+}
+
 // WebhookDispatcher is an interface that defines methods for relaying the body of a (webhook) message after it has been transformed.
 type WebhookDispatcher interface {
 	// Dispatch() relays the body of a message (according to rules defined defined by the package implementing the `WebhookDispatcher` interface).
 	Dispatch(context.Context, []byte) *WebhookError
 }
+
+// WebhookStreamingDispatcher is an optional interface for `WebhookDispatcher` implementations that can relay the body of a message without reading it in to memory.
+type WebhookStreamingDispatcher interface {
+	WebhookDispatcher
+	// DispatchStream() relays the body of a message read from an `io.Reader` instance. Since receivers may only validate a message once its final bytes have been read, implementations must not commit (or otherwise make visible to anyone else) any part of a message until the reader has returned `io.EOF` without error; for example by writing to a temporary file which is renamed once the entire body has been read. Dispatchers that relay bytes to a remote system as they arrive should not implement this interface.
+	DispatchStream(context.Context, io.Reader) *WebhookError
+}
+
+// WebhookSource is an interface that defines methods for consuming (webhook) messages from a queue, topic or other pull-based source rather than receiving them over HTTP.
+type WebhookSource interface {
+	// Consume() reads messages from the source, passing each one to a `WebhookSourceFunc`, until the context is cancelled, the source has no more messages to read (in which case it returns nil) or an unrecoverable error occurs. Implementations should only acknowledge (or commit) a message once the function has returned without error.
+	Consume(context.Context, WebhookSourceFunc) error
+	// Close() releases any resources (for example network connections) used by the source.
+	Close() error
+}
+
+// WebhookSourceFunc is a function used by `WebhookSource` implementations to process a single (webhook) message.
+type WebhookSourceFunc func(context.Context, *WebhookMessage) *WebhookError
+
+// WebhookMessage is a (webhook) message read from a `WebhookSource`.
+type WebhookMessage struct {
+	// ID is an optional unique identifier for the message.
+	ID string
+	// Body is the body of the message.
+	Body []byte
+	// Header is an optional dictionary of source-specific metadata for the message, for example Kafka record headers.
+	Header http.Header
+}
+
+// WebhookMiddleware is an interface that defines methods for applying cross-cutting policies (for example authentication, rate limiting or request size limits) to webhook requests before they are processed.
+type WebhookMiddleware interface {
+	// Handler() returns an `http.Handler` that applies a policy (according to rules defined by the package implementing the `WebhookMiddleware` interface) to a request and, if the request satisfies the policy, passes it to the next `http.Handler`.
+	Handler(http.Handler) http.Handler
+}
+
+// WebhookReporter is an interface that defines methods for reporting unexpected failures, like panics, to an external error tracking service.
+type WebhookReporter interface {
+	// Report() sends the details of a recovered panic to an error tracking service (according to rules defined by the package implementing the `WebhookReporter` interface).
+	Report(context.Context, *WebhookPanic) error
+}
+
+// WebhookPanic describes a panic that was recovered while processing a (webhook) message.
+type WebhookPanic struct {
+	// Value is the string representation of the value passed to `panic`.
+	Value string
+	// Stack is the stack trace of the Go routine that panicked.
+	Stack []byte
+	// Step is the processing step (for example "handler" or "dispatcher") that panicked.
+	Step string
+	// Endpoint is the endpoint of the webhook, or the path of the request, being processed.
+	Endpoint string
+	// DeliveryID is the unique identifier for the message being processed, if known.
+	DeliveryID string
+	// Time is the time the panic was recovered.
+	Time time.Time
+}
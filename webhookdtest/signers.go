@@ -0,0 +1,129 @@
+package webhookdtest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/whosonfirst/go-webhookd/v3/signature"
+)
+
+// Signer is a function that adds the headers required to authenticate 'body' to 'req', for example a provider's signature header.
+type Signer func(req *http.Request, body []byte) error
+
+// GitHubSigner returns a `Signer` that signs messages with 'secret' in the same way as GitHub, adding both the SHA-256 and
+// (legacy) SHA-1 signature headers. If 'event' is not empty it is assigned to the "X-GitHub-Event" header.
+func GitHubSigner(secret string, event string) Signer {
+
+	return func(req *http.Request, body []byte) error {
+
+		req.Header.Set(signature.GITHUB_SHA256_HEADER, signature.SignGitHubSHA256(secret, body))
+		req.Header.Set(signature.GITHUB_SHA1_HEADER, signature.SignGitHubSHA1(secret, body))
+
+		if event != "" {
+			req.Header.Set("X-GitHub-Event", event)
+		}
+
+		return nil
+	}
+}
+
+// GitLabSigner returns a `Signer` that authenticates messages with 'token' in the same way as GitLab. If 'event' is not empty it
+// is assigned to the "X-Gitlab-Event" header.
+func GitLabSigner(token string, event string) Signer {
+
+	return func(req *http.Request, body []byte) error {
+
+		req.Header.Set(signature.GITLAB_TOKEN_HEADER, signature.SignGitLab(token))
+
+		if event != "" {
+			req.Header.Set("X-Gitlab-Event", event)
+		}
+
+		return nil
+	}
+}
+
+// SlackSigner returns a `Signer` that signs messages with 'secret', and the current time, in the same way as Slack.
+func SlackSigner(secret string) Signer {
+
+	return func(req *http.Request, body []byte) error {
+
+		now := time.Now()
+
+		req.Header.Set(signature.SLACK_TIMESTAMP_HEADER, strconv.FormatInt(now.Unix(), 10))
+		req.Header.Set(signature.SLACK_SIGNATURE_HEADER, signature.SignSlack(secret, body, now))
+
+		return nil
+	}
+}
+
+// StripeSigner returns a `Signer` that signs messages with 'secret', and the current time, in the same way as Stripe.
+func StripeSigner(secret string) Signer {
+
+	return func(req *http.Request, body []byte) error {
+		req.Header.Set(signature.STRIPE_SIGNATURE_HEADER, signature.SignStripe(secret, body, time.Now()))
+		return nil
+	}
+}
+
+// StandardWebhooksSigner returns a `Signer` that signs messages with 'secret', and the current time, according to the Standard
+// Webhooks specification (https://www.standardwebhooks.com/). If 'id' is empty a unique identifier is derived from the current time.
+func StandardWebhooksSigner(secret string, id string) Signer {
+
+	return func(req *http.Request, body []byte) error {
+
+		now := time.Now()
+
+		msg_id := id
+
+		if msg_id == "" {
+			msg_id = fmt.Sprintf("msg_%d", now.UnixNano())
+		}
+
+		sig, err := signature.SignStandardWebhooks(secret, msg_id, now, body)
+
+		if err != nil {
+			return err
+		}
+
+		req.Header.Set(signature.STANDARD_WEBHOOKS_ID_HEADER, msg_id)
+		req.Header.Set(signature.STANDARD_WEBHOOKS_TIMESTAMP_HEADER, strconv.FormatInt(now.Unix(), 10))
+		req.Header.Set(signature.STANDARD_WEBHOOKS_SIGNATURE_HEADER, sig)
+
+		return nil
+	}
+}
+
+// HMACSigner returns a `Signer` that assigns the hex-encoded HMAC-SHA256 digest of the message body, signed with 'secret' and
+// prefixed by 'prefix', to the header 'header'. This is the scheme used by many providers, for example Gitea ("X-Gitea-Signature")
+// and Linear ("Linear-Signature"), and by receivers created with the webhookd-new tool.
+func HMACSigner(header string, secret string, prefix string) Signer {
+
+	return func(req *http.Request, body []byte) error {
+		sum := signature.HMAC(sha256.New, []byte(secret), body)
+		req.Header.Set(header, prefix+hex.EncodeToString(sum))
+		return nil
+	}
+}
+
+// HeaderSigner returns a `Signer` that assigns 'value' to the header 'name', for example a shared token or an event type.
+func HeaderSigner(name string, value string) Signer {
+
+	return func(req *http.Request, body []byte) error {
+		req.Header.Set(name, value)
+		return nil
+	}
+}
+
+// BasicAuthSigner returns a `Signer` that authenticates messages using HTTP basic authentication with 'username' and 'password'.
+func BasicAuthSigner(username string, password string) Signer {
+
+	return func(req *http.Request, body []byte) error {
+		req.SetBasicAuth(username, password)
+		return nil
+	}
+}
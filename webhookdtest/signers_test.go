@@ -0,0 +1,96 @@
+package webhookdtest
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/whosonfirst/go-webhookd/v3/signature"
+)
+
+func newSignedRequest(t *testing.T, body []byte, sign Signer) *http.Request {
+
+	req, err := http.NewRequest(http.MethodPost, "http://localhost/test", strings.NewReader(string(body)))
+
+	if err != nil {
+		t.Fatalf("Failed to create request, %v", err)
+	}
+
+	err = sign(req, body)
+
+	if err != nil {
+		t.Fatalf("Failed to sign request, %v", err)
+	}
+
+	return req
+}
+
+func TestSigners(t *testing.T) {
+
+	body := []byte(`{"hello":"world"}`)
+	secret := "s33kret"
+
+	req := newSignedRequest(t, body, GitHubSigner(secret, "push"))
+
+	err := signature.VerifyGitHub(secret, body, req.Header, true)
+
+	if err != nil || req.Header.Get("X-GitHub-Event") != "push" {
+		t.Fatalf("Invalid GitHub signature, %v", err)
+	}
+
+	req = newSignedRequest(t, body, GitLabSigner(secret, "Push Hook"))
+
+	err = signature.VerifyGitLab(secret, req.Header.Get(signature.GITLAB_TOKEN_HEADER))
+
+	if err != nil || req.Header.Get("X-Gitlab-Event") != "Push Hook" {
+		t.Fatalf("Invalid GitLab token, %v", err)
+	}
+
+	req = newSignedRequest(t, body, SlackSigner(secret))
+
+	err = signature.VerifySlack(secret, body, req.Header.Get(signature.SLACK_TIMESTAMP_HEADER), req.Header.Get(signature.SLACK_SIGNATURE_HEADER), time.Minute)
+
+	if err != nil {
+		t.Fatalf("Invalid Slack signature, %v", err)
+	}
+
+	req = newSignedRequest(t, body, StripeSigner(secret))
+
+	err = signature.VerifyStripe(secret, body, req.Header.Get(signature.STRIPE_SIGNATURE_HEADER), time.Minute)
+
+	if err != nil {
+		t.Fatalf("Invalid Stripe signature, %v", err)
+	}
+
+	sw_secret := "whsec_MfKQ9r8GKYqrTwjUPD8ILPZIo2LaLaSw"
+
+	req = newSignedRequest(t, body, StandardWebhooksSigner(sw_secret, ""))
+
+	h := req.Header
+	err = signature.VerifyStandardWebhooks(sw_secret, h.Get(signature.STANDARD_WEBHOOKS_ID_HEADER), h.Get(signature.STANDARD_WEBHOOKS_TIMESTAMP_HEADER), h.Get(signature.STANDARD_WEBHOOKS_SIGNATURE_HEADER), body, time.Minute)
+
+	if err != nil {
+		t.Fatalf("Invalid Standard Webhooks signature, %v", err)
+	}
+
+	req = newSignedRequest(t, body, HMACSigner("X-Signature", secret, "sha256="))
+
+	if req.Header.Get("X-Signature") != signature.SignGitHubSHA256(secret, body) {
+		t.Fatalf("Unexpected HMAC signature '%s'", req.Header.Get("X-Signature"))
+	}
+
+	req = newSignedRequest(t, body, HeaderSigner("X-Token", secret))
+
+	if req.Header.Get("X-Token") != secret {
+		t.Fatalf("Unexpected token '%s'", req.Header.Get("X-Token"))
+	}
+
+	req = newSignedRequest(t, body, BasicAuthSigner("bob", secret))
+
+	username, password, ok := req.BasicAuth()
+
+	if !ok || username != "bob" || password != secret {
+		t.Fatalf("Unexpected basic auth")
+	}
+}
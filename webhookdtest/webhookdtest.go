@@ -0,0 +1,445 @@
+// Package webhookdtest provides utilities for writing end-to-end tests for webhookd configs. A `Server` runs the webhooks defined
+// by a config in-process, replacing their dispatchers with dispatchers that capture the messages relayed to them, so that tests can
+// send (signed) provider messages to the webhooks and make assertions about what would have been dispatched.
+package webhookdtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+	"github.com/whosonfirst/go-webhookd/v3/config"
+	"github.com/whosonfirst/go-webhookd/v3/daemon"
+	"github.com/whosonfirst/go-webhookd/v3/dispatcher"
+)
+
+// CAPTURE_SCHEME is the URI scheme for the dispatchers that capture the messages relayed to them by a `Server`.
+const CAPTURE_SCHEME string = "webhookdtest"
+
+// servers is a dictionary of running `Server` instances, keyed by their unique identifier, used to look up the server that a
+// capturing dispatcher belongs to.
+var servers = make(map[string]*Server)
+
+// servers_mu is a `sync.RWMutex` used to guard 'servers'.
+var servers_mu = new(sync.RWMutex)
+
+// server_count is used to assign a unique identifier to each `Server`.
+var server_count int64
+
+func init() {
+
+	ctx := context.Background()
+	err := dispatcher.RegisterDispatcher(ctx, CAPTURE_SCHEME, newCaptureDispatcher)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Message is a message captured by a `Server`.
+type Message struct {
+	// Dispatcher is the label of the dispatcher the message was relayed to. The labels of dispatchers defined by a tenant are
+	// prefixed by the name of the tenant and a "/" character.
+	Dispatcher string
+	// DeliveryID is the unique identifier of the delivery that produced the message.
+	DeliveryID string
+	// Headers are the message headers assigned to the message by the receiver and transformations for the webhook.
+	Headers http.Header
+	// Body is the (transformed) body of the message.
+	Body []byte
+	// Time is the time the message was captured.
+	Time time.Time
+}
+
+// Options defines configuration options for a `Server`.
+type Options struct {
+	// Dispatchers is an optional list of dispatcher labels whose dispatchers are not replaced by capturing dispatchers.
+	Dispatchers []string
+	// Logger is an optional `log.Logger` instance used to log requests. Default is a logger that discards everything.
+	Logger *log.Logger
+}
+
+// Server runs the webhooks defined by a `config.WebhookConfig` using an `httptest.Server`.
+type Server struct {
+	// URL is the base URL of the server, in the form of "http://{HOST}:{PORT}".
+	URL string
+	// Daemon is the `daemon.WebhookDaemon` running the webhooks.
+	Daemon *daemon.WebhookDaemon
+	// id is the unique identifier of the server.
+	id string
+	// server is the underlying `httptest.Server` instance.
+	server *httptest.Server
+	// messages is the list of captured messages, in the order they were captured.
+	messages []*Message
+	// errors is a dictionary of dispatcher labels and the errors that their dispatchers return.
+	errors map[string]*webhookd.WebhookError
+	// mu is a `sync.Mutex` used to guard 'messages' and 'errors'.
+	mu *sync.Mutex
+	// captured is used to signal that a message has been captured.
+	captured *sync.Cond
+}
+
+// NewServer returns a new `Server` running the webhooks defined in 'cfg', and the webhooks for each tenant, with their dispatchers
+// replaced by dispatchers that capture the messages relayed to them (see `Messages`). 'cfg' is not modified. The server only
+// handles webhook requests: services that run alongside the webhooks (for example the GraphQL endpoint) are not started, sources
+// are not consumed and properties that connect to, or push data to, external services (cluster, election, controller, metrics,
+// reporter, tunnel and drift) are ignored. Callers should call `Close` when they are done with the server.
+func NewServer(ctx context.Context, cfg *config.WebhookConfig, opts *Options) (*Server, error) {
+
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	id := fmt.Sprintf("%d", atomic.AddInt64(&server_count, 1))
+
+	test_cfg, err := testConfig(cfg, id, opts)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive test config, %w", err)
+	}
+
+	s := &Server{
+		id:       id,
+		messages: make([]*Message, 0),
+		errors:   make(map[string]*webhookd.WebhookError),
+		mu:       new(sync.Mutex),
+	}
+
+	s.captured = sync.NewCond(s.mu)
+
+	servers_mu.Lock()
+	servers[id] = s
+	servers_mu.Unlock()
+
+	d, err := daemon.NewWebhookDaemonFromConfig(ctx, test_cfg)
+
+	if err != nil {
+		s.unregister()
+		return nil, fmt.Errorf("Failed to create daemon, %w", err)
+	}
+
+	logger := opts.Logger
+
+	if logger == nil {
+		logger = log.New(io.Discard, "", 0)
+	}
+
+	handler, err := d.HandlerFuncWithLogger(logger)
+
+	if err != nil {
+		s.unregister()
+		return nil, fmt.Errorf("Failed to create handler, %w", err)
+	}
+
+	s.Daemon = d
+	s.server = httptest.NewServer(handler)
+	s.URL = s.server.URL
+
+	return s, nil
+}
+
+// Close shuts down 's' and blocks until all outstanding requests have completed.
+func (s *Server) Close() {
+	s.server.Close()
+	s.unregister()
+}
+
+// unregister removes 's' from the dictionary of running servers.
+func (s *Server) unregister() {
+	servers_mu.Lock()
+	delete(servers, s.id)
+	servers_mu.Unlock()
+}
+
+// Post sends 'body' to the webhook for 'endpoint' as a POST request, after calling 'signers' (for example `GitHubSigner`), in
+// order, to add the headers required by its receiver.
+func (s *Server) Post(ctx context.Context, endpoint string, body []byte, signers ...Signer) (*http.Response, error) {
+
+	req, err := s.NewRequest(ctx, http.MethodPost, endpoint, body, signers...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.server.Client().Do(req)
+}
+
+// NewRequest returns a new `http.Request` for 'endpoint', with the method 'method' and the body 'body', after calling 'signers',
+// in order, to add the headers required by the receiver for the webhook.
+func (s *Server) NewRequest(ctx context.Context, method string, endpoint string, body []byte, signers ...Signer) (*http.Request, error) {
+
+	req, err := http.NewRequestWithContext(ctx, method, s.URL+endpoint, bytes.NewReader(body))
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create request, %w", err)
+	}
+
+	if len(body) > 0 && json.Valid(body) {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	for _, sign := range signers {
+
+		err := sign(req, body)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to sign request, %w", err)
+		}
+	}
+
+	return req, nil
+}
+
+// Messages returns the list of messages captured by 's' for the dispatcher 'label', or for every dispatcher if 'label' is empty,
+// in the order they were captured.
+func (s *Server) Messages(label string) []*Message {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.filterMessages(label)
+}
+
+// WaitForMessages waits until 's' has captured at least 'count' messages for the dispatcher 'label', or for every dispatcher if
+// 'label' is empty, and returns them. It returns an error, along with the messages captured so far, if 'timeout' elapses or
+// 'ctx' is cancelled first. It is used to test webhooks that dispatch messages asynchronously.
+func (s *Server) WaitForMessages(ctx context.Context, label string, count int, timeout time.Duration) ([]*Message, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// Wake up waiters when the context is done so they can give up
+
+	stop := make(chan bool)
+	defer close(stop)
+
+	go func() {
+
+		select {
+		case <-ctx.Done():
+			s.mu.Lock()
+			s.captured.Broadcast()
+			s.mu.Unlock()
+		case <-stop:
+		}
+	}()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for {
+
+		messages := s.filterMessages(label)
+
+		if len(messages) >= count {
+			return messages, nil
+		}
+
+		if ctx.Err() != nil {
+			return messages, fmt.Errorf("Timed out waiting for %d message(s), captured %d", count, len(messages))
+		}
+
+		s.captured.Wait()
+	}
+}
+
+// Reset removes all the messages captured by 's' and any errors assigned with `SetError`.
+func (s *Server) Reset() {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.messages = make([]*Message, 0)
+	s.errors = make(map[string]*webhookd.WebhookError)
+}
+
+// SetError causes the capturing dispatcher 'label' to return 'err', without capturing messages, until `Reset` is called or 'err'
+// is nil. It is used to test how webhooks handle failed dispatches.
+func (s *Server) SetError(label string, err *webhookd.WebhookError) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err == nil {
+		delete(s.errors, label)
+		return
+	}
+
+	s.errors[label] = err
+}
+
+// filterMessages returns the list of messages for the dispatcher 'label', or every message if 'label' is empty. It assumes the
+// caller holds 's.mu'.
+func (s *Server) filterMessages(label string) []*Message {
+
+	messages := make([]*Message, 0)
+
+	for _, m := range s.messages {
+
+		if label == "" || m.Dispatcher == label {
+			messages = append(messages, m)
+		}
+	}
+
+	return messages
+}
+
+// capture records 'body', relayed to the dispatcher 'label', unless an error has been assigned to that dispatcher.
+func (s *Server) capture(ctx context.Context, label string, body []byte) *webhookd.WebhookError {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err, ok := s.errors[label]
+
+	if ok {
+		return err
+	}
+
+	m := &Message{
+		Dispatcher: label,
+		DeliveryID: webhookd.DeliveryID(ctx),
+		Headers:    webhookd.MessageHeaders(ctx, body),
+		Body:       append([]byte{}, body...),
+		Time:       time.Now(),
+	}
+
+	s.messages = append(s.messages, m)
+	s.captured.Broadcast()
+
+	return nil
+}
+
+// testConfig returns a copy of 'cfg' with its dispatchers, and the dispatchers for each tenant, replaced by capturing dispatchers
+// for the server 'id' and properties that connect to external services removed.
+func testConfig(cfg *config.WebhookConfig, id string, opts *Options) (*config.WebhookConfig, error) {
+
+	enc_cfg, err := json.Marshal(cfg)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to encode config, %w", err)
+	}
+
+	var test_cfg *config.WebhookConfig
+
+	err = json.Unmarshal(enc_cfg, &test_cfg)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decode config, %w", err)
+	}
+
+	// The daemon isn't started so its scheme and host don't matter but its query parameters (response headers, etc.) do
+
+	daemon_uri := "http://localhost:8080"
+
+	u, err := url.Parse(test_cfg.Daemon)
+
+	if err == nil && u.RawQuery != "" {
+		daemon_uri = daemon_uri + "?" + u.RawQuery
+	}
+
+	test_cfg.Daemon = daemon_uri
+
+	test_cfg.Cluster = ""
+	test_cfg.Election = ""
+	test_cfg.Controller = ""
+	test_cfg.Metrics = ""
+	test_cfg.Reporter = ""
+	test_cfg.Tunnel = ""
+	test_cfg.Drift = ""
+	test_cfg.GRPC = ""
+
+	keep := make(map[string]bool)
+
+	for _, label := range opts.Dispatchers {
+		keep[label] = true
+	}
+
+	for label := range test_cfg.Dispatchers {
+
+		if !keep[label] {
+			test_cfg.Dispatchers[label] = captureURI(id, label)
+		}
+	}
+
+	for name, t := range test_cfg.Tenants {
+
+		for label := range t.Dispatchers {
+
+			if !keep[name+"/"+label] {
+				t.Dispatchers[label] = captureURI(id, name+"/"+label)
+			}
+		}
+	}
+
+	return test_cfg, nil
+}
+
+// captureURI returns the URI for the capturing dispatcher 'label' for the server 'id'.
+func captureURI(id string, label string) string {
+
+	q := url.Values{}
+	q.Set("label", label)
+
+	return fmt.Sprintf("%s://%s?%s", CAPTURE_SCHEME, id, q.Encode())
+}
+
+// captureDispatcher implements the `webhookd.WebhookDispatcher` interface for capturing messages relayed to a dispatcher.
+type captureDispatcher struct {
+	webhookd.WebhookDispatcher
+	// server is the unique identifier of the `Server` that messages are captured by.
+	server string
+	// label is the label of the dispatcher that was replaced.
+	label string
+}
+
+// newCaptureDispatcher returns a new `captureDispatcher` instance configured by 'uri' in the form of:
+//
+//	webhookdtest://{SERVER}?label={LABEL}
+func newCaptureDispatcher(ctx context.Context, uri string) (webhookd.WebhookDispatcher, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	label := u.Query().Get("label")
+
+	if label == "" {
+		return nil, fmt.Errorf("Missing ?label= parameter")
+	}
+
+	d := &captureDispatcher{
+		server: u.Host,
+		label:  label,
+	}
+
+	return d, nil
+}
+
+// Dispatch records 'body' with the `Server` that 'd' belongs to.
+func (d *captureDispatcher) Dispatch(ctx context.Context, body []byte) *webhookd.WebhookError {
+
+	servers_mu.RLock()
+	s, ok := servers[d.server]
+	servers_mu.RUnlock()
+
+	if !ok {
+		code := http.StatusServiceUnavailable
+		message := fmt.Sprintf("Server %s is not running", d.server)
+		return &webhookd.WebhookError{Code: code, Message: message}
+	}
+
+	return s.capture(ctx, d.label, body)
+}
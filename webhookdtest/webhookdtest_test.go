@@ -0,0 +1,241 @@
+package webhookdtest
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/whosonfirst/go-webhookd/v3"
+	"github.com/whosonfirst/go-webhookd/v3/config"
+)
+
+func testWebhookConfig() *config.WebhookConfig {
+
+	return &config.WebhookConfig{
+		Daemon: "http://localhost:8080?delivery_id_header=true",
+		Receivers: map[string]string{
+			"insecure": "insecure://",
+			"gitea":    "gitea://?secret=s33kret",
+		},
+		Transformations: map[string]string{
+			"null": "null://",
+		},
+		Dispatchers: map[string]string{
+			"log":   "log://",
+			"other": "null://",
+		},
+		Webhooks: []config.WebhookWebhooksConfig{
+			{Endpoint: "/insecure", Receiver: "insecure", Dispatchers: []string{"log", "other"}},
+			{Endpoint: "/gitea", Receiver: "gitea", Transformations: []string{"null"}, Dispatchers: []string{"log"}},
+		},
+		Tenants: map[string]config.WebhookTenantConfig{
+			"acme": {
+				Receivers:   map[string]string{"insecure": "insecure://"},
+				Dispatchers: map[string]string{"log": "log://"},
+				Webhooks: []config.WebhookWebhooksConfig{
+					{Endpoint: "/test", Receiver: "insecure", Dispatchers: []string{"log"}},
+				},
+			},
+		},
+	}
+}
+
+func TestServer(t *testing.T) {
+
+	ctx := context.Background()
+
+	cfg := testWebhookConfig()
+
+	s, err := NewServer(ctx, cfg, nil)
+
+	if err != nil {
+		t.Fatalf("Failed to create server, %v", err)
+	}
+
+	defer s.Close()
+
+	if cfg.Dispatchers["log"] != "log://" {
+		t.Fatalf("Expected config to be unmodified")
+	}
+
+	body := []byte(`{"hello":"world"}`)
+
+	tests := []struct {
+		endpoint string
+		signers  []Signer
+		expected int
+	}{
+		{"/insecure", nil, http.StatusOK},
+		{"/gitea", []Signer{HMACSigner("X-Gitea-Signature", "s33kret", "")}, http.StatusOK},
+		{"/gitea", []Signer{HMACSigner("X-Gitea-Signature", "bogus", "")}, http.StatusForbidden},
+		{"/t/acme/test", nil, http.StatusOK},
+		{"/bogus", nil, http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+
+		rsp, err := s.Post(ctx, test.endpoint, body, test.signers...)
+
+		if err != nil {
+			t.Fatalf("Failed to post to %s, %v", test.endpoint, err)
+		}
+
+		rsp.Body.Close()
+
+		if rsp.StatusCode != test.expected {
+			t.Fatalf("Expected %d for %s but got %d", test.expected, test.endpoint, rsp.StatusCode)
+		}
+	}
+
+	expected := map[string]int{
+		"":         4,
+		"log":      2,
+		"other":    1,
+		"acme/log": 1,
+	}
+
+	for label, count := range expected {
+
+		messages := s.Messages(label)
+
+		if len(messages) != count {
+			t.Fatalf("Expected %d messages for '%s' but got %d", count, label, len(messages))
+		}
+
+		for _, m := range messages {
+
+			if string(m.Body) != string(body) {
+				t.Fatalf("Unexpected body '%s'", string(m.Body))
+			}
+
+			if m.DeliveryID == "" {
+				t.Fatalf("Missing delivery ID")
+			}
+		}
+	}
+
+	s.Reset()
+
+	if len(s.Messages("")) != 0 {
+		t.Fatalf("Expected no messages after reset")
+	}
+}
+
+func TestServerSetError(t *testing.T) {
+
+	ctx := context.Background()
+
+	s, err := NewServer(ctx, testWebhookConfig(), nil)
+
+	if err != nil {
+		t.Fatalf("Failed to create server, %v", err)
+	}
+
+	defer s.Close()
+
+	s.SetError("log", &webhookd.WebhookError{Code: http.StatusBadGateway, Message: "Nope"})
+
+	rsp, err := s.Post(ctx, "/insecure", []byte("hello"))
+
+	if err != nil {
+		t.Fatalf("Failed to post, %v", err)
+	}
+
+	rsp.Body.Close()
+
+	if rsp.StatusCode < 400 {
+		t.Fatalf("Expected failed dispatch to fail but got %d", rsp.StatusCode)
+	}
+
+	if len(s.Messages("log")) != 0 {
+		t.Fatalf("Expected failing dispatcher not to capture messages")
+	}
+
+	s.SetError("log", nil)
+
+	rsp, err = s.Post(ctx, "/insecure", []byte("hello"))
+
+	if err != nil {
+		t.Fatalf("Failed to post, %v", err)
+	}
+
+	rsp.Body.Close()
+
+	if rsp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected 200 after clearing error but got %d", rsp.StatusCode)
+	}
+}
+
+func TestServerKeepDispatchers(t *testing.T) {
+
+	ctx := context.Background()
+
+	opts := &Options{
+		Dispatchers: []string{"other"},
+	}
+
+	s, err := NewServer(ctx, testWebhookConfig(), opts)
+
+	if err != nil {
+		t.Fatalf("Failed to create server, %v", err)
+	}
+
+	defer s.Close()
+
+	rsp, err := s.Post(ctx, "/insecure", []byte("hello"))
+
+	if err != nil {
+		t.Fatalf("Failed to post, %v", err)
+	}
+
+	rsp.Body.Close()
+
+	if len(s.Messages("log")) != 1 || len(s.Messages("other")) != 0 {
+		t.Fatalf("Expected only the log dispatcher to be captured")
+	}
+}
+
+func TestWaitForMessages(t *testing.T) {
+
+	ctx := context.Background()
+
+	s, err := NewServer(ctx, testWebhookConfig(), nil)
+
+	if err != nil {
+		t.Fatalf("Failed to create server, %v", err)
+	}
+
+	defer s.Close()
+
+	go func() {
+
+		time.Sleep(50 * time.Millisecond)
+
+		rsp, err := s.Post(ctx, "/insecure", []byte("hello"))
+
+		if err == nil {
+			rsp.Body.Close()
+		}
+	}()
+
+	messages, err := s.WaitForMessages(ctx, "log", 1, 5*time.Second)
+
+	if err != nil {
+		t.Fatalf("Failed to wait for messages, %v", err)
+	}
+
+	if len(messages) != 1 {
+		t.Fatalf("Expected 1 message but got %d", len(messages))
+	}
+
+	messages, err = s.WaitForMessages(ctx, "log", 2, 50*time.Millisecond)
+
+	if err == nil {
+		t.Fatalf("Expected waiting for more messages to time out")
+	}
+
+	if len(messages) != 1 {
+		t.Fatalf("Expected 1 message after timing out but got %d", len(messages))
+	}
+}